@@ -17,11 +17,13 @@ limitations under the License.
 package options
 
 import (
+	"context"
 	"crypto/tls"
 	"flag"
 	"fmt"
 	v1 "k8s.io/api/core/v1"
 	"kubesphere.io/devops/pkg/client/cache"
+	"kubesphere.io/devops/pkg/client/devops/cached"
 	"kubesphere.io/devops/pkg/client/devops/jclient"
 	"kubesphere.io/devops/pkg/client/sonarqube"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -33,6 +35,8 @@ import (
 	"kubesphere.io/devops/pkg/client/clientset/versioned/scheme"
 	apiserverconfig "kubesphere.io/devops/pkg/config"
 	"kubesphere.io/devops/pkg/informers"
+	"kubesphere.io/devops/pkg/models/audit"
+	"kubesphere.io/devops/pkg/models/webhook"
 	genericoptions "kubesphere.io/devops/pkg/server/options"
 
 	"net/http"
@@ -70,6 +74,9 @@ func (s *ServerRunOptions) Flags() (fss cliflag.NamedFlagSets) {
 	s.S3Options.AddFlags(fss.FlagSet("s3"), s.S3Options)
 	s.ArgoCDOption.AddFlags(fss.FlagSet("argocd"), s.ArgoCDOption)
 	s.FluxCDOption.AddFlags(fss.FlagSet("fluxcd"), s.FluxCDOption)
+	s.AuditOptions.AddFlags(fss.FlagSet("audit"), s.AuditOptions)
+	s.HistoryOptions.AddFlags(fss.FlagSet("history"), s.HistoryOptions)
+	s.WebhookDeliveryOptions.AddFlags(fss.FlagSet("webhook"), s.WebhookDeliveryOptions)
 
 	fs = fss.FlagSet("klog")
 	local := flag.NewFlagSet("klog", flag.ExitOnError)
@@ -112,6 +119,9 @@ func (s *ServerRunOptions) NewAPIServer(stopCh <-chan struct{}) (*apiserver.APIS
 		}
 	}
 
+	// use the active Jenkins instance, it's the standby one after switching over to it
+	s.JenkinsOptions = s.JenkinsOptions.ResolveActive(context.TODO(), kubernetesClient.Kubernetes().CoreV1())
+
 	if !s.JenkinsOptions.SkipVerify && s.JenkinsOptions.Host != "" {
 		devopsClient, err := jclient.NewJenkinsClient(s.JenkinsOptions)
 		if err != nil {
@@ -145,6 +155,25 @@ func (s *ServerRunOptions) NewAPIServer(stopCh <-chan struct{}) (*apiserver.APIS
 		apiServer.CacheClient = cache.NewSimpleCache()
 	}
 
+	if apiServer.DevopsClient != nil && s.JenkinsOptions.CacheTTL > 0 {
+		// cache the queries of the pipelines and the runs which are polled by the console
+		apiServer.DevopsClient = cached.NewClient(apiServer.DevopsClient, apiServer.CacheClient, s.JenkinsOptions.CacheTTL)
+	}
+
+	if s.AuditOptions != nil && s.AuditOptions.Enabled {
+		apiServer.AuditStore = audit.NewCacheStore(apiServer.CacheClient, s.AuditOptions.Retention)
+	}
+
+	if s.WebhookDeliveryOptions != nil && s.WebhookDeliveryOptions.Enabled {
+		apiServer.WebhookDeliveryStore = webhook.NewCacheStore(apiServer.CacheClient, s.WebhookDeliveryOptions.Retention)
+	}
+
+	if s.HistoryOptions != nil {
+		if apiServer.HistoryStore, err = s.HistoryOptions.NewStore(); err != nil {
+			return nil, fmt.Errorf("failed to connect to the history database, error: %v", err)
+		}
+	}
+
 	server := &http.Server{
 		Addr: fmt.Sprintf(":%d", s.GenericServerRunOptions.InsecurePort),
 	}
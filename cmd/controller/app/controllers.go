@@ -17,12 +17,42 @@ limitations under the License.
 package app
 
 import (
+	"net"
+	"net/http"
+	"time"
+
 	"kubesphere.io/devops/controllers/addon"
 	"kubesphere.io/devops/controllers/argocd"
+	"kubesphere.io/devops/controllers/artifact"
+	artifactrepositorycontroller "kubesphere.io/devops/controllers/artifactrepository"
+	callbackcontroller "kubesphere.io/devops/controllers/callback"
+	cloudeventscontroller "kubesphere.io/devops/controllers/cloudevents"
+	"kubesphere.io/devops/controllers/credential"
+	projectcontroller "kubesphere.io/devops/controllers/devopsproject"
+	doracontroller "kubesphere.io/devops/controllers/dora"
 	"kubesphere.io/devops/controllers/fluxcd"
 	"kubesphere.io/devops/controllers/gitrepository"
+	historycontroller "kubesphere.io/devops/controllers/history"
+	imagecontroller "kubesphere.io/devops/controllers/image"
 	"kubesphere.io/devops/controllers/jenkins/devopscredential"
 	"kubesphere.io/devops/controllers/jenkins/devopsproject"
+	"kubesphere.io/devops/controllers/jenkins/switchover"
+	"kubesphere.io/devops/controllers/logarchive"
+	multiclustercontroller "kubesphere.io/devops/controllers/multicluster"
+	notificationcontroller "kubesphere.io/devops/controllers/notification"
+	pathfiltercontroller "kubesphere.io/devops/controllers/pathfilter"
+	"kubesphere.io/devops/controllers/pipelinegroup"
+	"kubesphere.io/devops/controllers/pipelinerevision"
+	previewcontroller "kubesphere.io/devops/controllers/preview"
+	"kubesphere.io/devops/controllers/promotion"
+	qualitygatecontroller "kubesphere.io/devops/controllers/qualitygate"
+	reapercontroller "kubesphere.io/devops/controllers/reaper"
+	releasecontroller "kubesphere.io/devops/controllers/release"
+	"kubesphere.io/devops/controllers/s2ibinary"
+	"kubesphere.io/devops/controllers/trash"
+	triggercontroller "kubesphere.io/devops/controllers/trigger"
+	usagecontroller "kubesphere.io/devops/controllers/usage"
+	versioningcontroller "kubesphere.io/devops/controllers/versioning"
 	"kubesphere.io/devops/pkg/jwt/token"
 	"kubesphere.io/devops/pkg/server/errors"
 
@@ -32,20 +62,29 @@ import (
 	"kubesphere.io/devops/controllers/jenkins/config"
 	jenkinspipeline "kubesphere.io/devops/controllers/jenkins/pipeline"
 	"kubesphere.io/devops/controllers/jenkins/pipelinerun"
+	"kubesphere.io/devops/pkg/backend"
 	"kubesphere.io/devops/pkg/client/devops"
+	"kubesphere.io/devops/pkg/client/devops/breaker"
+	"kubesphere.io/devops/pkg/client/devops/jclient"
+	"kubesphere.io/devops/pkg/client/devops/jenkins"
+	"kubesphere.io/devops/pkg/client/history"
 	"kubesphere.io/devops/pkg/client/k8s"
+	"kubesphere.io/devops/pkg/client/multicluster"
+	"kubesphere.io/devops/pkg/client/notification"
+	"kubesphere.io/devops/pkg/client/s3"
+	"kubesphere.io/devops/pkg/client/sonarqube"
 	"kubesphere.io/devops/pkg/informers"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
 func addControllers(mgr manager.Manager, client k8s.Client, informerFactory informers.InformerFactory,
-	devopsClient devops.Interface, jenkinsCore core.JenkinsCore,
+	devopsClient devops.Interface, jenkinsCore core.JenkinsCore, jenkinsBreaker *breaker.Breaker,
 	s *options.DevOpsControllerManagerOptions) error {
 	if devopsClient == nil {
 		return errors.New("devopsClient should not be nil")
 	}
 
-	reconcilers := getAllControllers(mgr, client, informerFactory, devopsClient, s, jenkinsCore)
+	reconcilers := getAllControllers(mgr, client, informerFactory, devopsClient, s, jenkinsCore, jenkinsBreaker)
 	reconcilers["pipeline"] = func(mgr manager.Manager) (err error) {
 		tokenIssuer := token.NewTokenIssuer(s.JWTOptions.Secret, s.JWTOptions.MaximumClockSkew)
 		// add PipelineRun controller
@@ -55,7 +94,10 @@ func addControllers(mgr manager.Manager, client k8s.Client, informerFactory info
 			DevOpsClient:         devopsClient,
 			JenkinsCore:          jenkinsCore,
 			TokenIssuer:          tokenIssuer,
+			Breaker:              jenkinsBreaker,
 			PipelineRunDataStore: s.FeatureOptions.PipelineRunDataStore,
+			BackendRouter:        backend.NewRouter(mgr.GetClient(), s.FeatureOptions.GetPipelineBackend()),
+			ControllerOptions:    s.ReconcilerOptions.GetControllerOptions("pipelinerun-controller"),
 		}).SetupWithManager(mgr); err != nil {
 			klog.Errorf("unable to create pipelinerun-controller, err: %v", err)
 			return
@@ -63,21 +105,155 @@ func addControllers(mgr manager.Manager, client k8s.Client, informerFactory info
 
 		// add PipelineRun Synchronizer
 		if err = (&pipelinerun.SyncReconciler{
-			Client:      mgr.GetClient(),
-			JenkinsCore: jenkinsCore,
+			Client:            mgr.GetClient(),
+			JenkinsCore:       jenkinsCore,
+			ControllerOptions: s.ReconcilerOptions.GetControllerOptions("pipelinerun-synchronizer"),
 		}).SetupWithManager(mgr); err != nil {
 			klog.Errorf("unable to create pipelinerun-synchronizer, err: %v", err)
 			return
 		}
 
 		// add Pipeline metadata controller
-		err = (&jenkinspipeline.Reconciler{
-			Client:      mgr.GetClient(),
-			JenkinsCore: jenkinsCore,
-		}).SetupWithManager(mgr)
+		if err = (&jenkinspipeline.Reconciler{
+			Client:            mgr.GetClient(),
+			JenkinsCore:       jenkinsCore,
+			ControllerOptions: s.ReconcilerOptions.GetControllerOptions("pipeline-metadata-controller"),
+		}).SetupWithManager(mgr); err != nil {
+			return
+		}
+
+		// add DevOpsProject quota controller
+		if err = (&projectcontroller.QuotaReconciler{
+			Client:            mgr.GetClient(),
+			ControllerOptions: s.ReconcilerOptions.GetControllerOptions("devopsproject-quota-controller"),
+		}).SetupWithManager(mgr); err != nil {
+			klog.Errorf("unable to create devopsproject-quota-controller, err: %v", err)
+			return
+		}
+
+		// add Pipeline template controller
+		if err = (&jenkinspipeline.TemplateReconciler{
+			Client:            mgr.GetClient(),
+			ControllerOptions: s.ReconcilerOptions.GetControllerOptions("pipeline-template-controller"),
+		}).SetupWithManager(mgr); err != nil {
+			klog.Errorf("unable to create pipeline-template-controller, err: %v", err)
+			return
+		}
+
+		// add PipelineRun notifier
+		if err = (&notificationcontroller.Reconciler{
+			Client: mgr.GetClient(),
+			Sender: notification.NewSender(&http.Client{Timeout: 30 * time.Second}, s.NotificationOptions),
+		}).SetupWithManager(mgr); err != nil {
+			klog.Errorf("unable to create pipelinerun-notifier, err: %v", err)
+			return
+		}
+
+		// add Pipeline callbacks
+		var callbackNetworks []*net.IPNet
+		if callbackNetworks, err = callbackcontroller.ParseNetworks(s.CallbackAllowedNetworks); err != nil {
+			return
+		}
+		if err = (&callbackcontroller.Reconciler{
+			Client:     mgr.GetClient(),
+			HTTPClient: callbackcontroller.NewHTTPClient(callbackNetworks),
+		}).SetupWithManager(mgr); err != nil {
+			klog.Errorf("unable to create pipelinerun-callback-controller, err: %v", err)
+			return
+		}
+
+		// add CloudEvents emitters when a sink is configured
+		if emitter := s.CloudEventsOptions.NewEmitter(); emitter != nil {
+			if err = (&cloudeventscontroller.PipelineReconciler{
+				Client:  mgr.GetClient(),
+				Emitter: emitter,
+			}).SetupWithManager(mgr); err != nil {
+				klog.Errorf("unable to create pipeline-cloudevents-emitter, err: %v", err)
+				return
+			}
+			if err = (&cloudeventscontroller.PipelineRunReconciler{
+				Client:  mgr.GetClient(),
+				Emitter: emitter,
+			}).SetupWithManager(mgr); err != nil {
+				klog.Errorf("unable to create pipelinerun-cloudevents-emitter, err: %v", err)
+				return
+			}
+		}
+
+		// add PipelineRun history controller when a database is configured
+		var historyStore history.Interface
+		if historyStore, err = s.HistoryOptions.NewStore(); err != nil {
+			klog.Errorf("unable to connect to the history database, err: %v", err)
+			return
+		} else if historyStore != nil {
+			if err = (&historycontroller.Reconciler{
+				Client: mgr.GetClient(),
+				Store:  historyStore,
+			}).SetupWithManager(mgr); err != nil {
+				klog.Errorf("unable to create pipelinerun-history-controller, err: %v", err)
+				return
+			}
+		}
+
+		// add DORA metrics controller, it reads the PipelineRuns from the history database if it's available
+		if err = (&doracontroller.Reconciler{
+			Client: mgr.GetClient(),
+			Store:  historyStore,
+		}).SetupWithManager(mgr); err != nil {
+			klog.Errorf("unable to create dora-metrics-controller, err: %v", err)
+			return
+		}
+
+		// add PipelineRun log and artifact archive controllers when S3 is available
+		var s3Client s3.Interface
+		if s.S3Options != nil && s.S3Options.Endpoint != "" {
+			if s3Client, err = s3.NewS3Client(s.S3Options); err != nil {
+				klog.Errorf("unable to create S3 client, err: %v", err)
+				return
+			}
+			backends := map[backend.Type]backend.Interface{
+				backend.Jenkins: pipelinerun.NewBackend(devopsClient, jenkinsCore),
+			}
+			router := backend.NewRouter(mgr.GetClient(), s.FeatureOptions.GetPipelineBackend())
+			if err = (&logarchive.Reconciler{
+				Client:   mgr.GetClient(),
+				S3Client: s3Client,
+				Backends: backends,
+				Router:   router,
+			}).SetupWithManager(mgr); err != nil {
+				return
+			}
+			if err = (&artifact.Reconciler{
+				Client:   mgr.GetClient(),
+				S3Client: s3Client,
+				Backends: backends,
+				Router:   router,
+			}).SetupWithManager(mgr); err != nil {
+				return
+			}
+		}
+
+		// the uploaded S2iBinaries are ready once they pass the scan, or at once if no scanner is configured
+		scanReconciler := &s2ibinary.ScanReconciler{Client: mgr.GetClient()}
+		if s3Client != nil {
+			scanReconciler.S3Client = s3Client
+			scanReconciler.Scanner = s.ScannerOptions.NewScanner()
+		}
+		if err = scanReconciler.SetupWithManager(mgr); err != nil {
+			klog.Errorf("unable to create s2ibinary-scan-controller, err: %v", err)
+		}
 		return
 	}
 
+	// the Jenkins and Tekton backends can be enabled at the same time, the predicates
+	// and the backend router make sure that each controller ignores the objects of others
+	if !s.FeatureOptions.IsPipelineBackendEnabled(backend.Jenkins) {
+		delete(reconcilers, "pipeline")
+	}
+	if s.FeatureOptions.IsPipelineBackendEnabled(backend.Tekton) {
+		klog.Warning("the Tekton Pipeline controllers are not included in this controller manager")
+	}
+
 	// Add all controllers into manager.
 	for name, ok := range s.FeatureOptions.GetControllers() {
 		ctrl := reconcilers[name]
@@ -95,7 +271,8 @@ func addControllers(mgr manager.Manager, client k8s.Client, informerFactory info
 }
 
 func getAllControllers(mgr manager.Manager, client k8s.Client, informerFactory informers.InformerFactory,
-	devopsClient devops.Interface, s *options.DevOpsControllerManagerOptions, jenkinsCore core.JenkinsCore) map[string]func(mgr manager.Manager) error {
+	devopsClient devops.Interface, s *options.DevOpsControllerManagerOptions, jenkinsCore core.JenkinsCore,
+	jenkinsBreaker *breaker.Breaker) map[string]func(mgr manager.Manager) error {
 
 	argocdReconciler := &argocd.Reconciler{
 		Client:        mgr.GetClient(),
@@ -142,6 +319,62 @@ func getAllControllers(mgr manager.Manager, client k8s.Client, informerFactory i
 	fluxcdAppStatusReconciler := &fluxcd.ApplicationStatusReconciler{
 		Client: mgr.GetClient(),
 	}
+	memberClusters := multicluster.NewClients(mgr.GetClient(), mgr.GetScheme())
+	pipelineRunDispatcher := &multiclustercontroller.PipelineRunDispatcher{
+		Client:      mgr.GetClient(),
+		Clusters:    memberClusters,
+		HostCluster: s.FeatureOptions.ClusterName,
+	}
+	projectPropagator := &multiclustercontroller.ProjectPropagator{
+		Client:      mgr.GetClient(),
+		Clusters:    memberClusters,
+		HostCluster: s.FeatureOptions.ClusterName,
+	}
+	pipelineGroupReconciler := &pipelinegroup.Reconciler{
+		Client: mgr.GetClient(),
+	}
+	promotionReconciler := &promotion.Reconciler{
+		Client: mgr.GetClient(),
+	}
+	releaseReconciler := &releasecontroller.Reconciler{
+		Client: mgr.GetClient(),
+	}
+	versioningReconciler := &versioningcontroller.Reconciler{
+		Client: mgr.GetClient(),
+	}
+	pathFilterReconciler := &pathfiltercontroller.Reconciler{
+		Client: mgr.GetClient(),
+	}
+	imageReconciler := &imagecontroller.Reconciler{
+		Client: mgr.GetClient(),
+	}
+	previewReconciler := &previewcontroller.Reconciler{
+		Client: mgr.GetClient(),
+	}
+	qualityGateReconciler := &qualitygatecontroller.Reconciler{
+		Client: mgr.GetClient(),
+	}
+	artifactRepositoryReconciler := &artifactrepositorycontroller.Reconciler{
+		Client:                   mgr.GetClient(),
+		TargetConfigMapNamespace: s.FeatureOptions.SystemNamespace,
+	}
+	triggerReconciler := &triggercontroller.Reconciler{
+		Client: mgr.GetClient(),
+	}
+	usageReconciler := &usagecontroller.Reconciler{
+		Client: mgr.GetClient(),
+	}
+	reaperReconciler := &reapercontroller.Reconciler{
+		Client:        mgr.GetClient(),
+		JenkinsClient: devopsClient,
+	}
+	pipelineRevisionReconciler := &pipelinerevision.Reconciler{
+		Client: mgr.GetClient(),
+	}
+	trashReconciler := &trash.Reconciler{
+		Client:    mgr.GetClient(),
+		Retention: s.FeatureOptions.PipelineTrashRetention,
+	}
 
 	return map[string]func(mgr manager.Manager) error{
 		gitRepoReconcilers.GetName(): func(mgr manager.Manager) error {
@@ -150,6 +383,18 @@ func getAllControllers(mgr manager.Manager, client k8s.Client, informerFactory i
 				ExternalAddress: s.FeatureOptions.ExternalAddress,
 				ClusterName:     s.FeatureOptions.ClusterName,
 			}).SetupWithManager(mgr)
+			if err == nil {
+				err = (&gitrepository.CommitStatusReconciler{
+					Client:          mgr.GetClient(),
+					ExternalAddress: s.FeatureOptions.ExternalAddress,
+					ClusterName:     s.FeatureOptions.ClusterName,
+				}).SetupWithManager(mgr)
+			}
+			if err == nil {
+				err = (&gitrepository.PipelineSourceReconciler{
+					Client: mgr.GetClient(),
+				}).SetupWithManager(mgr)
+			}
 			if err != nil {
 				return err
 			}
@@ -170,6 +415,12 @@ func getAllControllers(mgr manager.Manager, client k8s.Client, informerFactory i
 			return jenkinsPodTemplate.SetupWithManager(mgr)
 		},
 		"jenkinsconfig": func(mgr manager.Manager) error {
+			if err := (&config.AgentTemplateReconciler{
+				Client:                   mgr.GetClient(),
+				TargetConfigMapNamespace: s.FeatureOptions.SystemNamespace,
+			}).SetupWithManager(mgr); err != nil {
+				return err
+			}
 			return mgr.Add(config.NewController(&config.ControllerOptions{
 				LimitRangeClient:    client.Kubernetes().CoreV1(),
 				ResourceQuotaClient: client.Kubernetes().CoreV1(),
@@ -184,10 +435,15 @@ func getAllControllers(mgr manager.Manager, client k8s.Client, informerFactory i
 			}, s.JenkinsOptions))
 		},
 		"jenkins": func(mgr manager.Manager) error {
-			err := mgr.Add(devopscredential.NewController(client.Kubernetes(),
+			credentialController := devopscredential.NewController(client.Kubernetes(),
 				devopsClient,
 				informerFactory.KubernetesSharedInformerFactory().Core().V1().Namespaces(),
-				informerFactory.KubernetesSharedInformerFactory().Core().V1().Secrets()))
+				informerFactory.KubernetesSharedInformerFactory().Core().V1().Secrets())
+			credentialController.SecretResolver = s.SecretStoreOptions.NewResolver(client.Kubernetes().CoreV1())
+			credentialController.AuditInterval = s.FeatureOptions.CredentialAuditInterval
+			credentialController.GCMode, _ = devopscredential.ParseGCMode(s.FeatureOptions.CredentialGCMode)
+			credentialController.Breaker = jenkinsBreaker
+			err := mgr.Add(credentialController)
 			if err == nil {
 				err = mgr.Add(devopsproject.NewController(client.Kubernetes(),
 					client.KubeSphere(), devopsClient,
@@ -195,10 +451,13 @@ func getAllControllers(mgr manager.Manager, client k8s.Client, informerFactory i
 					informerFactory.KubeSphereSharedInformerFactory().Devops().V1alpha3().DevOpsProjects()))
 			}
 			if err == nil {
-				err = mgr.Add(jenkinspipeline.NewController(client.Kubernetes(),
+				pipelineController := jenkinspipeline.NewController(client.Kubernetes(),
 					client.KubeSphere(), devopsClient,
 					informerFactory.KubernetesSharedInformerFactory().Core().V1().Namespaces(),
-					informerFactory.KubeSphereSharedInformerFactory().Devops().V1alpha3().Pipelines()))
+					informerFactory.KubeSphereSharedInformerFactory().Devops().V1alpha3().Pipelines())
+				pipelineController.BackendRouter = backend.NewRouter(mgr.GetClient(), s.FeatureOptions.GetPipelineBackend())
+				pipelineController.Breaker = jenkinsBreaker
+				err = mgr.Add(pipelineController)
 			}
 
 			if err == nil {
@@ -212,6 +471,24 @@ func getAllControllers(mgr manager.Manager, client k8s.Client, informerFactory i
 			if err == nil {
 				err = jenkinsAgentLabelsReconciler.SetupWithManager(mgr)
 			}
+			if err == nil {
+				err = (&config.PluginSetReconciler{
+					Client:      mgr.GetClient(),
+					JenkinsCore: jenkinsCore,
+					TokenIssuer: tokenIssuer,
+				}).SetupWithManager(mgr)
+			}
+			if err == nil {
+				err = (&credential.ExpiryReconciler{
+					Client: mgr.GetClient(),
+					Rotators: map[string]credential.Rotator{
+						credential.GitLabRotatorName: credential.NewGitLabRotator(&http.Client{Timeout: 30 * time.Second}),
+					},
+				}).SetupWithManager(mgr)
+			}
+			if err == nil && s.JenkinsOptions.StandbyHost != "" {
+				err = setupSwitchoverReconciler(mgr, client, s)
+			}
 			return err
 		},
 		argocdReconciler.GetGroupName(): func(mgr manager.Manager) (err error) {
@@ -242,7 +519,94 @@ func getAllControllers(mgr manager.Manager, client k8s.Client, informerFactory i
 			if err = fluxcdAppStatusReconciler.SetupWithManager(mgr); err != nil {
 				return
 			}
+			if err = (&fluxcd.ImageUpdaterReconciler{
+				Client: mgr.GetClient(),
+			}).SetupWithManager(mgr); err != nil {
+				return
+			}
 			return fluxcdApplicationReconciler.SetupWithManager(mgr)
 		},
+		pipelineRunDispatcher.GetGroupName(): func(mgr manager.Manager) error {
+			if err := projectPropagator.SetupWithManager(mgr); err != nil {
+				return err
+			}
+			return pipelineRunDispatcher.SetupWithManager(mgr)
+		},
+		pipelineGroupReconciler.GetGroupName(): func(mgr manager.Manager) error {
+			return pipelineGroupReconciler.SetupWithManager(mgr)
+		},
+		promotionReconciler.GetGroupName(): func(mgr manager.Manager) error {
+			return promotionReconciler.SetupWithManager(mgr)
+		},
+		releaseReconciler.GetGroupName(): func(mgr manager.Manager) error {
+			return releaseReconciler.SetupWithManager(mgr)
+		},
+		versioningReconciler.GetGroupName(): func(mgr manager.Manager) error {
+			return versioningReconciler.SetupWithManager(mgr)
+		},
+		pathFilterReconciler.GetGroupName(): func(mgr manager.Manager) error {
+			return pathFilterReconciler.SetupWithManager(mgr)
+		},
+		imageReconciler.GetGroupName(): func(mgr manager.Manager) error {
+			return imageReconciler.SetupWithManager(mgr)
+		},
+		previewReconciler.GetGroupName(): func(mgr manager.Manager) error {
+			return previewReconciler.SetupWithManager(mgr)
+		},
+		qualityGateReconciler.GetGroupName(): func(mgr manager.Manager) error {
+			// the quality gates are only received from the SonarQube webhook if SonarQube is not configured
+			if s.SonarQubeOptions != nil && s.SonarQubeOptions.Host != "" {
+				sonarClient, err := sonarqube.NewSonarQubeClient(s.SonarQubeOptions)
+				if err != nil {
+					return err
+				}
+				qualityGateReconciler.Host = s.SonarQubeOptions.Host
+				qualityGateReconciler.SonarQube = sonarqube.NewSonar(sonarClient.SonarQube())
+			}
+			return qualityGateReconciler.SetupWithManager(mgr)
+		},
+		artifactRepositoryReconciler.GetGroupName(): func(mgr manager.Manager) error {
+			return artifactRepositoryReconciler.SetupWithManager(mgr)
+		},
+		triggerReconciler.GetGroupName(): func(mgr manager.Manager) error {
+			return triggerReconciler.SetupWithManager(mgr)
+		},
+		usageReconciler.GetGroupName(): func(mgr manager.Manager) error {
+			return usageReconciler.SetupWithManager(mgr)
+		},
+		reaperReconciler.GetGroupName(): func(mgr manager.Manager) error {
+			return reaperReconciler.SetupWithManager(mgr)
+		},
+		pipelineRevisionReconciler.GetGroupName(): func(mgr manager.Manager) error {
+			return pipelineRevisionReconciler.SetupWithManager(mgr)
+		},
+		trashReconciler.GetGroupName(): func(mgr manager.Manager) error {
+			err := trashReconciler.SetupWithManager(mgr)
+			if err == nil {
+				err = (&trash.ExpirationReconciler{
+					Client:        mgr.GetClient(),
+					JenkinsClient: devopsClient,
+				}).SetupWithManager(mgr)
+			}
+			return err
+		},
+	}
+}
+
+// setupSwitchoverReconciler setups the reconciler which switches between the primary and the standby Jenkins
+func setupSwitchoverReconciler(mgr manager.Manager, client k8s.Client, s *options.DevOpsControllerManagerOptions) error {
+	clients := map[jenkins.Instance]devops.Interface{}
+	for _, instance := range []jenkins.Instance{jenkins.Primary, jenkins.Standby} {
+		instanceClient, err := jclient.NewJenkinsClient(s.JenkinsOptions.ForInstance(instance))
+		if err != nil {
+			return err
+		}
+		clients[instance] = instanceClient
 	}
+	return (&switchover.Reconciler{
+		Client:         mgr.GetClient(),
+		Namespace:      s.JenkinsOptions.Namespace,
+		Clients:        clients,
+		SecretResolver: s.SecretStoreOptions.NewResolver(client.Kubernetes().CoreV1()),
+	}).SetupWithManager(mgr)
 }
@@ -0,0 +1,54 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"net/http"
+
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"kubesphere.io/devops/cmd/controller/app/options"
+	"kubesphere.io/devops/pkg/backend"
+	"kubesphere.io/devops/pkg/client/devops/jenkins"
+	"kubesphere.io/devops/pkg/client/k8s"
+	"kubesphere.io/devops/pkg/health"
+)
+
+// addHealthChecks adds the checks of the enabled Pipeline backends into /readyz, every check is available
+// at /readyz/<name> as well. The backends are not checked by /healthz, restarting the controller manager
+// does not help when Jenkins is down.
+func addHealthChecks(mgr manager.Manager, kubernetesClient k8s.Client, jenkinsOptions *jenkins.Options,
+	jenkinsRoundTripper http.RoundTripper, s *options.DevOpsControllerManagerOptions) (err error) {
+	if err = mgr.AddHealthzCheck("ping", healthz.Ping); err != nil {
+		return
+	}
+	if err = mgr.AddReadyzCheck("ping", healthz.Ping); err != nil {
+		return
+	}
+
+	if s.FeatureOptions.IsPipelineBackendEnabled(backend.Jenkins) && jenkinsOptions != nil && jenkinsOptions.Host != "" {
+		if err = mgr.AddReadyzCheck("jenkins", health.NewJenkinsChecker(jenkinsOptions.Host,
+			jenkinsOptions.Username, jenkinsOptions.Password, jenkinsRoundTripper)); err != nil {
+			return
+		}
+	}
+	if s.FeatureOptions.IsPipelineBackendEnabled(backend.Tekton) {
+		err = mgr.AddReadyzCheck("tekton", health.NewTektonChecker(kubernetesClient.Kubernetes().Discovery()))
+	}
+	return
+}
@@ -18,9 +18,13 @@ package options
 
 import (
 	"strings"
+	"time"
 
 	"github.com/spf13/pflag"
+	"k8s.io/apiserver/pkg/authentication/serviceaccount"
 	cliflag "k8s.io/component-base/cli/flag"
+	"kubesphere.io/devops/controllers/jenkins/devopscredential"
+	"kubesphere.io/devops/pkg/backend"
 	"kubesphere.io/devops/pkg/utils/reflectutils"
 )
 
@@ -31,6 +35,17 @@ type FeatureOptions struct {
 	ExternalAddress      string
 	ClusterName          string
 	PipelineRunDataStore string
+	// PipelineBackend is a comma-separated list of the enabled Pipeline backends,
+	// the first one is the default backend of DevOpsProjects which do not declare one
+	PipelineBackend string
+	// CredentialAuditInterval is the period of comparing the Jenkins credentials with the secrets
+	CredentialAuditInterval time.Duration
+	// CredentialGCMode decides how to handle the orphaned credentials, could be disabled, dry-run or enabled
+	CredentialGCMode string
+	// PipelineTrashRetention is the period of keeping the deleted Pipelines in the trash bin, zero disables the trash bin
+	PipelineTrashRetention time.Duration
+	// APIServerServiceAccount is the name of the service account of the DevOps apiserver in the system namespace
+	APIServerServiceAccount string
 }
 
 // GetControllers returns the controllers map
@@ -66,7 +81,47 @@ func NewFeatureOptions() *FeatureOptions {
 
 // Validate checks validation of FeatureOptions.
 func (o *FeatureOptions) Validate() []error {
-	return []error{}
+	errs := []error{}
+	if o.PipelineBackend != "" {
+		if _, err := backend.ParseTypes(o.PipelineBackend); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if _, err := devopscredential.ParseGCMode(o.CredentialGCMode); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// GetPipelineBackends returns the enabled Pipeline backends, Jenkins is the only one if it's not specified.
+func (o *FeatureOptions) GetPipelineBackends() []backend.Type {
+	if backendTypes, err := backend.ParseTypes(o.PipelineBackend); err == nil {
+		return backendTypes
+	}
+	return []backend.Type{backend.Jenkins}
+}
+
+// GetPipelineBackend returns the default Pipeline backend which is the first enabled one.
+func (o *FeatureOptions) GetPipelineBackend() backend.Type {
+	return o.GetPipelineBackends()[0]
+}
+
+// IsPipelineBackendEnabled returns true if the given Pipeline backend is enabled.
+func (o *FeatureOptions) IsPipelineBackendEnabled(backendType backend.Type) bool {
+	for _, item := range o.GetPipelineBackends() {
+		if item == backendType {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAPIServerUsername returns the username of the service account of the DevOps apiserver
+func (o *FeatureOptions) GetAPIServerUsername() string {
+	if o.APIServerServiceAccount == "" {
+		return ""
+	}
+	return serviceaccount.MakeUsername(o.SystemNamespace, o.APIServerServiceAccount)
 }
 
 // ApplyTo fills up FeatureOptions config with options
@@ -84,6 +139,19 @@ func (o *FeatureOptions) AddFlags(fs *pflag.FlagSet, c *FeatureOptions) {
 	fs.StringVarP(&o.ClusterName, "cluster-name", "", "default", "Current cluster name")
 	fs.StringVarP(&o.PipelineRunDataStore, "pipelinerun-data-store", "", "configmap",
 		"The data store type of the PipelineRun data, could be empty or configmap")
+	fs.StringVarP(&o.PipelineBackend, "pipeline-backend", "", string(backend.Jenkins),
+		"A comma-separated list of the enabled Pipeline backends, could be Jenkins or Tekton. "+
+			"The first one is the default backend of DevOpsProjects which do not declare one in spec.pipelineBackend")
+	fs.DurationVarP(&o.CredentialAuditInterval, "credential-audit-interval", "", 0,
+		"The period of comparing the Jenkins credentials with the credential secrets, zero disables the audit")
+	fs.StringVarP(&o.CredentialGCMode, "credential-gc-mode", "", string(devopscredential.GCDisabled),
+		"How to handle the orphaned credentials found by the audit, could be disabled, dry-run or enabled")
+	fs.DurationVarP(&o.PipelineTrashRetention, "pipeline-trash-retention", "", 0,
+		"The period of keeping the deleted Pipelines and their Jenkins jobs in the trash bin before removing them "+
+			"permanently, zero disables the trash bin")
+	fs.StringVarP(&o.APIServerServiceAccount, "apiserver-service-account", "", "devops-apiserver",
+		"The name of the service account of the DevOps apiserver in the system namespace, only it is trusted to "+
+			"record the identities which trigger the PipelineRuns")
 }
 
 func (o *FeatureOptions) knownControllers() []string {
@@ -22,6 +22,7 @@ import (
 
 	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
+	"kubesphere.io/devops/pkg/backend"
 )
 
 func TestFeatureOptions_GetControllers(t *testing.T) {
@@ -121,4 +122,43 @@ func TestFeatureOptions(t *testing.T) {
 	assert.NotNil(t, flagSet.Lookup("external-address"))
 	assert.NotNil(t, flagSet.Lookup("cluster-name"))
 	assert.NotNil(t, flagSet.Lookup("pipelinerun-data-store"))
+	assert.NotNil(t, flagSet.Lookup("pipeline-backend"))
+	assert.NotNil(t, flagSet.Lookup("credential-audit-interval"))
+	assert.NotNil(t, flagSet.Lookup("credential-gc-mode"))
+}
+
+func TestFeatureOptions_PipelineBackend(t *testing.T) {
+	opt := NewFeatureOptions()
+	assert.Equal(t, backend.Jenkins, opt.GetPipelineBackend())
+
+	opt.PipelineBackend = "tekton"
+	assert.Empty(t, opt.Validate())
+	assert.Equal(t, backend.Tekton, opt.GetPipelineBackend())
+
+	opt.PipelineBackend = "fake"
+	assert.Len(t, opt.Validate(), 1)
+	assert.Equal(t, backend.Jenkins, opt.GetPipelineBackend())
+
+	opt.PipelineBackend = "Tekton,Jenkins"
+	assert.Empty(t, opt.Validate())
+	assert.Equal(t, backend.Tekton, opt.GetPipelineBackend())
+	assert.Equal(t, []backend.Type{backend.Tekton, backend.Jenkins}, opt.GetPipelineBackends())
+	assert.True(t, opt.IsPipelineBackendEnabled(backend.Jenkins))
+
+	opt.PipelineBackend = "Tekton"
+	assert.False(t, opt.IsPipelineBackendEnabled(backend.Jenkins))
+
+	opt.PipelineBackend = "Jenkins,fake"
+	assert.Len(t, opt.Validate(), 1)
+}
+
+func TestFeatureOptions_CredentialGCMode(t *testing.T) {
+	opt := NewFeatureOptions()
+	assert.Empty(t, opt.Validate())
+
+	opt.CredentialGCMode = "dry-run"
+	assert.Empty(t, opt.Validate())
+
+	opt.CredentialGCMode = "fake"
+	assert.Len(t, opt.Validate(), 1)
 }
@@ -18,14 +18,22 @@ package options
 
 import (
 	"flag"
+	"fmt"
+	"net"
 	"strings"
 	"time"
 
 	"kubesphere.io/devops/pkg/config"
 
+	"kubesphere.io/devops/pkg/client/cloudevents"
 	"kubesphere.io/devops/pkg/client/devops/jenkins"
+	"kubesphere.io/devops/pkg/client/history"
 	"kubesphere.io/devops/pkg/client/k8s"
+	"kubesphere.io/devops/pkg/client/notification"
 	"kubesphere.io/devops/pkg/client/s3"
+	"kubesphere.io/devops/pkg/client/scanner"
+	"kubesphere.io/devops/pkg/client/secretstore"
+	"kubesphere.io/devops/pkg/client/sonarqube"
 
 	"k8s.io/apimachinery/pkg/labels"
 
@@ -33,6 +41,16 @@ import (
 	"k8s.io/client-go/tools/leaderelection"
 	cliflag "k8s.io/component-base/cli/flag"
 	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	// DefaultLeaderElectionID is the default name of the resource lock of the leader election
+	DefaultLeaderElectionID = "ks-devops-controller-manager-leader-election"
+	// DefaultLeaderElectionNamespace is the default namespace of the resource lock of the leader election
+	DefaultLeaderElectionNamespace = "kubesphere-devops-system"
+	// DefaultHealthProbeBindAddress is the default address of the health probes
+	DefaultHealthProbeBindAddress = ":8081"
 )
 
 type DevOpsControllerManagerOptions struct {
@@ -41,10 +59,45 @@ type DevOpsControllerManagerOptions struct {
 	LeaderElect       bool
 	LeaderElection    *leaderelection.LeaderElectionConfig
 	WebhookCertDir    string
+	EnableWebhook     bool
 	S3Options         *s3.Options
 	FeatureOptions    *FeatureOptions
 	JWTOptions        *JWTOptions
 	ArgoCDOption      *config.ArgoCDOption
+	ReconcilerOptions *ReconcilerOptions
+
+	// SecretStoreOptions configures the external secret stores which the credentials are able to refer to
+	SecretStoreOptions *secretstore.Options
+
+	// ScannerOptions configures the scanner of the uploaded S2iBinaries
+	ScannerOptions *scanner.Options
+
+	// NotificationOptions configures the SMTP server of the Email notifications
+	NotificationOptions *notification.Options
+
+	// CloudEventsOptions configures the sink of the CloudEvents of Pipelines and PipelineRuns
+	CloudEventsOptions *cloudevents.Options
+
+	// HistoryOptions configures the database which keeps the summaries of the completed PipelineRuns
+	HistoryOptions *history.Options
+
+	// SonarQubeOptions configures the SonarQube which checks the quality gates of the PipelineRuns
+	SonarQubeOptions *sonarqube.Options
+
+	// CallbackAllowedNetworks are the private networks in CIDR notation which the callbacks of Pipelines are able to
+	// reach, such as the networks of the in-cluster receivers
+	CallbackAllowedNetworks []string
+
+	// HealthProbeBindAddress is the address of the /healthz and /readyz endpoints, "0" disables them
+	HealthProbeBindAddress string
+
+	// LeaderElectionID is the name of the resource lock which is used for the leader election
+	LeaderElectionID string
+	// LeaderElectionNamespace is the namespace of the resource lock
+	LeaderElectionNamespace string
+	// LeaderElectionReleaseOnCancel makes the leader step down voluntarily when the manager is stopping,
+	// then another replica is able to take over without waiting for the lease to expire
+	LeaderElectionReleaseOnCancel bool
 
 	// KubeSphere is using sigs.k8s.io/application as fundamental object to implement Application Management.
 	// There are other projects also built on sigs.k8s.io/application, when KubeSphere installed along side
@@ -70,6 +123,22 @@ func NewDevOpsControllerManagerOptions() *DevOpsControllerManagerOptions {
 		ApplicationSelector: "",
 		KubernetesOptions:   &k8s.KubernetesOptions{},
 		ArgoCDOption:        &config.ArgoCDOption{},
+		ReconcilerOptions:   NewReconcilerOptions(),
+
+		LeaderElectionID:              DefaultLeaderElectionID,
+		LeaderElectionNamespace:       DefaultLeaderElectionNamespace,
+		LeaderElectionReleaseOnCancel: true,
+
+		SecretStoreOptions: secretstore.NewOptions(),
+		ScannerOptions:     scanner.NewOptions(),
+
+		NotificationOptions: notification.NewOptions(),
+		CloudEventsOptions:  cloudevents.NewOptions(),
+
+		HistoryOptions:   history.NewOptions(),
+		SonarQubeOptions: sonarqube.NewSonarQubeOptions(),
+
+		HealthProbeBindAddress: DefaultHealthProbeBindAddress,
 	}
 
 	return s
@@ -82,6 +151,13 @@ func (s *DevOpsControllerManagerOptions) Flags() cliflag.NamedFlagSets {
 	s.JenkinsOptions.AddFlags(fss.FlagSet("devops"), s.JenkinsOptions)
 	s.FeatureOptions.AddFlags(fss.FlagSet("feature"), s.FeatureOptions)
 	s.ArgoCDOption.AddFlags(fss.FlagSet("argocd"), s.ArgoCDOption)
+	s.ReconcilerOptions.AddFlags(fss.FlagSet("reconciler"), s.ReconcilerOptions)
+	s.SecretStoreOptions.AddFlags(fss.FlagSet("secretstore"), s.SecretStoreOptions)
+	s.ScannerOptions.AddFlags(fss.FlagSet("scanner"), s.ScannerOptions)
+	s.NotificationOptions.AddFlags(fss.FlagSet("notification"), s.NotificationOptions)
+	s.CloudEventsOptions.AddFlags(fss.FlagSet("cloudevents"), s.CloudEventsOptions)
+	s.HistoryOptions.AddFlags(fss.FlagSet("history"), s.HistoryOptions)
+	s.SonarQubeOptions.AddFlags(fss.FlagSet("sonarqube"), s.SonarQubeOptions)
 
 	fs := fss.FlagSet("leaderelection")
 	s.bindLeaderElectionFlags(s.LeaderElection, fs)
@@ -95,7 +171,17 @@ func (s *DevOpsControllerManagerOptions) Flags() cliflag.NamedFlagSets {
 		"if not set, webhook server would look up the server key and certificate in"+
 		"{TempDir}/k8s-webhook-server/serving-certs")
 
+	fs.BoolVar(&s.EnableWebhook, "enable-webhook", s.EnableWebhook, ""+
+		"Whether to enable the admission webhooks of Pipeline and PipelineRun. A self-signed certificate will be"+
+		"generated into the webhook-cert-dir if there is no certificate.")
+
 	gfs := fss.FlagSet("generic")
+	gfs.StringVar(&s.HealthProbeBindAddress, "health-probe-bind-address", s.HealthProbeBindAddress, ""+
+		"The address of the /healthz and /readyz endpoints, /readyz checks the dependencies of the enabled "+
+		"Pipeline backends as well, such as the Jenkins API. Set it to 0 to disable the endpoints.")
+	gfs.StringSliceVar(&s.CallbackAllowedNetworks, "callback-allowed-networks", s.CallbackAllowedNetworks, ""+
+		"The networks in CIDR notation which the callbacks of Pipelines are able to reach even though they are "+
+		"loopback, private or link-local, such as 10.96.0.0/12. The other networks of these kinds are refused.")
 	gfs.StringVar(&s.ApplicationSelector, "application-selector", s.ApplicationSelector, ""+
 		"Only reconcile application(sigs.k8s.io/application) objects match given selector, this could avoid conflicts with "+
 		"other projects built on top of sig-application. Default behavior is to reconcile all of application objects.")
@@ -116,6 +202,17 @@ func (s *DevOpsControllerManagerOptions) Validate() []error {
 	errs = append(errs, s.JenkinsOptions.Validate()...)
 	errs = append(errs, s.KubernetesOptions.Validate()...)
 	errs = append(errs, s.FeatureOptions.Validate()...)
+	errs = append(errs, s.ReconcilerOptions.Validate()...)
+
+	if s.LeaderElect {
+		errs = append(errs, s.validateLeaderElection()...)
+	}
+
+	for _, cidr := range s.CallbackAllowedNetworks {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			errs = append(errs, err)
+		}
+	}
 
 	if len(s.ApplicationSelector) != 0 {
 		_, err := labels.Parse(s.ApplicationSelector)
@@ -141,4 +238,49 @@ func (s *DevOpsControllerManagerOptions) bindLeaderElectionFlags(l *leaderelecti
 	fs.DurationVar(&l.RetryPeriod, "leader-elect-retry-period", l.RetryPeriod, ""+
 		"The duration the clients should wait between attempting acquisition and renewal "+
 		"of a leadership. This is only applicable if leader election is enabled.")
+	fs.StringVar(&s.LeaderElectionID, "leader-elect-id", s.LeaderElectionID, ""+
+		"The name of the resource lock which is used for the leader election. "+
+		"This is only applicable if leader election is enabled.")
+	fs.StringVar(&s.LeaderElectionNamespace, "leader-elect-namespace", s.LeaderElectionNamespace, ""+
+		"The namespace of the resource lock which is used for the leader election. "+
+		"This is only applicable if leader election is enabled.")
+	fs.BoolVar(&s.LeaderElectionReleaseOnCancel, "leader-elect-release-on-cancel", s.LeaderElectionReleaseOnCancel, ""+
+		"Whether the leader should step down voluntarily when the controller manager is stopping. It lets "+
+		"another replica take over immediately instead of waiting for the lease to expire. "+
+		"This is only applicable if leader election is enabled.")
+}
+
+func (s *DevOpsControllerManagerOptions) validateLeaderElection() (errs []error) {
+	if s.LeaderElectionID == "" {
+		errs = append(errs, fmt.Errorf("--leader-elect-id cannot be empty when leader election is enabled"))
+	}
+	if s.LeaderElectionNamespace == "" {
+		errs = append(errs, fmt.Errorf("--leader-elect-namespace cannot be empty when leader election is enabled"))
+	}
+	if l := s.LeaderElection; l != nil {
+		if l.LeaseDuration <= l.RenewDeadline {
+			errs = append(errs, fmt.Errorf("--leader-elect-lease-duration must be greater than --leader-elect-renew-deadline"))
+		}
+		if l.RenewDeadline <= time.Duration(leaderelection.JitterFactor*float64(l.RetryPeriod)) {
+			errs = append(errs, fmt.Errorf("--leader-elect-renew-deadline must be greater than %v times --leader-elect-retry-period",
+				leaderelection.JitterFactor))
+		}
+	}
+	return
+}
+
+// ApplyLeaderElectionTo sets the leader election options of the controller manager
+func (s *DevOpsControllerManagerOptions) ApplyLeaderElectionTo(opts *manager.Options) {
+	if !s.LeaderElect {
+		return
+	}
+	opts.LeaderElection = true
+	opts.LeaderElectionID = s.LeaderElectionID
+	opts.LeaderElectionNamespace = s.LeaderElectionNamespace
+	opts.LeaderElectionReleaseOnCancel = s.LeaderElectionReleaseOnCancel
+	if s.LeaderElection != nil {
+		opts.LeaseDuration = &s.LeaderElection.LeaseDuration
+		opts.RenewDeadline = &s.LeaderElection.RenewDeadline
+		opts.RetryPeriod = &s.LeaderElection.RetryPeriod
+	}
 }
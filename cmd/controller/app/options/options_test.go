@@ -18,8 +18,10 @@ package options
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
 func TestOption(t *testing.T) {
@@ -34,6 +36,7 @@ func TestOption(t *testing.T) {
 	assert.NotNil(t, flags.FlagSet("argocd"))
 	assert.NotNil(t, flags.FlagSet("generic"))
 	assert.NotNil(t, flags.FlagSet("leaderelection"))
+	assert.NotNil(t, flags.FlagSet("reconciler"))
 	assert.NotNil(t, flags.FlagSet("klog"))
 
 	opt.ApplicationSelector = "key=value"
@@ -42,3 +45,35 @@ func TestOption(t *testing.T) {
 	opt.ApplicationSelector = "!@#$"
 	assert.NotNil(t, opt.Validate())
 }
+
+func TestLeaderElection(t *testing.T) {
+	opt := NewDevOpsControllerManagerOptions()
+	assert.Equal(t, DefaultLeaderElectionID, opt.LeaderElectionID)
+	assert.Equal(t, DefaultLeaderElectionNamespace, opt.LeaderElectionNamespace)
+	assert.True(t, opt.LeaderElectionReleaseOnCancel)
+
+	// leader election is disabled by default
+	mgrOptions := manager.Options{}
+	opt.ApplyLeaderElectionTo(&mgrOptions)
+	assert.False(t, mgrOptions.LeaderElection)
+	assert.Empty(t, mgrOptions.LeaderElectionID)
+
+	fss := opt.Flags()
+	assert.Nil(t, fss.FlagSet("leaderelection").Parse([]string{"--leader-elect", "--leader-elect-id=lock", "--leader-elect-namespace=ns",
+		"--leader-elect-release-on-cancel=false"}))
+	assert.Nil(t, opt.Validate())
+
+	opt.ApplyLeaderElectionTo(&mgrOptions)
+	assert.True(t, mgrOptions.LeaderElection)
+	assert.Equal(t, "lock", mgrOptions.LeaderElectionID)
+	assert.Equal(t, "ns", mgrOptions.LeaderElectionNamespace)
+	assert.False(t, mgrOptions.LeaderElectionReleaseOnCancel)
+	assert.Equal(t, 30*time.Second, *mgrOptions.LeaseDuration)
+	assert.Equal(t, 15*time.Second, *mgrOptions.RenewDeadline)
+	assert.Equal(t, 5*time.Second, *mgrOptions.RetryPeriod)
+
+	// invalid options
+	opt.LeaderElectionID = ""
+	opt.LeaderElection.RenewDeadline = opt.LeaderElection.LeaseDuration
+	assert.Equal(t, 2, len(opt.Validate()))
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// ReconcilerOptions provides the options to tune the throughput of the reconcilers
+type ReconcilerOptions struct {
+	// MaxConcurrentReconciles is the default maximum number of concurrent reconciles of a controller
+	MaxConcurrentReconciles int
+	// ControllerMaxConcurrentReconciles overrides MaxConcurrentReconciles by the name of controllers
+	ControllerMaxConcurrentReconciles map[string]int
+	// RateLimiterBaseDelay is the delay of requeuing a failed request at the first time
+	RateLimiterBaseDelay time.Duration
+	// RateLimiterMaxDelay is the maximum delay of requeuing a failed request
+	RateLimiterMaxDelay time.Duration
+	// SyncPeriod is the minimum frequency at which the watched resources are reconciled
+	SyncPeriod time.Duration
+}
+
+// NewReconcilerOptions provides the default options which are the same as controller-runtime
+func NewReconcilerOptions() *ReconcilerOptions {
+	return &ReconcilerOptions{
+		MaxConcurrentReconciles: 1,
+		RateLimiterBaseDelay:    5 * time.Millisecond,
+		RateLimiterMaxDelay:     1000 * time.Second,
+		SyncPeriod:              10 * time.Hour,
+	}
+}
+
+// AddFlags adds flags related to ReconcilerOptions for controller manager to the reconciler FlagSet.
+func (o *ReconcilerOptions) AddFlags(fs *pflag.FlagSet, c *ReconcilerOptions) {
+	fs.IntVar(&o.MaxConcurrentReconciles, "max-concurrent-reconciles", c.MaxConcurrentReconciles,
+		"The default maximum number of concurrent reconciles of a controller")
+	fs.StringToIntVar(&o.ControllerMaxConcurrentReconciles, "controller-max-concurrent-reconciles",
+		c.ControllerMaxConcurrentReconciles, "A set of controller=number pairs which override the maximum number "+
+			"of concurrent reconciles of the given controllers, such as pipelinerun-controller=5")
+	fs.DurationVar(&o.RateLimiterBaseDelay, "rate-limiter-base-delay", c.RateLimiterBaseDelay,
+		"The delay of requeuing a failed request at the first time, it grows exponentially for the next failures")
+	fs.DurationVar(&o.RateLimiterMaxDelay, "rate-limiter-max-delay", c.RateLimiterMaxDelay,
+		"The maximum delay of requeuing a failed request")
+	fs.DurationVar(&o.SyncPeriod, "sync-period", c.SyncPeriod,
+		"The minimum frequency at which the watched resources are reconciled")
+}
+
+// Validate checks validation of ReconcilerOptions.
+func (o *ReconcilerOptions) Validate() (errs []error) {
+	if o.MaxConcurrentReconciles <= 0 {
+		errs = append(errs, fmt.Errorf("--max-concurrent-reconciles must be greater than 0"))
+	}
+	for name, number := range o.ControllerMaxConcurrentReconciles {
+		if number <= 0 {
+			errs = append(errs, fmt.Errorf("the max concurrent reconciles of %s must be greater than 0", name))
+		}
+	}
+	if o.RateLimiterBaseDelay <= 0 || o.RateLimiterMaxDelay < o.RateLimiterBaseDelay {
+		errs = append(errs, fmt.Errorf("--rate-limiter-max-delay must be greater than --rate-limiter-base-delay, and both must be positive"))
+	}
+	if o.SyncPeriod <= 0 {
+		errs = append(errs, fmt.Errorf("--sync-period must be greater than 0"))
+	}
+	return
+}
+
+// GetControllerOptions returns the options of the given controller
+func (o *ReconcilerOptions) GetControllerOptions(name string) controller.Options {
+	maxConcurrentReconciles := o.MaxConcurrentReconciles
+	if number, ok := o.ControllerMaxConcurrentReconciles[name]; ok {
+		maxConcurrentReconciles = number
+	}
+	return controller.Options{
+		MaxConcurrentReconciles: maxConcurrentReconciles,
+		// the same as workqueue.DefaultControllerRateLimiter except the delays of the failures
+		RateLimiter: workqueue.NewMaxOfRateLimiter(
+			workqueue.NewItemExponentialFailureRateLimiter(o.RateLimiterBaseDelay, o.RateLimiterMaxDelay),
+			&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(10), 100)},
+		),
+	}
+}
+
+// ApplyTo sets the options which take effect on all the controllers of the manager
+func (o *ReconcilerOptions) ApplyTo(opts *manager.Options) {
+	opts.SyncPeriod = &o.SyncPeriod
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+func TestReconcilerOptions(t *testing.T) {
+	opt := NewReconcilerOptions()
+	assert.Nil(t, opt.Validate())
+
+	fs := pflag.NewFlagSet("reconciler", pflag.ContinueOnError)
+	opt.AddFlags(fs, opt)
+	assert.Nil(t, fs.Parse([]string{"--max-concurrent-reconciles=2", "--controller-max-concurrent-reconciles=pipelinerun-controller=5",
+		"--rate-limiter-max-delay=1m", "--sync-period=1h"}))
+	assert.Nil(t, opt.Validate())
+
+	assert.Equal(t, 5, opt.GetControllerOptions("pipelinerun-controller").MaxConcurrentReconciles)
+	assert.NotNil(t, opt.GetControllerOptions("pipelinerun-controller").RateLimiter)
+	assert.Equal(t, 2, opt.GetControllerOptions("other").MaxConcurrentReconciles)
+
+	rateLimiter := opt.GetControllerOptions("other").RateLimiter
+	for i := 0; i < 20; i++ {
+		rateLimiter.When("item")
+	}
+	assert.Equal(t, time.Minute, rateLimiter.When("item"))
+
+	mgrOptions := manager.Options{}
+	opt.ApplyTo(&mgrOptions)
+	assert.Equal(t, time.Hour, *mgrOptions.SyncPeriod)
+
+	// invalid options
+	opt.MaxConcurrentReconciles = 0
+	opt.ControllerMaxConcurrentReconciles["pipelinerun-controller"] = -1
+	opt.RateLimiterMaxDelay = time.Millisecond
+	opt.SyncPeriod = 0
+	assert.Equal(t, 4, len(opt.Validate()))
+}
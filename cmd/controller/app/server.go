@@ -26,11 +26,15 @@ import (
 	"kubesphere.io/devops/pkg/apis"
 	"kubesphere.io/devops/pkg/client/devops"
 	"kubesphere.io/devops/pkg/client/devops/jclient"
+	"kubesphere.io/devops/pkg/client/history"
 	"kubesphere.io/devops/pkg/client/k8s"
+	"kubesphere.io/devops/pkg/client/sonarqube"
 	"kubesphere.io/devops/pkg/config"
 	"kubesphere.io/devops/pkg/indexers"
 	"kubesphere.io/devops/pkg/informers"
+	"kubesphere.io/devops/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
+	"time"
 
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -50,6 +54,12 @@ func NewControllerManagerCommand() *cobra.Command {
 		if conf.ArgoCDOption == nil {
 			conf.ArgoCDOption = &config.ArgoCDOption{}
 		}
+		if conf.HistoryOptions == nil {
+			conf.HistoryOptions = history.NewOptions()
+		}
+		if conf.SonarQubeOptions == nil {
+			conf.SonarQubeOptions = sonarqube.NewSonarQubeOptions()
+		}
 		// make sure LeaderElection is not nil
 		// override devops controller manager options
 		s = &options.DevOpsControllerManagerOptions{
@@ -65,6 +75,25 @@ func NewControllerManagerCommand() *cobra.Command {
 			LeaderElection: s.LeaderElection,
 			LeaderElect:    s.LeaderElect,
 			WebhookCertDir: s.WebhookCertDir,
+			EnableWebhook:  s.EnableWebhook,
+
+			ReconcilerOptions: s.ReconcilerOptions,
+
+			LeaderElectionID:              s.LeaderElectionID,
+			LeaderElectionNamespace:       s.LeaderElectionNamespace,
+			LeaderElectionReleaseOnCancel: s.LeaderElectionReleaseOnCancel,
+
+			SecretStoreOptions: s.SecretStoreOptions,
+			ScannerOptions:     s.ScannerOptions,
+
+			NotificationOptions: s.NotificationOptions,
+			CloudEventsOptions:  s.CloudEventsOptions,
+
+			HistoryOptions:   conf.HistoryOptions,
+			SonarQubeOptions: conf.SonarQubeOptions,
+
+			CallbackAllowedNetworks: s.CallbackAllowedNetworks,
+			HealthProbeBindAddress:  s.HealthProbeBindAddress,
 		}
 	} else {
 		klog.Fatal("Failed to load configuration from disk", err)
@@ -120,11 +149,24 @@ func Run(s *options.DevOpsControllerManagerOptions, ctx context.Context) error {
 		return err
 	}
 
+	// all the requests to Jenkins share the same circuit breaker, they fail fast while Jenkins is unavailable
+	jenkinsBreaker := s.JenkinsOptions.NewBreaker()
+	// all the requests to Jenkins share the pooled connections as well
+	jenkinsRoundTripper := jenkinsBreaker.RoundTripper(metrics.InstrumentRoundTripper(s.JenkinsOptions.NewTransport()))
+
+	// use the active Jenkins instance, it's the standby one after switching over to it
+	jenkinsOptions := s.JenkinsOptions.ResolveActive(ctx, kubernetesClient.Kubernetes().CoreV1())
+
 	// Init DevOps client while Jenkins options and Jenkins host
 	var devopsClient devops.Interface
 	if s.JenkinsOptions != nil && len(s.JenkinsOptions.Host) != 0 {
 		// Make sure that Jenkins host is not empty
-		devopsClient, err = jclient.NewJenkinsClient(s.JenkinsOptions)
+		var jenkinsClient *jclient.JenkinsClient
+		jenkinsClient, err = jclient.NewJenkinsClient(jenkinsOptions)
+		if jenkinsClient != nil {
+			jenkinsClient.SetRoundTripper(jenkinsRoundTripper)
+			devopsClient = jenkinsClient
+		}
 		if !s.JenkinsOptions.SkipVerify && err != nil {
 			errMsg := fmt.Sprintf("failed to connect jenkins, please check jenkins status, error: %v", err)
 			if s.JenkinsOptions.SkipVerify {
@@ -137,9 +179,12 @@ func Run(s *options.DevOpsControllerManagerOptions, ctx context.Context) error {
 
 	// Init Jenkins client
 	jenkinsCore := core.JenkinsCore{
-		URL:      s.JenkinsOptions.Host,
-		UserName: s.JenkinsOptions.Username,
-		Token:    s.JenkinsOptions.Password,
+		URL:      jenkinsOptions.Host,
+		UserName: jenkinsOptions.Username,
+		Token:    jenkinsOptions.Password,
+		Timeout:  jenkinsOptions.RequestTimeout / time.Second,
+		// observe the latency of the Jenkins API
+		RoundTripper: jenkinsRoundTripper,
 	}
 
 	// Init informers
@@ -151,20 +196,12 @@ func Run(s *options.DevOpsControllerManagerOptions, ctx context.Context) error {
 	mgrOptions := manager.Options{
 		CertDir: s.WebhookCertDir,
 		Port:    8443,
-	}
 
-	if s.LeaderElect {
-		mgrOptions = manager.Options{
-			CertDir:                 s.WebhookCertDir,
-			Port:                    8443,
-			LeaderElection:          s.LeaderElect,
-			LeaderElectionNamespace: "kubesphere-devops-system",
-			LeaderElectionID:        "ks-devops-controller-manager-leader-election",
-			LeaseDuration:           &s.LeaderElection.LeaseDuration,
-			RetryPeriod:             &s.LeaderElection.RetryPeriod,
-			RenewDeadline:           &s.LeaderElection.RenewDeadline,
-		}
+		HealthProbeBindAddress: s.HealthProbeBindAddress,
 	}
+	// only the leader reconciles the resources when there are multiple replicas
+	s.ApplyLeaderElectionTo(&mgrOptions)
+	s.ReconcilerOptions.ApplyTo(&mgrOptions)
 
 	klog.V(0).Info("setting up manager")
 	ctrl.SetLogger(klogr.New())
@@ -185,10 +222,21 @@ func Run(s *options.DevOpsControllerManagerOptions, ctx context.Context) error {
 		informerFactory,
 		devopsClient,
 		jenkinsCore,
+		jenkinsBreaker,
 		s); err != nil {
 		return fmt.Errorf("unable to register controllers to the manager: %v", err)
 	}
 
+	if err = addHealthChecks(mgr, kubernetesClient, jenkinsOptions, jenkinsRoundTripper, s); err != nil {
+		return fmt.Errorf("unable to add the health checks: %v", err)
+	}
+
+	if s.EnableWebhook {
+		if err = setupWebhooks(ctx, mgr, kubernetesClient, s); err != nil {
+			return fmt.Errorf("unable to set up the webhooks: %v", err)
+		}
+	}
+
 	if err = indexers.CreatePipelineRunSCMRefNameIndexer(mgr.GetCache()); err != nil {
 		return err
 	}
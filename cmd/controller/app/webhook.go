@@ -0,0 +1,90 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	ctrlwebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	"kubesphere.io/devops/cmd/controller/app/options"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/backend"
+	"kubesphere.io/devops/pkg/client/k8s"
+	"kubesphere.io/devops/pkg/policy"
+	"kubesphere.io/devops/pkg/utils/certutil"
+	"kubesphere.io/devops/pkg/webhook"
+)
+
+const (
+	webhookServiceName                 = "ks-devops-webhook-service"
+	webhookServiceNamespace            = "kubesphere-devops-system"
+	validatingWebhookConfigurationName = "ks-devops-validating-webhook-configuration"
+	mutatingWebhookConfigurationName   = "ks-devops-mutating-webhook-configuration"
+)
+
+//+kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=validatingwebhookconfigurations;mutatingwebhookconfigurations,verbs=get;update
+
+// setupWebhooks registers the admission webhooks, and makes sure there is a serving certificate for them
+func setupWebhooks(ctx context.Context, mgr manager.Manager, kubernetesClient k8s.Client,
+	s *options.DevOpsControllerManagerOptions) (err error) {
+	host := fmt.Sprintf("%s.%s.svc", webhookServiceName, webhookServiceNamespace)
+	var caBundle []byte
+	if caBundle, err = certutil.EnsureSelfSignedCert(s.WebhookCertDir, host, host+".cluster.local"); err != nil {
+		return
+	}
+	if caBundle != nil {
+		klog.V(0).Infof("generated a self-signed certificate for the webhook service %s", host)
+		admissionClient := kubernetesClient.Kubernetes().AdmissionregistrationV1()
+		if err = certutil.InjectCABundle(ctx, admissionClient, validatingWebhookConfigurationName, caBundle); err != nil {
+			return
+		}
+		if err = certutil.InjectMutatingCABundle(ctx, admissionClient, mutatingWebhookConfigurationName, caBundle); err != nil {
+			return
+		}
+	}
+
+	if err = (&v1alpha3.Pipeline{}).SetupWebhookWithManager(mgr); err != nil {
+		return
+	}
+	mgr.GetWebhookServer().Register(webhook.PipelineModifierAnnotatorPath, &ctrlwebhook.Admission{
+		Handler: &webhook.PipelineModifierAnnotator{},
+	})
+	mgr.GetWebhookServer().Register(webhook.PipelineQuotaValidatorPath, &ctrlwebhook.Admission{
+		Handler: &webhook.PipelineQuotaValidator{Reader: mgr.GetClient()},
+	})
+	mgr.GetWebhookServer().Register(webhook.PipelinePolicyValidatorPath, &ctrlwebhook.Admission{
+		Handler: &webhook.PipelinePolicyValidator{Evaluator: &policy.Evaluator{
+			Reader:     mgr.GetClient(),
+			HTTPClient: &http.Client{Timeout: 10 * time.Second},
+		}},
+	})
+	mgr.GetWebhookServer().Register(webhook.PipelineRunClusterValidatorPath, &ctrlwebhook.Admission{
+		Handler: &webhook.PipelineRunClusterValidator{Reader: mgr.GetClient()},
+	})
+	err = (&v1alpha3.PipelineRun{}).SetupWebhookWithManager(mgr, &webhook.PipelineRunDefaulter{
+		Reader:                  mgr.GetClient(),
+		Router:                  backend.NewRouter(mgr.GetClient(), s.FeatureOptions.GetPipelineBackend()),
+		APIServerServiceAccount: s.FeatureOptions.GetAPIServerUsername(),
+	})
+	return
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+// The prefixes of the DevOps APIs
+const (
+	apiPrefix        = "/kapis/devops.kubesphere.io/v1alpha3"
+	jenkinsAPIPrefix = "/kapis/devops.kubesphere.io/v1alpha2"
+)
+
+type rootOption struct {
+	loadingRules     *clientcmd.ClientConfigLoadingRules
+	overrides        *clientcmd.ConfigOverrides
+	serverURL        string
+	serviceNamespace string
+	serviceName      string
+
+	namespace string
+	api       *apiClient
+	client    client.Client
+}
+
+// complete creates the clients from the kubeconfig, the clients which have been set are kept
+func (o *rootOption) complete() (err error) {
+	if o.api != nil && o.client != nil {
+		return
+	}
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(o.loadingRules, o.overrides)
+	if o.namespace, _, err = clientConfig.Namespace(); err != nil {
+		return
+	}
+	var config *rest.Config
+	if config, err = clientConfig.ClientConfig(); err != nil {
+		return
+	}
+
+	if o.client == nil {
+		scheme := runtime.NewScheme()
+		if err = v1alpha3.AddToScheme(scheme); err != nil {
+			return
+		}
+		if o.client, err = client.New(config, client.Options{Scheme: scheme}); err != nil {
+			return
+		}
+	}
+	if o.api == nil {
+		var httpClient *http.Client
+		if httpClient, err = rest.HTTPClientFor(config); err != nil {
+			return
+		}
+		baseURL := o.serverURL
+		if baseURL == "" {
+			// the credential of the kubeconfig is verified by the Kubernetes apiserver, then passed to the DevOps apiserver
+			baseURL = fmt.Sprintf("%s/api/v1/namespaces/%s/services/%s/proxy",
+				strings.TrimSuffix(config.Host, "/"), o.serviceNamespace, o.serviceName)
+		}
+		o.api = &apiClient{baseURL: strings.TrimSuffix(baseURL, "/"), client: httpClient}
+	}
+	return
+}
+
+// apiClient sends requests to the DevOps apiserver
+type apiClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+// do sends a request to the API which has the prefix, the caller should close the body of the response
+func (c *apiClient) do(ctx context.Context, method, api string, body interface{}) (resp *http.Response, err error) {
+	var reader io.Reader
+	if body != nil {
+		var data []byte
+		if data, err = json.Marshal(body); err != nil {
+			return
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctx, method, c.baseURL+api, reader); err != nil {
+		return
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if resp, err = c.client.Do(req); err == nil && resp.StatusCode >= http.StatusBadRequest {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		_ = resp.Body.Close()
+		err = fmt.Errorf("%s %s failed, status %d: %s", method, api, resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return
+}
+
+// get sends a GET request and decodes the response into the result
+func (c *apiClient) get(ctx context.Context, api string, result interface{}) error {
+	return c.call(ctx, http.MethodGet, api, nil, result)
+}
+
+// call sends a request and decodes the response into the result
+func (c *apiClient) call(ctx context.Context, method, api string, body, result interface{}) (err error) {
+	var resp *http.Response
+	if resp, err = c.do(ctx, method, api, body); err != nil {
+		return
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	return json.NewDecoder(resp.Body).Decode(result)
+}
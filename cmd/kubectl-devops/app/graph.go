@@ -0,0 +1,128 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+
+	"kubesphere.io/devops/pkg/models/pipelinerun"
+)
+
+func (o *runOption) graph(cmd *cobra.Command, args []string) (err error) {
+	var nodes []pipelinerun.NodeDetail
+	api := fmt.Sprintf("%s/namespaces/%s/pipelineruns/%s/nodedetails", apiPrefix, o.namespace, args[0])
+	if err = o.api.get(cmd.Context(), api, &nodes); err != nil {
+		return
+	}
+	if len(nodes) == 0 {
+		cmd.Println("No stages found")
+		return
+	}
+	cmd.Print(renderGraph(nodes))
+	return
+}
+
+// renderGraph renders the stages from left to right, the parallel stages are placed in the same column
+func renderGraph(nodes []pipelinerun.NodeDetail) string {
+	levels := map[string]int{}
+	changed := true
+	// the nodes are supposed to be in topological order, the loop is bounded in case they are not
+	for i := 0; changed && i < len(nodes); i++ {
+		changed = false
+		for _, node := range nodes {
+			for _, edge := range node.Edges {
+				if level := levels[node.ID] + 1; level > levels[edge.ID] {
+					levels[edge.ID] = level
+					changed = true
+				}
+			}
+		}
+	}
+
+	var columns [][]string
+	for _, node := range nodes {
+		level := levels[node.ID]
+		for len(columns) <= level {
+			columns = append(columns, nil)
+		}
+		columns[level] = append(columns[level], formatNode(&node))
+	}
+
+	rows, widths := 0, make([]int, len(columns))
+	for i, column := range columns {
+		if len(column) > rows {
+			rows = len(column)
+		}
+		for _, cell := range column {
+			if width := utf8.RuneCountInString(cell); width > widths[i] {
+				widths[i] = width
+			}
+		}
+	}
+
+	builder := &strings.Builder{}
+	for row := 0; row < rows; row++ {
+		line := &strings.Builder{}
+		for i, column := range columns {
+			cell := ""
+			if row < len(column) {
+				cell = column[row]
+			}
+			if i > 0 {
+				if row == 0 {
+					line.WriteString(" ──▶ ")
+				} else {
+					line.WriteString("     ")
+				}
+			}
+			line.WriteString(cell)
+			line.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)))
+		}
+		builder.WriteString(strings.TrimRight(line.String(), " "))
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+// formatNode formats a stage like "[✔ build 1m2s]"
+func formatNode(node *pipelinerun.NodeDetail) string {
+	icon := "○"
+	switch {
+	case node.State == "RUNNING":
+		icon = "▶"
+	case node.State == "PAUSED":
+		icon = "⏸"
+	case node.Result == "SUCCESS":
+		icon = "✔"
+	case node.Result == "FAILURE":
+		icon = "✘"
+	case node.Result == "UNSTABLE":
+		icon = "!"
+	case node.Result == "ABORTED":
+		icon = "⊘"
+	}
+	text := icon + " " + node.DisplayName
+	if node.DurationInMillis > 0 {
+		text += " " + (time.Duration(node.DurationInMillis) * time.Millisecond).Round(time.Second).String()
+	}
+	return "[" + text + "]"
+}
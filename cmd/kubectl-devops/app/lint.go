@@ -0,0 +1,97 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/pipeline"
+)
+
+type lintOption struct {
+	*rootOption
+
+	file       string
+	showObject bool
+}
+
+func newLintCmd(root *rootOption) (cmd *cobra.Command) {
+	opt := &lintOption{rootOption: root}
+	cmd = &cobra.Command{
+		Use:   "lint",
+		Short: "Validate a Pipeline manifest or a Jenkinsfile without saving it",
+		Example: "kubectl devops lint -f pipeline.yaml\n" +
+			"kubectl devops lint -f Jenkinsfile",
+		Args: cobra.NoArgs,
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			return opt.complete()
+		},
+		RunE: opt.lint,
+	}
+	cmd.Flags().StringVarP(&opt.file, "filename", "f", "", "The Pipeline manifest or Jenkinsfile, - means the stdin")
+	cmd.Flags().BoolVarP(&opt.showObject, "show-object", "", false, "Print the generated backend object")
+	_ = cmd.MarkFlagRequired("filename")
+	return
+}
+
+func (o *lintOption) lint(cmd *cobra.Command, _ []string) (err error) {
+	var data []byte
+	if o.file == "-" {
+		data, err = io.ReadAll(cmd.InOrStdin())
+	} else {
+		data, err = os.ReadFile(o.file)
+	}
+	if err != nil {
+		return
+	}
+
+	request := &pipeline.DryRunRequest{}
+	manifest := &v1alpha3.Pipeline{}
+	if yaml.Unmarshal(data, manifest) == nil && manifest.Kind == "Pipeline" {
+		request.Pipeline = manifest
+	} else {
+		request.Jenkinsfile = string(data)
+	}
+
+	result := &pipeline.DryRunResult{}
+	if err = o.api.call(cmd.Context(), http.MethodPost, fmt.Sprintf("%s/namespaces/%s/pipelines/dryrun", apiPrefix, o.namespace),
+		request, result); err != nil {
+		return
+	}
+	for _, warning := range result.Warnings {
+		cmd.Printf("Warning: %s\n", warning)
+	}
+	for _, validationErr := range result.Errors {
+		cmd.Printf("Error: %s\n", validationErr)
+	}
+	if o.showObject && result.Object != "" {
+		cmd.Println(strings.TrimSpace(result.Object))
+	}
+	if !result.Valid {
+		return fmt.Errorf("%s is invalid", o.file)
+	}
+	cmd.Printf("%s is valid for the %s backend\n", o.file, result.Backend)
+	return
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// NewCmd creates the root command of the kubectl plugin, it could be invoked as "kubectl devops"
+func NewCmd() (cmd *cobra.Command) {
+	opt := &rootOption{
+		loadingRules: clientcmd.NewDefaultClientConfigLoadingRules(),
+		overrides:    &clientcmd.ConfigOverrides{},
+	}
+
+	cmd = &cobra.Command{
+		Use:          "kubectl-devops",
+		Short:        "Manage the pipelines of KubeSphere DevOps",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Help()
+		},
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.StringVarP(&opt.loadingRules.ExplicitPath, "kubeconfig", "", "",
+		"Path to the kubeconfig file to use for CLI requests")
+	clientcmd.BindOverrideFlags(opt.overrides, flags, clientcmd.RecommendedConfigOverrideFlags(""))
+	flags.StringVarP(&opt.serverURL, "server-url", "", "",
+		"The address of the DevOps apiserver. It's accessed through the proxy of the Kubernetes apiserver if it's empty")
+	flags.StringVarP(&opt.serviceNamespace, "service-namespace", "", "kubesphere-devops-system",
+		"The namespace of the DevOps apiserver service")
+	flags.StringVarP(&opt.serviceName, "service-name", "", "devops-apiserver:9090",
+		"The name and port of the DevOps apiserver service")
+
+	cmd.AddCommand(newRunCmd(opt), newLintCmd(opt))
+	return
+}
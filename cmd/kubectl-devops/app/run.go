@@ -0,0 +1,350 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/backend"
+	"kubesphere.io/devops/pkg/client/devops"
+	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/pipelinerun"
+)
+
+// logPollInterval is the interval of polling the progressive logs of Jenkins
+var logPollInterval = 2 * time.Second
+
+type runOption struct {
+	*rootOption
+
+	pipeline string
+	phase    string
+	limit    int
+	branch   string
+	params   []string
+	follow   bool
+	output   string
+}
+
+func newRunCmd(root *rootOption) (cmd *cobra.Command) {
+	opt := &runOption{rootOption: root}
+	cmd = &cobra.Command{
+		Use:     "run",
+		Aliases: []string{"runs", "pipelinerun", "pr"},
+		Short:   "Manage the PipelineRuns",
+		PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
+			return opt.complete()
+		},
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the PipelineRuns with their status, the latest runs come first",
+		Args:  cobra.NoArgs,
+		RunE:  opt.list,
+	}
+	listCmd.Flags().StringVarP(&opt.pipeline, "pipeline", "", "", "Only list the runs of the pipeline")
+	listCmd.Flags().StringVarP(&opt.phase, "phase", "", "", "The phases separated by commas, e.g. Failed,Cancelled")
+	listCmd.Flags().IntVarP(&opt.limit, "limit", "", 20, "The maximum number of the runs")
+
+	createCmd := &cobra.Command{
+		Use:     "create PIPELINE",
+		Aliases: []string{"trigger"},
+		Short:   "Trigger a pipeline",
+		Example: "kubectl devops run create build -p VERSION=v1.0.0 --follow",
+		Args:    cobra.ExactArgs(1),
+		RunE:    opt.create,
+	}
+	createCmd.Flags().StringVarP(&opt.branch, "branch", "", "", "The branch of the multi-branch pipeline")
+	createCmd.Flags().StringArrayVarP(&opt.params, "param", "p", nil, "The parameters in the form of NAME=VALUE")
+	createCmd.Flags().BoolVarP(&opt.follow, "follow", "f", false, "Tail the logs of the run")
+
+	cancelCmd := &cobra.Command{
+		Use:   "cancel RUN",
+		Short: "Cancel a PipelineRun",
+		Args:  cobra.ExactArgs(1),
+		RunE:  opt.cancel,
+	}
+
+	logsCmd := &cobra.Command{
+		Use:   "logs RUN",
+		Short: "Print the logs of a PipelineRun",
+		Args:  cobra.ExactArgs(1),
+		RunE:  opt.logs,
+	}
+	logsCmd.Flags().BoolVarP(&opt.follow, "follow", "f", false, "Keep streaming the logs until the run has completed")
+
+	artifactsCmd := &cobra.Command{
+		Use:   "artifacts RUN",
+		Short: "List the archived artifacts of a PipelineRun",
+		Args:  cobra.ExactArgs(1),
+		RunE:  opt.listArtifacts,
+	}
+
+	downloadCmd := &cobra.Command{
+		Use:   "download RUN ARTIFACT",
+		Short: "Download an archived artifact of a PipelineRun",
+		Args:  cobra.ExactArgs(2),
+		RunE:  opt.download,
+	}
+	downloadCmd.Flags().StringVarP(&opt.output, "output", "o", "", "The file to save the artifact, defaults to the name of the artifact")
+
+	graphCmd := &cobra.Command{
+		Use:   "graph RUN",
+		Short: "Render the stages of a PipelineRun as a graph",
+		Args:  cobra.ExactArgs(1),
+		RunE:  opt.graph,
+	}
+
+	cmd.AddCommand(listCmd, createCmd, cancelCmd, logsCmd, artifactsCmd, downloadCmd, graphCmd)
+	return
+}
+
+func (o *runOption) list(cmd *cobra.Command, _ []string) (err error) {
+	query := url.Values{"limit": {strconv.Itoa(o.limit)}}
+	if o.pipeline != "" {
+		query.Set("pipeline", o.pipeline)
+	}
+	if o.phase != "" {
+		query.Set(string(pipelinerun.FieldPhase), o.phase)
+	}
+	result := &struct {
+		Items []v1alpha3.PipelineRun `json:"items"`
+	}{}
+	if err = o.api.get(cmd.Context(), fmt.Sprintf("%s/namespaces/%s/pipelineruns?%s", apiPrefix, o.namespace, query.Encode()), result); err != nil {
+		return
+	}
+
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "NAME\tPIPELINE\tPHASE\tTRIGGER\tAGE\tDURATION")
+	for i := range result.Items {
+		run := &result.Items[i]
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n", run.Name, run.Labels[v1alpha3.PipelineNameLabelKey],
+			stringOrNone(string(run.Status.Phase)), stringOrNone(run.GetTrigger()),
+			duration.HumanDuration(time.Since(run.CreationTimestamp.Time)), getDuration(run))
+	}
+	return writer.Flush()
+}
+
+func (o *runOption) create(cmd *cobra.Command, args []string) (err error) {
+	payload := devops.RunPayload{}
+	for _, param := range o.params {
+		pair := strings.SplitN(param, "=", 2)
+		if len(pair) != 2 || pair[0] == "" {
+			return fmt.Errorf("invalid parameter %q, it should be like NAME=VALUE", param)
+		}
+		payload.Parameters = append(payload.Parameters, devops.Parameter{Name: pair[0], Value: pair[1]})
+	}
+	api := fmt.Sprintf("%s/namespaces/%s/pipelines/%s/pipelineruns", apiPrefix, o.namespace, args[0])
+	if o.branch != "" {
+		api += "?" + url.Values{"branch": {o.branch}}.Encode()
+	}
+
+	run := &v1alpha3.PipelineRun{}
+	if err = o.api.call(cmd.Context(), http.MethodPost, api, payload, run); err != nil {
+		return
+	}
+	cmd.Printf("pipelinerun/%s created\n", run.Name)
+	if o.follow {
+		err = o.tailLogs(cmd.Context(), cmd.OutOrStdout(), run.Name)
+	}
+	return
+}
+
+func (o *runOption) cancel(cmd *cobra.Command, args []string) (err error) {
+	ctx := cmd.Context()
+	run := &v1alpha3.PipelineRun{}
+	if err = o.client.Get(ctx, client.ObjectKey{Namespace: o.namespace, Name: args[0]}, run); err != nil {
+		return
+	}
+	if run.HasCompleted() {
+		return fmt.Errorf("pipelinerun/%s has completed with the phase %s", run.Name, run.Status.Phase)
+	}
+	patch := client.MergeFrom(run.DeepCopy())
+	action := v1alpha3.Stop
+	run.Spec.Action = &action
+	if err = o.client.Patch(ctx, run, patch); err == nil {
+		cmd.Printf("pipelinerun/%s cancelled\n", run.Name)
+	}
+	return
+}
+
+func (o *runOption) logs(cmd *cobra.Command, args []string) error {
+	return o.tailLogs(cmd.Context(), cmd.OutOrStdout(), args[0])
+}
+
+// tailLogs prints the logs of a PipelineRun. The logs of the Jenkins builds are polled from the progressive
+// log API of Jenkins, the others are streamed by the DevOps apiserver.
+func (o *runOption) tailLogs(ctx context.Context, writer io.Writer, name string) (err error) {
+	run, err := o.getPipelineRun(ctx, name)
+	if err != nil {
+		return
+	}
+	runID, isJenkins := run.Annotations[v1alpha3.JenkinsPipelineRunIDAnnoKey]
+	if backendType, _ := backend.TypeOf(run); !isJenkins && o.follow && !run.HasCompleted() && backendType != backend.Tekton {
+		// the Jenkins build has not been created yet
+		for !isJenkins && !run.HasCompleted() {
+			if err = sleep(ctx, logPollInterval); err != nil {
+				return
+			}
+			if run, err = o.getPipelineRun(ctx, name); err != nil {
+				return
+			}
+			runID, isJenkins = run.Annotations[v1alpha3.JenkinsPipelineRunIDAnnoKey]
+		}
+	}
+	if !isJenkins {
+		var resp *http.Response
+		api := fmt.Sprintf("%s/namespaces/%s/pipelineruns/%s/log?follow=%t", apiPrefix, o.namespace, name, o.follow)
+		if resp, err = o.api.do(ctx, http.MethodGet, api, nil); err != nil {
+			return
+		}
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+		_, err = io.Copy(writer, resp.Body)
+		return
+	}
+
+	api := fmt.Sprintf("%s/devops/%s/pipelines/%s", jenkinsAPIPrefix, o.namespace, run.Labels[v1alpha3.PipelineNameLabelKey])
+	if branch := run.GetRefName(); branch != "" {
+		api += "/branches/" + url.PathEscape(branch)
+	}
+	api += "/runs/" + runID + "/log"
+	for start := int64(0); ; {
+		var resp *http.Response
+		if resp, err = o.api.do(ctx, http.MethodGet, fmt.Sprintf("%s?start=%d", api, start), nil); err != nil {
+			return
+		}
+		_, err = io.Copy(writer, resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return
+		}
+		if size, parseErr := strconv.ParseInt(resp.Header.Get("X-Text-Size"), 10, 64); parseErr == nil {
+			start = size
+		}
+		if !o.follow || resp.Header.Get("X-More-Data") != "true" {
+			return
+		}
+		if err = sleep(ctx, logPollInterval); err != nil {
+			return
+		}
+	}
+}
+
+func (o *runOption) listArtifacts(cmd *cobra.Command, args []string) (err error) {
+	var artifacts []v1alpha3.PipelineRunArtifact
+	api := fmt.Sprintf("%s/namespaces/%s/pipelineruns/%s/archived-artifacts", apiPrefix, o.namespace, args[0])
+	if err = o.api.get(cmd.Context(), api, &artifacts); err != nil {
+		return
+	}
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "NAME\tPATH\tSIZE")
+	for _, artifact := range artifacts {
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%d\n", artifact.Name, artifact.Path, artifact.Size)
+	}
+	return writer.Flush()
+}
+
+func (o *runOption) download(cmd *cobra.Command, args []string) (err error) {
+	ctx := cmd.Context()
+	download := &pipelinerun.ArtifactDownload{}
+	api := fmt.Sprintf("%s/namespaces/%s/pipelineruns/%s/archived-artifacts/%s", apiPrefix, o.namespace, args[0], url.PathEscape(args[1]))
+	if err = o.api.get(ctx, api, download); err != nil {
+		return
+	}
+
+	// the presigned URL points to the object storage, so it's accessed without the credential of kubeconfig
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctx, http.MethodGet, download.URL, nil); err != nil {
+		return
+	}
+	var resp *http.Response
+	if resp, err = http.DefaultClient.Do(req); err != nil {
+		return
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download the artifact %s, status %d", args[1], resp.StatusCode)
+	}
+
+	output := o.output
+	if output == "" {
+		output = download.Name
+	}
+	var file *os.File
+	if file, err = os.Create(output); err != nil {
+		return
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	var size int64
+	if size, err = io.Copy(file, resp.Body); err == nil {
+		cmd.Printf("%s saved, %d bytes\n", output, size)
+	}
+	return
+}
+
+func (o *runOption) getPipelineRun(ctx context.Context, name string) (run *v1alpha3.PipelineRun, err error) {
+	run = &v1alpha3.PipelineRun{}
+	err = o.api.get(ctx, fmt.Sprintf("%s/namespaces/%s/pipelineruns/%s", apiPrefix, o.namespace, name), run)
+	return
+}
+
+func getDuration(run *v1alpha3.PipelineRun) string {
+	if run.Status.StartTime == nil {
+		return "<none>"
+	}
+	end := time.Now()
+	if run.Status.CompletionTime != nil {
+		end = run.Status.CompletionTime.Time
+	}
+	return duration.HumanDuration(end.Sub(run.Status.StartTime.Time))
+}
+
+func stringOrNone(value string) string {
+	if value == "" {
+		return "<none>"
+	}
+	return value
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jenkins-zh/jenkins-client/pkg/job"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/models/pipelinerun"
+)
+
+func TestRunCmd(t *testing.T) {
+	logPollInterval = time.Millisecond
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	startTime := metav1.NewTime(time.Now().Add(-time.Minute))
+	completionTime := metav1.NewTime(startTime.Add(30 * time.Second))
+	runs := map[string]*v1alpha3.PipelineRun{
+		"build-1": {
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "build-1", CreationTimestamp: startTime, Labels: map[string]string{
+				v1alpha3.PipelineNameLabelKey: "build",
+			}, Annotations: map[string]string{v1alpha3.JenkinsPipelineRunIDAnnoKey: "1"}},
+			Status: v1alpha3.PipelineRunStatus{Phase: v1alpha3.Succeeded, StartTime: &startTime, CompletionTime: &completionTime},
+		},
+		"build-2": {
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "build-2", CreationTimestamp: startTime, Labels: map[string]string{
+				v1alpha3.PipelineNameLabelKey: "build",
+			}, Annotations: map[string]string{v1alpha3.JenkinsPipelineRunIDAnnoKey: "2"}},
+			Status: v1alpha3.PipelineRunStatus{Phase: v1alpha3.Running, StartTime: &startTime},
+		},
+	}
+
+	var createPayload string
+	logRequests := 0
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON := func(obj interface{}) {
+			_ = json.NewEncoder(w).Encode(obj)
+		}
+		switch path := strings.TrimPrefix(r.URL.Path, apiPrefix); {
+		case path == "/namespaces/ns/pipelineruns" && r.Method == http.MethodGet:
+			assert.Equal(t, "build", r.URL.Query().Get("pipeline"))
+			writeJSON(map[string]interface{}{"items": []interface{}{runs["build-2"], runs["build-1"]}, "totalItems": 2})
+		case path == "/namespaces/ns/pipelines/build/pipelineruns" && r.Method == http.MethodPost:
+			data, _ := io.ReadAll(r.Body)
+			createPayload = string(data)
+			w.WriteHeader(http.StatusCreated)
+			writeJSON(runs["build-2"])
+		case strings.HasPrefix(path, "/namespaces/ns/pipelineruns/build-") && strings.Count(path, "/") == 4:
+			if run, ok := runs[strings.TrimPrefix(path, "/namespaces/ns/pipelineruns/")]; ok {
+				writeJSON(run)
+			} else {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case r.URL.Path == jenkinsAPIPrefix+"/devops/ns/pipelines/build/runs/2/log":
+			logRequests++
+			start := r.URL.Query().Get("start")
+			if start == "0" {
+				w.Header().Set("X-Text-Size", "6")
+				w.Header().Set("X-More-Data", "true")
+				_, _ = w.Write([]byte("line1\n"))
+			} else {
+				assert.Equal(t, "6", start)
+				w.Header().Set("X-Text-Size", "12")
+				_, _ = w.Write([]byte("line2\n"))
+			}
+		case path == "/namespaces/ns/pipelineruns/build-1/nodedetails":
+			writeJSON([]pipelinerun.NodeDetail{
+				{Node: job.Node{ID: "1", DisplayName: "checkout", Result: "SUCCESS", DurationInMillis: 3000, Edges: []job.Edge{{ID: "2"}, {ID: "3"}}}},
+				{Node: job.Node{ID: "2", DisplayName: "unit", Result: "SUCCESS", Edges: []job.Edge{{ID: "4"}}}},
+				{Node: job.Node{ID: "3", DisplayName: "lint", Result: "FAILURE", Edges: []job.Edge{{ID: "4"}}}},
+				{Node: job.Node{ID: "4", DisplayName: "deploy"}},
+			})
+		case path == "/namespaces/ns/pipelineruns/build-1/archived-artifacts":
+			writeJSON([]v1alpha3.PipelineRunArtifact{{Name: "app.jar", Path: "target/app.jar", Size: 7}})
+		case path == "/namespaces/ns/pipelineruns/build-1/archived-artifacts/app.jar":
+			writeJSON(map[string]string{"name": "app.jar", "url": server.URL + "/s3/app.jar"})
+		case path == "/s3/app.jar" || r.URL.Path == "/s3/app.jar":
+			_, _ = w.Write([]byte("content"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("not found"))
+		}
+	}))
+	defer server.Close()
+
+	output := filepath.Join(t.TempDir(), "app.jar")
+	tests := []struct {
+		name   string
+		args   []string
+		verify func(t *testing.T, out string, err error, c client.Client)
+	}{{
+		name: "list",
+		args: []string{"list", "--pipeline", "build"},
+		verify: func(t *testing.T, out string, err error, _ client.Client) {
+			assert.Nil(t, err)
+			lines := strings.Split(strings.TrimSpace(out), "\n")
+			if assert.Len(t, lines, 3) {
+				assert.Regexp(t, `^NAME\s+PIPELINE\s+PHASE`, lines[0])
+				assert.Regexp(t, `^build-2\s+build\s+Running\s+unknown\s+60s\s+\S+$`, lines[1])
+				assert.Regexp(t, `^build-1\s+build\s+Succeeded\s+unknown\s+60s\s+30s$`, lines[2])
+			}
+		},
+	}, {
+		name: "create and follow",
+		args: []string{"create", "build", "-p", "VERSION=v1=rc", "-f"},
+		verify: func(t *testing.T, out string, err error, _ client.Client) {
+			assert.Nil(t, err)
+			assert.JSONEq(t, `{"parameters":[{"name":"VERSION","value":"v1=rc"}]}`, createPayload)
+			assert.Equal(t, "pipelinerun/build-2 created\nline1\nline2\n", out)
+			assert.Equal(t, 2, logRequests)
+		},
+	}, {
+		name: "invalid parameter",
+		args: []string{"create", "build", "-p", "VERSION"},
+		verify: func(t *testing.T, _ string, err error, _ client.Client) {
+			assert.Error(t, err)
+		},
+	}, {
+		name: "cancel",
+		args: []string{"cancel", "build-2"},
+		verify: func(t *testing.T, out string, err error, c client.Client) {
+			assert.Nil(t, err)
+			assert.Equal(t, "pipelinerun/build-2 cancelled\n", out)
+			run := &v1alpha3.PipelineRun{}
+			assert.Nil(t, c.Get(context.Background(), client.ObjectKey{Namespace: "ns", Name: "build-2"}, run))
+			if assert.NotNil(t, run.Spec.Action) {
+				assert.Equal(t, v1alpha3.Stop, *run.Spec.Action)
+			}
+		},
+	}, {
+		name: "cancel a completed run",
+		args: []string{"cancel", "build-1"},
+		verify: func(t *testing.T, _ string, err error, _ client.Client) {
+			assert.Error(t, err)
+		},
+	}, {
+		name: "logs not found",
+		args: []string{"logs", "build-3"},
+		verify: func(t *testing.T, _ string, err error, _ client.Client) {
+			assert.Error(t, err)
+		},
+	}, {
+		name: "graph",
+		args: []string{"graph", "build-1"},
+		verify: func(t *testing.T, out string, err error, _ client.Client) {
+			assert.Nil(t, err)
+			assert.Equal(t, "[✔ checkout 3s] ──▶ [✔ unit] ──▶ [○ deploy]\n"+
+				"                    [✘ lint]\n", out)
+		},
+	}, {
+		name: "artifacts",
+		args: []string{"artifacts", "build-1"},
+		verify: func(t *testing.T, out string, err error, _ client.Client) {
+			assert.Nil(t, err)
+			assert.Regexp(t, `app.jar\s+target/app.jar\s+7`, out)
+		},
+	}, {
+		name: "download",
+		args: []string{"download", "build-1", "app.jar", "-o", output},
+		verify: func(t *testing.T, out string, err error, _ client.Client) {
+			assert.Nil(t, err)
+			data, _ := os.ReadFile(output)
+			assert.Equal(t, "content", string(data))
+			assert.Contains(t, out, "7 bytes")
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).
+				WithObjects(runs["build-1"].DeepCopy(), runs["build-2"].DeepCopy()).Build()
+			root := &rootOption{
+				namespace: "ns",
+				api:       &apiClient{baseURL: server.URL, client: server.Client()},
+				client:    c,
+			}
+			cmd := newRunCmd(root)
+			buf := &bytes.Buffer{}
+			cmd.SetOut(buf)
+			cmd.SetErr(io.Discard)
+			cmd.SetArgs(tt.args)
+			err := cmd.ExecuteContext(context.Background())
+			tt.verify(t, buf.String(), err, c)
+		})
+	}
+}
+
+func TestNewCmd(t *testing.T) {
+	cmd := NewCmd()
+	for _, name := range []string{"kubeconfig", "namespace", "context", "server-url", "service-name"} {
+		assert.NotNil(t, cmd.PersistentFlags().Lookup(name), name)
+	}
+	runCmd, _, err := cmd.Find([]string{"run", "trigger"})
+	assert.Nil(t, err)
+	assert.Equal(t, "create", runCmd.Name())
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package artifact
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/backend"
+	"kubesphere.io/devops/pkg/client/s3"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// ArtifactArchived is the event reason of archiving an artifact successfully
+	ArtifactArchived = "ArtifactArchived"
+	// FailedArtifactArchive is the event reason of failing to archive an artifact
+	FailedArtifactArchive = "FailedArtifactArchive"
+)
+
+// Reconciler archives the declared artifacts of the completed PipelineRuns into S3
+type Reconciler struct {
+	client.Client
+	S3Client s3.Interface
+	// Backends provides the artifacts of PipelineRuns, the PipelineRuns of other backends will be skipped
+	Backends map[backend.Type]backend.Interface
+	Router   *backend.Router
+
+	log      logr.Logger
+	recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns,verbs=get;list;watch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns/status,verbs=get;update;patch
+
+// Reconcile uploads the missing artifacts of a completed PipelineRun, then records them into its status
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	pr := &v1alpha3.PipelineRun{}
+	if err = r.Get(ctx, req.NamespacedName, pr); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	outputs := getMissingOutputs(pr)
+	if len(outputs) == 0 {
+		return
+	}
+
+	var backendType backend.Type
+	if backendType, err = r.Router.BackendOfObject(ctx, pr); err != nil {
+		return
+	}
+	artifactBackend, ok := r.Backends[backendType]
+	if !ok {
+		r.log.V(6).Info(fmt.Sprintf("skip %s due to no artifact provider for backend %s", req.NamespacedName, backendType))
+		return
+	}
+
+	var artifacts []v1alpha3.PipelineRunArtifact
+	for _, output := range outputs {
+		var artifact *v1alpha3.PipelineRunArtifact
+		if artifact, err = r.archive(ctx, artifactBackend, pr, output); err != nil {
+			r.recorder.Eventf(pr, v1.EventTypeWarning, FailedArtifactArchive,
+				"failed to archive artifact %s, error: %v", output.Name, err)
+			// the artifact might not be produced by a failed PipelineRun, don't retry it
+			err = nil
+			continue
+		}
+		artifacts = append(artifacts, *artifact)
+	}
+	if len(artifacts) == 0 {
+		return
+	}
+
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() (err error) {
+		latest := &v1alpha3.PipelineRun{}
+		if err = r.Get(ctx, req.NamespacedName, latest); err != nil {
+			return
+		}
+		for _, artifact := range artifacts {
+			if latest.Status.GetArtifact(artifact.Name) == nil {
+				latest.Status.Artifacts = append(latest.Status.Artifacts, artifact)
+			}
+		}
+		return r.Status().Update(ctx, latest)
+	})
+	if err == nil {
+		for _, artifact := range artifacts {
+			r.recorder.Eventf(pr, v1.EventTypeNormal, ArtifactArchived, "artifact %s was archived into %s", artifact.Name, artifact.Key)
+		}
+	}
+	return
+}
+
+func (r *Reconciler) archive(ctx context.Context, artifactBackend backend.Interface, pr *v1alpha3.PipelineRun,
+	output v1alpha3.ArtifactOutput) (artifact *v1alpha3.PipelineRunArtifact, err error) {
+	var reader io.ReadCloser
+	if reader, err = artifactBackend.GetArtifact(ctx, pr, output.Path); err != nil {
+		return
+	}
+	if reader == nil {
+		err = fmt.Errorf("artifact %s was not found", output.Path)
+		return
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	key := GetArtifactKey(pr, output.Name)
+	counter := &countingReader{reader: reader}
+	if err = r.S3Client.Upload(key, path.Base(output.Path), counter); err != nil {
+		return
+	}
+	now := metav1.Now()
+	artifact = &v1alpha3.PipelineRunArtifact{
+		Name:        output.Name,
+		Path:        output.Path,
+		Key:         key,
+		Size:        counter.size,
+		ArchiveTime: &now,
+	}
+	return
+}
+
+// GetArtifactKey returns the object key of an archived artifact
+func GetArtifactKey(pr *v1alpha3.PipelineRun, name string) string {
+	return fmt.Sprintf("pipelinerun-artifacts/%s/%s/%s/%s", pr.Namespace, pr.Name, pr.UID, name)
+}
+
+// getMissingOutputs returns the declared artifact outputs which have not been archived
+func getMissingOutputs(pr *v1alpha3.PipelineRun) (outputs []v1alpha3.ArtifactOutput) {
+	if !pr.HasCompleted() || !pr.DeletionTimestamp.IsZero() || pr.Spec.PipelineSpec == nil {
+		return
+	}
+	for _, output := range pr.Spec.PipelineSpec.GetArtifactOutputs() {
+		if pr.Status.GetArtifact(output.Name) == nil {
+			outputs = append(outputs, output)
+		}
+	}
+	return
+}
+
+type countingReader struct {
+	reader io.Reader
+	size   int64
+}
+
+func (c *countingReader) Read(p []byte) (n int, err error) {
+	n, err = c.reader.Read(p)
+	c.size += int64(n)
+	return
+}
+
+var artifactPredicate = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool {
+		pr, ok := e.Object.(*v1alpha3.PipelineRun)
+		return ok && len(getMissingOutputs(pr)) > 0
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldPr, okOld := e.ObjectOld.(*v1alpha3.PipelineRun)
+		newPr, okNew := e.ObjectNew.(*v1alpha3.PipelineRun)
+		// only handle the completion, the failed artifacts will not be retried by other updates
+		return okOld && okNew && !oldPr.HasCompleted() && len(getMissingOutputs(newPr)) > 0
+	},
+	DeleteFunc: func(e event.DeleteEvent) bool {
+		return false
+	},
+}
+
+// GetName returns the name of this reconciler
+func (r *Reconciler) GetName() string {
+	return "pipelinerun-artifact-controller"
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	r.log = ctrl.Log.WithName(r.GetName())
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha3.PipelineRun{}).
+		WithEventFilter(artifactPredicate).
+		Complete(r)
+}
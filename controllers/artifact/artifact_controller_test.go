@@ -0,0 +1,134 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package artifact
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/backend"
+	fakes3 "kubesphere.io/devops/pkg/client/s3/fake"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+type fakeBackend struct {
+	backend.Interface
+	artifacts map[string]string
+}
+
+func (b *fakeBackend) GetArtifact(ctx context.Context, pipelineRun *v1alpha3.PipelineRun, path string) (io.ReadCloser, error) {
+	if content, ok := b.artifacts[path]; ok {
+		return ioutil.NopCloser(strings.NewReader(content)), nil
+	}
+	return nil, errors.New("not found")
+}
+
+func newPipelineRun(completed bool, outputs []v1alpha3.ArtifactOutput, archived []v1alpha3.PipelineRunArtifact) *v1alpha3.PipelineRun {
+	pr := &v1alpha3.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "run", UID: "uid"},
+		Spec: v1alpha3.PipelineRunSpec{
+			PipelineSpec: &v1alpha3.PipelineSpec{ArtifactOutputs: outputs},
+		},
+		Status: v1alpha3.PipelineRunStatus{Artifacts: archived},
+	}
+	if completed {
+		now := metav1.Now()
+		pr.Status.CompletionTime = &now
+	}
+	return pr
+}
+
+func TestReconciler(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	outputs := []v1alpha3.ArtifactOutput{{Name: "jar", Path: "target/app.jar"}, {Name: "report", Path: "report.html"}}
+	tests := []struct {
+		name          string
+		pipelineRun   *v1alpha3.PipelineRun
+		wantArtifacts []string
+		wantObjects   []string
+	}{{
+		name:        "not completed",
+		pipelineRun: newPipelineRun(false, outputs, nil),
+	}, {
+		name:        "no artifact outputs",
+		pipelineRun: newPipelineRun(true, nil, nil),
+	}, {
+		name:          "archive the produced artifacts",
+		pipelineRun:   newPipelineRun(true, outputs, nil),
+		wantArtifacts: []string{"jar"},
+		wantObjects:   []string{"pipelinerun-artifacts/ns/run/uid/jar"},
+	}, {
+		name: "archived already",
+		pipelineRun: newPipelineRun(true, outputs[:1], []v1alpha3.PipelineRunArtifact{{
+			Name: "jar", Path: "target/app.jar", Key: "key",
+		}}),
+		wantArtifacts: []string{"jar"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s3Client := fakes3.NewFakeS3()
+			r := &Reconciler{
+				Client:   fake.NewClientBuilder().WithScheme(schema).WithObjects(tt.pipelineRun).Build(),
+				S3Client: s3Client,
+				Backends: map[backend.Type]backend.Interface{
+					backend.Jenkins: &fakeBackend{artifacts: map[string]string{"target/app.jar": "jar"}},
+				},
+				log:      logr.New(log.NullLogSink{}),
+				recorder: record.NewFakeRecorder(10),
+			}
+			key := types.NamespacedName{Namespace: "ns", Name: "run"}
+			_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+			assert.Nil(t, err)
+
+			pr := &v1alpha3.PipelineRun{}
+			assert.Nil(t, r.Get(context.Background(), key, pr))
+			var artifacts []string
+			for _, artifact := range pr.Status.Artifacts {
+				artifacts = append(artifacts, artifact.Name)
+			}
+			assert.Equal(t, tt.wantArtifacts, artifacts)
+
+			var objects []string
+			for objectKey := range s3Client.Storage {
+				objects = append(objects, objectKey)
+			}
+			assert.Equal(t, tt.wantObjects, objects)
+		})
+	}
+}
+
+func TestCountingReader(t *testing.T) {
+	reader := &countingReader{reader: strings.NewReader("content")}
+	data, err := ioutil.ReadAll(reader)
+	assert.Nil(t, err)
+	assert.Equal(t, "content", string(data))
+	assert.Equal(t, int64(7), reader.size)
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package artifactrepository
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/artifactrepository"
+	cascutil "kubesphere.io/devops/pkg/utils/casc"
+	"kubesphere.io/devops/pkg/utils/k8sutil"
+	"kubesphere.io/devops/pkg/utils/stringutils"
+)
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=artifactrepositories,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=artifactrepositories/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=artifactrepositories/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;update
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconciler checks the ArtifactRepositories, and pushes the Maven settings of the maven repositories
+// into the Jenkins CasC, so that the Jenkins Pipelines are able to use them via configFileProvider.
+type Reconciler struct {
+	client.Client
+
+	TargetConfigMapName      string
+	TargetConfigMapNamespace string
+	TargetConfigMapKey       string
+	// Interval is the interval of checking the repositories
+	Interval time.Duration
+
+	// HTTPClient accesses the repositories, the proxy of each repository takes effect if it's nil
+	HTTPClient *http.Client
+
+	recorder record.EventRecorder
+	now      func() time.Time
+}
+
+// Reconcile checks an ArtifactRepository and syncs its Maven settings to Jenkins
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	repo := &v1alpha3.ArtifactRepository{}
+	if err = r.Get(ctx, req.NamespacedName, repo); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+
+	if !repo.DeletionTimestamp.IsZero() {
+		if err = r.syncMavenSettings(ctx, repo, false); err == nil {
+			k8sutil.RemoveFinalizer(&repo.ObjectMeta, v1alpha3.ArtifactRepositoryFinalizerName)
+			err = r.Update(ctx, repo)
+		}
+		return
+	}
+	if k8sutil.AddFinalizer(&repo.ObjectMeta, v1alpha3.ArtifactRepositoryFinalizerName) {
+		if err = r.Update(ctx, repo); err != nil {
+			return
+		}
+	}
+
+	if err = r.syncMavenSettings(ctx, repo, repo.Spec.GetFormat() == v1alpha3.ArtifactFormatMaven); err != nil {
+		r.recorder.Eventf(repo, v1.EventTypeWarning, "SyncSettingsFailed", "Failed to sync the Maven settings to Jenkins, error was %v", err)
+		return
+	}
+
+	status := repo.Status.DeepCopy()
+	status.URL = repo.Spec.GetRepositoryURL()
+	status.LastCheckTime = &metav1.Time{Time: r.getNow()}
+	if checkErr := r.check(ctx, repo); checkErr != nil {
+		if status.Phase != v1alpha3.ArtifactRepositoryFailed {
+			r.recorder.Eventf(repo, v1.EventTypeWarning, "CheckFailed", "Failed to access the repository, error was %v", checkErr)
+		}
+		status.Phase = v1alpha3.ArtifactRepositoryFailed
+		status.Message = checkErr.Error()
+	} else {
+		status.Phase = v1alpha3.ArtifactRepositoryReady
+		status.Message = ""
+	}
+
+	if !equality.Semantic.DeepEqual(status, &repo.Status) {
+		repo.Status = *status
+		err = r.Status().Update(ctx, repo)
+	}
+	if err == nil {
+		result.RequeueAfter = r.Interval
+	}
+	return
+}
+
+// check makes sure the repository is accessible with its credential
+func (r *Reconciler) check(ctx context.Context, repo *v1alpha3.ArtifactRepository) (err error) {
+	var credential *artifactrepository.Credential
+	if repo.Spec.Secret != "" {
+		secret := &v1.Secret{}
+		if err = r.Get(ctx, client.ObjectKey{Namespace: repo.Namespace, Name: repo.Spec.Secret}, secret); err != nil {
+			return
+		}
+		if credential = artifactrepository.GetCredential(secret); credential == nil {
+			return fmt.Errorf("the type of the secret %s is not %s", repo.Spec.Secret, v1alpha3.SecretTypeBasicAuth)
+		}
+	}
+
+	var repoClient artifactrepository.Interface
+	if repoClient, err = artifactrepository.NewClient(&repo.Spec, credential, r.HTTPClient); err == nil {
+		err = repoClient.Check(ctx)
+	}
+	return
+}
+
+// syncMavenSettings adds, updates or removes the Maven settings of the repository in the Jenkins CasC
+func (r *Reconciler) syncMavenSettings(ctx context.Context, repo *v1alpha3.ArtifactRepository, present bool) (err error) {
+	cm := &v1.ConfigMap{}
+	if err = r.Get(ctx, client.ObjectKey{Namespace: r.TargetConfigMapNamespace, Name: r.TargetConfigMapName}, cm); err != nil {
+		// we will handle it only when the cm exists
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	data := strings.TrimSpace(cm.Data[r.TargetConfigMapKey])
+	if data == "" {
+		return
+	}
+
+	var settings map[string]interface{}
+	if present {
+		var content string
+		if content, err = artifactrepository.GenerateMavenSettings(repo); err != nil {
+			return
+		}
+		settings = map[string]interface{}{
+			"id":           repo.GetSettingsID(),
+			"name":         repo.Namespace + "/" + repo.Name,
+			"comment":      "Managed by ks-devops",
+			"content":      content,
+			"isReplaceAll": true,
+		}
+	}
+
+	var casc map[string]interface{}
+	if err = yaml.Unmarshal([]byte(data), &casc); err != nil {
+		return
+	}
+	if !cascutil.SetGlobalConfigFile(casc, "mavenSettings", repo.GetSettingsID(), settings) {
+		return
+	}
+
+	var newData []byte
+	if newData, err = yaml.Marshal(casc); err == nil {
+		cm.Data[r.TargetConfigMapKey] = string(newData)
+		err = r.Update(ctx, cm)
+	}
+	return
+}
+
+func (r *Reconciler) getNow() time.Time {
+	if r.now != nil {
+		return r.now()
+	}
+	return time.Now()
+}
+
+// GetName returns the name of this reconciler
+func (r *Reconciler) GetName() string {
+	return "artifactrepository-controller"
+}
+
+// GetGroupName returns the group name of this reconciler
+func (r *Reconciler) GetGroupName() string {
+	return "artifactrepository"
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	r.TargetConfigMapName = stringutils.SetOrDefault(r.TargetConfigMapName, "jenkins-casc-config")
+	r.TargetConfigMapNamespace = stringutils.SetOrDefault(r.TargetConfigMapNamespace, "kubesphere-devops-system")
+	r.TargetConfigMapKey = stringutils.SetOrDefault(r.TargetConfigMapKey, "jenkins_user.yaml")
+	if r.Interval == 0 {
+		r.Interval = 10 * time.Minute
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.GetName()).
+		For(&v1alpha3.ArtifactRepository{}).
+		Complete(r)
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package artifactrepository
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+func TestReconcile(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, scheme.AddToScheme(schema))
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, _ := r.BasicAuth(); username != "admin" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"name":"maven-public"}]`))
+	}))
+	defer server.Close()
+
+	now := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "nexus"},
+		Type:       v1alpha3.SecretTypeBasicAuth,
+		Data: map[string][]byte{
+			v1.BasicAuthUsernameKey: []byte("admin"),
+			v1.BasicAuthPasswordKey: []byte("secret"),
+		},
+	}
+	newRepo := func(format, secret string) *v1alpha3.ArtifactRepository {
+		return &v1alpha3.ArtifactRepository{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "nexus"},
+			Spec: v1alpha3.ArtifactRepositorySpec{
+				Type:       v1alpha3.ArtifactRepositoryNexus,
+				Server:     server.URL,
+				Repository: "maven-public",
+				Format:     format,
+				Secret:     secret,
+			},
+		}
+	}
+	newConfigMap := func(data string) *v1.ConfigMap {
+		return &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kubesphere-devops-system", Name: "jenkins-casc-config"},
+			Data:       map[string]string{"jenkins_user.yaml": data},
+		}
+	}
+	getMavenSettings := func(t *testing.T, c client.Client) []interface{} {
+		cm := &v1.ConfigMap{}
+		assert.Nil(t, c.Get(context.Background(), client.ObjectKey{Namespace: "kubesphere-devops-system", Name: "jenkins-casc-config"}, cm))
+		casc := map[string]interface{}{}
+		assert.Nil(t, yaml.Unmarshal([]byte(cm.Data["jenkins_user.yaml"]), &casc))
+		unclassified, _ := casc["unclassified"].(map[string]interface{})
+		globalConfigFiles, _ := unclassified["globalConfigFiles"].(map[string]interface{})
+		configs, _ := globalConfigFiles["configs"].([]interface{})
+		return configs
+	}
+
+	tests := []struct {
+		name    string
+		repo    *v1alpha3.ArtifactRepository
+		objects []client.Object
+		verify  func(t *testing.T, c client.Client, result ctrl.Result)
+	}{{
+		name:    "not found",
+		repo:    nil,
+		objects: []client.Object{},
+		verify: func(t *testing.T, c client.Client, result ctrl.Result) {
+			assert.Equal(t, ctrl.Result{}, result)
+		},
+	}, {
+		name:    "a ready maven repository",
+		repo:    newRepo(v1alpha3.ArtifactFormatMaven, "nexus"),
+		objects: []client.Object{secret, newConfigMap("jenkins:\n  mode: EXCLUSIVE\n")},
+		verify: func(t *testing.T, c client.Client, result ctrl.Result) {
+			assert.Equal(t, 10*time.Minute, result.RequeueAfter)
+			repo := &v1alpha3.ArtifactRepository{}
+			assert.Nil(t, c.Get(context.Background(), client.ObjectKey{Namespace: "ns", Name: "nexus"}, repo))
+			assert.Equal(t, []string{v1alpha3.ArtifactRepositoryFinalizerName}, repo.Finalizers)
+			assert.Equal(t, v1alpha3.ArtifactRepositoryReady, repo.Status.Phase)
+			assert.Equal(t, server.URL+"/repository/maven-public", repo.Status.URL)
+			assert.Equal(t, now, repo.Status.LastCheckTime.Time.UTC())
+
+			configs := getMavenSettings(t, c)
+			if assert.Len(t, configs, 1) {
+				settings := configs[0].(map[string]interface{})["mavenSettings"].(map[string]interface{})
+				assert.Equal(t, "ns-nexus", settings["id"])
+				assert.Equal(t, true, settings["isReplaceAll"])
+				assert.Contains(t, settings["content"], "<mirrorOf>*</mirrorOf>")
+			}
+		},
+	}, {
+		name: "replace the existing maven settings",
+		repo: newRepo(v1alpha3.ArtifactFormatMaven, "nexus"),
+		objects: []client.Object{secret, newConfigMap(`unclassified:
+  globalConfigFiles:
+    configs:
+    - mavenSettings:
+        id: ns-nexus
+        content: old
+    - custom:
+        id: other
+`)},
+		verify: func(t *testing.T, c client.Client, result ctrl.Result) {
+			configs := getMavenSettings(t, c)
+			if assert.Len(t, configs, 2) {
+				settings := configs[0].(map[string]interface{})["mavenSettings"].(map[string]interface{})
+				assert.NotEqual(t, "old", settings["content"])
+				assert.Contains(t, configs[1], "custom")
+			}
+		},
+	}, {
+		name:    "a raw repository without the credential",
+		repo:    newRepo("", ""),
+		objects: []client.Object{newConfigMap("jenkins:\n  mode: EXCLUSIVE\n")},
+		verify: func(t *testing.T, c client.Client, result ctrl.Result) {
+			repo := &v1alpha3.ArtifactRepository{}
+			assert.Nil(t, c.Get(context.Background(), client.ObjectKey{Namespace: "ns", Name: "nexus"}, repo))
+			assert.Equal(t, v1alpha3.ArtifactRepositoryFailed, repo.Status.Phase)
+			assert.Contains(t, repo.Status.Message, "not authorized")
+			assert.Empty(t, getMavenSettings(t, c))
+		},
+	}, {
+		name: "the secret is not basic auth",
+		repo: newRepo(v1alpha3.ArtifactFormatNPM, "token"),
+		objects: []client.Object{&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "token"},
+			Type:       v1alpha3.SecretTypeSecretText,
+		}},
+		verify: func(t *testing.T, c client.Client, result ctrl.Result) {
+			repo := &v1alpha3.ArtifactRepository{}
+			assert.Nil(t, c.Get(context.Background(), client.ObjectKey{Namespace: "ns", Name: "nexus"}, repo))
+			assert.Equal(t, v1alpha3.ArtifactRepositoryFailed, repo.Status.Phase)
+			assert.Contains(t, repo.Status.Message, "token")
+		},
+	}, {
+		name: "remove the maven settings when deleting",
+		repo: func() *v1alpha3.ArtifactRepository {
+			repo := newRepo(v1alpha3.ArtifactFormatMaven, "nexus")
+			repo.Finalizers = []string{v1alpha3.ArtifactRepositoryFinalizerName}
+			repo.DeletionTimestamp = &metav1.Time{Time: now}
+			return repo
+		}(),
+		objects: []client.Object{secret, newConfigMap(`unclassified:
+  globalConfigFiles:
+    configs:
+    - mavenSettings:
+        id: ns-nexus
+`)},
+		verify: func(t *testing.T, c client.Client, result ctrl.Result) {
+			assert.Empty(t, getMavenSettings(t, c))
+			repo := &v1alpha3.ArtifactRepository{}
+			err := c.Get(context.Background(), client.ObjectKey{Namespace: "ns", Name: "nexus"}, repo)
+			assert.True(t, err != nil || len(repo.Finalizers) == 0)
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := tt.objects
+			if tt.repo != nil {
+				objects = append(objects, tt.repo.DeepCopy())
+			}
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(objects...).Build()
+			r := &Reconciler{
+				Client:                   c,
+				TargetConfigMapName:      "jenkins-casc-config",
+				TargetConfigMapNamespace: "kubesphere-devops-system",
+				TargetConfigMapKey:       "jenkins_user.yaml",
+				Interval:                 10 * time.Minute,
+				HTTPClient:               server.Client(),
+				recorder:                 &record.FakeRecorder{},
+				now:                      func() time.Time { return now },
+			}
+			result, err := r.Reconcile(context.Background(), ctrl.Request{
+				NamespacedName: client.ObjectKey{Namespace: "ns", Name: "nexus"},
+			})
+			assert.Nil(t, err)
+			tt.verify(t, c, result)
+		})
+	}
+}
@@ -0,0 +1,303 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package callback
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/history"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// CallbackDelivered is the event reason of delivering a callback
+	CallbackDelivered = "CallbackDelivered"
+	// FailedDeliverCallback is the event reason of giving up a callback
+	FailedDeliverCallback = "FailedDeliverCallback"
+
+	// SignatureHeader carries the HMAC-SHA256 signature of the payload, in the format of sha256=<hex>
+	SignatureHeader = "X-DevOps-Signature-256"
+	// DeliveryHeader carries the UID of the PipelineRun, the receivers can deduplicate the retried deliveries by it
+	DeliveryHeader = "X-DevOps-Delivery"
+
+	// maxAttempts is the number of attempts before giving up a callback
+	maxAttempts = 5
+	// initialBackoff is the delay of the first retry, it's doubled for each of the next retries
+	initialBackoff = 10 * time.Second
+	// maxCallbackDelay avoids calling back the stale PipelineRuns, e.g. the first time the controller runs
+	maxCallbackDelay = time.Hour
+)
+
+// DeliveryState is the state of a callback delivery
+type DeliveryState string
+
+const (
+	// DeliveryPending means the callback will be retried
+	DeliveryPending DeliveryState = "Pending"
+	// DeliveryDelivered means the callback was accepted by the receiver
+	DeliveryDelivered DeliveryState = "Delivered"
+	// DeliveryFailed means the callback was given up after the retries
+	DeliveryFailed DeliveryState = "Failed"
+	// DeliverySkipped means the PipelineRun completed long before the callback was found
+	DeliverySkipped DeliveryState = "Skipped"
+)
+
+// DeliveryStatus is the delivery status of a callback, the statuses are recorded as JSON in the
+// annotation devops.kubesphere.io/callback-status of the PipelineRun
+type DeliveryStatus struct {
+	URL             string        `json:"url"`
+	State           DeliveryState `json:"state"`
+	Attempts        int           `json:"attempts"`
+	LastAttemptTime *time.Time    `json:"lastAttemptTime,omitempty"`
+	Message         string        `json:"message,omitempty"`
+}
+
+func (s *DeliveryStatus) isFinished() bool {
+	return s.State != DeliveryPending
+}
+
+// nextAttemptTime returns the time of the next retry
+func (s *DeliveryStatus) nextAttemptTime() time.Time {
+	if s.LastAttemptTime == nil || s.Attempts == 0 {
+		return time.Time{}
+	}
+	return s.LastAttemptTime.Add(initialBackoff << (s.Attempts - 1))
+}
+
+// Reconciler calls the callbacks of the Pipelines once their PipelineRuns completed
+type Reconciler struct {
+	client.Client
+	// HTTPClient delivers the callbacks, it's created by NewHTTPClient without any allowed networks if it's nil
+	HTTPClient *http.Client
+
+	log      logr.Logger
+	recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelines,verbs=get;list;watch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns,verbs=get;list;watch;patch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile delivers the pending callbacks of a completed PipelineRun
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	pr := &v1alpha3.PipelineRun{}
+	if err = r.Get(ctx, req.NamespacedName, pr); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	if !needCallback(pr) {
+		return
+	}
+
+	pipelineName := pr.GetPipelineName()
+	if pipelineName == "" {
+		return
+	}
+	pipeline := &v1alpha3.Pipeline{}
+	if err = r.Get(ctx, types.NamespacedName{Namespace: pr.Namespace, Name: pipelineName}, pipeline); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	if len(pipeline.Spec.Callbacks) == 0 {
+		return
+	}
+
+	statuses := GetDeliveryStatuses(pr)
+	stale := len(statuses) == 0 && time.Since(pr.Status.CompletionTime.Time) > maxCallbackDelay
+	var payload []byte
+	if payload, err = json.Marshal(history.NewRunSummary(pr)); err != nil {
+		return
+	}
+
+	now := time.Now()
+	newStatuses := make([]DeliveryStatus, 0, len(pipeline.Spec.Callbacks))
+	for _, callback := range pipeline.Spec.Callbacks {
+		status := findStatus(statuses, callback.URL)
+		switch {
+		case stale:
+			status.State = DeliverySkipped
+		case status.isFinished():
+		case now.Before(status.nextAttemptTime()):
+			result = requeueBefore(result, status.nextAttemptTime().Sub(now))
+		default:
+			r.deliver(ctx, pr, callback, payload, &status)
+			if !status.isFinished() {
+				result = requeueBefore(result, status.nextAttemptTime().Sub(now))
+			}
+		}
+		newStatuses = append(newStatuses, status)
+	}
+
+	var data []byte
+	if data, err = json.Marshal(newStatuses); err != nil {
+		return
+	}
+	patch := client.MergeFrom(pr.DeepCopy())
+	if pr.Annotations == nil {
+		pr.Annotations = map[string]string{}
+	}
+	pr.Annotations[v1alpha3.PipelineRunCallbackStatusAnnoKey] = string(data)
+	err = r.Patch(ctx, pr, patch)
+	return
+}
+
+func (r *Reconciler) deliver(ctx context.Context, pr *v1alpha3.PipelineRun, callback v1alpha3.PipelineCallback,
+	payload []byte, status *DeliveryStatus) {
+	now := time.Now()
+	status.Attempts++
+	status.LastAttemptTime = &now
+
+	err := r.post(ctx, pr, callback, payload)
+	if err == nil {
+		status.State, status.Message = DeliveryDelivered, ""
+		r.recorder.Eventf(pr, v1.EventTypeNormal, CallbackDelivered, "delivered the callback to %s", callback.URL)
+		return
+	}
+
+	status.Message = err.Error()
+	if status.Attempts >= maxAttempts {
+		status.State = DeliveryFailed
+		r.recorder.Eventf(pr, v1.EventTypeWarning, FailedDeliverCallback,
+			"gave up the callback to %s after %d attempts, error: %v", callback.URL, status.Attempts, err)
+	} else {
+		status.State = DeliveryPending
+		r.log.V(4).Info(fmt.Sprintf("failed to deliver the callback to %s, error: %v", callback.URL, err))
+	}
+}
+
+func (r *Reconciler) post(ctx context.Context, pr *v1alpha3.PipelineRun, callback v1alpha3.PipelineCallback,
+	payload []byte) (err error) {
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctx, http.MethodPost, callback.URL, bytes.NewReader(payload)); err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(DeliveryHeader, string(pr.UID))
+
+	if callback.SecretRef != nil {
+		secret := &v1.Secret{}
+		if err = r.Get(ctx, types.NamespacedName{Namespace: pr.Namespace, Name: callback.SecretRef.Name}, secret); err != nil {
+			return
+		}
+		req.Header.Set(SignatureHeader, Sign(secret.Data[callback.SecretRef.Key], payload))
+	}
+
+	httpClient := r.HTTPClient
+	if httpClient == nil {
+		httpClient = NewHTTPClient(nil)
+	}
+	var resp *http.Response
+	if resp, err = httpClient.Do(req); err != nil {
+		return
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		err = fmt.Errorf("unexpected status code from the receiver: %d", resp.StatusCode)
+	}
+	return
+}
+
+// Sign returns the HMAC-SHA256 signature of the payload, the receivers verify the SignatureHeader by it
+func Sign(secret, payload []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// GetDeliveryStatuses returns the delivery statuses of the callbacks of a PipelineRun
+func GetDeliveryStatuses(pr *v1alpha3.PipelineRun) (statuses []DeliveryStatus) {
+	if data, ok := pr.Annotations[v1alpha3.PipelineRunCallbackStatusAnnoKey]; ok {
+		_ = json.Unmarshal([]byte(data), &statuses)
+	}
+	return
+}
+
+func findStatus(statuses []DeliveryStatus, url string) DeliveryStatus {
+	for _, status := range statuses {
+		if status.URL == url {
+			return status
+		}
+	}
+	return DeliveryStatus{URL: url, State: DeliveryPending}
+}
+
+func requeueBefore(result ctrl.Result, after time.Duration) ctrl.Result {
+	if result.RequeueAfter == 0 || after < result.RequeueAfter {
+		result.RequeueAfter = after
+	}
+	return result
+}
+
+// needCallback returns true if some callbacks of the completed PipelineRun might be undelivered
+func needCallback(pr *v1alpha3.PipelineRun) bool {
+	if !pr.DeletionTimestamp.IsZero() || !pr.HasCompleted() {
+		return false
+	}
+	statuses := GetDeliveryStatuses(pr)
+	for i := range statuses {
+		if !statuses[i].isFinished() {
+			return true
+		}
+	}
+	return len(statuses) == 0
+}
+
+var callbackPredicate = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool {
+		pr, ok := e.Object.(*v1alpha3.PipelineRun)
+		return ok && needCallback(pr)
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		pr, ok := e.ObjectNew.(*v1alpha3.PipelineRun)
+		return ok && needCallback(pr)
+	},
+	DeleteFunc: func(e event.DeleteEvent) bool {
+		return false
+	},
+}
+
+// GetName returns the name of this reconciler
+func (r *Reconciler) GetName() string {
+	return "pipelinerun-callback-controller"
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	r.log = ctrl.Log.WithName(r.GetName())
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha3.PipelineRun{}).
+		WithEventFilter(callbackPredicate).
+		Complete(r)
+}
@@ -0,0 +1,264 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package callback
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func newPipelineRun(completionTime time.Time, statuses []DeliveryStatus) *v1alpha3.PipelineRun {
+	completion := metav1.NewTime(completionTime)
+	pr := &v1alpha3.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns",
+			Name:      "build-1",
+			UID:       "uid",
+			Labels:    map[string]string{v1alpha3.PipelineNameLabelKey: "build"},
+		},
+		Status: v1alpha3.PipelineRunStatus{Phase: v1alpha3.Succeeded, CompletionTime: &completion},
+	}
+	if statuses != nil {
+		data, _ := json.Marshal(statuses)
+		pr.Annotations = map[string]string{v1alpha3.PipelineRunCallbackStatusAnnoKey: string(data)}
+	}
+	return pr
+}
+
+// loopback allows the callbacks to the test servers
+var loopback, _ = ParseNetworks([]string{"127.0.0.0/8"})
+
+func TestReconciler(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, scheme.AddToScheme(schema))
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	var requests []*http.Request
+	var bodies [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		requests, bodies = append(requests, req), append(bodies, body)
+		if req.URL.Path == "/failed" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	okURL, failedURL := server.URL+"/ok", server.URL+"/failed"
+	pipeline := &v1alpha3.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "build"},
+		Spec: v1alpha3.PipelineSpec{Callbacks: []v1alpha3.PipelineCallback{{
+			URL: okURL,
+			SecretRef: &v1.SecretKeySelector{
+				LocalObjectReference: v1.LocalObjectReference{Name: "callback"}, Key: "token",
+			},
+		}, {
+			URL: failedURL,
+		}}},
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "callback"},
+		Data:       map[string][]byte{"token": []byte("secret")},
+	}
+
+	now := time.Now()
+	lastAttempt := now.Add(-time.Second)
+	tests := []struct {
+		name         string
+		pipelineRun  *v1alpha3.PipelineRun
+		objects      []runtime.Object
+		wantRequests int
+		wantStates   []DeliveryState
+		wantAttempts []int
+		wantRequeue  bool
+	}{{
+		name:         "deliver the callbacks",
+		pipelineRun:  newPipelineRun(now, nil),
+		objects:      []runtime.Object{pipeline, secret},
+		wantRequests: 2,
+		wantStates:   []DeliveryState{DeliveryDelivered, DeliveryPending},
+		wantAttempts: []int{1, 1},
+		wantRequeue:  true,
+	}, {
+		name: "wait for the backoff",
+		pipelineRun: newPipelineRun(now, []DeliveryStatus{
+			{URL: okURL, State: DeliveryDelivered, Attempts: 1, LastAttemptTime: &lastAttempt},
+			{URL: failedURL, State: DeliveryPending, Attempts: 1, LastAttemptTime: &lastAttempt},
+		}),
+		objects:      []runtime.Object{pipeline, secret},
+		wantStates:   []DeliveryState{DeliveryDelivered, DeliveryPending},
+		wantAttempts: []int{1, 1},
+		wantRequeue:  true,
+	}, {
+		name: "give up after the last attempt",
+		pipelineRun: newPipelineRun(now, []DeliveryStatus{
+			{URL: okURL, State: DeliveryDelivered, Attempts: 1},
+			{URL: failedURL, State: DeliveryPending, Attempts: maxAttempts - 1},
+		}),
+		objects:      []runtime.Object{pipeline, secret},
+		wantRequests: 1,
+		wantStates:   []DeliveryState{DeliveryDelivered, DeliveryFailed},
+		wantAttempts: []int{1, maxAttempts},
+	}, {
+		name:         "skip the stale PipelineRun",
+		pipelineRun:  newPipelineRun(now.Add(-2*time.Hour), nil),
+		objects:      []runtime.Object{pipeline, secret},
+		wantStates:   []DeliveryState{DeliverySkipped, DeliverySkipped},
+		wantAttempts: []int{0, 0},
+	}, {
+		name:        "no callbacks",
+		pipelineRun: newPipelineRun(now, nil),
+		objects:     []runtime.Object{&v1alpha3.Pipeline{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "build"}}},
+	}, {
+		name:        "the Pipeline was deleted",
+		pipelineRun: newPipelineRun(now, nil),
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests, bodies = nil, nil
+			objects := append([]runtime.Object{tt.pipelineRun}, tt.objects...)
+			r := &Reconciler{
+				Client:     fake.NewClientBuilder().WithScheme(schema).WithRuntimeObjects(objects...).Build(),
+				HTTPClient: NewHTTPClient(loopback),
+				log:        logr.New(log.NullLogSink{}),
+				recorder:   record.NewFakeRecorder(10),
+			}
+
+			result, err := r.Reconcile(context.TODO(), ctrl.Request{
+				NamespacedName: types.NamespacedName{Namespace: "ns", Name: "build-1"},
+			})
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantRequeue, result.RequeueAfter > 0)
+			assert.Equal(t, tt.wantRequests, len(requests))
+
+			pr := &v1alpha3.PipelineRun{}
+			assert.Nil(t, r.Get(context.TODO(), types.NamespacedName{Namespace: "ns", Name: "build-1"}, pr))
+			statuses := GetDeliveryStatuses(pr)
+			var states []DeliveryState
+			var attempts []int
+			for _, status := range statuses {
+				states, attempts = append(states, status.State), append(attempts, status.Attempts)
+			}
+			assert.Equal(t, tt.wantStates, states)
+			assert.Equal(t, tt.wantAttempts, attempts)
+		})
+	}
+}
+
+func TestSignature(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, scheme.AddToScheme(schema))
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	var signature, delivery string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		signature, delivery = req.Header.Get(SignatureHeader), req.Header.Get(DeliveryHeader)
+		body, _ = io.ReadAll(req.Body)
+	}))
+	defer server.Close()
+
+	r := &Reconciler{
+		Client: fake.NewClientBuilder().WithScheme(schema).WithRuntimeObjects(&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "callback"},
+			Data:       map[string][]byte{"token": []byte("secret")},
+		}).Build(),
+		HTTPClient: NewHTTPClient(loopback),
+	}
+	err := r.post(context.TODO(), newPipelineRun(time.Now(), nil), v1alpha3.PipelineCallback{
+		URL:       server.URL,
+		SecretRef: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "callback"}, Key: "token"},
+	}, []byte(`{"name":"build-1"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, `{"name":"build-1"}`, string(body))
+	assert.Equal(t, "uid", delivery)
+	assert.Equal(t, Sign([]byte("secret"), body), signature)
+	assert.Equal(t, "sha256=696b986bf04ea14a9d88c751adc443da340d9386d9e375cc83843b4b8933dfab", signature)
+
+	// the secret does not exist
+	err = r.post(context.TODO(), newPipelineRun(time.Now(), nil), v1alpha3.PipelineCallback{
+		URL:       server.URL,
+		SecretRef: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "fake"}, Key: "token"},
+	}, nil)
+	assert.NotNil(t, err)
+
+	// the loopback addresses are not allowed by default
+	signature = ""
+	r.HTTPClient = nil
+	err = r.post(context.TODO(), newPipelineRun(time.Now(), nil), v1alpha3.PipelineCallback{URL: server.URL}, nil)
+	assert.NotNil(t, err)
+	assert.Empty(t, signature)
+}
+
+func TestCheckAddress(t *testing.T) {
+	networks, err := ParseNetworks([]string{"10.96.0.0/12"})
+	assert.Nil(t, err)
+	_, err = ParseNetworks([]string{"10.96.0.0"})
+	assert.NotNil(t, err)
+
+	tests := []struct {
+		address string
+		wantErr bool
+	}{
+		{address: "140.82.112.3:443"},
+		{address: "[2606:50c0:8000::153]:443"},
+		{address: "10.96.0.10:80"},
+		{address: "127.0.0.1:80", wantErr: true},
+		{address: "[::1]:80", wantErr: true},
+		{address: "192.168.1.1:80", wantErr: true},
+		{address: "10.0.0.1:80", wantErr: true},
+		{address: "169.254.169.254:80", wantErr: true},
+		{address: "0.0.0.0:80", wantErr: true},
+		{address: "invalid", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			assert.Equal(t, tt.wantErr, checkAddress(tt.address, networks) != nil)
+		})
+	}
+}
+
+func TestCallbackPredicate(t *testing.T) {
+	running := &v1alpha3.PipelineRun{Status: v1alpha3.PipelineRunStatus{Phase: v1alpha3.Running}}
+	delivered := newPipelineRun(time.Now(), []DeliveryStatus{{URL: "http://fake", State: DeliveryDelivered}})
+	pending := newPipelineRun(time.Now(), []DeliveryStatus{{URL: "http://fake", State: DeliveryPending}})
+
+	assert.False(t, callbackPredicate.Create(event.CreateEvent{Object: running}))
+	assert.True(t, callbackPredicate.Create(event.CreateEvent{Object: newPipelineRun(time.Now(), nil)}))
+	assert.False(t, callbackPredicate.Update(event.UpdateEvent{ObjectNew: delivered}))
+	assert.True(t, callbackPredicate.Update(event.UpdateEvent{ObjectNew: pending}))
+	assert.False(t, callbackPredicate.Delete(event.DeleteEvent{Object: pending}))
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package callback
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// requestTimeout is the timeout of delivering a callback
+const requestTimeout = 30 * time.Second
+
+// NewHTTPClient creates the client of the callbacks. The URLs of the callbacks are set by the users of the Pipelines,
+// so the client refuses to connect to the loopback, private and link-local addresses, such as the cloud metadata
+// service, unless they are in the allowed networks. The addresses are checked after the DNS resolution.
+func NewHTTPClient(allowedNetworks []*net.IPNet) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: requestTimeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			return checkAddress(address, allowedNetworks)
+		},
+	}
+	return &http.Client{
+		Timeout: requestTimeout,
+		// the proxies are not used, otherwise the addresses of the proxies would be checked instead of the receivers
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+	}
+}
+
+// ParseNetworks parses the networks in CIDR notation
+func ParseNetworks(cidrs []string) (networks []*net.IPNet, err error) {
+	for _, cidr := range cidrs {
+		var network *net.IPNet
+		if _, network, err = net.ParseCIDR(cidr); err != nil {
+			return
+		}
+		networks = append(networks, network)
+	}
+	return
+}
+
+func checkAddress(address string, allowedNetworks []*net.IPNet) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("invalid IP address %s", host)
+	}
+	for _, network := range allowedNetworks {
+		if network.Contains(ip) {
+			return nil
+		}
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsUnspecified() || ip.IsMulticast() {
+		return fmt.Errorf("the callbacks to %s are not allowed", ip)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudevents
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/cloudevents"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+type fakeEmitter struct {
+	events []cloudevents.Event
+	err    error
+}
+
+func (e *fakeEmitter) Emit(ctx context.Context, event cloudevents.Event) error {
+	if e.err != nil {
+		return e.err
+	}
+	e.events = append(e.events, event)
+	return nil
+}
+
+func TestPipelineReconciler(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	newPipeline := func(generation int64, emitted string, created time.Time) *v1alpha3.Pipeline {
+		pipeline := &v1alpha3.Pipeline{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:         "ns",
+				Name:              "build",
+				Generation:        generation,
+				CreationTimestamp: metav1.NewTime(created),
+			},
+		}
+		if emitted != "" {
+			pipeline.Annotations = map[string]string{EmittedGenerationAnnoKey: emitted}
+		}
+		return pipeline
+	}
+
+	now := time.Now()
+	tests := []struct {
+		name        string
+		pipeline    *v1alpha3.Pipeline
+		emitErr     error
+		wantErr     bool
+		wantType    string
+		wantEmitted string
+	}{{
+		name:        "created",
+		pipeline:    newPipeline(1, "", now),
+		wantType:    PipelineCreatedEventType,
+		wantEmitted: "1",
+	}, {
+		name:        "updated",
+		pipeline:    newPipeline(3, "2", now.Add(-2*time.Hour)),
+		wantType:    PipelineUpdatedEventType,
+		wantEmitted: "3",
+	}, {
+		name:        "emitted",
+		pipeline:    newPipeline(3, "3", now),
+		wantEmitted: "3",
+	}, {
+		name:        "stale",
+		pipeline:    newPipeline(2, "", now.Add(-2*time.Hour)),
+		wantEmitted: "2",
+	}, {
+		name:     "failed to emit",
+		pipeline: newPipeline(1, "", now),
+		emitErr:  errors.New("unavailable"),
+		wantErr:  true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithRuntimeObjects(tt.pipeline.DeepCopy()).Build()
+			emitter := &fakeEmitter{err: tt.emitErr}
+			r := &PipelineReconciler{Client: c, Emitter: emitter, log: logr.New(log.NullLogSink{})}
+
+			key := types.NamespacedName{Namespace: "ns", Name: "build"}
+			_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+			assert.Equal(t, tt.wantErr, err != nil, err)
+			if tt.wantType == "" {
+				assert.Empty(t, emitter.events)
+			} else if assert.Equal(t, 1, len(emitter.events)) {
+				assert.Equal(t, tt.wantType, emitter.events[0].Type)
+				assert.Equal(t, "namespaces/ns/pipelines/build", emitter.events[0].Subject)
+				data := emitter.events[0].Data.(*v1alpha3.Pipeline)
+				assert.Empty(t, data.Annotations[EmittedGenerationAnnoKey])
+			}
+
+			pipeline := &v1alpha3.Pipeline{}
+			assert.Nil(t, c.Get(context.TODO(), key, pipeline))
+			assert.Equal(t, tt.wantEmitted, pipeline.Annotations[EmittedGenerationAnnoKey])
+		})
+	}
+}
+
+func TestPipelineRunReconciler(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	newPipelineRun := func(phase v1alpha3.RunPhase, emitted string, updated time.Time) *v1alpha3.PipelineRun {
+		updateTime := metav1.NewTime(updated)
+		pr := &v1alpha3.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "build-1"},
+			Status:     v1alpha3.PipelineRunStatus{Phase: phase, UpdateTime: &updateTime},
+		}
+		if emitted != "" {
+			pr.Annotations = map[string]string{EmittedPhaseAnnoKey: emitted}
+		}
+		return pr
+	}
+
+	now := time.Now()
+	tests := []struct {
+		name        string
+		pipelineRun *v1alpha3.PipelineRun
+		wantType    string
+		wantEmitted string
+	}{{
+		name:        "no phase",
+		pipelineRun: newPipelineRun("", "", now),
+	}, {
+		name:        "running",
+		pipelineRun: newPipelineRun(v1alpha3.Running, "Pending", now),
+		wantType:    "io.kubesphere.devops.pipelinerun.running",
+		wantEmitted: "Running",
+	}, {
+		name:        "succeeded",
+		pipelineRun: newPipelineRun(v1alpha3.Succeeded, "Running", now),
+		wantType:    "io.kubesphere.devops.pipelinerun.succeeded",
+		wantEmitted: "Succeeded",
+	}, {
+		name:        "emitted",
+		pipelineRun: newPipelineRun(v1alpha3.Failed, "Failed", now),
+		wantEmitted: "Failed",
+	}, {
+		name:        "stale",
+		pipelineRun: newPipelineRun(v1alpha3.Failed, "", now.Add(-2*time.Hour)),
+		wantEmitted: "Failed",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithRuntimeObjects(tt.pipelineRun.DeepCopy()).Build()
+			emitter := &fakeEmitter{}
+			r := &PipelineRunReconciler{Client: c, Emitter: emitter, log: logr.New(log.NullLogSink{})}
+
+			key := types.NamespacedName{Namespace: "ns", Name: "build-1"}
+			_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+			assert.Nil(t, err)
+			if tt.wantType == "" {
+				assert.Empty(t, emitter.events)
+			} else if assert.Equal(t, 1, len(emitter.events)) {
+				assert.Equal(t, tt.wantType, emitter.events[0].Type)
+				assert.Equal(t, "namespaces/ns/pipelineruns/build-1", emitter.events[0].Subject)
+			}
+
+			pr := &v1alpha3.PipelineRun{}
+			assert.Nil(t, c.Get(context.TODO(), key, pr))
+			assert.Equal(t, tt.wantEmitted, pr.Annotations[EmittedPhaseAnnoKey])
+		})
+	}
+}
+
+func TestGetName(t *testing.T) {
+	assert.Equal(t, "pipeline-cloudevents-emitter", (&PipelineReconciler{}).GetName())
+	assert.Equal(t, "pipelinerun-cloudevents-emitter", (&PipelineRunReconciler{}).GetName())
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudevents
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/cloudevents"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// PipelineCreatedEventType is the CloudEvent type of creating a Pipeline
+	PipelineCreatedEventType = "io.kubesphere.devops.pipeline.created"
+	// PipelineUpdatedEventType is the CloudEvent type of updating the spec of a Pipeline
+	PipelineUpdatedEventType = "io.kubesphere.devops.pipeline.updated"
+
+	// EmittedGenerationAnnoKey is the generation of a Pipeline which has been emitted
+	EmittedGenerationAnnoKey = "devops.kubesphere.io/cloudevents-generation"
+
+	// maxEventDelay avoids emitting the stale events, e.g. the first time the emitter runs
+	maxEventDelay = time.Hour
+)
+
+// PipelineReconciler emits the CloudEvents of creating and updating Pipelines
+type PipelineReconciler struct {
+	client.Client
+	Emitter cloudevents.Emitter
+
+	log logr.Logger
+}
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelines,verbs=get;list;watch;patch
+
+// Reconcile emits the event of the current generation of a Pipeline once
+func (r *PipelineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	pipeline := &v1alpha3.Pipeline{}
+	if err = r.Get(ctx, req.NamespacedName, pipeline); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	if !needEmitPipeline(pipeline) {
+		return
+	}
+
+	emitted, hasEmitted := pipeline.Annotations[EmittedGenerationAnnoKey]
+	eventType := PipelineUpdatedEventType
+	if !hasEmitted && pipeline.Generation <= 1 {
+		eventType = PipelineCreatedEventType
+	}
+	if !hasEmitted && time.Since(pipeline.CreationTimestamp.Time) > maxEventDelay {
+		r.log.V(6).Info(fmt.Sprintf("skip the stale event of %s", req.NamespacedName))
+	} else if err = r.Emitter.Emit(ctx, cloudevents.Event{
+		ID:      fmt.Sprintf("%s-%d", pipeline.UID, pipeline.Generation),
+		Type:    eventType,
+		Subject: fmt.Sprintf("namespaces/%s/pipelines/%s", pipeline.Namespace, pipeline.Name),
+		Data:    getPipelineData(pipeline),
+	}); err != nil {
+		r.log.Error(err, "failed to emit the CloudEvent", "pipeline", req.NamespacedName, "previous", emitted)
+		return
+	}
+
+	patch := client.MergeFrom(pipeline.DeepCopy())
+	if pipeline.Annotations == nil {
+		pipeline.Annotations = map[string]string{}
+	}
+	pipeline.Annotations[EmittedGenerationAnnoKey] = strconv.FormatInt(pipeline.Generation, 10)
+	err = r.Patch(ctx, pipeline, patch)
+	return
+}
+
+// needEmitPipeline returns true if the current generation of the Pipeline has not been emitted
+func needEmitPipeline(pipeline *v1alpha3.Pipeline) bool {
+	return pipeline.DeletionTimestamp.IsZero() &&
+		pipeline.Annotations[EmittedGenerationAnnoKey] != strconv.FormatInt(pipeline.Generation, 10)
+}
+
+func getPipelineData(pipeline *v1alpha3.Pipeline) *v1alpha3.Pipeline {
+	data := pipeline.DeepCopy()
+	data.ManagedFields = nil
+	delete(data.Annotations, EmittedGenerationAnnoKey)
+	return data
+}
+
+var pipelinePredicate = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool {
+		pipeline, ok := e.Object.(*v1alpha3.Pipeline)
+		return ok && needEmitPipeline(pipeline)
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		pipeline, ok := e.ObjectNew.(*v1alpha3.Pipeline)
+		return ok && needEmitPipeline(pipeline)
+	},
+	DeleteFunc: func(e event.DeleteEvent) bool {
+		return false
+	},
+}
+
+// GetName returns the name of this reconciler
+func (r *PipelineReconciler) GetName() string {
+	return "pipeline-cloudevents-emitter"
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PipelineReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.log = ctrl.Log.WithName(r.GetName())
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha3.Pipeline{}).
+		WithEventFilter(pipelinePredicate).
+		Complete(r)
+}
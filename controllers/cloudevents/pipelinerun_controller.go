@@ -0,0 +1,136 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudevents
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/cloudevents"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// PipelineRunEventTypePrefix is the prefix of the CloudEvent types of PipelineRuns,
+	// the suffix is the lower case phase, e.g. io.kubesphere.devops.pipelinerun.succeeded
+	PipelineRunEventTypePrefix = "io.kubesphere.devops.pipelinerun."
+
+	// EmittedPhaseAnnoKey is the phase of a PipelineRun which has been emitted
+	EmittedPhaseAnnoKey = "devops.kubesphere.io/cloudevents-phase"
+)
+
+// PipelineRunReconciler emits the CloudEvents of the phase transitions of PipelineRuns,
+// it works with all backends because it only relies on the status of PipelineRuns
+type PipelineRunReconciler struct {
+	client.Client
+	Emitter cloudevents.Emitter
+
+	log logr.Logger
+}
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns,verbs=get;list;watch;patch
+
+// Reconcile emits the event of the current phase of a PipelineRun once
+func (r *PipelineRunReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	pr := &v1alpha3.PipelineRun{}
+	if err = r.Get(ctx, req.NamespacedName, pr); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	if !needEmitPipelineRun(pr) {
+		return
+	}
+
+	if time.Since(getPhaseTime(pr)) > maxEventDelay {
+		r.log.V(6).Info(fmt.Sprintf("skip the stale %s event of %s", pr.Status.Phase, req.NamespacedName))
+	} else if err = r.Emitter.Emit(ctx, cloudevents.Event{
+		ID:      fmt.Sprintf("%s-%s", pr.UID, strings.ToLower(string(pr.Status.Phase))),
+		Type:    PipelineRunEventTypePrefix + strings.ToLower(string(pr.Status.Phase)),
+		Subject: fmt.Sprintf("namespaces/%s/pipelineruns/%s", pr.Namespace, pr.Name),
+		Data:    getPipelineRunData(pr),
+	}); err != nil {
+		r.log.Error(err, "failed to emit the CloudEvent", "pipelinerun", req.NamespacedName)
+		return
+	}
+
+	patch := client.MergeFrom(pr.DeepCopy())
+	if pr.Annotations == nil {
+		pr.Annotations = map[string]string{}
+	}
+	pr.Annotations[EmittedPhaseAnnoKey] = string(pr.Status.Phase)
+	err = r.Patch(ctx, pr, patch)
+	return
+}
+
+// needEmitPipelineRun returns true if the current phase of the PipelineRun has not been emitted
+func needEmitPipelineRun(pr *v1alpha3.PipelineRun) bool {
+	return pr.DeletionTimestamp.IsZero() && pr.Status.Phase != "" &&
+		pr.Annotations[EmittedPhaseAnnoKey] != string(pr.Status.Phase)
+}
+
+// getPhaseTime returns the time when the PipelineRun entered the current phase
+func getPhaseTime(pr *v1alpha3.PipelineRun) time.Time {
+	for _, item := range []*metav1.Time{pr.Status.CompletionTime, pr.Status.UpdateTime, pr.Status.StartTime} {
+		if item != nil {
+			return item.Time
+		}
+	}
+	return pr.CreationTimestamp.Time
+}
+
+func getPipelineRunData(pr *v1alpha3.PipelineRun) *v1alpha3.PipelineRun {
+	data := pr.DeepCopy()
+	data.ManagedFields = nil
+	delete(data.Annotations, EmittedPhaseAnnoKey)
+	return data
+}
+
+var pipelineRunPredicate = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool {
+		pr, ok := e.Object.(*v1alpha3.PipelineRun)
+		return ok && needEmitPipelineRun(pr)
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		pr, ok := e.ObjectNew.(*v1alpha3.PipelineRun)
+		return ok && needEmitPipelineRun(pr)
+	},
+	DeleteFunc: func(e event.DeleteEvent) bool {
+		return false
+	},
+}
+
+// GetName returns the name of this reconciler
+func (r *PipelineRunReconciler) GetName() string {
+	return "pipelinerun-cloudevents-emitter"
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PipelineRunReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.log = ctrl.Log.WithName(r.GetName())
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha3.PipelineRun{}).
+		WithEventFilter(pipelineRunPredicate).
+		Complete(r)
+}
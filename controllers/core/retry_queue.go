@@ -0,0 +1,86 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"sync"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// RetryQueue keeps the objects which could not be reconciled because the backend was unavailable.
+// The parked objects are sent back to the controller in the order of parking once Flush is called,
+// which usually happens when the backend recovers. The objects are de-duplicated by their keys.
+// The queue lives in memory only, the objects are reconciled again anyway after a restart of the controller.
+type RetryQueue struct {
+	mu      sync.Mutex
+	objects []client.Object
+	keys    map[client.ObjectKey]bool
+	events  chan event.GenericEvent
+}
+
+// NewRetryQueue creates a RetryQueue
+func NewRetryQueue() *RetryQueue {
+	return &RetryQueue{
+		keys:   map[client.ObjectKey]bool{},
+		events: make(chan event.GenericEvent),
+	}
+}
+
+// Park puts an object into the queue, it's ignored if the object is already in the queue
+func (q *RetryQueue) Park(obj client.Object) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	key := client.ObjectKeyFromObject(obj)
+	if q.keys[key] {
+		return
+	}
+	q.keys[key] = true
+	q.objects = append(q.objects, obj)
+}
+
+// Len returns the number of the parked objects
+func (q *RetryQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.objects)
+}
+
+// Flush sends all the parked objects to the controller in order without blocking the caller
+func (q *RetryQueue) Flush() {
+	q.mu.Lock()
+	objects := q.objects
+	q.objects, q.keys = nil, map[client.ObjectKey]bool{}
+	q.mu.Unlock()
+
+	if len(objects) == 0 {
+		return
+	}
+	go func() {
+		for _, obj := range objects {
+			q.events <- event.GenericEvent{Object: obj}
+		}
+	}()
+}
+
+// Source returns the source and the handler which should be watched by the controller
+func (q *RetryQueue) Source() (source.Source, handler.EventHandler) {
+	return &source.Channel{Source: q.events}, &handler.EnqueueRequestForObject{}
+}
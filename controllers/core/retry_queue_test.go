@@ -0,0 +1,62 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+)
+
+func TestRetryQueue(t *testing.T) {
+	queue := NewRetryQueue()
+	src, eventHandler := queue.Source()
+	assert.NotNil(t, src)
+	assert.Equal(t, &handler.EnqueueRequestForObject{}, eventHandler)
+
+	// nothing happens with an empty queue
+	queue.Flush()
+
+	newObject := func(name string) *v1.ConfigMap {
+		return &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name}}
+	}
+	queue.Park(newObject("b"))
+	queue.Park(newObject("a"))
+	queue.Park(newObject("b"))
+	assert.Equal(t, 2, queue.Len())
+
+	queue.Flush()
+	assert.Equal(t, 0, queue.Len())
+	var names []string
+	for i := 0; i < 2; i++ {
+		select {
+		case e := <-queue.events:
+			names = append(names, e.Object.GetName())
+		case <-time.After(time.Second):
+			t.Fatal("timeout")
+		}
+	}
+	assert.Equal(t, []string{"b", "a"}, names)
+
+	// the flushed objects could be parked again
+	queue.Park(newObject("b"))
+	assert.Equal(t, 1, queue.Len())
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credential
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+const (
+	// DefaultExpiryWarningPeriod is the default period before the expiry in which a credential is expiring soon
+	DefaultExpiryWarningPeriod = 7 * 24 * time.Hour
+
+	// CredentialExpiringSoon indicates the credential is going to expire soon
+	CredentialExpiringSoon = "CredentialExpiringSoon"
+	// CredentialExpired indicates the credential has expired
+	CredentialExpired = "CredentialExpired"
+	// CredentialRotated indicates the credential has been regenerated
+	CredentialRotated = "CredentialRotated"
+	// FailedCredentialRotate indicates the controller fails to regenerate the credential
+	FailedCredentialRotate = "FailedCredentialRotate"
+	// InvalidExpireTime indicates the expire time of the credential is invalid
+	InvalidExpireTime = "InvalidExpireTime"
+)
+
+// Rotator regenerates a credential, usually by the API of the provider who issued it
+type Rotator interface {
+	// Rotate returns the new data of the credential and its expire time
+	Rotate(ctx context.Context, secret *v1.Secret) (data map[string][]byte, expireTime time.Time, err error)
+}
+
+// ExpiryReconciler tracks the expire time of the credentials, and rotates them before they expire
+// if there is a rotator for them.
+type ExpiryReconciler struct {
+	client.Client
+	// WarningPeriod is the period before the expiry in which a credential is expiring soon
+	WarningPeriod time.Duration
+	// Rotators are the rotators which are referred by the credentials with their names
+	Rotators map[string]Rotator
+
+	log      logr.Logger
+	recorder record.EventRecorder
+	// now is for the test purpose
+	now func() time.Time
+}
+
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile updates the expiry status of the credential
+func (r *ExpiryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.log.WithValues("credential", req.NamespacedName)
+	secret := &v1.Secret{}
+	if err := r.Get(ctx, req.NamespacedName, secret); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !secret.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	expireTimeText, ok := secret.Annotations[v1alpha3.CredentialExpireTimeAnnoKey]
+	if !ok {
+		return ctrl.Result{}, r.setExpiryStatus(ctx, secret, "")
+	}
+	expireTime, err := time.Parse(time.RFC3339, expireTimeText)
+	if err != nil {
+		r.recorder.Eventf(secret, v1.EventTypeWarning, InvalidExpireTime, "The expire time %q is not in RFC3339 format", expireTimeText)
+		return ctrl.Result{}, nil
+	}
+
+	now := r.now()
+	status, nextCheck := r.getExpiryStatus(expireTime, now)
+	if status != v1alpha3.CredentialValid {
+		if rotator, ok := r.Rotators[secret.Annotations[v1alpha3.CredentialRotatorAnnoKey]]; ok {
+			if err = r.rotate(ctx, secret, rotator); err != nil {
+				log.Error(err, "unable to rotate the credential")
+				r.recorder.Eventf(secret, v1.EventTypeWarning, FailedCredentialRotate, "Failed to rotate the credential, err = %v", err)
+				return ctrl.Result{}, err
+			}
+			// the update of the secret brings us back with the new expire time
+			return ctrl.Result{}, nil
+		}
+	}
+
+	if status != v1alpha3.CredentialExpiryStatus(secret.Annotations[v1alpha3.CredentialExpiryStatusAnnoKey]) {
+		switch status {
+		case v1alpha3.CredentialExpiringSoon:
+			r.recorder.Eventf(secret, v1.EventTypeWarning, CredentialExpiringSoon, "The credential is going to expire at %s", expireTimeText)
+		case v1alpha3.CredentialExpired:
+			r.recorder.Eventf(secret, v1.EventTypeWarning, CredentialExpired, "The credential expired at %s", expireTimeText)
+		}
+		if err = r.setExpiryStatus(ctx, secret, status); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if nextCheck > 0 {
+		return ctrl.Result{RequeueAfter: nextCheck}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// getExpiryStatus returns the expiry status and the duration after which the status changes
+func (r *ExpiryReconciler) getExpiryStatus(expireTime, now time.Time) (v1alpha3.CredentialExpiryStatus, time.Duration) {
+	warningPeriod := r.WarningPeriod
+	if warningPeriod <= 0 {
+		warningPeriod = DefaultExpiryWarningPeriod
+	}
+	switch {
+	case !now.Before(expireTime):
+		return v1alpha3.CredentialExpired, 0
+	case !now.Before(expireTime.Add(-warningPeriod)):
+		return v1alpha3.CredentialExpiringSoon, expireTime.Sub(now)
+	default:
+		return v1alpha3.CredentialValid, expireTime.Add(-warningPeriod).Sub(now)
+	}
+}
+
+func (r *ExpiryReconciler) rotate(ctx context.Context, secret *v1.Secret, rotator Rotator) error {
+	data, expireTime, err := rotator.Rotate(ctx, secret)
+	if err != nil {
+		return err
+	}
+	patch := client.MergeFrom(secret.DeepCopy())
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	for key, value := range data {
+		secret.Data[key] = value
+	}
+	secret.Annotations[v1alpha3.CredentialExpireTimeAnnoKey] = expireTime.UTC().Format(time.RFC3339)
+	delete(secret.Annotations, v1alpha3.CredentialExpiryStatusAnnoKey)
+	if err = r.Patch(ctx, secret, patch); err != nil {
+		return err
+	}
+	r.recorder.Eventf(secret, v1.EventTypeNormal, CredentialRotated, "The credential has been rotated, it expires at %s",
+		secret.Annotations[v1alpha3.CredentialExpireTimeAnnoKey])
+	return nil
+}
+
+func (r *ExpiryReconciler) setExpiryStatus(ctx context.Context, secret *v1.Secret, status v1alpha3.CredentialExpiryStatus) error {
+	current, ok := secret.Annotations[v1alpha3.CredentialExpiryStatusAnnoKey]
+	if current == string(status) && (ok || status == "") {
+		return nil
+	}
+	patch := client.MergeFrom(secret.DeepCopy())
+	if status == "" {
+		delete(secret.Annotations, v1alpha3.CredentialExpiryStatusAnnoKey)
+	} else {
+		if secret.Annotations == nil {
+			secret.Annotations = map[string]string{}
+		}
+		secret.Annotations[v1alpha3.CredentialExpiryStatusAnnoKey] = string(status)
+	}
+	return client.IgnoreNotFound(r.Patch(ctx, secret, patch))
+}
+
+// isCredential returns true if the object is a devops credential
+func isCredential(obj client.Object) bool {
+	secret, ok := obj.(*v1.Secret)
+	if !ok {
+		return false
+	}
+	for _, credentialType := range v1alpha3.GetSupportedCredentialTypes() {
+		if secret.Type == credentialType {
+			return true
+		}
+	}
+	return false
+}
+
+// SetupWithManager setups the reconciler with controller manager
+func (r *ExpiryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.log = ctrl.Log.WithName("credential-expiry-controller")
+	r.recorder = mgr.GetEventRecorderFor("credential-expiry-controller")
+	if r.now == nil {
+		r.now = time.Now
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("credential-expiry-controller").
+		For(&v1.Secret{}).
+		WithEventFilter(predicate.NewPredicateFuncs(isCredential)).
+		Complete(r)
+}
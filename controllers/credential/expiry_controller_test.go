@@ -0,0 +1,163 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credential
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+type fakeRotator struct {
+	expireTime time.Time
+	err        error
+}
+
+func (f *fakeRotator) Rotate(ctx context.Context, secret *v1.Secret) (map[string][]byte, time.Time, error) {
+	return map[string][]byte{v1alpha3.BasicAuthPasswordKey: []byte("new")}, f.expireTime, f.err
+}
+
+func newCredential(annotations map[string]string) *v1.Secret {
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "credential", Annotations: annotations},
+		Type:       v1alpha3.SecretTypeBasicAuth,
+		Data:       map[string][]byte{v1alpha3.BasicAuthPasswordKey: []byte("old")},
+	}
+}
+
+func TestExpiryReconciler(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.Nil(t, v1.AddToScheme(scheme))
+
+	now := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	expireTimeAfter := func(d time.Duration) string {
+		return now.Add(d).Format(time.RFC3339)
+	}
+	newExpireTime := now.Add(90 * 24 * time.Hour)
+
+	tests := []struct {
+		name         string
+		secret       *v1.Secret
+		rotator      Rotator
+		wantStatus   string
+		wantRequeue  time.Duration
+		wantPassword string
+		wantErr      bool
+		wantEvent    bool
+	}{{
+		name:         "no expire time",
+		secret:       newCredential(nil),
+		wantPassword: "old",
+	}, {
+		name: "valid",
+		secret: newCredential(map[string]string{
+			v1alpha3.CredentialExpireTimeAnnoKey: expireTimeAfter(30 * 24 * time.Hour),
+		}),
+		wantStatus:   string(v1alpha3.CredentialValid),
+		wantRequeue:  23 * 24 * time.Hour,
+		wantPassword: "old",
+	}, {
+		name: "expiring soon",
+		secret: newCredential(map[string]string{
+			v1alpha3.CredentialExpireTimeAnnoKey: expireTimeAfter(24 * time.Hour),
+		}),
+		wantStatus:   string(v1alpha3.CredentialExpiringSoon),
+		wantRequeue:  24 * time.Hour,
+		wantPassword: "old",
+		wantEvent:    true,
+	}, {
+		name: "expired",
+		secret: newCredential(map[string]string{
+			v1alpha3.CredentialExpireTimeAnnoKey:   expireTimeAfter(-time.Hour),
+			v1alpha3.CredentialExpiryStatusAnnoKey: string(v1alpha3.CredentialExpiringSoon),
+		}),
+		wantStatus:   string(v1alpha3.CredentialExpired),
+		wantPassword: "old",
+		wantEvent:    true,
+	}, {
+		name: "rotated",
+		secret: newCredential(map[string]string{
+			v1alpha3.CredentialExpireTimeAnnoKey: expireTimeAfter(time.Hour),
+			v1alpha3.CredentialRotatorAnnoKey:    "fake",
+		}),
+		rotator:      &fakeRotator{expireTime: newExpireTime},
+		wantPassword: "new",
+		wantEvent:    true,
+	}, {
+		name: "failed to rotate",
+		secret: newCredential(map[string]string{
+			v1alpha3.CredentialExpireTimeAnnoKey: expireTimeAfter(time.Hour),
+			v1alpha3.CredentialRotatorAnnoKey:    "fake",
+		}),
+		rotator:      &fakeRotator{err: errors.New("fake")},
+		wantPassword: "old",
+		wantErr:      true,
+		wantEvent:    true,
+	}, {
+		name: "invalid expire time",
+		secret: newCredential(map[string]string{
+			v1alpha3.CredentialExpireTimeAnnoKey: "tomorrow",
+		}),
+		wantPassword: "old",
+		wantEvent:    true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			r := &ExpiryReconciler{
+				Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(tt.secret).Build(),
+				Rotators: map[string]Rotator{"fake": tt.rotator},
+				log:      logr.New(log.NullLogSink{}),
+				recorder: recorder,
+				now:      func() time.Time { return now },
+			}
+			key := types.NamespacedName{Namespace: "ns", Name: "credential"}
+			result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+			assert.Equal(t, tt.wantErr, err != nil, err)
+			assert.Equal(t, tt.wantRequeue, result.RequeueAfter)
+			assert.Equal(t, tt.wantEvent, len(recorder.Events) > 0)
+
+			secret := &v1.Secret{}
+			assert.Nil(t, r.Get(context.Background(), key, secret))
+			assert.Equal(t, tt.wantStatus, secret.Annotations[v1alpha3.CredentialExpiryStatusAnnoKey])
+			assert.Equal(t, tt.wantPassword, string(secret.Data[v1alpha3.BasicAuthPasswordKey]))
+			if tt.rotator != nil && !tt.wantErr {
+				assert.Equal(t, newExpireTime.Format(time.RFC3339), secret.Annotations[v1alpha3.CredentialExpireTimeAnnoKey])
+			}
+		})
+	}
+}
+
+func TestIsCredential(t *testing.T) {
+	assert.True(t, isCredential(newCredential(nil)))
+	assert.False(t, isCredential(&v1.Secret{Type: v1.SecretTypeOpaque}))
+	assert.False(t, isCredential(&v1.ConfigMap{}))
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credential
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+const (
+	// GitLabRotatorName is the name of GitLabRotator
+	GitLabRotatorName = "gitlab"
+	// DefaultGitLabServer is the GitLab server of the credentials which do not have a rotator server
+	DefaultGitLabServer = "https://gitlab.com"
+	// DefaultGitLabTokenValidity is the validity of the rotated GitLab tokens
+	DefaultGitLabTokenValidity = 30 * 24 * time.Hour
+)
+
+// GitLabRotator rotates the GitLab personal, group or project access tokens by themselves, see also
+// https://docs.gitlab.com/ee/api/personal_access_tokens.html#rotate-a-personal-access-token
+// The token is the password of a basic-auth credential, or the secret of a secret-text credential.
+type GitLabRotator struct {
+	Client *http.Client
+	// Validity is the validity of the rotated tokens, it's DefaultGitLabTokenValidity if it's zero
+	Validity time.Duration
+
+	// now is for the test purpose
+	now func() time.Time
+}
+
+var _ Rotator = &GitLabRotator{}
+
+// NewGitLabRotator creates a GitLabRotator
+func NewGitLabRotator(client *http.Client) *GitLabRotator {
+	return &GitLabRotator{Client: client, now: time.Now}
+}
+
+// gitlabToken is the rotated token returned by GitLab
+type gitlabToken struct {
+	Token     string `json:"token"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// Rotate replaces the token with a new one, the old token is revoked by GitLab
+func (g *GitLabRotator) Rotate(ctx context.Context, secret *v1.Secret) (data map[string][]byte, expireTime time.Time, err error) {
+	var key string
+	switch secret.Type {
+	case v1alpha3.SecretTypeBasicAuth:
+		key = v1alpha3.BasicAuthPasswordKey
+	case v1alpha3.SecretTypeSecretText:
+		key = v1alpha3.SecretTextSecretKey
+	default:
+		err = fmt.Errorf("the credential type %s is not supported by the GitLab rotator", secret.Type)
+		return
+	}
+	if len(secret.Data[key]) == 0 {
+		err = fmt.Errorf("the GitLab token is not found in the credential")
+		return
+	}
+
+	server := DefaultGitLabServer
+	if value := secret.Annotations[v1alpha3.CredentialRotatorServerAnnoKey]; value != "" {
+		server = value
+	}
+	validity := g.Validity
+	if validity <= 0 {
+		validity = DefaultGitLabTokenValidity
+	}
+	now := time.Now
+	if g.now != nil {
+		now = g.now
+	}
+	form := url.Values{"expires_at": []string{now().Add(validity).UTC().Format("2006-01-02")}}
+
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctx, http.MethodPost,
+		strings.TrimSuffix(server, "/")+"/api/v4/personal_access_tokens/self/rotate",
+		strings.NewReader(form.Encode())); err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("PRIVATE-TOKEN", string(secret.Data[key]))
+
+	var resp *http.Response
+	if resp, err = g.Client.Do(req); err != nil {
+		return
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("unexpected status code from GitLab: %d", resp.StatusCode)
+		return
+	}
+
+	token := &gitlabToken{}
+	if err = json.NewDecoder(resp.Body).Decode(token); err != nil {
+		return
+	}
+	if token.Token == "" {
+		err = fmt.Errorf("no token was returned by GitLab")
+		return
+	}
+	// the token expires at the beginning of the day in UTC
+	if expireTime, err = time.Parse("2006-01-02", token.ExpiresAt); err != nil {
+		return
+	}
+	data = map[string][]byte{key: []byte(token.Token)}
+	return
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credential
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+func newGitLabServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/v4/personal_access_tokens/self/rotate", r.URL.Path)
+		if r.Header.Get("PRIVATE-TOKEN") != "old" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Nil(t, r.ParseForm())
+		assert.Equal(t, "2023-05-31", r.PostForm.Get("expires_at"))
+		_, _ = w.Write([]byte(`{"id":42,"token":"new","expires_at":"2023-05-31"}`))
+	}))
+}
+
+func TestGitLabRotator(t *testing.T) {
+	server := newGitLabServer(t)
+	defer server.Close()
+
+	now := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	rotator := NewGitLabRotator(server.Client())
+	rotator.now = func() time.Time { return now }
+
+	tests := []struct {
+		name           string
+		secret         *v1.Secret
+		wantData       map[string][]byte
+		wantExpireTime time.Time
+		wantErr        bool
+	}{{
+		name: "basic auth",
+		secret: &v1.Secret{
+			Type: v1alpha3.SecretTypeBasicAuth,
+			Data: map[string][]byte{v1alpha3.BasicAuthUsernameKey: []byte("bot"), v1alpha3.BasicAuthPasswordKey: []byte("old")},
+		},
+		wantData:       map[string][]byte{v1alpha3.BasicAuthPasswordKey: []byte("new")},
+		wantExpireTime: time.Date(2023, 5, 31, 0, 0, 0, 0, time.UTC),
+	}, {
+		name: "secret text",
+		secret: &v1.Secret{
+			Type: v1alpha3.SecretTypeSecretText,
+			Data: map[string][]byte{v1alpha3.SecretTextSecretKey: []byte("old")},
+		},
+		wantData:       map[string][]byte{v1alpha3.SecretTextSecretKey: []byte("new")},
+		wantExpireTime: time.Date(2023, 5, 31, 0, 0, 0, 0, time.UTC),
+	}, {
+		name: "revoked token",
+		secret: &v1.Secret{
+			Type: v1alpha3.SecretTypeSecretText,
+			Data: map[string][]byte{v1alpha3.SecretTextSecretKey: []byte("revoked")},
+		},
+		wantErr: true,
+	}, {
+		name: "no token",
+		secret: &v1.Secret{
+			Type: v1alpha3.SecretTypeSecretText,
+		},
+		wantErr: true,
+	}, {
+		name: "unsupported type",
+		secret: &v1.Secret{
+			Type: v1alpha3.SecretTypeSSHAuth,
+			Data: map[string][]byte{v1alpha3.SSHAuthPrivateKey: []byte("old")},
+		},
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.secret.Annotations == nil {
+				tt.secret.Annotations = map[string]string{v1alpha3.CredentialRotatorServerAnnoKey: server.URL}
+			}
+			data, expireTime, err := rotator.Rotate(context.Background(), tt.secret)
+			assert.Equal(t, tt.wantErr, err != nil, err)
+			assert.Equal(t, tt.wantData, data)
+			assert.True(t, tt.wantExpireTime.Equal(expireTime))
+		})
+	}
+}
+
+func TestExpiryReconcilerWithGitLabRotator(t *testing.T) {
+	server := newGitLabServer(t)
+	defer server.Close()
+
+	scheme := runtime.NewScheme()
+	assert.Nil(t, v1.AddToScheme(scheme))
+
+	now := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	rotator := NewGitLabRotator(server.Client())
+	rotator.now = func() time.Time { return now }
+
+	secret := newCredential(map[string]string{
+		v1alpha3.CredentialExpireTimeAnnoKey:    now.Add(time.Hour).Format(time.RFC3339),
+		v1alpha3.CredentialRotatorAnnoKey:       GitLabRotatorName,
+		v1alpha3.CredentialRotatorServerAnnoKey: server.URL,
+	})
+	r := &ExpiryReconciler{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build(),
+		Rotators: map[string]Rotator{GitLabRotatorName: rotator},
+		log:      logr.New(log.NullLogSink{}),
+		recorder: record.NewFakeRecorder(10),
+		now:      func() time.Time { return now },
+	}
+	key := types.NamespacedName{Namespace: "ns", Name: "credential"}
+	_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+	assert.Nil(t, err)
+
+	assert.Nil(t, r.Get(context.Background(), key, secret))
+	assert.Equal(t, "new", string(secret.Data[v1alpha3.BasicAuthPasswordKey]))
+	assert.Equal(t, "2023-05-31T00:00:00Z", secret.Annotations[v1alpha3.CredentialExpireTimeAnnoKey])
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package devopsproject
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/quota"
+)
+
+// quotaUsageResyncPeriod is the period of refreshing the quota usage of DevOpsProjects
+const quotaUsageResyncPeriod = time.Minute
+
+// QuotaReconciler reports the quota usage of DevOpsProjects into their status
+type QuotaReconciler struct {
+	client.Client
+	log logr.Logger
+	// ControllerOptions tunes the concurrency and the rate limiter of this controller
+	ControllerOptions controller.Options
+}
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=devopsprojects,verbs=get;list;watch;update
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelines;pipelineruns,verbs=get;list;watch
+
+// Reconcile calculates the quota usage of the DevOpsProject
+func (r *QuotaReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	project := &v1alpha3.DevOpsProject{}
+	if err = r.Get(ctx, req.NamespacedName, project); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+
+	var usage *v1alpha3.ProjectQuotaUsage
+	if project.Spec.Quota != nil && project.Status.AdminNamespace != "" {
+		if usage, err = quota.GetUsage(ctx, r.Client, project.Status.AdminNamespace, time.Now()); err != nil {
+			r.log.Error(err, "unable to calculate the quota usage", "DevOpsProject", req.Name)
+			return
+		}
+		result.RequeueAfter = quotaUsageResyncPeriod
+	}
+	err = r.updateUsage(ctx, req.NamespacedName, usage)
+	return
+}
+
+func (r *QuotaReconciler) updateUsage(ctx context.Context, key types.NamespacedName, usage *v1alpha3.ProjectQuotaUsage) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		project := &v1alpha3.DevOpsProject{}
+		if err := r.Get(ctx, key, project); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		if usageEqual(project.Status.QuotaUsage, usage) {
+			return nil
+		}
+		project.Status.QuotaUsage = usage
+		return r.Update(ctx, project)
+	})
+}
+
+// usageEqual compares the usages regardless of the update time
+func usageEqual(a, b *v1alpha3.ProjectQuotaUsage) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	a, b = a.DeepCopy(), b.DeepCopy()
+	a.UpdateTime, b.UpdateTime = nil, nil
+	return reflect.DeepEqual(a, b)
+}
+
+// SetupWithManager setups the reconciler with controller manager
+func (r *QuotaReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.log = ctrl.Log.WithName("devopsproject-quota-controller")
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("devopsproject-quota-controller").
+		For(&v1alpha3.DevOpsProject{}).
+		WithOptions(r.ControllerOptions).
+		Complete(r)
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package devopsproject
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+func TestQuotaReconciler(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	newProject := func(quota *v1alpha3.ProjectQuota, usage *v1alpha3.ProjectQuotaUsage) *v1alpha3.DevOpsProject {
+		project := &v1alpha3.DevOpsProject{ObjectMeta: metav1.ObjectMeta{Name: "project"}}
+		project.Spec.Quota = quota
+		project.Status.AdminNamespace = "ns"
+		project.Status.QuotaUsage = usage
+		return project
+	}
+
+	tests := []struct {
+		name        string
+		project     *v1alpha3.DevOpsProject
+		wantUsage   *v1alpha3.ProjectQuotaUsage
+		wantRequeue bool
+	}{{
+		name:    "no quota",
+		project: newProject(nil, nil),
+	}, {
+		name:        "report the usage",
+		project:     newProject(&v1alpha3.ProjectQuota{MaxPipelines: 10}, nil),
+		wantUsage:   &v1alpha3.ProjectQuotaUsage{Pipelines: 1},
+		wantRequeue: true,
+	}, {
+		name:    "clean up the usage",
+		project: newProject(nil, &v1alpha3.ProjectQuotaUsage{Pipelines: 1}),
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &QuotaReconciler{
+				Client: fake.NewClientBuilder().WithScheme(schema).WithObjects(tt.project,
+					&v1alpha3.Pipeline{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pipeline"}}).Build(),
+				log: logr.New(log.NullLogSink{}),
+			}
+			key := types.NamespacedName{Name: "project"}
+			result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantRequeue, result.RequeueAfter > 0)
+
+			project := &v1alpha3.DevOpsProject{}
+			assert.Nil(t, r.Get(context.Background(), key, project))
+			assert.True(t, usageEqual(tt.wantUsage, project.Status.QuotaUsage), project.Status.QuotaUsage)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dora
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/history"
+	"kubesphere.io/devops/pkg/metrics"
+	"kubesphere.io/devops/pkg/models/dora"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// Reconciler refreshes the DORA metrics of a DevOps project once a PipelineRun of it completed.
+// The requests are keyed by the namespaces, so the PipelineRuns of the same project share one calculation.
+type Reconciler struct {
+	client.Client
+	// Store is optional, the PipelineRuns are read from the cluster if it's nil
+	Store history.Interface
+	// Window is the period of the metrics, it's dora.DefaultWindow if it's zero
+	Window time.Duration
+
+	log logr.Logger
+}
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelines;pipelineruns,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+
+// Reconcile calculates the DORA metrics of the namespace
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	namespace := req.Name
+	ns := &v1.Namespace{}
+	if err = r.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		if apierrors.IsNotFound(err) {
+			metrics.ForgetDORA(namespace)
+		}
+		err = client.IgnoreNotFound(err)
+		return
+	}
+
+	window := r.Window
+	if window <= 0 {
+		window = dora.DefaultWindow
+	}
+	until := time.Now()
+	var doraMetrics *dora.Metrics
+	if doraMetrics, err = dora.Collect(ctx, r.Client, r.Store, namespace, until.Add(-window), until); err != nil {
+		r.log.Error(err, "failed to calculate the DORA metrics", "namespace", namespace)
+		return
+	}
+	metrics.ObserveDORA(doraMetrics)
+	return
+}
+
+var completedPredicate = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool {
+		pr, ok := e.Object.(*v1alpha3.PipelineRun)
+		return ok && pr.HasCompleted()
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldPR, oldOK := e.ObjectOld.(*v1alpha3.PipelineRun)
+		newPR, newOK := e.ObjectNew.(*v1alpha3.PipelineRun)
+		return oldOK && newOK && !oldPR.HasCompleted() && newPR.HasCompleted()
+	},
+	DeleteFunc: func(e event.DeleteEvent) bool {
+		return false
+	},
+}
+
+func namespaceRequest(obj client.Object) []reconcile.Request {
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: obj.GetNamespace()}}}
+}
+
+// GetName returns the name of this reconciler
+func (r *Reconciler) GetName() string {
+	return "dora-metrics-controller"
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.log = ctrl.Log.WithName(r.GetName())
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.GetName()).
+		Watches(&source.Kind{Type: &v1alpha3.PipelineRun{}},
+			handler.EnqueueRequestsFromMapFunc(namespaceRequest),
+			builder.WithPredicates(completedPredicate)).
+		Complete(r)
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dora
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/metrics"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestReconciler(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+	assert.Nil(t, v1.AddToScheme(schema))
+
+	completionTime := metav1.NewTime(time.Now().Add(-time.Hour))
+	startTime := metav1.NewTime(completionTime.Add(-time.Minute))
+	objects := []runtime.Object{
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "project"}},
+		&v1alpha3.Pipeline{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "project", Name: "deploy",
+			Annotations: map[string]string{v1alpha3.PipelineDeploymentAnnoKey: "true"},
+		}},
+		&v1alpha3.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "project", Name: "deploy-1",
+				Labels: map[string]string{v1alpha3.PipelineNameLabelKey: "deploy"},
+			},
+			Status: v1alpha3.PipelineRunStatus{
+				Phase:          v1alpha3.Failed,
+				StartTime:      &startTime,
+				CompletionTime: &completionTime,
+			},
+		},
+	}
+	r := &Reconciler{
+		Client: fake.NewClientBuilder().WithScheme(schema).WithRuntimeObjects(objects...).Build(),
+		log:    logr.New(log.NullLogSink{}),
+	}
+
+	_, err = r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "project"}})
+	assert.Nil(t, err)
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.ChangeFailureRate.WithLabelValues("project")))
+
+	// the metrics are removed along with the namespace
+	assert.Nil(t, r.Delete(context.TODO(), objects[0].(*v1.Namespace)))
+	_, err = r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "project"}})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, testutil.CollectAndCount(metrics.ChangeFailureRate))
+}
+
+func TestCompletedPredicate(t *testing.T) {
+	running := &v1alpha3.PipelineRun{Status: v1alpha3.PipelineRunStatus{Phase: v1alpha3.Running}}
+	completed := &v1alpha3.PipelineRun{Status: v1alpha3.PipelineRunStatus{Phase: v1alpha3.Succeeded,
+		CompletionTime: &metav1.Time{Time: time.Now()}}}
+
+	assert.False(t, completedPredicate.Create(event.CreateEvent{Object: running}))
+	assert.True(t, completedPredicate.Create(event.CreateEvent{Object: completed}))
+	assert.True(t, completedPredicate.Update(event.UpdateEvent{ObjectOld: running, ObjectNew: completed}))
+	assert.False(t, completedPredicate.Update(event.UpdateEvent{ObjectOld: completed, ObjectNew: completed}))
+	assert.False(t, completedPredicate.Delete(event.DeleteEvent{Object: completed}))
+
+	assert.Equal(t, []ctrl.Request{{NamespacedName: types.NamespacedName{Name: "project"}}},
+		namespaceRequest(&v1alpha3.PipelineRun{ObjectMeta: metav1.ObjectMeta{Namespace: "project"}}))
+}
@@ -0,0 +1,242 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fluxcd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
+	"kubesphere.io/devops/pkg/api/gitops/v1alpha1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// imageUpdaterLabelKey is the label key of the ImageUpdater which owns the FluxCD image objects
+	imageUpdaterLabelKey = "gitops.kubesphere.io/image-updater"
+	// defaultImagePolicy selects the latest stable version
+	defaultImagePolicy = "semver:>=0.0.0"
+)
+
+//+kubebuilder:rbac:groups=gitops.kubesphere.io,resources=imageupdaters,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups="image.toolkit.fluxcd.io",resources=imagerepositories;imagepolicies;imageupdateautomations,verbs=get;list;create;update;delete
+
+// ImageUpdaterReconciler reconciles the ImageUpdater into the FluxCD ImageRepository, ImagePolicy and
+// ImageUpdateAutomation, then FluxCD commits the new image tags to the manifests in the git repository
+type ImageUpdaterReconciler struct {
+	client.Client
+	log      logr.Logger
+	recorder record.EventRecorder
+}
+
+// Reconcile maintains the FluxCD image objects against the ImageUpdater
+func (r *ImageUpdaterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	updater := &v1alpha1.ImageUpdater{}
+	if err = r.Get(ctx, req.NamespacedName, updater); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+
+	// skip if kind is not fluxcd, the FluxCD image objects are deleted along with the ImageUpdater
+	flux := updater.Spec.Flux
+	if updater.Spec.Kind != string(v1alpha1.FluxCD) || flux == nil || !updater.DeletionTimestamp.IsZero() {
+		return
+	}
+	if flux.GitRepository.Name == "" || flux.Branch == "" {
+		r.recorder.Eventf(updater, corev1.EventTypeWarning, "Missing", "git repository and branch are required")
+		return
+	}
+
+	names := sets.NewString()
+	for _, image := range updater.Spec.Images {
+		alias, imageName := parseImage(image)
+		var policy map[string]interface{}
+		if policy, err = getImagePolicy(getOrDefault(flux.Policies[alias], defaultImagePolicy)); err != nil {
+			r.recorder.Eventf(updater, corev1.EventTypeWarning, "InvalidPolicy", "invalid policy of image %s: %v", alias, err)
+			err = nil
+			return
+		}
+
+		name := fmt.Sprintf("%s-%s", updater.Name, alias)
+		names.Insert(name)
+		if err = r.apply(ctx, updater, createBareFluxImageObject("ImageRepository"), name, func(obj *unstructured.Unstructured) {
+			_ = unstructured.SetNestedField(obj.Object, imageName, "spec", "image")
+			_ = unstructured.SetNestedField(obj.Object, "1m", "spec", "interval")
+			if secret := flux.Secrets[alias]; secret != "" {
+				_ = unstructured.SetNestedField(obj.Object, secret, "spec", "secretRef", "name")
+			} else {
+				unstructured.RemoveNestedField(obj.Object, "spec", "secretRef")
+			}
+		}); err != nil {
+			return
+		}
+		if err = r.apply(ctx, updater, createBareFluxImageObject("ImagePolicy"), name, func(obj *unstructured.Unstructured) {
+			_ = unstructured.SetNestedField(obj.Object, name, "spec", "imageRepositoryRef", "name")
+			_ = unstructured.SetNestedMap(obj.Object, policy, "spec", "policy")
+			if pattern := flux.AllowTags[alias]; pattern != "" {
+				_ = unstructured.SetNestedField(obj.Object, pattern, "spec", "filterTags", "pattern")
+			} else {
+				unstructured.RemoveNestedField(obj.Object, "spec", "filterTags")
+			}
+		}); err != nil {
+			return
+		}
+	}
+	if err = r.prune(ctx, updater, names); err != nil {
+		return
+	}
+
+	err = r.apply(ctx, updater, createBareFluxImageObject("ImageUpdateAutomation"), updater.Name, func(obj *unstructured.Unstructured) {
+		_ = unstructured.SetNestedField(obj.Object, "1m", "spec", "interval")
+		_ = unstructured.SetNestedField(obj.Object, "GitRepository", "spec", "sourceRef", "kind")
+		_ = unstructured.SetNestedField(obj.Object, getFluxRepoName(flux.GitRepository.Name), "spec", "sourceRef", "name")
+		_ = unstructured.SetNestedField(obj.Object, flux.Branch, "spec", "git", "checkout", "ref", "branch")
+		_ = unstructured.SetNestedField(obj.Object, flux.Branch, "spec", "git", "push", "branch")
+		_ = unstructured.SetNestedField(obj.Object, getOrDefault(flux.AuthorName, "ks-devops"), "spec", "git", "commit", "author", "name")
+		_ = unstructured.SetNestedField(obj.Object, getOrDefault(flux.AuthorEmail, "ks-devops@kubesphere.io"), "spec", "git", "commit", "author", "email")
+		_ = unstructured.SetNestedField(obj.Object, getOrDefault(flux.Path, "./"), "spec", "update", "path")
+		_ = unstructured.SetNestedField(obj.Object, "Setters", "spec", "update", "strategy")
+	})
+	return
+}
+
+// apply creates or updates the FluxCD image object which is owned by the ImageUpdater
+func (r *ImageUpdaterReconciler) apply(ctx context.Context, updater *v1alpha1.ImageUpdater, obj *unstructured.Unstructured,
+	name string, mutate func(obj *unstructured.Unstructured)) (err error) {
+	obj.SetNamespace(updater.Namespace)
+	obj.SetName(name)
+	var op controllerutil.OperationResult
+	if op, err = controllerutil.CreateOrUpdate(ctx, r.Client, obj, func() error {
+		labels := obj.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels["app.kubernetes.io/managed-by"] = v1alpha1.GroupName
+		labels[imageUpdaterLabelKey] = updater.Name
+		obj.SetLabels(labels)
+		mutate(obj)
+		return controllerutil.SetControllerReference(updater, obj, r.Scheme())
+	}); err != nil {
+		r.recorder.Eventf(updater, corev1.EventTypeWarning, "FailedWithFluxCD",
+			"failed to apply FluxCD %s %s, error is: %v", obj.GetKind(), name, err)
+	} else if op != controllerutil.OperationResultNone {
+		r.log.Info(fmt.Sprintf("%s FluxCD %s", op, obj.GetKind()), "name", name)
+	}
+	return
+}
+
+// prune deletes the FluxCD ImageRepositories and ImagePolicies of the images which have been removed
+func (r *ImageUpdaterReconciler) prune(ctx context.Context, updater *v1alpha1.ImageUpdater, names sets.String) (err error) {
+	for _, kind := range []string{"ImageRepository", "ImagePolicy"} {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(createBareFluxImageObject(kind + "List").GroupVersionKind())
+		if err = r.List(ctx, list, client.InNamespace(updater.Namespace),
+			client.MatchingLabels{imageUpdaterLabelKey: updater.Name}); err != nil {
+			return
+		}
+		for i := range list.Items {
+			if obj := &list.Items[i]; !names.Has(obj.GetName()) {
+				if err = client.IgnoreNotFound(r.Delete(ctx, obj)); err != nil {
+					return
+				}
+			}
+		}
+	}
+	return
+}
+
+// parseImage parses the image in the form of [alias=]image[:tag], the alias defaults to the last part of the image
+func parseImage(image string) (alias, name string) {
+	alias, name = "", image
+	if index := strings.Index(image, "="); index >= 0 {
+		alias, name = image[:index], image[index+1:]
+	}
+	if index := strings.LastIndex(name, ":"); index > strings.LastIndex(name, "/") {
+		name = name[:index]
+	}
+	if alias == "" {
+		alias = name[strings.LastIndex(name, "/")+1:]
+	}
+	return
+}
+
+// getImagePolicy converts the policy in the form of semver:<range>, alphabetical:<order> or numerical:<order>
+// to the policy of FluxCD ImagePolicy
+func getImagePolicy(policy string) (map[string]interface{}, error) {
+	index := strings.Index(policy, ":")
+	if index < 0 {
+		return nil, fmt.Errorf("the policy %q should be in the form of <type>:<value>", policy)
+	}
+	policyType, value := policy[:index], policy[index+1:]
+	switch policyType {
+	case "semver":
+		return map[string]interface{}{"semver": map[string]interface{}{"range": value}}, nil
+	case "alphabetical", "numerical":
+		if value != "asc" && value != "desc" {
+			return nil, fmt.Errorf("the order of the %s policy should be asc or desc instead of %q", policyType, value)
+		}
+		return map[string]interface{}{policyType: map[string]interface{}{"order": value}}, nil
+	}
+	return nil, fmt.Errorf("unsupported policy type %q", policyType)
+}
+
+func getOrDefault(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+func createBareFluxImageObject(kind string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(schema.GroupVersionKind{
+		Group:   "image.toolkit.fluxcd.io",
+		Version: "v1beta1",
+		Kind:    kind,
+	})
+	return obj
+}
+
+// GetName returns the name of this reconciler
+func (r *ImageUpdaterReconciler) GetName() string {
+	return "FluxImageUpdaterReconciler"
+}
+
+// GetGroupName returns the group name of this reconciler
+func (r *ImageUpdaterReconciler) GetGroupName() string {
+	return controllerGroupName
+}
+
+// SetupWithManager setups the reconciler with a manager
+// setup the logger, recorder
+func (r *ImageUpdaterReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.log = ctrl.Log.WithName(r.GetName())
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.GetName()).
+		For(&v1alpha1.ImageUpdater{}).
+		Complete(r)
+}
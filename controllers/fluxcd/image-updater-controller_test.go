@@ -0,0 +1,192 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fluxcd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"kubesphere.io/devops/pkg/api/gitops/v1alpha1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestImageUpdaterReconciler(t *testing.T) {
+	schema, err := v1alpha1.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	updater := &v1alpha1.ImageUpdater{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "updater"},
+		Spec: v1alpha1.ImageUpdaterSpec{
+			Kind:   string(v1alpha1.FluxCD),
+			Images: []string{"ghcr.io/org/app:v1.0.0", "web=docker.io/org/frontend"},
+			Flux: &v1alpha1.FluxImageUpdater{
+				GitRepository: v1.LocalObjectReference{Name: "manifests"},
+				Branch:        "main",
+				Path:          "./deploy",
+				Policies:      map[string]string{"web": "numerical:desc"},
+				AllowTags:     map[string]string{"web": "^build-"},
+				Secrets:       map[string]string{"app": "registry"},
+			},
+		},
+	}
+
+	argoUpdater := updater.DeepCopy()
+	argoUpdater.Spec.Kind = string(v1alpha1.ArgoCD)
+
+	invalidUpdater := updater.DeepCopy()
+	invalidUpdater.Spec.Flux.Policies = map[string]string{"app": "latest"}
+
+	staleRepo := createBareFluxImageObject("ImageRepository")
+	staleRepo.SetNamespace("ns")
+	staleRepo.SetName("updater-removed")
+	staleRepo.SetLabels(map[string]string{imageUpdaterLabelKey: "updater"})
+
+	getObject := func(c client.Client, kind, name string) (*unstructured.Unstructured, error) {
+		obj := createBareFluxImageObject(kind)
+		err := c.Get(context.TODO(), types.NamespacedName{Namespace: "ns", Name: name}, obj)
+		return obj, err
+	}
+
+	tests := []struct {
+		name    string
+		objects []runtime.Object
+		verify  func(t *testing.T, c client.Client, recorder *record.FakeRecorder)
+	}{{
+		name: "not found",
+		verify: func(t *testing.T, c client.Client, recorder *record.FakeRecorder) {
+			assert.Empty(t, recorder.Events)
+		},
+	}, {
+		name:    "not a FluxCD image updater",
+		objects: []runtime.Object{argoUpdater},
+		verify: func(t *testing.T, c client.Client, recorder *record.FakeRecorder) {
+			_, err := getObject(c, "ImageUpdateAutomation", "updater")
+			assert.True(t, apierrors.IsNotFound(err))
+		},
+	}, {
+		name:    "invalid policy",
+		objects: []runtime.Object{invalidUpdater},
+		verify: func(t *testing.T, c client.Client, recorder *record.FakeRecorder) {
+			assert.Contains(t, <-recorder.Events, "InvalidPolicy")
+			_, err := getObject(c, "ImageUpdateAutomation", "updater")
+			assert.True(t, apierrors.IsNotFound(err))
+		},
+	}, {
+		name:    "create the FluxCD image objects",
+		objects: []runtime.Object{updater, staleRepo},
+		verify: func(t *testing.T, c client.Client, recorder *record.FakeRecorder) {
+			repo, err := getObject(c, "ImageRepository", "updater-app")
+			assert.Nil(t, err)
+			assert.Equal(t, "ghcr.io/org/app", repo.Object["spec"].(map[string]interface{})["image"])
+			secret, _, _ := unstructured.NestedString(repo.Object, "spec", "secretRef", "name")
+			assert.Equal(t, "registry", secret)
+			assert.Len(t, repo.GetOwnerReferences(), 1)
+
+			policy, err := getObject(c, "ImagePolicy", "updater-app")
+			assert.Nil(t, err)
+			semverRange, _, _ := unstructured.NestedString(policy.Object, "spec", "policy", "semver", "range")
+			assert.Equal(t, ">=0.0.0", semverRange)
+
+			policy, err = getObject(c, "ImagePolicy", "updater-web")
+			assert.Nil(t, err)
+			order, _, _ := unstructured.NestedString(policy.Object, "spec", "policy", "numerical", "order")
+			assert.Equal(t, "desc", order)
+			pattern, _, _ := unstructured.NestedString(policy.Object, "spec", "filterTags", "pattern")
+			assert.Equal(t, "^build-", pattern)
+
+			automation, err := getObject(c, "ImageUpdateAutomation", "updater")
+			assert.Nil(t, err)
+			source, _, _ := unstructured.NestedString(automation.Object, "spec", "sourceRef", "name")
+			assert.Equal(t, "fluxcd-manifests", source)
+			branch, _, _ := unstructured.NestedString(automation.Object, "spec", "git", "push", "branch")
+			assert.Equal(t, "main", branch)
+			path, _, _ := unstructured.NestedString(automation.Object, "spec", "update", "path")
+			assert.Equal(t, "./deploy", path)
+
+			_, err = getObject(c, "ImageRepository", "updater-removed")
+			assert.True(t, apierrors.IsNotFound(err))
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithRuntimeObjects(tt.objects...).Build()
+			recorder := record.NewFakeRecorder(10)
+			r := &ImageUpdaterReconciler{
+				Client:   c,
+				log:      logr.New(log.NullLogSink{}),
+				recorder: recorder,
+			}
+			_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "ns", Name: "updater"}})
+			assert.Nil(t, err)
+			tt.verify(t, c, recorder)
+		})
+	}
+}
+
+func TestParseImage(t *testing.T) {
+	tests := []struct {
+		image     string
+		wantAlias string
+		wantName  string
+	}{{
+		image:     "nginx",
+		wantAlias: "nginx",
+		wantName:  "nginx",
+	}, {
+		image:     "localhost:5000/org/app:v1",
+		wantAlias: "app",
+		wantName:  "localhost:5000/org/app",
+	}, {
+		image:     "web=docker.io/org/frontend:latest",
+		wantAlias: "web",
+		wantName:  "docker.io/org/frontend",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			alias, name := parseImage(tt.image)
+			assert.Equal(t, tt.wantAlias, alias)
+			assert.Equal(t, tt.wantName, name)
+		})
+	}
+}
+
+func TestGetImagePolicy(t *testing.T) {
+	policy, err := getImagePolicy("semver:~1.2")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"semver": map[string]interface{}{"range": "~1.2"}}, policy)
+
+	policy, err = getImagePolicy("alphabetical:asc")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"alphabetical": map[string]interface{}{"order": "asc"}}, policy)
+
+	for _, invalid := range []string{"latest", "numerical:up", "digest:sha"} {
+		_, err = getImagePolicy(invalid)
+		assert.NotNil(t, err, invalid)
+	}
+}
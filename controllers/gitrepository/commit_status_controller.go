@@ -0,0 +1,255 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitrepository
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/jenkins-x/go-scm/scm"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/git"
+	scmclient "kubesphere.io/devops/pkg/client/scm"
+	"kubesphere.io/devops/pkg/constants"
+	"kubesphere.io/devops/pkg/utils/net"
+)
+
+const (
+	// defaultCommitStatusLabel is the default context of the commit statuses
+	defaultCommitStatusLabel = "KubeSphere DevOps"
+	// defaultRevisionParameter is the default PipelineRun parameter which carries the commit SHA
+	defaultRevisionParameter = "GIT_COMMIT"
+	// pipelineSCMAnnoKey is the annotation key of the git URL of a Pipeline which is triggered by webhooks
+	pipelineSCMAnnoKey = "scm.devops.kubesphere.io"
+
+	// commitStatusReported indicates that the commit status has been reported to the SCM
+	commitStatusReported = "CommitStatusReported"
+	// failedReportCommitStatus indicates that it failed to report the commit status to the SCM
+	failedReportCommitStatus = "FailedReportCommitStatus"
+)
+
+// ProviderFactory creates a SCM provider
+type ProviderFactory func(name, server string, secretRef *v1.SecretReference, k8sClient git.ResourceGetter) (scmclient.Provider, error)
+
+// CommitStatusReconciler reports the phase of PipelineRuns to the associated revision as commit statuses,
+// according to the CommitStatus of the DevOpsProject
+type CommitStatusReconciler struct {
+	client.Client
+	ExternalAddress string
+	ClusterName     string
+
+	// NewProvider creates the SCM provider, scmclient.NewProviderFromSecret will be used if it's nil
+	NewProvider ProviderFactory
+
+	log      logr.Logger
+	recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns,verbs=get;list;watch;patch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelines;devopsprojects,verbs=get
+//+kubebuilder:rbac:groups="",resources=namespaces;secrets,verbs=get
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile is the main entry of this reconciler
+func (r *CommitStatusReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	pipelineRun := &v1alpha3.PipelineRun{}
+	if err = r.Get(ctx, req.NamespacedName, pipelineRun); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+
+	phase := pipelineRun.Status.Phase
+	if phase == "" || pipelineRun.Annotations[v1alpha3.PipelineRunCommitStatusAnnoKey] == string(phase) {
+		return
+	}
+	// the pull requests of multi-branch Pipelines are taken care of by the PullRequestStatusReconciler
+	if pipelineRun.Spec.IsMultiBranchPipeline() && pipelineRun.Spec.SCM != nil {
+		if _, prErr := getPRNumber(pipelineRun.Spec.SCM.RefName); prErr == nil {
+			return
+		}
+	}
+
+	var project *v1alpha3.DevOpsProject
+	if project, err = r.getProject(ctx, pipelineRun.Namespace); err != nil || project == nil ||
+		project.Spec.CommitStatus == nil {
+		return
+	}
+	commitStatus := project.Spec.CommitStatus
+
+	revision := getRevision(pipelineRun, commitStatus.RevisionParameter)
+	repo := r.getRepo(ctx, pipelineRun)
+	if revision == "" || repo == "" {
+		return
+	}
+
+	var secretRef *v1.SecretReference
+	if commitStatus.Secret != nil {
+		secretRef = commitStatus.Secret.DeepCopy()
+		if secretRef.Namespace == "" {
+			secretRef.Namespace = pipelineRun.Namespace
+		}
+	}
+	newProvider := r.NewProvider
+	if newProvider == nil {
+		newProvider = scmclient.NewProviderFromSecret
+	}
+
+	var provider scmclient.Provider
+	if provider, err = newProvider(commitStatus.Provider, commitStatus.Server, secretRef, r.Client); err == nil {
+		err = provider.CreateStatus(ctx, repo, revision, &scm.StatusInput{
+			State:  convertPipelineRunPhaseToSCMStatus(phase),
+			Label:  getOrDefault(commitStatus.Label, defaultCommitStatusLabel),
+			Desc:   string(phase),
+			Target: r.getTarget(project, pipelineRun),
+		})
+	}
+	if err != nil {
+		r.recorder.Eventf(pipelineRun, v1.EventTypeWarning, failedReportCommitStatus,
+			"Failed to report the status %s to %s@%s, error was %v", phase, repo, revision, err)
+		return
+	}
+	r.recorder.Eventf(pipelineRun, v1.EventTypeNormal, commitStatusReported,
+		"Reported the status %s to %s@%s", phase, repo, revision)
+
+	// record the reported phase to avoid reporting the same status again
+	patch := client.MergeFrom(pipelineRun.DeepCopy())
+	if pipelineRun.Annotations == nil {
+		pipelineRun.Annotations = map[string]string{}
+	}
+	pipelineRun.Annotations[v1alpha3.PipelineRunCommitStatusAnnoKey] = string(phase)
+	err = client.IgnoreNotFound(r.Patch(ctx, pipelineRun, patch))
+	return
+}
+
+// getProject returns the DevOpsProject which owns the namespace, or nil if there is no such project
+func (r *CommitStatusReconciler) getProject(ctx context.Context, namespace string) (project *v1alpha3.DevOpsProject, err error) {
+	ns := &v1.Namespace{}
+	if err = r.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	projectName := ns.GetLabels()[constants.DevOpsProjectLabelKey]
+	if projectName == "" {
+		return
+	}
+
+	project = &v1alpha3.DevOpsProject{}
+	if err = r.Get(ctx, types.NamespacedName{Name: projectName}, project); err != nil {
+		project = nil
+		err = client.IgnoreNotFound(err)
+	}
+	return
+}
+
+// getRepo returns the repository (owner/repo) of the PipelineRun from the webhook payload,
+// the multi-branch Pipeline, or the git URL of its Pipeline
+func (r *CommitStatusReconciler) getRepo(ctx context.Context, pipelineRun *v1alpha3.PipelineRun) string {
+	if repo := pipelineRun.Annotations[v1alpha3.PipelineRunSCMRepoAnnoKey]; repo != "" {
+		return repo
+	}
+	if pipelineRun.Spec.IsMultiBranchPipeline() {
+		if info := getRepoInfo(pipelineRun.Spec.PipelineSpec.MultiBranchPipeline); info.owner != "" && info.repo != "" {
+			return info.getRepoPath()
+		}
+	}
+	if pipelineRun.Spec.PipelineRef == nil {
+		return ""
+	}
+	pipeline := &v1alpha3.Pipeline{}
+	if err := r.Get(ctx, types.NamespacedName{
+		Namespace: pipelineRun.Namespace, Name: pipelineRun.Spec.PipelineRef.Name,
+	}, pipeline); err != nil {
+		return ""
+	}
+	return getRepoFromGitURL(pipeline.Annotations[pipelineSCMAnnoKey])
+}
+
+func (r *CommitStatusReconciler) getTarget(project *v1alpha3.DevOpsProject, pipelineRun *v1alpha3.PipelineRun) string {
+	if r.ExternalAddress == "" || pipelineRun.Spec.PipelineRef == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/clusters/%s/devops/%s/pipelines/%s/run/%s/task-status",
+		net.ParseURL(r.ExternalAddress), project.GetLabels()["kubesphere.io/workspace"], r.ClusterName,
+		pipelineRun.Namespace, pipelineRun.Spec.PipelineRef.Name, pipelineRun.Name)
+}
+
+// getRevision returns the commit SHA from the webhook payload, or the parameter of the PipelineRun
+func getRevision(pipelineRun *v1alpha3.PipelineRun, parameterName string) string {
+	if revision := pipelineRun.Annotations[v1alpha3.PipelineRunSCMRevisionAnnoKey]; revision != "" {
+		return revision
+	}
+	parameterName = getOrDefault(parameterName, defaultRevisionParameter)
+	for _, parameter := range pipelineRun.Spec.Parameters {
+		if parameter.Name == parameterName {
+			return parameter.Value
+		}
+	}
+	return ""
+}
+
+// getRepoFromGitURL parses the repository (owner/repo) from a git URL, such as
+// https://github.com/owner/repo.git or git@github.com:owner/repo.git
+func getRepoFromGitURL(gitURL string) (repo string) {
+	if gitURL == "" {
+		return
+	}
+	if strings.Contains(gitURL, "://") {
+		if u, err := url.Parse(gitURL); err == nil {
+			repo = u.Path
+		}
+	} else if index := strings.Index(gitURL, ":"); index >= 0 {
+		repo = gitURL[index+1:]
+	}
+	repo = strings.TrimSuffix(strings.Trim(repo, "/"), ".git")
+	return
+}
+
+func getOrDefault(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+// GetName returns the name of this reconciler
+func (r *CommitStatusReconciler) GetName() string {
+	return "commit-status-controller"
+}
+
+// GetGroupName returns the group name of the set of reconcilers
+func (r *CommitStatusReconciler) GetGroupName() string {
+	return groupName
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *CommitStatusReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	r.log = ctrl.Log.WithName(r.GetName())
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.GetName()).
+		For(&v1alpha3.PipelineRun{}).
+		Complete(r)
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitrepository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	mgrcore "kubesphere.io/devops/controllers/core"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/git"
+	scmclient "kubesphere.io/devops/pkg/client/scm"
+	"kubesphere.io/devops/pkg/constants"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type fakeProvider struct {
+	scmclient.Provider
+	err error
+
+	repo, ref string
+	status    *scm.StatusInput
+}
+
+func (p *fakeProvider) CreateStatus(ctx context.Context, repo, ref string, status *scm.StatusInput) error {
+	p.repo, p.ref, p.status = repo, ref, status
+	return p.err
+}
+
+func TestCommitStatusReconciler(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+	err = v1.SchemeBuilder.AddToScheme(schema)
+	assert.Nil(t, err)
+
+	ns := &v1.Namespace{}
+	ns.SetName("ns")
+	ns.SetLabels(map[string]string{constants.DevOpsProjectLabelKey: "project"})
+
+	project := &v1alpha3.DevOpsProject{}
+	project.SetName("project")
+	project.SetLabels(map[string]string{"kubesphere.io/workspace": "ws"})
+	project.Spec.CommitStatus = &v1alpha3.CommitStatus{
+		Provider: scmclient.GitHub,
+		Secret:   &v1.SecretReference{Name: "token"},
+	}
+
+	projectWithoutCommitStatus := project.DeepCopy()
+	projectWithoutCommitStatus.Spec.CommitStatus = nil
+
+	pipeline := &v1alpha3.Pipeline{}
+	pipeline.SetName("pipeline")
+	pipeline.SetNamespace("ns")
+	pipeline.SetAnnotations(map[string]string{pipelineSCMAnnoKey: "https://github.com/octocat/hello-world.git"})
+
+	pipelineRun := &v1alpha3.PipelineRun{}
+	pipelineRun.SetName("run")
+	pipelineRun.SetNamespace("ns")
+	pipelineRun.Spec.PipelineRef = &v1.ObjectReference{Name: "pipeline"}
+	pipelineRun.Spec.Parameters = []v1alpha3.Parameter{{Name: "GIT_COMMIT", Value: "sha"}}
+	pipelineRun.Status.Phase = v1alpha3.Succeeded
+
+	reportedPipelineRun := pipelineRun.DeepCopy()
+	reportedPipelineRun.SetAnnotations(map[string]string{v1alpha3.PipelineRunCommitStatusAnnoKey: string(v1alpha3.Succeeded)})
+
+	webhookPipelineRun := pipelineRun.DeepCopy()
+	webhookPipelineRun.Spec.Parameters = nil
+	webhookPipelineRun.SetAnnotations(map[string]string{
+		v1alpha3.PipelineRunSCMRepoAnnoKey:     "octocat/webhook",
+		v1alpha3.PipelineRunSCMRevisionAnnoKey: "after",
+	})
+
+	tests := []struct {
+		name         string
+		objects      []runtime.Object
+		providerErr  error
+		wantErr      bool
+		wantReported bool
+		wantRepo     string
+		wantRef      string
+	}{{
+		name:    "not found",
+		objects: []runtime.Object{ns, project},
+	}, {
+		name:    "without commit status in the project",
+		objects: []runtime.Object{ns, projectWithoutCommitStatus, pipeline, pipelineRun.DeepCopy()},
+	}, {
+		name:    "has been reported",
+		objects: []runtime.Object{ns, project, pipeline, reportedPipelineRun.DeepCopy()},
+	}, {
+		name:         "report with the revision parameter",
+		objects:      []runtime.Object{ns, project, pipeline, pipelineRun.DeepCopy()},
+		wantReported: true,
+		wantRepo:     "octocat/hello-world",
+		wantRef:      "sha",
+	}, {
+		name:         "report with the webhook payload",
+		objects:      []runtime.Object{ns, project, pipeline, webhookPipelineRun.DeepCopy()},
+		wantReported: true,
+		wantRepo:     "octocat/webhook",
+		wantRef:      "after",
+	}, {
+		name:        "failed to report",
+		objects:     []runtime.Object{ns, project, pipeline, pipelineRun.DeepCopy()},
+		providerErr: errors.New("fake"),
+		wantErr:     true,
+		wantRepo:    "octocat/hello-world",
+		wantRef:     "sha",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithRuntimeObjects(tt.objects...).Build()
+
+			provider := &fakeProvider{err: tt.providerErr}
+			var secretRef *v1.SecretReference
+			r := &CommitStatusReconciler{
+				Client:          c,
+				ExternalAddress: "http://ks.com",
+				ClusterName:     "host",
+				NewProvider: func(name, server string, ref *v1.SecretReference, _ git.ResourceGetter) (scmclient.Provider, error) {
+					secretRef = ref
+					return provider, nil
+				},
+				recorder: &record.FakeRecorder{},
+			}
+			_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "ns", Name: "run"}})
+			if tt.wantErr {
+				assert.NotNil(t, err)
+			} else {
+				assert.Nil(t, err)
+			}
+			assert.Equal(t, tt.wantRepo, provider.repo)
+			assert.Equal(t, tt.wantRef, provider.ref)
+			if tt.wantRef != "" {
+				assert.Equal(t, &v1.SecretReference{Name: "token", Namespace: "ns"}, secretRef)
+				assert.Equal(t, &scm.StatusInput{
+					State:  scm.StateSuccess,
+					Label:  defaultCommitStatusLabel,
+					Desc:   string(v1alpha3.Succeeded),
+					Target: "http://ks.com/ws/clusters/host/devops/ns/pipelines/pipeline/run/run/task-status",
+				}, provider.status)
+			}
+
+			if tt.wantReported {
+				pipelineRun := &v1alpha3.PipelineRun{}
+				assert.Nil(t, c.Get(context.TODO(), types.NamespacedName{Namespace: "ns", Name: "run"}, pipelineRun))
+				assert.Equal(t, string(v1alpha3.Succeeded), pipelineRun.Annotations[v1alpha3.PipelineRunCommitStatusAnnoKey])
+			}
+		})
+	}
+}
+
+func TestGetRepoFromGitURL(t *testing.T) {
+	tests := []struct {
+		gitURL string
+		want   string
+	}{{
+		gitURL: "",
+		want:   "",
+	}, {
+		gitURL: "https://github.com/octocat/hello-world.git",
+		want:   "octocat/hello-world",
+	}, {
+		gitURL: "https://gitlab.com/group/sub/repo",
+		want:   "group/sub/repo",
+	}, {
+		gitURL: "git@github.com:octocat/hello-world.git",
+		want:   "octocat/hello-world",
+	}}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			assert.Equal(t, tt.want, getRepoFromGitURL(tt.gitURL))
+		})
+	}
+}
+
+func TestCommitStatusReconciler_SetupWithManager(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	r := &CommitStatusReconciler{}
+	assert.Nil(t, r.SetupWithManager(&mgrcore.FakeManager{Scheme: schema}))
+	assert.Equal(t, "commit-status-controller", r.GetName())
+	assert.Equal(t, groupName, r.GetGroupName())
+}
@@ -24,11 +24,17 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
 	"kubesphere.io/devops/pkg/client/git"
+	webhookmodel "kubesphere.io/devops/pkg/models/webhook"
 
 	"github.com/go-logr/logr"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 // Reconciler reconciles a GitRepository object
@@ -40,6 +46,7 @@ type Reconciler struct {
 
 //+kubebuilder:rbac:groups=devops.kubesphere.io,resources=webhooks,verbs=get;list;update;patch
 //+kubebuilder:rbac:groups=devops.kubesphere.io,resources=secrets,verbs=get
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
 //+kubebuilder:rbac:groups=devops.kubesphere.io,resources=gitrepositories,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
@@ -109,8 +116,15 @@ func (r *Reconciler) createOrUpdateWebhook(repo *v1alpha3.GitRepository) (err er
 			continue
 		}
 
-		// the token is optional, we can ignore the error
-		webhookToken, _ := r.getTokenFromSecret(repo.Spec.Secret, repo.Namespace)
+		// the webhook secret of the DevOpsProject takes precedence over the token of the git provider
+		var webhookToken string
+		if webhookToken, err = webhookmodel.GetSecretToken(context.TODO(), r.Client, repo.Namespace); err != nil {
+			return
+		}
+		if webhookToken == "" {
+			// the token is optional, we can ignore the error
+			webhookToken, _ = r.getTokenFromSecret(repo.Spec.Secret, repo.Namespace)
+		}
 
 		// TODO users need to add every single event of target git provider if they want to add all of them
 		//   it's possible to have a solution to allow users add all events in an easy way.
@@ -262,5 +276,27 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.log = ctrl.Log.WithName(r.GetName())
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha3.GitRepository{}).
+		Watches(&source.Kind{Type: &v1.Secret{}}, handler.EnqueueRequestsFromMapFunc(r.findGitRepositories),
+			builder.WithPredicates(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+				_, ok := obj.GetLabels()[webhookmodel.SecretLabelKey]
+				return ok
+			}))).
 		Complete(r)
 }
+
+// findGitRepositories returns the GitRepositories with webhooks in the namespace of the webhook secret, their
+// provider webhooks are updated with the generated, rotated or revoked secret
+func (r *Reconciler) findGitRepositories(secret client.Object) (requests []reconcile.Request) {
+	repos := &v1alpha3.GitRepositoryList{}
+	if err := r.Client.List(context.Background(), repos, client.InNamespace(secret.GetNamespace())); err != nil {
+		r.log.Error(err, "failed to list the GitRepositories", "namespace", secret.GetNamespace())
+		return
+	}
+	for i := range repos.Items {
+		if len(repos.Items[i].Spec.Webhooks) > 0 {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Namespace: repos.Items[i].Namespace, Name: repos.Items[i].Name}})
+		}
+	}
+	return
+}
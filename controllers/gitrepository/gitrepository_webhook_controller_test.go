@@ -34,6 +34,7 @@ import (
 	"k8s.io/client-go/tools/record"
 	mgrcore "kubesphere.io/devops/controllers/core"
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	webhookmodel "kubesphere.io/devops/pkg/models/webhook"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -871,6 +872,34 @@ func TestReconciler_Reconcile(t *testing.T) {
 			assert.Nil(t, err)
 			return true
 		},
+	}, {
+		name: "register the webhook with the webhook secret of the DevOpsProject",
+		fields: fields{
+			Client: fake.NewFakeClientWithScheme(schema, repoWithSecret.DeepCopy(), secret.DeepCopy(), webhook.DeepCopy(),
+				&v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: webhookmodel.SecretName},
+					Data:       map[string][]byte{webhookmodel.SecretKey: []byte("project-token")},
+				}),
+		},
+		args: args{req: req},
+		prepare: func() {
+			gock.New("https://api.github.com").
+				Get("/repos/linuxsuren/test/hooks").
+				Reply(200).
+				Type("application/json").
+				File("testdata/hooks.json")
+
+			gock.New("https://api.github.com").
+				Post("/repos/linuxsuren/test/hooks").
+				BodyString(`"secret":"project-token"`).
+				Reply(201).
+				Type("application/json").
+				File("testdata/hook.json")
+		},
+		wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+			assert.Nil(t, err)
+			return true
+		},
 	}}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -891,3 +920,23 @@ func TestReconciler_Reconcile(t *testing.T) {
 		})
 	}
 }
+
+func TestReconciler_findGitRepositories(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	withWebhooks := &v1alpha3.GitRepository{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "with-webhooks"}}
+	withWebhooks.Spec.Webhooks = []v1.LocalObjectReference{{Name: "fake"}}
+	withoutWebhooks := &v1alpha3.GitRepository{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "without-webhooks"}}
+	otherNamespace := withWebhooks.DeepCopy()
+	otherNamespace.Namespace = "other"
+
+	r := &Reconciler{
+		Client: fake.NewClientBuilder().WithScheme(schema).WithObjects(withWebhooks, withoutWebhooks, otherNamespace).Build(),
+		log:    logr.New(log.NullLogSink{}),
+	}
+	requests := r.findGitRepositories(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: webhookmodel.SecretName}})
+	if assert.Len(t, requests, 1) {
+		assert.Equal(t, types.NamespacedName{Namespace: "ns", Name: "with-webhooks"}, requests[0].NamespacedName)
+	}
+}
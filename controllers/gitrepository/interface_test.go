@@ -56,6 +56,12 @@ func TestInterfaceImplement(t *testing.T) {
 			NamedReconciler: &PullRequestStatusReconciler{},
 			GroupReconciler: &PullRequestStatusReconciler{},
 		},
+	}, {
+		name: "PipelineSourceReconciler",
+		instance: interInstance{
+			NamedReconciler: &PipelineSourceReconciler{},
+			GroupReconciler: &PipelineSourceReconciler{},
+		},
 	}}
 	for i := range tests {
 		tt := tests[i]
@@ -0,0 +1,208 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitrepository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	scmclient "kubesphere.io/devops/pkg/client/scm"
+)
+
+const (
+	// pipelineSourceLoaded indicates that the Pipeline definition has been loaded from the git repository
+	pipelineSourceLoaded = "PipelineSourceLoaded"
+	// failedLoadPipelineSource indicates that it failed to load the Pipeline definition from the git repository
+	failedLoadPipelineSource = "FailedLoadPipelineSource"
+)
+
+// PipelineSourceReconciler loads the definition of the Pipelines which refer to a file in a git repository.
+// The definition is reloaded when the source changes, or a push event requests it via an annotation.
+// It does not depend on any backend, the loaded Pipelines are handled by the backend controllers as usual.
+type PipelineSourceReconciler struct {
+	client.Client
+
+	// NewProvider creates the SCM provider, scmclient.NewProviderFromSecret will be used if it's nil
+	NewProvider ProviderFactory
+
+	log      logr.Logger
+	recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelines,verbs=get;list;watch;update
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=gitrepositories,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile is the main entry of this reconciler
+func (r *PipelineSourceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	pipeline := &v1alpha3.Pipeline{}
+	if err = r.Get(ctx, req.NamespacedName, pipeline); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	pipelineSource := pipeline.Spec.Source
+	if pipelineSource == nil || !pipeline.DeletionTimestamp.IsZero() {
+		return
+	}
+	synced := getSyncedSource(pipeline)
+	if pipeline.Annotations[v1alpha3.PipelineSourceSyncedAnnoKey] == synced {
+		return
+	}
+
+	repo := &v1alpha3.GitRepository{}
+	if err = r.Get(ctx, types.NamespacedName{Namespace: pipeline.Namespace, Name: pipelineSource.GitRepository}, repo); err != nil {
+		if apierrors.IsNotFound(err) {
+			// wait for the GitRepository to be created, the watch will bring us back
+			r.recorder.Eventf(pipeline, v1.EventTypeWarning, failedLoadPipelineSource,
+				"The GitRepository %s is not found", pipelineSource.GitRepository)
+			err = nil
+		}
+		return
+	}
+
+	var data []byte
+	if data, err = r.getFileContents(ctx, repo, pipelineSource); err != nil {
+		r.recorder.Eventf(pipeline, v1.EventTypeWarning, failedLoadPipelineSource,
+			"Failed to fetch %s from the GitRepository %s, error was %v", pipelineSource.GetPath(), repo.Name, err)
+		return
+	}
+
+	spec, loadErr := v1alpha3.LoadPipelineSource(data)
+	if loadErr != nil {
+		// there is no point retrying until the definition file is changed
+		r.recorder.Eventf(pipeline, v1.EventTypeWarning, failedLoadPipelineSource, "%v", loadErr)
+		return
+	}
+	spec.Source = pipelineSource
+	if spec.Pipeline != nil && spec.Pipeline.Name == "" {
+		spec.Pipeline.Name = pipeline.Name
+	}
+	if spec.MultiBranchPipeline != nil && spec.MultiBranchPipeline.Name == "" {
+		spec.MultiBranchPipeline.Name = pipeline.Name
+	}
+
+	pipeline.Spec = *spec
+	if pipeline.Annotations == nil {
+		pipeline.Annotations = map[string]string{}
+	}
+	pipeline.Annotations[v1alpha3.PipelineSourceSyncedAnnoKey] = synced
+	if err = r.Update(ctx, pipeline); err != nil {
+		r.log.Error(err, "unable to update the Pipeline from its source", "Pipeline", req.NamespacedName)
+		return
+	}
+	r.recorder.Eventf(pipeline, v1.EventTypeNormal, pipelineSourceLoaded,
+		"Loaded the Pipeline definition from %s of the GitRepository %s", pipelineSource.GetPath(), repo.Name)
+	return
+}
+
+func (r *PipelineSourceReconciler) getFileContents(ctx context.Context, repo *v1alpha3.GitRepository,
+	pipelineSource *v1alpha3.PipelineSource) (data []byte, err error) {
+	repoPath := GetRepoPath(repo)
+	if repoPath == "" {
+		err = fmt.Errorf("cannot find the repository from the GitRepository %s", repo.Name)
+		return
+	}
+
+	var secretRef *v1.SecretReference
+	if repo.Spec.Secret != nil {
+		secretRef = repo.Spec.Secret.DeepCopy()
+		if secretRef.Namespace == "" {
+			secretRef.Namespace = repo.Namespace
+		}
+	}
+	newProvider := r.NewProvider
+	if newProvider == nil {
+		newProvider = scmclient.NewProviderFromSecret
+	}
+
+	var provider scmclient.Provider
+	if provider, err = newProvider(repo.Spec.Provider, repo.Spec.Server, secretRef, r.Client); err == nil {
+		data, err = provider.GetFileContents(ctx, repoPath, pipelineSource.GetPath(), pipelineSource.Ref)
+	}
+	return
+}
+
+// getSyncedSource returns the identity of the source which should be loaded, it changes with
+// the source itself and the revision pushed by the webhook
+func getSyncedSource(pipeline *v1alpha3.Pipeline) string {
+	pipelineSource := pipeline.Spec.Source
+	return fmt.Sprintf("%s/%s@%s#%s", pipelineSource.GitRepository, pipelineSource.GetPath(), pipelineSource.Ref,
+		pipeline.Annotations[v1alpha3.PipelineSourceRevisionAnnoKey])
+}
+
+// GetRepoPath returns the repository (owner/repo) of the GitRepository
+func GetRepoPath(repo *v1alpha3.GitRepository) string {
+	if repo.Spec.Owner != "" && repo.Spec.Repo != "" {
+		return repo.Spec.Owner + "/" + repo.Spec.Repo
+	}
+	return getRepoFromGitURL(repo.Spec.URL)
+}
+
+// findReferringPipelines finds the Pipelines which load their definitions from the changed GitRepository
+func (r *PipelineSourceReconciler) findReferringPipelines(obj client.Object) (requests []reconcile.Request) {
+	pipelineList := &v1alpha3.PipelineList{}
+	if err := r.List(context.Background(), pipelineList, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.log.Error(err, "unable to list Pipelines", "GitRepository", obj.GetName())
+		return
+	}
+	for i := range pipelineList.Items {
+		pipeline := &pipelineList.Items[i]
+		if pipeline.Spec.Source == nil || pipeline.Spec.Source.GitRepository != obj.GetName() {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: pipeline.Namespace,
+			Name:      pipeline.Name,
+		}})
+	}
+	return
+}
+
+// GetName returns the name of this reconciler
+func (r *PipelineSourceReconciler) GetName() string {
+	return "pipeline-source-controller"
+}
+
+// GetGroupName returns the group name of the set of reconcilers
+func (r *PipelineSourceReconciler) GetGroupName() string {
+	return groupName
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PipelineSourceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	r.log = ctrl.Log.WithName(r.GetName())
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.GetName()).
+		For(&v1alpha3.Pipeline{}).
+		Watches(&source.Kind{Type: &v1alpha3.GitRepository{}},
+			handler.EnqueueRequestsFromMapFunc(r.findReferringPipelines)).
+		Complete(r)
+}
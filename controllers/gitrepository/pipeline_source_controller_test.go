@@ -0,0 +1,206 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitrepository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/git"
+	scmclient "kubesphere.io/devops/pkg/client/scm"
+)
+
+type fakeFileProvider struct {
+	scmclient.Provider
+	data []byte
+	err  error
+
+	repo, path, ref string
+}
+
+func (p *fakeFileProvider) GetFileContents(ctx context.Context, repo, path, ref string) ([]byte, error) {
+	p.repo, p.path, p.ref = repo, path, ref
+	return p.data, p.err
+}
+
+func TestPipelineSourceReconciler(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	repo := &v1alpha3.GitRepository{}
+	repo.SetName("repo")
+	repo.SetNamespace("ns")
+	repo.Spec.Provider = scmclient.GitHub
+	repo.Spec.URL = "https://github.com/octocat/hello-world.git"
+	repo.Spec.Secret = &v1.SecretReference{Name: "token"}
+
+	pipeline := &v1alpha3.Pipeline{}
+	pipeline.SetName("pipeline")
+	pipeline.SetNamespace("ns")
+	pipeline.Spec.Type = v1alpha3.NoScmPipelineType
+	pipeline.Spec.Source = &v1alpha3.PipelineSource{GitRepository: "repo", Ref: "master"}
+
+	syncedPipeline := pipeline.DeepCopy()
+	syncedPipeline.SetAnnotations(map[string]string{
+		v1alpha3.PipelineSourceSyncedAnnoKey: "repo/.kubesphere/pipeline.yaml@master#",
+	})
+
+	pushedPipeline := syncedPipeline.DeepCopy()
+	pushedPipeline.Annotations[v1alpha3.PipelineSourceRevisionAnnoKey] = "sha"
+
+	definition := []byte(`spec:
+  type: pipeline
+  pipeline:
+    jenkinsfile: pipeline {}`)
+
+	tests := []struct {
+		name        string
+		objects     []runtime.Object
+		data        []byte
+		providerErr error
+		wantErr     bool
+		wantFetched bool
+		wantSynced  string
+		wantEvent   string
+	}{{
+		name:    "not found",
+		objects: []runtime.Object{repo.DeepCopy()},
+	}, {
+		name:    "has been loaded",
+		objects: []runtime.Object{repo.DeepCopy(), syncedPipeline.DeepCopy()},
+	}, {
+		name:      "the GitRepository does not exist",
+		objects:   []runtime.Object{pipeline.DeepCopy()},
+		wantEvent: failedLoadPipelineSource,
+	}, {
+		name:        "failed to fetch the definition",
+		objects:     []runtime.Object{repo.DeepCopy(), pipeline.DeepCopy()},
+		providerErr: errors.New("fake"),
+		wantErr:     true,
+		wantFetched: true,
+		wantEvent:   failedLoadPipelineSource,
+	}, {
+		name:        "invalid definition",
+		objects:     []runtime.Object{repo.DeepCopy(), pipeline.DeepCopy()},
+		data:        []byte("spec:\n  type: unknown"),
+		wantFetched: true,
+		wantEvent:   failedLoadPipelineSource,
+	}, {
+		name:        "load the definition",
+		objects:     []runtime.Object{repo.DeepCopy(), pipeline.DeepCopy()},
+		data:        definition,
+		wantFetched: true,
+		wantSynced:  "repo/.kubesphere/pipeline.yaml@master#",
+		wantEvent:   pipelineSourceLoaded,
+	}, {
+		name:        "reload the definition after a push",
+		objects:     []runtime.Object{repo.DeepCopy(), pushedPipeline.DeepCopy()},
+		data:        definition,
+		wantFetched: true,
+		wantSynced:  "repo/.kubesphere/pipeline.yaml@master#sha",
+		wantEvent:   pipelineSourceLoaded,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithRuntimeObjects(tt.objects...).Build()
+			recorder := record.NewFakeRecorder(10)
+
+			provider := &fakeFileProvider{data: tt.data, err: tt.providerErr}
+			var secretRef *v1.SecretReference
+			r := &PipelineSourceReconciler{
+				Client: c,
+				NewProvider: func(name, server string, ref *v1.SecretReference, _ git.ResourceGetter) (scmclient.Provider, error) {
+					secretRef = ref
+					return provider, nil
+				},
+				log:      logr.New(log.NullLogSink{}),
+				recorder: recorder,
+			}
+			_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "ns", Name: "pipeline"}})
+			if tt.wantErr {
+				assert.NotNil(t, err)
+			} else {
+				assert.Nil(t, err)
+			}
+
+			if tt.wantFetched {
+				assert.Equal(t, &v1.SecretReference{Name: "token", Namespace: "ns"}, secretRef)
+				assert.Equal(t, "octocat/hello-world", provider.repo)
+				assert.Equal(t, v1alpha3.DefaultPipelineSourcePath, provider.path)
+				assert.Equal(t, "master", provider.ref)
+			} else {
+				assert.Empty(t, provider.repo)
+			}
+
+			if tt.wantEvent == "" {
+				assert.Empty(t, recorder.Events)
+			} else {
+				assert.Contains(t, <-recorder.Events, tt.wantEvent)
+			}
+
+			if tt.wantSynced != "" {
+				result := &v1alpha3.Pipeline{}
+				assert.Nil(t, c.Get(context.TODO(), types.NamespacedName{Namespace: "ns", Name: "pipeline"}, result))
+				assert.Equal(t, tt.wantSynced, result.Annotations[v1alpha3.PipelineSourceSyncedAnnoKey])
+				assert.Equal(t, pipeline.Spec.Source, result.Spec.Source)
+				assert.Equal(t, &v1alpha3.NoScmPipeline{Name: "pipeline", Jenkinsfile: "pipeline {}"}, result.Spec.Pipeline)
+			}
+		})
+	}
+}
+
+func TestPipelineSourceReconciler_findReferringPipelines(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	referring := &v1alpha3.Pipeline{}
+	referring.SetName("referring")
+	referring.SetNamespace("ns")
+	referring.Spec.Source = &v1alpha3.PipelineSource{GitRepository: "repo"}
+
+	other := referring.DeepCopy()
+	other.SetName("other")
+	other.Spec.Source.GitRepository = "other"
+
+	plain := referring.DeepCopy()
+	plain.SetName("plain")
+	plain.Spec.Source = nil
+
+	repo := &v1alpha3.GitRepository{}
+	repo.SetName("repo")
+	repo.SetNamespace("ns")
+
+	r := &PipelineSourceReconciler{
+		Client: fake.NewClientBuilder().WithScheme(schema).WithRuntimeObjects(referring, other, plain).Build(),
+		log:    logr.New(log.NullLogSink{}),
+	}
+	requests := r.findReferringPipelines(repo)
+	assert.Len(t, requests, 1)
+	assert.Equal(t, types.NamespacedName{Namespace: "ns", Name: "referring"}, requests[0].NamespacedName)
+}
@@ -423,7 +423,7 @@ func (s *StatusMaker) CreateWithPipelinePhase(ctx context.Context, phase v1alpha
 
 func convertPipelineRunPhaseToSCMStatus(phase v1alpha3.RunPhase) (status scm.State) {
 	switch phase {
-	case v1alpha3.Pending:
+	case v1alpha3.Pending, v1alpha3.Queued:
 		status = scm.StatePending
 	case v1alpha3.Failed:
 		status = scm.StateFailure
@@ -374,6 +374,10 @@ func TestConvertPipelineRunPhaseToSCMStatus(t *testing.T) {
 		name:       "pendding",
 		phase:      v1alpha3.Pending,
 		wantStatus: scm.StatePending,
+	}, {
+		name:       "queued",
+		phase:      v1alpha3.Queued,
+		wantStatus: scm.StatePending,
 	}, {
 		name:       "unknown",
 		phase:      v1alpha3.Unknown,
@@ -0,0 +1,108 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package history
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/history"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// FailedHistoryArchive is the event reason of failing to save the PipelineRun into the history database
+const FailedHistoryArchive = "FailedHistoryArchive"
+
+// Reconciler saves the summaries of the completed PipelineRuns into the history database,
+// so that they are still available after the PipelineRuns are deleted
+type Reconciler struct {
+	client.Client
+	Store history.Interface
+
+	log      logr.Logger
+	recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns,verbs=get;list;watch;update;patch
+
+// Reconcile saves the summary of a completed PipelineRun, then marks it as archived
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	pr := &v1alpha3.PipelineRun{}
+	if err = r.Get(ctx, req.NamespacedName, pr); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	if !needArchive(pr) {
+		return
+	}
+
+	if err = r.Store.Save(ctx, history.NewRunSummary(pr)); err != nil {
+		r.recorder.Eventf(pr, v1.EventTypeWarning, FailedHistoryArchive, "failed to save the history, error: %v", err)
+		return
+	}
+
+	latest := pr.DeepCopy()
+	if latest.Annotations == nil {
+		latest.Annotations = map[string]string{}
+	}
+	latest.Annotations[v1alpha3.PipelineRunHistoryArchivedAnnoKey] = "true"
+	err = r.Patch(ctx, latest, client.MergeFrom(pr))
+	return
+}
+
+func needArchive(pr *v1alpha3.PipelineRun) bool {
+	if !pr.HasCompleted() {
+		return false
+	}
+	_, archived := pr.Annotations[v1alpha3.PipelineRunHistoryArchivedAnnoKey]
+	return !archived
+}
+
+var archivePredicate = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool {
+		pr, ok := e.Object.(*v1alpha3.PipelineRun)
+		return ok && needArchive(pr)
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		pr, ok := e.ObjectNew.(*v1alpha3.PipelineRun)
+		return ok && needArchive(pr)
+	},
+	DeleteFunc: func(e event.DeleteEvent) bool {
+		return false
+	},
+}
+
+// GetName returns the name of this reconciler
+func (r *Reconciler) GetName() string {
+	return "pipelinerun-history-controller"
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	r.log = ctrl.Log.WithName(r.GetName())
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha3.PipelineRun{}).
+		WithEventFilter(archivePredicate).
+		Complete(r)
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package history
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	fakehistory "kubesphere.io/devops/pkg/client/history/fake"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func newPipelineRun(phase v1alpha3.RunPhase, annotations map[string]string) *v1alpha3.PipelineRun {
+	pr := &v1alpha3.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "ns",
+			Name:        "build-1",
+			UID:         "uid",
+			Labels:      map[string]string{v1alpha3.PipelineNameLabelKey: "build"},
+			Annotations: annotations,
+		},
+		Status: v1alpha3.PipelineRunStatus{Phase: phase},
+	}
+	if phase == v1alpha3.Succeeded || phase == v1alpha3.Failed {
+		now := metav1.Now()
+		pr.Status.StartTime = &now
+		pr.Status.CompletionTime = &now
+	}
+	return pr
+}
+
+func TestReconciler(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name         string
+		pipelineRun  *v1alpha3.PipelineRun
+		storeErr     error
+		wantErr      bool
+		wantSaved    bool
+		wantArchived bool
+	}{{
+		name:        "not completed",
+		pipelineRun: newPipelineRun(v1alpha3.Running, nil),
+	}, {
+		name:         "completed",
+		pipelineRun:  newPipelineRun(v1alpha3.Failed, nil),
+		wantSaved:    true,
+		wantArchived: true,
+	}, {
+		name:         "archived",
+		pipelineRun:  newPipelineRun(v1alpha3.Succeeded, map[string]string{v1alpha3.PipelineRunHistoryArchivedAnnoKey: "true"}),
+		wantArchived: true,
+	}, {
+		name:        "failed to save",
+		pipelineRun: newPipelineRun(v1alpha3.Succeeded, nil),
+		storeErr:    errors.New("database is down"),
+		wantErr:     true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithRuntimeObjects(tt.pipelineRun.DeepCopy()).Build()
+			store := fakehistory.NewStore()
+			store.Err = tt.storeErr
+			r := &Reconciler{
+				Client:   c,
+				Store:    store,
+				log:      logr.New(log.NullLogSink{}),
+				recorder: record.NewFakeRecorder(10),
+			}
+
+			key := types.NamespacedName{Namespace: "ns", Name: "build-1"}
+			_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+			assert.Equal(t, tt.wantErr, err != nil, err)
+
+			summary, saved := store.Summaries["uid"]
+			assert.Equal(t, tt.wantSaved, saved)
+			if saved {
+				assert.Equal(t, "build", summary.Pipeline)
+				assert.Equal(t, string(tt.pipelineRun.Status.Phase), summary.Phase)
+			}
+
+			pr := &v1alpha3.PipelineRun{}
+			assert.Nil(t, c.Get(context.TODO(), key, pr))
+			_, archived := pr.Annotations[v1alpha3.PipelineRunHistoryArchivedAnnoKey]
+			assert.Equal(t, tt.wantArchived, archived)
+		})
+	}
+
+	// reconcile a PipelineRun which does not exist
+	r := &Reconciler{Client: fake.NewClientBuilder().WithScheme(schema).Build(), Store: fakehistory.NewStore()}
+	_, err = r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "ns", Name: "fake"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "pipelinerun-history-controller", r.GetName())
+}
+
+func TestArchivePredicate(t *testing.T) {
+	completed := newPipelineRun(v1alpha3.Succeeded, nil)
+	running := newPipelineRun(v1alpha3.Running, nil)
+	assert.True(t, archivePredicate.Create(event.CreateEvent{Object: completed}))
+	assert.False(t, archivePredicate.Create(event.CreateEvent{Object: running}))
+	assert.True(t, archivePredicate.Update(event.UpdateEvent{ObjectOld: running, ObjectNew: completed}))
+	assert.False(t, archivePredicate.Delete(event.DeleteEvent{Object: completed}))
+}
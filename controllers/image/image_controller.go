@@ -0,0 +1,208 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/registry"
+)
+
+const (
+	// FailedImageResolve is the event reason of failing to resolve an image from its registry
+	FailedImageResolve = "FailedImageResolve"
+	// MissingImageAttachment is the event reason of not finding the SBOM or the attestation of an image
+	MissingImageAttachment = "MissingImageAttachment"
+)
+
+// RegistryFactory creates the client of a registry with the credential, which could be nil
+type RegistryFactory func(ctx context.Context, server string, credential *registry.Credential) registry.Interface
+
+// Reconciler records the images pushed by the completed PipelineRuns into their status, along with the
+// digests and the links to the registries. The images are reported by the results, see v1alpha3.ImageURLResult.
+type Reconciler struct {
+	client.Client
+
+	// NewRegistry creates the registry clients, the type of a registry is detected if it's nil
+	NewRegistry RegistryFactory
+
+	recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns,verbs=get;list;watch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile resolves the images of a completed PipelineRun, then records them into its status
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	pr := &v1alpha3.PipelineRun{}
+	if err = r.Get(ctx, req.NamespacedName, pr); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	if !hasUnresolvedImages(pr) {
+		return
+	}
+
+	var secrets []v1.Secret
+	if secrets, err = r.getRegistrySecrets(ctx, pr.Namespace); err != nil {
+		return
+	}
+	var images []v1alpha3.PipelineRunImage
+	for _, imageResult := range pr.Status.GetImageResults() {
+		ref, parseErr := registry.ParseReference(imageResult.URL)
+		if parseErr != nil {
+			r.recorder.Eventf(pr, v1.EventTypeWarning, FailedImageResolve, "invalid image %s, error: %v", imageResult.URL, parseErr)
+			continue
+		}
+		images = append(images, r.resolve(ctx, pr, ref, imageResult.Digest, secrets))
+	}
+	if len(images) == 0 {
+		return
+	}
+
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() (err error) {
+		latest := &v1alpha3.PipelineRun{}
+		if err = r.Get(ctx, req.NamespacedName, latest); err != nil {
+			return
+		}
+		latest.Status.Images = images
+		return r.Status().Update(ctx, latest)
+	})
+	return
+}
+
+// resolve completes the digest and the link of an image, the image is recorded even if the registry is unreachable
+func (r *Reconciler) resolve(ctx context.Context, pr *v1alpha3.PipelineRun, ref *registry.Reference,
+	digest string, secrets []v1.Secret) (image v1alpha3.PipelineRunImage) {
+	image = v1alpha3.PipelineRunImage{Name: ref.Name(), Tag: ref.Tag, Digest: digest}
+	if image.Digest == "" {
+		image.Digest = ref.Digest
+	}
+
+	registryClient := r.newRegistry(ctx, ref.Registry, registry.FindCredential(secrets, ref.Registry))
+
+	var err error
+	if image.Digest == "" {
+		if image.Digest, err = registryClient.GetDigest(ctx, ref.Repository, ref.Tag); err != nil {
+			r.recorder.Eventf(pr, v1.EventTypeWarning, FailedImageResolve, "failed to get the digest of %s, error: %v", ref.Name(), err)
+			return
+		}
+	}
+	if image.URL, err = registryClient.GetWebURL(ctx, ref.Repository, image.Digest); err != nil && !errors.Is(err, registry.ErrNotSupported) {
+		r.recorder.Eventf(pr, v1.EventTypeWarning, FailedImageResolve, "failed to get the link of %s, error: %v", ref.Name(), err)
+	}
+
+	if pr.Spec.PipelineSpec != nil && pr.Spec.PipelineSpec.SupplyChain != nil {
+		policy := pr.Spec.PipelineSpec.SupplyChain
+		if policy.SBOM {
+			image.SBOM = r.findAttachment(ctx, pr, registryClient, ref, image.Digest, registry.SBOMSuffix)
+		}
+		if policy.Provenance {
+			image.Attestation = r.findAttachment(ctx, pr, registryClient, ref, image.Digest, registry.AttestationSuffix)
+		}
+	}
+	return
+}
+
+// findAttachment returns the reference of an attachment which was pushed to the registry by cosign,
+// or an empty string if it does not exist.
+func (r *Reconciler) findAttachment(ctx context.Context, pr *v1alpha3.PipelineRun, registryClient registry.Interface,
+	ref *registry.Reference, digest, suffix string) string {
+	tag := registry.AttachmentTag(digest, suffix)
+	if _, err := registryClient.GetDigest(ctx, ref.Repository, tag); err != nil {
+		r.recorder.Eventf(pr, v1.EventTypeWarning, MissingImageAttachment, "the %s of %s was not found, error: %v", suffix, ref.Name(), err)
+		return ""
+	}
+	return ref.Name() + ":" + tag
+}
+
+func (r *Reconciler) newRegistry(ctx context.Context, server string, credential *registry.Credential) registry.Interface {
+	if r.NewRegistry != nil {
+		return r.NewRegistry(ctx, server, credential)
+	}
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	return registry.NewClient(registry.DetectType(ctx, server, httpClient), server, credential, httpClient)
+}
+
+// getRegistrySecrets returns the secrets which might have the credentials of the registries
+func (r *Reconciler) getRegistrySecrets(ctx context.Context, namespace string) (secrets []v1.Secret, err error) {
+	secretList := &v1.SecretList{}
+	if err = r.List(ctx, secretList, client.InNamespace(namespace)); err != nil {
+		return
+	}
+	for _, secret := range secretList.Items {
+		switch secret.Type {
+		case v1.SecretTypeDockerConfigJson, v1.SecretTypeDockercfg:
+			secrets = append(secrets, secret)
+		}
+	}
+	return
+}
+
+// hasUnresolvedImages indicates if the PipelineRun completed with the image results which have not been recorded
+func hasUnresolvedImages(pr *v1alpha3.PipelineRun) bool {
+	return pr.HasCompleted() && pr.DeletionTimestamp.IsZero() && len(pr.Status.Images) == 0 &&
+		len(pr.Status.GetImageResults()) > 0
+}
+
+var imagePredicate = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool {
+		pr, ok := e.Object.(*v1alpha3.PipelineRun)
+		return ok && hasUnresolvedImages(pr)
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		newPr, ok := e.ObjectNew.(*v1alpha3.PipelineRun)
+		return ok && hasUnresolvedImages(newPr)
+	},
+	DeleteFunc: func(e event.DeleteEvent) bool {
+		return false
+	},
+}
+
+// GetName returns the name of this reconciler
+func (r *Reconciler) GetName() string {
+	return "pipelinerun-image-controller"
+}
+
+// GetGroupName returns the group name of this reconciler
+func (r *Reconciler) GetGroupName() string {
+	return "image"
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.GetName()).
+		For(&v1alpha3.PipelineRun{}).
+		WithEventFilter(imagePredicate).
+		Complete(r)
+}
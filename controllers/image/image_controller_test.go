@@ -0,0 +1,168 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/registry"
+)
+
+type fakeRegistry struct {
+	registry.Interface
+	server     string
+	credential *registry.Credential
+}
+
+func (f *fakeRegistry) GetDigest(_ context.Context, repository, reference string) (string, error) {
+	if repository == "devops/missing" || (repository == "devops/unsigned" && strings.HasSuffix(reference, ".att")) {
+		return "", fmt.Errorf("not found")
+	}
+	return "sha256:" + reference, nil
+}
+
+func (f *fakeRegistry) GetWebURL(_ context.Context, repository, reference string) (string, error) {
+	if f.server != "harbor.example.com" {
+		return "", registry.ErrNotSupported
+	}
+	return fmt.Sprintf("https://%s/%s/%s", f.server, repository, reference), nil
+}
+
+func TestReconcile(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+	assert.Nil(t, v1.AddToScheme(schema))
+
+	now := metav1.Now()
+	newPipelineRun := func(results ...v1alpha3.RunResult) *v1alpha3.PipelineRun {
+		return &v1alpha3.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "build-1"},
+			Status:     v1alpha3.PipelineRunStatus{CompletionTime: &now, Phase: v1alpha3.Succeeded, Results: results},
+		}
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "harbor"},
+		Type:       v1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			v1.DockerConfigJsonKey: []byte(`{"auths":{"harbor.example.com":{"username":"robot","password":"token"}}}`),
+		},
+	}
+
+	tests := []struct {
+		name            string
+		pipelineRun     *v1alpha3.PipelineRun
+		wantImages      []v1alpha3.PipelineRunImage
+		wantCredentials map[string]*registry.Credential
+	}{{
+		name:        "no images",
+		pipelineRun: newPipelineRun(v1alpha3.RunResult{Name: "VERSION", Value: "1.0.0"}),
+	}, {
+		name: "images with and without digests",
+		pipelineRun: newPipelineRun(
+			v1alpha3.RunResult{Name: "IMAGE_URL", Value: "harbor.example.com/devops/app:v1"},
+			v1alpha3.RunResult{Name: "IMAGE_DIGEST", Value: "sha256:app"},
+			v1alpha3.RunResult{Name: "WEB_IMAGE_URL", Value: "registry.example.com/devops/web:v1"},
+		),
+		wantImages: []v1alpha3.PipelineRunImage{{
+			Name: "harbor.example.com/devops/app", Tag: "v1", Digest: "sha256:app",
+			URL: "https://harbor.example.com/devops/app/sha256:app",
+		}, {
+			Name: "registry.example.com/devops/web", Tag: "v1", Digest: "sha256:v1",
+		}},
+		wantCredentials: map[string]*registry.Credential{
+			"harbor.example.com":   {Username: "robot", Password: "token"},
+			"registry.example.com": nil,
+		},
+	}, {
+		name: "images with the SBOMs and attestations",
+		pipelineRun: func() *v1alpha3.PipelineRun {
+			pr := newPipelineRun(
+				v1alpha3.RunResult{Name: "IMAGE_URL", Value: "harbor.example.com/devops/app:v1"},
+				v1alpha3.RunResult{Name: "IMAGE_DIGEST", Value: "sha256:app"},
+				v1alpha3.RunResult{Name: "WEB_IMAGE_URL", Value: "registry.example.com/devops/unsigned:v1"},
+			)
+			pr.Spec.PipelineSpec = &v1alpha3.PipelineSpec{
+				SupplyChain: &v1alpha3.SupplyChainPolicy{SBOM: true, Provenance: true, SigningKey: "cosign"},
+			}
+			return pr
+		}(),
+		wantImages: []v1alpha3.PipelineRunImage{{
+			Name: "harbor.example.com/devops/app", Tag: "v1", Digest: "sha256:app",
+			URL:         "https://harbor.example.com/devops/app/sha256:app",
+			SBOM:        "harbor.example.com/devops/app:sha256-app.sbom",
+			Attestation: "harbor.example.com/devops/app:sha256-app.att",
+		}, {
+			Name: "registry.example.com/devops/unsigned", Tag: "v1", Digest: "sha256:v1",
+			SBOM: "registry.example.com/devops/unsigned:sha256-v1.sbom",
+		}},
+	}, {
+		name:        "unreachable registry",
+		pipelineRun: newPipelineRun(v1alpha3.RunResult{Name: "IMAGE_URL", Value: "registry.example.com/devops/missing:v1"}),
+		wantImages:  []v1alpha3.PipelineRunImage{{Name: "registry.example.com/devops/missing", Tag: "v1"}},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(tt.pipelineRun, secret).Build()
+			credentials := map[string]*registry.Credential{}
+			r := &Reconciler{
+				Client: c,
+				NewRegistry: func(_ context.Context, server string, credential *registry.Credential) registry.Interface {
+					credentials[server] = credential
+					return &fakeRegistry{server: server, credential: credential}
+				},
+				recorder: &record.FakeRecorder{},
+			}
+			key := client.ObjectKeyFromObject(tt.pipelineRun)
+			_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+			assert.Nil(t, err)
+
+			pipelineRun := &v1alpha3.PipelineRun{}
+			assert.Nil(t, c.Get(context.TODO(), key, pipelineRun))
+			assert.Equal(t, tt.wantImages, pipelineRun.Status.Images)
+			if tt.wantCredentials != nil {
+				assert.Equal(t, tt.wantCredentials, credentials)
+			}
+		})
+	}
+}
+
+func TestHasUnresolvedImages(t *testing.T) {
+	now := metav1.Now()
+	pr := &v1alpha3.PipelineRun{Status: v1alpha3.PipelineRunStatus{
+		Results: []v1alpha3.RunResult{{Name: "IMAGE_URL", Value: "nginx"}},
+	}}
+	assert.False(t, hasUnresolvedImages(pr))
+
+	pr.Status.CompletionTime = &now
+	assert.True(t, hasUnresolvedImages(pr))
+
+	pr.Status.Images = []v1alpha3.PipelineRunImage{{Name: "docker.io/library/nginx"}}
+	assert.False(t, hasUnresolvedImages(pr))
+}
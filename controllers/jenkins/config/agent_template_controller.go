@@ -0,0 +1,225 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	k8s "github.com/jenkins-zh/jenkins-client/pkg/k8s"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/utils/k8sutil"
+	"kubesphere.io/devops/pkg/utils/stringutils"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;update
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=kubernetesagenttemplates,verbs=get;list;watch;update
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=kubernetesagenttemplates/status,verbs=get;update;patch
+
+// AgentTemplateReconciler writes the KubernetesAgentTemplates into the Jenkins CasC ConfigMap,
+// then the Jenkins configuration is reloaded by the jenkinsconfig controller.
+type AgentTemplateReconciler struct {
+	TargetConfigMapName      string
+	TargetConfigMapNamespace string
+	TargetConfigMapKey       string
+	Interval                 time.Duration
+
+	client.Client
+	log      logr.Logger
+	recorder record.EventRecorder
+}
+
+// Reconcile is the entrypoint of this reconciler
+func (r *AgentTemplateReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	r.log.V(5).Info("start to reconcile KubernetesAgentTemplate", "resource", req)
+
+	agentTemplate := &v1alpha3.KubernetesAgentTemplate{}
+	if err = r.Get(ctx, req.NamespacedName, agentTemplate); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+
+	if agentTemplate.DeletionTimestamp.IsZero() && k8sutil.AddFinalizer(&agentTemplate.ObjectMeta, agentTemplateFinalizer) {
+		if err = r.Update(ctx, agentTemplate); err != nil {
+			return
+		}
+	}
+
+	if agentTemplate.DeletionTimestamp.IsZero() {
+		if validateErr := agentTemplate.Validate(); validateErr != nil {
+			r.recorder.Eventf(agentTemplate, v1.EventTypeWarning, "Invalid", validateErr.Error())
+			err = r.updateStatus(ctx, agentTemplate, v1alpha3.AgentTemplatePhaseInvalid, validateErr.Error())
+			return
+		}
+	}
+
+	// get the Jenkins CasC data that we will manipulate
+	cm := &v1.ConfigMap{}
+	if err = r.Get(ctx, types.NamespacedName{
+		Namespace: r.TargetConfigMapNamespace,
+		Name:      r.TargetConfigMapName,
+	}, cm); err != nil {
+		// we will handle it only when the cm exists
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	data := strings.TrimSpace(cm.Data[r.TargetConfigMapKey])
+	if data == "" {
+		r.log.V(7).Info("skip update cm due to expect key is empty", "resource", req)
+		return
+	}
+	casc := k8s.JenkinsConfig{
+		Config: []byte(data),
+	}
+
+	if !agentTemplate.DeletionTimestamp.IsZero() {
+		if err = casc.RemovePodTemplate(agentTemplate.Name); err == nil {
+			if err = r.updateConfigMap(ctx, cm, data, casc.GetConfigAsString()); err == nil {
+				k8sutil.RemoveFinalizer(&agentTemplate.ObjectMeta, agentTemplateFinalizer)
+				err = r.Update(ctx, agentTemplate)
+			}
+		}
+		return
+	}
+
+	var podTemplate *v1.PodTemplate
+	if podTemplate, err = ToPodTemplate(agentTemplate); err != nil {
+		return
+	}
+	if err = casc.ReplaceOrAddPodTemplate(podTemplate); err != nil {
+		return
+	}
+	if err = r.updateConfigMap(ctx, cm, data, casc.GetConfigAsString()); err != nil {
+		return
+	}
+	if err = r.updateStatus(ctx, agentTemplate, v1alpha3.AgentTemplatePhaseReady, ""); err == nil {
+		// make sure the templates always could be in the Jenkins CasC
+		result = ctrl.Result{RequeueAfter: r.Interval}
+	}
+	return
+}
+
+// updateConfigMap writes back the CasC data only if it's changed, the jenkinsconfig controller reloads Jenkins once
+// the ConfigMap changed
+func (r *AgentTemplateReconciler) updateConfigMap(ctx context.Context, cm *v1.ConfigMap, oldData, newData string) error {
+	if strings.TrimSpace(newData) == oldData {
+		return nil
+	}
+	cm.Data[r.TargetConfigMapKey] = newData
+	return r.Update(ctx, cm)
+}
+
+func (r *AgentTemplateReconciler) updateStatus(ctx context.Context, agentTemplate *v1alpha3.KubernetesAgentTemplate,
+	phase, message string) error {
+	status := v1alpha3.KubernetesAgentTemplateStatus{
+		Phase:              phase,
+		Message:            message,
+		ObservedGeneration: agentTemplate.Generation,
+	}
+	if agentTemplate.Status == status {
+		return nil
+	}
+	agentTemplate.Status = status
+	return r.Status().Update(ctx, agentTemplate)
+}
+
+// ToPodTemplate converts a KubernetesAgentTemplate to a PodTemplate which is understood by the Jenkins client.
+// The fields which are not supported by the PodTemplate conversion are put into the raw pod YAML of Jenkins.
+func ToPodTemplate(agentTemplate *v1alpha3.KubernetesAgentTemplate) (podTemplate *v1.PodTemplate, err error) {
+	spec := agentTemplate.Spec
+	podTemplate = &v1.PodTemplate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        agentTemplate.Name,
+			Namespace:   spec.GetNamespace(),
+			Annotations: map[string]string{},
+		},
+	}
+	if len(spec.Labels) > 0 {
+		podTemplate.Annotations["jenkins.agent.labels"] = strings.Join(spec.Labels, " ")
+	}
+	if spec.InheritFrom != "" {
+		podTemplate.Annotations["inherit.from"] = spec.InheritFrom
+	}
+	if spec.IdleMinutes > 0 {
+		podTemplate.Annotations["idleMinutes"] = strconv.Itoa(int(spec.IdleMinutes))
+	}
+
+	rawPod := &v1.Pod{}
+	rawPod.APIVersion, rawPod.Kind = "v1", "Pod"
+	rawPod.Spec.NodeSelector = spec.NodeSelector
+	rawPod.Spec.Tolerations = spec.Tolerations
+	rawPod.Spec.Volumes = spec.Volumes
+	for _, container := range spec.Containers {
+		privileged := container.Privileged
+		podTemplate.Template.Spec.Containers = append(podTemplate.Template.Spec.Containers, v1.Container{
+			Name:            container.Name,
+			Image:           container.Image,
+			Command:         container.Command,
+			Args:            container.Args,
+			Resources:       container.Resources,
+			SecurityContext: &v1.SecurityContext{Privileged: &privileged},
+		})
+		if len(container.VolumeMounts) > 0 {
+			rawPod.Spec.Containers = append(rawPod.Spec.Containers, v1.Container{
+				Name:         container.Name,
+				VolumeMounts: container.VolumeMounts,
+			})
+		}
+	}
+
+	if len(rawPod.Spec.NodeSelector) > 0 || len(rawPod.Spec.Tolerations) > 0 || len(rawPod.Spec.Volumes) > 0 {
+		var data []byte
+		if data, err = yaml.Marshal(rawPod); err != nil {
+			return
+		}
+		podTemplate.Annotations["containers.yaml"] = string(data)
+	}
+	return
+}
+
+// GetName returns the name of this reconcile
+func (r *AgentTemplateReconciler) GetName() string {
+	return "kubernetes-agent-template"
+}
+
+// GetGroupName returns the group name of this reconcile
+func (r *AgentTemplateReconciler) GetGroupName() string {
+	return reconcilerGroupName
+}
+
+// SetupWithManager setups the reconciler
+func (r *AgentTemplateReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.log = ctrl.Log.WithName(r.GetName())
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	r.TargetConfigMapName = stringutils.SetOrDefault(r.TargetConfigMapName, jenkinsConfigName)
+	r.TargetConfigMapNamespace = stringutils.SetOrDefault(r.TargetConfigMapNamespace, "kubesphere-devops-system")
+	r.TargetConfigMapKey = stringutils.SetOrDefault(r.TargetConfigMapKey, jenkinsUserYamlKey)
+	if r.Interval == 0 {
+		r.Interval = 5 * time.Minute
+	}
+	return ctrl.NewControllerManagedBy(mgr).For(&v1alpha3.KubernetesAgentTemplate{}).Complete(r)
+}
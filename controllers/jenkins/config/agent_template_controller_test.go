@@ -0,0 +1,172 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	mgrcore "kubesphere.io/devops/controllers/core"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newAgentTemplate() *v1alpha3.KubernetesAgentTemplate {
+	return &v1alpha3.KubernetesAgentTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "nodejs", ResourceVersion: "999"},
+		Spec: v1alpha3.KubernetesAgentTemplateSpec{
+			Labels:      []string{"node", "nodejs16"},
+			IdleMinutes: 5,
+			Containers: []v1alpha3.AgentContainer{{
+				Name:    "nodejs",
+				Image:   "node:16",
+				Command: []string{"cat"},
+				Resources: v1.ResourceRequirements{Limits: v1.ResourceList{
+					v1.ResourceCPU: resource.MustParse("1"),
+				}},
+				VolumeMounts: []v1.VolumeMount{{Name: "cache", MountPath: "/root/.npm"}},
+			}},
+			Volumes: []v1.Volume{{
+				Name:         "cache",
+				VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+			}},
+			NodeSelector: map[string]string{"ci": "true"},
+		},
+	}
+}
+
+func TestToPodTemplate(t *testing.T) {
+	podTemplate, err := ToPodTemplate(newAgentTemplate())
+	assert.Nil(t, err)
+	assert.Equal(t, "nodejs", podTemplate.Name)
+	assert.Equal(t, v1alpha3.DefaultAgentNamespace, podTemplate.Namespace)
+	assert.Equal(t, "node nodejs16", podTemplate.Annotations["jenkins.agent.labels"])
+	assert.Equal(t, "5", podTemplate.Annotations["idleMinutes"])
+	assert.Contains(t, podTemplate.Annotations["containers.yaml"], "nodeSelector")
+	assert.Contains(t, podTemplate.Annotations["containers.yaml"], "/root/.npm")
+	if assert.Equal(t, 1, len(podTemplate.Template.Spec.Containers)) {
+		assert.Equal(t, "node:16", podTemplate.Template.Spec.Containers[0].Image)
+	}
+
+	// there's nothing for the raw YAML
+	agentTemplate := newAgentTemplate()
+	agentTemplate.Spec.Volumes, agentTemplate.Spec.NodeSelector = nil, nil
+	agentTemplate.Spec.Containers[0].VolumeMounts = nil
+	podTemplate, err = ToPodTemplate(agentTemplate)
+	assert.Nil(t, err)
+	assert.NotContains(t, podTemplate.Annotations, "containers.yaml")
+}
+
+func TestAgentTemplateReconciler_Reconcile(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+	err = v1.SchemeBuilder.AddToScheme(schema)
+	assert.Nil(t, err)
+
+	cascData, err := ioutil.ReadFile("testdata/casc.yaml")
+	assert.Nil(t, err)
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "kubesphere-devops-system",
+			Name:            "jenkins-casc-config",
+			ResourceVersion: "999",
+		},
+		Data: map[string]string{"jenkins_user.yaml": string(cascData)},
+	}
+
+	invalid := newAgentTemplate()
+	invalid.Spec.Containers[0].Image = ""
+	now := metav1.Now()
+	deleting := newAgentTemplate()
+	deleting.DeletionTimestamp = &now
+	deleting.Finalizers = []string{agentTemplateFinalizer}
+
+	req := controllerruntime.Request{NamespacedName: types.NamespacedName{Name: "nodejs"}}
+	tests := []struct {
+		name       string
+		objects    []client.Object
+		wantResult controllerruntime.Result
+		verify     func(*testing.T, client.Client)
+	}{{
+		name: "not found",
+	}, {
+		name:    "no related ConfigMap exist",
+		objects: []client.Object{newAgentTemplate()},
+	}, {
+		name:       "write the template into CasC",
+		objects:    []client.Object{newAgentTemplate(), cm.DeepCopy()},
+		wantResult: controllerruntime.Result{RequeueAfter: 5 * time.Minute},
+		verify: func(t *testing.T, c client.Client) {
+			agentTemplate := &v1alpha3.KubernetesAgentTemplate{}
+			assert.Nil(t, c.Get(context.Background(), req.NamespacedName, agentTemplate))
+			assert.Equal(t, []string{agentTemplateFinalizer}, agentTemplate.Finalizers)
+			assert.Equal(t, v1alpha3.AgentTemplatePhaseReady, agentTemplate.Status.Phase)
+
+			casc := &v1.ConfigMap{}
+			assert.Nil(t, c.Get(context.Background(), client.ObjectKeyFromObject(cm), casc))
+			assert.Contains(t, casc.Data["jenkins_user.yaml"], "node:16")
+			assert.Contains(t, casc.Data["jenkins_user.yaml"], "node nodejs16 nodejs")
+		},
+	}, {
+		name:    "invalid template",
+		objects: []client.Object{invalid, cm.DeepCopy()},
+		verify: func(t *testing.T, c client.Client) {
+			agentTemplate := &v1alpha3.KubernetesAgentTemplate{}
+			assert.Nil(t, c.Get(context.Background(), req.NamespacedName, agentTemplate))
+			assert.Equal(t, v1alpha3.AgentTemplatePhaseInvalid, agentTemplate.Status.Phase)
+			assert.NotEmpty(t, agentTemplate.Status.Message)
+
+			casc := &v1.ConfigMap{}
+			assert.Nil(t, c.Get(context.Background(), client.ObjectKeyFromObject(cm), casc))
+			assert.Equal(t, string(cascData), casc.Data["jenkins_user.yaml"])
+		},
+	}, {
+		name:    "remove a deleting template",
+		objects: []client.Object{deleting, cm.DeepCopy()},
+		verify: func(t *testing.T, c client.Client) {
+			agentTemplate := &v1alpha3.KubernetesAgentTemplate{}
+			err := c.Get(context.Background(), req.NamespacedName, agentTemplate)
+			assert.Nil(t, client.IgnoreNotFound(err))
+			assert.Empty(t, agentTemplate.Finalizers)
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(tt.objects...).Build()
+			r := &AgentTemplateReconciler{Client: c}
+			assert.Nil(t, r.SetupWithManager(&mgrcore.FakeManager{Scheme: schema}))
+			r.recorder = record.NewFakeRecorder(10)
+
+			result, err := r.Reconcile(context.Background(), req)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantResult, result)
+			if tt.verify != nil {
+				tt.verify(t, c)
+			}
+		})
+	}
+}
@@ -35,3 +35,5 @@ const (
 const reconcilerGroupName = "jenkins"
 
 const podTemplateFinalizer = "podtemplate.devops.kubesphere.io/finalizer"
+
+const agentTemplateFinalizer = "agenttemplate.devops.kubesphere.io/finalizer"
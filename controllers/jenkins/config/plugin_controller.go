@@ -0,0 +1,214 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/jenkins-zh/jenkins-client/pkg/core"
+	"github.com/jenkins-zh/jenkins-client/pkg/plugin"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/tools/record"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/jwt/token"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=jenkinspluginsets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=jenkinspluginsets/status,verbs=get;update;patch
+
+// PluginManager is the Jenkins plugin manager which is used by the PluginSetReconciler
+type PluginManager interface {
+	GetPlugins(depth int) (*plugin.InstalledPluginList, error)
+	InstallPlugin(names []string) error
+}
+
+// PluginSetReconciler compares the declared JenkinsPluginSet with the installed plugins of Jenkins,
+// reports the drifts and installs the plugins if it's required.
+type PluginSetReconciler struct {
+	JenkinsCore core.JenkinsCore
+	TokenIssuer token.Issuer
+	Interval    time.Duration
+
+	client.Client
+	log                  logr.Logger
+	recorder             record.EventRecorder
+	pluginManagerCreator func() (PluginManager, error)
+}
+
+// Reconcile is the entrypoint of this reconciler
+func (r *PluginSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	r.log.V(5).Info("start to reconcile JenkinsPluginSet", "resource", req)
+
+	pluginSet := &v1alpha3.JenkinsPluginSet{}
+	if err = r.Get(ctx, req.NamespacedName, pluginSet); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	if !pluginSet.DeletionTimestamp.IsZero() {
+		return
+	}
+
+	status := v1alpha3.JenkinsPluginSetStatus{LastCheckTime: &metav1.Time{Time: time.Now()}}
+	if validateErr := pluginSet.Validate(); validateErr != nil {
+		r.recorder.Eventf(pluginSet, v1.EventTypeWarning, "Invalid", validateErr.Error())
+		status.Phase, status.Message = v1alpha3.PluginSetPhaseFailed, validateErr.Error()
+		err = r.updateStatus(ctx, pluginSet, status)
+		return
+	}
+
+	var pluginManager PluginManager
+	var installed *plugin.InstalledPluginList
+	if pluginManager, err = r.pluginManagerCreator(); err == nil {
+		installed, err = pluginManager.GetPlugins(1)
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to get the installed plugins from Jenkins, error: %v", err)
+		status.Phase, status.Message = v1alpha3.PluginSetPhaseFailed, err.Error()
+		_ = r.updateStatus(ctx, pluginSet, status)
+		return
+	}
+
+	status.Drifts = GetPluginDrifts(pluginSet.Spec.Plugins, installed.Plugins)
+	status.Phase = v1alpha3.PluginSetPhaseSynced
+	for _, drift := range status.Drifts {
+		if drift.Type == v1alpha3.PluginDriftInactive {
+			status.RestartRequired = true
+		}
+	}
+	if len(status.Drifts) > 0 {
+		status.Phase = v1alpha3.PluginSetPhaseDrifted
+		r.recorder.Eventf(pluginSet, v1.EventTypeWarning, "Drifted", "%d plugin(s) don't match the declared list", len(status.Drifts))
+	}
+
+	if toInstall := getPluginsToInstall(pluginSet.Spec.Plugins, status.Drifts); pluginSet.Spec.AutoInstall && len(toInstall) > 0 {
+		r.log.Info("install Jenkins plugins", "plugins", toInstall)
+		if installErr := pluginManager.InstallPlugin(toInstall); installErr != nil {
+			status.Phase, status.Message = v1alpha3.PluginSetPhaseFailed, fmt.Sprintf("failed to install plugins, error: %v", installErr)
+			r.recorder.Eventf(pluginSet, v1.EventTypeWarning, "InstallFailed", status.Message)
+		} else {
+			// the updated plugins take effect after a restart of Jenkins
+			status.Phase, status.RestartRequired = v1alpha3.PluginSetPhaseInstalling, true
+			r.recorder.Eventf(pluginSet, v1.EventTypeNormal, "Installing", "installing plugins: %v", toInstall)
+		}
+	}
+
+	if err = r.updateStatus(ctx, pluginSet, status); err == nil {
+		// make sure the drifts could be found even if someone changes the plugins via Jenkins
+		result = ctrl.Result{RequeueAfter: r.Interval}
+	}
+	return
+}
+
+// GetPluginDrifts returns the plugins which don't match the declared ones, the result is sorted by name
+func GetPluginDrifts(desired []v1alpha3.JenkinsPlugin, installed []plugin.InstalledPlugin) (drifts []v1alpha3.PluginDrift) {
+	installedPlugins := make(map[string]plugin.InstalledPlugin, len(installed))
+	for _, item := range installed {
+		installedPlugins[item.ShortName] = item
+	}
+
+	for _, item := range desired {
+		drift := v1alpha3.PluginDrift{Name: item.Name, DesiredVersion: item.Version}
+		installedPlugin, ok := installedPlugins[item.Name]
+		switch {
+		case !ok || installedPlugin.Deleted:
+			drift.Type = v1alpha3.PluginDriftMissing
+		case item.Version != "" && installedPlugin.Version != item.Version:
+			drift.Type = v1alpha3.PluginDriftVersionMismatch
+		case !installedPlugin.Active || !installedPlugin.Enabled:
+			drift.Type = v1alpha3.PluginDriftInactive
+		default:
+			continue
+		}
+		if ok {
+			drift.InstalledVersion = installedPlugin.Version
+		}
+		drifts = append(drifts, drift)
+	}
+	sort.Slice(drifts, func(i, j int) bool {
+		return drifts[i].Name < drifts[j].Name
+	})
+	return
+}
+
+// getPluginsToInstall returns the missing and mismatched plugins in the format of name@version
+func getPluginsToInstall(desired []v1alpha3.JenkinsPlugin, drifts []v1alpha3.PluginDrift) (plugins []string) {
+	desiredPlugins := make(map[string]v1alpha3.JenkinsPlugin, len(desired))
+	for _, item := range desired {
+		desiredPlugins[item.Name] = item
+	}
+	for _, drift := range drifts {
+		if drift.Type == v1alpha3.PluginDriftMissing || drift.Type == v1alpha3.PluginDriftVersionMismatch {
+			plugins = append(plugins, desiredPlugins[drift.Name].String())
+		}
+	}
+	return
+}
+
+func (r *PluginSetReconciler) updateStatus(ctx context.Context, pluginSet *v1alpha3.JenkinsPluginSet,
+	status v1alpha3.JenkinsPluginSetStatus) error {
+	pluginSet.Status = status
+	return r.Status().Update(ctx, pluginSet)
+}
+
+func (r *PluginSetReconciler) newPluginManager() (PluginManager, error) {
+	jenkinsCore := r.JenkinsCore
+	if r.TokenIssuer != nil {
+		// installing plugins requires the administrator permission
+		accessToken, err := r.TokenIssuer.IssueTo(&user.DefaultInfo{Name: "admin"}, token.AccessToken, tokenExpireIn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to issue access token for admin, error was %v", err)
+		}
+		jenkinsCore.UserName, jenkinsCore.Token = "admin", accessToken
+	}
+	return &plugin.Manager{JenkinsCore: jenkinsCore}, nil
+}
+
+// GetName returns the name of this reconciler
+func (r *PluginSetReconciler) GetName() string {
+	return "jenkins-plugin-set"
+}
+
+// GetGroupName returns the group name of this reconciler
+func (r *PluginSetReconciler) GetGroupName() string {
+	return reconcilerGroupName
+}
+
+// SetupWithManager setups the reconciler
+func (r *PluginSetReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.log = ctrl.Log.WithName(r.GetName())
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	if r.pluginManagerCreator == nil {
+		r.pluginManagerCreator = r.newPluginManager
+	}
+	if r.Interval == 0 {
+		r.Interval = 10 * time.Minute
+	}
+	// the status updates are ignored, the periodic requeue takes care of the drifts
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha3.JenkinsPluginSet{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jenkins-zh/jenkins-client/pkg/plugin"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	mgrcore "kubesphere.io/devops/controllers/core"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type fakePluginManager struct {
+	plugins    []plugin.InstalledPlugin
+	getErr     error
+	installErr error
+	installed  []string
+}
+
+func (f *fakePluginManager) GetPlugins(int) (*plugin.InstalledPluginList, error) {
+	return &plugin.InstalledPluginList{Plugins: f.plugins}, f.getErr
+}
+
+func (f *fakePluginManager) InstallPlugin(names []string) error {
+	f.installed = append(f.installed, names...)
+	return f.installErr
+}
+
+func newInstalledPlugin(name, version string) plugin.InstalledPlugin {
+	return plugin.InstalledPlugin{
+		Plugin:    plugin.Plugin{Active: true, Enabled: true},
+		ShortName: name,
+		Version:   version,
+	}
+}
+
+func TestGetPluginDrifts(t *testing.T) {
+	inactive := newInstalledPlugin("git", "4.11.0")
+	inactive.Active = false
+	drifts := GetPluginDrifts([]v1alpha3.JenkinsPlugin{
+		{Name: "kubernetes", Version: "3600.v144b_cd192ca_a_"},
+		{Name: "workflow-aggregator"},
+		{Name: "git"},
+		{Name: "blueocean", Version: "1.25.2"},
+		{Name: "matrix-auth"},
+	}, []plugin.InstalledPlugin{
+		newInstalledPlugin("kubernetes", "1.30.1"),
+		newInstalledPlugin("workflow-aggregator", "2.6"),
+		newInstalledPlugin("blueocean", "1.25.2"),
+		inactive,
+	})
+	assert.Equal(t, []v1alpha3.PluginDrift{{
+		Name: "git", Type: v1alpha3.PluginDriftInactive, InstalledVersion: "4.11.0",
+	}, {
+		Name: "kubernetes", Type: v1alpha3.PluginDriftVersionMismatch,
+		DesiredVersion: "3600.v144b_cd192ca_a_", InstalledVersion: "1.30.1",
+	}, {
+		Name: "matrix-auth", Type: v1alpha3.PluginDriftMissing,
+	}}, drifts)
+
+	assert.Nil(t, GetPluginDrifts(nil, nil))
+}
+
+func TestPluginSetReconciler_Reconcile(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	newPluginSet := func(autoInstall bool, plugins ...v1alpha3.JenkinsPlugin) *v1alpha3.JenkinsPluginSet {
+		return &v1alpha3.JenkinsPluginSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "default"},
+			Spec:       v1alpha3.JenkinsPluginSetSpec{Plugins: plugins, AutoInstall: autoInstall},
+		}
+	}
+	installedPlugins := []plugin.InstalledPlugin{newInstalledPlugin("git", "4.11.0")}
+
+	req := controllerruntime.Request{NamespacedName: types.NamespacedName{Name: "default"}}
+	tests := []struct {
+		name          string
+		pluginSet     *v1alpha3.JenkinsPluginSet
+		pluginManager *fakePluginManager
+		wantResult    controllerruntime.Result
+		wantErr       bool
+		wantInstalled []string
+		verify        func(*testing.T, v1alpha3.JenkinsPluginSetStatus)
+	}{{
+		name:          "not found",
+		pluginManager: &fakePluginManager{},
+	}, {
+		name:          "invalid plugin set",
+		pluginSet:     newPluginSet(false, v1alpha3.JenkinsPlugin{Name: "git"}, v1alpha3.JenkinsPlugin{Name: "git"}),
+		pluginManager: &fakePluginManager{},
+		verify: func(t *testing.T, status v1alpha3.JenkinsPluginSetStatus) {
+			assert.Equal(t, v1alpha3.PluginSetPhaseFailed, status.Phase)
+			assert.Contains(t, status.Message, "duplicated")
+		},
+	}, {
+		name:          "synced",
+		pluginSet:     newPluginSet(true, v1alpha3.JenkinsPlugin{Name: "git", Version: "4.11.0"}),
+		pluginManager: &fakePluginManager{plugins: installedPlugins},
+		wantResult:    controllerruntime.Result{RequeueAfter: 10 * time.Minute},
+		verify: func(t *testing.T, status v1alpha3.JenkinsPluginSetStatus) {
+			assert.Equal(t, v1alpha3.PluginSetPhaseSynced, status.Phase)
+			assert.Empty(t, status.Drifts)
+			assert.NotNil(t, status.LastCheckTime)
+		},
+	}, {
+		name:          "only report the drifts",
+		pluginSet:     newPluginSet(false, v1alpha3.JenkinsPlugin{Name: "git", Version: "4.12.0"}),
+		pluginManager: &fakePluginManager{plugins: installedPlugins},
+		wantResult:    controllerruntime.Result{RequeueAfter: 10 * time.Minute},
+		verify: func(t *testing.T, status v1alpha3.JenkinsPluginSetStatus) {
+			assert.Equal(t, v1alpha3.PluginSetPhaseDrifted, status.Phase)
+			assert.Equal(t, 1, len(status.Drifts))
+			assert.False(t, status.RestartRequired)
+		},
+	}, {
+		name: "install the missing and mismatched plugins",
+		pluginSet: newPluginSet(true, v1alpha3.JenkinsPlugin{Name: "git", Version: "4.12.0"},
+			v1alpha3.JenkinsPlugin{Name: "kubernetes"}),
+		pluginManager: &fakePluginManager{plugins: installedPlugins},
+		wantResult:    controllerruntime.Result{RequeueAfter: 10 * time.Minute},
+		wantInstalled: []string{"git@4.12.0", "kubernetes"},
+		verify: func(t *testing.T, status v1alpha3.JenkinsPluginSetStatus) {
+			assert.Equal(t, v1alpha3.PluginSetPhaseInstalling, status.Phase)
+			assert.Equal(t, 2, len(status.Drifts))
+			assert.True(t, status.RestartRequired)
+		},
+	}, {
+		name:          "failed to install plugins",
+		pluginSet:     newPluginSet(true, v1alpha3.JenkinsPlugin{Name: "kubernetes"}),
+		pluginManager: &fakePluginManager{installErr: errors.New("fake")},
+		wantResult:    controllerruntime.Result{RequeueAfter: 10 * time.Minute},
+		wantInstalled: []string{"kubernetes"},
+		verify: func(t *testing.T, status v1alpha3.JenkinsPluginSetStatus) {
+			assert.Equal(t, v1alpha3.PluginSetPhaseFailed, status.Phase)
+			assert.Contains(t, status.Message, "fake")
+		},
+	}, {
+		name:          "failed to get plugins from Jenkins",
+		pluginSet:     newPluginSet(true, v1alpha3.JenkinsPlugin{Name: "kubernetes"}),
+		pluginManager: &fakePluginManager{getErr: errors.New("fake")},
+		wantErr:       true,
+		verify: func(t *testing.T, status v1alpha3.JenkinsPluginSetStatus) {
+			assert.Equal(t, v1alpha3.PluginSetPhaseFailed, status.Phase)
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := fake.NewClientBuilder().WithScheme(schema)
+			if tt.pluginSet != nil {
+				builder.WithObjects(tt.pluginSet)
+			}
+			c := builder.Build()
+			r := &PluginSetReconciler{
+				Client: c,
+				pluginManagerCreator: func() (PluginManager, error) {
+					return tt.pluginManager, nil
+				},
+			}
+			assert.Nil(t, r.SetupWithManager(&mgrcore.FakeManager{Scheme: schema}))
+			r.recorder = record.NewFakeRecorder(10)
+
+			result, err := r.Reconcile(context.Background(), req)
+			assert.Equal(t, tt.wantErr, err != nil, err)
+			assert.Equal(t, tt.wantResult, result)
+			assert.Equal(t, tt.wantInstalled, tt.pluginManager.installed)
+			if tt.verify != nil {
+				pluginSet := &v1alpha3.JenkinsPluginSet{}
+				assert.Nil(t, c.Get(context.Background(), client.ObjectKeyFromObject(tt.pluginSet), pluginSet))
+				tt.verify(t, pluginSet.Status)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package devopscredential
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/klog/v2"
+
+	devopsv1alpha3 "kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/constants"
+	"kubesphere.io/devops/pkg/metrics"
+)
+
+// GCMode decides how to handle the orphaned credentials found by the audit
+type GCMode string
+
+const (
+	// GCDisabled only reports the orphaned credentials
+	GCDisabled GCMode = "disabled"
+	// GCDryRun reports what would be cleaned up without changing anything
+	GCDryRun GCMode = "dry-run"
+	// GCEnabled deletes the orphaned credentials in Jenkins, and syncs the missing ones again
+	GCEnabled GCMode = "enabled"
+)
+
+// ParseGCMode parses the GC mode, an empty string means disabled
+func ParseGCMode(mode string) (GCMode, error) {
+	switch GCMode(mode) {
+	case "", GCDisabled:
+		return GCDisabled, nil
+	case GCDryRun, GCEnabled:
+		return GCMode(mode), nil
+	}
+	return "", fmt.Errorf("invalid credential GC mode %q, it could be disabled, dry-run or enabled", mode)
+}
+
+const (
+	// OrphanedCredential is the event reason of the credentials which only exist in Jenkins
+	OrphanedCredential = "OrphanedCredential"
+	// MissingCredential is the event reason of the credentials which are missing in Jenkins
+	MissingCredential = "MissingCredential"
+)
+
+// AuditResult is the difference between the credentials in Jenkins and the secrets of a DevOps project
+type AuditResult struct {
+	Namespace string
+	// OrphanedInJenkins are the IDs of the Jenkins credentials which have no backing secret
+	OrphanedInJenkins []string
+	// MissingInJenkins are the names of the secrets which have no corresponding Jenkins credential
+	MissingInJenkins []string
+}
+
+// Audit compares the Jenkins credentials of a DevOps project with its credential secrets
+func (c *Controller) Audit(namespace string) (result *AuditResult, err error) {
+	var secrets []*v1.Secret
+	if secrets, err = c.secretLister.Secrets(namespace).List(labels.Everything()); err != nil {
+		return
+	}
+	secretNames := map[string]bool{}
+	for _, secret := range secrets {
+		if isJenkinsCredential(secret) && secret.DeletionTimestamp.IsZero() {
+			secretNames[secret.Name] = true
+		}
+	}
+
+	credentials, err := c.devopsClient.ListCredentialsInProject(namespace)
+	if err != nil {
+		err = fmt.Errorf("failed to list the credentials of %s from Jenkins, error: %v", namespace, err)
+		return
+	}
+
+	result = &AuditResult{Namespace: namespace}
+	for _, credential := range credentials {
+		if secretNames[credential.Id] {
+			delete(secretNames, credential.Id)
+		} else {
+			result.OrphanedInJenkins = append(result.OrphanedInJenkins, credential.Id)
+		}
+	}
+	for name := range secretNames {
+		result.MissingInJenkins = append(result.MissingInJenkins, name)
+	}
+	sort.Strings(result.OrphanedInJenkins)
+	sort.Strings(result.MissingInJenkins)
+	return
+}
+
+// auditAll audits all the DevOps projects, then cleans up the orphaned credentials according to the GC mode
+func (c *Controller) auditAll() {
+	requirement, err := labels.NewRequirement(constants.DevOpsProjectLabelKey, selection.Exists, nil)
+	if err != nil {
+		klog.Error(err)
+		return
+	}
+	namespaces, err := c.namespaceLister.List(labels.NewSelector().Add(*requirement))
+	if err != nil {
+		klog.Errorf("failed to list the DevOps project namespaces, error: %v", err)
+		return
+	}
+
+	metrics.OrphanedCredentials.Reset()
+	for _, namespace := range namespaces {
+		if !isDevOpsProjectAdminNamespace(namespace) {
+			continue
+		}
+		result, err := c.Audit(namespace.Name)
+		if err != nil {
+			klog.Error(err)
+			continue
+		}
+		metrics.OrphanedCredentials.WithLabelValues(namespace.Name, "jenkins").Set(float64(len(result.OrphanedInJenkins)))
+		metrics.OrphanedCredentials.WithLabelValues(namespace.Name, "kubernetes").Set(float64(len(result.MissingInJenkins)))
+		c.cleanup(namespace, result)
+	}
+}
+
+// cleanup deletes the orphaned Jenkins credentials, and resets the sync status of the missing ones,
+// then the missing credentials will be created again in the next round of sync.
+// The secrets are the source of truth, so they are never deleted.
+func (c *Controller) cleanup(namespace *v1.Namespace, result *AuditResult) {
+	for _, id := range result.OrphanedInJenkins {
+		switch c.GCMode {
+		case GCEnabled:
+			if _, err := c.devopsClient.DeleteCredentialInProject(namespace.Name, id); err != nil {
+				klog.Errorf("failed to delete the orphaned credential %s/%s, error: %v", namespace.Name, id, err)
+				continue
+			}
+			c.eventRecorder.Eventf(namespace, v1.EventTypeNormal, OrphanedCredential,
+				"Deleted the Jenkins credential %s which has no backing secret", id)
+		case GCDryRun:
+			c.eventRecorder.Eventf(namespace, v1.EventTypeNormal, OrphanedCredential,
+				"Would delete the Jenkins credential %s which has no backing secret (dry-run)", id)
+		default:
+			c.eventRecorder.Eventf(namespace, v1.EventTypeWarning, OrphanedCredential,
+				"The Jenkins credential %s has no backing secret", id)
+		}
+	}
+
+	for _, name := range result.MissingInJenkins {
+		secret, err := c.secretLister.Secrets(namespace.Name).Get(name)
+		if err != nil {
+			continue
+		}
+		switch c.GCMode {
+		case GCEnabled:
+			if err = c.resync(secret); err != nil {
+				klog.Errorf("failed to resync the credential %s/%s, error: %v", namespace.Name, name, err)
+			}
+		case GCDryRun:
+			c.eventRecorder.Eventf(secret, v1.EventTypeNormal, MissingCredential,
+				"Would sync the credential into Jenkins again (dry-run)")
+		default:
+			c.eventRecorder.Eventf(secret, v1.EventTypeWarning, MissingCredential,
+				"The credential does not exist in Jenkins")
+		}
+	}
+}
+
+// resync removes the sync status of a secret, then the sync handler creates it in Jenkins again
+func (c *Controller) resync(secret *v1.Secret) (err error) {
+	if _, ok := secret.Annotations[devopsv1alpha3.CredentialSyncStatusAnnoKey]; !ok {
+		return
+	}
+	copySecret := secret.DeepCopy()
+	delete(copySecret.Annotations, devopsv1alpha3.CredentialSyncStatusAnnoKey)
+	if _, err = c.client.CoreV1().Secrets(secret.Namespace).Update(context.Background(), copySecret, metav1.UpdateOptions{}); err == nil {
+		c.eventRecorder.Eventf(secret, v1.EventTypeNormal, MissingCredential,
+			"The credential does not exist in Jenkins, sync it again")
+	}
+	return
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package devopscredential
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	devops "kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/constants"
+)
+
+func TestParseGCMode(t *testing.T) {
+	for input, expected := range map[string]GCMode{
+		"":         GCDisabled,
+		"disabled": GCDisabled,
+		"dry-run":  GCDryRun,
+		"enabled":  GCEnabled,
+	} {
+		mode, err := ParseGCMode(input)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, mode)
+	}
+
+	_, err := ParseGCMode("fake")
+	assert.NotNil(t, err)
+}
+
+func TestAudit(t *testing.T) {
+	const ns = "project-a"
+	tests := []struct {
+		name               string
+		mode               GCMode
+		wantCredentials    []string
+		wantSyncStatusLeft bool
+		wantEvents         int
+	}{{
+		name:               "only report",
+		mode:               GCDisabled,
+		wantCredentials:    []string{"orphan", "synced"},
+		wantSyncStatusLeft: true,
+		wantEvents:         2,
+	}, {
+		name:               "dry-run",
+		mode:               GCDryRun,
+		wantCredentials:    []string{"orphan", "synced"},
+		wantSyncStatusLeft: true,
+		wantEvents:         2,
+	}, {
+		name:            "clean up",
+		mode:            GCEnabled,
+		wantCredentials: []string{"synced"},
+		wantEvents:      2,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namespace := newNamespace(ns, "a")
+			synced := newSecret(ns, "synced", nil, true, false, true)
+			missing := newSecret(ns, "missing", nil, true, false, true)
+			deleting := newDeletingSecret(ns, "deleting")
+			notCredential := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: "orphan"}}
+
+			f := newFixture(t)
+			f.initDevOpsProject = ns
+			f.initCredential = []*v1.Secret{synced, newSecret(ns, "orphan", nil, false, false, false)}
+			f.namespaceLister = []*v1.Namespace{namespace}
+			f.secretLister = []*v1.Secret{synced, missing, deleting, notCredential}
+			f.kubeobjects = []runtime.Object{namespace, synced, missing, deleting, notCredential}
+			c, _, dI := f.newController()
+			recorder := record.NewFakeRecorder(10)
+			c.eventRecorder = recorder
+			c.GCMode = tt.mode
+
+			result, err := c.Audit(ns)
+			assert.Nil(t, err)
+			assert.Equal(t, &AuditResult{
+				Namespace:         ns,
+				OrphanedInJenkins: []string{"orphan"},
+				MissingInJenkins:  []string{"missing"},
+			}, result)
+
+			c.auditAll()
+			credentials, err := dI.ListCredentialsInProject(ns)
+			assert.Nil(t, err)
+			var ids []string
+			for _, credential := range credentials {
+				ids = append(ids, credential.Id)
+			}
+			assert.Equal(t, tt.wantCredentials, ids)
+			assert.Equal(t, tt.wantEvents, len(recorder.Events))
+
+			secret, err := f.kubeclient.CoreV1().Secrets(ns).Get(context.Background(), "missing", metav1.GetOptions{})
+			assert.Nil(t, err)
+			_, ok := secret.Annotations[devops.CredentialSyncStatusAnnoKey]
+			assert.Equal(t, tt.wantSyncStatusLeft, ok)
+		})
+	}
+
+	// not a DevOps project
+	f := newFixture(t)
+	f.namespaceLister = []*v1.Namespace{{
+		ObjectMeta: metav1.ObjectMeta{Name: "fake", Labels: map[string]string{constants.DevOpsProjectLabelKey: "fake"}},
+	}}
+	c, _, _ := f.newController()
+	c.eventRecorder = record.NewFakeRecorder(10)
+	c.auditAll()
+}
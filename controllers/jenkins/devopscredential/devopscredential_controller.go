@@ -43,6 +43,8 @@ import (
 	devopsv1alpha3 "kubesphere.io/devops/pkg/api/devops/v1alpha3"
 
 	devopsClient "kubesphere.io/devops/pkg/client/devops"
+	"kubesphere.io/devops/pkg/client/devops/breaker"
+	"kubesphere.io/devops/pkg/client/secretstore"
 	"kubesphere.io/devops/pkg/constants"
 	"kubesphere.io/devops/pkg/utils"
 	"kubesphere.io/devops/pkg/utils/k8sutil"
@@ -51,6 +53,9 @@ import (
 
 //+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;update;watch
 
+// FailedResolveCredential indicates the controller fails to read the credential from the external secret store
+const FailedResolveCredential = "FailedResolveCredential"
+
 // Controller is the controller for DevOpsProject
 type Controller struct {
 	client           clientset.Interface
@@ -68,6 +73,18 @@ type Controller struct {
 	workerLoopPeriod time.Duration
 
 	devopsClient devopsClient.Interface
+
+	// SecretResolver reads the data of the credentials which refer to the external secret stores
+	SecretResolver *secretstore.Resolver
+
+	// AuditInterval is the period of comparing the Jenkins credentials with the secrets, zero disables the audit
+	AuditInterval time.Duration
+	// GCMode decides how to handle the orphaned credentials found by the audit
+	GCMode GCMode
+
+	// Breaker is the circuit breaker of Jenkins, the secrets are parked while Jenkins is unavailable
+	Breaker *breaker.Breaker
+	parked  *parkedKeys
 }
 
 // NewController creates an instance of the DevOpsProject controller
@@ -100,7 +117,7 @@ func NewController(client clientset.Interface,
 	secretInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			secret, ok := obj.(*v1.Secret)
-			if ok && strings.HasPrefix(string(secret.Type), devopsv1alpha3.DevOpsCredentialPrefix) {
+			if ok && isJenkinsCredential(secret) {
 				v.enqueueSecret(obj)
 			}
 		},
@@ -110,13 +127,13 @@ func NewController(client clientset.Interface,
 			if ook && nok && old.ResourceVersion == new.ResourceVersion {
 				return
 			}
-			if ook && nok && strings.HasPrefix(string(new.Type), devopsv1alpha3.DevOpsCredentialPrefix) {
+			if ook && nok && isJenkinsCredential(new) {
 				v.enqueueSecret(newObj)
 			}
 		},
 		DeleteFunc: func(obj interface{}) {
 			secret, ok := obj.(*v1.Secret)
-			if ok && strings.HasPrefix(string(secret.Type), devopsv1alpha3.DevOpsCredentialPrefix) {
+			if ok && isJenkinsCredential(secret) {
 				v.enqueueSecret(obj)
 			}
 		},
@@ -124,6 +141,13 @@ func NewController(client clientset.Interface,
 	return v
 }
 
+// isJenkinsCredential indicates if the secret is a credential which should be synchronized to Jenkins
+func isJenkinsCredential(secret *v1.Secret) bool {
+	// the cosign keys are only used by Tekton
+	return strings.HasPrefix(string(secret.Type), devopsv1alpha3.DevOpsCredentialPrefix) &&
+		secret.Type != devopsv1alpha3.SecretTypeCosignKey
+}
+
 // enqueueSecret takes a Foo resource and converts it into a namespace/name
 // string which is then put onto the work workqueue. This method should *not* be
 // passed resources of any type other than DevOpsProject.
@@ -154,7 +178,12 @@ func (c *Controller) processNextWorkItem() bool {
 			utilruntime.HandleError(fmt.Errorf("expected string in workqueue but got %#v", obj))
 			return nil
 		}
-		if err := c.syncHandler(key); err != nil {
+		if err := c.syncHandler(key); breaker.IsUnavailable(err) {
+			// don't count the outage of Jenkins as the failures of this secret
+			c.workqueue.Forget(obj)
+			c.park(key)
+			return nil
+		} else if err != nil {
 			c.workqueue.AddRateLimited(key)
 			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
 		}
@@ -195,9 +224,20 @@ func (c *Controller) Run(workers int, stopCh <-chan struct{}) error {
 		return fmt.Errorf("failed to wait for caches to sync")
 	}
 
+	if c.Breaker != nil {
+		c.parked = &parkedKeys{}
+		c.Breaker.OnRecover(c.resume)
+	}
+
 	for i := 0; i < workers; i++ {
 		go wait.Until(c.worker, c.workerLoopPeriod, stopCh)
 	}
+	if c.AuditInterval > 0 {
+		if !cache.WaitForCacheSync(stopCh, c.namespaceSynced) {
+			return fmt.Errorf("failed to wait for caches to sync")
+		}
+		go wait.Until(c.auditAll, c.AuditInterval, stopCh)
+	}
 
 	<-stopCh
 	return nil
@@ -245,9 +285,16 @@ func (c *Controller) syncHandler(key string) error {
 			copySecret.Annotations = map[string]string{}
 		}
 
+		// read the data from the external secret store, the plaintext is never written back into the secret
+		resolvedSecret, err := c.SecretResolver.Resolve(context.Background(), copySecret)
+		if err != nil {
+			c.eventRecorder.Eventf(secret, v1.EventTypeWarning, FailedResolveCredential, "Failed to resolve the credential, err = %v", err)
+			return err
+		}
+
 		//If the sync is successful, return handle
 		if state, ok := copySecret.Annotations[devopsv1alpha3.CredentialSyncStatusAnnoKey]; ok && state == constants.StatusSuccessful {
-			specHash := utils.ComputeHash(copySecret.Data)
+			specHash := utils.ComputeHash(resolvedSecret.Data)
 			oldHash := copySecret.Annotations[devopsv1alpha3.DevOpsCredentialDataHash] // don't need to check if it's nil, only compare if they're different
 			if specHash == oldHash {
 				// it was synced successfully, and there's any change with the Pipeline spec, skip this round
@@ -262,17 +309,17 @@ func (c *Controller) syncHandler(key string) error {
 		}
 		// Check secret config exists, otherwise we will create it.
 		// if secret exists, update config
-		_, err := c.devopsClient.GetCredentialInProject(nsName, copySecret.Name)
+		_, err = c.devopsClient.GetCredentialInProject(nsName, copySecret.Name)
 		if err == nil {
-			if _, ok := copySecret.Annotations[devopsv1alpha3.CredentialAutoSyncAnnoKey]; ok {
-				_, err := c.devopsClient.UpdateCredentialInProject(nsName, copySecret)
+			if _, ok := copySecret.Annotations[devopsv1alpha3.CredentialAutoSyncAnnoKey]; ok || secretstore.IsExternal(copySecret) {
+				_, err := c.devopsClient.UpdateCredentialInProject(nsName, resolvedSecret)
 				if err != nil {
 					klog.V(8).Info(err, fmt.Sprintf("failed to update secret %s ", key))
 					return err
 				}
 			}
 		} else {
-			_, err = c.devopsClient.CreateCredentialInProject(nsName, copySecret)
+			_, err = c.devopsClient.CreateCredentialInProject(nsName, resolvedSecret)
 			if err != nil {
 				klog.V(8).Info(err, fmt.Sprintf("failed to create secret %s ", key))
 				return err
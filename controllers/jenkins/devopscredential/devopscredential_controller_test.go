@@ -347,3 +347,16 @@ func TestNotUpdateCredential(t *testing.T) {
 	f.expectCredential = []*v1.Secret{initSecret}
 	f.run(getKey(expectSecret, t))
 }
+
+func TestIsJenkinsCredential(t *testing.T) {
+	for secretType, want := range map[v1.SecretType]bool{
+		devops.SecretTypeBasicAuth:  true,
+		devops.SecretTypeKubeConfig: true,
+		devops.SecretTypeCosignKey:  false,
+		v1.SecretTypeOpaque:         false,
+	} {
+		if got := isJenkinsCredential(&v1.Secret{Type: secretType}); got != want {
+			t.Errorf("isJenkinsCredential(%s) = %v, want %v", secretType, got, want)
+		}
+	}
+}
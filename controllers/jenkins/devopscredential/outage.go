@@ -0,0 +1,74 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package devopscredential
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// defaultRetryAfter is the delay of retrying the parked secrets when the circuit breaker does not tell one
+const defaultRetryAfter = 30 * time.Second
+
+// parkedKeys keeps the keys of the secrets in order, the duplicated keys are ignored
+type parkedKeys struct {
+	mu   sync.Mutex
+	keys []string
+	set  map[string]bool
+}
+
+func (p *parkedKeys) add(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.set == nil {
+		p.set = map[string]bool{}
+	}
+	if !p.set[key] {
+		p.set[key] = true
+		p.keys = append(p.keys, key)
+	}
+}
+
+func (p *parkedKeys) drain() (keys []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	keys, p.keys, p.set = p.keys, nil, nil
+	return
+}
+
+// park delays the secret until Jenkins recovers. It's retried after the cool-down of the circuit breaker as well,
+// so that a probe request could be sent.
+func (c *Controller) park(key string) {
+	klog.V(4).Infof("Jenkins is unavailable, park the secret '%s'", key)
+	if c.parked != nil {
+		c.parked.add(key)
+	}
+	retryAfter := c.Breaker.RetryAfter()
+	if retryAfter == 0 {
+		retryAfter = defaultRetryAfter
+	}
+	c.workqueue.AddAfter(key, retryAfter)
+}
+
+// resume puts the parked secrets back to the workqueue in order
+func (c *Controller) resume() {
+	for _, key := range c.parked.drain() {
+		c.workqueue.Add(key)
+	}
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package devopscredential
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"kubesphere.io/devops/pkg/client/devops/breaker"
+)
+
+func TestParkAndResume(t *testing.T) {
+	f := newFixture(t)
+	c, _, _ := f.newController()
+	defer c.workqueue.ShutDown()
+	c.Breaker = breaker.New(1, time.Hour, time.Hour)
+	c.parked = &parkedKeys{}
+	c.Breaker.OnRecover(c.resume)
+	c.Breaker.Failure()
+
+	c.park("ns/b")
+	c.park("ns/a")
+	c.park("ns/b")
+	assert.Equal(t, 0, c.workqueue.Len())
+
+	c.Breaker.Success()
+	assert.Equal(t, 2, c.workqueue.Len())
+	first, _ := c.workqueue.Get()
+	second, _ := c.workqueue.Get()
+	assert.Equal(t, []interface{}{"ns/b", "ns/a"}, []interface{}{first, second})
+	assert.Empty(t, c.parked.drain())
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+// imageScanStageName is the name of the injected stage, it is used to avoid injecting the stage twice
+const imageScanStageName = "Image Scan"
+
+// imageScanStageTemplate scans every image parameter with Trivy, then archives the reports as one JSON array.
+// The placeholders are the Trivy image, the report path, and the optional steps which check the critical vulnerabilities.
+const imageScanStageTemplate = `
+    stage('` + imageScanStageName + `') {
+      when {
+        beforeAgent true
+        expression { return params.any { it.key.endsWith('%[1]s') && it.value } }
+      }
+      agent {
+        kubernetes {
+          yaml '''
+apiVersion: v1
+kind: Pod
+spec:
+  containers:
+  - name: trivy
+    image: %[2]s
+    command: ['cat']
+    tty: true
+'''
+        }
+      }
+      steps {
+        container('trivy') {
+          script {
+            def images = params.findAll { it.key.endsWith('%[1]s') && it.value }.collect { it.value }
+            def reports = images.collect { image ->
+              withEnv(["IMAGE=${image}"]) {
+                return sh(script: 'trivy image --quiet --format json "$IMAGE"', returnStdout: true).trim()
+              }
+            }
+            writeFile file: '%[3]s', text: '[' + reports.join(',') + ']'
+            archiveArtifacts artifacts: '%[3]s'%[4]s
+          }
+        }
+      }
+    }
+`
+
+// imageScanFailOnCritical fails the stage if there are any critical vulnerabilities, the database has been
+// downloaded by the previous scanning.
+const imageScanFailOnCritical = `
+            images.each { image ->
+              withEnv(["IMAGE=${image}"]) {
+                sh 'trivy image --quiet --skip-db-update --severity CRITICAL --exit-code 1 "$IMAGE"'
+              }
+            }`
+
+// getDesiredPipeline returns the Pipeline which is synchronized to Jenkins, the image scanning stage
+// is injected into its Jenkinsfile if it's required.
+func getDesiredPipeline(pipeline *v1alpha3.Pipeline) (*v1alpha3.Pipeline, error) {
+	if !pipeline.Spec.ScanImage || pipeline.Spec.Pipeline == nil {
+		return pipeline, nil
+	}
+	jenkinsfile, err := injectImageScanStage(pipeline.Spec.Pipeline.Jenkinsfile, pipeline.Spec.ImageScan)
+	if err != nil {
+		return nil, err
+	}
+	desired := pipeline.DeepCopy()
+	desired.Spec.Pipeline.Jenkinsfile = jenkinsfile
+	return desired, nil
+}
+
+// injectImageScanStage appends the image scanning stage to the stages of a declarative Jenkinsfile
+func injectImageScanStage(jenkinsfile string, policy *v1alpha3.ImageScanPolicy) (string, error) {
+	if strings.Contains(jenkinsfile, "stage('"+imageScanStageName+"')") {
+		return jenkinsfile, nil
+	}
+	end := findStagesEnd(jenkinsfile)
+	if end < 0 {
+		return "", fmt.Errorf("unable to inject the image scanning stage, no stages found in the declarative Jenkinsfile")
+	}
+	check := ""
+	if policy != nil && policy.FailOnCritical {
+		check = imageScanFailOnCritical
+	}
+	stage := fmt.Sprintf(imageScanStageTemplate, v1alpha3.ImageURLResult, policy.GetImage(), v1alpha3.ImageScanReportPath, check)
+	// keep the indent of the closing brace if it's on a separate line
+	if lineStart := strings.LastIndex(jenkinsfile[:end], "\n") + 1; strings.TrimSpace(jenkinsfile[lineStart:end]) == "" {
+		end = lineStart
+		stage = strings.TrimPrefix(stage, "\n")
+	}
+	return jenkinsfile[:end] + stage + jenkinsfile[end:], nil
+}
+
+// findStagesEnd returns the position of the closing brace of the stages block in the pipeline block,
+// or -1 if there's no such block. The strings and comments of groovy are skipped.
+func findStagesEnd(jenkinsfile string) int {
+	depth := 0
+	pipelineDepth, stagesDepth := -1, -1
+	for i := 0; i < len(jenkinsfile); {
+		c := jenkinsfile[i]
+		switch {
+		case strings.HasPrefix(jenkinsfile[i:], "//"):
+			i = skipUntil(jenkinsfile, i+2, "\n")
+		case strings.HasPrefix(jenkinsfile[i:], "/*"):
+			i = skipUntil(jenkinsfile, i+2, "*/")
+		case strings.HasPrefix(jenkinsfile[i:], "'''"), strings.HasPrefix(jenkinsfile[i:], `"""`):
+			i = skipString(jenkinsfile, i+3, jenkinsfile[i:i+3])
+		case c == '\'' || c == '"':
+			i = skipString(jenkinsfile, i+1, string(c))
+		case c == '{':
+			depth++
+			i++
+		case c == '}':
+			if depth == stagesDepth {
+				return i
+			}
+			depth--
+			i++
+		case isIdentifierStart(c):
+			start := i
+			for i < len(jenkinsfile) && isIdentifierPart(jenkinsfile[i]) {
+				i++
+			}
+			if !nextIsBrace(jenkinsfile, i) {
+				continue
+			}
+			switch word := jenkinsfile[start:i]; {
+			case word == "pipeline" && depth == 0 && pipelineDepth < 0:
+				pipelineDepth = depth + 1
+			case word == "stages" && pipelineDepth > 0 && depth == pipelineDepth && stagesDepth < 0:
+				stagesDepth = depth + 1
+			}
+		default:
+			i++
+		}
+	}
+	return -1
+}
+
+// skipUntil returns the position after the terminator, or the end of the text
+func skipUntil(text string, from int, terminator string) int {
+	if index := strings.Index(text[from:], terminator); index >= 0 {
+		return from + index + len(terminator)
+	}
+	return len(text)
+}
+
+// skipString returns the position after the closing quote of a string, the escaped quotes are skipped
+func skipString(text string, from int, quote string) int {
+	for i := from; i < len(text); i++ {
+		if text[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.HasPrefix(text[i:], quote) {
+			return i + len(quote)
+		}
+	}
+	return len(text)
+}
+
+func nextIsBrace(text string, from int) bool {
+	for _, c := range text[from:] {
+		if !unicode.IsSpace(c) {
+			return c == '{'
+		}
+	}
+	return false
+}
+
+func isIdentifierStart(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentifierPart(c byte) bool {
+	return isIdentifierStart(c) || (c >= '0' && c <= '9')
+}
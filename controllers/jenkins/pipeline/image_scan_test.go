@@ -0,0 +1,150 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	devopsv1alpha3 "kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+func Test_findStagesEnd(t *testing.T) {
+	tests := []struct {
+		name        string
+		jenkinsfile string
+		want        string
+	}{{
+		name:        "not a declarative Pipeline",
+		jenkinsfile: "node { stage('build') { sh 'make' } }",
+	}, {
+		name:        "no stages",
+		jenkinsfile: "pipeline { agent any }",
+	}, {
+		name: "simple",
+		jenkinsfile: `pipeline {
+  agent any
+  stages {
+    stage('build') {
+      steps { sh 'make' }
+    }
+  }
+}`,
+		want: "}\n}",
+	}, {
+		name: "braces in strings and comments",
+		jenkinsfile: `// stages { }
+pipeline {
+  /* stages { */
+  environment { A = "}" }
+  stages {
+    stage('build') {
+      steps {
+        sh '''echo "}"'''
+        sh "echo \"}\""
+        echo '}'
+      }
+    }
+  }
+  post { always { echo 'done' } }
+}`,
+		want: "}\n  post { always { echo 'done' } }\n}",
+	}, {
+		name: "nested stages are skipped",
+		jenkinsfile: `pipeline {
+  agent any
+  stages {
+    stage('parallel') {
+      stages {
+        stage('a') { steps { echo 'a' } }
+      }
+    }
+  }
+}`,
+		want: "}\n}",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			end := findStagesEnd(tt.jenkinsfile)
+			if tt.want == "" {
+				assert.Equal(t, -1, end)
+				return
+			}
+			assert.Equal(t, tt.want, tt.jenkinsfile[end:])
+		})
+	}
+}
+
+func Test_injectImageScanStage(t *testing.T) {
+	jenkinsfile := `pipeline {
+  agent any
+  stages {
+    stage('build') {
+      steps { sh 'make' }
+    }
+  }
+}`
+	result, err := injectImageScanStage(jenkinsfile, nil)
+	assert.Nil(t, err)
+	assert.Contains(t, result, "    stage('build') {\n      steps { sh 'make' }\n    }\n    stage('Image Scan') {")
+	assert.Contains(t, result, "image: "+devopsv1alpha3.DefaultImageScanner)
+	assert.Contains(t, result, "archiveArtifacts artifacts: '"+devopsv1alpha3.ImageScanReportPath+"'\n")
+	assert.NotContains(t, result, "--exit-code 1")
+	assert.Equal(t, "  }\n}", result[len(result)-5:])
+
+	// the stage is injected only once
+	again, err := injectImageScanStage(result, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, result, again)
+
+	result, err = injectImageScanStage(jenkinsfile, &devopsv1alpha3.ImageScanPolicy{
+		Image:          "mirror/trivy:latest",
+		FailOnCritical: true,
+	})
+	assert.Nil(t, err)
+	assert.Contains(t, result, "image: mirror/trivy:latest")
+	assert.Contains(t, result, "--severity CRITICAL --exit-code 1")
+
+	_, err = injectImageScanStage("node { sh 'make' }", nil)
+	assert.NotNil(t, err)
+}
+
+func Test_getDesiredPipeline(t *testing.T) {
+	pipeline := &devopsv1alpha3.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "fake"},
+		Spec: devopsv1alpha3.PipelineSpec{
+			Type: devopsv1alpha3.NoScmPipelineType,
+			Pipeline: &devopsv1alpha3.NoScmPipeline{
+				Jenkinsfile: "pipeline { stages { stage('a') { steps { echo 'a' } } } }",
+			},
+		},
+	}
+	desired, err := getDesiredPipeline(pipeline)
+	assert.Nil(t, err)
+	assert.Equal(t, pipeline, desired)
+
+	pipeline.Spec.ScanImage = true
+	desired, err = getDesiredPipeline(pipeline)
+	assert.Nil(t, err)
+	assert.Contains(t, desired.Spec.Pipeline.Jenkinsfile, "stage('Image Scan')")
+	assert.NotContains(t, pipeline.Spec.Pipeline.Jenkinsfile, "stage('Image Scan')")
+
+	pipeline.Spec.Pipeline.Jenkinsfile = "node {}"
+	_, err = getDesiredPipeline(pipeline)
+	assert.NotNil(t, err)
+}
@@ -32,6 +32,7 @@ import (
 	"k8s.io/client-go/util/retry"
 
 	v1alpha3 "kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/backend"
 	"kubesphere.io/devops/pkg/jwt/token"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -250,7 +251,7 @@ func (r *JenkinsfileReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.log = ctrl.Log.WithName(r.GetName())
 	r.recorder = mgr.GetEventRecorderFor(r.GetName())
 	return ctrl.NewControllerManagedBy(mgr).
-		WithEventFilter(jenkinsfilePredicate).
+		WithEventFilter(predicate.And(jenkinsfilePredicate, backend.NewPredicate(backend.Jenkins))).
 		For(&v1alpha3.Pipeline{}).
 		Complete(r)
 }
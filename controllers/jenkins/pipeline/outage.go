@@ -0,0 +1,118 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	devopsv1alpha3 "kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+// defaultRetryAfter is the delay of retrying the parked Pipelines when the circuit breaker does not tell one
+const defaultRetryAfter = 30 * time.Second
+
+// parkedKeys keeps the keys of the Pipelines in order, the duplicated keys are ignored
+type parkedKeys struct {
+	mu   sync.Mutex
+	keys []string
+	set  map[string]bool
+}
+
+func (p *parkedKeys) add(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.set == nil {
+		p.set = map[string]bool{}
+	}
+	if !p.set[key] {
+		p.set[key] = true
+		p.keys = append(p.keys, key)
+	}
+}
+
+func (p *parkedKeys) drain() (keys []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	keys, p.keys, p.set = p.keys, nil, nil
+	return
+}
+
+// park delays the Pipeline until Jenkins recovers. It's retried after the cool-down of the circuit breaker as well,
+// so that a probe request could be sent.
+func (c *Controller) park(key string) {
+	klog.V(4).Infof("Jenkins is unavailable, park the Pipeline '%s'", key)
+	if c.parked != nil {
+		c.parked.add(key)
+	}
+	retryAfter := c.Breaker.RetryAfter()
+	if retryAfter == 0 {
+		retryAfter = defaultRetryAfter
+	}
+	c.workqueue.AddAfter(key, retryAfter)
+}
+
+// resume puts the parked Pipelines back to the workqueue in order
+func (c *Controller) resume() {
+	for _, key := range c.parked.drain() {
+		c.workqueue.Add(key)
+	}
+}
+
+// markBackendUnavailable marks the Pipeline as waiting for Jenkins. The original error is returned so that
+// the Pipeline will be parked.
+func (c *Controller) markBackendUnavailable(pipeline, copyPipeline *devopsv1alpha3.Pipeline, cause error) error {
+	if condition := copyPipeline.Status.GetCondition(devopsv1alpha3.ConditionBackendAvailable); condition == nil ||
+		condition.Status != devopsv1alpha3.ConditionFalse {
+		c.eventRecorder.Eventf(copyPipeline, v1.EventTypeWarning, devopsv1alpha3.BackendUnavailable,
+			"Jenkins is unavailable, the Pipeline will be synchronized once it recovers")
+	}
+	copyPipeline.Status.SetCondition(devopsv1alpha3.Condition{
+		Type:    devopsv1alpha3.ConditionBackendAvailable,
+		Status:  devopsv1alpha3.ConditionFalse,
+		Reason:  devopsv1alpha3.BackendUnavailable,
+		Message: cause.Error(),
+	})
+	if !reflect.DeepEqual(pipeline.Status, copyPipeline.Status) {
+		// keep the old spec hash, the Pipeline has not been synchronized yet
+		if hash, ok := pipeline.Annotations[devopsv1alpha3.PipelineSpecHash]; ok {
+			copyPipeline.Annotations[devopsv1alpha3.PipelineSpecHash] = hash
+		}
+		if err := c.updatePipeline(context.Background(), copyPipeline.Name, copyPipeline.Namespace, copyPipeline); err != nil {
+			klog.Error(err, fmt.Sprintf("failed to update the conditions of pipeline %s/%s", copyPipeline.Namespace, copyPipeline.Name))
+		}
+	}
+	return cause
+}
+
+// markBackendAvailable flips the BackendAvailable condition if the Pipeline was waiting for Jenkins
+func markBackendAvailable(pipeline *devopsv1alpha3.Pipeline) {
+	if condition := pipeline.Status.GetCondition(devopsv1alpha3.ConditionBackendAvailable); condition != nil &&
+		condition.Status == devopsv1alpha3.ConditionFalse {
+		pipeline.Status.SetCondition(devopsv1alpha3.Condition{
+			Type:   devopsv1alpha3.ConditionBackendAvailable,
+			Status: devopsv1alpha3.ConditionTrue,
+			Reason: devopsv1alpha3.BackendRecovered,
+		})
+	}
+}
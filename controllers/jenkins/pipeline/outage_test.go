@@ -0,0 +1,107 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jenkins-zh/jenkins-client/pkg/core"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kubesphere.io/devops/controllers/jenkins/pipelinerun"
+	devops "kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	devopsClient "kubesphere.io/devops/pkg/client/devops"
+	"kubesphere.io/devops/pkg/client/devops/breaker"
+)
+
+// unavailableDevOps rejects the requests of the Pipelines as if the circuit breaker was open
+type unavailableDevOps struct {
+	devopsClient.Interface
+}
+
+func (d *unavailableDevOps) GetProjectPipelineConfig(string, string) (*devops.Pipeline, error) {
+	return nil, breaker.ErrBackendUnavailable
+}
+
+func (d *unavailableDevOps) DeleteProjectPipeline(string, string) (string, error) {
+	return "", breaker.ErrBackendUnavailable
+}
+
+func TestParkAndResume(t *testing.T) {
+	f := newFixture(t)
+	c, _, _, _ := f.newController()
+	defer c.workqueue.ShutDown()
+	c.Breaker = breaker.New(1, time.Hour, time.Hour)
+	c.parked = &parkedKeys{}
+	c.Breaker.OnRecover(c.resume)
+	c.Breaker.Failure()
+
+	c.park("ns/b")
+	c.park("ns/a")
+	c.park("ns/b")
+	assert.Equal(t, 0, c.workqueue.Len())
+
+	c.Breaker.Success()
+	assert.Equal(t, 2, c.workqueue.Len())
+	first, _ := c.workqueue.Get()
+	second, _ := c.workqueue.Get()
+	assert.Equal(t, []interface{}{"ns/b", "ns/a"}, []interface{}{first, second})
+	assert.Empty(t, c.parked.drain())
+}
+
+func TestSyncWhileBackendUnavailable(t *testing.T) {
+	spec := devops.PipelineSpec{Type: devops.NoScmPipelineType, Pipeline: &devops.NoScmPipeline{Name: "test"}}
+	tests := []struct {
+		name     string
+		pipeline *devops.Pipeline
+	}{{
+		name:     "create or update",
+		pipeline: newPipeline("test-123", "test", spec, false, false),
+	}, {
+		name:     "delete",
+		pipeline: newDeletingPipeline("test-123", "test"),
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			f.pipelineLister = append(f.pipelineLister, tt.pipeline)
+			f.objects = append(f.objects, tt.pipeline)
+			c, _, _, dI := f.newController()
+			c.devopsClient = &unavailableDevOps{Interface: dI}
+			c.Backend = pipelinerun.NewBackend(c.devopsClient, core.JenkinsCore{})
+
+			err := c.syncHandler(getKey(tt.pipeline, t))
+			assert.True(t, breaker.IsUnavailable(err))
+
+			pipeline, err := f.client.DevopsV1alpha3().Pipelines("test-123").Get(context.Background(), "test", metav1.GetOptions{})
+			assert.Nil(t, err)
+			condition := pipeline.Status.GetCondition(devops.ConditionBackendAvailable)
+			if assert.NotNil(t, condition) {
+				assert.Equal(t, devops.ConditionFalse, condition.Status)
+				assert.Equal(t, devops.BackendUnavailable, condition.Reason)
+			}
+			assert.Equal(t, []string{devops.PipelineFinalizerName}, pipeline.Finalizers)
+
+			// the condition is flipped once the Pipeline is synchronized
+			markBackendAvailable(pipeline)
+			assert.Equal(t, devops.ConditionTrue, pipeline.Status.GetCondition(devops.ConditionBackendAvailable).Status)
+		})
+	}
+}
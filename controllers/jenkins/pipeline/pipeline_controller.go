@@ -19,14 +19,14 @@ package pipeline
 import (
 	"context"
 	"fmt"
-	"net/http"
 	"reflect"
+	"strings"
 	"time"
 
 	"kubesphere.io/devops/pkg/utils"
 	"kubesphere.io/devops/pkg/utils/sliceutil"
 
-	"github.com/emicklei/go-restful"
+	"github.com/jenkins-zh/jenkins-client/pkg/core"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -43,10 +43,13 @@ import (
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 
+	"kubesphere.io/devops/controllers/jenkins/pipelinerun"
 	devopsv1alpha3 "kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/backend"
 
 	kubesphereclient "kubesphere.io/devops/pkg/client/clientset/versioned"
 	devopsClient "kubesphere.io/devops/pkg/client/devops"
+	"kubesphere.io/devops/pkg/client/devops/breaker"
 	devopsinformers "kubesphere.io/devops/pkg/client/informers/externalversions/devops/v1alpha3"
 	devopslisters "kubesphere.io/devops/pkg/client/listers/devops/v1alpha3"
 	"kubesphere.io/devops/pkg/constants"
@@ -70,6 +73,14 @@ type Controller struct {
 
 	workerLoopPeriod time.Duration
 	devopsClient     devopsClient.Interface
+
+	// Backend creates and deletes the Jenkins jobs of the Pipelines
+	Backend backend.Interface
+	// BackendRouter skips the Pipelines which belong to DevOpsProjects using other backends
+	BackendRouter *backend.Router
+	// Breaker is the circuit breaker of Jenkins, the Pipelines are parked while Jenkins is unavailable
+	Breaker *breaker.Breaker
+	parked  *parkedKeys
 }
 
 // NewController creates the controller instance
@@ -88,6 +99,7 @@ func NewController(client clientset.Interface,
 	v := &Controller{
 		client:              client,
 		devopsClient:        devopsClient,
+		Backend:             pipelinerun.NewBackend(devopsClient, core.JenkinsCore{}),
 		kubesphereClient:    kubesphereClient,
 		workqueue:           workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "pipeline"),
 		devOpsProjectLister: devopsInformer.Lister(),
@@ -152,7 +164,12 @@ func (c *Controller) processNextWorkItem() bool {
 			utilruntime.HandleError(fmt.Errorf("expected string in workqueue but got %#v", obj))
 			return nil
 		}
-		if err := c.syncHandler(key); err != nil {
+		if err := c.syncHandler(key); breaker.IsUnavailable(err) {
+			// don't count the outage of Jenkins as the failures of this Pipeline
+			c.workqueue.Forget(obj)
+			c.park(key)
+			return nil
+		} else if err != nil {
 			c.workqueue.AddRateLimited(key)
 			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
 		}
@@ -191,6 +208,11 @@ func (c *Controller) Run(workers int, stopCh <-chan struct{}) error {
 		return fmt.Errorf("failed to wait for caches to sync")
 	}
 
+	if c.Breaker != nil {
+		c.parked = &parkedKeys{}
+		c.Breaker.OnRecover(c.resume)
+	}
+
 	for i := 0; i < workers; i++ {
 		go wait.Until(c.worker, c.workerLoopPeriod, stopCh)
 	}
@@ -236,6 +258,10 @@ func (c *Controller) syncHandler(key string) error {
 	copyPipeline := pipeline.DeepCopy()
 	// DeletionTimestamp.IsZero() means copyPipeline has not been deleted.
 	if copyPipeline.ObjectMeta.DeletionTimestamp.IsZero() {
+		if handled, err := c.BackendRouter.HandlesObject(context.Background(), copyPipeline, backend.Jenkins); err != nil || !handled {
+			return err
+		}
+
 		// make sure Annotations is not nil
 		if copyPipeline.Annotations == nil {
 			copyPipeline.Annotations = map[string]string{}
@@ -245,7 +271,7 @@ func (c *Controller) syncHandler(key string) error {
 		if state, ok := copyPipeline.Annotations[devopsv1alpha3.PipelineSyncStatusAnnoKey]; ok && state == constants.StatusSuccessful {
 			specHash := utils.ComputeHash(copyPipeline.Spec)
 			oldHash := copyPipeline.Annotations[devopsv1alpha3.PipelineSpecHash] // don't need to check if it's nil, only compare if they're different
-			if specHash == oldHash {
+			if specHash == oldHash && copyPipeline.Status.GetCondition(devopsv1alpha3.ConditionSynced) != nil {
 				klog.V(9).Info(fmt.Sprintf("%s/%s has no changes in spec", copyPipeline.Namespace, copyPipeline.Name))
 				// it was synced successfully, and there's any change with the Pipeline spec, skip this round
 				return nil
@@ -254,47 +280,61 @@ func (c *Controller) syncHandler(key string) error {
 		}
 
 		// https://kubernetes.io/docs/tasks/access-kubernetes-api/custom-resources/custom-resource-definitions/#finalizers
-		if !sliceutil.HasString(copyPipeline.ObjectMeta.Finalizers, devopsv1alpha3.PipelineFinalizerName) {
-			copyPipeline.ObjectMeta.Finalizers = append(copyPipeline.ObjectMeta.Finalizers, devopsv1alpha3.PipelineFinalizerName)
+		copyPipeline.Spec.GetDeletionPolicy().ApplyFinalizers(&copyPipeline.ObjectMeta, devopsv1alpha3.PipelineFinalizerName)
+
+		// the Jenkins job might be different from the Pipeline, such as the injected stages
+		desiredPipeline, err := getDesiredPipeline(copyPipeline)
+		if err != nil {
+			return c.markSyncFailed(pipeline, copyPipeline, err)
 		}
 
 		// Check pipeline config exists, otherwise we will create it.
 		// if pipeline exists, check & update config
 		jenkinsPipeline, err := c.devopsClient.GetProjectPipelineConfig(nsName, pipeline.Name)
-		if err == nil {
-			if !reflect.DeepEqual(jenkinsPipeline.Spec, copyPipeline.Spec) {
-				_, err := c.devopsClient.UpdateProjectPipeline(nsName, copyPipeline)
-				if err != nil {
+		if breaker.IsUnavailable(err) {
+			return c.markBackendUnavailable(pipeline, copyPipeline, err)
+		} else if err == nil {
+			if changes := diffPipelineSpec(&jenkinsPipeline.Spec, &desiredPipeline.Spec); len(changes) > 0 {
+				err := c.Backend.CreatePipeline(context.Background(), desiredPipeline)
+				if breaker.IsUnavailable(err) {
+					return c.markBackendUnavailable(pipeline, copyPipeline, err)
+				} else if err != nil {
 					klog.V(8).Info(err, fmt.Sprintf("failed to update pipeline config %s ", key))
-					return err
+					return c.markSyncFailed(pipeline, copyPipeline, err)
 				}
+				c.eventRecorder.Eventf(copyPipeline, v1.EventTypeNormal, devopsv1alpha3.Updated,
+					"Updated the Jenkins job due to the changes of %s", strings.Join(changes, ", "))
 			} else {
 				klog.V(8).Info(fmt.Sprintf("nothing was changed, pipeline '%v'", copyPipeline.Spec))
 			}
 		} else {
-			_, err = c.devopsClient.CreateProjectPipeline(nsName, copyPipeline)
-			if err != nil {
+			err = c.Backend.CreatePipeline(context.Background(), desiredPipeline)
+			if breaker.IsUnavailable(err) {
+				return c.markBackendUnavailable(pipeline, copyPipeline, err)
+			} else if err != nil {
 				klog.V(8).Info(err, fmt.Sprintf("failed to create copyPipeline %s ", key))
-				return err
+				return c.markSyncFailed(pipeline, copyPipeline, err)
 			}
 		}
 
 		//If there is no early return, then the sync is successful.
 		copyPipeline.Annotations[devopsv1alpha3.PipelineSyncStatusAnnoKey] = constants.StatusSuccessful
+		setSyncConditions(copyPipeline, devopsv1alpha3.ConditionTrue, devopsv1alpha3.SyncSucceeded,
+			"the Pipeline has been synchronized to Jenkins")
+		markBackendAvailable(copyPipeline)
 	} else {
 		// Finalizers processing logic
 		if sliceutil.HasString(copyPipeline.ObjectMeta.Finalizers, devopsv1alpha3.PipelineFinalizerName) {
 			delSuccess := false
-			if _, err := c.devopsClient.DeleteProjectPipeline(nsName, pipeline.Name); err != nil {
-				// the status code should be 404 if the job does not exist
-				if srvErr, ok := err.(restful.ServiceError); ok {
-					delSuccess = srvErr.Code == http.StatusNotFound
-				} else if srvErr, ok := err.(*devopsClient.ErrorResponse); ok {
-					delSuccess = srvErr.Response.StatusCode == http.StatusNotFound
-				} else {
-					klog.Error(fmt.Sprintf("unexpected error type: %v, should be *restful.ServiceError", err))
-				}
-
+			if copyPipeline.Spec.GetDeletionPolicy() != devopsv1alpha3.DeletionPolicyDelete {
+				// the policy was changed after the finalizer had been added, keep the Jenkins job
+				delSuccess = true
+			} else if isTrashed(copyPipeline) {
+				// the Pipeline is moved into the trash bin, the Jenkins job is kept until it's expired
+				delSuccess = true
+			} else if err := c.Backend.DeletePipeline(context.Background(), copyPipeline); breaker.IsUnavailable(err) {
+				return c.markBackendUnavailable(pipeline, copyPipeline, err)
+			} else if err != nil {
 				klog.V(8).Info(err, fmt.Sprintf("failed to delete pipeline %s in devops", key))
 			} else {
 				delSuccess = true
@@ -323,6 +363,46 @@ func (c *Controller) syncHandler(key string) error {
 	return nil
 }
 
+// markSyncFailed records the synchronization error as an event and the conditions of the Pipeline.
+// The original error is returned so that the Pipeline will be requeued.
+func (c *Controller) markSyncFailed(pipeline, copyPipeline *devopsv1alpha3.Pipeline, syncErr error) error {
+	c.eventRecorder.Eventf(copyPipeline, v1.EventTypeWarning, devopsv1alpha3.SyncFailed,
+		"Failed to synchronize the Pipeline to Jenkins, and error was %v", syncErr)
+	setSyncConditions(copyPipeline, devopsv1alpha3.ConditionFalse, devopsv1alpha3.SyncFailed, syncErr.Error())
+	if !reflect.DeepEqual(pipeline.Status, copyPipeline.Status) {
+		if err := c.updatePipeline(context.Background(), copyPipeline.Name, copyPipeline.Namespace, copyPipeline); err != nil {
+			klog.Error(err, fmt.Sprintf("failed to update the conditions of pipeline %s/%s", copyPipeline.Namespace, copyPipeline.Name))
+		}
+	}
+	return syncErr
+}
+
+// isTrashed returns true if the deleted Pipeline is being or has been moved into the trash bin
+func isTrashed(pipeline *devopsv1alpha3.Pipeline) bool {
+	if _, ok := pipeline.Annotations[devopsv1alpha3.PipelineTrashedAnnoKey]; ok {
+		return true
+	}
+	return sliceutil.HasString(pipeline.Finalizers, devopsv1alpha3.PipelineTrashFinalizerName)
+}
+
+// setSyncConditions sets the Synced, Ready and Failed conditions according to the synchronization result
+func setSyncConditions(pipeline *devopsv1alpha3.Pipeline, synced devopsv1alpha3.ConditionStatus, reason, message string) {
+	failed := devopsv1alpha3.ConditionFalse
+	if synced == devopsv1alpha3.ConditionFalse {
+		failed = devopsv1alpha3.ConditionTrue
+	}
+	conditions := []devopsv1alpha3.Condition{
+		{Type: devopsv1alpha3.ConditionSynced, Status: synced},
+		{Type: devopsv1alpha3.ConditionReady, Status: synced},
+		{Type: devopsv1alpha3.ConditionFailed, Status: failed},
+	}
+	for _, condition := range conditions {
+		condition.Reason = reason
+		condition.Message = message
+		pipeline.Status.SetCondition(condition)
+	}
+}
+
 // Update with retry, if update failed, get new version and update again
 func (c *Controller) updatePipeline(ctx context.Context, name string, nsName string, pipeline *devopsv1alpha3.Pipeline) (err error) {
 	return retry.RetryOnConflict(retry.DefaultRetry, func() (err error) {
@@ -333,7 +413,8 @@ func (c *Controller) updatePipeline(ctx context.Context, name string, nsName str
 
 		if newPipeline.Annotations[devopsv1alpha3.PipelineSyncStatusAnnoKey] == pipeline.Annotations[devopsv1alpha3.PipelineSyncStatusAnnoKey] &&
 			newPipeline.Annotations[devopsv1alpha3.PipelineSpecHash] == pipeline.Annotations[devopsv1alpha3.PipelineSpecHash] &&
-			reflect.DeepEqual(newPipeline.ObjectMeta.Finalizers, pipeline.ObjectMeta.Finalizers) {
+			reflect.DeepEqual(newPipeline.ObjectMeta.Finalizers, pipeline.ObjectMeta.Finalizers) &&
+			reflect.DeepEqual(newPipeline.Status.Conditions, pipeline.Status.Conditions) {
 			return nil
 		}
 		if pipeline.Annotations != nil {
@@ -342,6 +423,7 @@ func (c *Controller) updatePipeline(ctx context.Context, name string, nsName str
 			newPipeline.Annotations[devopsv1alpha3.PipelineSpecHash] = pipeline.Annotations[devopsv1alpha3.PipelineSpecHash]
 		}
 		newPipeline.ObjectMeta.Finalizers = pipeline.ObjectMeta.Finalizers
+		newPipeline.Status.Conditions = pipeline.Status.Conditions
 		_, err = c.kubesphereClient.DevopsV1alpha3().Pipelines(nsName).Update(ctx, newPipeline, metav1.UpdateOptions{})
 		return err
 	})
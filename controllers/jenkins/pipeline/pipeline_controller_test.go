@@ -218,13 +218,37 @@ func (f *fixture) runController(projectName string, startInformers bool, expectE
 		f.t.Errorf(" unexpected objects: %v", dI.Projects)
 	}
 	for _, pipeline := range f.expectPipeline {
-		actualPipeline := dI.Pipelines[f.initDevOpsProject][pipeline.Name]
-		if !reflect.DeepEqual(actualPipeline, pipeline) {
+		actualPipeline := withoutTransitionTime(dI.Pipelines[f.initDevOpsProject][pipeline.Name])
+		if !reflect.DeepEqual(actualPipeline, withoutTransitionTime(pipeline)) {
 			f.t.Errorf(" pipeline %+v not match %+v", pipeline, actualPipeline)
 		}
 	}
 }
 
+// withoutTransitionTime clears the LastTransitionTime of conditions which depends on the current time
+func withoutTransitionTime(pipeline *devops.Pipeline) *devops.Pipeline {
+	if pipeline == nil {
+		return nil
+	}
+	pipeline = pipeline.DeepCopy()
+	for i := range pipeline.Status.Conditions {
+		pipeline.Status.Conditions[i].LastTransitionTime = metav1.Time{}
+	}
+	return pipeline
+}
+
+func newSyncConditions(status devops.ConditionStatus, reason, message string) []devops.Condition {
+	failed := devops.ConditionFalse
+	if status == devops.ConditionFalse {
+		failed = devops.ConditionTrue
+	}
+	return []devops.Condition{
+		{Type: devops.ConditionSynced, Status: status, Reason: reason, Message: message},
+		{Type: devops.ConditionReady, Status: status, Reason: reason, Message: message},
+		{Type: devops.ConditionFailed, Status: failed, Reason: reason, Message: message},
+	}
+}
+
 // checkAction verifies that expected and actual actions are equal and both have
 // same attached resources
 func checkAction(expected, actual core.Action, t *testing.T) {
@@ -373,6 +397,8 @@ func TestCreatePipeline(t *testing.T) {
 	expectPipeline.Annotations = map[string]string{
 		devops.PipelineSyncStatusAnnoKey: constants.StatusSuccessful,
 	}
+	expectPipeline.Status.Conditions = newSyncConditions(devops.ConditionTrue, devops.SyncSucceeded,
+		"the Pipeline has been synchronized to Jenkins")
 	f.expectPipeline = []*devops.Pipeline{expectPipeline}
 
 	f.run(getKey(pipeline, t))
@@ -399,6 +425,82 @@ func TestDeletePipeline(t *testing.T) {
 	f.run(getKey(pipeline, t))
 }
 
+func TestDeleteRetainedPipeline(t *testing.T) {
+	f := newFixture(t)
+	nsName := "test-123"
+	pipelineName := "test"
+	projectName := "test_project"
+
+	ns := newNamespace(nsName, projectName)
+	pipeline := newDeletingPipeline(nsName, pipelineName)
+	pipeline.Spec.DeletionPolicy = devops.DeletionPolicyRetain
+
+	expectPipeline := pipeline.DeepCopy()
+	expectPipeline.Finalizers = []string{}
+	f.pipelineLister = append(f.pipelineLister, pipeline)
+	f.namespaceLister = append(f.namespaceLister, ns)
+	f.objects = append(f.objects, pipeline)
+	f.initDevOpsProject = nsName
+	f.initPipeline = []*devops.Pipeline{pipeline}
+	// the Jenkins job is kept
+	f.expectPipeline = []*devops.Pipeline{pipeline}
+	f.expectUpdatePipelineAction(expectPipeline)
+	f.run(getKey(pipeline, t))
+}
+
+func TestDeleteTrashedPipeline(t *testing.T) {
+	f := newFixture(t)
+	nsName := "test-123"
+	pipelineName := "test"
+	projectName := "test_project"
+
+	ns := newNamespace(nsName, projectName)
+	pipeline := newDeletingPipeline(nsName, pipelineName)
+	pipeline.Annotations = map[string]string{devops.PipelineTrashedAnnoKey: "test-12345678"}
+
+	expectPipeline := pipeline.DeepCopy()
+	expectPipeline.Finalizers = []string{}
+	f.pipelineLister = append(f.pipelineLister, pipeline)
+	f.namespaceLister = append(f.namespaceLister, ns)
+	f.objects = append(f.objects, pipeline)
+	f.initDevOpsProject = nsName
+	f.initPipeline = []*devops.Pipeline{pipeline}
+	// the Jenkins job is kept in the trash bin
+	f.expectPipeline = []*devops.Pipeline{pipeline}
+	f.expectUpdatePipelineAction(expectPipeline)
+	f.run(getKey(pipeline, t))
+}
+
+func TestCreateOrphanPipeline(t *testing.T) {
+	f := newFixture(t)
+	nsName := "test-123"
+	pipelineName := "test"
+	projectName := "test_project"
+	spec := devops.PipelineSpec{
+		Type:           devops.NoScmPipelineType,
+		Pipeline:       &devops.NoScmPipeline{Name: pipelineName},
+		DeletionPolicy: devops.DeletionPolicyOrphan,
+	}
+	pipeline := newPipeline(nsName, pipelineName, spec, false, false)
+	ns := newNamespace(nsName, projectName)
+
+	f.pipelineLister = append(f.pipelineLister, pipeline)
+	f.namespaceLister = append(f.namespaceLister, ns)
+	f.objects = append(f.objects, pipeline)
+	f.initDevOpsProject = nsName
+
+	expectPipeline := pipeline.DeepCopy()
+	expectPipeline.Finalizers = []string{metav1.FinalizerOrphanDependents}
+	expectPipeline.Annotations = map[string]string{
+		devops.PipelineSyncStatusAnnoKey: constants.StatusSuccessful,
+	}
+	expectPipeline.Status.Conditions = newSyncConditions(devops.ConditionTrue, devops.SyncSucceeded,
+		"the Pipeline has been synchronized to Jenkins")
+	f.expectPipeline = []*devops.Pipeline{expectPipeline}
+
+	f.run(getKey(pipeline, t))
+}
+
 func TestDeleteNotExistPipeline(t *testing.T) {
 	f := newFixture(t)
 	nsName := "test-123"
@@ -430,6 +532,8 @@ func TestUpdatePipelineConfig(t *testing.T) {
 	initPipeline := newPipeline(nsName, pipelineName, devops.PipelineSpec{}, true, false)
 	modifiedPipeline := newPipeline(nsName, pipelineName, devops.PipelineSpec{Type: "aa"}, true, false)
 	expectPipeline := newPipeline(nsName, pipelineName, devops.PipelineSpec{Type: "aa"}, true, true)
+	expectPipeline.Status.Conditions = newSyncConditions(devops.ConditionTrue, devops.SyncSucceeded,
+		"the Pipeline has been synchronized to Jenkins")
 	f.pipelineLister = append(f.pipelineLister, modifiedPipeline)
 	f.namespaceLister = append(f.namespaceLister, ns)
 	f.objects = append(f.objects, modifiedPipeline)
@@ -29,8 +29,10 @@ import (
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/backend"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
@@ -48,6 +50,8 @@ type Reconciler struct {
 	JenkinsCore core.JenkinsCore
 	recorder    record.EventRecorder
 	log         logr.Logger
+	// ControllerOptions tunes the concurrency and the rate limiter of this controller
+	ControllerOptions controller.Options
 }
 
 //+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelines,verbs=get;list;watch;create;update;patch;delete
@@ -190,7 +194,8 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.recorder = mgr.GetEventRecorderFor("pipeline-metadata-controller")
 	r.log = ctrl.Log.WithName("pipeline-metadata-controller")
 	return ctrl.NewControllerManagedBy(mgr).
-		WithEventFilter(pipelineMetadataPredicate).
+		WithEventFilter(predicate.And(pipelineMetadataPredicate, backend.NewPredicate(backend.Jenkins))).
 		For(&v1alpha3.Pipeline{}).
+		WithOptions(r.ControllerOptions).
 		Complete(r)
 }
@@ -0,0 +1,83 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	devopsv1alpha3 "kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+// diffPipelineSpec returns the paths of the fields which drifted from the live PipelineSpec to the desired one.
+// Only the fields which round-trip through the Jenkins job are compared, the others are never returned by
+// GetProjectPipelineConfig. An empty result means the two specs are semantically equal.
+func diffPipelineSpec(live, desired *devopsv1alpha3.PipelineSpec) []string {
+	if live == nil || desired == nil {
+		if live == desired {
+			return nil
+		}
+		return []string{"spec"}
+	}
+	return diffValue("spec", reflect.ValueOf(jenkinsJobSpec(live)), reflect.ValueOf(jenkinsJobSpec(desired)))
+}
+
+// jenkinsJobSpec returns the fields of a PipelineSpec which are stored in the Jenkins job
+func jenkinsJobSpec(spec *devopsv1alpha3.PipelineSpec) devopsv1alpha3.PipelineSpec {
+	return devopsv1alpha3.PipelineSpec{
+		Type:                spec.Type,
+		Pipeline:            spec.Pipeline,
+		MultiBranchPipeline: spec.MultiBranchPipeline,
+	}
+}
+
+func diffValue(path string, live, desired reflect.Value) (changes []string) {
+	switch live.Kind() {
+	case reflect.Ptr:
+		if live.IsNil() || desired.IsNil() {
+			if live.IsNil() != desired.IsNil() {
+				changes = append(changes, path)
+			}
+			return
+		}
+		return diffValue(path, live.Elem(), desired.Elem())
+	case reflect.Struct:
+		for i := 0; i < live.NumField(); i++ {
+			field := live.Type().Field(i)
+			if field.PkgPath != "" {
+				// skip the unexported fields
+				continue
+			}
+			changes = append(changes, diffValue(path+"."+fieldName(field), live.Field(i), desired.Field(i))...)
+		}
+		return
+	default:
+		if !equality.Semantic.DeepEqual(live.Interface(), desired.Interface()) {
+			changes = append(changes, path)
+		}
+		return
+	}
+}
+
+// fieldName returns the JSON name of a struct field, or the Go name if there is no JSON tag.
+func fieldName(field reflect.StructField) string {
+	if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag != "" && tag != "-" {
+		return tag
+	}
+	return field.Name
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	devopsv1alpha3 "kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+func Test_diffPipelineSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		live    *devopsv1alpha3.PipelineSpec
+		desired *devopsv1alpha3.PipelineSpec
+		want    []string
+	}{{
+		name: "both are nil",
+	}, {
+		name:    "live is nil",
+		desired: &devopsv1alpha3.PipelineSpec{},
+		want:    []string{"spec"},
+	}, {
+		name:    "no changes",
+		live:    &devopsv1alpha3.PipelineSpec{Type: devopsv1alpha3.NoScmPipelineType, Pipeline: &devopsv1alpha3.NoScmPipeline{Name: "a"}},
+		desired: &devopsv1alpha3.PipelineSpec{Type: devopsv1alpha3.NoScmPipelineType, Pipeline: &devopsv1alpha3.NoScmPipeline{Name: "a"}},
+	}, {
+		name: "nil and empty slices are semantically equal",
+		live: &devopsv1alpha3.PipelineSpec{Pipeline: &devopsv1alpha3.NoScmPipeline{}},
+		desired: &devopsv1alpha3.PipelineSpec{Pipeline: &devopsv1alpha3.NoScmPipeline{
+			Parameters: []devopsv1alpha3.ParameterDefinition{},
+		}},
+	}, {
+		name: "type changed",
+		live: &devopsv1alpha3.PipelineSpec{Type: devopsv1alpha3.NoScmPipelineType},
+		desired: &devopsv1alpha3.PipelineSpec{
+			Type: devopsv1alpha3.MultiBranchPipelineType,
+		},
+		want: []string{"spec.type"},
+	}, {
+		name: "nested fields changed",
+		live: &devopsv1alpha3.PipelineSpec{Pipeline: &devopsv1alpha3.NoScmPipeline{
+			Jenkinsfile: "pipeline {}",
+		}},
+		desired: &devopsv1alpha3.PipelineSpec{Pipeline: &devopsv1alpha3.NoScmPipeline{
+			Jenkinsfile:  "pipeline { agent any }",
+			TimerTrigger: &devopsv1alpha3.TimerTrigger{Cron: "H * * * *"},
+		}},
+		want: []string{"spec.pipeline.timer_trigger", "spec.pipeline.jenkinsfile"},
+	}, {
+		name: "the fields which are not stored in Jenkins are ignored",
+		live: &devopsv1alpha3.PipelineSpec{Type: devopsv1alpha3.NoScmPipelineType},
+		desired: &devopsv1alpha3.PipelineSpec{
+			Type:           devopsv1alpha3.NoScmPipelineType,
+			Concurrency:    &devopsv1alpha3.ConcurrencyPolicy{},
+			ScanImage:      true,
+			DeletionPolicy: devopsv1alpha3.DeletionPolicyOrphan,
+		},
+	}, {
+		name:    "pointer field becomes nil",
+		live:    &devopsv1alpha3.PipelineSpec{MultiBranchPipeline: &devopsv1alpha3.MultiBranchPipeline{}},
+		desired: &devopsv1alpha3.PipelineSpec{},
+		want:    []string{"spec.multi_branch_pipeline"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, diffPipelineSpec(tt.live, tt.desired))
+		})
+	}
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/backend"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// TemplateRendered indicates the Jenkinsfile of Pipeline has been rendered from its template.
+	TemplateRendered = "TemplateRendered"
+	// FailedTemplateRender indicates the controller fails to render the Jenkinsfile of Pipeline from its template.
+	FailedTemplateRender = "FailedTemplateRender"
+	// DeprecatedTemplate indicates the Pipeline refers to a deprecated template.
+	DeprecatedTemplate = "DeprecatedTemplate"
+)
+
+// TemplateReconciler renders the Jenkinsfile of the Pipelines which refer to a Template or ClusterTemplate.
+type TemplateReconciler struct {
+	client.Client
+	recorder record.EventRecorder
+	log      logr.Logger
+	// ControllerOptions tunes the concurrency and the rate limiter of this controller
+	ControllerOptions controller.Options
+}
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelines,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=templates;clustertemplates,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile renders the template referred by the Pipeline into its Jenkinsfile.
+func (r *TemplateReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.log.WithValues("Pipeline", req.NamespacedName)
+	pipeline := &v1alpha3.Pipeline{}
+	if err := r.Get(ctx, req.NamespacedName, pipeline); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	ref := pipeline.Spec.Template
+	if ref == nil || pipeline.Spec.Type != v1alpha3.NoScmPipelineType || !pipeline.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	templateObject, err := r.getTemplate(ctx, pipeline.Namespace, ref)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			// wait for the template to be created, the watch will bring us back
+			r.recorder.Eventf(pipeline, v1.EventTypeWarning, FailedTemplateRender, "The template %s/%s is not found", ref.GetKind(), ref.Name)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	templateSpec := templateObject.TemplateSpec()
+	if ref.Version != "" && ref.Version != templateSpec.Version {
+		// keep the Jenkinsfile rendered from the pinned version
+		r.recorder.Eventf(pipeline, v1.EventTypeWarning, FailedTemplateRender, "The version of template %s/%s is %q, but %q is required",
+			ref.GetKind(), ref.Name, templateSpec.Version, ref.Version)
+		return ctrl.Result{}, nil
+	}
+	if templateSpec.Deprecated {
+		r.recorder.Eventf(pipeline, v1.EventTypeWarning, DeprecatedTemplate, "The template %s/%s is deprecated: %s",
+			ref.GetKind(), ref.Name, templateSpec.DeprecationMessage)
+	}
+
+	jenkinsfile, err := templateSpec.Render(ref.Name, ref.Parameters)
+	if err != nil {
+		log.Info("failed to render template", "err", err)
+		r.recorder.Eventf(pipeline, v1.EventTypeWarning, FailedTemplateRender, "Failed to render the Jenkinsfile, err = %v", err)
+		return ctrl.Result{}, nil
+	}
+	if pipeline.Spec.Pipeline != nil && pipeline.Spec.Pipeline.Jenkinsfile == jenkinsfile {
+		return ctrl.Result{}, nil
+	}
+
+	if pipeline.Spec.Pipeline == nil {
+		pipeline.Spec.Pipeline = &v1alpha3.NoScmPipeline{Name: pipeline.Name}
+	}
+	pipeline.Spec.Pipeline.Jenkinsfile = jenkinsfile
+	if err = r.Update(ctx, pipeline); err != nil {
+		log.Error(err, "unable to update the Jenkinsfile of Pipeline")
+		return ctrl.Result{}, err
+	}
+	r.recorder.Eventf(pipeline, v1.EventTypeNormal, TemplateRendered, "The Jenkinsfile has been rendered from %s/%s", ref.GetKind(), ref.Name)
+	return ctrl.Result{}, nil
+}
+
+func (r *TemplateReconciler) getTemplate(ctx context.Context, namespace string, ref *v1alpha3.PipelineTemplateRef) (
+	templateObject v1alpha3.TemplateObject, err error) {
+	if ref.IsClusterTemplate() {
+		clusterTemplate := &v1alpha3.ClusterTemplate{}
+		err = r.Get(ctx, types.NamespacedName{Name: ref.Name}, clusterTemplate)
+		templateObject = clusterTemplate
+	} else {
+		template := &v1alpha3.Template{}
+		err = r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ref.Name}, template)
+		templateObject = template
+	}
+	return
+}
+
+// findReferringPipelines returns a function which finds the Pipelines referring to the changed template.
+func (r *TemplateReconciler) findReferringPipelines(kind string) handler.MapFunc {
+	return func(obj client.Object) (requests []reconcile.Request) {
+		pipelineList := &v1alpha3.PipelineList{}
+		var opts []client.ListOption
+		if kind == v1alpha3.ResourceKindTemplate {
+			opts = append(opts, client.InNamespace(obj.GetNamespace()))
+		}
+		if err := r.List(context.Background(), pipelineList, opts...); err != nil {
+			r.log.Error(err, "unable to list Pipelines", "template", obj.GetName())
+			return
+		}
+		for i := range pipelineList.Items {
+			pipeline := &pipelineList.Items[i]
+			ref := pipeline.Spec.Template
+			if ref == nil || ref.Name != obj.GetName() || ref.IsClusterTemplate() != (kind == v1alpha3.ResourceKindClusterTemplate) {
+				continue
+			}
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Namespace: pipeline.Namespace,
+				Name:      pipeline.Name,
+			}})
+		}
+		return
+	}
+}
+
+// SetupWithManager setups reconciler with controller manager.
+func (r *TemplateReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor("pipeline-template-controller")
+	r.log = ctrl.Log.WithName("pipeline-template-controller")
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("pipeline-template-controller").
+		For(&v1alpha3.Pipeline{}, builder.WithPredicates(backend.NewPredicate(backend.Jenkins))).
+		Watches(&source.Kind{Type: &v1alpha3.Template{}},
+			handler.EnqueueRequestsFromMapFunc(r.findReferringPipelines(v1alpha3.ResourceKindTemplate))).
+		Watches(&source.Kind{Type: &v1alpha3.ClusterTemplate{}},
+			handler.EnqueueRequestsFromMapFunc(r.findReferringPipelines(v1alpha3.ResourceKindClusterTemplate))).
+		WithOptions(r.ControllerOptions).
+		Complete(r)
+}
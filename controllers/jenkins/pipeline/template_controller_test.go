@@ -0,0 +1,176 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	apiextensionv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func newTemplatePipeline(name string, ref *v1alpha3.PipelineTemplateRef) *v1alpha3.Pipeline {
+	return &v1alpha3.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name},
+		Spec: v1alpha3.PipelineSpec{
+			Type:     v1alpha3.NoScmPipelineType,
+			Template: ref,
+		},
+	}
+}
+
+func TestTemplateReconciler(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	template := &v1alpha3.Template{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "build"},
+		Spec: v1alpha3.TemplateSpec{
+			Parameters: []v1alpha3.TemplateParameter{{Name: "image", Default: apiextensionv1.JSON{Raw: []byte(`"golang"`)}}},
+			Template:   "image: $(.params.image)",
+		},
+	}
+	clusterTemplate := &v1alpha3.ClusterTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "deploy"},
+		Spec: v1alpha3.TemplateSpec{
+			Parameters:         []v1alpha3.TemplateParameter{{Name: "chart", Required: true}},
+			Template:           "chart: $(.params.chart)",
+			Version:            "v2",
+			Deprecated:         true,
+			DeprecationMessage: "use helm-deploy instead",
+		},
+	}
+
+	tests := []struct {
+		name            string
+		pipeline        *v1alpha3.Pipeline
+		wantJenkinsfile string
+		wantEvents      []string
+	}{{
+		name:     "no template",
+		pipeline: newTemplatePipeline("fake", nil),
+	}, {
+		name:            "render with the default values",
+		pipeline:        newTemplatePipeline("fake", &v1alpha3.PipelineTemplateRef{Name: "build"}),
+		wantJenkinsfile: "image: golang",
+	}, {
+		name: "render a ClusterTemplate",
+		pipeline: newTemplatePipeline("fake", &v1alpha3.PipelineTemplateRef{
+			Kind:       v1alpha3.ResourceKindClusterTemplate,
+			Name:       "deploy",
+			Parameters: []v1alpha3.Parameter{{Name: "chart", Value: "nginx"}},
+		}),
+		wantJenkinsfile: "chart: nginx",
+		wantEvents: []string{
+			"Warning DeprecatedTemplate The template ClusterTemplate/deploy is deprecated: use helm-deploy instead",
+			"Normal TemplateRendered The Jenkinsfile has been rendered from ClusterTemplate/deploy",
+		},
+	}, {
+		name: "version mismatch",
+		pipeline: newTemplatePipeline("fake", &v1alpha3.PipelineTemplateRef{
+			Kind:       v1alpha3.ResourceKindClusterTemplate,
+			Name:       "deploy",
+			Version:    "v1",
+			Parameters: []v1alpha3.Parameter{{Name: "chart", Value: "nginx"}},
+		}),
+		wantEvents: []string{`Warning FailedTemplateRender The version of template ClusterTemplate/deploy is "v2", but "v1" is required`},
+	}, {
+		name: "missing required parameter",
+		pipeline: newTemplatePipeline("fake", &v1alpha3.PipelineTemplateRef{
+			Kind: v1alpha3.ResourceKindClusterTemplate,
+			Name: "deploy",
+		}),
+	}, {
+		name:     "template not found",
+		pipeline: newTemplatePipeline("fake", &v1alpha3.PipelineTemplateRef{Name: "absent"}),
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			r := &TemplateReconciler{
+				Client: fake.NewClientBuilder().WithScheme(schema).
+					WithRuntimeObjects(tt.pipeline, template, clusterTemplate).Build(),
+				log:      logr.New(log.NullLogSink{}),
+				recorder: recorder,
+			}
+			key := types.NamespacedName{Namespace: "ns", Name: "fake"}
+			_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+			assert.Nil(t, err)
+
+			pipeline := &v1alpha3.Pipeline{}
+			assert.Nil(t, r.Get(context.Background(), key, pipeline))
+			if tt.wantJenkinsfile == "" {
+				assert.Nil(t, pipeline.Spec.Pipeline)
+			} else {
+				assert.Equal(t, tt.wantJenkinsfile, pipeline.Spec.Pipeline.Jenkinsfile)
+				assert.Equal(t, "fake", pipeline.Spec.Pipeline.Name)
+			}
+			if tt.wantEvents != nil {
+				close(recorder.Events)
+				var events []string
+				for event := range recorder.Events {
+					events = append(events, event)
+				}
+				assert.Equal(t, tt.wantEvents, events)
+			}
+		})
+	}
+}
+
+func TestTemplateReconciler_findReferringPipelines(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	objects := []runtime.Object{
+		newTemplatePipeline("a", &v1alpha3.PipelineTemplateRef{Name: "build"}),
+		newTemplatePipeline("b", &v1alpha3.PipelineTemplateRef{Kind: v1alpha3.ResourceKindClusterTemplate, Name: "build"}),
+		newTemplatePipeline("c", &v1alpha3.PipelineTemplateRef{Name: "other"}),
+		newTemplatePipeline("d", nil),
+	}
+	r := &TemplateReconciler{
+		Client: fake.NewClientBuilder().WithScheme(schema).WithRuntimeObjects(objects...).Build(),
+		log:    logr.New(log.NullLogSink{}),
+	}
+
+	getNames := func(kind string, obj v1alpha3.TemplateObject) (names []string) {
+		for _, request := range r.findReferringPipelines(kind)(obj) {
+			names = append(names, request.Name)
+		}
+		sort.Strings(names)
+		return
+	}
+	assert.Equal(t, []string{"a"}, getNames(v1alpha3.ResourceKindTemplate, &v1alpha3.Template{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "build"},
+	}))
+	assert.Equal(t, []string{"b"}, getNames(v1alpha3.ResourceKindClusterTemplate, &v1alpha3.ClusterTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "build"},
+	}))
+	assert.Nil(t, getNames(v1alpha3.ResourceKindTemplate, &v1alpha3.Template{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "build"},
+	}))
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelinerun
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/emicklei/go-restful"
+	"github.com/jenkins-zh/jenkins-client/pkg/core"
+	"github.com/jenkins-zh/jenkins-client/pkg/job"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/klog/v2"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/backend"
+	devopsClient "kubesphere.io/devops/pkg/client/devops"
+	"kubesphere.io/devops/pkg/jwt/token"
+)
+
+// Backend is the Jenkins implementation of backend.Interface.
+type Backend struct {
+	DevOpsClient devopsClient.Interface
+	JenkinsCore  core.JenkinsCore
+	// TokenIssuer issues the temporary tokens of the creators, the PipelineRuns which have creators are triggered as
+	// their creators, they fail to be triggered without it
+	TokenIssuer token.Issuer
+}
+
+var _ backend.Interface = &Backend{}
+var _ backend.RawRunGetter = &Backend{}
+
+// NewBackend creates a Jenkins backend.
+func NewBackend(devopsClient devopsClient.Interface, jenkinsCore core.JenkinsCore) *Backend {
+	return &Backend{
+		DevOpsClient: devopsClient,
+		JenkinsCore:  jenkinsCore,
+	}
+}
+
+// Type returns the type of Jenkins backend.
+func (b *Backend) Type() backend.Type {
+	return backend.Jenkins
+}
+
+// CreatePipeline creates the Jenkins job of the Pipeline, or updates it if it already exists.
+func (b *Backend) CreatePipeline(_ context.Context, pipeline *v1alpha3.Pipeline) (err error) {
+	if _, err = b.DevOpsClient.GetProjectPipelineConfig(pipeline.Namespace, pipeline.Name); err == nil {
+		_, err = b.DevOpsClient.UpdateProjectPipeline(pipeline.Namespace, pipeline)
+	} else {
+		_, err = b.DevOpsClient.CreateProjectPipeline(pipeline.Namespace, pipeline)
+	}
+	return
+}
+
+// DeletePipeline deletes the Jenkins job of the Pipeline.
+func (b *Backend) DeletePipeline(_ context.Context, pipeline *v1alpha3.Pipeline) (err error) {
+	if _, err = b.DevOpsClient.DeleteProjectPipeline(pipeline.Namespace, pipeline.Name); err != nil && isNotFound(err) {
+		err = nil
+	}
+	return
+}
+
+// TriggerRun triggers a build of the Jenkins job which the PipelineRun refers to.
+func (b *Backend) TriggerRun(_ context.Context, pipelineRun *v1alpha3.PipelineRun) (runID string, err error) {
+	if pipelineRun.Spec.HasInlinePipelineSpec() {
+		return "", backend.ErrNotSupported
+	}
+	pipelineName := pipelineRun.GetPipelineName()
+	if pipelineName == "" {
+		return "", fmt.Errorf("no Pipeline reference found in PipelineRun %s/%s", pipelineRun.Namespace, pipelineRun.Name)
+	}
+
+	jenkinsCore, err := b.getOrCreateJenkinsCore(pipelineRun.GetAnnotations())
+	if err != nil {
+		return "", err
+	}
+	handler := &jenkinsHandler{jenkinsCore}
+	jobRun, err := handler.triggerJenkinsJob(pipelineRun.Namespace, pipelineName, &pipelineRun.Spec)
+	if err != nil {
+		return "", err
+	}
+	return jobRun.ID, nil
+}
+
+// GetRunStatus retrieves the build result from Jenkins and applies it to a copy of the PipelineRun status.
+func (b *Backend) GetRunStatus(_ context.Context, pipelineRun *v1alpha3.PipelineRun) (*v1alpha3.PipelineRunStatus, error) {
+	status, _, err := b.getRunStatus(pipelineRun)
+	return status, err
+}
+
+// GetRunStatusAndRaw returns the status of the PipelineRun along with the Jenkins build in JSON.
+func (b *Backend) GetRunStatusAndRaw(_ context.Context, pipelineRun *v1alpha3.PipelineRun) (
+	status *v1alpha3.PipelineRunStatus, raw []byte, err error) {
+	var pipelineBuild *job.PipelineRun
+	if status, pipelineBuild, err = b.getRunStatus(pipelineRun); err == nil {
+		raw, err = json.Marshal(pipelineBuild)
+	}
+	return
+}
+
+func (b *Backend) getRunStatus(pipelineRun *v1alpha3.PipelineRun) (*v1alpha3.PipelineRunStatus, *job.PipelineRun, error) {
+	handler := &jenkinsHandler{&b.JenkinsCore}
+	pipelineBuild, err := handler.getPipelineRunResult(pipelineRun.Namespace, pipelineRun.GetPipelineName(), pipelineRun)
+	if err != nil {
+		return nil, nil, err
+	}
+	status := pipelineRun.Status.DeepCopy()
+	pipelineBuildApplier{pipelineBuild}.apply(status)
+	// the results are fetched once the build has just completed, they don't change after that
+	if justCompleted := !pipelineRun.HasCompleted() && !status.CompletionTime.IsZero(); justCompleted {
+		if results, err := handler.getPipelineRunResults(pipelineRun); err != nil {
+			klog.Errorf("unable to get the results of PipelineRun %s/%s, error: %v", pipelineRun.Namespace, pipelineRun.Name, err)
+		} else {
+			status.Results = results
+		}
+	}
+	return status, pipelineBuild, nil
+}
+
+// DeleteRun deletes the Jenkins build of the PipelineRun.
+func (b *Backend) DeleteRun(_ context.Context, pipelineRun *v1alpha3.PipelineRun) error {
+	handler := &jenkinsHandler{&b.JenkinsCore}
+	return handler.deleteJenkinsJobHistory(pipelineRun)
+}
+
+// GetLogs returns the full console logs of the Jenkins build.
+func (b *Backend) GetLogs(_ context.Context, pipelineRun *v1alpha3.PipelineRun) (data []byte, err error) {
+	runID, exists := pipelineRun.GetPipelineRunID()
+	if !exists {
+		return nil, fmt.Errorf("unable to get logs of PipelineRun %s/%s due to not found run ID",
+			pipelineRun.Namespace, pipelineRun.Name)
+	}
+
+	params := &devopsClient.HttpParameters{
+		Method: http.MethodGet,
+		Url:    &url.URL{RawQuery: "start=0"},
+	}
+	pipelineName := pipelineRun.GetPipelineName()
+	if refName := pipelineRun.GetRefName(); refName != "" {
+		data, err = b.DevOpsClient.GetBranchRunLog(pipelineRun.Namespace, pipelineName, refName, runID, params)
+	} else {
+		data, _, err = b.DevOpsClient.GetRunLog(pipelineRun.Namespace, pipelineName, runID, params)
+	}
+	return
+}
+
+// GetArtifact downloads an artifact which is archived by the Jenkins build.
+func (b *Backend) GetArtifact(_ context.Context, pipelineRun *v1alpha3.PipelineRun, path string) (io.ReadCloser, error) {
+	runID, exists := pipelineRun.GetPipelineRunID()
+	if !exists {
+		return nil, fmt.Errorf("unable to get artifact of PipelineRun %s/%s due to not found run ID",
+			pipelineRun.Namespace, pipelineRun.Name)
+	}
+	return b.DevOpsClient.DownloadArtifact(pipelineRun.Namespace, pipelineRun.GetPipelineName(), runID, path,
+		pipelineRun.Spec.IsMultiBranchPipeline(), pipelineRun.GetRefName())
+}
+
+// getOrCreateJenkinsCore returns a JenkinsCore of the creator if the PipelineRun has the creator annotation.
+// It never falls back to the admin of Jenkins for a creator, the PipelineRun would gain the permissions of admin.
+func (b *Backend) getOrCreateJenkinsCore(annotations map[string]string) (*core.JenkinsCore, error) {
+	creator, ok := annotations[v1alpha3.PipelineRunCreatorAnnoKey]
+	if !ok || creator == "" {
+		return &b.JenkinsCore, nil
+	}
+	if b.TokenIssuer == nil {
+		return nil, fmt.Errorf("unable to trigger the PipelineRun as creator %s due to no token issuer", creator)
+	}
+	// create a new JenkinsCore for current creator
+	accessToken, err := b.TokenIssuer.IssueTo(&user.DefaultInfo{Name: creator}, token.AccessToken, tokenExpireIn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue access token for creator %s, error was %v", creator, err)
+	}
+	jenkinsCore := &core.JenkinsCore{
+		URL:          b.JenkinsCore.URL,
+		UserName:     creator,
+		Token:        accessToken,
+		RoundTripper: b.JenkinsCore.RoundTripper,
+	}
+	return jenkinsCore, nil
+}
+
+// isNotFound returns true if the Jenkins object does not exist
+func isNotFound(err error) bool {
+	if srvErr, ok := err.(restful.ServiceError); ok {
+		return srvErr.Code == http.StatusNotFound
+	}
+	return devopsClient.GetDevOpsStatusCode(err) == http.StatusNotFound
+}
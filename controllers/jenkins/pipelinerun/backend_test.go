@@ -0,0 +1,275 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelinerun
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jenkins-zh/jenkins-client/pkg/core"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/backend"
+	fakedevops "kubesphere.io/devops/pkg/client/devops/fake"
+	"kubesphere.io/devops/pkg/jwt/token"
+)
+
+func TestBackend_Pipeline(t *testing.T) {
+	pipeline := &v1alpha3.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pipeline"},
+		Spec:       v1alpha3.PipelineSpec{Type: v1alpha3.NoScmPipelineType},
+	}
+	devopsClient := fakedevops.NewWithPipelines("ns")
+	b := NewBackend(devopsClient, core.JenkinsCore{})
+	assert.Equal(t, backend.Jenkins, b.Type())
+
+	// create
+	assert.Nil(t, b.CreatePipeline(context.Background(), pipeline))
+	assert.Equal(t, pipeline, devopsClient.Pipelines["ns"]["pipeline"])
+
+	// update
+	updated := pipeline.DeepCopy()
+	updated.Spec.Type = v1alpha3.MultiBranchPipelineType
+	assert.Nil(t, b.CreatePipeline(context.Background(), updated))
+	assert.Equal(t, updated, devopsClient.Pipelines["ns"]["pipeline"])
+
+	// delete, it should be idempotent
+	assert.Nil(t, b.DeletePipeline(context.Background(), pipeline))
+	assert.Nil(t, devopsClient.Pipelines["ns"]["pipeline"])
+	assert.Nil(t, b.DeletePipeline(context.Background(), pipeline))
+}
+
+func TestBackend_TriggerRun(t *testing.T) {
+	b := NewBackend(fakedevops.New(), core.JenkinsCore{})
+
+	inline := &v1alpha3.PipelineRun{}
+	inline.Spec.PipelineSpec = &v1alpha3.PipelineSpec{Type: v1alpha3.NoScmPipelineType}
+	_, err := b.TriggerRun(context.Background(), inline)
+	assert.Equal(t, backend.ErrNotSupported, err)
+
+	_, err = b.TriggerRun(context.Background(), &v1alpha3.PipelineRun{})
+	assert.NotNil(t, err)
+}
+
+func TestBackend_GetRunStatus(t *testing.T) {
+	var resultsRequests int
+	var resultsFailed bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/blue/rest/"):
+			_, _ = w.Write([]byte(`{"id":"2","state":"FINISHED","result":"SUCCESS",` +
+				`"startTime":"2022-01-01T00:00:00.000+0000","endTime":"2022-01-01T00:01:00.000+0000"}`))
+		case r.URL.Path == "/job/ns/job/pipeline/2/api/json":
+			resultsRequests++
+			if resultsFailed {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			_, _ = w.Write([]byte(`{"actions":[{"parameters":[{"name":"IMAGE","value":"nginx"}]}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	newPipelineRun := func(completed bool) *v1alpha3.PipelineRun {
+		pipelineRun := &v1alpha3.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "ns",
+				Annotations: map[string]string{v1alpha3.JenkinsPipelineRunIDAnnoKey: "2"},
+			},
+			Spec: v1alpha3.PipelineRunSpec{PipelineRef: &v1.ObjectReference{Name: "pipeline"}},
+		}
+		if completed {
+			pipelineRun.Status.CompletionTime = &metav1.Time{Time: time.Date(2022, 1, 1, 0, 1, 0, 0, time.UTC)}
+		}
+		return pipelineRun
+	}
+
+	tests := []struct {
+		name                string
+		completed           bool
+		resultsFailed       bool
+		wantResults         []v1alpha3.RunResult
+		wantResultsRequests int
+	}{{
+		name:                "just completed",
+		wantResults:         []v1alpha3.RunResult{{Name: "IMAGE", Value: "nginx"}},
+		wantResultsRequests: 1,
+	}, {
+		name:      "completed already",
+		completed: true,
+	}, {
+		name:                "failed to get the results",
+		resultsFailed:       true,
+		wantResultsRequests: 1,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resultsRequests, resultsFailed = 0, tt.resultsFailed
+			b := NewBackend(fakedevops.New(), core.JenkinsCore{URL: server.URL})
+
+			status, raw, err := b.GetRunStatusAndRaw(context.Background(), newPipelineRun(tt.completed))
+			assert.Nil(t, err)
+			assert.NotEmpty(t, raw)
+			assert.Equal(t, v1alpha3.Succeeded, status.Phase)
+			assert.Equal(t, tt.wantResults, status.Results)
+			assert.Equal(t, tt.wantResultsRequests, resultsRequests)
+		})
+	}
+}
+
+func TestBackend_DeleteRun(t *testing.T) {
+	b := NewBackend(fakedevops.New(), core.JenkinsCore{})
+
+	// there is no Jenkins build to delete without run ID
+	assert.Nil(t, b.DeleteRun(context.Background(), &v1alpha3.PipelineRun{}))
+}
+
+func TestBackend_getOrCreateJenkinsCore(t *testing.T) {
+	defaultJenkinsCore := &core.JenkinsCore{
+		URL:      "https://devops.com",
+		UserName: "admin",
+		Token:    "fake-token",
+	}
+	tokenIssuer := token.NewTokenIssuer("test-secret", 0)
+	accessToken, err := tokenIssuer.IssueTo(&user.DefaultInfo{Name: "tester"}, token.AccessToken, tokenExpireIn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type fields struct {
+		JenkinsCore core.JenkinsCore
+		TokenIssuer token.Issuer
+	}
+	type args struct {
+		annotations map[string]string
+	}
+	tests := []struct {
+		name      string
+		fields    fields
+		args      args
+		want      *core.JenkinsCore
+		assertion func(*core.JenkinsCore)
+		wantErr   bool
+	}{{
+		name: "Empty annotations",
+		fields: fields{
+			JenkinsCore: *defaultJenkinsCore,
+			TokenIssuer: tokenIssuer,
+		},
+		want: defaultJenkinsCore,
+	}, {
+		name: "Has creator in annotations",
+		fields: fields{
+			JenkinsCore: *defaultJenkinsCore,
+			TokenIssuer: tokenIssuer,
+		},
+		args: args{
+			annotations: map[string]string{
+				v1alpha3.PipelineRunCreatorAnnoKey: "tester",
+			},
+		},
+		want: &core.JenkinsCore{
+			URL:      defaultJenkinsCore.URL,
+			UserName: "tester",
+			Token:    accessToken,
+		},
+		assertion: func(jenkinsCore *core.JenkinsCore) {
+			assert.Equal(t, "tester", jenkinsCore.UserName)
+			assert.Equal(t, defaultJenkinsCore.URL, jenkinsCore.URL)
+			userInfo, tokenType, err := tokenIssuer.Verify(jenkinsCore.Token)
+			assert.Equal(t, &user.DefaultInfo{Name: "tester"}, userInfo)
+			assert.Equal(t, token.AccessToken, tokenType)
+			assert.Nil(t, err)
+		},
+	}, {
+		name: "Has creator in annotations without token issuer",
+		fields: fields{
+			JenkinsCore: *defaultJenkinsCore,
+		},
+		args: args{
+			annotations: map[string]string{
+				v1alpha3.PipelineRunCreatorAnnoKey: "tester",
+			},
+		},
+		wantErr: true,
+	},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Backend{
+				JenkinsCore: tt.fields.JenkinsCore,
+				TokenIssuer: tt.fields.TokenIssuer,
+			}
+			got, err := b.getOrCreateJenkinsCore(tt.args.annotations)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Backend.getOrCreateJenkinsCore() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.assertion != nil {
+				tt.assertion(got)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Backend.getOrCreateJenkinsCore() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackend_GetLogs(t *testing.T) {
+	b := NewBackend(fakedevops.New(), core.JenkinsCore{})
+
+	_, err := b.GetLogs(context.Background(), &v1alpha3.PipelineRun{})
+	assert.NotNil(t, err, "should fail without run ID")
+
+	pipelineRun := &v1alpha3.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "ns",
+			Annotations: map[string]string{v1alpha3.JenkinsPipelineRunIDAnnoKey: "1"},
+		},
+		Spec: v1alpha3.PipelineRunSpec{PipelineRef: &v1.ObjectReference{Name: "pipeline"}},
+	}
+	_, err = b.GetLogs(context.Background(), pipelineRun)
+	assert.Nil(t, err)
+}
+
+func TestBackend_GetArtifact(t *testing.T) {
+	b := NewBackend(fakedevops.New(), core.JenkinsCore{})
+
+	_, err := b.GetArtifact(context.Background(), &v1alpha3.PipelineRun{}, "app.jar")
+	assert.NotNil(t, err, "should fail without run ID")
+
+	pipelineRun := &v1alpha3.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "ns",
+			Annotations: map[string]string{v1alpha3.JenkinsPipelineRunIDAnnoKey: "1"},
+		},
+		Spec: v1alpha3.PipelineRunSpec{PipelineRef: &v1.ObjectReference{Name: "pipeline"}},
+	}
+	_, err = b.GetArtifact(context.Background(), pipelineRun, "app.jar")
+	assert.Nil(t, err)
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelinerun
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+// queuedRequeuePeriod is the period of checking if a queued PipelineRun could be triggered
+const queuedRequeuePeriod = 5 * time.Second
+
+// admit checks if the PipelineRun is allowed to be triggered according to the concurrency policy of the Pipeline.
+// The PipelineRuns which are waiting to be triggered are admitted by their priorities, then in FIFO order.
+func (r *Reconciler) admit(ctx context.Context, pipeline *v1alpha3.Pipeline, pr *v1alpha3.PipelineRun) (admitted bool, err error) {
+	policy := pipeline.Spec.Concurrency
+	if !policy.Limited() {
+		return true, nil
+	}
+
+	pipelineRuns := &v1alpha3.PipelineRunList{}
+	if err = r.List(ctx, pipelineRuns, client.InNamespace(pipeline.Namespace),
+		client.MatchingLabels{v1alpha3.PipelineNameLabelKey: pipeline.Name}); err != nil {
+		return
+	}
+
+	var occupied int32
+	for i := range pipelineRuns.Items {
+		item := &pipelineRuns.Items[i]
+		if item.Name == pr.Name || item.HasCompleted() {
+			continue
+		}
+		if item.HasStarted() || (item.Buildable() && isAheadOf(policy, item, pr)) {
+			occupied++
+		}
+	}
+	admitted = occupied < policy.MaxConcurrentRuns
+	return
+}
+
+// isAheadOf indicates if the PipelineRun a should be triggered before b. The one of a higher priority goes first,
+// and the ones of the same priority are in the order of their creation.
+func isAheadOf(policy *v1alpha3.ConcurrencyPolicy, a, b *v1alpha3.PipelineRun) bool {
+	if priorityA, priorityB := policy.GetPriority(a), policy.GetPriority(b); priorityA != priorityB {
+		return priorityA > priorityB
+	}
+	if a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.Name < b.Name
+	}
+	return a.CreationTimestamp.Before(&b.CreationTimestamp)
+}
+
+// holdPipelineRun queues or discards the PipelineRun which exceeds the concurrency limit of the Pipeline
+func (r *Reconciler) holdPipelineRun(ctx context.Context, pipeline *v1alpha3.Pipeline, pr *v1alpha3.PipelineRun) (ctrl.Result, error) {
+	policy := pipeline.Spec.Concurrency
+	cause := fmt.Sprintf("the Pipeline %s has reached the limit of %d concurrent runs",
+		pipeline.Name, policy.MaxConcurrentRuns)
+	discard := policy.QueuePolicy == v1alpha3.QueuePolicyDiscard
+	if !discard && policy.MaxQueuedRuns > 0 && pr.Status.Phase != v1alpha3.Queued {
+		var err error
+		if discard, err = r.preempt(ctx, pipeline, pr); err != nil {
+			return ctrl.Result{}, err
+		} else if discard {
+			cause = fmt.Sprintf("the queue of the Pipeline %s is full of %d PipelineRuns which are ahead of it",
+				pipeline.Name, policy.MaxQueuedRuns)
+		}
+	}
+	return r.hold(ctx, pr, v1alpha3.ConcurrencyLimited, cause, discard)
+}
+
+// preempt makes room for the PipelineRun if the queue of the Pipeline is full. The queued PipelineRun at the tail
+// is cancelled if the PipelineRun is ahead of it, otherwise the PipelineRun itself should be discarded.
+func (r *Reconciler) preempt(ctx context.Context, pipeline *v1alpha3.Pipeline, pr *v1alpha3.PipelineRun) (discard bool, err error) {
+	policy := pipeline.Spec.Concurrency
+	pipelineRuns := &v1alpha3.PipelineRunList{}
+	if err = r.List(ctx, pipelineRuns, client.InNamespace(pipeline.Namespace),
+		client.MatchingLabels{v1alpha3.PipelineNameLabelKey: pipeline.Name}); err != nil {
+		return
+	}
+
+	var queued int32
+	var tail *v1alpha3.PipelineRun
+	for i := range pipelineRuns.Items {
+		item := &pipelineRuns.Items[i]
+		if item.Name == pr.Name || item.Status.Phase != v1alpha3.Queued || item.HasStarted() || !item.Buildable() {
+			continue
+		}
+		queued++
+		if tail == nil || isAheadOf(policy, tail, item) {
+			tail = item
+		}
+	}
+	if queued < policy.MaxQueuedRuns {
+		return
+	}
+	if !isAheadOf(policy, pr, tail) {
+		discard = true
+		return
+	}
+
+	now := v1.Now()
+	message := fmt.Sprintf("preempted by the PipelineRun %s of the priority %d", pr.Name, policy.GetPriority(pr))
+	status := tail.Status.DeepCopy()
+	status.AddCondition(&v1alpha3.Condition{
+		Type:               v1alpha3.ConditionSucceeded,
+		Status:             v1alpha3.ConditionFalse,
+		Reason:             v1alpha3.Preempted,
+		Message:            message,
+		LastTransitionTime: now,
+		LastProbeTime:      now,
+	})
+	status.Phase = v1alpha3.Cancelled
+	status.UpdateTime = &now
+	status.CompletionTime = &now
+	if err = r.updateStatus(ctx, status, client.ObjectKeyFromObject(tail)); err == nil {
+		r.recorder.Eventf(tail, corev1.EventTypeWarning, v1alpha3.Preempted, "PipelineRun %s was %s", tail.Name, message)
+	}
+	return
+}
+
+// hold queues the PipelineRun until it's allowed to be triggered, or discards it when discard is true
+func (r *Reconciler) hold(ctx context.Context, pr *v1alpha3.PipelineRun, reason, cause string, discard bool) (ctrl.Result, error) {
+	now := v1.Now()
+	status := pr.Status.DeepCopy()
+	status.UpdateTime = &now
+
+	if discard {
+		message := "discarded because " + cause
+		status.AddCondition(&v1alpha3.Condition{
+			Type:               v1alpha3.ConditionSucceeded,
+			Status:             v1alpha3.ConditionFalse,
+			Reason:             reason,
+			Message:            message,
+			LastTransitionTime: now,
+			LastProbeTime:      now,
+		})
+		status.Phase = v1alpha3.Cancelled
+		status.CompletionTime = &now
+		r.recorder.Eventf(pr, corev1.EventTypeWarning, reason, "PipelineRun %s was %s", pr.Name, message)
+		return ctrl.Result{}, r.updateStatus(ctx, status, client.ObjectKeyFromObject(pr))
+	}
+
+	if status.Phase != v1alpha3.Queued {
+		// the label is required to find the queued PipelineRuns of the Pipeline
+		if err := r.updateLabelsAndAnnotations(ctx, pr); err != nil {
+			return ctrl.Result{}, err
+		}
+		status.AddCondition(&v1alpha3.Condition{
+			Type:               v1alpha3.ConditionReady,
+			Status:             v1alpha3.ConditionFalse,
+			Reason:             reason,
+			Message:            "waiting because " + cause,
+			LastTransitionTime: now,
+			LastProbeTime:      now,
+		})
+		status.Phase = v1alpha3.Queued
+		r.recorder.Eventf(pr, corev1.EventTypeNormal, reason, "Queued PipelineRun %s", pr.Name)
+		if err := r.updateStatus(ctx, status, client.ObjectKeyFromObject(pr)); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{RequeueAfter: queuedRequeuePeriod}, nil
+}
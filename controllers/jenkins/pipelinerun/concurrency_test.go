@@ -0,0 +1,327 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelinerun
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/constants"
+)
+
+func newPipelineRunOf(name, pipeline string, created time.Time) *v1alpha3.PipelineRun {
+	pr := &v1alpha3.PipelineRun{}
+	pr.SetName(name)
+	pr.SetNamespace("ns")
+	pr.SetLabels(map[string]string{v1alpha3.PipelineNameLabelKey: pipeline})
+	pr.SetCreationTimestamp(metav1.NewTime(created))
+	pr.Spec.PipelineRef = &v1.ObjectReference{Name: pipeline}
+	return pr
+}
+
+func int32Ptr(value int32) *int32 {
+	return &value
+}
+
+func TestReconciler_admit(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	now := time.Now()
+	pipeline := &v1alpha3.Pipeline{}
+	pipeline.SetName("pipeline")
+	pipeline.SetNamespace("ns")
+	limitedPipeline := pipeline.DeepCopy()
+	limitedPipeline.Spec.Concurrency = &v1alpha3.ConcurrencyPolicy{MaxConcurrentRuns: 1}
+
+	current := newPipelineRunOf("current", "pipeline", now)
+	running := newPipelineRunOf("running", "pipeline", now.Add(-time.Minute))
+	running.SetAnnotations(map[string]string{v1alpha3.JenkinsPipelineRunIDAnnoKey: "1"})
+	completed := running.DeepCopy()
+	completed.Status.CompletionTime = &metav1.Time{Time: now}
+	earlier := newPipelineRunOf("earlier", "pipeline", now.Add(-time.Second))
+	later := newPipelineRunOf("later", "pipeline", now.Add(time.Second))
+	otherPipeline := newPipelineRunOf("other", "other", now.Add(-time.Minute))
+	otherPipeline.SetAnnotations(map[string]string{v1alpha3.JenkinsPipelineRunIDAnnoKey: "1"})
+	urgent := later.DeepCopy()
+	urgent.Spec.Priority = int32Ptr(10)
+	release := later.DeepCopy()
+	release.Spec.SCM = &v1alpha3.SCM{RefName: "release-1.0"}
+	prioritizedPipeline := limitedPipeline.DeepCopy()
+	prioritizedPipeline.Spec.Concurrency.PriorityClasses = []v1alpha3.PriorityClass{
+		{Name: "release", Value: 100, Refs: []string{"release-*"}},
+		{Name: "others", Value: -1},
+	}
+
+	tests := []struct {
+		name     string
+		pipeline *v1alpha3.Pipeline
+		objects  []runtime.Object
+		want     bool
+	}{{
+		name:     "without limit",
+		pipeline: pipeline,
+		objects:  []runtime.Object{running, current},
+		want:     true,
+	}, {
+		name:     "no other PipelineRuns",
+		pipeline: limitedPipeline,
+		objects:  []runtime.Object{current},
+		want:     true,
+	}, {
+		name:     "has a running PipelineRun",
+		pipeline: limitedPipeline,
+		objects:  []runtime.Object{running, current},
+		want:     false,
+	}, {
+		name:     "the running PipelineRun has completed",
+		pipeline: limitedPipeline,
+		objects:  []runtime.Object{completed, current, later},
+		want:     true,
+	}, {
+		name:     "has an earlier waiting PipelineRun",
+		pipeline: limitedPipeline,
+		objects:  []runtime.Object{earlier, current},
+		want:     false,
+	}, {
+		name:     "has a later waiting PipelineRun of a higher priority",
+		pipeline: limitedPipeline,
+		objects:  []runtime.Object{urgent, current},
+		want:     false,
+	}, {
+		name:     "has a later waiting PipelineRun of a higher priority class",
+		pipeline: prioritizedPipeline,
+		objects:  []runtime.Object{release, current},
+		want:     false,
+	}, {
+		name:     "has a later waiting PipelineRun of a lower priority class",
+		pipeline: prioritizedPipeline,
+		objects:  []runtime.Object{later, current},
+		want:     true,
+	}, {
+		name:     "the running PipelineRun belongs to another Pipeline",
+		pipeline: limitedPipeline,
+		objects:  []runtime.Object{otherPipeline, current},
+		want:     true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Reconciler{
+				Client: fake.NewClientBuilder().WithScheme(schema).WithRuntimeObjects(tt.objects...).Build(),
+			}
+			admitted, err := r.admit(context.Background(), tt.pipeline, current)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, admitted)
+		})
+	}
+}
+
+func TestReconciler_holdPipelineRun(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	pipeline := &v1alpha3.Pipeline{}
+	pipeline.SetName("pipeline")
+	pipeline.SetNamespace("ns")
+	pipeline.Spec.Concurrency = &v1alpha3.ConcurrencyPolicy{MaxConcurrentRuns: 1}
+	discardPipeline := pipeline.DeepCopy()
+	discardPipeline.Spec.Concurrency.QueuePolicy = v1alpha3.QueuePolicyDiscard
+
+	tests := []struct {
+		name         string
+		pipeline     *v1alpha3.Pipeline
+		wantPhase    v1alpha3.RunPhase
+		wantRequeue  bool
+		wantComplete bool
+	}{{
+		name:        "queue",
+		pipeline:    pipeline,
+		wantPhase:   v1alpha3.Queued,
+		wantRequeue: true,
+	}, {
+		name:         "discard",
+		pipeline:     discardPipeline,
+		wantPhase:    v1alpha3.Cancelled,
+		wantComplete: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := newPipelineRunOf("current", "pipeline", time.Now())
+			k8sClient := fake.NewClientBuilder().WithScheme(schema).WithObjects(pr.DeepCopy()).Build()
+			recorder := record.NewFakeRecorder(1)
+			r := &Reconciler{
+				Client:   k8sClient,
+				log:      logr.New(log.NullLogSink{}),
+				recorder: recorder,
+			}
+			result, err := r.holdPipelineRun(context.Background(), tt.pipeline, pr)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantRequeue, result.RequeueAfter > 0)
+			assert.Equal(t, 1, len(recorder.Events))
+
+			got := &v1alpha3.PipelineRun{}
+			assert.Nil(t, k8sClient.Get(context.Background(), types.NamespacedName{Namespace: "ns", Name: "current"}, got))
+			assert.Equal(t, tt.wantPhase, got.Status.Phase)
+			assert.Equal(t, tt.wantComplete, got.HasCompleted())
+			if assert.NotNil(t, got.Status.GetLatestCondition()) {
+				assert.Equal(t, v1alpha3.ConcurrencyLimited, got.Status.GetLatestCondition().Reason)
+			}
+		})
+	}
+}
+
+func TestReconciler_checkQuota(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.Nil(t, v1.AddToScheme(scheme))
+	assert.Nil(t, v1alpha3.AddToScheme(scheme))
+
+	ns := &v1.Namespace{}
+	ns.SetName("ns")
+	ns.SetLabels(map[string]string{constants.DevOpsProjectLabelKey: "project"})
+	running := newPipelineRunOf("running", "pipeline", time.Now().Add(-time.Minute))
+	running.SetAnnotations(map[string]string{v1alpha3.JenkinsPipelineRunIDAnnoKey: "1"})
+	running.Status.StartTime = &metav1.Time{Time: time.Now().Add(-time.Minute)}
+
+	tests := []struct {
+		name      string
+		quota     *v1alpha3.ProjectQuota
+		wantHeld  bool
+		wantPhase v1alpha3.RunPhase
+	}{{
+		name: "no quota",
+	}, {
+		name:  "within quota",
+		quota: &v1alpha3.ProjectQuota{MaxConcurrentRuns: 2},
+	}, {
+		name:      "queued",
+		quota:     &v1alpha3.ProjectQuota{MaxConcurrentRuns: 1},
+		wantHeld:  true,
+		wantPhase: v1alpha3.Queued,
+	}, {
+		name:      "cancelled",
+		quota:     &v1alpha3.ProjectQuota{MaxRunMinutesPerDay: 1},
+		wantHeld:  true,
+		wantPhase: v1alpha3.Cancelled,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := &v1alpha3.DevOpsProject{}
+			project.SetName("project")
+			project.Spec.Quota = tt.quota
+			pr := newPipelineRunOf("current", "pipeline", time.Now())
+			k8sClient := fake.NewClientBuilder().WithScheme(scheme).
+				WithObjects(ns, project, running.DeepCopy(), pr.DeepCopy()).Build()
+			r := &Reconciler{
+				Client:   k8sClient,
+				log:      logr.New(log.NullLogSink{}),
+				recorder: record.NewFakeRecorder(1),
+			}
+			held, _, err := r.checkQuota(context.Background(), pr)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantHeld, held)
+
+			got := &v1alpha3.PipelineRun{}
+			assert.Nil(t, k8sClient.Get(context.Background(), types.NamespacedName{Namespace: "ns", Name: "current"}, got))
+			assert.Equal(t, tt.wantPhase, got.Status.Phase)
+			if tt.wantHeld && assert.NotNil(t, got.Status.GetLatestCondition()) {
+				assert.Equal(t, v1alpha3.QuotaExceeded, got.Status.GetLatestCondition().Reason)
+			}
+		})
+	}
+}
+
+func TestReconciler_preempt(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	now := time.Now()
+	pipeline := &v1alpha3.Pipeline{}
+	pipeline.SetName("pipeline")
+	pipeline.SetNamespace("ns")
+	pipeline.Spec.Concurrency = &v1alpha3.ConcurrencyPolicy{MaxConcurrentRuns: 1, MaxQueuedRuns: 1}
+
+	newQueued := func(name string, priority int32, created time.Time) *v1alpha3.PipelineRun {
+		pr := newPipelineRunOf(name, "pipeline", created)
+		pr.Spec.Priority = int32Ptr(priority)
+		pr.Status.Phase = v1alpha3.Queued
+		return pr
+	}
+
+	tests := []struct {
+		name          string
+		queued        *v1alpha3.PipelineRun
+		priority      int32
+		wantCurrent   v1alpha3.RunPhase
+		wantQueued    v1alpha3.RunPhase
+		wantPreempted bool
+	}{{
+		name:          "preempt the queued PipelineRun of a lower priority",
+		queued:        newQueued("queued", 0, now.Add(-time.Minute)),
+		priority:      10,
+		wantCurrent:   v1alpha3.Queued,
+		wantQueued:    v1alpha3.Cancelled,
+		wantPreempted: true,
+	}, {
+		name:        "discard the PipelineRun of the same priority",
+		queued:      newQueued("queued", 10, now.Add(-time.Minute)),
+		priority:    10,
+		wantCurrent: v1alpha3.Cancelled,
+		wantQueued:  v1alpha3.Queued,
+	}, {
+		name:        "the queue is not full",
+		queued:      newPipelineRunOf("queued", "pipeline", now.Add(-time.Minute)),
+		priority:    0,
+		wantCurrent: v1alpha3.Queued,
+		wantQueued:  "",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := newPipelineRunOf("current", "pipeline", now)
+			current.Spec.Priority = int32Ptr(tt.priority)
+			k8sClient := fake.NewClientBuilder().WithScheme(schema).WithObjects(current.DeepCopy(), tt.queued).Build()
+			r := &Reconciler{
+				Client:   k8sClient,
+				log:      logr.New(log.NullLogSink{}),
+				recorder: record.NewFakeRecorder(2),
+			}
+			_, err := r.holdPipelineRun(context.Background(), pipeline, current)
+			assert.Nil(t, err)
+
+			got := &v1alpha3.PipelineRun{}
+			assert.Nil(t, k8sClient.Get(context.Background(), types.NamespacedName{Namespace: "ns", Name: "current"}, got))
+			assert.Equal(t, tt.wantCurrent, got.Status.Phase)
+			assert.Nil(t, k8sClient.Get(context.Background(), types.NamespacedName{Namespace: "ns", Name: "queued"}, got))
+			assert.Equal(t, tt.wantQueued, got.Status.Phase)
+			if tt.wantPreempted && assert.NotNil(t, got.Status.GetLatestCondition()) {
+				assert.Equal(t, v1alpha3.Preempted, got.Status.GetLatestCondition().Reason)
+				assert.True(t, got.HasCompleted())
+			}
+		})
+	}
+}
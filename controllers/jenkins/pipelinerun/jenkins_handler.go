@@ -18,6 +18,7 @@ package pipelinerun
 
 import (
 	"fmt"
+	"net/http"
 	"strconv"
 	"strings"
 
@@ -110,6 +111,45 @@ func (handler *jenkinsHandler) getPipelineRunResult(devopsProjectName, pipelineN
 	})
 }
 
+// jenkinsBuildParameters is the build of a Jenkins job which only contains the parameters
+type jenkinsBuildParameters struct {
+	Actions []struct {
+		Parameters []struct {
+			Name  string      `json:"name"`
+			Value interface{} `json:"value"`
+		} `json:"parameters"`
+	} `json:"actions"`
+}
+
+// getPipelineRunResults returns the effective parameters of the Jenkins build as the results of the PipelineRun,
+// including the default values of the parameters which are not specified by the PipelineRun.
+func (handler *jenkinsHandler) getPipelineRunResults(pr *v1alpha3.PipelineRun) (results []v1alpha3.RunResult, err error) {
+	buildNum := getJenkinsBuildNumber(pr)
+	jobPath := getJenkinsJobPath(pr)
+	if buildNum < 0 || jobPath == "" {
+		return nil, fmt.Errorf("unable to get PipelineRun results due to not found Jenkins build")
+	}
+
+	build := &jenkinsBuildParameters{}
+	api := fmt.Sprintf("%s/%d/api/json?tree=actions[parameters[name,value]]", jobPath, buildNum)
+	if err = handler.RequestWithData(http.MethodGet, api, nil, nil, http.StatusOK, build); err != nil {
+		return
+	}
+	for _, action := range build.Actions {
+		for _, parameter := range action.Parameters {
+			// the value of a password or file parameter is not exposed by Jenkins
+			if parameter.Value == nil {
+				continue
+			}
+			results = append(results, v1alpha3.RunResult{
+				Name:  parameter.Name,
+				Value: fmt.Sprint(parameter.Value),
+			})
+		}
+	}
+	return
+}
+
 func (handler *jenkinsHandler) triggerJenkinsJob(devopsProjectName, pipelineName string, prSpec *v1alpha3.PipelineRunSpec) (*job.PipelineRun, error) {
 	c := job.BlueOceanClient{JenkinsCore: *handler.JenkinsCore, Organization: "jenkins"}
 
@@ -23,6 +23,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -311,3 +312,38 @@ func Test_getJenkinsJobPath(t *testing.T) {
 		})
 	}
 }
+
+func Test_getPipelineRunResults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/job/ns/job/pipeline/2/api/json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"actions":[{},{"parameters":[{"name":"IMAGE","value":"nginx"},` +
+			`{"name":"PUSH","value":true},{"name":"PASSWORD"}]}]}`))
+	}))
+	defer server.Close()
+
+	handler := &jenkinsHandler{&core.JenkinsCore{URL: server.URL}}
+	pipelineRun := &v1alpha3.PipelineRun{
+		ObjectMeta: v1.ObjectMeta{
+			Namespace:   "ns",
+			Annotations: map[string]string{v1alpha3.JenkinsPipelineRunIDAnnoKey: "2"},
+		},
+		Spec: v1alpha3.PipelineRunSpec{
+			PipelineRef: &corev1.ObjectReference{Name: "pipeline"},
+		},
+	}
+	results, err := handler.getPipelineRunResults(pipelineRun)
+	assert.Nil(t, err)
+	assert.Equal(t, []v1alpha3.RunResult{{Name: "IMAGE", Value: "nginx"}, {Name: "PUSH", Value: "true"}}, results)
+
+	// not found build
+	pipelineRun.Annotations[v1alpha3.JenkinsPipelineRunIDAnnoKey] = "3"
+	_, err = handler.getPipelineRunResults(pipelineRun)
+	assert.NotNil(t, err)
+
+	// not started
+	_, err = handler.getPipelineRunResults(&v1alpha3.PipelineRun{})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelinerun
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/devops/breaker"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// parkPipelineRun marks the PipelineRun as waiting for Jenkins, then parks it until Jenkins recovers.
+// It's requeued after the cool-down of the circuit breaker as well, so that a probe request could be sent.
+func (r *Reconciler) parkPipelineRun(ctx context.Context, pr *v1alpha3.PipelineRun, cause error) (ctrl.Result, error) {
+	r.log.V(4).Info("Jenkins is unavailable, park the PipelineRun", "PipelineRun", client.ObjectKeyFromObject(pr))
+	status := pr.Status.DeepCopy()
+	if condition := status.GetCondition(v1alpha3.ConditionBackendAvailable); condition == nil ||
+		condition.Status != v1alpha3.ConditionFalse {
+		now := v1.Now()
+		status.AddCondition(&v1alpha3.Condition{
+			Type:               v1alpha3.ConditionBackendAvailable,
+			Status:             v1alpha3.ConditionFalse,
+			Reason:             v1alpha3.BackendUnavailable,
+			Message:            cause.Error(),
+			LastTransitionTime: now,
+			LastProbeTime:      now,
+		})
+		r.recorder.Eventf(pr, corev1.EventTypeWarning, v1alpha3.BackendUnavailable,
+			"Jenkins is unavailable, the PipelineRun will be resumed once it recovers")
+		if err := r.updateStatus(ctx, status, client.ObjectKeyFromObject(pr)); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if r.retryQueue != nil {
+		r.retryQueue.Park(pr)
+	}
+	retryAfter := r.Breaker.RetryAfter()
+	if retryAfter == 0 {
+		retryAfter = defaultRetryAfter
+	}
+	return ctrl.Result{RequeueAfter: retryAfter}, nil
+}
+
+// markBackendAvailable flips the BackendAvailable condition if the PipelineRun was waiting for Jenkins
+func markBackendAvailable(status *v1alpha3.PipelineRunStatus) {
+	if condition := status.GetCondition(v1alpha3.ConditionBackendAvailable); condition != nil &&
+		condition.Status == v1alpha3.ConditionFalse {
+		now := v1.Now()
+		status.AddCondition(&v1alpha3.Condition{
+			Type:               v1alpha3.ConditionBackendAvailable,
+			Status:             v1alpha3.ConditionTrue,
+			Reason:             v1alpha3.BackendRecovered,
+			LastTransitionTime: now,
+			LastProbeTime:      now,
+		})
+	}
+}
+
+// isBackendUnavailable returns true if the error was caused by an outage of Jenkins
+func isBackendUnavailable(err error) bool {
+	return breaker.IsUnavailable(err)
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelinerun
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/jenkins-zh/jenkins-client/pkg/core"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrlCore "kubesphere.io/devops/controllers/core"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/devops/breaker"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestReconcileWhileJenkinsUnavailable(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	pipeline := &v1alpha3.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pipeline"},
+		Spec:       v1alpha3.PipelineSpec{Type: v1alpha3.NoScmPipelineType},
+	}
+	pipelineRun := &v1alpha3.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "ns",
+			Name:        "run",
+			Annotations: map[string]string{v1alpha3.JenkinsPipelineRunIDAnnoKey: "1"},
+		},
+		Spec: v1alpha3.PipelineRunSpec{
+			PipelineRef: &v1.ObjectReference{Namespace: "ns", Name: "pipeline"},
+		},
+	}
+
+	jenkinsBreaker := breaker.New(1, time.Minute, time.Minute)
+	jenkinsBreaker.Failure()
+
+	c := fake.NewClientBuilder().WithScheme(schema).WithObjects(pipeline, pipelineRun).Build()
+	r := &Reconciler{
+		Client: c,
+		JenkinsCore: core.JenkinsCore{
+			URL:          "http://jenkins.fake",
+			RoundTripper: jenkinsBreaker.RoundTripper(nil),
+		},
+		Breaker: jenkinsBreaker,
+	}
+	assert.Nil(t, r.SetupWithManager(&ctrlCore.FakeManager{Client: c, Scheme: schema}))
+	r.log = logr.New(log.NullLogSink{})
+	recorder := record.NewFakeRecorder(10)
+	r.recorder = recorder
+
+	result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(pipelineRun)})
+	assert.Nil(t, err)
+	assert.True(t, result.RequeueAfter > 0 && result.RequeueAfter <= time.Minute)
+	assert.Equal(t, 1, r.retryQueue.Len())
+	assert.Equal(t, 1, len(recorder.Events))
+
+	updated := &v1alpha3.PipelineRun{}
+	assert.Nil(t, c.Get(context.Background(), client.ObjectKeyFromObject(pipelineRun), updated))
+	condition := updated.Status.GetCondition(v1alpha3.ConditionBackendAvailable)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, v1alpha3.ConditionFalse, condition.Status)
+		assert.Equal(t, v1alpha3.BackendUnavailable, condition.Reason)
+	}
+
+	// the condition and the event are not repeated
+	_, err = r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(pipelineRun)})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, r.retryQueue.Len())
+	assert.Equal(t, 1, len(recorder.Events))
+
+	// the parked PipelineRuns are flushed once Jenkins recovers
+	jenkinsBreaker.Success()
+	assert.Equal(t, 0, r.retryQueue.Len())
+
+	markBackendAvailable(&updated.Status)
+	condition = updated.Status.GetCondition(v1alpha3.ConditionBackendAvailable)
+	assert.Equal(t, v1alpha3.ConditionTrue, condition.Status)
+	assert.Equal(t, v1alpha3.BackendRecovered, condition.Reason)
+}
+
+func TestMarkBackendAvailable(t *testing.T) {
+	status := &v1alpha3.PipelineRunStatus{}
+	markBackendAvailable(status)
+	assert.Nil(t, status.GetCondition(v1alpha3.ConditionBackendAvailable))
+}
@@ -28,19 +28,23 @@ import (
 	"time"
 
 	"github.com/jenkins-zh/jenkins-client/pkg/core"
-	"github.com/jenkins-zh/jenkins-client/pkg/job"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
+	mgrcore "kubesphere.io/devops/controllers/core"
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/backend"
 	devopsClient "kubesphere.io/devops/pkg/client/devops"
+	"kubesphere.io/devops/pkg/client/devops/breaker"
 	"kubesphere.io/devops/pkg/jwt/token"
+	"kubesphere.io/devops/pkg/metrics"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
 // tokenExpireIn indicates that the temporary token issued by controller will be expired in some time.
@@ -49,6 +53,9 @@ const tokenExpireIn time.Duration = 5 * time.Minute
 // BuildNotExistMsg indicates the build with pipelinerun-id not exist in jenkins
 const BuildNotExistMsg = "not found resources"
 
+// defaultRetryAfter is the requeue delay of the parked PipelineRuns when there is no circuit breaker
+const defaultRetryAfter = 30 * time.Second
+
 // Reconciler reconciles a PipelineRun object
 type Reconciler struct {
 	client.Client
@@ -61,6 +68,15 @@ type Reconciler struct {
 	TokenIssuer          token.Issuer
 	recorder             record.EventRecorder
 	PipelineRunDataStore string
+	// BackendRouter skips the PipelineRuns which belong to DevOpsProjects using other backends
+	BackendRouter *backend.Router
+	// ControllerOptions tunes the concurrency and the rate limiter of this controller
+	ControllerOptions controller.Options
+	// Backend triggers and tracks the PipelineRuns, it's the Jenkins backend of above clients by default
+	Backend backend.Interface
+	// Breaker is the circuit breaker of Jenkins, the PipelineRuns are parked while Jenkins is unavailable
+	Breaker    *breaker.Breaker
+	retryQueue *mgrcore.RetryQueue
 }
 
 //+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns,verbs=get;list;watch;create;update;patch;delete
@@ -80,14 +96,18 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	jHandler := &jenkinsHandler{&r.JenkinsCore}
-
 	// don't modify the cache in other places, like informer cache.
 	pipelineRunCopied := pipelineRun.DeepCopy()
 
 	// DeletionTimestamp.IsZero() means copyPipeline has not been deleted.
 	if !pipelineRunCopied.ObjectMeta.DeletionTimestamp.IsZero() {
-		if err = jHandler.deleteJenkinsJobHistory(pipelineRunCopied); err != nil {
+		if pipelineRunCopied.Spec.GetDeletionPolicy() != v1alpha3.DeletionPolicyDelete {
+			// the policy was changed after the finalizer had been added, keep the Jenkins build
+			k8sutil.RemoveFinalizer(&pipelineRunCopied.ObjectMeta, v1alpha3.PipelineRunFinalizerName)
+			err = r.Update(context.TODO(), pipelineRunCopied)
+		} else if err = r.backend().DeleteRun(ctx, pipelineRunCopied); isBackendUnavailable(err) {
+			return r.parkPipelineRun(ctx, pipelineRunCopied, err)
+		} else if err != nil {
 			klog.V(4).Infof("failed to delete Jenkins job history from PipelineRun: %s/%s, error: %v",
 				pipelineRunCopied.Namespace, pipelineRunCopied.Name, err)
 		} else {
@@ -97,11 +117,23 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, err
 	}
 
+	// the PipelineRun is handled by another backend
+	if handled, err := r.BackendRouter.HandlesObject(ctx, pipelineRunCopied, backend.Jenkins); err != nil || !handled {
+		return ctrl.Result{}, err
+	}
+
 	// the PipelineRun cannot allow building
 	if !pipelineRunCopied.Buildable() {
 		return ctrl.Result{}, nil
 	}
 
+	// Jenkins is not able to run a Pipeline without a corresponding job
+	if pipelineRunCopied.Spec.HasInlinePipelineSpec() {
+		r.recorder.Eventf(pipelineRunCopied, corev1.EventTypeWarning, v1alpha3.InlinePipelineSpecUnsupported,
+			"Jenkins does not support running the inline PipelineSpec of PipelineRun %s", req.NamespacedName)
+		return ctrl.Result{}, r.rejectInlinePipelineRun(ctx, pipelineRunCopied)
+	}
+
 	// check PipelineRef
 	if pipelineRunCopied.Spec.PipelineRef == nil || pipelineRunCopied.Spec.PipelineRef.Name == "" {
 		// make the PipelineRun as orphan
@@ -129,8 +161,11 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	// check PipelineRun status
 	if pipelineRunCopied.HasStarted() {
 		log.V(5).Info("pipeline has already started, and we are retrieving run data from Jenkins.")
-		pipelineBuild, err := jHandler.getPipelineRunResult(namespaceName, pipelineName, pipelineRunCopied)
+		status, runResultJSON, err := r.getRunStatus(ctx, pipelineRunCopied)
 		if err != nil {
+			if isBackendUnavailable(err) {
+				return r.parkPipelineRun(ctx, pipelineRunCopied, err)
+			}
 			if err.Error() == BuildNotExistMsg { // retry if get pipelinerun failed by not exist
 				runID, _ := pipelineRunCopied.GetPipelineRunID()
 				log.Info(fmt.Sprintf("get pipelinerun data(id: %s) error with not exit, retry.", runID))
@@ -141,27 +176,26 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 			return ctrl.Result{}, err
 		}
 
-		// update pipelinerun status with pipelineBuild
-		status := pipelineRunCopied.Status.DeepCopy()
-		pbApplier := pipelineBuildApplier{pipelineBuild}
-		pbApplier.apply(status)
+		markBackendAvailable(status)
+		justCompleted := !pipelineRunCopied.HasCompleted() && !status.CompletionTime.IsZero()
 		// Because the status is a subresource of PipelineRun, we have to update status separately.
 		// See also: https://book-v1.book.kubebuilder.io/basics/status_subresource.html
 		if err := r.updateStatus(ctx, status, req.NamespacedName); err != nil {
 			log.Error(err, "unable to update PipelineRun status.")
 			return ctrl.Result{}, err
 		}
+		if justCompleted {
+			completedPipelineRun := pipelineRunCopied.DeepCopy()
+			completedPipelineRun.Status = *status
+			metrics.ObservePipelineRunCompleted(backend.Jenkins, completedPipelineRun)
+		}
 
+		jHandler := &jenkinsHandler{&r.JenkinsCore}
 		nodeDetails, err := jHandler.getPipelineNodeDetails(pipelineName, namespaceName, pipelineRunCopied)
 		if err != nil {
 			log.Error(err, "unable to get PipelineRun nodes detail")
 			r.recorder.Eventf(pipelineRunCopied, corev1.EventTypeWarning, v1alpha3.RetrieveFailed, "Failed to retrieve nodes detail from Jenkins, and error was %v", err)
 		}
-		runResultJSON, err := json.Marshal(pipelineBuild)
-		if err != nil {
-			log.Error(err, "unable to marshal result data to JSON")
-			runResultJSON = []byte("{}")
-		}
 		nodeDetailsJSON, err := json.Marshal(nodeDetails)
 		if err != nil {
 			log.Error(err, "unable to marshal nodes details to JSON")
@@ -178,7 +212,9 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		if pipelineRunCopied.Annotations == nil {
 			pipelineRunCopied.Annotations = make(map[string]string)
 		}
-		pipelineRunCopied.Annotations[v1alpha3.JenkinsPipelineRunStatusAnnoKey] = string(runResultJSON)
+		if runResultJSON != nil {
+			pipelineRunCopied.Annotations[v1alpha3.JenkinsPipelineRunStatusAnnoKey] = string(runResultJSON)
+		}
 		// update labels and annotations
 		if err := r.updateLabelsAndAnnotations(ctx, pipelineRunCopied); err != nil {
 			log.Error(err, "unable to update PipelineRun labels and annotations.")
@@ -191,23 +227,57 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{RequeueAfter: 3 * time.Second}, nil
 	}
 
-	// get or create JenkinsCore if the PipelineRun has creator annotation
-	jenkinsCore, err := r.getOrCreateJenkinsCore(pipelineRunCopied.GetAnnotations())
-	if err != nil {
-		r.recorder.Eventf(pipelineRunCopied, corev1.EventTypeWarning, v1alpha3.TriggerFailed, "Failed to trigger PipelineRun %s, and error was %v", req.NamespacedName, err)
+	// hold the PipelineRun until the versioning controller computed its version
+	if pipeline.Spec.Versioning != nil {
+		if _, computed := pipelineRunCopied.Annotations[v1alpha3.PipelineRunVersionAnnoKey]; !computed {
+			log.V(5).Info("waiting for the version of the PipelineRun")
+			return ctrl.Result{}, nil
+		}
+	}
+
+	// hold the PipelineRun until the path filter controller decided whether it is necessary
+	if pipeline.Spec.PathFilter != nil {
+		if _, decided := pipelineRunCopied.Annotations[v1alpha3.PipelineRunPathFilterAnnoKey]; !decided {
+			log.V(5).Info("waiting for the decision of the path filter")
+			return ctrl.Result{}, nil
+		}
+	}
+
+	// hold the PipelineRun until the quality gate controller passed the SonarQube parameters
+	if pipeline.Spec.QualityGate != nil {
+		if _, injected := pipelineRunCopied.Annotations[v1alpha3.PipelineRunQualityGateAnnoKey]; !injected {
+			log.V(5).Info("waiting for the SonarQube parameters of the PipelineRun")
+			return ctrl.Result{}, nil
+		}
+	}
+
+	// hold the PipelineRun if the DevOpsProject has reached its quota
+	if held, result, err := r.checkQuota(ctx, pipelineRunCopied); err != nil {
+		log.Error(err, "unable to check the quota of the DevOpsProject")
+		return ctrl.Result{}, err
+	} else if held {
+		return result, nil
+	}
+
+	// hold the PipelineRun if the Pipeline has reached its concurrency limit
+	if admitted, err := r.admit(ctx, pipeline, pipelineRunCopied); err != nil {
+		log.Error(err, "unable to check the concurrency of the Pipeline")
 		return ctrl.Result{}, err
+	} else if !admitted {
+		return r.holdPipelineRun(ctx, pipeline, pipelineRunCopied)
 	}
-	// create trigger handler
-	triggerHandler := &jenkinsHandler{jenkinsCore}
-	// first run
-	jobRun, err := triggerHandler.triggerJenkinsJob(namespaceName, pipelineName, &pipelineRunCopied.Spec)
-	if err != nil {
+
+	// first run, it's triggered as the creator if the PipelineRun has creator annotation
+	runID, err := r.backend().TriggerRun(ctx, pipelineRunCopied)
+	if isBackendUnavailable(err) {
+		return r.parkPipelineRun(ctx, pipelineRunCopied, err)
+	} else if err != nil {
 		log.Error(err, "unable to run pipeline", "namespace", namespaceName, "pipeline", pipeline.Name)
 		r.recorder.Eventf(pipelineRunCopied, corev1.EventTypeWarning, v1alpha3.TriggerFailed, "Failed to trigger PipelineRun %s, and error was %v", req.NamespacedName, err)
-		return ctrl.Result{}, err
+		return ctrl.Result{}, r.markTriggerFailed(ctx, pipelineRunCopied, err)
 	}
 	// check if there is still a same PipelineRun
-	if exists, err := r.hasSamePipelineRun(jobRun, pipeline); err != nil {
+	if exists, err := r.hasSamePipelineRun(runID, pipelineRunCopied.GetRefName(), pipeline); err != nil {
 		return ctrl.Result{}, err
 	} else if exists {
 		// if there still exists the same pending PipelineRun, then give up reconciling
@@ -219,13 +289,13 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, nil
 	}
 
-	log.Info("Triggered a PipelineRun", "runID", jobRun.ID)
+	log.Info("Triggered a PipelineRun", "runID", runID)
 
 	// set Jenkins run ID
 	if pipelineRunCopied.Annotations == nil {
 		pipelineRunCopied.Annotations = make(map[string]string)
 	}
-	pipelineRunCopied.Annotations[v1alpha3.JenkinsPipelineRunIDAnnoKey] = jobRun.ID
+	pipelineRunCopied.Annotations[v1alpha3.JenkinsPipelineRunIDAnnoKey] = runID
 
 	// the Update method only updates fields except subresource: status
 	if err := r.updateLabelsAndAnnotations(ctx, pipelineRunCopied); err != nil {
@@ -233,8 +303,13 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, err
 	}
 
+	if pipelineRunCopied.Status.Phase == v1alpha3.Queued {
+		pipelineRunCopied.Status.Phase = v1alpha3.Pending
+	}
 	pipelineRunCopied.Status.StartTime = &v1.Time{Time: time.Now()}
 	pipelineRunCopied.Status.UpdateTime = &v1.Time{Time: time.Now()}
+	pipelineRunCopied.Status.TriggeredBy = pipelineRunCopied.GetTriggerIdentity()
+	markBackendAvailable(&pipelineRunCopied.Status)
 	// due to the status is subresource of PipelineRun, we have to update status separately.
 	// see also: https://book-v1.book.kubebuilder.io/basics/status_subresource.html
 
@@ -243,6 +318,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, err
 	}
 	r.recorder.Eventf(pipelineRunCopied, corev1.EventTypeNormal, v1alpha3.Started, "Started PipelineRun %s", req.NamespacedName)
+	metrics.ObservePipelineRunCreated(backend.Jenkins, pipelineRunCopied)
 	// requeue after 1 second
 	return ctrl.Result{}, nil
 }
@@ -276,7 +352,28 @@ func (r *Reconciler) storePipelineRunData(nodeDetailsJSON string, pipelineRunCop
 	return
 }
 
-func (r *Reconciler) hasSamePipelineRun(jobRun *job.PipelineRun, pipeline *v1alpha3.Pipeline) (exists bool, err error) {
+// backend returns the backend of the PipelineRuns, it's the Jenkins backend if not specified
+func (r *Reconciler) backend() backend.Interface {
+	if r.Backend == nil {
+		r.Backend = &Backend{
+			DevOpsClient: r.DevOpsClient,
+			JenkinsCore:  r.JenkinsCore,
+			TokenIssuer:  r.TokenIssuer,
+		}
+	}
+	return r.Backend
+}
+
+// getRunStatus returns the latest status of the PipelineRun, and the raw data if the backend keeps it
+func (r *Reconciler) getRunStatus(ctx context.Context, pr *v1alpha3.PipelineRun) (*v1alpha3.PipelineRunStatus, []byte, error) {
+	if getter, ok := r.backend().(backend.RawRunGetter); ok {
+		return getter.GetRunStatusAndRaw(ctx, pr)
+	}
+	status, err := r.backend().GetRunStatus(ctx, pr)
+	return status, nil, err
+}
+
+func (r *Reconciler) hasSamePipelineRun(runID, refName string, pipeline *v1alpha3.Pipeline) (exists bool, err error) {
 	// check if the run ID exists in the PipelineRun
 	pipelineRuns := &v1alpha3.PipelineRunList{}
 	listOptions := []client.ListOption{
@@ -285,12 +382,13 @@ func (r *Reconciler) hasSamePipelineRun(jobRun *job.PipelineRun, pipeline *v1alp
 	}
 	if pipeline.Spec.Type == v1alpha3.MultiBranchPipelineType {
 		// add SCM reference name into list options for multi-branch Pipeline
-		listOptions = append(listOptions, client.MatchingFields{v1alpha3.PipelineRunSCMRefNameField: jobRun.Pipeline})
+		listOptions = append(listOptions, client.MatchingFields{v1alpha3.PipelineRunSCMRefNameField: refName})
+	} else {
+		refName = ""
 	}
 	if err = r.Client.List(context.Background(), pipelineRuns, listOptions...); err == nil {
-		isMultiBranch := pipeline.Spec.Type == v1alpha3.MultiBranchPipelineType
 		finder := newPipelineRunFinder(pipelineRuns.Items)
-		_, exists = finder.find(jobRun, isMultiBranch)
+		_, exists = finder[pipelineRunIdentity{id: runID, refName: refName}]
 	}
 	return
 }
@@ -314,15 +412,17 @@ func (r *Reconciler) updateLabelsAndAnnotations(ctx context.Context, pr *v1alpha
 		if err != nil {
 			return err
 		}
-		if reflect.DeepEqual(pr.Labels, prToUpdate.Labels) && reflect.DeepEqual(pr.Annotations, prToUpdate.Annotations) {
+		prToUpdate = *prToUpdate.DeepCopy()
+		// make sure all PipelineRuns have the finalizers matching their deletion policy
+		finalizersChanged := prToUpdate.Spec.GetDeletionPolicy().ApplyFinalizers(&prToUpdate.ObjectMeta,
+			v1alpha3.PipelineRunFinalizerName)
+		if !finalizersChanged && reflect.DeepEqual(pr.Labels, prToUpdate.Labels) &&
+			reflect.DeepEqual(pr.Annotations, prToUpdate.Annotations) {
 			return nil
 		}
 
-		prToUpdate = *prToUpdate.DeepCopy()
 		prToUpdate.Labels = pr.Labels
 		prToUpdate.Annotations = pr.Annotations
-		// make sure all PipelineRuns have the finalizer
-		k8sutil.AddFinalizer(&prToUpdate.ObjectMeta, v1alpha3.PipelineRunFinalizerName)
 		return r.Update(ctx, &prToUpdate)
 	})
 }
@@ -364,30 +464,64 @@ func (r *Reconciler) makePipelineRunOrphan(ctx context.Context, pr *v1alpha3.Pip
 	return
 }
 
-func (r *Reconciler) getOrCreateJenkinsCore(annotations map[string]string) (*core.JenkinsCore, error) {
-	creator, ok := annotations[v1alpha3.PipelineRunCreatorAnnoKey]
-	if !ok || creator == "" {
-		return &r.JenkinsCore, nil
-	}
-	// create a new JenkinsCore for current creator
-	accessToken, err := r.TokenIssuer.IssueTo(&user.DefaultInfo{Name: creator}, token.AccessToken, tokenExpireIn)
-	if err != nil {
-		return nil, fmt.Errorf("failed to issue access token for creator %s, error was %v", creator, err)
-	}
-	jenkinsCore := &core.JenkinsCore{
-		URL:      r.JenkinsCore.URL,
-		UserName: creator,
-		Token:    accessToken,
+// rejectInlinePipelineRun marks the PipelineRun as failed because the Jenkins backend requires a PipelineRef.
+func (r *Reconciler) rejectInlinePipelineRun(ctx context.Context, pr *v1alpha3.PipelineRun) error {
+	now := v1.Now()
+	status := pr.Status.DeepCopy()
+	status.AddCondition(&v1alpha3.Condition{
+		Type:               v1alpha3.ConditionSucceeded,
+		Status:             v1alpha3.ConditionFalse,
+		Reason:             v1alpha3.InlinePipelineSpecUnsupported,
+		Message:            "Jenkins backend requires a PipelineRef, please create the Pipeline first.",
+		LastTransitionTime: now,
+		LastProbeTime:      now,
+	})
+	status.Phase = v1alpha3.Failed
+	status.CompletionTime = &now
+	status.UpdateTime = &now
+	return r.updateStatus(ctx, status, client.ObjectKey{Namespace: pr.Namespace, Name: pr.Name})
+}
+
+// markTriggerFailed records the trigger error as the Ready condition of the PipelineRun. The original error is
+// returned so that the PipelineRun will be requeued.
+func (r *Reconciler) markTriggerFailed(ctx context.Context, pr *v1alpha3.PipelineRun, triggerErr error) error {
+	now := v1.Now()
+	status := pr.Status.DeepCopy()
+	status.AddCondition(&v1alpha3.Condition{
+		Type:               v1alpha3.ConditionReady,
+		Status:             v1alpha3.ConditionFalse,
+		Reason:             v1alpha3.TriggerFailed,
+		Message:            triggerErr.Error(),
+		LastTransitionTime: now,
+		LastProbeTime:      now,
+	})
+	if err := r.updateStatus(ctx, status, client.ObjectKey{Namespace: pr.Namespace, Name: pr.Name}); err != nil {
+		r.log.Error(err, "unable to update the status of PipelineRun", "PipelineRun", client.ObjectKeyFromObject(pr))
 	}
-	return jenkinsCore, nil
+	return triggerErr
 }
 
+// localPredicate filters out the PipelineRuns which run on the member clusters
+var localPredicate = predicate.NewPredicateFuncs(func(obj client.Object) bool {
+	pipelineRun, ok := obj.(*v1alpha3.PipelineRun)
+	return !ok || !pipelineRun.Spec.IsRemote()
+})
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 	// the name should obey Kubernetes naming convention: https://kubernetes.io/docs/concepts/overview/working-with-objects/names/
 	r.recorder = mgr.GetEventRecorderFor("pipelinerun-controller")
 	r.log = ctrl.Log.WithName("pipelinerun-controller")
-	return ctrl.NewControllerManagedBy(mgr).
+	builder := ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha3.PipelineRun{}).
-		Complete(r)
+		// the PipelineRuns which run on the member clusters are handled by the dispatcher
+		WithEventFilter(predicate.And(backend.NewPredicate(backend.Jenkins), localPredicate)).
+		WithOptions(r.ControllerOptions)
+	if r.Breaker != nil {
+		// resume the parked PipelineRuns in order once Jenkins recovers
+		r.retryQueue = mgrcore.NewRetryQueue()
+		r.Breaker.OnRecover(r.retryQueue.Flush)
+		builder = builder.Watches(r.retryQueue.Source())
+	}
+	return builder.Complete(metrics.NewReconciler(backend.Jenkins, "pipelinerun-controller", r))
 }
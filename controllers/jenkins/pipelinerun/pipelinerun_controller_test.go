@@ -20,22 +20,18 @@ import (
 	"context"
 	"fmt"
 	"github.com/go-logr/logr"
-	"github.com/jenkins-zh/jenkins-client/pkg/core"
-	"github.com/jenkins-zh/jenkins-client/pkg/job"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/client-go/tools/record"
 	ctrlCore "kubesphere.io/devops/controllers/core"
 	"kubesphere.io/devops/pkg/api/devops/v1alpha1"
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/backend"
 	"kubesphere.io/devops/pkg/client/clientset/versioned/scheme"
-	"kubesphere.io/devops/pkg/jwt/token"
-	"reflect"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -206,13 +202,7 @@ var _ = Describe("TestReconciler_hasSamePipelineRun", func() {
 			reconciler := &Reconciler{
 				Client: client,
 			}
-			jobRun := &job.PipelineRun{
-				BlueItemRun: job.BlueItemRun{
-					ID:       "123",
-					Pipeline: "main",
-				},
-			}
-			exists, err := reconciler.hasSamePipelineRun(jobRun, multiBranchPipeline)
+			exists, err := reconciler.hasSamePipelineRun("123", "main", multiBranchPipeline)
 			Expect(err).To(BeNil())
 			Expect(exists).To(BeTrue())
 		})
@@ -221,13 +211,7 @@ var _ = Describe("TestReconciler_hasSamePipelineRun", func() {
 			reconciler := &Reconciler{
 				Client: client,
 			}
-			jobRun := &job.PipelineRun{
-				BlueItemRun: job.BlueItemRun{
-					ID:       "non-existent-id",
-					Pipeline: "main",
-				},
-			}
-			exists, err := reconciler.hasSamePipelineRun(jobRun, multiBranchPipeline)
+			exists, err := reconciler.hasSamePipelineRun("non-existent-id", "main", multiBranchPipeline)
 			Expect(err).To(BeNil())
 			Expect(exists).To(BeFalse())
 		})
@@ -236,13 +220,7 @@ var _ = Describe("TestReconciler_hasSamePipelineRun", func() {
 			reconciler := &Reconciler{
 				Client: client,
 			}
-			jobRun := &job.PipelineRun{
-				BlueItemRun: job.BlueItemRun{
-					ID:       "123",
-					Pipeline: "non-existent-branch",
-				},
-			}
-			exists, err := reconciler.hasSamePipelineRun(jobRun, multiBranchPipeline)
+			exists, err := reconciler.hasSamePipelineRun("123", "non-existent-branch", multiBranchPipeline)
 			Expect(err).To(BeNil())
 			Expect(exists).To(BeFalse())
 		})
@@ -259,13 +237,7 @@ var _ = Describe("TestReconciler_hasSamePipelineRun", func() {
 			reconciler := &Reconciler{
 				Client: client,
 			}
-			jobRun := &job.PipelineRun{
-				BlueItemRun: job.BlueItemRun{
-					ID:       "123",
-					Pipeline: "general-pipeline",
-				},
-			}
-			exists, err := reconciler.hasSamePipelineRun(jobRun, genernalPipeline)
+			exists, err := reconciler.hasSamePipelineRun("123", "", genernalPipeline)
 			Expect(err).To(Succeed())
 			Expect(exists).To(BeTrue())
 		})
@@ -274,100 +246,13 @@ var _ = Describe("TestReconciler_hasSamePipelineRun", func() {
 			reconciler := &Reconciler{
 				Client: client,
 			}
-			jobRun := &job.PipelineRun{
-				BlueItemRun: job.BlueItemRun{
-					ID:       "non-existent-id",
-					Pipeline: "general-pipeline",
-				},
-			}
-			exists, err := reconciler.hasSamePipelineRun(jobRun, genernalPipeline)
+			exists, err := reconciler.hasSamePipelineRun("non-existent-id", "", genernalPipeline)
 			Expect(err).To(Succeed())
 			Expect(exists).To(BeFalse())
 		})
 	})
 })
 
-func TestReconciler_getOrCreateJenkinsCoreIfHasCreator(t *testing.T) {
-	defaultJenkinsCore := &core.JenkinsCore{
-		URL:      "https://devops.com",
-		UserName: "admin",
-		Token:    "fake-token",
-	}
-	tokenIssuer := token.NewTokenIssuer("test-secret", 0)
-	accessToken, err := tokenIssuer.IssueTo(&user.DefaultInfo{Name: "tester"}, token.AccessToken, tokenExpireIn)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	type fields struct {
-		JenkinsCore core.JenkinsCore
-		TokenIssuer token.Issuer
-	}
-	type args struct {
-		annotations map[string]string
-	}
-	tests := []struct {
-		name      string
-		fields    fields
-		args      args
-		want      *core.JenkinsCore
-		assertion func(*core.JenkinsCore)
-		wantErr   bool
-	}{{
-		name: "Empty annotations",
-		fields: fields{
-			JenkinsCore: *defaultJenkinsCore,
-			TokenIssuer: tokenIssuer,
-		},
-		want: defaultJenkinsCore,
-	}, {
-		name: "Has creator in annotations",
-		fields: fields{
-			JenkinsCore: *defaultJenkinsCore,
-			TokenIssuer: tokenIssuer,
-		},
-		args: args{
-			annotations: map[string]string{
-				v1alpha3.PipelineRunCreatorAnnoKey: "tester",
-			},
-		},
-		want: &core.JenkinsCore{
-			URL:      defaultJenkinsCore.URL,
-			UserName: "tester",
-			Token:    accessToken,
-		},
-		assertion: func(jenkinsCore *core.JenkinsCore) {
-			assert.Equal(t, "tester", jenkinsCore.UserName)
-			assert.Equal(t, defaultJenkinsCore.URL, jenkinsCore.URL)
-			userInfo, tokenType, err := tokenIssuer.Verify(jenkinsCore.Token)
-			assert.Equal(t, &user.DefaultInfo{Name: "tester"}, userInfo)
-			assert.Equal(t, token.AccessToken, tokenType)
-			assert.Nil(t, err)
-		},
-	},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			r := &Reconciler{
-				JenkinsCore: tt.fields.JenkinsCore,
-				TokenIssuer: tt.fields.TokenIssuer,
-			}
-			got, err := r.getOrCreateJenkinsCore(tt.args.annotations)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("Reconciler.getOrCreateJenkinsCoreIfHasCreator() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if tt.assertion != nil {
-				tt.assertion(got)
-				return
-			}
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("Reconciler.getOrCreateJenkinsCoreIfHasCreator() = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
-
 func TestPipelineRunReconciler_SetupWithManager(t *testing.T) {
 	schema, err := v1alpha3.SchemeBuilder.Register().Build()
 	assert.Nil(t, err)
@@ -480,6 +365,131 @@ func TestPipelineRunReconcile(t *testing.T) {
 	}
 }
 
+func TestPipelineRunReconcileInlinePipelineSpec(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	pipelineRun := &v1alpha3.PipelineRun{}
+	pipelineRun.SetName("name")
+	pipelineRun.SetNamespace("ns")
+	pipelineRun.Spec.PipelineSpec = &v1alpha3.PipelineSpec{
+		Type: v1alpha3.NoScmPipelineType,
+		Pipeline: &v1alpha3.NoScmPipeline{
+			Name:        "one-off",
+			Jenkinsfile: "pipeline {}",
+		},
+	}
+
+	k8sClient := fake.NewClientBuilder().WithScheme(schema).WithObjects(pipelineRun).Build()
+	recorder := record.NewFakeRecorder(1)
+	r := &Reconciler{
+		Client:   k8sClient,
+		log:      logr.New(log.NullLogSink{}),
+		recorder: recorder,
+	}
+	_, err = r.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Namespace: "ns", Name: "name"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(recorder.Events))
+
+	result := &v1alpha3.PipelineRun{}
+	assert.Nil(t, k8sClient.Get(context.Background(), types.NamespacedName{Namespace: "ns", Name: "name"}, result))
+	assert.Equal(t, v1alpha3.Failed, result.Status.Phase)
+	assert.True(t, result.HasCompleted())
+	assert.False(t, result.Buildable())
+	if assert.NotNil(t, result.Status.GetLatestCondition()) {
+		assert.Equal(t, v1alpha3.InlinePipelineSpecUnsupported, result.Status.GetLatestCondition().Reason)
+	}
+}
+
+func TestPipelineRunReconcileWithOtherBackend(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+	assert.Nil(t, v1.AddToScheme(schema))
+
+	ns := &v1.Namespace{}
+	ns.SetName("ns")
+	pipelineRun := &v1alpha3.PipelineRun{}
+	pipelineRun.SetName("name")
+	pipelineRun.SetNamespace("ns")
+	pipelineRun.Spec.PipelineRef = &v1.ObjectReference{Name: "pipeline"}
+
+	k8sClient := fake.NewClientBuilder().WithScheme(schema).WithObjects(ns, pipelineRun).Build()
+	r := &Reconciler{
+		Client:        k8sClient,
+		log:           logr.New(log.NullLogSink{}),
+		BackendRouter: backend.NewRouter(k8sClient, backend.Tekton),
+	}
+	_, err = r.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Namespace: "ns", Name: "name"},
+	})
+	assert.Nil(t, err)
+
+	result := &v1alpha3.PipelineRun{}
+	assert.Nil(t, k8sClient.Get(context.Background(), types.NamespacedName{Namespace: "ns", Name: "name"}, result))
+	assert.False(t, result.HasStarted())
+	assert.Nil(t, result.Labels)
+}
+
+func TestPipelineRunReconcileWaitingForVersion(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	pipeline := &v1alpha3.Pipeline{}
+	pipeline.SetName("pipeline")
+	pipeline.SetNamespace("ns")
+	pipeline.Spec.Versioning = &v1alpha3.VersioningPolicy{GitRepository: "app"}
+	pipelineRun := &v1alpha3.PipelineRun{}
+	pipelineRun.SetName("name")
+	pipelineRun.SetNamespace("ns")
+	pipelineRun.Spec.PipelineRef = &v1.ObjectReference{Name: "pipeline"}
+
+	k8sClient := fake.NewClientBuilder().WithScheme(schema).WithObjects(pipeline, pipelineRun).Build()
+	r := &Reconciler{
+		Client: k8sClient,
+		log:    logr.New(log.NullLogSink{}),
+	}
+	result, err := r.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Namespace: "ns", Name: "name"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, ctrl.Result{}, result)
+
+	run := &v1alpha3.PipelineRun{}
+	assert.Nil(t, k8sClient.Get(context.Background(), types.NamespacedName{Namespace: "ns", Name: "name"}, run))
+	assert.False(t, run.HasStarted())
+}
+
+func TestPipelineRunReconcileWaitingForPathFilter(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	pipeline := &v1alpha3.Pipeline{}
+	pipeline.SetName("pipeline")
+	pipeline.SetNamespace("ns")
+	pipeline.Spec.PathFilter = &v1alpha3.PathFilterPolicy{GitRepository: "app", Includes: []string{"service-a/"}}
+	pipelineRun := &v1alpha3.PipelineRun{}
+	pipelineRun.SetName("name")
+	pipelineRun.SetNamespace("ns")
+	pipelineRun.Spec.PipelineRef = &v1.ObjectReference{Name: "pipeline"}
+
+	k8sClient := fake.NewClientBuilder().WithScheme(schema).WithObjects(pipeline, pipelineRun).Build()
+	r := &Reconciler{
+		Client: k8sClient,
+		log:    logr.New(log.NullLogSink{}),
+	}
+	result, err := r.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Namespace: "ns", Name: "name"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, ctrl.Result{}, result)
+
+	run := &v1alpha3.PipelineRun{}
+	assert.Nil(t, k8sClient.Get(context.Background(), types.NamespacedName{Namespace: "ns", Name: "name"}, run))
+	assert.False(t, run.HasStarted())
+}
+
 func TestStorePipelineRunData(t *testing.T) {
 	schema, err := v1alpha3.SchemeBuilder.Register().Build()
 	assert.Nil(t, err)
@@ -27,9 +27,11 @@ import (
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/backend"
 	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/pipelinerun"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
@@ -48,6 +50,8 @@ type SyncReconciler struct {
 	log         logr.Logger
 	recorder    record.EventRecorder
 	JenkinsCore core.JenkinsCore
+	// ControllerOptions tunes the concurrency and the rate limiter of this controller
+	ControllerOptions controller.Options
 }
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
@@ -211,7 +215,9 @@ func (r *SyncReconciler) SetupWithManager(mgr ctrl.Manager) error {
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha3.Pipeline{}).
-		WithEventFilter(predicate.And(predicate.ResourceVersionChangedPredicate{}, requestSyncPredicate())).
+		WithEventFilter(predicate.And(predicate.ResourceVersionChangedPredicate{}, requestSyncPredicate(),
+			backend.NewPredicate(backend.Jenkins))).
+		WithOptions(r.ControllerOptions).
 		Complete(r)
 }
 
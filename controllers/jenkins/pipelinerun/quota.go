@@ -0,0 +1,47 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelinerun
+
+import (
+	"context"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/quota"
+)
+
+// checkQuota holds the PipelineRun if the DevOpsProject has reached its quota, held is false if the
+// PipelineRun is allowed to be triggered.
+func (r *Reconciler) checkQuota(ctx context.Context, pr *v1alpha3.PipelineRun) (held bool, result ctrl.Result, err error) {
+	var projectQuota *v1alpha3.ProjectQuota
+	if projectQuota, err = quota.GetProjectQuota(ctx, r.Client, pr.Namespace); err != nil || projectQuota == nil {
+		return
+	}
+	var usage *v1alpha3.ProjectQuotaUsage
+	if usage, err = quota.GetUsage(ctx, r.Client, pr.Namespace, time.Now()); err != nil {
+		return
+	}
+	cancel, exceeded := quota.CheckPipelineRun(projectQuota, usage)
+	if exceeded == nil {
+		return
+	}
+	held = true
+	result, err = r.hold(ctx, pr, v1alpha3.QuotaExceeded, exceeded.Error(), cancel)
+	return
+}
@@ -0,0 +1,303 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package switchover
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/devops"
+	"kubesphere.io/devops/pkg/client/devops/jenkins"
+	"kubesphere.io/devops/pkg/client/secretstore"
+)
+
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;update
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=devopsprojects;pipelines,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+
+// maxReportedMismatches limits the mismatches in the message of a failed switchover
+const maxReportedMismatches = 10
+
+// Reconciler switches the active Jenkins to the requested instance. It synchronizes all the DevOps projects,
+// pipelines and credentials to the target instance, then makes it active once both instances are in parity.
+// The components pick the active instance when they start.
+type Reconciler struct {
+	client.Client
+	// Namespace is where the switchover ConfigMap is
+	Namespace string
+	// Clients are the clients of the Jenkins instances
+	Clients        map[jenkins.Instance]devops.Interface
+	SecretResolver *secretstore.Resolver
+
+	log      logr.Logger
+	recorder record.EventRecorder
+}
+
+// Reconcile is the entrypoint of this reconciler
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	cm := &v1.ConfigMap{}
+	if err = r.Get(ctx, req.NamespacedName, cm); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	state := jenkins.ParseSwitchoverState(cm)
+	if !state.InProgress() {
+		return
+	}
+	r.log.Info("switch Jenkins", "active", state.Active, "target", state.Target, "phase", state.Phase)
+
+	target := r.Clients[state.Target]
+	if target == nil || state.Target == state.Active {
+		err = r.complete(ctx, cm, state, invalidRequestMessage(state))
+		return
+	}
+
+	state.Phase, state.Message = jenkins.SwitchoverSyncing, ""
+	if err = r.updateState(ctx, cm, state); err != nil {
+		return
+	}
+	if err = r.sync(ctx, target); err != nil {
+		err = r.complete(ctx, cm, state, fmt.Sprintf("failed to synchronize to the %s Jenkins, error: %v", state.Target, err))
+		return
+	}
+
+	state.Phase = jenkins.SwitchoverVerifying
+	if err = r.updateState(ctx, cm, state); err != nil {
+		return
+	}
+	var mismatches []string
+	if mismatches, err = r.verify(ctx, target); err != nil {
+		err = r.complete(ctx, cm, state, fmt.Sprintf("failed to verify the %s Jenkins, error: %v", state.Target, err))
+		return
+	}
+	if len(mismatches) > 0 {
+		message := fmt.Sprintf("the %s Jenkins is not in parity, %d mismatch(es): %s", state.Target, len(mismatches),
+			strings.Join(truncate(mismatches, maxReportedMismatches), ", "))
+		err = r.complete(ctx, cm, state, message)
+		return
+	}
+	err = r.complete(ctx, cm, state, "")
+	return
+}
+
+// invalidRequestMessage returns the reason of an invalid switchover request
+func invalidRequestMessage(state *jenkins.SwitchoverState) string {
+	if state.Target == state.Active {
+		return fmt.Sprintf("the %s Jenkins is active already", state.Target)
+	}
+	return fmt.Sprintf("the %s Jenkins is not configured", state.Target)
+}
+
+// complete finishes the switchover, the target instance becomes active if there's no failure
+func (r *Reconciler) complete(ctx context.Context, cm *v1.ConfigMap, state *jenkins.SwitchoverState, failure string) error {
+	if failure != "" {
+		state.Phase, state.Message = jenkins.SwitchoverFailed, failure
+		r.recorder.Event(cm, v1.EventTypeWarning, string(jenkins.SwitchoverFailed), failure)
+	} else {
+		state.Active, state.Phase = state.Target, jenkins.SwitchoverSucceeded
+		state.Message = fmt.Sprintf("the %s Jenkins is active now, restart ks-apiserver and ks-controller-manager to use it",
+			state.Active)
+		r.recorder.Event(cm, v1.EventTypeNormal, string(jenkins.SwitchoverSucceeded), state.Message)
+	}
+	return r.updateState(ctx, cm, state)
+}
+
+func (r *Reconciler) updateState(ctx context.Context, cm *v1.ConfigMap, state *jenkins.SwitchoverState) error {
+	state.ApplyTo(cm)
+	return r.Update(ctx, cm)
+}
+
+// sync creates or updates the DevOps projects, the pipelines and the credentials in the target Jenkins
+func (r *Reconciler) sync(ctx context.Context, target devops.Interface) error {
+	namespaces, err := r.getProjectNamespaces(ctx)
+	if err != nil {
+		return err
+	}
+	for _, ns := range namespaces {
+		if _, err = target.GetDevOpsProject(ns); err != nil {
+			if _, err = target.CreateDevOpsProject(ns); err != nil {
+				return fmt.Errorf("failed to create DevOps project %s, error: %v", ns, err)
+			}
+		}
+
+		// the credentials go first, the pipelines might refer to them
+		var secrets []v1.Secret
+		if secrets, err = r.getCredentials(ctx, ns); err != nil {
+			return err
+		}
+		for i := range secrets {
+			if err = r.syncCredential(ctx, target, ns, &secrets[i]); err != nil {
+				return fmt.Errorf("failed to synchronize credential %s/%s, error: %v", ns, secrets[i].Name, err)
+			}
+		}
+
+		var pipelines []v1alpha3.Pipeline
+		if pipelines, err = r.getPipelines(ctx, ns); err != nil {
+			return err
+		}
+		for i := range pipelines {
+			pipeline := &pipelines[i]
+			if _, err = target.GetProjectPipelineConfig(ns, pipeline.Name); err == nil {
+				_, err = target.UpdateProjectPipeline(ns, pipeline)
+			} else {
+				_, err = target.CreateProjectPipeline(ns, pipeline)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to synchronize pipeline %s/%s, error: %v", ns, pipeline.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Reconciler) syncCredential(ctx context.Context, target devops.Interface, namespace string, secret *v1.Secret) (err error) {
+	var resolved *v1.Secret
+	if resolved, err = r.SecretResolver.Resolve(ctx, secret); err != nil {
+		return
+	}
+	if _, err = target.GetCredentialInProject(namespace, secret.Name); err == nil {
+		_, err = target.UpdateCredentialInProject(namespace, resolved)
+	} else {
+		_, err = target.CreateCredentialInProject(namespace, resolved)
+	}
+	return
+}
+
+// verify returns the DevOps projects, the pipelines and the credentials which are missing in the target Jenkins
+func (r *Reconciler) verify(ctx context.Context, target devops.Interface) (mismatches []string, err error) {
+	var namespaces []string
+	if namespaces, err = r.getProjectNamespaces(ctx); err != nil {
+		return
+	}
+	for _, ns := range namespaces {
+		if _, projectErr := target.GetDevOpsProject(ns); projectErr != nil {
+			mismatches = append(mismatches, "project "+ns)
+			continue
+		}
+
+		var secrets []v1.Secret
+		if secrets, err = r.getCredentials(ctx, ns); err != nil {
+			return
+		}
+		var credentials []devops.Credential
+		if credentials, err = target.ListCredentialsInProject(ns); err != nil {
+			return
+		}
+		credentialIDs := make(map[string]bool, len(credentials))
+		for _, credential := range credentials {
+			credentialIDs[credential.Id] = true
+		}
+		for _, secret := range secrets {
+			if !credentialIDs[secret.Name] {
+				mismatches = append(mismatches, fmt.Sprintf("credential %s/%s", ns, secret.Name))
+			}
+		}
+
+		var pipelines []v1alpha3.Pipeline
+		if pipelines, err = r.getPipelines(ctx, ns); err != nil {
+			return
+		}
+		for _, pipeline := range pipelines {
+			if _, pipelineErr := target.GetProjectPipelineConfig(ns, pipeline.Name); pipelineErr != nil {
+				mismatches = append(mismatches, fmt.Sprintf("pipeline %s/%s", ns, pipeline.Name))
+			}
+		}
+	}
+	sort.Strings(mismatches)
+	return
+}
+
+// getProjectNamespaces returns the admin namespaces of the DevOps projects which are not being deleted
+func (r *Reconciler) getProjectNamespaces(ctx context.Context) (namespaces []string, err error) {
+	projects := &v1alpha3.DevOpsProjectList{}
+	if err = r.List(ctx, projects); err != nil {
+		return
+	}
+	for _, project := range projects.Items {
+		if project.DeletionTimestamp.IsZero() && project.Status.AdminNamespace != "" {
+			namespaces = append(namespaces, project.Status.AdminNamespace)
+		}
+	}
+	sort.Strings(namespaces)
+	return
+}
+
+func (r *Reconciler) getCredentials(ctx context.Context, namespace string) (credentials []v1.Secret, err error) {
+	secrets := &v1.SecretList{}
+	if err = r.List(ctx, secrets, client.InNamespace(namespace)); err != nil {
+		return
+	}
+	for _, secret := range secrets.Items {
+		if strings.HasPrefix(string(secret.Type), v1alpha3.DevOpsCredentialPrefix) && secret.DeletionTimestamp.IsZero() {
+			credentials = append(credentials, secret)
+		}
+	}
+	return
+}
+
+func (r *Reconciler) getPipelines(ctx context.Context, namespace string) (pipelines []v1alpha3.Pipeline, err error) {
+	pipelineList := &v1alpha3.PipelineList{}
+	if err = r.List(ctx, pipelineList, client.InNamespace(namespace)); err != nil {
+		return
+	}
+	for _, pipeline := range pipelineList.Items {
+		if pipeline.DeletionTimestamp.IsZero() {
+			pipelines = append(pipelines, pipeline)
+		}
+	}
+	return
+}
+
+func truncate(items []string, max int) []string {
+	if len(items) > max {
+		return append(items[:max:max], "...")
+	}
+	return items
+}
+
+// GetName returns the name of this reconciler
+func (r *Reconciler) GetName() string {
+	return "jenkins-switchover"
+}
+
+// GetGroupName returns the group name of this reconciler
+func (r *Reconciler) GetGroupName() string {
+	return "jenkins"
+}
+
+// SetupWithManager setups the reconciler
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.log = ctrl.Log.WithName(r.GetName())
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.GetName()).
+		For(&v1.ConfigMap{}, builder.WithPredicates(predicate.NewPredicateFuncs(func(object client.Object) bool {
+			return object.GetNamespace() == r.Namespace && object.GetName() == jenkins.SwitchoverConfigMapName
+		}))).
+		Complete(r)
+}
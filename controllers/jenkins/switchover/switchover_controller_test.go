@@ -0,0 +1,147 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package switchover
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/devops"
+	fakedevops "kubesphere.io/devops/pkg/client/devops/fake"
+	"kubesphere.io/devops/pkg/client/devops/jenkins"
+)
+
+func newSwitchoverConfigMap(state *jenkins.SwitchoverState) *v1.ConfigMap {
+	cm := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "kubesphere-devops-system", Name: jenkins.SwitchoverConfigMapName}}
+	state.ApplyTo(cm)
+	return cm
+}
+
+func TestReconcile(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1.AddToScheme(schema))
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	project := &v1alpha3.DevOpsProject{
+		ObjectMeta: metav1.ObjectMeta{Name: "project"},
+		Status:     v1alpha3.DevOpsProjectStatus{AdminNamespace: "project-ns"},
+	}
+	pipeline := &v1alpha3.Pipeline{ObjectMeta: metav1.ObjectMeta{Namespace: "project-ns", Name: "build"}}
+	credential := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "project-ns", Name: "git"},
+		Type:       v1alpha3.SecretTypeBasicAuth,
+	}
+	secret := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "project-ns", Name: "not-credential"}}
+	pending := &jenkins.SwitchoverState{Active: jenkins.Primary, Target: jenkins.Standby, Phase: jenkins.SwitchoverPending}
+
+	// the pipelines are lost since the pipelines of the project are not initialized in the fake client
+	lossy := fakedevops.New("project-ns")
+	lossy.Credentials["project-ns"] = map[string]*v1.Secret{}
+
+	tests := []struct {
+		name        string
+		objects     []client.Object
+		standby     devops.Interface
+		wantState   *jenkins.SwitchoverState
+		verify      func(t *testing.T, standby devops.Interface)
+		noConfigMap bool
+	}{{
+		name:        "not found",
+		noConfigMap: true,
+	}, {
+		name:      "not in progress",
+		objects:   []client.Object{newSwitchoverConfigMap(&jenkins.SwitchoverState{Active: jenkins.Standby})},
+		standby:   fakedevops.New(),
+		wantState: &jenkins.SwitchoverState{Active: jenkins.Standby, Target: ""},
+	}, {
+		name:    "standby is not configured",
+		objects: []client.Object{newSwitchoverConfigMap(pending)},
+		wantState: &jenkins.SwitchoverState{Active: jenkins.Primary, Target: jenkins.Standby,
+			Phase: jenkins.SwitchoverFailed, Message: "the standby Jenkins is not configured"},
+	}, {
+		name:    "switched",
+		objects: []client.Object{newSwitchoverConfigMap(pending), project, pipeline, credential, secret},
+		standby: fakedevops.New(),
+		wantState: &jenkins.SwitchoverState{Active: jenkins.Standby, Target: jenkins.Standby,
+			Phase:   jenkins.SwitchoverSucceeded,
+			Message: "the standby Jenkins is active now, restart ks-apiserver and ks-controller-manager to use it"},
+		verify: func(t *testing.T, standby devops.Interface) {
+			_, err := standby.GetDevOpsProject("project-ns")
+			assert.Nil(t, err)
+			_, err = standby.GetProjectPipelineConfig("project-ns", "build")
+			assert.Nil(t, err)
+			credentials, err := standby.ListCredentialsInProject("project-ns")
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(credentials))
+		},
+	}, {
+		name:    "not in parity",
+		objects: []client.Object{newSwitchoverConfigMap(pending), project, pipeline, credential},
+		standby: lossy,
+		wantState: &jenkins.SwitchoverState{Active: jenkins.Primary, Target: jenkins.Standby,
+			Phase:   jenkins.SwitchoverFailed,
+			Message: "the standby Jenkins is not in parity, 1 mismatch(es): pipeline project-ns/build"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Reconciler{
+				Client:    fake.NewClientBuilder().WithScheme(schema).WithObjects(tt.objects...).Build(),
+				Namespace: "kubesphere-devops-system",
+				Clients:   map[jenkins.Instance]devops.Interface{jenkins.Primary: fakedevops.New()},
+				log:       logr.Discard(),
+				recorder:  record.NewFakeRecorder(10),
+			}
+			if tt.standby != nil {
+				r.Clients[jenkins.Standby] = tt.standby
+			}
+
+			key := types.NamespacedName{Namespace: "kubesphere-devops-system", Name: jenkins.SwitchoverConfigMapName}
+			_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+			assert.Nil(t, err)
+
+			cm := &v1.ConfigMap{}
+			err = r.Get(context.TODO(), key, cm)
+			if tt.noConfigMap {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantState, jenkins.ParseSwitchoverState(cm))
+			if tt.verify != nil {
+				tt.verify(t, tt.standby)
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, []string{"a", "b"}, truncate([]string{"a", "b"}, 2))
+	assert.Equal(t, []string{"a", "..."}, truncate([]string{"a", "b"}, 1))
+}
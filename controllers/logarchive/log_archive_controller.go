@@ -0,0 +1,157 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logarchive
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/backend"
+	"kubesphere.io/devops/pkg/client/s3"
+	"kubesphere.io/devops/pkg/utils/secretutil"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// LogArchived is the event reason of archiving the logs successfully
+	LogArchived = "LogArchived"
+	// FailedLogArchive is the event reason of failing to archive the logs
+	FailedLogArchive = "FailedLogArchive"
+)
+
+// Reconciler archives the logs of the completed PipelineRuns into S3
+type Reconciler struct {
+	client.Client
+	S3Client s3.Interface
+	// Backends provides the logs of PipelineRuns, the PipelineRuns of other backends will be skipped
+	Backends map[backend.Type]backend.Interface
+	Router   *backend.Router
+
+	log      logr.Logger
+	recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+
+// Reconcile uploads the logs of a completed PipelineRun, then records the object key into its annotations
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	pr := &v1alpha3.PipelineRun{}
+	if err = r.Get(ctx, req.NamespacedName, pr); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	if !needArchive(pr) {
+		return
+	}
+
+	var backendType backend.Type
+	if backendType, err = r.Router.BackendOfObject(ctx, pr); err != nil {
+		return
+	}
+	logBackend, ok := r.Backends[backendType]
+	if !ok {
+		r.log.V(6).Info(fmt.Sprintf("skip %s due to no log provider for backend %s", req.NamespacedName, backendType))
+		return
+	}
+
+	var data []byte
+	if data, err = logBackend.GetLogs(ctx, pr); err != nil {
+		r.recorder.Eventf(pr, v1.EventTypeWarning, FailedLogArchive, "failed to get the logs, error: %v", err)
+		return
+	}
+
+	// mask the credentials before the logs leave the cluster
+	var masker *secretutil.LogMasker
+	if masker, err = secretutil.NewLogMaskerForNamespace(ctx, r.Client, pr.Namespace); err != nil {
+		return
+	}
+
+	key := GetArchiveKey(pr)
+	if err = r.S3Client.Upload(key, pr.Name+".log", bytes.NewReader(masker.Mask(data))); err != nil {
+		r.recorder.Eventf(pr, v1.EventTypeWarning, FailedLogArchive, "failed to upload the logs, error: %v", err)
+		return
+	}
+
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() (err error) {
+		latest := &v1alpha3.PipelineRun{}
+		if err = r.Get(ctx, req.NamespacedName, latest); err != nil {
+			return
+		}
+		if latest.Annotations == nil {
+			latest.Annotations = map[string]string{}
+		}
+		latest.Annotations[v1alpha3.PipelineRunLogArchiveAnnoKey] = key
+		return r.Update(ctx, latest)
+	})
+	if err == nil {
+		r.recorder.Eventf(pr, v1.EventTypeNormal, LogArchived, "the logs were archived into %s", key)
+	}
+	return
+}
+
+// GetArchiveKey returns the object key of the archived logs, the UID makes sure
+// there's no conflict between the PipelineRuns which have the same name.
+func GetArchiveKey(pr *v1alpha3.PipelineRun) string {
+	return fmt.Sprintf("pipelinerun-logs/%s/%s/%s.log", pr.Namespace, pr.Name, pr.UID)
+}
+
+func needArchive(pr *v1alpha3.PipelineRun) bool {
+	if !pr.HasCompleted() || !pr.DeletionTimestamp.IsZero() {
+		return false
+	}
+	_, archived := pr.Annotations[v1alpha3.PipelineRunLogArchiveAnnoKey]
+	return !archived
+}
+
+var logArchivePredicate = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool {
+		pr, ok := e.Object.(*v1alpha3.PipelineRun)
+		return ok && needArchive(pr)
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		pr, ok := e.ObjectNew.(*v1alpha3.PipelineRun)
+		return ok && needArchive(pr)
+	},
+	DeleteFunc: func(e event.DeleteEvent) bool {
+		return false
+	},
+}
+
+// GetName returns the name of this reconciler
+func (r *Reconciler) GetName() string {
+	return "pipelinerun-log-archive-controller"
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	r.log = ctrl.Log.WithName(r.GetName())
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha3.PipelineRun{}).
+		WithEventFilter(logArchivePredicate).
+		Complete(r)
+}
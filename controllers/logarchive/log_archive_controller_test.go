@@ -0,0 +1,145 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logarchive
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/backend"
+	fakes3 "kubesphere.io/devops/pkg/client/s3/fake"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+type fakeBackend struct {
+	backend.Interface
+	logs []byte
+	err  error
+}
+
+func (b *fakeBackend) GetLogs(ctx context.Context, pipelineRun *v1alpha3.PipelineRun) ([]byte, error) {
+	return b.logs, b.err
+}
+
+func newPipelineRun(phase v1alpha3.RunPhase, annotations map[string]string, labels map[string]string) *v1alpha3.PipelineRun {
+	pr := &v1alpha3.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "ns",
+			Name:        "run",
+			UID:         "uid",
+			Annotations: annotations,
+			Labels:      labels,
+		},
+		Status: v1alpha3.PipelineRunStatus{Phase: phase},
+	}
+	if phase == v1alpha3.Succeeded || phase == v1alpha3.Failed {
+		now := metav1.Now()
+		pr.Status.CompletionTime = &now
+	}
+	return pr
+}
+
+func TestReconciler(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+	assert.Nil(t, v1.AddToScheme(schema))
+	credential := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "token"},
+		Type:       v1alpha3.SecretTypeBasicAuth,
+		Data: map[string][]byte{
+			v1alpha3.BasicAuthUsernameKey: []byte("admin"),
+			v1alpha3.BasicAuthPasswordKey: []byte("ghp_token"),
+		},
+	}
+
+	tests := []struct {
+		name        string
+		pipelineRun *v1alpha3.PipelineRun
+		backend     *fakeBackend
+		wantErr     bool
+		wantKey     string
+		wantLogs    string
+	}{{
+		name:        "not completed",
+		pipelineRun: newPipelineRun(v1alpha3.Running, nil, nil),
+		backend:     &fakeBackend{logs: []byte("logs")},
+	}, {
+		name:        "archived already",
+		pipelineRun: newPipelineRun(v1alpha3.Succeeded, map[string]string{v1alpha3.PipelineRunLogArchiveAnnoKey: "key"}, nil),
+		backend:     &fakeBackend{logs: []byte("logs")},
+		wantKey:     "key",
+	}, {
+		name: "no log provider for the backend",
+		pipelineRun: newPipelineRun(v1alpha3.Succeeded, nil,
+			map[string]string{v1alpha3.PipelineBackendLabelKey: string(backend.Tekton)}),
+		backend: &fakeBackend{logs: []byte("logs")},
+	}, {
+		name:        "failed to get logs",
+		pipelineRun: newPipelineRun(v1alpha3.Failed, nil, nil),
+		backend:     &fakeBackend{err: errors.New("fake")},
+		wantErr:     true,
+	}, {
+		name:        "archive the logs",
+		pipelineRun: newPipelineRun(v1alpha3.Failed, nil, nil),
+		backend:     &fakeBackend{logs: []byte("logs")},
+		wantKey:     "pipelinerun-logs/ns/run/uid.log",
+		wantLogs:    "logs",
+	}, {
+		name:        "mask the credentials",
+		pipelineRun: newPipelineRun(v1alpha3.Succeeded, nil, nil),
+		backend:     &fakeBackend{logs: []byte("login admin:ghp_token")},
+		wantKey:     "pipelinerun-logs/ns/run/uid.log",
+		wantLogs:    "login admin:********",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s3Client := fakes3.NewFakeS3()
+			r := &Reconciler{
+				Client:   fake.NewClientBuilder().WithScheme(schema).WithObjects(tt.pipelineRun, credential).Build(),
+				S3Client: s3Client,
+				Backends: map[backend.Type]backend.Interface{backend.Jenkins: tt.backend},
+				log:      logr.New(log.NullLogSink{}),
+				recorder: record.NewFakeRecorder(10),
+			}
+			key := types.NamespacedName{Namespace: "ns", Name: "run"}
+			_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+			assert.Equal(t, tt.wantErr, err != nil)
+
+			pr := &v1alpha3.PipelineRun{}
+			assert.Nil(t, r.Get(context.Background(), key, pr))
+			assert.Equal(t, tt.wantKey, pr.Annotations[v1alpha3.PipelineRunLogArchiveAnnoKey])
+			if tt.wantLogs != "" {
+				data, err := ioutil.ReadAll(s3Client.Storage[tt.wantKey].Body)
+				assert.Nil(t, err)
+				assert.Equal(t, tt.wantLogs, string(data))
+			} else {
+				assert.Empty(t, s3Client.Storage)
+			}
+		})
+	}
+}
@@ -0,0 +1,327 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multicluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/multicluster"
+	"kubesphere.io/devops/pkg/utils/k8sutil"
+	"kubesphere.io/devops/pkg/utils/sliceutil"
+)
+
+const (
+	// DispatcherFinalizerName makes sure the PipelineRuns on the member clusters are deleted along with the host ones
+	DispatcherFinalizerName = "dispatcher.pipelinerun.finalizers.kubesphere.io"
+	// DefaultSyncPeriod is the default period of aggregating the status of the dispatched PipelineRuns
+	DefaultSyncPeriod = 15 * time.Second
+
+	// defaultHostCluster is recorded on the dispatched PipelineRuns when the name of the host cluster is unknown
+	defaultHostCluster = "host"
+)
+
+// aggregatedAnnotations are copied from the dispatched PipelineRuns, the console renders the stages by them
+var aggregatedAnnotations = []string{
+	v1alpha3.JenkinsPipelineRunIDAnnoKey,
+	v1alpha3.JenkinsPipelineRunStatusAnnoKey,
+	v1alpha3.JenkinsPipelineRunStagesStatusAnnoKey,
+}
+
+//+kubebuilder:rbac:groups=cluster.kubesphere.io,resources=clusters,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=devopsprojects,verbs=get;list;watch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns/status,verbs=get;update;patch
+
+// PipelineRunDispatcher runs the PipelineRuns on the member clusters of their ClusterTargets.
+// It creates a copy of the PipelineRun, as well as its Pipeline, on the member cluster,
+// then aggregates the status of the copy back into the PipelineRun until it completes.
+type PipelineRunDispatcher struct {
+	client.Client
+	// Clusters provides the clients of the member clusters
+	Clusters multicluster.ClientGetter
+	// HostCluster is the name of the current cluster, it's recorded on the dispatched PipelineRuns
+	HostCluster string
+	// SyncPeriod is the period of aggregating the status from the member clusters
+	SyncPeriod time.Duration
+
+	log      logr.Logger
+	recorder record.EventRecorder
+}
+
+// Reconcile dispatches the PipelineRun to its member cluster and aggregates the status
+func (r *PipelineRunDispatcher) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	pipelineRun := &v1alpha3.PipelineRun{}
+	if err = r.Get(ctx, req.NamespacedName, pipelineRun); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	if !pipelineRun.Spec.IsRemote() {
+		return
+	}
+	cluster := pipelineRun.Spec.ClusterTarget.Name
+
+	if !pipelineRun.DeletionTimestamp.IsZero() {
+		err = r.cleanup(ctx, pipelineRun, cluster)
+		return
+	}
+	if k8sutil.AddFinalizer(&pipelineRun.ObjectMeta, DispatcherFinalizerName) {
+		if err = r.Update(ctx, pipelineRun); err != nil {
+			return
+		}
+	}
+	if pipelineRun.HasCompleted() {
+		return
+	}
+	// check again in case the placement of the DevOpsProject has changed since the PipelineRun was created
+	if err = multicluster.CheckPlacement(ctx, r.Client, pipelineRun.Namespace, cluster); err != nil {
+		if errors.Is(err, multicluster.ErrNotPlaced) {
+			return r.dispatchFailed(ctx, pipelineRun, "ClusterNotPlaced", err)
+		}
+		return
+	}
+
+	var memberClient client.Client
+	if memberClient, err = r.Clusters.GetClient(ctx, cluster); err != nil {
+		return r.dispatchFailed(ctx, pipelineRun, "ClusterUnavailable", err)
+	}
+
+	remote := &v1alpha3.PipelineRun{}
+	err = memberClient.Get(ctx, client.ObjectKeyFromObject(pipelineRun), remote)
+	switch {
+	case apierrors.IsNotFound(err):
+		if remote, err = r.dispatch(ctx, memberClient, pipelineRun); err != nil {
+			return r.dispatchFailed(ctx, pipelineRun, "DispatchFailed", err)
+		}
+		r.recorder.Eventf(pipelineRun, v1.EventTypeNormal, "Dispatched", "Dispatched to cluster %s", cluster)
+	case err != nil:
+		return r.dispatchFailed(ctx, pipelineRun, "ClusterUnavailable", err)
+	case !reflect.DeepEqual(remote.Spec.Action, pipelineRun.Spec.Action):
+		// pass through the actions, such as stopping the PipelineRun
+		remote.Spec.Action = pipelineRun.Spec.Action
+		if err = memberClient.Update(ctx, remote); err != nil {
+			return
+		}
+	}
+
+	if err = r.aggregate(ctx, pipelineRun, remote, cluster); err != nil {
+		return
+	}
+	if !pipelineRun.HasCompleted() {
+		result.RequeueAfter = r.getSyncPeriod()
+	}
+	return
+}
+
+// dispatch creates the PipelineRun and its Pipeline on the member cluster
+func (r *PipelineRunDispatcher) dispatch(ctx context.Context, memberClient client.Client,
+	pipelineRun *v1alpha3.PipelineRun) (*v1alpha3.PipelineRun, error) {
+	if ref := pipelineRun.Spec.PipelineRef; ref != nil && ref.Name != "" {
+		if err := r.syncPipeline(ctx, memberClient, pipelineRun.Namespace, ref.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	remote := &v1alpha3.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   pipelineRun.Namespace,
+			Name:        pipelineRun.Name,
+			Labels:      copyMap(pipelineRun.Labels),
+			Annotations: copyMap(pipelineRun.Annotations),
+		},
+		Spec: *pipelineRun.Spec.DeepCopy(),
+	}
+	remote.Spec.ClusterTarget = nil
+	hostCluster := r.HostCluster
+	if hostCluster == "" {
+		hostCluster = defaultHostCluster
+	}
+	remote.Labels[v1alpha3.PipelineRunDispatchedLabelKey] = hostCluster
+	return remote, memberClient.Create(ctx, remote)
+}
+
+// syncPipeline makes sure the member cluster runs the same Pipeline as the host cluster
+func (r *PipelineRunDispatcher) syncPipeline(ctx context.Context, memberClient client.Client, namespace, name string) (err error) {
+	pipeline := &v1alpha3.Pipeline{}
+	if err = r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, pipeline); err != nil {
+		return
+	}
+
+	remote := &v1alpha3.Pipeline{}
+	if err = memberClient.Get(ctx, client.ObjectKeyFromObject(pipeline), remote); apierrors.IsNotFound(err) {
+		remote = &v1alpha3.Pipeline{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: pipeline.Namespace,
+				Name:      pipeline.Name,
+				Labels:    copyMap(pipeline.Labels),
+			},
+			Spec: *pipeline.Spec.DeepCopy(),
+		}
+		return memberClient.Create(ctx, remote)
+	} else if err != nil {
+		return
+	}
+
+	if !reflect.DeepEqual(remote.Spec, pipeline.Spec) {
+		remote.Spec = *pipeline.Spec.DeepCopy()
+		err = memberClient.Update(ctx, remote)
+	}
+	return
+}
+
+// aggregate copies the status of the dispatched PipelineRun back
+func (r *PipelineRunDispatcher) aggregate(ctx context.Context, pipelineRun, remote *v1alpha3.PipelineRun, cluster string) error {
+	var annotationsChanged bool
+	for _, key := range aggregatedAnnotations {
+		if value, ok := remote.Annotations[key]; ok && pipelineRun.Annotations[key] != value {
+			if pipelineRun.Annotations == nil {
+				pipelineRun.Annotations = map[string]string{}
+			}
+			pipelineRun.Annotations[key] = value
+			annotationsChanged = true
+		}
+	}
+	if annotationsChanged {
+		if err := r.Update(ctx, pipelineRun); err != nil {
+			return err
+		}
+	}
+
+	status := remote.Status.DeepCopy()
+	status.Conditions = append(status.Conditions, dispatchedCondition(pipelineRun, v1alpha3.ConditionTrue,
+		"Dispatched", fmt.Sprintf("running on cluster %s", cluster)))
+	if reflect.DeepEqual(pipelineRun.Status, *status) {
+		return nil
+	}
+	pipelineRun.Status = *status
+	return r.Status().Update(ctx, pipelineRun)
+}
+
+// dispatchFailed records the failure in the condition, it's retried in the next sync period
+func (r *PipelineRunDispatcher) dispatchFailed(ctx context.Context, pipelineRun *v1alpha3.PipelineRun,
+	reason string, cause error) (result ctrl.Result, err error) {
+	r.log.Error(cause, "failed to dispatch the PipelineRun", "PipelineRun", client.ObjectKeyFromObject(pipelineRun),
+		"cluster", pipelineRun.Spec.ClusterTarget.Name)
+	result.RequeueAfter = r.getSyncPeriod()
+
+	condition := dispatchedCondition(pipelineRun, v1alpha3.ConditionFalse, reason, cause.Error())
+	if existing := pipelineRun.Status.GetCondition(v1alpha3.ConditionDispatched); existing != nil && *existing == condition {
+		return
+	}
+	r.recorder.Event(pipelineRun, v1.EventTypeWarning, reason, cause.Error())
+	pipelineRun.Status.AddCondition(&condition)
+	if pipelineRun.Status.Phase == "" {
+		pipelineRun.Status.Phase = v1alpha3.Pending
+	}
+	err = r.Status().Update(ctx, pipelineRun)
+	return
+}
+
+// cleanup deletes the dispatched PipelineRun, it gives up if the member cluster does not exist anymore
+func (r *PipelineRunDispatcher) cleanup(ctx context.Context, pipelineRun *v1alpha3.PipelineRun, cluster string) error {
+	if !sliceutil.HasString(pipelineRun.Finalizers, DispatcherFinalizerName) {
+		return nil
+	}
+
+	memberClient, err := r.Clusters.GetClient(ctx, cluster)
+	if err == nil {
+		err = client.IgnoreNotFound(memberClient.Delete(ctx, &v1alpha3.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{Namespace: pipelineRun.Namespace, Name: pipelineRun.Name},
+		}))
+	} else if apierrors.IsNotFound(err) || errors.Is(err, multicluster.ErrHostCluster) {
+		r.log.Info("skip deleting the dispatched PipelineRun", "PipelineRun", client.ObjectKeyFromObject(pipelineRun),
+			"cluster", cluster, "reason", err.Error())
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+
+	k8sutil.RemoveFinalizer(&pipelineRun.ObjectMeta, DispatcherFinalizerName)
+	return r.Update(ctx, pipelineRun)
+}
+
+func (r *PipelineRunDispatcher) getSyncPeriod() time.Duration {
+	if r.SyncPeriod <= 0 {
+		return DefaultSyncPeriod
+	}
+	return r.SyncPeriod
+}
+
+// dispatchedCondition keeps the transition time of the existing condition if the status is not changed
+func dispatchedCondition(pipelineRun *v1alpha3.PipelineRun, status v1alpha3.ConditionStatus, reason, message string) v1alpha3.Condition {
+	condition := v1alpha3.Condition{
+		Type:    v1alpha3.ConditionDispatched,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}
+	if existing := pipelineRun.Status.GetCondition(v1alpha3.ConditionDispatched); existing != nil && existing.Status == status {
+		condition.LastProbeTime = existing.LastProbeTime
+		condition.LastTransitionTime = existing.LastTransitionTime
+	} else {
+		condition.LastProbeTime = metav1.Now()
+		condition.LastTransitionTime = condition.LastProbeTime
+	}
+	return condition
+}
+
+func copyMap(m map[string]string) map[string]string {
+	result := make(map[string]string, len(m))
+	for key, value := range m {
+		result[key] = value
+	}
+	return result
+}
+
+// GetName returns the name of this controller
+func (r *PipelineRunDispatcher) GetName() string {
+	return "pipelinerun-dispatcher"
+}
+
+// GetGroupName returns the group name of this controller
+func (r *PipelineRunDispatcher) GetGroupName() string {
+	return "multicluster"
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PipelineRunDispatcher) SetupWithManager(mgr ctrl.Manager) error {
+	r.log = ctrl.Log.WithName(r.GetName())
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.GetName()).
+		For(&v1alpha3.PipelineRun{}).
+		WithEventFilter(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+			pipelineRun, ok := obj.(*v1alpha3.PipelineRun)
+			return ok && pipelineRun.Spec.IsRemote()
+		})).
+		Complete(r)
+}
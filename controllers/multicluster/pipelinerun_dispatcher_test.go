@@ -0,0 +1,245 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multicluster
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/multicluster"
+	"kubesphere.io/devops/pkg/constants"
+)
+
+type fakeClusters map[string]client.Client
+
+func (c fakeClusters) GetClient(_ context.Context, cluster string) (client.Client, error) {
+	if memberClient, ok := c[cluster]; ok {
+		return memberClient, nil
+	}
+	if cluster == "host" {
+		return nil, fmt.Errorf("cluster host is the host cluster: %w", multicluster.ErrHostCluster)
+	}
+	return nil, fmt.Errorf("cluster %s is unreachable", cluster)
+}
+
+func TestPipelineRunDispatcher(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+	assert.Nil(t, v1.AddToScheme(schema))
+
+	namespace := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "ns",
+		Labels: map[string]string{constants.DevOpsProjectLabelKey: "project"},
+	}}
+	project := &v1alpha3.DevOpsProject{
+		ObjectMeta: metav1.ObjectMeta{Name: "project"},
+		Spec: v1alpha3.DevOpsProjectSpec{
+			Placement: &v1alpha3.ProjectPlacement{Clusters: []string{"member", "unknown", "host"}},
+		},
+	}
+	pipeline := &v1alpha3.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "build"},
+		Spec:       v1alpha3.PipelineSpec{Type: v1alpha3.NoScmPipelineType, Pipeline: &v1alpha3.NoScmPipeline{Jenkinsfile: "pipeline {}"}},
+	}
+	newPipelineRun := func(cluster string, finalizers ...string) *v1alpha3.PipelineRun {
+		pipelineRun := &v1alpha3.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:  "ns",
+				Name:       "build-1",
+				Labels:     map[string]string{v1alpha3.PipelineNameLabelKey: "build"},
+				Finalizers: finalizers,
+			},
+			Spec: v1alpha3.PipelineRunSpec{PipelineRef: &v1.ObjectReference{Name: "build"}},
+		}
+		if cluster != "" {
+			pipelineRun.Spec.ClusterTarget = &v1alpha3.ClusterTarget{Name: cluster}
+		}
+		return pipelineRun
+	}
+	key := client.ObjectKey{Namespace: "ns", Name: "build-1"}
+	stop := v1alpha3.Stop
+	now := metav1.Now()
+
+	tests := []struct {
+		name          string
+		pipelineRun   *v1alpha3.PipelineRun
+		memberObjects []client.Object
+		expectRequeue bool
+		verify        func(t *testing.T, host, member client.Client)
+	}{{
+		name:        "run on the current cluster",
+		pipelineRun: newPipelineRun(""),
+		verify: func(t *testing.T, host, member client.Client) {
+			pipelineRun := &v1alpha3.PipelineRun{}
+			assert.Nil(t, host.Get(context.TODO(), key, pipelineRun))
+			assert.Empty(t, pipelineRun.Finalizers)
+			assert.NotNil(t, member.Get(context.TODO(), key, &v1alpha3.PipelineRun{}))
+		},
+	}, {
+		name:          "unavailable cluster",
+		pipelineRun:   newPipelineRun("unknown"),
+		expectRequeue: true,
+		verify: func(t *testing.T, host, member client.Client) {
+			pipelineRun := &v1alpha3.PipelineRun{}
+			assert.Nil(t, host.Get(context.TODO(), key, pipelineRun))
+			condition := pipelineRun.Status.GetCondition(v1alpha3.ConditionDispatched)
+			if assert.NotNil(t, condition) {
+				assert.Equal(t, v1alpha3.ConditionFalse, condition.Status)
+				assert.Equal(t, "ClusterUnavailable", condition.Reason)
+			}
+			assert.Equal(t, v1alpha3.Pending, pipelineRun.Status.Phase)
+		},
+	}, {
+		name:          "cluster out of the placement",
+		pipelineRun:   newPipelineRun("other"),
+		expectRequeue: true,
+		verify: func(t *testing.T, host, member client.Client) {
+			pipelineRun := &v1alpha3.PipelineRun{}
+			assert.Nil(t, host.Get(context.TODO(), key, pipelineRun))
+			condition := pipelineRun.Status.GetCondition(v1alpha3.ConditionDispatched)
+			if assert.NotNil(t, condition) {
+				assert.Equal(t, v1alpha3.ConditionFalse, condition.Status)
+				assert.Equal(t, "ClusterNotPlaced", condition.Reason)
+			}
+			assert.NotNil(t, member.Get(context.TODO(), key, &v1alpha3.PipelineRun{}))
+		},
+	}, {
+		name:          "dispatch to the member cluster",
+		pipelineRun:   newPipelineRun("member"),
+		expectRequeue: true,
+		verify: func(t *testing.T, host, member client.Client) {
+			remotePipeline := &v1alpha3.Pipeline{}
+			assert.Nil(t, member.Get(context.TODO(), client.ObjectKeyFromObject(pipeline), remotePipeline))
+			assert.Equal(t, pipeline.Spec, remotePipeline.Spec)
+
+			remote := &v1alpha3.PipelineRun{}
+			assert.Nil(t, member.Get(context.TODO(), key, remote))
+			assert.Nil(t, remote.Spec.ClusterTarget)
+			assert.Equal(t, "build", remote.Spec.PipelineRef.Name)
+			assert.Equal(t, "host-cluster", remote.Labels[v1alpha3.PipelineRunDispatchedLabelKey])
+
+			pipelineRun := &v1alpha3.PipelineRun{}
+			assert.Nil(t, host.Get(context.TODO(), key, pipelineRun))
+			assert.Equal(t, []string{DispatcherFinalizerName}, pipelineRun.Finalizers)
+			condition := pipelineRun.Status.GetCondition(v1alpha3.ConditionDispatched)
+			if assert.NotNil(t, condition) {
+				assert.Equal(t, v1alpha3.ConditionTrue, condition.Status)
+			}
+		},
+	}, {
+		name: "aggregate the status and pass through the action",
+		pipelineRun: func() *v1alpha3.PipelineRun {
+			pipelineRun := newPipelineRun("member", DispatcherFinalizerName)
+			pipelineRun.Spec.Action = &stop
+			return pipelineRun
+		}(),
+		memberObjects: []client.Object{func() client.Object {
+			remote := newPipelineRun("")
+			remote.Annotations = map[string]string{v1alpha3.JenkinsPipelineRunIDAnnoKey: "1"}
+			remote.Status = v1alpha3.PipelineRunStatus{Phase: v1alpha3.Running, StartTime: &now}
+			return remote
+		}()},
+		expectRequeue: true,
+		verify: func(t *testing.T, host, member client.Client) {
+			remote := &v1alpha3.PipelineRun{}
+			assert.Nil(t, member.Get(context.TODO(), key, remote))
+			assert.Equal(t, &stop, remote.Spec.Action)
+
+			pipelineRun := &v1alpha3.PipelineRun{}
+			assert.Nil(t, host.Get(context.TODO(), key, pipelineRun))
+			assert.Equal(t, "1", pipelineRun.Annotations[v1alpha3.JenkinsPipelineRunIDAnnoKey])
+			assert.Equal(t, v1alpha3.Running, pipelineRun.Status.Phase)
+			assert.NotNil(t, pipelineRun.Status.GetCondition(v1alpha3.ConditionDispatched))
+		},
+	}, {
+		name:        "completed on the member cluster",
+		pipelineRun: newPipelineRun("member", DispatcherFinalizerName),
+		memberObjects: []client.Object{func() client.Object {
+			remote := newPipelineRun("")
+			remote.Status = v1alpha3.PipelineRunStatus{Phase: v1alpha3.Succeeded, StartTime: &now, CompletionTime: &now}
+			return remote
+		}()},
+		verify: func(t *testing.T, host, member client.Client) {
+			pipelineRun := &v1alpha3.PipelineRun{}
+			assert.Nil(t, host.Get(context.TODO(), key, pipelineRun))
+			assert.Equal(t, v1alpha3.Succeeded, pipelineRun.Status.Phase)
+			assert.True(t, pipelineRun.HasCompleted())
+		},
+	}, {
+		name: "delete the dispatched PipelineRun",
+		pipelineRun: func() *v1alpha3.PipelineRun {
+			pipelineRun := newPipelineRun("member", DispatcherFinalizerName)
+			pipelineRun.DeletionTimestamp = &now
+			return pipelineRun
+		}(),
+		memberObjects: []client.Object{newPipelineRun("")},
+		verify: func(t *testing.T, host, member client.Client) {
+			assert.NotNil(t, member.Get(context.TODO(), key, &v1alpha3.PipelineRun{}))
+
+			// the PipelineRun is gone once the finalizer is removed
+			assert.NotNil(t, host.Get(context.TODO(), key, &v1alpha3.PipelineRun{}))
+		},
+	}, {
+		name: "delete the PipelineRun of the host cluster",
+		pipelineRun: func() *v1alpha3.PipelineRun {
+			pipelineRun := newPipelineRun("host", DispatcherFinalizerName)
+			pipelineRun.DeletionTimestamp = &now
+			return pipelineRun
+		}(),
+		verify: func(t *testing.T, host, member client.Client) {
+			// the PipelineRun is gone once the finalizer is removed
+			assert.NotNil(t, host.Get(context.TODO(), key, &v1alpha3.PipelineRun{}))
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host := fake.NewClientBuilder().WithScheme(schema).WithObjects(tt.pipelineRun, pipeline.DeepCopy(),
+				namespace.DeepCopy(), project.DeepCopy()).Build()
+			member := fake.NewClientBuilder().WithScheme(schema).WithObjects(tt.memberObjects...).Build()
+			r := &PipelineRunDispatcher{
+				Client:      host,
+				Clusters:    fakeClusters{"member": member},
+				HostCluster: "host-cluster",
+				SyncPeriod:  time.Minute,
+				log:         logr.Discard(),
+				recorder:    record.NewFakeRecorder(10),
+			}
+
+			result, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+			assert.Nil(t, err)
+			if tt.expectRequeue {
+				assert.Equal(t, time.Minute, result.RequeueAfter)
+			} else {
+				assert.Zero(t, result.RequeueAfter)
+			}
+			tt.verify(t, host, member)
+		})
+	}
+}
@@ -0,0 +1,391 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multicluster
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/multicluster"
+	"kubesphere.io/devops/pkg/constants"
+	"kubesphere.io/devops/pkg/utils/k8sutil"
+	"kubesphere.io/devops/pkg/utils/sliceutil"
+)
+
+const (
+	// PropagatorFinalizerName makes sure the replicated projects are deleted from the member clusters
+	PropagatorFinalizerName = "propagator.devopsproject.finalizers.kubesphere.io"
+	// DefaultPropagationPeriod is the default period of checking the consistency of the replicated projects
+	DefaultPropagationPeriod = time.Minute
+)
+
+// localAnnotations are the states of the current cluster, they are not replicated to the member clusters
+var localAnnotations = []string{
+	v1alpha3.DevOpeProjectSyncStatusAnnoKey,
+	v1alpha3.DevOpeProjectSyncTimeAnnoKey,
+	v1alpha3.PipelineSyncStatusAnnoKey,
+	v1alpha3.PipelineSyncTimeAnnoKey,
+	v1alpha3.PipelineSyncMsgAnnoKey,
+	v1alpha3.PipelineJenkinsMetadataAnnoKey,
+	v1alpha3.PipelineJenkinsBranchesAnnoKey,
+	v1alpha3.CredentialSyncStatusAnnoKey,
+	v1alpha3.CredentialSyncTimeAnnoKey,
+	v1alpha3.CredentialSyncMsgAnnoKey,
+	v1alpha3.CredentialExpiryStatusAnnoKey,
+}
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=devopsprojects,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelines,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+
+// ProjectPropagator replicates the DevOpsProjects, as well as their Pipelines and credentials, to the member clusters
+// declared by their placements. The replicated objects are labeled with devops.kubesphere.io/propagated-from,
+// the labeled objects which do not exist in the current cluster are deleted from the member clusters.
+type ProjectPropagator struct {
+	client.Client
+	// Clusters provides the clients of the member clusters
+	Clusters multicluster.ClientGetter
+	// HostCluster is the name of the current cluster, it's recorded on the replicated objects
+	HostCluster string
+	// Period is the period of checking the consistency of the replicated projects
+	Period time.Duration
+
+	log      logr.Logger
+	recorder record.EventRecorder
+}
+
+// Reconcile replicates the DevOpsProject to the member clusters and reports their sync states
+func (r *ProjectPropagator) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	project := &v1alpha3.DevOpsProject{}
+	if err = r.Get(ctx, req.NamespacedName, project); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+
+	placed := project.Spec.GetPlacedClusters()
+	deleting := !project.DeletionTimestamp.IsZero()
+	if deleting {
+		placed = nil
+	}
+	if len(placed) == 0 && len(project.Status.Clusters) == 0 &&
+		!sliceutil.HasString(project.Finalizers, PropagatorFinalizerName) {
+		return
+	}
+
+	original := project.DeepCopy()
+	var statuses []v1alpha3.ClusterSyncStatus
+	for _, status := range project.Status.Clusters {
+		if sliceutil.HasString(placed, status.Cluster) {
+			continue
+		}
+		// the cluster has been removed from the placement
+		if unpropagateErr := r.unpropagate(ctx, project, status.Cluster); unpropagateErr != nil {
+			statuses = append(statuses, r.newStatus(project, status.Cluster, v1alpha3.ClusterSyncFailed,
+				fmt.Sprintf("failed to delete the replicated project: %v", unpropagateErr)))
+		}
+	}
+	for _, cluster := range placed {
+		statuses = append(statuses, r.propagate(ctx, project, cluster))
+	}
+
+	if len(statuses) == 0 {
+		k8sutil.RemoveFinalizer(&project.ObjectMeta, PropagatorFinalizerName)
+	} else if !deleting {
+		k8sutil.AddFinalizer(&project.ObjectMeta, PropagatorFinalizerName)
+	}
+	project.Status.Clusters = statuses
+	if !reflect.DeepEqual(original, project) {
+		if err = r.Update(ctx, project); err != nil {
+			return
+		}
+	}
+
+	if len(statuses) > 0 {
+		result.RequeueAfter = r.getPeriod()
+	}
+	return
+}
+
+// propagate replicates the project and its resources to a member cluster
+func (r *ProjectPropagator) propagate(ctx context.Context, project *v1alpha3.DevOpsProject, cluster string) v1alpha3.ClusterSyncStatus {
+	failed := func(err error) v1alpha3.ClusterSyncStatus {
+		r.log.Error(err, "failed to replicate the DevOpsProject", "project", project.Name, "cluster", cluster)
+		r.recorder.Eventf(project, v1.EventTypeWarning, "PropagationFailed", "cluster %s: %v", cluster, err)
+		return r.newStatus(project, cluster, v1alpha3.ClusterSyncFailed, err.Error())
+	}
+
+	memberClient, err := r.Clusters.GetClient(ctx, cluster)
+	if err != nil {
+		return failed(err)
+	}
+	namespace := project.Status.AdminNamespace
+	if namespace == "" {
+		return failed(fmt.Errorf("the admin namespace of the project is not ready"))
+	}
+
+	remoteProject := &v1alpha3.DevOpsProject{ObjectMeta: metav1.ObjectMeta{Name: project.Name}}
+	if _, err = controllerutil.CreateOrUpdate(ctx, memberClient, remoteProject, func() error {
+		r.copyMeta(&project.ObjectMeta, &remoteProject.ObjectMeta)
+		remoteProject.Spec = *project.Spec.DeepCopy()
+		// avoid replicating it again from the member cluster
+		remoteProject.Spec.Placement = nil
+		return nil
+	}); err != nil {
+		return failed(err)
+	}
+
+	// the same namespace is used in the member cluster, it's adopted by the DevOpsProject there
+	remoteNamespace := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}
+	if _, err = controllerutil.CreateOrUpdate(ctx, memberClient, remoteNamespace, func() error {
+		if remoteNamespace.Labels == nil {
+			remoteNamespace.Labels = map[string]string{}
+		}
+		remoteNamespace.Labels[constants.DevOpsProjectLabelKey] = project.Name
+		remoteNamespace.Labels[v1alpha3.PropagatedFromLabelKey] = r.getHostCluster()
+		return nil
+	}); err != nil {
+		return failed(err)
+	}
+
+	status := r.newStatus(project, cluster, v1alpha3.ClusterSynced, "")
+	if status.Credentials, err = r.syncCredentials(ctx, memberClient, namespace); err != nil {
+		return failed(err)
+	}
+	if status.Pipelines, err = r.syncPipelines(ctx, memberClient, namespace); err != nil {
+		return failed(err)
+	}
+	return status
+}
+
+// syncCredentials replicates the credentials in the namespace, and deletes the replicated ones which do not exist anymore
+func (r *ProjectPropagator) syncCredentials(ctx context.Context, memberClient client.Client, namespace string) (count int32, err error) {
+	secrets := &v1.SecretList{}
+	if err = r.List(ctx, secrets, client.InNamespace(namespace)); err != nil {
+		return
+	}
+	credentialTypes := v1alpha3.GetSupportedCredentialTypes()
+	names := map[string]bool{}
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		if !containsSecretType(credentialTypes, secret.Type) {
+			continue
+		}
+		names[secret.Name] = true
+
+		remote := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: secret.Name}}
+		if _, err = controllerutil.CreateOrUpdate(ctx, memberClient, remote, func() error {
+			r.copyMeta(&secret.ObjectMeta, &remote.ObjectMeta)
+			remote.Type = secret.Type
+			remote.Data = secret.Data
+			return nil
+		}); err != nil {
+			return
+		}
+		count++
+	}
+
+	remotes := &v1.SecretList{}
+	if err = memberClient.List(ctx, remotes, client.InNamespace(namespace), r.propagatedLabels()); err != nil {
+		return
+	}
+	for i := range remotes.Items {
+		if !names[remotes.Items[i].Name] {
+			if err = client.IgnoreNotFound(memberClient.Delete(ctx, &remotes.Items[i])); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// syncPipelines replicates the Pipelines in the namespace, and deletes the replicated ones which do not exist anymore
+func (r *ProjectPropagator) syncPipelines(ctx context.Context, memberClient client.Client, namespace string) (count int32, err error) {
+	pipelines := &v1alpha3.PipelineList{}
+	if err = r.List(ctx, pipelines, client.InNamespace(namespace)); err != nil {
+		return
+	}
+	names := map[string]bool{}
+	for i := range pipelines.Items {
+		pipeline := &pipelines.Items[i]
+		names[pipeline.Name] = true
+
+		remote := &v1alpha3.Pipeline{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: pipeline.Name}}
+		if _, err = controllerutil.CreateOrUpdate(ctx, memberClient, remote, func() error {
+			r.copyMeta(&pipeline.ObjectMeta, &remote.ObjectMeta)
+			remote.Spec = *pipeline.Spec.DeepCopy()
+			return nil
+		}); err != nil {
+			return
+		}
+		count++
+	}
+
+	remotes := &v1alpha3.PipelineList{}
+	if err = memberClient.List(ctx, remotes, client.InNamespace(namespace), r.propagatedLabels()); err != nil {
+		return
+	}
+	for i := range remotes.Items {
+		if !names[remotes.Items[i].Name] {
+			if err = client.IgnoreNotFound(memberClient.Delete(ctx, &remotes.Items[i])); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// unpropagate deletes the replicated project from a member cluster, the replicated resources are deleted along
+// with the admin namespace. It gives up if the member cluster does not exist anymore.
+func (r *ProjectPropagator) unpropagate(ctx context.Context, project *v1alpha3.DevOpsProject, cluster string) error {
+	memberClient, err := r.Clusters.GetClient(ctx, cluster)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			r.log.Info("skip deleting the replicated project", "project", project.Name, "cluster", cluster)
+			return nil
+		}
+		return err
+	}
+
+	remote := &v1alpha3.DevOpsProject{}
+	if err = memberClient.Get(ctx, types.NamespacedName{Name: project.Name}, remote); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if remote.Labels[v1alpha3.PropagatedFromLabelKey] != r.getHostCluster() {
+		// it's not created by the current cluster
+		return nil
+	}
+	if err = client.IgnoreNotFound(memberClient.Delete(ctx, remote)); err == nil {
+		r.recorder.Eventf(project, v1.EventTypeNormal, "Unpropagated", "Deleted the replicated project from cluster %s", cluster)
+	}
+	return err
+}
+
+// copyMeta copies the labels and the annotations except the local states, then labels the replicated object.
+// The labels and the annotations added by the member cluster are kept.
+func (r *ProjectPropagator) copyMeta(from, to *metav1.ObjectMeta) {
+	if to.Labels == nil {
+		to.Labels = map[string]string{}
+	}
+	for key, value := range from.Labels {
+		to.Labels[key] = value
+	}
+	to.Labels[v1alpha3.PropagatedFromLabelKey] = r.getHostCluster()
+
+	for key, value := range from.Annotations {
+		if sliceutil.HasString(localAnnotations, key) {
+			continue
+		}
+		if to.Annotations == nil {
+			to.Annotations = map[string]string{}
+		}
+		to.Annotations[key] = value
+	}
+}
+
+func (r *ProjectPropagator) propagatedLabels() client.MatchingLabels {
+	return client.MatchingLabels{v1alpha3.PropagatedFromLabelKey: r.getHostCluster()}
+}
+
+// newStatus creates the status of a cluster, the transition time is kept if the state is not changed
+func (r *ProjectPropagator) newStatus(project *v1alpha3.DevOpsProject, cluster string,
+	state v1alpha3.ClusterSyncState, message string) v1alpha3.ClusterSyncStatus {
+	status := v1alpha3.ClusterSyncStatus{Cluster: cluster, State: state, Message: message}
+	for _, existing := range project.Status.Clusters {
+		if existing.Cluster == cluster && existing.State == state {
+			status.LastTransitionTime = existing.LastTransitionTime
+		}
+	}
+	if status.LastTransitionTime == nil {
+		now := metav1.Now()
+		status.LastTransitionTime = &now
+	}
+	return status
+}
+
+func (r *ProjectPropagator) getHostCluster() string {
+	if r.HostCluster == "" {
+		return defaultHostCluster
+	}
+	return r.HostCluster
+}
+
+func (r *ProjectPropagator) getPeriod() time.Duration {
+	if r.Period <= 0 {
+		return DefaultPropagationPeriod
+	}
+	return r.Period
+}
+
+func containsSecretType(types []v1.SecretType, secretType v1.SecretType) bool {
+	for _, item := range types {
+		if item == secretType {
+			return true
+		}
+	}
+	return false
+}
+
+// GetName returns the name of this controller
+func (r *ProjectPropagator) GetName() string {
+	return "devopsproject-propagator"
+}
+
+// GetGroupName returns the group name of this controller
+func (r *ProjectPropagator) GetGroupName() string {
+	return "multicluster"
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ProjectPropagator) SetupWithManager(mgr ctrl.Manager) error {
+	r.log = ctrl.Log.WithName(r.GetName())
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.GetName()).
+		For(&v1alpha3.DevOpsProject{}).
+		// replicate the changes of the Pipelines in time, the credentials are replicated periodically
+		Watches(&source.Kind{Type: &v1alpha3.Pipeline{}}, handler.EnqueueRequestsFromMapFunc(r.findProject)).
+		Complete(r)
+}
+
+// findProject finds the DevOpsProject of a Pipeline by the label of its namespace
+func (r *ProjectPropagator) findProject(obj client.Object) []reconcile.Request {
+	ns := &v1.Namespace{}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: obj.GetNamespace()}, ns); err != nil {
+		return nil
+	}
+	if project := ns.Labels[constants.DevOpsProjectLabelKey]; project != "" {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: project}}}
+	}
+	return nil
+}
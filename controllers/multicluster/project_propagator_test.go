@@ -0,0 +1,187 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multicluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/constants"
+)
+
+func TestProjectPropagator(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1.AddToScheme(schema))
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	newProject := func(clusters ...string) *v1alpha3.DevOpsProject {
+		project := &v1alpha3.DevOpsProject{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "demo",
+				Annotations: map[string]string{v1alpha3.DevOpeProjectSyncStatusAnnoKey: "successful"},
+			},
+			Spec:   v1alpha3.DevOpsProjectSpec{PipelineBackend: "Jenkins"},
+			Status: v1alpha3.DevOpsProjectStatus{AdminNamespace: "demo"},
+		}
+		if len(clusters) > 0 {
+			project.Spec.Placement = &v1alpha3.ProjectPlacement{Clusters: clusters}
+		}
+		return project
+	}
+	hostObjects := []client.Object{
+		&v1alpha3.Pipeline{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "build",
+				Annotations: map[string]string{v1alpha3.PipelineSyncStatusAnnoKey: "successful"}},
+			Spec: v1alpha3.PipelineSpec{Type: v1alpha3.NoScmPipelineType},
+		},
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "git"},
+			Type:       v1alpha3.SecretTypeBasicAuth,
+			Data:       map[string][]byte{"username": []byte("admin")},
+		},
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "not-credential"},
+			Type:       v1.SecretTypeOpaque,
+		},
+	}
+	propagated := map[string]string{v1alpha3.PropagatedFromLabelKey: "host-cluster"}
+	projectKey := types.NamespacedName{Name: "demo"}
+
+	tests := []struct {
+		name          string
+		project       *v1alpha3.DevOpsProject
+		memberObjects []client.Object
+		expectRequeue bool
+		verify        func(t *testing.T, host, member client.Client)
+	}{{
+		name:    "without placement",
+		project: newProject(),
+		verify: func(t *testing.T, host, member client.Client) {
+			assert.NotNil(t, member.Get(context.TODO(), projectKey, &v1alpha3.DevOpsProject{}))
+		},
+	}, {
+		name:          "replicate to the member cluster",
+		project:       newProject("member", "unknown"),
+		memberObjects: []client.Object{&v1alpha3.Pipeline{ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "stale", Labels: propagated}}},
+		expectRequeue: true,
+		verify: func(t *testing.T, host, member client.Client) {
+			remoteProject := &v1alpha3.DevOpsProject{}
+			assert.Nil(t, member.Get(context.TODO(), projectKey, remoteProject))
+			assert.Nil(t, remoteProject.Spec.Placement)
+			assert.Equal(t, "Jenkins", remoteProject.Spec.PipelineBackend)
+			assert.Equal(t, "host-cluster", remoteProject.Labels[v1alpha3.PropagatedFromLabelKey])
+			assert.NotContains(t, remoteProject.Annotations, v1alpha3.DevOpeProjectSyncStatusAnnoKey)
+
+			ns := &v1.Namespace{}
+			assert.Nil(t, member.Get(context.TODO(), types.NamespacedName{Name: "demo"}, ns))
+			assert.Equal(t, "demo", ns.Labels[constants.DevOpsProjectLabelKey])
+
+			pipeline := &v1alpha3.Pipeline{}
+			assert.Nil(t, member.Get(context.TODO(), types.NamespacedName{Namespace: "demo", Name: "build"}, pipeline))
+			assert.Equal(t, v1alpha3.NoScmPipelineType, pipeline.Spec.Type)
+			assert.NotContains(t, pipeline.Annotations, v1alpha3.PipelineSyncStatusAnnoKey)
+			assert.NotNil(t, member.Get(context.TODO(), types.NamespacedName{Namespace: "demo", Name: "stale"}, &v1alpha3.Pipeline{}))
+
+			secret := &v1.Secret{}
+			assert.Nil(t, member.Get(context.TODO(), types.NamespacedName{Namespace: "demo", Name: "git"}, secret))
+			assert.Equal(t, []byte("admin"), secret.Data["username"])
+			assert.NotNil(t, member.Get(context.TODO(), types.NamespacedName{Namespace: "demo", Name: "not-credential"}, &v1.Secret{}))
+
+			project := &v1alpha3.DevOpsProject{}
+			assert.Nil(t, host.Get(context.TODO(), projectKey, project))
+			assert.Equal(t, []string{PropagatorFinalizerName}, project.Finalizers)
+			if assert.Equal(t, 2, len(project.Status.Clusters)) {
+				assert.Equal(t, v1alpha3.ClusterSynced, project.Status.Clusters[0].State)
+				assert.Equal(t, int32(1), project.Status.Clusters[0].Pipelines)
+				assert.Equal(t, int32(1), project.Status.Clusters[0].Credentials)
+				assert.Equal(t, v1alpha3.ClusterSyncFailed, project.Status.Clusters[1].State)
+				assert.NotEmpty(t, project.Status.Clusters[1].Message)
+			}
+		},
+	}, {
+		name: "removed from the placement",
+		project: func() *v1alpha3.DevOpsProject {
+			project := newProject()
+			project.Finalizers = []string{PropagatorFinalizerName}
+			project.Status.Clusters = []v1alpha3.ClusterSyncStatus{{Cluster: "member", State: v1alpha3.ClusterSynced}}
+			return project
+		}(),
+		memberObjects: []client.Object{&v1alpha3.DevOpsProject{ObjectMeta: metav1.ObjectMeta{Name: "demo", Labels: propagated}}},
+		verify: func(t *testing.T, host, member client.Client) {
+			assert.NotNil(t, member.Get(context.TODO(), projectKey, &v1alpha3.DevOpsProject{}))
+
+			project := &v1alpha3.DevOpsProject{}
+			assert.Nil(t, host.Get(context.TODO(), projectKey, project))
+			assert.Empty(t, project.Finalizers)
+			assert.Empty(t, project.Status.Clusters)
+		},
+	}, {
+		name: "keep the project not created by the host cluster",
+		project: func() *v1alpha3.DevOpsProject {
+			project := newProject("member")
+			now := metav1.Now()
+			project.DeletionTimestamp = &now
+			project.Finalizers = []string{PropagatorFinalizerName, v1alpha3.DevOpsProjectFinalizerName}
+			project.Status.Clusters = []v1alpha3.ClusterSyncStatus{{Cluster: "member", State: v1alpha3.ClusterSynced}}
+			return project
+		}(),
+		memberObjects: []client.Object{&v1alpha3.DevOpsProject{ObjectMeta: metav1.ObjectMeta{Name: "demo"}}},
+		verify: func(t *testing.T, host, member client.Client) {
+			assert.Nil(t, member.Get(context.TODO(), projectKey, &v1alpha3.DevOpsProject{}))
+
+			project := &v1alpha3.DevOpsProject{}
+			assert.Nil(t, host.Get(context.TODO(), projectKey, project))
+			assert.Equal(t, []string{v1alpha3.DevOpsProjectFinalizerName}, project.Finalizers)
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host := fake.NewClientBuilder().WithScheme(schema).WithObjects(append(hostObjects, tt.project)...).Build()
+			member := fake.NewClientBuilder().WithScheme(schema).WithObjects(tt.memberObjects...).Build()
+			r := &ProjectPropagator{
+				Client:      host,
+				Clusters:    fakeClusters{"member": member},
+				HostCluster: "host-cluster",
+				Period:      time.Minute,
+				log:         logr.Discard(),
+				recorder:    record.NewFakeRecorder(10),
+			}
+
+			result, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: projectKey})
+			assert.Nil(t, err)
+			if tt.expectRequeue {
+				assert.Equal(t, time.Minute, result.RequeueAfter)
+			} else {
+				assert.Zero(t, result.RequeueAfter)
+			}
+			tt.verify(t, host, member)
+		})
+	}
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/notification"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// NotificationSent is the event reason of sending a notification
+	NotificationSent = "NotificationSent"
+	// FailedSendNotification is the event reason of failing to send a notification
+	FailedSendNotification = "FailedSendNotification"
+
+	// maxNotificationDelay avoids notifying the stale PipelineRuns, e.g. the first time the notifier runs
+	maxNotificationDelay = time.Hour
+)
+
+// Reconciler sends the lifecycle events of PipelineRuns to the receivers of the Notifications
+type Reconciler struct {
+	client.Client
+	Sender notification.Sender
+
+	log      logr.Logger
+	recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=notifications,verbs=get;list;watch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns,verbs=get;list;watch;patch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile notifies the current phase of a PipelineRun once
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	pr := &v1alpha3.PipelineRun{}
+	if err = r.Get(ctx, req.NamespacedName, pr); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	if needNotifyStuck(pr) {
+		if err = r.notifyAll(ctx, pr, v1alpha3.NotificationEventStuck); err == nil {
+			err = r.markNotified(ctx, pr, v1alpha3.NotifiedStuckAnnoKey, "true")
+		}
+		return
+	}
+	if !needNotify(pr) {
+		return
+	}
+
+	eventType, _ := v1alpha3.GetNotificationEvent(pr.Status.Phase)
+	if isStale(pr, eventType) {
+		r.log.V(6).Info(fmt.Sprintf("skip the stale %s event of %s", eventType, req.NamespacedName))
+	} else if err = r.notifyAll(ctx, pr, eventType); err != nil {
+		return
+	}
+	err = r.markNotified(ctx, pr, v1alpha3.NotifiedPhaseAnnoKey, string(pr.Status.Phase))
+	return
+}
+
+func (r *Reconciler) notifyAll(ctx context.Context, pr *v1alpha3.PipelineRun, eventType v1alpha3.NotificationEvent) (err error) {
+	notifications := &v1alpha3.NotificationList{}
+	if err = r.List(ctx, notifications, client.InNamespace(pr.Namespace)); err != nil {
+		return
+	}
+	for i := range notifications.Items {
+		r.notify(ctx, &notifications.Items[i], pr, eventType)
+	}
+	return
+}
+
+// markNotified annotates the PipelineRun, the notification is best-effort, the failures are recorded as
+// events instead of retrying
+func (r *Reconciler) markNotified(ctx context.Context, pr *v1alpha3.PipelineRun, key, value string) error {
+	patch := client.MergeFrom(pr.DeepCopy())
+	if pr.Annotations == nil {
+		pr.Annotations = map[string]string{}
+	}
+	pr.Annotations[key] = value
+	return r.Patch(ctx, pr, patch)
+}
+
+func (r *Reconciler) notify(ctx context.Context, notify *v1alpha3.Notification, pr *v1alpha3.PipelineRun,
+	eventType v1alpha3.NotificationEvent) {
+	pipeline := pr.GetPipelineName()
+	if !notify.Matches(pipeline, eventType) {
+		return
+	}
+
+	data := newEvent(pr, pipeline, eventType)
+	var err error
+	if data.Message, err = notification.Render(notify.GetTemplate(pipeline), data); err != nil {
+		r.recorder.Eventf(pr, v1.EventTypeWarning, FailedSendNotification,
+			"failed to render the message of notification %s, error: %v", notify.Name, err)
+		return
+	}
+
+	for _, item := range notify.Spec.Receivers {
+		var receiver *notification.Receiver
+		if receiver, err = r.getReceiver(ctx, notify.Namespace, item); err == nil {
+			err = r.Sender.Send(ctx, *receiver, data)
+		}
+		if err != nil {
+			r.recorder.Eventf(pr, v1.EventTypeWarning, FailedSendNotification,
+				"failed to send the %s notification %s, error: %v", item.Type, notify.Name, err)
+			continue
+		}
+		r.recorder.Eventf(pr, v1.EventTypeNormal, NotificationSent, "sent the %s notification %s", item.Type, notify.Name)
+	}
+}
+
+func (r *Reconciler) getReceiver(ctx context.Context, namespace string, receiver v1alpha3.NotificationReceiver) (
+	result *notification.Receiver, err error) {
+	result = &notification.Receiver{Type: receiver.Type, URL: receiver.URL, To: receiver.To}
+	if receiver.URLFrom != nil {
+		secret := &v1.Secret{}
+		if err = r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: receiver.URLFrom.Name}, secret); err != nil {
+			return
+		}
+		result.URL = string(secret.Data[receiver.URLFrom.Key])
+	}
+	return
+}
+
+func newEvent(pr *v1alpha3.PipelineRun, pipeline string, eventType v1alpha3.NotificationEvent) *notification.Event {
+	data := &notification.Event{
+		Type:        string(eventType),
+		Namespace:   pr.Namespace,
+		Pipeline:    pipeline,
+		PipelineRun: pr.Name,
+		Phase:       string(pr.Status.Phase),
+	}
+	if eventType == v1alpha3.NotificationEventStuck {
+		if condition := pr.Status.GetCondition(v1alpha3.ConditionStuck); condition != nil {
+			data.Reason = condition.Message
+		}
+	}
+	if pr.Status.StartTime != nil {
+		data.StartTime = &pr.Status.StartTime.Time
+	}
+	if pr.Status.CompletionTime != nil {
+		data.CompletionTime = &pr.Status.CompletionTime.Time
+		if data.StartTime != nil {
+			data.Duration = data.CompletionTime.Sub(*data.StartTime).Round(time.Second).String()
+		}
+	}
+	return data
+}
+
+// needNotify returns true if the current phase of the PipelineRun has not been notified
+func needNotify(pr *v1alpha3.PipelineRun) bool {
+	if !pr.DeletionTimestamp.IsZero() {
+		return false
+	}
+	if _, ok := v1alpha3.GetNotificationEvent(pr.Status.Phase); !ok {
+		return false
+	}
+	return pr.Annotations[v1alpha3.NotifiedPhaseAnnoKey] != string(pr.Status.Phase)
+}
+
+// needNotifyStuck returns true if the agent pods of the running PipelineRun are stuck, and it has not been notified
+func needNotifyStuck(pr *v1alpha3.PipelineRun) bool {
+	if !pr.DeletionTimestamp.IsZero() || pr.HasCompleted() {
+		return false
+	}
+	condition := pr.Status.GetCondition(v1alpha3.ConditionStuck)
+	return condition != nil && condition.Status == v1alpha3.ConditionTrue && pr.Annotations[v1alpha3.NotifiedStuckAnnoKey] == ""
+}
+
+// isStale returns true if the event happened long ago
+func isStale(pr *v1alpha3.PipelineRun, eventType v1alpha3.NotificationEvent) bool {
+	eventTime := pr.Status.CompletionTime
+	if eventType == v1alpha3.NotificationEventStarted {
+		eventTime = pr.Status.StartTime
+	}
+	return eventTime != nil && time.Since(eventTime.Time) > maxNotificationDelay
+}
+
+var notifyPredicate = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool {
+		pr, ok := e.Object.(*v1alpha3.PipelineRun)
+		return ok && (needNotify(pr) || needNotifyStuck(pr))
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		pr, ok := e.ObjectNew.(*v1alpha3.PipelineRun)
+		return ok && (needNotify(pr) || needNotifyStuck(pr))
+	},
+	DeleteFunc: func(e event.DeleteEvent) bool {
+		return false
+	},
+}
+
+// GetName returns the name of this reconciler
+func (r *Reconciler) GetName() string {
+	return "pipelinerun-notifier"
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	r.log = ctrl.Log.WithName(r.GetName())
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha3.PipelineRun{}).
+		WithEventFilter(notifyPredicate).
+		Complete(r)
+}
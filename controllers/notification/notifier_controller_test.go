@@ -0,0 +1,199 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/notification"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+type fakeSender struct {
+	sent []string
+}
+
+func (s *fakeSender) Send(ctx context.Context, receiver notification.Receiver, event *notification.Event) error {
+	if receiver.URL == "" {
+		return errors.New("empty url")
+	}
+	s.sent = append(s.sent, receiver.URL+" "+event.Message)
+	return nil
+}
+
+func newPipelineRun(phase v1alpha3.RunPhase, notified string, completionTime time.Time) *v1alpha3.PipelineRun {
+	startTime := metav1.NewTime(completionTime.Add(-time.Minute))
+	pr := &v1alpha3.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns",
+			Name:      "build-1",
+			Labels:    map[string]string{v1alpha3.PipelineNameLabelKey: "build"},
+		},
+		Status: v1alpha3.PipelineRunStatus{Phase: phase, StartTime: &startTime},
+	}
+	if notified != "" {
+		pr.Annotations = map[string]string{v1alpha3.NotifiedPhaseAnnoKey: notified}
+	}
+	if phase != v1alpha3.Running {
+		completion := metav1.NewTime(completionTime)
+		pr.Status.CompletionTime = &completion
+	}
+	return pr
+}
+
+func TestReconciler(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, scheme.AddToScheme(schema))
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	notifications := []runtime.Object{&v1alpha3.Notification{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "all"},
+		Spec: v1alpha3.NotificationSpec{
+			Receivers: []v1alpha3.NotificationReceiver{{
+				Type: v1alpha3.NotificationReceiverSlack,
+				URL:  "http://slack",
+			}, {
+				Type:    v1alpha3.NotificationReceiverDingTalk,
+				URLFrom: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "dingtalk"}, Key: "url"},
+			}, {
+				Type: v1alpha3.NotificationReceiverWebhook,
+			}},
+		},
+	}, &v1alpha3.Notification{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "failed"},
+		Spec: v1alpha3.NotificationSpec{
+			Events:    []v1alpha3.NotificationEvent{v1alpha3.NotificationEventFailed},
+			Template:  "{{.Pipeline}} failed",
+			Receivers: []v1alpha3.NotificationReceiver{{Type: v1alpha3.NotificationReceiverWebhook, URL: "http://webhook"}},
+		},
+	}, &v1alpha3.Notification{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "other"},
+		Spec: v1alpha3.NotificationSpec{
+			Receivers: []v1alpha3.NotificationReceiver{{Type: v1alpha3.NotificationReceiverWebhook, URL: "http://other"}},
+		},
+	}, &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "dingtalk"},
+		Data:       map[string][]byte{"url": []byte("http://dingtalk")},
+	}}
+
+	now := time.Now()
+	stuck := newPipelineRun(v1alpha3.Running, "Running", now)
+	stuck.Status.AddCondition(&v1alpha3.Condition{
+		Type:    v1alpha3.ConditionStuck,
+		Status:  v1alpha3.ConditionTrue,
+		Reason:  "ImagePullBackOff",
+		Message: "container maven of pod agent: ImagePullBackOff",
+	})
+	stuckNotified := stuck.DeepCopy()
+	stuckNotified.Annotations[v1alpha3.NotifiedStuckAnnoKey] = "true"
+
+	tests := []struct {
+		name         string
+		pipelineRun  *v1alpha3.PipelineRun
+		wantSent     []string
+		wantNotified string
+		wantStuck    string
+	}{{
+		name:        "pending",
+		pipelineRun: newPipelineRun(v1alpha3.Pending, "", now),
+	}, {
+		name:        "started",
+		pipelineRun: newPipelineRun(v1alpha3.Running, "", now),
+		wantSent: []string{
+			"http://slack [ns] Pipeline build run build-1 started",
+			"http://dingtalk [ns] Pipeline build run build-1 started",
+		},
+		wantNotified: "Running",
+	}, {
+		name:        "failed",
+		pipelineRun: newPipelineRun(v1alpha3.Failed, "Running", now),
+		wantSent: []string{
+			"http://slack [ns] Pipeline build run build-1 failed in 1m0s",
+			"http://dingtalk [ns] Pipeline build run build-1 failed in 1m0s",
+			"http://webhook build failed",
+		},
+		wantNotified: "Failed",
+	}, {
+		name:         "notified",
+		pipelineRun:  newPipelineRun(v1alpha3.Succeeded, "Succeeded", now),
+		wantNotified: "Succeeded",
+	}, {
+		name:         "stale",
+		pipelineRun:  newPipelineRun(v1alpha3.Succeeded, "", now.Add(-2*time.Hour)),
+		wantNotified: "Succeeded",
+	}, {
+		name:        "stuck",
+		pipelineRun: stuck,
+		wantSent: []string{
+			"http://slack [ns] Pipeline build run build-1 stuck: container maven of pod agent: ImagePullBackOff",
+			"http://dingtalk [ns] Pipeline build run build-1 stuck: container maven of pod agent: ImagePullBackOff",
+		},
+		wantNotified: "Running",
+		wantStuck:    "true",
+	}, {
+		name:         "stuck notified",
+		pipelineRun:  stuckNotified,
+		wantNotified: "Running",
+		wantStuck:    "true",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).
+				WithRuntimeObjects(append(notifications, tt.pipelineRun.DeepCopy())...).Build()
+			sender := &fakeSender{}
+			r := &Reconciler{
+				Client:   c,
+				Sender:   sender,
+				log:      logr.New(log.NullLogSink{}),
+				recorder: record.NewFakeRecorder(10),
+			}
+
+			key := types.NamespacedName{Namespace: "ns", Name: "build-1"}
+			_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+			assert.Nil(t, err)
+			assert.ElementsMatch(t, tt.wantSent, sender.sent)
+
+			pr := &v1alpha3.PipelineRun{}
+			assert.Nil(t, c.Get(context.TODO(), key, pr))
+			assert.Equal(t, tt.wantNotified, pr.Annotations[v1alpha3.NotifiedPhaseAnnoKey])
+			assert.Equal(t, tt.wantStuck, pr.Annotations[v1alpha3.NotifiedStuckAnnoKey])
+		})
+	}
+
+	// not found
+	r := &Reconciler{Client: fake.NewClientBuilder().WithScheme(schema).Build()}
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "ns", Name: "fake"}})
+	assert.Nil(t, err)
+}
+
+func TestReconciler_GetName(t *testing.T) {
+	assert.Equal(t, "pipelinerun-notifier", (&Reconciler{}).GetName())
+}
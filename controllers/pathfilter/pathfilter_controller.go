@@ -0,0 +1,201 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pathfilter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/controllers/gitrepository"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	scmclient "kubesphere.io/devops/pkg/client/scm"
+)
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelines;gitrepositories,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// The reasons of the PathFiltered condition
+const (
+	reasonChangesMatched   = "ChangesMatched"
+	reasonNoChangesMatched = "NoChangesMatched"
+	reasonChangesUnknown   = "ChangesUnknown"
+)
+
+// Reconciler decides whether the PipelineRuns, whose Pipelines have path filters, are necessary by the paths
+// changed in the commit range which triggered them. The PipelineRuns are held by the backends until the decision
+// is made, and the ones which changed none of the matched paths are marked as Skipped.
+type Reconciler struct {
+	client.Client
+
+	// NewProvider creates the SCM provider, scmclient.NewProviderFromSecret will be used if it's nil
+	NewProvider gitrepository.ProviderFactory
+
+	recorder record.EventRecorder
+}
+
+// Reconcile makes the decision of the path filter for a PipelineRun
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	pipelineRun := &v1alpha3.PipelineRun{}
+	if err = r.Get(ctx, req.NamespacedName, pipelineRun); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	if !pipelineRun.DeletionTimestamp.IsZero() || pipelineRun.Spec.PipelineRef == nil ||
+		pipelineRun.HasStarted() || pipelineRun.HasCompleted() {
+		return
+	}
+	if _, decided := pipelineRun.Annotations[v1alpha3.PipelineRunPathFilterAnnoKey]; decided {
+		return
+	}
+
+	pipeline := &v1alpha3.Pipeline{}
+	if err = r.Get(ctx, client.ObjectKey{Namespace: pipelineRun.Namespace, Name: pipelineRun.Spec.PipelineRef.Name}, pipeline); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	policy := pipeline.Spec.PathFilter
+	if policy == nil {
+		return
+	}
+
+	// the PipelineRuns which were not triggered by commits, such as the manual ones, are never skipped
+	base := pipelineRun.Annotations[v1alpha3.PipelineRunSCMBaseRevisionAnnoKey]
+	head := pipelineRun.Annotations[v1alpha3.PipelineRunSCMRevisionAnnoKey]
+	if base == "" || head == "" || strings.Trim(base, "0") == "" {
+		err = r.decide(ctx, pipelineRun, v1alpha3.PathFilterUnknown, reasonChangesUnknown,
+			"the PipelineRun was not triggered by a range of commits")
+		return
+	}
+
+	provider, repoPath, err := r.getProvider(ctx, pipeline.Namespace, policy.GitRepository)
+	if apierrors.IsNotFound(err) {
+		r.recorder.Eventf(pipelineRun, v1.EventTypeWarning, "GitRepositoryNotFound", "GitRepository %s not found", policy.GitRepository)
+		err = r.decide(ctx, pipelineRun, v1alpha3.PathFilterUnknown, reasonChangesUnknown,
+			fmt.Sprintf("GitRepository %s not found", policy.GitRepository))
+		return
+	} else if err != nil {
+		return
+	}
+
+	files, err := provider.ListChangedFiles(ctx, repoPath, base, head)
+	if err != nil {
+		// don't hold the PipelineRun forever, it runs as usual if the changes are unknown
+		r.recorder.Eventf(pipelineRun, v1.EventTypeWarning, "ListChangesFailed",
+			"Failed to list the changes between %s and %s, error was %v", base, head, err)
+		err = r.decide(ctx, pipelineRun, v1alpha3.PathFilterUnknown, reasonChangesUnknown,
+			fmt.Sprintf("failed to list the changes between %s and %s: %v", base, head, err))
+		return
+	}
+
+	if matched := policy.Match(files); len(matched) > 0 {
+		err = r.decide(ctx, pipelineRun, v1alpha3.PathFilterMatched, reasonChangesMatched,
+			fmt.Sprintf("%d of the %d changed files matched, such as %s", len(matched), len(files), matched[0]))
+	} else {
+		err = r.decide(ctx, pipelineRun, v1alpha3.PathFilterSkipped, reasonNoChangesMatched,
+			fmt.Sprintf("none of the %d changed files between %s and %s matched", len(files), base, head))
+	}
+	return
+}
+
+// decide records the decision in the status, then annotates the PipelineRun to release it.
+// The status goes first, so that the backends never start a skipped PipelineRun.
+func (r *Reconciler) decide(ctx context.Context, pipelineRun *v1alpha3.PipelineRun, decision, reason, message string) error {
+	now := metav1.Now()
+	status := v1alpha3.ConditionTrue
+	switch decision {
+	case v1alpha3.PathFilterSkipped:
+		status = v1alpha3.ConditionFalse
+		pipelineRun.Status.Phase = v1alpha3.Skipped
+		pipelineRun.Status.CompletionTime = &now
+		r.recorder.Eventf(pipelineRun, v1.EventTypeNormal, string(v1alpha3.Skipped), "Skipped the PipelineRun because %s", message)
+	case v1alpha3.PathFilterUnknown:
+		status = v1alpha3.ConditionUnknown
+	}
+	pipelineRun.Status.AddCondition(&v1alpha3.Condition{
+		Type:               v1alpha3.ConditionPathFiltered,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: now,
+		LastProbeTime:      now,
+	})
+	pipelineRun.Status.UpdateTime = &now
+	if err := r.Status().Update(ctx, pipelineRun); err != nil {
+		return err
+	}
+
+	if pipelineRun.Annotations == nil {
+		pipelineRun.Annotations = map[string]string{}
+	}
+	pipelineRun.Annotations[v1alpha3.PipelineRunPathFilterAnnoKey] = decision
+	return r.Update(ctx, pipelineRun)
+}
+
+func (r *Reconciler) getProvider(ctx context.Context, namespace, name string) (provider scmclient.Provider, repoPath string, err error) {
+	repo := &v1alpha3.GitRepository{}
+	if err = r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, repo); err != nil {
+		return
+	}
+	if repoPath = gitrepository.GetRepoPath(repo); repoPath == "" {
+		err = fmt.Errorf("cannot find the repository from the GitRepository %s", repo.Name)
+		return
+	}
+
+	var secretRef *v1.SecretReference
+	if repo.Spec.Secret != nil {
+		secretRef = repo.Spec.Secret.DeepCopy()
+		if secretRef.Namespace == "" {
+			secretRef.Namespace = repo.Namespace
+		}
+	}
+	newProvider := r.NewProvider
+	if newProvider == nil {
+		newProvider = scmclient.NewProviderFromSecret
+	}
+	provider, err = newProvider(repo.Spec.Provider, repo.Spec.Server, secretRef, r.Client)
+	return
+}
+
+// GetName returns the name of this controller
+func (r *Reconciler) GetName() string {
+	return "path-filter-controller"
+}
+
+// GetGroupName returns the group name of this controller
+func (r *Reconciler) GetGroupName() string {
+	return "pathfilter"
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.GetName()).
+		For(&v1alpha3.PipelineRun{}).
+		Complete(r)
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pathfilter
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/git"
+	scmclient "kubesphere.io/devops/pkg/client/scm"
+)
+
+type fakeProvider struct {
+	scmclient.Provider
+	err error
+
+	base, head string
+}
+
+func (p *fakeProvider) ListChangedFiles(_ context.Context, _, base, head string) ([]string, error) {
+	p.base, p.head = base, head
+	return []string{"service-a/main.go", "docs/README.md"}, p.err
+}
+
+func TestReconcile(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	repo := &v1alpha3.GitRepository{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "app"},
+		Spec:       v1alpha3.GitRepositorySpec{Provider: scmclient.GitHub, URL: "https://github.com/org/app.git"},
+	}
+	newPipeline := func(policy *v1alpha3.PathFilterPolicy) *v1alpha3.Pipeline {
+		return &v1alpha3.Pipeline{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "build"},
+			Spec:       v1alpha3.PipelineSpec{Type: v1alpha3.NoScmPipelineType, PathFilter: policy},
+		}
+	}
+	triggered := map[string]string{
+		v1alpha3.PipelineRunSCMBaseRevisionAnnoKey: "sha-1",
+		v1alpha3.PipelineRunSCMRevisionAnnoKey:     "sha-2",
+	}
+	newPipelineRun := func(annotations map[string]string) *v1alpha3.PipelineRun {
+		return &v1alpha3.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "build-1", Annotations: annotations},
+			Spec:       v1alpha3.PipelineRunSpec{PipelineRef: &v1.ObjectReference{Name: "build"}},
+		}
+	}
+	serviceA := &v1alpha3.PathFilterPolicy{GitRepository: "app", Includes: []string{"service-a/"}}
+	serviceB := &v1alpha3.PathFilterPolicy{GitRepository: "app", Includes: []string{"service-b/**"}}
+
+	tests := []struct {
+		name        string
+		objects     []client.Object
+		providerErr error
+		wantErr     bool
+		verify      func(t *testing.T, pipelineRun *v1alpha3.PipelineRun, provider *fakeProvider)
+	}{{
+		name:    "path filter is not enabled",
+		objects: []client.Object{newPipeline(nil), newPipelineRun(triggered), repo},
+		verify: func(t *testing.T, pipelineRun *v1alpha3.PipelineRun, provider *fakeProvider) {
+			assert.NotContains(t, pipelineRun.Annotations, v1alpha3.PipelineRunPathFilterAnnoKey)
+			assert.Empty(t, provider.head)
+		},
+	}, {
+		name:    "the changes matched",
+		objects: []client.Object{newPipeline(serviceA), newPipelineRun(triggered), repo},
+		verify: func(t *testing.T, pipelineRun *v1alpha3.PipelineRun, provider *fakeProvider) {
+			assert.Equal(t, v1alpha3.PathFilterMatched, pipelineRun.Annotations[v1alpha3.PipelineRunPathFilterAnnoKey])
+			assert.Equal(t, "sha-1", provider.base)
+			assert.Equal(t, "sha-2", provider.head)
+			assert.False(t, pipelineRun.HasCompleted())
+			condition := pipelineRun.Status.GetCondition(v1alpha3.ConditionPathFiltered)
+			if assert.NotNil(t, condition) {
+				assert.Equal(t, v1alpha3.ConditionTrue, condition.Status)
+				assert.Contains(t, condition.Message, "service-a/main.go")
+			}
+		},
+	}, {
+		name:    "none of the changes matched",
+		objects: []client.Object{newPipeline(serviceB), newPipelineRun(triggered), repo},
+		verify: func(t *testing.T, pipelineRun *v1alpha3.PipelineRun, provider *fakeProvider) {
+			assert.Equal(t, v1alpha3.PathFilterSkipped, pipelineRun.Annotations[v1alpha3.PipelineRunPathFilterAnnoKey])
+			assert.Equal(t, v1alpha3.Skipped, pipelineRun.Status.Phase)
+			assert.True(t, pipelineRun.HasCompleted())
+			assert.False(t, pipelineRun.Buildable())
+			condition := pipelineRun.Status.GetCondition(v1alpha3.ConditionPathFiltered)
+			if assert.NotNil(t, condition) {
+				assert.Equal(t, v1alpha3.ConditionFalse, condition.Status)
+				assert.Equal(t, reasonNoChangesMatched, condition.Reason)
+			}
+		},
+	}, {
+		name:    "excluded changes",
+		objects: []client.Object{newPipeline(&v1alpha3.PathFilterPolicy{GitRepository: "app", Excludes: []string{"**/*.go", "docs/"}}), newPipelineRun(triggered), repo},
+		verify: func(t *testing.T, pipelineRun *v1alpha3.PipelineRun, provider *fakeProvider) {
+			assert.Equal(t, v1alpha3.PathFilterSkipped, pipelineRun.Annotations[v1alpha3.PipelineRunPathFilterAnnoKey])
+		},
+	}, {
+		name:    "not triggered by commits",
+		objects: []client.Object{newPipeline(serviceB), newPipelineRun(nil), repo},
+		verify: func(t *testing.T, pipelineRun *v1alpha3.PipelineRun, provider *fakeProvider) {
+			assert.Equal(t, v1alpha3.PathFilterUnknown, pipelineRun.Annotations[v1alpha3.PipelineRunPathFilterAnnoKey])
+			assert.False(t, pipelineRun.HasCompleted())
+			assert.Empty(t, provider.head)
+		},
+	}, {
+		name: "a new branch was pushed",
+		objects: []client.Object{newPipeline(serviceB), newPipelineRun(map[string]string{
+			v1alpha3.PipelineRunSCMBaseRevisionAnnoKey: "0000000000000000000000000000000000000000",
+			v1alpha3.PipelineRunSCMRevisionAnnoKey:     "sha-2",
+		}), repo},
+		verify: func(t *testing.T, pipelineRun *v1alpha3.PipelineRun, provider *fakeProvider) {
+			assert.Equal(t, v1alpha3.PathFilterUnknown, pipelineRun.Annotations[v1alpha3.PipelineRunPathFilterAnnoKey])
+		},
+	}, {
+		name:        "failed to list the changes",
+		objects:     []client.Object{newPipeline(serviceB), newPipelineRun(triggered), repo},
+		providerErr: fmt.Errorf("not found"),
+		verify: func(t *testing.T, pipelineRun *v1alpha3.PipelineRun, provider *fakeProvider) {
+			assert.Equal(t, v1alpha3.PathFilterUnknown, pipelineRun.Annotations[v1alpha3.PipelineRunPathFilterAnnoKey])
+			assert.False(t, pipelineRun.HasCompleted())
+			condition := pipelineRun.Status.GetCondition(v1alpha3.ConditionPathFiltered)
+			if assert.NotNil(t, condition) {
+				assert.Equal(t, v1alpha3.ConditionUnknown, condition.Status)
+			}
+		},
+	}, {
+		name:    "GitRepository not found",
+		objects: []client.Object{newPipeline(serviceB), newPipelineRun(triggered)},
+		verify: func(t *testing.T, pipelineRun *v1alpha3.PipelineRun, provider *fakeProvider) {
+			assert.Equal(t, v1alpha3.PathFilterUnknown, pipelineRun.Annotations[v1alpha3.PipelineRunPathFilterAnnoKey])
+		},
+	}, {
+		name: "decided PipelineRun",
+		objects: []client.Object{newPipeline(serviceB), newPipelineRun(map[string]string{
+			v1alpha3.PipelineRunPathFilterAnnoKey: v1alpha3.PathFilterMatched,
+		}), repo},
+		verify: func(t *testing.T, pipelineRun *v1alpha3.PipelineRun, provider *fakeProvider) {
+			assert.Equal(t, v1alpha3.PathFilterMatched, pipelineRun.Annotations[v1alpha3.PipelineRunPathFilterAnnoKey])
+			assert.Empty(t, pipelineRun.Status.Conditions)
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(tt.objects...).Build()
+			provider := &fakeProvider{err: tt.providerErr}
+			r := &Reconciler{
+				Client: c,
+				NewProvider: func(name, server string, ref *v1.SecretReference, _ git.ResourceGetter) (scmclient.Provider, error) {
+					return provider, nil
+				},
+				recorder: &record.FakeRecorder{},
+			}
+			key := client.ObjectKey{Namespace: "ns", Name: "build-1"}
+			_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+			assert.Equal(t, tt.wantErr, err != nil, err)
+
+			pipelineRun := &v1alpha3.PipelineRun{}
+			assert.Nil(t, c.Get(context.TODO(), key, pipelineRun))
+			tt.verify(t, pipelineRun, provider)
+		})
+	}
+}
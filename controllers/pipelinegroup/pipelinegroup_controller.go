@@ -0,0 +1,341 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelinegroup
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/pipelinerun"
+)
+
+// referencePattern matches the references in the parameter values, such as $(params.env) or $(members.build.results.image)
+var referencePattern = regexp.MustCompile(`\$\(\s*(params\.[^)\s]+|members\.[^)\s]+\.results\.[^)\s]+)\s*\)`)
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelinegroups,verbs=get;list;watch;update
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelinegroups/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelines,verbs=get;list;watch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns,verbs=get;list;watch;create
+
+// Reconciler triggers the members of PipelineGroups in the order of their dependencies.
+// A member is triggered once all of its dependencies have succeeded, and skipped once any of them did not succeed.
+type Reconciler struct {
+	client.Client
+
+	recorder record.EventRecorder
+}
+
+// Reconcile triggers the members whose dependencies have succeeded and aggregates the status of the group
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	group := &v1alpha3.PipelineGroup{}
+	if err = r.Get(ctx, req.NamespacedName, group); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	if !group.DeletionTimestamp.IsZero() || group.HasCompleted() {
+		return
+	}
+
+	status := group.Status.DeepCopy()
+	if status.StartTime == nil {
+		now := metav1.Now()
+		status.StartTime = &now
+	}
+	if validateErr := group.Validate(); validateErr != nil {
+		r.recorder.Event(group, v1.EventTypeWarning, "InvalidPipelineGroup", validateErr.Error())
+		status.Phase = v1alpha3.Failed
+		status.Message = validateErr.Error()
+		status.CompletionTime = status.StartTime
+		err = r.updateStatus(ctx, group, status)
+		return
+	}
+
+	runs := &v1alpha3.PipelineRunList{}
+	if err = r.List(ctx, runs, client.InNamespace(group.Namespace),
+		client.MatchingLabels{v1alpha3.PipelineGroupLabelKey: group.Name}); err != nil {
+		return
+	}
+	s := &scheduler{
+		Reconciler: r,
+		group:      group,
+		runs:       map[string]*v1alpha3.PipelineRun{},
+		statuses:   map[string]*v1alpha3.PipelineGroupMemberStatus{},
+	}
+	for i := range runs.Items {
+		run := &runs.Items[i]
+		s.runs[run.Labels[v1alpha3.PipelineGroupMemberLabelKey]] = run
+	}
+
+	status.Members = make([]v1alpha3.PipelineGroupMemberStatus, 0, len(group.Spec.Members))
+	for _, member := range group.Spec.Members {
+		var memberStatus *v1alpha3.PipelineGroupMemberStatus
+		if memberStatus, err = s.schedule(ctx, member.Name); err != nil {
+			return
+		}
+		status.Members = append(status.Members, *memberStatus)
+	}
+	aggregate(status)
+	err = r.updateStatus(ctx, group, status)
+	return
+}
+
+func (r *Reconciler) updateStatus(ctx context.Context, group *v1alpha3.PipelineGroup, status *v1alpha3.PipelineGroupStatus) error {
+	if reflect.DeepEqual(group.Status, *status) {
+		return nil
+	}
+	if status.CompletionTime != nil {
+		r.recorder.Eventf(group, v1.EventTypeNormal, string(status.Phase), "PipelineGroup %s", strings.ToLower(string(status.Phase)))
+	}
+	group.Status = *status
+	return r.Status().Update(ctx, group)
+}
+
+// scheduler works out the status of the members during one reconciliation
+type scheduler struct {
+	*Reconciler
+	group    *v1alpha3.PipelineGroup
+	runs     map[string]*v1alpha3.PipelineRun
+	statuses map[string]*v1alpha3.PipelineGroupMemberStatus
+}
+
+// schedule returns the status of a member, it triggers the member if all of its dependencies have succeeded.
+// The dependencies are scheduled before the member, it terminates since the dependencies were validated to be acyclic.
+func (s *scheduler) schedule(ctx context.Context, name string) (status *v1alpha3.PipelineGroupMemberStatus, err error) {
+	if status = s.statuses[name]; status != nil {
+		return
+	}
+	status = &v1alpha3.PipelineGroupMemberStatus{Name: name}
+	defer func() {
+		if err == nil {
+			s.statuses[name] = status
+		}
+	}()
+
+	if run, ok := s.runs[name]; ok {
+		setRunStatus(status, run)
+		return
+	}
+
+	member := s.group.GetMember(name)
+	var waiting []string
+	for _, dependency := range member.DependsOn {
+		var dependencyStatus *v1alpha3.PipelineGroupMemberStatus
+		if dependencyStatus, err = s.schedule(ctx, dependency); err != nil {
+			return
+		}
+		switch {
+		case isSucceeded(dependencyStatus):
+		case isTerminated(dependencyStatus):
+			status.Phase = v1alpha3.Skipped
+			status.Message = fmt.Sprintf("dependency %s did not succeed", dependency)
+			return
+		default:
+			waiting = append(waiting, dependency)
+		}
+	}
+	if len(waiting) > 0 {
+		status.Phase = v1alpha3.Pending
+		status.Message = fmt.Sprintf("waiting for %s", strings.Join(waiting, ", "))
+		return
+	}
+
+	var run *v1alpha3.PipelineRun
+	if run, err = s.trigger(ctx, member); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return
+		}
+		// the Pipeline does not exist, there is no point in retrying
+		status.Phase = v1alpha3.Failed
+		status.Message = err.Error()
+		err = nil
+		return
+	}
+	s.recorder.Eventf(s.group, v1.EventTypeNormal, "Triggered", "Triggered PipelineRun %s for member %s", run.Name, name)
+	setRunStatus(status, run)
+	return
+}
+
+// trigger creates the PipelineRun of a member
+func (s *scheduler) trigger(ctx context.Context, member *v1alpha3.PipelineGroupMember) (*v1alpha3.PipelineRun, error) {
+	pipeline := &v1alpha3.Pipeline{}
+	if err := s.Get(ctx, client.ObjectKey{Namespace: s.group.Namespace, Name: member.PipelineRef}, pipeline); err != nil {
+		return nil, err
+	}
+
+	run := pipelinerun.CreateBarePipelineRun(pipeline, s.resolveParameters(member), member.SCM)
+	run.Labels[v1alpha3.PipelineGroupLabelKey] = s.group.Name
+	run.Labels[v1alpha3.PipelineGroupMemberLabelKey] = member.Name
+	// the PipelineRun is controlled by its Pipeline, the group only owns it for the garbage collection
+	run.OwnerReferences = append(run.OwnerReferences, metav1.OwnerReference{
+		APIVersion: v1alpha3.GroupVersion.String(),
+		Kind:       "PipelineGroup",
+		Name:       s.group.Name,
+		UID:        s.group.UID,
+	})
+	return run, s.Create(ctx, run)
+}
+
+// resolveParameters merges the parameters of the group and the member, then replaces the references in the values.
+// The unknown references are kept as they are.
+func (s *scheduler) resolveParameters(member *v1alpha3.PipelineGroupMember) []v1alpha3.Parameter {
+	values := map[string]string{}
+	var names []string
+	for _, params := range [][]v1alpha3.Parameter{s.group.Spec.Parameters, member.Parameters} {
+		for _, param := range params {
+			if _, ok := values[param.Name]; !ok {
+				names = append(names, param.Name)
+			}
+			values[param.Name] = param.Value
+		}
+	}
+
+	parameters := make([]v1alpha3.Parameter, 0, len(names))
+	for _, name := range names {
+		value := referencePattern.ReplaceAllStringFunc(values[name], func(reference string) string {
+			if resolved, ok := s.resolveReference(member, referencePattern.FindStringSubmatch(reference)[1]); ok {
+				return resolved
+			}
+			return reference
+		})
+		parameters = append(parameters, v1alpha3.Parameter{Name: name, Value: value})
+	}
+	return parameters
+}
+
+// resolveReference resolves params.<name> from the group, and members.<name>.results.<result> from the dependencies
+func (s *scheduler) resolveReference(member *v1alpha3.PipelineGroupMember, reference string) (string, bool) {
+	if name := strings.TrimPrefix(reference, "params."); name != reference {
+		for _, param := range s.group.Spec.Parameters {
+			if param.Name == name {
+				return param.Value, true
+			}
+		}
+		return "", false
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(reference, "members."), ".results.", 2)
+	dependency, result := parts[0], parts[1]
+	run, ok := s.runs[dependency]
+	if !ok || !dependsOn(member, dependency) {
+		return "", false
+	}
+	return run.Status.GetResult(result)
+}
+
+func dependsOn(member *v1alpha3.PipelineGroupMember, name string) bool {
+	for _, dependency := range member.DependsOn {
+		if dependency == name {
+			return true
+		}
+	}
+	return false
+}
+
+func setRunStatus(status *v1alpha3.PipelineGroupMemberStatus, run *v1alpha3.PipelineRun) {
+	status.PipelineRun = run.Name
+	status.Phase = run.Status.Phase
+	if status.Phase == "" {
+		status.Phase = v1alpha3.Pending
+	}
+	if run.HasCompleted() && status.Phase != v1alpha3.Succeeded && status.Phase != v1alpha3.Cancelled {
+		// a completed PipelineRun which did not succeed is regarded as failed, such as the unknown ones
+		status.Phase = v1alpha3.Failed
+	}
+}
+
+func isSucceeded(status *v1alpha3.PipelineGroupMemberStatus) bool {
+	return status.Phase == v1alpha3.Succeeded
+}
+
+func isTerminated(status *v1alpha3.PipelineGroupMemberStatus) bool {
+	switch status.Phase {
+	case v1alpha3.Succeeded, v1alpha3.Failed, v1alpha3.Cancelled, v1alpha3.Skipped:
+		return true
+	}
+	return false
+}
+
+// aggregate works out the phase of the group by its members
+func aggregate(status *v1alpha3.PipelineGroupStatus) {
+	var unsuccessful []string
+	for i := range status.Members {
+		member := &status.Members[i]
+		if !isTerminated(member) {
+			status.Phase = v1alpha3.Running
+			status.Message = ""
+			return
+		}
+		if !isSucceeded(member) {
+			unsuccessful = append(unsuccessful, member.Name)
+		}
+	}
+
+	now := metav1.Now()
+	status.CompletionTime = &now
+	if len(unsuccessful) == 0 {
+		status.Phase = v1alpha3.Succeeded
+		status.Message = ""
+		return
+	}
+	sort.Strings(unsuccessful)
+	status.Phase = v1alpha3.Failed
+	status.Message = fmt.Sprintf("members did not succeed: %s", strings.Join(unsuccessful, ", "))
+}
+
+// findGroup maps the PipelineRuns to the groups which triggered them
+func findGroup(obj client.Object) []reconcile.Request {
+	name, ok := obj.GetLabels()[v1alpha3.PipelineGroupLabelKey]
+	if !ok {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: client.ObjectKey{Namespace: obj.GetNamespace(), Name: name}}}
+}
+
+// GetName returns the name of this controller
+func (r *Reconciler) GetName() string {
+	return "pipelinegroup-controller"
+}
+
+// GetGroupName returns the group name of this controller
+func (r *Reconciler) GetGroupName() string {
+	return "pipelinegroup"
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.GetName()).
+		For(&v1alpha3.PipelineGroup{}).
+		Watches(&source.Kind{Type: &v1alpha3.PipelineRun{}}, handler.EnqueueRequestsFromMapFunc(findGroup)).
+		Complete(r)
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelinegroup
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+func TestReconcile(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	newPipeline := func(name string) *v1alpha3.Pipeline {
+		return &v1alpha3.Pipeline{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name},
+			Spec:       v1alpha3.PipelineSpec{Type: v1alpha3.NoScmPipelineType, Pipeline: &v1alpha3.NoScmPipeline{Jenkinsfile: "pipeline {}"}},
+		}
+	}
+	newGroup := func(members ...v1alpha3.PipelineGroupMember) *v1alpha3.PipelineGroup {
+		return &v1alpha3.PipelineGroup{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "release", UID: "uid"},
+			Spec: v1alpha3.PipelineGroupSpec{
+				Members:    members,
+				Parameters: []v1alpha3.Parameter{{Name: "env", Value: "prod"}},
+			},
+		}
+	}
+	newRun := func(member string, phase v1alpha3.RunPhase, results ...v1alpha3.RunResult) *v1alpha3.PipelineRun {
+		run := &v1alpha3.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ns",
+				Name:      member + "-1",
+				Labels: map[string]string{
+					v1alpha3.PipelineGroupLabelKey:       "release",
+					v1alpha3.PipelineGroupMemberLabelKey: member,
+				},
+			},
+			Status: v1alpha3.PipelineRunStatus{Phase: phase, Results: results},
+		}
+		if phase == v1alpha3.Succeeded || phase == v1alpha3.Failed {
+			now := metav1.Now()
+			run.Status.CompletionTime = &now
+		}
+		return run
+	}
+	build := v1alpha3.PipelineGroupMember{Name: "build", PipelineRef: "build"}
+	deploy := v1alpha3.PipelineGroupMember{
+		Name:        "deploy",
+		PipelineRef: "deploy",
+		DependsOn:   []string{"build"},
+		Parameters: []v1alpha3.Parameter{
+			{Name: "image", Value: "$(members.build.results.image)"},
+			{Name: "target", Value: "$(params.env)-cluster"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		objects []client.Object
+		verify  func(t *testing.T, group *v1alpha3.PipelineGroup, runs []v1alpha3.PipelineRun)
+	}{{
+		name:    "trigger the members without dependencies",
+		objects: []client.Object{newGroup(build, deploy), newPipeline("build"), newPipeline("deploy")},
+		verify: func(t *testing.T, group *v1alpha3.PipelineGroup, runs []v1alpha3.PipelineRun) {
+			assert.Equal(t, v1alpha3.Running, group.Status.Phase)
+			assert.NotNil(t, group.Status.StartTime)
+			assert.Nil(t, group.Status.CompletionTime)
+			if assert.Len(t, runs, 1) {
+				run := runs[0]
+				assert.Equal(t, "build", run.Labels[v1alpha3.PipelineGroupMemberLabelKey])
+				assert.Equal(t, []v1alpha3.Parameter{{Name: "env", Value: "prod"}}, run.Spec.Parameters)
+				assert.Len(t, run.OwnerReferences, 2)
+				assert.Equal(t, "PipelineGroup", run.OwnerReferences[1].Kind)
+				assert.Equal(t, []v1alpha3.PipelineGroupMemberStatus{
+					{Name: "build", PipelineRun: run.Name, Phase: v1alpha3.Pending},
+					{Name: "deploy", Phase: v1alpha3.Pending, Message: "waiting for build"},
+				}, group.Status.Members)
+			}
+		},
+	}, {
+		name: "trigger the member once its dependencies succeeded",
+		objects: []client.Object{newGroup(build, deploy), newPipeline("build"), newPipeline("deploy"),
+			newRun("build", v1alpha3.Succeeded, v1alpha3.RunResult{Name: "image", Value: "app:v1"})},
+		verify: func(t *testing.T, group *v1alpha3.PipelineGroup, runs []v1alpha3.PipelineRun) {
+			assert.Equal(t, v1alpha3.Running, group.Status.Phase)
+			if assert.Len(t, runs, 2) {
+				run := runs[1]
+				if run.Name == "build-1" {
+					run = runs[0]
+				}
+				assert.Equal(t, "deploy", run.Labels[v1alpha3.PipelineGroupMemberLabelKey])
+				assert.Equal(t, []v1alpha3.Parameter{
+					{Name: "env", Value: "prod"},
+					{Name: "image", Value: "app:v1"},
+					{Name: "target", Value: "prod-cluster"},
+				}, run.Spec.Parameters)
+			}
+		},
+	}, {
+		name: "skip the member once its dependencies failed",
+		objects: []client.Object{newGroup(build, deploy), newPipeline("build"), newPipeline("deploy"),
+			newRun("build", v1alpha3.Failed)},
+		verify: func(t *testing.T, group *v1alpha3.PipelineGroup, runs []v1alpha3.PipelineRun) {
+			assert.Len(t, runs, 1)
+			assert.Equal(t, v1alpha3.Failed, group.Status.Phase)
+			assert.Equal(t, "members did not succeed: build, deploy", group.Status.Message)
+			assert.NotNil(t, group.Status.CompletionTime)
+			assert.Equal(t, []v1alpha3.PipelineGroupMemberStatus{
+				{Name: "build", PipelineRun: "build-1", Phase: v1alpha3.Failed},
+				{Name: "deploy", Phase: v1alpha3.Skipped, Message: "dependency build did not succeed"},
+			}, group.Status.Members)
+		},
+	}, {
+		name: "all the members succeeded",
+		objects: []client.Object{newGroup(build, deploy), newPipeline("build"), newPipeline("deploy"),
+			newRun("build", v1alpha3.Succeeded), newRun("deploy", v1alpha3.Succeeded)},
+		verify: func(t *testing.T, group *v1alpha3.PipelineGroup, runs []v1alpha3.PipelineRun) {
+			assert.Len(t, runs, 2)
+			assert.Equal(t, v1alpha3.Succeeded, group.Status.Phase)
+			assert.NotNil(t, group.Status.CompletionTime)
+		},
+	}, {
+		name:    "the Pipeline does not exist",
+		objects: []client.Object{newGroup(build, deploy)},
+		verify: func(t *testing.T, group *v1alpha3.PipelineGroup, runs []v1alpha3.PipelineRun) {
+			assert.Empty(t, runs)
+			assert.Equal(t, v1alpha3.Failed, group.Status.Phase)
+			assert.Equal(t, v1alpha3.Failed, group.Status.Members[0].Phase)
+			assert.Equal(t, v1alpha3.Skipped, group.Status.Members[1].Phase)
+		},
+	}, {
+		name: "invalid group",
+		objects: []client.Object{newGroup(v1alpha3.PipelineGroupMember{
+			Name: "build", PipelineRef: "build", DependsOn: []string{"build"},
+		}), newPipeline("build")},
+		verify: func(t *testing.T, group *v1alpha3.PipelineGroup, runs []v1alpha3.PipelineRun) {
+			assert.Empty(t, runs)
+			assert.Equal(t, v1alpha3.Failed, group.Status.Phase)
+			assert.Equal(t, `member "build" depends on itself`, group.Status.Message)
+			assert.NotNil(t, group.Status.CompletionTime)
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(tt.objects...).Build()
+			r := &Reconciler{
+				Client:   c,
+				recorder: &record.FakeRecorder{},
+			}
+			key := client.ObjectKey{Namespace: "ns", Name: "release"}
+			_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+			assert.Nil(t, err)
+
+			group := &v1alpha3.PipelineGroup{}
+			assert.Nil(t, c.Get(context.Background(), key, group))
+			runs := &v1alpha3.PipelineRunList{}
+			assert.Nil(t, c.List(context.Background(), runs))
+			tt.verify(t, group, runs.Items)
+		})
+	}
+}
+
+func TestReconcileCompleted(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	now := metav1.Now()
+	group := &v1alpha3.PipelineGroup{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "release"},
+		Spec:       v1alpha3.PipelineGroupSpec{Members: []v1alpha3.PipelineGroupMember{{Name: "build", PipelineRef: "build"}}},
+		Status:     v1alpha3.PipelineGroupStatus{Phase: v1alpha3.Succeeded, CompletionTime: &now},
+	}
+	c := fake.NewClientBuilder().WithScheme(schema).WithObjects(group).Build()
+	r := &Reconciler{Client: c, recorder: &record.FakeRecorder{}}
+	_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(group)})
+	assert.Nil(t, err)
+
+	runs := &v1alpha3.PipelineRunList{}
+	assert.Nil(t, c.List(context.Background(), runs))
+	assert.Empty(t, runs.Items)
+}
+
+func TestFindGroup(t *testing.T) {
+	assert.Nil(t, findGroup(&v1alpha3.PipelineRun{}))
+	assert.Equal(t, "ns/release", findGroup(&v1alpha3.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns",
+			Labels:    map[string]string{v1alpha3.PipelineGroupLabelKey: "release"},
+		},
+	})[0].String())
+}
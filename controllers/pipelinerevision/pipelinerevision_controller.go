@@ -0,0 +1,131 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelinerevision
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	modelpipeline "kubesphere.io/devops/pkg/models/pipeline"
+	"kubesphere.io/devops/pkg/utils"
+)
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelines,verbs=get;list;watch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelinerevisions,verbs=get;list;watch;create;delete
+
+// DefaultHistoryLimit is the number of the PipelineRevisions kept for each Pipeline by default
+const DefaultHistoryLimit = 20
+
+// Reconciler records a PipelineRevision whenever the spec of a Pipeline changes, and removes the oldest ones
+// which exceed the history limit. The PipelineRevisions are owned by the Pipeline, so they are garbage collected
+// along with it.
+type Reconciler struct {
+	client.Client
+
+	// HistoryLimit is the number of the PipelineRevisions kept for each Pipeline, DefaultHistoryLimit is used if
+	// it's not positive
+	HistoryLimit int
+}
+
+// Reconcile records the current spec of a Pipeline as a new PipelineRevision if it's different from the latest one
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	pipeline := &v1alpha3.Pipeline{}
+	if err = r.Get(ctx, req.NamespacedName, pipeline); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	if !pipeline.DeletionTimestamp.IsZero() {
+		return
+	}
+
+	var revisions []v1alpha3.PipelineRevision
+	if revisions, err = modelpipeline.ListRevisions(ctx, r.Client, pipeline.Namespace, pipeline.Name); err != nil {
+		return
+	}
+	hash := utils.ComputeHash(pipeline.Spec)
+	var latest int64
+	if len(revisions) > 0 {
+		if revisions[0].Spec.Hash == hash {
+			return
+		}
+		latest = revisions[0].Spec.Revision
+	}
+
+	revision := &v1alpha3.PipelineRevision{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: pipeline.Namespace,
+			Name:      v1alpha3.GetPipelineRevisionName(pipeline.Name, latest+1),
+			Labels:    map[string]string{v1alpha3.PipelineNameLabelKey: pipeline.Name},
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(pipeline, v1alpha3.GroupVersion.WithKind(v1alpha3.ResourceKindPipeline)),
+			},
+		},
+		Spec: v1alpha3.PipelineRevisionSpec{
+			Pipeline:     pipeline.Name,
+			Revision:     latest + 1,
+			Author:       pipeline.Annotations[v1alpha3.PipelineModifiedByAnnoKey],
+			Hash:         hash,
+			PipelineSpec: *pipeline.Spec.DeepCopy(),
+		},
+	}
+	if err = r.Create(ctx, revision); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			// the revision has been recorded by the previous reconciling, but the cache is not up-to-date yet
+			err = nil
+		}
+		return
+	}
+
+	limit := r.HistoryLimit
+	if limit <= 0 {
+		limit = DefaultHistoryLimit
+	}
+	// the new revision is not in the list
+	for i := limit - 1; i < len(revisions); i++ {
+		if err = r.Delete(ctx, &revisions[i]); err != nil && !apierrors.IsNotFound(err) {
+			return
+		}
+		err = nil
+	}
+	return
+}
+
+// GetName returns the name of this reconciler
+func (r *Reconciler) GetName() string {
+	return "pipelinerevision-controller"
+}
+
+// GetGroupName returns the group name of this reconciler
+func (r *Reconciler) GetGroupName() string {
+	return "pipelinerevision"
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.GetName()).
+		For(&v1alpha3.Pipeline{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Owns(&v1alpha3.PipelineRevision{}).
+		Complete(r)
+}
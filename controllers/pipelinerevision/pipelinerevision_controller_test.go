@@ -0,0 +1,105 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelinerevision
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	modelpipeline "kubesphere.io/devops/pkg/models/pipeline"
+)
+
+func TestReconcile(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	pipeline := &v1alpha3.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "ns",
+			Name:        "build",
+			UID:         "uid",
+			Annotations: map[string]string{v1alpha3.PipelineModifiedByAnnoKey: "alice"},
+		},
+		Spec: v1alpha3.PipelineSpec{
+			Type:     v1alpha3.NoScmPipelineType,
+			Pipeline: &v1alpha3.NoScmPipeline{Name: "build", Jenkinsfile: "v1"},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(schema).WithObjects(pipeline).Build()
+	r := &Reconciler{Client: c, HistoryLimit: 2}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "ns", Name: "build"}}
+	listRevisions := func() []v1alpha3.PipelineRevision {
+		revisions, err := modelpipeline.ListRevisions(context.TODO(), c, "ns", "build")
+		assert.Nil(t, err)
+		return revisions
+	}
+	updateJenkinsfile := func(jenkinsfile, modifier string) {
+		assert.Nil(t, c.Get(context.TODO(), req.NamespacedName, pipeline))
+		pipeline.Spec.Pipeline.Jenkinsfile = jenkinsfile
+		pipeline.Annotations[v1alpha3.PipelineModifiedByAnnoKey] = modifier
+		assert.Nil(t, c.Update(context.TODO(), pipeline))
+	}
+
+	// the first revision
+	_, err := r.Reconcile(context.TODO(), req)
+	assert.Nil(t, err)
+	revisions := listRevisions()
+	if assert.Len(t, revisions, 1) {
+		assert.Equal(t, "build-r1", revisions[0].Name)
+		assert.Equal(t, int64(1), revisions[0].Spec.Revision)
+		assert.Equal(t, "alice", revisions[0].Spec.Author)
+		assert.Equal(t, "v1", revisions[0].Spec.PipelineSpec.Pipeline.Jenkinsfile)
+		if assert.NotNil(t, metav1.GetControllerOf(&revisions[0])) {
+			assert.Equal(t, types.UID("uid"), metav1.GetControllerOf(&revisions[0]).UID)
+		}
+	}
+
+	// nothing changed
+	_, err = r.Reconcile(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.Len(t, listRevisions(), 1)
+
+	// the oldest revisions are removed once they exceed the limit
+	updateJenkinsfile("v2", "bob")
+	_, err = r.Reconcile(context.TODO(), req)
+	assert.Nil(t, err)
+	updateJenkinsfile("v3", "alice")
+	_, err = r.Reconcile(context.TODO(), req)
+	assert.Nil(t, err)
+	revisions = listRevisions()
+	if assert.Len(t, revisions, 2) {
+		assert.Equal(t, int64(3), revisions[0].Spec.Revision)
+		assert.Equal(t, "v3", revisions[0].Spec.PipelineSpec.Pipeline.Jenkinsfile)
+		assert.Equal(t, int64(2), revisions[1].Spec.Revision)
+		assert.Equal(t, "bob", revisions[1].Spec.Author)
+	}
+
+	// the Pipeline is gone
+	assert.Nil(t, c.Delete(context.TODO(), pipeline))
+	_, err = r.Reconcile(context.TODO(), req)
+	assert.Nil(t, err)
+	assert.Nil(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "ns", Name: "build-r3"}, &v1alpha3.PipelineRevision{}))
+}
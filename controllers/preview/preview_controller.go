@@ -0,0 +1,310 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preview
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"kubesphere.io/devops/controllers/gitrepository"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	scmclient "kubesphere.io/devops/pkg/client/scm"
+	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/pipelinerun"
+)
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=previewenvironments,verbs=get;list;watch;update;patch;delete
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=previewenvironments/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelines;gitrepositories,verbs=get;list;watch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns,verbs=get;list;watch;create
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;create;delete
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconciler deploys the pull requests to the namespaces of their PreviewEnvironments through the preview Pipeline,
+// posts the results back to the pull requests, and tears the namespaces down when the PreviewEnvironments are
+// deleted or expired.
+type Reconciler struct {
+	client.Client
+
+	// NewProvider creates the SCM provider, scmclient.NewProviderFromSecret will be used if it's nil
+	NewProvider gitrepository.ProviderFactory
+
+	recorder record.EventRecorder
+	now      func() time.Time
+}
+
+// Reconcile makes the PreviewEnvironment
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	preview := &v1alpha3.PreviewEnvironment{}
+	if err = r.Get(ctx, req.NamespacedName, preview); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	if !preview.DeletionTimestamp.IsZero() {
+		err = r.tearDown(ctx, preview)
+		return
+	}
+	if !controllerutil.ContainsFinalizer(preview, v1alpha3.PreviewFinalizerName) {
+		controllerutil.AddFinalizer(preview, v1alpha3.PreviewFinalizerName)
+		err = r.Update(ctx, preview)
+		return
+	}
+
+	status := preview.Status.DeepCopy()
+	if status.ExpireTime == nil {
+		status.ExpireTime = r.expireTimeFrom(preview, preview.CreationTimestamp.Time)
+	}
+	if !r.getNow().Before(status.ExpireTime.Time) {
+		r.recorder.Eventf(preview, v1.EventTypeNormal, "Expired", "PreviewEnvironment expired at %s", status.ExpireTime)
+		err = client.IgnoreNotFound(r.Delete(ctx, preview))
+		return
+	}
+
+	if err = r.ensureNamespace(ctx, preview); err == nil {
+		status.Namespace = preview.GetPreviewNamespace()
+		status.URL = preview.Render(preview.Spec.URL)
+		if err = r.deploy(ctx, preview, status); err == nil {
+			err = r.comment(ctx, preview, status)
+		}
+	}
+	if updateErr := r.updateStatus(ctx, preview, status); err == nil {
+		err = updateErr
+	}
+	if err == nil {
+		result.RequeueAfter = status.ExpireTime.Sub(r.getNow())
+	}
+	return
+}
+
+// tearDown deletes the namespace of the PreviewEnvironment, then removes the finalizer
+func (r *Reconciler) tearDown(ctx context.Context, preview *v1alpha3.PreviewEnvironment) (err error) {
+	if !controllerutil.ContainsFinalizer(preview, v1alpha3.PreviewFinalizerName) {
+		return
+	}
+	ns := &v1.Namespace{}
+	ns.SetName(preview.GetPreviewNamespace())
+	if err = r.Delete(ctx, ns); err != nil && !apierrors.IsNotFound(err) {
+		return
+	}
+	r.recorder.Eventf(preview, v1.EventTypeNormal, "TornDown", "Deleted the namespace %s", ns.Name)
+	controllerutil.RemoveFinalizer(preview, v1alpha3.PreviewFinalizerName)
+	return r.Update(ctx, preview)
+}
+
+func (r *Reconciler) ensureNamespace(ctx context.Context, preview *v1alpha3.PreviewEnvironment) (err error) {
+	ns := &v1.Namespace{}
+	if err = r.Get(ctx, client.ObjectKey{Name: preview.GetPreviewNamespace()}, ns); !apierrors.IsNotFound(err) {
+		return
+	}
+	ns = &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: preview.GetPreviewNamespace(),
+			Labels: map[string]string{
+				v1alpha3.PreviewEnvironmentLabelKey: preview.Name,
+			},
+			Annotations: map[string]string{
+				v1alpha3.PreviewEnvironmentLabelKey: preview.Namespace + "/" + preview.Name,
+			},
+		},
+	}
+	if err = r.Create(ctx, ns); err == nil {
+		r.recorder.Eventf(preview, v1.EventTypeNormal, "NamespaceCreated", "Created the namespace %s", ns.Name)
+	}
+	return
+}
+
+// deploy creates a PipelineRun for the head revision of the pull request, then follows its phase
+func (r *Reconciler) deploy(ctx context.Context, preview *v1alpha3.PreviewEnvironment, status *v1alpha3.PreviewEnvironmentStatus) (err error) {
+	if status.PipelineRun != "" {
+		latest := &v1alpha3.PipelineRun{}
+		if err = r.Get(ctx, client.ObjectKey{Namespace: preview.Namespace, Name: status.PipelineRun}, latest); err != nil && !apierrors.IsNotFound(err) {
+			return
+		}
+		if err == nil && latest.Annotations[v1alpha3.PipelineRunSCMRevisionAnnoKey] == preview.Spec.Revision {
+			switch {
+			case !latest.HasCompleted():
+				status.Phase = v1alpha3.PreviewDeploying
+			case latest.Status.Phase == v1alpha3.Succeeded:
+				status.Phase = v1alpha3.PreviewReady
+				status.DeployedRevision = preview.Spec.Revision
+				status.Message = ""
+			default:
+				status.Phase = v1alpha3.PreviewFailed
+				status.Message = fmt.Sprintf("PipelineRun %s did not succeed", latest.Name)
+			}
+			return
+		}
+		err = nil
+	}
+
+	pipeline := &v1alpha3.Pipeline{}
+	if err = r.Get(ctx, client.ObjectKey{Namespace: preview.Namespace, Name: preview.Spec.PipelineRef}, pipeline); err != nil {
+		if apierrors.IsNotFound(err) {
+			err = nil
+			r.fail(preview, status, "PipelineNotFound", fmt.Sprintf("Pipeline %s not found", preview.Spec.PipelineRef))
+		}
+		return
+	}
+	var scm *v1alpha3.SCM
+	if scm, err = pipelinerun.CreateScm(&pipeline.Spec, fmt.Sprintf("PR-%d", preview.Spec.PullRequest)); err != nil {
+		return
+	}
+
+	parameters := []v1alpha3.Parameter{
+		{Name: "PREVIEW_NAMESPACE", Value: preview.GetPreviewNamespace()},
+		{Name: "PULL_REQUEST", Value: fmt.Sprint(preview.Spec.PullRequest)},
+		{Name: "REVISION", Value: preview.Spec.Revision},
+	}
+	for _, param := range preview.Spec.Parameters {
+		parameters = append(parameters, v1alpha3.Parameter{Name: param.Name, Value: preview.Render(param.Value)})
+	}
+	run := pipelinerun.CreateBarePipelineRun(pipeline, parameters, scm)
+	run.Labels[v1alpha3.PreviewEnvironmentLabelKey] = preview.Name
+	run.Annotations[v1alpha3.PipelineRunSCMRevisionAnnoKey] = preview.Spec.Revision
+	if err = r.Create(ctx, run); err != nil {
+		return
+	}
+	r.recorder.Eventf(preview, v1.EventTypeNormal, "Deploying", "Deploying %s by PipelineRun %s", preview.Spec.Revision, run.Name)
+	status.Phase = v1alpha3.PreviewDeploying
+	status.PipelineRun = run.Name
+	status.Message = ""
+	// every push to the pull request keeps the PreviewEnvironment alive
+	status.ExpireTime = r.expireTimeFrom(preview, r.getNow())
+	return
+}
+
+// comment posts the result of the deployment back to the pull request once per revision
+func (r *Reconciler) comment(ctx context.Context, preview *v1alpha3.PreviewEnvironment, status *v1alpha3.PreviewEnvironmentStatus) (err error) {
+	if status.Phase != v1alpha3.PreviewReady && status.Phase != v1alpha3.PreviewFailed ||
+		status.CommentedRevision == preview.Spec.Revision {
+		return
+	}
+
+	repo := &v1alpha3.GitRepository{}
+	if err = r.Get(ctx, client.ObjectKey{Namespace: preview.Namespace, Name: preview.Spec.GitRepository}, repo); err != nil {
+		if apierrors.IsNotFound(err) {
+			err = nil
+			r.recorder.Eventf(preview, v1.EventTypeWarning, "GitRepositoryNotFound", "GitRepository %s not found", preview.Spec.GitRepository)
+		}
+		return
+	}
+	repoPath := gitrepository.GetRepoPath(repo)
+	if repoPath == "" {
+		return fmt.Errorf("cannot find the repository from the GitRepository %s", repo.Name)
+	}
+
+	var secretRef *v1.SecretReference
+	if repo.Spec.Secret != nil {
+		secretRef = repo.Spec.Secret.DeepCopy()
+		if secretRef.Namespace == "" {
+			secretRef.Namespace = repo.Namespace
+		}
+	}
+	newProvider := r.NewProvider
+	if newProvider == nil {
+		newProvider = scmclient.NewProviderFromSecret
+	}
+	var provider scmclient.Provider
+	if provider, err = newProvider(repo.Spec.Provider, repo.Spec.Server, secretRef, r.Client); err != nil {
+		return
+	}
+	if err = provider.CreateComment(ctx, repoPath, preview.Spec.PullRequest, getCommentBody(preview, status)); err != nil {
+		r.recorder.Eventf(preview, v1.EventTypeWarning, "CommentFailed", "Failed to comment on %s#%d, error was %v",
+			repoPath, preview.Spec.PullRequest, err)
+		return
+	}
+	status.CommentedRevision = preview.Spec.Revision
+	return
+}
+
+func getCommentBody(preview *v1alpha3.PreviewEnvironment, status *v1alpha3.PreviewEnvironmentStatus) string {
+	if status.Phase == v1alpha3.PreviewFailed {
+		return fmt.Sprintf("Failed to deploy %s to the preview environment %s: %s",
+			preview.Spec.Revision, status.Namespace, status.Message)
+	}
+	if status.URL == "" {
+		return fmt.Sprintf("Deployed %s to the preview environment %s.", preview.Spec.Revision, status.Namespace)
+	}
+	return fmt.Sprintf("Deployed %s to the preview environment: %s", preview.Spec.Revision, status.URL)
+}
+
+func (r *Reconciler) expireTimeFrom(preview *v1alpha3.PreviewEnvironment, from time.Time) *metav1.Time {
+	expireTime := metav1.NewTime(from.Add(preview.Spec.PreviewPolicy.GetTTL()))
+	return &expireTime
+}
+
+func (r *Reconciler) fail(preview *v1alpha3.PreviewEnvironment, status *v1alpha3.PreviewEnvironmentStatus, reason, message string) {
+	r.recorder.Event(preview, v1.EventTypeWarning, reason, message)
+	status.Phase = v1alpha3.PreviewFailed
+	status.Message = message
+}
+
+func (r *Reconciler) updateStatus(ctx context.Context, preview *v1alpha3.PreviewEnvironment, status *v1alpha3.PreviewEnvironmentStatus) error {
+	if reflect.DeepEqual(preview.Status, *status) {
+		return nil
+	}
+	preview.Status = *status
+	return r.Status().Update(ctx, preview)
+}
+
+func (r *Reconciler) getNow() time.Time {
+	if r.now != nil {
+		return r.now()
+	}
+	return time.Now()
+}
+
+// findPreviewEnvironment maps the PipelineRuns to the PreviewEnvironments which they deploy
+func (r *Reconciler) findPreviewEnvironment(obj client.Object) (requests []reconcile.Request) {
+	if name, ok := obj.GetLabels()[v1alpha3.PreviewEnvironmentLabelKey]; ok {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKey{Namespace: obj.GetNamespace(), Name: name}})
+	}
+	return
+}
+
+// GetName returns the name of this controller
+func (r *Reconciler) GetName() string {
+	return "preview-environment-controller"
+}
+
+// GetGroupName returns the group name of this controller
+func (r *Reconciler) GetGroupName() string {
+	return "preview"
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.GetName()).
+		For(&v1alpha3.PreviewEnvironment{}).
+		Watches(&source.Kind{Type: &v1alpha3.PipelineRun{}}, handler.EnqueueRequestsFromMapFunc(r.findPreviewEnvironment)).
+		Complete(r)
+}
@@ -0,0 +1,240 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preview
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/git"
+	scmclient "kubesphere.io/devops/pkg/client/scm"
+)
+
+type fakeProvider struct {
+	scmclient.Provider
+
+	number   int
+	comments []string
+}
+
+func (p *fakeProvider) CreateComment(_ context.Context, _ string, number int, body string) error {
+	p.number = number
+	p.comments = append(p.comments, body)
+	return nil
+}
+
+func TestReconcile(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+	assert.Nil(t, v1.AddToScheme(schema))
+
+	now := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	repo := &v1alpha3.GitRepository{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "app"},
+		Spec:       v1alpha3.GitRepositorySpec{Provider: scmclient.GitHub, URL: "https://github.com/org/app.git"},
+	}
+	pipeline := &v1alpha3.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "deploy-preview"},
+		Spec:       v1alpha3.PipelineSpec{Type: v1alpha3.NoScmPipelineType},
+	}
+	newPreview := func(status v1alpha3.PreviewEnvironmentStatus) *v1alpha3.PreviewEnvironment {
+		return &v1alpha3.PreviewEnvironment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "app-pr-12", CreationTimestamp: metav1.NewTime(now),
+				Finalizers: []string{v1alpha3.PreviewFinalizerName}},
+			Spec: v1alpha3.PreviewEnvironmentSpec{
+				GitRepository: "app",
+				PullRequest:   12,
+				Revision:      "sha-2",
+				PreviewPolicy: v1alpha3.PreviewPolicy{
+					PipelineRef: "deploy-preview",
+					Parameters:  []v1alpha3.Parameter{{Name: "HOST", Value: "pr-$(preview.number).example.com"}},
+					URL:         "https://pr-$(preview.number).example.com",
+				},
+			},
+			Status: status,
+		}
+	}
+	newPipelineRun := func(revision string, phase v1alpha3.RunPhase) *v1alpha3.PipelineRun {
+		pipelineRun := &v1alpha3.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "deploy-preview-1",
+				Annotations: map[string]string{v1alpha3.PipelineRunSCMRevisionAnnoKey: revision}},
+			Status: v1alpha3.PipelineRunStatus{Phase: phase},
+		}
+		if phase != v1alpha3.Running {
+			completionTime := metav1.NewTime(now)
+			pipelineRun.Status.CompletionTime = &completionTime
+		}
+		return pipelineRun
+	}
+	expireTime := metav1.NewTime(now.Add(time.Hour))
+
+	tests := []struct {
+		name    string
+		objects []client.Object
+		passed  time.Duration
+		verify  func(t *testing.T, c client.Client, preview *v1alpha3.PreviewEnvironment, provider *fakeProvider)
+	}{{
+		name:    "add the finalizer",
+		objects: []client.Object{&v1alpha3.PreviewEnvironment{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "app-pr-12"}}},
+		verify: func(t *testing.T, c client.Client, preview *v1alpha3.PreviewEnvironment, provider *fakeProvider) {
+			assert.Equal(t, []string{v1alpha3.PreviewFinalizerName}, preview.Finalizers)
+		},
+	}, {
+		name:    "create the namespace and deploy",
+		objects: []client.Object{newPreview(v1alpha3.PreviewEnvironmentStatus{}), pipeline, repo},
+		verify: func(t *testing.T, c client.Client, preview *v1alpha3.PreviewEnvironment, provider *fakeProvider) {
+			ns := &v1.Namespace{}
+			assert.Nil(t, c.Get(context.TODO(), client.ObjectKey{Name: "ns-app-pr-12"}, ns))
+			assert.Equal(t, "app-pr-12", ns.Labels[v1alpha3.PreviewEnvironmentLabelKey])
+
+			assert.Equal(t, v1alpha3.PreviewDeploying, preview.Status.Phase)
+			assert.Equal(t, "ns-app-pr-12", preview.Status.Namespace)
+			assert.Equal(t, "https://pr-12.example.com", preview.Status.URL)
+			assert.True(t, now.Add(v1alpha3.DefaultPreviewTTL).Equal(preview.Status.ExpireTime.Time))
+
+			pipelineRun := &v1alpha3.PipelineRun{}
+			if assert.Nil(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "ns", Name: preview.Status.PipelineRun}, pipelineRun)) {
+				assert.Equal(t, "app-pr-12", pipelineRun.Labels[v1alpha3.PreviewEnvironmentLabelKey])
+				assert.Equal(t, "sha-2", pipelineRun.Annotations[v1alpha3.PipelineRunSCMRevisionAnnoKey])
+				assert.Equal(t, []v1alpha3.Parameter{
+					{Name: "PREVIEW_NAMESPACE", Value: "ns-app-pr-12"},
+					{Name: "PULL_REQUEST", Value: "12"},
+					{Name: "REVISION", Value: "sha-2"},
+					{Name: "HOST", Value: "pr-12.example.com"},
+				}, pipelineRun.Spec.Parameters)
+			}
+			assert.Empty(t, provider.comments)
+		},
+	}, {
+		name: "deployed and commented",
+		objects: []client.Object{newPreview(v1alpha3.PreviewEnvironmentStatus{PipelineRun: "deploy-preview-1", ExpireTime: &expireTime}),
+			newPipelineRun("sha-2", v1alpha3.Succeeded), pipeline, repo},
+		verify: func(t *testing.T, c client.Client, preview *v1alpha3.PreviewEnvironment, provider *fakeProvider) {
+			assert.Equal(t, v1alpha3.PreviewReady, preview.Status.Phase)
+			assert.Equal(t, "sha-2", preview.Status.DeployedRevision)
+			assert.Equal(t, "sha-2", preview.Status.CommentedRevision)
+			assert.Equal(t, 12, provider.number)
+			assert.Equal(t, []string{"Deployed sha-2 to the preview environment: https://pr-12.example.com"}, provider.comments)
+		},
+	}, {
+		name: "deployment failed",
+		objects: []client.Object{newPreview(v1alpha3.PreviewEnvironmentStatus{PipelineRun: "deploy-preview-1", ExpireTime: &expireTime}),
+			newPipelineRun("sha-2", v1alpha3.Failed), pipeline, repo},
+		verify: func(t *testing.T, c client.Client, preview *v1alpha3.PreviewEnvironment, provider *fakeProvider) {
+			assert.Equal(t, v1alpha3.PreviewFailed, preview.Status.Phase)
+			assert.Equal(t, "PipelineRun deploy-preview-1 did not succeed", preview.Status.Message)
+			assert.Len(t, provider.comments, 1)
+		},
+	}, {
+		name: "redeploy the new revision",
+		objects: []client.Object{newPreview(v1alpha3.PreviewEnvironmentStatus{PipelineRun: "deploy-preview-1", ExpireTime: &expireTime,
+			Phase: v1alpha3.PreviewReady, DeployedRevision: "sha-1", CommentedRevision: "sha-1"}),
+			newPipelineRun("sha-1", v1alpha3.Succeeded), pipeline, repo},
+		passed: time.Minute,
+		verify: func(t *testing.T, c client.Client, preview *v1alpha3.PreviewEnvironment, provider *fakeProvider) {
+			assert.Equal(t, v1alpha3.PreviewDeploying, preview.Status.Phase)
+			assert.NotEqual(t, "deploy-preview-1", preview.Status.PipelineRun)
+			assert.Equal(t, "sha-1", preview.Status.DeployedRevision)
+			assert.True(t, now.Add(time.Minute+v1alpha3.DefaultPreviewTTL).Equal(preview.Status.ExpireTime.Time))
+		},
+	}, {
+		name:    "Pipeline not found",
+		objects: []client.Object{newPreview(v1alpha3.PreviewEnvironmentStatus{ExpireTime: &expireTime}), repo},
+		verify: func(t *testing.T, c client.Client, preview *v1alpha3.PreviewEnvironment, provider *fakeProvider) {
+			assert.Equal(t, v1alpha3.PreviewFailed, preview.Status.Phase)
+			assert.Equal(t, "Pipeline deploy-preview not found", preview.Status.Message)
+		},
+	}, {
+		name:    "expired",
+		objects: []client.Object{newPreview(v1alpha3.PreviewEnvironmentStatus{ExpireTime: &expireTime}), pipeline, repo},
+		passed:  2 * time.Hour,
+		verify: func(t *testing.T, c client.Client, preview *v1alpha3.PreviewEnvironment, provider *fakeProvider) {
+			// the finalizer keeps it until the namespace is torn down
+			assert.False(t, preview.DeletionTimestamp.IsZero())
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(tt.objects...).Build()
+			provider := &fakeProvider{}
+			r := &Reconciler{
+				Client: c,
+				NewProvider: func(name, server string, ref *v1.SecretReference, _ git.ResourceGetter) (scmclient.Provider, error) {
+					return provider, nil
+				},
+				recorder: &record.FakeRecorder{},
+				now: func() time.Time {
+					return now.Add(tt.passed)
+				},
+			}
+			key := client.ObjectKey{Namespace: "ns", Name: "app-pr-12"}
+			_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+			assert.Nil(t, err)
+
+			preview := &v1alpha3.PreviewEnvironment{}
+			assert.Nil(t, c.Get(context.TODO(), key, preview))
+			tt.verify(t, c, preview, provider)
+		})
+	}
+}
+
+func TestTearDown(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+	assert.Nil(t, v1.AddToScheme(schema))
+
+	deletionTime := metav1.Now()
+	preview := &v1alpha3.PreviewEnvironment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "app-pr-12", DeletionTimestamp: &deletionTime,
+			Finalizers: []string{v1alpha3.PreviewFinalizerName}},
+	}
+	ns := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-app-pr-12"}}
+	c := fake.NewClientBuilder().WithScheme(schema).WithObjects(preview, ns).Build()
+	r := &Reconciler{Client: c, recorder: &record.FakeRecorder{}}
+
+	key := client.ObjectKeyFromObject(preview)
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+	assert.Nil(t, err)
+	assert.True(t, apierrors.IsNotFound(c.Get(context.TODO(), client.ObjectKey{Name: "ns-app-pr-12"}, &v1.Namespace{})))
+
+	// nothing to do once the finalizer is removed
+	if err = c.Get(context.TODO(), key, preview); err == nil {
+		assert.Empty(t, preview.Finalizers)
+	}
+}
+
+func TestFindPreviewEnvironment(t *testing.T) {
+	r := &Reconciler{}
+	requests := r.findPreviewEnvironment(&v1alpha3.PipelineRun{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "deploy-1",
+		Labels: map[string]string{v1alpha3.PreviewEnvironmentLabelKey: "app-pr-12"}}})
+	if assert.Len(t, requests, 1) {
+		assert.Equal(t, client.ObjectKey{Namespace: "ns", Name: "app-pr-12"}, requests[0].NamespacedName)
+	}
+	assert.Empty(t, r.findPreviewEnvironment(&v1alpha3.PipelineRun{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "build-1"}}))
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package promotion
+
+import (
+	"context"
+	"errors"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/promotion"
+)
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=environments,verbs=get;list;watch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=environments/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelines,verbs=get;list;watch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns,verbs=get;list;watch;create;update
+
+// Reconciler promotes the succeeded PipelineRuns to the next Environment if it's promoted automatically.
+// The Environments with approvers are promoted through the API by the approvers.
+type Reconciler struct {
+	client.Client
+
+	recorder record.EventRecorder
+}
+
+// Reconcile promotes the PipelineRun once it succeeded
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	pipelineRun := &v1alpha3.PipelineRun{}
+	if err = r.Get(ctx, req.NamespacedName, pipelineRun); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+
+	var target *v1alpha3.Environment
+	if target, err = promotion.GetTargetEnvironment(ctx, r.Client, pipelineRun); err != nil {
+		if errors.Is(err, promotion.ErrNotPromotable) || errors.Is(err, promotion.ErrAlreadyPromoted) {
+			err = nil
+		}
+		return
+	}
+	if !target.IsAutoPromoted() {
+		return
+	}
+
+	var promoted *v1alpha3.PipelineRun
+	if promoted, err = promotion.Promote(ctx, r.Client, pipelineRun, target, nil); err == nil {
+		r.recorder.Eventf(pipelineRun, v1.EventTypeNormal, "Promoted", "Promoted to Environment %s by PipelineRun %s",
+			target.Name, promoted.Name)
+	}
+	return
+}
+
+// GetName returns the name of this controller
+func (r *Reconciler) GetName() string {
+	return "promotion-controller"
+}
+
+// GetGroupName returns the group name of this controller
+func (r *Reconciler) GetGroupName() string {
+	return "promotion"
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.GetName()).
+		For(&v1alpha3.PipelineRun{}).
+		WithEventFilter(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+			_, ok := obj.GetLabels()[v1alpha3.EnvironmentLabelKey]
+			return ok
+		})).
+		Complete(r)
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package promotion
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+func TestReconcile(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	pipeline := &v1alpha3.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "deploy"},
+		Spec:       v1alpha3.PipelineSpec{Type: v1alpha3.NoScmPipelineType, Pipeline: &v1alpha3.NoScmPipeline{Jenkinsfile: "pipeline {}"}},
+	}
+	dev := &v1alpha3.Environment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "dev"},
+		Spec:       v1alpha3.EnvironmentSpec{PipelineRef: "deploy", Next: "staging"},
+	}
+	newStaging := func(promotion v1alpha3.EnvironmentPromotionPolicy) *v1alpha3.Environment {
+		return &v1alpha3.Environment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "staging"},
+			Spec:       v1alpha3.EnvironmentSpec{PipelineRef: "deploy", Promotion: promotion},
+		}
+	}
+	newRun := func(phase v1alpha3.RunPhase) *v1alpha3.PipelineRun {
+		run := &v1alpha3.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ns",
+				Name:      "run",
+				Labels:    map[string]string{v1alpha3.EnvironmentLabelKey: "dev"},
+			},
+			Status: v1alpha3.PipelineRunStatus{Phase: phase},
+		}
+		if phase != v1alpha3.Running {
+			now := metav1.Now()
+			run.Status.CompletionTime = &now
+		}
+		return run
+	}
+
+	tests := []struct {
+		name         string
+		objects      []client.Object
+		wantPromoted bool
+	}{{
+		name:         "promoted automatically",
+		objects:      []client.Object{newRun(v1alpha3.Succeeded), dev, newStaging(v1alpha3.EnvironmentPromotionPolicy{Auto: true}), pipeline},
+		wantPromoted: true,
+	}, {
+		name:    "still running",
+		objects: []client.Object{newRun(v1alpha3.Running), dev, newStaging(v1alpha3.EnvironmentPromotionPolicy{Auto: true}), pipeline},
+	}, {
+		name:    "failed",
+		objects: []client.Object{newRun(v1alpha3.Failed), dev, newStaging(v1alpha3.EnvironmentPromotionPolicy{Auto: true}), pipeline},
+	}, {
+		name:    "promoted manually",
+		objects: []client.Object{newRun(v1alpha3.Succeeded), dev, newStaging(v1alpha3.EnvironmentPromotionPolicy{}), pipeline},
+	}, {
+		name: "requires approval",
+		objects: []client.Object{newRun(v1alpha3.Succeeded), dev, newStaging(v1alpha3.EnvironmentPromotionPolicy{
+			Auto: true, Approvers: []string{"alice"},
+		}), pipeline},
+	}, {
+		name:    "PipelineRun not found",
+		objects: []client.Object{dev, newStaging(v1alpha3.EnvironmentPromotionPolicy{Auto: true}), pipeline},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(tt.objects...).Build()
+			r := &Reconciler{Client: c, recorder: &record.FakeRecorder{}}
+			_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKey{Namespace: "ns", Name: "run"}})
+			assert.Nil(t, err)
+
+			runs := &v1alpha3.PipelineRunList{}
+			assert.Nil(t, c.List(context.Background(), runs, client.MatchingLabels{v1alpha3.EnvironmentLabelKey: "staging"}))
+			if !tt.wantPromoted {
+				assert.Empty(t, runs.Items)
+				return
+			}
+			if assert.Len(t, runs.Items, 1) {
+				assert.Equal(t, "run", runs.Items[0].Annotations[v1alpha3.PromotedFromAnnoKey])
+			}
+			staging := &v1alpha3.Environment{}
+			assert.Nil(t, c.Get(context.Background(), client.ObjectKey{Namespace: "ns", Name: "staging"}, staging))
+			if assert.Len(t, staging.Status.Promotions, 1) {
+				assert.Empty(t, staging.Status.Promotions[0].PromotedBy)
+			}
+
+			// it's promoted only once
+			_, err = r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKey{Namespace: "ns", Name: "run"}})
+			assert.Nil(t, err)
+			assert.Nil(t, c.List(context.Background(), runs, client.MatchingLabels{v1alpha3.EnvironmentLabelKey: "staging"}))
+			assert.Len(t, runs.Items, 1)
+		})
+	}
+}
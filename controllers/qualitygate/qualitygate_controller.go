@@ -0,0 +1,243 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package qualitygate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	sonargo "github.com/kubesphere/sonargo/sonar"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/sonarqube"
+)
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelines,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// pollInterval is the interval of polling the quality gate of a completed PipelineRun
+const pollInterval = 30 * time.Second
+
+// QualityGateFailed is the reason of the PipelineRuns which failed due to their quality gates
+const QualityGateFailed = "QualityGateFailed"
+
+// Reconciler passes the SonarQube analysis parameters to the PipelineRuns whose Pipelines have a quality gate,
+// then records the quality gates of the completed PipelineRuns. The quality gates are either received from
+// the SonarQube webhook, or polled from SonarQube until the timeout.
+type Reconciler struct {
+	client.Client
+
+	// SonarQube polls the quality gates, they are only received from the webhook if it's nil
+	SonarQube sonarqube.QualityGateInterface
+	// Host is the address of SonarQube which is passed to the PipelineRuns
+	Host string
+
+	recorder record.EventRecorder
+	now      func() time.Time
+}
+
+// Reconcile passes the analysis parameters to, or records the quality gate of a PipelineRun
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	pipelineRun := &v1alpha3.PipelineRun{}
+	if err = r.Get(ctx, req.NamespacedName, pipelineRun); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	if !pipelineRun.DeletionTimestamp.IsZero() || pipelineRun.Spec.PipelineRef == nil {
+		return
+	}
+
+	pipeline := &v1alpha3.Pipeline{}
+	if err = r.Get(ctx, client.ObjectKey{Namespace: pipelineRun.Namespace, Name: pipelineRun.Spec.PipelineRef.Name}, pipeline); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	policy := pipeline.Spec.QualityGate
+	if policy == nil {
+		return
+	}
+
+	if _, injected := pipelineRun.Annotations[v1alpha3.PipelineRunQualityGateAnnoKey]; !injected {
+		// it's too late to pass the parameters to a started PipelineRun
+		if !pipelineRun.HasStarted() && !pipelineRun.HasCompleted() {
+			err = r.injectParameters(ctx, policy, pipelineRun)
+		}
+		return
+	}
+	if !pipelineRun.HasCompleted() {
+		return
+	}
+
+	status := pipelineRun.Status.DeepCopy()
+	if status.QualityGate == nil {
+		if status.QualityGate, err = r.pollQualityGate(policy, pipelineRun); err != nil {
+			r.recorder.Eventf(pipelineRun, v1.EventTypeWarning, "QualityGatePollFailed", "Failed to poll the quality gate, error was %v", err)
+			return
+		}
+		if status.QualityGate == nil {
+			if deadline := status.CompletionTime.Add(policy.GetTimeout()); r.getNow().Before(deadline) {
+				result.RequeueAfter = minDuration(pollInterval, deadline.Sub(r.getNow()))
+				return
+			}
+			r.recorder.Eventf(pipelineRun, v1.EventTypeWarning, "QualityGateTimeout",
+				"No analysis of the project %s was found in %v", policy.ProjectKey, policy.GetTimeout())
+			status.QualityGate = &v1alpha3.QualityGateStatus{ProjectKey: policy.ProjectKey, Status: v1alpha3.QualityGateNone}
+		}
+		status.QualityGate.UpdateTime = &metav1.Time{Time: r.getNow()}
+	}
+	if status.QualityGate.URL == "" && r.Host != "" {
+		status.QualityGate.URL = fmt.Sprintf("%s/dashboard?id=%s", strings.TrimSuffix(r.Host, "/"), status.QualityGate.ProjectKey)
+	}
+	if status.QualityGate.Metrics == nil && status.QualityGate.Status != v1alpha3.QualityGateNone && r.SonarQube != nil {
+		if status.QualityGate.Metrics, err = r.SonarQube.GetMeasures(status.QualityGate.ProjectKey, sonarqube.QualityGateMetricKeys); err != nil {
+			return
+		}
+	}
+
+	if policy.FailOnError && status.QualityGate.IsFailed() && status.Phase == v1alpha3.Succeeded {
+		r.failPipelineRun(status)
+		r.recorder.Eventf(pipelineRun, v1.EventTypeWarning, QualityGateFailed, "The quality gate of the project %s failed", policy.ProjectKey)
+	}
+	if !equality.Semantic.DeepEqual(status, &pipelineRun.Status) {
+		pipelineRun.Status = *status
+		err = r.Status().Update(ctx, pipelineRun)
+	}
+	return
+}
+
+// injectParameters passes the analysis parameters to the PipelineRun, the parameters given by the user take precedence
+func (r *Reconciler) injectParameters(ctx context.Context, policy *v1alpha3.QualityGatePolicy, pipelineRun *v1alpha3.PipelineRun) error {
+	parameters := []v1alpha3.Parameter{
+		{Name: v1alpha3.SonarProjectKeyParameter, Value: policy.ProjectKey},
+		{Name: v1alpha3.SonarAnalysisParamsParameter, Value: v1alpha3.GetSonarAnalysisParams(pipelineRun)},
+	}
+	if r.Host != "" {
+		parameters = append(parameters, v1alpha3.Parameter{Name: v1alpha3.SonarHostURLParameter, Value: r.Host})
+	}
+	for _, parameter := range parameters {
+		if !hasParameter(pipelineRun.Spec.Parameters, parameter.Name) {
+			pipelineRun.Spec.Parameters = append(pipelineRun.Spec.Parameters, parameter)
+		}
+	}
+	if pipelineRun.Annotations == nil {
+		pipelineRun.Annotations = map[string]string{}
+	}
+	pipelineRun.Annotations[v1alpha3.PipelineRunQualityGateAnnoKey] = policy.ProjectKey
+	return r.Update(ctx, pipelineRun)
+}
+
+// pollQualityGate returns the quality gate of the latest analysis of the project, it's nil if there is no analysis yet
+func (r *Reconciler) pollQualityGate(policy *v1alpha3.QualityGatePolicy, pipelineRun *v1alpha3.PipelineRun) (status *v1alpha3.QualityGateStatus, err error) {
+	if r.SonarQube == nil {
+		return
+	}
+	// the analysis which is done by the PipelineRun must be created after it started, it's not precise if
+	// there are concurrent PipelineRuns analyzing the same project, the webhook should be preferred then
+	since := pipelineRun.CreationTimestamp.Time
+	if pipelineRun.Status.StartTime != nil {
+		since = pipelineRun.Status.StartTime.Time
+	}
+	var analysisID string
+	if analysisID, err = r.SonarQube.GetLatestAnalysis(policy.ProjectKey, since); err != nil || analysisID == "" {
+		return
+	}
+	var projectStatus *sonargo.ProjectStatus
+	if projectStatus, err = r.SonarQube.GetQualityGate(analysisID); err != nil || projectStatus == nil {
+		return
+	}
+
+	status = &v1alpha3.QualityGateStatus{
+		ProjectKey: policy.ProjectKey,
+		AnalysisID: analysisID,
+		Status:     v1alpha3.QualityGateState(projectStatus.Status),
+	}
+	for _, condition := range projectStatus.Conditions {
+		status.Conditions = append(status.Conditions, v1alpha3.QualityGateCondition{
+			Metric:         condition.MetricKey,
+			Comparator:     condition.Comparator,
+			ErrorThreshold: condition.ErrorThreshold,
+			ActualValue:    condition.ActualValue,
+			Status:         v1alpha3.QualityGateState(condition.Status),
+		})
+	}
+	return
+}
+
+func (r *Reconciler) failPipelineRun(status *v1alpha3.PipelineRunStatus) {
+	now := metav1.NewTime(r.getNow())
+	status.Phase = v1alpha3.Failed
+	status.AddCondition(&v1alpha3.Condition{
+		Type:               v1alpha3.ConditionSucceeded,
+		Status:             v1alpha3.ConditionFalse,
+		Reason:             QualityGateFailed,
+		Message:            fmt.Sprintf("the quality gate of the project %s is %s", status.QualityGate.ProjectKey, status.QualityGate.Status),
+		LastProbeTime:      now,
+		LastTransitionTime: now,
+	})
+}
+
+func (r *Reconciler) getNow() time.Time {
+	if r.now != nil {
+		return r.now()
+	}
+	return time.Now()
+}
+
+func hasParameter(parameters []v1alpha3.Parameter, name string) bool {
+	for _, parameter := range parameters {
+		if parameter.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// GetName returns the name of this controller
+func (r *Reconciler) GetName() string {
+	return "qualitygate-controller"
+}
+
+// GetGroupName returns the group name of this controller
+func (r *Reconciler) GetGroupName() string {
+	return "qualitygate"
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.GetName()).
+		For(&v1alpha3.PipelineRun{}).
+		Complete(r)
+}
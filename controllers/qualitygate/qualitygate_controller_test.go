@@ -0,0 +1,226 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package qualitygate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sonargo "github.com/kubesphere/sonargo/sonar"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+type fakeSonarQube struct {
+	analysisID string
+	status     *sonargo.ProjectStatus
+	since      time.Time
+}
+
+func (s *fakeSonarQube) GetLatestAnalysis(_ string, since time.Time) (string, error) {
+	s.since = since
+	return s.analysisID, nil
+}
+
+func (s *fakeSonarQube) GetQualityGate(string) (*sonargo.ProjectStatus, error) {
+	return s.status, nil
+}
+
+func (s *fakeSonarQube) GetMeasures(string, string) (map[string]string, error) {
+	return map[string]string{"bugs": "3"}, nil
+}
+
+func TestReconcile(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	now := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	startTime := metav1.NewTime(now.Add(-10 * time.Minute))
+	newPipeline := func(policy *v1alpha3.QualityGatePolicy) *v1alpha3.Pipeline {
+		return &v1alpha3.Pipeline{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "build"},
+			Spec:       v1alpha3.PipelineSpec{Type: v1alpha3.NoScmPipelineType, QualityGate: policy},
+		}
+	}
+	policy := &v1alpha3.QualityGatePolicy{ProjectKey: "app", FailOnError: true}
+	completedRun := func(completion time.Time, qualityGate *v1alpha3.QualityGateStatus) *v1alpha3.PipelineRun {
+		return &v1alpha3.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "build-1", Annotations: map[string]string{
+				v1alpha3.PipelineRunQualityGateAnnoKey: "app",
+				v1alpha3.JenkinsPipelineRunIDAnnoKey:   "1",
+			}},
+			Spec: v1alpha3.PipelineRunSpec{PipelineRef: &v1.ObjectReference{Name: "build"}},
+			Status: v1alpha3.PipelineRunStatus{
+				Phase:          v1alpha3.Succeeded,
+				StartTime:      &startTime,
+				CompletionTime: &metav1.Time{Time: completion},
+				QualityGate:    qualityGate,
+			},
+		}
+	}
+	errorGate := &sonargo.ProjectStatus{Status: "ERROR", Conditions: []*sonargo.Condition{{
+		Status: "ERROR", MetricKey: "new_coverage", Comparator: "LT", ErrorThreshold: "80", ActualValue: "62.5",
+	}}}
+
+	tests := []struct {
+		name        string
+		pipeline    *v1alpha3.Pipeline
+		pipelineRun *v1alpha3.PipelineRun
+		sonar       *fakeSonarQube
+		verify      func(t *testing.T, pipelineRun *v1alpha3.PipelineRun, result ctrl.Result, sonar *fakeSonarQube)
+	}{{
+		name:     "without the quality gate",
+		pipeline: newPipeline(nil),
+		pipelineRun: &v1alpha3.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "build-1"},
+			Spec:       v1alpha3.PipelineRunSpec{PipelineRef: &v1.ObjectReference{Name: "build"}},
+		},
+		verify: func(t *testing.T, pipelineRun *v1alpha3.PipelineRun, _ ctrl.Result, _ *fakeSonarQube) {
+			assert.Empty(t, pipelineRun.Annotations)
+			assert.Empty(t, pipelineRun.Spec.Parameters)
+		},
+	}, {
+		name:     "inject the parameters",
+		pipeline: newPipeline(policy),
+		pipelineRun: &v1alpha3.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "build-1"},
+			Spec: v1alpha3.PipelineRunSpec{
+				PipelineRef: &v1.ObjectReference{Name: "build"},
+				Parameters:  []v1alpha3.Parameter{{Name: v1alpha3.SonarProjectKeyParameter, Value: "custom"}},
+			},
+		},
+		verify: func(t *testing.T, pipelineRun *v1alpha3.PipelineRun, _ ctrl.Result, _ *fakeSonarQube) {
+			assert.Equal(t, "app", pipelineRun.Annotations[v1alpha3.PipelineRunQualityGateAnnoKey])
+			assert.Equal(t, []v1alpha3.Parameter{
+				{Name: v1alpha3.SonarProjectKeyParameter, Value: "custom"},
+				{Name: v1alpha3.SonarAnalysisParamsParameter, Value: "-Dsonar.analysis.pipelineRun=ns/build-1"},
+				{Name: v1alpha3.SonarHostURLParameter, Value: "https://sonar.example.com"},
+			}, pipelineRun.Spec.Parameters)
+		},
+	}, {
+		name:     "too late to inject the parameters",
+		pipeline: newPipeline(policy),
+		pipelineRun: &v1alpha3.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "build-1", Annotations: map[string]string{
+				v1alpha3.JenkinsPipelineRunIDAnnoKey: "1",
+			}},
+			Spec: v1alpha3.PipelineRunSpec{PipelineRef: &v1.ObjectReference{Name: "build"}},
+		},
+		verify: func(t *testing.T, pipelineRun *v1alpha3.PipelineRun, _ ctrl.Result, _ *fakeSonarQube) {
+			assert.NotContains(t, pipelineRun.Annotations, v1alpha3.PipelineRunQualityGateAnnoKey)
+			assert.Empty(t, pipelineRun.Spec.Parameters)
+		},
+	}, {
+		name:        "wait for the analysis",
+		pipeline:    newPipeline(policy),
+		pipelineRun: completedRun(now.Add(-time.Minute), nil),
+		sonar:       &fakeSonarQube{},
+		verify: func(t *testing.T, pipelineRun *v1alpha3.PipelineRun, result ctrl.Result, sonar *fakeSonarQube) {
+			assert.Nil(t, pipelineRun.Status.QualityGate)
+			assert.Equal(t, pollInterval, result.RequeueAfter)
+			assert.True(t, startTime.Time.Equal(sonar.since))
+		},
+	}, {
+		name:        "no analysis before the timeout",
+		pipeline:    newPipeline(policy),
+		pipelineRun: completedRun(now.Add(-time.Hour), nil),
+		sonar:       &fakeSonarQube{},
+		verify: func(t *testing.T, pipelineRun *v1alpha3.PipelineRun, result ctrl.Result, _ *fakeSonarQube) {
+			assert.Zero(t, result.RequeueAfter)
+			assert.Equal(t, v1alpha3.QualityGateNone, pipelineRun.Status.QualityGate.Status)
+			assert.Nil(t, pipelineRun.Status.QualityGate.Metrics)
+			assert.Equal(t, v1alpha3.Succeeded, pipelineRun.Status.Phase)
+		},
+	}, {
+		name:        "fail the PipelineRun due to the polled quality gate",
+		pipeline:    newPipeline(policy),
+		pipelineRun: completedRun(now.Add(-time.Minute), nil),
+		sonar:       &fakeSonarQube{analysisID: "analysis-1", status: errorGate},
+		verify: func(t *testing.T, pipelineRun *v1alpha3.PipelineRun, _ ctrl.Result, _ *fakeSonarQube) {
+			qualityGate := pipelineRun.Status.QualityGate
+			assert.Equal(t, "analysis-1", qualityGate.AnalysisID)
+			assert.Equal(t, v1alpha3.QualityGateError, qualityGate.Status)
+			assert.Equal(t, []v1alpha3.QualityGateCondition{{
+				Metric: "new_coverage", Comparator: "LT", ErrorThreshold: "80", ActualValue: "62.5", Status: v1alpha3.QualityGateError,
+			}}, qualityGate.Conditions)
+			assert.Equal(t, map[string]string{"bugs": "3"}, qualityGate.Metrics)
+			assert.Equal(t, "https://sonar.example.com/dashboard?id=app", qualityGate.URL)
+			assert.Equal(t, v1alpha3.Failed, pipelineRun.Status.Phase)
+			condition := pipelineRun.Status.GetCondition(v1alpha3.ConditionSucceeded)
+			if assert.NotNil(t, condition) {
+				assert.Equal(t, QualityGateFailed, condition.Reason)
+			}
+		},
+	}, {
+		name:     "keep the PipelineRun succeeded if the quality gate is optional",
+		pipeline: newPipeline(&v1alpha3.QualityGatePolicy{ProjectKey: "app"}),
+		pipelineRun: completedRun(now.Add(-time.Minute), &v1alpha3.QualityGateStatus{
+			ProjectKey: "app", Status: v1alpha3.QualityGateError, URL: "https://sonar.example.com/dashboard?id=app",
+		}),
+		sonar: &fakeSonarQube{},
+		verify: func(t *testing.T, pipelineRun *v1alpha3.PipelineRun, _ ctrl.Result, _ *fakeSonarQube) {
+			assert.Equal(t, map[string]string{"bugs": "3"}, pipelineRun.Status.QualityGate.Metrics)
+			assert.Equal(t, v1alpha3.Succeeded, pipelineRun.Status.Phase)
+		},
+	}, {
+		name:     "the quality gate received from the webhook without the SonarQube client",
+		pipeline: newPipeline(policy),
+		pipelineRun: completedRun(now.Add(-time.Minute), &v1alpha3.QualityGateStatus{
+			ProjectKey: "app", TaskID: "task-1", Status: v1alpha3.QualityGateError,
+		}),
+		verify: func(t *testing.T, pipelineRun *v1alpha3.PipelineRun, _ ctrl.Result, _ *fakeSonarQube) {
+			assert.Nil(t, pipelineRun.Status.QualityGate.Metrics)
+			assert.Equal(t, v1alpha3.Failed, pipelineRun.Status.Phase)
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(tt.pipeline.DeepCopy(), tt.pipelineRun.DeepCopy()).Build()
+			r := &Reconciler{
+				Client:   c,
+				Host:     "https://sonar.example.com",
+				recorder: &record.FakeRecorder{},
+				now:      func() time.Time { return now },
+			}
+			if tt.sonar != nil {
+				r.SonarQube = tt.sonar
+			}
+			key := client.ObjectKeyFromObject(tt.pipelineRun)
+			result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+			assert.Nil(t, err)
+
+			pipelineRun := &v1alpha3.PipelineRun{}
+			assert.Nil(t, c.Get(context.Background(), key, pipelineRun))
+			tt.verify(t, pipelineRun, result, tt.sonar)
+		})
+	}
+}
+
+func TestGetName(t *testing.T) {
+	r := &Reconciler{}
+	assert.Equal(t, "qualitygate-controller", r.GetName())
+	assert.Equal(t, "qualitygate", r.GetGroupName())
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reaper
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// The reasons of the stuck agent pods, the waiting reasons of the containers are taken as well
+const (
+	reasonEvicted        = "Evicted"
+	reasonPendingTimeout = "PendingTimeout"
+)
+
+// the waiting reasons of the containers which never recover
+var fatalWaitingReasons = map[string]bool{
+	"InvalidImageName":  true,
+	"ErrImageNeverPull": true,
+}
+
+// the waiting reasons of the containers which keep pulling the images in a loop
+var pullingWaitingReasons = map[string]bool{
+	"ErrImagePull":     true,
+	"ImagePullBackOff": true,
+}
+
+// diagnosis is why an agent pod is stuck
+type diagnosis struct {
+	reason  string
+	message string
+}
+
+// diagnose returns the diagnosis if the pod is stuck, otherwise it returns the duration after which the pod
+// should be checked again, it's zero if the pod is not pending.
+func diagnose(pod *v1.Pod, pendingTimeout time.Duration, now time.Time) (result *diagnosis, recheckAfter time.Duration) {
+	if pod.Status.Phase == v1.PodFailed && pod.Status.Reason == reasonEvicted {
+		result = &diagnosis{reason: reasonEvicted, message: fmt.Sprintf("pod %s was evicted: %s", pod.Name, pod.Status.Message)}
+		return
+	}
+	if pod.Status.Phase != v1.PodPending {
+		return
+	}
+
+	waiting := getWaitingContainer(pod, fatalWaitingReasons)
+	if waiting != nil {
+		result = newWaitingDiagnosis(pod, waiting)
+		return
+	}
+	pending := now.Sub(pod.CreationTimestamp.Time)
+	if pending < pendingTimeout {
+		recheckAfter = pendingTimeout - pending
+		return
+	}
+
+	if waiting = getWaitingContainer(pod, pullingWaitingReasons); waiting != nil {
+		result = newWaitingDiagnosis(pod, waiting)
+		return
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodScheduled && condition.Status == v1.ConditionFalse && condition.Reason != "" {
+			result = &diagnosis{reason: condition.Reason,
+				message: fmt.Sprintf("pod %s cannot be scheduled for %s: %s", pod.Name, pending.Round(time.Second), condition.Message)}
+			return
+		}
+	}
+	result = &diagnosis{reason: reasonPendingTimeout,
+		message: fmt.Sprintf("pod %s has been pending for %s", pod.Name, pending.Round(time.Second))}
+	return
+}
+
+func getWaitingContainer(pod *v1.Pod, reasons map[string]bool) *v1.ContainerStatus {
+	statuses := append(append([]v1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for i := range statuses {
+		if waiting := statuses[i].State.Waiting; waiting != nil && reasons[waiting.Reason] {
+			return &statuses[i]
+		}
+	}
+	return nil
+}
+
+func newWaitingDiagnosis(pod *v1.Pod, status *v1.ContainerStatus) *diagnosis {
+	return &diagnosis{reason: status.State.Waiting.Reason,
+		message: strings.TrimSpace(fmt.Sprintf("container %s of pod %s: %s %s",
+			status.Name, pod.Name, status.State.Waiting.Reason, status.State.Waiting.Message))}
+}
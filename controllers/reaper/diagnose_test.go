@@ -0,0 +1,101 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reaper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newPod(phase v1.PodPhase, created time.Time) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "agent", CreationTimestamp: metav1.NewTime(created)},
+		Status:     v1.PodStatus{Phase: phase},
+	}
+}
+
+func waitingStatus(name, reason string) v1.ContainerStatus {
+	return v1.ContainerStatus{Name: name, State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: reason}}}
+}
+
+func Test_diagnose(t *testing.T) {
+	now := time.Date(2022, 1, 1, 1, 0, 0, 0, time.UTC)
+	timeout := 10 * time.Minute
+
+	evicted := newPod(v1.PodFailed, now.Add(-time.Hour))
+	evicted.Status.Reason = "Evicted"
+	evicted.Status.Message = "The node was low on resource: memory."
+	invalidImage := newPod(v1.PodPending, now.Add(-time.Minute))
+	invalidImage.Status.ContainerStatuses = []v1.ContainerStatus{waitingStatus("jnlp", "ContainerCreating"),
+		waitingStatus("maven", "InvalidImageName")}
+	pullingNew := newPod(v1.PodPending, now.Add(-time.Minute))
+	pullingNew.Status.InitContainerStatuses = []v1.ContainerStatus{waitingStatus("init", "ImagePullBackOff")}
+	pullingOld := pullingNew.DeepCopy()
+	pullingOld.CreationTimestamp = metav1.NewTime(now.Add(-time.Hour))
+	unschedulable := newPod(v1.PodPending, now.Add(-time.Hour))
+	unschedulable.Status.Conditions = []v1.PodCondition{{
+		Type:    v1.PodScheduled,
+		Status:  v1.ConditionFalse,
+		Reason:  "Unschedulable",
+		Message: "0/3 nodes are available",
+	}}
+
+	tests := []struct {
+		name         string
+		pod          *v1.Pod
+		expect       *diagnosis
+		recheckAfter time.Duration
+	}{{
+		name: "running",
+		pod:  newPod(v1.PodRunning, now.Add(-time.Hour)),
+	}, {
+		name:   "evicted",
+		pod:    evicted,
+		expect: &diagnosis{reason: "Evicted", message: "pod agent was evicted: The node was low on resource: memory."},
+	}, {
+		name:   "invalid image",
+		pod:    invalidImage,
+		expect: &diagnosis{reason: "InvalidImageName", message: "container maven of pod agent: InvalidImageName"},
+	}, {
+		name:         "pulling image shortly",
+		pod:          pullingNew,
+		recheckAfter: 9 * time.Minute,
+	}, {
+		name:   "pulling image for too long",
+		pod:    pullingOld,
+		expect: &diagnosis{reason: "ImagePullBackOff", message: "container init of pod agent: ImagePullBackOff"},
+	}, {
+		name:   "unschedulable",
+		pod:    unschedulable,
+		expect: &diagnosis{reason: "Unschedulable", message: "pod agent cannot be scheduled for 1h0m0s: 0/3 nodes are available"},
+	}, {
+		name:   "pending for too long",
+		pod:    newPod(v1.PodPending, now.Add(-time.Hour)),
+		expect: &diagnosis{reason: "PendingTimeout", message: "pod agent has been pending for 1h0m0s"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, recheckAfter := diagnose(tt.pod, timeout, now)
+			assert.Equal(t, tt.expect, result)
+			assert.Equal(t, tt.recheckAfter, recheckAfter)
+		})
+	}
+}
@@ -0,0 +1,246 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reaper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/devops"
+	"kubesphere.io/devops/pkg/models/usage"
+)
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelines,verbs=get;list;watch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;delete
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// reasonRecovered is the reason of the Stuck condition once the agent pod is running again
+const reasonRecovered = "Recovered"
+
+// BuildStopper stops the Jenkins builds, it's implemented by devops.Interface
+type BuildStopper interface {
+	StopPipeline(projectName, pipelineName, runId string, httpParameters *devops.HttpParameters) (*devops.StopPipeline, error)
+	StopBranchPipeline(projectName, pipelineName, branchName, runId string, httpParameters *devops.HttpParameters) (*devops.StopPipeline, error)
+}
+
+// Reconciler watches the Jenkins agent pods, and deals with the PipelineRuns whose agent pods are stuck by the
+// StuckRunPolicy of their Pipelines. Otherwise, such PipelineRuns would wait for the agents forever.
+type Reconciler struct {
+	client.Client
+
+	// JenkinsClient stops the builds of the failed PipelineRuns, the builds are left as they are if it's nil
+	JenkinsClient BuildStopper
+	// Now returns the current time, time.Now will be used if it's nil
+	Now func() time.Time
+
+	recorder record.EventRecorder
+}
+
+// Reconcile diagnoses an agent pod, then retries, fails or escalates its PipelineRun if the pod is stuck
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	pod := &v1.Pod{}
+	if err = r.Get(ctx, req.NamespacedName, pod); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	ref, ok := usage.ParseRunURL(pod.Annotations[usage.RunURLAnnoKey])
+	if !ok || !pod.DeletionTimestamp.IsZero() {
+		return
+	}
+
+	var pipelineRun *v1alpha3.PipelineRun
+	if pipelineRun, err = usage.FindPipelineRun(ctx, r.Client, ref); err != nil || pipelineRun == nil ||
+		pipelineRun.HasCompleted() {
+		return
+	}
+	pipeline := &v1alpha3.Pipeline{}
+	if err = r.Get(ctx, client.ObjectKey{Namespace: ref.Project, Name: ref.Pipeline}, pipeline); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	policy := pipeline.Spec.StuckRun
+
+	now := time.Now
+	if r.Now != nil {
+		now = r.Now
+	}
+	stuck, recheckAfter := diagnose(pod, policy.GetPendingTimeout(), now())
+	if stuck == nil {
+		if pod.Status.Phase == v1.PodRunning {
+			err = r.recover(ctx, pipelineRun)
+		}
+		result.RequeueAfter = recheckAfter
+		return
+	}
+
+	switch policy.GetAction() {
+	case v1alpha3.StuckRunNotify:
+		err = r.escalate(ctx, pipelineRun, stuck)
+	case v1alpha3.StuckRunRetry:
+		retries, _ := strconv.Atoi(pipelineRun.Annotations[v1alpha3.PipelineRunStuckRetriesAnnoKey])
+		if int32(retries) < policy.GetMaxRetries() {
+			err = r.retry(ctx, pipelineRun, pod, stuck, retries+1)
+			break
+		}
+		err = r.fail(ctx, pipelineRun, pod, ref, stuck)
+	default:
+		err = r.fail(ctx, pipelineRun, pod, ref, stuck)
+	}
+	return
+}
+
+// escalate marks the PipelineRun as stuck, then the notifier sends it through the Notifications
+func (r *Reconciler) escalate(ctx context.Context, pipelineRun *v1alpha3.PipelineRun, stuck *diagnosis) error {
+	if condition := pipelineRun.Status.GetCondition(v1alpha3.ConditionStuck); condition != nil &&
+		condition.Status == v1alpha3.ConditionTrue {
+		return nil
+	}
+	r.markStuck(&pipelineRun.Status, v1alpha3.ConditionTrue, stuck.reason, stuck.message)
+	if err := r.Status().Update(ctx, pipelineRun); err != nil {
+		return err
+	}
+	r.recorder.Eventf(pipelineRun, v1.EventTypeWarning, v1alpha3.Stuck, "The agent is stuck, %s", stuck.message)
+	return nil
+}
+
+// retry deletes the stuck pod, then the Kubernetes plugin of Jenkins provisions another agent
+func (r *Reconciler) retry(ctx context.Context, pipelineRun *v1alpha3.PipelineRun, pod *v1.Pod, stuck *diagnosis,
+	retries int) error {
+	if pipelineRun.Annotations == nil {
+		pipelineRun.Annotations = map[string]string{}
+	}
+	pipelineRun.Annotations[v1alpha3.PipelineRunStuckRetriesAnnoKey] = strconv.Itoa(retries)
+	if err := r.Update(ctx, pipelineRun); err != nil {
+		return err
+	}
+	if err := r.Delete(ctx, pod); client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	r.recorder.Eventf(pipelineRun, v1.EventTypeWarning, v1alpha3.Stuck, "Retried the agent for the %d time, %s",
+		retries, stuck.message)
+	return nil
+}
+
+// fail stops the build, marks the PipelineRun as failed, then deletes the stuck pod
+func (r *Reconciler) fail(ctx context.Context, pipelineRun *v1alpha3.PipelineRun, pod *v1.Pod, ref *usage.RunRef,
+	stuck *diagnosis) error {
+	if err := r.stopBuild(ref); err != nil {
+		// the PipelineRun is failed anyway, otherwise it would hang forever
+		r.recorder.Eventf(pipelineRun, v1.EventTypeWarning, v1alpha3.Stuck, "Failed to stop the Jenkins build, error was %v", err)
+	}
+
+	now := metav1.Now()
+	status := &pipelineRun.Status
+	r.markStuck(status, v1alpha3.ConditionTrue, stuck.reason, stuck.message)
+	status.AddCondition(&v1alpha3.Condition{
+		Type:               v1alpha3.ConditionSucceeded,
+		Status:             v1alpha3.ConditionFalse,
+		Reason:             v1alpha3.Stuck,
+		Message:            stuck.message,
+		LastTransitionTime: now,
+		LastProbeTime:      now,
+	})
+	status.Phase = v1alpha3.Failed
+	status.CompletionTime = &now
+	if err := r.Status().Update(ctx, pipelineRun); err != nil {
+		return err
+	}
+	r.recorder.Eventf(pipelineRun, v1.EventTypeWarning, v1alpha3.Stuck, "Failed the PipelineRun because %s", stuck.message)
+	return client.IgnoreNotFound(r.Delete(ctx, pod))
+}
+
+// recover marks the PipelineRun as not stuck once its agent pod is running
+func (r *Reconciler) recover(ctx context.Context, pipelineRun *v1alpha3.PipelineRun) error {
+	if condition := pipelineRun.Status.GetCondition(v1alpha3.ConditionStuck); condition == nil ||
+		condition.Status != v1alpha3.ConditionTrue {
+		return nil
+	}
+	r.markStuck(&pipelineRun.Status, v1alpha3.ConditionFalse, reasonRecovered, "the agent is running")
+	return r.Status().Update(ctx, pipelineRun)
+}
+
+func (r *Reconciler) markStuck(status *v1alpha3.PipelineRunStatus, conditionStatus v1alpha3.ConditionStatus,
+	reason, message string) {
+	now := metav1.Now()
+	status.AddCondition(&v1alpha3.Condition{
+		Type:               v1alpha3.ConditionStuck,
+		Status:             conditionStatus,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: now,
+		LastProbeTime:      now,
+	})
+	status.UpdateTime = &now
+}
+
+func (r *Reconciler) stopBuild(ref *usage.RunRef) (err error) {
+	if r.JenkinsClient == nil {
+		return
+	}
+	params := &devops.HttpParameters{
+		Method: http.MethodPut,
+		Url:    &url.URL{RawQuery: "blocking=true&timeOutInSecs=10"},
+	}
+	if ref.Branch == "" {
+		_, err = r.JenkinsClient.StopPipeline(ref.Project, ref.Pipeline, ref.RunID, params)
+	} else {
+		// the branch names are encoded as the job names of Jenkins, then encoded in the URL again
+		_, err = r.JenkinsClient.StopBranchPipeline(ref.Project, ref.Pipeline, url.PathEscape(url.PathEscape(ref.Branch)),
+			ref.RunID, params)
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to stop the build %s of %s/%s: %v", ref.RunID, ref.Project, ref.Pipeline, err)
+	}
+	return
+}
+
+// GetName returns the name of this controller
+func (r *Reconciler) GetName() string {
+	return "stuck-run-reaper"
+}
+
+// GetGroupName returns the group name of this controller
+func (r *Reconciler) GetGroupName() string {
+	return "reaper"
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.GetName()).
+		For(&v1.Pod{}, builder.WithPredicates(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+			_, ok := obj.GetAnnotations()[usage.RunURLAnnoKey]
+			return ok
+		}))).
+		Complete(r)
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reaper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/devops"
+	"kubesphere.io/devops/pkg/models/usage"
+)
+
+type fakeStopper struct {
+	stopped []string
+	err     error
+}
+
+func (s *fakeStopper) StopPipeline(projectName, pipelineName, runID string, _ *devops.HttpParameters) (*devops.StopPipeline, error) {
+	s.stopped = append(s.stopped, projectName+"/"+pipelineName+"/"+runID)
+	return &devops.StopPipeline{}, s.err
+}
+
+func (s *fakeStopper) StopBranchPipeline(projectName, pipelineName, branchName, runID string, _ *devops.HttpParameters) (*devops.StopPipeline, error) {
+	s.stopped = append(s.stopped, projectName+"/"+pipelineName+"/"+branchName+"/"+runID)
+	return &devops.StopPipeline{}, s.err
+}
+
+func TestReconcile(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+	assert.Nil(t, v1.AddToScheme(schema))
+
+	now := time.Date(2022, 1, 1, 1, 0, 0, 0, time.UTC)
+	newAgent := func(phase v1.PodPhase, created time.Time) *v1.Pod {
+		pod := newPod(phase, created)
+		pod.Namespace = v1alpha3.DefaultAgentNamespace
+		pod.Annotations = map[string]string{usage.RunURLAnnoKey: "job/project/job/build/1/"}
+		return pod
+	}
+	newPipeline := func(policy *v1alpha3.StuckRunPolicy) *v1alpha3.Pipeline {
+		return &v1alpha3.Pipeline{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "project", Name: "build"},
+			Spec:       v1alpha3.PipelineSpec{Type: v1alpha3.NoScmPipelineType, StuckRun: policy},
+		}
+	}
+	newPipelineRun := func(annotations map[string]string, stuck v1alpha3.ConditionStatus) *v1alpha3.PipelineRun {
+		pipelineRun := &v1alpha3.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "project",
+				Name:        "build-1",
+				Labels:      map[string]string{v1alpha3.PipelineNameLabelKey: "build"},
+				Annotations: map[string]string{v1alpha3.JenkinsPipelineRunIDAnnoKey: "1"},
+			},
+			Status: v1alpha3.PipelineRunStatus{Phase: v1alpha3.Running},
+		}
+		for key, value := range annotations {
+			pipelineRun.Annotations[key] = value
+		}
+		if stuck != "" {
+			pipelineRun.Status.AddCondition(&v1alpha3.Condition{Type: v1alpha3.ConditionStuck, Status: stuck})
+		}
+		return pipelineRun
+	}
+	pending := newAgent(v1.PodPending, now.Add(-time.Hour))
+	notify := &v1alpha3.StuckRunPolicy{Action: v1alpha3.StuckRunNotify}
+	retry := &v1alpha3.StuckRunPolicy{Action: v1alpha3.StuckRunRetry, MaxRetries: 2}
+
+	tests := []struct {
+		name         string
+		objects      []client.Object
+		stopErr      error
+		expectResult ctrl.Result
+		expectPhase  v1alpha3.RunPhase
+		expectStuck  v1alpha3.ConditionStatus
+		expectRetry  string
+		expectPod    bool
+		expectStops  int
+	}{{
+		name:         "pending shortly",
+		objects:      []client.Object{newAgent(v1.PodPending, now.Add(-time.Minute)), newPipeline(nil), newPipelineRun(nil, "")},
+		expectResult: ctrl.Result{RequeueAfter: 29 * time.Minute},
+		expectPhase:  v1alpha3.Running,
+		expectPod:    true,
+	}, {
+		name:        "fail by default",
+		objects:     []client.Object{pending.DeepCopy(), newPipeline(nil), newPipelineRun(nil, "")},
+		expectPhase: v1alpha3.Failed,
+		expectStuck: v1alpha3.ConditionTrue,
+		expectStops: 1,
+	}, {
+		name:        "fail even if the build cannot be stopped",
+		objects:     []client.Object{pending.DeepCopy(), newPipeline(nil), newPipelineRun(nil, "")},
+		stopErr:     errors.New("fake"),
+		expectPhase: v1alpha3.Failed,
+		expectStuck: v1alpha3.ConditionTrue,
+		expectStops: 1,
+	}, {
+		name:        "notify",
+		objects:     []client.Object{pending.DeepCopy(), newPipeline(notify), newPipelineRun(nil, "")},
+		expectPhase: v1alpha3.Running,
+		expectStuck: v1alpha3.ConditionTrue,
+		expectPod:   true,
+	}, {
+		name:        "retry",
+		objects:     []client.Object{pending.DeepCopy(), newPipeline(retry), newPipelineRun(nil, "")},
+		expectPhase: v1alpha3.Running,
+		expectRetry: "1",
+	}, {
+		name: "fail after retries",
+		objects: []client.Object{pending.DeepCopy(), newPipeline(retry),
+			newPipelineRun(map[string]string{v1alpha3.PipelineRunStuckRetriesAnnoKey: "2"}, "")},
+		expectPhase: v1alpha3.Failed,
+		expectStuck: v1alpha3.ConditionTrue,
+		expectRetry: "2",
+		expectStops: 1,
+	}, {
+		name:        "recovered",
+		objects:     []client.Object{newAgent(v1.PodRunning, now.Add(-time.Hour)), newPipeline(notify), newPipelineRun(nil, v1alpha3.ConditionTrue)},
+		expectPhase: v1alpha3.Running,
+		expectStuck: v1alpha3.ConditionFalse,
+		expectPod:   true,
+	}, {
+		name: "completed PipelineRun",
+		objects: []client.Object{pending.DeepCopy(), newPipeline(nil), func() *v1alpha3.PipelineRun {
+			pipelineRun := newPipelineRun(nil, "")
+			pipelineRun.Status.Phase = v1alpha3.Succeeded
+			pipelineRun.Status.CompletionTime = &metav1.Time{Time: now}
+			return pipelineRun
+		}()},
+		expectPhase: v1alpha3.Succeeded,
+		expectPod:   true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(tt.objects...).Build()
+			stopper := &fakeStopper{err: tt.stopErr}
+			r := &Reconciler{
+				Client:        c,
+				JenkinsClient: stopper,
+				Now:           func() time.Time { return now },
+				recorder:      record.NewFakeRecorder(10),
+			}
+
+			key := client.ObjectKey{Namespace: v1alpha3.DefaultAgentNamespace, Name: "agent"}
+			result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expectResult, result)
+			assert.Equal(t, tt.expectStops, len(stopper.stopped))
+
+			err = c.Get(context.Background(), key, &v1.Pod{})
+			assert.Equal(t, tt.expectPod, err == nil)
+			if !tt.expectPod {
+				assert.True(t, apierrors.IsNotFound(err))
+			}
+
+			pipelineRun := &v1alpha3.PipelineRun{}
+			assert.Nil(t, c.Get(context.Background(), client.ObjectKey{Namespace: "project", Name: "build-1"}, pipelineRun))
+			assert.Equal(t, tt.expectPhase, pipelineRun.Status.Phase)
+			assert.Equal(t, tt.expectRetry, pipelineRun.Annotations[v1alpha3.PipelineRunStuckRetriesAnnoKey])
+			var stuck v1alpha3.ConditionStatus
+			if condition := pipelineRun.Status.GetCondition(v1alpha3.ConditionStuck); condition != nil {
+				stuck = condition.Status
+			}
+			assert.Equal(t, tt.expectStuck, stuck)
+		})
+	}
+}
+
+func Test_stopBuild(t *testing.T) {
+	stopper := &fakeStopper{}
+	r := &Reconciler{JenkinsClient: stopper}
+	assert.Nil(t, r.stopBuild(&usage.RunRef{Project: "project", Pipeline: "build", Branch: "feat/a", RunID: "3"}))
+	assert.Equal(t, []string{"project/build/feat%252Fa/3"}, stopper.stopped)
+
+	stopper.err = errors.New("fake")
+	assert.NotNil(t, r.stopBuild(&usage.RunRef{Project: "project", Pipeline: "build", RunID: "3"}))
+
+	assert.Nil(t, (&Reconciler{}).stopBuild(&usage.RunRef{}))
+}
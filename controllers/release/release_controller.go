@@ -0,0 +1,243 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package release
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	goscm "github.com/jenkins-x/go-scm/scm"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"kubesphere.io/devops/controllers/gitrepository"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	scmclient "kubesphere.io/devops/pkg/client/scm"
+	"kubesphere.io/devops/pkg/models/release"
+)
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=releases,verbs=get;list;watch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=releases/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns;gitrepositories,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconciler collects the images and the changelog of the Releases once their PipelineRuns succeeded,
+// then publishes the release notes to the SCM provider if required.
+type Reconciler struct {
+	client.Client
+
+	// NewProvider creates the SCM provider, scmclient.NewProviderFromSecret will be used if it's nil
+	NewProvider gitrepository.ProviderFactory
+
+	recorder record.EventRecorder
+}
+
+// Reconcile makes the Release
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	rel := &v1alpha3.Release{}
+	if err = r.Get(ctx, req.NamespacedName, rel); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	if !rel.DeletionTimestamp.IsZero() || rel.HasCompleted() {
+		return
+	}
+
+	status := rel.Status.DeepCopy()
+	if status.Phase != v1alpha3.ReleaseReady {
+		if err = r.collect(ctx, rel, status); err != nil {
+			return
+		}
+	}
+	if status.Phase == v1alpha3.ReleaseReady && rel.Spec.Publish {
+		err = r.publish(ctx, rel, status)
+	}
+	if updateErr := r.updateStatus(ctx, rel, status); err == nil {
+		err = updateErr
+	}
+	return
+}
+
+// collect waits for the PipelineRuns, then collects the images and the changelog.
+// The Release fails if any PipelineRun did not succeed.
+func (r *Reconciler) collect(ctx context.Context, rel *v1alpha3.Release, status *v1alpha3.ReleaseStatus) error {
+	pipelineRuns := make([]v1alpha3.PipelineRun, 0, len(rel.Spec.PipelineRuns))
+	for _, name := range rel.Spec.PipelineRuns {
+		pipelineRun := v1alpha3.PipelineRun{}
+		if err := r.Get(ctx, client.ObjectKey{Namespace: rel.Namespace, Name: name}, &pipelineRun); err != nil {
+			if apierrors.IsNotFound(err) {
+				r.fail(rel, status, "PipelineRunNotFound", fmt.Sprintf("PipelineRun %s not found", name))
+				return nil
+			}
+			return err
+		}
+		switch {
+		case !pipelineRun.HasCompleted():
+			status.Phase = v1alpha3.ReleasePending
+			status.Message = fmt.Sprintf("waiting for PipelineRun %s", name)
+			return nil
+		case pipelineRun.Status.Phase != v1alpha3.Succeeded:
+			r.fail(rel, status, "PipelineRunFailed", fmt.Sprintf("PipelineRun %s did not succeed", name))
+			return nil
+		}
+		pipelineRuns = append(pipelineRuns, pipelineRun)
+	}
+	status.Images = release.CollectImages(pipelineRuns)
+
+	repo := &v1alpha3.GitRepository{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: rel.Namespace, Name: rel.Spec.GitRepository}, repo); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.fail(rel, status, "GitRepositoryNotFound", fmt.Sprintf("GitRepository %s not found", rel.Spec.GitRepository))
+			return nil
+		}
+		return err
+	}
+	provider, repoPath, err := r.getProvider(repo)
+	if err != nil {
+		return err
+	}
+
+	// the tag might not exist until the release is published, take the commitish instead
+	changelog, err := release.GenerateChangelog(ctx, provider, repoPath, rel.Spec.Tag, rel.Spec.PreviousTag)
+	if err != nil && rel.Spec.Commitish != "" {
+		changelog, err = release.GenerateChangelog(ctx, provider, repoPath, rel.Spec.Commitish, rel.Spec.PreviousTag)
+	}
+	if err != nil {
+		r.recorder.Eventf(rel, v1.EventTypeWarning, "ChangelogFailed", "Failed to generate the changelog, error was %v", err)
+		return err
+	}
+	status.Changelog = changelog
+	status.Phase = v1alpha3.ReleaseReady
+	status.Message = ""
+	status.Notes = release.RenderNotes(&v1alpha3.Release{Spec: rel.Spec, Status: *status})
+	return nil
+}
+
+// publish creates the release with the notes on the SCM provider
+func (r *Reconciler) publish(ctx context.Context, rel *v1alpha3.Release, status *v1alpha3.ReleaseStatus) error {
+	repo := &v1alpha3.GitRepository{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: rel.Namespace, Name: rel.Spec.GitRepository}, repo); err != nil {
+		return err
+	}
+	provider, repoPath, err := r.getProvider(repo)
+	if err != nil {
+		return err
+	}
+
+	published, err := provider.CreateRelease(ctx, repoPath, &goscm.ReleaseInput{
+		Title:       rel.Spec.Tag,
+		Description: status.Notes,
+		Tag:         rel.Spec.Tag,
+		Commitish:   rel.Spec.Commitish,
+		Prerelease:  rel.Spec.Prerelease,
+	})
+	if err != nil {
+		r.recorder.Eventf(rel, v1.EventTypeWarning, "PublishFailed", "Failed to publish to %s, error was %v", repoPath, err)
+		return err
+	}
+	r.recorder.Eventf(rel, v1.EventTypeNormal, "Published", "Published to %s", repoPath)
+	now := metav1.Now()
+	status.Phase = v1alpha3.ReleasePublished
+	status.URL = published.Link
+	status.PublishTime = &now
+	return nil
+}
+
+func (r *Reconciler) getProvider(repo *v1alpha3.GitRepository) (provider scmclient.Provider, repoPath string, err error) {
+	if repoPath = gitrepository.GetRepoPath(repo); repoPath == "" {
+		err = fmt.Errorf("cannot find the repository from the GitRepository %s", repo.Name)
+		return
+	}
+
+	var secretRef *v1.SecretReference
+	if repo.Spec.Secret != nil {
+		secretRef = repo.Spec.Secret.DeepCopy()
+		if secretRef.Namespace == "" {
+			secretRef.Namespace = repo.Namespace
+		}
+	}
+	newProvider := r.NewProvider
+	if newProvider == nil {
+		newProvider = scmclient.NewProviderFromSecret
+	}
+	provider, err = newProvider(repo.Spec.Provider, repo.Spec.Server, secretRef, r.Client)
+	return
+}
+
+func (r *Reconciler) fail(rel *v1alpha3.Release, status *v1alpha3.ReleaseStatus, reason, message string) {
+	r.recorder.Event(rel, v1.EventTypeWarning, reason, message)
+	status.Phase = v1alpha3.ReleaseFailed
+	status.Message = message
+}
+
+func (r *Reconciler) updateStatus(ctx context.Context, rel *v1alpha3.Release, status *v1alpha3.ReleaseStatus) error {
+	if reflect.DeepEqual(rel.Status, *status) {
+		return nil
+	}
+	rel.Status = *status
+	return r.Status().Update(ctx, rel)
+}
+
+// findReleases maps the PipelineRuns to the Releases which are waiting for them
+func (r *Reconciler) findReleases(obj client.Object) (requests []reconcile.Request) {
+	releases := &v1alpha3.ReleaseList{}
+	if err := r.List(context.Background(), releases, client.InNamespace(obj.GetNamespace())); err != nil {
+		return
+	}
+	for i := range releases.Items {
+		rel := &releases.Items[i]
+		if rel.HasCompleted() {
+			continue
+		}
+		for _, name := range rel.Spec.PipelineRuns {
+			if name == obj.GetName() {
+				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(rel)})
+				break
+			}
+		}
+	}
+	return
+}
+
+// GetName returns the name of this controller
+func (r *Reconciler) GetName() string {
+	return "release-controller"
+}
+
+// GetGroupName returns the group name of this controller
+func (r *Reconciler) GetGroupName() string {
+	return "release"
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.GetName()).
+		For(&v1alpha3.Release{}).
+		Watches(&source.Kind{Type: &v1alpha3.PipelineRun{}}, handler.EnqueueRequestsFromMapFunc(r.findReleases)).
+		Complete(r)
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package release
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	goscm "github.com/jenkins-x/go-scm/scm"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/git"
+	scmclient "kubesphere.io/devops/pkg/client/scm"
+)
+
+type fakeProvider struct {
+	scmclient.Provider
+	publishErr error
+
+	ref, since string
+	published  *goscm.ReleaseInput
+}
+
+func (p *fakeProvider) ListCommits(_ context.Context, _, ref, since string) ([]*goscm.Commit, error) {
+	if ref == "v1.1.0" {
+		return nil, fmt.Errorf("tag %s not found", ref)
+	}
+	p.ref, p.since = ref, since
+	return []*goscm.Commit{{Sha: "sha-2", Message: "Add the feature"}}, nil
+}
+
+func (p *fakeProvider) CreateRelease(_ context.Context, _ string, input *goscm.ReleaseInput) (*goscm.Release, error) {
+	if p.publishErr != nil {
+		return nil, p.publishErr
+	}
+	p.published = input
+	return &goscm.Release{Tag: input.Tag, Link: "https://github.com/org/app/releases/v1.1.0"}, nil
+}
+
+func TestReconcile(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	repo := &v1alpha3.GitRepository{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "app"},
+		Spec:       v1alpha3.GitRepositorySpec{Provider: scmclient.GitHub, URL: "https://github.com/org/app.git"},
+	}
+	newRelease := func(publish bool) *v1alpha3.Release {
+		return &v1alpha3.Release{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "app-v1-1-0"},
+			Spec: v1alpha3.ReleaseSpec{
+				GitRepository: "app",
+				Tag:           "v1.1.0",
+				Commitish:     "master",
+				PreviousTag:   "v1.0.0",
+				PipelineRuns:  []string{"build-1"},
+				Publish:       publish,
+			},
+		}
+	}
+	newPipelineRun := func(phase v1alpha3.RunPhase) *v1alpha3.PipelineRun {
+		pipelineRun := &v1alpha3.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "build-1"},
+			Status: v1alpha3.PipelineRunStatus{Phase: phase, Results: []v1alpha3.RunResult{
+				{Name: "IMAGE_URL", Value: "docker.io/org/app:v1.1.0"},
+				{Name: "IMAGE_DIGEST", Value: "sha256:app"},
+			}},
+		}
+		if phase != v1alpha3.Running {
+			now := metav1.Now()
+			pipelineRun.Status.CompletionTime = &now
+		}
+		return pipelineRun
+	}
+
+	tests := []struct {
+		name       string
+		objects    []client.Object
+		publishErr error
+		wantErr    bool
+		verify     func(t *testing.T, rel *v1alpha3.Release, provider *fakeProvider)
+	}{{
+		name:    "waiting for the PipelineRuns",
+		objects: []client.Object{newRelease(false), newPipelineRun(v1alpha3.Running), repo},
+		verify: func(t *testing.T, rel *v1alpha3.Release, provider *fakeProvider) {
+			assert.Equal(t, v1alpha3.ReleasePending, rel.Status.Phase)
+			assert.Equal(t, "waiting for PipelineRun build-1", rel.Status.Message)
+		},
+	}, {
+		name:    "PipelineRun failed",
+		objects: []client.Object{newRelease(false), newPipelineRun(v1alpha3.Failed), repo},
+		verify: func(t *testing.T, rel *v1alpha3.Release, provider *fakeProvider) {
+			assert.Equal(t, v1alpha3.ReleaseFailed, rel.Status.Phase)
+			assert.Equal(t, "PipelineRun build-1 did not succeed", rel.Status.Message)
+		},
+	}, {
+		name:    "PipelineRun not found",
+		objects: []client.Object{newRelease(false), repo},
+		verify: func(t *testing.T, rel *v1alpha3.Release, provider *fakeProvider) {
+			assert.Equal(t, v1alpha3.ReleaseFailed, rel.Status.Phase)
+		},
+	}, {
+		name:    "collected without publishing",
+		objects: []client.Object{newRelease(false), newPipelineRun(v1alpha3.Succeeded), repo},
+		verify: func(t *testing.T, rel *v1alpha3.Release, provider *fakeProvider) {
+			assert.Equal(t, v1alpha3.ReleaseReady, rel.Status.Phase)
+			assert.Equal(t, []v1alpha3.ReleaseImage{{Name: "docker.io/org/app", Digest: "sha256:app", PipelineRun: "build-1"}},
+				rel.Status.Images)
+			assert.Equal(t, []v1alpha3.ChangelogEntry{{Sha: "sha-2", Title: "Add the feature"}}, rel.Status.Changelog)
+			assert.Contains(t, rel.Status.Notes, "- Add the feature")
+			assert.Equal(t, "master", provider.ref)
+			assert.Equal(t, "v1.0.0", provider.since)
+			assert.Nil(t, provider.published)
+		},
+	}, {
+		name:    "published",
+		objects: []client.Object{newRelease(true), newPipelineRun(v1alpha3.Succeeded), repo},
+		verify: func(t *testing.T, rel *v1alpha3.Release, provider *fakeProvider) {
+			assert.Equal(t, v1alpha3.ReleasePublished, rel.Status.Phase)
+			assert.Equal(t, "https://github.com/org/app/releases/v1.1.0", rel.Status.URL)
+			assert.NotNil(t, rel.Status.PublishTime)
+			if assert.NotNil(t, provider.published) {
+				assert.Equal(t, "v1.1.0", provider.published.Tag)
+				assert.Equal(t, "master", provider.published.Commitish)
+				assert.Equal(t, rel.Status.Notes, provider.published.Description)
+			}
+		},
+	}, {
+		name:       "failed to publish",
+		objects:    []client.Object{newRelease(true), newPipelineRun(v1alpha3.Succeeded), repo},
+		publishErr: fmt.Errorf("unauthorized"),
+		wantErr:    true,
+		verify: func(t *testing.T, rel *v1alpha3.Release, provider *fakeProvider) {
+			// the collected content is kept, publishing is retried
+			assert.Equal(t, v1alpha3.ReleaseReady, rel.Status.Phase)
+			assert.NotEmpty(t, rel.Status.Notes)
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(tt.objects...).Build()
+			provider := &fakeProvider{publishErr: tt.publishErr}
+			r := &Reconciler{
+				Client: c,
+				NewProvider: func(name, server string, ref *v1.SecretReference, _ git.ResourceGetter) (scmclient.Provider, error) {
+					return provider, nil
+				},
+				recorder: &record.FakeRecorder{},
+			}
+			key := client.ObjectKey{Namespace: "ns", Name: "app-v1-1-0"}
+			_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+			assert.Equal(t, tt.wantErr, err != nil, err)
+
+			rel := &v1alpha3.Release{}
+			assert.Nil(t, c.Get(context.TODO(), key, rel))
+			tt.verify(t, rel, provider)
+		})
+	}
+}
+
+func TestFindReleases(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	c := fake.NewClientBuilder().WithScheme(schema).WithObjects(&v1alpha3.Release{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pending"},
+		Spec:       v1alpha3.ReleaseSpec{PipelineRuns: []string{"build-1"}},
+	}, &v1alpha3.Release{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "published"},
+		Spec:       v1alpha3.ReleaseSpec{PipelineRuns: []string{"build-1"}},
+		Status:     v1alpha3.ReleaseStatus{Phase: v1alpha3.ReleasePublished},
+	}).Build()
+	r := &Reconciler{Client: c}
+
+	requests := r.findReleases(&v1alpha3.PipelineRun{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "build-1"}})
+	if assert.Len(t, requests, 1) {
+		assert.Equal(t, "pending", requests[0].Name)
+	}
+	assert.Empty(t, r.findReleases(&v1alpha3.PipelineRun{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "build-2"}}))
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package s2ibinary
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha1"
+	"kubesphere.io/devops/pkg/client/s3"
+	"kubesphere.io/devops/pkg/client/scanner"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// S2iBinaryScanned is the event reason of a clean scan
+	S2iBinaryScanned = "S2iBinaryScanned"
+	// S2iBinaryQuarantined is the event reason of finding malware in an uploaded file
+	S2iBinaryQuarantined = "S2iBinaryQuarantined"
+	// FailedS2iBinaryScan is the event reason of failing to scan an uploaded file
+	FailedS2iBinaryScan = "FailedS2iBinaryScan"
+)
+
+// ScanReconciler scans the uploaded S2iBinaries for viruses and malware. The uploaded S2iBinary stays in the
+// Scanning phase until the scan passes, the infected one will be quarantined. The uploaded S2iBinaries are
+// ready at once if there is no scanner.
+type ScanReconciler struct {
+	client.Client
+	S3Client s3.Interface
+	// Scanner is optional, the uploaded files are not scanned if it's nil
+	Scanner scanner.Interface
+
+	log      logr.Logger
+	recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=s2ibinaries,verbs=get;list;update;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile scans the uploaded file of a S2iBinary which has not been scanned
+func (r *ScanReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	s2ibin := &v1alpha1.S2iBinary{}
+	if err = r.Get(ctx, req.NamespacedName, s2ibin); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	if !needScan(s2ibin) {
+		return
+	}
+
+	// the file was scanned already, e.g. the upload of another file was aborted
+	if scan := s2ibin.Status.Scan; scan != nil && scan.Revision == getRevision(s2ibin) {
+		s2ibin.Status.Phase = v1alpha1.StatusReady
+		if scan.Verdict != v1alpha1.ScanVerdictClean {
+			s2ibin.Status.Phase = v1alpha1.StatusQuarantined
+		}
+		err = r.Update(ctx, s2ibin)
+		return
+	}
+	if r.Scanner == nil {
+		if s2ibin.Status.Phase == v1alpha1.StatusScanning {
+			s2ibin.Status.Phase = v1alpha1.StatusReady
+			err = r.Update(ctx, s2ibin)
+		}
+		return
+	}
+
+	// lock the S2iBinary before scanning, the builds only consume the ready ones
+	if s2ibin.Status.Phase != v1alpha1.StatusScanning {
+		s2ibin.Status.Phase = v1alpha1.StatusScanning
+		if err = r.Update(ctx, s2ibin); err != nil {
+			return
+		}
+	}
+
+	var verdict *scanner.Verdict
+	if verdict, err = r.scan(ctx, s2ibin); err != nil {
+		r.recorder.Eventf(s2ibin, v1.EventTypeWarning, FailedS2iBinaryScan, "failed to scan %s, error: %v",
+			s2ibin.Spec.FileName, err)
+		return
+	}
+
+	now := metav1.Now()
+	s2ibin.Status.Scan = &v1alpha1.S2iBinaryScan{
+		Scanner:  r.Scanner.Name(),
+		Revision: getRevision(s2ibin),
+		ScanTime: &now,
+	}
+	if verdict.Clean {
+		s2ibin.Status.Phase = v1alpha1.StatusReady
+		s2ibin.Status.Scan.Verdict = v1alpha1.ScanVerdictClean
+	} else {
+		s2ibin.Status.Phase = v1alpha1.StatusQuarantined
+		s2ibin.Status.Scan.Verdict = v1alpha1.ScanVerdictInfected
+		s2ibin.Status.Scan.Message = verdict.Signature
+	}
+	if err = r.Update(ctx, s2ibin); err == nil {
+		if verdict.Clean {
+			r.recorder.Eventf(s2ibin, v1.EventTypeNormal, S2iBinaryScanned, "no malware was found in %s", s2ibin.Spec.FileName)
+		} else {
+			r.recorder.Eventf(s2ibin, v1.EventTypeWarning, S2iBinaryQuarantined, "%s was quarantined due to %s",
+				s2ibin.Spec.FileName, verdict.Signature)
+		}
+	}
+	return
+}
+
+// scan streams the uploaded file to the scanner, the file might be too large to be held in memory
+func (r *ScanReconciler) scan(ctx context.Context, s2ibin *v1alpha1.S2iBinary) (verdict *scanner.Verdict, err error) {
+	var body io.ReadCloser
+	if body, err = r.S3Client.Open(v1alpha1.GetS2iBinaryKey(s2ibin.Namespace, s2ibin.Name)); err != nil {
+		return
+	}
+	defer func() {
+		_ = body.Close()
+	}()
+	return r.Scanner.Scan(ctx, s2ibin.Spec.FileName, body)
+}
+
+// needScan returns true if the uploaded file is waiting for a scan, or it has not been scanned since it was uploaded
+func needScan(s2ibin *v1alpha1.S2iBinary) bool {
+	if !s2ibin.DeletionTimestamp.IsZero() || s2ibin.Spec.UploadTimeStamp == nil {
+		return false
+	}
+	if s2ibin.Status.Phase == v1alpha1.StatusScanning {
+		return true
+	}
+	return s2ibin.Status.Phase == v1alpha1.StatusReady &&
+		(s2ibin.Status.Scan == nil || s2ibin.Status.Scan.Revision != getRevision(s2ibin))
+}
+
+// getRevision identifies an uploaded file by its checksum and upload time
+func getRevision(s2ibin *v1alpha1.S2iBinary) string {
+	return fmt.Sprintf("%s@%s", s2ibin.Spec.MD5, s2ibin.Spec.UploadTimeStamp.UTC().Format(time.RFC3339))
+}
+
+var scanPredicate = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool {
+		s2ibin, ok := e.Object.(*v1alpha1.S2iBinary)
+		return ok && needScan(s2ibin)
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		s2ibin, ok := e.ObjectNew.(*v1alpha1.S2iBinary)
+		return ok && needScan(s2ibin)
+	},
+	DeleteFunc: func(e event.DeleteEvent) bool {
+		return false
+	},
+}
+
+// GetName returns the name of this reconciler
+func (r *ScanReconciler) GetName() string {
+	return "s2ibinary-scan-controller"
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ScanReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	r.log = ctrl.Log.WithName(r.GetName())
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.S2iBinary{}).
+		WithEventFilter(scanPredicate).
+		Complete(r)
+}
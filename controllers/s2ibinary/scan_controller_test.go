@@ -0,0 +1,198 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package s2ibinary
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha1"
+	fakes3 "kubesphere.io/devops/pkg/client/s3/fake"
+	"kubesphere.io/devops/pkg/client/scanner"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+type fakeScanner struct{}
+
+func (s *fakeScanner) Name() string {
+	return "fake"
+}
+
+func (s *fakeScanner) Scan(ctx context.Context, fileName string, body io.Reader) (*scanner.Verdict, error) {
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	switch string(data) {
+	case "clean":
+		return &scanner.Verdict{Clean: true}, nil
+	case "infected":
+		return &scanner.Verdict{Signature: "Eicar-Signature"}, nil
+	}
+	return nil, errors.New("unknown file")
+}
+
+func newS2iBinary(phase string, uploaded bool, scan *v1alpha1.S2iBinaryScan) *v1alpha1.S2iBinary {
+	s2ibin := &v1alpha1.S2iBinary{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "binary"},
+		Spec:       v1alpha1.S2iBinarySpec{FileName: "app.jar", MD5: "md5"},
+		Status:     v1alpha1.S2iBinaryStatus{Phase: phase, Scan: scan},
+	}
+	if uploaded {
+		s2ibin.Spec.UploadTimeStamp = &metav1.Time{Time: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	}
+	return s2ibin
+}
+
+func TestScanReconciler(t *testing.T) {
+	schema, err := v1alpha1.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name        string
+		s2ibin      *v1alpha1.S2iBinary
+		content     string
+		noScanner   bool
+		wantErr     bool
+		wantPhase   string
+		wantVerdict string
+	}{{
+		name:      "not uploaded",
+		s2ibin:    newS2iBinary("", false, nil),
+		wantPhase: "",
+	}, {
+		name:      "uploading",
+		s2ibin:    newS2iBinary(v1alpha1.StatusUploading, true, nil),
+		wantPhase: v1alpha1.StatusUploading,
+	}, {
+		name:        "clean",
+		s2ibin:      newS2iBinary(v1alpha1.StatusReady, true, nil),
+		content:     "clean",
+		wantPhase:   v1alpha1.StatusReady,
+		wantVerdict: v1alpha1.ScanVerdictClean,
+	}, {
+		name:        "uploaded",
+		s2ibin:      newS2iBinary(v1alpha1.StatusScanning, true, nil),
+		content:     "clean",
+		wantPhase:   v1alpha1.StatusReady,
+		wantVerdict: v1alpha1.ScanVerdictClean,
+	}, {
+		name:      "uploaded without scanner",
+		s2ibin:    newS2iBinary(v1alpha1.StatusScanning, true, nil),
+		content:   "infected",
+		noScanner: true,
+		wantPhase: v1alpha1.StatusReady,
+	}, {
+		name:      "ready without scanner",
+		s2ibin:    newS2iBinary(v1alpha1.StatusReady, true, nil),
+		content:   "infected",
+		noScanner: true,
+		wantPhase: v1alpha1.StatusReady,
+	}, {
+		name: "restore the quarantined file after aborting an upload",
+		s2ibin: newS2iBinary(v1alpha1.StatusScanning, true, &v1alpha1.S2iBinaryScan{
+			Verdict: v1alpha1.ScanVerdictInfected, Revision: "md5@2022-01-01T00:00:00Z",
+		}),
+		wantPhase:   v1alpha1.StatusQuarantined,
+		wantVerdict: v1alpha1.ScanVerdictInfected,
+	}, {
+		name:        "infected",
+		s2ibin:      newS2iBinary(v1alpha1.StatusReady, true, nil),
+		content:     "infected",
+		wantPhase:   v1alpha1.StatusQuarantined,
+		wantVerdict: v1alpha1.ScanVerdictInfected,
+	}, {
+		name: "re-uploaded after a scan",
+		s2ibin: newS2iBinary(v1alpha1.StatusReady, true, &v1alpha1.S2iBinaryScan{
+			Verdict: v1alpha1.ScanVerdictClean, Revision: "old",
+		}),
+		content:     "infected",
+		wantPhase:   v1alpha1.StatusQuarantined,
+		wantVerdict: v1alpha1.ScanVerdictInfected,
+	}, {
+		name: "scanned",
+		s2ibin: newS2iBinary(v1alpha1.StatusReady, true, &v1alpha1.S2iBinaryScan{
+			Verdict: v1alpha1.ScanVerdictClean, Revision: "md5@2022-01-01T00:00:00Z",
+		}),
+		content:     "infected",
+		wantPhase:   v1alpha1.StatusReady,
+		wantVerdict: v1alpha1.ScanVerdictClean,
+	}, {
+		name:      "failed to scan",
+		s2ibin:    newS2iBinary(v1alpha1.StatusReady, true, nil),
+		content:   "unknown",
+		wantErr:   true,
+		wantPhase: v1alpha1.StatusScanning,
+	}, {
+		name:      "missing file",
+		s2ibin:    newS2iBinary(v1alpha1.StatusReady, true, nil),
+		wantErr:   true,
+		wantPhase: v1alpha1.StatusScanning,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s3 := fakes3.NewFakeS3()
+			if tt.content != "" {
+				assert.Nil(t, s3.Upload("ns-binary", "app.jar", strings.NewReader(tt.content)))
+			}
+			c := fake.NewClientBuilder().WithScheme(schema).WithRuntimeObjects(tt.s2ibin.DeepCopy()).Build()
+			r := &ScanReconciler{
+				Client:   c,
+				S3Client: s3,
+				Scanner:  &fakeScanner{},
+				log:      logr.New(log.NullLogSink{}),
+				recorder: record.NewFakeRecorder(10),
+			}
+			if tt.noScanner {
+				r.Scanner = nil
+			}
+
+			_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "ns", Name: "binary"}})
+			assert.Equal(t, tt.wantErr, err != nil, err)
+
+			s2ibin := &v1alpha1.S2iBinary{}
+			assert.Nil(t, c.Get(context.TODO(), types.NamespacedName{Namespace: "ns", Name: "binary"}, s2ibin))
+			assert.Equal(t, tt.wantPhase, s2ibin.Status.Phase)
+			if tt.wantVerdict == "" {
+				assert.Nil(t, s2ibin.Status.Scan)
+			} else if assert.NotNil(t, s2ibin.Status.Scan) {
+				assert.Equal(t, tt.wantVerdict, s2ibin.Status.Scan.Verdict)
+			}
+		})
+	}
+
+	// not found
+	r := &ScanReconciler{Client: fake.NewClientBuilder().WithScheme(schema).Build()}
+	_, err = r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "ns", Name: "fake"}})
+	assert.Nil(t, err)
+}
+
+func TestScanReconciler_GetName(t *testing.T) {
+	assert.Equal(t, "s2ibinary-scan-controller", (&ScanReconciler{}).GetName())
+}
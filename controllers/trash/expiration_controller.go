@@ -0,0 +1,110 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trash
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/devops"
+)
+
+// JobDeleter deletes the Jenkins jobs, it's implemented by devops.Interface
+type JobDeleter interface {
+	DeleteProjectPipeline(projectID string, pipelineID string) (string, error)
+}
+
+// ExpirationReconciler removes the expired DeletedPipelines along with their Jenkins jobs
+type ExpirationReconciler struct {
+	client.Client
+
+	// JenkinsClient deletes the Jenkins jobs of the expired DeletedPipelines, the jobs are left if it's nil
+	JenkinsClient JobDeleter
+	// Now returns the current time, time.Now will be used if it's nil
+	Now func() time.Time
+}
+
+// Reconcile removes a DeletedPipeline permanently once it's expired, or requeues it until then
+func (r *ExpirationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	deletedPipeline := &v1alpha3.DeletedPipeline{}
+	if err = r.Get(ctx, req.NamespacedName, deletedPipeline); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+
+	now := time.Now
+	if r.Now != nil {
+		now = r.Now
+	}
+	current := metav1.NewTime(now())
+	if !deletedPipeline.IsExpired(current) {
+		result.RequeueAfter = deletedPipeline.Spec.ExpirationTime.Sub(current.Time)
+		return
+	}
+
+	if err = r.deleteJob(ctx, deletedPipeline); err != nil {
+		return
+	}
+	err = client.IgnoreNotFound(r.Delete(ctx, deletedPipeline))
+	return
+}
+
+// deleteJob deletes the Jenkins job unless a Pipeline with the same name owns it now, such as the undeleted one
+func (r *ExpirationReconciler) deleteJob(ctx context.Context, deletedPipeline *v1alpha3.DeletedPipeline) (err error) {
+	if r.JenkinsClient == nil {
+		return
+	}
+	pipeline := &v1alpha3.Pipeline{}
+	if err = r.Get(ctx, client.ObjectKey{Namespace: deletedPipeline.Namespace, Name: deletedPipeline.Spec.Pipeline},
+		pipeline); err == nil || !apierrors.IsNotFound(err) {
+		return
+	}
+
+	if _, err = r.JenkinsClient.DeleteProjectPipeline(deletedPipeline.Namespace, deletedPipeline.Spec.Pipeline); err != nil {
+		// the status code should be 404 if the job does not exist
+		if srvErr, ok := err.(restful.ServiceError); ok && srvErr.Code == http.StatusNotFound {
+			return nil
+		} else if srvErr, ok := err.(*devops.ErrorResponse); ok && srvErr.Response.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		err = fmt.Errorf("failed to delete the Jenkins job of %s/%s: %v", deletedPipeline.Namespace,
+			deletedPipeline.Spec.Pipeline, err)
+	}
+	return
+}
+
+// GetName returns the name of this controller
+func (r *ExpirationReconciler) GetName() string {
+	return "pipeline-trash-expiration"
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ExpirationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.GetName()).
+		For(&v1alpha3.DeletedPipeline{}).
+		Complete(r)
+}
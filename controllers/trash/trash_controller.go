@@ -0,0 +1,134 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trash
+
+import (
+	"context"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelines,verbs=get;list;watch;update
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=deletedpipelines,verbs=get;list;watch;create;delete
+
+// Reconciler moves the deleted Pipelines into the trash bin. It holds the Pipelines by a finalizer, then archives
+// them as DeletedPipelines before they are gone. The Jenkins jobs of the archived Pipelines are kept by the
+// Pipeline controller, so that they could be undeleted with their history.
+type Reconciler struct {
+	client.Client
+
+	// Retention is the period of keeping the deleted Pipelines, zero disables the trash bin
+	Retention time.Duration
+	// Now returns the current time, time.Now will be used if it's nil
+	Now func() time.Time
+}
+
+// Reconcile applies the trash finalizer to a Pipeline, or archives it if it's being deleted
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	pipeline := &v1alpha3.Pipeline{}
+	if err = r.Get(ctx, req.NamespacedName, pipeline); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+
+	if pipeline.DeletionTimestamp.IsZero() {
+		// only the Pipelines whose Jenkins jobs are deleted along with them need the trash bin
+		trashable := r.Retention > 0 && pipeline.Spec.GetDeletionPolicy() == v1alpha3.DeletionPolicyDelete
+		if trashable == controllerutil.ContainsFinalizer(pipeline, v1alpha3.PipelineTrashFinalizerName) {
+			return
+		}
+		if trashable {
+			controllerutil.AddFinalizer(pipeline, v1alpha3.PipelineTrashFinalizerName)
+		} else {
+			controllerutil.RemoveFinalizer(pipeline, v1alpha3.PipelineTrashFinalizerName)
+		}
+		err = r.Update(ctx, pipeline)
+		return
+	}
+
+	if !controllerutil.ContainsFinalizer(pipeline, v1alpha3.PipelineTrashFinalizerName) {
+		return
+	}
+	var deletedPipeline *v1alpha3.DeletedPipeline
+	if deletedPipeline, err = r.archive(ctx, pipeline); err != nil {
+		return
+	}
+
+	// the annotation tells the Pipeline controller to keep the Jenkins job
+	if pipeline.Annotations == nil {
+		pipeline.Annotations = map[string]string{}
+	}
+	pipeline.Annotations[v1alpha3.PipelineTrashedAnnoKey] = deletedPipeline.Name
+	controllerutil.RemoveFinalizer(pipeline, v1alpha3.PipelineTrashFinalizerName)
+	err = client.IgnoreNotFound(r.Update(ctx, pipeline))
+	return
+}
+
+// archive creates the DeletedPipeline of a Pipeline, it's fine if the DeletedPipeline exists already
+func (r *Reconciler) archive(ctx context.Context, pipeline *v1alpha3.Pipeline) (
+	deletedPipeline *v1alpha3.DeletedPipeline, err error) {
+	now := time.Now
+	if r.Now != nil {
+		now = r.Now
+	}
+	deletionTime := *pipeline.DeletionTimestamp
+	deletedPipeline = &v1alpha3.DeletedPipeline{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: pipeline.Namespace,
+			Name:      v1alpha3.GetDeletedPipelineName(pipeline),
+			Labels:    map[string]string{v1alpha3.PipelineNameLabelKey: pipeline.Name},
+		},
+		Spec: v1alpha3.DeletedPipelineSpec{
+			Pipeline:       pipeline.Name,
+			UID:            pipeline.UID,
+			Labels:         pipeline.Labels,
+			Annotations:    pipeline.Annotations,
+			PipelineSpec:   pipeline.Spec,
+			DeletionTime:   deletionTime,
+			ExpirationTime: metav1.NewTime(now().Add(r.Retention)),
+		},
+	}
+	if err = r.Create(ctx, deletedPipeline); apierrors.IsAlreadyExists(err) {
+		err = nil
+	}
+	return
+}
+
+// GetName returns the name of this controller
+func (r *Reconciler) GetName() string {
+	return "pipeline-trash"
+}
+
+// GetGroupName returns the group name of this controller
+func (r *Reconciler) GetGroupName() string {
+	return "trash"
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.GetName()).
+		For(&v1alpha3.Pipeline{}).
+		Complete(r)
+}
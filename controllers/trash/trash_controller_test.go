@@ -0,0 +1,210 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trash
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+func TestReconcile(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+	now := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	newPipeline := func(policy v1alpha3.DeletionPolicy) *v1alpha3.Pipeline {
+		return &v1alpha3.Pipeline{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "ns",
+				Name:        "build",
+				UID:         "1234567890",
+				Labels:      map[string]string{"team": "a"},
+				Annotations: map[string]string{"description": "build the app"},
+			},
+			Spec: v1alpha3.PipelineSpec{
+				Type:           v1alpha3.NoScmPipelineType,
+				Pipeline:       &v1alpha3.NoScmPipeline{Name: "build", Jenkinsfile: "pipeline {}"},
+				DeletionPolicy: policy,
+			},
+		}
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "ns", Name: "build"}}
+
+	tests := []struct {
+		name      string
+		pipeline  *v1alpha3.Pipeline
+		retention time.Duration
+		verify    func(t *testing.T, c client.Client, pipeline *v1alpha3.Pipeline)
+	}{{
+		name:      "add the finalizer",
+		pipeline:  newPipeline(""),
+		retention: time.Hour,
+		verify: func(t *testing.T, c client.Client, pipeline *v1alpha3.Pipeline) {
+			assert.True(t, controllerutil.ContainsFinalizer(pipeline, v1alpha3.PipelineTrashFinalizerName))
+		},
+	}, {
+		name:     "the trash bin is disabled",
+		pipeline: newPipeline(""),
+		verify: func(t *testing.T, c client.Client, pipeline *v1alpha3.Pipeline) {
+			assert.False(t, controllerutil.ContainsFinalizer(pipeline, v1alpha3.PipelineTrashFinalizerName))
+		},
+	}, {
+		name: "remove the finalizer from the retained Pipeline",
+		pipeline: func() *v1alpha3.Pipeline {
+			pipeline := newPipeline(v1alpha3.DeletionPolicyRetain)
+			pipeline.Finalizers = []string{v1alpha3.PipelineTrashFinalizerName}
+			return pipeline
+		}(),
+		retention: time.Hour,
+		verify: func(t *testing.T, c client.Client, pipeline *v1alpha3.Pipeline) {
+			assert.False(t, controllerutil.ContainsFinalizer(pipeline, v1alpha3.PipelineTrashFinalizerName))
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(tt.pipeline).Build()
+			r := &Reconciler{Client: c, Retention: tt.retention, Now: func() time.Time { return now }}
+			_, err := r.Reconcile(context.TODO(), req)
+			assert.Nil(t, err)
+
+			pipeline := &v1alpha3.Pipeline{}
+			assert.Nil(t, c.Get(context.TODO(), req.NamespacedName, pipeline))
+			tt.verify(t, c, pipeline)
+		})
+	}
+
+	t.Run("archive the deleted Pipeline", func(t *testing.T) {
+		pipeline := newPipeline("")
+		// the finalizer of the Pipeline controller holds the Pipeline after it's archived
+		pipeline.Finalizers = []string{v1alpha3.PipelineFinalizerName, v1alpha3.PipelineTrashFinalizerName}
+		c := fake.NewClientBuilder().WithScheme(schema).WithObjects(pipeline).Build()
+		assert.Nil(t, c.Delete(context.TODO(), pipeline))
+
+		r := &Reconciler{Client: c, Retention: time.Hour, Now: func() time.Time { return now }}
+		_, err := r.Reconcile(context.TODO(), req)
+		assert.Nil(t, err)
+
+		assert.Nil(t, c.Get(context.TODO(), req.NamespacedName, pipeline))
+		assert.Equal(t, []string{v1alpha3.PipelineFinalizerName}, pipeline.Finalizers)
+		assert.Equal(t, "build-12345678", pipeline.Annotations[v1alpha3.PipelineTrashedAnnoKey])
+
+		deletedPipeline := &v1alpha3.DeletedPipeline{}
+		assert.Nil(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "ns", Name: "build-12345678"}, deletedPipeline))
+		assert.Equal(t, "build", deletedPipeline.Spec.Pipeline)
+		assert.Equal(t, "build", deletedPipeline.Labels[v1alpha3.PipelineNameLabelKey])
+		assert.Equal(t, map[string]string{"team": "a"}, deletedPipeline.Spec.Labels)
+		assert.Equal(t, "build the app", deletedPipeline.Spec.Annotations["description"])
+		assert.Equal(t, "pipeline {}", deletedPipeline.Spec.PipelineSpec.Pipeline.Jenkinsfile)
+		assert.Equal(t, now.Add(time.Hour), deletedPipeline.Spec.ExpirationTime.Time.UTC())
+	})
+}
+
+// fakeJobDeleter records the deleted jobs, it fails to delete any job if failed is true
+type fakeJobDeleter struct {
+	deleted []string
+	failed  bool
+}
+
+func (d *fakeJobDeleter) DeleteProjectPipeline(projectID string, pipelineID string) (string, error) {
+	if d.failed {
+		return "", fmt.Errorf("unexpected error")
+	}
+	d.deleted = append(d.deleted, projectID+"/"+pipelineID)
+	return "", nil
+}
+
+func TestExpirationReconcile(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+	now := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	deletedPipeline := &v1alpha3.DeletedPipeline{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "build-12345678"},
+		Spec: v1alpha3.DeletedPipelineSpec{
+			Pipeline:       "build",
+			ExpirationTime: metav1.NewTime(now.Add(time.Hour)),
+		},
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "ns", Name: "build-12345678"}}
+
+	tests := []struct {
+		name          string
+		now           time.Time
+		objects       []client.Object
+		jobDeleter    *fakeJobDeleter
+		expectErr     bool
+		expectRequeue time.Duration
+		expectDeleted []string
+		expectRemoved bool
+	}{{
+		name:          "not expired",
+		now:           now,
+		jobDeleter:    &fakeJobDeleter{},
+		expectRequeue: time.Hour,
+	}, {
+		name:          "expired",
+		now:           now.Add(time.Hour),
+		jobDeleter:    &fakeJobDeleter{},
+		expectDeleted: []string{"ns/build"},
+		expectRemoved: true,
+	}, {
+		name: "the job belongs to another Pipeline",
+		now:  now.Add(2 * time.Hour),
+		objects: []client.Object{&v1alpha3.Pipeline{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "build"},
+		}},
+		jobDeleter:    &fakeJobDeleter{},
+		expectRemoved: true,
+	}, {
+		name:       "failed to delete the job",
+		now:        now.Add(time.Hour),
+		jobDeleter: &fakeJobDeleter{failed: true},
+		expectErr:  true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := append([]client.Object{deletedPipeline.DeepCopy()}, tt.objects...)
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(objects...).Build()
+			r := &ExpirationReconciler{
+				Client:        c,
+				JenkinsClient: tt.jobDeleter,
+				Now:           func() time.Time { return tt.now },
+			}
+			result, err := r.Reconcile(context.TODO(), req)
+			assert.Equal(t, tt.expectErr, err != nil, err)
+			assert.Equal(t, tt.expectRequeue, result.RequeueAfter)
+			assert.Equal(t, tt.expectDeleted, tt.jobDeleter.deleted)
+
+			err = c.Get(context.TODO(), req.NamespacedName, &v1alpha3.DeletedPipeline{})
+			assert.Equal(t, tt.expectRemoved, apierrors.IsNotFound(err))
+		})
+	}
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	eventtrigger "kubesphere.io/devops/pkg/trigger"
+)
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=triggers,verbs=get;list;watch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=triggers/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelines,verbs=get;list;watch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns,verbs=create
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// invalidMessagePrefix is the prefix of the status message of the invalid Triggers
+const invalidMessagePrefix = "invalid Trigger: "
+
+// maxMissedSchedules limits the iterations of looking for the latest missed schedule
+const maxMissedSchedules = 1000
+
+// Reconciler validates the Triggers, and fires the ones of the cron source by their schedules.
+// The Triggers of the other sources are fired by the DevOps apiserver once it receives the events.
+type Reconciler struct {
+	client.Client
+
+	dispatcher *eventtrigger.Dispatcher
+	recorder   record.EventRecorder
+	now        func() time.Time
+}
+
+// Reconcile validates a Trigger, and creates a PipelineRun if its schedule is due
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	trigger := &v1alpha3.Trigger{}
+	if err = r.Get(ctx, req.NamespacedName, trigger); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	base := trigger.DeepCopy()
+
+	if validateErr := r.dispatcher.Evaluator.ValidateSpec(&trigger.Spec); validateErr != nil {
+		trigger.Status.Message = invalidMessagePrefix + validateErr.Error()
+		err = r.patchStatus(ctx, trigger, base)
+		return
+	} else if strings.HasPrefix(trigger.Status.Message, invalidMessagePrefix) {
+		trigger.Status.Message = ""
+	}
+
+	if trigger.Spec.Source.Type == v1alpha3.TriggerSourceCron && !trigger.Spec.Suspend {
+		result, err = r.schedule(ctx, trigger)
+	}
+	if err == nil {
+		err = r.patchStatus(ctx, trigger, base)
+	}
+	return
+}
+
+// schedule fires the Trigger once for the latest missed schedule, then waits for the next one
+func (r *Reconciler) schedule(ctx context.Context, trigger *v1alpha3.Trigger) (result ctrl.Result, err error) {
+	schedule, err := eventtrigger.ParseSchedule(trigger.Spec.Source.Schedule, trigger.Spec.Source.TimeZone)
+	if err != nil {
+		return
+	}
+
+	now := r.getNow()
+	last := trigger.CreationTimestamp.Time
+	if trigger.Status.LastScheduleTime != nil {
+		last = trigger.Status.LastScheduleTime.Time
+	}
+	scheduled := schedule.Next(last)
+	if scheduled.IsZero() {
+		trigger.Status.Message = fmt.Sprintf("the schedule %q never matches", trigger.Spec.Source.Schedule)
+		return
+	}
+	if scheduled.After(now) {
+		result.RequeueAfter = scheduled.Sub(now)
+		return
+	}
+	for i := 0; i < maxMissedSchedules; i++ {
+		next := schedule.Next(scheduled)
+		if next.IsZero() || next.After(now) {
+			break
+		}
+		scheduled = next
+	}
+
+	trigger.Status.LastScheduleTime = &metav1.Time{Time: scheduled}
+	run, fireErr := r.dispatcher.Fire(ctx, trigger, &eventtrigger.Event{
+		SourceType: v1alpha3.TriggerSourceCron,
+		Type:       eventtrigger.EventCron,
+		Source:     eventtrigger.EventCron,
+		Time:       scheduled,
+	})
+	eventtrigger.SetStatus(&trigger.Status, run, fireErr, now)
+	if fireErr != nil {
+		r.recorder.Eventf(trigger, v1.EventTypeWarning, "FireFailed", "Failed to create the PipelineRun, error was %v", fireErr)
+	} else if run != nil {
+		r.recorder.Eventf(trigger, v1.EventTypeNormal, "Triggered", "Created the PipelineRun %s", run.Name)
+	}
+
+	if next := schedule.Next(now); !next.IsZero() {
+		result.RequeueAfter = next.Sub(now)
+	}
+	return
+}
+
+func (r *Reconciler) patchStatus(ctx context.Context, trigger, base *v1alpha3.Trigger) error {
+	if equality.Semantic.DeepEqual(trigger.Status, base.Status) {
+		return nil
+	}
+	return r.Status().Patch(ctx, trigger, client.MergeFrom(base))
+}
+
+func (r *Reconciler) getNow() time.Time {
+	if r.now != nil {
+		return r.now()
+	}
+	return time.Now()
+}
+
+// GetName returns the name of this reconciler
+func (r *Reconciler) GetName() string {
+	return "trigger-controller"
+}
+
+// GetGroupName returns the group name of this reconciler
+func (r *Reconciler) GetGroupName() string {
+	return "trigger"
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	r.dispatcher = eventtrigger.NewDispatcher(r.Client)
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.GetName()).
+		For(&v1alpha3.Trigger{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
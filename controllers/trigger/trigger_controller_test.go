@@ -0,0 +1,122 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	eventtrigger "kubesphere.io/devops/pkg/trigger"
+)
+
+func TestReconcile(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	now := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	pipeline := &v1alpha3.Pipeline{
+		TypeMeta:   metav1.TypeMeta{Kind: "Pipeline", APIVersion: v1alpha3.GroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "nightly"},
+		Spec:       v1alpha3.PipelineSpec{Type: v1alpha3.NoScmPipelineType},
+	}
+	newTrigger := func(schedule string, lastSchedule time.Time) *v1alpha3.Trigger {
+		trigger := &v1alpha3.Trigger{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:         "ns",
+				Name:              "nightly",
+				CreationTimestamp: metav1.Time{Time: now.Add(-time.Hour * 48)},
+			},
+			Spec: v1alpha3.TriggerSpec{
+				Pipeline:   pipeline.Name,
+				Source:     v1alpha3.TriggerSource{Type: v1alpha3.TriggerSourceCron, Schedule: schedule},
+				Parameters: []v1alpha3.TriggerParameter{{Name: "TIME", Expression: "event.time"}},
+			},
+		}
+		if !lastSchedule.IsZero() {
+			trigger.Status.LastScheduleTime = &metav1.Time{Time: lastSchedule}
+		}
+		return trigger
+	}
+
+	tests := []struct {
+		name             string
+		trigger          *v1alpha3.Trigger
+		expectedRuns     int
+		expectedRequeue  time.Duration
+		expectedSchedule time.Time
+		expectedMessage  string
+	}{{
+		name:             "fire the latest missed schedule",
+		trigger:          newTrigger("0 2 * * *", time.Time{}),
+		expectedRuns:     1,
+		expectedRequeue:  time.Date(2023, 1, 3, 2, 0, 0, 0, time.UTC).Sub(now),
+		expectedSchedule: time.Date(2023, 1, 2, 2, 0, 0, 0, time.UTC),
+	}, {
+		name:             "wait for the next schedule",
+		trigger:          newTrigger("0 2 * * *", time.Date(2023, 1, 2, 2, 0, 0, 0, time.UTC)),
+		expectedRequeue:  time.Date(2023, 1, 3, 2, 0, 0, 0, time.UTC).Sub(now),
+		expectedSchedule: time.Date(2023, 1, 2, 2, 0, 0, 0, time.UTC),
+	}, {
+		name:            "invalid schedule",
+		trigger:         newTrigger("every day", time.Time{}),
+		expectedMessage: invalidMessagePrefix,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(pipeline.DeepCopy(), tt.trigger).Build()
+			r := &Reconciler{
+				Client:     c,
+				dispatcher: eventtrigger.NewDispatcher(c),
+				recorder:   &record.FakeRecorder{},
+				now:        func() time.Time { return now },
+			}
+
+			result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(tt.trigger)})
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expectedRequeue, result.RequeueAfter)
+
+			runList := &v1alpha3.PipelineRunList{}
+			assert.Nil(t, c.List(context.Background(), runList))
+			assert.Len(t, runList.Items, tt.expectedRuns)
+			if tt.expectedRuns > 0 {
+				assert.Equal(t, []v1alpha3.Parameter{{Name: "TIME", Value: "2023-01-02T02:00:00Z"}},
+					runList.Items[0].Spec.Parameters)
+			}
+
+			trigger := &v1alpha3.Trigger{}
+			assert.Nil(t, c.Get(context.Background(), client.ObjectKeyFromObject(tt.trigger), trigger))
+			if !tt.expectedSchedule.IsZero() {
+				assert.True(t, tt.expectedSchedule.Equal(trigger.Status.LastScheduleTime.Time))
+			}
+			if tt.expectedMessage != "" {
+				assert.Contains(t, trigger.Status.Message, tt.expectedMessage)
+			} else {
+				assert.Empty(t, trigger.Status.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package usage
+
+import (
+	"context"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/metrics"
+	"kubesphere.io/devops/pkg/models/usage"
+	"kubesphere.io/devops/pkg/utils/k8sutil"
+)
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns,verbs=get;list;watch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;update;patch
+
+// Reconciler accounts the compute resources consumed by the Jenkins agent pods into the status of their
+// PipelineRuns. The pods are held by a finalizer until they terminated, so that the usage is never missed
+// even if Jenkins deletes them right after the builds.
+type Reconciler struct {
+	client.Client
+
+	// Now returns the current time, time.Now will be used if it's nil
+	Now func() time.Time
+}
+
+// Reconcile accounts the usage of a Jenkins agent pod once it terminated
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	pod := &v1.Pod{}
+	if err = r.Get(ctx, req.NamespacedName, pod); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	ref, ok := usage.ParseRunURL(pod.Annotations[usage.RunURLAnnoKey])
+	if !ok {
+		return
+	}
+
+	terminated := pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed
+	if pod.DeletionTimestamp.IsZero() && !terminated {
+		if k8sutil.AddFinalizer(&pod.ObjectMeta, usage.PodFinalizerName) {
+			err = r.Update(ctx, pod)
+		}
+		return
+	}
+
+	if err = r.account(ctx, pod, ref); err != nil {
+		return
+	}
+	for _, finalizer := range pod.Finalizers {
+		if finalizer == usage.PodFinalizerName {
+			k8sutil.RemoveFinalizer(&pod.ObjectMeta, usage.PodFinalizerName)
+			err = r.Update(ctx, pod)
+			break
+		}
+	}
+	return
+}
+
+// account adds the usage of the pod into its PipelineRun, the pods which have been accounted are ignored
+func (r *Reconciler) account(ctx context.Context, pod *v1.Pod, ref *usage.RunRef) error {
+	now := time.Now
+	if r.Now != nil {
+		now = r.Now
+	}
+	podUsage := usage.PodUsage(pod, now())
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		pipelineRun, err := usage.FindPipelineRun(ctx, r.Client, ref)
+		if err != nil || pipelineRun == nil {
+			// the PipelineRun might have been deleted, don't block the deletion of the pod
+			return err
+		}
+		if pipelineRun.Status.Usage == nil {
+			pipelineRun.Status.Usage = &v1alpha3.ResourceUsage{}
+		}
+		if pipelineRun.Status.Usage.HasPod(pod.Name) {
+			return nil
+		}
+		pipelineRun.Status.Usage.CPUMilliCoreSeconds += podUsage.CPUMilliCoreSeconds
+		pipelineRun.Status.Usage.MemoryMiBSeconds += podUsage.MemoryMiBSeconds
+		pipelineRun.Status.Usage.Pods = append(pipelineRun.Status.Usage.Pods, pod.Name)
+		if err = r.Status().Update(ctx, pipelineRun); err == nil {
+			metrics.ObservePipelineRunUsage(pipelineRun, podUsage.CPUMilliCoreSeconds, podUsage.MemoryMiBSeconds)
+		}
+		return err
+	})
+}
+
+// GetName returns the name of this controller
+func (r *Reconciler) GetName() string {
+	return "usage-controller"
+}
+
+// GetGroupName returns the group name of this controller
+func (r *Reconciler) GetGroupName() string {
+	return "usage"
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.GetName()).
+		For(&v1.Pod{}, builder.WithPredicates(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+			_, ok := obj.GetAnnotations()[usage.RunURLAnnoKey]
+			return ok
+		}))).
+		Complete(r)
+}
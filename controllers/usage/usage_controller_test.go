@@ -0,0 +1,129 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package usage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/models/usage"
+)
+
+func TestReconcile(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+	assert.Nil(t, v1.AddToScheme(schema))
+
+	now := time.Date(2022, 1, 1, 0, 10, 0, 0, time.UTC)
+	start := metav1.NewTime(now.Add(-10 * time.Minute))
+	deleted := metav1.NewTime(now.Add(-5 * time.Minute))
+	newPod := func(runURL string, phase v1.PodPhase, finalizers ...string) *v1.Pod {
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: v1alpha3.DefaultAgentNamespace, Name: "agent", Finalizers: finalizers},
+			Spec: v1.PodSpec{Containers: []v1.Container{{Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("1"),
+				v1.ResourceMemory: resource.MustParse("1Gi"),
+			}}}}},
+			Status: v1.PodStatus{Phase: phase, StartTime: &start},
+		}
+		if runURL != "" {
+			pod.Annotations = map[string]string{usage.RunURLAnnoKey: runURL}
+		}
+		return pod
+	}
+	deletingPod := newPod("job/project/job/build/1/", v1.PodRunning, usage.PodFinalizerName)
+	deletingPod.DeletionTimestamp = &deleted
+	pipelineRun := &v1alpha3.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "project",
+			Name:        "build-1",
+			Labels:      map[string]string{v1alpha3.PipelineNameLabelKey: "build"},
+			Annotations: map[string]string{v1alpha3.JenkinsPipelineRunIDAnnoKey: "1"},
+		},
+	}
+	accountedPipelineRun := pipelineRun.DeepCopy()
+	accountedPipelineRun.Status.Usage = &v1alpha3.ResourceUsage{CPUMilliCoreSeconds: 1, Pods: []string{"agent"}}
+
+	tests := []struct {
+		name            string
+		objects         []client.Object
+		expectFinalizer bool
+		expectUsage     *v1alpha3.ResourceUsage
+	}{{
+		name:    "not an agent pod",
+		objects: []client.Object{newPod("", v1.PodRunning), pipelineRun.DeepCopy()},
+	}, {
+		name:            "running agent pod",
+		objects:         []client.Object{newPod("job/project/job/build/1/", v1.PodRunning), pipelineRun.DeepCopy()},
+		expectFinalizer: true,
+	}, {
+		name:    "terminated agent pod",
+		objects: []client.Object{newPod("job/project/job/build/1/", v1.PodFailed, usage.PodFinalizerName), pipelineRun.DeepCopy()},
+		expectUsage: &v1alpha3.ResourceUsage{
+			CPUMilliCoreSeconds: 1000 * 600, MemoryMiBSeconds: 1024 * 600, Pods: []string{"agent"},
+		},
+	}, {
+		name:    "deleting agent pod",
+		objects: []client.Object{deletingPod.DeepCopy(), pipelineRun.DeepCopy()},
+		expectUsage: &v1alpha3.ResourceUsage{
+			CPUMilliCoreSeconds: 1000 * 300, MemoryMiBSeconds: 1024 * 300, Pods: []string{"agent"},
+		},
+	}, {
+		name:        "accounted agent pod",
+		objects:     []client.Object{newPod("job/project/job/build/1/", v1.PodSucceeded), accountedPipelineRun.DeepCopy()},
+		expectUsage: accountedPipelineRun.Status.Usage,
+	}, {
+		name:    "PipelineRun not found",
+		objects: []client.Object{newPod("job/project/job/build/2/", v1.PodSucceeded, usage.PodFinalizerName), pipelineRun.DeepCopy()},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(tt.objects...).Build()
+			r := &Reconciler{Client: c, Now: func() time.Time { return now }}
+
+			_, err := r.Reconcile(context.Background(), ctrl.Request{
+				NamespacedName: client.ObjectKey{Namespace: v1alpha3.DefaultAgentNamespace, Name: "agent"},
+			})
+			assert.Nil(t, err)
+
+			pod := &v1.Pod{}
+			err = c.Get(context.Background(), client.ObjectKey{Namespace: v1alpha3.DefaultAgentNamespace, Name: "agent"}, pod)
+			if err == nil {
+				assert.Equal(t, tt.expectFinalizer, len(pod.Finalizers) > 0)
+			} else {
+				assert.True(t, apierrors.IsNotFound(err))
+				assert.False(t, tt.expectFinalizer)
+			}
+
+			result := &v1alpha3.PipelineRun{}
+			assert.Nil(t, c.Get(context.Background(), client.ObjectKey{Namespace: "project", Name: "build-1"}, result))
+			assert.Equal(t, tt.expectUsage, result.Status.Usage)
+		})
+	}
+}
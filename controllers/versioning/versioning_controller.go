@@ -0,0 +1,199 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package versioning
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/controllers/gitrepository"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	scmclient "kubesphere.io/devops/pkg/client/scm"
+	"kubesphere.io/devops/pkg/models/versioning"
+)
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelines;gitrepositories,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconciler computes the semantic versions of the PipelineRuns whose Pipelines enable versioning.
+// The version is passed as a parameter before the PipelineRun starts, and it's tagged in the repository
+// once the PipelineRun succeeded if required.
+type Reconciler struct {
+	client.Client
+
+	// NewProvider creates the SCM provider, scmclient.NewProviderFromSecret will be used if it's nil
+	NewProvider gitrepository.ProviderFactory
+
+	recorder record.EventRecorder
+}
+
+// Reconcile computes or tags the version of a PipelineRun
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	pipelineRun := &v1alpha3.PipelineRun{}
+	if err = r.Get(ctx, req.NamespacedName, pipelineRun); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	if !pipelineRun.DeletionTimestamp.IsZero() || pipelineRun.Spec.PipelineRef == nil {
+		return
+	}
+
+	pipeline := &v1alpha3.Pipeline{}
+	if err = r.Get(ctx, client.ObjectKey{Namespace: pipelineRun.Namespace, Name: pipelineRun.Spec.PipelineRef.Name}, pipeline); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	policy := pipeline.Spec.Versioning
+	if policy == nil {
+		return
+	}
+
+	if _, computed := pipelineRun.Annotations[v1alpha3.PipelineRunVersionAnnoKey]; !computed {
+		// it's too late to pass the version to a started PipelineRun
+		if !pipelineRun.HasStarted() && !pipelineRun.HasCompleted() {
+			err = r.computeVersion(ctx, pipeline, pipelineRun)
+		}
+		return
+	}
+	if policy.Tag && pipelineRun.Status.Phase == v1alpha3.Succeeded &&
+		pipelineRun.Annotations[v1alpha3.PipelineRunVersionCommitAnnoKey] != "" &&
+		pipelineRun.Annotations[v1alpha3.PipelineRunVersionTaggedAnnoKey] != "true" {
+		err = r.tagVersion(ctx, pipeline, pipelineRun)
+	}
+	return
+}
+
+// computeVersion passes the next version as a parameter of the PipelineRun. The version annotation is set
+// to empty if the version cannot be computed, so that the PipelineRun will not be held forever.
+func (r *Reconciler) computeVersion(ctx context.Context, pipeline *v1alpha3.Pipeline, pipelineRun *v1alpha3.PipelineRun) error {
+	policy := pipeline.Spec.Versioning
+	parameter := policy.GetParameter()
+	for _, param := range pipelineRun.Spec.Parameters {
+		// the version given by the user takes precedence, it will not be tagged
+		if param.Name == parameter {
+			return r.annotate(ctx, pipelineRun, map[string]string{v1alpha3.PipelineRunVersionAnnoKey: param.Value})
+		}
+	}
+
+	provider, repoPath, err := r.getProvider(ctx, pipeline.Namespace, policy.GitRepository)
+	if apierrors.IsNotFound(err) {
+		r.recorder.Eventf(pipelineRun, v1.EventTypeWarning, "GitRepositoryNotFound", "GitRepository %s not found", policy.GitRepository)
+		return r.annotate(ctx, pipelineRun, map[string]string{v1alpha3.PipelineRunVersionAnnoKey: ""})
+	} else if err != nil {
+		return err
+	}
+
+	ref := policy.Ref
+	if pipeline.IsMultiBranch() && pipelineRun.Spec.SCM != nil && pipelineRun.Spec.SCM.RefName != "" {
+		ref = pipelineRun.Spec.SCM.RefName
+	}
+	next, err := versioning.NextVersion(ctx, provider, repoPath, ref, policy.GetTagPrefix())
+	if err != nil {
+		r.recorder.Eventf(pipelineRun, v1.EventTypeWarning, "VersionFailed", "Failed to compute the version, error was %v", err)
+		return err
+	}
+
+	pipelineRun.Spec.Parameters = append(pipelineRun.Spec.Parameters, v1alpha3.Parameter{Name: parameter, Value: next.Version})
+	annotations := map[string]string{v1alpha3.PipelineRunVersionAnnoKey: next.Version}
+	if next.Bumped {
+		annotations[v1alpha3.PipelineRunVersionCommitAnnoKey] = next.Sha
+	}
+	if err = r.annotate(ctx, pipelineRun, annotations); err == nil {
+		r.recorder.Eventf(pipelineRun, v1.EventTypeNormal, "VersionComputed", "Computed the version %s", next.Version)
+	}
+	return err
+}
+
+// tagVersion creates the version tag on the commit which the version was computed from
+func (r *Reconciler) tagVersion(ctx context.Context, pipeline *v1alpha3.Pipeline, pipelineRun *v1alpha3.PipelineRun) error {
+	policy := pipeline.Spec.Versioning
+	provider, repoPath, err := r.getProvider(ctx, pipeline.Namespace, policy.GitRepository)
+	if err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	tag := policy.GetTagPrefix() + pipelineRun.Annotations[v1alpha3.PipelineRunVersionAnnoKey]
+	sha := pipelineRun.Annotations[v1alpha3.PipelineRunVersionCommitAnnoKey]
+	if err = provider.CreateTag(ctx, repoPath, tag, sha); err != nil {
+		r.recorder.Eventf(pipelineRun, v1.EventTypeWarning, "TagFailed", "Failed to create the tag %s in %s, error was %v", tag, repoPath, err)
+		return err
+	}
+	r.recorder.Eventf(pipelineRun, v1.EventTypeNormal, "Tagged", "Created the tag %s in %s", tag, repoPath)
+	return r.annotate(ctx, pipelineRun, map[string]string{v1alpha3.PipelineRunVersionTaggedAnnoKey: "true"})
+}
+
+func (r *Reconciler) annotate(ctx context.Context, pipelineRun *v1alpha3.PipelineRun, annotations map[string]string) error {
+	if pipelineRun.Annotations == nil {
+		pipelineRun.Annotations = map[string]string{}
+	}
+	for key, value := range annotations {
+		pipelineRun.Annotations[key] = value
+	}
+	return r.Update(ctx, pipelineRun)
+}
+
+func (r *Reconciler) getProvider(ctx context.Context, namespace, name string) (provider scmclient.Provider, repoPath string, err error) {
+	repo := &v1alpha3.GitRepository{}
+	if err = r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, repo); err != nil {
+		return
+	}
+	if repoPath = gitrepository.GetRepoPath(repo); repoPath == "" {
+		err = fmt.Errorf("cannot find the repository from the GitRepository %s", repo.Name)
+		return
+	}
+
+	var secretRef *v1.SecretReference
+	if repo.Spec.Secret != nil {
+		secretRef = repo.Spec.Secret.DeepCopy()
+		if secretRef.Namespace == "" {
+			secretRef.Namespace = repo.Namespace
+		}
+	}
+	newProvider := r.NewProvider
+	if newProvider == nil {
+		newProvider = scmclient.NewProviderFromSecret
+	}
+	provider, err = newProvider(repo.Spec.Provider, repo.Spec.Server, secretRef, r.Client)
+	return
+}
+
+// GetName returns the name of this controller
+func (r *Reconciler) GetName() string {
+	return "versioning-controller"
+}
+
+// GetGroupName returns the group name of this controller
+func (r *Reconciler) GetGroupName() string {
+	return "versioning"
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.GetName()).
+		For(&v1alpha3.PipelineRun{}).
+		Complete(r)
+}
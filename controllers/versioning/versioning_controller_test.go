@@ -0,0 +1,188 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package versioning
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	goscm "github.com/jenkins-x/go-scm/scm"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/git"
+	scmclient "kubesphere.io/devops/pkg/client/scm"
+)
+
+type fakeProvider struct {
+	scmclient.Provider
+	tagErr error
+
+	ref      string
+	tag, sha string
+}
+
+func (p *fakeProvider) ListTags(context.Context, string) ([]*goscm.Reference, error) {
+	return []*goscm.Reference{{Name: "v1.0.0"}}, nil
+}
+
+func (p *fakeProvider) ListCommits(_ context.Context, _, ref, _ string) ([]*goscm.Commit, error) {
+	p.ref = ref
+	return []*goscm.Commit{{Sha: "sha-2", Message: "feat: add the versioning"}}, nil
+}
+
+func (p *fakeProvider) CreateTag(_ context.Context, _, tag, sha string) error {
+	if p.tagErr != nil {
+		return p.tagErr
+	}
+	p.tag, p.sha = tag, sha
+	return nil
+}
+
+func TestReconcile(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	repo := &v1alpha3.GitRepository{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "app"},
+		Spec:       v1alpha3.GitRepositorySpec{Provider: scmclient.GitHub, URL: "https://github.com/org/app.git"},
+	}
+	newPipeline := func(policy *v1alpha3.VersioningPolicy) *v1alpha3.Pipeline {
+		return &v1alpha3.Pipeline{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "build"},
+			Spec:       v1alpha3.PipelineSpec{Type: v1alpha3.NoScmPipelineType, Versioning: policy},
+		}
+	}
+	newPipelineRun := func(annotations map[string]string, phase v1alpha3.RunPhase, params ...v1alpha3.Parameter) *v1alpha3.PipelineRun {
+		return &v1alpha3.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "build-1", Annotations: annotations},
+			Spec: v1alpha3.PipelineRunSpec{
+				PipelineRef: &v1.ObjectReference{Name: "build"},
+				Parameters:  params,
+			},
+			Status: v1alpha3.PipelineRunStatus{Phase: phase},
+		}
+	}
+	computed := map[string]string{
+		v1alpha3.PipelineRunVersionAnnoKey:       "1.1.0",
+		v1alpha3.PipelineRunVersionCommitAnnoKey: "sha-2",
+	}
+
+	tests := []struct {
+		name    string
+		objects []client.Object
+		tagErr  error
+		wantErr bool
+		verify  func(t *testing.T, pipelineRun *v1alpha3.PipelineRun, provider *fakeProvider)
+	}{{
+		name:    "versioning is not enabled",
+		objects: []client.Object{newPipeline(nil), newPipelineRun(nil, ""), repo},
+		verify: func(t *testing.T, pipelineRun *v1alpha3.PipelineRun, provider *fakeProvider) {
+			assert.Empty(t, pipelineRun.Annotations)
+			assert.Empty(t, pipelineRun.Spec.Parameters)
+		},
+	}, {
+		name:    "compute the version",
+		objects: []client.Object{newPipeline(&v1alpha3.VersioningPolicy{GitRepository: "app", Ref: "main"}), newPipelineRun(nil, ""), repo},
+		verify: func(t *testing.T, pipelineRun *v1alpha3.PipelineRun, provider *fakeProvider) {
+			assert.Equal(t, "1.1.0", pipelineRun.Annotations[v1alpha3.PipelineRunVersionAnnoKey])
+			assert.Equal(t, "sha-2", pipelineRun.Annotations[v1alpha3.PipelineRunVersionCommitAnnoKey])
+			assert.Equal(t, []v1alpha3.Parameter{{Name: "VERSION", Value: "1.1.0"}}, pipelineRun.Spec.Parameters)
+			assert.Equal(t, "main", provider.ref)
+		},
+	}, {
+		name: "the version is given by the user",
+		objects: []client.Object{newPipeline(&v1alpha3.VersioningPolicy{GitRepository: "app", Parameter: "TAG"}),
+			newPipelineRun(nil, "", v1alpha3.Parameter{Name: "TAG", Value: "2.0.0"}), repo},
+		verify: func(t *testing.T, pipelineRun *v1alpha3.PipelineRun, provider *fakeProvider) {
+			assert.Equal(t, "2.0.0", pipelineRun.Annotations[v1alpha3.PipelineRunVersionAnnoKey])
+			assert.Empty(t, pipelineRun.Annotations[v1alpha3.PipelineRunVersionCommitAnnoKey])
+			assert.Len(t, pipelineRun.Spec.Parameters, 1)
+		},
+	}, {
+		name:    "GitRepository not found",
+		objects: []client.Object{newPipeline(&v1alpha3.VersioningPolicy{GitRepository: "app"}), newPipelineRun(nil, "")},
+		verify: func(t *testing.T, pipelineRun *v1alpha3.PipelineRun, provider *fakeProvider) {
+			version, ok := pipelineRun.Annotations[v1alpha3.PipelineRunVersionAnnoKey]
+			assert.True(t, ok)
+			assert.Empty(t, version)
+		},
+	}, {
+		name: "started PipelineRun",
+		objects: []client.Object{newPipeline(&v1alpha3.VersioningPolicy{GitRepository: "app"}),
+			newPipelineRun(map[string]string{v1alpha3.JenkinsPipelineRunIDAnnoKey: "1"}, v1alpha3.Running), repo},
+		verify: func(t *testing.T, pipelineRun *v1alpha3.PipelineRun, provider *fakeProvider) {
+			assert.NotContains(t, pipelineRun.Annotations, v1alpha3.PipelineRunVersionAnnoKey)
+		},
+	}, {
+		name:    "tag the succeeded PipelineRun",
+		objects: []client.Object{newPipeline(&v1alpha3.VersioningPolicy{GitRepository: "app", Tag: true}), newPipelineRun(computed, v1alpha3.Succeeded), repo},
+		verify: func(t *testing.T, pipelineRun *v1alpha3.PipelineRun, provider *fakeProvider) {
+			assert.Equal(t, "v1.1.0", provider.tag)
+			assert.Equal(t, "sha-2", provider.sha)
+			assert.Equal(t, "true", pipelineRun.Annotations[v1alpha3.PipelineRunVersionTaggedAnnoKey])
+		},
+	}, {
+		name:    "do not tag the failed PipelineRun",
+		objects: []client.Object{newPipeline(&v1alpha3.VersioningPolicy{GitRepository: "app", Tag: true}), newPipelineRun(computed, v1alpha3.Failed), repo},
+		verify: func(t *testing.T, pipelineRun *v1alpha3.PipelineRun, provider *fakeProvider) {
+			assert.Empty(t, provider.tag)
+		},
+	}, {
+		name:    "tagging is not required",
+		objects: []client.Object{newPipeline(&v1alpha3.VersioningPolicy{GitRepository: "app"}), newPipelineRun(computed, v1alpha3.Succeeded), repo},
+		verify: func(t *testing.T, pipelineRun *v1alpha3.PipelineRun, provider *fakeProvider) {
+			assert.Empty(t, provider.tag)
+		},
+	}, {
+		name:    "failed to tag",
+		objects: []client.Object{newPipeline(&v1alpha3.VersioningPolicy{GitRepository: "app", Tag: true}), newPipelineRun(computed, v1alpha3.Succeeded), repo},
+		tagErr:  fmt.Errorf("forbidden"),
+		wantErr: true,
+		verify: func(t *testing.T, pipelineRun *v1alpha3.PipelineRun, provider *fakeProvider) {
+			assert.NotContains(t, pipelineRun.Annotations, v1alpha3.PipelineRunVersionTaggedAnnoKey)
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(tt.objects...).Build()
+			provider := &fakeProvider{tagErr: tt.tagErr}
+			r := &Reconciler{
+				Client: c,
+				NewProvider: func(name, server string, ref *v1.SecretReference, _ git.ResourceGetter) (scmclient.Provider, error) {
+					return provider, nil
+				},
+				recorder: &record.FakeRecorder{},
+			}
+			key := client.ObjectKey{Namespace: "ns", Name: "build-1"}
+			_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+			assert.Equal(t, tt.wantErr, err != nil, err)
+
+			pipelineRun := &v1alpha3.PipelineRun{}
+			assert.Nil(t, c.Get(context.TODO(), key, pipelineRun))
+			tt.verify(t, pipelineRun, provider)
+		})
+	}
+}
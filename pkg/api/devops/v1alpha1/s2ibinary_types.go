@@ -17,6 +17,8 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"fmt"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -32,11 +34,34 @@ const (
 	StatusUploadFailed = "UploadFailed"
 )
 
+const (
+	// StatusScanning means the uploaded file is waiting for a scan, it cannot be consumed until the scan passes
+	StatusScanning = "Scanning"
+	// StatusQuarantined means malware was found in the uploaded file
+	StatusQuarantined = "Quarantined"
+)
+
+const (
+	// ScanVerdictClean means no malware was found
+	ScanVerdictClean = "Clean"
+	// ScanVerdictInfected means malware was found
+	ScanVerdictInfected = "Infected"
+	// ScanVerdictError means the file could not be scanned
+	ScanVerdictError = "Error"
+)
+
 const (
 	S2iBinaryFinalizerName = "s2ibinary.finalizers.kubesphere.io"
 	S2iBinaryLabelKey      = "s2ibinary-name.kubesphere.io"
 )
 
+const (
+	// S2iBinaryUploadIDAnnoKey is the ID of the multipart upload which is in progress
+	S2iBinaryUploadIDAnnoKey = "s2ibinary.kubesphere.io/upload-id"
+	// S2iBinaryUploadFileNameAnnoKey is the file name of the multipart upload which is in progress
+	S2iBinaryUploadFileNameAnnoKey = "s2ibinary.kubesphere.io/upload-filename"
+)
+
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
@@ -58,6 +83,23 @@ type S2iBinarySpec struct {
 type S2iBinaryStatus struct {
 	//Phase is status of S2iBinary . Possible value is "Ready","UnableToDownload"
 	Phase string `json:"phase,omitempty"`
+
+	// Scan is the result of scanning the uploaded file for viruses and malware
+	Scan *S2iBinaryScan `json:"scan,omitempty"`
+}
+
+// S2iBinaryScan is the scanning verdict of an uploaded file
+type S2iBinaryScan struct {
+	// Scanner is the name of the scanner, e.g. clamav
+	Scanner string `json:"scanner,omitempty"`
+	// Verdict is the result of the scan. Possible value is "Clean","Infected","Error"
+	Verdict string `json:"verdict,omitempty"`
+	// Message is the malware signature, or the reason why the scan failed
+	Message string `json:"message,omitempty"`
+	// Revision identifies the uploaded file which was scanned
+	Revision string `json:"revision,omitempty"`
+	// ScanTime is the time of the scan
+	ScanTime *metav1.Time `json:"scanTime,omitempty"`
 }
 
 // +genclient
@@ -77,6 +119,11 @@ type S2iBinary struct {
 	Status S2iBinaryStatus `json:"status,omitempty"`
 }
 
+// GetS2iBinaryKey returns the object key of the uploaded file in S3
+func GetS2iBinaryKey(namespace, name string) string {
+	return fmt.Sprintf("%s-%s", namespace, name)
+}
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // S2iBinaryList contains a list of S2iBinary
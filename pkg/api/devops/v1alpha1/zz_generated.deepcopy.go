@@ -267,7 +267,7 @@ func (in *S2iBinary) DeepCopyInto(out *S2iBinary) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S2iBinary.
@@ -320,6 +320,25 @@ func (in *S2iBinaryList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *S2iBinaryScan) DeepCopyInto(out *S2iBinaryScan) {
+	*out = *in
+	if in.ScanTime != nil {
+		in, out := &in.ScanTime, &out.ScanTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S2iBinaryScan.
+func (in *S2iBinaryScan) DeepCopy() *S2iBinaryScan {
+	if in == nil {
+		return nil
+	}
+	out := new(S2iBinaryScan)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *S2iBinarySpec) DeepCopyInto(out *S2iBinarySpec) {
 	*out = *in
@@ -342,6 +361,11 @@ func (in *S2iBinarySpec) DeepCopy() *S2iBinarySpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *S2iBinaryStatus) DeepCopyInto(out *S2iBinaryStatus) {
 	*out = *in
+	if in.Scan != nil {
+		in, out := &in.Scan, &out.Scan
+		*out = new(S2iBinaryScan)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S2iBinaryStatus.
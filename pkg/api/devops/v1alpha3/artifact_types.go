@@ -0,0 +1,54 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// ArtifactOutput declares a file produced by a Pipeline, which will be archived into the object storage
+// after the PipelineRun completed.
+type ArtifactOutput struct {
+	// Name is the unique name of the artifact in a Pipeline.
+	Name string `json:"name"`
+	// Path is the path of the file which is archived by the backend, such as "target/app.jar".
+	Path string `json:"path"`
+}
+
+// PipelineRunArtifact is an artifact of a PipelineRun which has been archived into the object storage.
+type PipelineRunArtifact struct {
+	// Name is the name of the corresponding ArtifactOutput.
+	Name string `json:"name"`
+	// Path is the path of the file which is archived by the backend.
+	Path string `json:"path"`
+	// Key is the object key in the object storage.
+	Key string `json:"key"`
+	// Size is the size of the artifact in bytes.
+	// +optional
+	Size int64 `json:"size,omitempty"`
+	// ArchiveTime is the time when the artifact was archived.
+	// +optional
+	ArchiveTime *metav1.Time `json:"archiveTime,omitempty"`
+}
+
+// GetArtifact returns the archived artifact by name.
+func (prStatus *PipelineRunStatus) GetArtifact(name string) *PipelineRunArtifact {
+	for i := range prStatus.Artifacts {
+		if prStatus.Artifacts[i].Name == name {
+			return &prStatus.Artifacts[i]
+		}
+	}
+	return nil
+}
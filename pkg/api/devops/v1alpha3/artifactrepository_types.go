@@ -0,0 +1,210 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ArtifactRepositoryType is the product of a binary repository
+type ArtifactRepositoryType string
+
+// The supported binary repositories
+const (
+	// ArtifactRepositoryNexus is Sonatype Nexus Repository 3
+	ArtifactRepositoryNexus ArtifactRepositoryType = "nexus"
+	// ArtifactRepositoryArtifactory is JFrog Artifactory
+	ArtifactRepositoryArtifactory ArtifactRepositoryType = "artifactory"
+)
+
+// The formats of the artifact repositories
+const (
+	ArtifactFormatMaven = "maven"
+	ArtifactFormatNPM   = "npm"
+	ArtifactFormatPyPI  = "pypi"
+	ArtifactFormatRaw   = "raw"
+)
+
+// ArtifactRepositoryPhase is the phase of an ArtifactRepository
+type ArtifactRepositoryPhase string
+
+const (
+	// ArtifactRepositoryReady indicates the repository is reachable with the credential
+	ArtifactRepositoryReady ArtifactRepositoryPhase = "Ready"
+	// ArtifactRepositoryFailed indicates the repository is not reachable, see the message for the details
+	ArtifactRepositoryFailed ArtifactRepositoryPhase = "Failed"
+)
+
+// The parameters which are passed to the PipelineRuns whose Pipelines use an ArtifactRepository,
+// they work as both the parameters of Jenkins and the params of Tekton tasks
+const (
+	ArtifactRepositoryURLParameter        = "ARTIFACT_REPOSITORY_URL"
+	ArtifactRepositoryFormatParameter     = "ARTIFACT_REPOSITORY_FORMAT"
+	ArtifactRepositoryCredentialParameter = "ARTIFACT_REPOSITORY_CREDENTIAL"
+	// ArtifactRepositorySettingsParameter is the ID of the Maven settings file in Jenkins, it's only passed for
+	// the maven repositories
+	ArtifactRepositorySettingsParameter = "ARTIFACT_REPOSITORY_SETTINGS"
+	HTTPProxyParameter                  = "HTTP_PROXY"
+	HTTPSProxyParameter                 = "HTTPS_PROXY"
+	NoProxyParameter                    = "NO_PROXY"
+)
+
+// The environment variables which the Maven settings file in Jenkins reads the credential from,
+// they are supposed to be bound by withCredentials
+const (
+	ArtifactRepositoryUsernameEnv = "ARTIFACT_REPOSITORY_USERNAME"
+	ArtifactRepositoryPasswordEnv = "ARTIFACT_REPOSITORY_PASSWORD"
+)
+
+// ArtifactRepositoryFinalizerName is the finalizer which removes the Maven settings of an ArtifactRepository from Jenkins
+const ArtifactRepositoryFinalizerName = "artifactrepository.finalizers.kubesphere.io"
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:categories="devops"
+//+kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.type`
+//+kubebuilder:printcolumn:name="Format",type=string,JSONPath=`.spec.format`
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ArtifactRepository is a repository of Nexus or Artifactory in a DevOpsProject. The Pipelines resolve
+// and publish their artifacts through it with the credential which is managed by the DevOpsProject.
+type ArtifactRepository struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ArtifactRepositorySpec   `json:"spec,omitempty"`
+	Status ArtifactRepositoryStatus `json:"status,omitempty"`
+}
+
+// ArtifactRepositorySpec is the configuration of an ArtifactRepository
+type ArtifactRepositorySpec struct {
+	// Type is the product of the binary repository
+	// +kubebuilder:validation:Enum=nexus;artifactory
+	Type ArtifactRepositoryType `json:"type"`
+
+	// Server is the address of the binary repository, such as https://nexus.example.com
+	Server string `json:"server"`
+
+	// Repository is the name of the repository on the server
+	Repository string `json:"repository"`
+
+	// Format is the format of the repository, defaults to raw
+	// +kubebuilder:validation:Enum=maven;npm;pypi;raw
+	// +optional
+	Format string `json:"format,omitempty"`
+
+	// Secret is the name of a credential in the same namespace which has the type SecretTypeBasicAuth
+	// +optional
+	Secret string `json:"secret,omitempty"`
+
+	// Proxy is the HTTP proxy to access the server
+	// +optional
+	Proxy *ArtifactRepositoryProxy `json:"proxy,omitempty"`
+}
+
+// ArtifactRepositoryProxy is an HTTP proxy
+type ArtifactRepositoryProxy struct {
+	// URL is the address of the proxy, such as http://proxy.example.com:3128
+	URL string `json:"url"`
+
+	// NoProxy are the hosts which are accessed directly
+	// +optional
+	NoProxy []string `json:"noProxy,omitempty"`
+}
+
+// ArtifactRepositoryStatus is the observed state of an ArtifactRepository
+type ArtifactRepositoryStatus struct {
+	// Phase indicates whether the repository is reachable
+	// +optional
+	Phase ArtifactRepositoryPhase `json:"phase,omitempty"`
+
+	// URL is the address of the repository, the artifacts are located under it
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// Message tells the details of the phase
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// LastCheckTime is the last time the repository was checked
+	// +optional
+	LastCheckTime *metav1.Time `json:"lastCheckTime,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ArtifactRepositoryList contains a list of ArtifactRepository
+type ArtifactRepositoryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ArtifactRepository `json:"items"`
+}
+
+// GetFormat returns the format of the repository
+func (spec *ArtifactRepositorySpec) GetFormat() string {
+	if spec.Format == "" {
+		return ArtifactFormatRaw
+	}
+	return spec.Format
+}
+
+// GetRepositoryURL returns the address of the repository, the artifacts are located under it
+func (spec *ArtifactRepositorySpec) GetRepositoryURL() string {
+	server := strings.TrimSuffix(spec.Server, "/")
+	switch spec.Type {
+	case ArtifactRepositoryArtifactory:
+		return fmt.Sprintf("%s/artifactory/%s", server, spec.Repository)
+	default:
+		return fmt.Sprintf("%s/repository/%s", server, spec.Repository)
+	}
+}
+
+// GetSettingsID returns the ID of the Maven settings file in Jenkins
+func (r *ArtifactRepository) GetSettingsID() string {
+	return r.Namespace + "-" + r.Name
+}
+
+// GetParameters returns the parameters which are passed to the PipelineRuns using the ArtifactRepository
+func (r *ArtifactRepository) GetParameters() (parameters []Parameter) {
+	parameters = []Parameter{
+		{Name: ArtifactRepositoryURLParameter, Value: r.Spec.GetRepositoryURL()},
+		{Name: ArtifactRepositoryFormatParameter, Value: r.Spec.GetFormat()},
+	}
+	if r.Spec.Secret != "" {
+		parameters = append(parameters, Parameter{Name: ArtifactRepositoryCredentialParameter, Value: r.Spec.Secret})
+	}
+	if r.Spec.GetFormat() == ArtifactFormatMaven {
+		parameters = append(parameters, Parameter{Name: ArtifactRepositorySettingsParameter, Value: r.GetSettingsID()})
+	}
+	if proxy := r.Spec.Proxy; proxy != nil && proxy.URL != "" {
+		parameters = append(parameters,
+			Parameter{Name: HTTPProxyParameter, Value: proxy.URL},
+			Parameter{Name: HTTPSProxyParameter, Value: proxy.URL})
+		if len(proxy.NoProxy) > 0 {
+			parameters = append(parameters, Parameter{Name: NoProxyParameter, Value: strings.Join(proxy.NoProxy, ",")})
+		}
+	}
+	return
+}
+
+func init() {
+	SchemeBuilder.Register(&ArtifactRepository{}, &ArtifactRepositoryList{})
+}
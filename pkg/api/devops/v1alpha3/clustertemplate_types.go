@@ -25,8 +25,12 @@ var _ TemplateObject = &ClusterTemplate{}
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 //+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.spec.version`,description="The version of the template"
+//+kubebuilder:printcolumn:name="Deprecated",type=boolean,JSONPath=`.spec.deprecated`,description="Whether the template is deprecated"
 
-// ClusterTemplate is the Schema for the clustertemplates API
+// ClusterTemplate is the Schema for the clustertemplates API.
+// The ClusterTemplates are published by the cluster administrators, all DevOpsProjects can refer to them
+// but are not able to change them.
 type ClusterTemplate struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
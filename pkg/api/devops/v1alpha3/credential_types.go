@@ -67,6 +67,22 @@ const (
 	SecretTypeKubeConfig v1.SecretType = DevOpsCredentialPrefix + "kubeconfig"
 	// KubeConfigSecretKey is the key of the secret for SecretTypeKubeConfig secrets
 	KubeConfigSecretKey = "content"
+
+	// SecretTypeCosignKey contains a key pair generated by cosign, which signs the images and the attestations.
+	// The keys are the same as the ones generated by "cosign generate-key-pair k8s://namespace/name".
+	// It is not synchronized to Jenkins.
+	//
+	// Required fields:
+	// - Secret.Data["cosign.key"] - the encrypted private key
+	// - Secret.Data["cosign.password"] - the password of the private key
+	// - Secret.Data["cosign.pub"] - the public key
+	SecretTypeCosignKey v1.SecretType = DevOpsCredentialPrefix + "cosign-key"
+	// CosignPrivateKey is the key of the private key for SecretTypeCosignKey secrets
+	CosignPrivateKey = "cosign.key"
+	// CosignPasswordKey is the key of the password for SecretTypeCosignKey secrets
+	CosignPasswordKey = "cosign.password"
+	// CosignPublicKey is the key of the public key for SecretTypeCosignKey secrets
+	CosignPublicKey = "cosign.pub"
 	//	CredentialAutoSyncAnnoKey is used to indicate whether the secret is automatically synchronized to devops.
 	//	In the old version, the credential is stored in jenkins and cannot be obtained.
 	//	This field is set to ensure that the secret is not overwritten by a nil value.
@@ -74,6 +90,32 @@ const (
 	CredentialSyncStatusAnnoKey = DevOpsCredentialPrefix + "syncstatus"
 	CredentialSyncTimeAnnoKey   = DevOpsCredentialPrefix + "synctime"
 	CredentialSyncMsgAnnoKey    = DevOpsCredentialPrefix + "syncmsg"
+
+	// CredentialExpireTimeAnnoKey is the time (RFC3339) when the credential expires
+	CredentialExpireTimeAnnoKey = DevOpsCredentialPrefix + "expire-time"
+	// CredentialExpiryStatusAnnoKey is the expiry status of the credential, see CredentialExpiryStatus
+	CredentialExpiryStatusAnnoKey = DevOpsCredentialPrefix + "expiry-status"
+	// CredentialRotatorAnnoKey is the name of the rotator which regenerates the credential before it expires
+	CredentialRotatorAnnoKey = DevOpsCredentialPrefix + "rotator"
+	// CredentialRotatorServerAnnoKey is the server which the rotator regenerates the credential by, such as
+	// https://gitlab.example.com. The public service of the rotator is used if it's not set.
+	CredentialRotatorServerAnnoKey = DevOpsCredentialPrefix + "rotator-server"
+	// CredentialExternalRefAnnoKey refers to the secret in an external store, such as vault://ci/github. The path is
+	// relative to the place of the DevOpsProject in the store, so the secrets of other projects are not accessible.
+	// The data of the credential is read from the external store when it's synchronized.
+	CredentialExternalRefAnnoKey = DevOpsCredentialPrefix + "external-ref"
+)
+
+// CredentialExpiryStatus is the expiry status of a credential
+type CredentialExpiryStatus string
+
+const (
+	// CredentialValid indicates the credential is not going to expire soon
+	CredentialValid CredentialExpiryStatus = "Valid"
+	// CredentialExpiringSoon indicates the credential is going to expire soon
+	CredentialExpiringSoon CredentialExpiryStatus = "ExpiringSoon"
+	// CredentialExpired indicates the credential has expired
+	CredentialExpired CredentialExpiryStatus = "Expired"
 )
 
 var supportedCredentialTypes = []v1.SecretType{
@@ -81,6 +123,7 @@ var supportedCredentialTypes = []v1.SecretType{
 	SecretTypeSSHAuth,
 	SecretTypeSecretText,
 	SecretTypeKubeConfig,
+	SecretTypeCosignKey,
 }
 
 // GetSupportedCredentialTypes gets all supported credential types. The return value is unmodifiable.
@@ -0,0 +1,105 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// PipelineTrashFinalizerName is the finalizer which moves the deleted Pipeline into the trash bin
+	PipelineTrashFinalizerName = "trash.finalizers.kubesphere.io"
+	// PipelineTrashedAnnoKey is the annotation key of the DeletedPipeline which the deleted Pipeline was moved to,
+	// the backend keeps the resource of the Pipeline if it's set
+	PipelineTrashedAnnoKey = PipelinePrefix + "trashed"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:categories="devops"
+//+kubebuilder:printcolumn:name="Pipeline",type=string,JSONPath=`.spec.pipeline`
+//+kubebuilder:printcolumn:name="Expiration",type=date,JSONPath=`.spec.expirationTime`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// DeletedPipeline is a Pipeline in the trash bin. The resource of the Pipeline in the backend, such as the Jenkins
+// job, is kept until the expiration time, so that the Pipeline could be undeleted with its history.
+type DeletedPipeline struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec DeletedPipelineSpec `json:"spec,omitempty"`
+}
+
+// DeletedPipelineSpec is the archived copy of a deleted Pipeline
+type DeletedPipelineSpec struct {
+	// Pipeline is the name of the deleted Pipeline
+	Pipeline string `json:"pipeline"`
+
+	// UID is the UID of the deleted Pipeline
+	// +optional
+	UID types.UID `json:"uid,omitempty"`
+
+	// Labels are the labels of the deleted Pipeline
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations are the annotations of the deleted Pipeline
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// PipelineSpec is the spec of the deleted Pipeline, it's not validated since the schema of Pipeline evolves
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	PipelineSpec PipelineSpec `json:"pipelineSpec"`
+
+	// DeletionTime is the time when the Pipeline was deleted
+	DeletionTime metav1.Time `json:"deletionTime"`
+
+	// ExpirationTime is the time when the resource in the backend and the DeletedPipeline are removed permanently
+	ExpirationTime metav1.Time `json:"expirationTime"`
+}
+
+// IsExpired returns true if the DeletedPipeline should be removed permanently
+func (d *DeletedPipeline) IsExpired(now metav1.Time) bool {
+	return !now.Before(&d.Spec.ExpirationTime)
+}
+
+//+kubebuilder:object:root=true
+
+// DeletedPipelineList contains a list of DeletedPipeline
+type DeletedPipelineList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DeletedPipeline `json:"items"`
+}
+
+// GetDeletedPipelineName returns the name of the DeletedPipeline of a Pipeline, the UID tells apart the Pipelines
+// which have the same name
+func GetDeletedPipelineName(pipeline *Pipeline) string {
+	uid := string(pipeline.UID)
+	if len(uid) > 8 {
+		uid = uid[:8]
+	}
+	return fmt.Sprintf("%s-%s", pipeline.Name, uid)
+}
+
+func init() {
+	SchemeBuilder.Register(&DeletedPipeline{}, &DeletedPipelineList{})
+}
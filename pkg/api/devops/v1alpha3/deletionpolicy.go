@@ -0,0 +1,84 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DeletionPolicy decides whether deleting a Pipeline or PipelineRun deletes its resource in the backend as well,
+// such as the Jenkins job of a Pipeline and the Jenkins build of a PipelineRun.
+// +kubebuilder:validation:Enum=Delete;Orphan;Retain
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes the resource in the backend before the object is gone, it's the default policy
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyOrphan keeps the resource in the backend, and orphans the dependents of the object instead of
+	// deleting them, such as the PipelineRuns of a Pipeline
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+	// DeletionPolicyRetain keeps the resource in the backend, the object has no finalizer so that its deletion
+	// never waits for the backend, the dependents are deleted as usual
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+)
+
+// GetDeletionPolicy returns the deletion policy of the Pipeline
+func (s *PipelineSpec) GetDeletionPolicy() DeletionPolicy {
+	if s == nil || s.DeletionPolicy == "" {
+		return DeletionPolicyDelete
+	}
+	return s.DeletionPolicy
+}
+
+// GetDeletionPolicy returns the deletion policy of the PipelineRun, it's inherited from its Pipeline if it's empty
+func (prSpec *PipelineRunSpec) GetDeletionPolicy() DeletionPolicy {
+	if prSpec.DeletionPolicy != "" {
+		return prSpec.DeletionPolicy
+	}
+	return prSpec.PipelineSpec.GetDeletionPolicy()
+}
+
+// ApplyFinalizers makes the finalizers of the object match the deletion policy. The finalizer of the controller,
+// which deletes the resource in the backend, is only kept by the Delete policy. The orphan finalizer, which asks
+// the garbage collector to orphan the dependents, is only kept by the Orphan policy. It returns true if changed.
+func (p DeletionPolicy) ApplyFinalizers(objectMeta *metav1.ObjectMeta, finalizer string) (changed bool) {
+	desired := map[string]bool{
+		finalizer:                        p == DeletionPolicyDelete || p == "",
+		metav1.FinalizerOrphanDependents: p == DeletionPolicyOrphan,
+	}
+	var finalizers []string
+	for _, item := range objectMeta.Finalizers {
+		if keep, ok := desired[item]; ok {
+			if !keep {
+				changed = true
+				continue
+			}
+			delete(desired, item)
+		}
+		finalizers = append(finalizers, item)
+	}
+	for _, item := range []string{finalizer, metav1.FinalizerOrphanDependents} {
+		if desired[item] {
+			finalizers = append(finalizers, item)
+			changed = true
+		}
+	}
+	if changed {
+		objectMeta.Finalizers = finalizers
+	}
+	return
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPipelineRunSpec_GetDeletionPolicy(t *testing.T) {
+	assert.Equal(t, DeletionPolicyDelete, (&PipelineRunSpec{}).GetDeletionPolicy())
+	assert.Equal(t, DeletionPolicyRetain, (&PipelineRunSpec{
+		PipelineSpec: &PipelineSpec{DeletionPolicy: DeletionPolicyRetain},
+	}).GetDeletionPolicy())
+	assert.Equal(t, DeletionPolicyOrphan, (&PipelineRunSpec{
+		DeletionPolicy: DeletionPolicyOrphan,
+		PipelineSpec:   &PipelineSpec{DeletionPolicy: DeletionPolicyRetain},
+	}).GetDeletionPolicy())
+}
+
+func TestDeletionPolicy_ApplyFinalizers(t *testing.T) {
+	const finalizer = "fake.finalizers.kubesphere.io"
+	tests := []struct {
+		name          string
+		policy        DeletionPolicy
+		finalizers    []string
+		expect        []string
+		expectChanged bool
+	}{{
+		name:          "default",
+		expect:        []string{finalizer},
+		expectChanged: true,
+	}, {
+		name:       "delete",
+		policy:     DeletionPolicyDelete,
+		finalizers: []string{"other", finalizer},
+		expect:     []string{"other", finalizer},
+	}, {
+		name:          "orphan",
+		policy:        DeletionPolicyOrphan,
+		finalizers:    []string{"other", finalizer},
+		expect:        []string{"other", metav1.FinalizerOrphanDependents},
+		expectChanged: true,
+	}, {
+		name:          "retain",
+		policy:        DeletionPolicyRetain,
+		finalizers:    []string{finalizer, metav1.FinalizerOrphanDependents},
+		expectChanged: true,
+	}, {
+		name:   "retain without finalizers",
+		policy: DeletionPolicyRetain,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objectMeta := &metav1.ObjectMeta{Finalizers: tt.finalizers}
+			assert.Equal(t, tt.expectChanged, tt.policy.ApplyFinalizers(objectMeta, finalizer))
+			assert.Equal(t, tt.expect, objectMeta.Finalizers)
+		})
+	}
+}
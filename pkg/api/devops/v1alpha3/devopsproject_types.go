@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha3
 
 import (
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -36,6 +37,118 @@ const (
 // DevOpsProjectSpec defines the desired state of DevOpsProject
 type DevOpsProjectSpec struct {
 	Argo *Argo `json:"argo,omitempty"`
+
+	// PipelineBackend is the backend which runs the Pipelines of this project, such as Jenkins or Tekton.
+	// The default backend of the controller manager will be used if it is empty.
+	// +optional
+	PipelineBackend string `json:"pipelineBackend,omitempty"`
+
+	// CommitStatus reports the status of PipelineRuns back to the SCM when it is set.
+	// +optional
+	CommitStatus *CommitStatus `json:"commitStatus,omitempty"`
+
+	// Quota limits the Pipelines and PipelineRuns of this project.
+	// +optional
+	Quota *ProjectQuota `json:"quota,omitempty"`
+
+	// Placement replicates the project, as well as its Pipelines and credentials, to the member clusters.
+	// +optional
+	Placement *ProjectPlacement `json:"placement,omitempty"`
+}
+
+// ProjectPlacement declares the member clusters which a DevOpsProject is replicated to
+type ProjectPlacement struct {
+	// Clusters are the names of the member clusters, they are cluster.kubesphere.io Clusters.
+	// +optional
+	Clusters []string `json:"clusters,omitempty"`
+}
+
+// GetPlacedClusters returns the member clusters which the DevOpsProject is replicated to
+func (spec *DevOpsProjectSpec) GetPlacedClusters() []string {
+	if spec.Placement == nil {
+		return nil
+	}
+	return spec.Placement.Clusters
+}
+
+// ClusterSyncState is the sync state of a member cluster
+type ClusterSyncState string
+
+const (
+	// ClusterSynced means the project and its resources are consistent with the member cluster
+	ClusterSynced ClusterSyncState = "Synced"
+	// ClusterSyncFailed means the project or some of its resources failed to be replicated
+	ClusterSyncFailed ClusterSyncState = "Failed"
+)
+
+// ClusterSyncStatus is the sync state of a member cluster which a DevOpsProject is replicated to
+type ClusterSyncStatus struct {
+	// Cluster is the name of the member cluster
+	Cluster string `json:"cluster"`
+	// State is the sync state, it could be Synced or Failed
+	State ClusterSyncState `json:"state"`
+	// Message tells why the sync failed
+	// +optional
+	Message string `json:"message,omitempty"`
+	// Pipelines is the number of the replicated Pipelines
+	// +optional
+	Pipelines int32 `json:"pipelines,omitempty"`
+	// Credentials is the number of the replicated credentials
+	// +optional
+	Credentials int32 `json:"credentials,omitempty"`
+	// LastTransitionTime is the last time the state changed
+	// +optional
+	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// ProjectQuota limits the resources of a DevOpsProject, zero means no limit
+type ProjectQuota struct {
+	// MaxPipelines is the maximum number of Pipelines, the excess Pipelines will be rejected.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxPipelines int32 `json:"maxPipelines,omitempty"`
+	// MaxConcurrentRuns is the maximum number of running PipelineRuns, the excess PipelineRuns will be queued.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxConcurrentRuns int32 `json:"maxConcurrentRuns,omitempty"`
+	// MaxRunMinutesPerDay is the maximum minutes of PipelineRuns per day (UTC),
+	// the PipelineRuns created after reaching the limit will be cancelled.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxRunMinutesPerDay int32 `json:"maxRunMinutesPerDay,omitempty"`
+}
+
+// ProjectQuotaUsage is the current usage of the quota of a DevOpsProject
+type ProjectQuotaUsage struct {
+	// Pipelines is the number of Pipelines
+	Pipelines int32 `json:"pipelines"`
+	// RunningPipelineRuns is the number of PipelineRuns which are running
+	RunningPipelineRuns int32 `json:"runningPipelineRuns"`
+	// RunMinutesToday is the minutes of PipelineRuns spent today (UTC)
+	RunMinutesToday int32 `json:"runMinutesToday"`
+	// UpdateTime is the last time the usage was calculated
+	// +optional
+	UpdateTime *metav1.Time `json:"updateTime,omitempty"`
+}
+
+// CommitStatus is the SCM provider and credential which are used to create commit statuses
+type CommitStatus struct {
+	// Provider is the SCM provider, such as github, gitlab, bitbucket_cloud, bitbucket-server and gitea.
+	Provider string `json:"provider"`
+	// Server is the address of a self-hosted SCM server, it could be empty for the public services.
+	// +optional
+	Server string `json:"server,omitempty"`
+	// Secret holds the token which is able to create commit statuses.
+	// The namespace of the PipelineRun will be used if the namespace of the secret is empty.
+	// +optional
+	Secret *v1.SecretReference `json:"secret,omitempty"`
+	// Label is the context of commit statuses, "KubeSphere DevOps" by default.
+	// +optional
+	Label string `json:"label,omitempty"`
+	// RevisionParameter is the PipelineRun parameter which carries the commit SHA, "GIT_COMMIT" by default.
+	// It's only used when the PipelineRun was not triggered by a webhook.
+	// +optional
+	RevisionParameter string `json:"revisionParameter,omitempty"`
 }
 
 // Argo represents the Argo CD specification
@@ -143,6 +256,14 @@ type SignatureKey struct {
 // DevOpsProjectStatus defines the observed state of DevOpsProject
 type DevOpsProjectStatus struct {
 	AdminNamespace string `json:"adminNamespace,omitempty"`
+
+	// QuotaUsage is the usage of the quota, it's only reported when the quota is set
+	// +optional
+	QuotaUsage *ProjectQuotaUsage `json:"quotaUsage,omitempty"`
+
+	// Clusters are the sync states of the member clusters which the project is replicated to.
+	// +optional
+	Clusters []ClusterSyncStatus `json:"clusters,omitempty"`
 }
 
 // +genclient
@@ -0,0 +1,103 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DevOpsProjectConfigName is the name of the DevOpsProjectConfig which takes effect in a DevOpsProject,
+	// the others are ignored
+	DevOpsProjectConfigName = "default"
+)
+
+// The parameters which are passed to the PipelineRuns of a DevOpsProject which has a DevOpsProjectConfig,
+// they work as both the parameters of Jenkins and the params of Tekton tasks. The proxy is passed as
+// HTTPProxyParameter and its siblings.
+const (
+	// ImageRegistryParameter is the address of the default image registry
+	ImageRegistryParameter = "IMAGE_REGISTRY"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:categories="devops"
+//+kubebuilder:printcolumn:name="Registry",type=string,JSONPath=`.spec.registry`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// DevOpsProjectConfig is the shared configuration of the PipelineRuns in a DevOpsProject, such as the image
+// registry, the proxy and the default parameters. Only the one named DevOpsProjectConfigName takes effect.
+type DevOpsProjectConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec DevOpsProjectConfigSpec `json:"spec,omitempty"`
+}
+
+// DevOpsProjectConfigSpec is the shared configuration of the PipelineRuns
+type DevOpsProjectConfigSpec struct {
+	// Registry is the address of the default image registry, such as harbor.example.com/library
+	// +optional
+	Registry string `json:"registry,omitempty"`
+
+	// Proxy is the HTTP proxy of the PipelineRuns
+	// +optional
+	Proxy *ArtifactRepositoryProxy `json:"proxy,omitempty"`
+
+	// Env are the environment variables of the PipelineRuns. They are only passed as parameters, the Jenkins
+	// Pipelines need to define the parameters to read them.
+	// +optional
+	Env []Parameter `json:"env,omitempty"`
+
+	// Parameters are the default parameters of the PipelineRuns, the parameters given by users take precedence
+	// +optional
+	Parameters []Parameter `json:"parameters,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// DevOpsProjectConfigList contains a list of DevOpsProjectConfig
+type DevOpsProjectConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DevOpsProjectConfig `json:"items"`
+}
+
+// GetParameters returns the parameters which are passed to the PipelineRuns, the former ones take precedence
+// if there are duplicated names
+func (c *DevOpsProjectConfig) GetParameters() (parameters []Parameter) {
+	parameters = append(parameters, c.Spec.Parameters...)
+	parameters = append(parameters, c.Spec.Env...)
+	if c.Spec.Registry != "" {
+		parameters = append(parameters, Parameter{Name: ImageRegistryParameter, Value: c.Spec.Registry})
+	}
+	if proxy := c.Spec.Proxy; proxy != nil && proxy.URL != "" {
+		parameters = append(parameters,
+			Parameter{Name: HTTPProxyParameter, Value: proxy.URL},
+			Parameter{Name: HTTPSProxyParameter, Value: proxy.URL})
+		if len(proxy.NoProxy) > 0 {
+			parameters = append(parameters, Parameter{Name: NoProxyParameter, Value: strings.Join(proxy.NoProxy, ",")})
+		}
+	}
+	return
+}
+
+func init() {
+	SchemeBuilder.Register(&DevOpsProjectConfig{}, &DevOpsProjectConfigList{})
+}
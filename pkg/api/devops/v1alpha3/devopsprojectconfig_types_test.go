@@ -0,0 +1,46 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDevOpsProjectConfig(t *testing.T) {
+	config := &DevOpsProjectConfig{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: DevOpsProjectConfigName},
+	}
+	assert.Empty(t, config.GetParameters())
+
+	config.Spec = DevOpsProjectConfigSpec{
+		Registry:   "harbor.example.com",
+		Proxy:      &ArtifactRepositoryProxy{URL: "http://proxy:3128"},
+		Env:        []Parameter{{Name: "REGION", Value: "east"}, {Name: "MOTD", Value: "line1\nline2"}},
+		Parameters: []Parameter{{Name: "REGION", Value: "west"}},
+	}
+	assert.Equal(t, []Parameter{
+		{Name: "REGION", Value: "west"},
+		{Name: "REGION", Value: "east"},
+		{Name: "MOTD", Value: "line1\nline2"},
+		{Name: ImageRegistryParameter, Value: "harbor.example.com"},
+		{Name: HTTPProxyParameter, Value: "http://proxy:3128"},
+		{Name: HTTPSProxyParameter, Value: "http://proxy:3128"},
+	}, config.GetParameters())
+}
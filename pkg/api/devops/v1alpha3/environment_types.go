@@ -0,0 +1,143 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"kubesphere.io/devops/pkg/api/devops"
+)
+
+const (
+	// EnvironmentLabelKey is label key of the Environment which the PipelineRun deploys to.
+	EnvironmentLabelKey = devops.GroupName + "/environment"
+	// PromotedFromAnnoKey is annotation key of the PipelineRun which was promoted to this one.
+	PromotedFromAnnoKey = devops.GroupName + "/promoted-from"
+	// PromotedToAnnoKey is annotation key of the PipelineRun which this one was promoted to.
+	PromotedToAnnoKey = devops.GroupName + "/promoted-to"
+	// PromotionChainAnnoKey is annotation key of the PipelineRuns promoted one by one before this one, separated by commas.
+	PromotionChainAnnoKey = devops.GroupName + "/promotion-chain"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:shortName="env",categories="devops"
+//+kubebuilder:printcolumn:name="Pipeline",type=string,JSONPath=`.spec.pipelineRef`
+//+kubebuilder:printcolumn:name="Next",type=string,JSONPath=`.spec.next`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// Environment is a stage of the delivery, such as dev, staging or prod. The successful PipelineRuns of an
+// Environment could be promoted to the next one, which triggers the deployment Pipeline of the next Environment.
+type Environment struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   EnvironmentSpec   `json:"spec,omitempty"`
+	Status EnvironmentStatus `json:"status,omitempty"`
+}
+
+// EnvironmentSpec declares how to deploy to the Environment
+type EnvironmentSpec struct {
+	// PipelineRef is the name of the Pipeline which deploys to the Environment.
+	PipelineRef string `json:"pipelineRef"`
+
+	// Parameters are passed to the PipelineRuns promoted to the Environment.
+	// +optional
+	Parameters []Parameter `json:"parameters,omitempty"`
+
+	// SCM is the branch or tag to run when the Pipeline is a multi-branch Pipeline.
+	// +optional
+	SCM *SCM `json:"scm,omitempty"`
+
+	// Next is the name of the Environment which the successful PipelineRuns are promoted to.
+	// +optional
+	Next string `json:"next,omitempty"`
+
+	// Promotion decides how the PipelineRuns of the previous Environment are promoted to this one.
+	// +optional
+	Promotion EnvironmentPromotionPolicy `json:"promotion,omitempty"`
+}
+
+// EnvironmentPromotionPolicy decides who promotes the PipelineRuns to an Environment
+type EnvironmentPromotionPolicy struct {
+	// Auto promotes the successful PipelineRuns of the previous Environment automatically.
+	// It does not take effect if there are approvers.
+	// +optional
+	Auto bool `json:"auto,omitempty"`
+
+	// Approvers are the users who are allowed to promote the PipelineRuns to the Environment.
+	// The users who are allowed to trigger the Pipeline could promote if it's empty.
+	// +optional
+	Approvers []string `json:"approvers,omitempty"`
+}
+
+// EnvironmentStatus records the promotions to the Environment
+type EnvironmentStatus struct {
+	// Promotions are the latest promotions to the Environment, the latest one comes first.
+	// +optional
+	Promotions []PromotionRecord `json:"promotions,omitempty"`
+}
+
+// PromotionRecord is a promotion from the previous Environment
+type PromotionRecord struct {
+	// PipelineRun is the name of the PipelineRun triggered by the promotion.
+	PipelineRun string `json:"pipelineRun"`
+
+	// Source is the name of the PipelineRun which was promoted.
+	Source string `json:"source"`
+
+	// SourceEnvironment is the Environment of the source PipelineRun.
+	SourceEnvironment string `json:"sourceEnvironment"`
+
+	// PromotedBy is the user who promoted, it's empty if it was promoted automatically.
+	// +optional
+	PromotedBy string `json:"promotedBy,omitempty"`
+
+	// Chain are the PipelineRuns promoted one by one until the source, the first one comes first.
+	// +optional
+	Chain []string `json:"chain,omitempty"`
+
+	// Time is the time of the promotion.
+	Time metav1.Time `json:"time"`
+}
+
+//+kubebuilder:object:root=true
+
+// EnvironmentList contains a list of Environment
+type EnvironmentList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Environment `json:"items"`
+}
+
+// IsAutoPromoted indicates if the successful PipelineRuns of the previous Environment are promoted automatically.
+func (env *Environment) IsAutoPromoted() bool {
+	return env.Spec.Promotion.Auto && len(env.Spec.Promotion.Approvers) == 0
+}
+
+// IsApprover checks if the user is one of the approvers of the Environment.
+func (env *Environment) IsApprover(user string) bool {
+	for _, approver := range env.Spec.Promotion.Approvers {
+		if approver == user {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	SchemeBuilder.Register(&Environment{}, &EnvironmentList{})
+}
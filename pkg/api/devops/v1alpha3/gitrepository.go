@@ -69,6 +69,8 @@ type GitRepositorySpec struct {
 	Repo     string                    `json:"repo,omitempty"`
 	Secret   *v1.SecretReference       `json:"secret,omitempty"`
 	Webhooks []v1.LocalObjectReference `json:"webhooks,omitempty"`
+	// Preview deploys the pull requests to PreviewEnvironments if it's set
+	Preview *PreviewPolicy `json:"preview,omitempty"`
 }
 
 func init() {
@@ -34,8 +34,47 @@ const (
 	PipelineRunOrphanLabelKey = devops.GroupName + "/jenkins-pipelinerun-orphan"
 	// PipelineNameLabelKey is label key of Pipeline name.
 	PipelineNameLabelKey = devops.GroupName + "/pipeline"
+	// PipelineRunLogArchiveAnnoKey is annotation key of the object key of the archived PipelineRun logs in S3.
+	PipelineRunLogArchiveAnnoKey = devops.GroupName + "/log-archive"
+	// PipelineBackendLabelKey is label key of the backend which handles the Pipeline or PipelineRun, such as Jenkins or Tekton.
+	PipelineBackendLabelKey = devops.GroupName + "/pipeline-backend"
+	// PipelineRunSCMRepoAnnoKey is annotation key of the SCM repository (owner/repo) which triggered the PipelineRun.
+	PipelineRunSCMRepoAnnoKey = devops.GroupName + "/scm-repo"
+	// PipelineRunSCMRevisionAnnoKey is annotation key of the commit SHA which triggered the PipelineRun.
+	PipelineRunSCMRevisionAnnoKey = devops.GroupName + "/scm-revision"
+	// PipelineRunSCMBaseRevisionAnnoKey is annotation key of the commit SHA before the commits which triggered the PipelineRun.
+	PipelineRunSCMBaseRevisionAnnoKey = devops.GroupName + "/scm-base-revision"
+	// PipelineRunCommitStatusAnnoKey is annotation key of the PipelineRun phase which was reported to the SCM.
+	PipelineRunCommitStatusAnnoKey = devops.GroupName + "/commit-status"
 	// PipelineRunCreatorAnnoKey is annotation key of PipelineRun's creator
 	PipelineRunCreatorAnnoKey = devops.GroupName + "/creator"
+	// PipelineRunTriggeredByAnnoKey is annotation key of the user who was authorized to trigger the PipelineRun.
+	PipelineRunTriggeredByAnnoKey = devops.GroupName + "/triggered-by"
+	// PipelineRunIdentityAnnoKey is annotation key of the identity which triggered the PipelineRun, the value is a
+	// TriggerIdentity in JSON.
+	PipelineRunIdentityAnnoKey = devops.GroupName + "/triggered-by-identity"
+	// PipelineRunHistoryArchivedAnnoKey is annotation key which indicates the PipelineRun has been saved into the history database.
+	PipelineRunHistoryArchivedAnnoKey = devops.GroupName + "/history-archived"
+	// PipelineRunCallbackStatusAnnoKey is annotation key of the delivery status of the Pipeline callbacks.
+	PipelineRunCallbackStatusAnnoKey = devops.GroupName + "/callback-status"
+	// PipelineRunDispatchedLabelKey is label key of the PipelineRuns which were dispatched from the host cluster,
+	// the value is the name of the host cluster.
+	PipelineRunDispatchedLabelKey = devops.GroupName + "/dispatched-from"
+	// PropagatedFromLabelKey is label key of the objects which were replicated from the host cluster along with
+	// their DevOpsProjects, the value is the name of the host cluster.
+	PropagatedFromLabelKey = devops.GroupName + "/propagated-from"
+	// PipelineRunVersionAnnoKey is annotation key of the semantic version computed for the PipelineRun.
+	PipelineRunVersionAnnoKey = devops.GroupName + "/version"
+	// PipelineRunVersionCommitAnnoKey is annotation key of the commit SHA which the version was computed from.
+	PipelineRunVersionCommitAnnoKey = devops.GroupName + "/version-commit"
+	// PipelineRunVersionTaggedAnnoKey is annotation key which indicates the version tag has been created.
+	PipelineRunVersionTaggedAnnoKey = devops.GroupName + "/version-tagged"
+	// PipelineRunQualityGateAnnoKey is annotation key which indicates the SonarQube parameters have been passed to the PipelineRun.
+	PipelineRunQualityGateAnnoKey = devops.GroupName + "/quality-gate"
+	// PipelineRunPathFilterAnnoKey is annotation key of the decision of the path filter, see also PathFilterMatched.
+	PipelineRunPathFilterAnnoKey = devops.GroupName + "/path-filter"
+	// PipelineRunStuckRetriesAnnoKey is annotation key of the number of the retries of the stuck agent pods.
+	PipelineRunStuckRetriesAnnoKey = devops.GroupName + "/stuck-retries"
 	// PipelineRunSCMRefNameField is the field name of SCM reference name in PipelineRun spec.
 	PipelineRunSCMRefNameField = "spec.scm.ref-name"
 	// PipelineRunIdentifierIndexerName is an indexer name of PipelineRun identifier.
@@ -0,0 +1,140 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The drift types of a Jenkins plugin
+const (
+	// PluginDriftMissing means the plugin is not installed
+	PluginDriftMissing = "Missing"
+	// PluginDriftVersionMismatch means the installed version is different from the desired one
+	PluginDriftVersionMismatch = "VersionMismatch"
+	// PluginDriftInactive means the plugin is installed but not active, it usually needs a restart of Jenkins
+	PluginDriftInactive = "Inactive"
+)
+
+// The phases of JenkinsPluginSet
+const (
+	// PluginSetPhaseSynced means all the plugins match the declared list
+	PluginSetPhaseSynced = "Synced"
+	// PluginSetPhaseDrifted means there're some plugins don't match the declared list
+	PluginSetPhaseDrifted = "Drifted"
+	// PluginSetPhaseInstalling means the missing or mismatched plugins are being installed
+	PluginSetPhaseInstalling = "Installing"
+	// PluginSetPhaseFailed means failed to talk with Jenkins or install the plugins
+	PluginSetPhaseFailed = "Failed"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="AutoInstall",type=boolean,JSONPath=`.spec.autoInstall`
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// JenkinsPluginSet declares the plugins which the Jenkins should have
+type JenkinsPluginSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   JenkinsPluginSetSpec   `json:"spec,omitempty"`
+	Status JenkinsPluginSetStatus `json:"status,omitempty"`
+}
+
+// JenkinsPluginSetSpec is the desired plugin list
+type JenkinsPluginSetSpec struct {
+	Plugins []JenkinsPlugin `json:"plugins"`
+	// AutoInstall installs the missing plugins and updates the mismatched ones, only report the drift if it's false
+	// +optional
+	AutoInstall bool `json:"autoInstall,omitempty"`
+}
+
+// JenkinsPlugin is a Jenkins plugin with its version
+type JenkinsPlugin struct {
+	// Name is the short name of the plugin, such as: kubernetes
+	Name string `json:"name"`
+	// Version is the desired version, any installed version is acceptable if it's empty
+	// +optional
+	Version string `json:"version,omitempty"`
+}
+
+// JenkinsPluginSetStatus is the observed state of JenkinsPluginSet
+type JenkinsPluginSetStatus struct {
+	Phase string `json:"phase,omitempty"`
+	// Message is the error message when the phase is Failed
+	Message string `json:"message,omitempty"`
+	// Drifts are the plugins which don't match the declared list
+	Drifts []PluginDrift `json:"drifts,omitempty"`
+	// RestartRequired indicates that Jenkins needs a restart to activate the installed plugins
+	RestartRequired bool `json:"restartRequired,omitempty"`
+	// LastCheckTime is the last time of comparing with Jenkins
+	LastCheckTime *metav1.Time `json:"lastCheckTime,omitempty"`
+}
+
+// PluginDrift represents the difference between the desired plugin and the installed one
+type PluginDrift struct {
+	Name string `json:"name"`
+	// Type could be Missing, VersionMismatch or Inactive
+	Type             string `json:"type"`
+	DesiredVersion   string `json:"desiredVersion,omitempty"`
+	InstalledVersion string `json:"installedVersion,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// JenkinsPluginSetList contains a list of JenkinsPluginSet
+type JenkinsPluginSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []JenkinsPluginSet `json:"items"`
+}
+
+// String returns the plugin in the format of name@version which is accepted by the Jenkins plugin manager
+func (p JenkinsPlugin) String() string {
+	if p.Version == "" {
+		return p.Name
+	}
+	return fmt.Sprintf("%s@%s", p.Name, p.Version)
+}
+
+// Validate checks if the plugin list is valid
+func (s *JenkinsPluginSet) Validate() error {
+	names := map[string]bool{}
+	for _, plugin := range s.Spec.Plugins {
+		if plugin.Name == "" {
+			return fmt.Errorf("the name of plugin is required")
+		}
+		if strings.ContainsAny(plugin.Name, "@ ") || strings.ContainsAny(plugin.Version, "@ ") {
+			return fmt.Errorf("invalid plugin %q", plugin.String())
+		}
+		if names[plugin.Name] {
+			return fmt.Errorf("duplicated plugin %q", plugin.Name)
+		}
+		names[plugin.Name] = true
+	}
+	return nil
+}
+
+func init() {
+	SchemeBuilder.Register(&JenkinsPluginSet{}, &JenkinsPluginSetList{})
+}
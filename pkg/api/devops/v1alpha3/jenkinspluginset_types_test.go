@@ -0,0 +1,60 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJenkinsPlugin_String(t *testing.T) {
+	assert.Equal(t, "git", JenkinsPlugin{Name: "git"}.String())
+	assert.Equal(t, "git@4.11.0", JenkinsPlugin{Name: "git", Version: "4.11.0"}.String())
+}
+
+func TestJenkinsPluginSet_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		plugins []JenkinsPlugin
+		wantErr bool
+	}{{
+		name:    "valid",
+		plugins: []JenkinsPlugin{{Name: "git", Version: "4.11.0"}, {Name: "kubernetes"}},
+	}, {
+		name: "empty list",
+	}, {
+		name:    "without name",
+		plugins: []JenkinsPlugin{{Version: "4.11.0"}},
+		wantErr: true,
+	}, {
+		name:    "version in the name",
+		plugins: []JenkinsPlugin{{Name: "git@4.11.0"}},
+		wantErr: true,
+	}, {
+		name:    "duplicated",
+		plugins: []JenkinsPlugin{{Name: "git"}, {Name: "git", Version: "4.11.0"}},
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pluginSet := &JenkinsPluginSet{Spec: JenkinsPluginSetSpec{Plugins: tt.plugins}}
+			err := pluginSet.Validate()
+			assert.Equal(t, tt.wantErr, err != nil, err)
+		})
+	}
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultAgentNamespace is the namespace of the Jenkins agent pods
+const DefaultAgentNamespace = "kubesphere-devops-worker"
+
+// The phases of KubernetesAgentTemplate
+const (
+	// AgentTemplatePhaseReady means the template has been written into the Jenkins configuration
+	AgentTemplatePhaseReady = "Ready"
+	// AgentTemplatePhaseInvalid means the template was rejected by the validation
+	AgentTemplatePhaseInvalid = "Invalid"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Labels",type=string,JSONPath=`.spec.labels`
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// KubernetesAgentTemplate is a pod template of the Jenkins Kubernetes plugin, the Pipelines take
+// its name or labels as the agent label.
+type KubernetesAgentTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KubernetesAgentTemplateSpec   `json:"spec,omitempty"`
+	Status KubernetesAgentTemplateStatus `json:"status,omitempty"`
+}
+
+// KubernetesAgentTemplateSpec is the desired pod template
+type KubernetesAgentTemplateSpec struct {
+	// Labels are the extra agent labels, the name of the template is always one of the labels
+	Labels []string `json:"labels,omitempty"`
+	// Namespace is where the agent pods run, defaults to kubesphere-devops-worker
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// InheritFrom is the name of the parent pod template
+	// +optional
+	InheritFrom string `json:"inheritFrom,omitempty"`
+	// IdleMinutes keeps the agent pods alive after the builds
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	IdleMinutes int32 `json:"idleMinutes,omitempty"`
+	// Containers are the containers of the agent pods, the jnlp container is provided by Jenkins if it's absent
+	// +kubebuilder:validation:MinItems=1
+	Containers []AgentContainer `json:"containers"`
+	// Volumes are the volumes of the agent pods which can be mounted by the containers
+	Volumes []v1.Volume `json:"volumes,omitempty"`
+	// NodeSelector selects the nodes of the agent pods
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Tolerations are the tolerations of the agent pods
+	Tolerations []v1.Toleration `json:"tolerations,omitempty"`
+}
+
+// AgentContainer is a container of the Jenkins agent pods
+type AgentContainer struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+	// Command replaces the entrypoint of the image, it's usually a command which never exits, such as "cat"
+	Command []string `json:"command,omitempty"`
+	Args    []string `json:"args,omitempty"`
+	// Privileged runs the container in privileged mode, it's required by some builders such as Docker in Docker
+	Privileged   bool                    `json:"privileged,omitempty"`
+	Resources    v1.ResourceRequirements `json:"resources,omitempty"`
+	VolumeMounts []v1.VolumeMount        `json:"volumeMounts,omitempty"`
+}
+
+// KubernetesAgentTemplateStatus is the observed state of KubernetesAgentTemplate
+type KubernetesAgentTemplateStatus struct {
+	// Phase is Ready or Invalid
+	Phase string `json:"phase,omitempty"`
+	// Message is the reason of the invalid template
+	Message string `json:"message,omitempty"`
+	// ObservedGeneration is the generation which has been handled
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// KubernetesAgentTemplateList contains a list of KubernetesAgentTemplate
+type KubernetesAgentTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KubernetesAgentTemplate `json:"items"`
+}
+
+// GetNamespace returns the namespace of the agent pods
+func (s *KubernetesAgentTemplateSpec) GetNamespace() string {
+	if s.Namespace == "" {
+		return DefaultAgentNamespace
+	}
+	return s.Namespace
+}
+
+// Validate checks if the template could be a valid pod template of Jenkins
+func (t *KubernetesAgentTemplate) Validate() error {
+	if len(t.Spec.Containers) == 0 {
+		return fmt.Errorf("at least one container is required")
+	}
+
+	volumes := map[string]bool{}
+	for _, volume := range t.Spec.Volumes {
+		if volume.Name == "" {
+			return fmt.Errorf("the name of volume is required")
+		}
+		if volumes[volume.Name] {
+			return fmt.Errorf("duplicated volume %q", volume.Name)
+		}
+		volumes[volume.Name] = true
+	}
+
+	containers := map[string]bool{}
+	for _, container := range t.Spec.Containers {
+		if container.Name == "" {
+			return fmt.Errorf("the name of container is required")
+		}
+		if containers[container.Name] {
+			return fmt.Errorf("duplicated container %q", container.Name)
+		}
+		containers[container.Name] = true
+		if container.Image == "" {
+			return fmt.Errorf("the image of container %q is required", container.Name)
+		}
+		for _, mount := range container.VolumeMounts {
+			if !volumes[mount.Name] {
+				return fmt.Errorf("container %q mounts an undefined volume %q", container.Name, mount.Name)
+			}
+		}
+	}
+
+	for _, label := range t.Spec.Labels {
+		if label == "" || label != strings.TrimSpace(label) || strings.ContainsAny(label, " \t") {
+			return fmt.Errorf("invalid label %q, the labels should not contain whitespaces", label)
+		}
+	}
+	return nil
+}
+
+func init() {
+	SchemeBuilder.Register(&KubernetesAgentTemplate{}, &KubernetesAgentTemplateList{})
+}
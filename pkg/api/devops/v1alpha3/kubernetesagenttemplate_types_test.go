@@ -0,0 +1,88 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestKubernetesAgentTemplateSpec_GetNamespace(t *testing.T) {
+	spec := &KubernetesAgentTemplateSpec{}
+	assert.Equal(t, DefaultAgentNamespace, spec.GetNamespace())
+	spec.Namespace = "ns"
+	assert.Equal(t, "ns", spec.GetNamespace())
+}
+
+func TestKubernetesAgentTemplate_Validate(t *testing.T) {
+	validSpec := func() KubernetesAgentTemplateSpec {
+		return KubernetesAgentTemplateSpec{
+			Labels: []string{"maven"},
+			Containers: []AgentContainer{{
+				Name:         "maven",
+				Image:        "maven:3",
+				VolumeMounts: []v1.VolumeMount{{Name: "cache", MountPath: "/root/.m2"}},
+			}},
+			Volumes: []v1.Volume{{Name: "cache"}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(*KubernetesAgentTemplateSpec)
+		wantErr bool
+	}{{
+		name:   "valid",
+		modify: func(*KubernetesAgentTemplateSpec) {},
+	}, {
+		name:    "no containers",
+		modify:  func(s *KubernetesAgentTemplateSpec) { s.Containers = nil },
+		wantErr: true,
+	}, {
+		name:    "duplicated volumes",
+		modify:  func(s *KubernetesAgentTemplateSpec) { s.Volumes = append(s.Volumes, v1.Volume{Name: "cache"}) },
+		wantErr: true,
+	}, {
+		name: "duplicated containers",
+		modify: func(s *KubernetesAgentTemplateSpec) {
+			s.Containers = append(s.Containers, AgentContainer{Name: "maven", Image: "maven:3"})
+		},
+		wantErr: true,
+	}, {
+		name:    "container without image",
+		modify:  func(s *KubernetesAgentTemplateSpec) { s.Containers[0].Image = "" },
+		wantErr: true,
+	}, {
+		name:    "mount an undefined volume",
+		modify:  func(s *KubernetesAgentTemplateSpec) { s.Volumes = nil },
+		wantErr: true,
+	}, {
+		name:    "label with whitespace",
+		modify:  func(s *KubernetesAgentTemplateSpec) { s.Labels = []string{"maven jdk11"} },
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := &KubernetesAgentTemplate{Spec: validSpec()}
+			tt.modify(&template.Spec)
+			err := template.Validate()
+			assert.Equal(t, tt.wantErr, err != nil, err)
+		})
+	}
+}
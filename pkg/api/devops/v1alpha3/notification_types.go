@@ -0,0 +1,186 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"kubesphere.io/devops/pkg/utils/sliceutil"
+)
+
+// NotifiedPhaseAnnoKey is the phase of a PipelineRun which has been notified
+const NotifiedPhaseAnnoKey = "devops.kubesphere.io/notified-phase"
+
+// NotifiedStuckAnnoKey indicates the stuck agent pods of a PipelineRun have been notified
+const NotifiedStuckAnnoKey = "devops.kubesphere.io/notified-stuck"
+
+// NotificationEvent is a lifecycle event of PipelineRun
+type NotificationEvent string
+
+const (
+	// NotificationEventStarted means the PipelineRun started running
+	NotificationEventStarted NotificationEvent = "Started"
+	// NotificationEventSucceeded means the PipelineRun succeeded
+	NotificationEventSucceeded NotificationEvent = "Succeeded"
+	// NotificationEventFailed means the PipelineRun failed
+	NotificationEventFailed NotificationEvent = "Failed"
+	// NotificationEventCancelled means the PipelineRun was cancelled
+	NotificationEventCancelled NotificationEvent = "Cancelled"
+	// NotificationEventStuck means the agent pods of the PipelineRun are stuck
+	NotificationEventStuck NotificationEvent = "Stuck"
+)
+
+// NotificationReceiverType is the type of notification receiver
+type NotificationReceiverType string
+
+const (
+	// NotificationReceiverSlack sends the messages to a Slack incoming webhook
+	NotificationReceiverSlack NotificationReceiverType = "Slack"
+	// NotificationReceiverDingTalk sends the messages to a DingTalk robot
+	NotificationReceiverDingTalk NotificationReceiverType = "DingTalk"
+	// NotificationReceiverWeCom sends the messages to a WeCom robot
+	NotificationReceiverWeCom NotificationReceiverType = "WeCom"
+	// NotificationReceiverEmail sends the messages via the SMTP server of the controller manager
+	NotificationReceiverEmail NotificationReceiverType = "Email"
+	// NotificationReceiverWebhook posts the events as JSON to a generic webhook
+	NotificationReceiverWebhook NotificationReceiverType = "Webhook"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// Notification sends the lifecycle events of the PipelineRuns in a DevOpsProject to the receivers
+// +k8s:openapi-gen=true
+// +kubebuilder:printcolumn:name="Events",type="string",JSONPath=".spec.events"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type Notification struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec NotificationSpec `json:"spec,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NotificationList contains a list of Notification
+type NotificationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Notification `json:"items"`
+}
+
+// NotificationSpec represents the desired state of a Notification
+type NotificationSpec struct {
+	// Pipelines are the names of the Pipelines to notify, all Pipelines of the project are notified if it's empty
+	Pipelines []string `json:"pipelines,omitempty"`
+	// Events are the events to notify, all events are notified if it's empty
+	Events []NotificationEvent `json:"events,omitempty"`
+	// Template is a Go template of the message, see also the default message of the notifier
+	Template  string                 `json:"template,omitempty"`
+	Receivers []NotificationReceiver `json:"receivers"`
+	// Overrides customize the events and the message of some Pipelines
+	Overrides []NotificationOverride `json:"overrides,omitempty"`
+}
+
+// NotificationReceiver is where the messages are sent to
+type NotificationReceiver struct {
+	Type NotificationReceiverType `json:"type"`
+	// URL is the address of the robot or the webhook, it's ignored by the Email receiver
+	URL string `json:"url,omitempty"`
+	// URLFrom takes the address from a secret in the same namespace, it takes precedence over URL
+	URLFrom *v1.SecretKeySelector `json:"urlFrom,omitempty"`
+	// To are the recipients of the Email receiver
+	To []string `json:"to,omitempty"`
+}
+
+// NotificationOverride customizes the notification of a Pipeline
+type NotificationOverride struct {
+	Pipeline string `json:"pipeline"`
+	// Events replaces the events of the Notification if it's not empty
+	Events []NotificationEvent `json:"events,omitempty"`
+	// Template replaces the template of the Notification if it's not empty
+	Template string `json:"template,omitempty"`
+	// Disabled stops notifying the Pipeline
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// GetEvents returns the events to notify for a Pipeline
+func (n *Notification) GetEvents(pipeline string) []NotificationEvent {
+	if override := n.getOverride(pipeline); override != nil && len(override.Events) > 0 {
+		return override.Events
+	}
+	return n.Spec.Events
+}
+
+// GetTemplate returns the message template of a Pipeline
+func (n *Notification) GetTemplate(pipeline string) string {
+	if override := n.getOverride(pipeline); override != nil && override.Template != "" {
+		return override.Template
+	}
+	return n.Spec.Template
+}
+
+// Matches returns true if the event of the Pipeline should be notified
+func (n *Notification) Matches(pipeline string, event NotificationEvent) bool {
+	if override := n.getOverride(pipeline); override != nil && override.Disabled {
+		return false
+	}
+	if len(n.Spec.Pipelines) > 0 && !sliceutil.HasString(n.Spec.Pipelines, pipeline) {
+		return false
+	}
+	events := n.GetEvents(pipeline)
+	if len(events) == 0 {
+		return true
+	}
+	for _, item := range events {
+		if item == event {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *Notification) getOverride(pipeline string) *NotificationOverride {
+	for i := range n.Spec.Overrides {
+		if n.Spec.Overrides[i].Pipeline == pipeline {
+			return &n.Spec.Overrides[i]
+		}
+	}
+	return nil
+}
+
+// GetNotificationEvent returns the event of a PipelineRun phase, it returns false if there is no event of it
+func GetNotificationEvent(phase RunPhase) (event NotificationEvent, ok bool) {
+	ok = true
+	switch phase {
+	case Running:
+		event = NotificationEventStarted
+	case Succeeded:
+		event = NotificationEventSucceeded
+	case Failed:
+		event = NotificationEventFailed
+	case Cancelled:
+		event = NotificationEventCancelled
+	default:
+		ok = false
+	}
+	return
+}
+
+func init() {
+	SchemeBuilder.Register(&Notification{}, &NotificationList{})
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotification_Matches(t *testing.T) {
+	notification := &Notification{
+		Spec: NotificationSpec{
+			Pipelines: []string{"build", "deploy", "test"},
+			Events:    []NotificationEvent{NotificationEventFailed},
+			Template:  "default",
+			Overrides: []NotificationOverride{{
+				Pipeline: "deploy",
+				Events:   []NotificationEvent{NotificationEventStarted, NotificationEventSucceeded},
+				Template: "deploy",
+			}, {
+				Pipeline: "test",
+				Disabled: true,
+			}},
+		},
+	}
+
+	assert.True(t, notification.Matches("build", NotificationEventFailed))
+	assert.False(t, notification.Matches("build", NotificationEventSucceeded))
+	assert.False(t, notification.Matches("other", NotificationEventFailed))
+	assert.True(t, notification.Matches("deploy", NotificationEventStarted))
+	assert.False(t, notification.Matches("deploy", NotificationEventFailed))
+	assert.False(t, notification.Matches("test", NotificationEventFailed))
+	assert.Equal(t, "default", notification.GetTemplate("build"))
+	assert.Equal(t, "deploy", notification.GetTemplate("deploy"))
+	assert.Equal(t, "default", notification.GetTemplate("test"))
+
+	// all Pipelines and all events
+	notification = &Notification{}
+	assert.True(t, notification.Matches("build", NotificationEventCancelled))
+}
+
+func TestGetNotificationEvent(t *testing.T) {
+	tests := []struct {
+		phase  RunPhase
+		event  NotificationEvent
+		wantOK bool
+	}{
+		{phase: Pending},
+		{phase: Queued},
+		{phase: Unknown},
+		{phase: Running, event: NotificationEventStarted, wantOK: true},
+		{phase: Succeeded, event: NotificationEventSucceeded, wantOK: true},
+		{phase: Failed, event: NotificationEventFailed, wantOK: true},
+		{phase: Cancelled, event: NotificationEventCancelled, wantOK: true},
+	}
+	for _, tt := range tests {
+		event, ok := GetNotificationEvent(tt.phase)
+		assert.Equal(t, tt.wantOK, ok, tt.phase)
+		assert.Equal(t, tt.event, event, tt.phase)
+	}
+}
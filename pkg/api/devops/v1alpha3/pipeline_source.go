@@ -0,0 +1,53 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+)
+
+// LoadPipelineSource parses and validates the Pipeline definition file which is loaded from a git repository.
+// The file is a Pipeline manifest, only its spec is taken, and it cannot refer to another source or template.
+func LoadPipelineSource(data []byte) (spec *PipelineSpec, err error) {
+	pipeline := &Pipeline{}
+	if err = yaml.UnmarshalStrict(data, pipeline); err != nil {
+		err = fmt.Errorf("failed to parse the Pipeline definition: %v", err)
+		return
+	}
+	if pipeline.Kind != "" && pipeline.Kind != ResourceKindPipeline {
+		err = fmt.Errorf("the kind of the Pipeline definition should be %s instead of %s", ResourceKindPipeline, pipeline.Kind)
+		return
+	}
+
+	spec = &pipeline.Spec
+	path := field.NewPath("spec")
+	errs := spec.validate(path)
+	if spec.Source != nil {
+		errs = append(errs, field.Forbidden(path.Child("source"), "not supported in the Pipeline definition"))
+	}
+	if spec.Template != nil {
+		errs = append(errs, field.Forbidden(path.Child("template"), "not supported in the Pipeline definition"))
+	}
+	if len(errs) > 0 {
+		spec = nil
+		err = fmt.Errorf("invalid Pipeline definition: %v", errs.ToAggregate())
+	}
+	return
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadPipelineSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    *PipelineSpec
+		wantErr bool
+	}{{
+		name: "valid definition",
+		data: `apiVersion: devops.kubesphere.io/v1alpha3
+kind: Pipeline
+metadata:
+  name: ignored
+spec:
+  type: pipeline
+  pipeline:
+    name: build
+    jenkinsfile: pipeline {}
+  concurrency:
+    maxConcurrentRuns: 1`,
+		want: &PipelineSpec{
+			Type:        NoScmPipelineType,
+			Pipeline:    &NoScmPipeline{Name: "build", Jenkinsfile: "pipeline {}"},
+			Concurrency: &ConcurrencyPolicy{MaxConcurrentRuns: 1},
+		},
+	}, {
+		name:    "invalid yaml",
+		data:    "spec: [",
+		wantErr: true,
+	}, {
+		name:    "unknown field",
+		data:    "spec:\n  type: pipeline\n  unknown: true",
+		wantErr: true,
+	}, {
+		name:    "unexpected kind",
+		data:    "kind: PipelineRun\nspec:\n  type: pipeline\n  pipeline:\n    name: build",
+		wantErr: true,
+	}, {
+		name:    "without the pipeline",
+		data:    "spec:\n  type: pipeline",
+		wantErr: true,
+	}, {
+		name:    "refer to another source",
+		data:    "spec:\n  type: pipeline\n  source:\n    gitRepository: repo",
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := LoadPipelineSource([]byte(tt.data))
+			if tt.wantErr {
+				assert.NotNil(t, err)
+			} else {
+				assert.Nil(t, err)
+			}
+			assert.Equal(t, tt.want, spec)
+		})
+	}
+}
@@ -18,6 +18,10 @@ package v1alpha3
 
 import (
 	"fmt"
+	"path"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -47,6 +51,20 @@ const (
 	PipelineJenkinsfileEditModeAnnoKey = PipelinePrefix + "jenkinsfile.edit.mode"
 	// PipelineJenkinsfileValidateAnnoKey is the annotation key of the Jenkinsfile validate, success or failure
 	PipelineJenkinsfileValidateAnnoKey = PipelinePrefix + "jenkinsfile.validate"
+	// PipelineSourceRevisionAnnoKey is the annotation key of the pushed revision which requests to reload the Pipeline source
+	PipelineSourceRevisionAnnoKey = PipelinePrefix + "source-revision"
+	// PipelineSourceSyncedAnnoKey is the annotation key of the Pipeline source which has been loaded
+	PipelineSourceSyncedAnnoKey = PipelinePrefix + "source-synced"
+	// PipelineDeploymentAnnoKey is the annotation key which marks the Pipeline as a deployment Pipeline,
+	// its PipelineRuns are taken as the deployments of the DORA metrics
+	PipelineDeploymentAnnoKey = PipelinePrefix + "deployment"
+	// PipelineImportedFromAnnoKey is the annotation key of the full name of the pre-existing Jenkins job
+	// which the Pipeline was imported from
+	PipelineImportedFromAnnoKey = PipelinePrefix + "imported-from"
+	// PipelineModifiedByAnnoKey is the annotation key of the user who changed the Pipeline spec last time
+	PipelineModifiedByAnnoKey = PipelinePrefix + "modified-by"
+	// PipelineRolledBackToAnnoKey is the annotation key of the revision which the Pipeline was rolled back to last time
+	PipelineRolledBackToAnnoKey = PipelinePrefix + "rolled-back-to"
 
 	// PipelineJenkinsfileEditModeJSON indicates the Jenkinsfile editing mode is JSON
 	PipelineJenkinsfileEditModeJSON = "json"
@@ -64,12 +82,397 @@ type PipelineSpec struct {
 	Type                PipelineType         `json:"type" description:"type of devops pipeline, in scm or no scm"`
 	Pipeline            *NoScmPipeline       `json:"pipeline,omitempty" description:"no scm pipeline structs"`
 	MultiBranchPipeline *MultiBranchPipeline `json:"multi_branch_pipeline,omitempty" description:"in scm pipeline structs"`
+	// ArtifactOutputs are the files which will be archived into the object storage after the PipelineRun completed
+	ArtifactOutputs []ArtifactOutput `json:"artifactOutputs,omitempty" description:"artifacts to be archived"`
+	// Concurrency limits the number of PipelineRuns of this Pipeline which are running at the same time
+	Concurrency *ConcurrencyPolicy `json:"concurrency,omitempty" description:"concurrency policy of the PipelineRuns"`
+	// Template renders the Jenkinsfile of this Pipeline from a Template or ClusterTemplate
+	Template *PipelineTemplateRef `json:"template,omitempty" description:"the template which renders the Jenkinsfile"`
+	// Source loads the definition of this Pipeline from a file in a git repository
+	Source *PipelineSource `json:"source,omitempty" description:"the git repository file which defines the Pipeline"`
+	// Callbacks are the HTTP endpoints which are called once the PipelineRuns of this Pipeline completed
+	Callbacks []PipelineCallback `json:"callbacks,omitempty" description:"HTTP callbacks of the completed PipelineRuns"`
+	// Versioning computes the next semantic version of every PipelineRun from the conventional commits
+	Versioning *VersioningPolicy `json:"versioning,omitempty" description:"semantic versioning of the PipelineRuns"`
+	// ScanImage injects a Trivy stage which scans the images reported by the PipelineRuns, see also ImageURLResult.
+	// It is only supported by the type pipeline, because the Jenkinsfile of a multi-branch Pipeline is in its repository.
+	ScanImage bool `json:"scanImage,omitempty" description:"scan the images of the PipelineRuns"`
+	// ImageScan customizes the injected scanning stage, it takes effect only if ScanImage is true
+	ImageScan *ImageScanPolicy `json:"imageScan,omitempty" description:"policy of the image scanning"`
+	// SupplyChain generates the SBOMs and signs the provenance attestations of the pushed images.
+	// It is only supported by the Tekton backend.
+	SupplyChain *SupplyChainPolicy `json:"supplyChain,omitempty" description:"supply chain security of the pushed images"`
+	// QualityGate passes the SonarQube analysis parameters to the PipelineRuns, then records their quality gates
+	QualityGate *QualityGatePolicy `json:"qualityGate,omitempty" description:"SonarQube quality gate of the PipelineRuns"`
+	// ArtifactRepository is the name of an ArtifactRepository in the same namespace, its address, credential
+	// and proxy are passed to the PipelineRuns as parameters, see also ArtifactRepositoryURLParameter
+	ArtifactRepository string `json:"artifactRepository,omitempty" description:"the binary repository which the PipelineRuns resolve and publish artifacts through"`
+	// PathFilter skips the PipelineRuns triggered by the commits which did not change any of the matched paths
+	PathFilter *PathFilterPolicy `json:"pathFilter,omitempty" description:"changed paths which the PipelineRuns are triggered by"`
+	// StuckRun decides what to do with the PipelineRuns whose agent pods are stuck, they are failed by default
+	StuckRun *StuckRunPolicy `json:"stuckRun,omitempty" description:"what to do with the PipelineRuns whose agent pods are stuck"`
+	// DeletionPolicy is one of Delete, Orphan and Retain, defaults to Delete. It is inherited by the PipelineRuns
+	// which do not declare one
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty" description:"whether deleting the Pipeline deletes the Jenkins job as well"`
+}
+
+// PipelineCallback is an HTTP endpoint which receives the completed PipelineRuns of a Pipeline
+type PipelineCallback struct {
+	// URL is the address which receives the JSON payload by POST requests
+	URL string `json:"url"`
+	// SecretRef refers to a key of a secret in the same namespace, the payload is signed with HMAC-SHA256 by it
+	// +optional
+	SecretRef *v1.SecretKeySelector `json:"secretRef,omitempty"`
+}
+
+// The defaults of the versioning policy
+const (
+	DefaultVersionParameter = "VERSION"
+	DefaultVersionTagPrefix = "v"
+)
+
+// VersioningPolicy computes the next semantic version from the git history. The latest tag decides the
+// current version, and the conventional commits after it decide which part of the version is bumped.
+type VersioningPolicy struct {
+	// GitRepository is the name of the GitRepository in the same namespace as the Pipeline
+	GitRepository string `json:"gitRepository"`
+	// Ref is the branch to compute the version from, defaults to the branch of multi-branch PipelineRuns,
+	// or the default branch of the repository
+	// +optional
+	Ref string `json:"ref,omitempty"`
+	// Parameter is the name of the PipelineRun parameter which carries the version, defaults to VERSION
+	// +optional
+	Parameter string `json:"parameter,omitempty"`
+	// TagPrefix is the prefix of the version tags, defaults to v
+	// +optional
+	TagPrefix *string `json:"tagPrefix,omitempty"`
+	// Tag creates the version tag in the repository once the PipelineRun succeeded
+	// +optional
+	Tag bool `json:"tag,omitempty"`
+}
+
+// GetParameter returns the name of the parameter which carries the version
+func (p *VersioningPolicy) GetParameter() string {
+	if p.Parameter == "" {
+		return DefaultVersionParameter
+	}
+	return p.Parameter
+}
+
+// GetTagPrefix returns the prefix of the version tags, an empty prefix is allowed
+func (p *VersioningPolicy) GetTagPrefix() string {
+	if p.TagPrefix == nil {
+		return DefaultVersionTagPrefix
+	}
+	return *p.TagPrefix
+}
+
+// The decisions of the path filter, see also PipelineRunPathFilterAnnoKey
+const (
+	// PathFilterMatched indicates the commits changed some of the matched paths
+	PathFilterMatched = "matched"
+	// PathFilterSkipped indicates the commits changed none of the matched paths, the PipelineRun is skipped
+	PathFilterSkipped = "skipped"
+	// PathFilterUnknown indicates the changed paths cannot be computed, the PipelineRun runs as usual
+	PathFilterUnknown = "unknown"
+)
+
+// PathFilterPolicy decides whether a PipelineRun triggered by the commits is necessary by the paths they changed.
+// The patterns are globs of the slash-separated paths, ** matches any number of directories, and a pattern
+// ends with a slash matches everything under the directory, such as service-a/ or docs/**/*.md.
+type PathFilterPolicy struct {
+	// GitRepository is the name of the GitRepository in the same namespace as the Pipeline,
+	// the changed paths are computed through its provider and secret
+	GitRepository string `json:"gitRepository"`
+	// Includes are the patterns of the paths which trigger the PipelineRuns, all paths are included if it's empty
+	// +optional
+	Includes []string `json:"includes,omitempty"`
+	// Excludes are the patterns of the paths which are ignored even if they are included
+	// +optional
+	Excludes []string `json:"excludes,omitempty"`
+}
+
+// Match returns the paths which are included and not excluded
+func (p *PathFilterPolicy) Match(paths []string) (matched []string) {
+	for _, item := range paths {
+		if (len(p.Includes) == 0 || matchAnyPath(p.Includes, item)) && !matchAnyPath(p.Excludes, item) {
+			matched = append(matched, item)
+		}
+	}
+	return
+}
+
+func matchAnyPath(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if MatchPath(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchPath reports whether the slash-separated path matches the glob pattern, see also PathFilterPolicy
+func MatchPath(pattern, name string) bool {
+	pattern = strings.TrimPrefix(pattern, "/")
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	return matchPathSegments(strings.Split(pattern, "/"), strings.Split(strings.TrimPrefix(name, "/"), "/"))
+}
+
+func matchPathSegments(patterns, names []string) bool {
+	for len(patterns) > 0 {
+		if patterns[0] == "**" {
+			// ** matches zero or more directories
+			for i := 0; i <= len(names); i++ {
+				if matchPathSegments(patterns[1:], names[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(names) == 0 {
+			return false
+		}
+		if matched, err := path.Match(patterns[0], names[0]); err != nil || !matched {
+			return false
+		}
+		patterns, names = patterns[1:], names[1:]
+	}
+	return len(names) == 0
+}
+
+// The defaults of the image scanning
+const (
+	DefaultImageScanner = "aquasec/trivy:0.38.3"
+	// ImageScanArtifact is the name of the artifact which is the JSON report of Trivy
+	ImageScanArtifact = "image-scan-report"
+	// ImageScanReportPath is the path of the report in the workspace of Jenkins
+	ImageScanReportPath = "image-scan-report.json"
+)
+
+// ImageScanPolicy customizes the image scanning stage
+type ImageScanPolicy struct {
+	// Image is the Trivy image which runs the scanning, defaults to DefaultImageScanner
+	// +optional
+	Image string `json:"image,omitempty"`
+	// FailOnCritical fails the PipelineRun if there are any critical vulnerabilities
+	// +optional
+	FailOnCritical bool `json:"failOnCritical,omitempty"`
+}
+
+// GetImage returns the Trivy image which runs the scanning
+func (p *ImageScanPolicy) GetImage() string {
+	if p == nil || p.Image == "" {
+		return DefaultImageScanner
+	}
+	return p.Image
+}
+
+// The formats of the SBOMs which are generated by Syft
+const (
+	SBOMFormatSPDX      = "spdx-json"
+	SBOMFormatCycloneDX = "cyclonedx-json"
+)
+
+// SupplyChainPolicy generates the SBOMs of the pushed images with Syft, and signs the SLSA provenance attestations
+// with cosign. Both of them are pushed to the registry along with the images, see also ImageURLResult.
+type SupplyChainPolicy struct {
+	// SBOM generates the SBOMs of the pushed images
+	// +optional
+	SBOM bool `json:"sbom,omitempty"`
+	// SBOMFormat is the format of the SBOMs, defaults to spdx-json
+	// +optional
+	// +kubebuilder:validation:Enum=spdx-json;cyclonedx-json
+	SBOMFormat string `json:"sbomFormat,omitempty"`
+	// Provenance signs the SLSA provenance attestations of the pushed images
+	// +optional
+	Provenance bool `json:"provenance,omitempty"`
+	// SigningKey is the name of a credential in the same namespace which has the type SecretTypeCosignKey.
+	// It is required by the provenance attestations.
+	// +optional
+	SigningKey string `json:"signingKey,omitempty"`
+}
+
+// GetSBOMFormat returns the format of the SBOMs
+func (p *SupplyChainPolicy) GetSBOMFormat() string {
+	if p.SBOMFormat == "" {
+		return SBOMFormatSPDX
+	}
+	return p.SBOMFormat
+}
+
+// GetArtifactOutputs returns the declared artifacts, and the report of the image scanning if ScanImage is true
+func (spec *PipelineSpec) GetArtifactOutputs() (outputs []ArtifactOutput) {
+	outputs = spec.ArtifactOutputs
+	if !spec.ScanImage {
+		return
+	}
+	for _, output := range outputs {
+		if output.Name == ImageScanArtifact {
+			return
+		}
+	}
+	outputs = append(outputs[:len(outputs):len(outputs)], ArtifactOutput{Name: ImageScanArtifact, Path: ImageScanReportPath})
+	return
+}
+
+// DefaultPipelineSourcePath is the default path of the Pipeline definition file in a git repository
+const DefaultPipelineSourcePath = ".kubesphere/pipeline.yaml"
+
+// PipelineSource refers to a file in a git repository which defines the Pipeline
+type PipelineSource struct {
+	// GitRepository is the name of the GitRepository in the same namespace as the Pipeline
+	GitRepository string `json:"gitRepository"`
+	// Ref is the branch, tag or commit of the definition file, defaults to the default branch
+	// +optional
+	Ref string `json:"ref,omitempty"`
+	// Path is the path of the definition file, defaults to .kubesphere/pipeline.yaml
+	// +optional
+	Path string `json:"path,omitempty"`
+}
+
+// GetPath returns the path of the definition file
+func (s *PipelineSource) GetPath() string {
+	if s.Path == "" {
+		return DefaultPipelineSourcePath
+	}
+	return s.Path
+}
+
+// PipelineTemplateRef refers to a Template or a ClusterTemplate with the values of its parameters
+type PipelineTemplateRef struct {
+	// Kind is the kind of the template, Template or ClusterTemplate, defaults to Template
+	// +kubebuilder:validation:Enum=Template;ClusterTemplate
+	// +optional
+	Kind string `json:"kind,omitempty"`
+	// Name is the name of the template, a Template must be in the same namespace as the Pipeline
+	Name string `json:"name"`
+	// Version pins the version of the template, the template is rendered only if its version matches
+	// +optional
+	Version string `json:"version,omitempty"`
+	// Parameters are the values of the template parameters, the default values are used for the absent ones
+	// +optional
+	Parameters []Parameter `json:"parameters,omitempty"`
+}
+
+// GetKind returns the kind of the template, Template is the default one
+func (ref *PipelineTemplateRef) GetKind() string {
+	if ref.Kind == "" {
+		return ResourceKindTemplate
+	}
+	return ref.Kind
+}
+
+// IsClusterTemplate indicates if the reference is a ClusterTemplate
+func (ref *PipelineTemplateRef) IsClusterTemplate() bool {
+	return ref.Kind == ResourceKindClusterTemplate
+}
+
+// QueuePolicy decides what to do with the PipelineRuns which exceed the concurrency limit
+type QueuePolicy string
+
+const (
+	// QueuePolicyQueue holds the excess PipelineRuns in the Queued phase, and releases them in the order of
+	// their priorities, the ones of the same priority are released in FIFO order
+	QueuePolicyQueue QueuePolicy = "Queue"
+	// QueuePolicyDiscard cancels the excess PipelineRuns directly
+	QueuePolicyDiscard QueuePolicy = "Discard"
+)
+
+// ConcurrencyPolicy is the concurrency limit of the PipelineRuns of a Pipeline
+type ConcurrencyPolicy struct {
+	// MaxConcurrentRuns is the maximum number of the running PipelineRuns, zero means no limit
+	// +kubebuilder:validation:Minimum=0
+	MaxConcurrentRuns int32 `json:"maxConcurrentRuns,omitempty"`
+	// QueuePolicy decides what to do with the excess PipelineRuns, defaults to Queue
+	// +kubebuilder:validation:Enum=Queue;Discard
+	// +optional
+	QueuePolicy QueuePolicy `json:"queuePolicy,omitempty"`
+	// MaxQueuedRuns is the maximum number of the queued PipelineRuns, zero means no limit.
+	// Once the queue is full, the queued PipelineRun of the lowest priority is preempted by a new one of
+	// a higher priority, or the new one is discarded if there is no such PipelineRun.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxQueuedRuns int32 `json:"maxQueuedRuns,omitempty"`
+	// PriorityClasses decide the default priorities of the PipelineRuns by their refs, the first matched one wins
+	// +optional
+	PriorityClasses []PriorityClass `json:"priorityClasses,omitempty"`
+}
+
+// PriorityClass is the priority of the PipelineRuns of the matched refs, such as release-* > main > PR-*
+type PriorityClass struct {
+	// Name is the name of the class, such as release
+	Name string `json:"name"`
+	// Value is the priority, the PipelineRuns of a higher priority are released from the queue first
+	Value int32 `json:"value"`
+	// Refs are the glob patterns of the branches, tags or pull requests of the multi-branch PipelineRuns,
+	// such as release-* or PR-*. The class without refs matches all the PipelineRuns.
+	// +optional
+	Refs []string `json:"refs,omitempty"`
+}
+
+// Limited indicates if there is a concurrency limit
+func (c *ConcurrencyPolicy) Limited() bool {
+	return c != nil && c.MaxConcurrentRuns > 0
+}
+
+// GetPriority returns the priority of the PipelineRun, the priority in its spec takes precedence over the
+// priority classes. Zero is returned if there is no priority at all.
+func (c *ConcurrencyPolicy) GetPriority(pr *PipelineRun) int32 {
+	if pr.Spec.Priority != nil {
+		return *pr.Spec.Priority
+	}
+	if c == nil {
+		return 0
+	}
+	var ref string
+	if pr.Spec.SCM != nil {
+		ref = pr.Spec.SCM.RefName
+	}
+	for _, class := range c.PriorityClasses {
+		if len(class.Refs) == 0 || (ref != "" && matchAnyPath(class.Refs, ref)) {
+			return class.Value
+		}
+	}
+	return 0
 }
 
 // PipelineStatus defines the observed state of Pipeline
 type PipelineStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// Current state of Pipeline, such as Ready, Synced and Failed.
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// GetCondition returns the condition of the given type, or nil if it does not exist.
+func (status *PipelineStatus) GetCondition(conditionType ConditionType) *Condition {
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == conditionType {
+			return &status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// SetCondition adds or replaces the condition of the same type. The LastTransitionTime is only
+// changed when the status of the condition is changed.
+func (status *PipelineStatus) SetCondition(newCondition Condition) {
+	if existing := status.GetCondition(newCondition.Type); existing != nil {
+		if existing.Status == newCondition.Status {
+			newCondition.LastTransitionTime = existing.LastTransitionTime
+		} else if newCondition.LastTransitionTime.IsZero() {
+			newCondition.LastTransitionTime = metav1.Now()
+		}
+		*existing = newCondition
+		return
+	}
+	if newCondition.LastTransitionTime.IsZero() {
+		newCondition.LastTransitionTime = metav1.Now()
+	}
+	status.Conditions = append(status.Conditions, newCondition)
 }
 
 // +genclient
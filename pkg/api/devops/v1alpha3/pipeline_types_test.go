@@ -124,3 +124,113 @@ func TestMultiBranchPipeline_GetGitURL(t *testing.T) {
 		})
 	}
 }
+
+func TestPipelineStatus_SetCondition(t *testing.T) {
+	status := &PipelineStatus{}
+	assert.Nil(t, status.GetCondition(ConditionReady))
+
+	status.SetCondition(Condition{Type: ConditionReady, Status: ConditionFalse, Reason: SyncFailed})
+	ready := status.GetCondition(ConditionReady)
+	assert.NotNil(t, ready)
+	assert.Equal(t, ConditionFalse, ready.Status)
+	assert.False(t, ready.LastTransitionTime.IsZero())
+	transitionTime := ready.LastTransitionTime
+
+	// the transition time should be kept if the status is not changed
+	status.SetCondition(Condition{Type: ConditionReady, Status: ConditionFalse, Reason: SyncFailed, Message: "again"})
+	assert.Len(t, status.Conditions, 1)
+	assert.Equal(t, "again", status.GetCondition(ConditionReady).Message)
+	assert.Equal(t, transitionTime, status.GetCondition(ConditionReady).LastTransitionTime)
+
+	status.SetCondition(Condition{Type: ConditionSynced, Status: ConditionTrue})
+	assert.Len(t, status.Conditions, 2)
+	assert.Equal(t, ConditionTrue, status.GetCondition(ConditionSynced).Status)
+}
+
+func TestVersioningPolicy(t *testing.T) {
+	policy := &VersioningPolicy{GitRepository: "app"}
+	assert.Equal(t, DefaultVersionParameter, policy.GetParameter())
+	assert.Equal(t, DefaultVersionTagPrefix, policy.GetTagPrefix())
+
+	emptyPrefix := ""
+	policy = &VersioningPolicy{GitRepository: "app", Parameter: "TAG", TagPrefix: &emptyPrefix}
+	assert.Equal(t, "TAG", policy.GetParameter())
+	assert.Equal(t, "", policy.GetTagPrefix())
+}
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		name     string
+		expected bool
+	}{
+		{pattern: "README.md", name: "README.md", expected: true},
+		{pattern: "*.md", name: "docs/README.md", expected: false},
+		{pattern: "**/*.md", name: "README.md", expected: true},
+		{pattern: "**/*.md", name: "docs/guide/README.md", expected: true},
+		{pattern: "docs/**", name: "docs/guide/README.md", expected: true},
+		{pattern: "docs/**/*.png", name: "docs/guide/README.md", expected: false},
+		{pattern: "service-a/", name: "service-a/cmd/main.go", expected: true},
+		{pattern: "/service-a/", name: "service-a/main.go", expected: true},
+		{pattern: "service-a/", name: "service-ab/main.go", expected: false},
+		{pattern: "service-?/*.go", name: "service-b/main.go", expected: true},
+		{pattern: "[", name: "[", expected: false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, MatchPath(tt.pattern, tt.name), "%s should match %s: %v", tt.pattern, tt.name, tt.expected)
+	}
+}
+
+func TestPathFilterPolicy_Match(t *testing.T) {
+	files := []string{"service-a/main.go", "service-a/README.md", "service-b/main.go"}
+	assert.Equal(t, files, (&PathFilterPolicy{}).Match(files))
+	assert.Equal(t, []string{"service-a/main.go"}, (&PathFilterPolicy{
+		Includes: []string{"service-a/"},
+		Excludes: []string{"**/*.md"},
+	}).Match(files))
+	assert.Empty(t, (&PathFilterPolicy{Includes: []string{"service-c/"}}).Match(files))
+}
+
+func TestConcurrencyPolicy_GetPriority(t *testing.T) {
+	priority := int32(5)
+	explicit := &PipelineRun{Spec: PipelineRunSpec{Priority: &priority}}
+	release := &PipelineRun{Spec: PipelineRunSpec{SCM: &SCM{RefName: "release-1.0"}}}
+	pullRequest := &PipelineRun{Spec: PipelineRunSpec{SCM: &SCM{RefName: "PR-1"}}}
+	noSCM := &PipelineRun{}
+
+	var policy *ConcurrencyPolicy
+	assert.Equal(t, int32(5), policy.GetPriority(explicit))
+	assert.Equal(t, int32(0), policy.GetPriority(release))
+
+	policy = &ConcurrencyPolicy{PriorityClasses: []PriorityClass{
+		{Name: "release", Value: 100, Refs: []string{"release-*"}},
+		{Name: "main", Value: 50, Refs: []string{"main"}},
+	}}
+	assert.Equal(t, int32(5), policy.GetPriority(explicit))
+	assert.Equal(t, int32(100), policy.GetPriority(release))
+	assert.Equal(t, int32(0), policy.GetPriority(pullRequest))
+	assert.Equal(t, int32(0), policy.GetPriority(noSCM))
+
+	policy.PriorityClasses = append(policy.PriorityClasses, PriorityClass{Name: "others", Value: -10})
+	assert.Equal(t, int32(-10), policy.GetPriority(pullRequest))
+	assert.Equal(t, int32(-10), policy.GetPriority(noSCM))
+}
+
+func TestPipelineSpec_GetArtifactOutputs(t *testing.T) {
+	jar := ArtifactOutput{Name: "jar", Path: "target/app.jar"}
+	spec := &PipelineSpec{ArtifactOutputs: []ArtifactOutput{jar}}
+	assert.Equal(t, []ArtifactOutput{jar}, spec.GetArtifactOutputs())
+
+	spec.ScanImage = true
+	assert.Equal(t, []ArtifactOutput{jar, {Name: ImageScanArtifact, Path: ImageScanReportPath}}, spec.GetArtifactOutputs())
+	assert.Equal(t, []ArtifactOutput{jar}, spec.ArtifactOutputs)
+
+	// the declared output takes precedence
+	report := ArtifactOutput{Name: ImageScanArtifact, Path: "reports/trivy.json"}
+	spec.ArtifactOutputs = []ArtifactOutput{report}
+	assert.Equal(t, []ArtifactOutput{report}, spec.GetArtifactOutputs())
+
+	assert.Equal(t, DefaultImageScanner, spec.ImageScan.GetImage())
+	spec.ImageScan = &ImageScanPolicy{Image: "mirror/trivy:latest"}
+	assert.Equal(t, "mirror/trivy:latest", spec.ImageScan.GetImage())
+}
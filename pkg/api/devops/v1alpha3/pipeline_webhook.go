@@ -0,0 +1,188 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// ParameterTypes are the supported types of the parameter definitions
+var ParameterTypes = sets.NewString("string", "choice", "text", "boolean", "file", "password")
+
+// SetupWebhookWithManager registers the validating webhook of Pipeline
+func (p *Pipeline) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(p).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-devops-kubesphere-io-v1alpha3-pipeline,mutating=false,failurePolicy=fail,sideEffects=None,groups=devops.kubesphere.io,resources=pipelines,verbs=create;update,versions=v1alpha3,name=vpipeline.devops.kubesphere.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &Pipeline{}
+
+// ValidateCreate implements webhook.Validator
+func (p *Pipeline) ValidateCreate() error {
+	return p.toInvalidError(p.validate())
+}
+
+// ValidateUpdate implements webhook.Validator
+func (p *Pipeline) ValidateUpdate(old runtime.Object) error {
+	return p.toInvalidError(p.validate())
+}
+
+// ValidateDelete implements webhook.Validator
+func (p *Pipeline) ValidateDelete() error {
+	return nil
+}
+
+func (p *Pipeline) validate() (errs field.ErrorList) {
+	errs = append(errs, validateName(field.NewPath("metadata", "name"), p.Name)...)
+	errs = append(errs, p.Spec.validate(field.NewPath("spec"))...)
+	return
+}
+
+func (p *Pipeline) toInvalidError(errs field.ErrorList) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind(ResourceKindPipeline).GroupKind(), p.Name, errs)
+}
+
+func (spec *PipelineSpec) validate(path *field.Path) (errs field.ErrorList) {
+	switch spec.Type {
+	case NoScmPipelineType:
+		if spec.Pipeline == nil {
+			if spec.Template != nil || spec.Source != nil {
+				// the Pipeline will be rendered from the template, or loaded from the git repository
+				break
+			}
+			errs = append(errs, field.Required(path.Child("pipeline"), "required by the type "+string(spec.Type)))
+		} else {
+			errs = append(errs, validateParameterDefinitions(path.Child("pipeline", "parameters"), spec.Pipeline.Parameters)...)
+		}
+	case MultiBranchPipelineType:
+		if spec.MultiBranchPipeline == nil {
+			errs = append(errs, field.Required(path.Child("multi_branch_pipeline"), "required by the type "+string(spec.Type)))
+		}
+	default:
+		errs = append(errs, field.NotSupported(path.Child("type"), spec.Type,
+			[]string{string(NoScmPipelineType), string(MultiBranchPipelineType)}))
+	}
+
+	names := sets.NewString()
+	for i, output := range spec.ArtifactOutputs {
+		outputPath := path.Child("artifactOutputs").Index(i)
+		if output.Name == "" {
+			errs = append(errs, field.Required(outputPath.Child("name"), ""))
+		} else if names.Has(output.Name) {
+			errs = append(errs, field.Duplicate(outputPath.Child("name"), output.Name))
+		}
+		names.Insert(output.Name)
+		if output.Path == "" {
+			errs = append(errs, field.Required(outputPath.Child("path"), ""))
+		}
+	}
+
+	if spec.ScanImage && spec.Type != NoScmPipelineType {
+		errs = append(errs, field.Forbidden(path.Child("scanImage"), "only supported by the type "+string(NoScmPipelineType)))
+	}
+
+	if spec.SupplyChain != nil {
+		supplyChainPath := path.Child("supplyChain")
+		switch spec.SupplyChain.SBOMFormat {
+		case "", SBOMFormatSPDX, SBOMFormatCycloneDX:
+		default:
+			errs = append(errs, field.NotSupported(supplyChainPath.Child("sbomFormat"), spec.SupplyChain.SBOMFormat,
+				[]string{SBOMFormatSPDX, SBOMFormatCycloneDX}))
+		}
+		if spec.SupplyChain.Provenance && spec.SupplyChain.SigningKey == "" {
+			errs = append(errs, field.Required(supplyChainPath.Child("signingKey"), "required by the provenance attestations"))
+		}
+	}
+
+	if spec.Concurrency != nil {
+		concurrencyPath := path.Child("concurrency")
+		if spec.Concurrency.MaxConcurrentRuns < 0 {
+			errs = append(errs, field.Invalid(concurrencyPath.Child("maxConcurrentRuns"),
+				spec.Concurrency.MaxConcurrentRuns, "must be greater than or equal to 0"))
+		}
+		switch spec.Concurrency.QueuePolicy {
+		case "", QueuePolicyQueue, QueuePolicyDiscard:
+		default:
+			errs = append(errs, field.NotSupported(concurrencyPath.Child("queuePolicy"), spec.Concurrency.QueuePolicy,
+				[]string{string(QueuePolicyQueue), string(QueuePolicyDiscard)}))
+		}
+	}
+
+	if spec.Template != nil {
+		templatePath := path.Child("template")
+		if spec.Type != NoScmPipelineType {
+			errs = append(errs, field.Forbidden(templatePath, "only supported by the type "+string(NoScmPipelineType)))
+		}
+		if spec.Template.Name == "" {
+			errs = append(errs, field.Required(templatePath.Child("name"), ""))
+		}
+		switch spec.Template.Kind {
+		case "", ResourceKindTemplate, ResourceKindClusterTemplate:
+		default:
+			errs = append(errs, field.NotSupported(templatePath.Child("kind"), spec.Template.Kind,
+				[]string{ResourceKindTemplate, ResourceKindClusterTemplate}))
+		}
+	}
+
+	if spec.Source != nil {
+		sourcePath := path.Child("source")
+		if spec.Template != nil {
+			errs = append(errs, field.Forbidden(sourcePath, "cannot be used together with the template"))
+		}
+		if spec.Source.GitRepository == "" {
+			errs = append(errs, field.Required(sourcePath.Child("gitRepository"), ""))
+		}
+	}
+	return
+}
+
+func validateParameterDefinitions(path *field.Path, parameters []ParameterDefinition) (errs field.ErrorList) {
+	names := sets.NewString()
+	for i, parameter := range parameters {
+		parameterPath := path.Index(i)
+		if parameter.Name == "" {
+			errs = append(errs, field.Required(parameterPath.Child("name"), ""))
+		} else if names.Has(parameter.Name) {
+			errs = append(errs, field.Duplicate(parameterPath.Child("name"), parameter.Name))
+		}
+		names.Insert(parameter.Name)
+		if !ParameterTypes.Has(parameter.Type) {
+			errs = append(errs, field.NotSupported(parameterPath.Child("type"), parameter.Type, ParameterTypes.List()))
+		}
+	}
+	return
+}
+
+// validateName makes sure the name could be used as a label value, which is required by Tekton
+func validateName(path *field.Path, name string) (errs field.ErrorList) {
+	if len(name) > validation.LabelValueMaxLength {
+		errs = append(errs, field.TooLong(path, name, validation.LabelValueMaxLength))
+	}
+	return
+}
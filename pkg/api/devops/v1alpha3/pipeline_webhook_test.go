@@ -0,0 +1,206 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPipeline_ValidateCreate(t *testing.T) {
+	tests := []struct {
+		name     string
+		pipeline *Pipeline
+		wantErr  bool
+	}{{
+		name: "valid Pipeline",
+		pipeline: &Pipeline{
+			ObjectMeta: metav1.ObjectMeta{Name: "fake"},
+			Spec: PipelineSpec{
+				Type: NoScmPipelineType,
+				Pipeline: &NoScmPipeline{
+					Parameters: []ParameterDefinition{{Name: "a", Type: "string"}, {Name: "b", Type: "choice"}},
+				},
+				ArtifactOutputs: []ArtifactOutput{{Name: "jar", Path: "target/app.jar"}},
+			},
+		},
+	}, {
+		name: "name is too long",
+		pipeline: &Pipeline{
+			ObjectMeta: metav1.ObjectMeta{Name: strings.Repeat("a", 64)},
+			Spec:       PipelineSpec{Type: NoScmPipelineType, Pipeline: &NoScmPipeline{}},
+		},
+		wantErr: true,
+	}, {
+		name: "unknown type",
+		pipeline: &Pipeline{
+			ObjectMeta: metav1.ObjectMeta{Name: "fake"},
+			Spec:       PipelineSpec{Type: "fake"},
+		},
+		wantErr: true,
+	}, {
+		name: "multi-branch Pipeline without the definition",
+		pipeline: &Pipeline{
+			ObjectMeta: metav1.ObjectMeta{Name: "fake"},
+			Spec:       PipelineSpec{Type: MultiBranchPipelineType},
+		},
+		wantErr: true,
+	}, {
+		name: "duplicate parameter names",
+		pipeline: &Pipeline{
+			ObjectMeta: metav1.ObjectMeta{Name: "fake"},
+			Spec: PipelineSpec{
+				Type: NoScmPipelineType,
+				Pipeline: &NoScmPipeline{
+					Parameters: []ParameterDefinition{{Name: "a", Type: "string"}, {Name: "a", Type: "text"}},
+				},
+			},
+		},
+		wantErr: true,
+	}, {
+		name: "invalid parameter type",
+		pipeline: &Pipeline{
+			ObjectMeta: metav1.ObjectMeta{Name: "fake"},
+			Spec: PipelineSpec{
+				Type: NoScmPipelineType,
+				Pipeline: &NoScmPipeline{
+					Parameters: []ParameterDefinition{{Name: "a", Type: "array"}},
+				},
+			},
+		},
+		wantErr: true,
+	}, {
+		name: "duplicate artifact outputs",
+		pipeline: &Pipeline{
+			ObjectMeta: metav1.ObjectMeta{Name: "fake"},
+			Spec: PipelineSpec{
+				Type:            NoScmPipelineType,
+				Pipeline:        &NoScmPipeline{},
+				ArtifactOutputs: []ArtifactOutput{{Name: "jar", Path: "a.jar"}, {Name: "jar", Path: "b.jar"}},
+			},
+		},
+		wantErr: true,
+	}, {
+		name: "scan the images of a multi-branch Pipeline",
+		pipeline: &Pipeline{
+			ObjectMeta: metav1.ObjectMeta{Name: "fake"},
+			Spec: PipelineSpec{
+				Type:                MultiBranchPipelineType,
+				MultiBranchPipeline: &MultiBranchPipeline{},
+				ScanImage:           true,
+			},
+		},
+		wantErr: true,
+	}, {
+		name: "valid supply chain policy",
+		pipeline: &Pipeline{
+			ObjectMeta: metav1.ObjectMeta{Name: "fake"},
+			Spec: PipelineSpec{
+				Type:        NoScmPipelineType,
+				Pipeline:    &NoScmPipeline{},
+				SupplyChain: &SupplyChainPolicy{SBOM: true, SBOMFormat: SBOMFormatCycloneDX, Provenance: true, SigningKey: "cosign"},
+			},
+		},
+	}, {
+		name: "provenance without a signing key",
+		pipeline: &Pipeline{
+			ObjectMeta: metav1.ObjectMeta{Name: "fake"},
+			Spec: PipelineSpec{
+				Type:        NoScmPipelineType,
+				Pipeline:    &NoScmPipeline{},
+				SupplyChain: &SupplyChainPolicy{SBOMFormat: "syft-json", Provenance: true},
+			},
+		},
+		wantErr: true,
+	}, {
+		name: "valid concurrency policy",
+		pipeline: &Pipeline{
+			ObjectMeta: metav1.ObjectMeta{Name: "fake"},
+			Spec: PipelineSpec{
+				Type:        NoScmPipelineType,
+				Pipeline:    &NoScmPipeline{},
+				Concurrency: &ConcurrencyPolicy{MaxConcurrentRuns: 1, QueuePolicy: QueuePolicyDiscard},
+			},
+		},
+	}, {
+		name: "invalid concurrency policy",
+		pipeline: &Pipeline{
+			ObjectMeta: metav1.ObjectMeta{Name: "fake"},
+			Spec: PipelineSpec{
+				Type:        NoScmPipelineType,
+				Pipeline:    &NoScmPipeline{},
+				Concurrency: &ConcurrencyPolicy{MaxConcurrentRuns: -1, QueuePolicy: "Replace"},
+			},
+		},
+		wantErr: true,
+	}, {
+		name: "valid template reference",
+		pipeline: &Pipeline{
+			ObjectMeta: metav1.ObjectMeta{Name: "fake"},
+			Spec: PipelineSpec{
+				Type:     NoScmPipelineType,
+				Template: &PipelineTemplateRef{Kind: ResourceKindClusterTemplate, Name: "build-go"},
+			},
+		},
+	}, {
+		name: "invalid template reference",
+		pipeline: &Pipeline{
+			ObjectMeta: metav1.ObjectMeta{Name: "fake"},
+			Spec: PipelineSpec{
+				Type:     NoScmPipelineType,
+				Pipeline: &NoScmPipeline{},
+				Template: &PipelineTemplateRef{Kind: "StepTemplate"},
+			},
+		},
+		wantErr: true,
+	}, {
+		name: "valid source",
+		pipeline: &Pipeline{
+			ObjectMeta: metav1.ObjectMeta{Name: "fake"},
+			Spec: PipelineSpec{
+				Type:   NoScmPipelineType,
+				Source: &PipelineSource{GitRepository: "repo"},
+			},
+		},
+	}, {
+		name: "invalid source",
+		pipeline: &Pipeline{
+			ObjectMeta: metav1.ObjectMeta{Name: "fake"},
+			Spec: PipelineSpec{
+				Type:     NoScmPipelineType,
+				Template: &PipelineTemplateRef{Name: "build-go"},
+				Source:   &PipelineSource{},
+			},
+		},
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.pipeline.ValidateCreate()
+			if tt.wantErr {
+				assert.True(t, apierrors.IsInvalid(err), "unexpected error: %v", err)
+			} else {
+				assert.Nil(t, err)
+			}
+			assert.Equal(t, err, tt.pipeline.ValidateUpdate(tt.pipeline.DeepCopy()))
+		})
+	}
+}
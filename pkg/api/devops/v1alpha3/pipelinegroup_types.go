@@ -0,0 +1,217 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"kubesphere.io/devops/pkg/api/devops"
+)
+
+const (
+	// PipelineGroupLabelKey is label key of the PipelineGroup which triggered the PipelineRun.
+	PipelineGroupLabelKey = devops.GroupName + "/pipelinegroup"
+	// PipelineGroupMemberLabelKey is label key of the member of the PipelineGroup which the PipelineRun belongs to.
+	PipelineGroupMemberLabelKey = devops.GroupName + "/pipelinegroup-member"
+
+	// Skipped indicates that the member of a PipelineGroup is not triggered since its dependencies did not succeed.
+	Skipped RunPhase = "Skipped"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:shortName="pg",categories="devops"
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// PipelineGroup runs a group of Pipelines in the order of their dependencies. A member is triggered once all the
+// members it depends on have succeeded, the members without dependencies are triggered at the beginning.
+type PipelineGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PipelineGroupSpec   `json:"spec,omitempty"`
+	Status PipelineGroupStatus `json:"status,omitempty"`
+}
+
+// PipelineGroupSpec declares the Pipelines and their dependencies
+type PipelineGroupSpec struct {
+	// Members are the Pipelines of the group, they are in the same namespace as the group.
+	Members []PipelineGroupMember `json:"members"`
+
+	// Parameters are passed to the PipelineRuns of all the members.
+	// The values could be referred by the parameters of the members in the format of $(params.<name>).
+	// +optional
+	Parameters []Parameter `json:"parameters,omitempty"`
+}
+
+// PipelineGroupMember is a Pipeline in a PipelineGroup
+type PipelineGroupMember struct {
+	// Name identifies the member in the group.
+	Name string `json:"name"`
+
+	// PipelineRef is the name of the Pipeline.
+	PipelineRef string `json:"pipelineRef"`
+
+	// DependsOn are the names of the members which must succeed before triggering this one.
+	// +optional
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// Parameters are passed to the PipelineRun of this member, they override the parameters of the group.
+	// The results of the members it depends on could be referred in the format of $(members.<name>.results.<result>).
+	// +optional
+	Parameters []Parameter `json:"parameters,omitempty"`
+
+	// SCM is the branch or tag to run when the Pipeline is a multi-branch Pipeline.
+	// +optional
+	SCM *SCM `json:"scm,omitempty"`
+}
+
+// PipelineGroupStatus is the aggregated status of the members
+type PipelineGroupStatus struct {
+	// Phase is the phase of the group, it's Succeeded only if all the members have succeeded.
+	// +optional
+	Phase RunPhase `json:"phase,omitempty"`
+
+	// Message tells why the group failed.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// StartTime is the time when the group was started.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is the time when all the members completed or skipped.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Members are the statuses of the members.
+	// +optional
+	Members []PipelineGroupMemberStatus `json:"members,omitempty"`
+}
+
+// PipelineGroupMemberStatus is the status of a member of the PipelineGroup
+type PipelineGroupMemberStatus struct {
+	// Name is the name of the member.
+	Name string `json:"name"`
+
+	// PipelineRun is the name of the PipelineRun which was triggered for the member.
+	// +optional
+	PipelineRun string `json:"pipelineRun,omitempty"`
+
+	// Phase is the phase of the PipelineRun, or Skipped if the member was not triggered.
+	// +optional
+	Phase RunPhase `json:"phase,omitempty"`
+
+	// Message tells why the member was skipped or failed to be triggered.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// PipelineGroupList contains a list of PipelineGroup
+type PipelineGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PipelineGroup `json:"items"`
+}
+
+// HasCompleted indicates if all the members of the PipelineGroup have completed or skipped.
+func (g *PipelineGroup) HasCompleted() bool {
+	return !g.Status.CompletionTime.IsZero()
+}
+
+// GetMember returns the member by name.
+func (g *PipelineGroup) GetMember(name string) *PipelineGroupMember {
+	for i := range g.Spec.Members {
+		if g.Spec.Members[i].Name == name {
+			return &g.Spec.Members[i]
+		}
+	}
+	return nil
+}
+
+// GetMemberStatus returns the status of a member, it's nil if the member has not been handled.
+func (status *PipelineGroupStatus) GetMemberStatus(name string) *PipelineGroupMemberStatus {
+	for i := range status.Members {
+		if status.Members[i].Name == name {
+			return &status.Members[i]
+		}
+	}
+	return nil
+}
+
+// Validate checks if the members are unique and their dependencies are acyclic.
+func (g *PipelineGroup) Validate() error {
+	if len(g.Spec.Members) == 0 {
+		return fmt.Errorf("at least one member is required")
+	}
+	members := map[string]*PipelineGroupMember{}
+	for i := range g.Spec.Members {
+		member := &g.Spec.Members[i]
+		if member.Name == "" || member.PipelineRef == "" {
+			return fmt.Errorf("both name and pipelineRef of the members are required")
+		}
+		if members[member.Name] != nil {
+			return fmt.Errorf("duplicated member %q", member.Name)
+		}
+		members[member.Name] = member
+	}
+	for _, member := range g.Spec.Members {
+		for _, dependency := range member.DependsOn {
+			if members[dependency] == nil {
+				return fmt.Errorf("member %q depends on an unknown member %q", member.Name, dependency)
+			}
+		}
+	}
+
+	// detect the cycles by depth-first search
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	states := map[string]int{}
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch states[name] {
+		case visiting:
+			return fmt.Errorf("member %q depends on itself", name)
+		case visited:
+			return nil
+		}
+		states[name] = visiting
+		for _, dependency := range members[name].DependsOn {
+			if err := visit(dependency); err != nil {
+				return err
+			}
+		}
+		states[name] = visited
+		return nil
+	}
+	for _, member := range g.Spec.Members {
+		if err := visit(member.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	SchemeBuilder.Register(&PipelineGroup{}, &PipelineGroupList{})
+}
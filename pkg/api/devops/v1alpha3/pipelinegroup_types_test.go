@@ -0,0 +1,73 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipelineGroup_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		members []PipelineGroupMember
+		wantErr bool
+	}{{
+		name: "valid",
+		members: []PipelineGroupMember{
+			{Name: "build", PipelineRef: "build"},
+			{Name: "test", PipelineRef: "test", DependsOn: []string{"build"}},
+			{Name: "scan", PipelineRef: "scan", DependsOn: []string{"build"}},
+			{Name: "deploy", PipelineRef: "deploy", DependsOn: []string{"test", "scan"}},
+		},
+	}, {
+		name:    "no members",
+		wantErr: true,
+	}, {
+		name:    "without pipelineRef",
+		members: []PipelineGroupMember{{Name: "build"}},
+		wantErr: true,
+	}, {
+		name:    "duplicated",
+		members: []PipelineGroupMember{{Name: "build", PipelineRef: "build"}, {Name: "build", PipelineRef: "test"}},
+		wantErr: true,
+	}, {
+		name:    "unknown dependency",
+		members: []PipelineGroupMember{{Name: "build", PipelineRef: "build", DependsOn: []string{"fetch"}}},
+		wantErr: true,
+	}, {
+		name:    "depends on itself",
+		members: []PipelineGroupMember{{Name: "build", PipelineRef: "build", DependsOn: []string{"build"}}},
+		wantErr: true,
+	}, {
+		name: "cycle",
+		members: []PipelineGroupMember{
+			{Name: "build", PipelineRef: "build", DependsOn: []string{"deploy"}},
+			{Name: "test", PipelineRef: "test", DependsOn: []string{"build"}},
+			{Name: "deploy", PipelineRef: "deploy", DependsOn: []string{"test"}},
+		},
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group := &PipelineGroup{Spec: PipelineGroupSpec{Members: tt.members}}
+			err := group.Validate()
+			assert.Equal(t, tt.wantErr, err != nil, err)
+		})
+	}
+}
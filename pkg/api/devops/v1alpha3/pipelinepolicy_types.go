@@ -0,0 +1,145 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// PolicyAction is the action taken when an object violates the rules of a PipelinePolicy
+type PolicyAction string
+
+const (
+	// PolicyActionDeny rejects the violating objects
+	PolicyActionDeny PolicyAction = "Deny"
+	// PolicyActionWarn admits the violating objects, and returns the violations as warnings
+	PolicyActionWarn PolicyAction = "Warn"
+)
+
+// PolicyFailurePolicy decides how to handle the rules which are not able to be evaluated,
+// such as invalid expressions or an unreachable OPA server.
+type PolicyFailurePolicy string
+
+const (
+	// PolicyFailurePolicyFail takes the rule as violated
+	PolicyFailurePolicyFail PolicyFailurePolicy = "Fail"
+	// PolicyFailurePolicyIgnore takes the rule as passed
+	PolicyFailurePolicyIgnore PolicyFailurePolicy = "Ignore"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Action",type=string,JSONPath=`.spec.action`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// PipelinePolicy is a set of rules which are evaluated on the admission of the created or updated Pipelines and
+// PipelineRuns, such as disallowing privileged pod templates, or restricting the target namespaces.
+type PipelinePolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec PipelinePolicySpec `json:"spec,omitempty"`
+}
+
+// PipelinePolicySpec is the rules and the scope of a PipelinePolicy
+type PipelinePolicySpec struct {
+	// Kinds are the kinds of the objects which the rules are evaluated on, could be Pipeline or PipelineRun.
+	// All of them are evaluated if it's empty.
+	// +optional
+	Kinds []string `json:"kinds,omitempty"`
+	// NamespaceSelector selects the namespaces which the policy applies to, all namespaces are selected if it's nil
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// Action is the action taken on the violations, defaults to Deny
+	// +optional
+	// +kubebuilder:validation:Enum=Deny;Warn
+	Action PolicyAction `json:"action,omitempty"`
+	// FailurePolicy decides how to handle the rules which are not able to be evaluated, defaults to Fail
+	// +optional
+	// +kubebuilder:validation:Enum=Fail;Ignore
+	FailurePolicy PolicyFailurePolicy `json:"failurePolicy,omitempty"`
+	Rules         []PolicyRule        `json:"rules"`
+}
+
+// PolicyRule is a rule written in CEL or Rego, only one of them should be specified.
+type PolicyRule struct {
+	// Name is the unique name of the rule in a PipelinePolicy
+	Name string `json:"name"`
+	// CEL is an expression which must be evaluated to true by the admitted objects. The variables are object,
+	// the object as a map, and request, which has the kind, namespace, operation and username of the admission.
+	// +optional
+	CEL string `json:"cel,omitempty"`
+	// Rego asks an OPA server for the violations
+	// +optional
+	Rego *RegoRule `json:"rego,omitempty"`
+	// Message is returned when the CEL expression is violated, defaults to the expression
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// RegoRule asks an OPA server for the violations. The input has the object, and the kind, namespace, operation
+// and username of the admission. The result of the query should be a set of messages, such as the deny rules of Gatekeeper.
+type RegoRule struct {
+	// Server is the address of the OPA server, such as http://opa.opa-system:8181
+	Server string `json:"server"`
+	// Query is the path of the document which has the violations, such as devops/pipelines/deny
+	Query string `json:"query"`
+	// Module is the Rego source which is uploaded to the OPA server before querying, it could be omitted if the
+	// policies are maintained by the OPA server.
+	// +optional
+	Module string `json:"module,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// PipelinePolicyList contains a list of PipelinePolicy
+type PipelinePolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PipelinePolicy `json:"items"`
+}
+
+// GetAction returns the action taken on the violations
+func (s *PipelinePolicySpec) GetAction() PolicyAction {
+	if s.Action == "" {
+		return PolicyActionDeny
+	}
+	return s.Action
+}
+
+// GetFailurePolicy returns how to handle the rules which are not able to be evaluated
+func (s *PipelinePolicySpec) GetFailurePolicy() PolicyFailurePolicy {
+	if s.FailurePolicy == "" {
+		return PolicyFailurePolicyFail
+	}
+	return s.FailurePolicy
+}
+
+// AppliesToKind indicates if the rules are evaluated on the objects of the kind
+func (s *PipelinePolicySpec) AppliesToKind(kind string) bool {
+	if len(s.Kinds) == 0 {
+		return true
+	}
+	for _, item := range s.Kinds {
+		if item == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	SchemeBuilder.Register(&PipelinePolicy{}, &PipelinePolicyList{})
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipelinePolicySpec(t *testing.T) {
+	spec := &PipelinePolicySpec{}
+	assert.Equal(t, PolicyActionDeny, spec.GetAction())
+	assert.Equal(t, PolicyFailurePolicyFail, spec.GetFailurePolicy())
+	assert.True(t, spec.AppliesToKind(ResourceKindPipeline))
+	assert.True(t, spec.AppliesToKind(ResourceKindPipelineRun))
+
+	spec = &PipelinePolicySpec{
+		Kinds:         []string{ResourceKindPipelineRun},
+		Action:        PolicyActionWarn,
+		FailurePolicy: PolicyFailurePolicyIgnore,
+	}
+	assert.Equal(t, PolicyActionWarn, spec.GetAction())
+	assert.Equal(t, PolicyFailurePolicyIgnore, spec.GetFailurePolicy())
+	assert.False(t, spec.AppliesToKind(ResourceKindPipeline))
+	assert.True(t, spec.AppliesToKind(ResourceKindPipelineRun))
+}
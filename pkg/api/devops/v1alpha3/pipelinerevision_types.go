@@ -0,0 +1,82 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ResourceKindPipelineRevision is the kind of PipelineRevision
+const ResourceKindPipelineRevision = "PipelineRevision"
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:shortName="prev",categories="devops"
+//+kubebuilder:printcolumn:name="Pipeline",type=string,JSONPath=`.spec.pipeline`
+//+kubebuilder:printcolumn:name="Revision",type=integer,JSONPath=`.spec.revision`
+//+kubebuilder:printcolumn:name="Author",type=string,JSONPath=`.spec.author`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// PipelineRevision is an immutable snapshot of the spec of a Pipeline. It's recorded by the controller whenever the
+// spec changes, and removed along with its Pipeline.
+type PipelineRevision struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec PipelineRevisionSpec `json:"spec,omitempty"`
+}
+
+// PipelineRevisionSpec is the snapshot of a Pipeline spec
+type PipelineRevisionSpec struct {
+	// Pipeline is the name of the Pipeline in the same namespace
+	Pipeline string `json:"pipeline"`
+
+	// Revision increases by one for every change of the Pipeline spec, it starts from 1
+	Revision int64 `json:"revision"`
+
+	// Author is the user who made the change, see also PipelineModifiedByAnnoKey
+	// +optional
+	Author string `json:"author,omitempty"`
+
+	// Hash is the hash of the Pipeline spec
+	Hash string `json:"hash"`
+
+	// PipelineSpec is the Pipeline spec of the revision, it's not validated since the schema of Pipeline evolves
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	PipelineSpec PipelineSpec `json:"pipelineSpec"`
+}
+
+//+kubebuilder:object:root=true
+
+// PipelineRevisionList contains a list of PipelineRevision
+type PipelineRevisionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PipelineRevision `json:"items"`
+}
+
+// GetPipelineRevisionName returns the name of a PipelineRevision, such as build-r3
+func GetPipelineRevisionName(pipeline string, revision int64) string {
+	return fmt.Sprintf("%s-r%d", pipeline, revision)
+}
+
+func init() {
+	SchemeBuilder.Register(&PipelineRevision{}, &PipelineRevisionList{})
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha3
 
 import (
+	"encoding/json"
 	"sort"
 	"strings"
 
@@ -27,12 +28,18 @@ import (
 // PipelineRunFinalizerName is the name of PipelineRun finalizer
 const PipelineRunFinalizerName = "pipelinerun.finalizers.kubesphere.io"
 
+// ResourceKindPipelineRun is the kind of PipelineRun
+const ResourceKindPipelineRun = "PipelineRun"
+
 // PipelineRunSpec defines the desired state of PipelineRun
 type PipelineRunSpec struct {
-	// PipelineRef is the Pipeline to which the current PipelineRun belongs
-	PipelineRef *v1.ObjectReference `json:"pipelineRef"`
+	// PipelineRef is the Pipeline to which the current PipelineRun belongs.
+	// It could be empty if the PipelineSpec is inlined.
+	// +optional
+	PipelineRef *v1.ObjectReference `json:"pipelineRef,omitempty"`
 
 	// PipelineSpec is the specification of Pipeline when the current PipelineRun is created.
+	// A one-off PipelineRun could only carry the PipelineSpec without PipelineRef.
 	// +optional
 	PipelineSpec *PipelineSpec `json:"pipelineSpec,omitempty"`
 
@@ -47,6 +54,26 @@ type PipelineRunSpec struct {
 	// Action indicates what we need to do with current PipelineRun.
 	// +optional
 	Action *Action `json:"action,omitempty"`
+
+	// ClusterTarget is the member cluster which runs the PipelineRun.
+	// The PipelineRun runs on the current cluster if it's empty.
+	// +optional
+	ClusterTarget *ClusterTarget `json:"clusterTarget,omitempty"`
+
+	// Priority decides the order of the queued PipelineRuns of a Pipeline, the higher goes first.
+	// It defaults to the matched priority class in the concurrency policy of the Pipeline.
+	// +optional
+	Priority *int32 `json:"priority,omitempty"`
+
+	// DeletionPolicy is one of Delete, Orphan and Retain, it defaults to the deletion policy of the Pipeline.
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+}
+
+// ClusterTarget is the member cluster which runs a PipelineRun.
+type ClusterTarget struct {
+	// Name is the name of the member cluster, it's a cluster.kubesphere.io Cluster.
+	Name string `json:"name"`
 }
 
 // PipelineRunStatus defines the observed state of PipelineRun
@@ -72,6 +99,192 @@ type PipelineRunStatus struct {
 	// Current phase of PipelineRun.
 	// +optional
 	Phase RunPhase `json:"phase,omitempty"`
+
+	// Artifacts which have been archived into the object storage.
+	// +optional
+	Artifacts []PipelineRunArtifact `json:"artifacts,omitempty"`
+
+	// Results are the output values of the completed PipelineRun, such as the build parameters of Jenkins.
+	// +optional
+	Results []RunResult `json:"results,omitempty"`
+
+	// Images are the images which were pushed by the PipelineRun.
+	// +optional
+	Images []PipelineRunImage `json:"images,omitempty"`
+
+	// Tests sums up the test and coverage reports published by the PipelineRun.
+	// +optional
+	Tests *TestSummary `json:"tests,omitempty"`
+
+	// QualityGate is the SonarQube quality gate of the analysis which was done by the PipelineRun.
+	// +optional
+	QualityGate *QualityGateStatus `json:"qualityGate,omitempty"`
+
+	// Usage is the compute resources consumed by the pods of the PipelineRun, such as the Jenkins agent pods.
+	// +optional
+	Usage *ResourceUsage `json:"usage,omitempty"`
+
+	// TriggeredBy is the identity which triggered the PipelineRun, it's copied from PipelineRunIdentityAnnoKey.
+	// +optional
+	TriggeredBy *TriggerIdentity `json:"triggeredBy,omitempty"`
+}
+
+// TriggerIdentitySource is where a TriggerIdentity comes from.
+type TriggerIdentitySource string
+
+// The sources of TriggerIdentity
+const (
+	// TriggerIdentityKubernetes indicates the PipelineRun was created through kube-apiserver by an authenticated user.
+	TriggerIdentityKubernetes TriggerIdentitySource = "kubernetes"
+	// TriggerIdentityToken indicates the PipelineRun was created through the DevOps APIs with a verified token,
+	// which was issued by KubeSphere on behalf of its LDAP or OIDC identity providers.
+	TriggerIdentityToken TriggerIdentitySource = "token"
+	// TriggerIdentityImpersonation indicates the PipelineRun was created by a user who was authorized to
+	// impersonate the identity, see TriggerIdentity.Impersonator.
+	TriggerIdentityImpersonation TriggerIdentitySource = "impersonation"
+	// TriggerIdentitySCM indicates the PipelineRun was triggered by a SCM webhook, the username is the sender
+	// of the event on the SCM provider.
+	TriggerIdentitySCM TriggerIdentitySource = "scm"
+	// TriggerIdentityUnverified indicates the token of the user could not be verified, the groups are never
+	// trusted in this case.
+	TriggerIdentityUnverified TriggerIdentitySource = "unverified"
+)
+
+// TriggerIdentity is the identity which triggered a PipelineRun.
+type TriggerIdentity struct {
+	// Username is the name of the user.
+	Username string `json:"username"`
+	// Groups are the groups of the user, such as the groups mapped from LDAP or OIDC.
+	// +optional
+	Groups []string `json:"groups,omitempty"`
+	// Source is where the identity comes from.
+	Source TriggerIdentitySource `json:"source"`
+	// Impersonator is the user who triggered the PipelineRun on behalf of Username.
+	// +optional
+	Impersonator string `json:"impersonator,omitempty"`
+}
+
+// IsVerified returns true if the identity was verified, only the groups of the verified identities are trusted.
+func (i *TriggerIdentity) IsVerified() bool {
+	return i != nil && i.Username != "" && i.Source != TriggerIdentityUnverified
+}
+
+// GetTriggerIdentity returns the identity which triggered the PipelineRun, it's nil if it was not recorded.
+func (pr *PipelineRun) GetTriggerIdentity() *TriggerIdentity {
+	value := pr.Annotations[PipelineRunIdentityAnnoKey]
+	if value == "" {
+		return nil
+	}
+	identity := &TriggerIdentity{}
+	if err := json.Unmarshal([]byte(value), identity); err != nil || identity.Username == "" {
+		return nil
+	}
+	return identity
+}
+
+// SetTriggerIdentity records the identity which triggered the PipelineRun, see PipelineRunIdentityAnnoKey.
+func (pr *PipelineRun) SetTriggerIdentity(identity *TriggerIdentity) {
+	if identity == nil {
+		return
+	}
+	data, err := json.Marshal(identity)
+	if err != nil {
+		return
+	}
+	if pr.Annotations == nil {
+		pr.Annotations = map[string]string{}
+	}
+	pr.Annotations[PipelineRunIdentityAnnoKey] = string(data)
+}
+
+// ResourceUsage is the compute resources consumed by the pods of a PipelineRun. The requests of the pods
+// are multiplied by their lifetime, so that it can be used for the chargeback of the CI workloads.
+type ResourceUsage struct {
+	// CPUMilliCoreSeconds is the CPU requests in millicores multiplied by the lifetime of the pods in seconds.
+	// +optional
+	CPUMilliCoreSeconds int64 `json:"cpuMilliCoreSeconds,omitempty"`
+	// MemoryMiBSeconds is the memory requests in MiB multiplied by the lifetime of the pods in seconds.
+	// +optional
+	MemoryMiBSeconds int64 `json:"memoryMiBSeconds,omitempty"`
+	// Pods are the names of the pods which have been accounted.
+	// +optional
+	Pods []string `json:"pods,omitempty"`
+}
+
+// HasPod returns true if the pod has been accounted
+func (u *ResourceUsage) HasPod(name string) bool {
+	for _, pod := range u.Pods {
+		if pod == name {
+			return true
+		}
+	}
+	return false
+}
+
+// PipelineRunImage is an image which was pushed by a PipelineRun.
+type PipelineRunImage struct {
+	// Name is the image name without the tag and the digest, such as docker.io/library/nginx.
+	Name string `json:"name"`
+	// Tag is the pushed tag.
+	// +optional
+	Tag string `json:"tag,omitempty"`
+	// Digest is the digest of the pushed manifest.
+	// +optional
+	Digest string `json:"digest,omitempty"`
+	// URL is the link of the image on the web portal of the registry.
+	// +optional
+	URL string `json:"url,omitempty"`
+	// SBOM is the reference of the SBOM which is attached to the image, such as docker.io/library/nginx:sha256-abc.sbom.
+	// +optional
+	SBOM string `json:"sbom,omitempty"`
+	// Attestation is the reference of the signed provenance attestation which is attached to the image.
+	// +optional
+	Attestation string `json:"attestation,omitempty"`
+}
+
+// The results of the PipelineRuns which pushed images, the names could have a prefix if a PipelineRun
+// pushed more than one image, such as FRONTEND_IMAGE_URL. They follow the convention of Tekton Chains.
+const (
+	ImageURLResult    = "IMAGE_URL"
+	ImageDigestResult = "IMAGE_DIGEST"
+)
+
+// ImageResult is an image which was reported by the results of a PipelineRun.
+type ImageResult struct {
+	// URL is the image reference, such as docker.io/library/nginx:1.21
+	URL string
+	// Digest is empty if the PipelineRun did not report it
+	Digest string
+}
+
+// GetImageResults returns the images which were reported by the results, see also ImageURLResult.
+func (status *PipelineRunStatus) GetImageResults() (images []ImageResult) {
+	for _, result := range status.Results {
+		if !strings.HasSuffix(result.Name, ImageURLResult) || result.Value == "" {
+			continue
+		}
+		digest, _ := status.GetResult(strings.TrimSuffix(result.Name, ImageURLResult) + ImageDigestResult)
+		images = append(images, ImageResult{URL: result.Value, Digest: digest})
+	}
+	return
+}
+
+// RunResult is a named output value of a PipelineRun.
+type RunResult struct {
+	// Name is the name of the result.
+	Name string `json:"name"`
+	// Value is the value of the result.
+	Value string `json:"value"`
+}
+
+// GetResult returns the value of the result by name.
+func (status *PipelineRunStatus) GetResult(name string) (value string, ok bool) {
+	for _, result := range status.Results {
+		if result.Name == name {
+			return result.Value, true
+		}
+	}
+	return
 }
 
 // +kubebuilder:object:root=true
@@ -107,6 +320,16 @@ func (status *PipelineRunStatus) GetLatestCondition() *Condition {
 	return &status.Conditions[0]
 }
 
+// GetCondition returns the condition of the given type, or nil if it does not exist.
+func (status *PipelineRunStatus) GetCondition(conditionType ConditionType) *Condition {
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == conditionType {
+			return &status.Conditions[i]
+		}
+	}
+	return nil
+}
+
 // AddCondition adds a new condition into history of conditions.
 func (status *PipelineRunStatus) AddCondition(newCondition *Condition) {
 	// compare newCondition
@@ -163,6 +386,25 @@ func (prSpec *PipelineRunSpec) IsMultiBranchPipeline() bool {
 	return prSpec.PipelineSpec != nil && prSpec.PipelineSpec.Type == MultiBranchPipelineType
 }
 
+// IsRemote indicates if the PipelineRun runs on a member cluster instead of the current one.
+func (prSpec *PipelineRunSpec) IsRemote() bool {
+	return prSpec.ClusterTarget != nil && prSpec.ClusterTarget.Name != ""
+}
+
+// HasInlinePipelineSpec indicates if the PipelineRun carries an inline PipelineSpec instead of a PipelineRef.
+func (prSpec *PipelineRunSpec) HasInlinePipelineSpec() bool {
+	return prSpec.PipelineSpec != nil && (prSpec.PipelineRef == nil || prSpec.PipelineRef.Name == "")
+}
+
+// GetPipelineName returns the name of the Pipeline which the PipelineRun belongs to. The PipelineRef takes precedence
+// over the label PipelineNameLabelKey, the label is the only source of the PipelineRuns with inline Pipelines.
+func (pr *PipelineRun) GetPipelineName() string {
+	if pr.Spec.PipelineRef != nil && pr.Spec.PipelineRef.Name != "" {
+		return pr.Spec.PipelineRef.Name
+	}
+	return pr.GetLabels()[PipelineNameLabelKey]
+}
+
 // GetRefName get refName
 func (pr *PipelineRun) GetRefName() string {
 	var refName string
@@ -172,6 +414,44 @@ func (pr *PipelineRun) GetRefName() string {
 	return refName
 }
 
+// The triggers of PipelineRuns
+const (
+	// PipelineRunTriggerSCM indicates the PipelineRun was triggered by a SCM webhook
+	PipelineRunTriggerSCM = "scm"
+	// PipelineRunTriggerUser indicates the PipelineRun was triggered by a user
+	PipelineRunTriggerUser = "user"
+	// PipelineRunTriggerJenkins indicates the PipelineRun was triggered inside Jenkins, such as by a timer
+	PipelineRunTriggerJenkins = "jenkins"
+	// PipelineRunTriggerEvent indicates the PipelineRun was created by a Trigger from an event, see TriggerLabelKey
+	PipelineRunTriggerEvent = "event"
+	// PipelineRunTriggerUnknown indicates the trigger of the PipelineRun is unknown
+	PipelineRunTriggerUnknown = "unknown"
+)
+
+// GetTrigger returns what triggered the PipelineRun, see PipelineRunTriggerSCM and its siblings.
+func (pr *PipelineRun) GetTrigger() string {
+	switch {
+	case pr.Labels[TriggerLabelKey] != "":
+		return PipelineRunTriggerEvent
+	case pr.Annotations[PipelineRunSCMRepoAnnoKey] != "":
+		return PipelineRunTriggerSCM
+	case pr.Annotations[PipelineRunCreatorAnnoKey] != "" || pr.Annotations[PipelineRunTriggeredByAnnoKey] != "":
+		return PipelineRunTriggerUser
+	case pr.Labels[PipelineRunOrphanLabelKey] == "true":
+		return PipelineRunTriggerJenkins
+	default:
+		return PipelineRunTriggerUnknown
+	}
+}
+
+// GetCreator returns the user who triggered the PipelineRun, it's empty if it was not triggered by a user.
+func (pr *PipelineRun) GetCreator() string {
+	if creator := pr.Annotations[PipelineRunTriggeredByAnnoKey]; creator != "" {
+		return creator
+	}
+	return pr.Annotations[PipelineRunCreatorAnnoKey]
+}
+
 // GetPipelineRunID gets ID of PipelineRun.
 func (pr *PipelineRun) GetPipelineRunID() (pipelineRunID string, exist bool) {
 	pipelineRunID, exist = pr.Annotations[JenkinsPipelineRunIDAnnoKey]
@@ -248,6 +528,8 @@ type RunPhase string
 const (
 	// Pending indicates that the PipelineRun is pending.
 	Pending RunPhase = "Pending"
+	// Queued indicates that the PipelineRun is waiting for other PipelineRuns of the same Pipeline to finish.
+	Queued RunPhase = "Queued"
 	// Running indicates that the PipelineRun is running.
 	Running RunPhase = "Running"
 	// Succeeded indicates that the PipelineRun has succeeded.
@@ -271,6 +553,25 @@ const (
 	// ConditionSucceeded indicates that the pipeline has finished.
 	// For pipeline which runs to completion
 	ConditionSucceeded ConditionType = "Succeeded"
+
+	// ConditionSynced indicates that the Pipeline has been synchronized to the backend.
+	ConditionSynced ConditionType = "Synced"
+
+	// ConditionFailed indicates that the Pipeline or PipelineRun failed, the reason tells the details.
+	ConditionFailed ConditionType = "Failed"
+
+	// ConditionBackendAvailable indicates whether the backend of the PipelineRun is reachable.
+	ConditionBackendAvailable ConditionType = "BackendAvailable"
+
+	// ConditionDispatched indicates whether the PipelineRun has been dispatched to the member cluster of its ClusterTarget.
+	ConditionDispatched ConditionType = "Dispatched"
+
+	// ConditionPathFiltered indicates whether the commits which triggered the PipelineRun changed the paths
+	// matched by the path filter of its Pipeline.
+	ConditionPathFiltered ConditionType = "PathFiltered"
+
+	// ConditionStuck indicates whether the agent pods of the PipelineRun are stuck, see also StuckRunPolicy.
+	ConditionStuck ConditionType = "Stuck"
 )
 
 // ConditionStatus is the status of the current condition.
@@ -336,6 +637,24 @@ const (
 	TriggerFailed string = "TriggerFailed"
 	// RetrieveFailed indicates that it failed to retrieve the latest running data
 	RetrieveFailed string = "RetrieveFailed"
+	// InlinePipelineSpecUnsupported indicates that the backend is unable to run an inline PipelineSpec
+	InlinePipelineSpecUnsupported string = "InlinePipelineSpecUnsupported"
+	// SyncSucceeded indicates that the Pipeline has been synchronized to the backend
+	SyncSucceeded string = "SyncSucceeded"
+	// SyncFailed indicates that it failed to synchronize the Pipeline to the backend
+	SyncFailed string = "SyncFailed"
+	// ConcurrencyLimited indicates that the PipelineRun is queued or discarded due to the concurrency policy of the Pipeline
+	ConcurrencyLimited string = "ConcurrencyLimited"
+	// QuotaExceeded indicates that the PipelineRun is queued or cancelled due to the quota of the DevOpsProject
+	QuotaExceeded string = "QuotaExceeded"
+	// Preempted indicates that the queued PipelineRun is cancelled for a PipelineRun of a higher priority
+	Preempted string = "Preempted"
+	// Stuck indicates that the agent pod of the PipelineRun is stuck
+	Stuck string = "Stuck"
+	// BackendUnavailable indicates that the PipelineRun is waiting for the backend to recover
+	BackendUnavailable string = "BackendUnavailable"
+	// BackendRecovered indicates that the backend of the PipelineRun is reachable again
+	BackendRecovered string = "BackendRecovered"
 )
 
 func init() {
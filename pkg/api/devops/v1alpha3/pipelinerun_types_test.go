@@ -72,6 +72,49 @@ func TestPipelineRunSpec_IsMultiBranchPipeline(t *testing.T) {
 	}
 }
 
+func TestPipelineRunSpec_HasInlinePipelineSpec(t *testing.T) {
+	tests := []struct {
+		name   string
+		prSpec PipelineRunSpec
+		want   bool
+	}{{
+		name:   "empty spec",
+		prSpec: PipelineRunSpec{},
+		want:   false,
+	}, {
+		name: "only PipelineRef",
+		prSpec: PipelineRunSpec{
+			PipelineRef: &corev1.ObjectReference{Name: "fake"},
+		},
+		want: false,
+	}, {
+		name: "PipelineRef and PipelineSpec",
+		prSpec: PipelineRunSpec{
+			PipelineRef:  &corev1.ObjectReference{Name: "fake"},
+			PipelineSpec: &PipelineSpec{Type: NoScmPipelineType},
+		},
+		want: false,
+	}, {
+		name: "only PipelineSpec",
+		prSpec: PipelineRunSpec{
+			PipelineSpec: &PipelineSpec{Type: NoScmPipelineType},
+		},
+		want: true,
+	}, {
+		name: "PipelineSpec with an empty PipelineRef",
+		prSpec: PipelineRunSpec{
+			PipelineRef:  &corev1.ObjectReference{},
+			PipelineSpec: &PipelineSpec{Type: NoScmPipelineType},
+		},
+		want: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.prSpec.HasInlinePipelineSpec())
+		})
+	}
+}
+
 func TestPipelineRun_GetPipelineRunID(t *testing.T) {
 	type fields struct {
 		TypeMeta   v1.TypeMeta
@@ -241,6 +284,37 @@ func TestBuildPipelineRunIdentifier(t *testing.T) {
 	}
 }
 
+func TestPipelineRun_GetPipelineName(t *testing.T) {
+	tests := []struct {
+		name        string
+		pipelineRun *PipelineRun
+		want        string
+	}{{
+		name:        "empty PipelineRun",
+		pipelineRun: &PipelineRun{},
+		want:        "",
+	}, {
+		name: "from PipelineRef",
+		pipelineRun: &PipelineRun{
+			ObjectMeta: v1.ObjectMeta{Labels: map[string]string{PipelineNameLabelKey: "label"}},
+			Spec:       PipelineRunSpec{PipelineRef: &corev1.ObjectReference{Name: "ref"}},
+		},
+		want: "ref",
+	}, {
+		name: "from label",
+		pipelineRun: &PipelineRun{
+			ObjectMeta: v1.ObjectMeta{Labels: map[string]string{PipelineNameLabelKey: "label"}},
+			Spec:       PipelineRunSpec{PipelineRef: &corev1.ObjectReference{}},
+		},
+		want: "label",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.pipelineRun.GetPipelineName())
+		})
+	}
+}
+
 func TestPipelineRun_GetRefName(t *testing.T) {
 	type fields struct {
 		TypeMeta   v1.TypeMeta
@@ -315,3 +389,90 @@ func TestPipelineRun_GetRefName(t *testing.T) {
 		})
 	}
 }
+
+func TestPipelineRunStatus_GetResult(t *testing.T) {
+	status := &PipelineRunStatus{Results: []RunResult{{Name: "digest", Value: "sha256:abc"}}}
+	value, ok := status.GetResult("digest")
+	assert.True(t, ok)
+	assert.Equal(t, "sha256:abc", value)
+
+	_, ok = status.GetResult("missing")
+	assert.False(t, ok)
+}
+
+func TestPipelineRunStatus_GetImageResults(t *testing.T) {
+	status := &PipelineRunStatus{Results: []RunResult{
+		{Name: "IMAGE_URL", Value: "docker.io/org/app:v1"},
+		{Name: "IMAGE_DIGEST", Value: "sha256:app"},
+		{Name: "WEB_IMAGE_URL", Value: "docker.io/org/web:v1"},
+		{Name: "EMPTY_IMAGE_URL", Value: ""},
+		{Name: "VERSION", Value: "v1"},
+	}}
+	assert.Equal(t, []ImageResult{
+		{URL: "docker.io/org/app:v1", Digest: "sha256:app"},
+		{URL: "docker.io/org/web:v1"},
+	}, status.GetImageResults())
+}
+
+func TestPipelineRun_GetTrigger(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		labels      map[string]string
+		wantTrigger string
+		wantCreator string
+	}{{
+		name:        "unknown",
+		wantTrigger: PipelineRunTriggerUnknown,
+	}, {
+		name:        "triggered by SCM",
+		annotations: map[string]string{PipelineRunSCMRepoAnnoKey: "kubesphere/ks-devops"},
+		wantTrigger: PipelineRunTriggerSCM,
+	}, {
+		name:        "created by a user",
+		annotations: map[string]string{PipelineRunCreatorAnnoKey: "admin"},
+		wantTrigger: PipelineRunTriggerUser,
+		wantCreator: "admin",
+	}, {
+		name:        "triggered by a user",
+		annotations: map[string]string{PipelineRunCreatorAnnoKey: "admin", PipelineRunTriggeredByAnnoKey: "bob"},
+		wantTrigger: PipelineRunTriggerUser,
+		wantCreator: "bob",
+	}, {
+		name:        "triggered inside Jenkins",
+		labels:      map[string]string{PipelineRunOrphanLabelKey: "true"},
+		wantTrigger: PipelineRunTriggerJenkins,
+	}, {
+		name:        "created by a Trigger",
+		annotations: map[string]string{PipelineRunSCMRepoAnnoKey: "kubesphere/ks-devops"},
+		labels:      map[string]string{TriggerLabelKey: "release"},
+		wantTrigger: PipelineRunTriggerEvent,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := &PipelineRun{ObjectMeta: v1.ObjectMeta{Annotations: tt.annotations, Labels: tt.labels}}
+			assert.Equal(t, tt.wantTrigger, pr.GetTrigger())
+			assert.Equal(t, tt.wantCreator, pr.GetCreator())
+		})
+	}
+}
+
+func TestPipelineRun_TriggerIdentity(t *testing.T) {
+	pr := &PipelineRun{}
+	assert.Nil(t, pr.GetTriggerIdentity())
+	assert.False(t, pr.GetTriggerIdentity().IsVerified())
+
+	pr.SetTriggerIdentity(nil)
+	assert.Nil(t, pr.Annotations)
+
+	identity := &TriggerIdentity{Username: "alice", Groups: []string{"dev"}, Source: TriggerIdentityToken}
+	pr.SetTriggerIdentity(identity)
+	assert.Equal(t, identity, pr.GetTriggerIdentity())
+	assert.True(t, pr.GetTriggerIdentity().IsVerified())
+
+	pr.SetTriggerIdentity(&TriggerIdentity{Username: "bob", Source: TriggerIdentityUnverified})
+	assert.False(t, pr.GetTriggerIdentity().IsVerified())
+
+	pr.Annotations[PipelineRunIdentityAnnoKey] = "invalid"
+	assert.Nil(t, pr.GetTriggerIdentity())
+}
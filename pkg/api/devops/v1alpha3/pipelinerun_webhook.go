@@ -0,0 +1,121 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// SetupWebhookWithManager registers the validating webhook of PipelineRun, and the defaulting webhook
+// if the defaulter is not nil
+func (pr *PipelineRun) SetupWebhookWithManager(mgr ctrl.Manager, defaulter admission.CustomDefaulter) error {
+	builder := ctrl.NewWebhookManagedBy(mgr).For(pr)
+	if defaulter != nil {
+		builder = builder.WithDefaulter(defaulter)
+	}
+	return builder.Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-devops-kubesphere-io-v1alpha3-pipelinerun,mutating=false,failurePolicy=fail,sideEffects=None,groups=devops.kubesphere.io,resources=pipelineruns,verbs=create;update,versions=v1alpha3,name=vpipelinerun.devops.kubesphere.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &PipelineRun{}
+
+// ValidateCreate implements webhook.Validator
+func (pr *PipelineRun) ValidateCreate() error {
+	return pr.toInvalidError(pr.validate())
+}
+
+// ValidateUpdate implements webhook.Validator
+func (pr *PipelineRun) ValidateUpdate(old runtime.Object) error {
+	errs := pr.validate()
+	if oldPipelineRun, ok := old.(*PipelineRun); ok {
+		errs = append(errs, pr.validateImmutableFields(oldPipelineRun)...)
+	}
+	return pr.toInvalidError(errs)
+}
+
+// ValidateDelete implements webhook.Validator
+func (pr *PipelineRun) ValidateDelete() error {
+	return nil
+}
+
+func (pr *PipelineRun) validate() (errs field.ErrorList) {
+	errs = append(errs, validateName(field.NewPath("metadata", "name"), pr.Name)...)
+
+	specPath := field.NewPath("spec")
+	if pr.Spec.PipelineRef == nil || pr.Spec.PipelineRef.Name == "" {
+		if pr.Spec.PipelineSpec == nil {
+			errs = append(errs, field.Required(specPath.Child("pipelineRef"), "pipelineRef or pipelineSpec is required"))
+		}
+	}
+	if pr.Spec.HasInlinePipelineSpec() {
+		errs = append(errs, pr.Spec.PipelineSpec.validate(specPath.Child("pipelineSpec"))...)
+	}
+
+	names := sets.NewString()
+	for i, parameter := range pr.Spec.Parameters {
+		parameterPath := specPath.Child("parameters").Index(i)
+		if parameter.Name == "" {
+			errs = append(errs, field.Required(parameterPath.Child("name"), ""))
+		} else if names.Has(parameter.Name) {
+			errs = append(errs, field.Duplicate(parameterPath.Child("name"), parameter.Name))
+		}
+		names.Insert(parameter.Name)
+	}
+	return
+}
+
+// validateImmutableFields makes sure the PipelineRef and the trigger identity never change, and the rest of the spec
+// except the action cannot be changed once the PipelineRun has started.
+func (pr *PipelineRun) validateImmutableFields(old *PipelineRun) (errs field.ErrorList) {
+	if pr.Annotations[PipelineRunIdentityAnnoKey] != old.Annotations[PipelineRunIdentityAnnoKey] {
+		errs = append(errs, field.Forbidden(field.NewPath("metadata", "annotations").Key(PipelineRunIdentityAnnoKey),
+			"field is immutable"))
+	}
+	specPath := field.NewPath("spec")
+	if !reflect.DeepEqual(pr.Spec.PipelineRef, old.Spec.PipelineRef) {
+		errs = append(errs, field.Forbidden(specPath.Child("pipelineRef"), "field is immutable"))
+	}
+	if !old.HasStarted() {
+		return
+	}
+	if !reflect.DeepEqual(pr.Spec.PipelineSpec, old.Spec.PipelineSpec) {
+		errs = append(errs, field.Forbidden(specPath.Child("pipelineSpec"), "field is immutable once the PipelineRun has started"))
+	}
+	if !reflect.DeepEqual(pr.Spec.Parameters, old.Spec.Parameters) {
+		errs = append(errs, field.Forbidden(specPath.Child("parameters"), "field is immutable once the PipelineRun has started"))
+	}
+	if !reflect.DeepEqual(pr.Spec.SCM, old.Spec.SCM) {
+		errs = append(errs, field.Forbidden(specPath.Child("scm"), "field is immutable once the PipelineRun has started"))
+	}
+	return
+}
+
+func (pr *PipelineRun) toInvalidError(errs field.ErrorList) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind(ResourceKindPipelineRun).GroupKind(), pr.Name, errs)
+}
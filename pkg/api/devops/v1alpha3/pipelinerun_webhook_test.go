@@ -0,0 +1,159 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPipelineRun_ValidateCreate(t *testing.T) {
+	tests := []struct {
+		name        string
+		pipelineRun *PipelineRun
+		wantErr     bool
+	}{{
+		name: "valid PipelineRun",
+		pipelineRun: &PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{Name: "fake"},
+			Spec: PipelineRunSpec{
+				PipelineRef: &corev1.ObjectReference{Name: "fake"},
+				Parameters:  []Parameter{{Name: "a", Value: "a"}, {Name: "b", Value: "b"}},
+			},
+		},
+	}, {
+		name: "valid PipelineRun with inline PipelineSpec",
+		pipelineRun: &PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{Name: "fake"},
+			Spec: PipelineRunSpec{
+				PipelineSpec: &PipelineSpec{Type: NoScmPipelineType, Pipeline: &NoScmPipeline{}},
+			},
+		},
+	}, {
+		name: "missing PipelineRef",
+		pipelineRun: &PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{Name: "fake"},
+			Spec:       PipelineRunSpec{PipelineRef: &corev1.ObjectReference{}},
+		},
+		wantErr: true,
+	}, {
+		name: "invalid inline PipelineSpec",
+		pipelineRun: &PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{Name: "fake"},
+			Spec:       PipelineRunSpec{PipelineSpec: &PipelineSpec{Type: "fake"}},
+		},
+		wantErr: true,
+	}, {
+		name: "name is too long",
+		pipelineRun: &PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{Name: strings.Repeat("a", 64)},
+			Spec:       PipelineRunSpec{PipelineRef: &corev1.ObjectReference{Name: "fake"}},
+		},
+		wantErr: true,
+	}, {
+		name: "duplicate parameter names",
+		pipelineRun: &PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{Name: "fake"},
+			Spec: PipelineRunSpec{
+				PipelineRef: &corev1.ObjectReference{Name: "fake"},
+				Parameters:  []Parameter{{Name: "a", Value: "a"}, {Name: "a", Value: "b"}},
+			},
+		},
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.pipelineRun.ValidateCreate()
+			if tt.wantErr {
+				assert.True(t, apierrors.IsInvalid(err), "unexpected error: %v", err)
+			} else {
+				assert.Nil(t, err)
+			}
+		})
+	}
+}
+
+func TestPipelineRun_ValidateUpdate(t *testing.T) {
+	newPipelineRun := func(started bool) *PipelineRun {
+		pr := &PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{Name: "fake", Annotations: map[string]string{}},
+			Spec: PipelineRunSpec{
+				PipelineRef: &corev1.ObjectReference{Name: "fake"},
+				Parameters:  []Parameter{{Name: "a", Value: "a"}},
+			},
+		}
+		if started {
+			pr.Annotations[JenkinsPipelineRunIDAnnoKey] = "1"
+		}
+		return pr
+	}
+	stop := Stop
+
+	tests := []struct {
+		name    string
+		old     *PipelineRun
+		mutate  func(pr *PipelineRun)
+		wantErr bool
+	}{{
+		name:   "change the parameters before starting",
+		old:    newPipelineRun(false),
+		mutate: func(pr *PipelineRun) { pr.Spec.Parameters[0].Value = "b" },
+	}, {
+		name:   "change the action after starting",
+		old:    newPipelineRun(true),
+		mutate: func(pr *PipelineRun) { pr.Spec.Action = &stop },
+	}, {
+		name:    "change the PipelineRef",
+		old:     newPipelineRun(false),
+		mutate:  func(pr *PipelineRun) { pr.Spec.PipelineRef.Name = "another" },
+		wantErr: true,
+	}, {
+		name:    "change the parameters after starting",
+		old:     newPipelineRun(true),
+		mutate:  func(pr *PipelineRun) { pr.Spec.Parameters[0].Value = "b" },
+		wantErr: true,
+	}, {
+		name:    "change the SCM after starting",
+		old:     newPipelineRun(true),
+		mutate:  func(pr *PipelineRun) { pr.Spec.SCM = &SCM{RefName: "main"} },
+		wantErr: true,
+	}, {
+		name: "forge the trigger identity before starting",
+		old:  newPipelineRun(false),
+		mutate: func(pr *PipelineRun) {
+			pr.SetTriggerIdentity(&TriggerIdentity{Username: "admin", Source: TriggerIdentityToken})
+		},
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := tt.old.DeepCopy()
+			tt.mutate(pr)
+			err := pr.ValidateUpdate(tt.old)
+			if tt.wantErr {
+				assert.True(t, apierrors.IsInvalid(err), "unexpected error: %v", err)
+			} else {
+				assert.Nil(t, err)
+			}
+		})
+	}
+}
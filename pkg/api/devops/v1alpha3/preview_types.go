@@ -0,0 +1,180 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"kubesphere.io/devops/pkg/api/devops"
+)
+
+const (
+	// PreviewEnvironmentLabelKey is label key of the PreviewEnvironment which the PipelineRun or the namespace belongs to.
+	PreviewEnvironmentLabelKey = devops.GroupName + "/preview-environment"
+	// PreviewFinalizerName is the finalizer which tears down the namespace of a PreviewEnvironment
+	PreviewFinalizerName = "finalizer.preview.devops.kubesphere.io"
+	// DefaultPreviewTTL is the time to live of the PreviewEnvironments without a TTL
+	DefaultPreviewTTL = 72 * time.Hour
+)
+
+// PreviewPhase is the phase of a PreviewEnvironment
+type PreviewPhase string
+
+const (
+	// PreviewDeploying indicates that the pull request is being deployed to the PreviewEnvironment.
+	PreviewDeploying PreviewPhase = "Deploying"
+	// PreviewReady indicates that the head revision of the pull request has been deployed.
+	PreviewReady PreviewPhase = "Ready"
+	// PreviewFailed indicates that the deployment failed, see the message for the details.
+	PreviewFailed PreviewPhase = "Failed"
+)
+
+// PreviewPolicy declares how to deploy the pull requests of a GitRepository to PreviewEnvironments.
+// The values of the parameters and the URL could refer to $(preview.namespace), $(preview.number)
+// and $(preview.revision).
+type PreviewPolicy struct {
+	// PipelineRef is the name of the Pipeline which deploys a pull request to the namespace of its PreviewEnvironment.
+	PipelineRef string `json:"pipelineRef"`
+
+	// Parameters are passed to the PipelineRuns besides PREVIEW_NAMESPACE, PULL_REQUEST and REVISION.
+	// +optional
+	Parameters []Parameter `json:"parameters,omitempty"`
+
+	// URL is where the PreviewEnvironment could be visited, it's posted back to the pull request.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// TTL is how long a PreviewEnvironment lives after the latest push to the pull request, 72h by default.
+	// +optional
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:shortName="preview",categories="devops"
+//+kubebuilder:printcolumn:name="Pull Request",type=integer,JSONPath=`.spec.pullRequest`
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+//+kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.status.url`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// PreviewEnvironment is an ephemeral namespace which a pull request is deployed to. It's created when the pull request
+// is opened, redeployed when it's pushed, and torn down when it's closed or its TTL expires.
+type PreviewEnvironment struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PreviewEnvironmentSpec   `json:"spec,omitempty"`
+	Status PreviewEnvironmentStatus `json:"status,omitempty"`
+}
+
+// PreviewEnvironmentSpec declares which pull request is previewed
+type PreviewEnvironmentSpec struct {
+	// GitRepository is the name of the GitRepository in the same namespace.
+	GitRepository string `json:"gitRepository"`
+
+	// PullRequest is the number of the pull request.
+	PullRequest int `json:"pullRequest"`
+
+	// Branch is the source branch of the pull request.
+	// +optional
+	Branch string `json:"branch,omitempty"`
+
+	// Revision is the head commit of the pull request to deploy.
+	Revision string `json:"revision"`
+
+	// PreviewPolicy is copied from the GitRepository when the PreviewEnvironment was created.
+	PreviewPolicy `json:",inline"`
+}
+
+// PreviewEnvironmentStatus records the deployments of the PreviewEnvironment
+type PreviewEnvironmentStatus struct {
+	// Phase is the phase of the latest deployment.
+	// +optional
+	Phase PreviewPhase `json:"phase,omitempty"`
+
+	// Namespace is the namespace which the pull request is deployed to.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// URL is where the PreviewEnvironment could be visited.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// PipelineRun is the name of the latest PipelineRun which deploys the pull request.
+	// +optional
+	PipelineRun string `json:"pipelineRun,omitempty"`
+
+	// DeployedRevision is the revision of the latest successful deployment.
+	// +optional
+	DeployedRevision string `json:"deployedRevision,omitempty"`
+
+	// CommentedRevision is the revision whose deployment result was posted back to the pull request.
+	// +optional
+	CommentedRevision string `json:"commentedRevision,omitempty"`
+
+	// ExpireTime is when the PreviewEnvironment will be torn down.
+	// +optional
+	ExpireTime *metav1.Time `json:"expireTime,omitempty"`
+
+	// Message describes why the deployment failed.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// PreviewEnvironmentList contains a list of PreviewEnvironment
+type PreviewEnvironmentList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PreviewEnvironment `json:"items"`
+}
+
+// GetTTL returns the time to live of the PreviewEnvironments
+func (p *PreviewPolicy) GetTTL() time.Duration {
+	if p == nil || p.TTL == nil || p.TTL.Duration <= 0 {
+		return DefaultPreviewTTL
+	}
+	return p.TTL.Duration
+}
+
+// GetPreviewEnvironmentName returns the name of the PreviewEnvironment of a pull request
+func GetPreviewEnvironmentName(gitRepository string, pullRequest int) string {
+	return fmt.Sprintf("%s-pr-%d", gitRepository, pullRequest)
+}
+
+// GetPreviewNamespace returns the namespace which the pull request is deployed to
+func (p *PreviewEnvironment) GetPreviewNamespace() string {
+	return fmt.Sprintf("%s-%s", p.Namespace, p.Name)
+}
+
+// Render replaces the references to the PreviewEnvironment in the value
+func (p *PreviewEnvironment) Render(value string) string {
+	return strings.NewReplacer(
+		"$(preview.namespace)", p.GetPreviewNamespace(),
+		"$(preview.number)", strconv.Itoa(p.Spec.PullRequest),
+		"$(preview.revision)", p.Spec.Revision,
+	).Replace(value)
+}
+
+func init() {
+	SchemeBuilder.Register(&PreviewEnvironment{}, &PreviewEnvironmentList{})
+}
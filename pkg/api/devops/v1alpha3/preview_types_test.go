@@ -0,0 +1,43 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPreviewPolicy_GetTTL(t *testing.T) {
+	var policy *PreviewPolicy
+	assert.Equal(t, DefaultPreviewTTL, policy.GetTTL())
+	assert.Equal(t, DefaultPreviewTTL, (&PreviewPolicy{TTL: &metav1.Duration{}}).GetTTL())
+	assert.Equal(t, time.Hour, (&PreviewPolicy{TTL: &metav1.Duration{Duration: time.Hour}}).GetTTL())
+}
+
+func TestPreviewEnvironment_Render(t *testing.T) {
+	preview := &PreviewEnvironment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: GetPreviewEnvironmentName("app", 12)},
+		Spec:       PreviewEnvironmentSpec{PullRequest: 12, Revision: "sha-1"},
+	}
+	assert.Equal(t, "ns-app-pr-12", preview.GetPreviewNamespace())
+	assert.Equal(t, "https://ns-app-pr-12.example.com/?pr=12&sha=sha-1",
+		preview.Render("https://$(preview.namespace).example.com/?pr=$(preview.number)&sha=$(preview.revision)"))
+	assert.Equal(t, "$(params.name)", preview.Render("$(params.name)"))
+}
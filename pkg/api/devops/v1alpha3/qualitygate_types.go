@@ -0,0 +1,132 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The parameters which are passed to the PipelineRuns whose Pipelines have a quality gate,
+// the SonarQube scanner should take them, such as:
+//
+//	sonar-scanner -Dsonar.host.url=$SONAR_HOST_URL -Dsonar.projectKey=$SONAR_PROJECT_KEY $SONAR_ANALYSIS_PARAMS
+const (
+	SonarHostURLParameter        = "SONAR_HOST_URL"
+	SonarProjectKeyParameter     = "SONAR_PROJECT_KEY"
+	SonarAnalysisParamsParameter = "SONAR_ANALYSIS_PARAMS"
+)
+
+// SonarPipelineRunProperty is the analysis property which identifies the PipelineRun by "namespace/name".
+// SonarQube sends the properties prefixed with "sonar.analysis." back in the payloads of its webhooks.
+const SonarPipelineRunProperty = "sonar.analysis.pipelineRun"
+
+// DefaultQualityGateTimeout is the default duration to wait for the quality gate after the PipelineRun completed
+const DefaultQualityGateTimeout = 10 * time.Minute
+
+// QualityGatePolicy checks the SonarQube quality gate of the analysis which is done by the PipelineRuns
+type QualityGatePolicy struct {
+	// ProjectKey is the key of the SonarQube project
+	ProjectKey string `json:"projectKey"`
+	// FailOnError marks the PipelineRun as failed if the quality gate is ERROR
+	// +optional
+	FailOnError bool `json:"failOnError,omitempty"`
+	// Timeout is the duration to wait for the quality gate after the PipelineRun completed,
+	// defaults to DefaultQualityGateTimeout
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// WebhookSecretRef refers to the secret of the SonarQube webhook, the payloads are verified by it if it's set.
+	// The unsigned payloads are not able to fail a PipelineRun, or to overwrite a recorded quality gate
+	// +optional
+	WebhookSecretRef *v1.SecretKeySelector `json:"webhookSecretRef,omitempty"`
+}
+
+// GetTimeout returns the duration to wait for the quality gate
+func (p *QualityGatePolicy) GetTimeout() time.Duration {
+	if p == nil || p.Timeout == nil || p.Timeout.Duration <= 0 {
+		return DefaultQualityGateTimeout
+	}
+	return p.Timeout.Duration
+}
+
+// QualityGateState is the status of a quality gate
+type QualityGateState string
+
+// The status of the quality gates, they are the same as SonarQube
+const (
+	QualityGateOK    QualityGateState = "OK"
+	QualityGateWarn  QualityGateState = "WARN"
+	QualityGateError QualityGateState = "ERROR"
+	// QualityGateNone indicates there is no quality gate associated with the analysis, or the analysis
+	// was not found before the timeout
+	QualityGateNone QualityGateState = "NONE"
+)
+
+// QualityGateStatus is the quality gate of the analysis which was done by a PipelineRun
+type QualityGateStatus struct {
+	// ProjectKey is the key of the SonarQube project
+	ProjectKey string `json:"projectKey"`
+	// TaskID is the ID of the SonarQube background task which processed the analysis
+	// +optional
+	TaskID string `json:"taskID,omitempty"`
+	// AnalysisID is the ID of the analysis
+	// +optional
+	AnalysisID string `json:"analysisID,omitempty"`
+	// Status is the status of the quality gate
+	Status QualityGateState `json:"status"`
+	// Conditions are the conditions of the quality gate
+	// +optional
+	Conditions []QualityGateCondition `json:"conditions,omitempty"`
+	// Metrics are the measures of the project, such as coverage and bugs
+	// +optional
+	Metrics map[string]string `json:"metrics,omitempty"`
+	// URL is the link of the project on SonarQube
+	// +optional
+	URL string `json:"url,omitempty"`
+	// UpdateTime is the time when the quality gate was received
+	// +optional
+	UpdateTime *metav1.Time `json:"updateTime,omitempty"`
+}
+
+// QualityGateCondition is a condition of a quality gate
+type QualityGateCondition struct {
+	// Metric is the key of the metric, such as new_coverage
+	Metric string `json:"metric"`
+	// Comparator is the operator of the condition, such as LT or GT
+	// +optional
+	Comparator string `json:"comparator,omitempty"`
+	// ErrorThreshold is the threshold of the condition
+	// +optional
+	ErrorThreshold string `json:"errorThreshold,omitempty"`
+	// ActualValue is the value of the metric
+	// +optional
+	ActualValue string `json:"actualValue,omitempty"`
+	// Status is the status of the condition
+	Status QualityGateState `json:"status"`
+}
+
+// IsFailed returns true if the quality gate did not pass
+func (s *QualityGateStatus) IsFailed() bool {
+	return s != nil && s.Status == QualityGateError
+}
+
+// GetSonarAnalysisParams returns the analysis parameters which identify the PipelineRun
+func GetSonarAnalysisParams(pipelineRun *PipelineRun) string {
+	return "-D" + SonarPipelineRunProperty + "=" + pipelineRun.Namespace + "/" + pipelineRun.Name
+}
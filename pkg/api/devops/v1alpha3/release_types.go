@@ -0,0 +1,160 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ReleasePhase is the phase of a Release
+type ReleasePhase string
+
+const (
+	// ReleasePending indicates that the PipelineRuns of the Release are not completed yet.
+	ReleasePending ReleasePhase = "Pending"
+	// ReleaseReady indicates that the images and the changelog of the Release are collected.
+	ReleaseReady ReleasePhase = "Ready"
+	// ReleasePublished indicates that the release notes have been published to the SCM provider.
+	ReleasePublished ReleasePhase = "Published"
+	// ReleaseFailed indicates that the Release could not be made, see the message for the details.
+	ReleaseFailed ReleasePhase = "Failed"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:categories="devops"
+//+kubebuilder:printcolumn:name="Tag",type=string,JSONPath=`.spec.tag`
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// Release ties a git tag, the PipelineRuns which built it, the image digests and the changelog together.
+// The changelog is generated from the commits between the previous tag and the tag.
+type Release struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ReleaseSpec   `json:"spec,omitempty"`
+	Status ReleaseStatus `json:"status,omitempty"`
+}
+
+// ReleaseSpec declares what a Release consists of
+type ReleaseSpec struct {
+	// GitRepository is the name of the GitRepository in the same namespace.
+	GitRepository string `json:"gitRepository"`
+
+	// Tag is the git tag of the Release.
+	Tag string `json:"tag"`
+
+	// Commitish is the branch or commit which the tag is created from if the tag does not exist.
+	// +optional
+	Commitish string `json:"commitish,omitempty"`
+
+	// PreviousTag is the tag of the previous Release, the changelog contains the commits after it.
+	// +optional
+	PreviousTag string `json:"previousTag,omitempty"`
+
+	// PipelineRuns are the names of the PipelineRuns which built the Release, they must succeed.
+	// The image digests are collected from their results.
+	// +optional
+	PipelineRuns []string `json:"pipelineRuns,omitempty"`
+
+	// Publish publishes the release notes to the SCM provider, such as a GitHub release.
+	// +optional
+	Publish bool `json:"publish,omitempty"`
+
+	// Prerelease marks the published release as a pre-release.
+	// +optional
+	Prerelease bool `json:"prerelease,omitempty"`
+}
+
+// ReleaseStatus is the collected content of a Release
+type ReleaseStatus struct {
+	// +optional
+	Phase ReleasePhase `json:"phase,omitempty"`
+
+	// Message tells why the Release failed.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Images are the images built by the PipelineRuns.
+	// +optional
+	Images []ReleaseImage `json:"images,omitempty"`
+
+	// Changelog are the changes since the previous tag, the latest one comes first.
+	// +optional
+	Changelog []ChangelogEntry `json:"changelog,omitempty"`
+
+	// Notes is the release notes in Markdown.
+	// +optional
+	Notes string `json:"notes,omitempty"`
+
+	// URL is the link of the release published to the SCM provider.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// PublishTime is the time when the release was published.
+	// +optional
+	PublishTime *metav1.Time `json:"publishTime,omitempty"`
+}
+
+// ReleaseImage is an image built by a PipelineRun of the Release
+type ReleaseImage struct {
+	// Name is the image without the tag or the digest, such as docker.io/kubesphere/devops.
+	Name string `json:"name"`
+	// Digest is the digest of the image, such as sha256:abc.
+	Digest string `json:"digest"`
+	// PipelineRun is the name of the PipelineRun which built the image.
+	PipelineRun string `json:"pipelineRun"`
+}
+
+// ChangelogEntry is a change of the Release
+type ChangelogEntry struct {
+	// Sha is the commit of the change.
+	Sha string `json:"sha"`
+	// Title is the title of the pull request, or the first line of the commit message.
+	Title string `json:"title"`
+	// Author is the author of the commit.
+	// +optional
+	Author string `json:"author,omitempty"`
+	// PullRequest is the number of the merged pull request.
+	// +optional
+	PullRequest int `json:"pullRequest,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ReleaseList contains a list of Release
+type ReleaseList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Release `json:"items"`
+}
+
+// HasCompleted indicates if the Release has been made or failed.
+func (r *Release) HasCompleted() bool {
+	switch r.Status.Phase {
+	case ReleaseFailed, ReleasePublished:
+		return true
+	case ReleaseReady:
+		return !r.Spec.Publish
+	}
+	return false
+}
+
+func init() {
+	SchemeBuilder.Register(&Release{}, &ReleaseList{})
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultStuckPendingTimeout is the duration which an agent pod is allowed to be pending for
+const DefaultStuckPendingTimeout = 30 * time.Minute
+
+// StuckRunAction is what to do with a PipelineRun whose agent pods are stuck
+type StuckRunAction string
+
+const (
+	// StuckRunFail marks the PipelineRun as failed and stops its build
+	StuckRunFail StuckRunAction = "Fail"
+	// StuckRunRetry deletes the stuck pod so that the backend provisions another one, the PipelineRun
+	// fails once it has been retried for MaxRetries times
+	StuckRunRetry StuckRunAction = "Retry"
+	// StuckRunNotify leaves the PipelineRun as it is, and escalates it through the Notifications
+	StuckRunNotify StuckRunAction = "Notify"
+)
+
+// StuckRunPolicy decides what to do with the PipelineRuns whose agent pods are stuck, such as pending for
+// too long, pulling images in a loop or evicted. The stuck PipelineRuns are failed by default.
+type StuckRunPolicy struct {
+	// PendingTimeout is the duration which an agent pod is allowed to be pending for,
+	// defaults to DefaultStuckPendingTimeout
+	// +optional
+	PendingTimeout *metav1.Duration `json:"pendingTimeout,omitempty"`
+	// Action is one of Fail, Retry and Notify, defaults to Fail
+	// +optional
+	Action StuckRunAction `json:"action,omitempty"`
+	// MaxRetries is the number of the retries before failing the PipelineRun, defaults to 1.
+	// It takes effect only if the action is Retry
+	// +optional
+	MaxRetries int32 `json:"maxRetries,omitempty"`
+}
+
+// GetPendingTimeout returns the duration which an agent pod is allowed to be pending for
+func (p *StuckRunPolicy) GetPendingTimeout() time.Duration {
+	if p == nil || p.PendingTimeout == nil || p.PendingTimeout.Duration <= 0 {
+		return DefaultStuckPendingTimeout
+	}
+	return p.PendingTimeout.Duration
+}
+
+// GetAction returns what to do with the stuck PipelineRuns
+func (p *StuckRunPolicy) GetAction() StuckRunAction {
+	if p == nil || p.Action == "" {
+		return StuckRunFail
+	}
+	return p.Action
+}
+
+// GetMaxRetries returns the number of the retries before failing the PipelineRun
+func (p *StuckRunPolicy) GetMaxRetries() int32 {
+	if p == nil || p.MaxRetries <= 0 {
+		return 1
+	}
+	return p.MaxRetries
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"text/template"
+)
+
+const templateParametersKey = "params"
+
+// Render renders the template with the given parameters. The default values are taken for the absent
+// parameters, and an error is returned if any required parameter is missing.
+func (t *TemplateSpec) Render(name string, parameters []Parameter) (output string, err error) {
+	values := map[string]string{}
+	for _, param := range parameters {
+		values[param.Name] = param.Value
+	}
+	for _, item := range t.Parameters {
+		if _, ok := values[item.Name]; ok {
+			continue
+		}
+		if len(item.Default.Raw) > 0 {
+			values[item.Name] = defaultValueToString(item.Default.Raw)
+		} else if item.Required {
+			err = fmt.Errorf("the required parameter '%s' of template '%s' is missing", item.Name, name)
+			return
+		}
+	}
+
+	tpl := template.New(name).Delims("$(", ")")
+	if _, err = tpl.Parse(t.Template); err != nil {
+		err = fmt.Errorf("failed to parse template '%s': %v", name, err)
+		return
+	}
+
+	buffer := &bytes.Buffer{}
+	if err = tpl.Execute(buffer, map[string]interface{}{templateParametersKey: values}); err != nil {
+		err = fmt.Errorf("failed to render template '%s': %v", name, err)
+		return
+	}
+	output = buffer.String()
+	return
+}
+
+// defaultValueToString returns the string form of a JSON value, strings are unquoted
+func defaultValueToString(raw []byte) string {
+	var str string
+	if err := json.Unmarshal(raw, &str); err == nil {
+		return str
+	}
+	return string(raw)
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiextensionv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func TestTemplateSpec_Render(t *testing.T) {
+	spec := &TemplateSpec{
+		Parameters: []TemplateParameter{{
+			Name:     "url",
+			Required: true,
+		}, {
+			Name:    "revision",
+			Default: apiextensionv1.JSON{Raw: []byte(`"main"`)},
+		}, {
+			Name:    "buildOnly",
+			Type:    "bool",
+			Default: apiextensionv1.JSON{Raw: []byte(`false`)},
+		}},
+		Template: `git branch: '$(.params.revision)', url: '$(.params.url)'$(if ne .params.buildOnly "true") archive$(end)`,
+	}
+
+	tests := []struct {
+		name       string
+		spec       *TemplateSpec
+		parameters []Parameter
+		wantOutput string
+		wantErr    bool
+	}{{
+		name:       "take the default values",
+		spec:       spec,
+		parameters: []Parameter{{Name: "url", Value: "https://a.com/b.git"}},
+		wantOutput: "git branch: 'main', url: 'https://a.com/b.git' archive",
+	}, {
+		name: "override the default values",
+		spec: spec,
+		parameters: []Parameter{{Name: "url", Value: "https://a.com/b.git"},
+			{Name: "revision", Value: "dev"}, {Name: "buildOnly", Value: "true"}},
+		wantOutput: "git branch: 'dev', url: 'https://a.com/b.git'",
+	}, {
+		name:    "missing required parameter",
+		spec:    spec,
+		wantErr: true,
+	}, {
+		name:    "invalid template",
+		spec:    &TemplateSpec{Template: "$(if .params.a)"},
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := tt.spec.Render("fake", tt.parameters)
+			assert.Equal(t, tt.wantErr, err != nil, err)
+			assert.Equal(t, tt.wantOutput, output)
+		})
+	}
+}
@@ -23,6 +23,13 @@ import (
 
 var _ TemplateObject = &Template{}
 
+const (
+	// ResourceKindTemplate is the kind of Template
+	ResourceKindTemplate = "Template"
+	// ResourceKindClusterTemplate is the kind of ClusterTemplate
+	ResourceKindClusterTemplate = "ClusterTemplate"
+)
+
 // TemplateSpec defines the desired state of Template
 type TemplateSpec struct {
 	// Parameters are used to configure template.
@@ -31,6 +38,18 @@ type TemplateSpec struct {
 
 	// Template is a string with go-template style.
 	Template string `json:"template,omitempty"`
+
+	// Version is the version of the template, Pipelines can pin the version they are going to use.
+	//+optional
+	Version string `json:"version,omitempty"`
+
+	// Deprecated indicates that the template is no longer recommended for the new Pipelines.
+	//+optional
+	Deprecated bool `json:"deprecated,omitempty"`
+
+	// DeprecationMessage tells users why the template is deprecated and what to use instead.
+	//+optional
+	DeprecationMessage string `json:"deprecationMessage,omitempty"`
 }
 
 // TemplateStatus defines the observed state of Template
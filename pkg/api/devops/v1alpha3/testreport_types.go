@@ -0,0 +1,209 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// The formats of the test reports which could be published by the PipelineRuns
+const (
+	// TestReportFormatJUnit is the JUnit XML format, most of the test frameworks could produce it.
+	TestReportFormatJUnit = "junit"
+	// TestReportFormatCobertura is the Cobertura XML coverage format, it's produced by coverage.py, gocover-cobertura, etc.
+	TestReportFormatCobertura = "cobertura"
+	// TestReportFormatJaCoCo is the JaCoCo XML coverage format.
+	TestReportFormatJaCoCo = "jacoco"
+)
+
+// The status of the test cases
+const (
+	TestCasePassed  = "passed"
+	TestCaseFailed  = "failed"
+	TestCaseSkipped = "skipped"
+)
+
+// MaxFailedTestCases is the maximum number of the failed test cases recorded for a test report,
+// the full report could be found from the object storage.
+const MaxFailedTestCases = 50
+
+// TestSummary sums up the test reports published by a PipelineRun.
+type TestSummary struct {
+	TestCounts `json:",inline"`
+
+	// Duration is the total duration of the test cases.
+	// +optional
+	Duration metav1.Duration `json:"duration,omitempty"`
+
+	// Coverage is the sum of the coverage reports.
+	// +optional
+	Coverage *CoverageSummary `json:"coverage,omitempty"`
+
+	// Reports are the published test reports.
+	// +optional
+	Reports []TestReport `json:"reports,omitempty"`
+}
+
+// TestCounts counts the test cases by status.
+type TestCounts struct {
+	// +optional
+	Total int `json:"total,omitempty"`
+	// +optional
+	Passed int `json:"passed,omitempty"`
+	// +optional
+	Failed int `json:"failed,omitempty"`
+	// +optional
+	Skipped int `json:"skipped,omitempty"`
+}
+
+// CoverageSummary counts the covered lines and branches.
+type CoverageSummary struct {
+	// +optional
+	LinesCovered int64 `json:"linesCovered,omitempty"`
+	// +optional
+	LinesValid int64 `json:"linesValid,omitempty"`
+	// +optional
+	BranchesCovered int64 `json:"branchesCovered,omitempty"`
+	// +optional
+	BranchesValid int64 `json:"branchesValid,omitempty"`
+}
+
+// TestReport is a test report published by a PipelineRun, the raw report is kept in the object storage.
+type TestReport struct {
+	// Name is the unique name of the report in a PipelineRun, such as unit-tests.
+	Name string `json:"name"`
+	// Format is the format of the report, one of junit, cobertura and jacoco.
+	Format string `json:"format"`
+	// Key is the object key of the raw report in the object storage.
+	// +optional
+	Key string `json:"key,omitempty"`
+	// Tests counts the test cases of a JUnit report.
+	// +optional
+	Tests *TestCounts `json:"tests,omitempty"`
+	// Duration is the total duration of the test cases of a JUnit report.
+	// +optional
+	Duration metav1.Duration `json:"duration,omitempty"`
+	// FailedCases are the failed test cases of a JUnit report, at most MaxFailedTestCases cases are recorded.
+	// +optional
+	FailedCases []TestCaseResult `json:"failedCases,omitempty"`
+	// Coverage is the coverage of a coverage report.
+	// +optional
+	Coverage *CoverageSummary `json:"coverage,omitempty"`
+	// PublishTime is the time when the report was published.
+	// +optional
+	PublishTime *metav1.Time `json:"publishTime,omitempty"`
+}
+
+// TestCaseResult is the result of a test case.
+type TestCaseResult struct {
+	// Suite is the name of the test suite.
+	// +optional
+	Suite string `json:"suite,omitempty"`
+	// ClassName is the class name of the test case.
+	// +optional
+	ClassName string `json:"className,omitempty"`
+	// Name is the name of the test case.
+	Name string `json:"name"`
+	// Status is one of passed, failed and skipped.
+	Status string `json:"status"`
+	// Duration is the duration of the test case.
+	// +optional
+	Duration metav1.Duration `json:"duration,omitempty"`
+	// Message is the message of the failure.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// GetID returns the identity of the test case across PipelineRuns, such as com.example.AppTest.testAdd
+func (c *TestCaseResult) GetID() string {
+	if c.ClassName != "" {
+		return c.ClassName + "." + c.Name
+	}
+	if c.Suite != "" {
+		return c.Suite + "." + c.Name
+	}
+	return c.Name
+}
+
+// Add sums up the counts
+func (c *TestCounts) Add(counts TestCounts) {
+	c.Total += counts.Total
+	c.Passed += counts.Passed
+	c.Failed += counts.Failed
+	c.Skipped += counts.Skipped
+}
+
+// Add sums up the coverage
+func (c *CoverageSummary) Add(coverage CoverageSummary) {
+	c.LinesCovered += coverage.LinesCovered
+	c.LinesValid += coverage.LinesValid
+	c.BranchesCovered += coverage.BranchesCovered
+	c.BranchesValid += coverage.BranchesValid
+}
+
+// GetLineRate returns the percentage of the covered lines, it's zero if there are no lines.
+func (c *CoverageSummary) GetLineRate() float64 {
+	if c == nil || c.LinesValid == 0 {
+		return 0
+	}
+	return float64(c.LinesCovered) * 100 / float64(c.LinesValid)
+}
+
+// GetBranchRate returns the percentage of the covered branches, it's zero if there are no branches.
+func (c *CoverageSummary) GetBranchRate() float64 {
+	if c == nil || c.BranchesValid == 0 {
+		return 0
+	}
+	return float64(c.BranchesCovered) * 100 / float64(c.BranchesValid)
+}
+
+// GetReport returns the published test report by name.
+func (s *TestSummary) GetReport(name string) *TestReport {
+	if s == nil {
+		return nil
+	}
+	for i := range s.Reports {
+		if s.Reports[i].Name == name {
+			return &s.Reports[i]
+		}
+	}
+	return nil
+}
+
+// SetReport adds the report or replaces the one with the same name, then sums up the reports again.
+func (s *TestSummary) SetReport(report TestReport) {
+	if existing := s.GetReport(report.Name); existing != nil {
+		*existing = report
+	} else {
+		s.Reports = append(s.Reports, report)
+	}
+
+	s.TestCounts = TestCounts{}
+	s.Duration = metav1.Duration{}
+	s.Coverage = nil
+	for i := range s.Reports {
+		item := &s.Reports[i]
+		if item.Tests != nil {
+			s.TestCounts.Add(*item.Tests)
+			s.Duration.Duration += item.Duration.Duration
+		}
+		if item.Coverage != nil {
+			if s.Coverage == nil {
+				s.Coverage = &CoverageSummary{}
+			}
+			s.Coverage.Add(*item.Coverage)
+		}
+	}
+}
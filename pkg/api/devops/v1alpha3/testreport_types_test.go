@@ -0,0 +1,56 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTestSummary_SetReport(t *testing.T) {
+	summary := &TestSummary{}
+	summary.SetReport(TestReport{Name: "unit", Format: TestReportFormatJUnit,
+		Tests: &TestCounts{Total: 3, Passed: 2, Failed: 1}, Duration: metav1.Duration{Duration: time.Second}})
+	summary.SetReport(TestReport{Name: "e2e", Format: TestReportFormatJUnit,
+		Tests: &TestCounts{Total: 2, Skipped: 2}, Duration: metav1.Duration{Duration: time.Minute}})
+	summary.SetReport(TestReport{Name: "coverage", Format: TestReportFormatJaCoCo,
+		Coverage: &CoverageSummary{LinesCovered: 1, LinesValid: 2}})
+	assert.Equal(t, TestCounts{Total: 5, Passed: 2, Failed: 1, Skipped: 2}, summary.TestCounts)
+	assert.Equal(t, time.Minute+time.Second, summary.Duration.Duration)
+	assert.Equal(t, float64(50), summary.Coverage.GetLineRate())
+	assert.Equal(t, float64(0), summary.Coverage.GetBranchRate())
+
+	// republished
+	summary.SetReport(TestReport{Name: "unit", Format: TestReportFormatJUnit, Tests: &TestCounts{Total: 3, Passed: 3}})
+	assert.Len(t, summary.Reports, 3)
+	assert.Equal(t, TestCounts{Total: 5, Passed: 3, Skipped: 2}, summary.TestCounts)
+	assert.Equal(t, time.Minute, summary.Duration.Duration)
+
+	var empty *TestSummary
+	assert.Nil(t, empty.GetReport("unit"))
+	var coverage *CoverageSummary
+	assert.Equal(t, float64(0), coverage.GetLineRate())
+}
+
+func TestTestCaseResult_GetID(t *testing.T) {
+	assert.Equal(t, "pkg.Class.test", (&TestCaseResult{Suite: "suite", ClassName: "pkg.Class", Name: "test"}).GetID())
+	assert.Equal(t, "suite.test", (&TestCaseResult{Suite: "suite", Name: "test"}).GetID())
+	assert.Equal(t, "test", (&TestCaseResult{Name: "test"}).GetID())
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"kubesphere.io/devops/pkg/api/devops"
+)
+
+// TriggerLabelKey is the label key of the Trigger which created the PipelineRun
+const TriggerLabelKey = devops.GroupName + "/trigger"
+
+// TriggerSourceType is where the events of a Trigger come from
+type TriggerSourceType string
+
+// The supported sources of the Triggers
+const (
+	// TriggerSourceWebhook is the webhooks of the SCM providers, such as GitHub, GitLab and Bitbucket
+	TriggerSourceWebhook TriggerSourceType = "webhook"
+	// TriggerSourceCron fires the Trigger periodically by the schedule
+	TriggerSourceCron TriggerSourceType = "cron"
+	// TriggerSourceImagePush is the push notifications of the image registries, such as Harbor and Docker Hub
+	TriggerSourceImagePush TriggerSourceType = "image-push"
+	// TriggerSourceCloudEvent is the CloudEvents which are sent to the DevOps apiserver
+	TriggerSourceCloudEvent TriggerSourceType = "cloudevent"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:shortName="trg",categories="devops"
+//+kubebuilder:printcolumn:name="Pipeline",type=string,JSONPath=`.spec.pipeline`
+//+kubebuilder:printcolumn:name="Source",type=string,JSONPath=`.spec.source.type`
+//+kubebuilder:printcolumn:name="Last PipelineRun",type=string,JSONPath=`.status.lastPipelineRun`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// Trigger binds the events from a source to the PipelineRuns of a Pipeline. The events are filtered by a CEL
+// expression, and the parameters of the PipelineRuns are mapped from the events by CEL expressions as well.
+type Trigger struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   TriggerSpec   `json:"spec,omitempty"`
+	Status TriggerStatus `json:"status,omitempty"`
+}
+
+// TriggerSpec declares the source, the filter and the parameter mapping of a Trigger.
+// The CEL expressions are evaluated against the variable event, which has the following fields:
+// type, source, repository, url, ref, branch, tag, revision, baseRevision, changes, image, digest, time, headers and body.
+type TriggerSpec struct {
+	// Pipeline is the name of the Pipeline in the same namespace which is run by the events
+	Pipeline string `json:"pipeline"`
+
+	// Source is where the events come from
+	Source TriggerSource `json:"source"`
+
+	// Filter is a CEL expression which should be evaluated to a bool, the events which make it false are ignored.
+	// Such as event.tag.startsWith("v") or event.changes.exists(f, f.startsWith("service-a/"))
+	// +optional
+	Filter string `json:"filter,omitempty"`
+
+	// Parameters of the PipelineRuns are mapped from the events
+	// +optional
+	Parameters []TriggerParameter `json:"parameters,omitempty"`
+
+	// Branch is a CEL expression of the branch to run for a multi-branch Pipeline, defaults to event.branch
+	// +optional
+	Branch string `json:"branch,omitempty"`
+
+	// Suspend stops the Trigger from creating PipelineRuns
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// TriggerSource is where the events of a Trigger come from
+type TriggerSource struct {
+	// Type is the kind of the source
+	// +kubebuilder:validation:Enum=webhook;cron;image-push;cloudevent
+	Type TriggerSourceType `json:"type"`
+
+	// Schedule is the cron expression of the cron source, such as "0 2 * * *"
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+
+	// TimeZone is the name of the time zone of the schedule, such as Asia/Shanghai, defaults to UTC
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// SecretRef refers to a key of a secret in the same namespace, it's required by the image-push and cloudevent
+	// sources. The events must be signed with HMAC-SHA256 by it in the header X-DevOps-Signature-256, or carry it
+	// as the query parameter token for the senders which are not able to sign, otherwise they are ignored.
+	// The SCM webhooks must be signed by it in the way of the SCM provider if it's set for the webhook source.
+	// +optional
+	SecretRef *v1.SecretKeySelector `json:"secretRef,omitempty"`
+}
+
+// TriggerParameter maps a field of the events to a parameter of the PipelineRuns
+type TriggerParameter struct {
+	// Name is the name of the parameter
+	Name string `json:"name"`
+
+	// Expression is a CEL expression which is evaluated to the value of the parameter, such as event.revision
+	Expression string `json:"expression"`
+}
+
+// TriggerStatus is the observed state of a Trigger
+type TriggerStatus struct {
+	// LastTriggeredTime is the last time an event created a PipelineRun
+	// +optional
+	LastTriggeredTime *metav1.Time `json:"lastTriggeredTime,omitempty"`
+
+	// LastPipelineRun is the name of the PipelineRun which was created by the last event
+	// +optional
+	LastPipelineRun string `json:"lastPipelineRun,omitempty"`
+
+	// LastScheduleTime is the last time the cron source was scheduled
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	// Message tells why the last event failed to create a PipelineRun, it's empty if it succeeded
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// TriggerList contains a list of Trigger
+type TriggerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Trigger `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Trigger{}, &TriggerList{})
+}
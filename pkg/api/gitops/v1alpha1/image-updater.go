@@ -28,6 +28,7 @@ type ImageUpdaterSpec struct {
 	Kind   string            `json:"kind,omitempty"`
 	Images []string          `json:"images,omitempty"`
 	Argo   *ArgoImageUpdater `json:"argo,omitempty"`
+	Flux   *FluxImageUpdater `json:"flux,omitempty"`
 }
 
 // ArgoImageUpdater is the specification of the Argo image updater
@@ -43,6 +44,28 @@ type ArgoImageUpdater struct {
 	Secrets        map[string]string `json:"secrets,omitempty"`
 }
 
+// FluxImageUpdater is the specification of the FluxCD image update automation.
+// The images are scanned by FluxCD, and the new tags are committed to the manifests in the git repository.
+type FluxImageUpdater struct {
+	// GitRepository holds the manifests with the image policy markers, it must be an artifact repository
+	GitRepository v1.LocalObjectReference `json:"gitRepository"`
+	// Branch is the branch to check out and push the commits to
+	Branch string `json:"branch"`
+	// Path is the directory of the manifests to update, defaults to the root of the repository
+	Path string `json:"path,omitempty"`
+	// Policies are the tag policies keyed by the image alias, in the form of
+	// semver:<range>, alphabetical:<asc|desc> or numerical:<asc|desc>. Defaults to semver:>=0.0.0
+	Policies map[string]string `json:"policies,omitempty"`
+	// AllowTags are the regular expressions which filter the tags, keyed by the image alias
+	AllowTags map[string]string `json:"allowTags,omitempty"`
+	// Secrets are the pull secrets of the image registries, keyed by the image alias
+	Secrets map[string]string `json:"secrets,omitempty"`
+	// +kubebuilder:default:=ks-devops
+	AuthorName string `json:"authorName,omitempty"`
+	// +kubebuilder:default:=ks-devops@kubesphere.io
+	AuthorEmail string `json:"authorEmail,omitempty"`
+}
+
 // WriteMethod is an alias of string that represents the write back method of Argo CD Image updater
 type WriteMethod string
 
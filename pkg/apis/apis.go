@@ -20,6 +20,7 @@ package apis
 import (
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	devopsv1alpha1 "kubesphere.io/devops/pkg/api/devops/v1alpha1"
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
 	"kubesphere.io/devops/pkg/api/gitops/v1alpha1"
 	helmv2 "kubesphere.io/devops/pkg/external/fluxcd/helm/v2beta1"
@@ -39,6 +40,7 @@ func init() {
 	// Register the types with the Scheme so the components can map objects to GroupVersionKinds and back
 	addToSchemes = append(addToSchemes,
 		v1alpha3.SchemeBuilder.AddToScheme,
+		devopsv1alpha1.SchemeBuilder.AddToScheme,
 		v1alpha1.SchemeBuilder.AddToScheme,
 		helmv2.SchemeBuilder.AddToScheme,
 		kusv1.SchemeBuilder.AddToScheme,
@@ -39,9 +39,12 @@ import (
 	"kubesphere.io/devops/pkg/apiserver/authentication/request/anonymous"
 	"kubesphere.io/devops/pkg/apiserver/filters"
 	"kubesphere.io/devops/pkg/apiserver/request"
+	"kubesphere.io/devops/pkg/client/history"
 	"kubesphere.io/devops/pkg/indexers"
 	"kubesphere.io/devops/pkg/kapis/oauth"
+	"kubesphere.io/devops/pkg/models/audit"
 	"kubesphere.io/devops/pkg/models/auth"
+	"kubesphere.io/devops/pkg/models/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/emicklei/go-restful"
@@ -54,6 +57,7 @@ import (
 
 	"kubesphere.io/devops/pkg/client/cache"
 	"kubesphere.io/devops/pkg/client/devops"
+	"kubesphere.io/devops/pkg/client/devops/cached"
 	"kubesphere.io/devops/pkg/client/k8s"
 	"kubesphere.io/devops/pkg/client/s3"
 	"kubesphere.io/devops/pkg/client/sonarqube"
@@ -108,6 +112,15 @@ type APIServer struct {
 	RuntimeCache runtimecache.Cache
 
 	Client client.Client
+
+	// AuditStore keeps the write operations, the audit log is disabled if it's nil
+	AuditStore audit.Interface
+
+	// HistoryStore keeps the summaries of the completed PipelineRuns, the history APIs are disabled if it's nil
+	HistoryStore history.Interface
+
+	// WebhookDeliveryStore keeps the received SCM webhooks, the delivery APIs are disabled if it's nil
+	WebhookDeliveryStore webhook.Interface
 }
 
 func (s *APIServer) PrepareRun(stopCh <-chan struct{}) error {
@@ -158,7 +171,8 @@ func (s *APIServer) installKubeSphereAPIs() {
 		jenkinsCore)
 	utilruntime.Must(err)
 	wss = append(wss, v1alpha2WSS...)
-	wss = append(wss, devopsv1alpha3.AddToContainer(s.container, s.DevopsClient, s.KubernetesClient, s.Client, tokenIssue, jenkinsCore)...)
+	wss = append(wss, devopsv1alpha3.AddToContainer(s.container, s.DevopsClient, s.KubernetesClient, s.S3Client, s.Client, tokenIssue, jenkinsCore, s.AuditStore, s.HistoryStore,
+		s.WebhookDeliveryStore, s.Config.JenkinsOptions)...)
 	wss = append(wss, oauth.AddToContainer(s.container,
 		auth.NewTokenOperator(
 			s.CacheClient,
@@ -182,6 +196,12 @@ func (s *APIServer) Run(stopCh context.Context) (err error) {
 	if err := indexers.CreatePipelineRunIdentityIndexer(s.RuntimeCache); err != nil {
 		return err
 	}
+	// drop the cached Jenkins queries of a pipeline once it or its PipelineRuns change
+	if cachedClient, ok := s.DevopsClient.(*cached.Client); ok {
+		if err := cachedClient.InvalidateOnChange(stopCh, s.RuntimeCache); err != nil {
+			return err
+		}
+	}
 
 	err = s.waitForResourceSync(stopCh)
 	if err != nil {
@@ -215,13 +235,22 @@ func (s *APIServer) buildHandlerChain(stopCh <-chan struct{}) {
 
 	handler := s.Server.Handler
 	handler = filters.WithKubeAPIServer(handler, s.KubernetesClient.Config(), &errorResponder{})
+	// the requests which are proxied to kube-apiserver, like creating PipelineRuns, need to be audited as well
+	handler = filters.WithAudit(handler, s.AuditStore)
+	// the impersonated users are audited and recorded as the trigger of PipelineRuns
+	handler = filters.WithImpersonation(handler, s.KubernetesClient.Kubernetes().AuthorizationV1())
 
 	authenticators := make([]authenticator.Request, 0)
 	authenticators = append(authenticators, anonymous.NewAuthenticator())
 
 	switch s.Config.AuthMode {
 	case apiserverconfig.AuthModeToken:
-		authenticators = append(authenticators, bearertoken.New(devopsbearertoken.New()))
+		tokenAuthenticator := devopsbearertoken.New()
+		if s.Config.AuthenticationOptions != nil && s.Config.AuthenticationOptions.JwtSecret != "" {
+			// the tokens issued by KubeSphere carry the groups mapped from its LDAP or OIDC identity providers
+			tokenAuthenticator = devopsbearertoken.NewWithIssuer(getTokenIssue(s.Config))
+		}
+		authenticators = append(authenticators, bearertoken.New(tokenAuthenticator))
 	default:
 		// TODO error handle
 	}
@@ -23,17 +23,37 @@ import (
 	"k8s.io/apiserver/pkg/authentication/user"
 
 	"k8s.io/apiserver/pkg/authentication/authenticator"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/apiserver/authentication/identity"
 	jwt "kubesphere.io/devops/pkg/jwt/token"
 )
 
-// tokenAuthenticator implements an simple auth which only check the format of target JWT token
-type tokenAuthenticator struct{}
+// tokenAuthenticator implements an simple auth which only check the format of target JWT token.
+// The tokens are verified first if there's an issuer, only the verified users carry their groups.
+type tokenAuthenticator struct {
+	issuer jwt.Issuer
+}
 
 func New() authenticator.Token {
 	return &tokenAuthenticator{}
 }
 
+// NewWithIssuer returns an authenticator which verifies the tokens with the issuer which shares the secret
+// of KubeSphere, the tokens which fail to be verified are accepted as unverified users
+func NewWithIssuer(issuer jwt.Issuer) authenticator.Token {
+	return &tokenAuthenticator{issuer: issuer}
+}
+
 func (a *tokenAuthenticator) AuthenticateToken(ctx context.Context, token string) (response *authenticator.Response, ok bool, err error) {
+	if a.issuer != nil {
+		if verified, _, verifyErr := a.issuer.Verify(token); verifyErr == nil && verified.GetName() != "" {
+			response = &authenticator.Response{User: identity.WithSource(verified, v1alpha3.TriggerIdentityToken)}
+			ok = true
+			return
+		}
+	}
+
 	issuer := jwt.NewTokenIssuer("", time.Second)
 
 	var authenticated user.Info
@@ -0,0 +1,68 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bearertoken
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/apiserver/authentication/identity"
+	jwt "kubesphere.io/devops/pkg/jwt/token"
+)
+
+func TestAuthenticateToken(t *testing.T) {
+	alice := &user.DefaultInfo{Name: "alice", Groups: []string{"dev"}}
+	issue := func(secret string) string {
+		token, err := jwt.NewTokenIssuer(secret, time.Second).IssueTo(alice, jwt.AccessToken, time.Hour)
+		assert.Nil(t, err)
+		return token
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		expect *v1alpha3.TriggerIdentity
+	}{{
+		name:   "verified token",
+		token:  issue("secret"),
+		expect: &v1alpha3.TriggerIdentity{Username: "alice", Groups: []string{"dev"}, Source: v1alpha3.TriggerIdentityToken},
+	}, {
+		name:   "token signed by the others",
+		token:  issue("other"),
+		expect: &v1alpha3.TriggerIdentity{Username: "alice", Source: v1alpha3.TriggerIdentityUnverified},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, ok, err := NewWithIssuer(jwt.NewTokenIssuer("secret", time.Second)).
+				AuthenticateToken(context.TODO(), tt.token)
+			assert.True(t, ok)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expect, identity.FromUser(response.User))
+		})
+	}
+
+	// the tokens are never verified without an issuer
+	response, ok, err := New().AuthenticateToken(context.TODO(), issue("secret"))
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha3.TriggerIdentityUnverified, identity.FromUser(response.User).Source)
+}
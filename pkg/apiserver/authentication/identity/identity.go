@@ -0,0 +1,73 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package identity
+
+import (
+	"context"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/apiserver/request"
+)
+
+const (
+	// SourceExtraKey is the extra key of the authenticated users which records how they were verified,
+	// the value is one of the v1alpha3.TriggerIdentitySource
+	SourceExtraKey = "devops.kubesphere.io/identity-source"
+	// ImpersonatorExtraKey is the extra key of the authenticated users which records who impersonated them
+	ImpersonatorExtraKey = "devops.kubesphere.io/impersonator"
+)
+
+// WithSource returns a copy of the user which carries the source of its identity, the impersonator given by the
+// user is dropped
+func WithSource(u user.Info, source v1alpha3.TriggerIdentitySource) *user.DefaultInfo {
+	extra := map[string][]string{}
+	for key, values := range u.GetExtra() {
+		if key != ImpersonatorExtraKey {
+			extra[key] = values
+		}
+	}
+	extra[SourceExtraKey] = []string{string(source)}
+	return &user.DefaultInfo{Name: u.GetName(), UID: u.GetUID(), Groups: u.GetGroups(), Extra: extra}
+}
+
+// FromUser returns the identity of an authenticated user, the groups are dropped if the user was not verified.
+// It returns nil if there's no user.
+func FromUser(u user.Info) *v1alpha3.TriggerIdentity {
+	if u == nil || u.GetName() == "" {
+		return nil
+	}
+	identity := &v1alpha3.TriggerIdentity{Username: u.GetName(), Source: v1alpha3.TriggerIdentityUnverified}
+	extra := u.GetExtra()
+	if sources := extra[SourceExtraKey]; len(sources) > 0 && sources[0] != "" {
+		identity.Source = v1alpha3.TriggerIdentitySource(sources[0])
+	}
+	if impersonators := extra[ImpersonatorExtraKey]; len(impersonators) > 0 {
+		identity.Impersonator = impersonators[0]
+	}
+	if identity.IsVerified() {
+		identity.Groups = u.GetGroups()
+	}
+	return identity
+}
+
+// FromContext returns the identity of the user of a request, see FromUser
+func FromContext(ctx context.Context) *v1alpha3.TriggerIdentity {
+	u, _ := request.UserFrom(ctx)
+	return FromUser(u)
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package identity
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/apiserver/request"
+)
+
+func TestFromUser(t *testing.T) {
+	tests := []struct {
+		name   string
+		user   user.Info
+		expect *v1alpha3.TriggerIdentity
+	}{{
+		name: "no user",
+	}, {
+		name: "unverified user",
+		user: &user.DefaultInfo{Name: "alice", Groups: []string{"dev"}},
+		expect: &v1alpha3.TriggerIdentity{
+			Username: "alice",
+			Source:   v1alpha3.TriggerIdentityUnverified,
+		},
+	}, {
+		name: "verified user",
+		user: WithSource(&user.DefaultInfo{Name: "alice", Groups: []string{"dev"}}, v1alpha3.TriggerIdentityToken),
+		expect: &v1alpha3.TriggerIdentity{
+			Username: "alice",
+			Groups:   []string{"dev"},
+			Source:   v1alpha3.TriggerIdentityToken,
+		},
+	}, {
+		name: "impersonated user",
+		user: &user.DefaultInfo{Name: "alice", Groups: []string{"dev"}, Extra: map[string][]string{
+			SourceExtraKey:       {string(v1alpha3.TriggerIdentityImpersonation)},
+			ImpersonatorExtraKey: {"ci-bot"},
+		}},
+		expect: &v1alpha3.TriggerIdentity{
+			Username:     "alice",
+			Groups:       []string{"dev"},
+			Source:       v1alpha3.TriggerIdentityImpersonation,
+			Impersonator: "ci-bot",
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expect, FromUser(tt.user))
+			ctx := context.TODO()
+			if tt.user != nil {
+				ctx = request.WithUser(ctx, tt.user)
+			}
+			assert.Equal(t, tt.expect, FromContext(ctx))
+		})
+	}
+}
+
+func TestWithSource(t *testing.T) {
+	origin := &user.DefaultInfo{Name: "alice", UID: "uid", Extra: map[string][]string{
+		"scopes":             {"all"},
+		ImpersonatorExtraKey: {"someone"},
+	}}
+	result := WithSource(origin, v1alpha3.TriggerIdentityToken)
+	assert.Equal(t, "alice", result.GetName())
+	assert.Equal(t, "uid", result.GetUID())
+	assert.Equal(t, []string{"all"}, result.GetExtra()["scopes"])
+	assert.Equal(t, []string{string(v1alpha3.TriggerIdentityToken)}, result.GetExtra()[SourceExtraKey])
+	assert.NotContains(t, result.GetExtra(), ImpersonatorExtraKey)
+	// the origin user is not changed
+	assert.NotContains(t, origin.Extra, SourceExtraKey)
+}
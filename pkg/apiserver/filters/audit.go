@@ -0,0 +1,120 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filters
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"kubesphere.io/devops/pkg/apiserver/authentication/identity"
+	"kubesphere.io/devops/pkg/apiserver/request"
+	"kubesphere.io/devops/pkg/models/audit"
+)
+
+// auditVerbs contains the verbs which change the resources
+var auditVerbs = sets.NewString("create", "update", "patch", "delete", "deletecollection")
+
+// WithAudit records the write operations into the audit store. It relies on the RequestInfo and the user
+// in the context, so it needs to be installed after the authentication.
+func WithAudit(handler http.Handler, store audit.Interface) http.Handler {
+	if store == nil {
+		klog.Warningf("Audit is disabled")
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		info, ok := request.RequestInfoFrom(req.Context())
+		if !ok || !info.IsResourceRequest || !auditVerbs.Has(info.Verb) {
+			handler.ServeHTTP(w, req)
+			return
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		handler.ServeHTTP(recorder, req)
+
+		event := &audit.Event{
+			Time:        time.Now(),
+			Verb:        info.Verb,
+			Project:     info.DevOps,
+			APIGroup:    info.APIGroup,
+			APIVersion:  info.APIVersion,
+			Resource:    info.Resource,
+			Subresource: info.Subresource,
+			Name:        info.Name,
+			Method:      req.Method,
+			Path:        req.URL.Path,
+			SourceIP:    info.SourceIP,
+			UserAgent:   info.UserAgent,
+			StatusCode:  recorder.statusCode,
+		}
+		if event.Project == "" {
+			event.Project = info.Namespace
+		}
+		if user, ok := request.UserFrom(req.Context()); ok {
+			event.User = user.GetName()
+			// it's installed after the impersonation, so the user is the impersonated one
+			if impersonators := user.GetExtra()[identity.ImpersonatorExtraKey]; len(impersonators) > 0 {
+				event.Impersonator = impersonators[0]
+			}
+		}
+		if err := store.Record(event); err != nil {
+			klog.Errorf("failed to record the audit event of %s %s, error: %v", req.Method, req.URL.Path, err)
+		}
+	})
+}
+
+// statusRecorder keeps the status code of the response
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.statusCode = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(data []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(data)
+}
+
+// Flush makes the streaming responses work
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack makes the upgrade requests which are proxied to kube-apiserver work
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := r.ResponseWriter.(http.Hijacker); ok {
+		r.statusCode = http.StatusSwitchingProtocols
+		return hijacker.Hijack()
+	}
+	return nil, nil, fmt.Errorf("the response writer does not support hijacking")
+}
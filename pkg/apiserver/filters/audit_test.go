@@ -0,0 +1,126 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filters
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"kubesphere.io/devops/pkg/apiserver/authentication/identity"
+	"kubesphere.io/devops/pkg/apiserver/request"
+	"kubesphere.io/devops/pkg/client/cache"
+	"kubesphere.io/devops/pkg/models/audit"
+)
+
+func TestWithAudit(t *testing.T) {
+	resolver := &request.RequestInfoFactory{
+		APIPrefixes:          sets.NewString("api", "apis", "kapis", "kapi"),
+		GrouplessAPIPrefixes: sets.NewString("api", "kapi"),
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		user   user.Info
+		verify func(t *testing.T, events []audit.Event)
+	}{{
+		name:   "read operations are not recorded",
+		method: http.MethodGet,
+		path:   "/kapis/devops.kubesphere.io/v1alpha3/devops/project/credentials",
+		verify: func(t *testing.T, events []audit.Event) {
+			assert.Empty(t, events)
+		},
+	}, {
+		name:   "update a credential",
+		method: http.MethodPut,
+		path:   "/kapis/devops.kubesphere.io/v1alpha3/devops/project/credentials/github",
+		verify: func(t *testing.T, events []audit.Event) {
+			if assert.Equal(t, 1, len(events)) {
+				assert.Equal(t, "admin", events[0].User)
+				assert.Empty(t, events[0].Impersonator)
+				assert.Equal(t, "update", events[0].Verb)
+				assert.Equal(t, "project", events[0].Project)
+				assert.Equal(t, "credentials", events[0].Resource)
+				assert.Equal(t, "github", events[0].Name)
+				assert.Equal(t, http.StatusOK, events[0].StatusCode)
+			}
+		},
+	}, {
+		name:   "create a PipelineRun through kube-apiserver",
+		method: http.MethodPost,
+		path:   "/apis/devops.kubesphere.io/v1alpha3/namespaces/project/pipelineruns",
+		status: http.StatusForbidden,
+		verify: func(t *testing.T, events []audit.Event) {
+			if assert.Equal(t, 1, len(events)) {
+				assert.Equal(t, "create", events[0].Verb)
+				assert.Equal(t, "project", events[0].Project)
+				assert.Equal(t, "pipelineruns", events[0].Resource)
+				assert.Equal(t, http.StatusForbidden, events[0].StatusCode)
+			}
+		},
+	}, {
+		name:   "impersonated by a bot",
+		method: http.MethodDelete,
+		path:   "/kapis/devops.kubesphere.io/v1alpha3/devops/project/pipelines/app",
+		user: &user.DefaultInfo{Name: "tom", Extra: map[string][]string{
+			identity.ImpersonatorExtraKey: {"ci-bot"},
+		}},
+		verify: func(t *testing.T, events []audit.Event) {
+			if assert.Equal(t, 1, len(events)) {
+				assert.Equal(t, "tom", events[0].User)
+				assert.Equal(t, "ci-bot", events[0].Impersonator)
+				assert.Equal(t, "delete", events[0].Verb)
+			}
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := audit.NewCacheStore(cache.NewSimpleCache(), time.Hour)
+			handler := WithAudit(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				_, _ = w.Write([]byte("ok"))
+			}), store)
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			info, err := resolver.NewRequestInfo(req)
+			assert.Nil(t, err)
+			ctx := request.WithRequestInfo(req.Context(), info)
+			var requester user.Info = &user.DefaultInfo{Name: "admin"}
+			if tt.user != nil {
+				requester = tt.user
+			}
+			ctx = request.WithUser(ctx, requester)
+
+			handler.ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+			events, err := store.List(audit.Filter{})
+			assert.Nil(t, err)
+			tt.verify(t, events)
+		})
+	}
+
+	// the handler is returned as it is without the store
+	assert.NotNil(t, WithAudit(http.NotFoundHandler(), nil))
+}
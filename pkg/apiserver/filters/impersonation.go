@@ -0,0 +1,121 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filters
+
+import (
+	"fmt"
+	"net/http"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/apiserver/authentication/identity"
+	"kubesphere.io/devops/pkg/apiserver/request"
+)
+
+// WithImpersonation replaces the user of the requests which have the impersonation headers, such as the requests
+// from a CI system or a chat bot which acts on behalf of the users. Only the users whose tokens were verified are
+// able to impersonate, and they need the permission 'impersonate' on the users and groups like kube-apiserver.
+// It needs to be installed after the authentication.
+func WithImpersonation(handler http.Handler, sarClient authorizationv1client.SubjectAccessReviewsGetter) http.Handler {
+	s := serializer.NewCodecFactory(runtime.NewScheme()).WithoutConversion()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		username := req.Header.Get(authenticationv1.ImpersonateUserHeader)
+		groups := req.Header.Values(authenticationv1.ImpersonateGroupHeader)
+		if username == "" {
+			if len(groups) > 0 {
+				writeImpersonationError(w, req, s, apierrors.NewBadRequest("requested impersonation of groups without a user"))
+				return
+			}
+			handler.ServeHTTP(w, req)
+			return
+		}
+
+		ctx := req.Context()
+		impersonator, ok := request.UserFrom(ctx)
+		if verified := identity.FromUser(impersonator); !ok || verified == nil ||
+			verified.Source != v1alpha3.TriggerIdentityToken {
+			writeImpersonationError(w, req, s, apierrors.NewForbidden(schema.GroupResource{Resource: "users"}, username,
+				fmt.Errorf("only the users with a verified token are able to impersonate")))
+			return
+		}
+
+		targets := []*authorizationv1.ResourceAttributes{{Verb: "impersonate", Resource: "users", Name: username}}
+		for _, group := range groups {
+			targets = append(targets, &authorizationv1.ResourceAttributes{Verb: "impersonate", Resource: "groups", Name: group})
+		}
+		for _, target := range targets {
+			allowed, err := canImpersonate(req, sarClient, impersonator, target)
+			if err != nil {
+				responsewriters.InternalError(w, req, err)
+				return
+			}
+			if !allowed {
+				writeImpersonationError(w, req, s, apierrors.NewForbidden(schema.GroupResource{Resource: target.Resource},
+					target.Name, fmt.Errorf("user '%s' is not allowed to impersonate it", impersonator.GetName())))
+				return
+			}
+		}
+
+		impersonated := identity.WithSource(&user.DefaultInfo{Name: username, Groups: groups}, v1alpha3.TriggerIdentityImpersonation)
+		impersonated.Extra[identity.ImpersonatorExtraKey] = []string{impersonator.GetName()}
+		handler.ServeHTTP(w, req.WithContext(request.WithUser(ctx, impersonated)))
+	})
+}
+
+func canImpersonate(req *http.Request, sarClient authorizationv1client.SubjectAccessReviewsGetter, impersonator user.Info,
+	attributes *authorizationv1.ResourceAttributes) (bool, error) {
+	if sarClient == nil {
+		return false, nil
+	}
+	extra := make(map[string]authorizationv1.ExtraValue, len(impersonator.GetExtra()))
+	for key, value := range impersonator.GetExtra() {
+		extra[key] = value
+	}
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: attributes,
+			User:               impersonator.GetName(),
+			Groups:             impersonator.GetGroups(),
+			UID:                impersonator.GetUID(),
+			Extra:              extra,
+		},
+	}
+	result, err := sarClient.SubjectAccessReviews().Create(req.Context(), review, metav1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+	return result.Status.Allowed, nil
+}
+
+func writeImpersonationError(w http.ResponseWriter, req *http.Request, s runtime.NegotiatedSerializer, err error) {
+	gv := schema.GroupVersion{}
+	if info, ok := request.RequestInfoFrom(req.Context()); ok {
+		gv = schema.GroupVersion{Group: info.APIGroup, Version: info.APIVersion}
+	}
+	responsewriters.ErrorNegotiated(err, s, gv, w, req)
+}
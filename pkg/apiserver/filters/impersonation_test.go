@@ -0,0 +1,112 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filters
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/authentication/user"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/apiserver/authentication/identity"
+	"kubesphere.io/devops/pkg/apiserver/request"
+)
+
+func TestWithImpersonation(t *testing.T) {
+	// ci-bot is allowed to impersonate alice and the group dev
+	clientset := k8sfake.NewSimpleClientset()
+	clientset.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		result := review.DeepCopy()
+		attributes := review.Spec.ResourceAttributes
+		result.Status.Allowed = review.Spec.User == "ci-bot" && attributes.Verb == "impersonate" &&
+			((attributes.Resource == "users" && attributes.Name == "alice") ||
+				(attributes.Resource == "groups" && attributes.Name == "dev"))
+		return true, result, nil
+	})
+	verifiedBot := identity.WithSource(&user.DefaultInfo{Name: "ci-bot"}, v1alpha3.TriggerIdentityToken)
+
+	tests := []struct {
+		name       string
+		user       user.Info
+		headers    map[string][]string
+		expectCode int
+		expect     *v1alpha3.TriggerIdentity
+	}{{
+		name:       "without impersonation",
+		user:       &user.DefaultInfo{Name: "bob"},
+		expectCode: http.StatusOK,
+		expect:     &v1alpha3.TriggerIdentity{Username: "bob", Source: v1alpha3.TriggerIdentityUnverified},
+	}, {
+		name:       "impersonate a user and a group",
+		user:       verifiedBot,
+		headers:    map[string][]string{"Impersonate-User": {"alice"}, "Impersonate-Group": {"dev"}},
+		expectCode: http.StatusOK,
+		expect: &v1alpha3.TriggerIdentity{
+			Username:     "alice",
+			Groups:       []string{"dev"},
+			Source:       v1alpha3.TriggerIdentityImpersonation,
+			Impersonator: "ci-bot",
+		},
+	}, {
+		name:       "impersonate a group which is not allowed",
+		user:       verifiedBot,
+		headers:    map[string][]string{"Impersonate-User": {"alice"}, "Impersonate-Group": {"dev", "admin"}},
+		expectCode: http.StatusForbidden,
+	}, {
+		name:       "impersonate a user who is not allowed",
+		user:       verifiedBot,
+		headers:    map[string][]string{"Impersonate-User": {"bob"}},
+		expectCode: http.StatusForbidden,
+	}, {
+		name:       "unverified user",
+		user:       &user.DefaultInfo{Name: "ci-bot"},
+		headers:    map[string][]string{"Impersonate-User": {"alice"}},
+		expectCode: http.StatusForbidden,
+	}, {
+		name:       "impersonate a group without a user",
+		user:       verifiedBot,
+		headers:    map[string][]string{"Impersonate-Group": {"dev"}},
+		expectCode: http.StatusBadRequest,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var handled *v1alpha3.TriggerIdentity
+			handler := WithImpersonation(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				handled = identity.FromContext(req.Context())
+			}), clientset.AuthorizationV1())
+
+			req := httptest.NewRequest(http.MethodPost, "/kapis/devops.kubesphere.io/v1alpha3/namespaces/ns/pipelines/a/runs", nil)
+			for key, values := range tt.headers {
+				for _, value := range values {
+					req.Header.Add(key, value)
+				}
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req.WithContext(request.WithUser(req.Context(), tt.user)))
+			assert.Equal(t, tt.expectCode, recorder.Code)
+			assert.Equal(t, tt.expect, handled)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+// Type is the type of Pipeline backend.
+type Type string
+
+const (
+	// Jenkins indicates the Pipelines are run by Jenkins.
+	Jenkins Type = "Jenkins"
+	// Tekton indicates the Pipelines are run by Tekton.
+	Tekton Type = "Tekton"
+)
+
+// ErrNotSupported indicates that the backend is not able to handle the request.
+var ErrNotSupported = errors.New("not supported by the backend")
+
+// Interface is the common abstraction of a Pipeline backend, such as Jenkins or Tekton.
+// The controllers and the apiserver should talk to a backend through it instead of a backend-specific client.
+type Interface interface {
+	// Type returns the type of the backend.
+	Type() Type
+
+	// CreatePipeline creates the backend object of the Pipeline, or updates it if it already exists.
+	CreatePipeline(ctx context.Context, pipeline *v1alpha3.Pipeline) error
+
+	// DeletePipeline deletes the backend object of the Pipeline. It should not return an error if the object does not exist.
+	DeletePipeline(ctx context.Context, pipeline *v1alpha3.Pipeline) error
+
+	// TriggerRun triggers the PipelineRun and returns the run ID of the backend.
+	TriggerRun(ctx context.Context, pipelineRun *v1alpha3.PipelineRun) (runID string, err error)
+
+	// GetRunStatus returns the latest status of the PipelineRun which has been triggered.
+	GetRunStatus(ctx context.Context, pipelineRun *v1alpha3.PipelineRun) (*v1alpha3.PipelineRunStatus, error)
+
+	// DeleteRun deletes the backend record of the PipelineRun. It should not return an error if the record does not exist.
+	DeleteRun(ctx context.Context, pipelineRun *v1alpha3.PipelineRun) error
+
+	// GetLogs returns the full logs of the PipelineRun which has been triggered.
+	GetLogs(ctx context.Context, pipelineRun *v1alpha3.PipelineRun) ([]byte, error)
+
+	// GetArtifact returns the content of an artifact archived by the PipelineRun, the caller should close it.
+	GetArtifact(ctx context.Context, pipelineRun *v1alpha3.PipelineRun, path string) (io.ReadCloser, error)
+}
+
+// RawRunGetter is implemented by the backends which keep the raw data of the PipelineRuns, such as the builds of
+// Jenkins. The raw data is returned along with the status, then the backend is requested only once.
+type RawRunGetter interface {
+	// GetRunStatusAndRaw returns the latest status of the PipelineRun and the raw data of the backend in JSON.
+	GetRunStatusAndRaw(ctx context.Context, pipelineRun *v1alpha3.PipelineRun) (*v1alpha3.PipelineRunStatus, []byte, error)
+}
+
+// ParseType parses the type of backend from a string, the comparison is case-insensitive.
+func ParseType(text string) (Type, error) {
+	for _, t := range []Type{Jenkins, Tekton} {
+		if strings.EqualFold(string(t), strings.TrimSpace(text)) {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("unknown Pipeline backend: '%s'", text)
+}
+
+// ParseTypes parses a comma-separated list of backend types, duplicated types are ignored.
+func ParseTypes(text string) (types []Type, err error) {
+	for _, item := range strings.Split(text, ",") {
+		var t Type
+		if t, err = ParseType(item); err != nil {
+			return
+		}
+		if !containsType(types, t) {
+			types = append(types, t)
+		}
+	}
+	return
+}
+
+func containsType(types []Type, t Type) bool {
+	for _, item := range types {
+		if item == t {
+			return true
+		}
+	}
+	return false
+}
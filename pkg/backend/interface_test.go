@@ -0,0 +1,64 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseType(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    Type
+		wantErr bool
+	}{{
+		name: "Jenkins",
+		text: "Jenkins",
+		want: Jenkins,
+	}, {
+		name: "lower case with spaces",
+		text: " tekton ",
+		want: Tekton,
+	}, {
+		name:    "unknown backend",
+		text:    "argo",
+		wantErr: true,
+	}, {
+		name:    "empty",
+		text:    "",
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseType(tt.text)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestParseTypes(t *testing.T) {
+	types, err := ParseTypes("Jenkins, tekton,jenkins")
+	assert.Nil(t, err)
+	assert.Equal(t, []Type{Jenkins, Tekton}, types)
+
+	_, err = ParseTypes("Jenkins,fake")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backend
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// TypeOf returns the backend type from the label of the object. The second value is false
+// if the label is absent or invalid.
+func TypeOf(obj metav1.Object) (Type, bool) {
+	if obj == nil {
+		return "", false
+	}
+	value, ok := obj.GetLabels()[v1alpha3.PipelineBackendLabelKey]
+	if !ok {
+		return "", false
+	}
+	backendType, err := ParseType(value)
+	return backendType, err == nil
+}
+
+// NewPredicate filters out the objects which are labeled with another backend.
+// Objects without the backend label are accepted, the Router decides by their DevOpsProjects then.
+func NewPredicate(backendType Type) predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		actual, ok := TypeOf(obj)
+		return !ok || actual == backendType
+	})
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestTypeOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   Type
+		wantOk bool
+	}{{
+		name: "without labels",
+	}, {
+		name:   "with a valid backend",
+		labels: map[string]string{v1alpha3.PipelineBackendLabelKey: "tekton"},
+		want:   Tekton,
+		wantOk: true,
+	}, {
+		name:   "with an invalid backend",
+		labels: map[string]string{v1alpha3.PipelineBackendLabelKey: "fake"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipeline := &v1alpha3.Pipeline{}
+			pipeline.SetLabels(tt.labels)
+			got, ok := TypeOf(pipeline)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantOk, ok)
+		})
+	}
+}
+
+func TestNewPredicate(t *testing.T) {
+	jenkinsPipeline := &v1alpha3.Pipeline{}
+	jenkinsPipeline.SetLabels(map[string]string{v1alpha3.PipelineBackendLabelKey: string(Jenkins)})
+	tektonPipeline := &v1alpha3.Pipeline{}
+	tektonPipeline.SetLabels(map[string]string{v1alpha3.PipelineBackendLabelKey: string(Tekton)})
+	unlabeledPipeline := &v1alpha3.Pipeline{}
+
+	p := NewPredicate(Jenkins)
+	assert.True(t, p.Create(event.CreateEvent{Object: jenkinsPipeline}))
+	assert.True(t, p.Create(event.CreateEvent{Object: unlabeledPipeline}))
+	assert.False(t, p.Create(event.CreateEvent{Object: tektonPipeline}))
+	assert.False(t, p.Update(event.UpdateEvent{ObjectOld: tektonPipeline, ObjectNew: tektonPipeline}))
+	assert.False(t, p.Delete(event.DeleteEvent{Object: tektonPipeline}))
+}
+
+func TestRouter_HandlesObject(t *testing.T) {
+	pipeline := &v1alpha3.Pipeline{}
+	pipeline.SetNamespace("ns")
+	pipeline.SetLabels(map[string]string{v1alpha3.PipelineBackendLabelKey: string(Tekton)})
+
+	// the label takes precedence over the namespace
+	router := NewRouter(nil, Jenkins)
+	handled, err := router.HandlesObject(context.Background(), pipeline, Tekton)
+	assert.True(t, handled)
+	assert.Nil(t, err)
+
+	handled, err = router.HandlesObject(context.Background(), pipeline, Jenkins)
+	assert.False(t, handled)
+	assert.Nil(t, err)
+
+	backendType, err := router.BackendOfObject(context.Background(), pipeline)
+	assert.Equal(t, Tekton, backendType)
+	assert.Nil(t, err)
+
+	// a nil Router handles the objects without label
+	router = nil
+	handled, err = router.HandlesObject(context.Background(), &v1alpha3.Pipeline{}, Jenkins)
+	assert.True(t, handled)
+	assert.Nil(t, err)
+
+	backendType, err = router.BackendOfObject(context.Background(), &v1alpha3.Pipeline{})
+	assert.Equal(t, Jenkins, backendType)
+	assert.Nil(t, err)
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backend
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/constants"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Router finds out which backend is responsible for the Pipelines in a namespace,
+// according to the DevOpsProject which owns the namespace.
+type Router struct {
+	client.Reader
+
+	// Default is the backend of DevOpsProjects which do not declare one.
+	Default Type
+}
+
+// NewRouter creates a Router.
+func NewRouter(reader client.Reader, defaultType Type) *Router {
+	return &Router{
+		Reader:  reader,
+		Default: defaultType,
+	}
+}
+
+// BackendOf returns the backend type of the namespace. The default type is returned if the namespace
+// does not belong to any DevOpsProject.
+func (r *Router) BackendOf(ctx context.Context, namespace string) (Type, error) {
+	ns := &v1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		return "", client.IgnoreNotFound(err)
+	}
+	projectName, ok := ns.GetLabels()[constants.DevOpsProjectLabelKey]
+	if !ok || projectName == "" {
+		return r.Default, nil
+	}
+
+	project := &v1alpha3.DevOpsProject{}
+	if err := r.Get(ctx, types.NamespacedName{Name: projectName}, project); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			return r.Default, nil
+		}
+		return "", err
+	}
+	return GetProjectBackend(project, r.Default)
+}
+
+// Handles returns true if the namespace is handled by the given backend.
+// A nil Router handles everything, which keeps the behaviour of a single backend.
+func (r *Router) Handles(ctx context.Context, namespace string, backendType Type) (bool, error) {
+	if r == nil {
+		return true, nil
+	}
+	actual, err := r.BackendOf(ctx, namespace)
+	return actual == backendType, err
+}
+
+// HandlesObject returns true if the object is handled by the given backend. The backend label of the object
+// takes precedence over the backend of its namespace.
+func (r *Router) HandlesObject(ctx context.Context, obj metav1.Object, backendType Type) (bool, error) {
+	if actual, ok := TypeOf(obj); ok {
+		return actual == backendType, nil
+	}
+	return r.Handles(ctx, obj.GetNamespace(), backendType)
+}
+
+// BackendOfObject returns the backend type from the label of the object, or the backend type of its namespace.
+// A nil Router returns Jenkins for the objects without label, which keeps the behaviour of a single backend.
+func (r *Router) BackendOfObject(ctx context.Context, obj metav1.Object) (Type, error) {
+	if actual, ok := TypeOf(obj); ok {
+		return actual, nil
+	}
+	if r == nil {
+		return Jenkins, nil
+	}
+	return r.BackendOf(ctx, obj.GetNamespace())
+}
+
+// GetProjectBackend returns the backend type declared by the DevOpsProject, or the default type if it's empty.
+func GetProjectBackend(project *v1alpha3.DevOpsProject, defaultType Type) (Type, error) {
+	if project == nil || project.Spec.PipelineBackend == "" {
+		return defaultType, nil
+	}
+	return ParseType(project.Spec.PipelineBackend)
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/constants"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRouter_BackendOf(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1.AddToScheme(schema))
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	newNamespace := func(name, project string) *v1.Namespace {
+		ns := &v1.Namespace{}
+		ns.SetName(name)
+		if project != "" {
+			ns.SetLabels(map[string]string{constants.DevOpsProjectLabelKey: project})
+		}
+		return ns
+	}
+	newProject := func(name, backend string) *v1alpha3.DevOpsProject {
+		project := &v1alpha3.DevOpsProject{}
+		project.SetName(name)
+		project.Spec.PipelineBackend = backend
+		return project
+	}
+
+	tests := []struct {
+		name      string
+		objects   []client.Object
+		namespace string
+		want      Type
+		wantErr   bool
+	}{{
+		name:      "namespace not found",
+		namespace: "ns",
+		want:      "",
+	}, {
+		name:      "namespace without project label",
+		objects:   []client.Object{newNamespace("ns", "")},
+		namespace: "ns",
+		want:      Jenkins,
+	}, {
+		name:      "project not found",
+		objects:   []client.Object{newNamespace("ns", "project")},
+		namespace: "ns",
+		want:      Jenkins,
+	}, {
+		name:      "project without backend",
+		objects:   []client.Object{newNamespace("ns", "project"), newProject("project", "")},
+		namespace: "ns",
+		want:      Jenkins,
+	}, {
+		name:      "project with Tekton backend",
+		objects:   []client.Object{newNamespace("ns", "project"), newProject("project", "Tekton")},
+		namespace: "ns",
+		want:      Tekton,
+	}, {
+		name:      "project with invalid backend",
+		objects:   []client.Object{newNamespace("ns", "project"), newProject("project", "fake")},
+		namespace: "ns",
+		wantErr:   true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewRouter(fake.NewClientBuilder().WithScheme(schema).WithObjects(tt.objects...).Build(), Jenkins)
+			got, err := router.BackendOf(context.Background(), tt.namespace)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestRouter_Handles(t *testing.T) {
+	var router *Router
+	handled, err := router.Handles(context.Background(), "ns", Tekton)
+	assert.True(t, handled)
+	assert.Nil(t, err)
+
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1.AddToScheme(schema))
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+	ns := &v1.Namespace{}
+	ns.SetName("ns")
+	router = NewRouter(fake.NewClientBuilder().WithScheme(schema).WithObjects(ns).Build(), Tekton)
+
+	handled, err = router.Handles(context.Background(), "ns", Tekton)
+	assert.True(t, handled)
+	assert.Nil(t, err)
+
+	handled, err = router.Handles(context.Background(), "ns", Jenkins)
+	assert.False(t, handled)
+	assert.Nil(t, err)
+}
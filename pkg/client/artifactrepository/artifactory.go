@@ -0,0 +1,42 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package artifactrepository
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+type artifactory struct {
+	*binaryClient
+}
+
+func (a *artifactory) Type() v1alpha3.ArtifactRepositoryType {
+	return v1alpha3.ArtifactRepositoryArtifactory
+}
+
+// Check gets the configuration of the repository, Artifactory responds 400 if the repository does not exist
+func (a *artifactory) Check(ctx context.Context) (err error) {
+	var resp *http.Response
+	if resp, err = a.checkAPI(ctx, "/artifactory/api/repositories/"+url.PathEscape(a.repository)); err == nil {
+		_ = resp.Body.Close()
+	}
+	return
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package artifactrepository
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/http/httpproxy"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+// NewClient creates the client of the binary repository by its type, the proxy of the repository takes effect
+// if the HTTP client is nil
+func NewClient(spec *v1alpha3.ArtifactRepositorySpec, credential *Credential, client *http.Client) (Interface, error) {
+	if client == nil {
+		var err error
+		if client, err = NewHTTPClient(spec.Proxy); err != nil {
+			return nil, err
+		}
+	}
+	base := &binaryClient{
+		server:        strings.TrimSuffix(spec.Server, "/"),
+		repository:    spec.Repository,
+		repositoryURL: spec.GetRepositoryURL(),
+		credential:    credential,
+		client:        client,
+	}
+	switch spec.Type {
+	case v1alpha3.ArtifactRepositoryNexus:
+		return &nexus{binaryClient: base}, nil
+	case v1alpha3.ArtifactRepositoryArtifactory:
+		return &artifactory{binaryClient: base}, nil
+	default:
+		return nil, fmt.Errorf("unsupported binary repository %q", spec.Type)
+	}
+}
+
+// NewHTTPClient creates an HTTP client which accesses the binary repository through the proxy
+func NewHTTPClient(proxy *v1alpha3.ArtifactRepositoryProxy) (*http.Client, error) {
+	if proxy == nil || proxy.URL == "" {
+		return http.DefaultClient, nil
+	}
+	if _, err := url.Parse(proxy.URL); err != nil {
+		return nil, fmt.Errorf("invalid proxy %q: %v", proxy.URL, err)
+	}
+	proxyFunc := (&httpproxy.Config{
+		HTTPProxy:  proxy.URL,
+		HTTPSProxy: proxy.URL,
+		NoProxy:    strings.Join(proxy.NoProxy, ","),
+	}).ProxyFunc()
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// binaryClient is the common part of the binary repositories, the artifacts are downloaded
+// and uploaded by the plain HTTP methods
+type binaryClient struct {
+	server        string
+	repository    string
+	repositoryURL string
+	credential    *Credential
+	client        *http.Client
+}
+
+func (c *binaryClient) GetArtifactURL(path string) string {
+	return c.repositoryURL + "/" + strings.TrimPrefix(path, "/")
+}
+
+func (c *binaryClient) Exists(ctx context.Context, path string) (exists bool, err error) {
+	var resp *http.Response
+	if resp, err = c.do(ctx, http.MethodHead, c.GetArtifactURL(path), nil); err != nil {
+		return
+	}
+	_ = resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+	case resp.StatusCode < http.StatusMultipleChoices:
+		exists = true
+	default:
+		err = fmt.Errorf("unexpected status %d of the artifact %s", resp.StatusCode, path)
+	}
+	return
+}
+
+func (c *binaryClient) Upload(ctx context.Context, path string, body io.Reader) (err error) {
+	var resp *http.Response
+	if resp, err = c.do(ctx, http.MethodPut, c.GetArtifactURL(path), body); err != nil {
+		return
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		err = fmt.Errorf("failed to upload the artifact %s, status %d: %s", path, resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return
+}
+
+// checkAPI sends a GET request and makes sure the response is successful
+func (c *binaryClient) checkAPI(ctx context.Context, api string) (resp *http.Response, err error) {
+	if resp, err = c.do(ctx, http.MethodGet, c.server+api, nil); err != nil {
+		return
+	}
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		err = fmt.Errorf("not authorized to access the repository %s, status %d", c.repository, resp.StatusCode)
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusBadRequest:
+		err = ErrRepositoryNotFound
+	case resp.StatusCode >= http.StatusMultipleChoices:
+		err = fmt.Errorf("unexpected status %d of the repository %s", resp.StatusCode, c.repository)
+	}
+	if err != nil {
+		_ = resp.Body.Close()
+	}
+	return
+}
+
+func (c *binaryClient) do(ctx context.Context, method, address string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, address, body)
+	if err != nil {
+		return nil, err
+	}
+	if c.credential != nil {
+		req.SetBasicAuth(c.credential.Username, c.credential.Password)
+	}
+	return c.client.Do(req)
+}
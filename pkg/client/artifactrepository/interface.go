@@ -0,0 +1,66 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package artifactrepository
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	v1 "k8s.io/api/core/v1"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+// ErrRepositoryNotFound indicates the repository does not exist on the server
+var ErrRepositoryNotFound = errors.New("repository not found")
+
+// Interface is the common operations of the binary repositories
+type Interface interface {
+	// Type returns the type of the binary repository
+	Type() v1alpha3.ArtifactRepositoryType
+	// Check makes sure the repository exists and is accessible with the credential
+	Check(ctx context.Context) error
+	// GetArtifactURL returns the download link of the artifact in the repository
+	GetArtifactURL(path string) string
+	// Exists indicates if the artifact exists in the repository
+	Exists(ctx context.Context, path string) (bool, error)
+	// Upload publishes the artifact into the repository. It's supported by the maven and raw repositories of Nexus,
+	// and all the local repositories of Artifactory.
+	Upload(ctx context.Context, path string, body io.Reader) error
+}
+
+// Credential is the username and password, or the token, to access a binary repository
+type Credential struct {
+	Username string
+	Password string
+}
+
+// GetCredential returns the credential from a basic auth secret, nil is returned for the other secrets
+func GetCredential(secret *v1.Secret) *Credential {
+	if secret == nil {
+		return nil
+	}
+	switch secret.Type {
+	case v1.SecretTypeBasicAuth, v1alpha3.SecretTypeBasicAuth:
+		return &Credential{
+			Username: string(secret.Data[v1.BasicAuthUsernameKey]),
+			Password: string(secret.Data[v1.BasicAuthPasswordKey]),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package artifactrepository
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+type mavenSettings struct {
+	XMLName xml.Name      `xml:"settings"`
+	Servers []mavenServer `xml:"servers>server"`
+	Mirrors []mavenMirror `xml:"mirrors>mirror"`
+	Proxies *mavenProxies `xml:"proxies,omitempty"`
+}
+
+type mavenProxies struct {
+	Proxy []mavenProxy `xml:"proxy"`
+}
+
+type mavenServer struct {
+	ID       string `xml:"id"`
+	Username string `xml:"username"`
+	Password string `xml:"password"`
+}
+
+type mavenMirror struct {
+	ID       string `xml:"id"`
+	Name     string `xml:"name"`
+	URL      string `xml:"url"`
+	MirrorOf string `xml:"mirrorOf"`
+}
+
+type mavenProxy struct {
+	ID            string `xml:"id"`
+	Active        bool   `xml:"active"`
+	Protocol      string `xml:"protocol"`
+	Host          string `xml:"host"`
+	Port          string `xml:"port,omitempty"`
+	Username      string `xml:"username,omitempty"`
+	Password      string `xml:"password,omitempty"`
+	NonProxyHosts string `xml:"nonProxyHosts,omitempty"`
+}
+
+// GenerateMavenSettings generates the Maven settings.xml of an ArtifactRepository. The repository mirrors all
+// the others, and the credential is read from the environment variables which are bound by withCredentials.
+func GenerateMavenSettings(repo *v1alpha3.ArtifactRepository) (settings string, err error) {
+	id := repo.GetSettingsID()
+	mvn := mavenSettings{
+		Servers: []mavenServer{{
+			ID:       id,
+			Username: fmt.Sprintf("${env.%s}", v1alpha3.ArtifactRepositoryUsernameEnv),
+			Password: fmt.Sprintf("${env.%s}", v1alpha3.ArtifactRepositoryPasswordEnv),
+		}},
+		Mirrors: []mavenMirror{{
+			ID:       id,
+			Name:     repo.Namespace + "/" + repo.Name,
+			URL:      repo.Spec.GetRepositoryURL(),
+			MirrorOf: "*",
+		}},
+	}
+
+	if proxy := repo.Spec.Proxy; proxy != nil && proxy.URL != "" {
+		var proxyURL *url.URL
+		if proxyURL, err = url.Parse(proxy.URL); err != nil {
+			err = fmt.Errorf("invalid proxy %q: %v", proxy.URL, err)
+			return
+		}
+		mvnProxy := mavenProxy{
+			ID:            id + "-proxy",
+			Active:        true,
+			Protocol:      proxyURL.Scheme,
+			Host:          proxyURL.Hostname(),
+			Port:          proxyURL.Port(),
+			NonProxyHosts: strings.Join(proxy.NoProxy, "|"),
+		}
+		if proxyURL.User != nil {
+			mvnProxy.Username = proxyURL.User.Username()
+			mvnProxy.Password, _ = proxyURL.User.Password()
+		}
+		mvn.Proxies = &mavenProxies{Proxy: []mavenProxy{mvnProxy}}
+	}
+
+	var data []byte
+	if data, err = xml.MarshalIndent(mvn, "", "  "); err == nil {
+		settings = xml.Header + string(data) + "\n"
+	}
+	return
+}
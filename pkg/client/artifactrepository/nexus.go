@@ -0,0 +1,56 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package artifactrepository
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+type nexus struct {
+	*binaryClient
+}
+
+func (n *nexus) Type() v1alpha3.ArtifactRepositoryType {
+	return v1alpha3.ArtifactRepositoryNexus
+}
+
+// Check finds the repository from the repositories which are visible to the credential
+func (n *nexus) Check(ctx context.Context) (err error) {
+	var resp *http.Response
+	if resp, err = n.checkAPI(ctx, "/service/rest/v1/repositories"); err != nil {
+		return
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	var repositories []struct {
+		Name string `json:"name"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&repositories); err != nil {
+		return
+	}
+	for _, repository := range repositories {
+		if repository.Name == n.repository {
+			return nil
+		}
+	}
+	return ErrRepositoryNotFound
+}
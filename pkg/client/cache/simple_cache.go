@@ -19,6 +19,7 @@ package cache
 import (
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"kubesphere.io/devops/pkg/server/errors"
@@ -34,6 +35,8 @@ type simpleObject struct {
 
 // SimpleCache implements cache.Interface use memory objects, it should be used only for testing
 type simpleCache struct {
+	// lock guards the store, the cache is shared by the concurrent requests of the apiserver
+	lock  sync.RWMutex
 	store map[string]simpleObject
 }
 
@@ -43,13 +46,15 @@ func NewSimpleCache() Interface {
 
 func (s *simpleCache) Keys(pattern string) ([]string, error) {
 	// There is a little difference between go regexp and redis key pattern
-	// In redis, * means any character, while in go . means match everything.
-	pattern = strings.Replace(pattern, "*", ".", -1)
+	// In redis, * means any characters, while in go .* means match everything.
+	pattern = strings.Replace(pattern, "*", ".*", -1)
 
 	re, err := regexp.Compile(pattern)
 	if err != nil {
 		return nil, err
 	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 	var keys []string
 	for k := range s.store {
 		if re.MatchString(k) {
@@ -71,11 +76,15 @@ func (s *simpleCache) Set(key string, value string, duration time.Duration) erro
 		sobject.neverExpire = true
 	}
 
+	s.lock.Lock()
+	defer s.lock.Unlock()
 	s.store[key] = sobject
 	return nil
 }
 
 func (s *simpleCache) Del(keys ...string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
 	for _, key := range keys {
 		delete(s.store, key)
 	}
@@ -83,6 +92,8 @@ func (s *simpleCache) Del(keys ...string) error {
 }
 
 func (s *simpleCache) Get(key string) (string, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 	if sobject, ok := s.store[key]; ok {
 		if sobject.neverExpire || time.Now().Before(sobject.expiredAt) {
 			return sobject.value, nil
@@ -93,6 +104,8 @@ func (s *simpleCache) Get(key string) (string, error) {
 }
 
 func (s *simpleCache) Exists(keys ...string) (bool, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 	for _, key := range keys {
 		if _, ok := s.store[key]; !ok {
 			return false, nil
@@ -118,6 +131,8 @@ func (s *simpleCache) Expire(key string, duration time.Duration) error {
 		sobject.neverExpire = true
 	}
 
+	s.lock.Lock()
+	defer s.lock.Unlock()
 	s.store[key] = sobject
 	return nil
 }
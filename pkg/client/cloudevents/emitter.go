@@ -0,0 +1,97 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudevents
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/uuid"
+)
+
+// SpecVersion is the version of the CloudEvents specification
+const SpecVersion = "1.0"
+
+// Event is a CloudEvent, the data is encoded as JSON
+type Event struct {
+	ID      string
+	Type    string
+	Subject string
+	Time    time.Time
+	Data    interface{}
+}
+
+// Emitter sends the CloudEvents to a sink
+type Emitter interface {
+	Emit(ctx context.Context, event Event) error
+}
+
+type httpEmitter struct {
+	sink   string
+	source string
+	client *http.Client
+}
+
+// NewEmitter creates an Emitter which sends the events to a HTTP sink in the binary content mode,
+// such as a Knative Broker
+func NewEmitter(sink, source string, client *http.Client) Emitter {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &httpEmitter{sink: sink, source: source, client: client}
+}
+
+func (e *httpEmitter) Emit(ctx context.Context, event Event) (err error) {
+	var data []byte
+	if data, err = json.Marshal(event.Data); err != nil {
+		return
+	}
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctx, http.MethodPost, e.sink, bytes.NewReader(data)); err != nil {
+		return
+	}
+
+	if event.ID == "" {
+		event.ID = string(uuid.NewUUID())
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	req.Header.Set("Ce-Specversion", SpecVersion)
+	req.Header.Set("Ce-Id", event.ID)
+	req.Header.Set("Ce-Source", e.source)
+	req.Header.Set("Ce-Type", event.Type)
+	req.Header.Set("Ce-Time", event.Time.UTC().Format(time.RFC3339Nano))
+	if event.Subject != "" {
+		req.Header.Set("Ce-Subject", event.Subject)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var resp *http.Response
+	if resp, err = e.client.Do(req); err != nil {
+		return
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		err = fmt.Errorf("unexpected status code from the sink: %d", resp.StatusCode)
+	}
+	return
+}
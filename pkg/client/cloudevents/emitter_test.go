@@ -0,0 +1,78 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudevents
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmitter(t *testing.T) {
+	var header http.Header
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/error" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		header = r.Header
+		data, _ := ioutil.ReadAll(r.Body)
+		body = string(data)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	emitter := NewEmitter(server.URL, "kubesphere.io/devops", nil)
+	err := emitter.Emit(context.TODO(), Event{
+		ID:      "id",
+		Type:    "io.kubesphere.devops.pipeline.created",
+		Subject: "namespaces/ns/pipelines/build",
+		Time:    time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		Data:    map[string]string{"name": "build"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "1.0", header.Get("Ce-Specversion"))
+	assert.Equal(t, "id", header.Get("Ce-Id"))
+	assert.Equal(t, "kubesphere.io/devops", header.Get("Ce-Source"))
+	assert.Equal(t, "io.kubesphere.devops.pipeline.created", header.Get("Ce-Type"))
+	assert.Equal(t, "namespaces/ns/pipelines/build", header.Get("Ce-Subject"))
+	assert.Equal(t, "2022-01-01T00:00:00Z", header.Get("Ce-Time"))
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+	assert.Equal(t, `{"name":"build"}`, body)
+
+	// the id and time are generated if they are empty
+	assert.Nil(t, emitter.Emit(context.TODO(), Event{Type: "type"}))
+	assert.NotEmpty(t, header.Get("Ce-Id"))
+	assert.NotEmpty(t, header.Get("Ce-Time"))
+	assert.Empty(t, header.Get("Ce-Subject"))
+
+	assert.NotNil(t, NewEmitter(server.URL+"/error", "source", nil).Emit(context.TODO(), Event{Type: "type"}))
+	assert.NotNil(t, emitter.Emit(context.TODO(), Event{Type: "type", Data: make(chan int)}))
+}
+
+func TestOptions_NewEmitter(t *testing.T) {
+	var nilOptions *Options
+	assert.Nil(t, nilOptions.NewEmitter())
+	assert.Nil(t, NewOptions().NewEmitter())
+	assert.NotNil(t, (&Options{Sink: "http://broker"}).NewEmitter())
+}
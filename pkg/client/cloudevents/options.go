@@ -0,0 +1,53 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudevents
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// Options contains the configuration of the CloudEvents sink
+type Options struct {
+	Sink   string `json:"sink,omitempty" yaml:"sink"`
+	Source string `json:"source,omitempty" yaml:"source"`
+}
+
+// NewOptions creates an Options without sink
+func NewOptions() *Options {
+	return &Options{
+		Source: "kubesphere.io/devops",
+	}
+}
+
+// AddFlags adds the flags of the options
+func (o *Options) AddFlags(fs *pflag.FlagSet, c *Options) {
+	fs.StringVar(&o.Sink, "cloudevents-sink", c.Sink, ""+
+		"The HTTP address which receives the CloudEvents of Pipelines and PipelineRuns, e.g. a Knative Broker. "+
+		"No CloudEvents are emitted if it's empty.")
+	fs.StringVar(&o.Source, "cloudevents-source", c.Source, "The source attribute of the emitted CloudEvents.")
+}
+
+// NewEmitter creates the Emitter of the configured sink, it returns nil if there is no sink
+func (o *Options) NewEmitter() Emitter {
+	if o == nil || o.Sink == "" {
+		return nil
+	}
+	return NewEmitter(o.Sink, o.Source, &http.Client{Timeout: 30 * time.Second})
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package breaker provides a circuit breaker for the requests sent to the Pipeline backends, such as Jenkins.
+// The requests fail fast once the backend is considered unavailable, then a probe request is allowed after
+// a cool-down period. The listeners are notified when the backend recovers.
+package breaker
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrBackendUnavailable indicates that the request was rejected because the circuit is open
+var ErrBackendUnavailable = errors.New("the backend is unavailable")
+
+// State is the state of a circuit breaker
+type State string
+
+const (
+	// Closed means the requests are allowed
+	Closed State = "Closed"
+	// Open means the requests are rejected
+	Open State = "Open"
+	// HalfOpen means a probe request is allowed to check if the backend has recovered
+	HalfOpen State = "HalfOpen"
+)
+
+// Breaker is a circuit breaker which is safe for concurrent use
+type Breaker struct {
+	// Threshold is the number of the consecutive failures to open the circuit
+	Threshold int
+	// Cooldown is the initial duration of keeping the circuit open, it doubles after each failed probe
+	Cooldown time.Duration
+	// MaxCooldown is the maximum duration of keeping the circuit open
+	MaxCooldown time.Duration
+
+	mu        sync.Mutex
+	state     State
+	failures  int
+	openedAt  time.Time
+	cooldown  time.Duration
+	probing   bool
+	listeners []func()
+	now       func() time.Time
+}
+
+// New creates a circuit breaker
+func New(threshold int, cooldown, maxCooldown time.Duration) *Breaker {
+	if threshold <= 0 {
+		threshold = 1
+	}
+	if maxCooldown < cooldown {
+		maxCooldown = cooldown
+	}
+	return &Breaker{
+		Threshold:   threshold,
+		Cooldown:    cooldown,
+		MaxCooldown: maxCooldown,
+		state:       Closed,
+		now:         time.Now,
+	}
+}
+
+// OnRecover registers a listener which is called when the circuit closes again
+func (b *Breaker) OnRecover(listener func()) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.listeners = append(b.listeners, listener)
+}
+
+// State returns the current state
+func (b *Breaker) State() State {
+	if b == nil {
+		return Closed
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Allow returns true if a request is allowed. Only one probe request is allowed when the circuit is half-open.
+func (b *Breaker) Allow() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case Open:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = HalfOpen
+		fallthrough
+	case HalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+	}
+	return true
+}
+
+// Success records a successful request
+func (b *Breaker) Success() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	recovered := b.state != Closed
+	b.state, b.failures, b.probing, b.cooldown = Closed, 0, false, 0
+	listeners := b.listeners
+	b.mu.Unlock()
+
+	if recovered {
+		for _, listener := range listeners {
+			listener()
+		}
+	}
+}
+
+// Failure records a failed request
+func (b *Breaker) Failure() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	switch b.state {
+	case HalfOpen:
+		// the probe failed, keep the circuit open for a longer time
+		b.cooldown *= 2
+		if b.cooldown > b.MaxCooldown {
+			b.cooldown = b.MaxCooldown
+		}
+		b.state, b.openedAt, b.probing = Open, b.now(), false
+	case Closed:
+		if b.failures >= b.Threshold {
+			b.state, b.openedAt, b.cooldown = Open, b.now(), b.Cooldown
+		}
+	}
+}
+
+// RetryAfter returns the duration until the next probe request is allowed, it's zero if the circuit is closed
+func (b *Breaker) RetryAfter() time.Duration {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == Closed {
+		return 0
+	}
+	if remaining := b.cooldown - b.now().Sub(b.openedAt); remaining > time.Second {
+		return remaining
+	}
+	return time.Second
+}
+
+// RoundTripper wraps the next RoundTripper with the circuit breaker. The connection errors and the
+// responses of 502, 503 and 504 are considered as failures, other responses mean the backend is available.
+func (b *Breaker) RoundTripper(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if b == nil {
+		return next
+	}
+	return roundTripperFunc(func(req *http.Request) (resp *http.Response, err error) {
+		if !b.Allow() {
+			return nil, ErrBackendUnavailable
+		}
+		if resp, err = next.RoundTrip(req); err != nil || isUnavailableStatus(resp.StatusCode) {
+			b.Failure()
+		} else {
+			b.Success()
+		}
+		return
+	})
+}
+
+// IsUnavailable returns true if the error was caused by an open circuit. Some clients format the errors
+// without wrapping them, so the message is checked as well.
+func IsUnavailable(err error) bool {
+	return err != nil && (errors.Is(err, ErrBackendUnavailable) ||
+		strings.Contains(err.Error(), ErrBackendUnavailable.Error()))
+}
+
+func isUnavailableStatus(code int) bool {
+	return code == http.StatusBadGateway || code == http.StatusServiceUnavailable || code == http.StatusGatewayTimeout
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package breaker
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Now()
+	b := New(2, 10*time.Second, 30*time.Second)
+	b.now = func() time.Time { return now }
+	var recovered int
+	b.OnRecover(func() { recovered++ })
+
+	assert.Equal(t, Closed, b.State())
+	assert.Equal(t, time.Duration(0), b.RetryAfter())
+	assert.True(t, b.Allow())
+	b.Failure()
+	assert.Equal(t, Closed, b.State())
+	b.Failure()
+	assert.Equal(t, Open, b.State())
+	assert.False(t, b.Allow())
+	assert.Equal(t, 10*time.Second, b.RetryAfter())
+
+	// only one probe is allowed after the cool-down
+	now = now.Add(10 * time.Second)
+	assert.True(t, b.Allow())
+	assert.Equal(t, HalfOpen, b.State())
+	assert.False(t, b.Allow())
+
+	// the failed probe doubles the cool-down
+	b.Failure()
+	assert.Equal(t, Open, b.State())
+	assert.Equal(t, 20*time.Second, b.RetryAfter())
+	now = now.Add(20 * time.Second)
+	assert.True(t, b.Allow())
+	b.Failure()
+	assert.Equal(t, 30*time.Second, b.RetryAfter())
+
+	now = now.Add(30 * time.Second)
+	assert.True(t, b.Allow())
+	b.Success()
+	assert.Equal(t, Closed, b.State())
+	assert.Equal(t, 1, recovered)
+
+	// no notification if it's closed already
+	b.Success()
+	assert.Equal(t, 1, recovered)
+}
+
+func TestNilBreaker(t *testing.T) {
+	var b *Breaker
+	assert.True(t, b.Allow())
+	assert.Equal(t, Closed, b.State())
+	assert.Equal(t, time.Duration(0), b.RetryAfter())
+	b.Failure()
+	b.Success()
+	b.OnRecover(func() {})
+	assert.Equal(t, http.DefaultTransport, b.RoundTripper(nil))
+}
+
+func TestRoundTripper(t *testing.T) {
+	code := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(code)
+	}))
+	defer server.Close()
+
+	b := New(1, time.Minute, time.Minute)
+	client := &http.Client{Transport: b.RoundTripper(nil)}
+
+	resp, err := client.Get(server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, Open, b.State())
+
+	// fail fast without sending the request
+	_, err = client.Get(server.URL)
+	assert.True(t, IsUnavailable(err))
+
+	b = New(1, time.Minute, time.Minute)
+	client = &http.Client{Transport: b.RoundTripper(nil)}
+	code = http.StatusNotFound
+	resp, err = client.Get(server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, Closed, b.State())
+}
+
+func TestIsUnavailable(t *testing.T) {
+	assert.False(t, IsUnavailable(nil))
+	assert.False(t, IsUnavailable(errors.New("fake")))
+	assert.True(t, IsUnavailable(ErrBackendUnavailable))
+	assert.True(t, IsUnavailable(fmt.Errorf("wrapped: %w", ErrBackendUnavailable)))
+	assert.True(t, IsUnavailable(fmt.Errorf("formatted: %v", ErrBackendUnavailable)))
+}
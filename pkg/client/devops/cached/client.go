@@ -0,0 +1,276 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cached provides a read-through cache of the Jenkins job metadata and build status, it cuts the round trips
+// to Jenkins while the console polls the runs of many pipelines concurrently.
+package cached
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	runtimecache "sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/cache"
+	"kubesphere.io/devops/pkg/client/devops"
+)
+
+const keyPrefix = "kubesphere:devops:jenkins"
+
+// Client caches the queries of the pipelines and the pipeline runs, other requests are sent to the wrapped client.
+// The queries are cached per Authorization header, since Jenkins returns different results to different users.
+// All the cached queries of a pipeline are invalidated at once by changing the version of the pipeline,
+// so that the invalidation doesn't need to scan the keys.
+type Client struct {
+	devops.Interface
+	store cache.Interface
+	ttl   time.Duration
+}
+
+// NewClient creates a client which caches the queries for the ttl
+func NewClient(next devops.Interface, store cache.Interface, ttl time.Duration) *Client {
+	return &Client{Interface: next, store: store, ttl: ttl}
+}
+
+// GetPipeline returns the cached pipeline
+func (c *Client) GetPipeline(projectName, pipelineName string, httpParameters *devops.HttpParameters) (result *devops.Pipeline, err error) {
+	key := c.key(projectName, pipelineName, httpParameters, "pipeline")
+	if c.get(key, &result) {
+		return
+	}
+	if result, err = c.Interface.GetPipeline(projectName, pipelineName, httpParameters); err == nil {
+		c.set(key, result)
+	}
+	return
+}
+
+// GetPipelineRun returns the cached pipeline run
+func (c *Client) GetPipelineRun(projectName, pipelineName, runID string, httpParameters *devops.HttpParameters) (result *devops.PipelineRun, err error) {
+	key := c.key(projectName, pipelineName, httpParameters, "run", runID)
+	if c.get(key, &result) {
+		return
+	}
+	if result, err = c.Interface.GetPipelineRun(projectName, pipelineName, runID, httpParameters); err == nil {
+		c.set(key, result)
+	}
+	return
+}
+
+// ListPipelineRuns returns the cached pipeline runs
+func (c *Client) ListPipelineRuns(projectName, pipelineName string, httpParameters *devops.HttpParameters) (result *devops.PipelineRunList, err error) {
+	key := c.key(projectName, pipelineName, httpParameters, "runs")
+	if c.get(key, &result) {
+		return
+	}
+	if result, err = c.Interface.ListPipelineRuns(projectName, pipelineName, httpParameters); err == nil {
+		c.set(key, result)
+	}
+	return
+}
+
+// GetBranchPipeline returns the cached branch pipeline
+func (c *Client) GetBranchPipeline(projectName, pipelineName, branchName string, httpParameters *devops.HttpParameters) (result *devops.BranchPipeline, err error) {
+	key := c.key(projectName, pipelineName, httpParameters, "branch", branchName)
+	if c.get(key, &result) {
+		return
+	}
+	if result, err = c.Interface.GetBranchPipeline(projectName, pipelineName, branchName, httpParameters); err == nil {
+		c.set(key, result)
+	}
+	return
+}
+
+// GetBranchPipelineRun returns the cached pipeline run of a branch
+func (c *Client) GetBranchPipelineRun(projectName, pipelineName, branchName, runID string, httpParameters *devops.HttpParameters) (result *devops.PipelineRun, err error) {
+	key := c.key(projectName, pipelineName, httpParameters, "branch", branchName, "run", runID)
+	if c.get(key, &result) {
+		return
+	}
+	if result, err = c.Interface.GetBranchPipelineRun(projectName, pipelineName, branchName, runID, httpParameters); err == nil {
+		c.set(key, result)
+	}
+	return
+}
+
+// GetPipelineBranch returns the cached branches of a pipeline
+func (c *Client) GetPipelineBranch(projectName, pipelineName string, httpParameters *devops.HttpParameters) (result *devops.PipelineBranch, err error) {
+	key := c.key(projectName, pipelineName, httpParameters, "branches")
+	if c.get(key, &result) {
+		return
+	}
+	if result, err = c.Interface.GetPipelineBranch(projectName, pipelineName, httpParameters); err == nil {
+		c.set(key, result)
+	}
+	return
+}
+
+// RunPipeline triggers a pipeline and invalidates its cache
+func (c *Client) RunPipeline(projectName, pipelineName string, httpParameters *devops.HttpParameters) (*devops.RunPipeline, error) {
+	defer c.Invalidate(projectName, pipelineName)
+	return c.Interface.RunPipeline(projectName, pipelineName, httpParameters)
+}
+
+// StopPipeline stops a pipeline run and invalidates the cache of the pipeline
+func (c *Client) StopPipeline(projectName, pipelineName, runID string, httpParameters *devops.HttpParameters) (*devops.StopPipeline, error) {
+	defer c.Invalidate(projectName, pipelineName)
+	return c.Interface.StopPipeline(projectName, pipelineName, runID, httpParameters)
+}
+
+// ReplayPipeline replays a pipeline run and invalidates the cache of the pipeline
+func (c *Client) ReplayPipeline(projectName, pipelineName, runID string, httpParameters *devops.HttpParameters) (*devops.ReplayPipeline, error) {
+	defer c.Invalidate(projectName, pipelineName)
+	return c.Interface.ReplayPipeline(projectName, pipelineName, runID, httpParameters)
+}
+
+// SubmitInputStep submits an input step and invalidates the cache of the pipeline
+func (c *Client) SubmitInputStep(projectName, pipelineName, runID, nodeID, stepID string, httpParameters *devops.HttpParameters) ([]byte, error) {
+	defer c.Invalidate(projectName, pipelineName)
+	return c.Interface.SubmitInputStep(projectName, pipelineName, runID, nodeID, stepID, httpParameters)
+}
+
+// RunBranchPipeline triggers a branch pipeline and invalidates the cache of the pipeline
+func (c *Client) RunBranchPipeline(projectName, pipelineName, branchName string, httpParameters *devops.HttpParameters) (*devops.RunPipeline, error) {
+	defer c.Invalidate(projectName, pipelineName)
+	return c.Interface.RunBranchPipeline(projectName, pipelineName, branchName, httpParameters)
+}
+
+// StopBranchPipeline stops a pipeline run of a branch and invalidates the cache of the pipeline
+func (c *Client) StopBranchPipeline(projectName, pipelineName, branchName, runID string, httpParameters *devops.HttpParameters) (*devops.StopPipeline, error) {
+	defer c.Invalidate(projectName, pipelineName)
+	return c.Interface.StopBranchPipeline(projectName, pipelineName, branchName, runID, httpParameters)
+}
+
+// ReplayBranchPipeline replays a pipeline run of a branch and invalidates the cache of the pipeline
+func (c *Client) ReplayBranchPipeline(projectName, pipelineName, branchName, runID string, httpParameters *devops.HttpParameters) (*devops.ReplayPipeline, error) {
+	defer c.Invalidate(projectName, pipelineName)
+	return c.Interface.ReplayBranchPipeline(projectName, pipelineName, branchName, runID, httpParameters)
+}
+
+// SubmitBranchInputStep submits an input step of a branch and invalidates the cache of the pipeline
+func (c *Client) SubmitBranchInputStep(projectName, pipelineName, branchName, runID, nodeID, stepID string, httpParameters *devops.HttpParameters) ([]byte, error) {
+	defer c.Invalidate(projectName, pipelineName)
+	return c.Interface.SubmitBranchInputStep(projectName, pipelineName, branchName, runID, nodeID, stepID, httpParameters)
+}
+
+// ScanBranch scans the branches and invalidates the cache of the pipeline
+func (c *Client) ScanBranch(projectName, pipelineName string, httpParameters *devops.HttpParameters) ([]byte, error) {
+	defer c.Invalidate(projectName, pipelineName)
+	return c.Interface.ScanBranch(projectName, pipelineName, httpParameters)
+}
+
+// UpdateProjectPipeline updates a pipeline and invalidates its cache
+func (c *Client) UpdateProjectPipeline(projectID string, pipeline *v1alpha3.Pipeline) (string, error) {
+	defer c.Invalidate(projectID, pipeline.Name)
+	return c.Interface.UpdateProjectPipeline(projectID, pipeline)
+}
+
+// DeleteProjectPipeline deletes a pipeline and invalidates its cache
+func (c *Client) DeleteProjectPipeline(projectID string, pipelineID string) (string, error) {
+	defer c.Invalidate(projectID, pipelineID)
+	return c.Interface.DeleteProjectPipeline(projectID, pipelineID)
+}
+
+// Invalidate drops all the cached queries of a pipeline
+func (c *Client) Invalidate(projectName, pipelineName string) {
+	// the cached queries expire before the version, so that they never come back once the version expires
+	version := strconv.FormatInt(time.Now().UnixNano(), 10)
+	if err := c.store.Set(c.versionKey(projectName, pipelineName), version, 2*c.ttl); err != nil {
+		klog.Errorf("failed to invalidate the cache of pipeline %s/%s, error: %v", projectName, pipelineName, err)
+	}
+}
+
+// InvalidateOnChange invalidates the cache of a pipeline once the pipeline or its PipelineRuns change,
+// for instance, the controller updates the status of a PipelineRun after reconciling it
+func (c *Client) InvalidateOnChange(ctx context.Context, informers runtimecache.Informers) error {
+	for _, obj := range []client.Object{&v1alpha3.Pipeline{}, &v1alpha3.PipelineRun{}} {
+		informer, err := informers.GetInformer(ctx, obj)
+		if err != nil {
+			return err
+		}
+		informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+			AddFunc: c.onChange,
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				// skip the periodic resync
+				if oldObj.(client.Object).GetResourceVersion() != newObj.(client.Object).GetResourceVersion() {
+					c.onChange(newObj)
+				}
+			},
+			DeleteFunc: c.onChange,
+		})
+	}
+	return nil
+}
+
+func (c *Client) onChange(obj interface{}) {
+	switch o := obj.(type) {
+	case *v1alpha3.Pipeline:
+		c.Invalidate(o.Namespace, o.Name)
+	case *v1alpha3.PipelineRun:
+		if pipelineName := o.GetLabels()[v1alpha3.PipelineNameLabelKey]; pipelineName != "" {
+			c.Invalidate(o.Namespace, pipelineName)
+		}
+	case toolscache.DeletedFinalStateUnknown:
+		c.onChange(o.Obj)
+	}
+}
+
+func (c *Client) versionKey(projectName, pipelineName string) string {
+	return fmt.Sprintf("%s:%s:%s:version", keyPrefix, projectName, pipelineName)
+}
+
+func (c *Client) key(projectName, pipelineName string, httpParameters *devops.HttpParameters, parts ...string) string {
+	// it's fine to take an empty version if the pipeline was never invalidated
+	version, _ := c.store.Get(c.versionKey(projectName, pipelineName))
+	var query, identity string
+	if httpParameters != nil {
+		if httpParameters.Url != nil {
+			query = httpParameters.Url.RawQuery
+		}
+		// the credentials must not be stored in the keys as plain text
+		if auth := httpParameters.Header.Get("Authorization"); auth != "" {
+			identity = fmt.Sprintf("%x", sha256.Sum256([]byte(auth)))
+		}
+	}
+	return fmt.Sprintf("%s:%s:%s:%s:%s:%s?%s", keyPrefix, projectName, pipelineName, version, identity,
+		strings.Join(parts, ":"), query)
+}
+
+func (c *Client) get(key string, result interface{}) bool {
+	value, err := c.store.Get(key)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal([]byte(value), result) == nil
+}
+
+func (c *Client) set(key string, result interface{}) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return
+	}
+	if err = c.store.Set(key, string(data), c.ttl); err != nil {
+		klog.V(4).Infof("failed to cache %s, error: %v", key, err)
+	}
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cached
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/cache"
+	"kubesphere.io/devops/pkg/client/devops"
+)
+
+type countingClient struct {
+	devops.Interface
+	queries int
+	runs    int
+}
+
+func (c *countingClient) ListPipelineRuns(projectName, pipelineName string, _ *devops.HttpParameters) (*devops.PipelineRunList, error) {
+	c.queries++
+	return &devops.PipelineRunList{Total: c.queries}, nil
+}
+
+func (c *countingClient) GetPipelineRun(projectName, pipelineName, runID string, _ *devops.HttpParameters) (*devops.PipelineRun, error) {
+	c.queries++
+	return nil, &devops.ErrorResponse{Message: "not found"}
+}
+
+func (c *countingClient) RunPipeline(projectName, pipelineName string, _ *devops.HttpParameters) (*devops.RunPipeline, error) {
+	c.runs++
+	return &devops.RunPipeline{}, nil
+}
+
+func newParameters(query string) *devops.HttpParameters {
+	return &devops.HttpParameters{Url: &url.URL{RawQuery: query}}
+}
+
+func TestClient(t *testing.T) {
+	next := &countingClient{}
+	c := NewClient(next, cache.NewSimpleCache(), time.Minute)
+
+	list, err := c.ListPipelineRuns("project", "pipeline", newParameters("start=0"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, list.Total)
+
+	// read from the cache
+	list, err = c.ListPipelineRuns("project", "pipeline", newParameters("start=0"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, list.Total)
+	assert.Equal(t, 1, next.queries)
+
+	// different queries are cached separately
+	list, err = c.ListPipelineRuns("project", "pipeline", newParameters("start=10"))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, list.Total)
+	list, err = c.ListPipelineRuns("project", "another", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, list.Total)
+
+	// triggering a pipeline invalidates its cache only
+	_, err = c.RunPipeline("project", "pipeline", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, next.runs)
+	list, err = c.ListPipelineRuns("project", "pipeline", newParameters("start=0"))
+	assert.Nil(t, err)
+	assert.Equal(t, 4, list.Total)
+	list, err = c.ListPipelineRuns("project", "another", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, list.Total)
+
+	// different users are cached separately
+	userParameters := func(auth string) *devops.HttpParameters {
+		parameters := newParameters("start=0")
+		parameters.Header = http.Header{"Authorization": []string{auth}}
+		return parameters
+	}
+	list, err = c.ListPipelineRuns("project", "pipeline", userParameters("Bearer alice"))
+	assert.Nil(t, err)
+	assert.Equal(t, 5, list.Total)
+	list, err = c.ListPipelineRuns("project", "pipeline", userParameters("Bearer bob"))
+	assert.Nil(t, err)
+	assert.Equal(t, 6, list.Total)
+	list, err = c.ListPipelineRuns("project", "pipeline", userParameters("Bearer alice"))
+	assert.Nil(t, err)
+	assert.Equal(t, 5, list.Total)
+
+	// errors are not cached
+	for i := 0; i < 2; i++ {
+		_, err = c.GetPipelineRun("project", "pipeline", "1", nil)
+		assert.NotNil(t, err)
+	}
+	assert.Equal(t, 8, next.queries)
+}
+
+func TestClientExpired(t *testing.T) {
+	next := &countingClient{}
+	c := NewClient(next, cache.NewSimpleCache(), time.Millisecond)
+
+	_, err := c.ListPipelineRuns("project", "pipeline", nil)
+	assert.Nil(t, err)
+	time.Sleep(5 * time.Millisecond)
+	_, err = c.ListPipelineRuns("project", "pipeline", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, next.queries)
+}
+
+func TestOnChange(t *testing.T) {
+	tests := []struct {
+		name        string
+		obj         interface{}
+		invalidated bool
+	}{{
+		name: "pipeline",
+		obj: &v1alpha3.Pipeline{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "project", Name: "pipeline"}},
+		invalidated: true,
+	}, {
+		name: "pipelinerun",
+		obj: &v1alpha3.PipelineRun{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "project", Name: "pipeline-x",
+			Labels: map[string]string{v1alpha3.PipelineNameLabelKey: "pipeline"}}},
+		invalidated: true,
+	}, {
+		name: "pipelinerun without pipeline",
+		obj: &v1alpha3.PipelineRun{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "project", Name: "pipeline-x"}},
+	}, {
+		name: "deleted pipeline",
+		obj: toolscache.DeletedFinalStateUnknown{Obj: &v1alpha3.Pipeline{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "project", Name: "pipeline"}}},
+		invalidated: true,
+	}, {
+		name: "another pipeline",
+		obj: &v1alpha3.Pipeline{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "project", Name: "another"}},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &countingClient{}
+			c := NewClient(next, cache.NewSimpleCache(), time.Minute)
+			_, _ = c.ListPipelineRuns("project", "pipeline", nil)
+
+			c.onChange(tt.obj)
+			_, _ = c.ListPipelineRuns("project", "pipeline", nil)
+			if tt.invalidated {
+				assert.Equal(t, 2, next.queries)
+			} else {
+				assert.Equal(t, 1, next.queries)
+			}
+		})
+	}
+}
+
+func TestInvalidateOnChange(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+	informers := &informertest.FakeInformers{Scheme: schema}
+
+	next := &countingClient{}
+	c := NewClient(next, cache.NewSimpleCache(), time.Minute)
+	assert.Nil(t, c.InvalidateOnChange(context.TODO(), informers))
+	informer, err := informers.FakeInformerFor(&v1alpha3.PipelineRun{})
+	assert.Nil(t, err)
+
+	pipelineRun := &v1alpha3.PipelineRun{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "project", Name: "pipeline-x", ResourceVersion: "1",
+		Labels: map[string]string{v1alpha3.PipelineNameLabelKey: "pipeline"}}}
+	_, _ = c.ListPipelineRuns("project", "pipeline", nil)
+
+	// resync
+	informer.Update(pipelineRun, pipelineRun.DeepCopy())
+	_, _ = c.ListPipelineRuns("project", "pipeline", nil)
+	assert.Equal(t, 1, next.queries)
+
+	// the status was updated by the controller
+	updated := pipelineRun.DeepCopy()
+	updated.ResourceVersion = "2"
+	informer.Update(pipelineRun, updated)
+	_, _ = c.ListPipelineRuns("project", "pipeline", nil)
+	assert.Equal(t, 2, next.queries)
+}
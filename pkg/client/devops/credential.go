@@ -68,4 +68,6 @@ type CredentialOperator interface {
 	GetCredentialInProject(projectId, id string) (*Credential, error)
 
 	DeleteCredentialInProject(projectId, id string) (string, error)
+
+	ListCredentialsInProject(projectId string) ([]Credential, error)
 }
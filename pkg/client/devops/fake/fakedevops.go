@@ -22,6 +22,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 
 	"github.com/emicklei/go-restful"
@@ -430,6 +431,16 @@ func (d *Devops) DeleteCredentialInProject(projectId, id string) (string, error)
 	delete(d.Credentials[projectId], id)
 	return "", nil
 }
+func (d *Devops) ListCredentialsInProject(projectId string) ([]devops.Credential, error) {
+	credentials := make([]devops.Credential, 0, len(d.Credentials[projectId]))
+	for id := range d.Credentials[projectId] {
+		credentials = append(credentials, devops.Credential{Id: id})
+	}
+	sort.Slice(credentials, func(i, j int) bool {
+		return credentials[i].Id < credentials[j].Id
+	})
+	return credentials, nil
+}
 
 // BuildGetter
 func (d *Devops) GetProjectPipelineBuildByType(projectId, pipelineId string, status string) (*devops.Build, error) {
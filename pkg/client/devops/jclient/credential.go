@@ -58,6 +58,11 @@ func (j *JenkinsClient) DeleteCredentialInProject(projectID, id string) (string,
 	return id, client.DeleteInFolder(projectID, id)
 }
 
+// ListCredentialsInProject returns all the credentials of a project
+func (j *JenkinsClient) ListCredentialsInProject(projectID string) ([]devops.Credential, error) {
+	return j.jenkins.ListCredentialsInProject(projectID)
+}
+
 func (j *JenkinsClient) getClient() *jcredential.CredentialsManager {
 	return &jcredential.CredentialsManager{JenkinsCore: j.Core}
 }
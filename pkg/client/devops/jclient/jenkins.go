@@ -17,6 +17,9 @@ limitations under the License.
 package jclient
 
 import (
+	"net/http"
+	"time"
+
 	"github.com/jenkins-zh/jenkins-client/pkg/casc"
 	"github.com/jenkins-zh/jenkins-client/pkg/core"
 	"kubesphere.io/devops/pkg/client/devops"
@@ -49,11 +52,27 @@ func NewJenkinsClient(options *jenkins.Options) (*JenkinsClient, error) {
 		URL:      options.Host,
 		UserName: options.Username,
 		Token:    options.Password,
+		// the timeout of the core client is in seconds
+		Timeout: options.RequestTimeout / time.Second,
 	}
 
-	devopsClient, _ := jenkins.NewDevopsClient(options) // For refactor purpose only
-	return &JenkinsClient{
+	devopsClient, err := jenkins.NewDevopsClient(options) // For refactor purpose only
+	if err != nil {
+		return nil, err
+	}
+	client := &JenkinsClient{
 		Core:    jenkinsCore,
 		jenkins: devopsClient, // For refactor purpose only
-	}, nil
+	}
+	// both of the clients share the pooled connections
+	client.SetRoundTripper(devopsClient.Requester.Client.Transport)
+	return client, nil
+}
+
+// SetRoundTripper sets the round tripper of all the requests sent to Jenkins
+func (j *JenkinsClient) SetRoundTripper(roundTripper http.RoundTripper) {
+	j.Core.RoundTripper = roundTripper
+	if j.jenkins != nil && j.jenkins.Requester != nil && j.jenkins.Requester.Client != nil {
+		j.jenkins.Requester.Client.Transport = roundTripper
+	}
 }
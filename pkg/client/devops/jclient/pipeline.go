@@ -25,6 +25,7 @@ import (
 	"github.com/jenkins-zh/jenkins-client/pkg/artifact"
 
 	"kubesphere.io/devops/pkg/client/devops"
+	"kubesphere.io/devops/pkg/client/devops/jenkins"
 )
 
 // GetPipeline returns a pipeline
@@ -233,3 +234,8 @@ func (j *JenkinsClient) CheckCron(projectName string, httpParameters *devops.Htt
 func (j *JenkinsClient) CheckPipelineName(projectName, pipelineName string, httpParameters *devops.HttpParameters) (map[string]interface{}, error) {
 	return j.jenkins.CheckPipelineName(projectName, pipelineName, httpParameters)
 }
+
+// ValidateJenkinsfile validates the syntax of a declarative Jenkinsfile
+func (j *JenkinsClient) ValidateJenkinsfile(jenkinsfile string) (*jenkins.ValidateJenkinsfileResponse, error) {
+	return j.jenkins.ValidateJenkinsfile(jenkinsfile)
+}
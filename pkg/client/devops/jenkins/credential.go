@@ -44,6 +44,29 @@ func (j *Jenkins) GetCredentialInProject(projectId, id string) (*devops.Credenti
 	return responseStruct, nil
 }
 
+// ListCredentialsInProject returns all the credentials in the folder of a project
+func (j *Jenkins) ListCredentialsInProject(projectId string) ([]devops.Credential, error) {
+	responseStruct := &struct {
+		Credentials []devops.Credential `json:"credentials"`
+	}{}
+
+	response, err := j.Requester.GetJSON(
+		fmt.Sprintf("/job/%s/credentials/store/folder/domain/_/api/json", projectId),
+		responseStruct, map[string]string{
+			"depth": "1",
+		})
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, errors.New(strconv.Itoa(response.StatusCode))
+	}
+	for i := range responseStruct.Credentials {
+		responseStruct.Credentials[i].Domain = "_"
+	}
+	return responseStruct.Credentials, nil
+}
+
 func (j *Jenkins) CreateCredentialInProject(projectId string, credential *v1.Secret) (string, error) {
 	// use pkg/client/devops/jclient/credential.go instead
 	panic(nil)
@@ -15,11 +15,20 @@ package jenkins
 
 import (
 	"net/http"
+	"net/http/cookiejar"
 )
 
 func NewDevopsClient(options *Options) (*Jenkins, error) {
+	// Jenkins binds the crumb to the web session, so keep the cookies for reusing the crumb
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
 	// we have to create http client with no redirection
 	client := &http.Client{
+		Transport: options.NewTransport(),
+		Timeout:   options.RequestTimeout,
+		Jar:       jar,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		},
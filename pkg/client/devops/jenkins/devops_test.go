@@ -18,6 +18,8 @@ package jenkins
 
 import (
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func Test_parseCronJobTime(t *testing.T) {
@@ -69,3 +71,16 @@ func Test_parseCronJobTime(t *testing.T) {
 		}
 	}
 }
+
+func TestNewTransport(t *testing.T) {
+	options := NewJenkinsOptions()
+	transport := options.NewTransport()
+	assert.Equal(t, options.MaxConnections, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, options.MaxConnections, transport.MaxConnsPerHost)
+	assert.Equal(t, options.IdleConnTimeout, transport.IdleConnTimeout)
+
+	jenkins, err := NewDevopsClient(options)
+	assert.Nil(t, err)
+	assert.Equal(t, options.RequestTimeout, jenkins.Requester.Client.Timeout)
+	assert.NotNil(t, jenkins.Requester.Client.Jar)
+}
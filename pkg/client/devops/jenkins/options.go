@@ -18,10 +18,13 @@ package jenkins
 
 import (
 	"fmt"
-	"kubesphere.io/devops/pkg/utils/reflectutils"
+	"net/http"
 	"time"
 
 	"github.com/spf13/pflag"
+
+	"kubesphere.io/devops/pkg/client/devops/breaker"
+	"kubesphere.io/devops/pkg/utils/reflectutils"
 )
 
 const DefaultAdminPassword = "119d76305a05e7a2a65d096f71feb77921"
@@ -35,6 +38,22 @@ type Options struct {
 	WorkerNamespace string        `json:"workerNamespace,omitempty" yaml:"workerNamespace"`
 	ReloadCasCDelay time.Duration `json:"reloadCasCDelay,omitempty" yaml:"reloadCasCDelay"`
 	SkipVerify      bool
+	// StandbyHost is the address of the standby Jenkins, the pipelines and the credentials could be switched to it
+	StandbyHost     string `json:"standbyHost,omitempty" yaml:"standbyHost"`
+	StandbyUsername string `json:"standbyUsername,omitempty" yaml:"standbyUsername"`
+	StandbyPassword string `json:"standbyPassword,omitempty" yaml:"standbyPassword"`
+	// RequestTimeout is the timeout of each request sent to Jenkins, zero means no timeout
+	RequestTimeout time.Duration `json:"requestTimeout,omitempty" yaml:"requestTimeout"`
+	// IdleConnTimeout is the duration of keeping an idle connection to Jenkins in the pool
+	IdleConnTimeout time.Duration `json:"idleConnTimeout,omitempty" yaml:"idleConnTimeout"`
+	// CacheTTL is the duration of caching the queries of the pipelines and the runs in the apiserver, zero disables it
+	CacheTTL time.Duration `json:"cacheTTL,omitempty" yaml:"cacheTTL"`
+	// BreakerThreshold is the number of the consecutive failures to consider Jenkins unavailable, zero disables it
+	BreakerThreshold int `json:"breakerThreshold,omitempty" yaml:"breakerThreshold"`
+	// BreakerCooldown is the initial duration of rejecting the requests once Jenkins is unavailable
+	BreakerCooldown time.Duration `json:"breakerCooldown,omitempty" yaml:"breakerCooldown"`
+	// BreakerMaxCooldown is the maximum duration of rejecting the requests once Jenkins is unavailable
+	BreakerMaxCooldown time.Duration `json:"breakerMaxCooldown,omitempty" yaml:"breakerMaxCooldown"`
 }
 
 // NewJenkinsOptions returns a `zero` instance
@@ -50,7 +69,35 @@ func NewJenkinsOptions() *Options {
 		// ConfigMap, so we use 70s as the default value of ReloadCasCDelay. Please see also:
 		// https://kubernetes.io/docs/reference/config-api/kubelet-config.v1beta1/#kubelet-config-k8s-io-v1beta1-KubeletConfiguration
 		ReloadCasCDelay: 70 * time.Second,
+		RequestTimeout:  30 * time.Second,
+		IdleConnTimeout: 90 * time.Second,
+		CacheTTL:        10 * time.Second,
+
+		BreakerThreshold:   5,
+		BreakerCooldown:    10 * time.Second,
+		BreakerMaxCooldown: 5 * time.Minute,
+	}
+}
+
+// NewBreaker creates the circuit breaker of Jenkins, it returns nil if the breaker is disabled
+func (s *Options) NewBreaker() *breaker.Breaker {
+	if s.BreakerThreshold <= 0 {
+		return nil
 	}
+	return breaker.New(s.BreakerThreshold, s.BreakerCooldown, s.BreakerMaxCooldown)
+}
+
+// NewTransport creates the transport which keeps at most MaxConnections alive connections to Jenkins,
+// all the clients of Jenkins should share it instead of dialing Jenkins for each request
+func (s *Options) NewTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = s.MaxConnections
+	transport.MaxIdleConnsPerHost = s.MaxConnections
+	transport.MaxConnsPerHost = s.MaxConnections
+	if s.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = s.IdleConnTimeout
+	}
+	return transport
 }
 
 // ApplyTo apply configuration to another options
@@ -77,6 +124,10 @@ func (s *Options) Validate() []error {
 		errors = append(errors, fmt.Errorf("the token of the Jenkins needs to update"))
 	}
 
+	if s.StandbyHost != "" && (s.StandbyUsername == "" || s.StandbyPassword == "") {
+		errors = append(errors, fmt.Errorf("the username or password of the standby Jenkins is empty"))
+	}
+
 	if s.MaxConnections <= 0 {
 		errors = append(errors, fmt.Errorf("jenkins's maximum connections should be greater than 0"))
 	}
@@ -95,6 +146,13 @@ func (s *Options) AddFlags(fs *pflag.FlagSet, c *Options) {
 	fs.StringVar(&s.Password, "jenkins-password", c.Password, ""+
 		"Password for access to Jenkins service, used pair with username.")
 
+	fs.StringVar(&s.StandbyHost, "jenkins-standby-host", c.StandbyHost,
+		"The address of the standby Jenkins which the pipelines and the credentials could be switched to.")
+	fs.StringVar(&s.StandbyUsername, "jenkins-standby-username", c.StandbyUsername,
+		"Username for access to the standby Jenkins.")
+	fs.StringVar(&s.StandbyPassword, "jenkins-standby-password", c.StandbyPassword,
+		"Password for access to the standby Jenkins, used pair with the standby username.")
+
 	fs.IntVar(&s.MaxConnections, "jenkins-max-connections", c.MaxConnections, ""+
 		"Maximum allowed connections to Jenkins. ")
 	fs.BoolVar(&s.SkipVerify, "jenkins-skip-verify", false,
@@ -105,4 +163,16 @@ func (s *Options) AddFlags(fs *pflag.FlagSet, c *Options) {
 	fs.DurationVar(&s.ReloadCasCDelay, "reload-casc-delay", c.ReloadCasCDelay,
 		"ReloadCasCDelay specifies the total duration that controller should delay the reload action for "+
 			"jenkins-casc-config ConfigMap change, and it is only valid for controller manager.")
+	fs.IntVar(&s.BreakerThreshold, "jenkins-breaker-threshold", c.BreakerThreshold,
+		"The number of the consecutive failures to consider Jenkins unavailable, zero disables the circuit breaker")
+	fs.DurationVar(&s.BreakerCooldown, "jenkins-breaker-cooldown", c.BreakerCooldown,
+		"The initial duration of rejecting the requests once Jenkins is unavailable, it doubles after each failed probe")
+	fs.DurationVar(&s.BreakerMaxCooldown, "jenkins-breaker-max-cooldown", c.BreakerMaxCooldown,
+		"The maximum duration of rejecting the requests once Jenkins is unavailable")
+	fs.DurationVar(&s.RequestTimeout, "jenkins-request-timeout", c.RequestTimeout,
+		"The timeout of each request sent to Jenkins, zero means no timeout")
+	fs.DurationVar(&s.IdleConnTimeout, "jenkins-idle-conn-timeout", c.IdleConnTimeout,
+		"The duration of keeping an idle connection to Jenkins before closing it")
+	fs.DurationVar(&s.CacheTTL, "jenkins-cache-ttl", c.CacheTTL,
+		"The duration of caching the queries of the pipelines and the runs in the apiserver, zero disables the cache")
 }
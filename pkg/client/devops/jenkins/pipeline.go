@@ -787,7 +787,7 @@ func (p *Pipeline) CheckCron() (*devops.CheckCronRes, error) {
 		reqJenkins.URL = cronServiceURL
 	}
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := p.Jenkins.Requester.redirectableClient()
 	reqJenkins.SetBasicAuth(p.Jenkins.Requester.BasicAuth.Username, p.Jenkins.Requester.BasicAuth.Password)
 	resp, err := client.Do(reqJenkins)
 	if err != nil {
@@ -28,6 +28,24 @@ import (
 	"kubesphere.io/devops/pkg/client/devops"
 )
 
+// GeneratePipelineConfig generates the config.xml of the Jenkins job of a Pipeline without creating the job
+func GeneratePipelineConfig(projectID string, pipeline *devopsv1alpha3.Pipeline) (string, error) {
+	switch pipeline.Spec.Type {
+	case devopsv1alpha3.NoScmPipelineType:
+		if pipeline.Spec.Pipeline == nil {
+			return "", fmt.Errorf("the pipeline of the type %s is required", pipeline.Spec.Type)
+		}
+		return createPipelineConfigXml(pipeline.Spec.Pipeline)
+	case devopsv1alpha3.MultiBranchPipelineType:
+		if pipeline.Spec.MultiBranchPipeline == nil {
+			return "", fmt.Errorf("the multi_branch_pipeline of the type %s is required", pipeline.Spec.Type)
+		}
+		return createMultiBranchPipelineConfigXml(projectID, pipeline.Spec.MultiBranchPipeline)
+	default:
+		return "", fmt.Errorf("unsupported pipeline type %q", pipeline.Spec.Type)
+	}
+}
+
 func (j *Jenkins) CreateProjectPipeline(projectId string, pipeline *devopsv1alpha3.Pipeline) (string, error) {
 	switch pipeline.Spec.Type {
 	case devopsv1alpha3.NoScmPipelineType:
@@ -143,7 +161,12 @@ func (j *Jenkins) UpdateProjectPipeline(projectId string, pipeline *devopsv1alph
 }
 
 func (j *Jenkins) GetProjectPipelineConfig(projectId, pipelineId string) (*devopsv1alpha3.Pipeline, error) {
-	job, err := j.GetJob(pipelineId, projectId)
+	var parentIDs []string
+	// the job is not in any folder if the project is empty
+	if projectId != "" {
+		parentIDs = append(parentIDs, projectId)
+	}
+	job, err := j.GetJob(pipelineId, parentIDs...)
 	if err != nil {
 		klog.Errorf("%+v", err)
 		return nil, restful.NewError(devops.GetDevOpsStatusCode(err), err.Error())
@@ -183,6 +206,7 @@ func (j *Jenkins) GetProjectPipelineConfig(projectId, pipelineId string) (*devop
 			},
 		}, nil
 	default:
+		err = fmt.Errorf("unsupported job class %q", job.Raw.Class)
 		klog.Errorf("%+v", err)
 		return nil, restful.NewError(http.StatusBadRequest, err.Error())
 	}
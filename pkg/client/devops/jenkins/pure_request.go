@@ -21,7 +21,6 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
-	"time"
 
 	"k8s.io/klog/v2"
 
@@ -45,7 +44,7 @@ func (j *Jenkins) SendPureRequestWithHeaderResp(path string, httpParameters *dev
 	}
 
 	apiURL.RawQuery = httpParameters.Url.RawQuery
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := j.Requester.redirectableClient()
 
 	header := httpParameters.Header.Clone()
 	if header == nil {
@@ -90,6 +89,10 @@ func (j *Jenkins) SendPureRequestWithHeaderResp(path string, httpParameters *dev
 
 	if resp.StatusCode >= http.StatusBadRequest {
 		klog.Errorf("%+v", string(resBody))
+		if j.Requester != nil && isInvalidCrumb(&devops.ErrorResponse{Response: resp, Message: string(resBody)}) {
+			// ask Jenkins for a new crumb in the next request
+			j.Requester.InvalidateCrumb()
+		}
 		jkerr := new(JkError)
 		jkerr.Code = resp.StatusCode
 		jkerr.Message = string(resBody)
@@ -26,6 +26,8 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
+	"time"
 
 	//"github.com/dgrijalva/jwt-go"
 
@@ -33,6 +35,9 @@ import (
 	"kubesphere.io/devops/pkg/client/devops"
 )
 
+// defaultRequestTimeout is the timeout of the requests which are not limited by the client of the requester
+const defaultRequestTimeout = 30 * time.Second
+
 // Request Methods
 
 type APIRequest struct {
@@ -62,6 +67,12 @@ type Requester struct {
 	CACert      []byte
 	SslVerify   bool
 	connControl chan struct{}
+
+	// crumbLock guards the crumb which is issued once and reused until Jenkins rejects it
+	crumbLock         sync.Mutex
+	crumbIssued       bool
+	crumbRequestField string
+	crumb             string
 }
 
 func (r *Requester) SetCrumb(ar *APIRequest) error {
@@ -72,69 +83,115 @@ func (r *Requester) SetCrumb(ar *APIRequest) error {
 
 // SetCrumbForConsumer makes crumb consumer set the crumb. Crumb consumer accepts crumb request field and crumb
 // parameters and can handle the crumb whatever it likes.
+// The crumb is cached until InvalidateCrumb is called, so that it's not necessary to ask Jenkins for each request.
 func (r *Requester) SetCrumbForConsumer(crumbConsumer func(crumbRequestField, crumb string)) error {
-	crumbData := map[string]string{}
-	response, err := r.GetJSON("/crumbIssuer/api/json", &crumbData, nil)
-	if err != nil {
-		jenkinsError, ok := err.(*devops.ErrorResponse)
-		if ok && jenkinsError.Response.StatusCode == http.StatusNotFound {
-			return nil
+	r.crumbLock.Lock()
+	defer r.crumbLock.Unlock()
+
+	if !r.crumbIssued {
+		crumbData := map[string]string{}
+		response, err := r.GetJSON("/crumbIssuer/api/json", &crumbData, nil)
+		if err != nil {
+			jenkinsError, ok := err.(*devops.ErrorResponse)
+			if !ok || jenkinsError.Response.StatusCode != http.StatusNotFound {
+				return err
+			}
+			// the CSRF protection is disabled
+			crumbData = map[string]string{}
+		} else if response.StatusCode != http.StatusOK {
+			crumbData = map[string]string{}
 		}
-		return err
-	}
-	if response.StatusCode == 200 && crumbData["crumbRequestField"] != "" {
-		crumbConsumer(crumbData["crumbRequestField"], crumbData["crumb"])
+		r.crumbIssued = true
+		r.crumbRequestField, r.crumb = crumbData["crumbRequestField"], crumbData["crumb"]
 	}
 
+	if r.crumbRequestField != "" {
+		crumbConsumer(r.crumbRequestField, r.crumb)
+	}
 	return nil
 }
 
+// InvalidateCrumb drops the cached crumb, the next request will ask Jenkins for a new one
+func (r *Requester) InvalidateCrumb() {
+	r.crumbLock.Lock()
+	defer r.crumbLock.Unlock()
+	r.crumbIssued = false
+	r.crumbRequestField, r.crumb = "", ""
+}
+
+// isInvalidCrumb checks if Jenkins rejected the request due to an expired or missing crumb
+func isInvalidCrumb(err error) bool {
+	jenkinsError, ok := err.(*devops.ErrorResponse)
+	return ok && jenkinsError.Response != nil && jenkinsError.Response.StatusCode == http.StatusForbidden &&
+		strings.Contains(jenkinsError.Message, "No valid crumb")
+}
+
+// doWithCrumb sends the request with the crumb, it refreshes the crumb and sends the request again once
+// Jenkins rejects the cached crumb
+func (r *Requester) doWithCrumb(ar *APIRequest, send func() (*http.Response, error)) (response *http.Response, err error) {
+	var payload []byte
+	if ar.Payload != nil {
+		if payload, err = ioutil.ReadAll(ar.Payload); err != nil {
+			return
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		if payload != nil {
+			ar.Payload = bytes.NewReader(payload)
+		}
+		if err = r.SetCrumb(ar); err != nil {
+			return
+		}
+		if response, err = send(); !isInvalidCrumb(err) {
+			break
+		}
+		r.InvalidateCrumb()
+	}
+	return
+}
+
 func (r *Requester) PostJSON(endpoint string, payload io.Reader, responseStruct interface{}, querystring map[string]string) (*http.Response, error) {
 	ar := NewAPIRequest("POST", endpoint, payload)
-	if err := r.SetCrumb(ar); err != nil {
-		return nil, err
-	}
 	ar.SetHeader("Content-Type", "application/x-www-form-urlencoded")
 	ar.Suffix = "api/json"
-	return r.Do(ar, responseStruct, querystring)
+	return r.doWithCrumb(ar, func() (*http.Response, error) {
+		return r.Do(ar, responseStruct, querystring)
+	})
 }
 
 func (r *Requester) Post(endpoint string, payload io.Reader, responseStruct interface{}, querystring map[string]string) (*http.Response, error) {
 	ar := NewAPIRequest("POST", endpoint, payload)
-	if err := r.SetCrumb(ar); err != nil {
-		return nil, err
-	}
 	ar.SetHeader("Content-Type", "application/x-www-form-urlencoded")
 	ar.Suffix = ""
-	return r.Do(ar, responseStruct, querystring)
+	return r.doWithCrumb(ar, func() (*http.Response, error) {
+		return r.Do(ar, responseStruct, querystring)
+	})
 }
 func (r *Requester) PostForm(endpoint string, payload io.Reader, responseStruct interface{}, formString map[string]string) (*http.Response, error) {
 	ar := NewAPIRequest("POST", endpoint, payload)
-	if err := r.SetCrumb(ar); err != nil {
-		return nil, err
-	}
 	ar.SetHeader("Content-Type", "application/x-www-form-urlencoded")
 	ar.Suffix = ""
-	return r.DoPostForm(ar, responseStruct, formString)
+	return r.doWithCrumb(ar, func() (*http.Response, error) {
+		return r.DoPostForm(ar, responseStruct, formString)
+	})
 }
 
 func (r *Requester) PostFiles(endpoint string, payload io.Reader, responseStruct interface{}, querystring map[string]string, files []string) (*http.Response, error) {
 	ar := NewAPIRequest("POST", endpoint, payload)
-	if err := r.SetCrumb(ar); err != nil {
-		return nil, err
-	}
-	return r.Do(ar, responseStruct, querystring, files)
+	return r.doWithCrumb(ar, func() (*http.Response, error) {
+		return r.Do(ar, responseStruct, querystring, files)
+	})
 }
 
 func (r *Requester) PostXML(endpoint string, xml string, responseStruct interface{}, querystring map[string]string) (*http.Response, error) {
 	payload := bytes.NewBuffer([]byte(xml))
 	ar := NewAPIRequest("POST", endpoint, payload)
-	if err := r.SetCrumb(ar); err != nil {
-		return nil, err
-	}
 	ar.SetHeader("Content-Type", "application/xml;charset=utf-8")
 	ar.Suffix = ""
-	return r.Do(ar, responseStruct, querystring)
+	return r.doWithCrumb(ar, func() (*http.Response, error) {
+		return r.Do(ar, responseStruct, querystring)
+	})
 }
 
 func (r *Requester) GetJSON(endpoint string, responseStruct interface{}, query map[string]string) (*http.Response, error) {
@@ -168,6 +225,20 @@ func (r *Requester) SetClient(client *http.Client) *Requester {
 	return r
 }
 
+// redirectableClient returns a client which shares the pooled connections and the cookies of the requester,
+// but follows the redirections
+func (r *Requester) redirectableClient() *http.Client {
+	client := &http.Client{}
+	if r != nil && r.Client != nil {
+		*client = *r.Client
+		client.CheckRedirect = nil
+	}
+	if client.Timeout == 0 {
+		client.Timeout = defaultRequestTimeout
+	}
+	return client
+}
+
 // Add auth on redirect if required.
 func (r *Requester) redirectPolicyFunc(req *http.Request, via []*http.Request) error {
 	if r.BasicAuth != nil {
@@ -235,7 +306,6 @@ func (r *Requester) DoGet(ar *APIRequest, responseStruct interface{}, options ..
 	if r.BasicAuth != nil {
 		req.SetBasicAuth(r.BasicAuth.Username, r.BasicAuth.Password)
 	}
-	req.Close = true
 	req.Header.Add("Accept", "*/*")
 	for k := range ar.Headers {
 		req.Header.Add(k, ar.Headers.Get(k))
@@ -248,6 +318,7 @@ func (r *Requester) DoGet(ar *APIRequest, responseStruct interface{}, options ..
 		<-r.connControl
 		errorText := response.Header.Get("X-Error")
 		if errorText != "" {
+			drainBody(response)
 			return nil, errors.New(errorText)
 		}
 		err := CheckResponse(response)
@@ -329,7 +400,6 @@ func (r *Requester) Do(ar *APIRequest, responseStruct interface{}, options ...in
 	if r.BasicAuth != nil {
 		req.SetBasicAuth(r.BasicAuth.Username, r.BasicAuth.Password)
 	}
-	req.Close = true
 	req.Header.Add("Accept", "*/*")
 	for k := range ar.Headers {
 		req.Header.Add(k, ar.Headers.Get(k))
@@ -342,6 +412,7 @@ func (r *Requester) Do(ar *APIRequest, responseStruct interface{}, options ...in
 		<-r.connControl
 		errorText := response.Header.Get("X-Error")
 		if errorText != "" {
+			drainBody(response)
 			return nil, errors.New(errorText)
 		}
 		err := CheckResponse(response)
@@ -377,7 +448,6 @@ func (r *Requester) DoPostForm(ar *APIRequest, responseStruct interface{}, form
 	if r.BasicAuth != nil {
 		req.SetBasicAuth(r.BasicAuth.Username, r.BasicAuth.Password)
 	}
-	req.Close = true
 	req.Header.Add("Accept", "*/*")
 	for k := range ar.Headers {
 		req.Header.Add(k, ar.Headers.Get(k))
@@ -390,6 +460,7 @@ func (r *Requester) DoPostForm(ar *APIRequest, responseStruct interface{}, form
 		<-r.connControl
 		errorText := response.Header.Get("X-Error")
 		if errorText != "" {
+			drainBody(response)
 			return nil, errors.New(errorText)
 		}
 		err := CheckResponse(response)
@@ -423,7 +494,8 @@ func (r *Requester) ReadRawResponse(response *http.Response, responseStruct inte
 }
 
 func (r *Requester) ReadJSONResponse(response *http.Response, responseStruct interface{}) (*http.Response, error) {
-	defer response.Body.Close()
+	// the decoder might not read the whole body, drain it for reusing the connection
+	defer drainBody(response)
 	err := json.NewDecoder(response.Body).Decode(responseStruct)
 	if err != nil && err.Error() == "EOF" {
 		return response, nil
@@ -431,6 +503,12 @@ func (r *Requester) ReadJSONResponse(response *http.Response, responseStruct int
 	return response, nil
 }
 
+// drainBody reads the rest of the response body and closes it, so that the connection goes back to the pool
+func drainBody(response *http.Response) {
+	_, _ = io.Copy(ioutil.Discard, response.Body)
+	_ = response.Body.Close()
+}
+
 func CheckResponse(r *http.Response) error {
 
 	switch r.StatusCode {
@@ -17,10 +17,20 @@ limitations under the License.
 package jenkins
 
 import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"kubesphere.io/devops/pkg/client/devops"
 )
 
 func newFakeRequester() *Requester {
@@ -52,3 +62,115 @@ func TestRequesterDoGet(t *testing.T) {
 	_, err := requester.DoGet(newFakeAPIRequest(), nil, fileNames)
 	assert.NotNil(t, err)
 }
+
+func newCrumbServer(crumb *string, crumbIssued *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/crumbIssuer/"):
+			*crumbIssued++
+			_, _ = w.Write([]byte(fmt.Sprintf(`{"crumbRequestField":"Jenkins-Crumb","crumb":"%s"}`, *crumb)))
+		default:
+			if r.Header.Get("Jenkins-Crumb") != *crumb {
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte("No valid crumb was included in the request"))
+				return
+			}
+			body, _ := ioutil.ReadAll(r.Body)
+			_, _ = w.Write(body)
+		}
+	}))
+}
+
+func TestRequesterCrumb(t *testing.T) {
+	crumb, crumbIssued := "a", 0
+	server := newCrumbServer(&crumb, &crumbIssued)
+	defer server.Close()
+
+	jenkins, err := NewDevopsClient(&Options{Host: server.URL, MaxConnections: 2})
+	assert.Nil(t, err)
+	requester := jenkins.Requester
+
+	var result string
+	_, err = requester.Post("/job/fake/build", strings.NewReader("payload"), &result, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "payload", result)
+	_, err = requester.Post("/job/fake/build", strings.NewReader("payload"), &result, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, crumbIssued, "the crumb should be reused")
+
+	// the crumb expired, the request should be sent again with a new crumb
+	crumb = "b"
+	result = ""
+	_, err = requester.Post("/job/fake/build", strings.NewReader("payload"), &result, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "payload", result)
+	assert.Equal(t, 2, crumbIssued)
+}
+
+func TestRequesterCrumbNotFound(t *testing.T) {
+	issued := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/crumbIssuer/") {
+			issued++
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	jenkins, err := NewDevopsClient(&Options{Host: server.URL, MaxConnections: 2})
+	assert.Nil(t, err)
+	for i := 0; i < 2; i++ {
+		consumed := false
+		assert.Nil(t, jenkins.Requester.SetCrumbForConsumer(func(string, string) {
+			consumed = true
+		}))
+		assert.False(t, consumed)
+	}
+	assert.Equal(t, 1, issued, "the CSRF protection is disabled, no need to ask for the crumb again")
+}
+
+func TestIsInvalidCrumb(t *testing.T) {
+	assert.False(t, isInvalidCrumb(nil))
+	assert.False(t, isInvalidCrumb(errors.New("fake")))
+	assert.False(t, isInvalidCrumb(&devops.ErrorResponse{Response: &http.Response{StatusCode: http.StatusForbidden}}))
+	assert.True(t, isInvalidCrumb(&devops.ErrorResponse{Response: &http.Response{StatusCode: http.StatusForbidden},
+		Message: "No valid crumb was included in the request"}))
+}
+
+func TestRequesterKeepAlive(t *testing.T) {
+	var connections int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"name":"fake"} and the rest`))
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&connections, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	jenkins, err := NewDevopsClient(&Options{Host: server.URL, MaxConnections: 2})
+	assert.Nil(t, err)
+	for i := 0; i < 3; i++ {
+		result := map[string]string{}
+		_, err = jenkins.Requester.GetJSON("/job/fake", &result, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, "fake", result["name"])
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&connections), "the connection should be reused")
+}
+
+func TestRedirectableClient(t *testing.T) {
+	var requester *Requester
+	assert.Equal(t, defaultRequestTimeout, requester.redirectableClient().Timeout)
+
+	jenkins, err := NewDevopsClient(&Options{Host: "http://localhost", MaxConnections: 2, RequestTimeout: time.Minute})
+	assert.Nil(t, err)
+	client := jenkins.Requester.redirectableClient()
+	assert.Nil(t, client.CheckRedirect)
+	assert.Equal(t, time.Minute, client.Timeout)
+	assert.Equal(t, jenkins.Requester.Client.Transport, client.Transport)
+	assert.Equal(t, jenkins.Requester.Client.Jar, client.Jar)
+	assert.NotNil(t, jenkins.Requester.Client.CheckRedirect)
+}
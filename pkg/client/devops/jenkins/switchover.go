@@ -0,0 +1,133 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jenkins
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// SwitchoverConfigMapName is the name of the ConfigMap which keeps the state of switching between
+// the primary and the standby Jenkins, it's in the namespace of Jenkins
+const SwitchoverConfigMapName = "jenkins-switchover"
+
+// Instance is one of the Jenkins instances
+type Instance string
+
+const (
+	// Primary is the Jenkins configured by the host, the username and the password
+	Primary Instance = "primary"
+	// Standby is the Jenkins configured by the standby host, the standby username and the standby password
+	Standby Instance = "standby"
+)
+
+// SwitchoverPhase is the phase of switching to another Jenkins instance
+type SwitchoverPhase string
+
+const (
+	// SwitchoverPending means the switchover was requested but not started yet
+	SwitchoverPending SwitchoverPhase = "Pending"
+	// SwitchoverSyncing means the pipelines and the credentials are being synchronized to the target instance
+	SwitchoverSyncing SwitchoverPhase = "Syncing"
+	// SwitchoverVerifying means checking if the target instance has all the pipelines and the credentials
+	SwitchoverVerifying SwitchoverPhase = "Verifying"
+	// SwitchoverSucceeded means the target instance became the active one
+	SwitchoverSucceeded SwitchoverPhase = "Succeeded"
+	// SwitchoverFailed means the target instance is not in parity, the active instance was kept
+	SwitchoverFailed SwitchoverPhase = "Failed"
+)
+
+// SwitchoverState is the state of switching between the Jenkins instances
+type SwitchoverState struct {
+	// Active is the instance in use, it's the primary one by default
+	Active Instance `json:"active"`
+	// Target is the instance to switch to
+	Target  Instance        `json:"target,omitempty"`
+	Phase   SwitchoverPhase `json:"phase,omitempty"`
+	Message string          `json:"message,omitempty"`
+}
+
+// InProgress returns true if the switchover is not completed yet
+func (s *SwitchoverState) InProgress() bool {
+	switch s.Phase {
+	case SwitchoverPending, SwitchoverSyncing, SwitchoverVerifying:
+		return true
+	}
+	return false
+}
+
+// ParseSwitchoverState reads the state from the ConfigMap, a nil ConfigMap means the primary instance is active
+func ParseSwitchoverState(cm *v1.ConfigMap) *SwitchoverState {
+	state := &SwitchoverState{Active: Primary}
+	if cm == nil {
+		return state
+	}
+	if active := Instance(cm.Data["active"]); active == Standby {
+		state.Active = active
+	}
+	state.Target = Instance(cm.Data["target"])
+	state.Phase = SwitchoverPhase(cm.Data["phase"])
+	state.Message = cm.Data["message"]
+	return state
+}
+
+// ApplyTo writes the state into the ConfigMap
+func (s *SwitchoverState) ApplyTo(cm *v1.ConfigMap) {
+	cm.Data = map[string]string{
+		"active":  string(s.Active),
+		"target":  string(s.Target),
+		"phase":   string(s.Phase),
+		"message": s.Message,
+	}
+}
+
+// ForInstance returns the options of the given instance, nil means the standby instance is not configured
+func (s *Options) ForInstance(instance Instance) *Options {
+	if instance != Standby {
+		return s
+	}
+	if s.StandbyHost == "" {
+		return nil
+	}
+	options := *s
+	options.Host, options.Username, options.Password = s.StandbyHost, s.StandbyUsername, s.StandbyPassword
+	return &options
+}
+
+// ResolveActive returns the options of the active instance according to the switchover state,
+// it falls back to the primary instance if the state is not available
+func (s *Options) ResolveActive(ctx context.Context, client v1core.ConfigMapsGetter) *Options {
+	if s.StandbyHost == "" || client == nil {
+		return s
+	}
+	cm, err := client.ConfigMaps(s.Namespace).Get(ctx, SwitchoverConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.Errorf("failed to get the active Jenkins instance, use the primary one, error: %v", err)
+		}
+		return s
+	}
+	if options := s.ForInstance(ParseSwitchoverState(cm).Active); options != nil {
+		return options
+	}
+	return s
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jenkins
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSwitchoverState(t *testing.T) {
+	assert.Equal(t, &SwitchoverState{Active: Primary}, ParseSwitchoverState(nil))
+	assert.Equal(t, &SwitchoverState{Active: Primary}, ParseSwitchoverState(&v1.ConfigMap{
+		Data: map[string]string{"active": "invalid"}}))
+
+	state := &SwitchoverState{Active: Standby, Target: Primary, Phase: SwitchoverFailed, Message: "failed"}
+	cm := &v1.ConfigMap{}
+	state.ApplyTo(cm)
+	assert.Equal(t, state, ParseSwitchoverState(cm))
+	assert.False(t, state.InProgress())
+
+	for _, phase := range []SwitchoverPhase{SwitchoverPending, SwitchoverSyncing, SwitchoverVerifying} {
+		state.Phase = phase
+		assert.True(t, state.InProgress())
+	}
+}
+
+func TestResolveActive(t *testing.T) {
+	options := &Options{Host: "http://primary", Username: "admin", Password: "primary", Namespace: "ns"}
+	assert.Nil(t, options.ForInstance(Standby))
+	assert.Equal(t, options, options.ForInstance(Primary))
+	assert.Equal(t, options, options.ResolveActive(context.TODO(), k8sfake.NewSimpleClientset().CoreV1()))
+
+	options.StandbyHost, options.StandbyUsername, options.StandbyPassword = "http://standby", "admin", "standby"
+	standby := options.ForInstance(Standby)
+	assert.Equal(t, "http://standby", standby.Host)
+	assert.Equal(t, "standby", standby.Password)
+	assert.Equal(t, "ns", standby.Namespace)
+
+	// the primary one is active without the switchover ConfigMap
+	assert.Equal(t, options, options.ResolveActive(context.TODO(), k8sfake.NewSimpleClientset().CoreV1()))
+
+	cm := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: SwitchoverConfigMapName}}
+	(&SwitchoverState{Active: Standby}).ApplyTo(cm)
+	assert.Equal(t, standby, options.ResolveActive(context.TODO(), k8sfake.NewSimpleClientset(cm).CoreV1()))
+
+	assert.Equal(t, 1, len((&Options{Host: "http://primary", Username: "admin", Password: "primary",
+		MaxConnections: 1, StandbyHost: "http://standby"}).Validate()))
+}
@@ -1116,6 +1116,17 @@ func (i *Input) Approvable(identify string) (ok bool) {
 	return
 }
 
+// ApprovableByGroups checks if one of the groups is a submitter of this input, the groups should come from a
+// verified identity
+func (i *Input) ApprovableByGroups(groups []string) bool {
+	for _, group := range groups {
+		if group != "" && i.Approvable(group) {
+			return true
+		}
+	}
+	return false
+}
+
 type HttpParameters struct {
 	Method   string        `json:"method,omitempty"`
 	Header   http.Header   `json:"header,omitempty"`
@@ -52,6 +52,10 @@ func TestApprovable(t *testing.T) {
 	assert.Equal(t, input.Approvable("fake"), true, "should be approvable")
 	assert.Equal(t, input.Approvable("good"), true, "should be approvable")
 	assert.Equal(t, input.Approvable("bad"), true, "should be approvable")
+
+	assert.Equal(t, input.ApprovableByGroups(nil), false, "should not approve by nobody")
+	assert.Equal(t, input.ApprovableByGroups([]string{"dev", ""}), false, "should not approve by the other groups")
+	assert.Equal(t, input.ApprovableByGroups([]string{"dev", "good"}), true, "should be approvable by a group")
 }
 
 func TestPipelineJsonMarshall(t *testing.T) {
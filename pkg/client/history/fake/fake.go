@@ -0,0 +1,107 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"kubesphere.io/devops/pkg/client/history"
+)
+
+// Store is an in-memory history store for testing
+type Store struct {
+	mutex     sync.Mutex
+	Summaries map[string]history.RunSummary
+	Err       error
+}
+
+// NewStore creates an empty in-memory history store
+func NewStore(summaries ...history.RunSummary) *Store {
+	store := &Store{Summaries: map[string]history.RunSummary{}}
+	for _, summary := range summaries {
+		store.Summaries[summary.UID] = summary
+	}
+	return store
+}
+
+// Save keeps the summary in memory
+func (s *Store) Save(ctx context.Context, summary *history.RunSummary) error {
+	if s.Err != nil {
+		return s.Err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.Summaries[summary.UID] = *summary
+	return nil
+}
+
+// List returns the matched summaries, the latest completed ones come first
+func (s *Store) List(ctx context.Context, filter history.Filter) ([]history.RunSummary, int, error) {
+	if s.Err != nil {
+		return nil, 0, s.Err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	result := make([]history.RunSummary, 0)
+	for _, summary := range s.Summaries {
+		if matches(summary, filter) {
+			result = append(result, summary)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		left, right := result[i].CompletionTime, result[j].CompletionTime
+		if left == nil || right == nil || left.Equal(*right) {
+			return result[i].UID < result[j].UID
+		}
+		return left.After(*right)
+	})
+
+	total := len(result)
+	if filter.Limit > 0 {
+		start, end := filter.Offset, filter.Offset+filter.Limit
+		if start > total {
+			start = total
+		}
+		if end > total {
+			end = total
+		}
+		result = result[start:end]
+	}
+	return result, total, nil
+}
+
+func matches(summary history.RunSummary, filter history.Filter) bool {
+	if (filter.Namespace != "" && filter.Namespace != summary.Namespace) ||
+		(filter.Pipeline != "" && filter.Pipeline != summary.Pipeline) ||
+		(filter.Branch != "" && filter.Branch != summary.Branch) ||
+		(filter.Phase != "" && filter.Phase != summary.Phase) {
+		return false
+	}
+	if summary.CompletionTime == nil {
+		return filter.Since.IsZero() && filter.Until.IsZero()
+	}
+	if !filter.Since.IsZero() && summary.CompletionTime.Before(filter.Since) {
+		return false
+	}
+	if !filter.Until.IsZero() && summary.CompletionTime.After(filter.Until) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+// RunSummary is the summary of a completed PipelineRun which is kept after the PipelineRun is deleted
+type RunSummary struct {
+	UID             string         `json:"uid"`
+	Namespace       string         `json:"namespace"`
+	Pipeline        string         `json:"pipeline"`
+	Name            string         `json:"name"`
+	Branch          string         `json:"branch,omitempty"`
+	Phase           string         `json:"phase"`
+	Trigger         string         `json:"trigger"`
+	Creator         string         `json:"creator,omitempty"`
+	SCMRepo         string         `json:"scmRepo,omitempty"`
+	SCMRevision     string         `json:"scmRevision,omitempty"`
+	StartTime       *time.Time     `json:"startTime,omitempty"`
+	CompletionTime  *time.Time     `json:"completionTime,omitempty"`
+	DurationSeconds int64          `json:"durationSeconds"`
+	Stages          []StageSummary `json:"stages,omitempty"`
+}
+
+// StageSummary is the result of a stage
+type StageSummary struct {
+	Name             string `json:"name"`
+	Result           string `json:"result,omitempty"`
+	State            string `json:"state,omitempty"`
+	DurationInMillis int64  `json:"durationInMillis"`
+}
+
+// Filter is the condition to query the history, the empty fields match everything
+type Filter struct {
+	Namespace string
+	Pipeline  string
+	Branch    string
+	Phase     string
+	// Since and Until limit the completion time of the PipelineRuns
+	Since time.Time
+	Until time.Time
+
+	Limit  int
+	Offset int
+}
+
+// Interface is the store of the PipelineRun history
+type Interface interface {
+	// Save creates or updates the summary of a PipelineRun
+	Save(ctx context.Context, summary *RunSummary) error
+	// List returns the summaries which match the filter and the total count, the latest completed ones come first
+	List(ctx context.Context, filter Filter) ([]RunSummary, int, error)
+}
+
+// NewRunSummary creates the summary of a PipelineRun
+func NewRunSummary(pr *v1alpha3.PipelineRun) *RunSummary {
+	summary := &RunSummary{
+		UID:         string(pr.UID),
+		Namespace:   pr.Namespace,
+		Pipeline:    pr.Labels[v1alpha3.PipelineNameLabelKey],
+		Name:        pr.Name,
+		Branch:      pr.GetRefName(),
+		Phase:       string(pr.Status.Phase),
+		Trigger:     pr.GetTrigger(),
+		Creator:     pr.GetCreator(),
+		SCMRepo:     pr.Annotations[v1alpha3.PipelineRunSCMRepoAnnoKey],
+		SCMRevision: pr.Annotations[v1alpha3.PipelineRunSCMRevisionAnnoKey],
+		Stages:      getStages(pr),
+	}
+	if pr.Status.StartTime != nil {
+		startTime := pr.Status.StartTime.UTC()
+		summary.StartTime = &startTime
+	}
+	if pr.Status.CompletionTime != nil {
+		completionTime := pr.Status.CompletionTime.UTC()
+		summary.CompletionTime = &completionTime
+	}
+	if summary.StartTime != nil && summary.CompletionTime != nil {
+		summary.DurationSeconds = int64(summary.CompletionTime.Sub(*summary.StartTime) / time.Second)
+	}
+	return summary
+}
+
+// getStages parses the stages from the Jenkins stages status, it's empty for the other backends
+func getStages(pr *v1alpha3.PipelineRun) (stages []StageSummary) {
+	stagesJSON, ok := pr.Annotations[v1alpha3.JenkinsPipelineRunStagesStatusAnnoKey]
+	if !ok {
+		return
+	}
+	nodes := make([]struct {
+		DisplayName      string `json:"displayName"`
+		Result           string `json:"result"`
+		State            string `json:"state"`
+		DurationInMillis int64  `json:"durationInMillis"`
+	}, 0)
+	if err := json.Unmarshal([]byte(stagesJSON), &nodes); err != nil {
+		return
+	}
+	for _, node := range nodes {
+		stages = append(stages, StageSummary{
+			Name:             node.DisplayName,
+			Result:           node.Result,
+			State:            node.State,
+			DurationInMillis: node.DurationInMillis,
+		})
+	}
+	return
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package history
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+// fakeDriver records the statements and returns the rows of the queries
+type fakeDriver struct {
+	statements []string
+	args       [][]driver.Value
+	count      int64
+	rows       [][]driver.Value
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) { return &fakeConn{driver: d}, nil }
+
+type fakeConn struct{ driver *fakeDriver }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{driver: c.driver, query: query}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return nil, driver.ErrSkip }
+
+type fakeStmt struct {
+	driver *fakeDriver
+	query  string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.driver.statements = append(s.driver.statements, s.query)
+	s.driver.args = append(s.driver.args, args)
+	return driver.RowsAffected(1), nil
+}
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.driver.statements = append(s.driver.statements, s.query)
+	s.driver.args = append(s.driver.args, args)
+	if len(s.query) > 15 && s.query[:15] == "SELECT COUNT(*)" {
+		return &fakeRows{columns: []string{"count"}, rows: [][]driver.Value{{s.driver.count}}}, nil
+	}
+	return &fakeRows{columns: columns, rows: s.driver.rows}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+	index   int
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.index >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.index])
+	r.index++
+	return nil
+}
+
+var fakeSQLDriver = &fakeDriver{}
+
+func init() {
+	sql.Register("history-fake", fakeSQLDriver)
+}
+
+func TestNewRunSummary(t *testing.T) {
+	startTime := metav1.NewTime(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+	completionTime := metav1.NewTime(startTime.Add(90 * time.Second))
+	pr := &v1alpha3.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns",
+			Name:      "build-1",
+			UID:       "uid",
+			Labels:    map[string]string{v1alpha3.PipelineNameLabelKey: "build"},
+			Annotations: map[string]string{
+				v1alpha3.PipelineRunSCMRepoAnnoKey:             "kubesphere/ks-devops",
+				v1alpha3.PipelineRunSCMRevisionAnnoKey:         "abc",
+				v1alpha3.JenkinsPipelineRunStagesStatusAnnoKey: `[{"displayName":"build","result":"SUCCESS","state":"FINISHED","durationInMillis":1000}]`,
+			},
+		},
+		Spec: v1alpha3.PipelineRunSpec{
+			PipelineSpec: &v1alpha3.PipelineSpec{Type: v1alpha3.MultiBranchPipelineType},
+			SCM:          &v1alpha3.SCM{RefName: "main"},
+		},
+		Status: v1alpha3.PipelineRunStatus{
+			Phase:          v1alpha3.Succeeded,
+			StartTime:      &startTime,
+			CompletionTime: &completionTime,
+		},
+	}
+
+	summary := NewRunSummary(pr)
+	assert.Equal(t, "uid", summary.UID)
+	assert.Equal(t, "build", summary.Pipeline)
+	assert.Equal(t, "main", summary.Branch)
+	assert.Equal(t, "Succeeded", summary.Phase)
+	assert.Equal(t, v1alpha3.PipelineRunTriggerSCM, summary.Trigger)
+	assert.Equal(t, "abc", summary.SCMRevision)
+	assert.Equal(t, int64(90), summary.DurationSeconds)
+	assert.Equal(t, []StageSummary{{Name: "build", Result: "SUCCESS", State: "FINISHED", DurationInMillis: 1000}}, summary.Stages)
+
+	// invalid stages
+	pr.Annotations[v1alpha3.JenkinsPipelineRunStagesStatusAnnoKey] = "invalid"
+	pr.Status.StartTime = nil
+	summary = NewRunSummary(pr)
+	assert.Empty(t, summary.Stages)
+	assert.Zero(t, summary.DurationSeconds)
+}
+
+func TestSQLStore(t *testing.T) {
+	db, err := sql.Open("history-fake", "")
+	assert.Nil(t, err)
+
+	_, err = NewSQLStore(db, "sqlite")
+	assert.NotNil(t, err)
+
+	store, err := NewSQLStore(db, DialectPostgres)
+	assert.Nil(t, err)
+	assert.Nil(t, store.(*sqlStore).Init(context.TODO()))
+	assert.Equal(t, 2, len(fakeSQLDriver.statements))
+
+	completionTime := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	fakeSQLDriver.statements = nil
+	fakeSQLDriver.args = nil
+	err = store.Save(context.TODO(), &RunSummary{
+		UID: "uid", Namespace: "ns", Pipeline: "build", Name: "build-1", Phase: "Failed",
+		CompletionTime: &completionTime, Stages: []StageSummary{{Name: "build"}},
+	})
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(fakeSQLDriver.statements)) {
+		assert.Contains(t, fakeSQLDriver.statements[0], "INSERT INTO pipelinerun_history")
+		assert.Contains(t, fakeSQLDriver.statements[0], "$14)")
+		assert.Contains(t, fakeSQLDriver.statements[0], "ON CONFLICT (uid) DO UPDATE SET namespace = EXCLUDED.namespace")
+		assert.Equal(t, 14, len(fakeSQLDriver.args[0]))
+		assert.Nil(t, fakeSQLDriver.args[0][10])
+		assert.Equal(t, completionTime, fakeSQLDriver.args[0][11])
+		assert.Equal(t, `[{"name":"build","durationInMillis":0}]`, fakeSQLDriver.args[0][13])
+	}
+
+	fakeSQLDriver.statements = nil
+	fakeSQLDriver.args = nil
+	fakeSQLDriver.count = 5
+	fakeSQLDriver.rows = [][]driver.Value{
+		{"uid", "ns", "build", "build-1", "", "Failed", "user", "admin", "", "", nil, completionTime, int64(0), `[{"name":"build"}]`},
+	}
+	summaries, total, err := store.List(context.TODO(), Filter{
+		Namespace: "ns", Pipeline: "build", Phase: "Failed", Since: completionTime.Add(-time.Hour), Limit: 1, Offset: 2,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 5, total)
+	if assert.Equal(t, 1, len(summaries)) {
+		assert.Equal(t, "build-1", summaries[0].Name)
+		assert.Nil(t, summaries[0].StartTime)
+		assert.Equal(t, completionTime, *summaries[0].CompletionTime)
+		assert.Equal(t, []StageSummary{{Name: "build"}}, summaries[0].Stages)
+	}
+	if assert.Equal(t, 2, len(fakeSQLDriver.statements)) {
+		assert.Equal(t, "SELECT COUNT(*) FROM pipelinerun_history WHERE namespace = $1 AND pipeline = $2 AND phase = $3 AND completion_time >= $4",
+			fakeSQLDriver.statements[0])
+		assert.Contains(t, fakeSQLDriver.statements[1], "ORDER BY completion_time DESC, uid LIMIT 1 OFFSET 2")
+		assert.Equal(t, 4, len(fakeSQLDriver.args[1]))
+	}
+}
+
+func TestSQLStore_MySQL(t *testing.T) {
+	store, err := NewSQLStore(nil, DialectMySQL)
+	assert.Nil(t, err)
+
+	sqlStore := store.(*sqlStore)
+	assert.Contains(t, sqlStore.upsertStatement(), "VALUES (?, ?, ?")
+	assert.Contains(t, sqlStore.upsertStatement(), "ON DUPLICATE KEY UPDATE namespace = VALUES(namespace)")
+
+	where, args := sqlStore.whereClause(Filter{Branch: "main", Until: time.Now()})
+	assert.Equal(t, " WHERE branch = ? AND completion_time <= ?", where)
+	assert.Equal(t, 2, len(args))
+
+	where, args = sqlStore.whereClause(Filter{})
+	assert.Empty(t, where)
+	assert.Empty(t, args)
+}
+
+func TestOptions_NewStore(t *testing.T) {
+	var nilOptions *Options
+	store, err := nilOptions.NewStore()
+	assert.Nil(t, store)
+	assert.Nil(t, err)
+
+	store, err = NewOptions().NewStore()
+	assert.Nil(t, store)
+	assert.Nil(t, err)
+
+	store, err = (&Options{Driver: "unknown", DSN: "dsn"}).NewStore()
+	assert.Nil(t, store)
+	assert.NotNil(t, err)
+
+	// the drivers of the supported dialects are registered
+	assert.Contains(t, sql.Drivers(), DialectMySQL)
+	assert.Contains(t, sql.Drivers(), DialectPostgres)
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package history
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// Options contains the database to keep the PipelineRun history.
+// The SQL drivers of MySQL and PostgreSQL are registered into database/sql by this package.
+type Options struct {
+	Driver string `json:"driver,omitempty" yaml:"driver" mapstructure:"driver"`
+	DSN    string `json:"dsn,omitempty" yaml:"dsn" mapstructure:"dsn"`
+}
+
+// NewOptions creates an Options without database
+func NewOptions() *Options {
+	return &Options{
+		Driver: DialectMySQL,
+	}
+}
+
+// AddFlags adds the flags of the options
+func (o *Options) AddFlags(fs *pflag.FlagSet, c *Options) {
+	fs.StringVar(&o.Driver, "history-driver", c.Driver, ""+
+		"The SQL driver of the PipelineRun history database, it should be mysql or postgres.")
+	fs.StringVar(&o.DSN, "history-dsn", c.DSN, ""+
+		"The data source name of the PipelineRun history database, the history is not kept if it's empty.")
+}
+
+// NewStore connects to the database and makes sure the table exists, it returns nil if no database is configured
+func (o *Options) NewStore() (store Interface, err error) {
+	if o == nil || o.DSN == "" {
+		return
+	}
+
+	var db *sql.DB
+	if db, err = sql.Open(o.Driver, o.DSN); err != nil {
+		return
+	}
+	if store, err = NewSQLStore(db, o.Driver); err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err = store.(*sqlStore).Init(ctx); err != nil {
+		store = nil
+	}
+	return
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package history
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// The supported SQL dialects
+const (
+	DialectMySQL    = "mysql"
+	DialectPostgres = "postgres"
+)
+
+const tableName = "pipelinerun_history"
+
+// columns are the columns of the history table, uid is the primary key
+var columns = []string{"uid", "namespace", "pipeline", "name", "branch", "phase", "trigger_type", "creator",
+	"scm_repo", "scm_revision", "start_time", "completion_time", "duration_seconds", "stages"}
+
+var schemas = map[string][]string{
+	DialectMySQL: {`CREATE TABLE IF NOT EXISTS ` + tableName + ` (
+	uid VARCHAR(64) NOT NULL PRIMARY KEY,
+	namespace VARCHAR(63) NOT NULL,
+	pipeline VARCHAR(253) NOT NULL,
+	name VARCHAR(253) NOT NULL,
+	branch VARCHAR(255) NOT NULL DEFAULT '',
+	phase VARCHAR(32) NOT NULL,
+	trigger_type VARCHAR(32) NOT NULL,
+	creator VARCHAR(255) NOT NULL DEFAULT '',
+	scm_repo VARCHAR(255) NOT NULL DEFAULT '',
+	scm_revision VARCHAR(64) NOT NULL DEFAULT '',
+	start_time DATETIME NULL,
+	completion_time DATETIME NULL,
+	duration_seconds BIGINT NOT NULL DEFAULT 0,
+	stages TEXT,
+	INDEX idx_pipelinerun_history_pipeline (namespace, pipeline, completion_time)
+)`},
+	DialectPostgres: {`CREATE TABLE IF NOT EXISTS ` + tableName + ` (
+	uid VARCHAR(64) NOT NULL PRIMARY KEY,
+	namespace VARCHAR(63) NOT NULL,
+	pipeline VARCHAR(253) NOT NULL,
+	name VARCHAR(253) NOT NULL,
+	branch VARCHAR(255) NOT NULL DEFAULT '',
+	phase VARCHAR(32) NOT NULL,
+	trigger_type VARCHAR(32) NOT NULL,
+	creator VARCHAR(255) NOT NULL DEFAULT '',
+	scm_repo VARCHAR(255) NOT NULL DEFAULT '',
+	scm_revision VARCHAR(64) NOT NULL DEFAULT '',
+	start_time TIMESTAMP WITH TIME ZONE NULL,
+	completion_time TIMESTAMP WITH TIME ZONE NULL,
+	duration_seconds BIGINT NOT NULL DEFAULT 0,
+	stages TEXT
+)`, `CREATE INDEX IF NOT EXISTS idx_pipelinerun_history_pipeline ON ` + tableName + ` (namespace, pipeline, completion_time)`},
+}
+
+// NewSQLStore creates a history store on top of a MySQL or PostgreSQL database
+func NewSQLStore(db *sql.DB, dialect string) (Interface, error) {
+	if _, ok := schemas[dialect]; !ok {
+		return nil, fmt.Errorf("unsupported SQL dialect '%s', it should be %s or %s", dialect, DialectMySQL, DialectPostgres)
+	}
+	return &sqlStore{db: db, dialect: dialect}, nil
+}
+
+type sqlStore struct {
+	db      *sql.DB
+	dialect string
+}
+
+// Init creates the table if it does not exist
+func (s *sqlStore) Init(ctx context.Context) (err error) {
+	for _, schema := range schemas[s.dialect] {
+		if _, err = s.db.ExecContext(ctx, schema); err != nil {
+			err = fmt.Errorf("failed to create the table of the PipelineRun history, error: %v", err)
+			return
+		}
+	}
+	return
+}
+
+func (s *sqlStore) Save(ctx context.Context, summary *RunSummary) (err error) {
+	var stages []byte
+	if stages, err = json.Marshal(summary.Stages); err != nil {
+		return
+	}
+	_, err = s.db.ExecContext(ctx, s.upsertStatement(),
+		summary.UID, summary.Namespace, summary.Pipeline, summary.Name, summary.Branch, summary.Phase, summary.Trigger,
+		summary.Creator, summary.SCMRepo, summary.SCMRevision, nullTime(summary.StartTime), nullTime(summary.CompletionTime),
+		summary.DurationSeconds, string(stages))
+	return
+}
+
+func (s *sqlStore) List(ctx context.Context, filter Filter) (summaries []RunSummary, total int, err error) {
+	where, args := s.whereClause(filter)
+	if err = s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+tableName+where, args...).Scan(&total); err != nil {
+		return
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s%s ORDER BY completion_time DESC, uid", strings.Join(columns, ", "), tableName, where)
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d OFFSET %d", filter.Limit, filter.Offset)
+	}
+	var rows *sql.Rows
+	if rows, err = s.db.QueryContext(ctx, query, args...); err != nil {
+		return
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	summaries = make([]RunSummary, 0)
+	for rows.Next() {
+		summary := RunSummary{}
+		var startTime, completionTime sql.NullTime
+		var stages sql.NullString
+		if err = rows.Scan(&summary.UID, &summary.Namespace, &summary.Pipeline, &summary.Name, &summary.Branch,
+			&summary.Phase, &summary.Trigger, &summary.Creator, &summary.SCMRepo, &summary.SCMRevision,
+			&startTime, &completionTime, &summary.DurationSeconds, &stages); err != nil {
+			return
+		}
+		if startTime.Valid {
+			summary.StartTime = &startTime.Time
+		}
+		if completionTime.Valid {
+			summary.CompletionTime = &completionTime.Time
+		}
+		if stages.Valid && stages.String != "" {
+			if err = json.Unmarshal([]byte(stages.String), &summary.Stages); err != nil {
+				return
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	err = rows.Err()
+	return
+}
+
+// upsertStatement returns the statement which inserts a summary or updates it if the uid exists
+func (s *sqlStore) upsertStatement() string {
+	placeholders := make([]string, len(columns))
+	updates := make([]string, 0, len(columns)-1)
+	for i, column := range columns {
+		placeholders[i] = s.placeholder(i + 1)
+		if column == "uid" {
+			continue
+		}
+		if s.dialect == DialectMySQL {
+			updates = append(updates, fmt.Sprintf("%s = VALUES(%s)", column, column))
+		} else {
+			updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", column, column))
+		}
+	}
+
+	statement := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", tableName, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+	if s.dialect == DialectMySQL {
+		return statement + " ON DUPLICATE KEY UPDATE " + strings.Join(updates, ", ")
+	}
+	return statement + " ON CONFLICT (uid) DO UPDATE SET " + strings.Join(updates, ", ")
+}
+
+// whereClause builds the WHERE clause and its arguments of the filter
+func (s *sqlStore) whereClause(filter Filter) (string, []interface{}) {
+	conditions := make([]string, 0, 6)
+	args := make([]interface{}, 0, 6)
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, s.placeholder(len(args))))
+	}
+
+	if filter.Namespace != "" {
+		addCondition("namespace = %s", filter.Namespace)
+	}
+	if filter.Pipeline != "" {
+		addCondition("pipeline = %s", filter.Pipeline)
+	}
+	if filter.Branch != "" {
+		addCondition("branch = %s", filter.Branch)
+	}
+	if filter.Phase != "" {
+		addCondition("phase = %s", filter.Phase)
+	}
+	if !filter.Since.IsZero() {
+		addCondition("completion_time >= %s", filter.Since.UTC())
+	}
+	if !filter.Until.IsZero() {
+		addCondition("completion_time <= %s", filter.Until.UTC())
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// placeholder returns the placeholder of the nth argument which starts from 1
+func (s *sqlStore) placeholder(n int) string {
+	if s.dialect == DialectPostgres {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package multicluster provides the clients of the member clusters which are managed by KubeSphere.
+package multicluster
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// HostClusterLabelKey is the label key of the host cluster
+const HostClusterLabelKey = "cluster-role.kubesphere.io/host"
+
+// ErrHostCluster indicates the cluster is the host cluster instead of a member cluster
+var ErrHostCluster = errors.New("not a member cluster")
+
+// ClusterGroupVersionKind is the GroupVersionKind of the KubeSphere clusters
+var ClusterGroupVersionKind = schema.GroupVersionKind{
+	Group:   "cluster.kubesphere.io",
+	Version: "v1alpha1",
+	Kind:    "Cluster",
+}
+
+// ClientGetter returns the client of a member cluster by its name
+type ClientGetter interface {
+	GetClient(ctx context.Context, cluster string) (client.Client, error)
+}
+
+// Clients creates the clients of the member clusters from the kubeconfig of the KubeSphere clusters.
+// The clients are cached until the cluster changes.
+type Clients struct {
+	reader client.Reader
+	scheme *runtime.Scheme
+
+	lock    sync.Mutex
+	clients map[string]cachedClient
+}
+
+type cachedClient struct {
+	resourceVersion string
+	client          client.Client
+}
+
+// NewClients creates the clients of the member clusters, the objects are encoded by the given scheme
+func NewClients(reader client.Reader, scheme *runtime.Scheme) *Clients {
+	return &Clients{
+		reader:  reader,
+		scheme:  scheme,
+		clients: map[string]cachedClient{},
+	}
+}
+
+// NewCluster returns an empty KubeSphere cluster object
+func NewCluster() *unstructured.Unstructured {
+	cluster := &unstructured.Unstructured{}
+	cluster.SetGroupVersionKind(ClusterGroupVersionKind)
+	return cluster
+}
+
+// GetClient returns the client of the given member cluster
+func (c *Clients) GetClient(ctx context.Context, name string) (client.Client, error) {
+	cluster := NewCluster()
+	if err := c.reader.Get(ctx, types.NamespacedName{Name: name}, cluster); err != nil {
+		return nil, err
+	}
+	if _, ok := cluster.GetLabels()[HostClusterLabelKey]; ok {
+		return nil, fmt.Errorf("cluster %s is the host cluster: %w", name, ErrHostCluster)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if cached, ok := c.clients[name]; ok && cached.resourceVersion == cluster.GetResourceVersion() {
+		return cached.client, nil
+	}
+
+	kubeconfig, err := GetKubeConfig(cluster)
+	if err != nil {
+		return nil, err
+	}
+	config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig of cluster %s: %v", name, err)
+	}
+	// discover the APIs lazily, the member cluster might be unreachable for now
+	mapper, err := apiutil.NewDynamicRESTMapper(config, apiutil.WithLazyDiscovery)
+	if err != nil {
+		return nil, err
+	}
+	memberClient, err := client.New(config, client.Options{Scheme: c.scheme, Mapper: mapper})
+	if err != nil {
+		return nil, err
+	}
+	c.clients[name] = cachedClient{resourceVersion: cluster.GetResourceVersion(), client: memberClient}
+	return memberClient, nil
+}
+
+// GetKubeConfig returns the kubeconfig of a KubeSphere cluster
+func GetKubeConfig(cluster *unstructured.Unstructured) ([]byte, error) {
+	encoded, _, _ := unstructured.NestedString(cluster.Object, "spec", "connection", "kubeconfig")
+	if encoded == "" {
+		return nil, fmt.Errorf("cluster %s has no kubeconfig", cluster.GetName())
+	}
+	return base64.StdEncoding.DecodeString(encoded)
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multicluster
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const kubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    server: https://member.example.com:6443
+  name: member
+contexts:
+- context:
+    cluster: member
+    user: admin
+  name: member
+current-context: member
+users:
+- name: admin
+  user:
+    token: token
+`
+
+func newCluster(name, kubeconfig string, labels map[string]string) *unstructured.Unstructured {
+	cluster := NewCluster()
+	cluster.SetName(name)
+	cluster.SetLabels(labels)
+	if kubeconfig != "" {
+		cluster.Object["spec"] = map[string]interface{}{
+			"connection": map[string]interface{}{
+				"kubeconfig": base64.StdEncoding.EncodeToString([]byte(kubeconfig)),
+			},
+		}
+	}
+	return cluster
+}
+
+func TestGetClient(t *testing.T) {
+	reader := fake.NewClientBuilder().WithObjects(
+		newCluster("host", kubeconfig, map[string]string{HostClusterLabelKey: ""}),
+		newCluster("member", kubeconfig, nil),
+		newCluster("broken", "", nil),
+	).Build()
+	clients := NewClients(reader, runtime.NewScheme())
+
+	_, err := clients.GetClient(context.TODO(), "not-found")
+	assert.NotNil(t, err)
+
+	_, err = clients.GetClient(context.TODO(), "host")
+	assert.True(t, errors.Is(err, ErrHostCluster))
+
+	_, err = clients.GetClient(context.TODO(), "broken")
+	assert.NotNil(t, err)
+
+	memberClient, err := clients.GetClient(context.TODO(), "member")
+	assert.Nil(t, err)
+	assert.NotNil(t, memberClient)
+
+	// the client is cached until the cluster changes
+	cached, err := clients.GetClient(context.TODO(), "member")
+	assert.Nil(t, err)
+	assert.Same(t, memberClient, cached)
+}
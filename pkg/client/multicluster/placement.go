@@ -0,0 +1,63 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multicluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/constants"
+	"kubesphere.io/devops/pkg/utils/sliceutil"
+)
+
+// ErrNotPlaced indicates the member cluster is not in the placement of the DevOpsProject
+var ErrNotPlaced = errors.New("not in the placement of the DevOpsProject")
+
+// CheckPlacement returns ErrNotPlaced if the DevOpsProject of the namespace is not replicated to the member cluster.
+// The PipelineRuns are run with the admin kubeconfig of the member clusters, so they are only allowed to target
+// the clusters which the project is placed on.
+func CheckPlacement(ctx context.Context, reader client.Reader, namespace, cluster string) error {
+	ns := &v1.Namespace{}
+	if err := reader.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		return fmt.Errorf("namespace %s does not exist: %w", namespace, ErrNotPlaced)
+	}
+	projectName := ns.GetLabels()[constants.DevOpsProjectLabelKey]
+	if projectName == "" {
+		return fmt.Errorf("namespace %s does not belong to a DevOpsProject: %w", namespace, ErrNotPlaced)
+	}
+
+	project := &v1alpha3.DevOpsProject{}
+	if err := reader.Get(ctx, types.NamespacedName{Name: projectName}, project); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		return fmt.Errorf("DevOpsProject %s does not exist: %w", projectName, ErrNotPlaced)
+	}
+	if !sliceutil.HasString(project.Spec.GetPlacedClusters(), cluster) {
+		return fmt.Errorf("cluster %s is %w %s", cluster, ErrNotPlaced, projectName)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultTemplate is the message template of the Notifications which do not declare one
+const DefaultTemplate = `[{{.Namespace}}] Pipeline {{.Pipeline}} run {{.PipelineRun}} {{.Type | lower}}` +
+	`{{if .Duration}} in {{.Duration}}{{end}}{{if .Reason}}: {{.Reason}}{{end}}`
+
+// Event is a lifecycle event of a PipelineRun, it's the data of the message template as well
+type Event struct {
+	Type           string     `json:"type"`
+	Namespace      string     `json:"namespace"`
+	Pipeline       string     `json:"pipeline,omitempty"`
+	PipelineRun    string     `json:"pipelineRun"`
+	Phase          string     `json:"phase"`
+	StartTime      *time.Time `json:"startTime,omitempty"`
+	CompletionTime *time.Time `json:"completionTime,omitempty"`
+	// Duration is the duration of a completed PipelineRun
+	Duration string `json:"duration,omitempty"`
+	// Reason is why the event happened, such as the diagnosis of the stuck agent pods
+	Reason string `json:"reason,omitempty"`
+	// Message is the rendered message
+	Message string `json:"message,omitempty"`
+}
+
+var templateFuncs = template.FuncMap{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// Render renders the message of the event by a Go template, the default template is used if it's empty
+func Render(text string, event *Event) (message string, err error) {
+	if text == "" {
+		text = DefaultTemplate
+	}
+	var tpl *template.Template
+	if tpl, err = template.New("notification").Funcs(templateFuncs).Parse(text); err != nil {
+		return
+	}
+	buf := &bytes.Buffer{}
+	if err = tpl.Execute(buf, event); err == nil {
+		message = buf.String()
+	}
+	return
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+func TestRender(t *testing.T) {
+	event := &Event{Type: "Succeeded", Namespace: "ns", Pipeline: "build", PipelineRun: "build-1", Duration: "1m0s"}
+
+	message, err := Render("", event)
+	assert.Nil(t, err)
+	assert.Equal(t, "[ns] Pipeline build run build-1 succeeded in 1m0s", message)
+
+	message, err = Render("{{.Pipeline | upper}} is {{.Type}}", event)
+	assert.Nil(t, err)
+	assert.Equal(t, "BUILD is Succeeded", message)
+
+	_, err = Render("{{.Pipeline", event)
+	assert.NotNil(t, err)
+	_, err = Render("{{.Fake}}", event)
+	assert.NotNil(t, err)
+}
+
+func TestSender(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/error" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		data, _ := ioutil.ReadAll(r.Body)
+		received = map[string]interface{}{}
+		_ = json.Unmarshal(data, &received)
+	}))
+	defer server.Close()
+
+	sender := NewSender(nil, nil)
+	event := &Event{Type: "Failed", Namespace: "ns", PipelineRun: "build-1", Message: "message"}
+
+	assert.Nil(t, sender.Send(context.TODO(), Receiver{Type: v1alpha3.NotificationReceiverSlack, URL: server.URL}, event))
+	assert.Equal(t, map[string]interface{}{"text": "message"}, received)
+
+	for _, receiverType := range []v1alpha3.NotificationReceiverType{v1alpha3.NotificationReceiverDingTalk,
+		v1alpha3.NotificationReceiverWeCom} {
+		assert.Nil(t, sender.Send(context.TODO(), Receiver{Type: receiverType, URL: server.URL}, event))
+		assert.Equal(t, map[string]interface{}{
+			"msgtype": "text",
+			"text":    map[string]interface{}{"content": "message"},
+		}, received)
+	}
+
+	assert.Nil(t, sender.Send(context.TODO(), Receiver{Type: v1alpha3.NotificationReceiverWebhook, URL: server.URL}, event))
+	assert.Equal(t, "Failed", received["type"])
+	assert.Equal(t, "build-1", received["pipelineRun"])
+	assert.Equal(t, "message", received["message"])
+
+	// errors
+	assert.NotNil(t, sender.Send(context.TODO(), Receiver{Type: v1alpha3.NotificationReceiverSlack}, event))
+	assert.NotNil(t, sender.Send(context.TODO(), Receiver{Type: v1alpha3.NotificationReceiverSlack, URL: server.URL + "/error"}, event))
+	assert.NotNil(t, sender.Send(context.TODO(), Receiver{Type: "fake", URL: server.URL}, event))
+	assert.NotNil(t, sender.Send(context.TODO(), Receiver{Type: v1alpha3.NotificationReceiverEmail, To: []string{"a@b.com"}}, event))
+}
+
+func TestSender_Email(t *testing.T) {
+	var addr, from string
+	var to []string
+	var msg []byte
+	sendMail = func(a string, auth smtp.Auth, f string, t []string, m []byte) error {
+		addr, from, to, msg = a, f, t, m
+		return nil
+	}
+	defer func() {
+		sendMail = smtp.SendMail
+	}()
+
+	options := NewOptions()
+	options.Host = "smtp.example.com"
+	options.From = "devops@example.com"
+	sender := NewSender(nil, options)
+	event := &Event{Type: "Failed", Namespace: "ns", Pipeline: "build", PipelineRun: "build-1", Message: "message"}
+
+	assert.NotNil(t, sender.Send(context.TODO(), Receiver{Type: v1alpha3.NotificationReceiverEmail}, event))
+	assert.Nil(t, sender.Send(context.TODO(), Receiver{Type: v1alpha3.NotificationReceiverEmail,
+		To: []string{"a@example.com", "b@example.com"}}, event))
+	assert.Equal(t, "smtp.example.com:25", addr)
+	assert.Equal(t, "devops@example.com", from)
+	assert.Equal(t, []string{"a@example.com", "b@example.com"}, to)
+	assert.True(t, strings.Contains(string(msg), "Subject: [ns] Pipeline build run build-1 failed\r\n"))
+	assert.True(t, strings.HasSuffix(string(msg), "\r\n\r\nmessage\r\n"))
+}
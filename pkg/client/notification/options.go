@@ -0,0 +1,47 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"github.com/spf13/pflag"
+)
+
+// Options contains the SMTP server to send the Email notifications
+type Options struct {
+	Host     string `json:"host,omitempty" yaml:"host"`
+	Port     int    `json:"port,omitempty" yaml:"port"`
+	From     string `json:"from,omitempty" yaml:"from"`
+	Username string `json:"username,omitempty" yaml:"username"`
+	Password string `json:"password,omitempty" yaml:"password"`
+}
+
+// NewOptions creates an Options without SMTP server
+func NewOptions() *Options {
+	return &Options{
+		Port: 25,
+	}
+}
+
+// AddFlags adds the flags of the options
+func (o *Options) AddFlags(fs *pflag.FlagSet, c *Options) {
+	fs.StringVar(&o.Host, "smtp-host", c.Host, ""+
+		"The host of the SMTP server, the Email notifications are not sent if it's empty.")
+	fs.IntVar(&o.Port, "smtp-port", c.Port, "The port of the SMTP server.")
+	fs.StringVar(&o.From, "smtp-from", c.From, "The sender address of the Email notifications.")
+	fs.StringVar(&o.Username, "smtp-username", c.Username, "The username to authenticate with the SMTP server.")
+	fs.StringVar(&o.Password, "smtp-password", c.Password, "The password to authenticate with the SMTP server.")
+}
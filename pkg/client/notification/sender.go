@@ -0,0 +1,120 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+// Receiver is a resolved notification receiver
+type Receiver struct {
+	Type v1alpha3.NotificationReceiverType
+	URL  string
+	To   []string
+}
+
+// Sender sends the events to the receivers
+type Sender interface {
+	Send(ctx context.Context, receiver Receiver, event *Event) error
+}
+
+type sender struct {
+	client *http.Client
+	smtp   *Options
+}
+
+// sendMail is the function to send emails, it's replaceable for testing
+var sendMail = smtp.SendMail
+
+// NewSender creates a Sender, the Email receivers are not supported if the SMTP server is not configured.
+// A client with the default timeout is used if the client is nil.
+func NewSender(client *http.Client, options *Options) Sender {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &sender{client: client, smtp: options}
+}
+
+func (s *sender) Send(ctx context.Context, receiver Receiver, event *Event) error {
+	switch receiver.Type {
+	case v1alpha3.NotificationReceiverSlack:
+		return s.post(ctx, receiver.URL, map[string]string{"text": event.Message})
+	case v1alpha3.NotificationReceiverDingTalk, v1alpha3.NotificationReceiverWeCom:
+		return s.post(ctx, receiver.URL, map[string]interface{}{
+			"msgtype": "text",
+			"text":    map[string]string{"content": event.Message},
+		})
+	case v1alpha3.NotificationReceiverWebhook:
+		return s.post(ctx, receiver.URL, event)
+	case v1alpha3.NotificationReceiverEmail:
+		return s.sendEmail(receiver.To, event)
+	}
+	return fmt.Errorf("unknown notification receiver type: %s", receiver.Type)
+}
+
+func (s *sender) post(ctx context.Context, url string, payload interface{}) (err error) {
+	if url == "" {
+		return fmt.Errorf("the url of receiver is empty")
+	}
+	var data []byte
+	if data, err = json.Marshal(payload); err != nil {
+		return
+	}
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data)); err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var resp *http.Response
+	if resp, err = s.client.Do(req); err != nil {
+		return
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		err = fmt.Errorf("unexpected status code from the receiver: %d", resp.StatusCode)
+	}
+	return
+}
+
+func (s *sender) sendEmail(to []string, event *Event) error {
+	if s.smtp == nil || s.smtp.Host == "" {
+		return fmt.Errorf("the SMTP server is not configured")
+	}
+	if len(to) == 0 {
+		return fmt.Errorf("there are no recipients of the email")
+	}
+
+	var auth smtp.Auth
+	if s.smtp.Username != "" {
+		auth = smtp.PlainAuth("", s.smtp.Username, s.smtp.Password, s.smtp.Host)
+	}
+	subject := fmt.Sprintf("[%s] Pipeline %s run %s %s", event.Namespace, event.Pipeline, event.PipelineRun,
+		strings.ToLower(event.Type))
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		s.smtp.From, strings.Join(to, ", "), subject, event.Message)
+	return sendMail(fmt.Sprintf("%s:%d", s.smtp.Host, s.smtp.Port), auth, s.smtp.From, to, []byte(msg))
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// dockerHubAPI is the address of the distribution API of Docker Hub
+	dockerHubAPI = "https://registry-1.docker.io"
+	// dockerHubPortal is the address of the web portal of Docker Hub
+	dockerHubPortal = "https://hub.docker.com"
+)
+
+type dockerHub struct {
+	*ociClient
+}
+
+// NewDockerHubClient creates a client of Docker Hub
+func NewDockerHubClient(credential *Credential, client *http.Client) Interface {
+	return &dockerHub{ociClient: newOCIClient(dockerHubAPI, credential, client)}
+}
+
+func (d *dockerHub) Type() Type {
+	return DockerHub
+}
+
+// GetWebURL returns the link of the tags of the repository, the official images are under /_/
+func (d *dockerHub) GetWebURL(_ context.Context, repository, reference string) (link string, err error) {
+	if name := strings.TrimPrefix(repository, "library/"); name != repository {
+		link = fmt.Sprintf("%s/_/%s/tags", dockerHubPortal, name)
+	} else {
+		link = fmt.Sprintf("%s/r/%s/tags", dockerHubPortal, repository)
+	}
+	if reference != "" && !isDigest(reference) {
+		link += "?name=" + url.QueryEscape(reference)
+	}
+	return
+}
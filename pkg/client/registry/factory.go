@@ -0,0 +1,153 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+// NewClient creates the client of the registry by its type, the server is the host or the URL of the registry
+func NewClient(registryType Type, server string, credential *Credential, client *http.Client) Interface {
+	switch registryType {
+	case DockerHub:
+		return NewDockerHubClient(credential, client)
+	case Harbor:
+		return NewHarborClient(ServerURL(server), credential, client)
+	default:
+		return NewOCIClient(ServerURL(server), credential, client)
+	}
+}
+
+// ServerURL returns the URL of the registry, HTTPS is taken if there is no scheme
+func ServerURL(server string) string {
+	if strings.HasPrefix(server, "http://") || strings.HasPrefix(server, "https://") {
+		return strings.TrimSuffix(server, "/")
+	}
+	return "https://" + strings.TrimSuffix(server, "/")
+}
+
+// DetectType detects the type of the registry. Harbor is detected by its system info API,
+// and OCI is taken if the registry is neither Docker Hub nor Harbor.
+func DetectType(ctx context.Context, server string, client *http.Client) Type {
+	if IsDockerHub(server) {
+		return DockerHub
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ServerURL(server)+"/api/v2.0/systeminfo", nil)
+	if err != nil {
+		return OCI
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return OCI
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	info := &struct {
+		HarborVersion string `json:"harbor_version"`
+	}{}
+	if resp.StatusCode == http.StatusOK && json.NewDecoder(resp.Body).Decode(info) == nil && info.HarborVersion != "" {
+		return Harbor
+	}
+	return OCI
+}
+
+// dockerAuth is an entry of the docker config
+type dockerAuth struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Auth     string `json:"auth"`
+}
+
+// GetCredential returns the credential of the registry from the secret. The docker config secrets and
+// the basic auth secrets are supported, nil is returned if the secret has no credential of the registry.
+func GetCredential(secret *v1.Secret, registry string) *Credential {
+	if secret == nil {
+		return nil
+	}
+	var auths map[string]dockerAuth
+	switch secret.Type {
+	case v1.SecretTypeBasicAuth, v1alpha3.SecretTypeBasicAuth:
+		return &Credential{
+			Username: string(secret.Data[v1.BasicAuthUsernameKey]),
+			Password: string(secret.Data[v1.BasicAuthPasswordKey]),
+		}
+	case v1.SecretTypeDockerConfigJson:
+		config := &struct {
+			Auths map[string]dockerAuth `json:"auths"`
+		}{}
+		if json.Unmarshal(secret.Data[v1.DockerConfigJsonKey], config) != nil {
+			return nil
+		}
+		auths = config.Auths
+	case v1.SecretTypeDockercfg:
+		if json.Unmarshal(secret.Data[v1.DockerConfigKey], &auths) != nil {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	for server, auth := range auths {
+		if !sameRegistry(server, registry) {
+			continue
+		}
+		credential := &Credential{Username: auth.Username, Password: auth.Password}
+		if auth.Auth != "" {
+			if decoded, err := base64.StdEncoding.DecodeString(auth.Auth); err == nil {
+				if pair := strings.SplitN(string(decoded), ":", 2); len(pair) == 2 {
+					credential.Username, credential.Password = pair[0], pair[1]
+				}
+			}
+		}
+		return credential
+	}
+	return nil
+}
+
+// FindCredential returns the first credential of the registry from the secrets
+func FindCredential(secrets []v1.Secret, registry string) *Credential {
+	for i := range secrets {
+		if credential := GetCredential(&secrets[i], registry); credential != nil {
+			return credential
+		}
+	}
+	return nil
+}
+
+// sameRegistry compares the registries without the schemes and the paths, such as https://index.docker.io/v1/
+func sameRegistry(server, registry string) bool {
+	host := func(address string) string {
+		address = strings.TrimPrefix(strings.TrimPrefix(address, "https://"), "http://")
+		return strings.SplitN(address, "/", 2)[0]
+	}
+	if IsDockerHub(host(server)) && IsDockerHub(host(registry)) {
+		return true
+	}
+	return host(server) == host(registry)
+}
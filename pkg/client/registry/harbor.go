@@ -0,0 +1,145 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// harborReportMimeType is the type of the vulnerability reports which are summarized in the scan overview
+const harborReportMimeType = "application/vnd.security.vulnerability.report; version=1.1"
+
+type harbor struct {
+	*ociClient
+}
+
+// NewHarborClient creates a client of Harbor v2, the server is the URL of Harbor
+func NewHarborClient(server string, credential *Credential, client *http.Client) Interface {
+	return &harbor{ociClient: newOCIClient(server, credential, client)}
+}
+
+func (h *harbor) Type() Type {
+	return Harbor
+}
+
+// harborScanOverview is the scan overview of an artifact in Harbor
+type harborScanOverview struct {
+	ScanStatus string    `json:"scan_status"`
+	Severity   string    `json:"severity"`
+	EndTime    time.Time `json:"end_time"`
+	Scanner    struct {
+		Name string `json:"name"`
+	} `json:"scanner"`
+	Summary struct {
+		Total   int            `json:"total"`
+		Fixable int            `json:"fixable"`
+		Summary map[string]int `json:"summary"`
+	} `json:"summary"`
+}
+
+func (h *harbor) GetVulnerabilities(ctx context.Context, repository, reference string) (report *VulnerabilityReport, err error) {
+	project, name, err := splitHarborRepository(repository)
+	if err != nil {
+		return
+	}
+	artifact := &struct {
+		ScanOverview map[string]harborScanOverview `json:"scan_overview"`
+	}{}
+	if err = h.getAPI(ctx, fmt.Sprintf("/projects/%s/repositories/%s/artifacts/%s?with_scan_overview=true",
+		url.PathEscape(project), url.PathEscape(url.PathEscape(name)), url.PathEscape(reference)),
+		http.Header{"X-Accept-Vulnerabilities": []string{harborReportMimeType}}, artifact); err != nil {
+		return
+	}
+
+	overview, ok := artifact.ScanOverview[harborReportMimeType]
+	if !ok {
+		// the artifact has not been scanned
+		return nil, nil
+	}
+	report = &VulnerabilityReport{
+		Scanner:  overview.Scanner.Name,
+		Status:   overview.ScanStatus,
+		Severity: overview.Severity,
+		Total:    overview.Summary.Total,
+		Fixable:  overview.Summary.Fixable,
+		Summary:  overview.Summary.Summary,
+	}
+	if !overview.EndTime.IsZero() {
+		report.CompleteTime = &overview.EndTime
+	}
+	return
+}
+
+// GetWebURL returns the link of the artifact, Harbor identifies the projects by their IDs in the portal
+func (h *harbor) GetWebURL(ctx context.Context, repository, reference string) (link string, err error) {
+	project, name, err := splitHarborRepository(repository)
+	if err != nil {
+		return
+	}
+	result := &struct {
+		ProjectID int `json:"project_id"`
+	}{}
+	if err = h.getAPI(ctx, "/projects/"+url.PathEscape(project), http.Header{"X-Is-Resource-Name": []string{"true"}}, result); err != nil {
+		return
+	}
+	var digest string
+	if digest, err = h.GetDigest(ctx, repository, reference); err != nil {
+		return
+	}
+	link = fmt.Sprintf("%s/harbor/projects/%d/repositories/%s/artifacts-tab/artifacts/%s",
+		h.server, result.ProjectID, url.PathEscape(name), digest)
+	return
+}
+
+// getAPI gets the resource from the Harbor API, which takes the basic auth instead of the token
+func (h *harbor) getAPI(ctx context.Context, path string, header http.Header, result interface{}) (err error) {
+	var req *http.Request
+	if req, err = newRequest(ctx, http.MethodGet, h.server+"/api/v2.0"+path, header); err != nil {
+		return
+	}
+	req.Header.Set("Accept", "application/json")
+	if h.credential != nil {
+		req.SetBasicAuth(h.credential.Username, h.credential.Password)
+	}
+	var resp *http.Response
+	if resp, err = h.client.Do(req); err != nil {
+		return
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d from %s: %s", resp.StatusCode, req.URL.Path, readError(resp))
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// splitHarborRepository splits the repository into the project and the repository name in the project
+func splitHarborRepository(repository string) (project, name string, err error) {
+	parts := strings.SplitN(repository, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		err = fmt.Errorf("invalid Harbor repository %q, it should be <project>/<repository>", repository)
+		return
+	}
+	return parts[0], parts[1], nil
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Type is the type of the image registries
+type Type string
+
+// The supported image registries
+const (
+	// Harbor supports the vulnerability scan results and the deep links of the artifacts
+	Harbor Type = "harbor"
+	// DockerHub supports the deep links of the repositories
+	DockerHub Type = "dockerhub"
+	// OCI is a generic registry which implements the OCI distribution API
+	OCI Type = "oci"
+)
+
+// DockerHubRegistry is the registry of the images without a registry host, such as nginx:latest
+const DockerHubRegistry = "docker.io"
+
+// ErrNotSupported indicates the operation is not supported by the registry
+var ErrNotSupported = errors.New("not supported by the registry")
+
+// Interface is the common operations of the image registries
+type Interface interface {
+	// Type returns the type of the registry
+	Type() Type
+	// ListTags returns all the tags of the repository
+	ListTags(ctx context.Context, repository string) ([]string, error)
+	// GetDigest returns the digest of the manifest of the reference, which is a tag or a digest
+	GetDigest(ctx context.Context, repository, reference string) (string, error)
+	// GetVulnerabilities returns the summary of the latest vulnerability scan of the reference
+	GetVulnerabilities(ctx context.Context, repository, reference string) (*VulnerabilityReport, error)
+	// GetWebURL returns the link of the reference on the web portal of the registry
+	GetWebURL(ctx context.Context, repository, reference string) (string, error)
+}
+
+// Credential is the username and password, or the token, to access a registry
+type Credential struct {
+	Username string
+	Password string
+}
+
+// VulnerabilityReport is the summary of a vulnerability scan
+type VulnerabilityReport struct {
+	// Scanner is the name of the scanner, such as Trivy
+	Scanner string `json:"scanner,omitempty"`
+	// Status is the status of the scan, such as Success or Running
+	Status string `json:"status,omitempty"`
+	// Severity is the highest severity of the vulnerabilities
+	Severity string `json:"severity,omitempty"`
+	// Total is the number of the vulnerabilities
+	Total int `json:"total"`
+	// Fixable is the number of the vulnerabilities which have fixed versions
+	Fixable int `json:"fixable"`
+	// Summary is the number of the vulnerabilities by their severities
+	Summary map[string]int `json:"summary,omitempty"`
+	// CompleteTime is the time when the scan completed
+	CompleteTime *time.Time `json:"completeTime,omitempty"`
+}
+
+// Reference is a parsed image reference, such as docker.io/library/nginx:1.21
+type Reference struct {
+	// Registry is the host of the registry, docker.io is taken if the image has no registry host
+	Registry string
+	// Repository is the path of the image in the registry
+	Repository string
+	// Tag is empty if the image has only the digest
+	Tag string
+	// Digest is empty if the image has no digest
+	Digest string
+}
+
+// ParseReference parses the image reference, the tag latest is taken if there is neither a tag nor a digest
+func ParseReference(image string) (ref *Reference, err error) {
+	if image == "" || strings.ContainsAny(image, " \t\n") {
+		err = fmt.Errorf("invalid image reference %q", image)
+		return
+	}
+	ref = &Reference{}
+	if index := strings.Index(image, "@"); index >= 0 {
+		image, ref.Digest = image[:index], image[index+1:]
+	}
+	if index := strings.LastIndex(image, ":"); index > strings.LastIndex(image, "/") {
+		image, ref.Tag = image[:index], image[index+1:]
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = "latest"
+	}
+
+	// the first component is the registry host if it looks like a host
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		ref.Registry, ref.Repository = parts[0], parts[1]
+	} else {
+		ref.Registry, ref.Repository = DockerHubRegistry, image
+	}
+	if IsDockerHub(ref.Registry) {
+		ref.Registry = DockerHubRegistry
+		if !strings.Contains(ref.Repository, "/") {
+			ref.Repository = "library/" + ref.Repository
+		}
+	}
+	if ref.Repository == "" || strings.HasPrefix(ref.Repository, "/") || strings.HasSuffix(ref.Repository, "/") {
+		err = fmt.Errorf("invalid image reference %q", image)
+	}
+	return
+}
+
+// Identifier returns the digest if it exists, otherwise returns the tag
+func (r *Reference) Identifier() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+	return r.Tag
+}
+
+// Name returns the image name without the tag and the digest
+func (r *Reference) Name() string {
+	return r.Registry + "/" + r.Repository
+}
+
+// The suffixes of the tags which are the attachments of an image pushed by cosign
+const (
+	SignatureSuffix   = "sig"
+	AttestationSuffix = "att"
+	SBOMSuffix        = "sbom"
+)
+
+// AttachmentTag returns the tag of an attachment of the image with the digest, such as sha256-abc.sbom.
+// It follows the convention of cosign.
+func AttachmentTag(digest, suffix string) string {
+	return strings.Replace(digest, ":", "-", 1) + "." + suffix
+}
+
+// IsDockerHub indicates if the registry host is Docker Hub
+func IsDockerHub(registry string) bool {
+	switch strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(registry, "https://"), "http://"), "/") {
+	case DockerHubRegistry, "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		return true
+	}
+	return false
+}
+
+// isDigest indicates if the reference is a digest instead of a tag
+func isDigest(reference string) bool {
+	return strings.Contains(reference, ":")
+}
@@ -0,0 +1,223 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// pageSize is the number of tags of a page
+const pageSize = 100
+
+// maxPages avoids listing forever when a registry keeps returning the next link
+const maxPages = 100
+
+// manifestMediaTypes are the accepted media types of the manifests, the indexes come first
+// so that the digest of a multi-arch image is the one of its index
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+var (
+	// nextLinkPattern matches the next page from the Link header, such as </v2/app/tags/list?n=100&last=v1>; rel="next"
+	nextLinkPattern = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+	// challengeParamPattern matches the parameters of the WWW-Authenticate header
+	challengeParamPattern = regexp.MustCompile(`(\w+)="([^"]*)"`)
+)
+
+// ociClient talks to the registries via the OCI distribution API, see also
+// https://github.com/opencontainers/distribution-spec/blob/main/spec.md
+type ociClient struct {
+	server     string
+	credential *Credential
+	client     *http.Client
+}
+
+// NewOCIClient creates a client of a generic registry, the server is the URL of the registry
+func NewOCIClient(server string, credential *Credential, client *http.Client) Interface {
+	return newOCIClient(server, credential, client)
+}
+
+func newOCIClient(server string, credential *Credential, client *http.Client) *ociClient {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &ociClient{server: strings.TrimSuffix(server, "/"), credential: credential, client: client}
+}
+
+func (c *ociClient) Type() Type {
+	return OCI
+}
+
+func (c *ociClient) ListTags(ctx context.Context, repository string) (tags []string, err error) {
+	next := fmt.Sprintf("/v2/%s/tags/list?n=%d", repository, pageSize)
+	for page := 0; page < maxPages && next != ""; page++ {
+		var resp *http.Response
+		if resp, err = c.do(ctx, http.MethodGet, c.server+next, nil); err != nil {
+			return
+		}
+		result := &struct {
+			Tags []string `json:"tags"`
+		}{}
+		err = json.NewDecoder(resp.Body).Decode(result)
+		_ = resp.Body.Close()
+		if err != nil {
+			return
+		}
+		tags = append(tags, result.Tags...)
+
+		next = ""
+		if matches := nextLinkPattern.FindStringSubmatch(resp.Header.Get("Link")); len(matches) == 2 {
+			next = matches[1]
+		}
+	}
+	return
+}
+
+func (c *ociClient) GetDigest(ctx context.Context, repository, reference string) (digest string, err error) {
+	if isDigest(reference) {
+		return reference, nil
+	}
+	header := http.Header{"Accept": []string{strings.Join(manifestMediaTypes, ", ")}}
+	var resp *http.Response
+	if resp, err = c.do(ctx, http.MethodHead, fmt.Sprintf("%s/v2/%s/manifests/%s", c.server, repository, reference), header); err != nil {
+		return
+	}
+	_ = resp.Body.Close()
+	if digest = resp.Header.Get("Docker-Content-Digest"); digest == "" {
+		err = fmt.Errorf("no digest of %s:%s in the response", repository, reference)
+	}
+	return
+}
+
+func (c *ociClient) GetVulnerabilities(context.Context, string, string) (*VulnerabilityReport, error) {
+	return nil, ErrNotSupported
+}
+
+func (c *ociClient) GetWebURL(context.Context, string, string) (string, error) {
+	return "", ErrNotSupported
+}
+
+// do sends the request, then authenticates by the challenge if the registry requires it
+func (c *ociClient) do(ctx context.Context, method, address string, header http.Header) (resp *http.Response, err error) {
+	var req *http.Request
+	if req, err = newRequest(ctx, method, address, header); err != nil {
+		return
+	}
+	if resp, err = c.client.Do(req); err != nil {
+		return
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		_ = resp.Body.Close()
+
+		if req, err = newRequest(ctx, method, address, header); err != nil {
+			return
+		}
+		if err = c.authorize(ctx, req, challenge); err != nil {
+			return
+		}
+		if resp, err = c.client.Do(req); err != nil {
+			return
+		}
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		err = fmt.Errorf("unexpected status code %d from %s: %s", resp.StatusCode, address, readError(resp))
+		_ = resp.Body.Close()
+	}
+	return
+}
+
+// authorize sets the authorization header by the challenge, it's the basic auth or a bearer token
+func (c *ociClient) authorize(ctx context.Context, req *http.Request, challenge string) (err error) {
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		if c.credential == nil {
+			return fmt.Errorf("the registry requires a credential")
+		}
+		req.SetBasicAuth(c.credential.Username, c.credential.Password)
+		return
+	}
+
+	params := map[string]string{}
+	for _, matches := range challengeParamPattern.FindAllStringSubmatch(challenge, -1) {
+		params[matches[1]] = matches[2]
+	}
+	if params["realm"] == "" {
+		return fmt.Errorf("no realm in the challenge %q", challenge)
+	}
+	query := url.Values{}
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+	var tokenReq *http.Request
+	if tokenReq, err = newRequest(ctx, http.MethodGet, params["realm"]+"?"+query.Encode(), nil); err != nil {
+		return
+	}
+	if c.credential != nil {
+		tokenReq.SetBasicAuth(c.credential.Username, c.credential.Password)
+	}
+	var resp *http.Response
+	if resp, err = c.client.Do(tokenReq); err != nil {
+		return
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, params["realm"])
+	}
+
+	token := &struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err = json.NewDecoder(resp.Body).Decode(token); err != nil {
+		return
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	req.Header.Set("Authorization", "Bearer "+token.Token)
+	return
+}
+
+func newRequest(ctx context.Context, method, address string, header http.Header) (req *http.Request, err error) {
+	if req, err = http.NewRequestWithContext(ctx, method, address, nil); err == nil {
+		for key, values := range header {
+			req.Header[key] = values
+		}
+	}
+	return
+}
+
+// readError reads the error message from the response body for troubleshooting
+func readError(resp *http.Response) string {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,264 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		image   string
+		want    *Reference
+		wantErr bool
+	}{{
+		image: "nginx",
+		want:  &Reference{Registry: "docker.io", Repository: "library/nginx", Tag: "latest"},
+	}, {
+		image: "kubesphere/ks-devops:v3.4",
+		want:  &Reference{Registry: "docker.io", Repository: "kubesphere/ks-devops", Tag: "v3.4"},
+	}, {
+		image: "index.docker.io/nginx@sha256:abc",
+		want:  &Reference{Registry: "docker.io", Repository: "library/nginx", Digest: "sha256:abc"},
+	}, {
+		image: "harbor.example.com:8443/devops/app:v1@sha256:abc",
+		want:  &Reference{Registry: "harbor.example.com:8443", Repository: "devops/app", Tag: "v1", Digest: "sha256:abc"},
+	}, {
+		image: "localhost/app",
+		want:  &Reference{Registry: "localhost", Repository: "app", Tag: "latest"},
+	}, {
+		image:   "",
+		wantErr: true,
+	}, {
+		image:   "registry.example.com/",
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			ref, err := ParseReference(tt.image)
+			assert.Equal(t, tt.wantErr, err != nil, err)
+			if !tt.wantErr {
+				assert.Equal(t, tt.want, ref)
+			}
+		})
+	}
+
+	ref, _ := ParseReference("harbor.example.com/devops/app:v1@sha256:abc")
+	assert.Equal(t, "sha256:abc", ref.Identifier())
+	assert.Equal(t, "harbor.example.com/devops/app", ref.Name())
+}
+
+func TestAttachmentTag(t *testing.T) {
+	assert.Equal(t, "sha256-abc.sbom", AttachmentTag("sha256:abc", SBOMSuffix))
+	assert.Equal(t, "sha256-abc.att", AttachmentTag("sha256:abc", AttestationSuffix))
+}
+
+func TestOCIClient(t *testing.T) {
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ := r.BasicAuth()
+		assert.Equal(t, "admin:secret", username+":"+password)
+		assert.Equal(t, "repository:devops/app:pull", r.URL.Query().Get("scope"))
+		_, _ = io.WriteString(w, `{"token":"token"}`)
+	})
+	mux.HandleFunc("/v2/devops/app/tags/list", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="registry",scope="repository:devops/app:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("last") == "" {
+			w.Header().Set("Link", `</v2/devops/app/tags/list?n=100&last=v1>; rel="next"`)
+			_, _ = io.WriteString(w, `{"name":"devops/app","tags":["v1"]}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"name":"devops/app","tags":["v2"]}`)
+	})
+	mux.HandleFunc("/v2/devops/app/manifests/v2", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodHead, r.Method)
+		assert.Contains(t, r.Header.Get("Accept"), "application/vnd.oci.image.index.v1+json")
+		username, _, ok := r.BasicAuth()
+		if !ok || username != "admin" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Docker-Content-Digest", "sha256:v2")
+	})
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient(OCI, server.URL, &Credential{Username: "admin", Password: "secret"}, nil)
+	assert.Equal(t, OCI, client.Type())
+
+	tags, err := client.ListTags(context.TODO(), "devops/app")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"v1", "v2"}, tags)
+
+	digest, err := client.GetDigest(context.TODO(), "devops/app", "v2")
+	assert.Nil(t, err)
+	assert.Equal(t, "sha256:v2", digest)
+
+	digest, err = client.GetDigest(context.TODO(), "devops/app", "sha256:v1")
+	assert.Nil(t, err)
+	assert.Equal(t, "sha256:v1", digest)
+
+	_, err = client.GetDigest(context.TODO(), "devops/missing", "v1")
+	assert.NotNil(t, err)
+
+	_, err = client.GetVulnerabilities(context.TODO(), "devops/app", "v2")
+	assert.Equal(t, ErrNotSupported, err)
+	_, err = client.GetWebURL(context.TODO(), "devops/app", "v2")
+	assert.Equal(t, ErrNotSupported, err)
+
+	// no credential for the basic auth
+	_, err = NewClient(OCI, server.URL, nil, nil).GetDigest(context.TODO(), "devops/app", "v2")
+	assert.NotNil(t, err)
+}
+
+func TestHarborClient(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2.0/systeminfo", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"harbor_version":"v2.5.0"}`)
+	})
+	mux.HandleFunc("/api/v2.0/projects/devops/repositories/", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v2.0/projects/devops/repositories/web%252Fapp/artifacts/v1", r.URL.EscapedPath())
+		assert.Equal(t, "true", r.URL.Query().Get("with_scan_overview"))
+		assert.Equal(t, harborReportMimeType, r.Header.Get("X-Accept-Vulnerabilities"))
+		_, _ = io.WriteString(w, `{"digest":"sha256:v1","scan_overview":{"`+harborReportMimeType+`":{
+"scan_status":"Success","severity":"High","end_time":"2023-01-02T03:04:05Z","scanner":{"name":"Trivy"},
+"summary":{"total":3,"fixable":1,"summary":{"High":1,"Low":2}}}}}`)
+	})
+	mux.HandleFunc("/api/v2.0/projects/devops", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "true", r.Header.Get("X-Is-Resource-Name"))
+		_, _ = io.WriteString(w, `{"project_id":3,"name":"devops"}`)
+	})
+	mux.HandleFunc("/v2/devops/web/app/manifests/v1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Docker-Content-Digest", "sha256:v1")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	assert.Equal(t, Harbor, DetectType(context.TODO(), server.URL, nil))
+	client := NewClient(Harbor, server.URL, nil, nil)
+	assert.Equal(t, Harbor, client.Type())
+
+	report, err := client.GetVulnerabilities(context.TODO(), "devops/web/app", "v1")
+	assert.Nil(t, err)
+	if assert.NotNil(t, report) {
+		assert.Equal(t, "Trivy", report.Scanner)
+		assert.Equal(t, "High", report.Severity)
+		assert.Equal(t, 3, report.Total)
+		assert.Equal(t, 1, report.Fixable)
+		assert.Equal(t, map[string]int{"High": 1, "Low": 2}, report.Summary)
+		assert.NotNil(t, report.CompleteTime)
+	}
+
+	link, err := client.GetWebURL(context.TODO(), "devops/web/app", "v1")
+	assert.Nil(t, err)
+	assert.Equal(t, server.URL+"/harbor/projects/3/repositories/web%2Fapp/artifacts-tab/artifacts/sha256:v1", link)
+
+	_, err = client.GetVulnerabilities(context.TODO(), "app", "v1")
+	assert.NotNil(t, err)
+}
+
+func TestDockerHub(t *testing.T) {
+	assert.Equal(t, DockerHub, DetectType(context.TODO(), "docker.io", nil))
+	client := NewClient(DockerHub, "docker.io", nil, nil)
+	assert.Equal(t, DockerHub, client.Type())
+
+	link, err := client.GetWebURL(context.TODO(), "library/nginx", "1.21")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://hub.docker.com/_/nginx/tags?name=1.21", link)
+	link, err = client.GetWebURL(context.TODO(), "kubesphere/ks-devops", "sha256:abc")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://hub.docker.com/r/kubesphere/ks-devops/tags", link)
+}
+
+func TestDetectType(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	assert.Equal(t, OCI, DetectType(context.TODO(), server.URL, nil))
+	assert.Equal(t, "https://registry.example.com", ServerURL("registry.example.com/"))
+}
+
+func TestGetCredential(t *testing.T) {
+	auth := base64.StdEncoding.EncodeToString([]byte("robot:token"))
+	tests := []struct {
+		name     string
+		secret   *v1.Secret
+		registry string
+		want     *Credential
+	}{{
+		name: "basic auth",
+		secret: &v1.Secret{Type: v1.SecretTypeBasicAuth, Data: map[string][]byte{
+			v1.BasicAuthUsernameKey: []byte("admin"), v1.BasicAuthPasswordKey: []byte("secret"),
+		}},
+		registry: "harbor.example.com",
+		want:     &Credential{Username: "admin", Password: "secret"},
+	}, {
+		name: "docker config json",
+		secret: &v1.Secret{Type: v1.SecretTypeDockerConfigJson, Data: map[string][]byte{
+			v1.DockerConfigJsonKey: []byte(`{"auths":{"https://harbor.example.com":{"auth":"` + auth + `"}}}`),
+		}},
+		registry: "harbor.example.com",
+		want:     &Credential{Username: "robot", Password: "token"},
+	}, {
+		name: "Docker Hub in docker config json",
+		secret: &v1.Secret{Type: v1.SecretTypeDockerConfigJson, Data: map[string][]byte{
+			v1.DockerConfigJsonKey: []byte(`{"auths":{"https://index.docker.io/v1/":{"username":"user","password":"pass"}}}`),
+		}},
+		registry: "docker.io",
+		want:     &Credential{Username: "user", Password: "pass"},
+	}, {
+		name: "docker config",
+		secret: &v1.Secret{Type: v1.SecretTypeDockercfg, Data: map[string][]byte{
+			v1.DockerConfigKey: []byte(`{"harbor.example.com":{"username":"user","password":"pass"}}`),
+		}},
+		registry: "harbor.example.com",
+		want:     &Credential{Username: "user", Password: "pass"},
+	}, {
+		name: "other registries",
+		secret: &v1.Secret{Type: v1.SecretTypeDockerConfigJson, Data: map[string][]byte{
+			v1.DockerConfigJsonKey: []byte(`{"auths":{"quay.io":{"auth":"` + auth + `"}}}`),
+		}},
+		registry: "harbor.example.com",
+	}, {
+		name:     "opaque",
+		secret:   &v1.Secret{Type: v1.SecretTypeOpaque},
+		registry: "harbor.example.com",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, GetCredential(tt.secret, tt.registry))
+		})
+	}
+	assert.Nil(t, GetCredential(nil, "docker.io"))
+
+	secrets := []v1.Secret{*tests[5].secret, *tests[4].secret, *tests[1].secret}
+	assert.Equal(t, &Credential{Username: "robot", Password: "token"}, FindCredential(secrets, "harbor.example.com"))
+	assert.Nil(t, FindCredential(secrets, "docker.io"))
+}
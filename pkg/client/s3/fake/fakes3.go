@@ -17,20 +17,33 @@ limitations under the License.
 package fake
 
 import (
+	"bytes"
+	"crypto/md5"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"sort"
 
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/s3"
+
+	ks3 "kubesphere.io/devops/pkg/client/s3"
 )
 
 type FakeS3 struct {
 	Storage map[string]*Object
+	Uploads map[string]*MultipartUpload
+}
+
+// MultipartUpload is an in-progress multipart upload
+type MultipartUpload struct {
+	Key      string
+	FileName string
+	Parts    map[int64][]byte
 }
 
 func NewFakeS3(objects ...*Object) *FakeS3 {
-	s3 := &FakeS3{Storage: map[string]*Object{}}
+	s3 := &FakeS3{Storage: map[string]*Object{}, Uploads: map[string]*MultipartUpload{}}
 	for _, object := range objects {
 		s3.Storage[object.Key] = object
 	}
@@ -74,3 +87,80 @@ func (s *FakeS3) Read(key string) ([]byte, error) {
 	}
 	return nil, awserr.New(s3.ErrCodeNoSuchKey, "no such object", nil)
 }
+
+func (s *FakeS3) Open(key string) (io.ReadCloser, error) {
+	if o, ok := s.Storage[key]; ok && o.Body != nil {
+		return ioutil.NopCloser(o.Body), nil
+	}
+	return nil, awserr.New(s3.ErrCodeNoSuchKey, "no such object", nil)
+}
+
+func (s *FakeS3) CreateMultipartUpload(key, fileName string) (string, error) {
+	uploadID := fmt.Sprintf("%s-%d", key, len(s.Uploads)+1)
+	s.Uploads[uploadID] = &MultipartUpload{Key: key, FileName: fileName, Parts: map[int64][]byte{}}
+	return uploadID, nil
+}
+
+func (s *FakeS3) UploadPart(key, uploadID string, partNumber int64, body io.ReadSeeker) (*ks3.Part, error) {
+	upload, err := s.getUpload(key, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	upload.Parts[partNumber] = data
+	return &ks3.Part{PartNumber: partNumber, ETag: getETag(data), Size: int64(len(data))}, nil
+}
+
+func (s *FakeS3) ListParts(key, uploadID string) (parts []ks3.Part, err error) {
+	var upload *MultipartUpload
+	if upload, err = s.getUpload(key, uploadID); err != nil {
+		return
+	}
+	for number, data := range upload.Parts {
+		parts = append(parts, ks3.Part{PartNumber: number, ETag: getETag(data), Size: int64(len(data))})
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].PartNumber < parts[j].PartNumber
+	})
+	return
+}
+
+func (s *FakeS3) CompleteMultipartUpload(key, uploadID string, parts []ks3.Part) error {
+	upload, err := s.getUpload(key, uploadID)
+	if err != nil {
+		return err
+	}
+	buf := &bytes.Buffer{}
+	for _, part := range parts {
+		data, ok := upload.Parts[part.PartNumber]
+		if !ok || getETag(data) != part.ETag {
+			return awserr.New("InvalidPart", "invalid part", nil)
+		}
+		buf.Write(data)
+	}
+	delete(s.Uploads, uploadID)
+	s.Storage[key] = &Object{Key: key, FileName: upload.FileName, Body: buf}
+	return nil
+}
+
+func (s *FakeS3) AbortMultipartUpload(key, uploadID string) error {
+	if _, err := s.getUpload(key, uploadID); err != nil {
+		return err
+	}
+	delete(s.Uploads, uploadID)
+	return nil
+}
+
+func (s *FakeS3) getUpload(key, uploadID string) (*MultipartUpload, error) {
+	if upload, ok := s.Uploads[uploadID]; ok && upload.Key == key {
+		return upload, nil
+	}
+	return nil, awserr.New(s3.ErrCodeNoSuchUpload, "no such upload", nil)
+}
+
+func getETag(data []byte) string {
+	return fmt.Sprintf("\"%x\"", md5.Sum(data))
+}
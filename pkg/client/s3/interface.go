@@ -24,6 +24,9 @@ type Interface interface {
 	//read the content, caller should close the io.ReadCloser.
 	Read(key string) ([]byte, error)
 
+	// Open streams the content of an object, the caller should close the io.ReadCloser
+	Open(key string) (io.ReadCloser, error)
+
 	// Upload uploads a object to storage and returns object location if succeeded
 	Upload(key, fileName string, body io.Reader) error
 
@@ -31,4 +34,26 @@ type Interface interface {
 
 	// Delete deletes an object by its key
 	Delete(key string) error
+
+	// CreateMultipartUpload starts a multipart upload and returns its upload ID
+	CreateMultipartUpload(key, fileName string) (string, error)
+
+	// UploadPart uploads a part of a multipart upload, the part number starts from 1
+	UploadPart(key, uploadID string, partNumber int64, body io.ReadSeeker) (*Part, error)
+
+	// ListParts lists the parts which have been uploaded
+	ListParts(key, uploadID string) ([]Part, error)
+
+	// CompleteMultipartUpload assembles the uploaded parts into the object
+	CompleteMultipartUpload(key, uploadID string, parts []Part) error
+
+	// AbortMultipartUpload aborts a multipart upload and discards the uploaded parts
+	AbortMultipartUpload(key, uploadID string) error
+}
+
+// Part is an uploaded part of a multipart upload
+type Part struct {
+	PartNumber int64  `json:"partNumber"`
+	ETag       string `json:"etag"`
+	Size       int64  `json:"size"`
 }
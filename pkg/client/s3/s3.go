@@ -68,6 +68,17 @@ func (s *Client) Read(key string) ([]byte, error) {
 	return writer.Bytes(), nil
 }
 
+func (s *Client) Open(key string) (io.ReadCloser, error) {
+	output, err := s.s3Client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output.Body, nil
+}
+
 func (s *Client) GetDownloadURL(key string, fileName string) (string, error) {
 	req, _ := s.s3Client.GetObjectRequest(&s3.GetObjectInput{
 		Bucket:                     aws.String(s.bucket),
@@ -88,6 +99,83 @@ func (s *Client) Delete(key string) error {
 	return nil
 }
 
+func (s *Client) CreateMultipartUpload(key, fileName string) (string, error) {
+	output, err := s.s3Client.CreateMultipartUpload(&s3.CreateMultipartUploadInput{
+		Bucket:             aws.String(s.bucket),
+		Key:                aws.String(key),
+		ContentDisposition: aws.String(fmt.Sprintf("attachment; filename=\"%s\"", fileName)),
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(output.UploadId), nil
+}
+
+func (s *Client) UploadPart(key, uploadID string, partNumber int64, body io.ReadSeeker) (*Part, error) {
+	size, err := body.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = body.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	output, err := s.s3Client.UploadPart(&s3.UploadPartInput{
+		Bucket:     aws.String(s.bucket),
+		Key:        aws.String(key),
+		UploadId:   aws.String(uploadID),
+		PartNumber: aws.Int64(partNumber),
+		Body:       body,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Part{PartNumber: partNumber, ETag: aws.StringValue(output.ETag), Size: size}, nil
+}
+
+func (s *Client) ListParts(key, uploadID string) (parts []Part, err error) {
+	err = s.s3Client.ListPartsPages(&s3.ListPartsInput{
+		Bucket:   aws.String(s.bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	}, func(output *s3.ListPartsOutput, lastPage bool) bool {
+		for _, part := range output.Parts {
+			parts = append(parts, Part{
+				PartNumber: aws.Int64Value(part.PartNumber),
+				ETag:       aws.StringValue(part.ETag),
+				Size:       aws.Int64Value(part.Size),
+			})
+		}
+		return true
+	})
+	return
+}
+
+func (s *Client) CompleteMultipartUpload(key, uploadID string, parts []Part) error {
+	completed := make([]*s3.CompletedPart, 0, len(parts))
+	for _, part := range parts {
+		completed = append(completed, &s3.CompletedPart{
+			ETag:       aws.String(part.ETag),
+			PartNumber: aws.Int64(part.PartNumber),
+		})
+	}
+	_, err := s.s3Client.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.bucket),
+		Key:             aws.String(key),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: completed},
+	})
+	return err
+}
+
+func (s *Client) AbortMultipartUpload(key, uploadID string) error {
+	_, err := s.s3Client.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(s.bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	})
+	return err
+}
+
 func NewS3Client(options *Options) (Interface, error) {
 	cred := credentials.NewStaticCredentials(options.AccessKeyID, options.SecretAccessKey, options.SessionToken)
 
@@ -0,0 +1,113 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	// ClamAVName is the name of the ClamAV scanner
+	ClamAVName = "clamav"
+
+	clamAVChunkSize = 64 * 1024
+)
+
+type clamAV struct {
+	address string
+	timeout time.Duration
+}
+
+// NewClamAV creates a scanner which sends the files to clamd via the INSTREAM command
+func NewClamAV(address string, timeout time.Duration) Interface {
+	return &clamAV{address: address, timeout: timeout}
+}
+
+func (c *clamAV) Name() string {
+	return ClamAVName
+}
+
+func (c *clamAV) Scan(ctx context.Context, fileName string, body io.Reader) (verdict *Verdict, err error) {
+	dialer := &net.Dialer{Timeout: c.timeout}
+	var conn net.Conn
+	if conn, err = dialer.DialContext(ctx, "tcp", c.address); err != nil {
+		return
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	} else if c.timeout > 0 {
+		_ = conn.SetDeadline(time.Now().Add(c.timeout))
+	}
+
+	if _, err = conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return
+	}
+	buf := make([]byte, clamAVChunkSize)
+	size := make([]byte, 4)
+	for {
+		n, readErr := body.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err = conn.Write(size); err != nil {
+				return
+			}
+			if _, err = conn.Write(buf[:n]); err != nil {
+				return
+			}
+		}
+		if readErr == io.EOF {
+			break
+		} else if readErr != nil {
+			err = readErr
+			return
+		}
+	}
+	// a zero-length chunk terminates the stream
+	if _, err = conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return
+	}
+
+	var reply string
+	if reply, err = bufio.NewReader(conn).ReadString(0); err != nil && err != io.EOF {
+		return
+	}
+	return parseClamAVReply(strings.TrimRight(reply, "\x00\n"))
+}
+
+// parseClamAVReply parses the reply like "stream: OK" or "stream: Eicar-Signature FOUND"
+func parseClamAVReply(reply string) (verdict *Verdict, err error) {
+	result := strings.TrimSpace(strings.TrimPrefix(reply, "stream:"))
+	switch {
+	case result == "OK":
+		verdict = &Verdict{Clean: true}
+	case strings.HasSuffix(result, " FOUND"):
+		verdict = &Verdict{Signature: strings.TrimSuffix(result, " FOUND")}
+	default:
+		err = fmt.Errorf("unexpected reply from clamd: %q", reply)
+	}
+	return
+}
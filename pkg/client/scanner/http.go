@@ -0,0 +1,78 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const (
+	// HTTPName is the name of the external HTTP scanner
+	HTTPName = "http"
+
+	// FileNameHeader is the header which carries the name of the scanned file
+	FileNameHeader = "X-File-Name"
+)
+
+type httpScanner struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPScanner creates a scanner which posts the files to an external service,
+// the service is expected to respond a JSON Verdict
+func NewHTTPScanner(url string, client *http.Client) Interface {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &httpScanner{url: url, client: client}
+}
+
+func (h *httpScanner) Name() string {
+	return HTTPName
+}
+
+func (h *httpScanner) Scan(ctx context.Context, fileName string, body io.Reader) (verdict *Verdict, err error) {
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctx, http.MethodPost, h.url, body); err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set(FileNameHeader, fileName)
+
+	var resp *http.Response
+	if resp, err = h.client.Do(req); err != nil {
+		return
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("unexpected status code from the scanner: %d", resp.StatusCode)
+		return
+	}
+
+	verdict = &Verdict{}
+	if err = json.NewDecoder(resp.Body).Decode(verdict); err != nil {
+		verdict = nil
+	}
+	return
+}
@@ -0,0 +1,39 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scanner
+
+import (
+	"context"
+	"io"
+)
+
+// Verdict is the result of scanning a file
+type Verdict struct {
+	// Clean indicates that no malware was found
+	Clean bool `json:"clean"`
+	// Signature is the name of the malware which was found
+	Signature string `json:"signature,omitempty"`
+}
+
+// Interface scans the files for viruses and malware
+type Interface interface {
+	// Name returns the name of the scanner
+	Name() string
+
+	// Scan scans the content of a file
+	Scan(ctx context.Context, fileName string, body io.Reader) (*Verdict, error)
+}
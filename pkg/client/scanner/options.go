@@ -0,0 +1,62 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scanner
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// Options contains the configuration of the scanner for the uploaded files
+type Options struct {
+	ClamAVAddress string        `json:"clamAVAddress,omitempty" yaml:"clamAVAddress"`
+	HTTPScanner   string        `json:"httpScanner,omitempty" yaml:"httpScanner"`
+	Timeout       time.Duration `json:"timeout,omitempty" yaml:"timeout"`
+}
+
+// NewOptions creates an Options without any scanner
+func NewOptions() *Options {
+	return &Options{
+		Timeout: 5 * time.Minute,
+	}
+}
+
+// AddFlags adds the flags of the options
+func (o *Options) AddFlags(fs *pflag.FlagSet, c *Options) {
+	fs.StringVar(&o.ClamAVAddress, "clamav-address", c.ClamAVAddress, ""+
+		"The address of clamd, e.g. clamav.kubesphere-devops-system:3310. The uploaded S2iBinaries are "+
+		"scanned by ClamAV if it's not empty.")
+	fs.StringVar(&o.HTTPScanner, "http-scanner", c.HTTPScanner, ""+
+		"The URL of an external scanner, the uploaded S2iBinaries are posted to it if it's not empty. "+
+		"It takes effect only if clamav-address is empty.")
+	fs.DurationVar(&o.Timeout, "scan-timeout", c.Timeout, "The timeout of scanning a file.")
+}
+
+// NewScanner creates the configured scanner, it returns nil if there is no one
+func (o *Options) NewScanner() Interface {
+	switch {
+	case o == nil:
+		return nil
+	case o.ClamAVAddress != "":
+		return NewClamAV(o.ClamAVAddress, o.Timeout)
+	case o.HTTPScanner != "":
+		return NewHTTPScanner(o.HTTPScanner, &http.Client{Timeout: o.Timeout})
+	}
+	return nil
+}
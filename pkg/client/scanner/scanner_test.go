@@ -0,0 +1,158 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// startFakeClamd starts a clamd which finds the malware if the stream contains "EICAR"
+func startFakeClamd(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer func() {
+					_ = conn.Close()
+				}()
+				reader := bufio.NewReader(conn)
+				if command, err := reader.ReadString(0); err != nil || command != "zINSTREAM\x00" {
+					_, _ = conn.Write([]byte("UNKNOWN COMMAND\x00"))
+					return
+				}
+				var content strings.Builder
+				size := make([]byte, 4)
+				for {
+					if _, err := io.ReadFull(reader, size); err != nil {
+						return
+					}
+					length := binary.BigEndian.Uint32(size)
+					if length == 0 {
+						break
+					}
+					chunk := make([]byte, length)
+					if _, err := io.ReadFull(reader, chunk); err != nil {
+						return
+					}
+					content.Write(chunk)
+				}
+				if strings.Contains(content.String(), "EICAR") {
+					_, _ = conn.Write([]byte("stream: Eicar-Signature FOUND\x00"))
+				} else {
+					_, _ = conn.Write([]byte("stream: OK\x00"))
+				}
+			}(conn)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestClamAV(t *testing.T) {
+	scanner := NewClamAV(startFakeClamd(t), time.Minute)
+	assert.Equal(t, ClamAVName, scanner.Name())
+
+	verdict, err := scanner.Scan(context.TODO(), "clean.jar", strings.NewReader(strings.Repeat("a", clamAVChunkSize*2+1)))
+	assert.Nil(t, err)
+	assert.Equal(t, &Verdict{Clean: true}, verdict)
+
+	verdict, err = scanner.Scan(context.TODO(), "infected.jar", strings.NewReader("X5O!P%@AP-EICAR"))
+	assert.Nil(t, err)
+	assert.Equal(t, &Verdict{Signature: "Eicar-Signature"}, verdict)
+
+	// clamd is not available
+	_, err = NewClamAV("127.0.0.1:1", time.Second).Scan(context.TODO(), "clean.jar", strings.NewReader("a"))
+	assert.NotNil(t, err)
+}
+
+func TestParseClamAVReply(t *testing.T) {
+	verdict, err := parseClamAVReply("stream: OK")
+	assert.Nil(t, err)
+	assert.True(t, verdict.Clean)
+
+	verdict, err = parseClamAVReply("stream: Win.Test.EICAR_HDB-1 FOUND")
+	assert.Nil(t, err)
+	assert.False(t, verdict.Clean)
+	assert.Equal(t, "Win.Test.EICAR_HDB-1", verdict.Signature)
+
+	_, err = parseClamAVReply("INSTREAM size limit exceeded. ERROR")
+	assert.NotNil(t, err)
+}
+
+func TestHTTPScanner(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		switch r.Header.Get(FileNameHeader) {
+		case "error.jar":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "invalid.jar":
+			_, _ = w.Write([]byte("invalid"))
+		default:
+			_ = json.NewEncoder(w).Encode(&Verdict{
+				Clean:     string(data) == "clean",
+				Signature: strings.TrimPrefix(string(data), "infected:"),
+			})
+		}
+	}))
+	defer server.Close()
+
+	scanner := NewHTTPScanner(server.URL, nil)
+	assert.Equal(t, HTTPName, scanner.Name())
+
+	verdict, err := scanner.Scan(context.TODO(), "clean.jar", strings.NewReader("clean"))
+	assert.Nil(t, err)
+	assert.True(t, verdict.Clean)
+
+	verdict, err = scanner.Scan(context.TODO(), "infected.jar", strings.NewReader("infected:trojan"))
+	assert.Nil(t, err)
+	assert.False(t, verdict.Clean)
+	assert.Equal(t, "trojan", verdict.Signature)
+
+	_, err = scanner.Scan(context.TODO(), "error.jar", strings.NewReader("clean"))
+	assert.NotNil(t, err)
+	_, err = scanner.Scan(context.TODO(), "invalid.jar", strings.NewReader("clean"))
+	assert.NotNil(t, err)
+}
+
+func TestOptions_NewScanner(t *testing.T) {
+	var nilOptions *Options
+	assert.Nil(t, nilOptions.NewScanner())
+	assert.Nil(t, NewOptions().NewScanner())
+	assert.Equal(t, ClamAVName, (&Options{ClamAVAddress: "clamav:3310", HTTPScanner: "http://scanner"}).NewScanner().Name())
+	assert.Equal(t, HTTPName, (&Options{HTTPScanner: "http://scanner"}).NewScanner().Name())
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scm
+
+import (
+	"context"
+
+	goscm "github.com/jenkins-x/go-scm/scm"
+)
+
+// The names of the supported SCM providers
+const (
+	GitHub          = "github"
+	GitLab          = "gitlab"
+	BitbucketCloud  = "bitbucket_cloud"
+	BitbucketServer = "bitbucket-server"
+	Gitea           = "gitea"
+)
+
+// Provider is the common operations of the SCM providers, such as GitHub, GitLab, Bitbucket and Gitea.
+// It's shared by the multi-branch discovery, webhook triggering and commit status reporting of all backends.
+type Provider interface {
+	// ListBranches returns all the branches of the repository
+	ListBranches(ctx context.Context, repo string) ([]*goscm.Reference, error)
+	// ListPullRequests returns all the open pull requests of the repository
+	ListPullRequests(ctx context.Context, repo string) ([]*goscm.PullRequest, error)
+	// GetFileContents returns the content of the file at the given ref
+	GetFileContents(ctx context.Context, repo, path, ref string) ([]byte, error)
+	// CreateStatus creates or updates the commit status of the given ref
+	CreateStatus(ctx context.Context, repo, ref string, status *goscm.StatusInput) error
+	// RegisterWebhook creates the webhook if there is no webhook with the same target
+	RegisterWebhook(ctx context.Context, repo string, hook *goscm.HookInput) error
+	// ListCommits returns the commits of the ref after the since ref, the latest commit comes first.
+	// All the commits of the ref are returned if since is empty.
+	ListCommits(ctx context.Context, repo, ref, since string) ([]*goscm.Commit, error)
+	// ListChangedFiles returns the paths of the files which were changed between the base and the head commits,
+	// the previous paths of the renamed files are included
+	ListChangedFiles(ctx context.Context, repo, base, head string) ([]string, error)
+	// FindPullRequest returns the pull request by number
+	FindPullRequest(ctx context.Context, repo string, number int) (*goscm.PullRequest, error)
+	// CreateRelease creates a release, the tag is created from the commitish if it does not exist
+	CreateRelease(ctx context.Context, repo string, release *goscm.ReleaseInput) (*goscm.Release, error)
+	// ListTags returns all the tags of the repository
+	ListTags(ctx context.Context, repo string) ([]*goscm.Reference, error)
+	// CreateTag creates a lightweight tag which points to the given commit
+	CreateTag(ctx context.Context, repo, tag, sha string) error
+	// CreateComment comments on the pull request
+	CreateComment(ctx context.Context, repo string, number int, body string) error
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scm
+
+import (
+	"context"
+	"fmt"
+
+	goscm "github.com/jenkins-x/go-scm/scm"
+	v1 "k8s.io/api/core/v1"
+	"kubesphere.io/devops/pkg/client/git"
+)
+
+// pageSize is the maximum size of a page which is accepted by most of the providers
+const pageSize = 100
+
+// maxPages avoids listing forever when a provider ignores the page option
+const maxPages = 100
+
+// provider implements Provider with a go-scm client, the go-scm drivers take care of the differences
+// between GitHub, GitLab, Bitbucket and Gitea
+type provider struct {
+	client *goscm.Client
+}
+
+// NewProvider creates a Provider with a go-scm client
+func NewProvider(client *goscm.Client) Provider {
+	return &provider{client: client}
+}
+
+// NewProviderFromSecret creates a Provider by the name of provider, the server address, and the secret
+// which holds the token. The server could be empty for the public services, such as github.com.
+func NewProviderFromSecret(name, server string, secretRef *v1.SecretReference, k8sClient git.ResourceGetter) (Provider, error) {
+	factory := git.NewClientFactory(name, secretRef, k8sClient)
+	factory.Server = server
+	client, err := factory.GetClient()
+	if err != nil {
+		return nil, err
+	}
+	return NewProvider(client), nil
+}
+
+func (p *provider) ListBranches(ctx context.Context, repo string) (branches []*goscm.Reference, err error) {
+	for page := 1; page <= maxPages; page++ {
+		var items []*goscm.Reference
+		if items, _, err = p.client.Git.ListBranches(ctx, repo, &goscm.ListOptions{Page: page, Size: pageSize}); err != nil {
+			return
+		}
+		branches = append(branches, items...)
+		if len(items) < pageSize {
+			break
+		}
+	}
+	return
+}
+
+func (p *provider) ListPullRequests(ctx context.Context, repo string) (pullRequests []*goscm.PullRequest, err error) {
+	for page := 1; page <= maxPages; page++ {
+		var items []*goscm.PullRequest
+		if items, _, err = p.client.PullRequests.List(ctx, repo, &goscm.PullRequestListOptions{
+			Page: page, Size: pageSize, Open: true,
+		}); err != nil {
+			return
+		}
+		pullRequests = append(pullRequests, items...)
+		if len(items) < pageSize {
+			break
+		}
+	}
+	return
+}
+
+func (p *provider) GetFileContents(ctx context.Context, repo, path, ref string) (data []byte, err error) {
+	var content *goscm.Content
+	if content, _, err = p.client.Contents.Find(ctx, repo, path, ref); err == nil {
+		data = content.Data
+	}
+	return
+}
+
+func (p *provider) CreateStatus(ctx context.Context, repo, ref string, status *goscm.StatusInput) (err error) {
+	_, _, err = p.client.Repositories.CreateStatus(ctx, repo, ref, status)
+	return
+}
+
+func (p *provider) RegisterWebhook(ctx context.Context, repo string, hook *goscm.HookInput) (err error) {
+	for page := 1; page <= maxPages; page++ {
+		var hooks []*goscm.Hook
+		if hooks, _, err = p.client.Repositories.ListHooks(ctx, repo, &goscm.ListOptions{Page: page, Size: pageSize}); err != nil {
+			return
+		}
+		for _, item := range hooks {
+			if item.Target == hook.Target {
+				return
+			}
+		}
+		if len(hooks) < pageSize {
+			break
+		}
+	}
+	_, _, err = p.client.Repositories.CreateHook(ctx, repo, hook)
+	return
+}
+
+func (p *provider) ListCommits(ctx context.Context, repo, ref, since string) (commits []*goscm.Commit, err error) {
+	var sinceSha string
+	if since != "" {
+		// resolve the since ref by its latest commit, it works with branches, lightweight and annotated tags
+		var items []*goscm.Commit
+		if items, _, err = p.client.Git.ListCommits(ctx, repo, commitListOptions(since, 1, 1)); err != nil {
+			return
+		}
+		if len(items) == 0 {
+			err = fmt.Errorf("cannot find any commits of %s", since)
+			return
+		}
+		sinceSha = items[0].Sha
+	}
+
+	for page := 1; page <= maxPages; page++ {
+		var items []*goscm.Commit
+		if items, _, err = p.client.Git.ListCommits(ctx, repo, commitListOptions(ref, page, pageSize)); err != nil {
+			return
+		}
+		for _, item := range items {
+			if sinceSha != "" && item.Sha == sinceSha {
+				return
+			}
+			commits = append(commits, item)
+		}
+		if len(items) < pageSize {
+			break
+		}
+	}
+	return
+}
+
+func (p *provider) ListChangedFiles(ctx context.Context, repo, base, head string) (files []string, err error) {
+	found := map[string]bool{}
+	for page := 1; page <= maxPages; page++ {
+		var changes []*goscm.Change
+		if changes, _, err = p.client.Git.CompareCommits(ctx, repo, base, head, &goscm.ListOptions{Page: page, Size: pageSize}); err != nil {
+			return
+		}
+		count := len(files)
+		for _, change := range changes {
+			for _, file := range []string{change.Path, change.PreviousPath} {
+				if file != "" && !found[file] {
+					found[file] = true
+					files = append(files, file)
+				}
+			}
+		}
+		// some drivers ignore the page option and return all the changes every time
+		if len(changes) < pageSize || len(files) == count {
+			break
+		}
+	}
+	return
+}
+
+// commitListOptions sets both the ref and the sha, GitHub takes the sha as the ref while GitLab takes the ref
+func commitListOptions(ref string, page, size int) goscm.CommitListOptions {
+	return goscm.CommitListOptions{Ref: ref, Sha: ref, Page: page, Size: size}
+}
+
+func (p *provider) FindPullRequest(ctx context.Context, repo string, number int) (pullRequest *goscm.PullRequest, err error) {
+	pullRequest, _, err = p.client.PullRequests.Find(ctx, repo, number)
+	return
+}
+
+func (p *provider) CreateRelease(ctx context.Context, repo string, release *goscm.ReleaseInput) (created *goscm.Release, err error) {
+	created, _, err = p.client.Releases.Create(ctx, repo, release)
+	return
+}
+
+func (p *provider) ListTags(ctx context.Context, repo string) (tags []*goscm.Reference, err error) {
+	for page := 1; page <= maxPages; page++ {
+		var items []*goscm.Reference
+		if items, _, err = p.client.Git.ListTags(ctx, repo, &goscm.ListOptions{Page: page, Size: pageSize}); err != nil {
+			return
+		}
+		tags = append(tags, items...)
+		if len(items) < pageSize {
+			break
+		}
+	}
+	return
+}
+
+func (p *provider) CreateTag(ctx context.Context, repo, tag, sha string) (err error) {
+	if p.client.Driver == goscm.DriverGithub {
+		_, _, err = p.client.Git.CreateRef(ctx, repo, "refs/tags/"+tag, sha)
+		return
+	}
+	// the other drivers create branches by CreateRef, a release creates the tag as well
+	_, _, err = p.client.Releases.Create(ctx, repo, &goscm.ReleaseInput{Tag: tag, Title: tag, Commitish: sha})
+	return
+}
+
+func (p *provider) CreateComment(ctx context.Context, repo string, number int, body string) (err error) {
+	_, _, err = p.client.PullRequests.CreateComment(ctx, repo, number, &goscm.CommentInput{Body: body})
+	return
+}
@@ -0,0 +1,248 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scm
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	goscm "github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/driver/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func newGitHubProvider(t *testing.T, handler http.Handler) Provider {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client, err := github.New(server.URL)
+	assert.Nil(t, err)
+	return NewProvider(client)
+}
+
+func TestProvider_ListBranches(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/branches", func(w http.ResponseWriter, r *http.Request) {
+		// the first page is full, so the second page should be requested
+		count := pageSize
+		if r.URL.Query().Get("page") == "2" {
+			count = 1
+		}
+		branches := make([]string, count)
+		for i := range branches {
+			branches[i] = fmt.Sprintf(`{"name":"branch-%s-%d","commit":{"sha":"sha"}}`, r.URL.Query().Get("page"), i)
+		}
+		_, _ = io.WriteString(w, "["+strings.Join(branches, ",")+"]")
+	})
+	provider := newGitHubProvider(t, mux)
+
+	branches, err := provider.ListBranches(context.TODO(), "org/repo")
+	assert.Nil(t, err)
+	assert.Len(t, branches, pageSize+1)
+	assert.Equal(t, "branch-2-0", branches[pageSize].Name)
+}
+
+func TestProvider_ListPullRequests(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/pulls", func(w http.ResponseWriter, r *http.Request) {
+		// GitHub lists the open pull requests by default
+		assert.Empty(t, r.URL.Query().Get("state"))
+		_, _ = io.WriteString(w, `[{"number":1,"title":"fix"}]`)
+	})
+	provider := newGitHubProvider(t, mux)
+
+	pullRequests, err := provider.ListPullRequests(context.TODO(), "org/repo")
+	assert.Nil(t, err)
+	if assert.Len(t, pullRequests, 1) {
+		assert.Equal(t, 1, pullRequests[0].Number)
+	}
+}
+
+func TestProvider_GetFileContents(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/contents/Jenkinsfile", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "master", r.URL.Query().Get("ref"))
+		_, _ = fmt.Fprintf(w, `{"path":"Jenkinsfile","content":"%s"}`, base64.StdEncoding.EncodeToString([]byte("pipeline {}")))
+	})
+	mux.HandleFunc("/repos/org/repo/contents/missing", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, `{"message":"Not Found"}`)
+	})
+	provider := newGitHubProvider(t, mux)
+
+	data, err := provider.GetFileContents(context.TODO(), "org/repo", "Jenkinsfile", "master")
+	assert.Nil(t, err)
+	assert.Equal(t, "pipeline {}", string(data))
+
+	_, err = provider.GetFileContents(context.TODO(), "org/repo", "missing", "master")
+	assert.NotNil(t, err)
+}
+
+func TestProvider_CreateStatus(t *testing.T) {
+	var requested bool
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/statuses/sha", func(w http.ResponseWriter, r *http.Request) {
+		requested = r.Method == http.MethodPost
+		_, _ = io.WriteString(w, `{"state":"success","context":"ks-devops"}`)
+	})
+	provider := newGitHubProvider(t, mux)
+
+	err := provider.CreateStatus(context.TODO(), "org/repo", "sha", &goscm.StatusInput{
+		State: goscm.StateSuccess, Label: "ks-devops",
+	})
+	assert.Nil(t, err)
+	assert.True(t, requested)
+}
+
+func TestProvider_RegisterWebhook(t *testing.T) {
+	var created int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/hooks", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			created++
+			_, _ = io.WriteString(w, `{"id":2,"config":{"url":"https://new"}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `[{"id":1,"config":{"url":"https://existing"}}]`)
+	})
+	provider := newGitHubProvider(t, mux)
+
+	err := provider.RegisterWebhook(context.TODO(), "org/repo", &goscm.HookInput{Target: "https://existing"})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, created)
+
+	err = provider.RegisterWebhook(context.TODO(), "org/repo", &goscm.HookInput{Target: "https://new"})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, created)
+}
+
+func TestProvider_ListCommits(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/commits", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("sha") {
+		case "v1.0.0":
+			_, _ = io.WriteString(w, `[{"sha":"sha-1","commit":{"message":"init"}}]`)
+		case "master":
+			_, _ = io.WriteString(w, `[{"sha":"sha-3","commit":{"message":"fix"}},{"sha":"sha-2","commit":{"message":"feat"}},`+
+				`{"sha":"sha-1","commit":{"message":"init"}}]`)
+		default:
+			_, _ = io.WriteString(w, `[]`)
+		}
+	})
+	provider := newGitHubProvider(t, mux)
+
+	commits, err := provider.ListCommits(context.TODO(), "org/repo", "master", "v1.0.0")
+	assert.Nil(t, err)
+	if assert.Len(t, commits, 2) {
+		assert.Equal(t, "sha-3", commits[0].Sha)
+		assert.Equal(t, "feat", commits[1].Message)
+	}
+
+	commits, err = provider.ListCommits(context.TODO(), "org/repo", "master", "")
+	assert.Nil(t, err)
+	assert.Len(t, commits, 3)
+
+	_, err = provider.ListCommits(context.TODO(), "org/repo", "master", "missing")
+	assert.NotNil(t, err)
+}
+
+func TestProvider_ListChangedFiles(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/compare/sha-1...sha-3", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"files":[{"filename":"service-a/main.go","status":"modified"},`+
+			`{"filename":"docs/new.md","previous_filename":"docs/old.md","status":"renamed"}]}`)
+	})
+	provider := newGitHubProvider(t, mux)
+
+	files, err := provider.ListChangedFiles(context.TODO(), "org/repo", "sha-1", "sha-3")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"service-a/main.go", "docs/new.md", "docs/old.md"}, files)
+
+	_, err = provider.ListChangedFiles(context.TODO(), "org/repo", "sha-1", "missing")
+	assert.NotNil(t, err)
+}
+
+func TestProvider_FindPullRequest(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"number":1,"title":"Add the release notes"}`)
+	})
+	provider := newGitHubProvider(t, mux)
+
+	pullRequest, err := provider.FindPullRequest(context.TODO(), "org/repo", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "Add the release notes", pullRequest.Title)
+}
+
+func TestProvider_CreateRelease(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/releases", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		_, _ = io.WriteString(w, `{"id":1,"tag_name":"v1.1.0","html_url":"https://github.com/org/repo/releases/v1.1.0"}`)
+	})
+	provider := newGitHubProvider(t, mux)
+
+	release, err := provider.CreateRelease(context.TODO(), "org/repo", &goscm.ReleaseInput{Tag: "v1.1.0", Title: "v1.1.0"})
+	assert.Nil(t, err)
+	assert.Equal(t, "https://github.com/org/repo/releases/v1.1.0", release.Link)
+}
+
+func TestProvider_ListTags(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/tags", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `[{"name":"v1.1.0","commit":{"sha":"sha-2"}},{"name":"v1.0.0","commit":{"sha":"sha-1"}}]`)
+	})
+	provider := newGitHubProvider(t, mux)
+
+	tags, err := provider.ListTags(context.TODO(), "org/repo")
+	assert.Nil(t, err)
+	if assert.Len(t, tags, 2) {
+		assert.Equal(t, "v1.1.0", tags[0].Name)
+		assert.Equal(t, "sha-2", tags[0].Sha)
+	}
+}
+
+func TestProvider_CreateTag(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/git/refs", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		data, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"ref":"refs/tags/v1.2.0","sha":"sha-3"}`, string(data))
+		_, _ = io.WriteString(w, `{"ref":"refs/tags/v1.2.0","object":{"sha":"sha-3"}}`)
+	})
+	provider := newGitHubProvider(t, mux)
+
+	assert.Nil(t, provider.CreateTag(context.TODO(), "org/repo", "v1.2.0", "sha-3"))
+}
+
+func TestProvider_CreateComment(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/issues/12/comments", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		data, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"body":"deployed"}`, string(data))
+		_, _ = io.WriteString(w, `{"id":1,"body":"deployed"}`)
+	})
+	provider := newGitHubProvider(t, mux)
+
+	assert.Nil(t, provider.CreateComment(context.TODO(), "org/repo", 12, "deployed"))
+}
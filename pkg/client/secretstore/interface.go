@@ -0,0 +1,110 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretstore
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+// Interface reads the data of secrets from an external secret store
+type Interface interface {
+	// Get returns the data of the secret which is located by the path in the store. The stores are shared by all
+	// the DevOpsProjects, so the path is relative to the place of the namespace, and a project is never able to
+	// read the secrets of other projects.
+	Get(ctx context.Context, namespace, path string) (map[string][]byte, error)
+}
+
+// Resolver resolves the credentials which refer to the secrets in the external stores.
+// The reference looks like "<store>://<path>", such as "vault://ci/github". The path is relative to the place
+// of the namespace of the credential in the store.
+type Resolver struct {
+	stores map[string]Interface
+}
+
+// NewResolver creates a Resolver without any store
+func NewResolver() *Resolver {
+	return &Resolver{stores: map[string]Interface{}}
+}
+
+// Register registers a store with its name, which is the scheme of the references
+func (r *Resolver) Register(name string, store Interface) {
+	r.stores[name] = store
+}
+
+// IsExternal returns true if the credential refers to a secret in an external store
+func IsExternal(secret *v1.Secret) bool {
+	_, ok := secret.Annotations[v1alpha3.CredentialExternalRefAnnoKey]
+	return ok
+}
+
+// Resolve returns a copy of the credential whose data is read from the external store.
+// The credential itself is returned if it doesn't refer to an external store. The returned
+// copy must not be persisted, so that the plaintext is only kept in the external store.
+func (r *Resolver) Resolve(ctx context.Context, secret *v1.Secret) (*v1.Secret, error) {
+	ref, ok := secret.Annotations[v1alpha3.CredentialExternalRefAnnoKey]
+	if !ok {
+		return secret, nil
+	}
+	name, path, err := ParseReference(ref)
+	if err != nil {
+		return nil, err
+	}
+	var store Interface
+	if r != nil {
+		store = r.stores[name]
+	}
+	if store == nil {
+		return nil, fmt.Errorf("the secret store %q is not configured", name)
+	}
+
+	data, err := store.Get(ctx, secret.Namespace, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q from the secret store %q: %v", path, name, err)
+	}
+	resolved := secret.DeepCopy()
+	resolved.Data = data
+	return resolved, nil
+}
+
+// pathSegmentPattern matches a segment of the relative paths, the special characters which are able to escape
+// from the place of the namespace are not allowed, such as the percent-encoded characters
+var pathSegmentPattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// ParseReference parses a reference like "<store>://<path>". The path must be relative, so the absolute paths
+// and the segments like ".." are rejected.
+func ParseReference(ref string) (store, path string, err error) {
+	items := strings.SplitN(ref, "://", 2)
+	if len(items) != 2 || items[0] == "" || items[1] == "" {
+		err = fmt.Errorf("invalid external secret reference %q, it should be like <store>://<path>", ref)
+		return
+	}
+	store, path = items[0], items[1]
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "." || segment == ".." || !pathSegmentPattern.MatchString(segment) {
+			err = fmt.Errorf("invalid external secret reference %q, the path should be relative to the project", ref)
+			return
+		}
+	}
+	return
+}
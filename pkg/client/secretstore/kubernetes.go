@@ -0,0 +1,55 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretstore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// KubernetesStoreName is the name of the store which reads secrets from a dedicated namespace
+const KubernetesStoreName = "kubernetes"
+
+// KubernetesStore reads secrets from a dedicated namespace, the secrets are usually synchronized from
+// the external secret managers by External Secrets Operator. The secrets of a project are named like
+// "<namespace>.<name>", and the credentials refer to them by kubernetes://<name>.
+type KubernetesStore struct {
+	Namespace string
+	client    v1core.SecretsGetter
+}
+
+// NewKubernetesStore creates a KubernetesStore
+func NewKubernetesStore(namespace string, client v1core.SecretsGetter) *KubernetesStore {
+	return &KubernetesStore{Namespace: namespace, client: client}
+}
+
+// Get returns the data of the secret "<namespace>.<path>", the path is the name of the secret
+func (s *KubernetesStore) Get(ctx context.Context, namespace, path string) (map[string][]byte, error) {
+	if namespace == "" || strings.Contains(path, "/") {
+		return nil, fmt.Errorf("invalid secret name %q of namespace %q", path, namespace)
+	}
+	// the names of namespaces have no dots, so the secrets of different namespaces never conflict
+	secret, err := s.client.Secrets(s.Namespace).Get(ctx, namespace+"."+path, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return secret.Data, nil
+}
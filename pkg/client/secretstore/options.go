@@ -0,0 +1,76 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretstore
+
+import (
+	"os"
+
+	"github.com/spf13/pflag"
+	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// Options contains the configuration of the external secret stores
+type Options struct {
+	VaultAddress   string `json:"vaultAddress,omitempty" yaml:"vaultAddress"`
+	VaultToken     string `json:"vaultToken,omitempty" yaml:"vaultToken"`
+	VaultNamespace string `json:"vaultNamespace,omitempty" yaml:"vaultNamespace"`
+	// VaultPathPrefix is the path under which each DevOpsProject has its own place, named by the namespace
+	VaultPathPrefix string `json:"vaultPathPrefix,omitempty" yaml:"vaultPathPrefix"`
+	// SecretNamespace is the namespace of the secrets which are synchronized by External Secrets Operator
+	SecretNamespace string `json:"secretNamespace,omitempty" yaml:"secretNamespace"`
+}
+
+// NewOptions creates an Options without any store, the Vault token is taken from the environment
+func NewOptions() *Options {
+	return &Options{
+		VaultToken:      os.Getenv("VAULT_TOKEN"),
+		VaultPathPrefix: "secret/data/devops",
+	}
+}
+
+// AddFlags adds the flags of the options
+func (o *Options) AddFlags(fs *pflag.FlagSet, c *Options) {
+	fs.StringVar(&o.VaultAddress, "vault-address", c.VaultAddress, ""+
+		"The address of HashiCorp Vault, the credentials are able to refer to the secrets in Vault by "+
+		"vault://<path> if it's not empty. The path is relative to <vault-path-prefix>/<namespace>.")
+	fs.StringVar(&o.VaultToken, "vault-token", c.VaultToken, ""+
+		"The token to access HashiCorp Vault, it's taken from the environment variable VAULT_TOKEN by default.")
+	fs.StringVar(&o.VaultNamespace, "vault-namespace", c.VaultNamespace, ""+
+		"The namespace of HashiCorp Vault Enterprise.")
+	fs.StringVar(&o.VaultPathPrefix, "vault-path-prefix", c.VaultPathPrefix, ""+
+		"The path of HashiCorp Vault under which each DevOpsProject has its own place named by the namespace, "+
+		"the projects are not able to read the secrets out of their own places.")
+	fs.StringVar(&o.SecretNamespace, "external-secret-namespace", c.SecretNamespace, ""+
+		"The namespace of the secrets synchronized by External Secrets Operator, the credentials are able to "+
+		"refer to them by kubernetes://<name> if it's not empty. The secrets are named like <namespace>.<name>.")
+}
+
+// NewResolver creates a Resolver with the configured stores
+func (o *Options) NewResolver(secretsGetter v1core.SecretsGetter) *Resolver {
+	resolver := NewResolver()
+	if o == nil {
+		return resolver
+	}
+	if o.VaultAddress != "" {
+		resolver.Register(VaultStoreName, NewVaultStore(o.VaultAddress, o.VaultToken, o.VaultNamespace,
+			o.VaultPathPrefix, nil))
+	}
+	if o.SecretNamespace != "" {
+		resolver.Register(KubernetesStoreName, NewKubernetesStore(o.SecretNamespace, secretsGetter))
+	}
+	return resolver
+}
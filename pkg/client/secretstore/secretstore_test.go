@@ -0,0 +1,162 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretstore
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+func TestParseReference(t *testing.T) {
+	store, path, err := ParseReference("vault://ci/github")
+	assert.Nil(t, err)
+	assert.Equal(t, "vault", store)
+	assert.Equal(t, "ci/github", path)
+
+	for _, ref := range []string{"", "vault", "vault://", "://path", "vault:///secret/data/other/github",
+		"vault://../other/github", "vault://ci/../../other/github", "vault://ci/./github", "vault://ci//github",
+		"vault://%2e%2e/other/github", "vault://ci/github?version=1"} {
+		_, _, err = ParseReference(ref)
+		assert.NotNil(t, err, ref)
+	}
+}
+
+func TestVaultStore(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" || r.Header.Get("X-Vault-Namespace") != "ns" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/devops/project/ci/github":
+			_, _ = w.Write([]byte(`{"data":{"data":{"username":"bot","password":"pass"},"metadata":{"version":1}}}`))
+		case "/v1/kv/project/ci/github":
+			_, _ = w.Write([]byte(`{"data":{"username":"bot","port":22}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	store := NewVaultStore(server.URL+"/", "token", "ns", "/secret/data/devops/", nil)
+	data, err := store.Get(context.Background(), "project", "ci/github")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]byte{"username": []byte("bot"), "password": []byte("pass")}, data)
+
+	data, err = NewVaultStore(server.URL, "token", "ns", "kv", nil).Get(context.Background(), "project", "ci/github")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]byte{"username": []byte("bot"), "port": []byte("22")}, data)
+
+	_, err = store.Get(context.Background(), "project", "absent")
+	assert.NotNil(t, err)
+	_, err = store.Get(context.Background(), "", "ci/github")
+	assert.NotNil(t, err)
+
+	_, err = NewVaultStore(server.URL, "wrong", "ns", "kv", nil).Get(context.Background(), "project", "ci/github")
+	assert.NotNil(t, err)
+}
+
+func TestResolver(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "external-secrets", Name: "project.github"},
+		Data:       map[string][]byte{v1alpha3.BasicAuthPasswordKey: []byte("pass")},
+	}, &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "external-secrets", Name: "other.github"},
+		Data:       map[string][]byte{v1alpha3.BasicAuthPasswordKey: []byte("other")},
+	}, &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "private"},
+		Data:       map[string][]byte{v1alpha3.BasicAuthPasswordKey: []byte("private")},
+	})
+	resolver := (&Options{SecretNamespace: "external-secrets"}).NewResolver(clientset.CoreV1())
+
+	newCredential := func(ref string) *v1.Secret {
+		secret := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "project", Name: "credential"}}
+		if ref != "" {
+			secret.Annotations = map[string]string{v1alpha3.CredentialExternalRefAnnoKey: ref}
+		}
+		return secret
+	}
+
+	plain := newCredential("")
+	resolved, err := resolver.Resolve(context.Background(), plain)
+	assert.Nil(t, err)
+	assert.Equal(t, plain, resolved)
+
+	external := newCredential("kubernetes://github")
+	resolved, err = resolver.Resolve(context.Background(), external)
+	assert.Nil(t, err)
+	assert.Equal(t, "pass", string(resolved.Data[v1alpha3.BasicAuthPasswordKey]))
+	assert.Nil(t, external.Data, "the original secret should not be changed")
+
+	_, err = resolver.Resolve(context.Background(), newCredential("kubernetes://other/private"))
+	assert.NotNil(t, err)
+
+	// the secrets of other projects are not accessible
+	for _, ref := range []string{"kubernetes://../other.github", "kubernetes://other.github"} {
+		_, err = resolver.Resolve(context.Background(), newCredential(ref))
+		assert.NotNil(t, err, ref)
+	}
+	_, err = resolver.Resolve(context.Background(), newCredential("vault://secret/data/ci/github"))
+	assert.NotNil(t, err, "vault is not configured")
+	_, err = (*Resolver)(nil).Resolve(context.Background(), external)
+	assert.NotNil(t, err)
+}
+
+func TestResolverWithVault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/secret/data/devops/project/github":
+			_, _ = w.Write([]byte(`{"data":{"data":{"password":"pass"},"metadata":{"version":1}}}`))
+		case "/v1/secret/data/devops/other/github":
+			_, _ = w.Write([]byte(`{"data":{"data":{"password":"other"},"metadata":{"version":1}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	options := NewOptions()
+	options.VaultAddress = server.URL
+	resolver := options.NewResolver(nil)
+	newCredential := func(ref string) *v1.Secret {
+		return &v1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "project",
+			Name:        "credential",
+			Annotations: map[string]string{v1alpha3.CredentialExternalRefAnnoKey: ref},
+		}}
+	}
+
+	resolved, err := resolver.Resolve(context.Background(), newCredential("vault://github"))
+	assert.Nil(t, err)
+	assert.Equal(t, "pass", string(resolved.Data["password"]))
+
+	// a project is not able to refer to the secrets of other projects
+	for _, ref := range []string{"vault://../other/github", "vault://other/github",
+		"vault:///secret/data/devops/other/github"} {
+		_, err = resolver.Resolve(context.Background(), newCredential(ref))
+		assert.NotNil(t, err, ref)
+	}
+}
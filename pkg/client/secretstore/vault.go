@@ -0,0 +1,125 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// VaultStoreName is the name of the HashiCorp Vault store
+const VaultStoreName = "vault"
+
+// vaultRequestTimeout is the timeout of the requests to Vault
+const vaultRequestTimeout = 30 * time.Second
+
+// VaultStore reads secrets from the KV secrets engine (v1 or v2) of HashiCorp Vault
+type VaultStore struct {
+	// Address is the address of Vault, such as https://vault.example.com:8200
+	Address string
+	// Token is used to authenticate with Vault
+	Token string
+	// Namespace is the Vault Enterprise namespace, it's optional
+	Namespace string
+	// PathPrefix is the path under which each project has its own place, such as "secret/data/devops"
+	PathPrefix string
+
+	client *http.Client
+}
+
+// NewVaultStore creates a VaultStore, the secrets of a project are located in "<pathPrefix>/<namespace>/"
+func NewVaultStore(address, token, namespace, pathPrefix string, client *http.Client) *VaultStore {
+	if client == nil {
+		client = &http.Client{Timeout: vaultRequestTimeout}
+	}
+	return &VaultStore{
+		Address:    strings.TrimSuffix(address, "/"),
+		Token:      token,
+		Namespace:  namespace,
+		PathPrefix: strings.Trim(pathPrefix, "/"),
+		client:     client,
+	}
+}
+
+type vaultSecret struct {
+	Data map[string]interface{} `json:"data"`
+}
+
+// Get reads the secret by the path of the namespace, such as "ci/github" which is located in
+// "secret/data/devops/<namespace>/ci/github" for the KV v2 engine
+func (s *VaultStore) Get(ctx context.Context, namespace, path string) (data map[string][]byte, err error) {
+	if namespace == "" {
+		return nil, fmt.Errorf("the namespace of secret %q is required", path)
+	}
+	api := fmt.Sprintf("%s/v1/%s/%s/%s", s.Address, s.PathPrefix, namespace, path)
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctx, http.MethodGet, api, nil); err != nil {
+		return
+	}
+	req.Header.Set("X-Vault-Token", s.Token)
+	if s.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", s.Namespace)
+	}
+
+	var resp *http.Response
+	if resp, err = s.client.Do(req); err != nil {
+		return
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	var body []byte
+	if body, err = ioutil.ReadAll(resp.Body); err != nil {
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("unexpected status code %d from Vault", resp.StatusCode)
+		return
+	}
+
+	secret := &vaultSecret{}
+	if err = json.Unmarshal(body, secret); err != nil {
+		return
+	}
+	values := secret.Data
+	// the KV v2 engine wraps the key-value pairs with their metadata
+	if nested, ok := values["data"].(map[string]interface{}); ok {
+		if _, hasMetadata := values["metadata"]; hasMetadata {
+			values = nested
+		}
+	}
+
+	data = make(map[string][]byte, len(values))
+	for key, value := range values {
+		switch v := value.(type) {
+		case string:
+			data[key] = []byte(v)
+		default:
+			var raw []byte
+			if raw, err = json.Marshal(v); err != nil {
+				return
+			}
+			data[key] = raw
+		}
+	}
+	return
+}
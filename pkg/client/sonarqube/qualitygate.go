@@ -0,0 +1,85 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sonarqube
+
+import (
+	"time"
+
+	sonargo "github.com/kubesphere/sonargo/sonar"
+)
+
+// QualityGateMetricKeys are the metric keys which are recorded along with the quality gates
+const QualityGateMetricKeys = "bugs,vulnerabilities,security_hotspots,code_smells,coverage,duplicated_lines_density,ncloc"
+
+// sonarDateTimeLayout is the layout of the datetime parameters of SonarQube
+const sonarDateTimeLayout = "2006-01-02T15:04:05-0700"
+
+// QualityGateInterface reads the quality gates of the SonarQube analyses
+type QualityGateInterface interface {
+	// GetLatestAnalysis returns the key of the latest analysis of a project since the given time,
+	// it's empty if there is no such analysis
+	GetLatestAnalysis(projectKey string, since time.Time) (string, error)
+	// GetQualityGate returns the quality gate of an analysis
+	GetQualityGate(analysisID string) (*sonargo.ProjectStatus, error)
+	// GetMeasures returns the values of the metrics of a project
+	GetMeasures(projectKey string, metricKeys string) (map[string]string, error)
+}
+
+var _ QualityGateInterface = &SonarQube{}
+
+// GetLatestAnalysis returns the key of the latest analysis of a project since the given time
+func (s *SonarQube) GetLatestAnalysis(projectKey string, since time.Time) (analysisID string, err error) {
+	var result *sonargo.ProjectAnalysesSearchObject
+	if result, _, err = s.client.ProjectAnalyses.Search(&sonargo.ProjectAnalysesSearchOption{
+		Project: projectKey,
+		From:    since.Format(sonarDateTimeLayout),
+		Ps:      1,
+	}); err == nil && result != nil && len(result.Analyses) > 0 {
+		// the analyses are sorted by date in descending order
+		analysisID = result.Analyses[0].Key
+	}
+	return
+}
+
+// GetQualityGate returns the quality gate of an analysis
+func (s *SonarQube) GetQualityGate(analysisID string) (status *sonargo.ProjectStatus, err error) {
+	var result *sonargo.QualitygatesProjectStatusObject
+	if result, _, err = s.client.Qualitygates.ProjectStatus(&sonargo.QualitygatesProjectStatusOption{
+		AnalysisId: analysisID,
+	}); err == nil && result != nil {
+		status = result.ProjectStatus
+	}
+	return
+}
+
+// GetMeasures returns the values of the metrics of a project
+func (s *SonarQube) GetMeasures(projectKey string, metricKeys string) (measures map[string]string, err error) {
+	var result *sonargo.MeasuresComponentObject
+	if result, _, err = s.client.Measures.Component(&sonargo.MeasuresComponentOption{
+		Component:  projectKey,
+		MetricKeys: metricKeys,
+	}); err != nil {
+		return
+	}
+	measures = map[string]string{}
+	if result != nil && result.Component != nil {
+		for _, measure := range result.Component.Measures {
+			measures[measure.Metric] = measure.Value
+		}
+	}
+	return
+}
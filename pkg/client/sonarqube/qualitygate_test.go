@@ -0,0 +1,84 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sonarqube
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	sonargo "github.com/kubesphere/sonargo/sonar"
+	"github.com/stretchr/testify/assert"
+)
+
+func newFakeSonarQube(t *testing.T) *SonarQube {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/project_analyses/search", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "fake", r.URL.Query().Get("project"))
+		assert.Equal(t, "2023-01-02T03:04:05+0000", r.URL.Query().Get("from"))
+		_, _ = w.Write([]byte(`{"analyses":[{"key":"analysis-2","date":"2023-01-02T03:05:00+0000"}]}`))
+	})
+	mux.HandleFunc("/api/qualitygates/project_status", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("analysisId") != "analysis-2" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[{"msg":"Analysis not found"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"projectStatus":{"status":"ERROR","conditions":[{"status":"ERROR","metricKey":"new_coverage",
+"comparator":"LT","errorThreshold":"80","actualValue":"62.5"}]}}`))
+	})
+	mux.HandleFunc("/api/measures/component", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "bugs,coverage", r.URL.Query().Get("metricKeys"))
+		_, _ = w.Write([]byte(`{"component":{"key":"fake","measures":[{"metric":"bugs","value":"3"},{"metric":"coverage","value":"62.5"}]}}`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client, err := NewSonarQubeClient(&Options{Host: server.URL})
+	assert.Nil(t, err)
+	return NewSonar(client.SonarQube())
+}
+
+func TestSonarQube_GetLatestAnalysis(t *testing.T) {
+	sonar := newFakeSonarQube(t)
+	analysisID, err := sonar.GetLatestAnalysis("fake", time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC))
+	assert.Nil(t, err)
+	assert.Equal(t, "analysis-2", analysisID)
+}
+
+func TestSonarQube_GetQualityGate(t *testing.T) {
+	sonar := newFakeSonarQube(t)
+	status, err := sonar.GetQualityGate("analysis-2")
+	assert.Nil(t, err)
+	assert.Equal(t, &sonargo.ProjectStatus{
+		Status: "ERROR",
+		Conditions: []*sonargo.Condition{{
+			Status: "ERROR", MetricKey: "new_coverage", Comparator: "LT", ErrorThreshold: "80", ActualValue: "62.5",
+		}},
+	}, status)
+
+	_, err = sonar.GetQualityGate("analysis-1")
+	assert.NotNil(t, err)
+}
+
+func TestSonarQube_GetMeasures(t *testing.T) {
+	sonar := newFakeSonarQube(t)
+	measures, err := sonar.GetMeasures("fake", "bugs,coverage")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"bugs": "3", "coverage": "62.5"}, measures)
+}
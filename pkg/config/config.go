@@ -28,7 +28,10 @@ import (
 	"github.com/spf13/viper"
 
 	"kubesphere.io/devops/pkg/client/devops/jenkins"
+	"kubesphere.io/devops/pkg/client/history"
 	"kubesphere.io/devops/pkg/client/s3"
+	"kubesphere.io/devops/pkg/models/audit"
+	"kubesphere.io/devops/pkg/models/webhook"
 )
 
 // Package config saves configuration for running KubeSphere components
@@ -89,6 +92,15 @@ type Config struct {
 	AuthenticationOptions *authoptions.AuthenticationOptions `json:"authentication,omitempty" yaml:"authentication,omitempty" mapstructure:"authentication"`
 	AuthMode              AuthMode                           `json:"authMode,omitempty" yaml:"authMode,omitempty" mapstructure:"authMode"`
 	JWTSecret             string                             `json:"jwtSecret,omitempty" yaml:"jwtSecret,omitempty" mapstructure:"jwtSecret"`
+
+	// AuditOptions controls the audit log of the DevOps APIs
+	AuditOptions *audit.Options `json:"audit,omitempty" yaml:"audit,omitempty" mapstructure:"audit"`
+
+	// HistoryOptions is the database which keeps the summaries of the completed PipelineRuns
+	HistoryOptions *history.Options `json:"history,omitempty" yaml:"history,omitempty" mapstructure:"history"`
+
+	// WebhookDeliveryOptions controls the history of the SCM webhook deliveries
+	WebhookDeliveryOptions *webhook.Options `json:"webhookDelivery,omitempty" yaml:"webhookDelivery,omitempty" mapstructure:"webhookDelivery"`
 }
 
 // New creates a default non-empty Config
@@ -101,6 +113,10 @@ func New() *Config {
 		AuthMode:          AuthModeToken,
 		ArgoCDOption:      &ArgoCDOption{},
 		FluxCDOption:      &FluxCDOption{},
+		AuditOptions:      audit.NewOptions(),
+		HistoryOptions:    history.NewOptions(),
+
+		WebhookDeliveryOptions: webhook.NewOptions(),
 	}
 }
 
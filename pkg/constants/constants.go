@@ -31,6 +31,7 @@ const (
 	DevOpsProjectTag         = "DevOps Project"
 	DevOpsTemplateTag        = "DevOps Template"
 	DevOpsClusterTemplateTag = "DevOps Cluster Template"
+	DevOpsAuditTag           = "DevOps Audit"
 
 	DevOpsImageBuilder = "DevOps ImageBuilder"
 )
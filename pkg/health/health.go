@@ -0,0 +1,109 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package health provides the health checkers of the dependencies of the Pipeline backends,
+// they are served by the /healthz and /readyz endpoints of controller-runtime.
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+
+	"kubesphere.io/devops/pkg/backend"
+	"kubesphere.io/devops/pkg/metrics"
+)
+
+const (
+	// defaultTimeout bounds a single check, a hanging backend should not hang the probes as well
+	defaultTimeout = 5 * time.Second
+
+	// TektonGroupVersion is the API group version which the Tekton backend relies on
+	TektonGroupVersion = "tekton.dev/v1beta1"
+)
+
+// tektonResources are the resources which must be served by the Tekton installation
+var tektonResources = []string{"pipelines", "pipelineruns", "tasks"}
+
+// NewJenkinsChecker returns a checker which verifies that the Jenkins API is reachable with the given credentials
+func NewJenkinsChecker(host, username, password string, roundTripper http.RoundTripper) healthz.Checker {
+	client := &http.Client{Transport: roundTripper}
+	url := strings.TrimSuffix(host, "/") + "/api/json?tree=mode"
+	return instrument(backend.Jenkins, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		if username != "" {
+			req.SetBasicAuth(username, password)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("jenkins is unreachable: %v", err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+			return fmt.Errorf("jenkins responded with an unexpected status: %s", resp.Status)
+		}
+		return nil
+	})
+}
+
+// NewTektonChecker returns a checker which verifies that the Tekton CRDs are served by kube-apiserver
+func NewTektonChecker(discoveryClient discovery.DiscoveryInterface) healthz.Checker {
+	return instrument(backend.Tekton, func(context.Context) error {
+		resources, err := discoveryClient.ServerResourcesForGroupVersion(TektonGroupVersion)
+		if err != nil {
+			return fmt.Errorf("failed to discover %s: %v", TektonGroupVersion, err)
+		}
+		served := make(map[string]bool, len(resources.APIResources))
+		for _, resource := range resources.APIResources {
+			served[resource.Name] = true
+		}
+		var missing []string
+		for _, resource := range tektonResources {
+			if !served[resource] {
+				missing = append(missing, resource)
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("%s is not serving: %s", TektonGroupVersion, strings.Join(missing, ", "))
+		}
+		return nil
+	})
+}
+
+// instrument records the result of a check into the metrics, then operators are able to alert on it
+func instrument(backendType backend.Type, check func(context.Context) error) healthz.Checker {
+	up := metrics.BackendUp.WithLabelValues(string(backendType))
+	return func(req *http.Request) error {
+		ctx, cancel := context.WithTimeout(req.Context(), defaultTimeout)
+		defer cancel()
+
+		err := check(ctx)
+		if err != nil {
+			up.Set(0)
+		} else {
+			up.Set(1)
+		}
+		return err
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package health
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"kubesphere.io/devops/pkg/metrics"
+)
+
+func TestJenkinsChecker(t *testing.T) {
+	tests := []struct {
+		name      string
+		handler   http.HandlerFunc
+		expectErr bool
+	}{{
+		name: "reachable",
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			username, password, _ := r.BasicAuth()
+			if r.URL.Path != "/api/json" || username != "admin" || password != "token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"mode":"NORMAL"}`))
+		},
+	}, {
+		name: "unavailable",
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		},
+		expectErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			checker := NewJenkinsChecker(server.URL+"/", "admin", "token", http.DefaultTransport)
+			err := checker(httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			up := testutil.ToFloat64(metrics.BackendUp.WithLabelValues("Jenkins"))
+			if tt.expectErr {
+				assert.NotNil(t, err)
+				assert.Equal(t, float64(0), up)
+			} else {
+				assert.Nil(t, err)
+				assert.Equal(t, float64(1), up)
+			}
+		})
+	}
+
+	t.Run("unreachable", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		server.Close()
+
+		checker := NewJenkinsChecker(server.URL, "", "", http.DefaultTransport)
+		assert.NotNil(t, checker(httptest.NewRequest(http.MethodGet, "/readyz", nil)))
+	})
+}
+
+func TestTektonChecker(t *testing.T) {
+	tests := []struct {
+		name      string
+		resources []*metav1.APIResourceList
+		expectErr bool
+	}{{
+		name:      "not installed",
+		expectErr: true,
+	}, {
+		name: "partially installed",
+		resources: []*metav1.APIResourceList{{
+			GroupVersion: TektonGroupVersion,
+			APIResources: []metav1.APIResource{{Name: "tasks"}},
+		}},
+		expectErr: true,
+	}, {
+		name: "installed",
+		resources: []*metav1.APIResourceList{{
+			GroupVersion: TektonGroupVersion,
+			APIResources: []metav1.APIResource{{Name: "pipelines"}, {Name: "pipelineruns"}, {Name: "tasks"}, {Name: "taskruns"}},
+		}},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			discoveryClient := k8sfake.NewSimpleClientset().Discovery().(*fakediscovery.FakeDiscovery)
+			discoveryClient.Resources = tt.resources
+
+			err := NewTektonChecker(discoveryClient)(httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			up := testutil.ToFloat64(metrics.BackendUp.WithLabelValues("Tekton"))
+			if tt.expectErr {
+				assert.NotNil(t, err)
+				assert.Equal(t, float64(0), up)
+			} else {
+				assert.Nil(t, err)
+				assert.Equal(t, float64(1), up)
+			}
+		})
+	}
+}
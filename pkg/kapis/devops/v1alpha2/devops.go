@@ -27,6 +27,7 @@ import (
 
 	"kubesphere.io/devops/pkg/kapis"
 
+	"kubesphere.io/devops/pkg/apiserver/authentication/identity"
 	"kubesphere.io/devops/pkg/apiserver/query"
 	"kubesphere.io/devops/pkg/apiserver/request"
 
@@ -345,6 +346,8 @@ func (h *ProjectPipelineHandler) GetPipelineRunNodes(req *restful.Request, resp
 // approvableCheck requires the users who have PipelineRun management permission to
 // approve a step. If the particular submitters exist, we also restrict the users
 // who are Pipeline creator or in the particular submitters can be able to approve or reject a step.
+// The submitters could be the groups of the users as well, such as the groups mapped from LDAP or OIDC,
+// but only the groups of the verified users are trusted.
 func (h *ProjectPipelineHandler) approvableCheck(nodes []clientDevOps.NodesDetail, pipe pipelineParam) {
 	userInfo, ok := request.UserFrom(pipe.Context)
 	if !ok {
@@ -353,6 +356,11 @@ func (h *ProjectPipelineHandler) approvableCheck(nodes []clientDevOps.NodesDetai
 		return
 	}
 
+	var groups []string
+	if submitter := identity.FromUser(userInfo); submitter != nil {
+		groups = submitter.Groups
+	}
+
 	// check every input steps if it's approvable
 	for i := range nodes {
 		node := &nodes[i]
@@ -371,7 +379,8 @@ func (h *ProjectPipelineHandler) approvableCheck(nodes []clientDevOps.NodesDetai
 				step.Approvable = true
 			} else {
 				isCreator := h.createdBy(pipe.ProjectName, pipe.Name, userInfo.GetName())
-				step.Approvable = isCreator || step.Input.Approvable(userInfo.GetName())
+				step.Approvable = isCreator || step.Input.Approvable(userInfo.GetName()) ||
+					step.Input.ApprovableByGroups(groups)
 			}
 		}
 	}
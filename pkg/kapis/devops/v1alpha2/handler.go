@@ -57,3 +57,7 @@ func NewS2iBinaryHandler(client versioned.Interface, informers externalversions.
 	k8sClient k8s.Client) S2iBinaryHandler {
 	return S2iBinaryHandler{devops.NewS2iBinaryUploader(client, informers, s3Client, k8sClient)}
 }
+
+func NewS2iBuilderTemplateHandler(client versioned.Interface) S2iBuilderTemplateHandler {
+	return S2iBuilderTemplateHandler{devops.NewS2iBuilderTemplateOperator(client)}
+}
@@ -44,11 +44,12 @@ import (
 	"net/http"
 
 	"kubesphere.io/devops/pkg/client/devops"
+	devopsmodel "kubesphere.io/devops/pkg/models/devops"
 )
 
 // TODO perhaps we can find a better way to declaim the permission needs of the apiserver
 //+kubebuilder:rbac:groups=devops.kubesphere.io,resources=s2ibinaries,verbs=get;list;update;delete;watch
-//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=s2ibuildertemplates,verbs=get;list;update;delete;watch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=s2ibuildertemplates,verbs=get;list;create;update;delete;watch
 //+kubebuilder:rbac:groups=devops.kubesphere.io,resources=s2ibuilders,verbs=get;list;update;delete;watch
 //+kubebuilder:rbac:groups=devops.kubesphere.io,resources=s2iruns,verbs=get;list;update;delete;watch
 
@@ -660,6 +661,93 @@ func AddS2IToWebService(webservice *restful.WebService, ksClient versioned.Inter
 			Param(webservice.PathParameter("s2ibinary", "the name of s2ibinary")).
 			Param(webservice.PathParameter("file", "the name of binary file")).
 			Returns(http.StatusOK, api.StatusOK, nil))
+
+		webservice.Route(webservice.POST("/namespaces/{namespace}/s2ibinaries/{s2ibinary}/uploads").
+			To(s2iHandler.initiateMultipartUpload).
+			Produces(restful.MIME_JSON).
+			Doc("Initiate a resumable multipart upload of S2iBinary file").
+			Param(webservice.PathParameter("namespace", "the name of namespaces")).
+			Param(webservice.PathParameter("s2ibinary", "the name of s2ibinary")).
+			Param(webservice.QueryParameter("fileName", "the name of binary file").Required(true)).
+			Returns(http.StatusOK, api.StatusOK, devopsmodel.MultipartUpload{}))
+
+		webservice.Route(webservice.GET("/namespaces/{namespace}/s2ibinaries/{s2ibinary}/uploads/{upload}").
+			To(s2iHandler.listParts).
+			Produces(restful.MIME_JSON).
+			Doc("List the uploaded parts of a multipart upload, it is used to resume an interrupted upload").
+			Param(webservice.PathParameter("namespace", "the name of namespaces")).
+			Param(webservice.PathParameter("s2ibinary", "the name of s2ibinary")).
+			Param(webservice.PathParameter("upload", "the ID of the multipart upload")).
+			Returns(http.StatusOK, api.StatusOK, devopsmodel.MultipartUpload{}))
+
+		webservice.Route(webservice.PUT("/namespaces/{namespace}/s2ibinaries/{s2ibinary}/uploads/{upload}/parts/{part}").
+			To(s2iHandler.uploadPart).
+			Consumes(restful.MIME_OCTET).
+			Produces(restful.MIME_JSON).
+			Doc("Upload a part of S2iBinary file, uploading the same part again overwrites it").
+			Param(webservice.PathParameter("namespace", "the name of namespaces")).
+			Param(webservice.PathParameter("s2ibinary", "the name of s2ibinary")).
+			Param(webservice.PathParameter("upload", "the ID of the multipart upload")).
+			Param(webservice.PathParameter("part", "the part number which starts from 1")).
+			Param(webservice.QueryParameter("md5", "md5 of the part").Required(false)).
+			Returns(http.StatusOK, api.StatusOK, s3.Part{}))
+
+		webservice.Route(webservice.POST("/namespaces/{namespace}/s2ibinaries/{s2ibinary}/uploads/{upload}/complete").
+			To(s2iHandler.completeMultipartUpload).
+			Produces(restful.MIME_JSON).
+			Doc("Complete a multipart upload of S2iBinary file").
+			Param(webservice.PathParameter("namespace", "the name of namespaces")).
+			Param(webservice.PathParameter("s2ibinary", "the name of s2ibinary")).
+			Param(webservice.PathParameter("upload", "the ID of the multipart upload")).
+			Reads(MultipartUploadCompletion{}).
+			Returns(http.StatusOK, api.StatusOK, devopsv1alpha1.S2iBinary{}))
+
+		webservice.Route(webservice.DELETE("/namespaces/{namespace}/s2ibinaries/{s2ibinary}/uploads/{upload}").
+			To(s2iHandler.abortMultipartUpload).
+			Doc("Abort a multipart upload of S2iBinary file").
+			Param(webservice.PathParameter("namespace", "the name of namespaces")).
+			Param(webservice.PathParameter("s2ibinary", "the name of s2ibinary")).
+			Param(webservice.PathParameter("upload", "the ID of the multipart upload")).
+			Returns(http.StatusOK, api.StatusOK, nil))
+
+		templateHandler := NewS2iBuilderTemplateHandler(ksClient)
+		webservice.Route(webservice.GET("/namespaces/{namespace}/s2ibinaries/{s2ibinary}/builders").
+			To(templateHandler.suggestBuilders).
+			Doc("Suggest the builder and runtime images for the uploaded S2iBinary file").
+			Param(webservice.PathParameter("namespace", "the name of namespaces")).
+			Param(webservice.PathParameter("s2ibinary", "the name of s2ibinary")).
+			Returns(http.StatusOK, api.StatusOK, devopsmodel.S2iBuilderSuggestion{}))
+
+		webservice.Route(webservice.GET("/s2ibuildertemplates").
+			To(templateHandler.listTemplates).
+			Doc("List the S2iBuilderTemplates").
+			Param(webservice.QueryParameter("codeFramework", "filter the templates by the code framework, e.g. Java").Required(false)).
+			Returns(http.StatusOK, api.StatusOK, []devopsv1alpha1.S2iBuilderTemplate{}))
+
+		webservice.Route(webservice.POST("/s2ibuildertemplates").
+			To(templateHandler.createTemplate).
+			Doc("Register a custom builder image as an S2iBuilderTemplate").
+			Reads(devopsv1alpha1.S2iBuilderTemplate{}).
+			Returns(http.StatusOK, api.StatusOK, devopsv1alpha1.S2iBuilderTemplate{}))
+
+		webservice.Route(webservice.GET("/s2ibuildertemplates/{template}").
+			To(templateHandler.getTemplate).
+			Doc("Get the S2iBuilderTemplate").
+			Param(webservice.PathParameter("template", "the name of S2iBuilderTemplate")).
+			Returns(http.StatusOK, api.StatusOK, devopsv1alpha1.S2iBuilderTemplate{}))
+
+		webservice.Route(webservice.PUT("/s2ibuildertemplates/{template}").
+			To(templateHandler.updateTemplate).
+			Doc("Update the S2iBuilderTemplate").
+			Param(webservice.PathParameter("template", "the name of S2iBuilderTemplate")).
+			Reads(devopsv1alpha1.S2iBuilderTemplate{}).
+			Returns(http.StatusOK, api.StatusOK, devopsv1alpha1.S2iBuilderTemplate{}))
+
+		webservice.Route(webservice.DELETE("/s2ibuildertemplates/{template}").
+			To(templateHandler.deleteTemplate).
+			Doc("Delete the S2iBuilderTemplate").
+			Param(webservice.PathParameter("template", "the name of S2iBuilderTemplate")).
+			Returns(http.StatusOK, api.StatusOK, nil))
 	}
 	return nil
 }
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"kubesphere.io/devops/pkg/kapis"
 	"net/http"
+	"strconv"
 
 	"code.cloudfoundry.org/bytefmt"
 	"github.com/emicklei/go-restful"
@@ -107,3 +108,72 @@ func (h S2iBinaryHandler) DownloadS2iBinaryHandler(req *restful.Request, resp *r
 	http.Redirect(resp.ResponseWriter, req.Request, url, http.StatusFound)
 	return
 }
+
+// MultipartUploadCompletion is the request body to complete a multipart upload
+type MultipartUploadCompletion struct {
+	// MD5 is the checksum of the whole file
+	MD5 string `json:"md5,omitempty"`
+}
+
+func (h S2iBinaryHandler) initiateMultipartUpload(req *restful.Request, resp *restful.Response) {
+	upload, err := h.s2iUploader.InitiateMultipartUpload(req.PathParameter("namespace"), req.PathParameter("s2ibinary"),
+		req.QueryParameter("fileName"))
+	if err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	_ = resp.WriteEntity(upload)
+}
+
+func (h S2iBinaryHandler) uploadPart(req *restful.Request, resp *restful.Response) {
+	partNumber, err := strconv.ParseInt(req.PathParameter("part"), 10, 64)
+	if err != nil {
+		kapis.HandleBadRequest(resp, req, err)
+		return
+	}
+
+	part, err := h.s2iUploader.UploadS2iBinaryPart(req.PathParameter("namespace"), req.PathParameter("s2ibinary"),
+		req.PathParameter("upload"), partNumber, req.QueryParameter("md5"), req.Request.Body)
+	if err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	_ = resp.WriteEntity(part)
+}
+
+func (h S2iBinaryHandler) listParts(req *restful.Request, resp *restful.Response) {
+	upload, err := h.s2iUploader.ListS2iBinaryParts(req.PathParameter("namespace"), req.PathParameter("s2ibinary"),
+		req.PathParameter("upload"))
+	if err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	_ = resp.WriteEntity(upload)
+}
+
+func (h S2iBinaryHandler) completeMultipartUpload(req *restful.Request, resp *restful.Response) {
+	completion := &MultipartUploadCompletion{}
+	if req.Request.ContentLength != 0 {
+		if err := req.ReadEntity(completion); err != nil {
+			kapis.HandleBadRequest(resp, req, err)
+			return
+		}
+	}
+
+	s2ibin, err := h.s2iUploader.CompleteMultipartUpload(req.PathParameter("namespace"), req.PathParameter("s2ibinary"),
+		req.PathParameter("upload"), completion.MD5)
+	if err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	_ = resp.WriteEntity(s2ibin)
+}
+
+func (h S2iBinaryHandler) abortMultipartUpload(req *restful.Request, resp *restful.Response) {
+	if err := h.s2iUploader.AbortMultipartUpload(req.PathParameter("namespace"), req.PathParameter("s2ibinary"),
+		req.PathParameter("upload")); err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+}
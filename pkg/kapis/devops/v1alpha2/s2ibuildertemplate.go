@@ -0,0 +1,121 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha1"
+	"kubesphere.io/devops/pkg/kapis"
+	"kubesphere.io/devops/pkg/models/devops"
+)
+
+type S2iBuilderTemplateHandler struct {
+	templateOperator devops.S2iBuilderTemplateOperator
+}
+
+func (h S2iBuilderTemplateHandler) listTemplates(req *restful.Request, resp *restful.Response) {
+	templates, err := h.templateOperator.ListS2iBuilderTemplates(req.QueryParameter("codeFramework"))
+	if err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	_ = resp.WriteEntity(templates)
+}
+
+func (h S2iBuilderTemplateHandler) getTemplate(req *restful.Request, resp *restful.Response) {
+	template, err := h.templateOperator.GetS2iBuilderTemplate(req.PathParameter("template"))
+	if err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	_ = resp.WriteEntity(template)
+}
+
+func (h S2iBuilderTemplateHandler) createTemplate(req *restful.Request, resp *restful.Response) {
+	template := &v1alpha1.S2iBuilderTemplate{}
+	if err := req.ReadEntity(template); err != nil {
+		kapis.HandleBadRequest(resp, req, err)
+		return
+	}
+	if err := validateS2iBuilderTemplate(template); err != nil {
+		kapis.HandleBadRequest(resp, req, err)
+		return
+	}
+
+	created, err := h.templateOperator.CreateS2iBuilderTemplate(template)
+	if err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	_ = resp.WriteEntity(created)
+}
+
+func (h S2iBuilderTemplateHandler) updateTemplate(req *restful.Request, resp *restful.Response) {
+	template := &v1alpha1.S2iBuilderTemplate{}
+	if err := req.ReadEntity(template); err != nil {
+		kapis.HandleBadRequest(resp, req, err)
+		return
+	}
+	template.Name = req.PathParameter("template")
+	if err := validateS2iBuilderTemplate(template); err != nil {
+		kapis.HandleBadRequest(resp, req, err)
+		return
+	}
+
+	updated, err := h.templateOperator.UpdateS2iBuilderTemplate(template)
+	if err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	_ = resp.WriteEntity(updated)
+}
+
+func (h S2iBuilderTemplateHandler) deleteTemplate(req *restful.Request, resp *restful.Response) {
+	if err := h.templateOperator.DeleteS2iBuilderTemplate(req.PathParameter("template")); err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+}
+
+func (h S2iBuilderTemplateHandler) suggestBuilders(req *restful.Request, resp *restful.Response) {
+	suggestion, err := h.templateOperator.SuggestS2iBuilders(req.PathParameter("namespace"), req.PathParameter("s2ibinary"))
+	if err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	_ = resp.WriteEntity(suggestion)
+}
+
+// validateS2iBuilderTemplate makes sure a custom builder template provides at least one builder image
+func validateS2iBuilderTemplate(template *v1alpha1.S2iBuilderTemplate) error {
+	if template.Name == "" {
+		return restful.NewError(http.StatusBadRequest, "the name of S2iBuilderTemplate is required")
+	}
+	if template.Spec.DefaultBaseImage != "" {
+		return nil
+	}
+	for _, info := range template.Spec.ContainerInfo {
+		if info.BuilderImage != "" {
+			return nil
+		}
+	}
+	return restful.NewError(http.StatusBadRequest, "at least one builder image is required")
+}
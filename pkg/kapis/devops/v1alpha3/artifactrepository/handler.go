@@ -0,0 +1,116 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package artifactrepository
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/artifactrepository"
+	"kubesphere.io/devops/pkg/kapis"
+)
+
+// repositoryFactory creates the client of an ArtifactRepository
+type repositoryFactory func(spec *v1alpha3.ArtifactRepositorySpec, credential *artifactrepository.Credential) (artifactrepository.Interface, error)
+
+type handler struct {
+	client        client.Client
+	newRepository repositoryFactory
+}
+
+// Artifact is the location of an artifact in an ArtifactRepository
+type Artifact struct {
+	Repository string `json:"repository"`
+	Path       string `json:"path"`
+	URL        string `json:"url"`
+	Exists     bool   `json:"exists"`
+}
+
+func newRepository(spec *v1alpha3.ArtifactRepositorySpec, credential *artifactrepository.Credential) (artifactrepository.Interface, error) {
+	httpClient, err := artifactrepository.NewHTTPClient(spec.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	// the default client is shared, so set the timeout on a copy of it
+	httpClient = &http.Client{Transport: httpClient.Transport, Timeout: 5 * time.Minute}
+	return artifactrepository.NewClient(spec, credential, httpClient)
+}
+
+func (h *handler) resolveArtifact(req *restful.Request, resp *restful.Response) {
+	ctx := req.Request.Context()
+	repo, repoClient, path, err := h.getRepository(req)
+	if err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	artifact := Artifact{Repository: repo.Name, Path: path, URL: repoClient.GetArtifactURL(path)}
+	if artifact.Exists, err = repoClient.Exists(ctx, path); err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	_ = resp.WriteEntity(artifact)
+}
+
+func (h *handler) publishArtifact(req *restful.Request, resp *restful.Response) {
+	repo, repoClient, path, err := h.getRepository(req)
+	if err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	if err = repoClient.Upload(req.Request.Context(), path, req.Request.Body); err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	_ = resp.WriteEntity(Artifact{Repository: repo.Name, Path: path, URL: repoClient.GetArtifactURL(path), Exists: true})
+}
+
+// getRepository creates the client of the ArtifactRepository with the credential from its secret
+func (h *handler) getRepository(req *restful.Request) (repo *v1alpha3.ArtifactRepository,
+	repoClient artifactrepository.Interface, path string, err error) {
+	ctx := req.Request.Context()
+	namespace := req.PathParameter("namespace")
+	if path = strings.TrimPrefix(req.QueryParameter("path"), "/"); path == "" {
+		err = restful.NewError(http.StatusBadRequest, "the path of the artifact is required")
+		return
+	}
+
+	repo = &v1alpha3.ArtifactRepository{}
+	if err = h.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: req.PathParameter("artifactrepository")}, repo); err != nil {
+		return
+	}
+	var credential *artifactrepository.Credential
+	if repo.Spec.Secret != "" {
+		secret := &v1.Secret{}
+		if err = h.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: repo.Spec.Secret}, secret); err != nil {
+			return
+		}
+		if credential = artifactrepository.GetCredential(secret); credential == nil {
+			err = restful.NewError(http.StatusBadRequest,
+				fmt.Sprintf("the type of the secret %s is not %s", repo.Spec.Secret, v1alpha3.SecretTypeBasicAuth))
+			return
+		}
+	}
+	repoClient, err = h.newRepository(&repo.Spec, credential)
+	return
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package artifactrepository
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	kapisruntime "kubesphere.io/devops/pkg/apiserver/runtime"
+	"kubesphere.io/devops/pkg/client/artifactrepository"
+)
+
+type fakeRepository struct {
+	artifactrepository.Interface
+	artifacts map[string]string
+}
+
+func (f *fakeRepository) GetArtifactURL(path string) string {
+	return "https://nexus.example.com/repository/raw/" + path
+}
+
+func (f *fakeRepository) Exists(_ context.Context, path string) (bool, error) {
+	if path == "broken" {
+		return false, fmt.Errorf("unexpected status 500")
+	}
+	_, ok := f.artifacts[path]
+	return ok, nil
+}
+
+func (f *fakeRepository) Upload(_ context.Context, path string, body io.Reader) error {
+	data, err := io.ReadAll(body)
+	f.artifacts[path] = string(data)
+	return err
+}
+
+func TestHandler(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1.AddToScheme(schema))
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	newRepo := func(name, secret string) *v1alpha3.ArtifactRepository {
+		return &v1alpha3.ArtifactRepository{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name},
+			Spec: v1alpha3.ArtifactRepositorySpec{
+				Type:       v1alpha3.ArtifactRepositoryNexus,
+				Server:     "https://nexus.example.com",
+				Repository: "raw",
+				Secret:     secret,
+			},
+		}
+	}
+	objects := []runtime.Object{
+		newRepo("nexus", "nexus"), newRepo("anonymous", ""), newRepo("token", "token"),
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "nexus"},
+			Type:       v1.SecretTypeBasicAuth,
+			Data:       map[string][]byte{v1.BasicAuthUsernameKey: []byte("admin"), v1.BasicAuthPasswordKey: []byte("secret")},
+		},
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "token"},
+			Type:       v1alpha3.SecretTypeSecretText,
+		},
+	}
+
+	tests := []struct {
+		name             string
+		method           string
+		repository       string
+		path             string
+		body             string
+		expectCode       int
+		expectBody       string
+		expectCredential *artifactrepository.Credential
+		expectArtifacts  map[string]string
+	}{{
+		name:             "resolve a missing artifact",
+		method:           http.MethodGet,
+		repository:       "nexus",
+		path:             "app/v1/app.tar.gz",
+		expectCode:       http.StatusOK,
+		expectBody:       `{"repository":"nexus","path":"app/v1/app.tar.gz","url":"https://nexus.example.com/repository/raw/app/v1/app.tar.gz","exists":false}`,
+		expectCredential: &artifactrepository.Credential{Username: "admin", Password: "secret"},
+	}, {
+		name:            "publish an artifact",
+		method:          http.MethodPut,
+		repository:      "anonymous",
+		path:            "/app/v1/app.tar.gz",
+		body:            "content",
+		expectCode:      http.StatusOK,
+		expectBody:      `{"repository":"anonymous","path":"app/v1/app.tar.gz","url":"https://nexus.example.com/repository/raw/app/v1/app.tar.gz","exists":true}`,
+		expectArtifacts: map[string]string{"app/v1/app.tar.gz": "content"},
+	}, {
+		name:       "without the path",
+		method:     http.MethodGet,
+		repository: "nexus",
+		expectCode: http.StatusBadRequest,
+	}, {
+		name:       "repository not found",
+		method:     http.MethodGet,
+		repository: "missing",
+		path:       "app",
+		expectCode: http.StatusNotFound,
+	}, {
+		name:       "not a basic auth secret",
+		method:     http.MethodGet,
+		repository: "token",
+		path:       "app",
+		expectCode: http.StatusBadRequest,
+	}, {
+		name:       "failed to resolve",
+		method:     http.MethodGet,
+		repository: "nexus",
+		path:       "broken",
+		expectCode: http.StatusInternalServerError,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithRuntimeObjects(objects...).Build()
+			repo := &fakeRepository{artifacts: map[string]string{}}
+			var gotCredential *artifactrepository.Credential
+			h := &handler{client: c, newRepository: func(_ *v1alpha3.ArtifactRepositorySpec, credential *artifactrepository.Credential) (artifactrepository.Interface, error) {
+				gotCredential = credential
+				return repo, nil
+			}}
+			ws := kapisruntime.NewWebService(v1alpha3.GroupVersion)
+			registerRoutes(ws, h)
+			container := restful.NewContainer()
+			container.Add(ws)
+
+			httpRequest, _ := http.NewRequest(tt.method, "http://fake.com/kapis/devops.kubesphere.io/v1alpha3/namespaces/ns/artifactrepositories/"+
+				tt.repository+"/artifacts?path="+tt.path, strings.NewReader(tt.body))
+			httpRequest.Header.Set("Content-Type", restful.MIME_OCTET)
+			httpWriter := httptest.NewRecorder()
+			container.Dispatch(httpWriter, httpRequest)
+			assert.Equal(t, tt.expectCode, httpWriter.Code, httpWriter.Body.String())
+			if tt.expectBody != "" {
+				assert.JSONEq(t, tt.expectBody, httpWriter.Body.String())
+			}
+			if tt.expectCode == http.StatusOK {
+				assert.Equal(t, tt.expectCredential, gotCredential)
+			}
+			if tt.expectArtifacts != nil {
+				assert.Equal(t, tt.expectArtifacts, repo.artifacts)
+			}
+		})
+	}
+}
+
+func TestRegisterRoutes(t *testing.T) {
+	ws := kapisruntime.NewWebService(v1alpha3.GroupVersion)
+	RegisterRoutes(ws, fake.NewClientBuilder().Build())
+	for _, route := range ws.Routes() {
+		assert.Equal(t, "/kapis/devops.kubesphere.io/v1alpha3/namespaces/{namespace}/artifactrepositories/{artifactrepository}/artifacts", route.Path)
+		assert.Len(t, route.ParameterDocs, 3)
+	}
+	assert.Len(t, ws.Routes(), 2)
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package artifactrepository
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/constants"
+)
+
+// RegisterRoutes registers the APIs of the artifacts in the ArtifactRepositories
+func RegisterRoutes(service *restful.WebService, genericClient client.Client) {
+	registerRoutes(service, &handler{client: genericClient, newRepository: newRepository})
+}
+
+func registerRoutes(service *restful.WebService, h *handler) {
+	params := []*restful.Parameter{
+		service.PathParameter("namespace", "Namespace of the DevOpsProject"),
+		service.PathParameter("artifactrepository", "Name of the ArtifactRepository"),
+		service.QueryParameter("path", "The path of the artifact in the repository, such as com/example/app/1.0/app-1.0.jar").Required(true),
+	}
+
+	resolveRoute := service.GET("/namespaces/{namespace}/artifactrepositories/{artifactrepository}/artifacts").
+		To(h.resolveArtifact).
+		Doc("Resolve an artifact, returns its download link and whether it exists").
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), Artifact{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsProjectTag})
+	publishRoute := service.PUT("/namespaces/{namespace}/artifactrepositories/{artifactrepository}/artifacts").
+		To(h.publishArtifact).
+		Consumes(restful.MIME_OCTET, restful.MIME_JSON).
+		Doc("Publish an artifact, the request body is the content of it").
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), Artifact{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsProjectTag})
+	for _, param := range params {
+		resolveRoute.Param(param)
+		publishRoute.Param(param)
+	}
+	service.Route(resolveRoute)
+	service.Route(publishRoute)
+}
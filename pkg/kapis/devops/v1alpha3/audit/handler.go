@@ -0,0 +1,73 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	"kubesphere.io/devops/pkg/api"
+	"kubesphere.io/devops/pkg/apiserver/query"
+	"kubesphere.io/devops/pkg/kapis"
+	"kubesphere.io/devops/pkg/models/audit"
+)
+
+type handler struct {
+	store audit.Interface
+}
+
+func (h *handler) listEvents(req *restful.Request, resp *restful.Response) {
+	filter := audit.Filter{
+		Project: req.QueryParameter("project"),
+		User:    req.QueryParameter("user"),
+	}
+
+	var err error
+	if filter.Since, err = parseTime(req.QueryParameter("since")); err != nil {
+		kapis.HandleBadRequest(resp, req, err)
+		return
+	}
+	if filter.Until, err = parseTime(req.QueryParameter("until")); err != nil {
+		kapis.HandleBadRequest(resp, req, err)
+		return
+	}
+
+	events, err := h.store.List(filter)
+	if err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+
+	pagination := query.ParseQueryParameter(req).Pagination
+	start, end := pagination.GetValidPagination(len(events))
+	items := make([]interface{}, 0, end-start)
+	for i := range events[start:end] {
+		items = append(items, events[start+i])
+	}
+	_ = resp.WriteEntity(api.NewListResult(items, len(events)))
+}
+
+func parseTime(value string) (result time.Time, err error) {
+	if value == "" {
+		return
+	}
+	if result, err = time.Parse(time.RFC3339, value); err != nil {
+		err = fmt.Errorf("invalid time '%s', it should be in RFC3339 format", value)
+	}
+	return
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"kubesphere.io/devops/pkg/api"
+	"kubesphere.io/devops/pkg/apiserver/query"
+	"kubesphere.io/devops/pkg/constants"
+	"kubesphere.io/devops/pkg/models/audit"
+)
+
+var (
+	// ProjectQueryParameter filters the audit events by the DevOps project
+	ProjectQueryParameter = restful.QueryParameter("project", "The name of the DevOps project")
+	// UserQueryParameter filters the audit events by the user
+	UserQueryParameter = restful.QueryParameter("user", "The name of the user who did the operations")
+	// SinceQueryParameter filters the audit events by the start time
+	SinceQueryParameter = restful.QueryParameter("since", "The start time in RFC3339 format, e.g. 2022-01-01T00:00:00Z")
+	// UntilQueryParameter filters the audit events by the end time
+	UntilQueryParameter = restful.QueryParameter("until", "The end time in RFC3339 format, e.g. 2022-01-02T00:00:00Z")
+)
+
+// RegisterRoutes registers the audit APIs, nothing is registered if the audit store is nil
+func RegisterRoutes(service *restful.WebService, store audit.Interface) {
+	if store == nil {
+		return
+	}
+
+	h := &handler{store: store}
+	service.Route(service.GET("/audit").
+		To(h.listEvents).
+		Param(ProjectQueryParameter).
+		Param(UserQueryParameter).
+		Param(SinceQueryParameter).
+		Param(UntilQueryParameter).
+		Param(service.QueryParameter(query.ParameterPage, "page").Required(false).DataFormat("page=%d").DefaultValue("page=1")).
+		Param(service.QueryParameter(query.ParameterLimit, "limit").Required(false)).
+		Doc("List the audit events of the DevOps APIs, the latest events come first").
+		Returns(http.StatusOK, api.StatusOK, api.ListResult{Items: []interface{}{}}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsAuditTag}))
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/emicklei/go-restful"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/client/history"
+	"kubesphere.io/devops/pkg/client/s3"
+	"kubesphere.io/devops/pkg/kapis"
+	"kubesphere.io/devops/pkg/models/backup"
+)
+
+type handler struct {
+	client   client.Client
+	s3Client s3.Interface
+	store    history.Interface
+}
+
+func (h *handler) backup(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("devops")
+	if h.s3Client == nil {
+		kapis.HandleError(req, resp, restful.NewError(http.StatusServiceUnavailable, "the object storage is not available"))
+		return
+	}
+
+	archive, err := backup.Export(req.Request.Context(), h.client, h.store, namespace)
+	if err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	buf := &bytes.Buffer{}
+	if err = archive.Encode(buf); err != nil {
+		kapis.HandleInternalError(resp, req, err)
+		return
+	}
+
+	fileName := fmt.Sprintf("%s-%s.json.gz", namespace, archive.CreationTime.UTC().Format("20060102150405"))
+	key := backupPrefix(namespace) + fileName
+	if err = h.s3Client.Upload(key, fileName, buf); err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	_ = resp.WriteHeaderAndEntity(http.StatusCreated, &BackupResult{Key: key, Summary: archive.GetSummary()})
+}
+
+func (h *handler) restore(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("devops")
+	request := &RestoreRequest{}
+	if err := req.ReadEntity(request); err != nil {
+		kapis.HandleBadRequest(resp, req, err)
+		return
+	}
+	if request.Key == "" {
+		kapis.HandleBadRequest(resp, req, fmt.Errorf("the key of the archive is required"))
+		return
+	}
+	// only the archives of the same DevOps project are allowed, it might be in another cluster though
+	if prefix := backupPrefix(namespace); !strings.HasPrefix(path.Clean(request.Key), prefix) {
+		kapis.HandleForbidden(resp, req, fmt.Errorf("the key of the archive must start with '%s'", prefix))
+		return
+	}
+	if h.s3Client == nil {
+		kapis.HandleError(req, resp, restful.NewError(http.StatusServiceUnavailable, "the object storage is not available"))
+		return
+	}
+
+	data, err := h.s3Client.Read(request.Key)
+	if err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	archive, err := backup.Decode(bytes.NewReader(data))
+	if err != nil {
+		kapis.HandleBadRequest(resp, req, fmt.Errorf("invalid archive '%s': %v", request.Key, err))
+		return
+	}
+
+	result, err := backup.Restore(req.Request.Context(), h.client, h.store, namespace, archive, request.RestoreOptions)
+	if err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	_ = resp.WriteEntity(result)
+}
+
+// backupPrefix returns the prefix of the object keys of the archives of a DevOps project
+func backupPrefix(namespace string) string {
+	return path.Join("backups", namespace) + "/"
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	kapisruntime "kubesphere.io/devops/pkg/apiserver/runtime"
+	fakes3 "kubesphere.io/devops/pkg/client/s3/fake"
+	"kubesphere.io/devops/pkg/constants"
+	"kubesphere.io/devops/pkg/models/backup"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1.AddToScheme(schema))
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	var objects []client.Object
+	for _, name := range []string{"source", "target"} {
+		objects = append(objects, &v1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{constants.DevOpsProjectLabelKey: name},
+		}}, &v1alpha3.DevOpsProject{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	}
+	objects = append(objects, &v1alpha3.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "source", Name: "build"},
+		Spec:       v1alpha3.PipelineSpec{Type: v1alpha3.NoScmPipelineType, Pipeline: &v1alpha3.NoScmPipeline{Name: "build"}},
+	})
+	c := fake.NewClientBuilder().WithScheme(schema).WithObjects(objects...).Build()
+
+	dispatch := func(s3Client *fakes3.FakeS3, uri, body string) *httptest.ResponseRecorder {
+		ws := kapisruntime.NewWebService(v1alpha3.GroupVersion)
+		if s3Client == nil {
+			RegisterRoutes(ws, c, nil, nil)
+		} else {
+			RegisterRoutes(ws, c, s3Client, nil)
+		}
+		container := restful.NewContainer()
+		container.Add(ws)
+
+		httpRequest, _ := http.NewRequest(http.MethodPost,
+			"http://fake.com/kapis/devops.kubesphere.io/v1alpha3"+uri, strings.NewReader(body))
+		httpRequest.Header.Set("Content-Type", "application/json")
+		httpWriter := httptest.NewRecorder()
+		container.Dispatch(httpWriter, httpRequest)
+		return httpWriter
+	}
+
+	httpWriter := dispatch(nil, "/devops/source/backups", "")
+	assert.Equal(t, http.StatusServiceUnavailable, httpWriter.Code)
+
+	s3Client := fakes3.NewFakeS3()
+	httpWriter = dispatch(s3Client, "/devops/default/backups", "")
+	assert.Equal(t, http.StatusNotFound, httpWriter.Code)
+
+	httpWriter = dispatch(s3Client, "/devops/source/backups", "")
+	assert.Equal(t, http.StatusCreated, httpWriter.Code, httpWriter.Body.String())
+	result := &BackupResult{}
+	assert.Nil(t, json.Unmarshal(httpWriter.Body.Bytes(), result))
+	assert.True(t, strings.HasPrefix(result.Key, "backups/source/"))
+	assert.Equal(t, 1, result.Pipelines)
+
+	httpWriter = dispatch(s3Client, "/devops/target/restore", `{}`)
+	assert.Equal(t, http.StatusBadRequest, httpWriter.Code)
+
+	// the archives of other projects are not allowed
+	httpWriter = dispatch(s3Client, "/devops/target/restore", `{"key":"`+result.Key+`"}`)
+	assert.Equal(t, http.StatusForbidden, httpWriter.Code, httpWriter.Body.String())
+	httpWriter = dispatch(s3Client, "/devops/target/restore", `{"key":"backups/target/../source/fake.json.gz"}`)
+	assert.Equal(t, http.StatusForbidden, httpWriter.Code, httpWriter.Body.String())
+
+	pipeline := &v1alpha3.Pipeline{}
+	assert.Nil(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "source", Name: "build"}, pipeline))
+	assert.Nil(t, c.Delete(context.TODO(), pipeline))
+	httpWriter = dispatch(s3Client, "/devops/source/restore", `{"key":"`+result.Key+`"}`)
+	assert.Equal(t, http.StatusOK, httpWriter.Code, httpWriter.Body.String())
+	restoreResult := &backup.RestoreResult{}
+	assert.Nil(t, json.Unmarshal(httpWriter.Body.Bytes(), restoreResult))
+	assert.Equal(t, backup.RestoreCount{Created: 1}, restoreResult.Pipelines)
+	assert.Nil(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "source", Name: "build"}, &v1alpha3.Pipeline{}))
+}
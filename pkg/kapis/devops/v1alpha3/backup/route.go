@@ -0,0 +1,69 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/client/history"
+	"kubesphere.io/devops/pkg/client/s3"
+	"kubesphere.io/devops/pkg/constants"
+	"kubesphere.io/devops/pkg/models/backup"
+)
+
+// DevopsPathParameter is a path parameter definition for devops
+var DevopsPathParameter = restful.PathParameter("devops", "DevOps project's namespace")
+
+// RestoreRequest is the archive to be restored into a DevOps project
+type RestoreRequest struct {
+	// Key is the object key of the archive in the object storage
+	Key                   string `json:"key"`
+	backup.RestoreOptions `json:",inline"`
+}
+
+// BackupResult is the archive which has been uploaded to the object storage
+type BackupResult struct {
+	Key            string `json:"key"`
+	backup.Summary `json:",inline"`
+}
+
+// RegisterRoutes registers the APIs of backing up and restoring the DevOps projects, the PipelineRun summaries
+// are not included if the history store is nil
+func RegisterRoutes(service *restful.WebService, c client.Client, s3Client s3.Interface, store history.Interface) {
+	h := &handler{client: c, s3Client: s3Client, store: store}
+	service.Route(service.POST("/devops/{devops}/backups").
+		To(h.backup).
+		Param(DevopsPathParameter).
+		Doc("Export the Pipelines, the Templates, the credential references and the PipelineRun summaries of a "+
+			"DevOps project into an archive in the object storage. The secret data of the credentials is not exported").
+		Returns(http.StatusCreated, http.StatusText(http.StatusCreated), BackupResult{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsProjectTag}))
+
+	service.Route(service.POST("/devops/{devops}/restore").
+		To(h.restore).
+		Param(DevopsPathParameter).
+		Reads(RestoreRequest{}).
+		Doc("Restore an archive into a DevOps project, which could be in another cluster sharing the same object "+
+			"storage. Only the archives under 'backups/{devops}/' are accepted. The existing Pipelines and Templates are "+
+			"skipped unless overwrite is true").
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), backup.RestoreResult{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsProjectTag}))
+}
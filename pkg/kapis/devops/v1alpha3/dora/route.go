@@ -0,0 +1,52 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dora
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"kubesphere.io/devops/pkg/client/history"
+	"kubesphere.io/devops/pkg/constants"
+	"kubesphere.io/devops/pkg/models/dora"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	// DevopsPathParameter is a path parameter definition for devops
+	DevopsPathParameter = restful.PathParameter("devops", "DevOps project's namespace")
+	// SinceQueryParameter is the start of the period
+	SinceQueryParameter = restful.QueryParameter("since", "The start time in RFC3339 format, it's 30 days ago by default")
+	// UntilQueryParameter is the end of the period
+	UntilQueryParameter = restful.QueryParameter("until", "The end time in RFC3339 format, it's now by default")
+)
+
+// RegisterRoutes registers the DORA metrics APIs. The PipelineRuns are read from the history store if it's
+// not nil, otherwise from the cluster.
+func RegisterRoutes(service *restful.WebService, c client.Client, store history.Interface) {
+	h := &handler{client: c, store: store}
+	service.Route(service.GET("/devops/{devops}/metrics/dora").
+		To(h.getMetrics).
+		Param(DevopsPathParameter).
+		Param(SinceQueryParameter).
+		Param(UntilQueryParameter).
+		Doc("Get the DORA metrics of a DevOps project, the runs of the Pipelines annotated with "+
+			"pipeline.devops.kubesphere.io/deployment=true are taken as the deployments").
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), dora.Metrics{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsProjectTag}))
+}
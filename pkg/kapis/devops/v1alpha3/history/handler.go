@@ -0,0 +1,76 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package history
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	"kubesphere.io/devops/pkg/api"
+	"kubesphere.io/devops/pkg/apiserver/query"
+	"kubesphere.io/devops/pkg/client/history"
+	"kubesphere.io/devops/pkg/kapis"
+)
+
+type handler struct {
+	store history.Interface
+}
+
+func (h *handler) listHistories(req *restful.Request, resp *restful.Response) {
+	pagination := query.ParseQueryParameter(req).Pagination
+	filter := history.Filter{
+		Namespace: req.PathParameter("namespace"),
+		Pipeline:  req.QueryParameter("pipeline"),
+		Branch:    req.QueryParameter("branch"),
+		Phase:     req.QueryParameter("phase"),
+		Limit:     pagination.Limit,
+		Offset:    pagination.Offset,
+	}
+
+	var err error
+	if filter.Since, err = parseTime(req.QueryParameter("since")); err != nil {
+		kapis.HandleBadRequest(resp, req, err)
+		return
+	}
+	if filter.Until, err = parseTime(req.QueryParameter("until")); err != nil {
+		kapis.HandleBadRequest(resp, req, err)
+		return
+	}
+
+	summaries, total, err := h.store.List(req.Request.Context(), filter)
+	if err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+
+	items := make([]interface{}, 0, len(summaries))
+	for i := range summaries {
+		items = append(items, summaries[i])
+	}
+	_ = resp.WriteEntity(api.NewListResult(items, total))
+}
+
+func parseTime(value string) (result time.Time, err error) {
+	if value == "" {
+		return
+	}
+	if result, err = time.Parse(time.RFC3339, value); err != nil {
+		err = fmt.Errorf("invalid time '%s', it should be in RFC3339 format", value)
+	}
+	return
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package history
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"kubesphere.io/devops/pkg/api"
+	"kubesphere.io/devops/pkg/apiserver/query"
+	"kubesphere.io/devops/pkg/client/history"
+	"kubesphere.io/devops/pkg/constants"
+)
+
+var (
+	// NamespacePathParameter is a path parameter definition for namespace
+	NamespacePathParameter = restful.PathParameter("namespace", "The namespace of the PipelineRuns")
+	// PipelineQueryParameter filters the history by the Pipeline
+	PipelineQueryParameter = restful.QueryParameter("pipeline", "The name of the Pipeline")
+	// BranchQueryParameter filters the history by the SCM branch
+	BranchQueryParameter = restful.QueryParameter("branch", "The branch of the multi-branch Pipeline")
+	// PhaseQueryParameter filters the history by the phase of the PipelineRuns
+	PhaseQueryParameter = restful.QueryParameter("phase", "The phase of the PipelineRuns, e.g. Succeeded, Failed")
+	// SinceQueryParameter filters the history by the completion time
+	SinceQueryParameter = restful.QueryParameter("since", "The start time in RFC3339 format, e.g. 2022-01-01T00:00:00Z")
+	// UntilQueryParameter filters the history by the completion time
+	UntilQueryParameter = restful.QueryParameter("until", "The end time in RFC3339 format, e.g. 2022-01-02T00:00:00Z")
+)
+
+// RegisterRoutes registers the PipelineRun history APIs, nothing is registered if the history store is nil
+func RegisterRoutes(service *restful.WebService, store history.Interface) {
+	if store == nil {
+		return
+	}
+
+	h := &handler{store: store}
+	service.Route(service.GET("/namespaces/{namespace}/pipelinerunhistories").
+		To(h.listHistories).
+		Param(NamespacePathParameter).
+		Param(PipelineQueryParameter).
+		Param(BranchQueryParameter).
+		Param(PhaseQueryParameter).
+		Param(SinceQueryParameter).
+		Param(UntilQueryParameter).
+		Param(service.QueryParameter(query.ParameterPage, "page").Required(false).DataFormat("page=%d").DefaultValue("page=1")).
+		Param(service.QueryParameter(query.ParameterLimit, "limit").Required(false)).
+		Doc("List the archived PipelineRuns, the latest completed ones come first").
+		Returns(http.StatusOK, api.StatusOK, api.ListResult{Items: []interface{}{}}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsPipelineTag}))
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/emicklei/go-restful"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/backend"
+	"kubesphere.io/devops/pkg/client/devops/jenkins"
+	"kubesphere.io/devops/pkg/kapis"
+)
+
+// JenkinsfileValidator validates the syntax of the declarative Jenkinsfiles
+type JenkinsfileValidator interface {
+	ValidateJenkinsfile(jenkinsfile string) (*jenkins.ValidateJenkinsfileResponse, error)
+}
+
+// DryRunRequest is the Pipeline to be validated, a Pipeline of the type pipeline is made from the Jenkinsfile
+// if the Pipeline is not given
+type DryRunRequest struct {
+	Pipeline    *v1alpha3.Pipeline `json:"pipeline,omitempty"`
+	Jenkinsfile string             `json:"jenkinsfile,omitempty"`
+}
+
+// DryRunResult is the generated backend object and the validation result of a Pipeline
+type DryRunResult struct {
+	// Valid indicates there are no errors, the Pipeline could be saved
+	Valid bool `json:"valid"`
+	// Backend is the backend which the Pipeline belongs to
+	Backend string `json:"backend"`
+	// Object is the generated backend object, it's the config.xml of the job for Jenkins
+	Object   string   `json:"object,omitempty"`
+	Errors   []string `json:"errors,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// dryRun validates a Pipeline by the Kubernetes apiserver along with the admission webhooks, and generates
+// the backend object of it, without persisting anything
+func (h *apiHandler) dryRun(request *restful.Request, response *restful.Response) {
+	ctx := request.Request.Context()
+	namespace := request.PathParameter("namespace")
+
+	dryRunRequest := &DryRunRequest{}
+	if err := request.ReadEntity(dryRunRequest); err != nil {
+		kapis.HandleBadRequest(response, request, err)
+		return
+	}
+	pipeline := dryRunRequest.Pipeline
+	if pipeline == nil {
+		if dryRunRequest.Jenkinsfile == "" {
+			kapis.HandleBadRequest(response, request, fmt.Errorf("either the pipeline or the jenkinsfile is required"))
+			return
+		}
+		pipeline = &v1alpha3.Pipeline{Spec: v1alpha3.PipelineSpec{
+			Type:     v1alpha3.NoScmPipelineType,
+			Pipeline: &v1alpha3.NoScmPipeline{Name: "dryrun", Jenkinsfile: dryRunRequest.Jenkinsfile},
+		}}
+	}
+	pipeline.Namespace = namespace
+	if pipeline.Name == "" && pipeline.GenerateName == "" {
+		pipeline.GenerateName = "dryrun-"
+	}
+
+	result := &DryRunResult{Backend: string(backend.Jenkins)}
+	if err := h.dryRunPipeline(ctx, pipeline.DeepCopy()); err != nil {
+		if !apierrors.IsInvalid(err) && !apierrors.IsForbidden(err) && !apierrors.IsBadRequest(err) {
+			kapis.HandleError(request, response, err)
+			return
+		}
+		result.Errors = append(result.Errors, getErrorMessages(err)...)
+	}
+
+	if backendType, ok := backend.TypeOf(pipeline); ok && backendType != backend.Jenkins {
+		result.Backend = string(backendType)
+		result.Warnings = append(result.Warnings, fmt.Sprintf("the objects of the %s backend are generated when running", backendType))
+	} else {
+		h.lintJenkinsPipeline(pipeline, result)
+	}
+	result.Valid = len(result.Errors) == 0
+	_ = response.WriteEntity(result)
+}
+
+// dryRunPipeline creates or updates the Pipeline in the dry-run mode
+func (h *apiHandler) dryRunPipeline(ctx context.Context, pipeline *v1alpha3.Pipeline) (err error) {
+	if pipeline.Name != "" {
+		existing := &v1alpha3.Pipeline{}
+		if err = h.client.Get(ctx, client.ObjectKeyFromObject(pipeline), existing); err == nil {
+			pipeline.ResourceVersion = existing.ResourceVersion
+			return h.client.Update(ctx, pipeline, client.DryRunAll)
+		} else if !apierrors.IsNotFound(err) {
+			return
+		}
+	}
+	return h.client.Create(ctx, pipeline, client.DryRunAll)
+}
+
+// lintJenkinsPipeline generates the config.xml of the Jenkins job, and validates the Jenkinsfile by Jenkins
+func (h *apiHandler) lintJenkinsPipeline(pipeline *v1alpha3.Pipeline, result *DryRunResult) {
+	var err error
+	if result.Object, err = jenkins.GeneratePipelineConfig(pipeline.Namespace, pipeline); err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return
+	}
+
+	var discarder *v1alpha3.DiscarderProperty
+	switch pipeline.Spec.Type {
+	case v1alpha3.NoScmPipelineType:
+		discarder = pipeline.Spec.Pipeline.Discarder
+		h.validateJenkinsfile(pipeline, result)
+	case v1alpha3.MultiBranchPipelineType:
+		discarder = pipeline.Spec.MultiBranchPipeline.Discarder
+	}
+	if discarder == nil || (discarder.DaysToKeep == "" && discarder.NumToKeep == "") {
+		result.Warnings = append(result.Warnings, "no discarder is configured, the builds are kept forever")
+	}
+}
+
+func (h *apiHandler) validateJenkinsfile(pipeline *v1alpha3.Pipeline, result *DryRunResult) {
+	jenkinsfile := pipeline.Spec.Pipeline.Jenkinsfile
+	switch {
+	case jenkinsfile == "" && pipeline.Spec.Template == nil && pipeline.Spec.Source == nil:
+		result.Errors = append(result.Errors, "the jenkinsfile is required")
+		return
+	case jenkinsfile == "":
+		// it's rendered from the template or loaded from the git repository by the controller
+		return
+	case h.validator == nil:
+		result.Warnings = append(result.Warnings, "the jenkinsfile is not validated because Jenkins is not available")
+		return
+	}
+
+	validation, err := h.validator.ValidateJenkinsfile(jenkinsfile)
+	if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("failed to validate the jenkinsfile: %v", err))
+		return
+	}
+	if validation.Data.Result == "failure" {
+		for _, validationErr := range validation.Data.Errors {
+			result.Errors = append(result.Errors, fmt.Sprint(validationErr["error"]))
+		}
+		if len(validation.Data.Errors) == 0 {
+			result.Errors = append(result.Errors, "the jenkinsfile is invalid")
+		}
+	}
+}
+
+// getErrorMessages returns the causes of an invalid error, or the message of the other errors
+func getErrorMessages(err error) (messages []string) {
+	if status, ok := err.(apierrors.APIStatus); ok && status.Status().Details != nil {
+		for _, cause := range status.Status().Details.Causes {
+			if cause.Field != "" {
+				messages = append(messages, fmt.Sprintf("%s: %s", cause.Field, cause.Message))
+			} else {
+				messages = append(messages, cause.Message)
+			}
+		}
+	}
+	if len(messages) == 0 {
+		messages = append(messages, err.Error())
+	}
+	return
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	kapisruntime "kubesphere.io/devops/pkg/apiserver/runtime"
+	"kubesphere.io/devops/pkg/client/devops/jenkins"
+)
+
+type fakeValidator struct {
+	err error
+}
+
+func (v *fakeValidator) ValidateJenkinsfile(jenkinsfile string) (*jenkins.ValidateJenkinsfileResponse, error) {
+	response := &jenkins.ValidateJenkinsfileResponse{Status: "ok"}
+	response.Data.Result = "success"
+	if strings.Contains(jenkinsfile, "invalid") {
+		response.Data.Result = "failure"
+		response.Data.Errors = []map[string]interface{}{{"error": "unexpected token: invalid @ line 1"}}
+	}
+	return response, v.err
+}
+
+func TestDryRun(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+	existing := &v1alpha3.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "build"},
+		Spec:       v1alpha3.PipelineSpec{Type: v1alpha3.NoScmPipelineType, Pipeline: &v1alpha3.NoScmPipeline{Name: "build"}},
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		validator  JenkinsfileValidator
+		expectCode int
+		verify     func(t *testing.T, result *DryRunResult)
+	}{{
+		name:       "neither pipeline nor jenkinsfile",
+		body:       `{}`,
+		expectCode: http.StatusBadRequest,
+	}, {
+		name:       "a valid jenkinsfile",
+		body:       `{"jenkinsfile":"pipeline { agent any }"}`,
+		validator:  &fakeValidator{},
+		expectCode: http.StatusOK,
+		verify: func(t *testing.T, result *DryRunResult) {
+			assert.True(t, result.Valid)
+			assert.Equal(t, "Jenkins", result.Backend)
+			assert.Contains(t, result.Object, "<script>pipeline { agent any }</script>")
+			assert.Equal(t, []string{"no discarder is configured, the builds are kept forever"}, result.Warnings)
+		},
+	}, {
+		name:       "an invalid jenkinsfile",
+		body:       `{"jenkinsfile":"invalid"}`,
+		validator:  &fakeValidator{},
+		expectCode: http.StatusOK,
+		verify: func(t *testing.T, result *DryRunResult) {
+			assert.False(t, result.Valid)
+			assert.Equal(t, []string{"unexpected token: invalid @ line 1"}, result.Errors)
+		},
+	}, {
+		name:       "Jenkins is not available",
+		body:       `{"jenkinsfile":"invalid"}`,
+		validator:  &fakeValidator{err: errors.New("connection refused")},
+		expectCode: http.StatusOK,
+		verify: func(t *testing.T, result *DryRunResult) {
+			assert.True(t, result.Valid)
+			assert.Contains(t, result.Warnings, "failed to validate the jenkinsfile: connection refused")
+		},
+	}, {
+		name: "update an existing pipeline without the validator",
+		body: `{"pipeline":{"metadata":{"name":"build"},"spec":{"type":"pipeline","pipeline":{"name":"build",
+			"jenkinsfile":"pipeline {}","discarder":{"num_to_keep":"10"}}}}}`,
+		expectCode: http.StatusOK,
+		verify: func(t *testing.T, result *DryRunResult) {
+			assert.True(t, result.Valid)
+			assert.Equal(t, []string{"the jenkinsfile is not validated because Jenkins is not available"}, result.Warnings)
+		},
+	}, {
+		name:       "a pipeline without the jenkinsfile",
+		body:       `{"pipeline":{"spec":{"type":"pipeline","pipeline":{"name":"build"}}}}`,
+		expectCode: http.StatusOK,
+		verify: func(t *testing.T, result *DryRunResult) {
+			assert.False(t, result.Valid)
+			assert.Equal(t, []string{"the jenkinsfile is required"}, result.Errors)
+		},
+	}, {
+		name:       "a multi-branch pipeline",
+		body:       `{"pipeline":{"spec":{"type":"multi-branch-pipeline","multi_branch_pipeline":{"name":"build","source_type":"git","git_source":{"url":"https://github.com/kubesphere/ks-devops"}}}}}`,
+		expectCode: http.StatusOK,
+		verify: func(t *testing.T, result *DryRunResult) {
+			assert.True(t, result.Valid)
+			assert.Contains(t, result.Object, "https://github.com/kubesphere/ks-devops")
+		},
+	}, {
+		name:       "an unsupported type",
+		body:       `{"pipeline":{"spec":{"type":"unknown"}}}`,
+		expectCode: http.StatusOK,
+		verify: func(t *testing.T, result *DryRunResult) {
+			assert.False(t, result.Valid)
+			assert.Equal(t, []string{`unsupported pipeline type "unknown"`}, result.Errors)
+		},
+	}, {
+		name:       "a Tekton pipeline",
+		body:       `{"pipeline":{"metadata":{"labels":{"devops.kubesphere.io/pipeline-backend":"Tekton"}},"spec":{"type":"pipeline"}}}`,
+		expectCode: http.StatusOK,
+		verify: func(t *testing.T, result *DryRunResult) {
+			assert.True(t, result.Valid)
+			assert.Equal(t, "Tekton", result.Backend)
+			assert.Empty(t, result.Object)
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(existing.DeepCopy()).Build()
+			ws := kapisruntime.NewWebService(v1alpha3.GroupVersion)
+			RegisterRoutes(ws, c, nil, tt.validator, nil)
+			container := restful.NewContainer()
+			container.Add(ws)
+
+			httpRequest, _ := http.NewRequest(http.MethodPost,
+				"http://fake.com/kapis/devops.kubesphere.io/v1alpha3/namespaces/ns/pipelines/dryrun", strings.NewReader(tt.body))
+			httpRequest.Header.Set("Content-Type", restful.MIME_JSON)
+			httpWriter := httptest.NewRecorder()
+			container.Dispatch(httpWriter, httpRequest)
+			assert.Equal(t, tt.expectCode, httpWriter.Code, httpWriter.Body.String())
+			if tt.verify != nil {
+				result := &DryRunResult{}
+				assert.Nil(t, json.Unmarshal(httpWriter.Body.Bytes(), result))
+				tt.verify(t, result)
+			}
+
+			// nothing is persisted
+			list := &v1alpha3.PipelineList{}
+			assert.Nil(t, c.List(httpRequest.Context(), list, client.InNamespace("ns")))
+			assert.Len(t, list.Items, 1)
+		})
+	}
+}
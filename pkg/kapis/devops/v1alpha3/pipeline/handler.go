@@ -20,6 +20,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 
 	"kubesphere.io/devops/pkg/kapis"
 
@@ -29,12 +30,16 @@ import (
 	"kubesphere.io/devops/pkg/api"
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
 	"kubesphere.io/devops/pkg/apiserver/query"
+	"kubesphere.io/devops/pkg/client/history"
 	modelpipeline "kubesphere.io/devops/pkg/models/pipeline"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type apiHandlerOption struct {
-	client client.Client
+	client    client.Client
+	store     history.Interface
+	validator JenkinsfileValidator
+	importer  JobConfigGetter
 }
 
 type apiHandler struct {
@@ -112,3 +117,73 @@ func (h *apiHandler) getBranch(request *restful.Request, response *restful.Respo
 	}
 	_ = response.WriteEntity(searchedBranch)
 }
+
+const (
+	defaultAnalyticsLimit = 20
+	maxAnalyticsLimit     = 100
+)
+
+func (h *apiHandler) getStageAnalytics(request *restful.Request, response *restful.Response) {
+	namespaceName := request.PathParameter("namespace")
+	pipelineName := request.PathParameter("pipeline")
+
+	limit := defaultAnalyticsLimit
+	if limitStr := request.QueryParameter("limit"); limitStr != "" {
+		var err error
+		if limit, err = strconv.Atoi(limitStr); err != nil || limit <= 0 || limit > maxAnalyticsLimit {
+			kapis.HandleBadRequest(response, request, fmt.Errorf("limit should be a number between 1 and %d", maxAnalyticsLimit))
+			return
+		}
+	}
+
+	pipeline := &v1alpha3.Pipeline{}
+	if err := h.client.Get(context.Background(), client.ObjectKey{Namespace: namespaceName, Name: pipelineName}, pipeline); err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+
+	summaries, err := modelpipeline.LoadRecentRuns(request.Request.Context(), h.client, h.store,
+		namespaceName, pipelineName, request.QueryParameter("branch"), limit)
+	if err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+	_ = response.WriteEntity(modelpipeline.Analyze(pipelineName, summaries))
+}
+
+func (h *apiHandler) getGraph(request *restful.Request, response *restful.Response) {
+	namespaceName := request.PathParameter("namespace")
+	pipelineName := request.PathParameter("pipeline")
+
+	pipeline := &v1alpha3.Pipeline{}
+	if err := h.client.Get(context.Background(), client.ObjectKey{Namespace: namespaceName, Name: pipelineName}, pipeline); err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+
+	// the Jenkinsfiles of a multi-branch Pipeline are in the branches of its repository
+	if pipeline.Spec.Type != v1alpha3.NoScmPipelineType || pipeline.Spec.Pipeline == nil {
+		kapis.HandleBadRequest(response, request, fmt.Errorf("only the graph of a non multi-branch Pipeline is supported"))
+		return
+	}
+
+	jenkinsfileJSON := pipeline.Annotations[v1alpha3.PipelineJenkinsfileValueAnnoKey]
+	if jenkinsfileJSON == "" {
+		switch {
+		case pipeline.Annotations[v1alpha3.PipelineJenkinsfileValidateAnnoKey] == v1alpha3.PipelineJenkinsfileValidateFailure:
+			kapis.HandleBadRequest(response, request, fmt.Errorf("the Jenkinsfile of Pipeline %s is invalid", pipelineName))
+		case pipeline.Spec.Pipeline.Jenkinsfile != "":
+			kapis.HandleConflict(response, request, fmt.Errorf("the Jenkinsfile of Pipeline %s is not parsed yet, please try again later", pipelineName))
+		default:
+			_ = response.WriteEntity(&modelpipeline.Graph{Nodes: []modelpipeline.GraphNode{}, Edges: []modelpipeline.GraphEdge{}})
+		}
+		return
+	}
+
+	graph, err := modelpipeline.ParseGraph(jenkinsfileJSON)
+	if err != nil {
+		kapis.HandleInternalError(response, request, err)
+		return
+	}
+	_ = response.WriteEntity(graph)
+}
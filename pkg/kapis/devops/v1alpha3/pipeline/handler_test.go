@@ -0,0 +1,118 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	kapisruntime "kubesphere.io/devops/pkg/apiserver/runtime"
+	modelpipeline "kubesphere.io/devops/pkg/models/pipeline"
+)
+
+func TestGetGraph(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	newPipeline := func(name string, annotations map[string]string, spec v1alpha3.PipelineSpec) *v1alpha3.Pipeline {
+		return &v1alpha3.Pipeline{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name, Annotations: annotations},
+			Spec:       spec,
+		}
+	}
+	noScm := v1alpha3.PipelineSpec{Type: v1alpha3.NoScmPipelineType, Pipeline: &v1alpha3.NoScmPipeline{Jenkinsfile: "pipeline {}"}}
+
+	tests := []struct {
+		name       string
+		pipeline   string
+		expectCode int
+		verify     func(t *testing.T, graph *modelpipeline.Graph)
+	}{{
+		name:       "not found",
+		pipeline:   "fake",
+		expectCode: http.StatusNotFound,
+	}, {
+		name:       "multi-branch pipeline",
+		pipeline:   "multi-branch",
+		expectCode: http.StatusBadRequest,
+	}, {
+		name:       "invalid jenkinsfile",
+		pipeline:   "invalid",
+		expectCode: http.StatusBadRequest,
+	}, {
+		name:       "jenkinsfile is not parsed yet",
+		pipeline:   "not-parsed",
+		expectCode: http.StatusConflict,
+	}, {
+		name:       "empty jenkinsfile",
+		pipeline:   "empty",
+		expectCode: http.StatusOK,
+		verify: func(t *testing.T, graph *modelpipeline.Graph) {
+			assert.Empty(t, graph.Nodes)
+		},
+	}, {
+		name:       "parsed jenkinsfile",
+		pipeline:   "build",
+		expectCode: http.StatusOK,
+		verify: func(t *testing.T, graph *modelpipeline.Graph) {
+			assert.Equal(t, []modelpipeline.GraphNode{{ID: "0", Name: "test", Type: modelpipeline.GraphNodeStage, Steps: 1}},
+				graph.Nodes)
+			assert.Equal(t, 1, graph.Parallelism)
+		},
+	}}
+
+	c := fake.NewClientBuilder().WithScheme(schema).WithObjects(
+		newPipeline("multi-branch", nil, v1alpha3.PipelineSpec{Type: v1alpha3.MultiBranchPipelineType,
+			MultiBranchPipeline: &v1alpha3.MultiBranchPipeline{}}),
+		newPipeline("invalid", map[string]string{
+			v1alpha3.PipelineJenkinsfileValidateAnnoKey: v1alpha3.PipelineJenkinsfileValidateFailure,
+		}, noScm),
+		newPipeline("not-parsed", nil, noScm),
+		newPipeline("empty", nil, v1alpha3.PipelineSpec{Type: v1alpha3.NoScmPipelineType, Pipeline: &v1alpha3.NoScmPipeline{}}),
+		newPipeline("build", map[string]string{
+			v1alpha3.PipelineJenkinsfileValueAnnoKey: `{"pipeline":{"stages":[{"name":"test","branches":[{"name":"default","steps":[{"name":"sh"}]}]}]}}`,
+		}, noScm),
+	).Build()
+	ws := kapisruntime.NewWebService(v1alpha3.GroupVersion)
+	RegisterRoutes(ws, c, nil, nil, nil)
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpRequest, _ := http.NewRequest(http.MethodGet,
+				"http://fake.com/kapis/devops.kubesphere.io/v1alpha3/namespaces/ns/pipelines/"+tt.pipeline+"/graph", nil)
+			httpWriter := httptest.NewRecorder()
+			container.Dispatch(httpWriter, httpRequest)
+			assert.Equal(t, tt.expectCode, httpWriter.Code, httpWriter.Body.String())
+			if tt.verify != nil {
+				graph := &modelpipeline.Graph{}
+				assert.Nil(t, json.Unmarshal(httpWriter.Body.Bytes(), graph))
+				tt.verify(t, graph)
+			}
+		})
+	}
+}
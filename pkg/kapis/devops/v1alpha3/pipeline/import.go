@@ -0,0 +1,149 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/emicklei/go-restful"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/kapis"
+)
+
+// JobConfigGetter reads the config of a Jenkins job as a Pipeline, the job is in the given folder
+type JobConfigGetter interface {
+	GetProjectPipelineConfig(projectID, pipelineID string) (*v1alpha3.Pipeline, error)
+}
+
+// ImportJob is a pre-existing Jenkins job to be imported
+type ImportJob struct {
+	// Path is the full name of the job, the folders are separated by slashes, e.g. team-a/backend/build
+	Path string `json:"path"`
+	// Name is the name of the Pipeline, it's made from the job name if it's empty
+	Name string `json:"name,omitempty"`
+}
+
+// ImportRequest is the Jenkins jobs to be imported into a DevOpsProject
+type ImportRequest struct {
+	Jobs []ImportJob `json:"jobs"`
+	// DryRun returns the Pipelines without creating them
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// ImportResult is the result of importing a Jenkins job, the Error is set if it failed
+type ImportResult struct {
+	Path     string             `json:"path"`
+	Pipeline *v1alpha3.Pipeline `json:"pipeline,omitempty"`
+	Error    string             `json:"error,omitempty"`
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// importJobs creates the Pipelines from the config of the Jenkins jobs in a DevOpsProject. The Pipelines are marked by
+// the annotation of the job path, the original jobs are kept untouched, then the controller creates the managed jobs
+// in the folder of the DevOpsProject.
+func (h *apiHandler) importJobs(request *restful.Request, response *restful.Response) {
+	ctx := request.Request.Context()
+	namespace := request.PathParameter("namespace")
+
+	importRequest := &ImportRequest{}
+	if err := request.ReadEntity(importRequest); err != nil {
+		kapis.HandleBadRequest(response, request, err)
+		return
+	}
+	if len(importRequest.Jobs) == 0 {
+		kapis.HandleBadRequest(response, request, fmt.Errorf("at least one job is required"))
+		return
+	}
+	if h.importer == nil {
+		kapis.HandleError(request, response, restful.NewError(http.StatusServiceUnavailable,
+			"unable to import the Jenkins jobs without Jenkins"))
+		return
+	}
+
+	results := make([]ImportResult, 0, len(importRequest.Jobs))
+	for _, job := range importRequest.Jobs {
+		result := ImportResult{Path: job.Path}
+		pipeline, err := h.importJob(ctx, namespace, job, importRequest.DryRun)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Pipeline = pipeline
+		}
+		results = append(results, result)
+	}
+	_ = response.WriteEntity(results)
+}
+
+// importJob reads the config of the Jenkins job, then creates the Pipeline unless it's a dry run
+func (h *apiHandler) importJob(ctx context.Context, namespace string, job ImportJob, dryRun bool) (
+	pipeline *v1alpha3.Pipeline, err error) {
+	segments := strings.Split(strings.Trim(job.Path, "/"), "/")
+	jobName := segments[len(segments)-1]
+	if jobName == "" {
+		return nil, fmt.Errorf("the job path is required")
+	}
+	name := job.Name
+	if name == "" {
+		name = strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(jobName), "-"), "-")
+	}
+	if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid pipeline name %q: %s", name, strings.Join(errs, ", "))
+	}
+
+	// the nested folders are joined as the parent path of the job
+	config, err := h.importer.GetProjectPipelineConfig(strings.Join(segments[:len(segments)-1], "/job/"), jobName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the config of job %q: %v", job.Path, err)
+	}
+
+	pipeline = &v1alpha3.Pipeline{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1alpha3.GroupVersion.String(),
+			Kind:       v1alpha3.ResourceKindPipeline,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespace,
+			Name:        name,
+			Annotations: map[string]string{v1alpha3.PipelineImportedFromAnnoKey: strings.Trim(job.Path, "/")},
+		},
+		Spec: config.Spec,
+	}
+	switch pipeline.Spec.Type {
+	case v1alpha3.NoScmPipelineType:
+		pipeline.Spec.Pipeline.Name = name
+	case v1alpha3.MultiBranchPipelineType:
+		pipeline.Spec.MultiBranchPipeline.Name = name
+	}
+
+	var opts []client.CreateOption
+	if dryRun {
+		opts = append(opts, client.DryRunAll)
+	}
+	if err = h.client.Create(ctx, pipeline, opts...); err != nil {
+		return nil, err
+	}
+	return
+}
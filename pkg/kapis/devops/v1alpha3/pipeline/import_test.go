@@ -0,0 +1,146 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	kapisruntime "kubesphere.io/devops/pkg/apiserver/runtime"
+)
+
+// fakeImporter holds the job configs keyed by the folder and the job name, which are joined by a space
+type fakeImporter map[string]*v1alpha3.Pipeline
+
+func (i fakeImporter) GetProjectPipelineConfig(projectID, pipelineID string) (*v1alpha3.Pipeline, error) {
+	if pipeline, ok := i[projectID+" "+pipelineID]; ok {
+		return pipeline.DeepCopy(), nil
+	}
+	return nil, fmt.Errorf("404")
+}
+
+func TestImportJobs(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+	importer := fakeImporter{
+		"team-a/job/backend Build_App": {Spec: v1alpha3.PipelineSpec{
+			Type:     v1alpha3.NoScmPipelineType,
+			Pipeline: &v1alpha3.NoScmPipeline{Name: "Build_App", Jenkinsfile: "pipeline { agent any }"},
+		}},
+		" release": {Spec: v1alpha3.PipelineSpec{
+			Type:                v1alpha3.MultiBranchPipelineType,
+			MultiBranchPipeline: &v1alpha3.MultiBranchPipeline{Name: "release", ScriptPath: "Jenkinsfile"},
+		}},
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		importer   JobConfigGetter
+		expectCode int
+		verify     func(t *testing.T, c client.Client, results []ImportResult)
+	}{{
+		name:       "no jobs",
+		body:       `{}`,
+		importer:   importer,
+		expectCode: http.StatusBadRequest,
+	}, {
+		name:       "without Jenkins",
+		body:       `{"jobs":[{"path":"release"}]}`,
+		expectCode: http.StatusServiceUnavailable,
+	}, {
+		name:       "import the jobs",
+		body:       `{"jobs":[{"path":"team-a/backend/Build_App"},{"path":"/release/","name":"release-app"},{"path":"missing"}]}`,
+		importer:   importer,
+		expectCode: http.StatusOK,
+		verify: func(t *testing.T, c client.Client, results []ImportResult) {
+			if !assert.Equal(t, 3, len(results)) {
+				return
+			}
+			assert.Empty(t, results[0].Error)
+			assert.Empty(t, results[1].Error)
+			assert.Contains(t, results[2].Error, `failed to read the config of job "missing"`)
+
+			pipeline := &v1alpha3.Pipeline{}
+			assert.Nil(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "ns", Name: "build-app"}, pipeline))
+			assert.Equal(t, "team-a/backend/Build_App", pipeline.Annotations[v1alpha3.PipelineImportedFromAnnoKey])
+			assert.Equal(t, "build-app", pipeline.Spec.Pipeline.Name)
+			assert.Equal(t, "pipeline { agent any }", pipeline.Spec.Pipeline.Jenkinsfile)
+
+			assert.Nil(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "ns", Name: "release-app"}, pipeline))
+			assert.Equal(t, "release", pipeline.Annotations[v1alpha3.PipelineImportedFromAnnoKey])
+			assert.Equal(t, "release-app", pipeline.Spec.MultiBranchPipeline.Name)
+		},
+	}, {
+		name:       "invalid name",
+		body:       `{"jobs":[{"path":"release","name":"Release"}]}`,
+		importer:   importer,
+		expectCode: http.StatusOK,
+		verify: func(t *testing.T, c client.Client, results []ImportResult) {
+			if assert.Equal(t, 1, len(results)) {
+				assert.Contains(t, results[0].Error, `invalid pipeline name "Release"`)
+			}
+		},
+	}, {
+		name:       "dry run",
+		body:       `{"jobs":[{"path":"release"}],"dryRun":true}`,
+		importer:   importer,
+		expectCode: http.StatusOK,
+		verify: func(t *testing.T, c client.Client, results []ImportResult) {
+			if assert.Equal(t, 1, len(results)) && assert.NotNil(t, results[0].Pipeline) {
+				assert.Equal(t, "release", results[0].Pipeline.Name)
+			}
+			pipelines := &v1alpha3.PipelineList{}
+			assert.Nil(t, c.List(context.TODO(), pipelines))
+			assert.Empty(t, pipelines.Items)
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).Build()
+			ws := kapisruntime.NewWebService(v1alpha3.GroupVersion)
+			RegisterRoutes(ws, c, nil, nil, tt.importer)
+			container := restful.NewContainer()
+			container.Add(ws)
+
+			httpRequest, _ := http.NewRequest(http.MethodPost,
+				"http://fake.com/kapis/devops.kubesphere.io/v1alpha3/namespaces/ns/pipelines/import",
+				strings.NewReader(tt.body))
+			httpRequest.Header.Set("Content-Type", "application/json")
+			httpWriter := httptest.NewRecorder()
+			container.Dispatch(httpWriter, httpRequest)
+			assert.Equal(t, tt.expectCode, httpWriter.Code, httpWriter.Body.String())
+			if tt.verify != nil {
+				var results []ImportResult
+				assert.Nil(t, json.Unmarshal(httpWriter.Body.Bytes(), &results))
+				tt.verify(t, c, results)
+			}
+		})
+	}
+}
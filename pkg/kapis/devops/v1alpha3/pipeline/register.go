@@ -21,16 +21,38 @@ import (
 
 	"github.com/emicklei/go-restful"
 	"kubesphere.io/devops/pkg/api"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/history"
 	"kubesphere.io/devops/pkg/models/pipeline"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-// RegisterRoutes register routes into web service.
-func RegisterRoutes(ws *restful.WebService, c client.Client) {
+// RegisterRoutes register routes into web service. The Jenkinsfiles are not validated by the dry-run if the validator is nil,
+// and the Jenkins jobs could not be imported if the importer is nil.
+func RegisterRoutes(ws *restful.WebService, c client.Client, store history.Interface, validator JenkinsfileValidator,
+	importer JobConfigGetter) {
 	handler := newAPIHandler(apiHandlerOption{
-		client: c,
+		client:    c,
+		store:     store,
+		validator: validator,
+		importer:  importer,
 	})
 
+	ws.Route(ws.POST("/namespaces/{namespace}/pipelines/dryrun").
+		To(handler.dryRun).
+		Doc("Validate a Pipeline, or a Jenkinsfile, and generate its backend object without persisting anything").
+		Param(ws.PathParameter("namespace", "Namespace of the Pipeline")).
+		Reads(DryRunRequest{}).
+		Returns(http.StatusOK, api.StatusOK, DryRunResult{}))
+
+	ws.Route(ws.POST("/namespaces/{namespace}/pipelines/import").
+		To(handler.importJobs).
+		Doc("Import the pre-existing Jenkins jobs as the Pipelines of a DevOpsProject. The original jobs are kept, "+
+			"and the imported Pipelines are synchronized to the jobs in the folder of the DevOpsProject").
+		Param(ws.PathParameter("namespace", "Namespace of the DevOpsProject")).
+		Reads(ImportRequest{}).
+		Returns(http.StatusOK, api.StatusOK, []ImportResult{}))
+
 	ws.Route(ws.GET("/namespaces/{namespace}/pipelines/{pipeline}/branches").
 		To(handler.getBranches).
 		Doc("Paging query branches of multi branch Pipeline").
@@ -46,4 +68,61 @@ func RegisterRoutes(ws *restful.WebService, c client.Client) {
 		Param(ws.PathParameter("pipeline", "Name of the Pipeline")).
 		Param(ws.PathParameter("branch", "Name of branch, tag or pull request")).
 		Returns(http.StatusOK, api.StatusOK, pipeline.Branch{}))
+
+	ws.Route(ws.GET("/namespaces/{namespace}/pipelines/{pipeline}/stageanalytics").
+		To(handler.getStageAnalytics).
+		Doc("Compare the stages across the recent PipelineRuns of the Pipeline, and detect the flaky stages").
+		Param(ws.PathParameter("namespace", "Namespace of the Pipeline")).
+		Param(ws.PathParameter("pipeline", "Name of the Pipeline")).
+		Param(ws.QueryParameter("branch", "Name of branch, tag or pull request")).
+		Param(ws.QueryParameter("limit", "Number of the latest PipelineRuns to compare").
+			DataType("integer").DefaultValue("20")).
+		Returns(http.StatusOK, api.StatusOK, pipeline.Analytics{}))
+
+	ws.Route(ws.GET("/namespaces/{namespace}/pipelines/{pipeline}/graph").
+		To(handler.getGraph).
+		Doc("Get the DAG of the stages of the Pipeline, which is computed from its Jenkinsfile").
+		Param(ws.PathParameter("namespace", "Namespace of the Pipeline")).
+		Param(ws.PathParameter("pipeline", "Name of the Pipeline")).
+		Returns(http.StatusOK, api.StatusOK, pipeline.Graph{}))
+
+	ws.Route(ws.GET("/namespaces/{namespace}/pipelines/{pipeline}/revisions").
+		To(handler.listRevisions).
+		Doc("Get the revision history of the Pipeline spec, the latest revision comes first").
+		Param(ws.PathParameter("namespace", "Namespace of the Pipeline")).
+		Param(ws.PathParameter("pipeline", "Name of the Pipeline")).
+		Returns(http.StatusOK, api.StatusOK, []v1alpha3.PipelineRevision{}))
+
+	ws.Route(ws.GET("/namespaces/{namespace}/pipelines/{pipeline}/revisions/{revision}/diff").
+		To(handler.diffRevision).
+		Doc("Get the unified diff of the Pipeline spec between a revision and the base one").
+		Param(ws.PathParameter("namespace", "Namespace of the Pipeline")).
+		Param(ws.PathParameter("pipeline", "Name of the Pipeline")).
+		Param(ws.PathParameter("revision", "Number of the revision")).
+		Param(ws.QueryParameter("base", "Number of the base revision, defaults to the previous revision").
+			DataType("integer")).
+		Returns(http.StatusOK, api.StatusOK, RevisionDiff{}))
+
+	ws.Route(ws.POST("/namespaces/{namespace}/pipelines/{pipeline}/revisions/{revision}/rollback").
+		To(handler.rollbackRevision).
+		Doc("Roll the Pipeline back to the spec of a revision, the change is applied by the backend as usual and "+
+			"recorded as a new revision").
+		Param(ws.PathParameter("namespace", "Namespace of the Pipeline")).
+		Param(ws.PathParameter("pipeline", "Name of the Pipeline")).
+		Param(ws.PathParameter("revision", "Number of the revision")).
+		Returns(http.StatusOK, api.StatusOK, v1alpha3.Pipeline{}))
+
+	ws.Route(ws.GET("/namespaces/{namespace}/deletedpipelines").
+		To(handler.listDeletedPipelines).
+		Doc("Get the Pipelines in the trash bin, the latest deleted one comes first").
+		Param(ws.PathParameter("namespace", "Namespace of the DeletedPipelines")).
+		Returns(http.StatusOK, api.StatusOK, []v1alpha3.DeletedPipeline{}))
+
+	ws.Route(ws.POST("/namespaces/{namespace}/deletedpipelines/{deletedpipeline}/undelete").
+		To(handler.undeletePipeline).
+		Doc("Restore a Pipeline from the trash bin along with its Jenkins job, it fails if a Pipeline with "+
+			"the same name exists").
+		Param(ws.PathParameter("namespace", "Namespace of the DeletedPipeline")).
+		Param(ws.PathParameter("deletedpipeline", "Name of the DeletedPipeline")).
+		Returns(http.StatusOK, api.StatusOK, v1alpha3.Pipeline{}))
 }
@@ -34,7 +34,7 @@ func TestAPIsExist(t *testing.T) {
 	schema, err := v1alpha1.SchemeBuilder.Register().Build()
 	assert.Nil(t, err)
 
-	RegisterRoutes(wsWithGroup, fake.NewFakeClientWithScheme(schema))
+	RegisterRoutes(wsWithGroup, fake.NewFakeClientWithScheme(schema), nil, nil, nil)
 	restful.DefaultContainer.Add(wsWithGroup)
 
 	type args struct {
@@ -56,6 +56,60 @@ func TestAPIsExist(t *testing.T) {
 			method: http.MethodGet,
 			uri:    "/namespaces/fake/pipelines/fake/branches/fake",
 		},
+	}, {
+		name: "get the stage analytics of the pipeline",
+		args: args{
+			method: http.MethodGet,
+			uri:    "/namespaces/fake/pipelines/fake/stageanalytics?limit=10",
+		},
+	}, {
+		name: "dry-run a pipeline",
+		args: args{
+			method: http.MethodPost,
+			uri:    "/namespaces/fake/pipelines/dryrun",
+		},
+	}, {
+		name: "import the jenkins jobs",
+		args: args{
+			method: http.MethodPost,
+			uri:    "/namespaces/fake/pipelines/import",
+		},
+	}, {
+		name: "get the graph of the pipeline",
+		args: args{
+			method: http.MethodGet,
+			uri:    "/namespaces/fake/pipelines/fake/graph",
+		},
+	}, {
+		name: "get the revisions of the pipeline",
+		args: args{
+			method: http.MethodGet,
+			uri:    "/namespaces/fake/pipelines/fake/revisions",
+		},
+	}, {
+		name: "get the diff of a revision",
+		args: args{
+			method: http.MethodGet,
+			uri:    "/namespaces/fake/pipelines/fake/revisions/1/diff",
+		},
+	}, {
+		name: "roll back to a revision",
+		args: args{
+			method: http.MethodPost,
+			uri:    "/namespaces/fake/pipelines/fake/revisions/1/rollback",
+		},
+	}, {
+		name: "get the deleted pipelines",
+		args: args{
+			method: http.MethodGet,
+			uri:    "/namespaces/fake/deletedpipelines",
+		},
+	}, {
+		name: "undelete a pipeline",
+		args: args{
+			method: http.MethodPost,
+			uri:    "/namespaces/fake/deletedpipelines/fake/undelete",
+		},
 	}}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,144 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/emicklei/go-restful"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	apiserverrequest "kubesphere.io/devops/pkg/apiserver/request"
+	"kubesphere.io/devops/pkg/kapis"
+	"kubesphere.io/devops/pkg/models/pipeline"
+)
+
+// RevisionDiff is the unified diff between the YAML of the Pipeline specs of two revisions
+type RevisionDiff struct {
+	// Base is the revision compared with, it's 0 if there's no base revision
+	Base     int64  `json:"base"`
+	Revision int64  `json:"revision"`
+	Diff     string `json:"diff"`
+}
+
+func (h *apiHandler) listRevisions(request *restful.Request, response *restful.Response) {
+	revisions, err := pipeline.ListRevisions(request.Request.Context(), h.client,
+		request.PathParameter("namespace"), request.PathParameter("pipeline"))
+	if err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+	_ = response.WriteEntity(revisions)
+}
+
+func (h *apiHandler) diffRevision(request *restful.Request, response *restful.Response) {
+	ctx := request.Request.Context()
+	namespace := request.PathParameter("namespace")
+	pipelineName := request.PathParameter("pipeline")
+	revisionNumber, err := parseRevision(request.PathParameter("revision"))
+	if err != nil {
+		kapis.HandleBadRequest(response, request, err)
+		return
+	}
+	// it's compared with the previous revision by default
+	baseNumber := revisionNumber - 1
+	if base := request.QueryParameter("base"); base != "" {
+		if baseNumber, err = parseRevision(base); err != nil {
+			kapis.HandleBadRequest(response, request, err)
+			return
+		}
+	}
+
+	revision, err := pipeline.GetRevision(ctx, h.client, namespace, pipelineName, revisionNumber)
+	if err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+	var baseSpec *v1alpha3.PipelineSpec
+	if baseNumber > 0 {
+		var baseRevision *v1alpha3.PipelineRevision
+		if baseRevision, err = pipeline.GetRevision(ctx, h.client, namespace, pipelineName, baseNumber); err == nil {
+			baseSpec = &baseRevision.Spec.PipelineSpec
+		} else if apierrors.IsNotFound(err) && request.QueryParameter("base") == "" {
+			// the previous revision might have been removed due to the history limit
+			baseNumber = 0
+		} else {
+			kapis.HandleError(request, response, err)
+			return
+		}
+	}
+
+	diff, err := pipeline.DiffSpecs(baseSpec, &revision.Spec.PipelineSpec,
+		pipeline.GetRevisionDisplayName(baseNumber), pipeline.GetRevisionDisplayName(revisionNumber))
+	if err != nil {
+		kapis.HandleInternalError(response, request, err)
+		return
+	}
+	_ = response.WriteEntity(&RevisionDiff{Base: baseNumber, Revision: revisionNumber, Diff: diff})
+}
+
+// rollbackRevision replaces the spec of the Pipeline with the one of a revision, then the backend reconciler
+// applies the change as usual, and a new revision is recorded.
+func (h *apiHandler) rollbackRevision(request *restful.Request, response *restful.Response) {
+	ctx := request.Request.Context()
+	namespace := request.PathParameter("namespace")
+	pipelineName := request.PathParameter("pipeline")
+	revisionNumber, err := parseRevision(request.PathParameter("revision"))
+	if err != nil {
+		kapis.HandleBadRequest(response, request, err)
+		return
+	}
+	currentUser, ok := apiserverrequest.UserFrom(ctx)
+	if !ok || currentUser == nil {
+		kapis.HandleUnauthorized(response, request, fmt.Errorf("unauthenticated user"))
+		return
+	}
+
+	revision, err := pipeline.GetRevision(ctx, h.client, namespace, pipelineName, revisionNumber)
+	if err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+	target := &v1alpha3.Pipeline{}
+	if err = h.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: pipelineName}, target); err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+
+	target.Spec = *revision.Spec.PipelineSpec.DeepCopy()
+	if target.Annotations == nil {
+		target.Annotations = map[string]string{}
+	}
+	// the webhook keeps the annotation, since the DevOps apiserver acts on behalf of the user
+	target.Annotations[v1alpha3.PipelineModifiedByAnnoKey] = currentUser.GetName()
+	target.Annotations[v1alpha3.PipelineRolledBackToAnnoKey] = strconv.FormatInt(revisionNumber, 10)
+	if err = h.client.Update(ctx, target); err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+	_ = response.WriteEntity(target)
+}
+
+func parseRevision(value string) (revision int64, err error) {
+	if revision, err = strconv.ParseInt(value, 10, 64); err != nil || revision <= 0 {
+		err = fmt.Errorf("invalid revision %q, it should be a positive integer", value)
+	}
+	return
+}
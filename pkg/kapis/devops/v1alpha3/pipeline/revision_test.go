@@ -0,0 +1,176 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/apiserver/request"
+	kapisruntime "kubesphere.io/devops/pkg/apiserver/runtime"
+)
+
+func TestRevisions(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	newSpec := func(jenkinsfile string) v1alpha3.PipelineSpec {
+		return v1alpha3.PipelineSpec{
+			Type:     v1alpha3.NoScmPipelineType,
+			Pipeline: &v1alpha3.NoScmPipeline{Name: "build", Jenkinsfile: jenkinsfile},
+		}
+	}
+	newRevision := func(revision int64, jenkinsfile string) *v1alpha3.PipelineRevision {
+		return &v1alpha3.PipelineRevision{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ns",
+				Name:      v1alpha3.GetPipelineRevisionName("build", revision),
+				Labels:    map[string]string{v1alpha3.PipelineNameLabelKey: "build"},
+			},
+			Spec: v1alpha3.PipelineRevisionSpec{Pipeline: "build", Revision: revision, PipelineSpec: newSpec(jenkinsfile)},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		uri        string
+		user       user.Info
+		expectCode int
+		verify     func(t *testing.T, c client.Client, body []byte)
+	}{{
+		name:       "list the revisions",
+		method:     http.MethodGet,
+		uri:        "/namespaces/ns/pipelines/build/revisions",
+		expectCode: http.StatusOK,
+		verify: func(t *testing.T, c client.Client, body []byte) {
+			var revisions []v1alpha3.PipelineRevision
+			assert.Nil(t, json.Unmarshal(body, &revisions))
+			if assert.Len(t, revisions, 2) {
+				assert.Equal(t, int64(3), revisions[0].Spec.Revision)
+			}
+		},
+	}, {
+		name:       "diff with the previous revision",
+		method:     http.MethodGet,
+		uri:        "/namespaces/ns/pipelines/build/revisions/3/diff",
+		expectCode: http.StatusOK,
+		verify: func(t *testing.T, c client.Client, body []byte) {
+			diff := &RevisionDiff{}
+			assert.Nil(t, json.Unmarshal(body, diff))
+			assert.Equal(t, int64(2), diff.Base)
+			assert.Contains(t, diff.Diff, "--- r2\n+++ r3\n")
+			assert.Contains(t, diff.Diff, "-  jenkinsfile: v2\n+  jenkinsfile: v3\n")
+		},
+	}, {
+		name:       "diff with the removed previous revision",
+		method:     http.MethodGet,
+		uri:        "/namespaces/ns/pipelines/build/revisions/2/diff",
+		expectCode: http.StatusOK,
+		verify: func(t *testing.T, c client.Client, body []byte) {
+			diff := &RevisionDiff{}
+			assert.Nil(t, json.Unmarshal(body, diff))
+			assert.Equal(t, int64(0), diff.Base)
+			assert.Contains(t, diff.Diff, "--- /dev/null\n+++ r2\n")
+			assert.Contains(t, diff.Diff, "+  jenkinsfile: v2\n")
+		},
+	}, {
+		name:       "diff with a given revision",
+		method:     http.MethodGet,
+		uri:        "/namespaces/ns/pipelines/build/revisions/2/diff?base=3",
+		expectCode: http.StatusOK,
+		verify: func(t *testing.T, c client.Client, body []byte) {
+			diff := &RevisionDiff{}
+			assert.Nil(t, json.Unmarshal(body, diff))
+			assert.Contains(t, diff.Diff, "-  jenkinsfile: v3\n+  jenkinsfile: v2\n")
+		},
+	}, {
+		name:       "diff with a missing revision",
+		method:     http.MethodGet,
+		uri:        "/namespaces/ns/pipelines/build/revisions/2/diff?base=1",
+		expectCode: http.StatusNotFound,
+	}, {
+		name:       "invalid revision",
+		method:     http.MethodGet,
+		uri:        "/namespaces/ns/pipelines/build/revisions/latest/diff",
+		expectCode: http.StatusBadRequest,
+	}, {
+		name:       "rollback without a user",
+		method:     http.MethodPost,
+		uri:        "/namespaces/ns/pipelines/build/revisions/2/rollback",
+		expectCode: http.StatusUnauthorized,
+	}, {
+		name:       "rollback to a revision",
+		method:     http.MethodPost,
+		uri:        "/namespaces/ns/pipelines/build/revisions/2/rollback",
+		user:       &user.DefaultInfo{Name: "alice"},
+		expectCode: http.StatusOK,
+		verify: func(t *testing.T, c client.Client, body []byte) {
+			pipeline := &v1alpha3.Pipeline{}
+			assert.Nil(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "ns", Name: "build"}, pipeline))
+			assert.Equal(t, "v2", pipeline.Spec.Pipeline.Jenkinsfile)
+			assert.Equal(t, "alice", pipeline.Annotations[v1alpha3.PipelineModifiedByAnnoKey])
+			assert.Equal(t, "2", pipeline.Annotations[v1alpha3.PipelineRolledBackToAnnoKey])
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(&v1alpha3.Pipeline{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "build"},
+				Spec:       newSpec("v3"),
+			}, newRevision(2, "v2"), newRevision(3, "v3"), &v1alpha3.PipelineRevision{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "ns",
+					Name:      v1alpha3.GetPipelineRevisionName("other", 1),
+					Labels:    map[string]string{v1alpha3.PipelineNameLabelKey: "other"},
+				},
+			}, newRevision(1, "v1")).Build()
+			// the first revision has been removed due to the history limit
+			assert.Nil(t, c.Delete(context.TODO(), newRevision(1, "")))
+
+			ws := kapisruntime.NewWebService(v1alpha3.GroupVersion)
+			RegisterRoutes(ws, c, nil, nil, nil)
+			container := restful.NewContainer()
+			container.Add(ws)
+
+			ctx := context.Background()
+			if tt.user != nil {
+				ctx = request.WithUser(ctx, tt.user)
+			}
+			httpRequest, _ := http.NewRequestWithContext(ctx, tt.method,
+				"http://fake.com/kapis/devops.kubesphere.io/v1alpha3"+tt.uri, nil)
+			httpWriter := httptest.NewRecorder()
+			container.Dispatch(httpWriter, httpRequest)
+			assert.Equal(t, tt.expectCode, httpWriter.Code, httpWriter.Body.String())
+			if tt.verify != nil {
+				tt.verify(t, c, httpWriter.Body.Bytes())
+			}
+		})
+	}
+}
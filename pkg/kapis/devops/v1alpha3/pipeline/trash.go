@@ -0,0 +1,100 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/emicklei/go-restful"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	apiserverrequest "kubesphere.io/devops/pkg/apiserver/request"
+	"kubesphere.io/devops/pkg/kapis"
+)
+
+// listDeletedPipelines lists the Pipelines in the trash bin, the latest deleted one comes first
+func (h *apiHandler) listDeletedPipelines(request *restful.Request, response *restful.Response) {
+	deletedPipelines := &v1alpha3.DeletedPipelineList{}
+	if err := h.client.List(request.Request.Context(), deletedPipelines,
+		client.InNamespace(request.PathParameter("namespace"))); err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+	items := deletedPipelines.Items
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[j].Spec.DeletionTime.Before(&items[i].Spec.DeletionTime)
+	})
+	_ = response.WriteEntity(items)
+}
+
+// undeletePipeline restores a Pipeline from the trash bin. The Jenkins job was kept, so the restored Pipeline takes
+// it over with the build history.
+func (h *apiHandler) undeletePipeline(request *restful.Request, response *restful.Response) {
+	ctx := request.Request.Context()
+	namespace := request.PathParameter("namespace")
+	currentUser, ok := apiserverrequest.UserFrom(ctx)
+	if !ok || currentUser == nil {
+		kapis.HandleUnauthorized(response, request, fmt.Errorf("unauthenticated user"))
+		return
+	}
+
+	deletedPipeline := &v1alpha3.DeletedPipeline{}
+	if err := h.client.Get(ctx, client.ObjectKey{Namespace: namespace,
+		Name: request.PathParameter("deletedpipeline")}, deletedPipeline); err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+
+	restored := &v1alpha3.Pipeline{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1alpha3.GroupVersion.String(),
+			Kind:       v1alpha3.ResourceKindPipeline,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespace,
+			Name:        deletedPipeline.Spec.Pipeline,
+			Labels:      deletedPipeline.Spec.Labels,
+			Annotations: map[string]string{},
+		},
+		Spec: deletedPipeline.Spec.PipelineSpec,
+	}
+	for key, value := range deletedPipeline.Spec.Annotations {
+		restored.Annotations[key] = value
+	}
+	delete(restored.Annotations, v1alpha3.PipelineTrashedAnnoKey)
+	// the webhook keeps the annotation, since the DevOps apiserver acts on behalf of the user
+	restored.Annotations[v1alpha3.PipelineModifiedByAnnoKey] = currentUser.GetName()
+
+	if err := h.client.Create(ctx, restored); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			kapis.HandleConflict(response, request, fmt.Errorf("pipeline %q exists already, "+
+				"please delete or rename it before undeleting", restored.Name))
+			return
+		}
+		kapis.HandleError(request, response, err)
+		return
+	}
+	if err := h.client.Delete(ctx, deletedPipeline); client.IgnoreNotFound(err) != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+	_ = response.WriteEntity(restored)
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/apiserver/request"
+	kapisruntime "kubesphere.io/devops/pkg/apiserver/runtime"
+)
+
+func TestTrash(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	now := time.Now()
+	newDeletedPipeline := func(name, pipeline string, deletionTime time.Time) *v1alpha3.DeletedPipeline {
+		return &v1alpha3.DeletedPipeline{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name},
+			Spec: v1alpha3.DeletedPipelineSpec{
+				Pipeline:    pipeline,
+				Labels:      map[string]string{"team": "a"},
+				Annotations: map[string]string{"description": "build the app"},
+				PipelineSpec: v1alpha3.PipelineSpec{
+					Type:     v1alpha3.NoScmPipelineType,
+					Pipeline: &v1alpha3.NoScmPipeline{Name: pipeline, Jenkinsfile: "pipeline {}"},
+				},
+				DeletionTime:   metav1.NewTime(deletionTime),
+				ExpirationTime: metav1.NewTime(deletionTime.Add(time.Hour)),
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		uri        string
+		user       user.Info
+		expectCode int
+		verify     func(t *testing.T, c client.Client, body []byte)
+	}{{
+		name:       "list the deleted Pipelines",
+		method:     http.MethodGet,
+		uri:        "/namespaces/ns/deletedpipelines",
+		expectCode: http.StatusOK,
+		verify: func(t *testing.T, c client.Client, body []byte) {
+			var deletedPipelines []v1alpha3.DeletedPipeline
+			assert.Nil(t, json.Unmarshal(body, &deletedPipelines))
+			if assert.Len(t, deletedPipelines, 2) {
+				assert.Equal(t, "deploy-12345678", deletedPipelines[0].Name)
+				assert.Equal(t, "build-12345678", deletedPipelines[1].Name)
+			}
+		},
+	}, {
+		name:       "undelete without a user",
+		method:     http.MethodPost,
+		uri:        "/namespaces/ns/deletedpipelines/build-12345678/undelete",
+		expectCode: http.StatusUnauthorized,
+	}, {
+		name:       "undelete a missing Pipeline",
+		method:     http.MethodPost,
+		uri:        "/namespaces/ns/deletedpipelines/missing/undelete",
+		user:       &user.DefaultInfo{Name: "alice"},
+		expectCode: http.StatusNotFound,
+	}, {
+		name:       "undelete a Pipeline",
+		method:     http.MethodPost,
+		uri:        "/namespaces/ns/deletedpipelines/build-12345678/undelete",
+		user:       &user.DefaultInfo{Name: "alice"},
+		expectCode: http.StatusOK,
+		verify: func(t *testing.T, c client.Client, body []byte) {
+			pipeline := &v1alpha3.Pipeline{}
+			assert.Nil(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "ns", Name: "build"}, pipeline))
+			assert.Equal(t, "pipeline {}", pipeline.Spec.Pipeline.Jenkinsfile)
+			assert.Equal(t, "a", pipeline.Labels["team"])
+			assert.Equal(t, "build the app", pipeline.Annotations["description"])
+			assert.Equal(t, "alice", pipeline.Annotations[v1alpha3.PipelineModifiedByAnnoKey])
+
+			err := c.Get(context.TODO(), client.ObjectKey{Namespace: "ns", Name: "build-12345678"},
+				&v1alpha3.DeletedPipeline{})
+			assert.True(t, apierrors.IsNotFound(err))
+		},
+	}, {
+		name:       "the Pipeline exists already",
+		method:     http.MethodPost,
+		uri:        "/namespaces/ns/deletedpipelines/deploy-12345678/undelete",
+		user:       &user.DefaultInfo{Name: "alice"},
+		expectCode: http.StatusConflict,
+		verify: func(t *testing.T, c client.Client, body []byte) {
+			assert.Nil(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "ns", Name: "deploy-12345678"},
+				&v1alpha3.DeletedPipeline{}))
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(
+				newDeletedPipeline("build-12345678", "build", now.Add(-time.Hour)),
+				newDeletedPipeline("deploy-12345678", "deploy", now),
+				&v1alpha3.Pipeline{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "deploy"}}).Build()
+
+			ws := kapisruntime.NewWebService(v1alpha3.GroupVersion)
+			RegisterRoutes(ws, c, nil, nil, nil)
+			container := restful.NewContainer()
+			container.Add(ws)
+
+			ctx := context.Background()
+			if tt.user != nil {
+				ctx = request.WithUser(ctx, tt.user)
+			}
+			httpRequest, _ := http.NewRequestWithContext(ctx, tt.method,
+				"http://fake.com/kapis/devops.kubesphere.io/v1alpha3"+tt.uri, nil)
+			httpWriter := httptest.NewRecorder()
+			container.Dispatch(httpWriter, httpRequest)
+			assert.Equal(t, tt.expectCode, httpWriter.Code, httpWriter.Body.String())
+			if tt.verify != nil {
+				tt.verify(t, c, httpWriter.Body.Bytes())
+			}
+		})
+	}
+}
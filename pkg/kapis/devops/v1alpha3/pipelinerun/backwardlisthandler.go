@@ -38,7 +38,7 @@ func (b backwardListHandler) Comparator() resourcesV1alpha3.CompareFunc {
 }
 
 func (b backwardListHandler) Filter() resourcesV1alpha3.FilterFunc {
-	return resourcesV1alpha3.DefaultFilter().And(func(object runtime.Object, filter query.Filter) bool {
+	return resourcesV1alpha3.DefaultFilter().And(pipelineRunFilter()).And(func(object runtime.Object, filter query.Filter) bool {
 		return b.backwardFilter(object)
 	})
 }
@@ -0,0 +1,366 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelinerun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/apiserver/authentication/identity"
+	apiserverrequest "kubesphere.io/devops/pkg/apiserver/request"
+	"kubesphere.io/devops/pkg/kapis"
+)
+
+// BatchAction is what to do with the PipelineRuns matched by a batch operation
+type BatchAction string
+
+const (
+	// BatchCancel stops the PipelineRuns which have not completed
+	BatchCancel BatchAction = "cancel"
+	// BatchDelete deletes the PipelineRuns
+	BatchDelete BatchAction = "delete"
+	// BatchRerun creates a new PipelineRun with the same parameters for each completed PipelineRun
+	BatchRerun BatchAction = "rerun"
+)
+
+// verb returns the verb of PipelineRuns which the users need to perform the action
+func (a BatchAction) verb() string {
+	switch a {
+	case BatchCancel:
+		return "update"
+	case BatchDelete:
+		return "delete"
+	case BatchRerun:
+		return "create"
+	default:
+		return ""
+	}
+}
+
+// BatchSelector matches the PipelineRuns of a batch operation, a PipelineRun is matched only if all the conditions
+// are satisfied
+type BatchSelector struct {
+	// Pipeline is the name of the Pipeline which the PipelineRuns belong to
+	Pipeline string `json:"pipeline,omitempty"`
+	// Branch is the name of SCM reference of the PipelineRuns
+	Branch string `json:"branch,omitempty"`
+	// Phases are the phases of the PipelineRuns, e.g. Failed and Cancelled
+	Phases []v1alpha3.RunPhase `json:"phases,omitempty"`
+	// OlderThan matches the PipelineRuns which were created before the duration, e.g. 24h
+	OlderThan *metav1.Duration `json:"olderThan,omitempty"`
+}
+
+// IsEmpty returns true if the selector has no condition, it's not allowed to match all the PipelineRuns by accident
+func (s *BatchSelector) IsEmpty() bool {
+	return s.Pipeline == "" && s.Branch == "" && len(s.Phases) == 0 && s.OlderThan == nil
+}
+
+// Matches returns true if the PipelineRun satisfies all the conditions except the Pipeline,
+// which is handled by the label selector
+func (s *BatchSelector) Matches(pr *v1alpha3.PipelineRun, now time.Time) bool {
+	if s.Branch != "" && pr.GetRefName() != s.Branch {
+		return false
+	}
+	if s.OlderThan != nil && pr.CreationTimestamp.Add(s.OlderThan.Duration).After(now) {
+		return false
+	}
+	if len(s.Phases) == 0 {
+		return true
+	}
+	for _, phase := range s.Phases {
+		if phase == pr.Status.Phase {
+			return true
+		}
+	}
+	return false
+}
+
+// BatchRequest is the request of a batch operation on the PipelineRuns in a namespace
+type BatchRequest struct {
+	Action   BatchAction   `json:"action"`
+	Selector BatchSelector `json:"selector"`
+}
+
+// BatchPhase is the phase of a batch operation
+type BatchPhase string
+
+const (
+	// BatchRunning means the matched PipelineRuns are being processed
+	BatchRunning BatchPhase = "Running"
+	// BatchCompleted means all the matched PipelineRuns have been processed, some of them might be failed
+	BatchCompleted BatchPhase = "Completed"
+)
+
+// maxBatchErrors is the maximum number of the error messages kept by a batch operation
+const maxBatchErrors = 20
+
+// batchRetention is how long a completed batch operation is kept for querying its result
+const batchRetention = time.Hour
+
+// BatchOperation is the progress of a batch operation
+type BatchOperation struct {
+	ID        string        `json:"id"`
+	Namespace string        `json:"namespace"`
+	Action    BatchAction   `json:"action"`
+	Selector  BatchSelector `json:"selector"`
+	Creator   string        `json:"creator,omitempty"`
+	Phase     BatchPhase    `json:"phase"`
+	// Total is the number of the matched PipelineRuns
+	Total int `json:"total"`
+	// Succeeded is the number of the PipelineRuns which have been processed successfully
+	Succeeded int `json:"succeeded"`
+	// Skipped is the number of the PipelineRuns which the action does not apply to,
+	// such as cancelling a completed PipelineRun
+	Skipped int `json:"skipped"`
+	// Failed is the number of the PipelineRuns which failed to be processed
+	Failed int `json:"failed"`
+	// Errors are the first few messages of the failed PipelineRuns
+	Errors         []string     `json:"errors,omitempty"`
+	StartTime      metav1.Time  `json:"startTime"`
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// identity is the identity of the creator, it's recorded by the PipelineRuns which are rerun
+	identity *v1alpha3.TriggerIdentity
+}
+
+// batchTracker keeps the progress of the batch operations in memory
+type batchTracker struct {
+	mutex      sync.RWMutex
+	operations map[string]*BatchOperation
+}
+
+func newBatchTracker() *batchTracker {
+	return &batchTracker{operations: map[string]*BatchOperation{}}
+}
+
+func batchKey(namespace, id string) string {
+	return namespace + "/" + id
+}
+
+// add tracks a new batch operation, and forgets the ones which have completed for a long time
+func (t *batchTracker) add(operation *BatchOperation) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for key, item := range t.operations {
+		if item.CompletionTime != nil && time.Since(item.CompletionTime.Time) > batchRetention {
+			delete(t.operations, key)
+		}
+	}
+	t.operations[batchKey(operation.Namespace, operation.ID)] = operation
+}
+
+// get returns a copy of the batch operation
+func (t *batchTracker) get(namespace, id string) (operation BatchOperation, ok bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	var item *BatchOperation
+	if item, ok = t.operations[batchKey(namespace, id)]; ok {
+		operation = *item
+		operation.Errors = append([]string(nil), item.Errors...)
+	}
+	return
+}
+
+// update modifies the batch operation with the lock held
+func (t *batchTracker) update(namespace, id string, modify func(*BatchOperation)) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if item, ok := t.operations[batchKey(namespace, id)]; ok {
+		modify(item)
+	}
+}
+
+// createBatch starts a batch operation on the matched PipelineRuns after making sure that the current user is allowed
+// to perform the action on PipelineRuns in the namespace, then the progress could be queried by its ID
+func (h *apiHandler) createBatch(request *restful.Request, response *restful.Response) {
+	nsName := request.PathParameter("namespace")
+	ctx := request.Request.Context()
+
+	currentUser, ok := apiserverrequest.UserFrom(ctx)
+	if !ok || currentUser == nil || currentUser.GetName() == "" || currentUser.GetName() == user.Anonymous {
+		kapis.HandleUnauthorized(response, request, fmt.Errorf("unauthenticated user is not allowed to operate PipelineRuns in batch"))
+		return
+	}
+
+	batchRequest := &BatchRequest{}
+	if err := request.ReadEntity(batchRequest); err != nil {
+		kapis.HandleBadRequest(response, request, err)
+		return
+	}
+	verb := batchRequest.Action.verb()
+	if verb == "" {
+		kapis.HandleBadRequest(response, request, fmt.Errorf("invalid action %q, it should be one of %s, %s and %s",
+			batchRequest.Action, BatchCancel, BatchDelete, BatchRerun))
+		return
+	}
+	if batchRequest.Selector.IsEmpty() {
+		kapis.HandleBadRequest(response, request, errors.New("the selector should have at least one condition"))
+		return
+	}
+
+	if err := h.authorize(ctx, currentUser, &authorizationv1.ResourceAttributes{
+		Namespace: nsName,
+		Verb:      verb,
+		Group:     v1alpha3.GroupVersion.Group,
+		Resource:  "pipelineruns",
+	}, fmt.Sprintf("user '%s' is not allowed to %s PipelineRuns in namespace '%s'", currentUser.GetName(), verb, nsName)); err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+
+	prs, err := h.listBatchPipelineRuns(ctx, nsName, &batchRequest.Selector)
+	if err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+
+	operation := &BatchOperation{
+		ID:        rand.String(8),
+		Namespace: nsName,
+		Action:    batchRequest.Action,
+		Selector:  batchRequest.Selector,
+		Creator:   currentUser.GetName(),
+		identity:  identity.FromUser(currentUser),
+		Phase:     BatchRunning,
+		Total:     len(prs),
+		StartTime: metav1.Now(),
+	}
+	h.batches.add(operation)
+	result, _ := h.batches.get(nsName, operation.ID)
+
+	// the request context is going to be cancelled once the response is written
+	go h.runBatch(context.Background(), result, prs)
+	_ = response.WriteHeaderAndEntity(http.StatusAccepted, result)
+}
+
+func (h *apiHandler) getBatch(request *restful.Request, response *restful.Response) {
+	nsName := request.PathParameter("namespace")
+	id := request.PathParameter("batch")
+
+	operation, ok := h.batches.get(nsName, id)
+	if !ok {
+		kapis.HandleNotFound(response, request, fmt.Errorf("batch operation '%s/%s' was not found", nsName, id))
+		return
+	}
+	_ = response.WriteEntity(operation)
+}
+
+// listBatchPipelineRuns lists the PipelineRuns matched by the selector
+func (h *apiHandler) listBatchPipelineRuns(ctx context.Context, namespace string, selector *BatchSelector) (
+	matched []v1alpha3.PipelineRun, err error) {
+	opts := []client.ListOption{client.InNamespace(namespace)}
+	if selector.Pipeline != "" {
+		opts = append(opts, client.MatchingLabels{v1alpha3.PipelineNameLabelKey: selector.Pipeline})
+	}
+	prs := &v1alpha3.PipelineRunList{}
+	if err = h.client.List(ctx, prs, opts...); err != nil {
+		return
+	}
+	now := time.Now()
+	for i := range prs.Items {
+		if selector.Matches(&prs.Items[i], now) {
+			matched = append(matched, prs.Items[i])
+		}
+	}
+	return
+}
+
+// runBatch performs the action on the PipelineRuns one by one, and records the progress
+func (h *apiHandler) runBatch(ctx context.Context, operation BatchOperation, prs []v1alpha3.PipelineRun) {
+	pipelines := map[string]*v1alpha3.Pipeline{}
+	for i := range prs {
+		pr := &prs[i]
+		skipped, err := h.runBatchItem(ctx, &operation, pr, pipelines)
+		if err != nil {
+			klog.V(4).Infof("failed to %s PipelineRun '%s/%s' in batch %s, error: %v",
+				operation.Action, pr.Namespace, pr.Name, operation.ID, err)
+		}
+		h.batches.update(operation.Namespace, operation.ID, func(item *BatchOperation) {
+			switch {
+			case err != nil:
+				item.Failed++
+				if len(item.Errors) < maxBatchErrors {
+					item.Errors = append(item.Errors, fmt.Sprintf("%s: %v", pr.Name, err))
+				}
+			case skipped:
+				item.Skipped++
+			default:
+				item.Succeeded++
+			}
+		})
+	}
+	h.batches.update(operation.Namespace, operation.ID, func(item *BatchOperation) {
+		now := metav1.Now()
+		item.Phase = BatchCompleted
+		item.CompletionTime = &now
+	})
+}
+
+// runBatchItem performs the action on a PipelineRun, it returns true if the action does not apply to the PipelineRun
+func (h *apiHandler) runBatchItem(ctx context.Context, operation *BatchOperation, pr *v1alpha3.PipelineRun,
+	pipelines map[string]*v1alpha3.Pipeline) (skipped bool, err error) {
+	switch operation.Action {
+	case BatchCancel:
+		if pr.HasCompleted() {
+			return true, nil
+		}
+		patch := client.MergeFrom(pr.DeepCopy())
+		action := v1alpha3.Stop
+		pr.Spec.Action = &action
+		err = client.IgnoreNotFound(h.client.Patch(ctx, pr, patch))
+	case BatchDelete:
+		err = client.IgnoreNotFound(h.client.Delete(ctx, pr))
+	case BatchRerun:
+		pipelineName := pr.Labels[v1alpha3.PipelineNameLabelKey]
+		if !pr.HasCompleted() || pipelineName == "" {
+			return true, nil
+		}
+		pipeline, ok := pipelines[pipelineName]
+		if !ok {
+			pipeline = &v1alpha3.Pipeline{}
+			if err = h.client.Get(ctx, client.ObjectKey{Namespace: pr.Namespace, Name: pipelineName}, pipeline); err != nil {
+				if apierrors.IsNotFound(err) {
+					pipeline = nil
+				} else {
+					return
+				}
+			}
+			pipelines[pipelineName] = pipeline
+		}
+		if pipeline == nil {
+			return false, fmt.Errorf("pipeline '%s/%s' was not found", pr.Namespace, pipelineName)
+		}
+		rerun := CreateBarePipelineRun(pipeline, pr.Spec.Parameters, pr.Spec.SCM)
+		rerun.Annotations[v1alpha3.PipelineRunCreatorAnnoKey] = operation.Creator
+		rerun.SetTriggerIdentity(operation.identity)
+		err = h.client.Create(ctx, rerun)
+	}
+	return
+}
@@ -0,0 +1,254 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelinerun
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/authentication/user"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/apiserver/request"
+	"kubesphere.io/devops/pkg/apiserver/runtime"
+	fakedevops "kubesphere.io/devops/pkg/client/devops/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestBatchSelector_Matches(t *testing.T) {
+	now := time.Now()
+	pr := &v1alpha3.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(now.Add(-2 * time.Hour))},
+		Spec: v1alpha3.PipelineRunSpec{
+			PipelineSpec: &v1alpha3.PipelineSpec{Type: v1alpha3.MultiBranchPipelineType},
+			SCM:          &v1alpha3.SCM{RefName: "main"},
+		},
+		Status: v1alpha3.PipelineRunStatus{Phase: v1alpha3.Failed},
+	}
+	tests := []struct {
+		name     string
+		selector BatchSelector
+		expect   bool
+	}{{
+		name:     "matched branch",
+		selector: BatchSelector{Branch: "main"},
+		expect:   true,
+	}, {
+		name:     "unmatched branch",
+		selector: BatchSelector{Branch: "dev"},
+	}, {
+		name:     "matched phases",
+		selector: BatchSelector{Phases: []v1alpha3.RunPhase{v1alpha3.Cancelled, v1alpha3.Failed}},
+		expect:   true,
+	}, {
+		name:     "unmatched phases",
+		selector: BatchSelector{Phases: []v1alpha3.RunPhase{v1alpha3.Running}},
+	}, {
+		name:     "older than an hour",
+		selector: BatchSelector{OlderThan: &metav1.Duration{Duration: time.Hour}},
+		expect:   true,
+	}, {
+		name:     "older than a day",
+		selector: BatchSelector{OlderThan: &metav1.Duration{Duration: 24 * time.Hour}},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expect, tt.selector.Matches(pr, now))
+		})
+	}
+}
+
+func TestBatchOperation(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	// only bob is allowed to operate the PipelineRuns
+	clientset := k8sfake.NewSimpleClientset()
+	var review *authorizationv1.SubjectAccessReview
+	clientset.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		review = action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		result := review.DeepCopy()
+		result.Status.Allowed = review.Spec.User == "bob"
+		return true, result, nil
+	})
+
+	newPipelineRun := func(name string, phase v1alpha3.RunPhase) *v1alpha3.PipelineRun {
+		pr := &v1alpha3.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "fake",
+				Name:      name,
+				Labels:    map[string]string{v1alpha3.PipelineNameLabelKey: "fake"},
+			},
+			Spec: v1alpha3.PipelineRunSpec{
+				Parameters: []v1alpha3.Parameter{{Name: "name", Value: name}},
+			},
+			Status: v1alpha3.PipelineRunStatus{Phase: phase},
+		}
+		if phase != v1alpha3.Running {
+			pr.Status.CompletionTime = &metav1.Time{Time: time.Now()}
+		}
+		return pr
+	}
+
+	tests := []struct {
+		name       string
+		user       user.Info
+		body       string
+		expectCode int
+		verify     func(t *testing.T, c client.Client, operation BatchOperation)
+	}{{
+		name:       "anonymous user",
+		user:       &user.DefaultInfo{Name: user.Anonymous},
+		body:       `{"action":"delete","selector":{"pipeline":"fake"}}`,
+		expectCode: http.StatusUnauthorized,
+	}, {
+		name:       "invalid action",
+		user:       &user.DefaultInfo{Name: "bob"},
+		body:       `{"action":"pause","selector":{"pipeline":"fake"}}`,
+		expectCode: http.StatusBadRequest,
+	}, {
+		name:       "empty selector",
+		user:       &user.DefaultInfo{Name: "bob"},
+		body:       `{"action":"delete"}`,
+		expectCode: http.StatusBadRequest,
+	}, {
+		name:       "without the permission",
+		user:       &user.DefaultInfo{Name: "alice"},
+		body:       `{"action":"delete","selector":{"pipeline":"fake"}}`,
+		expectCode: http.StatusForbidden,
+	}, {
+		name:       "cancel the running PipelineRuns",
+		user:       &user.DefaultInfo{Name: "bob"},
+		body:       `{"action":"cancel","selector":{"phases":["Running","Failed"]}}`,
+		expectCode: http.StatusAccepted,
+		verify: func(t *testing.T, c client.Client, operation BatchOperation) {
+			assert.Equal(t, "update", review.Spec.ResourceAttributes.Verb)
+			assert.Equal(t, "pipelineruns", review.Spec.ResourceAttributes.Resource)
+			assert.Equal(t, 2, operation.Total)
+			assert.Equal(t, 1, operation.Succeeded)
+			assert.Equal(t, 1, operation.Skipped)
+
+			pr := &v1alpha3.PipelineRun{}
+			assert.Nil(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "fake", Name: "running"}, pr))
+			if assert.NotNil(t, pr.Spec.Action) {
+				assert.Equal(t, v1alpha3.Stop, *pr.Spec.Action)
+			}
+		},
+	}, {
+		name:       "delete the failed PipelineRuns",
+		user:       &user.DefaultInfo{Name: "bob"},
+		body:       `{"action":"delete","selector":{"pipeline":"fake","phases":["Failed"]}}`,
+		expectCode: http.StatusAccepted,
+		verify: func(t *testing.T, c client.Client, operation BatchOperation) {
+			assert.Equal(t, "delete", review.Spec.ResourceAttributes.Verb)
+			assert.Equal(t, 1, operation.Succeeded)
+
+			prs := &v1alpha3.PipelineRunList{}
+			assert.Nil(t, c.List(context.TODO(), prs))
+			assert.Equal(t, 2, len(prs.Items))
+		},
+	}, {
+		name:       "rerun the failed PipelineRuns",
+		user:       &user.DefaultInfo{Name: "bob"},
+		body:       `{"action":"rerun","selector":{"phases":["Failed"]}}`,
+		expectCode: http.StatusAccepted,
+		verify: func(t *testing.T, c client.Client, operation BatchOperation) {
+			assert.Equal(t, "create", review.Spec.ResourceAttributes.Verb)
+			assert.Equal(t, 1, operation.Succeeded)
+
+			prs := &v1alpha3.PipelineRunList{}
+			assert.Nil(t, c.List(context.TODO(), prs))
+			if assert.Equal(t, 4, len(prs.Items)) {
+				var rerun *v1alpha3.PipelineRun
+				for i := range prs.Items {
+					if prs.Items[i].GenerateName != "" {
+						rerun = &prs.Items[i]
+					}
+				}
+				if assert.NotNil(t, rerun) {
+					assert.Equal(t, "bob", rerun.Annotations[v1alpha3.PipelineRunCreatorAnnoKey])
+					assert.Equal(t, []v1alpha3.Parameter{{Name: "name", Value: "failed"}}, rerun.Spec.Parameters)
+				}
+			}
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(&v1alpha3.Pipeline{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "fake", Name: "fake"},
+				Spec:       v1alpha3.PipelineSpec{Type: v1alpha3.NoScmPipelineType},
+			}, newPipelineRun("running", v1alpha3.Running),
+				newPipelineRun("failed", v1alpha3.Failed),
+				newPipelineRun("succeeded", v1alpha3.Succeeded)).Build()
+			ws := runtime.NewWebService(v1alpha3.GroupVersion)
+			RegisterRoutes(ws, fakedevops.NewFakeDevops(nil), c, nil, nil, clientset.AuthorizationV1())
+			container := restful.NewContainer()
+			container.Add(ws)
+
+			httpRequest, _ := http.NewRequestWithContext(request.WithUser(request.NewContext(), tt.user), http.MethodPost,
+				"http://fake.com/kapis/devops.kubesphere.io/v1alpha3/namespaces/fake/pipelinerun-batches",
+				strings.NewReader(tt.body))
+			httpRequest.Header.Set("Content-Type", "application/json")
+			httpWriter := httptest.NewRecorder()
+			container.Dispatch(httpWriter, httpRequest)
+			assert.Equal(t, tt.expectCode, httpWriter.Code, httpWriter.Body.String())
+			if tt.verify == nil {
+				return
+			}
+
+			operation := BatchOperation{}
+			assert.Nil(t, json.Unmarshal(httpWriter.Body.Bytes(), &operation))
+			assert.Eventually(t, func() bool {
+				httpRequest, _ := http.NewRequest(http.MethodGet,
+					"http://fake.com/kapis/devops.kubesphere.io/v1alpha3/namespaces/fake/pipelinerun-batches/"+operation.ID, nil)
+				httpWriter := httptest.NewRecorder()
+				container.Dispatch(httpWriter, httpRequest)
+				if httpWriter.Code != http.StatusOK {
+					return false
+				}
+				_ = json.Unmarshal(httpWriter.Body.Bytes(), &operation)
+				return operation.Phase == BatchCompleted
+			}, time.Second, 10*time.Millisecond)
+			tt.verify(t, c, operation)
+		})
+	}
+}
+
+func TestGetBatchNotFound(t *testing.T) {
+	ws := runtime.NewWebService(v1alpha3.GroupVersion)
+	RegisterRoutes(ws, fakedevops.NewFakeDevops(nil), fake.NewClientBuilder().Build(), nil, nil, nil)
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	httpRequest, _ := http.NewRequest(http.MethodGet,
+		"http://fake.com/kapis/devops.kubesphere.io/v1alpha3/namespaces/fake/pipelinerun-batches/fake", nil)
+	httpWriter := httptest.NewRecorder()
+	container.Dispatch(httpWriter, httpRequest)
+	assert.Equal(t, http.StatusNotFound, httpWriter.Code)
+}
@@ -24,37 +24,50 @@ import (
 	"io"
 	"k8s.io/apimachinery/pkg/types"
 	cmstore "kubesphere.io/devops/pkg/store/configmap"
+	"net/http"
 	"net/url"
+	"path"
 	"strconv"
 
 	"kubesphere.io/devops/pkg/kapis"
 
 	"github.com/emicklei/go-restful"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/apiserver/authentication/identity"
 	"kubesphere.io/devops/pkg/apiserver/query"
 	apiserverrequest "kubesphere.io/devops/pkg/apiserver/request"
+	"kubesphere.io/devops/pkg/backend"
 	"kubesphere.io/devops/pkg/client/devops"
 	devopsClient "kubesphere.io/devops/pkg/client/devops"
+	"kubesphere.io/devops/pkg/client/s3"
 	"kubesphere.io/devops/pkg/models/pipelinerun"
 	resourcesV1alpha3 "kubesphere.io/devops/pkg/models/resources/v1alpha3"
+	"kubesphere.io/devops/pkg/utils/secretutil"
 )
 
 // apiHandlerOption holds some useful tools for API handler.
 type apiHandlerOption struct {
 	devopsClient devopsClient.Interface
 	client       client.Client
+	podClient    corev1client.PodsGetter
+	s3Client     s3.Interface
+	sarClient    authorizationv1client.SubjectAccessReviewsGetter
 }
 
 // apiHandler contains functions to handle coming request and give a response.
 type apiHandler struct {
 	apiHandlerOption
+	batches *batchTracker
 }
 
 // newAPIHandler creates an APIHandler.
 func newAPIHandler(o apiHandlerOption) *apiHandler {
-	return &apiHandler{o}
+	return &apiHandler{apiHandlerOption: o, batches: newBatchTracker()}
 }
 
 func (h *apiHandler) listPipelineRuns(request *restful.Request, response *restful.Response) {
@@ -77,15 +90,33 @@ func (h *apiHandler) listPipelineRuns(request *restful.Request, response *restfu
 		return
 	}
 
+	h.writePipelineRuns(request, response, queryParam, pipeline.Namespace, pipeline.Name, branchName, backward)
+}
+
+// listNamespacedPipelineRuns lists the PipelineRuns of all the Pipelines in a namespace
+func (h *apiHandler) listNamespacedPipelineRuns(request *restful.Request, response *restful.Response) {
+	nsName := request.PathParameter("namespace")
+	pipName := request.QueryParameter("pipeline")
+	branchName := request.QueryParameter("branch")
+
+	queryParam := query.ParseQueryParameter(request)
+	// the pipeline is handled by the label selector
+	delete(queryParam.Filters, "pipeline")
+	h.writePipelineRuns(request, response, queryParam, nsName, pipName, branchName, false)
+}
+
+// writePipelineRuns fetches the PipelineRuns from the cache, then writes the filtered, sorted and paged ones
+func (h *apiHandler) writePipelineRuns(request *restful.Request, response *restful.Response, queryParam *query.Query,
+	namespace, pipelineName, branchName string, backward bool) {
 	// build label selector
-	labelSelector, err := buildLabelSelector(queryParam, pipeline.Name)
+	labelSelector, err := buildLabelSelector(queryParam, pipelineName)
 	if err != nil {
 		kapis.HandleError(request, response, err)
 		return
 	}
 
 	opts := make([]client.ListOption, 0, 3)
-	opts = append(opts, client.InNamespace(pipeline.Namespace))
+	opts = append(opts, client.InNamespace(namespace))
 	opts = append(opts, client.MatchingLabelsSelector{Selector: labelSelector})
 	if branchName != "" {
 		opts = append(opts, client.MatchingFields{v1alpha3.PipelineRunSCMRefNameField: branchName})
@@ -144,6 +175,7 @@ func (h *apiHandler) createPipelineRun(request *restful.Request, response *restf
 	if user.GetName() != "" {
 		pr.GetAnnotations()[v1alpha3.PipelineRunCreatorAnnoKey] = user.GetName()
 	}
+	pr.SetTriggerIdentity(identity.FromUser(user))
 	if err := h.client.Create(context.Background(), pr); err != nil {
 		kapis.HandleError(request, response, err)
 		return
@@ -165,6 +197,117 @@ func (h *apiHandler) getPipelineRun(request *restful.Request, response *restful.
 	_ = response.WriteEntity(&pr)
 }
 
+func (h *apiHandler) getPipelineRunLog(request *restful.Request, response *restful.Response) {
+	nsName := request.PathParameter("namespace")
+	prName := request.PathParameter("pipelinerun")
+	follow, _ := strconv.ParseBool(request.QueryParameter("follow"))
+	ctx := request.Request.Context()
+
+	pr := &v1alpha3.PipelineRun{}
+	if err := h.client.Get(ctx, client.ObjectKey{Namespace: nsName, Name: prName}, pr); err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+	// the credentials of the DevOpsProject never appear in the logs, no matter they were archived or not
+	masker, err := secretutil.NewLogMaskerForNamespace(ctx, h.client, nsName)
+	if err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+	// the archived logs are still available after the Pods or Jenkins builds have been garbage-collected
+	if key, ok := pr.Annotations[v1alpha3.PipelineRunLogArchiveAnnoKey]; ok && h.s3Client != nil {
+		data, err := h.s3Client.Read(key)
+		if err == nil {
+			response.Header().Set(restful.HEADER_ContentType, "text/plain; charset=utf-8")
+			_, _ = response.Write(masker.Mask(data))
+			return
+		}
+		klog.V(4).Infof("failed to read the archived logs of PipelineRun '%s/%s', error: %v", nsName, prName, err)
+	}
+	if backendType, ok := backend.TypeOf(pr); ok && backendType != backend.Tekton {
+		kapis.HandleBadRequest(response, request, fmt.Errorf("the logs of PipelineRun '%s/%s' are not in Pods, "+
+			"because it belongs to %s backend", nsName, prName, backendType))
+		return
+	}
+	if h.podClient == nil {
+		kapis.HandleInternalError(response, request, fmt.Errorf("the Kubernetes client is not available"))
+		return
+	}
+
+	streamer := newLogStreamer(h.podClient, nsName, prName, follow, func(ctx context.Context) (bool, error) {
+		latest := &v1alpha3.PipelineRun{}
+		if err := h.client.Get(ctx, client.ObjectKey{Namespace: nsName, Name: prName}, latest); err != nil {
+			return false, err
+		}
+		return latest.HasCompleted(), nil
+	})
+	response.Header().Set(restful.HEADER_ContentType, "text/plain; charset=utf-8")
+	response.WriteHeader(http.StatusOK)
+	writer := masker.NewWriter(flushWriter{writer: response.ResponseWriter})
+	if err = streamer.stream(ctx, writer); err == nil {
+		err = writer.Flush()
+	}
+	if err != nil {
+		// it's too late to change the status code after writing the logs
+		klog.Errorf("failed to stream the logs of PipelineRun '%s/%s', error: %v", nsName, prName, err)
+	}
+}
+
+// ArtifactDownload is the download information of an archived artifact.
+type ArtifactDownload struct {
+	Name string `json:"name"`
+	// URL is a presigned URL which will be expired in minutes
+	URL string `json:"url"`
+}
+
+func (h *apiHandler) listArchivedArtifacts(request *restful.Request, response *restful.Response) {
+	nsName := request.PathParameter("namespace")
+	prName := request.PathParameter("pipelinerun")
+
+	pr := &v1alpha3.PipelineRun{}
+	if err := h.client.Get(request.Request.Context(), client.ObjectKey{Namespace: nsName, Name: prName}, pr); err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+	artifacts := pr.Status.Artifacts
+	if artifacts == nil {
+		artifacts = []v1alpha3.PipelineRunArtifact{}
+	}
+	_ = response.WriteEntity(artifacts)
+}
+
+func (h *apiHandler) getArchivedArtifact(request *restful.Request, response *restful.Response) {
+	nsName := request.PathParameter("namespace")
+	prName := request.PathParameter("pipelinerun")
+	artifactName := request.PathParameter("artifact")
+
+	pr := &v1alpha3.PipelineRun{}
+	if err := h.client.Get(request.Request.Context(), client.ObjectKey{Namespace: nsName, Name: prName}, pr); err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+	artifact := pr.Status.GetArtifact(artifactName)
+	if artifact == nil {
+		kapis.HandleNotFound(response, request, fmt.Errorf("artifact '%s' of PipelineRun '%s/%s' was not found",
+			artifactName, nsName, prName))
+		return
+	}
+	if h.s3Client == nil {
+		kapis.HandleInternalError(response, request, fmt.Errorf("the object storage is not available"))
+		return
+	}
+
+	downloadURL, err := h.s3Client.GetDownloadURL(artifact.Key, path.Base(artifact.Path))
+	if err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+	_ = response.WriteEntity(&ArtifactDownload{
+		Name: artifact.Name,
+		URL:  downloadURL,
+	})
+}
+
 func (h *apiHandler) getNodeDetails(request *restful.Request, response *restful.Response) {
 	namespaceName := request.PathParameter("namespace")
 	pipelineRunName := request.PathParameter("pipelinerun")
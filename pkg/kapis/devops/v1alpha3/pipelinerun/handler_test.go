@@ -29,11 +29,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/emicklei/go-restful"
 	"github.com/stretchr/testify/assert"
 	"kubesphere.io/devops/pkg/apiserver/runtime"
 	fakedevops "kubesphere.io/devops/pkg/client/devops/fake"
+	fakes3 "kubesphere.io/devops/pkg/client/s3/fake"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -50,7 +52,7 @@ func TestApis(t *testing.T) {
 		Spec: v1alpha3.PipelineSpec{
 			Type: v1alpha3.NoScmPipelineType,
 		},
-	}))
+	}), nil, nil, nil)
 	restful.DefaultContainer.Add(wsWithGroup)
 
 	type args struct {
@@ -177,3 +179,153 @@ func TestGetNodeDetails(t *testing.T) {
  }
 ]`, string(body))
 }
+
+func TestArchivedArtifacts(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	pr := &v1alpha3.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pr"},
+		Status: v1alpha3.PipelineRunStatus{
+			Artifacts: []v1alpha3.PipelineRunArtifact{{Name: "jar", Path: "target/app.jar", Key: "key"}},
+		},
+	}
+	handler := newAPIHandler(apiHandlerOption{
+		client:   fake.NewClientBuilder().WithScheme(schema).WithObjects(pr).Build(),
+		s3Client: fakes3.NewFakeS3(&fakes3.Object{Key: "key"}),
+	})
+
+	tests := []struct {
+		name       string
+		artifact   string
+		wantStatus int
+		wantBody   string
+	}{{
+		name:       "list artifacts",
+		wantStatus: http.StatusOK,
+		wantBody:   `[{"name":"jar","path":"target/app.jar","key":"key"}]`,
+	}, {
+		name:       "get the download URL",
+		artifact:   "jar",
+		wantStatus: http.StatusOK,
+		wantBody:   `{"name":"jar","url":"http://key/app.jar"}`,
+	}, {
+		name:       "artifact not found",
+		artifact:   "fake",
+		wantStatus: http.StatusNotFound,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpRequest := httptest.NewRequest(http.MethodGet, "/namespaces/ns/pipelineruns/pr/archived-artifacts", nil)
+			request := restful.NewRequest(httpRequest)
+			request.PathParameters()["namespace"] = "ns"
+			request.PathParameters()["pipelinerun"] = "pr"
+			recorder := httptest.NewRecorder()
+			response := restful.NewResponse(recorder)
+			response.SetRequestAccepts(restful.MIME_JSON)
+			response.PrettyPrint(false)
+			if tt.artifact == "" {
+				handler.listArchivedArtifacts(request, response)
+			} else {
+				request.PathParameters()["artifact"] = tt.artifact
+				handler.getArchivedArtifact(request, response)
+			}
+
+			assert.Equal(t, tt.wantStatus, recorder.Code)
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestListNamespacedPipelineRuns(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	now := metav1.Now()
+	earlier := metav1.NewTime(now.Add(-time.Hour))
+	newPipelineRun := func(name, pipeline string, phase v1alpha3.RunPhase, startTime metav1.Time, annotations map[string]string) *v1alpha3.PipelineRun {
+		return &v1alpha3.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "fake",
+				Name:        name,
+				Labels:      map[string]string{v1alpha3.PipelineNameLabelKey: pipeline},
+				Annotations: annotations,
+			},
+			Status: v1alpha3.PipelineRunStatus{Phase: phase, StartTime: &startTime},
+		}
+	}
+	c := fake.NewClientBuilder().WithScheme(schema).WithObjects(
+		newPipelineRun("build-1", "build", v1alpha3.Succeeded, earlier, map[string]string{v1alpha3.PipelineRunCreatorAnnoKey: "admin"}),
+		newPipelineRun("build-2", "build", v1alpha3.Failed, now, map[string]string{v1alpha3.PipelineRunSCMRepoAnnoKey: "a/b"}),
+		newPipelineRun("deploy-1", "deploy", v1alpha3.Failed, earlier, nil),
+	).Build()
+
+	ws := runtime.NewWebService(v1alpha3.GroupVersion)
+	RegisterRoutes(ws, fakedevops.NewFakeDevops(nil), c, nil, nil, nil)
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	tests := []struct {
+		name      string
+		query     string
+		wantTotal int
+		wantNames []string
+	}{{
+		name:      "all runs, the latest one comes first",
+		wantTotal: 3,
+		wantNames: []string{"build-2", "build-1", "deploy-1"},
+	}, {
+		name:      "in ascending order",
+		query:     "ascending=true",
+		wantTotal: 3,
+		wantNames: []string{"deploy-1", "build-1", "build-2"},
+	}, {
+		name:      "filter by pipeline",
+		query:     "pipeline=build",
+		wantTotal: 2,
+		wantNames: []string{"build-2", "build-1"},
+	}, {
+		name:      "filter by phase",
+		query:     "phase=Failed",
+		wantTotal: 2,
+		wantNames: []string{"build-2", "deploy-1"},
+	}, {
+		name:      "filter by trigger",
+		query:     "trigger=scm",
+		wantTotal: 1,
+		wantNames: []string{"build-2"},
+	}, {
+		name:      "filter by user",
+		query:     "user=admin",
+		wantTotal: 1,
+		wantNames: []string{"build-1"},
+	}, {
+		name:      "pagination",
+		query:     "limit=1&page=2",
+		wantTotal: 3,
+		wantNames: []string{"build-1"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpRequest, _ := http.NewRequest(http.MethodGet,
+				"http://fake.com/kapis/devops.kubesphere.io/v1alpha3/namespaces/fake/pipelineruns?"+tt.query, nil)
+			httpWriter := httptest.NewRecorder()
+			container.Dispatch(httpWriter, httpRequest)
+			assert.Equal(t, http.StatusOK, httpWriter.Code)
+
+			result := &struct {
+				Items      []v1alpha3.PipelineRun `json:"items"`
+				TotalItems int                    `json:"totalItems"`
+			}{}
+			assert.Nil(t, json.Unmarshal(httpWriter.Body.Bytes(), result))
+			assert.Equal(t, tt.wantTotal, result.TotalItems)
+			var names []string
+			for _, item := range result.Items {
+				names = append(names, item.Name)
+			}
+			assert.Equal(t, tt.wantNames, names)
+		})
+	}
+}
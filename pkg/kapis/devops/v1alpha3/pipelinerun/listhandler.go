@@ -25,6 +25,20 @@ import (
 	resourcesV1alpha3 "kubesphere.io/devops/pkg/models/resources/v1alpha3"
 )
 
+// The fields to filter or sort PipelineRuns besides the default ones.
+const (
+	// FieldPhase filters PipelineRuns by phases which are separated by commas, e.g. ?phase=Failed,Cancelled
+	FieldPhase query.Field = "phase"
+	// FieldTrigger filters PipelineRuns by the trigger, e.g. ?trigger=scm
+	FieldTrigger query.Field = "trigger"
+	// FieldUser filters PipelineRuns by the user who triggered them, e.g. ?user=admin
+	FieldUser query.Field = "user"
+	// FieldStartTime sorts PipelineRuns by the start time, it's the default one
+	FieldStartTime query.Field = "startTime"
+	// FieldCompletionTime sorts PipelineRuns by the completion time, the ones are not completed come first
+	FieldCompletionTime query.Field = "completionTime"
+)
+
 // listHandler is default implementation for PipelineRun.
 type listHandler struct {
 }
@@ -45,6 +59,16 @@ func (b listHandler) Comparator() resourcesV1alpha3.CompareFunc {
 		if !ok {
 			return false
 		}
+		if f == FieldCompletionTime {
+			leftCompleted := !leftPipelineRun.Status.CompletionTime.IsZero()
+			rightCompleted := !rightPipelineRun.Status.CompletionTime.IsZero()
+			if leftCompleted != rightCompleted {
+				return rightCompleted
+			}
+			if leftCompleted && !leftPipelineRun.Status.CompletionTime.Equal(rightPipelineRun.Status.CompletionTime) {
+				return leftPipelineRun.Status.CompletionTime.After(rightPipelineRun.Status.CompletionTime.Time)
+			}
+		}
 		// Compare start time and creation time(if missing former)
 		leftTime := leftPipelineRun.Status.StartTime
 		if leftTime.IsZero() {
@@ -62,7 +86,32 @@ func (b listHandler) Comparator() resourcesV1alpha3.CompareFunc {
 }
 
 func (b listHandler) Filter() resourcesV1alpha3.FilterFunc {
-	return resourcesV1alpha3.DefaultFilter()
+	return resourcesV1alpha3.DefaultFilter().And(pipelineRunFilter())
+}
+
+// pipelineRunFilter filters PipelineRuns by the phase, trigger and user
+func pipelineRunFilter() resourcesV1alpha3.FilterFunc {
+	return func(object runtime.Object, filter query.Filter) bool {
+		pr, ok := checkPipelineRun(object)
+		if !ok {
+			return false
+		}
+		switch filter.Field {
+		case FieldPhase:
+			for _, phase := range strings.Split(string(filter.Value), ",") {
+				if strings.EqualFold(strings.TrimSpace(phase), string(pr.Status.Phase)) {
+					return true
+				}
+			}
+			return false
+		case FieldTrigger:
+			return pr.GetTrigger() == string(filter.Value)
+		case FieldUser:
+			return pr.GetCreator() == string(filter.Value)
+		default:
+			return true
+		}
+	}
 }
 
 func (b listHandler) Transformer() resourcesV1alpha3.TransformFunc {
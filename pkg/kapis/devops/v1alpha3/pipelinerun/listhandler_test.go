@@ -21,8 +21,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/apiserver/query"
 )
 
 func Test_listHandler_Comparator(t *testing.T) {
@@ -116,3 +118,52 @@ func Test_listHandler_Comparator(t *testing.T) {
 		})
 	}
 }
+
+func Test_listHandler_CompletionTimeComparator(t *testing.T) {
+	now := v1.Now()
+	later := v1.Time{Time: now.Add(time.Hour)}
+	createPipelineRun := func(name string, completionTime *v1.Time) *v1alpha3.PipelineRun {
+		return &v1alpha3.PipelineRun{
+			ObjectMeta: v1.ObjectMeta{Name: name, CreationTimestamp: now},
+			Status:     v1alpha3.PipelineRunStatus{StartTime: &now, CompletionTime: completionTime},
+		}
+	}
+
+	compare := listHandler{}.Comparator()
+	// the later completed one comes first
+	assert.True(t, compare(createPipelineRun("a", &later), createPipelineRun("b", &now), FieldCompletionTime))
+	assert.False(t, compare(createPipelineRun("a", &now), createPipelineRun("b", &later), FieldCompletionTime))
+	// the running one comes first
+	assert.True(t, compare(createPipelineRun("a", nil), createPipelineRun("b", &later), FieldCompletionTime))
+	assert.False(t, compare(createPipelineRun("a", &later), createPipelineRun("b", nil), FieldCompletionTime))
+	// compare the names at last
+	assert.True(t, compare(createPipelineRun("a", nil), createPipelineRun("b", nil), FieldCompletionTime))
+}
+
+func Test_pipelineRunFilter(t *testing.T) {
+	pr := &v1alpha3.PipelineRun{
+		ObjectMeta: v1.ObjectMeta{
+			Annotations: map[string]string{v1alpha3.PipelineRunCreatorAnnoKey: "admin"},
+		},
+		Status: v1alpha3.PipelineRunStatus{Phase: v1alpha3.Failed},
+	}
+
+	filter := pipelineRunFilter()
+	tests := []struct {
+		filter query.Filter
+		want   bool
+	}{
+		{filter: query.Filter{Field: FieldPhase, Value: "Failed"}, want: true},
+		{filter: query.Filter{Field: FieldPhase, Value: "succeeded, failed"}, want: true},
+		{filter: query.Filter{Field: FieldPhase, Value: "Succeeded"}, want: false},
+		{filter: query.Filter{Field: FieldTrigger, Value: v1alpha3.PipelineRunTriggerUser}, want: true},
+		{filter: query.Filter{Field: FieldTrigger, Value: v1alpha3.PipelineRunTriggerSCM}, want: false},
+		{filter: query.Filter{Field: FieldUser, Value: "admin"}, want: true},
+		{filter: query.Filter{Field: FieldUser, Value: "bob"}, want: false},
+		{filter: query.Filter{Field: "branch", Value: "main"}, want: true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, filter(pr, tt.filter), tt.filter)
+	}
+	assert.False(t, filter(&v1alpha3.Pipeline{}, query.Filter{Field: FieldPhase, Value: "Failed"}))
+}
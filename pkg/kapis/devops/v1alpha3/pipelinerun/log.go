@@ -0,0 +1,193 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelinerun
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+const (
+	// tektonPipelineRunLabelKey is the label key which Tekton puts on the Pods of a PipelineRun
+	tektonPipelineRunLabelKey = "tekton.dev/pipelineRun"
+	// tektonPipelineTaskLabelKey is the label key which Tekton puts on the Pods to indicate the task name
+	tektonPipelineTaskLabelKey = "tekton.dev/pipelineTask"
+	// tektonStepContainerPrefix is the name prefix of the step containers
+	tektonStepContainerPrefix = "step-"
+
+	defaultLogPollInterval = 2 * time.Second
+)
+
+// logStreamer writes the logs of all steps of a Tekton PipelineRun. Each Pod of the PipelineRun is a
+// stage (task), and each step container of the Pod is a step.
+type logStreamer struct {
+	podClient    corev1client.PodsGetter
+	namespace    string
+	pipelineRun  string
+	follow       bool
+	pollInterval time.Duration
+	// completed tells if the PipelineRun has completed, the streamer stops following once it returns true
+	completed func(ctx context.Context) (bool, error)
+
+	streamed map[string]bool
+}
+
+func newLogStreamer(podClient corev1client.PodsGetter, namespace, pipelineRun string, follow bool,
+	completed func(ctx context.Context) (bool, error)) *logStreamer {
+	return &logStreamer{
+		podClient:    podClient,
+		namespace:    namespace,
+		pipelineRun:  pipelineRun,
+		follow:       follow,
+		pollInterval: defaultLogPollInterval,
+		completed:    completed,
+		streamed:     map[string]bool{},
+	}
+}
+
+// stream writes the logs of the steps in order. In follow mode, it keeps discovering the new Pods
+// and steps until the PipelineRun has completed or the context is done.
+func (s *logStreamer) stream(ctx context.Context, writer io.Writer) error {
+	for {
+		// check the completion before listing Pods, then the last round won't miss any Pods
+		completed, err := s.completed(ctx)
+		if err != nil {
+			return err
+		}
+
+		var pods []v1.Pod
+		if pods, err = s.listPods(ctx); err != nil {
+			return err
+		}
+		for i := range pods {
+			pod := &pods[i]
+			for _, container := range getStepContainers(pod) {
+				key := pod.Name + "/" + container
+				if s.streamed[key] || !isContainerStarted(pod, container) {
+					continue
+				}
+				if err = s.streamContainer(ctx, writer, pod, container); err != nil {
+					return err
+				}
+				s.streamed[key] = true
+			}
+		}
+
+		if !s.follow || completed {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(s.pollInterval):
+		}
+	}
+}
+
+func (s *logStreamer) listPods(ctx context.Context) ([]v1.Pod, error) {
+	podList, err := s.podClient.Pods(s.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", tektonPipelineRunLabelKey, s.pipelineRun),
+	})
+	if err != nil {
+		return nil, err
+	}
+	pods := podList.Items
+	sort.SliceStable(pods, func(i, j int) bool {
+		if pods[i].CreationTimestamp.Equal(&pods[j].CreationTimestamp) {
+			return pods[i].Name < pods[j].Name
+		}
+		return pods[i].CreationTimestamp.Before(&pods[j].CreationTimestamp)
+	})
+	return pods, nil
+}
+
+func (s *logStreamer) streamContainer(ctx context.Context, writer io.Writer, pod *v1.Pod, container string) (err error) {
+	if _, err = fmt.Fprintf(writer, "[%s : %s]\n", getTaskName(pod),
+		strings.TrimPrefix(container, tektonStepContainerPrefix)); err != nil {
+		return
+	}
+
+	var reader io.ReadCloser
+	if reader, err = s.podClient.Pods(pod.Namespace).GetLogs(pod.Name, &v1.PodLogOptions{
+		Container: container,
+		Follow:    s.follow,
+	}).Stream(ctx); err != nil {
+		return
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	if _, err = io.Copy(writer, reader); err != nil {
+		return
+	}
+	_, err = fmt.Fprintln(writer)
+	return
+}
+
+// getStepContainers returns the step containers of a Pod, all containers are treated as steps if there's no step container.
+func getStepContainers(pod *v1.Pod) (containers []string) {
+	for _, container := range pod.Spec.Containers {
+		if strings.HasPrefix(container.Name, tektonStepContainerPrefix) {
+			containers = append(containers, container.Name)
+		}
+	}
+	if len(containers) == 0 {
+		for _, container := range pod.Spec.Containers {
+			containers = append(containers, container.Name)
+		}
+	}
+	return
+}
+
+func getTaskName(pod *v1.Pod) string {
+	if name := pod.GetLabels()[tektonPipelineTaskLabelKey]; name != "" {
+		return name
+	}
+	return pod.Name
+}
+
+// isContainerStarted returns true if the logs of the container are available
+func isContainerStarted(pod *v1.Pod, container string) bool {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == container {
+			return status.State.Running != nil || status.State.Terminated != nil
+		}
+	}
+	return false
+}
+
+// flushWriter flushes the data to the client after each writing
+type flushWriter struct {
+	writer io.Writer
+}
+
+func (w flushWriter) Write(data []byte) (n int, err error) {
+	n, err = w.writer.Write(data)
+	if flusher, ok := w.writer.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return
+}
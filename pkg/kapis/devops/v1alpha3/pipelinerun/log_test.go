@@ -0,0 +1,162 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelinerun
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	fakes3 "kubesphere.io/devops/pkg/client/s3/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTaskPod(name, pipelineRun, task string, created time.Time, containers map[string]bool) *v1.Pod {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "ns",
+			CreationTimestamp: metav1.NewTime(created),
+			Labels: map[string]string{
+				tektonPipelineRunLabelKey:  pipelineRun,
+				tektonPipelineTaskLabelKey: task,
+			},
+		},
+	}
+	for _, container := range []string{"step-clone", "step-build"} {
+		started, ok := containers[container]
+		if !ok {
+			continue
+		}
+		pod.Spec.Containers = append(pod.Spec.Containers, v1.Container{Name: container})
+		status := v1.ContainerStatus{Name: container}
+		if started {
+			status.State.Terminated = &v1.ContainerStateTerminated{}
+		} else {
+			status.State.Waiting = &v1.ContainerStateWaiting{}
+		}
+		pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, status)
+	}
+	return pod
+}
+
+func TestLogStreamer(t *testing.T) {
+	now := time.Now()
+	clientset := k8sfake.NewSimpleClientset(
+		newTaskPod("run-build", "run", "build", now, map[string]bool{"step-build": false}),
+		newTaskPod("run-clone", "run", "clone", now.Add(-time.Minute), map[string]bool{"step-clone": true}),
+		newTaskPod("other-clone", "other", "clone", now.Add(-time.Hour), map[string]bool{"step-clone": true}))
+
+	completed := func(ctx context.Context) (bool, error) {
+		return true, nil
+	}
+
+	buffer := &bytes.Buffer{}
+	streamer := newLogStreamer(clientset.CoreV1(), "ns", "run", false, completed)
+	assert.Nil(t, streamer.stream(context.Background(), buffer))
+	assert.Equal(t, "[clone : clone]\nfake logs\n", buffer.String())
+
+	// the step has started
+	pod := newTaskPod("run-build", "run", "build", now, map[string]bool{"step-build": true})
+	_, err := clientset.CoreV1().Pods("ns").Update(context.Background(), pod, metav1.UpdateOptions{})
+	assert.Nil(t, err)
+
+	buffer.Reset()
+	streamer.follow = true
+	assert.Nil(t, streamer.stream(context.Background(), buffer))
+	assert.Equal(t, "[build : build]\nfake logs\n", buffer.String())
+}
+
+func TestGetStepContainers(t *testing.T) {
+	pod := &v1.Pod{}
+	pod.Spec.Containers = []v1.Container{{Name: "step-build"}, {Name: "sidecar"}}
+	assert.Equal(t, []string{"step-build"}, getStepContainers(pod))
+
+	pod.Spec.Containers = []v1.Container{{Name: "sidecar"}}
+	assert.Equal(t, []string{"sidecar"}, getStepContainers(pod))
+}
+
+func TestGetPipelineRunLog(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+	assert.Nil(t, v1.AddToScheme(schema))
+
+	newPipelineRun := func(name string, labels, annotations map[string]string) *v1alpha3.PipelineRun {
+		return &v1alpha3.PipelineRun{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns", Name: name, Labels: labels, Annotations: annotations,
+		}}
+	}
+	handler := newAPIHandler(apiHandlerOption{
+		client: fake.NewClientBuilder().WithScheme(schema).WithObjects(
+			newPipelineRun("archived", nil, map[string]string{v1alpha3.PipelineRunLogArchiveAnnoKey: "key"}),
+			newPipelineRun("jenkins", map[string]string{v1alpha3.PipelineBackendLabelKey: "Jenkins"}, nil),
+			newPipelineRun("tekton", nil, nil),
+			&v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "token"},
+				Type:       v1alpha3.SecretTypeSecretText,
+				Data:       map[string][]byte{v1alpha3.SecretTextSecretKey: []byte("ghp_token")},
+			}).Build(),
+		podClient: k8sfake.NewSimpleClientset().CoreV1(),
+		s3Client: fakes3.NewFakeS3(&fakes3.Object{
+			Key:  "key",
+			Body: bytes.NewBufferString("archived logs with ghp_token"),
+		}),
+	})
+
+	tests := []struct {
+		name       string
+		wantStatus int
+		wantBody   string
+	}{{
+		name:       "archived",
+		wantStatus: http.StatusOK,
+		wantBody:   "archived logs with ********",
+	}, {
+		name:       "jenkins",
+		wantStatus: http.StatusBadRequest,
+	}, {
+		name:       "tekton",
+		wantStatus: http.StatusOK,
+	}, {
+		name:       "not-found",
+		wantStatus: http.StatusNotFound,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpRequest := httptest.NewRequest(http.MethodGet, "/namespaces/ns/pipelineruns/"+tt.name+"/log", nil)
+			request := restful.NewRequest(httpRequest)
+			request.PathParameters()["namespace"] = "ns"
+			request.PathParameters()["pipelinerun"] = tt.name
+			recorder := httptest.NewRecorder()
+			handler.getPipelineRunLog(request, restful.NewResponse(recorder))
+
+			assert.Equal(t, tt.wantStatus, recorder.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -26,17 +26,25 @@ import (
 	"kubesphere.io/devops/pkg/models/pipelinerun"
 
 	"github.com/emicklei/go-restful"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"kubesphere.io/devops/pkg/api"
+	"kubesphere.io/devops/pkg/apiserver/query"
 	"kubesphere.io/devops/pkg/client/devops"
 	devopsClient "kubesphere.io/devops/pkg/client/devops"
+	"kubesphere.io/devops/pkg/client/s3"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // RegisterRoutes register routes into web service.
-func RegisterRoutes(ws *restful.WebService, devopsClient devopsClient.Interface, c client.Client) {
+func RegisterRoutes(ws *restful.WebService, devopsClient devopsClient.Interface, c client.Client,
+	podClient corev1client.PodsGetter, s3Client s3.Interface, sarClient authorizationv1client.SubjectAccessReviewsGetter) {
 	handler := newAPIHandler(apiHandlerOption{
 		devopsClient: devopsClient,
 		client:       c,
+		podClient:    podClient,
+		s3Client:     s3Client,
+		sarClient:    sarClient,
 	})
 
 	ws.Route(ws.GET("/namespaces/{namespace}/pipelines/{pipeline}/pipelineruns").
@@ -45,6 +53,9 @@ func RegisterRoutes(ws *restful.WebService, devopsClient devopsClient.Interface,
 		Param(ws.PathParameter("namespace", "Namespace of the pipeline")).
 		Param(ws.PathParameter("pipeline", "Name of the pipeline")).
 		Param(ws.QueryParameter("branch", "The name of SCM reference")).
+		Param(ws.QueryParameter(string(FieldPhase), "The phases separated by commas, e.g. Failed,Cancelled")).
+		Param(ws.QueryParameter(string(FieldTrigger), "What triggered the runs, one of scm, user, jenkins and unknown")).
+		Param(ws.QueryParameter(string(FieldUser), "The user who triggered the runs")).
 		Param(ws.QueryParameter("backward", "Backward compatibility for v1alpha2 API "+
 			"`/devops/{devops}/pipelines/{pipeline}/runs`. By default, the backward is true. If you want to list "+
 			"full data of PipelineRuns, just set the parameters to false.").
@@ -52,6 +63,22 @@ func RegisterRoutes(ws *restful.WebService, devopsClient devopsClient.Interface,
 			DefaultValue("true")).
 		Returns(http.StatusOK, api.StatusOK, v1alpha3.PipelineRunList{}))
 
+	ws.Route(ws.GET("/namespaces/{namespace}/pipelineruns").
+		To(handler.listNamespacedPipelineRuns).
+		Doc("Get the runs of all pipelines in the specified namespace, the latest runs come first by default").
+		Param(ws.PathParameter("namespace", "Namespace of the pipelines")).
+		Param(ws.QueryParameter("pipeline", "The name of the pipeline")).
+		Param(ws.QueryParameter("branch", "The name of SCM reference")).
+		Param(ws.QueryParameter(string(FieldPhase), "The phases separated by commas, e.g. Failed,Cancelled")).
+		Param(ws.QueryParameter(string(FieldTrigger), "What triggered the runs, one of scm, user, jenkins and unknown")).
+		Param(ws.QueryParameter(string(FieldUser), "The user who triggered the runs")).
+		Param(ws.QueryParameter(query.ParameterOrderBy, "Sort by startTime or completionTime").DefaultValue(string(FieldStartTime))).
+		Param(ws.QueryParameter(query.ParameterAscending, "Sort in ascending order").DataType("bool").DefaultValue("false")).
+		Param(ws.QueryParameter(query.ParameterPage, "page").DataFormat("page=%d").DefaultValue("page=1")).
+		Param(ws.QueryParameter(query.ParameterLimit, "limit")).
+		Returns(http.StatusOK, api.StatusOK, api.ListResult{Items: []interface{}{}}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsPipelineTag}))
+
 	ws.Route(ws.POST("/namespaces/{namespace}/pipelines/{pipeline}/pipelineruns").
 		To(handler.createPipelineRun).
 		Doc("Create a PipelineRun for the specified pipeline").
@@ -61,6 +88,34 @@ func RegisterRoutes(ws *restful.WebService, devopsClient devopsClient.Interface,
 		Reads(devops.RunPayload{}).
 		Returns(http.StatusCreated, api.StatusOK, v1alpha3.PipelineRun{}))
 
+	ws.Route(ws.POST("/devops/{devops}/pipelines/{pipeline}/runs").
+		To(handler.triggerPipelineRun).
+		Doc("Trigger a PipelineRun for the specified pipeline. Instead of the permission of creating PipelineRuns, "+
+			"the current user needs the permission of creating the subresource 'pipelines/runs' in the DevOps project").
+		Param(ws.PathParameter("devops", "Name of the DevOps project")).
+		Param(ws.PathParameter("pipeline", "Name of the pipeline")).
+		Param(ws.QueryParameter("branch", "The name of SCM reference, only for multi-branch pipeline")).
+		Reads(devops.RunPayload{}).
+		Returns(http.StatusCreated, api.StatusOK, v1alpha3.PipelineRun{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsPipelineTag}))
+
+	ws.Route(ws.POST("/namespaces/{namespace}/pipelinerun-batches").
+		To(handler.createBatch).
+		Doc("Cancel, delete or rerun the PipelineRuns matched by the selector in the background. The current user needs "+
+			"the permission of updating, deleting or creating PipelineRuns in the namespace respectively").
+		Param(ws.PathParameter("namespace", "Namespace of the PipelineRuns")).
+		Reads(BatchRequest{}).
+		Returns(http.StatusAccepted, api.StatusOK, BatchOperation{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsPipelineTag}))
+
+	ws.Route(ws.GET("/namespaces/{namespace}/pipelinerun-batches/{batch}").
+		To(handler.getBatch).
+		Doc("Get the progress of a batch operation, it's kept for an hour after completed").
+		Param(ws.PathParameter("namespace", "Namespace of the PipelineRuns")).
+		Param(ws.PathParameter("batch", "ID of the batch operation")).
+		Returns(http.StatusOK, api.StatusOK, BatchOperation{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsPipelineTag}))
+
 	ws.Route(ws.GET("/namespaces/{namespace}/pipelineruns/{pipelinerun}").
 		To(handler.getPipelineRun).
 		Doc("Get a PipelineRun for a specified pipeline").
@@ -68,6 +123,18 @@ func RegisterRoutes(ws *restful.WebService, devopsClient devopsClient.Interface,
 		Param(ws.PathParameter("pipelinerun", "Name of the PipelineRun")).
 		Returns(http.StatusOK, api.StatusOK, v1alpha3.PipelineRun{}))
 
+	ws.Route(ws.GET("/namespaces/{namespace}/pipelineruns/{pipelinerun}/log").
+		To(handler.getPipelineRunLog).
+		Doc("Get the logs of all steps of a Tekton PipelineRun, the logs of each step start with a line like '[task : step]'. "+
+			"The archived logs will be returned if the PipelineRun has been archived").
+		Param(ws.PathParameter("namespace", "Namespace of the PipelineRun")).
+		Param(ws.PathParameter("pipelinerun", "Name of the PipelineRun")).
+		Param(ws.QueryParameter("follow", "Keep streaming the logs until the PipelineRun has completed").
+			DataType("bool").
+			DefaultValue("false")).
+		Produces("text/plain").
+		Returns(http.StatusOK, api.StatusOK, nil))
+
 	ws.Route(ws.GET("/namespaces/{namespace}/pipelineruns/{pipelinerun}/nodedetails").
 		To(handler.getNodeDetails).
 		Doc("Get node details including steps and approvable for a given Pipeline").
@@ -75,6 +142,23 @@ func RegisterRoutes(ws *restful.WebService, devopsClient devopsClient.Interface,
 		Param(ws.PathParameter("pipelinerun", "Name of the PipelineRun")).
 		Returns(http.StatusOK, api.StatusOK, []pipelinerun.NodeDetail{}))
 
+	ws.Route(ws.GET("/namespaces/{namespace}/pipelineruns/{pipelinerun}/archived-artifacts").
+		To(handler.listArchivedArtifacts).
+		Doc("Get the artifacts of a PipelineRun which have been archived into the object storage").
+		Param(ws.PathParameter("namespace", "Namespace of the PipelineRun")).
+		Param(ws.PathParameter("pipelinerun", "Name of the PipelineRun")).
+		Returns(http.StatusOK, api.StatusOK, []v1alpha3.PipelineRunArtifact{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsPipelineTag}))
+
+	ws.Route(ws.GET("/namespaces/{namespace}/pipelineruns/{pipelinerun}/archived-artifacts/{artifact}").
+		To(handler.getArchivedArtifact).
+		Doc("Get an expiring download URL of an archived artifact").
+		Param(ws.PathParameter("namespace", "Namespace of the PipelineRun")).
+		Param(ws.PathParameter("pipelinerun", "Name of the PipelineRun")).
+		Param(ws.PathParameter("artifact", "Name of the artifact")).
+		Returns(http.StatusOK, api.StatusOK, ArtifactDownload{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsPipelineTag}))
+
 	// download PipelineRun artifact
 	ws.Route(ws.GET("/namespaces/{namespace}/pipelineruns/{pipelinerun}/artifacts/download").
 		Param(ws.PathParameter("namespace", "Namespace of the PipelineRun")).
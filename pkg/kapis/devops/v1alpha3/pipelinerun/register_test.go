@@ -36,7 +36,7 @@ func TestAPIsExist(t *testing.T) {
 	schema, err := v1alpha1.SchemeBuilder.Register().Build()
 	assert.Nil(t, err)
 
-	RegisterRoutes(wsWithGroup, fakedevops.NewFakeDevops(nil), fake.NewFakeClientWithScheme(schema))
+	RegisterRoutes(wsWithGroup, fakedevops.NewFakeDevops(nil), fake.NewFakeClientWithScheme(schema), nil, nil, nil)
 	restful.DefaultContainer.Add(wsWithGroup)
 
 	type args struct {
@@ -58,6 +58,18 @@ func TestAPIsExist(t *testing.T) {
 			method: http.MethodPost,
 			uri:    "/namespaces/fake/pipelines/fake/pipelineruns",
 		},
+	}, {
+		name: "create a batch operation",
+		args: args{
+			method: http.MethodPost,
+			uri:    "/namespaces/fake/pipelinerun-batches",
+		},
+	}, {
+		name: "get a batch operation",
+		args: args{
+			method: http.MethodGet,
+			uri:    "/namespaces/fake/pipelinerun-batches/fake",
+		},
 	}, {
 		name: "get a pipelinerun",
 		args: args{
@@ -0,0 +1,133 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelinerun
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/apiserver/authentication/identity"
+	apiserverrequest "kubesphere.io/devops/pkg/apiserver/request"
+	"kubesphere.io/devops/pkg/client/devops"
+	"kubesphere.io/devops/pkg/kapis"
+)
+
+//+kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// triggerPipelineRun creates a PipelineRun on behalf of the current user after making sure that the user is allowed
+// to trigger the Pipeline. It means the users do not need the permission of creating PipelineRuns directly.
+func (h *apiHandler) triggerPipelineRun(request *restful.Request, response *restful.Response) {
+	nsName := request.PathParameter("devops")
+	pipName := request.PathParameter("pipeline")
+	branch := request.QueryParameter("branch")
+
+	currentUser, ok := apiserverrequest.UserFrom(request.Request.Context())
+	if !ok || currentUser == nil || currentUser.GetName() == "" || currentUser.GetName() == user.Anonymous {
+		kapis.HandleUnauthorized(response, request, fmt.Errorf("unauthenticated user is not allowed to trigger Pipeline '%s/%s'", nsName, pipName))
+		return
+	}
+
+	payload := devops.RunPayload{}
+	if err := request.ReadEntity(&payload); err != nil && err != io.EOF {
+		kapis.HandleBadRequest(response, request, err)
+		return
+	}
+
+	if err := h.authorizeTrigger(request.Request.Context(), currentUser, nsName, pipName); err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+
+	// validate the Pipeline
+	var pipeline v1alpha3.Pipeline
+	if err := h.client.Get(context.Background(), client.ObjectKey{Namespace: nsName, Name: pipName}, &pipeline); err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+
+	scm, err := CreateScm(&pipeline.Spec, branch)
+	if err != nil {
+		kapis.HandleBadRequest(response, request, err)
+		return
+	}
+
+	pr := CreatePipelineRun(&pipeline, &payload, scm)
+	pr.Annotations[v1alpha3.PipelineRunCreatorAnnoKey] = currentUser.GetName()
+	pr.Annotations[v1alpha3.PipelineRunTriggeredByAnnoKey] = currentUser.GetName()
+	pr.SetTriggerIdentity(identity.FromUser(currentUser))
+	if err := h.client.Create(context.Background(), pr); err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+	_ = response.WriteHeaderAndEntity(http.StatusCreated, pr)
+}
+
+// authorizeTrigger checks if the user is able to create the subresource 'pipelines/runs' by a SubjectAccessReview
+func (h *apiHandler) authorizeTrigger(ctx context.Context, currentUser user.Info, namespace, pipeline string) error {
+	return h.authorize(ctx, currentUser, &authorizationv1.ResourceAttributes{
+		Namespace:   namespace,
+		Verb:        "create",
+		Group:       v1alpha3.GroupVersion.Group,
+		Resource:    "pipelines",
+		Subresource: "runs",
+		Name:        pipeline,
+	}, fmt.Sprintf("user '%s' is not allowed to trigger Pipeline '%s/%s'", currentUser.GetName(), namespace, pipeline))
+}
+
+// authorize checks if the user is able to access the resource by a SubjectAccessReview,
+// the message is returned along with the reason if it's not allowed
+func (h *apiHandler) authorize(ctx context.Context, currentUser user.Info, attributes *authorizationv1.ResourceAttributes,
+	message string) error {
+	if h.sarClient == nil {
+		return restful.NewError(http.StatusServiceUnavailable, "unable to authorize the request without kube-apiserver")
+	}
+
+	extra := make(map[string]authorizationv1.ExtraValue, len(currentUser.GetExtra()))
+	for key, value := range currentUser.GetExtra() {
+		extra[key] = value
+	}
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: attributes,
+			User:               currentUser.GetName(),
+			Groups:             currentUser.GetGroups(),
+			UID:                currentUser.GetUID(),
+			Extra:              extra,
+		},
+	}
+
+	result, err := h.sarClient.SubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	if !result.Status.Allowed {
+		if result.Status.Reason != "" {
+			message = fmt.Sprintf("%s: %s", message, result.Status.Reason)
+		}
+		return restful.NewError(http.StatusForbidden, message)
+	}
+	return nil
+}
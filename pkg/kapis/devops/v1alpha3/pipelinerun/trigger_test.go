@@ -0,0 +1,140 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelinerun
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/authentication/user"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	k8stesting "k8s.io/client-go/testing"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/apiserver/request"
+	"kubesphere.io/devops/pkg/apiserver/runtime"
+	fakedevops "kubesphere.io/devops/pkg/client/devops/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestTriggerPipelineRun(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	// only bob is allowed to trigger the Pipelines
+	clientset := k8sfake.NewSimpleClientset()
+	var review *authorizationv1.SubjectAccessReview
+	clientset.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		review = action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		result := review.DeepCopy()
+		result.Status.Allowed = review.Spec.User == "bob"
+		if !result.Status.Allowed {
+			result.Status.Reason = "no RBAC policy matched"
+		}
+		return true, result, nil
+	})
+
+	tests := []struct {
+		name       string
+		user       user.Info
+		uri        string
+		sarClient  authorizationv1client.SubjectAccessReviewsGetter
+		expectCode int
+		verify     func(t *testing.T, c client.Client)
+	}{{
+		name:       "anonymous user",
+		user:       &user.DefaultInfo{Name: user.Anonymous},
+		uri:        "/devops/fake/pipelines/fake/runs",
+		sarClient:  clientset.AuthorizationV1(),
+		expectCode: http.StatusUnauthorized,
+	}, {
+		name:       "without the permission",
+		user:       &user.DefaultInfo{Name: "alice"},
+		uri:        "/devops/fake/pipelines/fake/runs",
+		sarClient:  clientset.AuthorizationV1(),
+		expectCode: http.StatusForbidden,
+	}, {
+		name:       "without kube-apiserver",
+		user:       &user.DefaultInfo{Name: "bob"},
+		uri:        "/devops/fake/pipelines/fake/runs",
+		expectCode: http.StatusServiceUnavailable,
+	}, {
+		name:       "pipeline not found",
+		user:       &user.DefaultInfo{Name: "bob"},
+		uri:        "/devops/fake/pipelines/not-found/runs",
+		sarClient:  clientset.AuthorizationV1(),
+		expectCode: http.StatusNotFound,
+	}, {
+		name:       "trigger a PipelineRun",
+		user:       &user.DefaultInfo{Name: "bob", Groups: []string{"devops-operators"}},
+		uri:        "/devops/fake/pipelines/fake/runs",
+		sarClient:  clientset.AuthorizationV1(),
+		expectCode: http.StatusCreated,
+		verify: func(t *testing.T, c client.Client) {
+			attributes := review.Spec.ResourceAttributes
+			assert.Equal(t, "fake", attributes.Namespace)
+			assert.Equal(t, "pipelines", attributes.Resource)
+			assert.Equal(t, "runs", attributes.Subresource)
+			assert.Equal(t, "fake", attributes.Name)
+			assert.Equal(t, []string{"devops-operators"}, review.Spec.Groups)
+
+			prs := &v1alpha3.PipelineRunList{}
+			assert.Nil(t, c.List(context.TODO(), prs))
+			if assert.Equal(t, 1, len(prs.Items)) {
+				pr := prs.Items[0]
+				assert.Equal(t, "bob", pr.Annotations[v1alpha3.PipelineRunTriggeredByAnnoKey])
+				assert.Equal(t, "bob", pr.Annotations[v1alpha3.PipelineRunCreatorAnnoKey])
+				// the groups of bob are not trusted since the token was not verified
+				assert.Equal(t, &v1alpha3.TriggerIdentity{Username: "bob", Source: v1alpha3.TriggerIdentityUnverified},
+					pr.GetTriggerIdentity())
+				assert.Equal(t, []v1alpha3.Parameter{{Name: "name", Value: "value"}}, pr.Spec.Parameters)
+			}
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(&v1alpha3.Pipeline{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "fake", Name: "fake"},
+				Spec:       v1alpha3.PipelineSpec{Type: v1alpha3.NoScmPipelineType},
+			}).Build()
+			ws := runtime.NewWebService(v1alpha3.GroupVersion)
+			RegisterRoutes(ws, fakedevops.NewFakeDevops(nil), c, nil, nil, tt.sarClient)
+			container := restful.NewContainer()
+			container.Add(ws)
+
+			httpRequest, _ := http.NewRequestWithContext(request.WithUser(request.NewContext(), tt.user), http.MethodPost,
+				"http://fake.com/kapis/devops.kubesphere.io/v1alpha3"+tt.uri,
+				strings.NewReader(`{"parameters":[{"name":"name","value":"value"}]}`))
+			httpRequest.Header.Set("Content-Type", "application/json")
+			httpWriter := httptest.NewRecorder()
+			container.Dispatch(httpWriter, httpRequest)
+			assert.Equal(t, tt.expectCode, httpWriter.Code, httpWriter.Body.String())
+			if tt.verify != nil {
+				tt.verify(t, c)
+			}
+		})
+	}
+}
@@ -33,6 +33,9 @@ import (
 
 func buildLabelSelector(queryParam *query.Query, pipelineName string) (labels.Selector, error) {
 	labelSelector := queryParam.Selector()
+	if pipelineName == "" {
+		return labelSelector, nil
+	}
 	rq, err := labels.NewRequirement(v1alpha3.PipelineNameLabelKey, selection.Equals, []string{pipelineName})
 	if err != nil {
 		// should never happen
@@ -120,5 +123,9 @@ func CreateBarePipelineRun(pipeline *v1alpha3.Pipeline, parameters []v1alpha3.Pa
 			SCM:          scm,
 		},
 	}
+	// the PipelineRun should be handled by the same backend as its Pipeline
+	if backendType, ok := pipeline.GetLabels()[v1alpha3.PipelineBackendLabelKey]; ok {
+		pipelineRun.Labels[v1alpha3.PipelineBackendLabelKey] = backendType
+	}
 	return pipelineRun
 }
@@ -64,6 +64,14 @@ func Test_buildLabelSelector(t *testing.T) {
 			pipelineName: "pipelineA",
 		},
 		want: parseSelector(fmt.Sprintf("%s=pipelineA,a=b", v1alpha3.PipelineNameLabelKey)),
+	}, {
+		name: "Without the pipeline name",
+		args: args{
+			queryParam: &query.Query{
+				LabelSelector: "a=b",
+			},
+		},
+		want: parseSelector("a=b"),
 	}}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -226,4 +234,9 @@ func TestCreatePipelineRun(t *testing.T) {
 	assert.Equal(t, pipelineRun.GenerateName, pipeline.Name+"-")
 	assert.Equal(t, pipelineRun.Namespace, pipeline.Namespace)
 	assert.NotNil(t, pipelineRun.Annotations)
+	assert.NotContains(t, pipelineRun.Labels, v1alpha3.PipelineBackendLabelKey)
+
+	pipeline.SetLabels(map[string]string{v1alpha3.PipelineBackendLabelKey: "Tekton"})
+	pipelineRun = CreatePipelineRun(pipeline, nil, nil)
+	assert.Equal(t, "Tekton", pipelineRun.Labels[v1alpha3.PipelineBackendLabelKey])
 }
@@ -0,0 +1,127 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package promotion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/apiserver/authentication/identity"
+	apiserverrequest "kubesphere.io/devops/pkg/apiserver/request"
+	"kubesphere.io/devops/pkg/kapis"
+)
+
+type handler struct {
+	client    client.Client
+	sarClient authorizationv1client.SubjectAccessReviewsGetter
+}
+
+func (h *handler) promote(req *restful.Request, resp *restful.Response) {
+	ctx := req.Request.Context()
+	namespace, name := req.PathParameter("namespace"), req.PathParameter("pipelinerun")
+	currentUser, ok := apiserverrequest.UserFrom(ctx)
+	if !ok || currentUser == nil || currentUser.GetName() == "" || currentUser.GetName() == user.Anonymous {
+		kapis.HandleUnauthorized(resp, req, fmt.Errorf("unauthenticated user is not allowed to promote PipelineRun '%s/%s'", namespace, name))
+		return
+	}
+
+	source := &v1alpha3.PipelineRun{}
+	if err := h.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, source); err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	target, err := GetTargetEnvironment(ctx, h.client, source)
+	switch {
+	case errors.Is(err, ErrAlreadyPromoted):
+		kapis.HandleConflict(resp, req, err)
+		return
+	case errors.Is(err, ErrNotPromotable):
+		kapis.HandleBadRequest(resp, req, err)
+		return
+	case err != nil:
+		kapis.HandleError(req, resp, err)
+		return
+	}
+
+	if err = h.authorize(ctx, currentUser, target); err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+
+	run, err := Promote(ctx, h.client, source, target, identity.FromUser(currentUser))
+	if err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	_ = resp.WriteHeaderAndEntity(http.StatusCreated, run)
+}
+
+// authorize checks if the user is one of the approvers of the target Environment. If there are no approvers,
+// it checks if the user is able to trigger the deployment Pipeline of the Environment by a SubjectAccessReview.
+func (h *handler) authorize(ctx context.Context, currentUser user.Info, target *v1alpha3.Environment) error {
+	if len(target.Spec.Promotion.Approvers) > 0 {
+		if target.IsApprover(currentUser.GetName()) {
+			return nil
+		}
+		return restful.NewError(http.StatusForbidden, fmt.Sprintf("user '%s' is not an approver of Environment '%s'",
+			currentUser.GetName(), target.Name))
+	}
+
+	if h.sarClient == nil {
+		return restful.NewError(http.StatusServiceUnavailable, "unable to authorize the request without kube-apiserver")
+	}
+	extra := make(map[string]authorizationv1.ExtraValue, len(currentUser.GetExtra()))
+	for key, value := range currentUser.GetExtra() {
+		extra[key] = value
+	}
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace:   target.Namespace,
+				Verb:        "create",
+				Group:       v1alpha3.GroupVersion.Group,
+				Resource:    "pipelines",
+				Subresource: "runs",
+				Name:        target.Spec.PipelineRef,
+			},
+			User:   currentUser.GetName(),
+			Groups: currentUser.GetGroups(),
+			UID:    currentUser.GetUID(),
+			Extra:  extra,
+		},
+	}
+
+	result, err := h.sarClient.SubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	if !result.Status.Allowed {
+		return restful.NewError(http.StatusForbidden, fmt.Sprintf("user '%s' is not allowed to promote to Environment '%s'",
+			currentUser.GetName(), target.Name))
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package promotion
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/authentication/user"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/apiserver/request"
+	"kubesphere.io/devops/pkg/apiserver/runtime"
+)
+
+func TestPromote(t *testing.T) {
+	schema := k8sruntime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	// only bob is allowed to trigger the Pipelines
+	clientset := k8sfake.NewSimpleClientset()
+	clientset.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		result := review.DeepCopy()
+		result.Status.Allowed = review.Spec.User == "bob"
+		return true, result, nil
+	})
+
+	newEnv := func(name, next string, approvers ...string) *v1alpha3.Environment {
+		return &v1alpha3.Environment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name},
+			Spec: v1alpha3.EnvironmentSpec{
+				PipelineRef: "deploy-" + name,
+				Parameters:  []v1alpha3.Parameter{{Name: "env", Value: name}},
+				Next:        next,
+				Promotion:   v1alpha3.EnvironmentPromotionPolicy{Approvers: approvers},
+			},
+		}
+	}
+	newPipeline := func(name string) *v1alpha3.Pipeline {
+		return &v1alpha3.Pipeline{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name},
+			Spec:       v1alpha3.PipelineSpec{Type: v1alpha3.NoScmPipelineType, Pipeline: &v1alpha3.NoScmPipeline{Jenkinsfile: "pipeline {}"}},
+		}
+	}
+	newRun := func(env string, phase v1alpha3.RunPhase, annotations map[string]string) *v1alpha3.PipelineRun {
+		now := metav1.Now()
+		return &v1alpha3.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "ns",
+				Name:        "run",
+				Labels:      map[string]string{v1alpha3.EnvironmentLabelKey: env},
+				Annotations: annotations,
+			},
+			Status: v1alpha3.PipelineRunStatus{Phase: phase, CompletionTime: &now},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		user       string
+		objects    []client.Object
+		expectCode int
+		verify     func(t *testing.T, c client.Client)
+	}{{
+		name:       "anonymous user",
+		user:       user.Anonymous,
+		expectCode: http.StatusUnauthorized,
+	}, {
+		name:       "PipelineRun not found",
+		user:       "bob",
+		expectCode: http.StatusNotFound,
+	}, {
+		name:       "failed PipelineRun",
+		user:       "bob",
+		objects:    []client.Object{newRun("dev", v1alpha3.Failed, nil), newEnv("dev", "prod"), newEnv("prod", "")},
+		expectCode: http.StatusBadRequest,
+	}, {
+		name:       "the last Environment",
+		user:       "bob",
+		objects:    []client.Object{newRun("prod", v1alpha3.Succeeded, nil), newEnv("prod", "")},
+		expectCode: http.StatusBadRequest,
+	}, {
+		name: "promoted already",
+		user: "bob",
+		objects: []client.Object{newRun("dev", v1alpha3.Succeeded, map[string]string{v1alpha3.PromotedToAnnoKey: "deploy-prod-1"}),
+			newEnv("dev", "prod"), newEnv("prod", "")},
+		expectCode: http.StatusConflict,
+	}, {
+		name:       "not allowed to trigger the Pipeline",
+		user:       "alice",
+		objects:    []client.Object{newRun("dev", v1alpha3.Succeeded, nil), newEnv("dev", "prod"), newEnv("prod", "")},
+		expectCode: http.StatusForbidden,
+	}, {
+		name:       "not an approver",
+		user:       "bob",
+		objects:    []client.Object{newRun("dev", v1alpha3.Succeeded, nil), newEnv("dev", "prod"), newEnv("prod", "", "alice")},
+		expectCode: http.StatusForbidden,
+	}, {
+		name: "promoted by an approver",
+		user: "alice",
+		objects: []client.Object{
+			newRun("staging", v1alpha3.Succeeded, map[string]string{v1alpha3.PromotionChainAnnoKey: "build"}),
+			newEnv("staging", "prod"), newEnv("prod", "", "alice"), newPipeline("deploy-prod"),
+		},
+		expectCode: http.StatusCreated,
+		verify: func(t *testing.T, c client.Client) {
+			source := &v1alpha3.PipelineRun{}
+			assert.Nil(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "ns", Name: "run"}, source))
+			promotedTo := source.Annotations[v1alpha3.PromotedToAnnoKey]
+			assert.NotEmpty(t, promotedTo)
+
+			run := &v1alpha3.PipelineRun{}
+			assert.Nil(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "ns", Name: promotedTo}, run))
+			assert.Equal(t, "prod", run.Labels[v1alpha3.EnvironmentLabelKey])
+			assert.Equal(t, "run", run.Annotations[v1alpha3.PromotedFromAnnoKey])
+			assert.Equal(t, "alice", run.Annotations[v1alpha3.PipelineRunTriggeredByAnnoKey])
+			assert.Equal(t, "alice", run.GetTriggerIdentity().Username)
+			assert.Equal(t, []string{"build", "run"}, GetChain(run))
+			assert.Equal(t, []v1alpha3.Parameter{{Name: "env", Value: "prod"}}, run.Spec.Parameters)
+
+			env := &v1alpha3.Environment{}
+			assert.Nil(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "ns", Name: "prod"}, env))
+			if assert.Len(t, env.Status.Promotions, 1) {
+				record := env.Status.Promotions[0]
+				assert.Equal(t, promotedTo, record.PipelineRun)
+				assert.Equal(t, "run", record.Source)
+				assert.Equal(t, "staging", record.SourceEnvironment)
+				assert.Equal(t, "alice", record.PromotedBy)
+				assert.Equal(t, []string{"build", "run"}, record.Chain)
+			}
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(tt.objects...).Build()
+			ws := runtime.NewWebService(v1alpha3.GroupVersion)
+			RegisterRoutes(ws, c, clientset.AuthorizationV1())
+			container := restful.NewContainer()
+			container.Add(ws)
+
+			httpRequest, _ := http.NewRequestWithContext(request.WithUser(request.NewContext(), &user.DefaultInfo{Name: tt.user}),
+				http.MethodPost, "http://fake.com/kapis/devops.kubesphere.io/v1alpha3/namespaces/ns/pipelineruns/run/promote", nil)
+			httpWriter := httptest.NewRecorder()
+			container.Dispatch(httpWriter, httpRequest)
+			assert.Equal(t, tt.expectCode, httpWriter.Code, httpWriter.Body.String())
+			if tt.verify != nil {
+				tt.verify(t, c)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package promotion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/pipelinerun"
+)
+
+// maxPromotionRecords is the number of the promotions kept in the status of an Environment
+const maxPromotionRecords = 10
+
+var (
+	// ErrNotPromotable indicates that the PipelineRun could not be promoted to another Environment
+	ErrNotPromotable = errors.New("the PipelineRun is not promotable")
+	// ErrAlreadyPromoted indicates that the PipelineRun was promoted already
+	ErrAlreadyPromoted = errors.New("the PipelineRun was promoted already")
+)
+
+// GetTargetEnvironment returns the Environment which the PipelineRun could be promoted to.
+// Only the succeeded PipelineRuns of an Environment which has the next one are promotable.
+func GetTargetEnvironment(ctx context.Context, c client.Client, source *v1alpha3.PipelineRun) (*v1alpha3.Environment, error) {
+	if promotedTo, ok := source.Annotations[v1alpha3.PromotedToAnnoKey]; ok {
+		return nil, fmt.Errorf("%w to PipelineRun %s", ErrAlreadyPromoted, promotedTo)
+	}
+	if !source.HasCompleted() || source.Status.Phase != v1alpha3.Succeeded {
+		return nil, fmt.Errorf("%w: it has not succeeded", ErrNotPromotable)
+	}
+	envName, ok := source.Labels[v1alpha3.EnvironmentLabelKey]
+	if !ok {
+		return nil, fmt.Errorf("%w: it does not belong to any Environment", ErrNotPromotable)
+	}
+
+	env := &v1alpha3.Environment{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: source.Namespace, Name: envName}, env); err != nil {
+		return nil, err
+	}
+	if env.Spec.Next == "" {
+		return nil, fmt.Errorf("%w: Environment %s is the last one", ErrNotPromotable, envName)
+	}
+
+	target := &v1alpha3.Environment{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: source.Namespace, Name: env.Spec.Next}, target); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// Promote triggers the deployment Pipeline of the target Environment for the source PipelineRun,
+// then records the promotion in both the source PipelineRun and the target Environment.
+// The promoter is nil if it's promoted automatically.
+func Promote(ctx context.Context, c client.Client, source *v1alpha3.PipelineRun, target *v1alpha3.Environment,
+	promoter *v1alpha3.TriggerIdentity) (*v1alpha3.PipelineRun, error) {
+	pipeline := &v1alpha3.Pipeline{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: target.Namespace, Name: target.Spec.PipelineRef}, pipeline); err != nil {
+		return nil, err
+	}
+
+	chain := append(GetChain(source), source.Name)
+	run := pipelinerun.CreateBarePipelineRun(pipeline, target.Spec.Parameters, target.Spec.SCM)
+	run.Labels[v1alpha3.EnvironmentLabelKey] = target.Name
+	run.Annotations[v1alpha3.PromotedFromAnnoKey] = source.Name
+	run.Annotations[v1alpha3.PromotionChainAnnoKey] = strings.Join(chain, ",")
+	var promotedBy string
+	if promoter != nil {
+		promotedBy = promoter.Username
+		run.Annotations[v1alpha3.PipelineRunCreatorAnnoKey] = promotedBy
+		run.Annotations[v1alpha3.PipelineRunTriggeredByAnnoKey] = promotedBy
+		run.SetTriggerIdentity(promoter)
+	}
+	if err := c.Create(ctx, run); err != nil {
+		return nil, err
+	}
+
+	// mark the source as promoted first, it prevents the source from being promoted twice
+	if source.Annotations == nil {
+		source.Annotations = map[string]string{}
+	}
+	source.Annotations[v1alpha3.PromotedToAnnoKey] = run.Name
+	if err := c.Update(ctx, source); err != nil {
+		return nil, err
+	}
+
+	record := v1alpha3.PromotionRecord{
+		PipelineRun:       run.Name,
+		Source:            source.Name,
+		SourceEnvironment: source.Labels[v1alpha3.EnvironmentLabelKey],
+		PromotedBy:        promotedBy,
+		Chain:             chain,
+		Time:              metav1.Now(),
+	}
+	target.Status.Promotions = append([]v1alpha3.PromotionRecord{record}, target.Status.Promotions...)
+	if len(target.Status.Promotions) > maxPromotionRecords {
+		target.Status.Promotions = target.Status.Promotions[:maxPromotionRecords]
+	}
+	return run, c.Status().Update(ctx, target)
+}
+
+// GetChain returns the PipelineRuns promoted one by one before the PipelineRun, the first one comes first
+func GetChain(run *v1alpha3.PipelineRun) []string {
+	chain, ok := run.Annotations[v1alpha3.PromotionChainAnnoKey]
+	if !ok || chain == "" {
+		return nil
+	}
+	return strings.Split(chain, ",")
+}
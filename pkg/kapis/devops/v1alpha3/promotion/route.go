@@ -0,0 +1,44 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package promotion
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/constants"
+)
+
+// RegisterRoutes registers the APIs of promoting the PipelineRuns between the Environments
+func RegisterRoutes(service *restful.WebService, genericClient client.Client,
+	sarClient authorizationv1client.SubjectAccessReviewsGetter) {
+	h := &handler{client: genericClient, sarClient: sarClient}
+	service.Route(service.POST("/namespaces/{namespace}/pipelineruns/{pipelinerun}/promote").
+		To(h.promote).
+		Param(service.PathParameter("namespace", "Namespace of the PipelineRun")).
+		Param(service.PathParameter("pipelinerun", "Name of the succeeded PipelineRun")).
+		Doc("Promote a succeeded PipelineRun to the next Environment, which triggers the deployment Pipeline of it. "+
+			"The current user needs to be one of the approvers of the next Environment, or be allowed to trigger "+
+			"the deployment Pipeline if there are no approvers").
+		Returns(http.StatusCreated, http.StatusText(http.StatusCreated), v1alpha3.PipelineRun{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsPipelineTag}))
+}
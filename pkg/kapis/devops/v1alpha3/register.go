@@ -28,13 +28,27 @@ import (
 	restfulspec "github.com/emicklei/go-restful-openapi"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
 	"kubesphere.io/devops/pkg/client/k8s"
+	"kubesphere.io/devops/pkg/client/s3"
+	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/artifactrepository"
+	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/audit"
+	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/backup"
 	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/common"
+	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/dora"
+	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/history"
 	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/pipeline"
 	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/pipelinerun"
+	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/promotion"
+	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/registry"
+	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/release"
 	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/scm"
+	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/switchover"
 	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/template"
+	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/testreport"
+	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/usage"
 	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -42,7 +56,11 @@ import (
 	"kubesphere.io/devops/pkg/apiserver/query"
 	"kubesphere.io/devops/pkg/apiserver/runtime"
 	devopsClient "kubesphere.io/devops/pkg/client/devops"
+	"kubesphere.io/devops/pkg/client/devops/jenkins"
+	historyclient "kubesphere.io/devops/pkg/client/history"
 	"kubesphere.io/devops/pkg/constants"
+	auditmodel "kubesphere.io/devops/pkg/models/audit"
+	webhookmodel "kubesphere.io/devops/pkg/models/webhook"
 	"kubesphere.io/devops/pkg/server/params"
 )
 
@@ -50,13 +68,24 @@ import (
 //+kubebuilder:rbac:groups=devops.kubesphere.io,resources=devopsprojects,verbs=get;list;update;delete;create;watch
 //+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelines,verbs=get;list;update;delete;create;watch
 //+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns,verbs=get;list;update;delete;create;watch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=environments,verbs=get;list;watch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=environments/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=releases,verbs=get;list;create
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=artifactrepositories,verbs=get;list;watch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=triggers,verbs=get;list;watch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=triggers/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups="",resources=pods;pods/log,verbs=get;list
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list
 
 // GroupVersion describes CRD group and its version.
 var GroupVersion = schema.GroupVersion{Group: api.GroupName, Version: "v1alpha3"}
 
 // AddToContainer adds web service into container.
 func AddToContainer(container *restful.Container, devopsClient devopsClient.Interface, k8sClient k8s.Client,
-	client client.Client, tokenIssue token.Issuer, jenkins core.JenkinsCore) (wss []*restful.WebService) {
+	s3Client s3.Interface, client client.Client, tokenIssue token.Issuer, jenkins core.JenkinsCore,
+	auditStore auditmodel.Interface, historyStore historyclient.Interface, deliveryStore webhookmodel.Interface,
+	jenkinsOptions *jenkins.Options) (wss []*restful.WebService) {
 
 	services := []*restful.WebService{
 		runtime.NewWebService(v1alpha3.GroupVersion),
@@ -65,15 +94,36 @@ func AddToContainer(container *restful.Container, devopsClient devopsClient.Inte
 
 	for _, service := range services {
 		registerRoutes(devopsClient, k8sClient, client, service)
-		pipelinerun.RegisterRoutes(service, devopsClient, client)
-		pipeline.RegisterRoutes(service, client)
+		var podClient corev1client.PodsGetter
+		var sarClient authorizationv1client.SubjectAccessReviewsGetter
+		if k8sClient != nil {
+			podClient = k8sClient.Kubernetes().CoreV1()
+			sarClient = k8sClient.Kubernetes().AuthorizationV1()
+		}
+		pipelinerun.RegisterRoutes(service, devopsClient, client, podClient, s3Client, sarClient)
+		var jenkinsfileValidator pipeline.JenkinsfileValidator
+		if validator, ok := devopsClient.(pipeline.JenkinsfileValidator); ok {
+			jenkinsfileValidator = validator
+		}
+		pipeline.RegisterRoutes(service, client, historyStore, jenkinsfileValidator, devopsClient)
 		template.RegisterRoutes(service, &common.Options{
 			GenericClient: client,
 		})
 		steptemplate.RegisterRoutes(service, &common.Options{
 			GenericClient: client,
 		})
-		webhook.RegisterWebhooks(client, service, tokenIssue, jenkins)
+		webhook.RegisterWebhooks(client, service, tokenIssue, jenkins, deliveryStore)
+		audit.RegisterRoutes(service, auditStore)
+		history.RegisterRoutes(service, historyStore)
+		dora.RegisterRoutes(service, client, historyStore)
+		usage.RegisterRoutes(service, client)
+		switchover.RegisterRoutes(service, client, sarClient, jenkinsOptions)
+		promotion.RegisterRoutes(service, client, sarClient)
+		release.RegisterRoutes(service, client)
+		registry.RegisterRoutes(service, client)
+		artifactrepository.RegisterRoutes(service, client)
+		testreport.RegisterRoutes(service, client, s3Client)
+		backup.RegisterRoutes(service, client, s3Client, historyStore)
 		container.Add(service)
 	}
 	return services
@@ -24,6 +24,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/emicklei/go-restful"
 	"github.com/stretchr/testify/assert"
@@ -32,10 +33,13 @@ import (
 	k8sfake "k8s.io/client-go/kubernetes/fake"
 	"kubesphere.io/devops/pkg/api/devops/v1alpha1"
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/cache"
 	fakeclientset "kubesphere.io/devops/pkg/client/clientset/versioned/fake"
 	fakedevops "kubesphere.io/devops/pkg/client/devops/fake"
+	fakehistory "kubesphere.io/devops/pkg/client/history/fake"
 	"kubesphere.io/devops/pkg/client/k8s"
 	"kubesphere.io/devops/pkg/constants"
+	"kubesphere.io/devops/pkg/models/audit"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -45,6 +49,8 @@ func TestAPIsExist(t *testing.T) {
 
 	err = v1.SchemeBuilder.AddToScheme(schema)
 	assert.Nil(t, err)
+	err = v1alpha3.AddToScheme(schema)
+	assert.Nil(t, err)
 
 	container := restful.NewContainer()
 	AddToContainer(container, fakedevops.NewFakeDevops(nil), k8s.NewFakeClientSets(k8sfake.NewSimpleClientset(&v1.Secret{
@@ -57,11 +63,12 @@ func TestAPIsExist(t *testing.T) {
 			Status:     v1alpha3.DevOpsProjectStatus{AdminNamespace: "fake"},
 		}, &v1alpha3.Pipeline{
 			ObjectMeta: metav1.ObjectMeta{Namespace: "fake", Name: "fake"},
-		})), fake.NewFakeClientWithScheme(schema, &v1.Secret{
+		})), nil, fake.NewFakeClientWithScheme(schema, &v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: "fake", Namespace: "fake",
 		},
-	}), &token.FakeIssuer{}, core.JenkinsCore{})
+	}), &token.FakeIssuer{}, core.JenkinsCore{}, audit.NewCacheStore(cache.NewSimpleCache(), time.Hour),
+		fakehistory.NewStore(), nil, nil)
 
 	type args struct {
 		method string
@@ -238,6 +245,58 @@ func TestAPIsExist(t *testing.T) {
 			uri:    "/ci/nodelabels",
 		},
 		expectCode: http.StatusBadRequest,
+	}, {
+		name: "list audit events",
+		args: args{
+			method: http.MethodGet,
+			uri:    "/audit?project=fake&since=2022-01-01T00:00:00Z",
+		},
+	}, {
+		name: "list audit events with invalid time",
+		args: args{
+			method: http.MethodGet,
+			uri:    "/audit?since=yesterday",
+		},
+		expectCode: http.StatusBadRequest,
+	}, {
+		name: "list PipelineRun histories",
+		args: args{
+			method: http.MethodGet,
+			uri:    "/namespaces/fake/pipelinerunhistories?pipeline=fake&phase=Succeeded&page=1&limit=10",
+		},
+	}, {
+		name: "list PipelineRun histories with invalid time",
+		args: args{
+			method: http.MethodGet,
+			uri:    "/namespaces/fake/pipelinerunhistories?until=tomorrow",
+		},
+		expectCode: http.StatusBadRequest,
+	}, {
+		name: "get DORA metrics",
+		args: args{
+			method: http.MethodGet,
+			uri:    "/devops/fake/metrics/dora",
+		},
+	}, {
+		name: "get DORA metrics with invalid period",
+		args: args{
+			method: http.MethodGet,
+			uri:    "/devops/fake/metrics/dora?since=2022-01-02T00:00:00Z&until=2022-01-01T00:00:00Z",
+		},
+		expectCode: http.StatusBadRequest,
+	}, {
+		name: "get resource usage",
+		args: args{
+			method: http.MethodGet,
+			uri:    "/devops/fake/metrics/usage",
+		},
+	}, {
+		name: "get resource usage with invalid time",
+		args: args{
+			method: http.MethodGet,
+			uri:    "/devops/fake/metrics/usage?since=yesterday",
+		},
+		expectCode: http.StatusBadRequest,
 	}}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -271,7 +330,7 @@ func TestGetDevOpsProject(t *testing.T) {
 					constants.WorkspaceLabelKey: "ws",
 				},
 			},
-		})), fake.NewFakeClientWithScheme(schema), &token.FakeIssuer{}, core.JenkinsCore{})
+		})), nil, fake.NewFakeClientWithScheme(schema), &token.FakeIssuer{}, core.JenkinsCore{}, nil, nil, nil, nil)
 
 	type args struct {
 		method string
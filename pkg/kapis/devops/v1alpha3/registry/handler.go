@@ -0,0 +1,153 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/client/registry"
+	"kubesphere.io/devops/pkg/kapis"
+)
+
+// registryFactory creates the client of a registry, the type is empty if it should be detected
+type registryFactory func(ctx context.Context, registryType registry.Type, server string, credential *registry.Credential) registry.Interface
+
+type handler struct {
+	client      client.Client
+	newRegistry registryFactory
+}
+
+// TagList is the tags of an image
+type TagList struct {
+	Image string   `json:"image"`
+	Tags  []string `json:"tags"`
+}
+
+// ImageDigest is the digest of an image and its link on the web portal of the registry
+type ImageDigest struct {
+	Image  string `json:"image"`
+	Tag    string `json:"tag,omitempty"`
+	Digest string `json:"digest"`
+	URL    string `json:"url,omitempty"`
+}
+
+func newRegistry(ctx context.Context, registryType registry.Type, server string, credential *registry.Credential) registry.Interface {
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	if registryType == "" {
+		registryType = registry.DetectType(ctx, server, httpClient)
+	}
+	return registry.NewClient(registryType, server, credential, httpClient)
+}
+
+func (h *handler) listTags(req *restful.Request, resp *restful.Response) {
+	ref, registryClient, err := h.getRegistry(req)
+	if err != nil {
+		kapis.HandleBadRequest(resp, req, err)
+		return
+	}
+	tags, err := registryClient.ListTags(req.Request.Context(), ref.Repository)
+	if err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	_ = resp.WriteEntity(TagList{Image: ref.Name(), Tags: tags})
+}
+
+func (h *handler) getDigest(req *restful.Request, resp *restful.Response) {
+	ctx := req.Request.Context()
+	ref, registryClient, err := h.getRegistry(req)
+	if err != nil {
+		kapis.HandleBadRequest(resp, req, err)
+		return
+	}
+	digest, err := registryClient.GetDigest(ctx, ref.Repository, ref.Identifier())
+	if err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	result := ImageDigest{Image: ref.Name(), Tag: ref.Tag, Digest: digest}
+	// the link is optional, not all registries have web portals
+	result.URL, _ = registryClient.GetWebURL(ctx, ref.Repository, digest)
+	_ = resp.WriteEntity(result)
+}
+
+func (h *handler) getVulnerabilities(req *restful.Request, resp *restful.Response) {
+	ref, registryClient, err := h.getRegistry(req)
+	if err != nil {
+		kapis.HandleBadRequest(resp, req, err)
+		return
+	}
+	report, err := registryClient.GetVulnerabilities(req.Request.Context(), ref.Repository, ref.Identifier())
+	switch {
+	case errors.Is(err, registry.ErrNotSupported):
+		_ = resp.WriteError(http.StatusNotImplemented, restful.NewError(http.StatusNotImplemented,
+			fmt.Sprintf("the %s registry does not provide the vulnerabilities", registryClient.Type())))
+	case err != nil:
+		kapis.HandleError(req, resp, err)
+	case report == nil:
+		kapis.HandleNotFound(resp, req, fmt.Errorf("%s has not been scanned", ref.Name()))
+	default:
+		_ = resp.WriteEntity(report)
+	}
+}
+
+// getRegistry parses the image, then creates the registry client with the credential from the secret.
+// The credential is searched from the docker config secrets of the namespace if the secret is not specified.
+func (h *handler) getRegistry(req *restful.Request) (ref *registry.Reference, registryClient registry.Interface, err error) {
+	ctx := req.Request.Context()
+	namespace := req.PathParameter("namespace")
+	if ref, err = registry.ParseReference(req.QueryParameter("image")); err != nil {
+		return
+	}
+
+	var secrets []v1.Secret
+	if secretName := req.QueryParameter("secret"); secretName != "" {
+		secret := v1.Secret{}
+		if err = h.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: secretName}, &secret); err != nil {
+			return
+		}
+		secrets = append(secrets, secret)
+	} else {
+		secretList := &v1.SecretList{}
+		if err = h.client.List(ctx, secretList, client.InNamespace(namespace)); err != nil {
+			return
+		}
+		for _, secret := range secretList.Items {
+			if secret.Type == v1.SecretTypeDockerConfigJson || secret.Type == v1.SecretTypeDockercfg {
+				secrets = append(secrets, secret)
+			}
+		}
+	}
+
+	registryType := registry.Type(req.QueryParameter("type"))
+	switch registryType {
+	case "", registry.Harbor, registry.DockerHub, registry.OCI:
+	default:
+		err = fmt.Errorf("unsupported registry type %q", registryType)
+		return
+	}
+	registryClient = h.newRegistry(ctx, registryType, ref.Registry, registry.FindCredential(secrets, ref.Registry))
+	return
+}
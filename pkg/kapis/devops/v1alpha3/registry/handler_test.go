@@ -0,0 +1,203 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	kapisruntime "kubesphere.io/devops/pkg/apiserver/runtime"
+	"kubesphere.io/devops/pkg/client/registry"
+)
+
+type fakeRegistry struct {
+	registry.Interface
+	registryType registry.Type
+}
+
+func (f *fakeRegistry) Type() registry.Type {
+	return f.registryType
+}
+
+func (f *fakeRegistry) ListTags(_ context.Context, repository string) ([]string, error) {
+	if repository == "devops/missing" {
+		return nil, fmt.Errorf("not found")
+	}
+	return []string{"v1", "v2"}, nil
+}
+
+func (f *fakeRegistry) GetDigest(_ context.Context, _, reference string) (string, error) {
+	return "sha256:" + reference, nil
+}
+
+func (f *fakeRegistry) GetWebURL(_ context.Context, repository, reference string) (string, error) {
+	if f.registryType != registry.Harbor {
+		return "", registry.ErrNotSupported
+	}
+	return "https://harbor.example.com/" + repository + "/" + reference, nil
+}
+
+func (f *fakeRegistry) GetVulnerabilities(_ context.Context, _, reference string) (*registry.VulnerabilityReport, error) {
+	switch {
+	case f.registryType != registry.Harbor:
+		return nil, registry.ErrNotSupported
+	case reference == "unscanned":
+		return nil, nil
+	}
+	return &registry.VulnerabilityReport{Scanner: "Trivy", Severity: "High", Total: 1}, nil
+}
+
+func TestHandler(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1.AddToScheme(schema))
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "harbor"},
+		Type:       v1.SecretTypeBasicAuth,
+		Data:       map[string][]byte{v1.BasicAuthUsernameKey: []byte("admin"), v1.BasicAuthPasswordKey: []byte("secret")},
+	}
+	dockerConfig := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "docker-config"},
+		Type:       v1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			v1.DockerConfigJsonKey: []byte(`{"auths":{"harbor.example.com":{"username":"robot","password":"token"}}}`),
+		},
+	}
+
+	tests := []struct {
+		name             string
+		path             string
+		query            url.Values
+		expectCode       int
+		expectBody       string
+		expectType       registry.Type
+		expectCredential *registry.Credential
+	}{{
+		name:       "invalid image",
+		path:       "tags",
+		query:      url.Values{"image": {""}},
+		expectCode: http.StatusBadRequest,
+	}, {
+		name:       "invalid type",
+		path:       "tags",
+		query:      url.Values{"image": {"nginx"}, "type": {"quay"}},
+		expectCode: http.StatusBadRequest,
+	}, {
+		name:       "secret not found",
+		path:       "tags",
+		query:      url.Values{"image": {"nginx"}, "secret": {"missing"}},
+		expectCode: http.StatusBadRequest,
+	}, {
+		name:             "list tags with the docker config of the namespace",
+		path:             "tags",
+		query:            url.Values{"image": {"harbor.example.com/devops/app"}},
+		expectCode:       http.StatusOK,
+		expectBody:       `{"image":"harbor.example.com/devops/app","tags":["v1","v2"]}`,
+		expectCredential: &registry.Credential{Username: "robot", Password: "token"},
+	}, {
+		name:       "failed to list tags",
+		path:       "tags",
+		query:      url.Values{"image": {"harbor.example.com/devops/missing"}},
+		expectCode: http.StatusInternalServerError,
+	}, {
+		name:             "digest with the link",
+		path:             "digest",
+		query:            url.Values{"image": {"harbor.example.com/devops/app:v1"}, "type": {"harbor"}, "secret": {"harbor"}},
+		expectCode:       http.StatusOK,
+		expectBody:       `{"image":"harbor.example.com/devops/app","tag":"v1","digest":"sha256:v1","url":"https://harbor.example.com/devops/app/sha256:v1"}`,
+		expectType:       registry.Harbor,
+		expectCredential: &registry.Credential{Username: "admin", Password: "secret"},
+	}, {
+		name:       "digest without the link",
+		path:       "digest",
+		query:      url.Values{"image": {"nginx:1.21"}, "type": {"oci"}},
+		expectCode: http.StatusOK,
+		expectBody: `{"image":"docker.io/library/nginx","tag":"1.21","digest":"sha256:1.21"}`,
+		expectType: registry.OCI,
+	}, {
+		name:             "vulnerabilities",
+		path:             "vulnerabilities",
+		query:            url.Values{"image": {"harbor.example.com/devops/app:v1"}, "type": {"harbor"}},
+		expectCode:       http.StatusOK,
+		expectBody:       `{"scanner":"Trivy","severity":"High","total":1,"fixable":0}`,
+		expectType:       registry.Harbor,
+		expectCredential: &registry.Credential{Username: "robot", Password: "token"},
+	}, {
+		name:       "not scanned",
+		path:       "vulnerabilities",
+		query:      url.Values{"image": {"harbor.example.com/devops/app:unscanned"}, "type": {"harbor"}},
+		expectCode: http.StatusNotFound,
+	}, {
+		name:       "vulnerabilities are not supported",
+		path:       "vulnerabilities",
+		query:      url.Values{"image": {"registry.example.com/devops/app:v1"}, "type": {"oci"}},
+		expectCode: http.StatusNotImplemented,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(secret, dockerConfig).Build()
+			var gotType registry.Type
+			var gotCredential *registry.Credential
+			h := &handler{client: c, newRegistry: func(_ context.Context, registryType registry.Type, _ string, credential *registry.Credential) registry.Interface {
+				gotType, gotCredential = registryType, credential
+				return &fakeRegistry{registryType: registryType}
+			}}
+			ws := kapisruntime.NewWebService(v1alpha3.GroupVersion)
+			registerRoutes(ws, h)
+			container := restful.NewContainer()
+			container.Add(ws)
+
+			httpRequest, _ := http.NewRequest(http.MethodGet, "http://fake.com/kapis/devops.kubesphere.io/v1alpha3/namespaces/ns/registry/"+
+				tt.path+"?"+tt.query.Encode(), nil)
+			httpWriter := httptest.NewRecorder()
+			container.Dispatch(httpWriter, httpRequest)
+			assert.Equal(t, tt.expectCode, httpWriter.Code, httpWriter.Body.String())
+			if tt.expectBody != "" {
+				assert.JSONEq(t, tt.expectBody, httpWriter.Body.String())
+			}
+			if tt.expectCode == http.StatusOK {
+				assert.Equal(t, tt.expectType, gotType)
+				assert.Equal(t, tt.expectCredential, gotCredential)
+			}
+		})
+	}
+}
+
+func TestRegisterRoutes(t *testing.T) {
+	ws := kapisruntime.NewWebService(v1alpha3.GroupVersion)
+	RegisterRoutes(ws, fake.NewClientBuilder().Build())
+	paths := map[string]bool{}
+	for _, route := range ws.Routes() {
+		paths[route.Path] = true
+		assert.Len(t, route.ParameterDocs, 4)
+	}
+	assert.True(t, paths["/kapis/devops.kubesphere.io/v1alpha3/namespaces/{namespace}/registry/tags"])
+	assert.True(t, paths["/kapis/devops.kubesphere.io/v1alpha3/namespaces/{namespace}/registry/digest"])
+	assert.True(t, paths["/kapis/devops.kubesphere.io/v1alpha3/namespaces/{namespace}/registry/vulnerabilities"])
+}
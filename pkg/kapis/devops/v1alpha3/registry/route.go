@@ -0,0 +1,67 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/client/registry"
+	"kubesphere.io/devops/pkg/constants"
+)
+
+// RegisterRoutes registers the APIs of the image registries
+func RegisterRoutes(service *restful.WebService, genericClient client.Client) {
+	registerRoutes(service, &handler{client: genericClient, newRegistry: newRegistry})
+}
+
+func registerRoutes(service *restful.WebService, h *handler) {
+	params := []*restful.Parameter{
+		service.PathParameter("namespace", "Namespace of the DevOpsProject"),
+		service.QueryParameter("image", "The image reference, such as harbor.example.com/devops/app:v1").Required(true),
+		service.QueryParameter("secret", "The secret which has the credential of the registry. The docker "+
+			"config secrets of the namespace are searched if it's empty"),
+		service.QueryParameter("type", "The type of the registry, harbor, dockerhub or oci. It's detected if it's empty"),
+	}
+
+	tagsRoute := service.GET("/namespaces/{namespace}/registry/tags").
+		To(h.listTags).
+		Doc("List the tags of an image").
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), TagList{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsProjectTag})
+	digestRoute := service.GET("/namespaces/{namespace}/registry/digest").
+		To(h.getDigest).
+		Doc("Get the digest of an image, along with its link on the web portal of the registry").
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), ImageDigest{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsProjectTag})
+	vulnerabilitiesRoute := service.GET("/namespaces/{namespace}/registry/vulnerabilities").
+		To(h.getVulnerabilities).
+		Doc("Get the summary of the latest vulnerability scan of an image, it's supported by Harbor").
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), registry.VulnerabilityReport{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsProjectTag})
+	for _, param := range params {
+		tagsRoute.Param(param)
+		digestRoute.Param(param)
+		vulnerabilitiesRoute.Param(param)
+	}
+	service.Route(tagsRoute)
+	service.Route(digestRoute)
+	service.Route(vulnerabilitiesRoute)
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package release
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/emicklei/go-restful"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/kapis"
+)
+
+// invalidNameCharacters are replaced when generating the name of a Release from the tag
+var invalidNameCharacters = regexp.MustCompile(`[^a-z0-9-]+`)
+
+type handler struct {
+	client client.Client
+}
+
+// cutRelease creates a Release, the changelog is generated since the tag of the latest Release
+// of the same GitRepository if the previous tag is not specified
+func (h *handler) cutRelease(req *restful.Request, resp *restful.Response) {
+	ctx := req.Request.Context()
+	namespace := req.PathParameter("namespace")
+
+	spec := &v1alpha3.ReleaseSpec{}
+	if err := req.ReadEntity(spec); err != nil {
+		kapis.HandleBadRequest(resp, req, err)
+		return
+	}
+	if spec.Tag == "" || spec.GitRepository == "" {
+		kapis.HandleBadRequest(resp, req, fmt.Errorf("both tag and gitRepository are required"))
+		return
+	}
+	if err := h.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: spec.GitRepository}, &v1alpha3.GitRepository{}); err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+
+	if spec.PreviousTag == "" {
+		previous, err := h.getLatestRelease(ctx, namespace, spec.GitRepository)
+		if err != nil {
+			kapis.HandleError(req, resp, err)
+			return
+		}
+		if previous != nil {
+			spec.PreviousTag = previous.Spec.Tag
+		}
+	}
+
+	rel := &v1alpha3.Release{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: getReleaseName(spec.GitRepository, spec.Tag)},
+		Spec:       *spec,
+	}
+	if err := h.client.Create(ctx, rel); err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	_ = resp.WriteHeaderAndEntity(http.StatusCreated, rel)
+}
+
+func (h *handler) getLatestRelease(ctx context.Context, namespace, gitRepository string) (latest *v1alpha3.Release, err error) {
+	releases := &v1alpha3.ReleaseList{}
+	if err = h.client.List(ctx, releases, client.InNamespace(namespace)); err != nil {
+		return
+	}
+	for i := range releases.Items {
+		rel := &releases.Items[i]
+		if rel.Spec.GitRepository != gitRepository || rel.Status.Phase == v1alpha3.ReleaseFailed {
+			continue
+		}
+		if latest == nil || latest.CreationTimestamp.Before(&rel.CreationTimestamp) {
+			latest = rel
+		}
+	}
+	return
+}
+
+// getReleaseName returns a valid name which is unique for the tag of the GitRepository, such as app-v1-0-0
+func getReleaseName(gitRepository, tag string) string {
+	name := strings.Trim(invalidNameCharacters.ReplaceAllString(strings.ToLower(gitRepository+"-"+tag), "-"), "-")
+	if len(name) > validation.DNS1123SubdomainMaxLength {
+		name = strings.TrimRight(name[:validation.DNS1123SubdomainMaxLength], "-")
+	}
+	return name
+}
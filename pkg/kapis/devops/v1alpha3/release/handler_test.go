@@ -0,0 +1,116 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package release
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	kapisruntime "kubesphere.io/devops/pkg/apiserver/runtime"
+)
+
+func TestCutRelease(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	repo := &v1alpha3.GitRepository{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "app"}}
+	newRelease := func(name, tag string, created time.Time, phase v1alpha3.ReleasePhase) *v1alpha3.Release {
+		return &v1alpha3.Release{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name, CreationTimestamp: metav1.NewTime(created)},
+			Spec:       v1alpha3.ReleaseSpec{GitRepository: "app", Tag: tag},
+			Status:     v1alpha3.ReleaseStatus{Phase: phase},
+		}
+	}
+	now := time.Now()
+
+	tests := []struct {
+		name            string
+		body            string
+		objects         []client.Object
+		expectCode      int
+		expectName      string
+		expectPrevious  string
+		expectNoRelease bool
+	}{{
+		name:       "invalid body",
+		body:       `{"tag":"v1.1.0"}`,
+		objects:    []client.Object{repo},
+		expectCode: http.StatusBadRequest,
+	}, {
+		name:       "GitRepository not found",
+		body:       `{"tag":"v1.1.0","gitRepository":"app"}`,
+		expectCode: http.StatusNotFound,
+	}, {
+		name: "previous tag of the latest Release",
+		body: `{"tag":"v1.1.0","gitRepository":"app","pipelineRuns":["build-1"]}`,
+		objects: []client.Object{repo,
+			newRelease("app-v0-9-0", "v0.9.0", now.Add(-2*time.Hour), v1alpha3.ReleasePublished),
+			newRelease("app-v1-0-0", "v1.0.0", now.Add(-time.Hour), v1alpha3.ReleasePublished),
+			newRelease("app-v1-0-1", "v1.0.1", now.Add(-time.Minute), v1alpha3.ReleaseFailed),
+		},
+		expectCode:     http.StatusCreated,
+		expectName:     "app-v1-1-0",
+		expectPrevious: "v1.0.0",
+	}, {
+		name:           "the specified previous tag",
+		body:           `{"tag":"Release/2.0","gitRepository":"app","previousTag":"v1.0.0"}`,
+		objects:        []client.Object{repo},
+		expectCode:     http.StatusCreated,
+		expectName:     "app-release-2-0",
+		expectPrevious: "v1.0.0",
+	}, {
+		name:       "release exists",
+		body:       `{"tag":"v1.0.0","gitRepository":"app"}`,
+		objects:    []client.Object{repo, newRelease("app-v1-0-0", "v1.0.0", now, v1alpha3.ReleasePublished)},
+		expectCode: http.StatusConflict,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(tt.objects...).Build()
+			ws := kapisruntime.NewWebService(v1alpha3.GroupVersion)
+			RegisterRoutes(ws, c)
+			container := restful.NewContainer()
+			container.Add(ws)
+
+			httpRequest, _ := http.NewRequest(http.MethodPost,
+				"http://fake.com/kapis/devops.kubesphere.io/v1alpha3/namespaces/ns/releases", strings.NewReader(tt.body))
+			httpRequest.Header.Set("Content-Type", "application/json")
+			httpWriter := httptest.NewRecorder()
+			container.Dispatch(httpWriter, httpRequest)
+			assert.Equal(t, tt.expectCode, httpWriter.Code, httpWriter.Body.String())
+			if tt.expectName == "" {
+				return
+			}
+
+			rel := &v1alpha3.Release{}
+			assert.Nil(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "ns", Name: tt.expectName}, rel))
+			assert.Equal(t, tt.expectPrevious, rel.Spec.PreviousTag)
+		})
+	}
+}
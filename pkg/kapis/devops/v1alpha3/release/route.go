@@ -0,0 +1,42 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package release
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/constants"
+)
+
+// RegisterRoutes registers the APIs of the Releases
+func RegisterRoutes(service *restful.WebService, genericClient client.Client) {
+	h := &handler{client: genericClient}
+	service.Route(service.POST("/namespaces/{namespace}/releases").
+		To(h.cutRelease).
+		Param(service.PathParameter("namespace", "Namespace of the Release")).
+		Reads(v1alpha3.ReleaseSpec{}).
+		Doc("Cut a Release of a git tag. The changelog is generated since the previous tag, which is the tag of "+
+			"the latest Release of the same GitRepository by default. The release notes are published to the SCM "+
+			"provider once the PipelineRuns succeeded if publish is true").
+		Returns(http.StatusCreated, http.StatusText(http.StatusCreated), v1alpha3.Release{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsProjectTag}))
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package switchover
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiserverrequest "kubesphere.io/devops/pkg/apiserver/request"
+	"kubesphere.io/devops/pkg/client/devops/jenkins"
+	"kubesphere.io/devops/pkg/kapis"
+)
+
+type handler struct {
+	client    client.Client
+	sarClient authorizationv1client.SubjectAccessReviewsGetter
+	namespace string
+}
+
+func (h *handler) getState(req *restful.Request, resp *restful.Response) {
+	cm, err := h.getConfigMap(req.Request.Context())
+	if err != nil && !apierrors.IsNotFound(err) {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	_ = resp.WriteEntity(jenkins.ParseSwitchoverState(cm))
+}
+
+func (h *handler) switchover(req *restful.Request, resp *restful.Response) {
+	ctx := req.Request.Context()
+	currentUser, ok := apiserverrequest.UserFrom(ctx)
+	if !ok || currentUser == nil || currentUser.GetName() == "" || currentUser.GetName() == user.Anonymous {
+		kapis.HandleUnauthorized(resp, req, fmt.Errorf("unauthenticated user is not allowed to switch Jenkins"))
+		return
+	}
+
+	request := &Request{}
+	if err := req.ReadEntity(request); err != nil {
+		kapis.HandleBadRequest(resp, req, err)
+		return
+	}
+	if request.Target != jenkins.Primary && request.Target != jenkins.Standby {
+		kapis.HandleBadRequest(resp, req, fmt.Errorf("invalid target %q, it should be primary or standby", request.Target))
+		return
+	}
+
+	if err := h.authorize(ctx, currentUser); err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+
+	cm, err := h.getConfigMap(ctx)
+	if err != nil && !apierrors.IsNotFound(err) {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	state := jenkins.ParseSwitchoverState(cm)
+	switch {
+	case state.InProgress():
+		kapis.HandleConflict(resp, req, fmt.Errorf("switching to the %s Jenkins is in progress", state.Target))
+		return
+	case state.Active == request.Target:
+		kapis.HandleBadRequest(resp, req, fmt.Errorf("the %s Jenkins is active already", request.Target))
+		return
+	}
+
+	state.Target, state.Phase, state.Message = request.Target, jenkins.SwitchoverPending, ""
+	if cm == nil {
+		cm = &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: h.namespace, Name: jenkins.SwitchoverConfigMapName}}
+		state.ApplyTo(cm)
+		err = h.client.Create(ctx, cm)
+	} else {
+		state.ApplyTo(cm)
+		err = h.client.Update(ctx, cm)
+	}
+	if err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	_ = resp.WriteHeaderAndEntity(http.StatusAccepted, state)
+}
+
+func (h *handler) getConfigMap(ctx context.Context) (*v1.ConfigMap, error) {
+	cm := &v1.ConfigMap{}
+	if err := h.client.Get(ctx, client.ObjectKey{Namespace: h.namespace, Name: jenkins.SwitchoverConfigMapName}, cm); err != nil {
+		return nil, err
+	}
+	return cm, nil
+}
+
+// authorize checks if the user is able to update the switchover ConfigMap by a SubjectAccessReview
+func (h *handler) authorize(ctx context.Context, currentUser user.Info) error {
+	if h.sarClient == nil {
+		return restful.NewError(http.StatusServiceUnavailable, "unable to authorize the request without kube-apiserver")
+	}
+
+	extra := make(map[string]authorizationv1.ExtraValue, len(currentUser.GetExtra()))
+	for key, value := range currentUser.GetExtra() {
+		extra[key] = value
+	}
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: h.namespace,
+				Verb:      "update",
+				Resource:  "configmaps",
+				Name:      jenkins.SwitchoverConfigMapName,
+			},
+			User:   currentUser.GetName(),
+			Groups: currentUser.GetGroups(),
+			UID:    currentUser.GetUID(),
+			Extra:  extra,
+		},
+	}
+
+	result, err := h.sarClient.SubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	if !result.Status.Allowed {
+		return restful.NewError(http.StatusForbidden, fmt.Sprintf("user '%s' is not allowed to switch Jenkins", currentUser.GetName()))
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package switchover
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/authentication/user"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/apiserver/request"
+	"kubesphere.io/devops/pkg/apiserver/runtime"
+	"kubesphere.io/devops/pkg/client/devops/jenkins"
+)
+
+func TestRegisterRoutes(t *testing.T) {
+	ws := runtime.NewWebService(v1alpha3.GroupVersion)
+	RegisterRoutes(ws, nil, nil, nil)
+	assert.Empty(t, ws.Routes())
+
+	RegisterRoutes(ws, nil, nil, &jenkins.Options{})
+	assert.Empty(t, ws.Routes())
+
+	RegisterRoutes(ws, nil, nil, &jenkins.Options{StandbyHost: "http://standby"})
+	assert.Equal(t, 2, len(ws.Routes()))
+}
+
+func TestSwitchover(t *testing.T) {
+	// only bob is allowed to switch Jenkins
+	clientset := k8sfake.NewSimpleClientset()
+	var review *authorizationv1.SubjectAccessReview
+	clientset.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		review = action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		result := review.DeepCopy()
+		result.Status.Allowed = review.Spec.User == "bob"
+		return true, result, nil
+	})
+
+	inProgress := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "kubesphere-devops-system", Name: jenkins.SwitchoverConfigMapName}}
+	(&jenkins.SwitchoverState{Active: jenkins.Primary, Target: jenkins.Standby, Phase: jenkins.SwitchoverSyncing}).ApplyTo(inProgress)
+
+	tests := []struct {
+		name       string
+		user       user.Info
+		body       string
+		objects    []client.Object
+		expectCode int
+		verify     func(t *testing.T, c client.Client)
+	}{{
+		name:       "anonymous user",
+		user:       &user.DefaultInfo{Name: user.Anonymous},
+		body:       `{"target":"standby"}`,
+		expectCode: http.StatusUnauthorized,
+	}, {
+		name:       "invalid target",
+		user:       &user.DefaultInfo{Name: "bob"},
+		body:       `{"target":"unknown"}`,
+		expectCode: http.StatusBadRequest,
+	}, {
+		name:       "without the permission",
+		user:       &user.DefaultInfo{Name: "alice"},
+		body:       `{"target":"standby"}`,
+		expectCode: http.StatusForbidden,
+	}, {
+		name:       "switch to the active one",
+		user:       &user.DefaultInfo{Name: "bob"},
+		body:       `{"target":"primary"}`,
+		expectCode: http.StatusBadRequest,
+	}, {
+		name:       "switching is in progress",
+		user:       &user.DefaultInfo{Name: "bob"},
+		body:       `{"target":"standby"}`,
+		objects:    []client.Object{inProgress.DeepCopy()},
+		expectCode: http.StatusConflict,
+	}, {
+		name:       "switch to the standby",
+		user:       &user.DefaultInfo{Name: "bob"},
+		body:       `{"target":"standby"}`,
+		expectCode: http.StatusAccepted,
+		verify: func(t *testing.T, c client.Client) {
+			attributes := review.Spec.ResourceAttributes
+			assert.Equal(t, "kubesphere-devops-system", attributes.Namespace)
+			assert.Equal(t, "update", attributes.Verb)
+			assert.Equal(t, jenkins.SwitchoverConfigMapName, attributes.Name)
+
+			cm := &v1.ConfigMap{}
+			assert.Nil(t, c.Get(context.TODO(), client.ObjectKey{
+				Namespace: "kubesphere-devops-system", Name: jenkins.SwitchoverConfigMapName}, cm))
+			state := jenkins.ParseSwitchoverState(cm)
+			assert.Equal(t, jenkins.Primary, state.Active)
+			assert.Equal(t, jenkins.Standby, state.Target)
+			assert.Equal(t, jenkins.SwitchoverPending, state.Phase)
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithObjects(tt.objects...).Build()
+			container := newContainer(c, clientset)
+
+			httpRequest, _ := http.NewRequestWithContext(request.WithUser(request.NewContext(), tt.user), http.MethodPost,
+				"http://fake.com/kapis/devops.kubesphere.io/v1alpha3/jenkins/switchover", strings.NewReader(tt.body))
+			httpRequest.Header.Set("Content-Type", "application/json")
+			httpWriter := httptest.NewRecorder()
+			container.Dispatch(httpWriter, httpRequest)
+			assert.Equal(t, tt.expectCode, httpWriter.Code, httpWriter.Body.String())
+			if tt.verify != nil {
+				tt.verify(t, c)
+			}
+		})
+	}
+}
+
+func TestGetState(t *testing.T) {
+	inProgress := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "kubesphere-devops-system", Name: jenkins.SwitchoverConfigMapName}}
+	(&jenkins.SwitchoverState{Active: jenkins.Primary, Target: jenkins.Standby, Phase: jenkins.SwitchoverVerifying}).ApplyTo(inProgress)
+
+	tests := []struct {
+		name        string
+		objects     []client.Object
+		expectState jenkins.SwitchoverState
+	}{{
+		name:        "never switched",
+		expectState: jenkins.SwitchoverState{Active: jenkins.Primary},
+	}, {
+		name:        "switching is in progress",
+		objects:     []client.Object{inProgress},
+		expectState: jenkins.SwitchoverState{Active: jenkins.Primary, Target: jenkins.Standby, Phase: jenkins.SwitchoverVerifying},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			container := newContainer(fake.NewClientBuilder().WithObjects(tt.objects...).Build(), nil)
+
+			httpRequest, _ := http.NewRequest(http.MethodGet,
+				"http://fake.com/kapis/devops.kubesphere.io/v1alpha3/jenkins/switchover", nil)
+			httpWriter := httptest.NewRecorder()
+			container.Dispatch(httpWriter, httpRequest)
+			assert.Equal(t, http.StatusOK, httpWriter.Code, httpWriter.Body.String())
+
+			state := jenkins.SwitchoverState{}
+			assert.Nil(t, json.Unmarshal(httpWriter.Body.Bytes(), &state))
+			assert.Equal(t, tt.expectState, state)
+		})
+	}
+}
+
+func newContainer(c client.Client, clientset *k8sfake.Clientset) *restful.Container {
+	ws := runtime.NewWebService(v1alpha3.GroupVersion)
+	options := &jenkins.Options{Namespace: "kubesphere-devops-system", StandbyHost: "http://standby"}
+	if clientset != nil {
+		RegisterRoutes(ws, c, clientset.AuthorizationV1(), options)
+	} else {
+		RegisterRoutes(ws, c, nil, options)
+	}
+	container := restful.NewContainer()
+	container.Add(ws)
+	return container
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package switchover
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/client/devops/jenkins"
+	"kubesphere.io/devops/pkg/constants"
+)
+
+// Request is the request of switching to another Jenkins instance
+type Request struct {
+	// Target is the instance to switch to, it could be primary or standby
+	Target jenkins.Instance `json:"target"`
+}
+
+// RegisterRoutes registers the APIs of switching between the Jenkins instances,
+// nothing is registered if the standby Jenkins is not configured
+func RegisterRoutes(service *restful.WebService, genericClient client.Client,
+	sarClient authorizationv1client.SubjectAccessReviewsGetter, options *jenkins.Options) {
+	if options == nil || options.StandbyHost == "" {
+		return
+	}
+
+	h := &handler{client: genericClient, sarClient: sarClient, namespace: options.Namespace}
+	service.Route(service.GET("/jenkins/switchover").
+		To(h.getState).
+		Doc("Get the state of switching between the primary and the standby Jenkins").
+		Returns(http.StatusOK, "OK", jenkins.SwitchoverState{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsJenkinsTag}))
+
+	service.Route(service.POST("/jenkins/switchover").
+		To(h.switchover).
+		Reads(Request{}).
+		Doc("Switch to another Jenkins instance after synchronizing all the pipelines and the credentials to it. "+
+			"It requires the permission of updating the switchover ConfigMap in the namespace of Jenkins").
+		Returns(http.StatusAccepted, "Accepted", jenkins.SwitchoverState{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsJenkinsTag}))
+}
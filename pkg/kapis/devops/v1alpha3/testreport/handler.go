@@ -0,0 +1,221 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testreport
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/emicklei/go-restful"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/s3"
+	"kubesphere.io/devops/pkg/kapis"
+	"kubesphere.io/devops/pkg/models/testreport"
+)
+
+const (
+	// maxReportSize is the maximum size of a raw report
+	maxReportSize = 32 << 20
+	defaultLimit  = 20
+	maxLimit      = 100
+)
+
+// validReportName avoids the report names which could not be a part of the object key
+var validReportName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+type handler struct {
+	client   client.Client
+	s3Client s3.Interface
+}
+
+// publishTestReport keeps the raw report in the object storage, then records the parsed report
+// into the status of the PipelineRun. The report with the same name is replaced.
+func (h *handler) publishTestReport(req *restful.Request, resp *restful.Response) {
+	ctx := req.Request.Context()
+	key := client.ObjectKey{Namespace: req.PathParameter("namespace"), Name: req.PathParameter("pipelinerun")}
+	name := req.QueryParameter("name")
+	format := req.QueryParameter("format")
+	if format == "" {
+		format = v1alpha3.TestReportFormatJUnit
+	}
+	if name == "" {
+		name = format
+	}
+	if !validReportName.MatchString(name) {
+		kapis.HandleBadRequest(resp, req, fmt.Errorf("invalid report name: %s", name))
+		return
+	}
+	if !testreport.IsSupportedFormat(format) {
+		kapis.HandleBadRequest(resp, req, fmt.Errorf("unsupported test report format: %s", format))
+		return
+	}
+	if h.s3Client == nil {
+		kapis.HandleInternalError(resp, req, fmt.Errorf("the object storage is not available"))
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(req.Request.Body, maxReportSize+1))
+	if err != nil {
+		kapis.HandleBadRequest(resp, req, err)
+		return
+	}
+	if len(data) > maxReportSize {
+		kapis.HandleBadRequest(resp, req, fmt.Errorf("the report is larger than %d bytes", maxReportSize))
+		return
+	}
+	report, err := testreport.Parse(format, data)
+	if err != nil {
+		kapis.HandleBadRequest(resp, req, err)
+		return
+	}
+
+	pipelineRun := &v1alpha3.PipelineRun{}
+	if err = h.client.Get(ctx, key, pipelineRun); err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	report.Name = name
+	report.Key = GetReportKey(pipelineRun, name)
+	if err = h.s3Client.Upload(report.Key, name+".xml", bytes.NewReader(data)); err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	now := metav1.Now()
+	report.PublishTime = &now
+
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() (err error) {
+		latest := &v1alpha3.PipelineRun{}
+		if err = h.client.Get(ctx, key, latest); err != nil {
+			return
+		}
+		if latest.Status.Tests == nil {
+			latest.Status.Tests = &v1alpha3.TestSummary{}
+		}
+		latest.Status.Tests.SetReport(*report)
+		return h.client.Status().Update(ctx, latest)
+	})
+	if err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	_ = resp.WriteHeaderAndEntity(http.StatusCreated, report)
+}
+
+// getTestSummary returns the test summary of a PipelineRun, it's empty if there are no test reports
+func (h *handler) getTestSummary(req *restful.Request, resp *restful.Response) {
+	key := client.ObjectKey{Namespace: req.PathParameter("namespace"), Name: req.PathParameter("pipelinerun")}
+	pipelineRun := &v1alpha3.PipelineRun{}
+	if err := h.client.Get(req.Request.Context(), key, pipelineRun); err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	summary := pipelineRun.Status.Tests
+	if summary == nil {
+		summary = &v1alpha3.TestSummary{}
+	}
+	_ = resp.WriteEntity(summary)
+}
+
+// getTestTrend returns the test results of the latest PipelineRuns of a Pipeline
+func (h *handler) getTestTrend(req *restful.Request, resp *restful.Response) {
+	limit, err := parseLimit(req)
+	if err != nil {
+		kapis.HandleBadRequest(resp, req, err)
+		return
+	}
+	pipelineRuns, err := h.listLatestPipelineRuns(req, limit)
+	if err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	_ = resp.WriteEntity(testreport.GetTrend(pipelineRuns))
+}
+
+// getTestCaseHistory returns the results of a test case in the latest PipelineRuns of a Pipeline
+func (h *handler) getTestCaseHistory(req *restful.Request, resp *restful.Response) {
+	id := req.QueryParameter("case")
+	if id == "" {
+		kapis.HandleBadRequest(resp, req, fmt.Errorf("the test case is required"))
+		return
+	}
+	limit, err := parseLimit(req)
+	if err != nil {
+		kapis.HandleBadRequest(resp, req, err)
+		return
+	}
+	if h.s3Client == nil {
+		kapis.HandleInternalError(resp, req, fmt.Errorf("the object storage is not available"))
+		return
+	}
+	pipelineRuns, err := h.listLatestPipelineRuns(req, limit)
+	if err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	records, err := testreport.GetCaseHistory(pipelineRuns, id, h.s3Client.Read)
+	if err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	_ = resp.WriteEntity(records)
+}
+
+// listLatestPipelineRuns returns the latest PipelineRuns of the Pipeline, the latest one comes first
+func (h *handler) listLatestPipelineRuns(req *restful.Request, limit int) (pipelineRuns []v1alpha3.PipelineRun, err error) {
+	list := &v1alpha3.PipelineRunList{}
+	if err = h.client.List(req.Request.Context(), list, client.InNamespace(req.PathParameter("namespace")),
+		client.MatchingLabels{v1alpha3.PipelineNameLabelKey: req.PathParameter("pipeline")}); err != nil {
+		return
+	}
+	pipelineRuns = list.Items
+	sort.SliceStable(pipelineRuns, func(i, j int) bool {
+		return pipelineRuns[j].CreationTimestamp.Before(&pipelineRuns[i].CreationTimestamp)
+	})
+	if len(pipelineRuns) > limit {
+		pipelineRuns = pipelineRuns[:limit]
+	}
+	return
+}
+
+// parseLimit returns the number of the PipelineRuns to query, it's capped by maxLimit
+func parseLimit(req *restful.Request) (limit int, err error) {
+	limit = defaultLimit
+	if value := req.QueryParameter("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 {
+			err = fmt.Errorf("invalid limit: %s", value)
+			return
+		}
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	return
+}
+
+// GetReportKey returns the object key of a raw test report
+func GetReportKey(pipelineRun *v1alpha3.PipelineRun, name string) string {
+	return fmt.Sprintf("pipelinerun-testreports/%s/%s/%s/%s", pipelineRun.Namespace, pipelineRun.Name, pipelineRun.UID, name)
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testreport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	kapisruntime "kubesphere.io/devops/pkg/apiserver/runtime"
+	fakes3 "kubesphere.io/devops/pkg/client/s3/fake"
+	"kubesphere.io/devops/pkg/models/testreport"
+)
+
+const junitReport = `<testsuite name="app">
+  <testcase classname="com.example.AppTest" name="testAdd" time="0.5"/>
+  <testcase classname="com.example.AppTest" name="testSub"><failure message="expected 1 but was 2"/></testcase>
+</testsuite>`
+
+func newContainer(c client.Client, s3Client *fakes3.FakeS3) *restful.Container {
+	ws := kapisruntime.NewWebService(v1alpha3.GroupVersion)
+	RegisterRoutes(ws, c, s3Client)
+	container := restful.NewContainer()
+	container.Add(ws)
+	return container
+}
+
+func TestPublishTestReport(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	pipelineRun := &v1alpha3.PipelineRun{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "build-1", UID: "uid"}}
+
+	tests := []struct {
+		name       string
+		query      string
+		body       string
+		expectCode int
+		verify     func(t *testing.T, summary *v1alpha3.TestSummary, s3Client *fakes3.FakeS3)
+	}{{
+		name:       "unsupported format",
+		query:      "?format=html",
+		body:       junitReport,
+		expectCode: http.StatusBadRequest,
+	}, {
+		name:       "invalid name",
+		query:      "?name=../unit",
+		body:       junitReport,
+		expectCode: http.StatusBadRequest,
+	}, {
+		name:       "invalid report",
+		body:       "not xml",
+		expectCode: http.StatusBadRequest,
+	}, {
+		name:       "JUnit report",
+		query:      "?name=unit",
+		body:       junitReport,
+		expectCode: http.StatusCreated,
+		verify: func(t *testing.T, summary *v1alpha3.TestSummary, s3Client *fakes3.FakeS3) {
+			assert.Equal(t, v1alpha3.TestCounts{Total: 2, Passed: 1, Failed: 1}, summary.TestCounts)
+			report := summary.GetReport("unit")
+			if assert.NotNil(t, report) {
+				assert.Equal(t, "pipelinerun-testreports/ns/build-1/uid/unit", report.Key)
+				assert.Len(t, report.FailedCases, 1)
+				assert.NotNil(t, s3Client.Storage[report.Key])
+			}
+		},
+	}, {
+		name:       "coverage report",
+		query:      "?format=cobertura",
+		body:       `<coverage lines-covered="3" lines-valid="4"/>`,
+		expectCode: http.StatusCreated,
+		verify: func(t *testing.T, summary *v1alpha3.TestSummary, s3Client *fakes3.FakeS3) {
+			assert.Equal(t, &v1alpha3.CoverageSummary{LinesCovered: 3, LinesValid: 4}, summary.Coverage)
+			assert.NotNil(t, summary.GetReport(v1alpha3.TestReportFormatCobertura))
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(pipelineRun.DeepCopy()).Build()
+			s3Client := fakes3.NewFakeS3()
+
+			httpRequest, _ := http.NewRequest(http.MethodPost, "http://fake.com/kapis/devops.kubesphere.io/v1alpha3/"+
+				"namespaces/ns/pipelineruns/build-1/testreports"+tt.query, strings.NewReader(tt.body))
+			httpRequest.Header.Set("Content-Type", "application/xml")
+			httpWriter := httptest.NewRecorder()
+			newContainer(c, s3Client).Dispatch(httpWriter, httpRequest)
+			assert.Equal(t, tt.expectCode, httpWriter.Code, httpWriter.Body.String())
+			if tt.verify == nil {
+				return
+			}
+
+			latest := &v1alpha3.PipelineRun{}
+			assert.Nil(t, c.Get(context.TODO(), client.ObjectKeyFromObject(pipelineRun), latest))
+			if assert.NotNil(t, latest.Status.Tests) {
+				tt.verify(t, latest.Status.Tests, s3Client)
+			}
+		})
+	}
+}
+
+func TestQueryTestResults(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	now := time.Now()
+	newPipelineRun := func(name string, created time.Time, tests *v1alpha3.TestSummary) *v1alpha3.PipelineRun {
+		return &v1alpha3.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name, CreationTimestamp: metav1.NewTime(created),
+				Labels: map[string]string{v1alpha3.PipelineNameLabelKey: "build"}},
+			Status: v1alpha3.PipelineRunStatus{Tests: tests},
+		}
+	}
+	newSummary := func(key string, failed int) *v1alpha3.TestSummary {
+		summary := &v1alpha3.TestSummary{}
+		summary.SetReport(v1alpha3.TestReport{Name: "unit", Format: v1alpha3.TestReportFormatJUnit, Key: key,
+			Tests: &v1alpha3.TestCounts{Total: 2, Passed: 2 - failed, Failed: failed}})
+		return summary
+	}
+	c := fake.NewClientBuilder().WithScheme(schema).WithObjects(
+		newPipelineRun("build-1", now.Add(-time.Hour), newSummary("build-1", 0)),
+		newPipelineRun("build-2", now, newSummary("build-2", 1)),
+		newPipelineRun("build-3", now.Add(-2*time.Hour), nil),
+	).Build()
+	s3Client := fakes3.NewFakeS3(&fakes3.Object{Key: "build-2", Body: strings.NewReader(junitReport)},
+		&fakes3.Object{Key: "build-1", Body: strings.NewReader(strings.ReplaceAll(junitReport,
+			`<failure message="expected 1 but was 2"/>`, ""))})
+	container := newContainer(c, s3Client)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		httpRequest, _ := http.NewRequest(http.MethodGet, "http://fake.com/kapis/devops.kubesphere.io/v1alpha3/"+path, nil)
+		httpWriter := httptest.NewRecorder()
+		container.Dispatch(httpWriter, httpRequest)
+		return httpWriter
+	}
+
+	resp := get("namespaces/ns/pipelines/build/testreports/trend")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var points []testreport.TrendPoint
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &points))
+	if assert.Len(t, points, 2) {
+		assert.Equal(t, "build-2", points[0].PipelineRun)
+		assert.Equal(t, 1, points[0].Failed)
+		assert.Equal(t, "build-1", points[1].PipelineRun)
+	}
+
+	resp = get("namespaces/ns/pipelines/build/testreports/trend?limit=1")
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &points))
+	assert.Len(t, points, 1)
+	assert.Equal(t, http.StatusBadRequest, get("namespaces/ns/pipelines/build/testreports/trend?limit=x").Code)
+
+	resp = get("namespaces/ns/pipelines/build/testcases/history?case=com.example.AppTest.testSub")
+	assert.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	var records []testreport.CaseRecord
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &records))
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, "build-2", records[0].PipelineRun)
+		assert.Equal(t, v1alpha3.TestCaseFailed, records[0].Status)
+		assert.Equal(t, "build-1", records[1].PipelineRun)
+		assert.Equal(t, v1alpha3.TestCasePassed, records[1].Status)
+	}
+	assert.Equal(t, http.StatusBadRequest, get("namespaces/ns/pipelines/build/testcases/history").Code)
+
+	resp = get("namespaces/ns/pipelineruns/build-3/testreports")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"duration":"0s"}`, resp.Body.String())
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testreport
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/s3"
+	"kubesphere.io/devops/pkg/constants"
+	"kubesphere.io/devops/pkg/models/testreport"
+)
+
+// RegisterRoutes registers the APIs of the test reports
+func RegisterRoutes(service *restful.WebService, genericClient client.Client, s3Client s3.Interface) {
+	h := &handler{client: genericClient, s3Client: s3Client}
+	service.Route(service.POST("/namespaces/{namespace}/pipelineruns/{pipelinerun}/testreports").
+		To(h.publishTestReport).
+		Param(service.PathParameter("namespace", "Namespace of the PipelineRun")).
+		Param(service.PathParameter("pipelinerun", "Name of the PipelineRun")).
+		Param(service.QueryParameter("name", "The unique name of the report in the PipelineRun, the report with "+
+			"the same name is replaced. It's the format by default")).
+		Param(service.QueryParameter("format", "The format of the report, one of junit, cobertura and jacoco").
+			DefaultValue(v1alpha3.TestReportFormatJUnit)).
+		Consumes("application/xml", "text/xml", "application/octet-stream").
+		Doc("Publish a JUnit or coverage XML report of the PipelineRun. The raw report is kept in the object storage, "+
+			"the parsed report is summed up into the status of the PipelineRun").
+		Returns(http.StatusCreated, http.StatusText(http.StatusCreated), v1alpha3.TestReport{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsPipelineTag}))
+
+	service.Route(service.GET("/namespaces/{namespace}/pipelineruns/{pipelinerun}/testreports").
+		To(h.getTestSummary).
+		Param(service.PathParameter("namespace", "Namespace of the PipelineRun")).
+		Param(service.PathParameter("pipelinerun", "Name of the PipelineRun")).
+		Doc("Get the summary of the test reports published by the PipelineRun").
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), v1alpha3.TestSummary{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsPipelineTag}))
+
+	service.Route(service.GET("/namespaces/{namespace}/pipelines/{pipeline}/testreports/trend").
+		To(h.getTestTrend).
+		Param(service.PathParameter("namespace", "Namespace of the Pipeline")).
+		Param(service.PathParameter("pipeline", "Name of the Pipeline")).
+		Param(service.QueryParameter("limit", "The number of the latest PipelineRuns, at most 100").DefaultValue("20")).
+		Doc("Get the test results and the coverage of the latest PipelineRuns, the latest one comes first").
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), []testreport.TrendPoint{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsPipelineTag}))
+
+	service.Route(service.GET("/namespaces/{namespace}/pipelines/{pipeline}/testcases/history").
+		To(h.getTestCaseHistory).
+		Param(service.PathParameter("namespace", "Namespace of the Pipeline")).
+		Param(service.PathParameter("pipeline", "Name of the Pipeline")).
+		Param(service.QueryParameter("case", "The test case in the format of <classname>.<name>, such as "+
+			"com.example.AppTest.testAdd. The suite name is taken if there is no classname").Required(true)).
+		Param(service.QueryParameter("limit", "The number of the latest PipelineRuns, at most 100").DefaultValue("20")).
+		Doc("Get the results of a test case in the latest PipelineRuns, the latest one comes first").
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), []testreport.CaseRecord{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsPipelineTag}))
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package usage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	"kubesphere.io/devops/pkg/kapis"
+	"kubesphere.io/devops/pkg/models/usage"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type handler struct {
+	client client.Client
+}
+
+func (h *handler) getUsage(req *restful.Request, resp *restful.Response) {
+	until, err := parseTime(req.QueryParameter("until"), time.Now())
+	if err != nil {
+		kapis.HandleBadRequest(resp, req, err)
+		return
+	}
+	since, err := parseTime(req.QueryParameter("since"), until.Add(-usage.DefaultWindow))
+	if err != nil {
+		kapis.HandleBadRequest(resp, req, err)
+		return
+	}
+	if !since.Before(until) {
+		kapis.HandleBadRequest(resp, req, fmt.Errorf("the since time should be before the until time"))
+		return
+	}
+
+	report, err := usage.Collect(req.Request.Context(), h.client, req.PathParameter("devops"), since, until)
+	if err != nil {
+		kapis.HandleError(req, resp, err)
+		return
+	}
+	_ = resp.WriteEntity(report)
+}
+
+func parseTime(value string, defaultValue time.Time) (result time.Time, err error) {
+	if value == "" {
+		result = defaultValue
+		return
+	}
+	if result, err = time.Parse(time.RFC3339, value); err != nil {
+		err = fmt.Errorf("invalid time '%s', it should be in RFC3339 format", value)
+	}
+	return
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package usage
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"kubesphere.io/devops/pkg/constants"
+	"kubesphere.io/devops/pkg/models/usage"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	// DevopsPathParameter is a path parameter definition for devops
+	DevopsPathParameter = restful.PathParameter("devops", "DevOps project's namespace")
+	// SinceQueryParameter is the start of the period
+	SinceQueryParameter = restful.QueryParameter("since", "The start time in RFC3339 format, it's 30 days ago by default")
+	// UntilQueryParameter is the end of the period
+	UntilQueryParameter = restful.QueryParameter("until", "The end time in RFC3339 format, it's now by default")
+)
+
+// RegisterRoutes registers the resource usage APIs
+func RegisterRoutes(service *restful.WebService, c client.Client) {
+	h := &handler{client: c}
+	service.Route(service.GET("/devops/{devops}/metrics/usage").
+		To(h.getUsage).
+		Param(DevopsPathParameter).
+		Param(SinceQueryParameter).
+		Param(UntilQueryParameter).
+		Doc("Get the compute resources consumed by the PipelineRuns of a DevOps project, which were created "+
+			"in the period, by the Pipelines").
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), usage.Report{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsProjectTag}))
+}
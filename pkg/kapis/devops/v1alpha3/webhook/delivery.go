@@ -0,0 +1,125 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/emicklei/go-restful"
+	"k8s.io/klog/v2"
+
+	"kubesphere.io/devops/pkg/api"
+	"kubesphere.io/devops/pkg/apiserver/query"
+	"kubesphere.io/devops/pkg/kapis"
+	webhookmodel "kubesphere.io/devops/pkg/models/webhook"
+)
+
+// sensitiveHeaders carry the credentials, they are not kept in the deliveries
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"X-Gitlab-Token":      true,
+}
+
+// getEventName returns the event name from the provider specific header
+func getEventName(request *http.Request) string {
+	for _, header := range []string{"X-GitHub-Event", "X-Gitlab-Event", "X-Event-Key"} {
+		if event := request.Header.Get(header); event != "" {
+			return event
+		}
+	}
+	return ""
+}
+
+func getDeliveryHeaders(header http.Header) (headers map[string]string) {
+	headers = map[string]string{}
+	for key := range header {
+		if !sensitiveHeaders[http.CanonicalHeaderKey(key)] {
+			headers[key] = header.Get(key)
+		}
+	}
+	return
+}
+
+func (h *SCMHandler) recordDelivery(delivery *webhookmodel.Delivery) {
+	if h.deliveries == nil {
+		return
+	}
+	if err := h.deliveries.Record(delivery); err != nil {
+		klog.Errorf("failed to record the webhook delivery from %s, error: %v", delivery.Repository, err)
+	}
+}
+
+func (h *SCMHandler) listDeliveries(request *restful.Request, response *restful.Response) {
+	deliveries, err := h.deliveries.List(webhookmodel.Filter{
+		Repository: request.QueryParameter("repository"),
+		Event:      request.QueryParameter("event"),
+	})
+	if err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+
+	pagination := query.ParseQueryParameter(request).Pagination
+	start, end := pagination.GetValidPagination(len(deliveries))
+	items := make([]interface{}, 0, end-start)
+	for i := range deliveries[start:end] {
+		items = append(items, deliveries[start+i])
+	}
+	_ = response.WriteEntity(api.NewListResult(items, len(deliveries)))
+}
+
+func (h *SCMHandler) getDelivery(request *restful.Request, response *restful.Response) {
+	delivery, err := h.deliveries.Get(request.PathParameter("delivery"))
+	if err == webhookmodel.ErrDeliveryNotFound {
+		kapis.HandleNotFound(response, request, err)
+		return
+	} else if err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+	_ = response.WriteEntity(delivery)
+}
+
+// replayDelivery handles the payload of a delivery once again, the replay is recorded as a new delivery
+func (h *SCMHandler) replayDelivery(request *restful.Request, response *restful.Response) {
+	original, err := h.deliveries.Get(request.PathParameter("delivery"))
+	if err == webhookmodel.ErrDeliveryNotFound {
+		kapis.HandleNotFound(response, request, err)
+		return
+	} else if err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+
+	replayRequest, err := http.NewRequestWithContext(request.Request.Context(), http.MethodPost,
+		request.Request.URL.String(), strings.NewReader(original.Payload))
+	if err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+	for key, value := range original.Headers {
+		replayRequest.Header.Set(key, value)
+	}
+
+	delivery := h.handleDelivery(replayRequest)
+	delivery.ReplayOf = original.ID
+	h.recordDelivery(delivery)
+	_ = response.WriteEntity(delivery)
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	"github.com/jenkins-zh/jenkins-client/pkg/core"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	apiserverruntime "kubesphere.io/devops/pkg/apiserver/runtime"
+	"kubesphere.io/devops/pkg/client/cache"
+	"kubesphere.io/devops/pkg/jwt/token"
+	webhookmodel "kubesphere.io/devops/pkg/models/webhook"
+)
+
+func TestSCMWebhookDeliveries(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+	assert.Nil(t, v1.AddToScheme(schema))
+	pipeline := &v1alpha3.Pipeline{}
+	pipeline.SetName("fake")
+	pipeline.SetNamespace("default")
+	pipeline.SetAnnotations(map[string]string{
+		scmRefAnnotationKey: `["master"]`,
+		scmAnnotationKey:    "https://gitlab.com/linuxsuren/test",
+	})
+	c := fake.NewClientBuilder().WithScheme(schema).WithObjects(pipeline).Build()
+	store := webhookmodel.NewCacheStore(cache.NewSimpleCache(), time.Hour)
+
+	container := restful.NewContainer()
+	ws := apiserverruntime.NewWebService(v1alpha3.GroupVersion)
+	RegisterWebhooks(c, ws, &token.FakeIssuer{}, core.JenkinsCore{}, store)
+	container.Add(ws)
+
+	request := func(method, uri string, body io.Reader, header map[string]string) *httptest.ResponseRecorder {
+		httpRequest, _ := http.NewRequest(method, "http://fake.com/kapis/devops.kubesphere.io/v1alpha3"+uri, body)
+		httpRequest.Header.Set("Content-Type", "application/json")
+		for k, v := range header {
+			httpRequest.Header.Set(k, v)
+		}
+		httpWriter := httptest.NewRecorder()
+		container.Dispatch(httpWriter, httpRequest)
+		return httpWriter
+	}
+
+	// receive a webhook
+	recorder := request(http.MethodPost, "/webhooks/scm", strings.NewReader(gitlabWebhookBody), map[string]string{
+		"X-Gitlab-Event": "Push Hook",
+		"X-Gitlab-Token": "secret",
+	})
+	assert.Equal(t, "ok", recorder.Body.String())
+	recorder = request(http.MethodPost, "/webhooks/scm", nil, nil)
+	assert.Equal(t, "unknown SCM type", recorder.Body.String())
+
+	// list the deliveries
+	recorder = request(http.MethodGet, "/webhooks/scm/deliveries?repository=linuxsuren/test", nil, nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	list := &struct {
+		Items      []webhookmodel.Delivery `json:"items"`
+		TotalItems int                     `json:"totalItems"`
+	}{}
+	assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), list))
+	if !assert.Equal(t, 1, list.TotalItems) {
+		return
+	}
+	delivery := list.Items[0]
+	assert.Equal(t, "gitlab", delivery.Provider)
+	assert.Equal(t, "Push Hook", delivery.Event)
+	assert.Equal(t, "refs/heads/master", delivery.Ref)
+	assert.Equal(t, http.StatusOK, delivery.StatusCode)
+	assert.Equal(t, "ok", delivery.Message)
+	assert.Equal(t, gitlabWebhookBody, delivery.Payload)
+	assert.Equal(t, "Push Hook", delivery.Headers["X-Gitlab-Event"])
+	assert.NotContains(t, delivery.Headers, "X-Gitlab-Token")
+	assert.Len(t, delivery.PipelineRuns, 1)
+
+	recorder = request(http.MethodGet, "/webhooks/scm/deliveries", nil, nil)
+	assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), list))
+	assert.Equal(t, 2, list.TotalItems)
+
+	// get a delivery
+	recorder = request(http.MethodGet, "/webhooks/scm/deliveries/"+delivery.ID, nil, nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	recorder = request(http.MethodGet, "/webhooks/scm/deliveries/fake", nil, nil)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// replay a delivery
+	recorder = request(http.MethodPost, "/webhooks/scm/deliveries/"+delivery.ID+"/replay", nil, nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	replay := &webhookmodel.Delivery{}
+	assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), replay))
+	assert.Equal(t, delivery.ID, replay.ReplayOf)
+	assert.NotEqual(t, delivery.ID, replay.ID)
+	assert.Equal(t, "ok", replay.Message)
+	assert.Len(t, replay.PipelineRuns, 1)
+	recorder = request(http.MethodPost, "/webhooks/scm/deliveries/fake/replay", nil, nil)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	runs := &v1alpha3.PipelineRunList{}
+	assert.Nil(t, c.List(context.Background(), runs))
+	assert.Len(t, runs.Items, 2)
+
+	deliveries, err := store.List(webhookmodel.Filter{})
+	assert.Nil(t, err)
+	assert.Len(t, deliveries, 3)
+}
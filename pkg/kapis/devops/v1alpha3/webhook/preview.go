@@ -0,0 +1,90 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+
+	"github.com/jenkins-x/go-scm/scm"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+// syncPreviewEnvironments creates or redeploys the PreviewEnvironments of the opened pull request,
+// and deletes them once the pull request is closed
+func (h *SCMHandler) syncPreviewEnvironments(ctx context.Context, hook *scm.PullRequestHook, scope *webhookScope) (requested bool, err error) {
+	var create, remove bool
+	switch hook.Action {
+	case scm.ActionOpen, scm.ActionReopen, scm.ActionSync:
+		create = true
+	case scm.ActionClose, scm.ActionMerge:
+		remove = true
+	default:
+		return
+	}
+
+	repoList := &v1alpha3.GitRepositoryList{}
+	if err = h.List(ctx, repoList); err != nil {
+		return
+	}
+	for i := range repoList.Items {
+		repo := &repoList.Items[i]
+		if repo.Spec.Preview == nil || repo.Spec.URL == "" || !scope.allows(repo.Namespace) ||
+			!gitRepoMatch(repo.Spec.URL, hook.Repo.Link, hook.Repo.Clone, hook.Repo.CloneSSH) {
+			continue
+		}
+		requested = true
+
+		preview := &v1alpha3.PreviewEnvironment{}
+		key := client.ObjectKey{Namespace: repo.Namespace,
+			Name: v1alpha3.GetPreviewEnvironmentName(repo.Name, hook.PullRequest.Number)}
+		if err = h.Get(ctx, key, preview); err != nil && !apierrors.IsNotFound(err) {
+			return
+		}
+		exists := err == nil
+		err = nil
+
+		switch {
+		case remove && exists:
+			err = client.IgnoreNotFound(h.Delete(ctx, preview))
+		case create && exists:
+			if preview.Spec.Revision != hook.PullRequest.Sha {
+				preview.Spec.Revision = hook.PullRequest.Sha
+				err = h.Update(ctx, preview)
+			}
+		case create:
+			preview = &v1alpha3.PreviewEnvironment{
+				ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name},
+				Spec: v1alpha3.PreviewEnvironmentSpec{
+					GitRepository: repo.Name,
+					PullRequest:   hook.PullRequest.Number,
+					Branch:        hook.PullRequest.Source,
+					Revision:      hook.PullRequest.Sha,
+					PreviewPolicy: *repo.Spec.Preview.DeepCopy(),
+				},
+			}
+			err = h.Create(ctx, preview)
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
@@ -23,18 +23,85 @@ import (
 
 	"github.com/emicklei/go-restful"
 	"kubesphere.io/devops/pkg/api"
+	"kubesphere.io/devops/pkg/apiserver/query"
+	webhookmodel "kubesphere.io/devops/pkg/models/webhook"
+	"kubesphere.io/devops/pkg/trigger"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-// RegisterWebhooks registers all webhooks into web service.
-func RegisterWebhooks(genericClient client.Client, ws *restful.WebService, issue token.Issuer, jenkins core.JenkinsCore) {
+// RegisterWebhooks registers all webhooks into web service. The delivery history APIs are not registered if deliveries is nil.
+func RegisterWebhooks(genericClient client.Client, ws *restful.WebService, issue token.Issuer, jenkins core.JenkinsCore,
+	deliveries webhookmodel.Interface) {
 	webhookHandler := NewHandler(genericClient)
 	ws.Route(ws.POST("/webhooks/jenkins").
 		To(webhookHandler.ReceiveEventsFromJenkins).
 		Doc("Webhook for receiving events from Jenkins").
 		Returns(http.StatusOK, api.StatusOK, nil))
+	ws.Route(ws.POST("/webhooks/sonarqube").
+		To(webhookHandler.ReceiveEventsFromSonarQube).
+		Doc("Webhook for receiving the quality gates of the analyses from SonarQube").
+		Returns(http.StatusOK, api.StatusOK, nil))
+
+	ws.Route(ws.GET("/namespaces/{namespace}/webhooksecret").
+		To(webhookHandler.getSecret).
+		Param(ws.PathParameter("namespace", "Namespace of the DevOpsProject")).
+		Doc("Get the webhook secret of the DevOpsProject without its token").
+		Returns(http.StatusOK, api.StatusOK, webhookmodel.SecretStatus{}))
+	ws.Route(ws.POST("/namespaces/{namespace}/webhooksecret").
+		To(webhookHandler.generateSecret).
+		Param(ws.PathParameter("namespace", "Namespace of the DevOpsProject")).
+		Doc("Generate the webhook secret of the DevOpsProject, the provider webhooks of its GitRepositories are "+
+			"registered with it. The token is only returned once").
+		Returns(http.StatusCreated, api.StatusOK, webhookmodel.SecretToken{}))
+	ws.Route(ws.POST("/namespaces/{namespace}/webhooksecret/rotate").
+		To(webhookHandler.rotateSecret).
+		Param(ws.PathParameter("namespace", "Namespace of the DevOpsProject")).
+		Doc("Replace the token of the webhook secret of the DevOpsProject, the provider webhooks of its "+
+			"GitRepositories are updated with the new one. The token is only returned once").
+		Returns(http.StatusOK, api.StatusOK, webhookmodel.SecretToken{}))
+	ws.Route(ws.DELETE("/namespaces/{namespace}/webhooksecret").
+		To(webhookHandler.revokeSecret).
+		Param(ws.PathParameter("namespace", "Namespace of the DevOpsProject")).
+		Doc("Revoke the webhook secret of the DevOpsProject").
+		Returns(http.StatusNoContent, api.StatusOK, nil))
 
-	scmHandler := NewSCMHandler(genericClient, issue, jenkins)
+	scmHandler := NewSCMHandler(genericClient, issue, jenkins, deliveries)
 	ws.Route(ws.POST("/webhooks/scm").
 		To(scmHandler.scmWebhook))
+
+	triggerHandler := newTriggerHandler(genericClient)
+	ws.Route(ws.POST("/webhooks/registry").
+		To(triggerHandler.receiveImagePush).
+		Param(ws.HeaderParameter(trigger.SignatureHeader, "The HMAC-SHA256 signature of the payload by the secret of the Triggers, in the format of sha256=<hex>")).
+		Param(ws.QueryParameter("token", "The secret of the Triggers, it's for the registries which are not able to sign the payloads")).
+		Doc("Webhook for receiving the image push notifications from Harbor, Docker Hub or Docker Distribution, which fire the Triggers whose secret matches").
+		Returns(http.StatusOK, api.StatusOK, TriggerResult{}))
+	ws.Route(ws.POST("/webhooks/cloudevents").
+		To(triggerHandler.receiveCloudEvent).
+		Param(ws.HeaderParameter(trigger.SignatureHeader, "The HMAC-SHA256 signature of the payload by the secret of the Triggers, in the format of sha256=<hex>")).
+		Param(ws.QueryParameter("token", "The secret of the Triggers, it's for the senders which are not able to sign the payloads")).
+		Doc("Webhook for receiving the CloudEvents in the binary or structured mode, which fire the Triggers whose secret matches").
+		Returns(http.StatusOK, api.StatusOK, TriggerResult{}))
+
+	if deliveries == nil {
+		return
+	}
+	ws.Route(ws.GET("/webhooks/scm/deliveries").
+		To(scmHandler.listDeliveries).
+		Param(ws.QueryParameter("repository", "The full name of the repository, such as kubesphere/ks-devops")).
+		Param(ws.QueryParameter("event", "The event name from the SCM provider, such as push")).
+		Param(ws.QueryParameter(query.ParameterPage, "page").Required(false).DataFormat("page=%d").DefaultValue("page=1")).
+		Param(ws.QueryParameter(query.ParameterLimit, "limit").Required(false)).
+		Doc("List the received SCM webhook deliveries, the latest deliveries come first").
+		Returns(http.StatusOK, api.StatusOK, api.ListResult{Items: []interface{}{}}))
+	ws.Route(ws.GET("/webhooks/scm/deliveries/{delivery}").
+		To(scmHandler.getDelivery).
+		Param(ws.PathParameter("delivery", "The ID of the delivery")).
+		Doc("Get a SCM webhook delivery with its headers and payload").
+		Returns(http.StatusOK, api.StatusOK, webhookmodel.Delivery{}))
+	ws.Route(ws.POST("/webhooks/scm/deliveries/{delivery}/replay").
+		To(scmHandler.replayDelivery).
+		Param(ws.PathParameter("delivery", "The ID of the delivery")).
+		Doc("Handle the payload of a SCM webhook delivery once again").
+		Returns(http.StatusOK, api.StatusOK, webhookmodel.Delivery{}))
 }
@@ -17,8 +17,10 @@ limitations under the License.
 package webhook
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/emicklei/go-restful"
 	"github.com/jenkins-x/go-scm/scm"
@@ -27,11 +29,17 @@ import (
 	"github.com/jenkins-x/go-scm/scm/driver/gitlab"
 	"github.com/jenkins-zh/jenkins-client/pkg/core"
 	"github.com/jenkins-zh/jenkins-client/pkg/job"
+	"io"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apiserver/pkg/authentication/user"
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
 	"kubesphere.io/devops/pkg/client/devops"
 	"kubesphere.io/devops/pkg/jwt/token"
 	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/pipelinerun"
+	webhookmodel "kubesphere.io/devops/pkg/models/webhook"
+	"kubesphere.io/devops/pkg/trigger"
 	"net/http"
 	"regexp"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -50,14 +58,21 @@ type SCMHandler struct {
 	client.Client
 	issue   token.Issuer
 	jenkins core.JenkinsCore
+	// deliveries keeps the received webhooks, nothing is recorded if it's nil
+	deliveries webhookmodel.Interface
+	// dispatcher fires the Triggers of the webhook source, they are skipped if it's nil
+	dispatcher *trigger.Dispatcher
 }
 
 // NewSCMHandler creates a new handler for handling webhooks.
-func NewSCMHandler(genericClient client.Client, issue token.Issuer, jenkins core.JenkinsCore) *SCMHandler {
+func NewSCMHandler(genericClient client.Client, issue token.Issuer, jenkins core.JenkinsCore,
+	deliveries webhookmodel.Interface) *SCMHandler {
 	return &SCMHandler{
-		Client:  genericClient,
-		issue:   issue,
-		jenkins: jenkins,
+		Client:     genericClient,
+		issue:      issue,
+		jenkins:    jenkins,
+		deliveries: deliveries,
+		dispatcher: trigger.NewDispatcher(genericClient),
 	}
 }
 
@@ -77,31 +92,67 @@ func getSCMClient(request *http.Request) *scm.Client {
 }
 
 func (h *SCMHandler) scmWebhook(request *restful.Request, response *restful.Response) {
-	scmClient := getSCMClient(request.Request)
+	delivery := h.handleDelivery(request.Request)
+	h.recordDelivery(delivery)
+	_ = response.WriteErrorString(delivery.StatusCode, delivery.Message)
+}
+
+// handleDelivery handles a webhook request, the outcome is returned as a delivery which is able to be replayed
+func (h *SCMHandler) handleDelivery(request *http.Request) (delivery *webhookmodel.Delivery) {
+	var payload []byte
+	if request.Body != nil {
+		payload, _ = io.ReadAll(request.Body)
+		request.Body = io.NopCloser(bytes.NewReader(payload))
+	}
+	delivery = &webhookmodel.Delivery{
+		Time:       time.Now(),
+		Event:      getEventName(request),
+		Headers:    getDeliveryHeaders(request.Header),
+		Payload:    string(payload),
+		StatusCode: http.StatusOK,
+	}
+
+	found, err := h.handleSCMEvent(request, delivery)
+	switch {
+	case !found && err != nil:
+		delivery.Message = err.Error()
+	case !found:
+		delivery.Message = "no pipeline matched"
+	case err != nil:
+		delivery.StatusCode = http.StatusBadRequest
+		delivery.Message = err.Error()
+	default:
+		delivery.Message = "ok"
+	}
+	return
+}
+
+// handleSCMEvent returns an error without found if the request is not a valid webhook
+func (h *SCMHandler) handleSCMEvent(request *http.Request, delivery *webhookmodel.Delivery) (found bool, err error) {
+	scmClient := getSCMClient(request)
 	if scmClient == nil {
-		_, _ = response.Write([]byte("unknown SCM type"))
+		err = errors.New("unknown SCM type")
 		return
 	}
+	delivery.Provider = scmClient.Driver.String()
 
-	webhook, err := scmClient.Webhooks.Parse(request.Request, func(webhook scm.Webhook) (string, error) {
-		return "", nil
-	})
+	ctx := context.TODO()
+	webhook, scope, err := h.verifyWebhook(ctx, scmClient, request, []byte(delivery.Payload))
 	if err != nil {
-		_, _ = response.Write([]byte(err.Error()))
 		return
 	}
+	delivery.Repository = getRepoFullName(webhook.Repository())
 
-	ctx := context.TODO()
-	found := false
 	if webhook.Kind() == scm.WebhookKindPush {
 		repo := webhook.Repository()
 		pushHook := webhook.(*scm.PushHook)
+		delivery.Ref = pushHook.Ref
 
 		pipelineList := &v1alpha3.PipelineList{}
 		if err = h.List(ctx, pipelineList); err == nil {
 			for i := range pipelineList.Items {
 				pipeline := pipelineList.Items[i]
-				if !branchMatch(pipeline, pushHook.Ref) {
+				if !scope.allows(pipeline.Namespace) || !branchMatch(pipeline, pushHook.Ref) {
 					continue
 				}
 				found = true
@@ -114,37 +165,195 @@ func (h *SCMHandler) scmWebhook(request *restful.Request, response *restful.Resp
 					}
 				} else if gitURL != "" {
 					if gitRepoMatch(gitURL, repo.Link, repo.Clone, repo.CloneSSH) {
-						err = h.createPipelineRun(pipeline, pushHook)
+						var run *v1alpha3.PipelineRun
+						if run, err = h.createPipelineRun(pipeline, pushHook, scope.verifies(pipeline.Namespace)); err == nil {
+							delivery.PipelineRuns = append(delivery.PipelineRuns, fmt.Sprintf("%s/%s", run.Namespace, run.Name))
+						}
 					} else {
 						err = fmt.Errorf("expect URL: %s, got: %v", gitURL, []string{repo.Link, repo.Clone, repo.CloneSSH})
 					}
 				}
 			}
 		}
+
+		if requested, requestErr := h.requestToLoadSources(ctx, pushHook, scope); requested {
+			found = true
+			if err == nil {
+				err = requestErr
+			}
+		}
+	}
+	if webhook.Kind() == scm.WebhookKindPullRequest {
+		pullRequestHook := webhook.(*scm.PullRequestHook)
+		delivery.Ref = pullRequestHook.PullRequest.Ref
+		found, err = h.syncPreviewEnvironments(ctx, pullRequestHook, scope)
 	}
 
-	if !found {
-		_ = response.WriteErrorString(http.StatusOK, "no pipeline matched")
+	if event := newSCMEvent(webhook, delivery); event != nil && h.dispatcher != nil {
+		event.ExcludedNamespaces = scope.denied()
+		// the Triggers which have their own secret are fired only if the signature is made by it
+		event.Proof = &trigger.Proof{Verifier: func(secret []byte) bool {
+			return verifySignature(scmClient, request, []byte(delivery.Payload), string(secret))
+		}}
+		pipelineRuns, dispatchErr := h.dispatcher.Dispatch(ctx, event)
+		if len(pipelineRuns) > 0 || dispatchErr != nil {
+			found = true
+			delivery.PipelineRuns = append(delivery.PipelineRuns, pipelineRuns...)
+		}
+		if err == nil {
+			err = dispatchErr
+		}
+	}
+	return
+}
+
+// webhookScope tells which namespaces a webhook is able to act on. The DevOpsProjects which have a webhook secret
+// only accept the webhooks signed by it, the others accept all the webhooks as before. A nil scope allows everything.
+type webhookScope struct {
+	// secured are the namespaces which have a webhook secret
+	secured map[string]bool
+	// verified are the namespaces whose webhook secret matches the signature
+	verified map[string]bool
+}
+
+func (s *webhookScope) allows(namespace string) bool {
+	return s == nil || !s.secured[namespace] || s.verified[namespace]
+}
+
+// verifies returns true if the signature matches the webhook secret of the namespace
+func (s *webhookScope) verifies(namespace string) bool {
+	return s != nil && s.verified[namespace]
+}
+
+// denied returns the namespaces whose webhook secret does not match the signature
+func (s *webhookScope) denied() (namespaces map[string]bool) {
+	if s == nil {
 		return
-	} else if err != nil {
-		_ = response.WriteError(http.StatusBadRequest, err)
-	} else {
-		_, _ = response.Write([]byte("ok"))
 	}
+	namespaces = map[string]bool{}
+	for namespace := range s.secured {
+		if !s.verified[namespace] {
+			namespaces[namespace] = true
+		}
+	}
+	return
 }
 
-func (h *SCMHandler) createPipelineRun(pipeline v1alpha3.Pipeline, hook *scm.PushHook) (err error) {
+// verifyWebhook parses the webhook, then verifies its signature by the webhook secrets of all the DevOpsProjects
+func (h *SCMHandler) verifyWebhook(ctx context.Context, scmClient *scm.Client, request *http.Request,
+	payload []byte) (webhook scm.Webhook, scope *webhookScope, err error) {
+	if webhook, err = scmClient.Webhooks.Parse(request, func(scm.Webhook) (string, error) {
+		return "", nil
+	}); err != nil {
+		return
+	}
+
+	secrets := &v1.SecretList{}
+	if err = h.List(ctx, secrets, client.MatchingLabels{webhookmodel.SecretLabelKey: "true"}); err != nil {
+		return
+	}
+	scope = &webhookScope{secured: map[string]bool{}, verified: map[string]bool{}}
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		token := string(secret.Data[webhookmodel.SecretKey])
+		if secret.Name != webhookmodel.SecretName || token == "" {
+			continue
+		}
+		scope.secured[secret.Namespace] = true
+		if verifySignature(scmClient, request, payload, token) {
+			scope.verified[secret.Namespace] = true
+		}
+	}
+	return
+}
+
+// verifySignature returns true if the webhook is signed by the token, or carries it for the providers which are
+// not able to sign the payloads
+func verifySignature(scmClient *scm.Client, request *http.Request, payload []byte, token string) bool {
+	if token == "" {
+		return false
+	}
+	request.Body = io.NopCloser(bytes.NewReader(payload))
+	_, err := scmClient.Webhooks.Parse(request, func(scm.Webhook) (string, error) {
+		return token, nil
+	})
+	return err == nil
+}
+
+func (h *SCMHandler) createPipelineRun(pipeline v1alpha3.Pipeline, hook *scm.PushHook, verified bool) (run *v1alpha3.PipelineRun, err error) {
 	branch := strings.TrimPrefix(hook.Ref, "refs/heads/")
 
 	var scmObj *v1alpha3.SCM
 	if scmObj, err = pipelinerun.CreateScm(&pipeline.Spec, branch); err == nil {
-		run := pipelinerun.CreatePipelineRun(&pipeline, &devops.RunPayload{}, scmObj)
+		run = pipelinerun.CreatePipelineRun(&pipeline, &devops.RunPayload{}, scmObj)
 		run.Annotations[triggerAnnotationKey] = "webhook"
+		run.Annotations[v1alpha3.PipelineRunSCMRevisionAnnoKey] = hook.After
+		run.Annotations[v1alpha3.PipelineRunSCMBaseRevisionAnnoKey] = hook.Before
+		run.Annotations[v1alpha3.PipelineRunSCMRepoAnnoKey] = getRepoFullName(hook.Repo)
+		if hook.Sender.Login != "" {
+			// the sender is the account on the SCM provider, it's not mapped to a KubeSphere user. Anyone is able
+			// to claim any sender in an unsigned webhook, so it's trusted only if the signature was verified
+			source := v1alpha3.TriggerIdentityUnverified
+			if verified {
+				source = v1alpha3.TriggerIdentitySCM
+			}
+			run.SetTriggerIdentity(&v1alpha3.TriggerIdentity{Username: hook.Sender.Login, Source: source})
+		}
 		err = h.Create(context.Background(), run)
 	}
 	return
 }
 
+// requestToLoadSources asks the Pipelines, which load their definitions from the pushed branch, to reload them
+func (h *SCMHandler) requestToLoadSources(ctx context.Context, hook *scm.PushHook, scope *webhookScope) (requested bool, err error) {
+	pipelineList := &v1alpha3.PipelineList{}
+	if err = h.List(ctx, pipelineList); err != nil {
+		return
+	}
+	branch := strings.TrimPrefix(hook.Ref, "refs/heads/")
+	for i := range pipelineList.Items {
+		pipeline := &pipelineList.Items[i]
+		pipelineSource := pipeline.Spec.Source
+		if pipelineSource == nil || !scope.allows(pipeline.Namespace) {
+			continue
+		}
+		if ref := pipelineSource.Ref; (ref == "" && branch != hook.Repo.Branch) || (ref != "" && ref != branch) {
+			continue
+		}
+
+		repo := &v1alpha3.GitRepository{}
+		if err = h.Get(ctx, types.NamespacedName{Namespace: pipeline.Namespace, Name: pipelineSource.GitRepository}, repo); err != nil {
+			if apierrors.IsNotFound(err) {
+				err = nil
+				continue
+			}
+			return
+		}
+		if repo.Spec.URL == "" || !gitRepoMatch(repo.Spec.URL, hook.Repo.Link, hook.Repo.Clone, hook.Repo.CloneSSH) {
+			continue
+		}
+
+		requested = true
+		patch := client.MergeFrom(pipeline.DeepCopy())
+		if pipeline.Annotations == nil {
+			pipeline.Annotations = map[string]string{}
+		}
+		pipeline.Annotations[v1alpha3.PipelineSourceRevisionAnnoKey] = hook.After
+		if err = h.Patch(ctx, pipeline, patch); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// getRepoFullName returns the repository name in the form of owner/repo
+func getRepoFullName(repo scm.Repository) string {
+	if repo.FullName != "" {
+		return repo.FullName
+	}
+	return scm.Join(repo.Namespace, repo.Name)
+}
+
 func scanJenkinsMultiBranchPipeline(pipeline v1alpha3.Pipeline, jenkins core.JenkinsCore, issue token.Issuer) (err error) {
 	var accessToken string
 	accessToken, err = issue.IssueTo(&user.DefaultInfo{Name: "admin"}, token.AccessToken, tokenExpireIn)
@@ -17,14 +17,26 @@ limitations under the License.
 package webhook
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+
 	"github.com/jenkins-x/go-scm/scm"
 	"github.com/jenkins-x/go-scm/scm/driver/bitbucket"
 	"github.com/jenkins-x/go-scm/scm/driver/github"
 	"github.com/jenkins-x/go-scm/scm/driver/gitlab"
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	webhookmodel "kubesphere.io/devops/pkg/models/webhook"
 	"net/http"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"testing"
 )
 
@@ -134,3 +146,228 @@ func Test_branchMatch(t *testing.T) {
 		})
 	}
 }
+
+func TestSCMHandler_requestToLoadSources(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	repo := &v1alpha3.GitRepository{}
+	repo.SetName("repo")
+	repo.SetNamespace("ns")
+	repo.Spec.URL = "https://github.com/octocat/hello-world"
+
+	newPipeline := func(name, gitRepository, ref string) *v1alpha3.Pipeline {
+		pipeline := &v1alpha3.Pipeline{}
+		pipeline.SetName(name)
+		pipeline.SetNamespace("ns")
+		if gitRepository != "" {
+			pipeline.Spec.Source = &v1alpha3.PipelineSource{GitRepository: gitRepository, Ref: ref}
+		}
+		return pipeline
+	}
+
+	hook := &scm.PushHook{
+		Ref:   "refs/heads/master",
+		After: "sha",
+		Repo: scm.Repository{
+			Branch: "master",
+			Link:   "https://github.com/octocat/hello-world",
+			Clone:  "https://github.com/octocat/hello-world.git",
+		},
+	}
+
+	tests := []struct {
+		name          string
+		objects       []runtime.Object
+		wantRequested bool
+		wantRequests  []string
+	}{{
+		name:    "without sources",
+		objects: []runtime.Object{repo.DeepCopy(), newPipeline("plain", "", "")},
+	}, {
+		name:    "the GitRepository does not exist",
+		objects: []runtime.Object{repo.DeepCopy(), newPipeline("missing", "missing", "")},
+	}, {
+		name: "match the default branch and the pushed branch",
+		objects: []runtime.Object{repo.DeepCopy(), newPipeline("default", "repo", ""),
+			newPipeline("master", "repo", "master"), newPipeline("dev", "repo", "dev")},
+		wantRequested: true,
+		wantRequests:  []string{"default", "master"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithRuntimeObjects(tt.objects...).Build()
+			h := &SCMHandler{Client: c}
+			requested, err := h.requestToLoadSources(context.Background(), hook, nil)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantRequested, requested)
+
+			for _, name := range tt.wantRequests {
+				pipeline := &v1alpha3.Pipeline{}
+				assert.Nil(t, c.Get(context.Background(), types.NamespacedName{Namespace: "ns", Name: name}, pipeline))
+				assert.Equal(t, "sha", pipeline.Annotations[v1alpha3.PipelineSourceRevisionAnnoKey])
+			}
+			pipeline := &v1alpha3.Pipeline{}
+			if err := c.Get(context.Background(), types.NamespacedName{Namespace: "ns", Name: "dev"}, pipeline); err == nil {
+				assert.Empty(t, pipeline.Annotations[v1alpha3.PipelineSourceRevisionAnnoKey])
+			}
+		})
+	}
+}
+
+func TestSCMHandler_syncPreviewEnvironments(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	repo := &v1alpha3.GitRepository{}
+	repo.SetName("repo")
+	repo.SetNamespace("ns")
+	repo.Spec.URL = "https://github.com/octocat/hello-world"
+	repo.Spec.Preview = &v1alpha3.PreviewPolicy{PipelineRef: "deploy-preview"}
+	otherRepo := &v1alpha3.GitRepository{}
+	otherRepo.SetName("other")
+	otherRepo.SetNamespace("ns")
+	otherRepo.Spec.URL = "https://github.com/octocat/hello-world"
+
+	newHook := func(action scm.Action, sha string) *scm.PullRequestHook {
+		return &scm.PullRequestHook{
+			Action:      action,
+			Repo:        scm.Repository{Link: "https://github.com/octocat/hello-world"},
+			PullRequest: scm.PullRequest{Number: 12, Sha: sha, Source: "feature"},
+		}
+	}
+	key := types.NamespacedName{Namespace: "ns", Name: "repo-pr-12"}
+
+	c := fake.NewClientBuilder().WithScheme(schema).WithRuntimeObjects(repo, otherRepo).Build()
+	h := &SCMHandler{Client: c}
+
+	// opened
+	requested, err := h.syncPreviewEnvironments(context.Background(), newHook(scm.ActionOpen, "sha-1"), nil)
+	assert.Nil(t, err)
+	assert.True(t, requested)
+	preview := &v1alpha3.PreviewEnvironment{}
+	if assert.Nil(t, c.Get(context.Background(), key, preview)) {
+		assert.Equal(t, "repo", preview.Spec.GitRepository)
+		assert.Equal(t, 12, preview.Spec.PullRequest)
+		assert.Equal(t, "feature", preview.Spec.Branch)
+		assert.Equal(t, "sha-1", preview.Spec.Revision)
+		assert.Equal(t, "deploy-preview", preview.Spec.PipelineRef)
+	}
+	assert.True(t, apierrors.IsNotFound(c.Get(context.Background(),
+		types.NamespacedName{Namespace: "ns", Name: "other-pr-12"}, &v1alpha3.PreviewEnvironment{})))
+
+	// pushed
+	_, err = h.syncPreviewEnvironments(context.Background(), newHook(scm.ActionSync, "sha-2"), nil)
+	assert.Nil(t, err)
+	if assert.Nil(t, c.Get(context.Background(), key, preview)) {
+		assert.Equal(t, "sha-2", preview.Spec.Revision)
+	}
+
+	// labeled
+	requested, err = h.syncPreviewEnvironments(context.Background(), newHook(scm.ActionLabel, "sha-2"), nil)
+	assert.Nil(t, err)
+	assert.False(t, requested)
+
+	// closed
+	_, err = h.syncPreviewEnvironments(context.Background(), newHook(scm.ActionClose, "sha-2"), nil)
+	assert.Nil(t, err)
+	assert.True(t, apierrors.IsNotFound(c.Get(context.Background(), key, preview)))
+}
+
+func TestSCMHandler_verifyWebhook(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+	assert.Nil(t, corev1.AddToScheme(schema))
+
+	newSecret := func(namespace, token string) *corev1.Secret {
+		secret := &corev1.Secret{}
+		secret.SetName(webhookmodel.SecretName)
+		secret.SetNamespace(namespace)
+		secret.SetLabels(map[string]string{webhookmodel.SecretLabelKey: "true"})
+		secret.Data = map[string][]byte{webhookmodel.SecretKey: []byte(token)}
+		return secret
+	}
+	payload := `{"ref":"refs/heads/master","after":"sha","repository":{"full_name":"octocat/hello-world"}}`
+	mac := hmac.New(sha1.New, []byte("s3cr3t"))
+	mac.Write([]byte(payload))
+	signature := "sha1=" + hex.EncodeToString(mac.Sum(nil))
+
+	tests := []struct {
+		name      string
+		signature string
+		wantAllow map[string]bool
+	}{{
+		name:      "signed by the secret of a project",
+		signature: signature,
+		wantAllow: map[string]bool{"signed": true, "other": false, "plain": true},
+	}, {
+		name:      "not signed",
+		wantAllow: map[string]bool{"signed": false, "other": false, "plain": true},
+	}, {
+		name:      "invalid signature",
+		signature: "sha1=invalid",
+		wantAllow: map[string]bool{"signed": false, "other": false, "plain": true},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).
+				WithObjects(newSecret("signed", "s3cr3t"), newSecret("other", "another")).Build()
+			h := &SCMHandler{Client: c}
+
+			request, _ := http.NewRequest(http.MethodPost, "/webhooks/scm", strings.NewReader(payload))
+			request.Header.Set("X-GitHub-Event", "push")
+			request.Header.Set("X-GitHub-Delivery", "1")
+			if tt.signature != "" {
+				request.Header.Set("X-Hub-Signature", tt.signature)
+			}
+			webhook, scope, err := h.verifyWebhook(context.Background(), github.NewDefault(), request, []byte(payload))
+			assert.Nil(t, err)
+			assert.NotNil(t, webhook)
+			for namespace, allow := range tt.wantAllow {
+				assert.Equal(t, allow, scope.allows(namespace), namespace)
+				assert.Equal(t, !allow, scope.denied()[namespace], namespace)
+				assert.Equal(t, allow && namespace != "plain", scope.verifies(namespace), namespace)
+			}
+		})
+	}
+}
+
+func TestSCMHandler_createPipelineRun(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	pipeline := v1alpha3.Pipeline{}
+	pipeline.SetName("fake")
+	pipeline.SetNamespace("ns")
+	hook := &scm.PushHook{
+		Ref:    "refs/heads/master",
+		Repo:   scm.Repository{Namespace: "octocat", Name: "hello-world"},
+		Sender: scm.User{Login: "octocat"},
+	}
+
+	tests := []struct {
+		name       string
+		verified   bool
+		wantSource v1alpha3.TriggerIdentitySource
+	}{{
+		name:       "signed webhook",
+		verified:   true,
+		wantSource: v1alpha3.TriggerIdentitySCM,
+	}, {
+		name:       "unsigned webhook",
+		wantSource: v1alpha3.TriggerIdentityUnverified,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &SCMHandler{Client: fake.NewClientBuilder().WithScheme(schema).Build()}
+			run, err := h.createPipelineRun(pipeline, hook, tt.verified)
+			assert.Nil(t, err)
+			identity := run.GetTriggerIdentity()
+			if assert.NotNil(t, identity) {
+				assert.Equal(t, "octocat", identity.Username)
+				assert.Equal(t, tt.wantSource, identity.Source)
+				assert.Equal(t, tt.verified, identity.IsVerified())
+			}
+		})
+	}
+}
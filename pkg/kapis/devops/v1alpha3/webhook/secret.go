@@ -0,0 +1,79 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"kubesphere.io/devops/pkg/kapis"
+	webhookmodel "kubesphere.io/devops/pkg/models/webhook"
+)
+
+// getSecret returns the status of the webhook secret of a DevOpsProject, the token is never returned by it
+func (handler *Handler) getSecret(request *restful.Request, response *restful.Response) {
+	status, err := webhookmodel.GetSecretStatus(request.Request.Context(), handler.Client,
+		request.PathParameter("namespace"))
+	if err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+	_ = response.WriteEntity(status)
+}
+
+// generateSecret creates the webhook secret of a DevOpsProject, then the GitRepository controller registers the
+// provider webhooks with it
+func (handler *Handler) generateSecret(request *restful.Request, response *restful.Response) {
+	namespace := request.PathParameter("namespace")
+	secretToken, err := webhookmodel.GenerateSecret(request.Request.Context(), handler.Client, namespace)
+	if err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			kapis.HandleConflict(response, request, fmt.Errorf("the webhook secret of %s exists already, "+
+				"please rotate it instead", namespace))
+			return
+		}
+		kapis.HandleError(request, response, err)
+		return
+	}
+	_ = response.WriteHeaderAndEntity(http.StatusCreated, secretToken)
+}
+
+// rotateSecret replaces the token of the webhook secret of a DevOpsProject, then the GitRepository controller
+// updates the provider webhooks with the new one
+func (handler *Handler) rotateSecret(request *restful.Request, response *restful.Response) {
+	secretToken, err := webhookmodel.RotateSecret(request.Request.Context(), handler.Client,
+		request.PathParameter("namespace"), time.Now())
+	if err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+	_ = response.WriteEntity(secretToken)
+}
+
+// revokeSecret deletes the webhook secret of a DevOpsProject
+func (handler *Handler) revokeSecret(request *restful.Request, response *restful.Response) {
+	if err := webhookmodel.RevokeSecret(request.Request.Context(), handler.Client,
+		request.PathParameter("namespace")); err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+	response.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+	"github.com/jenkins-zh/jenkins-client/pkg/core"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	apiserverruntime "kubesphere.io/devops/pkg/apiserver/runtime"
+	"kubesphere.io/devops/pkg/jwt/token"
+	webhookmodel "kubesphere.io/devops/pkg/models/webhook"
+)
+
+func TestWebhookSecret(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+	assert.Nil(t, v1.AddToScheme(schema))
+	c := fake.NewClientBuilder().WithScheme(schema).Build()
+
+	container := restful.NewContainer()
+	ws := apiserverruntime.NewWebService(v1alpha3.GroupVersion)
+	RegisterWebhooks(c, ws, &token.FakeIssuer{}, core.JenkinsCore{}, nil)
+	container.Add(ws)
+
+	request := func(method, uri string) *httptest.ResponseRecorder {
+		httpRequest, _ := http.NewRequest(method, "http://fake.com/kapis/devops.kubesphere.io/v1alpha3"+uri, nil)
+		httpWriter := httptest.NewRecorder()
+		container.Dispatch(httpWriter, httpRequest)
+		return httpWriter
+	}
+	getToken := func() string {
+		token, err := webhookmodel.GetSecretToken(context.TODO(), c, "ns")
+		assert.Nil(t, err)
+		return token
+	}
+
+	// there's no webhook secret
+	assert.Equal(t, http.StatusNotFound, request(http.MethodGet, "/namespaces/ns/webhooksecret").Code)
+	assert.Equal(t, http.StatusNotFound, request(http.MethodPost, "/namespaces/ns/webhooksecret/rotate").Code)
+	assert.Empty(t, getToken())
+
+	// generate
+	resp := request(http.MethodPost, "/namespaces/ns/webhooksecret")
+	assert.Equal(t, http.StatusCreated, resp.Code, resp.Body.String())
+	generated := &webhookmodel.SecretToken{}
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), generated))
+	assert.Len(t, generated.Token, 64)
+	assert.Equal(t, generated.Token, getToken())
+	assert.Equal(t, http.StatusConflict, request(http.MethodPost, "/namespaces/ns/webhooksecret").Code)
+
+	secret := &v1.Secret{}
+	assert.Nil(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "ns", Name: webhookmodel.SecretName}, secret))
+	assert.Equal(t, "true", secret.Labels[webhookmodel.SecretLabelKey])
+
+	// the token is not returned by the status
+	resp = request(http.MethodGet, "/namespaces/ns/webhooksecret")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.NotContains(t, resp.Body.String(), generated.Token)
+
+	// rotate
+	resp = request(http.MethodPost, "/namespaces/ns/webhooksecret/rotate")
+	assert.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	rotated := &webhookmodel.SecretToken{}
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), rotated))
+	assert.NotEqual(t, generated.Token, rotated.Token)
+	assert.NotNil(t, rotated.RotationTime)
+	assert.Equal(t, rotated.Token, getToken())
+
+	// revoke
+	assert.Equal(t, http.StatusNoContent, request(http.MethodDelete, "/namespaces/ns/webhooksecret").Code)
+	assert.Empty(t, getToken())
+	assert.Equal(t, http.StatusNoContent, request(http.MethodDelete, "/namespaces/ns/webhooksecret").Code)
+}
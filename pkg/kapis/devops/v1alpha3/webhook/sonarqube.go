@@ -0,0 +1,170 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/kapis"
+)
+
+// SonarSignatureHeader carries the hex HMAC-SHA256 signature of the payloads of the SonarQube webhooks
+const SonarSignatureHeader = "X-Sonar-Webhook-HMAC-SHA256"
+
+var errUnsignedQualityGate = errors.New("the quality gate requires a signed SonarQube webhook, " +
+	"please set the webhook secret of the quality gate")
+
+// sonarQubePayload is the payload of the SonarQube webhooks,
+// see also https://docs.sonarqube.org/latest/project-administration/webhooks/
+type sonarQubePayload struct {
+	TaskID  string `json:"taskId"`
+	Status  string `json:"status"`
+	Project struct {
+		Key string `json:"key"`
+		URL string `json:"url"`
+	} `json:"project"`
+	QualityGate *struct {
+		Status     string `json:"status"`
+		Conditions []struct {
+			Metric         string `json:"metric"`
+			Operator       string `json:"operator"`
+			Value          string `json:"value"`
+			Status         string `json:"status"`
+			ErrorThreshold string `json:"errorThreshold"`
+		} `json:"conditions"`
+	} `json:"qualityGate"`
+	Properties map[string]string `json:"properties"`
+}
+
+// ReceiveEventsFromSonarQube records the quality gate of the PipelineRun which did the analysis. The PipelineRun is
+// identified by the analysis property v1alpha3.SonarPipelineRunProperty, the other analyses are ignored.
+func (handler *Handler) ReceiveEventsFromSonarQube(request *restful.Request, response *restful.Response) {
+	body, err := ioutil.ReadAll(request.Request.Body)
+	if err != nil {
+		kapis.HandleBadRequest(response, request, err)
+		return
+	}
+	payload := &sonarQubePayload{}
+	if err = json.Unmarshal(body, payload); err != nil {
+		kapis.HandleBadRequest(response, request, err)
+		return
+	}
+	names := strings.Split(payload.Properties[v1alpha3.SonarPipelineRunProperty], "/")
+	if len(names) != 2 || payload.QualityGate == nil {
+		_, _ = response.Write([]byte("ignored the analysis which was not done by a PipelineRun"))
+		return
+	}
+
+	ctx := context.Background()
+	key := client.ObjectKey{Namespace: names[0], Name: names[1]}
+	pipelineRun := &v1alpha3.PipelineRun{}
+	if err = handler.Get(ctx, key, pipelineRun); err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+	policy, err := handler.getQualityGatePolicy(ctx, pipelineRun)
+	if err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+	if policy == nil || policy.ProjectKey != payload.Project.Key {
+		_, _ = response.Write([]byte("ignored the analysis which does not match the quality gate of the Pipeline"))
+		return
+	}
+	signed := policy.WebhookSecretRef != nil
+	if signed {
+		secret := &v1.Secret{}
+		if err = handler.Get(ctx, client.ObjectKey{Namespace: pipelineRun.Namespace, Name: policy.WebhookSecretRef.Name}, secret); err != nil {
+			kapis.HandleError(request, response, err)
+			return
+		}
+		if !verifySonarSignature(secret.Data[policy.WebhookSecretRef.Key], body, request.HeaderParameter(SonarSignatureHeader)) {
+			kapis.HandleUnauthorized(response, request, errors.New("invalid signature of the SonarQube webhook"))
+			return
+		}
+	} else if policy.FailOnError || pipelineRun.Status.QualityGate != nil {
+		// anyone is able to post an unsigned payload, it must not decide the result of the PipelineRun
+		kapis.HandleUnauthorized(response, request, errUnsignedQualityGate)
+		return
+	}
+
+	qualityGate := &v1alpha3.QualityGateStatus{
+		ProjectKey: payload.Project.Key,
+		TaskID:     payload.TaskID,
+		Status:     v1alpha3.QualityGateState(payload.QualityGate.Status),
+		URL:        payload.Project.URL,
+		UpdateTime: &metav1.Time{Time: time.Now()},
+	}
+	for _, condition := range payload.QualityGate.Conditions {
+		qualityGate.Conditions = append(qualityGate.Conditions, v1alpha3.QualityGateCondition{
+			Metric:         condition.Metric,
+			Comparator:     condition.Operator,
+			ErrorThreshold: condition.ErrorThreshold,
+			ActualValue:    condition.Value,
+			Status:         v1alpha3.QualityGateState(condition.Status),
+		})
+	}
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &v1alpha3.PipelineRun{}
+		if err := handler.Get(ctx, key, latest); err != nil {
+			return err
+		}
+		if !signed && latest.Status.QualityGate != nil {
+			return errUnsignedQualityGate
+		}
+		latest.Status.QualityGate = qualityGate
+		return handler.Status().Update(ctx, latest)
+	})
+	if err == errUnsignedQualityGate {
+		kapis.HandleUnauthorized(response, request, err)
+	} else if err != nil {
+		kapis.HandleError(request, response, err)
+	}
+}
+
+func (handler *Handler) getQualityGatePolicy(ctx context.Context, pipelineRun *v1alpha3.PipelineRun) (*v1alpha3.QualityGatePolicy, error) {
+	if pipelineRun.Spec.PipelineRef == nil {
+		return nil, nil
+	}
+	pipeline := &v1alpha3.Pipeline{}
+	if err := handler.Get(ctx, client.ObjectKey{Namespace: pipelineRun.Namespace, Name: pipelineRun.Spec.PipelineRef.Name}, pipeline); err != nil {
+		return nil, err
+	}
+	return pipeline.Spec.QualityGate, nil
+}
+
+func verifySonarSignature(secret, payload []byte, signature string) bool {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write(payload)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(strings.ToLower(signature)))
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+	"github.com/jenkins-zh/jenkins-client/pkg/core"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	apiserverruntime "kubesphere.io/devops/pkg/apiserver/runtime"
+	"kubesphere.io/devops/pkg/jwt/token"
+)
+
+func TestSonarQubeWebhook(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+	assert.Nil(t, v1.AddToScheme(schema))
+
+	payload := `{"taskId":"task-1","status":"SUCCESS","project":{"key":"app","url":"https://sonar.example.com/dashboard?id=app"},
+"qualityGate":{"name":"Sonar way","status":"ERROR","conditions":[{"metric":"new_coverage","operator":"LESS_THAN",
+"value":"62.5","status":"ERROR","errorThreshold":"80"}]},"properties":{"sonar.analysis.pipelineRun":"ns/build-1"}}`
+	sign := func(secret string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		_, _ = mac.Write([]byte(payload))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	newPipeline := func(policy *v1alpha3.QualityGatePolicy) *v1alpha3.Pipeline {
+		return &v1alpha3.Pipeline{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "build"},
+			Spec:       v1alpha3.PipelineSpec{Type: v1alpha3.NoScmPipelineType, QualityGate: policy},
+		}
+	}
+	secretPolicy := &v1alpha3.QualityGatePolicy{ProjectKey: "app", WebhookSecretRef: &v1.SecretKeySelector{
+		LocalObjectReference: v1.LocalObjectReference{Name: "sonar"}, Key: "secret",
+	}}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "sonar"},
+		Data:       map[string][]byte{"secret": []byte("s3cr3t")},
+	}
+
+	tests := []struct {
+		name        string
+		payload     string
+		signature   string
+		pipeline    *v1alpha3.Pipeline
+		recorded    *v1alpha3.QualityGateStatus
+		wantCode    int
+		wantUpdated bool
+	}{{
+		name:     "the analysis was not done by a PipelineRun",
+		payload:  `{"taskId":"task-1","project":{"key":"app"},"qualityGate":{"status":"OK"}}`,
+		pipeline: newPipeline(&v1alpha3.QualityGatePolicy{ProjectKey: "app"}),
+		wantCode: http.StatusOK,
+	}, {
+		name:        "record the quality gate",
+		payload:     payload,
+		pipeline:    newPipeline(&v1alpha3.QualityGatePolicy{ProjectKey: "app"}),
+		wantCode:    http.StatusOK,
+		wantUpdated: true,
+	}, {
+		name:     "the project does not match the quality gate",
+		payload:  payload,
+		pipeline: newPipeline(&v1alpha3.QualityGatePolicy{ProjectKey: "other"}),
+		wantCode: http.StatusOK,
+	}, {
+		name:        "valid signature",
+		payload:     payload,
+		signature:   sign("s3cr3t"),
+		pipeline:    newPipeline(secretPolicy),
+		wantCode:    http.StatusOK,
+		wantUpdated: true,
+	}, {
+		name:      "invalid signature",
+		payload:   payload,
+		signature: sign("wrong"),
+		pipeline:  newPipeline(secretPolicy),
+		wantCode:  http.StatusUnauthorized,
+	}, {
+		name:     "unsigned payload is not able to fail the PipelineRun",
+		payload:  payload,
+		pipeline: newPipeline(&v1alpha3.QualityGatePolicy{ProjectKey: "app", FailOnError: true}),
+		wantCode: http.StatusUnauthorized,
+	}, {
+		name:     "unsigned payload is not able to overwrite the quality gate",
+		payload:  payload,
+		pipeline: newPipeline(&v1alpha3.QualityGatePolicy{ProjectKey: "app"}),
+		recorded: &v1alpha3.QualityGateStatus{ProjectKey: "app", TaskID: "task-0", Status: v1alpha3.QualityGateOK},
+		wantCode: http.StatusUnauthorized,
+	}, {
+		name:        "signed payload overwrites the quality gate",
+		payload:     payload,
+		signature:   sign("s3cr3t"),
+		pipeline:    newPipeline(secretPolicy),
+		recorded:    &v1alpha3.QualityGateStatus{ProjectKey: "app", TaskID: "task-0", Status: v1alpha3.QualityGateOK},
+		wantCode:    http.StatusOK,
+		wantUpdated: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipelineRun := &v1alpha3.PipelineRun{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "build-1"},
+				Spec:       v1alpha3.PipelineRunSpec{PipelineRef: &v1.ObjectReference{Name: "build"}},
+				Status:     v1alpha3.PipelineRunStatus{QualityGate: tt.recorded},
+			}
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(tt.pipeline, pipelineRun, secret.DeepCopy()).Build()
+
+			container := restful.NewContainer()
+			ws := apiserverruntime.NewWebService(v1alpha3.GroupVersion)
+			RegisterWebhooks(c, ws, &token.FakeIssuer{}, core.JenkinsCore{}, nil)
+			container.Add(ws)
+
+			httpRequest, _ := http.NewRequest(http.MethodPost,
+				"http://fake.com/kapis/devops.kubesphere.io/v1alpha3/webhooks/sonarqube", strings.NewReader(tt.payload))
+			httpRequest.Header.Set("Content-Type", "application/json")
+			if tt.signature != "" {
+				httpRequest.Header.Set(SonarSignatureHeader, tt.signature)
+			}
+			httpWriter := httptest.NewRecorder()
+			container.Dispatch(httpWriter, httpRequest)
+			assert.Equal(t, tt.wantCode, httpWriter.Code)
+
+			result := &v1alpha3.PipelineRun{}
+			assert.Nil(t, c.Get(context.Background(), client.ObjectKeyFromObject(pipelineRun), result))
+			if !tt.wantUpdated {
+				assert.Equal(t, tt.recorded, result.Status.QualityGate)
+				return
+			}
+			qualityGate := result.Status.QualityGate
+			if assert.NotNil(t, qualityGate) {
+				assert.Equal(t, "task-1", qualityGate.TaskID)
+				assert.Equal(t, v1alpha3.QualityGateError, qualityGate.Status)
+				assert.Equal(t, "https://sonar.example.com/dashboard?id=app", qualityGate.URL)
+				assert.Equal(t, []v1alpha3.QualityGateCondition{{
+					Metric: "new_coverage", Comparator: "LESS_THAN", ErrorThreshold: "80", ActualValue: "62.5", Status: v1alpha3.QualityGateError,
+				}}, qualityGate.Conditions)
+			}
+		})
+	}
+}
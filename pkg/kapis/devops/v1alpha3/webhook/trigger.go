@@ -0,0 +1,156 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"io"
+	"strings"
+
+	"github.com/emicklei/go-restful"
+	"github.com/jenkins-x/go-scm/scm"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/kapis"
+	webhookmodel "kubesphere.io/devops/pkg/models/webhook"
+	"kubesphere.io/devops/pkg/trigger"
+)
+
+// TriggerResult is the outcome of dispatching the events to the Triggers
+type TriggerResult struct {
+	// PipelineRuns are the created PipelineRuns in the form of namespace/name
+	PipelineRuns []string `json:"pipelineRuns"`
+	// Errors are the reasons of the Triggers which failed to fire
+	Errors []string `json:"errors,omitempty"`
+}
+
+type triggerHandler struct {
+	dispatcher *trigger.Dispatcher
+}
+
+func newTriggerHandler(genericClient client.Client) *triggerHandler {
+	return &triggerHandler{dispatcher: trigger.NewDispatcher(genericClient)}
+}
+
+func (h *triggerHandler) receiveImagePush(request *restful.Request, response *restful.Response) {
+	payload, err := io.ReadAll(request.Request.Body)
+	if err != nil {
+		kapis.HandleBadRequest(response, request, err)
+		return
+	}
+	events, err := trigger.ParseImagePushEvents(payload)
+	if err != nil {
+		kapis.HandleBadRequest(response, request, err)
+		return
+	}
+	proof := newProof(request, payload)
+	for _, event := range events {
+		event.Proof = proof
+	}
+	h.dispatch(request, response, events...)
+}
+
+func (h *triggerHandler) receiveCloudEvent(request *restful.Request, response *restful.Response) {
+	payload, err := io.ReadAll(request.Request.Body)
+	if err != nil {
+		kapis.HandleBadRequest(response, request, err)
+		return
+	}
+	event, err := trigger.ParseCloudEvent(request.Request.Header, payload)
+	if err != nil {
+		kapis.HandleBadRequest(response, request, err)
+		return
+	}
+	event.Proof = newProof(request, payload)
+	h.dispatch(request, response, event)
+}
+
+// newProof collects the signature or the token of the request, the Triggers are fired only if it matches their secrets
+func newProof(request *restful.Request, payload []byte) *trigger.Proof {
+	return &trigger.Proof{
+		Payload:   payload,
+		Signature: request.HeaderParameter(trigger.SignatureHeader),
+		Token:     request.QueryParameter("token"),
+	}
+}
+
+func (h *triggerHandler) dispatch(request *restful.Request, response *restful.Response, events ...*trigger.Event) {
+	result := &TriggerResult{PipelineRuns: []string{}}
+	for _, event := range events {
+		pipelineRuns, err := h.dispatcher.Dispatch(request.Request.Context(), event)
+		result.PipelineRuns = append(result.PipelineRuns, pipelineRuns...)
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+		}
+	}
+	_ = response.WriteEntity(result)
+}
+
+// newSCMEvent converts the push and pull request webhooks into the events of the Triggers, nil is returned for others
+func newSCMEvent(webhook scm.Webhook, delivery *webhookmodel.Delivery) (event *trigger.Event) {
+	repo := webhook.Repository()
+	event = &trigger.Event{
+		SourceType: v1alpha3.TriggerSourceWebhook,
+		Source:     delivery.Provider,
+		Repository: getRepoFullName(repo),
+		URL:        repo.Link,
+		Headers:    delivery.Headers,
+		Time:       delivery.Time,
+	}
+	event.SetPayload([]byte(delivery.Payload))
+
+	switch hook := webhook.(type) {
+	case *scm.PushHook:
+		event.Type = trigger.EventPush
+		event.Ref = hook.Ref
+		event.Revision = hook.After
+		event.BaseRevision = hook.Before
+		if strings.HasPrefix(hook.Ref, "refs/tags/") {
+			event.Type = trigger.EventTag
+			event.Tag = strings.TrimPrefix(hook.Ref, "refs/tags/")
+		} else {
+			event.Branch = strings.TrimPrefix(hook.Ref, "refs/heads/")
+		}
+		event.Changes = getChangedFiles(hook.Commits)
+	case *scm.PullRequestHook:
+		event.Type = trigger.EventPullRequest
+		event.Ref = hook.PullRequest.Ref
+		event.Branch = hook.PullRequest.Source
+		event.Revision = hook.PullRequest.Sha
+		event.BaseRevision = hook.PullRequest.Base.Sha
+	default:
+		event = nil
+	}
+	return
+}
+
+// getChangedFiles returns the distinct paths which were added, modified or removed by the commits
+func getChangedFiles(commits []scm.PushCommit) (files []string) {
+	files = []string{}
+	found := map[string]bool{}
+	for _, commit := range commits {
+		for _, group := range [][]string{commit.Added, commit.Modified, commit.Removed} {
+			for _, file := range group {
+				if !found[file] {
+					found[file] = true
+					files = append(files, file)
+				}
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+	"github.com/jenkins-zh/jenkins-client/pkg/core"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/controllers/callback"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	apiserverruntime "kubesphere.io/devops/pkg/apiserver/runtime"
+	"kubesphere.io/devops/pkg/jwt/token"
+	"kubesphere.io/devops/pkg/trigger"
+)
+
+func TestTriggers(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+	assert.Nil(t, v1.AddToScheme(schema))
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "trigger-secret"},
+		Data:       map[string][]byte{"token": []byte("s3cr3t")},
+	}
+	secretRef := &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: secret.Name}, Key: "token"}
+	pipeline := &v1alpha3.Pipeline{
+		TypeMeta:   metav1.TypeMeta{Kind: "Pipeline", APIVersion: v1alpha3.GroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pipeline"},
+		Spec:       v1alpha3.PipelineSpec{Type: v1alpha3.NoScmPipelineType},
+	}
+	newTrigger := func(name string, source v1alpha3.TriggerSourceType, filter string,
+		parameters ...v1alpha3.TriggerParameter) *v1alpha3.Trigger {
+		return &v1alpha3.Trigger{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name},
+			Spec: v1alpha3.TriggerSpec{
+				Pipeline:   pipeline.Name,
+				Source:     v1alpha3.TriggerSource{Type: source, SecretRef: secretRef},
+				Filter:     filter,
+				Parameters: parameters,
+			},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		trigger      *v1alpha3.Trigger
+		uri          string
+		header       map[string]string
+		body         string
+		expectedCode int
+		expectedRuns int
+		expectedBody string
+		expectedArgs []v1alpha3.Parameter
+	}{{
+		name: "image push",
+		trigger: newTrigger("image", v1alpha3.TriggerSourceImagePush, `event.tag == "latest"`,
+			v1alpha3.TriggerParameter{Name: "IMAGE", Expression: `event.image + ":" + event.tag`}),
+		uri:          "/webhooks/registry?token=s3cr3t",
+		body:         `{"push_data":{"tag":"latest"},"repository":{"repo_name":"alice/app"}}`,
+		expectedCode: http.StatusOK,
+		expectedRuns: 1,
+		expectedArgs: []v1alpha3.Parameter{{Name: "IMAGE", Value: "docker.io/alice/app:latest"}},
+	}, {
+		name:         "image push without the token",
+		trigger:      newTrigger("image", v1alpha3.TriggerSourceImagePush, ""),
+		uri:          "/webhooks/registry",
+		body:         `{"push_data":{"tag":"latest"},"repository":{"repo_name":"alice/app"}}`,
+		expectedCode: http.StatusOK,
+	}, {
+		name: "image push to the Trigger without a secret",
+		trigger: func() *v1alpha3.Trigger {
+			trigger := newTrigger("image", v1alpha3.TriggerSourceImagePush, "")
+			trigger.Spec.Source.SecretRef = nil
+			return trigger
+		}(),
+		uri:          "/webhooks/registry?token=s3cr3t",
+		body:         `{"push_data":{"tag":"latest"},"repository":{"repo_name":"alice/app"}}`,
+		expectedCode: http.StatusOK,
+	}, {
+		name:         "unknown registry",
+		trigger:      newTrigger("image", v1alpha3.TriggerSourceImagePush, ""),
+		uri:          "/webhooks/registry",
+		body:         `{}`,
+		expectedCode: http.StatusBadRequest,
+	}, {
+		name: "CloudEvent",
+		trigger: newTrigger("release", v1alpha3.TriggerSourceCloudEvent, `event.type == "com.example.release"`,
+			v1alpha3.TriggerParameter{Name: "VERSION", Expression: "event.body.version"}),
+		uri: "/webhooks/cloudevents",
+		header: map[string]string{"Ce-Type": "com.example.release", "Ce-Source": "/release", "Ce-Id": "1",
+			trigger.SignatureHeader: callback.Sign([]byte("s3cr3t"), []byte(`{"version":"v1"}`))},
+		body:         `{"version":"v1"}`,
+		expectedCode: http.StatusOK,
+		expectedRuns: 1,
+		expectedArgs: []v1alpha3.Parameter{{Name: "VERSION", Value: "v1"}},
+	}, {
+		name:    "CloudEvent with an invalid signature",
+		trigger: newTrigger("release", v1alpha3.TriggerSourceCloudEvent, ""),
+		uri:     "/webhooks/cloudevents",
+		header: map[string]string{"Ce-Type": "com.example.release", "Ce-Source": "/release", "Ce-Id": "1",
+			trigger.SignatureHeader: callback.Sign([]byte("guess"), []byte(`{"version":"v1"}`))},
+		body:         `{"version":"v1"}`,
+		expectedCode: http.StatusOK,
+	}, {
+		name:         "CloudEvent without the type",
+		trigger:      newTrigger("release", v1alpha3.TriggerSourceCloudEvent, ""),
+		uri:          "/webhooks/cloudevents",
+		body:         `{"version":"v1"}`,
+		expectedCode: http.StatusBadRequest,
+	}, {
+		name: "SCM push",
+		trigger: newTrigger("master", v1alpha3.TriggerSourceWebhook, `event.type == "push" && event.branch == "master"`,
+			v1alpha3.TriggerParameter{Name: "REVISION", Expression: "event.revision"}),
+		uri:          "/webhooks/scm",
+		header:       map[string]string{"X-Gitlab-Event": "Push Hook", "X-Gitlab-Token": "s3cr3t"},
+		body:         gitlabWebhookBody,
+		expectedCode: http.StatusOK,
+		expectedRuns: 1,
+		expectedBody: "ok",
+		expectedArgs: []v1alpha3.Parameter{{Name: "REVISION", Value: "bd4f171cec5c6f9b8b184107ce318bf9a54dce26"}},
+	}, {
+		name:         "unsigned SCM push to the Trigger with a secret",
+		trigger:      newTrigger("master", v1alpha3.TriggerSourceWebhook, ""),
+		uri:          "/webhooks/scm",
+		header:       map[string]string{"X-Gitlab-Event": "Push Hook"},
+		body:         gitlabWebhookBody,
+		expectedCode: http.StatusOK,
+	}, {
+		name:         "SCM push with a wrong token",
+		trigger:      newTrigger("master", v1alpha3.TriggerSourceWebhook, ""),
+		uri:          "/webhooks/scm",
+		header:       map[string]string{"X-Gitlab-Event": "Push Hook", "X-Gitlab-Token": "guess"},
+		body:         gitlabWebhookBody,
+		expectedCode: http.StatusOK,
+	}, {
+		name: "unsigned SCM push to the Trigger without a secret",
+		trigger: func() *v1alpha3.Trigger {
+			trigger := newTrigger("master", v1alpha3.TriggerSourceWebhook, "")
+			trigger.Spec.Source.SecretRef = nil
+			return trigger
+		}(),
+		uri:          "/webhooks/scm",
+		header:       map[string]string{"X-Gitlab-Event": "Push Hook"},
+		body:         gitlabWebhookBody,
+		expectedCode: http.StatusOK,
+		expectedRuns: 1,
+	}, {
+		name:         "SCM push does not pass the filter",
+		trigger:      newTrigger("docs", v1alpha3.TriggerSourceWebhook, `event.branch == "docs"`),
+		uri:          "/webhooks/scm",
+		header:       map[string]string{"X-Gitlab-Event": "Push Hook"},
+		body:         gitlabWebhookBody,
+		expectedCode: http.StatusOK,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(pipeline.DeepCopy(), secret.DeepCopy(), tt.trigger).Build()
+			container := restful.NewContainer()
+			ws := apiserverruntime.NewWebService(v1alpha3.GroupVersion)
+			RegisterWebhooks(c, ws, &token.FakeIssuer{}, core.JenkinsCore{}, nil)
+			container.Add(ws)
+
+			httpRequest, _ := http.NewRequest(http.MethodPost, "http://fake.com/kapis/devops.kubesphere.io/v1alpha3"+tt.uri,
+				strings.NewReader(tt.body))
+			httpRequest.Header.Set("Content-Type", "application/json")
+			for k, v := range tt.header {
+				httpRequest.Header.Set(k, v)
+			}
+			httpWriter := httptest.NewRecorder()
+			container.Dispatch(httpWriter, httpRequest)
+			assert.Equal(t, tt.expectedCode, httpWriter.Code)
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, httpWriter.Body.String())
+			}
+
+			runList := &v1alpha3.PipelineRunList{}
+			assert.Nil(t, c.List(context.Background(), runList, client.InNamespace("ns")))
+			if !assert.Len(t, runList.Items, tt.expectedRuns) || tt.expectedRuns == 0 {
+				return
+			}
+			assert.Equal(t, tt.trigger.Name, runList.Items[0].Labels[v1alpha3.TriggerLabelKey])
+			assert.Equal(t, tt.expectedArgs, runList.Items[0].Spec.Parameters)
+			if tt.uri != "/webhooks/scm" {
+				result := &TriggerResult{}
+				data, _ := io.ReadAll(httpWriter.Body)
+				assert.Nil(t, json.Unmarshal(data, result))
+				assert.Equal(t, []string{"ns/" + runList.Items[0].Name}, result.PipelineRuns)
+			}
+		})
+	}
+}
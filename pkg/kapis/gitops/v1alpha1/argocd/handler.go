@@ -17,6 +17,8 @@ package argocd
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"github.com/emicklei/go-restful"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -39,6 +41,10 @@ var invalidRequestBodyError = restful.NewError(http.StatusBadRequest,
 	"invalid application sync request")
 var unauthenticatedError = restful.NewError(http.StatusUnauthorized,
 	"unauthenticated request")
+var invalidRollbackRequestBodyError = restful.NewError(http.StatusBadRequest,
+	"invalid application rollback request")
+var autoSyncEnabledError = restful.NewError(http.StatusBadRequest,
+	"rollback cannot be initiated when auto-sync is enabled")
 
 func (h *handler) createApplication(req *restful.Request, res *restful.Response) {
 	var err error
@@ -157,6 +163,73 @@ func (h *handler) syncApplication(namespace, name string, syncRequest *Applicati
 	return h.updateOperation(namespace, name, operation)
 }
 
+func (h *handler) handleRollbackApplication(req *restful.Request, res *restful.Response) {
+	namespace := common.GetPathParameter(req, common.NamespacePathParameter)
+	name := common.GetPathParameter(req, pathParameterApplication)
+
+	rollbackRequest := &ApplicationRollbackRequest{}
+	if err := req.ReadEntity(rollbackRequest); err != nil {
+		common.Response(req, res, nil, invalidRollbackRequestBodyError)
+		return
+	}
+
+	currentUser, ok := apiserverrequest.UserFrom(req.Request.Context())
+	if !ok || currentUser == nil {
+		common.Response(req, res, nil, unauthenticatedError)
+		return
+	}
+
+	app, err := h.rollbackApplication(namespace, name, rollbackRequest, currentUser)
+	common.Response(req, res, app, err)
+}
+
+// argoApplicationHistory is the deployment history in the status of an Argo CD Application
+type argoApplicationHistory struct {
+	History []struct {
+		ID       int64                       `json:"id"`
+		Revision string                      `json:"revision"`
+		Source   *v1alpha1.ApplicationSource `json:"source,omitempty"`
+	} `json:"history"`
+}
+
+// rollbackApplication syncs the application to the revision and source of a deployed revision, the same as Argo CD does
+func (h *handler) rollbackApplication(namespace, name string, rollbackRequest *ApplicationRollbackRequest, currentUser user.Info) (*v1alpha1.Application, error) {
+	app := &v1alpha1.Application{}
+	if err := h.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: name}, app); err != nil {
+		return nil, err
+	}
+	if app.Spec.ArgoApp == nil {
+		return nil, argoAppNotConfiguredError
+	}
+	if syncPolicy := app.Spec.ArgoApp.Spec.SyncPolicy; syncPolicy != nil && syncPolicy.Automated != nil {
+		return nil, autoSyncEnabledError
+	}
+
+	history := &argoApplicationHistory{}
+	if app.Status.ArgoApp != "" {
+		if err := json.Unmarshal([]byte(app.Status.ArgoApp), history); err != nil {
+			return nil, err
+		}
+	}
+	for i := range history.History {
+		deployed := history.History[i]
+		if deployed.ID != rollbackRequest.ID {
+			continue
+		}
+		return h.updateOperation(namespace, name, &v1alpha1.Operation{
+			Sync: &v1alpha1.SyncOperation{
+				Revision: deployed.Revision,
+				Source:   deployed.Source,
+				Prune:    rollbackRequest.Prune,
+				DryRun:   rollbackRequest.DryRun,
+			},
+			InitiatedBy: v1alpha1.OperationInitiator{Username: currentUser.GetName()},
+		})
+	}
+	return nil, restful.NewError(http.StatusNotFound,
+		fmt.Sprintf("the revision %d is not found in the deployment history", rollbackRequest.ID))
+}
+
 func (h *handler) updateOperation(namespace, name string, operation *v1alpha1.Operation) (*v1alpha1.Application, error) {
 	var app *v1alpha1.Application
 	return app, utilretry.RetryOnConflict(utilretry.DefaultRetry, func() error {
@@ -217,6 +217,117 @@ func Test_handler_handleSyncApplication(t *testing.T) {
 	}
 }
 
+func Test_handler_handleRollbackApplication(t *testing.T) {
+	const history = `{"history":[{"id":1,"revision":"old","source":{"repoURL":"https://github.com/org/app","path":"manifests"}},{"id":2,"revision":"new"}]}`
+	createApp := func(name string, automated bool) *v1alpha1.Application {
+		app := &v1alpha1.Application{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "fake-namespace",
+			},
+			Spec: v1alpha1.ApplicationSpec{
+				ArgoApp: &v1alpha1.ArgoApplication{},
+			},
+			Status: v1alpha1.ApplicationStatus{ArgoApp: history},
+		}
+		if automated {
+			app.Spec.ArgoApp.Spec.SyncPolicy = &v1alpha1.SyncPolicy{Automated: &v1alpha1.SyncPolicyAutomated{}}
+		}
+		return app
+	}
+	createRequest := func(name string, rollbackRequest *ApplicationRollbackRequest, withUser bool) *restful.Request {
+		var body io.Reader
+		if rollbackRequest != nil {
+			bodyJSON, err := json.Marshal(rollbackRequest)
+			assert.NoError(t, err)
+			body = bytes.NewBuffer(bodyJSON)
+		}
+		testReq := httptest.NewRequest(http.MethodPost, "/applications/app/rollback", body)
+		testReq.Header.Set(restful.HEADER_ContentType, restful.MIME_JSON)
+		if withUser {
+			ctx := request.WithUser(testReq.Context(), &user.DefaultInfo{
+				Name: "fake-user",
+			})
+			testReq = testReq.WithContext(ctx)
+		}
+		req := restful.NewRequest(testReq)
+		req.PathParameters()[common.NamespacePathParameter.Data().Name] = "fake-namespace"
+		req.PathParameters()[pathParameterApplication.Data().Name] = name
+		return req
+	}
+	tests := []struct {
+		name             string
+		apps             []v1alpha1.Application
+		req              *restful.Request
+		wantResponseCode int
+		verifyResponse   func(t *testing.T, response string)
+	}{{
+		name:             "Should return bad request error if rollback request is nil",
+		apps:             []v1alpha1.Application{*createApp("fake-app", false)},
+		req:              createRequest("fake-app", nil, true),
+		wantResponseCode: http.StatusBadRequest,
+		verifyResponse: func(t *testing.T, response string) {
+			assert.Contains(t, response, invalidRollbackRequestBodyError.Error())
+		},
+	}, {
+		name:             "Should return 401 if unauthenticated user requests this endpoint",
+		apps:             []v1alpha1.Application{*createApp("fake-app", false)},
+		req:              createRequest("fake-app", &ApplicationRollbackRequest{ID: 1}, false),
+		wantResponseCode: http.StatusUnauthorized,
+		verifyResponse: func(t *testing.T, response string) {
+			assert.Contains(t, response, unauthenticatedError.Error())
+		},
+	}, {
+		name:             "Should return 400 if auto-sync is enabled",
+		apps:             []v1alpha1.Application{*createApp("fake-app", true)},
+		req:              createRequest("fake-app", &ApplicationRollbackRequest{ID: 1}, true),
+		wantResponseCode: http.StatusBadRequest,
+		verifyResponse: func(t *testing.T, response string) {
+			assert.Contains(t, response, autoSyncEnabledError.Error())
+		},
+	}, {
+		name:             "Should return 404 if the revision is not in the history",
+		apps:             []v1alpha1.Application{*createApp("fake-app", false)},
+		req:              createRequest("fake-app", &ApplicationRollbackRequest{ID: 3}, true),
+		wantResponseCode: http.StatusNotFound,
+		verifyResponse: func(t *testing.T, response string) {
+			assert.Contains(t, response, "the revision 3 is not found")
+		},
+	}, {
+		name:             "Should sync to the deployed revision",
+		apps:             []v1alpha1.Application{*createApp("fake-app", false)},
+		req:              createRequest("fake-app", &ApplicationRollbackRequest{ID: 1, Prune: true}, true),
+		wantResponseCode: http.StatusOK,
+		verifyResponse: func(t *testing.T, response string) {
+			gotApp := &v1alpha1.Application{}
+			err := json.Unmarshal([]byte(response), gotApp)
+			assert.NoError(t, err)
+			gotOp := gotApp.Spec.ArgoApp.Operation
+			assert.NotNil(t, gotOp)
+			assert.Equal(t, "old", gotOp.Sync.Revision)
+			assert.Equal(t, "manifests", gotOp.Sync.Source.Path)
+			assert.True(t, gotOp.Sync.Prune)
+			assert.Equal(t, "fake-user", gotOp.InitiatedBy.Username)
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utilruntime.Must(v1alpha1.AddToScheme(scheme.Scheme))
+			fakeClient := fake.NewFakeClientWithScheme(scheme.Scheme, gitops.ToObjects(tt.apps)...)
+			h := &handler{
+				Handler: &gitops.Handler{Client: fakeClient},
+			}
+
+			recorder := httptest.NewRecorder()
+			resp := restful.NewResponse(recorder)
+			resp.SetRequestAccepts(restful.MIME_JSON)
+			h.handleRollbackApplication(tt.req, resp)
+			assert.Equal(t, tt.wantResponseCode, recorder.Code)
+			tt.verifyResponse(t, recorder.Body.String())
+		})
+	}
+}
+
 func Test_handler_updateOperation(t *testing.T) {
 	app := &v1alpha1.Application{
 		ObjectMeta: metav1.ObjectMeta{
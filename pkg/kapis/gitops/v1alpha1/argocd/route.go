@@ -60,6 +60,14 @@ type ApplicationSyncRequest struct {
 	SyncOptions   *v1alpha1.SyncOptions            `json:"syncOptions,omitempty"`
 }
 
+// ApplicationRollbackRequest is a request to roll back an application to a deployed revision.
+type ApplicationRollbackRequest struct {
+	// ID is the ID of the revision in the deployment history of the application
+	ID     int64 `json:"id"`
+	DryRun bool  `json:"dryRun"`
+	Prune  bool  `json:"prune"`
+}
+
 // RegisterRoutes is for registering Argo CD Application routes into WebService.
 func RegisterRoutes(service *restful.WebService, options *common.Options, argoOption *config.ArgoCDOption) {
 	handler := newHandler(options, argoOption)
@@ -104,6 +112,14 @@ func RegisterRoutes(service *restful.WebService, options *common.Options, argoOp
 		Doc("Sync a particular application manually").
 		Returns(http.StatusOK, api.StatusOK, v1alpha1.Application{}))
 
+	service.Route(service.POST("/namespaces/{namespace}/applications/{application}/rollback").
+		To(handler.handleRollbackApplication).
+		Param(common.NamespacePathParameter).
+		Param(pathParameterApplication).
+		Reads(ApplicationRollbackRequest{}).
+		Doc("Roll back a particular application to a revision of its deployment history").
+		Returns(http.StatusOK, api.StatusOK, v1alpha1.Application{}))
+
 	service.Route(service.DELETE("/namespaces/{namespace}/applications/{application}").
 		To(handler.DelApplication).
 		Param(common.NamespacePathParameter).
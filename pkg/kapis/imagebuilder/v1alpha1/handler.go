@@ -104,6 +104,14 @@ func (h *apiHandler) createImagebuild(request *restful.Request, response *restfu
 	}
 	build.Annotations[shbuild.AnnotationBuildRunDeletion] = "false"
 
+	if build.Namespace == "" {
+		build.Namespace = request.PathParameter("namespace")
+	}
+	if err := h.setS2iBinarySource(context.Background(), &build, request.QueryParameter(s2iBinaryQueryParam)); err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+
 	if err := h.client.Create(context.Background(), &build); err != nil {
 		kapis.HandleError(request, response, err)
 		return
@@ -154,6 +162,10 @@ func (h *apiHandler) updateImagebuild(request *restful.Request, response *restfu
 		kapis.HandleBadRequest(response, request, err)
 		return
 	}
+	if err := h.setS2iBinarySource(context.Background(), &oldBuild, request.QueryParameter(s2iBinaryQueryParam)); err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
 
 	if err := h.client.Update(context.Background(), &oldBuild); err != nil {
 		kapis.HandleError(request, response, err)
@@ -69,6 +69,7 @@ func registerRoutes(ws *restful.WebService, devopsClient devopsClient.Interface,
 		To(handler.createImagebuild).
 		Doc("Create an imagebuild").
 		Param(ws.PathParameter("namespace", "Namespace of the imagebuild")).
+		Param(ws.QueryParameter(s2iBinaryQueryParam, "Name of the uploaded S2iBinary which is the source archive of the imagebuild")).
 		Reads(shbuild.Build{}).
 		Returns(http.StatusCreated, api.StatusOK, shbuild.Build{}).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsImageBuilder}))
@@ -101,6 +102,7 @@ func registerRoutes(ws *restful.WebService, devopsClient devopsClient.Interface,
 		Doc("Update an imagebuild").
 		Param(ws.PathParameter("namespace", "Namespace of the imagebuild")).
 		Param(ws.PathParameter("imagebuild", "Name of the imagebuild")).
+		Param(ws.QueryParameter(s2iBinaryQueryParam, "Name of the uploaded S2iBinary which is the source archive of the imagebuild")).
 		Reads(shbuild.Build{}).
 		Returns(http.StatusCreated, api.StatusOK, shbuild.Build{}).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsImageBuilder}))
@@ -0,0 +1,65 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	shbuild "github.com/shipwright-io/build/pkg/apis/build/v1alpha1"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// s2iBinaryQueryParam is the query parameter of the S2iBinary which is the source archive of an imagebuild
+	s2iBinaryQueryParam = "s2ibinary"
+	// s2iBinarySourceName is the name of the build source which downloads the S2iBinary
+	s2iBinarySourceName = "s2ibinary"
+)
+
+// setS2iBinarySource makes the uploaded S2iBinary as the HTTP source of the build, nothing happens if the name is empty
+func (h *apiHandler) setS2iBinarySource(ctx context.Context, build *shbuild.Build, name string) (err error) {
+	if name == "" {
+		return
+	}
+
+	s2iBinary := &v1alpha1.S2iBinary{}
+	if err = h.client.Get(ctx, client.ObjectKey{Namespace: build.Namespace, Name: name}, s2iBinary); err != nil {
+		return
+	}
+	if s2iBinary.Status.Phase != v1alpha1.StatusReady || s2iBinary.Spec.DownloadURL == "" {
+		err = restful.NewError(http.StatusBadRequest, fmt.Sprintf("the S2iBinary %s is not ready", name))
+		return
+	}
+
+	source := shbuild.BuildSource{
+		Name: s2iBinarySourceName,
+		Type: shbuild.HTTP,
+		URL:  s2iBinary.Spec.DownloadURL,
+	}
+	for i := range build.Spec.Sources {
+		if build.Spec.Sources[i].Name == s2iBinarySourceName {
+			build.Spec.Sources[i] = source
+			return
+		}
+	}
+	build.Spec.Sources = append(build.Spec.Sources, source)
+	return
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+
+	shbuild "github.com/shipwright-io/build/pkg/apis/build/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSetS2iBinarySource(t *testing.T) {
+	schema, err := v1alpha1.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	ready := &v1alpha1.S2iBinary{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "ready"},
+		Spec:       v1alpha1.S2iBinarySpec{DownloadURL: "http://fake/app.tar.gz"},
+		Status:     v1alpha1.S2iBinaryStatus{Phase: v1alpha1.StatusReady},
+	}
+	uploading := &v1alpha1.S2iBinary{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "uploading"},
+		Status:     v1alpha1.S2iBinaryStatus{Phase: v1alpha1.StatusUploading},
+	}
+	h := newAPIHandler(apiHandlerOption{
+		client: fake.NewClientBuilder().WithScheme(schema).WithObjects(ready, uploading).Build(),
+	})
+	newBuild := func(sources ...shbuild.BuildSource) *shbuild.Build {
+		build := &shbuild.Build{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "build"}}
+		build.Spec.Sources = sources
+		return build
+	}
+	want := shbuild.BuildSource{Name: s2iBinarySourceName, Type: shbuild.HTTP, URL: "http://fake/app.tar.gz"}
+
+	build := newBuild()
+	assert.Nil(t, h.setS2iBinarySource(context.Background(), build, ""))
+	assert.Empty(t, build.Spec.Sources)
+
+	assert.Nil(t, h.setS2iBinarySource(context.Background(), build, "ready"))
+	assert.Equal(t, []shbuild.BuildSource{want}, build.Spec.Sources)
+
+	build = newBuild(shbuild.BuildSource{Name: "other"}, shbuild.BuildSource{Name: s2iBinarySourceName, URL: "http://old"})
+	assert.Nil(t, h.setS2iBinarySource(context.Background(), build, "ready"))
+	assert.Equal(t, []shbuild.BuildSource{{Name: "other"}, want}, build.Spec.Sources)
+
+	assert.NotNil(t, h.setS2iBinarySource(context.Background(), newBuild(), "uploading"))
+	assert.NotNil(t, h.setS2iBinarySource(context.Background(), newBuild(), "missing"))
+}
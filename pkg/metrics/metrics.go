@@ -0,0 +1,237 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics exposes the Prometheus metrics of the Pipelines through the metrics endpoint of controller-runtime.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/backend"
+	"kubesphere.io/devops/pkg/models/dora"
+)
+
+const namespace = "ks_devops"
+
+var (
+	// PipelineRunsCreated counts the PipelineRuns which have been triggered by the backends
+	PipelineRunsCreated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pipelineruns_created_total",
+		Help:      "Total number of the PipelineRuns which have been triggered",
+	}, []string{"backend", "devopsproject", "pipeline"})
+
+	// PipelineRunsCompleted counts the completed PipelineRuns by their phases, such as Succeeded and Failed
+	PipelineRunsCompleted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pipelineruns_completed_total",
+		Help:      "Total number of the completed PipelineRuns by phase",
+	}, []string{"backend", "devopsproject", "pipeline", "phase"})
+
+	// PipelineRunDuration observes the duration between the start and the completion of PipelineRuns
+	PipelineRunDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "pipelinerun_duration_seconds",
+		Help:      "Duration of the completed PipelineRuns in seconds",
+		// from 10 seconds to about 5.7 hours
+		Buckets: prometheus.ExponentialBuckets(10, 2, 12),
+	}, []string{"backend", "devopsproject", "pipeline", "phase"})
+
+	// PipelineRunCPUSeconds counts the CPU requests multiplied by the lifetime of the pods of the PipelineRuns
+	PipelineRunCPUSeconds = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pipelinerun_cpu_core_seconds_total",
+		Help:      "Total CPU core seconds requested by the pods of the PipelineRuns",
+	}, []string{"devopsproject", "pipeline"})
+
+	// PipelineRunMemorySeconds counts the memory requests multiplied by the lifetime of the pods of the PipelineRuns
+	PipelineRunMemorySeconds = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pipelinerun_memory_byte_seconds_total",
+		Help:      "Total memory byte seconds requested by the pods of the PipelineRuns",
+	}, []string{"devopsproject", "pipeline"})
+
+	// ReconcileErrors counts the errors returned by the reconcilers of the backends
+	ReconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reconcile_errors_total",
+		Help:      "Total number of the reconciliation errors by backend and controller",
+	}, []string{"backend", "controller"})
+
+	// JenkinsRequestDuration observes the latency of the requests sent to Jenkins
+	JenkinsRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "jenkins_request_duration_seconds",
+		Help:      "Latency of the Jenkins API requests in seconds",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"code", "method"})
+
+	// JenkinsRequests counts the requests sent to Jenkins by the response code
+	JenkinsRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "jenkins_requests_total",
+		Help:      "Total number of the Jenkins API requests by code and method",
+	}, []string{"code", "method"})
+
+	// JenkinsRequestErrors counts the requests to Jenkins which failed without any response, such as timeouts
+	JenkinsRequestErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "jenkins_request_errors_total",
+		Help:      "Total number of the Jenkins API requests which failed without any response",
+	}, []string{"method"})
+
+	// DeploymentFrequency is the average number of the successful deployments per day
+	DeploymentFrequency = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "dora_deployment_frequency",
+		Help:      "Average number of the successful deployments per day of the DevOps project",
+	}, []string{"devopsproject"})
+
+	// LeadTimeForChanges is the average time from the first PipelineRun of a change to its successful deployment
+	LeadTimeForChanges = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "dora_lead_time_for_changes_seconds",
+		Help:      "Average lead time for changes of the DevOps project in seconds",
+	}, []string{"devopsproject"})
+
+	// ChangeFailureRate is the ratio of the failed deployments
+	ChangeFailureRate = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "dora_change_failure_rate",
+		Help:      "Ratio of the failed deployments of the DevOps project",
+	}, []string{"devopsproject"})
+
+	// MeanTimeToRestore is the average time from a failed deployment to the next successful one
+	MeanTimeToRestore = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "dora_mean_time_to_restore_seconds",
+		Help:      "Mean time to restore of the DevOps project in seconds",
+	}, []string{"devopsproject"})
+
+	// OrphanedCredentials is the number of credentials which only exist in one side of Jenkins and Kubernetes
+	OrphanedCredentials = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "orphaned_credentials",
+		Help:      "Number of the credentials which only exist in Jenkins or Kubernetes",
+	}, []string{"devopsproject", "location"})
+
+	// BackendUp indicates whether the dependency of a Pipeline backend passed the last health check
+	BackendUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "backend_up",
+		Help:      "Whether the dependency of the Pipeline backend passed the last health check, 1 means up",
+	}, []string{"backend"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(PipelineRunsCreated, PipelineRunsCompleted, PipelineRunDuration,
+		PipelineRunCPUSeconds, PipelineRunMemorySeconds,
+		ReconcileErrors, JenkinsRequestDuration, JenkinsRequests, JenkinsRequestErrors,
+		DeploymentFrequency, LeadTimeForChanges, ChangeFailureRate, MeanTimeToRestore,
+		OrphanedCredentials, BackendUp)
+}
+
+// ObserveDORA records the DORA metrics of a DevOps project
+func ObserveDORA(doraMetrics *dora.Metrics) {
+	DeploymentFrequency.WithLabelValues(doraMetrics.Project).Set(doraMetrics.DeploymentFrequency)
+	LeadTimeForChanges.WithLabelValues(doraMetrics.Project).Set(doraMetrics.LeadTimeSeconds)
+	ChangeFailureRate.WithLabelValues(doraMetrics.Project).Set(doraMetrics.ChangeFailureRate)
+	MeanTimeToRestore.WithLabelValues(doraMetrics.Project).Set(doraMetrics.MTTRSeconds)
+}
+
+// ForgetDORA removes the DORA metrics of a DevOps project
+func ForgetDORA(project string) {
+	DeploymentFrequency.DeleteLabelValues(project)
+	LeadTimeForChanges.DeleteLabelValues(project)
+	ChangeFailureRate.DeleteLabelValues(project)
+	MeanTimeToRestore.DeleteLabelValues(project)
+}
+
+// ObservePipelineRunCreated records a PipelineRun which has been triggered by the backend
+func ObservePipelineRunCreated(backendType backend.Type, pipelineRun *v1alpha3.PipelineRun) {
+	PipelineRunsCreated.WithLabelValues(string(backendType), pipelineRun.Namespace, pipelineRun.GetPipelineName()).Inc()
+}
+
+// ObservePipelineRunCompleted records the phase and the duration of a completed PipelineRun
+func ObservePipelineRunCompleted(backendType backend.Type, pipelineRun *v1alpha3.PipelineRun) {
+	if !pipelineRun.HasCompleted() {
+		return
+	}
+	labels := []string{string(backendType), pipelineRun.Namespace, pipelineRun.GetPipelineName(),
+		string(pipelineRun.Status.Phase)}
+	PipelineRunsCompleted.WithLabelValues(labels...).Inc()
+
+	status := pipelineRun.Status
+	if status.StartTime != nil && !status.CompletionTime.Before(status.StartTime) {
+		PipelineRunDuration.WithLabelValues(labels...).
+			Observe(status.CompletionTime.Sub(status.StartTime.Time).Seconds())
+	}
+}
+
+// ObservePipelineRunUsage records the compute resources consumed by a pod of the PipelineRun
+func ObservePipelineRunUsage(pipelineRun *v1alpha3.PipelineRun, cpuMilliCoreSeconds, memoryMiBSeconds int64) {
+	labels := []string{pipelineRun.Namespace, pipelineRun.GetPipelineName()}
+	PipelineRunCPUSeconds.WithLabelValues(labels...).Add(float64(cpuMilliCoreSeconds) / 1000)
+	PipelineRunMemorySeconds.WithLabelValues(labels...).Add(float64(memoryMiBSeconds) * 1024 * 1024)
+}
+
+// NewReconciler wraps a reconciler to count its errors
+func NewReconciler(backendType backend.Type, controller string, r reconcile.Reconciler) reconcile.Reconciler {
+	return &instrumentedReconciler{
+		Reconciler: r,
+		errors:     ReconcileErrors.WithLabelValues(string(backendType), controller),
+	}
+}
+
+type instrumentedReconciler struct {
+	reconcile.Reconciler
+	errors prometheus.Counter
+}
+
+// Reconcile counts the error of the wrapped reconciler
+func (r *instrumentedReconciler) Reconcile(ctx context.Context, req reconcile.Request) (result reconcile.Result, err error) {
+	if result, err = r.Reconciler.Reconcile(ctx, req); err != nil {
+		r.errors.Inc()
+	}
+	return
+}
+
+// InstrumentRoundTripper observes the latency, the response codes and the errors of the requests sent to Jenkins
+func InstrumentRoundTripper(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	// the counter middleware of promhttp v1.13.0 increases twice for each request, so count the requests here
+	counted := promhttp.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			JenkinsRequestErrors.WithLabelValues(strings.ToLower(req.Method)).Inc()
+		} else {
+			JenkinsRequests.WithLabelValues(strconv.Itoa(resp.StatusCode), strings.ToLower(req.Method)).Inc()
+		}
+		return resp, err
+	})
+	return promhttp.InstrumentRoundTripperDuration(JenkinsRequestDuration, counted)
+}
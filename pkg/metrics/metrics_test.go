@@ -0,0 +1,125 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/backend"
+	"kubesphere.io/devops/pkg/models/dora"
+)
+
+func TestObservePipelineRun(t *testing.T) {
+	pipelineRun := &v1alpha3.PipelineRun{}
+	pipelineRun.SetNamespace("project")
+	pipelineRun.Spec.PipelineRef = &v1.ObjectReference{Name: "pipeline"}
+
+	ObservePipelineRunCreated(backend.Jenkins, pipelineRun)
+	assert.Equal(t, float64(1), testutil.ToFloat64(PipelineRunsCreated.WithLabelValues("Jenkins", "project", "pipeline")))
+
+	// not completed yet
+	ObservePipelineRunCompleted(backend.Jenkins, pipelineRun)
+	assert.Equal(t, 0, testutil.CollectAndCount(PipelineRunsCompleted))
+
+	now := time.Now()
+	pipelineRun.SetLabels(map[string]string{v1alpha3.PipelineNameLabelKey: "labeled"})
+	pipelineRun.Status.Phase = v1alpha3.Succeeded
+	pipelineRun.Status.StartTime = &metav1.Time{Time: now.Add(-time.Minute)}
+	pipelineRun.Status.CompletionTime = &metav1.Time{Time: now}
+	ObservePipelineRunCompleted(backend.Jenkins, pipelineRun)
+	// the PipelineRef takes precedence over the label
+	assert.Equal(t, float64(1), testutil.ToFloat64(
+		PipelineRunsCompleted.WithLabelValues("Jenkins", "project", "pipeline", "Succeeded")))
+	assert.Equal(t, 1, testutil.CollectAndCount(PipelineRunDuration))
+}
+
+func TestObservePipelineRunUsage(t *testing.T) {
+	pipelineRun := &v1alpha3.PipelineRun{}
+	pipelineRun.SetNamespace("project")
+	pipelineRun.SetLabels(map[string]string{v1alpha3.PipelineNameLabelKey: "usage"})
+
+	ObservePipelineRunUsage(pipelineRun, 1500, 2)
+	ObservePipelineRunUsage(pipelineRun, 500, 1)
+	assert.Equal(t, float64(2), testutil.ToFloat64(PipelineRunCPUSeconds.WithLabelValues("project", "usage")))
+	assert.Equal(t, float64(3*1024*1024), testutil.ToFloat64(PipelineRunMemorySeconds.WithLabelValues("project", "usage")))
+}
+
+type fakeReconciler struct {
+	err error
+}
+
+func (r *fakeReconciler) Reconcile(context.Context, reconcile.Request) (reconcile.Result, error) {
+	return reconcile.Result{}, r.err
+}
+
+func TestNewReconciler(t *testing.T) {
+	counter := ReconcileErrors.WithLabelValues("Jenkins", "fake")
+
+	_, err := NewReconciler(backend.Jenkins, "fake", &fakeReconciler{}).Reconcile(context.TODO(), reconcile.Request{})
+	assert.Nil(t, err)
+	assert.Equal(t, float64(0), testutil.ToFloat64(counter))
+
+	_, err = NewReconciler(backend.Jenkins, "fake", &fakeReconciler{err: errors.New("fake")}).
+		Reconcile(context.TODO(), reconcile.Request{})
+	assert.NotNil(t, err)
+	assert.Equal(t, float64(1), testutil.ToFloat64(counter))
+}
+
+func TestInstrumentRoundTripper(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: InstrumentRoundTripper(nil)}
+	resp, err := client.Get(server.URL)
+	assert.Nil(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, 1, testutil.CollectAndCount(JenkinsRequestDuration))
+	assert.Equal(t, float64(1), testutil.ToFloat64(JenkinsRequests.WithLabelValues("404", "get")))
+
+	// no response from a closed server
+	server.Close()
+	_, err = client.Get(server.URL)
+	assert.NotNil(t, err)
+	assert.Equal(t, float64(1), testutil.ToFloat64(JenkinsRequestErrors.WithLabelValues("get")))
+}
+
+func TestObserveDORA(t *testing.T) {
+	ObserveDORA(&dora.Metrics{Project: "project", DeploymentFrequency: 1.5, LeadTimeSeconds: 60,
+		ChangeFailureRate: 0.25, MTTRSeconds: 120})
+	assert.Equal(t, 1.5, testutil.ToFloat64(DeploymentFrequency.WithLabelValues("project")))
+	assert.Equal(t, float64(60), testutil.ToFloat64(LeadTimeForChanges.WithLabelValues("project")))
+	assert.Equal(t, 0.25, testutil.ToFloat64(ChangeFailureRate.WithLabelValues("project")))
+	assert.Equal(t, float64(120), testutil.ToFloat64(MeanTimeToRestore.WithLabelValues("project")))
+
+	ForgetDORA("project")
+	assert.Equal(t, 0, testutil.CollectAndCount(DeploymentFrequency))
+	assert.Equal(t, 0, testutil.CollectAndCount(MeanTimeToRestore))
+}
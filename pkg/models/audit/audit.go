@@ -0,0 +1,161 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"kubesphere.io/devops/pkg/client/cache"
+)
+
+// keyPrefix is the prefix of all the audit events in the cache
+const keyPrefix = "kubesphere:devops:audit"
+
+// clusterScope is the project name of the events which do not belong to any DevOps project
+const clusterScope = "-"
+
+// Event records an operation against the DevOps APIs
+type Event struct {
+	Time time.Time `json:"time"`
+	User string    `json:"user"`
+	// Impersonator is the user who impersonated the User, it's empty if there was no impersonation
+	Impersonator string `json:"impersonator,omitempty"`
+	Verb         string `json:"verb"`
+	Project      string `json:"project,omitempty"`
+	APIGroup     string `json:"apiGroup,omitempty"`
+	APIVersion   string `json:"apiVersion,omitempty"`
+	Resource     string `json:"resource,omitempty"`
+	Subresource  string `json:"subresource,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Method       string `json:"method"`
+	Path         string `json:"path"`
+	SourceIP     string `json:"sourceIP,omitempty"`
+	UserAgent    string `json:"userAgent,omitempty"`
+	StatusCode   int    `json:"statusCode"`
+}
+
+// Filter is the condition to query the audit events, the empty fields match everything
+type Filter struct {
+	Project string
+	User    string
+	Since   time.Time
+	Until   time.Time
+}
+
+// Interface is the store of the audit events
+type Interface interface {
+	// Record saves an audit event
+	Record(event *Event) error
+	// List returns the events which match the filter, the latest events come first
+	List(filter Filter) ([]Event, error)
+}
+
+// NewCacheStore creates an audit store on top of the cache, the events expire after the retention
+func NewCacheStore(cacheClient cache.Interface, retention time.Duration) Interface {
+	return &cacheStore{cache: cacheClient, retention: retention}
+}
+
+type cacheStore struct {
+	cache     cache.Interface
+	retention time.Duration
+}
+
+func (s *cacheStore) Record(event *Event) (err error) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	var data []byte
+	if data, err = json.Marshal(event); err == nil {
+		err = s.cache.Set(eventKey(event), string(data), s.retention)
+	}
+	return
+}
+
+func (s *cacheStore) List(filter Filter) (events []Event, err error) {
+	project := filter.Project
+	if project == "" {
+		project = "*"
+	}
+
+	var keys []string
+	if keys, err = s.cache.Keys(fmt.Sprintf("%s:%s:*", keyPrefix, project)); err != nil {
+		return
+	}
+
+	events = make([]Event, 0)
+	for _, key := range keys {
+		if !inTimeRange(key, filter) {
+			continue
+		}
+
+		var data string
+		if data, err = s.cache.Get(key); err != nil {
+			// the event might expire after listing the keys
+			err = nil
+			continue
+		}
+
+		event := Event{}
+		if err = json.Unmarshal([]byte(data), &event); err != nil {
+			return
+		}
+		if filter.User != "" && filter.User != event.User {
+			continue
+		}
+		events = append(events, event)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.After(events[j].Time)
+	})
+	return
+}
+
+// eventKey returns the cache key of the event, it looks like kubesphere:devops:audit:{project}:{unixNano}:{user}
+func eventKey(event *Event) string {
+	project := event.Project
+	if project == "" {
+		project = clusterScope
+	}
+	return fmt.Sprintf("%s:%s:%d:%s", keyPrefix, project, event.Time.UnixNano(), event.User)
+}
+
+// inTimeRange checks the timestamp in the key, it saves the trouble of fetching the events out of the range
+func inTimeRange(key string, filter Filter) bool {
+	parts := strings.SplitN(strings.TrimPrefix(key, keyPrefix+":"), ":", 3)
+	if len(parts) < 2 {
+		return false
+	}
+	timestamp, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return false
+	}
+
+	eventTime := time.Unix(0, timestamp)
+	if !filter.Since.IsZero() && eventTime.Before(filter.Since) {
+		return false
+	}
+	if !filter.Until.IsZero() && eventTime.After(filter.Until) {
+		return false
+	}
+	return true
+}
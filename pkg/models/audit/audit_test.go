@@ -0,0 +1,104 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"kubesphere.io/devops/pkg/client/cache"
+)
+
+func TestCacheStore(t *testing.T) {
+	store := NewCacheStore(cache.NewSimpleCache(), time.Hour)
+
+	now := time.Now()
+	events := []*Event{{
+		Time: now.Add(-3 * time.Hour), User: "admin", Verb: "create", Project: "project-a", Resource: "pipelines",
+	}, {
+		Time: now.Add(-2 * time.Hour), User: "tom", Verb: "update", Project: "project-a", Resource: "credentials",
+	}, {
+		Time: now.Add(-time.Hour), User: "admin", Verb: "create", Project: "project-ab", Resource: "pipelineruns",
+	}, {
+		User: "system:serviceaccount:kubesphere-devops-system:default", Verb: "create", Resource: "clustertemplates",
+	}}
+	for _, event := range events {
+		assert.Nil(t, store.Record(event))
+	}
+	assert.False(t, events[3].Time.IsZero())
+
+	tests := []struct {
+		name   string
+		filter Filter
+		verify func(t *testing.T, events []Event)
+	}{{
+		name: "all events",
+		verify: func(t *testing.T, events []Event) {
+			if assert.Equal(t, 4, len(events)) {
+				assert.Equal(t, "clustertemplates", events[0].Resource)
+				assert.Equal(t, "pipelines", events[3].Resource)
+			}
+		},
+	}, {
+		name:   "filter by project",
+		filter: Filter{Project: "project-a"},
+		verify: func(t *testing.T, events []Event) {
+			if assert.Equal(t, 2, len(events)) {
+				assert.Equal(t, "credentials", events[0].Resource)
+				assert.Equal(t, "pipelines", events[1].Resource)
+			}
+		},
+	}, {
+		name:   "filter by user",
+		filter: Filter{User: "admin"},
+		verify: func(t *testing.T, events []Event) {
+			if assert.Equal(t, 2, len(events)) {
+				assert.Equal(t, "pipelineruns", events[0].Resource)
+			}
+		},
+	}, {
+		name:   "filter by the user with colons",
+		filter: Filter{User: "system:serviceaccount:kubesphere-devops-system:default"},
+		verify: func(t *testing.T, events []Event) {
+			assert.Equal(t, 1, len(events))
+		},
+	}, {
+		name:   "filter by time range",
+		filter: Filter{Since: now.Add(-150 * time.Minute), Until: now.Add(-30 * time.Minute)},
+		verify: func(t *testing.T, events []Event) {
+			if assert.Equal(t, 2, len(events)) {
+				assert.Equal(t, "pipelineruns", events[0].Resource)
+				assert.Equal(t, "credentials", events[1].Resource)
+			}
+		},
+	}, {
+		name:   "no matched events",
+		filter: Filter{Project: "project-b"},
+		verify: func(t *testing.T, events []Event) {
+			assert.NotNil(t, events)
+			assert.Empty(t, events)
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := store.List(tt.filter)
+			assert.Nil(t, err)
+			tt.verify(t, result)
+		})
+	}
+}
@@ -0,0 +1,368 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package backup exports a DevOps project into an archive, and restores the archive into the same DevOps project
+// of the same or another cluster.
+package backup
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/history"
+	"kubesphere.io/devops/pkg/constants"
+	"kubesphere.io/devops/pkg/utils/sliceutil"
+)
+
+// ArchiveVersion is the version of the archive format
+const ArchiveVersion = "v1"
+
+// MaxArchivedRuns is the maximum number of the latest PipelineRun summaries kept by an archive
+const MaxArchivedRuns = 10000
+
+// historyPageSize is the number of the PipelineRun summaries fetched from the history store at a time
+const historyPageSize = 500
+
+// transientAnnotations are the states of the backend, they are regenerated after restoring
+var transientAnnotations = []string{
+	v1alpha3.PipelineSpecHash,
+	v1alpha3.PipelineSyncStatusAnnoKey,
+	v1alpha3.PipelineSyncTimeAnnoKey,
+	v1alpha3.PipelineSyncMsgAnnoKey,
+	v1alpha3.PipelineJenkinsMetadataAnnoKey,
+	v1alpha3.PipelineJenkinsBranchesAnnoKey,
+	v1alpha3.PipelineLastChanges,
+	v1alpha3.DevOpeProjectSyncStatusAnnoKey,
+	v1alpha3.DevOpeProjectSyncTimeAnnoKey,
+}
+
+// Archive is the exported DevOps project
+type Archive struct {
+	Version      string      `json:"version"`
+	CreationTime metav1.Time `json:"creationTime"`
+	// Namespace is the namespace of the exported DevOps project
+	Namespace string                     `json:"namespace"`
+	Project   v1alpha3.DevOpsProjectSpec `json:"project"`
+	Pipelines []v1alpha3.Pipeline        `json:"pipelines,omitempty"`
+	Templates []v1alpha3.Template        `json:"templates,omitempty"`
+	// Credentials are the references of the credentials, the secret data is never exported
+	Credentials []CredentialReference `json:"credentials,omitempty"`
+	// Runs are the summaries of the latest completed PipelineRuns, at most MaxArchivedRuns
+	Runs []history.RunSummary `json:"runs,omitempty"`
+}
+
+// CredentialReference is the name and the type of a credential which the Pipelines might refer to
+type CredentialReference struct {
+	Name string        `json:"name"`
+	Type v1.SecretType `json:"type"`
+}
+
+// Summary is the number of the objects in an archive
+type Summary struct {
+	Pipelines   int `json:"pipelines"`
+	Templates   int `json:"templates"`
+	Credentials int `json:"credentials"`
+	Runs        int `json:"runs"`
+}
+
+// GetSummary returns the number of the objects in the archive
+func (a *Archive) GetSummary() Summary {
+	return Summary{
+		Pipelines:   len(a.Pipelines),
+		Templates:   len(a.Templates),
+		Credentials: len(a.Credentials),
+		Runs:        len(a.Runs),
+	}
+}
+
+// Encode writes the archive as gzipped JSON
+func (a *Archive) Encode(writer io.Writer) (err error) {
+	gzipWriter := gzip.NewWriter(writer)
+	if err = json.NewEncoder(gzipWriter).Encode(a); err != nil {
+		return
+	}
+	return gzipWriter.Close()
+}
+
+// Decode reads an archive from gzipped JSON
+func Decode(reader io.Reader) (archive *Archive, err error) {
+	var gzipReader *gzip.Reader
+	if gzipReader, err = gzip.NewReader(reader); err != nil {
+		return
+	}
+	defer func() {
+		_ = gzipReader.Close()
+	}()
+	archive = &Archive{}
+	if err = json.NewDecoder(gzipReader).Decode(archive); err != nil {
+		return
+	}
+	if archive.Version != ArchiveVersion {
+		err = fmt.Errorf("unsupported archive version %q", archive.Version)
+	}
+	return
+}
+
+// GetProject returns the DevOps project which the namespace belongs to
+func GetProject(ctx context.Context, c client.Reader, namespace string) (project *v1alpha3.DevOpsProject, err error) {
+	ns := &v1.Namespace{}
+	if err = c.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		return
+	}
+	projectName, ok := ns.Labels[constants.DevOpsProjectLabelKey]
+	if !ok {
+		return nil, restful.NewError(http.StatusBadRequest, fmt.Sprintf("namespace '%s' is not a DevOps project", namespace))
+	}
+	project = &v1alpha3.DevOpsProject{}
+	err = c.Get(ctx, client.ObjectKey{Name: projectName}, project)
+	return
+}
+
+// Export collects the Pipelines, the Templates, the credential references and the PipelineRun summaries of
+// a DevOps project. The summaries are not exported if the history store is nil.
+func Export(ctx context.Context, c client.Reader, store history.Interface, namespace string) (archive *Archive, err error) {
+	var project *v1alpha3.DevOpsProject
+	if project, err = GetProject(ctx, c, namespace); err != nil {
+		return
+	}
+	archive = &Archive{
+		Version:      ArchiveVersion,
+		CreationTime: metav1.Now(),
+		Namespace:    namespace,
+		Project:      *project.Spec.DeepCopy(),
+	}
+
+	pipelines := &v1alpha3.PipelineList{}
+	if err = c.List(ctx, pipelines, client.InNamespace(namespace)); err != nil {
+		return
+	}
+	for i := range pipelines.Items {
+		item := &pipelines.Items[i]
+		archive.Pipelines = append(archive.Pipelines, v1alpha3.Pipeline{
+			ObjectMeta: exportMeta(&item.ObjectMeta),
+			Spec:       item.Spec,
+		})
+	}
+
+	templates := &v1alpha3.TemplateList{}
+	if err = c.List(ctx, templates, client.InNamespace(namespace)); err != nil {
+		return
+	}
+	for i := range templates.Items {
+		item := &templates.Items[i]
+		archive.Templates = append(archive.Templates, v1alpha3.Template{
+			ObjectMeta: exportMeta(&item.ObjectMeta),
+			Spec:       item.Spec,
+		})
+	}
+
+	secrets := &v1.SecretList{}
+	if err = c.List(ctx, secrets, client.InNamespace(namespace)); err != nil {
+		return
+	}
+	credentialTypes := v1alpha3.GetSupportedCredentialTypes()
+	for i := range secrets.Items {
+		for _, credentialType := range credentialTypes {
+			if secrets.Items[i].Type == credentialType {
+				archive.Credentials = append(archive.Credentials, CredentialReference{
+					Name: secrets.Items[i].Name,
+					Type: credentialType,
+				})
+				break
+			}
+		}
+	}
+
+	if store != nil {
+		archive.Runs, err = exportRuns(ctx, store, namespace)
+	}
+	return
+}
+
+// exportRuns pages through the history store until all the summaries, or MaxArchivedRuns of them, are fetched
+func exportRuns(ctx context.Context, store history.Interface, namespace string) (runs []history.RunSummary, err error) {
+	for len(runs) < MaxArchivedRuns {
+		var page []history.RunSummary
+		if page, _, err = store.List(ctx, history.Filter{
+			Namespace: namespace,
+			Limit:     historyPageSize,
+			Offset:    len(runs),
+		}); err != nil {
+			return
+		}
+		runs = append(runs, page...)
+		if len(page) < historyPageSize {
+			break
+		}
+	}
+	if len(runs) > MaxArchivedRuns {
+		runs = runs[:MaxArchivedRuns]
+	}
+	return
+}
+
+// exportMeta keeps the name, the labels and the annotations except the transient ones
+func exportMeta(meta *metav1.ObjectMeta) metav1.ObjectMeta {
+	result := metav1.ObjectMeta{Name: meta.Name, Labels: meta.Labels}
+	for key, value := range meta.Annotations {
+		if sliceutil.HasString(transientAnnotations, key) {
+			continue
+		}
+		if result.Annotations == nil {
+			result.Annotations = map[string]string{}
+		}
+		result.Annotations[key] = value
+	}
+	return result
+}
+
+// RestoreOptions decides how to restore an archive
+type RestoreOptions struct {
+	// Overwrite updates the existing Pipelines and Templates, as well as the spec of the DevOps project,
+	// they are skipped by default
+	Overwrite bool `json:"overwrite,omitempty"`
+}
+
+// RestoreCount is the number of the restored objects of a kind
+type RestoreCount struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Skipped int `json:"skipped"`
+}
+
+// RestoreResult is the result of restoring an archive
+type RestoreResult struct {
+	Pipelines RestoreCount `json:"pipelines"`
+	Templates RestoreCount `json:"templates"`
+	Runs      int          `json:"runs"`
+	// MissingCredentials are the credentials which need to be created again, since the secret data is not exported
+	MissingCredentials []string `json:"missingCredentials,omitempty"`
+}
+
+// Restore creates the Pipelines and the Templates of the archive in the namespace of a DevOps project, then saves
+// the PipelineRun summaries into the history store if it's not nil. The namespace must be the exported one, but
+// it could be in another cluster.
+func Restore(ctx context.Context, c client.Client, store history.Interface, namespace string, archive *Archive,
+	options RestoreOptions) (result *RestoreResult, err error) {
+	if archive.Namespace != namespace {
+		err = restful.NewError(http.StatusBadRequest,
+			fmt.Sprintf("the archive of DevOps project '%s' cannot be restored into '%s'", archive.Namespace, namespace))
+		return
+	}
+	var project *v1alpha3.DevOpsProject
+	if project, err = GetProject(ctx, c, namespace); err != nil {
+		return
+	}
+	result = &RestoreResult{}
+	if options.Overwrite {
+		spec := archive.Project.DeepCopy()
+		// the member clusters might be different
+		spec.Placement = project.Spec.Placement
+		project.Spec = *spec
+		if err = c.Update(ctx, project); err != nil {
+			return
+		}
+	}
+
+	for i := range archive.Pipelines {
+		item := archive.Pipelines[i].DeepCopy()
+		item.Namespace = namespace
+		if err = restoreObject(ctx, c, item, &v1alpha3.Pipeline{}, options, &result.Pipelines, func(existing client.Object) {
+			existing.(*v1alpha3.Pipeline).Spec = item.Spec
+		}); err != nil {
+			return
+		}
+	}
+	for i := range archive.Templates {
+		item := archive.Templates[i].DeepCopy()
+		item.Namespace = namespace
+		if err = restoreObject(ctx, c, item, &v1alpha3.Template{}, options, &result.Templates, func(existing client.Object) {
+			existing.(*v1alpha3.Template).Spec = item.Spec
+		}); err != nil {
+			return
+		}
+	}
+
+	for _, credential := range archive.Credentials {
+		secret := &v1.Secret{}
+		if err = c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: credential.Name}, secret); err == nil {
+			continue
+		} else if !apierrors.IsNotFound(err) {
+			return
+		}
+		err = nil
+		result.MissingCredentials = append(result.MissingCredentials, credential.Name)
+	}
+
+	if store == nil {
+		return
+	}
+	for i := range archive.Runs {
+		summary := archive.Runs[i]
+		if err = store.Save(ctx, &summary); err != nil {
+			return
+		}
+		result.Runs++
+	}
+	return
+}
+
+// restoreObject creates the object, or updates the existing one by the mutate function if it's allowed
+func restoreObject(ctx context.Context, c client.Client, object, existing client.Object, options RestoreOptions,
+	count *RestoreCount, mutate func(existing client.Object)) (err error) {
+	if err = c.Get(ctx, client.ObjectKeyFromObject(object), existing); apierrors.IsNotFound(err) {
+		if err = c.Create(ctx, object); err == nil {
+			count.Created++
+		}
+		return
+	} else if err != nil {
+		return
+	}
+
+	if !options.Overwrite {
+		count.Skipped++
+		return
+	}
+	mutate(existing)
+	existing.SetLabels(merge(existing.GetLabels(), object.GetLabels()))
+	existing.SetAnnotations(merge(existing.GetAnnotations(), object.GetAnnotations()))
+	if err = c.Update(ctx, existing); err == nil {
+		count.Updated++
+	}
+	return
+}
+
+// merge returns the existing map with the values of the desired one
+func merge(existing, desired map[string]string) map[string]string {
+	if existing == nil && len(desired) > 0 {
+		existing = make(map[string]string, len(desired))
+	}
+	for key, value := range desired {
+		existing[key] = value
+	}
+	return existing
+}
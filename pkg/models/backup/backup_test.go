@@ -0,0 +1,171 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/history"
+	historyfake "kubesphere.io/devops/pkg/client/history/fake"
+	"kubesphere.io/devops/pkg/constants"
+)
+
+func newProject(namespace string, placement *v1alpha3.ProjectPlacement) []client.Object {
+	return []client.Object{&v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   namespace,
+			Labels: map[string]string{constants.DevOpsProjectLabelKey: namespace},
+		},
+	}, &v1alpha3.DevOpsProject{
+		ObjectMeta: metav1.ObjectMeta{Name: namespace},
+		Spec:       v1alpha3.DevOpsProjectSpec{Placement: placement},
+	}}
+}
+
+func TestExportAndRestore(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1.AddToScheme(schema))
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	sourceObjects := newProject("source", &v1alpha3.ProjectPlacement{Clusters: []string{"host"}})
+	sourceObjects = append(sourceObjects, &v1alpha3.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "source",
+			Name:      "build",
+			Labels:    map[string]string{"app": "build"},
+			Annotations: map[string]string{
+				"description":                      "build the app",
+				v1alpha3.PipelineSyncStatusAnnoKey: "successful",
+			},
+		},
+		Spec: v1alpha3.PipelineSpec{
+			Type:     v1alpha3.NoScmPipelineType,
+			Pipeline: &v1alpha3.NoScmPipeline{Name: "build", Jenkinsfile: "pipeline { agent any }"},
+		},
+	}, &v1alpha3.Template{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "source", Name: "golang"},
+		Spec:       v1alpha3.TemplateSpec{Template: "pipeline {}"},
+	}, &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "source", Name: "git"},
+		Type:       v1alpha3.SecretTypeBasicAuth,
+	}, &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "source", Name: "token"},
+		Type:       v1.SecretTypeServiceAccountToken,
+	})
+	// the same DevOps project in another cluster
+	targetObjects := append(newProject("source", &v1alpha3.ProjectPlacement{Clusters: []string{"member"}}),
+		&v1alpha3.Pipeline{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "source", Name: "build", Labels: map[string]string{"team": "a"}},
+			Spec:       v1alpha3.PipelineSpec{Type: v1alpha3.NoScmPipelineType, Pipeline: &v1alpha3.NoScmPipeline{Name: "build"}},
+		})
+	targetObjects = append(targetObjects, newProject("target", nil)...)
+	store := historyfake.NewStore(history.RunSummary{UID: "uid-1", Namespace: "source", Pipeline: "build", Name: "build-1"},
+		history.RunSummary{UID: "uid-2", Namespace: "other", Pipeline: "build", Name: "build-1"})
+
+	t.Run("not a DevOps project", func(t *testing.T) {
+		c := fake.NewClientBuilder().WithScheme(schema).WithObjects(&v1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		}).Build()
+		_, err := Export(context.TODO(), c, nil, "default")
+		assert.NotNil(t, err)
+	})
+
+	tests := []struct {
+		name    string
+		options RestoreOptions
+		verify  func(t *testing.T, c client.Client, result *RestoreResult)
+	}{{
+		name: "skip the existing objects",
+		verify: func(t *testing.T, c client.Client, result *RestoreResult) {
+			assert.Equal(t, RestoreCount{Skipped: 1}, result.Pipelines)
+			assert.Equal(t, RestoreCount{Created: 1}, result.Templates)
+			assert.Equal(t, 1, result.Runs)
+			assert.Equal(t, []string{"git"}, result.MissingCredentials)
+
+			pipeline := &v1alpha3.Pipeline{}
+			assert.Nil(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "source", Name: "build"}, pipeline))
+			assert.Empty(t, pipeline.Spec.Pipeline.Jenkinsfile)
+
+			template := &v1alpha3.Template{}
+			assert.Nil(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "source", Name: "golang"}, template))
+			assert.Equal(t, "pipeline {}", template.Spec.Template)
+		},
+	}, {
+		name:    "overwrite the existing objects",
+		options: RestoreOptions{Overwrite: true},
+		verify: func(t *testing.T, c client.Client, result *RestoreResult) {
+			assert.Equal(t, RestoreCount{Updated: 1}, result.Pipelines)
+
+			pipeline := &v1alpha3.Pipeline{}
+			assert.Nil(t, c.Get(context.TODO(), client.ObjectKey{Namespace: "source", Name: "build"}, pipeline))
+			assert.Equal(t, "pipeline { agent any }", pipeline.Spec.Pipeline.Jenkinsfile)
+			assert.Equal(t, map[string]string{"app": "build", "team": "a"}, pipeline.Labels)
+			assert.Equal(t, "build the app", pipeline.Annotations["description"])
+			assert.NotContains(t, pipeline.Annotations, v1alpha3.PipelineSyncStatusAnnoKey)
+
+			project := &v1alpha3.DevOpsProject{}
+			assert.Nil(t, c.Get(context.TODO(), client.ObjectKey{Name: "source"}, project))
+			assert.Equal(t, []string{"member"}, project.Spec.GetPlacedClusters())
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sourceClient := fake.NewClientBuilder().WithScheme(schema).WithObjects(sourceObjects...).Build()
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(targetObjects...).Build()
+
+			archive, err := Export(context.TODO(), sourceClient, store, "source")
+			assert.Nil(t, err)
+			assert.Equal(t, Summary{Pipelines: 1, Templates: 1, Credentials: 1, Runs: 1}, archive.GetSummary())
+
+			buf := &bytes.Buffer{}
+			assert.Nil(t, archive.Encode(buf))
+			archive, err = Decode(buf)
+			assert.Nil(t, err)
+
+			targetStore := historyfake.NewStore()
+			// the archives of other DevOps projects are not allowed
+			_, err = Restore(context.TODO(), c, targetStore, "target", archive, tt.options)
+			assert.NotNil(t, err)
+
+			result, err := Restore(context.TODO(), c, targetStore, "source", archive, tt.options)
+			assert.Nil(t, err)
+			runs, _, err := targetStore.List(context.TODO(), history.Filter{Namespace: "source"})
+			assert.Nil(t, err)
+			if assert.Equal(t, 1, len(runs)) {
+				assert.Equal(t, "uid-1", runs[0].UID)
+			}
+			tt.verify(t, c, result)
+		})
+	}
+}
+
+func TestDecodeUnsupportedVersion(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.Nil(t, (&Archive{Version: "v0"}).Encode(buf))
+	_, err := Decode(buf)
+	assert.NotNil(t, err)
+}
@@ -19,6 +19,7 @@ package devops
 import (
 	"context"
 	"fmt"
+	"io"
 	"kubesphere.io/devops/pkg/client/k8s"
 	"mime/multipart"
 	"net/http"
@@ -47,6 +48,16 @@ type S2iBinaryUploader interface {
 	UploadS2iBinary(namespace, name, md5 string, header *multipart.FileHeader) (*v1alpha1.S2iBinary, error)
 
 	DownloadS2iBinary(namespace, name, fileName string) (string, error)
+
+	InitiateMultipartUpload(namespace, name, fileName string) (*MultipartUpload, error)
+
+	UploadS2iBinaryPart(namespace, name, uploadID string, partNumber int64, md5 string, body io.Reader) (*s3.Part, error)
+
+	ListS2iBinaryParts(namespace, name, uploadID string) (*MultipartUpload, error)
+
+	CompleteMultipartUpload(namespace, name, uploadID, md5 string) (*v1alpha1.S2iBinary, error)
+
+	AbortMultipartUpload(namespace, name, uploadID string) error
 }
 
 type s2iBinaryUploader struct {
@@ -108,7 +119,7 @@ func (s *s2iBinaryUploader) UploadS2iBinary(namespace, name, md5 string, fileHea
 	copy.Spec.FileName = fileHeader.Filename
 	copy.Spec.DownloadURL = fmt.Sprintf(GetS2iBinaryURL, namespace, name, copy.Spec.FileName)
 
-	err = s.s3Client.Upload(fmt.Sprintf("%s-%s", namespace, name), copy.Spec.FileName, binFile)
+	err = s.s3Client.Upload(v1alpha1.GetS2iBinaryKey(namespace, name), copy.Spec.FileName, binFile)
 	if err != nil {
 		if aerr, ok := err.(awserr.Error); ok {
 			switch aerr.Code() {
@@ -142,7 +153,8 @@ func (s *s2iBinaryUploader) UploadS2iBinary(namespace, name, md5 string, fileHea
 		return nil, err
 	}
 
-	copy, err = s.SetS2iBinaryStatusWithRetry(copy, v1alpha1.StatusReady)
+	// the file is ready once it passes the scan, see the s2ibinary-scan-controller
+	copy, err = s.SetS2iBinaryStatusWithRetry(copy, v1alpha1.StatusScanning)
 	if err != nil {
 		klog.Error(err)
 		return nil, err
@@ -169,7 +181,7 @@ func (s *s2iBinaryUploader) DownloadS2iBinary(namespace, name, fileName string)
 		klog.Error(err)
 		return "", err
 	}
-	return s.s3Client.GetDownloadURL(fmt.Sprintf("%s-%s", namespace, name), fileName)
+	return s.s3Client.GetDownloadURL(v1alpha1.GetS2iBinaryKey(namespace, name), fileName)
 }
 
 func (s *s2iBinaryUploader) SetS2iBinaryStatus(s2ibin *v1alpha1.S2iBinary, status string) (*v1alpha1.S2iBinary, error) {
@@ -0,0 +1,236 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package devops
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"code.cloudfoundry.org/bytefmt"
+	"github.com/emicklei/go-restful"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha1"
+	"kubesphere.io/devops/pkg/client/s3"
+	"kubesphere.io/devops/pkg/utils/hashutil"
+)
+
+// MaxS2iBinaryPartSize is the max size of a part of the multipart upload
+const MaxS2iBinaryPartSize = 100 * bytefmt.MEGABYTE
+
+// MultipartUpload is a resumable upload of S2iBinary
+type MultipartUpload struct {
+	UploadID string    `json:"uploadID"`
+	FileName string    `json:"fileName"`
+	Parts    []s3.Part `json:"parts"`
+}
+
+func (s *s2iBinaryUploader) InitiateMultipartUpload(namespace, name, fileName string) (upload *MultipartUpload, err error) {
+	if fileName == "" {
+		err = restful.NewError(http.StatusBadRequest, "fileName is required")
+		return
+	}
+
+	var s2ibin *v1alpha1.S2iBinary
+	if s2ibin, err = s.client.DevopsV1alpha1().S2iBinaries(namespace).Get(context.Background(), name, metav1.GetOptions{}); err != nil {
+		klog.Error(err)
+		return
+	}
+	if s2ibin.Status.Phase == v1alpha1.StatusUploading {
+		err = restful.NewError(http.StatusConflict, "file is uploading, please try later")
+		return
+	}
+
+	var uploadID string
+	if uploadID, err = s.s3Client.CreateMultipartUpload(v1alpha1.GetS2iBinaryKey(namespace, name), fileName); err != nil {
+		klog.Error(err)
+		return
+	}
+
+	copy := s2ibin.DeepCopy()
+	if copy.Annotations == nil {
+		copy.Annotations = map[string]string{}
+	}
+	copy.Annotations[v1alpha1.S2iBinaryUploadIDAnnoKey] = uploadID
+	copy.Annotations[v1alpha1.S2iBinaryUploadFileNameAnnoKey] = fileName
+	if copy, err = s.client.DevopsV1alpha1().S2iBinaries(namespace).Update(context.Background(), copy, metav1.UpdateOptions{}); err != nil {
+		klog.Error(err)
+		if abortErr := s.s3Client.AbortMultipartUpload(v1alpha1.GetS2iBinaryKey(namespace, name), uploadID); abortErr != nil {
+			klog.Error(abortErr)
+		}
+		return
+	}
+	if _, err = s.SetS2iBinaryStatus(copy, v1alpha1.StatusUploading); err != nil {
+		return
+	}
+	upload = &MultipartUpload{UploadID: uploadID, FileName: fileName, Parts: []s3.Part{}}
+	return
+}
+
+func (s *s2iBinaryUploader) UploadS2iBinaryPart(namespace, name, uploadID string, partNumber int64, md5 string,
+	body io.Reader) (part *s3.Part, err error) {
+	if partNumber < 1 || partNumber > 10000 {
+		err = restful.NewError(http.StatusBadRequest, "part number should be between 1 and 10000")
+		return
+	}
+	if _, err = s.getMultipartUpload(namespace, name, uploadID); err != nil {
+		return
+	}
+
+	var data []byte
+	if data, err = ioutil.ReadAll(io.LimitReader(body, int64(MaxS2iBinaryPartSize)+1)); err != nil {
+		klog.Error(err)
+		return
+	}
+	if len(data) > int(MaxS2iBinaryPartSize) {
+		err = restful.NewError(http.StatusRequestEntityTooLarge, fmt.Sprintf("part should not be larger than %s",
+			bytefmt.ByteSize(MaxS2iBinaryPartSize)))
+		return
+	}
+	if md5 != "" {
+		var partMD5 string
+		if partMD5, err = hashutil.GetMD5(ioutil.NopCloser(bytes.NewReader(data))); err != nil {
+			return
+		}
+		if partMD5 != md5 {
+			err = restful.NewError(http.StatusBadRequest, fmt.Sprintf("md5 not match, origin: %+v, calculate: %+v", md5, partMD5))
+			return
+		}
+	}
+
+	if part, err = s.s3Client.UploadPart(v1alpha1.GetS2iBinaryKey(namespace, name), uploadID, partNumber, bytes.NewReader(data)); err != nil {
+		klog.Error(err)
+	}
+	return
+}
+
+func (s *s2iBinaryUploader) ListS2iBinaryParts(namespace, name, uploadID string) (upload *MultipartUpload, err error) {
+	var s2ibin *v1alpha1.S2iBinary
+	if s2ibin, err = s.getMultipartUpload(namespace, name, uploadID); err != nil {
+		return
+	}
+
+	upload = &MultipartUpload{
+		UploadID: uploadID,
+		FileName: s2ibin.Annotations[v1alpha1.S2iBinaryUploadFileNameAnnoKey],
+	}
+	if upload.Parts, err = s.s3Client.ListParts(v1alpha1.GetS2iBinaryKey(namespace, name), uploadID); err != nil {
+		klog.Error(err)
+		return
+	}
+	if upload.Parts == nil {
+		upload.Parts = []s3.Part{}
+	}
+	return
+}
+
+func (s *s2iBinaryUploader) CompleteMultipartUpload(namespace, name, uploadID, md5 string) (s2ibin *v1alpha1.S2iBinary, err error) {
+	var upload *MultipartUpload
+	if upload, err = s.ListS2iBinaryParts(namespace, name, uploadID); err != nil {
+		return
+	}
+	if len(upload.Parts) == 0 {
+		err = restful.NewError(http.StatusBadRequest, "no parts have been uploaded")
+		return
+	}
+
+	var size int64
+	for i, part := range upload.Parts {
+		if part.PartNumber != int64(i+1) {
+			err = restful.NewError(http.StatusBadRequest, fmt.Sprintf("part %d is missing", i+1))
+			return
+		}
+		size += part.Size
+	}
+	if err = s.s3Client.CompleteMultipartUpload(v1alpha1.GetS2iBinaryKey(namespace, name), uploadID, upload.Parts); err != nil {
+		klog.Error(err)
+		return
+	}
+
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() (err error) {
+		if s2ibin, err = s.client.DevopsV1alpha1().S2iBinaries(namespace).Get(context.Background(), name, metav1.GetOptions{}); err != nil {
+			return
+		}
+		delete(s2ibin.Annotations, v1alpha1.S2iBinaryUploadIDAnnoKey)
+		delete(s2ibin.Annotations, v1alpha1.S2iBinaryUploadFileNameAnnoKey)
+		s2ibin.Spec.MD5 = md5
+		s2ibin.Spec.Size = bytefmt.ByteSize(uint64(size))
+		s2ibin.Spec.FileName = upload.FileName
+		s2ibin.Spec.DownloadURL = fmt.Sprintf(GetS2iBinaryURL, namespace, name, upload.FileName)
+		now := metav1.Now()
+		s2ibin.Spec.UploadTimeStamp = &now
+		s2ibin, err = s.client.DevopsV1alpha1().S2iBinaries(namespace).Update(context.Background(), s2ibin, metav1.UpdateOptions{})
+		return
+	})
+	if err != nil {
+		klog.Error(err)
+		return
+	}
+	// the file is ready once it passes the scan, see the s2ibinary-scan-controller
+	return s.SetS2iBinaryStatusWithRetry(s2ibin, v1alpha1.StatusScanning)
+}
+
+func (s *s2iBinaryUploader) AbortMultipartUpload(namespace, name, uploadID string) (err error) {
+	if _, err = s.getMultipartUpload(namespace, name, uploadID); err != nil {
+		return
+	}
+	if err = s.s3Client.AbortMultipartUpload(v1alpha1.GetS2iBinaryKey(namespace, name), uploadID); err != nil {
+		klog.Error(err)
+		return
+	}
+
+	var s2ibin *v1alpha1.S2iBinary
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() (err error) {
+		if s2ibin, err = s.client.DevopsV1alpha1().S2iBinaries(namespace).Get(context.Background(), name, metav1.GetOptions{}); err != nil {
+			return
+		}
+		delete(s2ibin.Annotations, v1alpha1.S2iBinaryUploadIDAnnoKey)
+		delete(s2ibin.Annotations, v1alpha1.S2iBinaryUploadFileNameAnnoKey)
+		s2ibin, err = s.client.DevopsV1alpha1().S2iBinaries(namespace).Update(context.Background(), s2ibin, metav1.UpdateOptions{})
+		return
+	})
+	if err != nil {
+		klog.Error(err)
+		return
+	}
+
+	// the previous file is still there if it was uploaded, its phase is restored by the s2ibinary-scan-controller
+	phase := v1alpha1.StatusUploadFailed
+	if s2ibin.Spec.UploadTimeStamp != nil {
+		phase = v1alpha1.StatusScanning
+	}
+	_, err = s.SetS2iBinaryStatusWithRetry(s2ibin, phase)
+	return
+}
+
+// getMultipartUpload returns the S2iBinary if the upload is in progress
+func (s *s2iBinaryUploader) getMultipartUpload(namespace, name, uploadID string) (s2ibin *v1alpha1.S2iBinary, err error) {
+	if s2ibin, err = s.client.DevopsV1alpha1().S2iBinaries(namespace).Get(context.Background(), name, metav1.GetOptions{}); err != nil {
+		klog.Error(err)
+		return
+	}
+	if uploadID == "" || s2ibin.Annotations[v1alpha1.S2iBinaryUploadIDAnnoKey] != uploadID {
+		err = restful.NewError(http.StatusNotFound, fmt.Sprintf("upload %s not found", uploadID))
+	}
+	return
+}
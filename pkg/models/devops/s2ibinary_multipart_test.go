@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package devops
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha1"
+	"kubesphere.io/devops/pkg/client/clientset/versioned/fake"
+	fakeS3 "kubesphere.io/devops/pkg/client/s3/fake"
+)
+
+func TestMultipartUpload(t *testing.T) {
+	binary := &v1alpha1.S2iBinary{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "binary"},
+	}
+	client := fake.NewSimpleClientset(binary)
+	s3 := fakeS3.NewFakeS3()
+	uploader := NewS2iBinaryUploader(client, nil, s3, nil)
+
+	// file name is required
+	_, err := uploader.InitiateMultipartUpload("ns", "binary", "")
+	assert.NotNil(t, err)
+
+	upload, err := uploader.InitiateMultipartUpload("ns", "binary", "demo.jar")
+	assert.Nil(t, err)
+	assert.NotEmpty(t, upload.UploadID)
+	assert.Equal(t, "demo.jar", upload.FileName)
+
+	// only one upload at the same time
+	_, err = uploader.InitiateMultipartUpload("ns", "binary", "demo.jar")
+	assert.NotNil(t, err)
+
+	// unknown upload
+	_, err = uploader.UploadS2iBinaryPart("ns", "binary", "fake", 1, "", strings.NewReader("hello "))
+	assert.NotNil(t, err)
+	// invalid part number
+	_, err = uploader.UploadS2iBinaryPart("ns", "binary", upload.UploadID, 0, "", strings.NewReader("hello "))
+	assert.NotNil(t, err)
+	// checksum mismatch
+	_, err = uploader.UploadS2iBinaryPart("ns", "binary", upload.UploadID, 1, "fake", strings.NewReader("hello "))
+	assert.NotNil(t, err)
+
+	// md5 of "hello "
+	part, err := uploader.UploadS2iBinaryPart("ns", "binary", upload.UploadID, 1, "f814893777bcc2295fff05f00e508da6",
+		strings.NewReader("hello "))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(6), part.Size)
+
+	// part 2 is missing
+	_, err = uploader.UploadS2iBinaryPart("ns", "binary", upload.UploadID, 3, "", strings.NewReader("world"))
+	assert.Nil(t, err)
+	_, err = uploader.CompleteMultipartUpload("ns", "binary", upload.UploadID, "")
+	assert.NotNil(t, err)
+
+	// resume the upload
+	_, err = uploader.UploadS2iBinaryPart("ns", "binary", upload.UploadID, 2, "", strings.NewReader("kubesphere "))
+	assert.Nil(t, err)
+	resumed, err := uploader.ListS2iBinaryParts("ns", "binary", upload.UploadID)
+	assert.Nil(t, err)
+	assert.Equal(t, "demo.jar", resumed.FileName)
+	assert.Equal(t, 3, len(resumed.Parts))
+
+	s2ibin, err := uploader.CompleteMultipartUpload("ns", "binary", upload.UploadID, "md5")
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha1.StatusScanning, s2ibin.Status.Phase)
+	assert.Equal(t, "demo.jar", s2ibin.Spec.FileName)
+	assert.Equal(t, "md5", s2ibin.Spec.MD5)
+	assert.Equal(t, "22B", s2ibin.Spec.Size)
+	assert.NotNil(t, s2ibin.Spec.UploadTimeStamp)
+	assert.Empty(t, s2ibin.Annotations[v1alpha1.S2iBinaryUploadIDAnnoKey])
+
+	data, err := s3.Read("ns-binary")
+	assert.Nil(t, err)
+	assert.Equal(t, "hello kubesphere world", string(data))
+
+	// abort an upload, the previous file is kept
+	upload, err = uploader.InitiateMultipartUpload("ns", "binary", "new.jar")
+	assert.Nil(t, err)
+	assert.Nil(t, uploader.AbortMultipartUpload("ns", "binary", upload.UploadID))
+	assert.NotNil(t, uploader.AbortMultipartUpload("ns", "binary", upload.UploadID))
+	s2ibin, err = client.DevopsV1alpha1().S2iBinaries("ns").Get(context.Background(), "binary", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha1.StatusScanning, s2ibin.Status.Phase)
+	assert.Equal(t, "demo.jar", s2ibin.Spec.FileName)
+	assert.Empty(t, s3.Uploads)
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package devops
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha1"
+	"kubesphere.io/devops/pkg/client/clientset/versioned"
+)
+
+// S2iBuilderSuggestion contains the builder templates which fit an uploaded S2iBinary
+type S2iBuilderSuggestion struct {
+	// CodeFramework is the language detected from the uploaded file, it is empty if unknown
+	CodeFramework v1alpha1.CodeFramework `json:"codeFramework,omitempty"`
+	Builders      []S2iBuilderCandidate  `json:"builders"`
+}
+
+// S2iBuilderCandidate is a pair of builder image and runtime image provided by a template
+type S2iBuilderCandidate struct {
+	Template      string                 `json:"template"`
+	CodeFramework v1alpha1.CodeFramework `json:"codeFramework,omitempty"`
+	Version       string                 `json:"version,omitempty"`
+	BuilderImage  string                 `json:"builderImage"`
+	RuntimeImage  string                 `json:"runtimeImage,omitempty"`
+	// Default indicates whether the builder image is the default base image of the template
+	Default bool `json:"default,omitempty"`
+}
+
+// S2iBuilderTemplateOperator manages the catalog of S2I builder templates
+type S2iBuilderTemplateOperator interface {
+	ListS2iBuilderTemplates(codeFramework string) ([]v1alpha1.S2iBuilderTemplate, error)
+
+	GetS2iBuilderTemplate(name string) (*v1alpha1.S2iBuilderTemplate, error)
+
+	CreateS2iBuilderTemplate(template *v1alpha1.S2iBuilderTemplate) (*v1alpha1.S2iBuilderTemplate, error)
+
+	UpdateS2iBuilderTemplate(template *v1alpha1.S2iBuilderTemplate) (*v1alpha1.S2iBuilderTemplate, error)
+
+	DeleteS2iBuilderTemplate(name string) error
+
+	SuggestS2iBuilders(namespace, s2ibinary string) (*S2iBuilderSuggestion, error)
+}
+
+type s2iBuilderTemplateOperator struct {
+	client versioned.Interface
+}
+
+// NewS2iBuilderTemplateOperator creates the operator of S2iBuilderTemplate
+func NewS2iBuilderTemplateOperator(client versioned.Interface) S2iBuilderTemplateOperator {
+	return &s2iBuilderTemplateOperator{client: client}
+}
+
+func (o *s2iBuilderTemplateOperator) ListS2iBuilderTemplates(codeFramework string) (templates []v1alpha1.S2iBuilderTemplate, err error) {
+	var list *v1alpha1.S2iBuilderTemplateList
+	if list, err = o.client.DevopsV1alpha1().S2iBuilderTemplates().List(context.Background(), metav1.ListOptions{}); err != nil {
+		klog.Errorf("%+v", err)
+		return
+	}
+	templates = make([]v1alpha1.S2iBuilderTemplate, 0, len(list.Items))
+	for i := range list.Items {
+		if codeFramework == "" || strings.EqualFold(string(list.Items[i].Spec.CodeFramework), codeFramework) {
+			templates = append(templates, list.Items[i])
+		}
+	}
+	sort.SliceStable(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+	return
+}
+
+func (o *s2iBuilderTemplateOperator) GetS2iBuilderTemplate(name string) (*v1alpha1.S2iBuilderTemplate, error) {
+	return o.client.DevopsV1alpha1().S2iBuilderTemplates().Get(context.Background(), name, metav1.GetOptions{})
+}
+
+func (o *s2iBuilderTemplateOperator) CreateS2iBuilderTemplate(template *v1alpha1.S2iBuilderTemplate) (*v1alpha1.S2iBuilderTemplate, error) {
+	completeS2iBuilderTemplate(template)
+	return o.client.DevopsV1alpha1().S2iBuilderTemplates().Create(context.Background(), template, metav1.CreateOptions{})
+}
+
+func (o *s2iBuilderTemplateOperator) UpdateS2iBuilderTemplate(template *v1alpha1.S2iBuilderTemplate) (*v1alpha1.S2iBuilderTemplate, error) {
+	origin, err := o.GetS2iBuilderTemplate(template.Name)
+	if err != nil {
+		return nil, err
+	}
+	updated := origin.DeepCopy()
+	updated.Spec = template.Spec
+	completeS2iBuilderTemplate(updated)
+	return o.client.DevopsV1alpha1().S2iBuilderTemplates().Update(context.Background(), updated, metav1.UpdateOptions{})
+}
+
+func (o *s2iBuilderTemplateOperator) DeleteS2iBuilderTemplate(name string) error {
+	return o.client.DevopsV1alpha1().S2iBuilderTemplates().Delete(context.Background(), name, metav1.DeleteOptions{})
+}
+
+func (o *s2iBuilderTemplateOperator) SuggestS2iBuilders(namespace, s2ibinary string) (suggestion *S2iBuilderSuggestion, err error) {
+	var binary *v1alpha1.S2iBinary
+	if binary, err = o.client.DevopsV1alpha1().S2iBinaries(namespace).Get(context.Background(), s2ibinary, metav1.GetOptions{}); err != nil {
+		klog.Errorf("%+v", err)
+		return
+	}
+
+	suggestion = &S2iBuilderSuggestion{
+		CodeFramework: DetectCodeFramework(binary.Spec.FileName),
+		Builders:      []S2iBuilderCandidate{},
+	}
+	if suggestion.CodeFramework == "" {
+		return
+	}
+
+	var templates []v1alpha1.S2iBuilderTemplate
+	if templates, err = o.ListS2iBuilderTemplates(string(suggestion.CodeFramework)); err != nil {
+		return
+	}
+	for i := range templates {
+		suggestion.Builders = append(suggestion.Builders, getS2iBuilderCandidates(&templates[i])...)
+	}
+	return
+}
+
+// completeS2iBuilderTemplate makes sure a custom builder image is usable as the default one
+func completeS2iBuilderTemplate(template *v1alpha1.S2iBuilderTemplate) {
+	if template.Spec.DefaultBaseImage == "" && len(template.Spec.ContainerInfo) > 0 {
+		template.Spec.DefaultBaseImage = template.Spec.ContainerInfo[0].BuilderImage
+	}
+}
+
+func getS2iBuilderCandidates(template *v1alpha1.S2iBuilderTemplate) (candidates []S2iBuilderCandidate) {
+	for _, info := range template.Spec.ContainerInfo {
+		if info.BuilderImage == "" {
+			continue
+		}
+		candidates = append(candidates, S2iBuilderCandidate{
+			Template:      template.Name,
+			CodeFramework: template.Spec.CodeFramework,
+			Version:       template.Spec.Version,
+			BuilderImage:  info.BuilderImage,
+			RuntimeImage:  info.RuntimeImage,
+			Default:       info.BuilderImage == template.Spec.DefaultBaseImage,
+		})
+	}
+	// the default builder comes first
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Default && !candidates[j].Default
+	})
+	return
+}
+
+// DetectCodeFramework detects the code framework from the name of an uploaded binary file
+func DetectCodeFramework(fileName string) v1alpha1.CodeFramework {
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".war":
+		return v1alpha1.JavaTomcat
+	case ".jar":
+		return v1alpha1.Java
+	case ".whl":
+		return v1alpha1.Python
+	case ".gem":
+		return v1alpha1.Ruby
+	}
+	return ""
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package devops
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha1"
+	"kubesphere.io/devops/pkg/client/clientset/versioned/fake"
+)
+
+func TestDetectCodeFramework(t *testing.T) {
+	tests := []struct {
+		fileName string
+		expect   v1alpha1.CodeFramework
+	}{{
+		fileName: "demo.war",
+		expect:   v1alpha1.JavaTomcat,
+	}, {
+		fileName: "Demo.JAR",
+		expect:   v1alpha1.Java,
+	}, {
+		fileName: "demo-0.1-py3-none-any.whl",
+		expect:   v1alpha1.Python,
+	}, {
+		fileName: "demo.gem",
+		expect:   v1alpha1.Ruby,
+	}, {
+		fileName: "demo.zip",
+	}, {
+		fileName: "",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.fileName, func(t *testing.T) {
+			assert.Equal(t, tt.expect, DetectCodeFramework(tt.fileName))
+		})
+	}
+}
+
+func TestS2iBuilderTemplateOperator(t *testing.T) {
+	javaTemplate := &v1alpha1.S2iBuilderTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "java"},
+		Spec: v1alpha1.S2iBuilderTemplateSpec{
+			CodeFramework:    v1alpha1.Java,
+			DefaultBaseImage: "kubesphere/java-11-centos7:v3.2.0",
+			ContainerInfo: []v1alpha1.ContainerInfo{{
+				BuilderImage: "kubesphere/java-8-centos7:v3.2.0",
+				RuntimeImage: "kubesphere/java-8-runtime:v3.2.0",
+			}, {
+				BuilderImage: "kubesphere/java-11-centos7:v3.2.0",
+				RuntimeImage: "kubesphere/java-11-runtime:v3.2.0",
+			}},
+		},
+	}
+	tomcatTemplate := &v1alpha1.S2iBuilderTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "tomcat"},
+		Spec: v1alpha1.S2iBuilderTemplateSpec{
+			CodeFramework: v1alpha1.JavaTomcat,
+			ContainerInfo: []v1alpha1.ContainerInfo{{
+				BuilderImage: "kubesphere/tomcat85-java8-centos7:v3.2.0",
+			}},
+		},
+	}
+	binary := &v1alpha1.S2iBinary{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "binary"},
+		Spec:       v1alpha1.S2iBinarySpec{FileName: "demo.jar"},
+	}
+	unknownBinary := &v1alpha1.S2iBinary{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "unknown"},
+		Spec:       v1alpha1.S2iBinarySpec{FileName: "demo.zip"},
+	}
+	operator := NewS2iBuilderTemplateOperator(fake.NewSimpleClientset(javaTemplate, tomcatTemplate, binary, unknownBinary))
+
+	// list
+	templates, err := operator.ListS2iBuilderTemplates("")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(templates))
+	templates, err = operator.ListS2iBuilderTemplates("javatomcat")
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(templates)) {
+		assert.Equal(t, "tomcat", templates[0].Name)
+	}
+
+	// suggest the builders, the default one comes first
+	suggestion, err := operator.SuggestS2iBuilders("ns", "binary")
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha1.Java, suggestion.CodeFramework)
+	assert.Equal(t, []S2iBuilderCandidate{{
+		Template:      "java",
+		CodeFramework: v1alpha1.Java,
+		BuilderImage:  "kubesphere/java-11-centos7:v3.2.0",
+		RuntimeImage:  "kubesphere/java-11-runtime:v3.2.0",
+		Default:       true,
+	}, {
+		Template:      "java",
+		CodeFramework: v1alpha1.Java,
+		BuilderImage:  "kubesphere/java-8-centos7:v3.2.0",
+		RuntimeImage:  "kubesphere/java-8-runtime:v3.2.0",
+	}}, suggestion.Builders)
+
+	suggestion, err = operator.SuggestS2iBuilders("ns", "unknown")
+	assert.Nil(t, err)
+	assert.Empty(t, suggestion.CodeFramework)
+	assert.Empty(t, suggestion.Builders)
+
+	_, err = operator.SuggestS2iBuilders("ns", "fake")
+	assert.NotNil(t, err)
+
+	// register a custom builder image
+	created, err := operator.CreateS2iBuilderTemplate(&v1alpha1.S2iBuilderTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "custom"},
+		Spec: v1alpha1.S2iBuilderTemplateSpec{
+			CodeFramework: v1alpha1.Go,
+			ContainerInfo: []v1alpha1.ContainerInfo{{BuilderImage: "example.com/go-builder:1.0"}},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "example.com/go-builder:1.0", created.Spec.DefaultBaseImage)
+
+	updated, err := operator.UpdateS2iBuilderTemplate(&v1alpha1.S2iBuilderTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "custom"},
+		Spec: v1alpha1.S2iBuilderTemplateSpec{
+			CodeFramework: v1alpha1.Go,
+			Version:       "1.1",
+			ContainerInfo: []v1alpha1.ContainerInfo{{BuilderImage: "example.com/go-builder:1.1"}},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "1.1", updated.Spec.Version)
+	assert.Equal(t, "example.com/go-builder:1.1", updated.Spec.DefaultBaseImage)
+
+	assert.Nil(t, operator.DeleteS2iBuilderTemplate("custom"))
+	_, err = operator.GetS2iBuilderTemplate("custom")
+	assert.NotNil(t, err)
+}
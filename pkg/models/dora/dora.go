@@ -0,0 +1,174 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package dora calculates the DORA metrics of the DevOps projects, which are deployment frequency,
+// lead time for changes, change failure rate and mean time to restore.
+package dora
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/history"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultWindow is the default period of the DORA metrics
+const DefaultWindow = 30 * 24 * time.Hour
+
+// Metrics is the DORA metrics of a DevOps project in a period
+type Metrics struct {
+	Project string    `json:"project"`
+	Since   time.Time `json:"since"`
+	Until   time.Time `json:"until"`
+
+	// Deployments is the number of the completed deployments, including the failed ones
+	Deployments int `json:"deployments"`
+	// FailedDeployments is the number of the failed deployments
+	FailedDeployments int `json:"failedDeployments"`
+
+	// DeploymentFrequency is the average number of the successful deployments per day
+	DeploymentFrequency float64 `json:"deploymentFrequency"`
+	// LeadTimeSeconds is the average time from the first PipelineRun of a revision to its successful deployment
+	LeadTimeSeconds float64 `json:"leadTimeForChangesSeconds"`
+	// ChangeFailureRate is the ratio of the failed deployments
+	ChangeFailureRate float64 `json:"changeFailureRate"`
+	// MTTRSeconds is the average time from a failed deployment to the next successful one of the same Pipeline
+	MTTRSeconds float64 `json:"meanTimeToRestoreSeconds"`
+}
+
+// IsDeploymentPipeline returns true if the PipelineRuns of the Pipeline are deployments
+func IsDeploymentPipeline(pipeline *v1alpha3.Pipeline) bool {
+	return pipeline.GetAnnotations()[v1alpha3.PipelineDeploymentAnnoKey] == "true"
+}
+
+// Collect calculates the DORA metrics of the DevOps project in the namespace. The PipelineRuns are read from
+// the history store if it's available, otherwise from the cluster.
+func Collect(ctx context.Context, c client.Reader, store history.Interface, namespace string,
+	since, until time.Time) (metrics *Metrics, err error) {
+	pipelineList := &v1alpha3.PipelineList{}
+	if err = c.List(ctx, pipelineList, client.InNamespace(namespace)); err != nil {
+		return
+	}
+	deploymentPipelines := map[string]bool{}
+	for i := range pipelineList.Items {
+		if IsDeploymentPipeline(&pipelineList.Items[i]) {
+			deploymentPipelines[pipelineList.Items[i].Name] = true
+		}
+	}
+
+	var summaries []history.RunSummary
+	if store != nil {
+		if summaries, _, err = store.List(ctx, history.Filter{Namespace: namespace, Since: since, Until: until}); err != nil {
+			return
+		}
+	} else {
+		pipelineRunList := &v1alpha3.PipelineRunList{}
+		if err = c.List(ctx, pipelineRunList, client.InNamespace(namespace)); err != nil {
+			return
+		}
+		for i := range pipelineRunList.Items {
+			summaries = append(summaries, *history.NewRunSummary(&pipelineRunList.Items[i]))
+		}
+	}
+
+	metrics = Calculate(summaries, deploymentPipelines, since, until)
+	metrics.Project = namespace
+	return
+}
+
+// Calculate calculates the DORA metrics from the PipelineRun summaries, the PipelineRuns of the deployment
+// Pipelines which completed in the period are taken as the deployments
+func Calculate(summaries []history.RunSummary, deploymentPipelines map[string]bool, since, until time.Time) *Metrics {
+	metrics := &Metrics{Since: since, Until: until}
+
+	// the changes are identified by the SCM revisions, they start from the first PipelineRun of the revision
+	changeStartTimes := map[string]time.Time{}
+	deployments := make([]history.RunSummary, 0)
+	for _, summary := range summaries {
+		if summary.SCMRevision != "" && summary.StartTime != nil {
+			if startTime, ok := changeStartTimes[summary.SCMRevision]; !ok || summary.StartTime.Before(startTime) {
+				changeStartTimes[summary.SCMRevision] = *summary.StartTime
+			}
+		}
+		if isDeployment(summary, deploymentPipelines, since, until) {
+			deployments = append(deployments, summary)
+		}
+	}
+	sort.SliceStable(deployments, func(i, j int) bool {
+		return deployments[i].CompletionTime.Before(*deployments[j].CompletionTime)
+	})
+
+	var succeeded int
+	var leadTimes, restoreTimes []time.Duration
+	failedSince := map[string]time.Time{}
+	for _, deployment := range deployments {
+		metrics.Deployments++
+		if deployment.Phase == string(v1alpha3.Failed) {
+			metrics.FailedDeployments++
+			if _, ok := failedSince[deployment.Pipeline]; !ok {
+				failedSince[deployment.Pipeline] = *deployment.CompletionTime
+			}
+			continue
+		}
+
+		succeeded++
+		if failedTime, ok := failedSince[deployment.Pipeline]; ok {
+			restoreTimes = append(restoreTimes, deployment.CompletionTime.Sub(failedTime))
+			delete(failedSince, deployment.Pipeline)
+		}
+		startTime, ok := changeStartTimes[deployment.SCMRevision]
+		if !ok && deployment.StartTime != nil {
+			startTime, ok = *deployment.StartTime, true
+		}
+		if ok {
+			leadTimes = append(leadTimes, deployment.CompletionTime.Sub(startTime))
+		}
+	}
+
+	if days := until.Sub(since).Hours() / 24; days > 0 {
+		metrics.DeploymentFrequency = float64(succeeded) / days
+	}
+	if metrics.Deployments > 0 {
+		metrics.ChangeFailureRate = float64(metrics.FailedDeployments) / float64(metrics.Deployments)
+	}
+	metrics.LeadTimeSeconds = averageSeconds(leadTimes)
+	metrics.MTTRSeconds = averageSeconds(restoreTimes)
+	return metrics
+}
+
+func isDeployment(summary history.RunSummary, deploymentPipelines map[string]bool, since, until time.Time) bool {
+	if !deploymentPipelines[summary.Pipeline] || summary.CompletionTime == nil {
+		return false
+	}
+	if summary.Phase != string(v1alpha3.Succeeded) && summary.Phase != string(v1alpha3.Failed) {
+		return false
+	}
+	return !summary.CompletionTime.Before(since) && !summary.CompletionTime.After(until)
+}
+
+func averageSeconds(durations []time.Duration) float64 {
+	if len(durations) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, duration := range durations {
+		total += duration
+	}
+	return total.Seconds() / float64(len(durations))
+}
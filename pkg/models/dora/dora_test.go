@@ -0,0 +1,148 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dora
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/history"
+	fakehistory "kubesphere.io/devops/pkg/client/history/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var baseTime = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func at(hours int) *time.Time {
+	t := baseTime.Add(time.Duration(hours) * time.Hour)
+	return &t
+}
+
+func summary(uid, pipeline, phase, revision string, start, completion int) history.RunSummary {
+	return history.RunSummary{
+		UID:            uid,
+		Namespace:      "project",
+		Pipeline:       pipeline,
+		Phase:          phase,
+		SCMRevision:    revision,
+		StartTime:      at(start),
+		CompletionTime: at(completion),
+	}
+}
+
+func TestCalculate(t *testing.T) {
+	deploymentPipelines := map[string]bool{"deploy": true}
+	since, until := baseTime, baseTime.Add(10*24*time.Hour)
+
+	tests := []struct {
+		name      string
+		summaries []history.RunSummary
+		expect    *Metrics
+	}{{
+		name:   "no deployments",
+		expect: &Metrics{Since: since, Until: until},
+	}, {
+		name: "the builds are not deployments",
+		summaries: []history.RunSummary{
+			summary("1", "build", "Succeeded", "a", 1, 2),
+			summary("2", "build", "Failed", "b", 3, 4),
+		},
+		expect: &Metrics{Since: since, Until: until},
+	}, {
+		name: "lead time starts from the first PipelineRun of the revision",
+		summaries: []history.RunSummary{
+			summary("1", "build", "Succeeded", "a", 1, 2),
+			summary("2", "deploy", "Succeeded", "a", 3, 5),
+			// no other PipelineRuns of the revision
+			summary("3", "deploy", "Succeeded", "", 10, 11),
+		},
+		expect: &Metrics{Since: since, Until: until, Deployments: 2,
+			DeploymentFrequency: 0.2, LeadTimeSeconds: 2.5 * 3600},
+	}, {
+		name: "failed deployments and the restore time",
+		summaries: []history.RunSummary{
+			summary("1", "deploy", "Failed", "a", 1, 2),
+			summary("2", "deploy", "Failed", "b", 3, 4),
+			summary("3", "deploy", "Succeeded", "c", 5, 6),
+			summary("4", "deploy", "Failed", "d", 7, 8),
+			summary("5", "deploy", "Succeeded", "e", 9, 10),
+		},
+		expect: &Metrics{Since: since, Until: until, Deployments: 5, FailedDeployments: 3,
+			DeploymentFrequency: 0.2, LeadTimeSeconds: 3600, ChangeFailureRate: 0.6, MTTRSeconds: 3 * 3600},
+	}, {
+		name: "cancelled and out of period PipelineRuns are ignored",
+		summaries: []history.RunSummary{
+			summary("1", "deploy", "Cancelled", "a", 1, 2),
+			summary("2", "deploy", "Failed", "b", -3, -2),
+			summary("3", "deploy", "Succeeded", "c", 300, 301),
+			{UID: "4", Pipeline: "deploy", Phase: "Running", StartTime: at(1)},
+		},
+		expect: &Metrics{Since: since, Until: until},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expect, Calculate(tt.summaries, deploymentPipelines, since, until))
+		})
+	}
+}
+
+func TestCollect(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	deployPipeline := &v1alpha3.Pipeline{ObjectMeta: v1.ObjectMeta{
+		Namespace: "project", Name: "deploy",
+		Annotations: map[string]string{v1alpha3.PipelineDeploymentAnnoKey: "true"},
+	}}
+	buildPipeline := &v1alpha3.Pipeline{ObjectMeta: v1.ObjectMeta{Namespace: "project", Name: "build"}}
+	pipelineRun := &v1alpha3.PipelineRun{
+		ObjectMeta: v1.ObjectMeta{
+			Namespace: "project", Name: "deploy-1",
+			Labels: map[string]string{v1alpha3.PipelineNameLabelKey: "deploy"},
+		},
+		Status: v1alpha3.PipelineRunStatus{
+			Phase:          v1alpha3.Failed,
+			StartTime:      &v1.Time{Time: *at(1)},
+			CompletionTime: &v1.Time{Time: *at(2)},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(schema).WithObjects(deployPipeline, buildPipeline, pipelineRun).Build()
+	since, until := baseTime, baseTime.Add(24*time.Hour)
+
+	// read from the cluster
+	metrics, err := Collect(context.TODO(), c, nil, "project", since, until)
+	assert.Nil(t, err)
+	assert.Equal(t, "project", metrics.Project)
+	assert.Equal(t, 1, metrics.Deployments)
+	assert.Equal(t, float64(1), metrics.ChangeFailureRate)
+
+	// read from the history store
+	store := fakehistory.NewStore(summary("1", "deploy", "Succeeded", "a", 1, 2))
+	metrics, err = Collect(context.TODO(), c, store, "project", since, until)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, metrics.Deployments)
+	assert.Equal(t, float64(1), metrics.DeploymentFrequency)
+	assert.Equal(t, float64(0), metrics.ChangeFailureRate)
+
+	store.Err = assert.AnError
+	_, err = Collect(context.TODO(), c, store, "project", since, until)
+	assert.Equal(t, assert.AnError, err)
+}
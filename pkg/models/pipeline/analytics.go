@@ -0,0 +1,196 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+	"sort"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/history"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// StageResultSuccess is the result of a succeeded stage
+	StageResultSuccess = "SUCCESS"
+	// StageResultFailure is the result of a failed stage
+	StageResultFailure = "FAILURE"
+	// StageResultUnstable is the result of a stage with failed tests
+	StageResultUnstable = "UNSTABLE"
+)
+
+// StageAnalytics is the comparison of a stage across the PipelineRuns
+type StageAnalytics struct {
+	Name     string `json:"name"`
+	Runs     int    `json:"runs"`
+	Failures int    `json:"failures"`
+	// FailureRate is the ratio of the failed runs of the stage
+	FailureRate float64 `json:"failureRate"`
+
+	AverageDurationInMillis int64 `json:"averageDurationInMillis"`
+	MinDurationInMillis     int64 `json:"minDurationInMillis"`
+	MaxDurationInMillis     int64 `json:"maxDurationInMillis"`
+
+	// Flaky indicates the stage both failed and succeeded on the same revision
+	Flaky bool `json:"flaky"`
+	// FlakyRevisions are the SCM revisions on which the stage behaved differently
+	FlakyRevisions []string `json:"flakyRevisions,omitempty"`
+	// Results are the stage results of the PipelineRuns, the latest one comes first
+	Results []StageResult `json:"results"`
+}
+
+// StageResult is the result of a stage in a PipelineRun
+type StageResult struct {
+	PipelineRun      string `json:"pipelineRun"`
+	SCMRevision      string `json:"scmRevision,omitempty"`
+	Result           string `json:"result"`
+	DurationInMillis int64  `json:"durationInMillis"`
+}
+
+// Analytics is the stage comparison of the recent PipelineRuns of a Pipeline
+type Analytics struct {
+	Pipeline string `json:"pipeline"`
+	// PipelineRuns are the names of the compared PipelineRuns, the latest one comes first
+	PipelineRuns []string         `json:"pipelineRuns"`
+	Stages       []StageAnalytics `json:"stages"`
+	// FlakyStages are the names of the flaky stages
+	FlakyStages []string `json:"flakyStages"`
+}
+
+// LoadRecentRuns returns the latest completed PipelineRuns of a Pipeline. They are read from the history
+// store if it's available, otherwise from the cluster.
+func LoadRecentRuns(ctx context.Context, c client.Reader, store history.Interface,
+	namespace, pipeline, branch string, limit int) (summaries []history.RunSummary, err error) {
+	if store != nil {
+		summaries, _, err = store.List(ctx, history.Filter{
+			Namespace: namespace,
+			Pipeline:  pipeline,
+			Branch:    branch,
+			Limit:     limit,
+		})
+		return
+	}
+
+	pipelineRunList := &v1alpha3.PipelineRunList{}
+	if err = c.List(ctx, pipelineRunList, client.InNamespace(namespace),
+		client.MatchingLabels{v1alpha3.PipelineNameLabelKey: pipeline}); err != nil {
+		return
+	}
+	for i := range pipelineRunList.Items {
+		pr := &pipelineRunList.Items[i]
+		if !pr.HasCompleted() || (branch != "" && pr.GetRefName() != branch) {
+			continue
+		}
+		summaries = append(summaries, *history.NewRunSummary(pr))
+	}
+	sort.SliceStable(summaries, func(i, j int) bool {
+		return summaries[i].CompletionTime.After(*summaries[j].CompletionTime)
+	})
+	if limit > 0 && len(summaries) > limit {
+		summaries = summaries[:limit]
+	}
+	return
+}
+
+// Analyze compares the stages across the PipelineRuns which are sorted from the latest one.
+// A stage is flaky if it both failed and succeeded on the same SCM revision, it's hard to tell
+// for the PipelineRuns without SCM revisions since their inputs are unknown.
+func Analyze(pipeline string, summaries []history.RunSummary) *Analytics {
+	analytics := &Analytics{
+		Pipeline:     pipeline,
+		PipelineRuns: make([]string, 0, len(summaries)),
+		Stages:       make([]StageAnalytics, 0),
+		FlakyStages:  make([]string, 0),
+	}
+
+	stageIndexes := map[string]int{}
+	for _, summary := range summaries {
+		analytics.PipelineRuns = append(analytics.PipelineRuns, summary.Name)
+		for _, stage := range summary.Stages {
+			index, ok := stageIndexes[stage.Name]
+			if !ok {
+				index = len(analytics.Stages)
+				stageIndexes[stage.Name] = index
+				analytics.Stages = append(analytics.Stages, StageAnalytics{Name: stage.Name, Results: make([]StageResult, 0)})
+			}
+			analytics.Stages[index].Results = append(analytics.Stages[index].Results, StageResult{
+				PipelineRun:      summary.Name,
+				SCMRevision:      summary.SCMRevision,
+				Result:           stage.Result,
+				DurationInMillis: stage.DurationInMillis,
+			})
+		}
+	}
+
+	for i := range analytics.Stages {
+		analyzeStage(&analytics.Stages[i])
+		if analytics.Stages[i].Flaky {
+			analytics.FlakyStages = append(analytics.FlakyStages, analytics.Stages[i].Name)
+		}
+	}
+	return analytics
+}
+
+func analyzeStage(stage *StageAnalytics) {
+	var totalDuration int64
+	succeededRevisions, failedRevisions := map[string]bool{}, map[string]bool{}
+	for _, result := range stage.Results {
+		var failed bool
+		switch result.Result {
+		case StageResultSuccess:
+		case StageResultFailure, StageResultUnstable:
+			failed = true
+		default:
+			// the stages which were skipped or aborted tell nothing about the stability
+			continue
+		}
+
+		stage.Runs++
+		totalDuration += result.DurationInMillis
+		if stage.Runs == 1 || result.DurationInMillis < stage.MinDurationInMillis {
+			stage.MinDurationInMillis = result.DurationInMillis
+		}
+		if result.DurationInMillis > stage.MaxDurationInMillis {
+			stage.MaxDurationInMillis = result.DurationInMillis
+		}
+
+		if failed {
+			stage.Failures++
+		}
+		if result.SCMRevision == "" {
+			continue
+		}
+		if failed {
+			failedRevisions[result.SCMRevision] = true
+		} else {
+			succeededRevisions[result.SCMRevision] = true
+		}
+	}
+
+	if stage.Runs > 0 {
+		stage.FailureRate = float64(stage.Failures) / float64(stage.Runs)
+		stage.AverageDurationInMillis = totalDuration / int64(stage.Runs)
+	}
+	for revision := range failedRevisions {
+		if succeededRevisions[revision] {
+			stage.FlakyRevisions = append(stage.FlakyRevisions, revision)
+		}
+	}
+	sort.Strings(stage.FlakyRevisions)
+	stage.Flaky = len(stage.FlakyRevisions) > 0
+}
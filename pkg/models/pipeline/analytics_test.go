@@ -0,0 +1,135 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/history"
+	fakehistory "kubesphere.io/devops/pkg/client/history/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestAnalyze(t *testing.T) {
+	summaries := []history.RunSummary{{
+		Name: "build-4", SCMRevision: "b",
+		Stages: []history.StageSummary{{Name: "checkout", Result: "SUCCESS", DurationInMillis: 100},
+			{Name: "test", Result: "SUCCESS", DurationInMillis: 3000}},
+	}, {
+		Name: "build-3", SCMRevision: "b",
+		Stages: []history.StageSummary{{Name: "checkout", Result: "SUCCESS", DurationInMillis: 300},
+			{Name: "test", Result: "UNSTABLE", DurationInMillis: 1000}},
+	}, {
+		Name: "build-2", SCMRevision: "a",
+		Stages: []history.StageSummary{{Name: "checkout", Result: "SUCCESS", DurationInMillis: 200},
+			{Name: "test", Result: "FAILURE", DurationInMillis: 2000},
+			{Name: "deploy", Result: "NOT_BUILT"}},
+	}, {
+		// there's no revision, the inputs are unknown
+		Name: "build-1",
+		Stages: []history.StageSummary{{Name: "checkout", Result: "SUCCESS", DurationInMillis: 200},
+			{Name: "test", Result: "SUCCESS", DurationInMillis: 2000}},
+	}}
+
+	analytics := Analyze("build", summaries)
+	assert.Equal(t, "build", analytics.Pipeline)
+	assert.Equal(t, []string{"build-4", "build-3", "build-2", "build-1"}, analytics.PipelineRuns)
+	assert.Equal(t, []string{"test"}, analytics.FlakyStages)
+	if assert.Equal(t, 3, len(analytics.Stages)) {
+		checkout := analytics.Stages[0]
+		assert.Equal(t, "checkout", checkout.Name)
+		assert.Equal(t, 4, checkout.Runs)
+		assert.Equal(t, 0, checkout.Failures)
+		assert.Equal(t, int64(200), checkout.AverageDurationInMillis)
+		assert.Equal(t, int64(100), checkout.MinDurationInMillis)
+		assert.Equal(t, int64(300), checkout.MaxDurationInMillis)
+		assert.False(t, checkout.Flaky)
+
+		test := analytics.Stages[1]
+		assert.Equal(t, 4, test.Runs)
+		assert.Equal(t, 2, test.Failures)
+		assert.Equal(t, 0.5, test.FailureRate)
+		assert.True(t, test.Flaky)
+		assert.Equal(t, []string{"b"}, test.FlakyRevisions)
+		assert.Equal(t, 4, len(test.Results))
+
+		deploy := analytics.Stages[2]
+		assert.Equal(t, 0, deploy.Runs)
+		assert.Equal(t, 1, len(deploy.Results))
+	}
+
+	empty := Analyze("build", nil)
+	assert.Empty(t, empty.Stages)
+	assert.Empty(t, empty.FlakyStages)
+}
+
+func TestLoadRecentRuns(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	newPipelineRun := func(name, branch string, phase v1alpha3.RunPhase, completion time.Duration) *v1alpha3.PipelineRun {
+		pr := &v1alpha3.PipelineRun{
+			ObjectMeta: v1.ObjectMeta{
+				Namespace: "ns", Name: name,
+				Labels: map[string]string{v1alpha3.PipelineNameLabelKey: "build"},
+			},
+			Status: v1alpha3.PipelineRunStatus{Phase: phase},
+		}
+		if branch != "" {
+			pr.Spec.PipelineSpec = &v1alpha3.PipelineSpec{Type: v1alpha3.MultiBranchPipelineType}
+			pr.Spec.SCM = &v1alpha3.SCM{RefName: branch}
+		}
+		if phase != v1alpha3.Running {
+			pr.Status.CompletionTime = &v1.Time{Time: time.Now().Add(completion)}
+		}
+		return pr
+	}
+	c := fake.NewClientBuilder().WithScheme(schema).WithObjects(
+		newPipelineRun("build-1", "main", v1alpha3.Succeeded, -3*time.Hour),
+		newPipelineRun("build-2", "main", v1alpha3.Failed, -2*time.Hour),
+		newPipelineRun("build-3", "dev", v1alpha3.Succeeded, -time.Hour),
+		newPipelineRun("build-4", "main", v1alpha3.Running, 0),
+	).Build()
+
+	summaries, err := LoadRecentRuns(context.TODO(), c, nil, "ns", "build", "", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"build-3", "build-2", "build-1"}, names(summaries))
+
+	summaries, err = LoadRecentRuns(context.TODO(), c, nil, "ns", "build", "main", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"build-2"}, names(summaries))
+
+	// read from the history store
+	completionTime := time.Now()
+	store := fakehistory.NewStore(history.RunSummary{UID: "1", Namespace: "ns", Pipeline: "build", Name: "archived",
+		CompletionTime: &completionTime})
+	summaries, err = LoadRecentRuns(context.TODO(), c, store, "ns", "build", "", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"archived"}, names(summaries))
+}
+
+func names(summaries []history.RunSummary) (result []string) {
+	for _, summary := range summaries {
+		result = append(result, summary.Name)
+	}
+	return
+}
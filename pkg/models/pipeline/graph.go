@@ -0,0 +1,203 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// GraphNodeStage is a stage which runs steps
+	GraphNodeStage = "stage"
+	// GraphNodeParallel is a stage whose children run at the same time
+	GraphNodeParallel = "parallel"
+	// GraphNodeSequential is a stage whose children run one after another
+	GraphNodeSequential = "sequential"
+)
+
+// Graph is the normalized DAG of the stages of a Pipeline
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+	// Parallelism is the max number of the stages which are able to run at the same time
+	Parallelism int `json:"parallelism"`
+}
+
+// GraphNode is a stage of a Pipeline
+type GraphNode struct {
+	// ID is the path of the stage in the Pipeline, such as 1.0
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Parent is the ID of the parallel or sequential stage which contains this stage
+	Parent string `json:"parent,omitempty"`
+	// Conditions are the expressions of the when directive, the stage is skipped unless all of them are met
+	Conditions []string `json:"conditions,omitempty"`
+	// Steps is the number of the steps in the stage
+	Steps int `json:"steps"`
+}
+
+// GraphEdge indicates the To stage starts once the From stage completed
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type pipelineModel struct {
+	Pipeline struct {
+		Stages []stageModel `json:"stages"`
+	} `json:"pipeline"`
+}
+
+type stageModel struct {
+	Name     string        `json:"name"`
+	Branches []branchModel `json:"branches"`
+	Parallel []stageModel  `json:"parallel"`
+	Stages   []stageModel  `json:"stages"`
+	When     *struct {
+		Conditions []conditionModel `json:"conditions"`
+	} `json:"when"`
+}
+
+type branchModel struct {
+	Name  string            `json:"name"`
+	Steps []json.RawMessage `json:"steps"`
+}
+
+type conditionModel struct {
+	Name      string           `json:"name"`
+	Arguments json.RawMessage  `json:"arguments"`
+	Children  []conditionModel `json:"children"`
+}
+
+type argumentModel struct {
+	Key   string     `json:"key"`
+	Value valueModel `json:"value"`
+}
+
+type valueModel struct {
+	IsLiteral bool        `json:"isLiteral"`
+	Value     interface{} `json:"value"`
+}
+
+// ParseGraph computes the DAG from the JSON format of a Jenkinsfile, which is converted by the pipeline-model-definition plugin
+func ParseGraph(jenkinsfileJSON string) (graph *Graph, err error) {
+	model := &pipelineModel{}
+	if err = json.Unmarshal([]byte(jenkinsfileJSON), model); err != nil {
+		err = fmt.Errorf("invalid Jenkinsfile JSON: %v", err)
+		return
+	}
+
+	graph = &Graph{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+	if len(model.Pipeline.Stages) > 0 {
+		graph.Parallelism = 1
+	}
+	graph.addSequence(model.Pipeline.Stages, "", "", nil)
+	return
+}
+
+// addSequence adds the stages one after another, the entries of the first stage follow the given exits.
+// It returns the exits of the last stage.
+func (g *Graph) addSequence(stages []stageModel, parentID, prefix string, exits []string) []string {
+	for i := range stages {
+		exits = g.addStage(&stages[i], parentID, joinID(prefix, i), exits)
+	}
+	return exits
+}
+
+func (g *Graph) addStage(stage *stageModel, parentID, id string, previous []string) (exits []string) {
+	node := GraphNode{ID: id, Name: stage.Name, Type: GraphNodeStage, Parent: parentID}
+	if stage.When != nil {
+		for i := range stage.When.Conditions {
+			node.Conditions = append(node.Conditions, conditionExpression(&stage.When.Conditions[i]))
+		}
+	}
+	for _, previousID := range previous {
+		g.Edges = append(g.Edges, GraphEdge{From: previousID, To: id})
+	}
+
+	// the legacy format of the parallel stages puts them as the branches
+	children := stage.Parallel
+	if len(children) == 0 && len(stage.Branches) > 1 {
+		for _, branch := range stage.Branches {
+			children = append(children, stageModel{Name: branch.Name, Branches: []branchModel{branch}})
+		}
+	}
+
+	switch {
+	case len(children) > 0:
+		node.Type = GraphNodeParallel
+		g.Nodes = append(g.Nodes, node)
+		if len(children) > g.Parallelism {
+			g.Parallelism = len(children)
+		}
+		for i := range children {
+			exits = append(exits, g.addStage(&children[i], id, joinID(id, i), []string{id})...)
+		}
+	case len(stage.Stages) > 0:
+		node.Type = GraphNodeSequential
+		g.Nodes = append(g.Nodes, node)
+		exits = g.addSequence(stage.Stages, id, id, []string{id})
+	default:
+		for _, branch := range stage.Branches {
+			node.Steps += len(branch.Steps)
+		}
+		g.Nodes = append(g.Nodes, node)
+		exits = []string{id}
+	}
+	return
+}
+
+func joinID(prefix string, index int) string {
+	if prefix == "" {
+		return strconv.Itoa(index)
+	}
+	return prefix + "." + strconv.Itoa(index)
+}
+
+// conditionExpression formats a condition of the when directive, such as branch(pattern: "main")
+func conditionExpression(condition *conditionModel) string {
+	var args []string
+	for i := range condition.Children {
+		args = append(args, conditionExpression(&condition.Children[i]))
+	}
+
+	named := []argumentModel{}
+	single := valueModel{}
+	if err := json.Unmarshal(condition.Arguments, &named); err == nil {
+		sort.SliceStable(named, func(i, j int) bool {
+			return named[i].Key < named[j].Key
+		})
+		for _, arg := range named {
+			args = append(args, fmt.Sprintf("%s: %s", arg.Key, formatValue(arg.Value)))
+		}
+	} else if err = json.Unmarshal(condition.Arguments, &single); err == nil && single.Value != nil {
+		args = append(args, formatValue(single))
+	}
+	return fmt.Sprintf("%s(%s)", condition.Name, strings.Join(args, ", "))
+}
+
+func formatValue(value valueModel) string {
+	if str, ok := value.Value.(string); ok && value.IsLiteral {
+		return strconv.Quote(str)
+	}
+	return fmt.Sprint(value.Value)
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGraph(t *testing.T) {
+	tests := []struct {
+		name        string
+		json        string
+		expectGraph *Graph
+		expectErr   bool
+	}{{
+		name:      "invalid JSON",
+		json:      "pipeline {}",
+		expectErr: true,
+	}, {
+		name:        "no stages",
+		json:        `{"pipeline":{"agent":{"type":"any"}}}`,
+		expectGraph: &Graph{Nodes: []GraphNode{}, Edges: []GraphEdge{}},
+	}, {
+		name: "parallel, sequential stages and conditions",
+		json: `{"pipeline":{"stages":[
+{"name":"checkout","branches":[{"name":"default","steps":[{"name":"git"},{"name":"sh"}]}]},
+{"name":"test","parallel":[
+  {"name":"unit","branches":[{"name":"default","steps":[{"name":"sh"}]}]},
+  {"name":"e2e","stages":[
+    {"name":"setup","branches":[{"name":"default","steps":[{"name":"sh"}]}]},
+    {"name":"run","branches":[{"name":"default","steps":[{"name":"sh"}]}]}]}]},
+{"name":"deploy","when":{"conditions":[
+  {"name":"branch","arguments":{"isLiteral":true,"value":"main"}},
+  {"name":"not","children":[{"name":"environment","arguments":[
+    {"key":"value","value":{"isLiteral":true,"value":"true"}},
+    {"key":"name","value":{"isLiteral":true,"value":"SKIP"}}]}]}]},
+ "branches":[{"name":"default","steps":[{"name":"sh"}]}]}]}}`,
+		expectGraph: &Graph{
+			Nodes: []GraphNode{
+				{ID: "0", Name: "checkout", Type: GraphNodeStage, Steps: 2},
+				{ID: "1", Name: "test", Type: GraphNodeParallel},
+				{ID: "1.0", Name: "unit", Type: GraphNodeStage, Parent: "1", Steps: 1},
+				{ID: "1.1", Name: "e2e", Type: GraphNodeSequential, Parent: "1"},
+				{ID: "1.1.0", Name: "setup", Type: GraphNodeStage, Parent: "1.1", Steps: 1},
+				{ID: "1.1.1", Name: "run", Type: GraphNodeStage, Parent: "1.1", Steps: 1},
+				{ID: "2", Name: "deploy", Type: GraphNodeStage, Steps: 1, Conditions: []string{
+					`branch("main")`, `not(environment(name: "SKIP", value: "true"))`}},
+			},
+			Edges: []GraphEdge{
+				{From: "0", To: "1"},
+				{From: "1", To: "1.0"},
+				{From: "1", To: "1.1"},
+				{From: "1.1", To: "1.1.0"},
+				{From: "1.1.0", To: "1.1.1"},
+				{From: "1.0", To: "2"},
+				{From: "1.1.1", To: "2"},
+			},
+			Parallelism: 2,
+		},
+	}, {
+		name: "legacy parallel branches",
+		json: `{"pipeline":{"stages":[{"name":"build","branches":[
+{"name":"amd64","steps":[{"name":"sh"}]},{"name":"arm64","steps":[{"name":"sh"}]},{"name":"s390x","steps":[]}]}]}}`,
+		expectGraph: &Graph{
+			Nodes: []GraphNode{
+				{ID: "0", Name: "build", Type: GraphNodeParallel},
+				{ID: "0.0", Name: "amd64", Type: GraphNodeStage, Parent: "0", Steps: 1},
+				{ID: "0.1", Name: "arm64", Type: GraphNodeStage, Parent: "0", Steps: 1},
+				{ID: "0.2", Name: "s390x", Type: GraphNodeStage, Parent: "0"},
+			},
+			Edges: []GraphEdge{
+				{From: "0", To: "0.0"},
+				{From: "0", To: "0.1"},
+				{From: "0", To: "0.2"},
+			},
+			Parallelism: 3,
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph, err := ParseGraph(tt.json)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectGraph, graph)
+		})
+	}
+}
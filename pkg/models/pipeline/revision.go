@@ -0,0 +1,84 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+// ListRevisions returns the PipelineRevisions of a Pipeline, the latest one comes first
+func ListRevisions(ctx context.Context, c client.Reader, namespace, pipeline string) (
+	revisions []v1alpha3.PipelineRevision, err error) {
+	list := &v1alpha3.PipelineRevisionList{}
+	if err = c.List(ctx, list, client.InNamespace(namespace),
+		client.MatchingLabels{v1alpha3.PipelineNameLabelKey: pipeline}); err != nil {
+		return
+	}
+	revisions = list.Items
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Spec.Revision > revisions[j].Spec.Revision
+	})
+	return
+}
+
+// GetRevision returns a PipelineRevision of a Pipeline by the revision number
+func GetRevision(ctx context.Context, c client.Reader, namespace, pipeline string, revision int64) (
+	result *v1alpha3.PipelineRevision, err error) {
+	result = &v1alpha3.PipelineRevision{}
+	err = c.Get(ctx, client.ObjectKey{
+		Namespace: namespace,
+		Name:      v1alpha3.GetPipelineRevisionName(pipeline, revision),
+	}, result)
+	return
+}
+
+// DiffSpecs returns the unified diff between the YAML of two Pipeline specs, it's empty if they're the same.
+// The from spec could be nil, which means all the lines are added.
+func DiffSpecs(from, to *v1alpha3.PipelineSpec, fromName, toName string) (diff string, err error) {
+	var fromData, toData []byte
+	if from != nil {
+		if fromData, err = yaml.Marshal(from); err != nil {
+			return
+		}
+	}
+	if toData, err = yaml.Marshal(to); err != nil {
+		return
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(fromData)),
+		B:        difflib.SplitLines(string(toData)),
+		FromFile: fromName,
+		ToFile:   toName,
+		Context:  3,
+	})
+}
+
+// GetRevisionDisplayName returns the name of a revision in the diff, such as r3, or /dev/null if there's no revision
+func GetRevisionDisplayName(revision int64) string {
+	if revision <= 0 {
+		return "/dev/null"
+	}
+	return fmt.Sprintf("r%d", revision)
+}
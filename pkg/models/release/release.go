@@ -0,0 +1,133 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package release collects the content of the Releases, which are the images built by the PipelineRuns,
+// the changelog generated from the commits, and the release notes rendered from both of them.
+package release
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/scm"
+)
+
+// pullRequestPatterns match the number of the pull request from the first line of the commit message,
+// such as "Merge pull request #12 from org/branch" or "Fix the typo (#12)"
+var pullRequestPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^Merge pull request #(\d+)`),
+	regexp.MustCompile(`\(#(\d+)\)$`),
+}
+
+// CollectImages returns the images built by the PipelineRuns according to their results,
+// the images without digests are skipped
+func CollectImages(pipelineRuns []v1alpha3.PipelineRun) (images []v1alpha3.ReleaseImage) {
+	for i := range pipelineRuns {
+		pipelineRun := &pipelineRuns[i]
+		for _, image := range pipelineRun.Status.GetImageResults() {
+			if image.Digest == "" {
+				continue
+			}
+			images = append(images, v1alpha3.ReleaseImage{
+				Name:        trimImageReference(image.URL),
+				Digest:      image.Digest,
+				PipelineRun: pipelineRun.Name,
+			})
+		}
+	}
+	return
+}
+
+// trimImageReference removes the tag and the digest of the image, it keeps the port of the registry
+func trimImageReference(image string) string {
+	if index := strings.Index(image, "@"); index >= 0 {
+		image = image[:index]
+	}
+	if index := strings.LastIndex(image, ":"); index > strings.LastIndex(image, "/") {
+		image = image[:index]
+	}
+	return image
+}
+
+// GenerateChangelog returns the changes of the ref after the since ref. The titles of the pull requests
+// are taken if the commits were merged from them, otherwise the first lines of the commit messages are taken.
+func GenerateChangelog(ctx context.Context, provider scm.Provider, repo, ref, since string) (changelog []v1alpha3.ChangelogEntry, err error) {
+	commits, err := provider.ListCommits(ctx, repo, ref, since)
+	if err != nil {
+		return
+	}
+	for _, commit := range commits {
+		entry := v1alpha3.ChangelogEntry{
+			Sha:    commit.Sha,
+			Title:  strings.TrimSpace(strings.SplitN(commit.Message, "\n", 2)[0]),
+			Author: commit.Author.Login,
+		}
+		if entry.Author == "" {
+			entry.Author = commit.Author.Name
+		}
+		if number := getPullRequestNumber(entry.Title); number > 0 {
+			entry.PullRequest = number
+			// the title of the commit is good enough if the pull request could not be found
+			if pullRequest, findErr := provider.FindPullRequest(ctx, repo, number); findErr == nil && pullRequest.Title != "" {
+				entry.Title = pullRequest.Title
+			}
+		}
+		changelog = append(changelog, entry)
+	}
+	return
+}
+
+func getPullRequestNumber(title string) int {
+	for _, pattern := range pullRequestPatterns {
+		if matches := pattern.FindStringSubmatch(title); len(matches) == 2 {
+			number, _ := strconv.Atoi(matches[1])
+			return number
+		}
+	}
+	return 0
+}
+
+// RenderNotes renders the release notes in Markdown
+func RenderNotes(release *v1alpha3.Release) string {
+	notes := &strings.Builder{}
+	_, _ = fmt.Fprintf(notes, "## %s\n", release.Spec.Tag)
+
+	if len(release.Status.Changelog) > 0 {
+		notes.WriteString("\n### Changes\n\n")
+		for _, entry := range release.Status.Changelog {
+			notes.WriteString("- " + entry.Title)
+			if entry.PullRequest > 0 && !strings.Contains(entry.Title, fmt.Sprintf("#%d", entry.PullRequest)) {
+				_, _ = fmt.Fprintf(notes, " (#%d)", entry.PullRequest)
+			}
+			if entry.Author != "" {
+				notes.WriteString(" by " + entry.Author)
+			}
+			notes.WriteString("\n")
+		}
+	}
+
+	if len(release.Status.Images) > 0 {
+		notes.WriteString("\n### Images\n\n")
+		for _, image := range release.Status.Images {
+			_, _ = fmt.Fprintf(notes, "- `%s@%s`\n", image.Name, image.Digest)
+		}
+	}
+	return notes.String()
+}
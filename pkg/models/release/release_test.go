@@ -0,0 +1,101 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package release
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	goscm "github.com/jenkins-x/go-scm/scm"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/scm"
+)
+
+type fakeProvider struct {
+	scm.Provider
+	commits      []*goscm.Commit
+	pullRequests map[int]string
+}
+
+func (p *fakeProvider) ListCommits(_ context.Context, _, _, _ string) ([]*goscm.Commit, error) {
+	return p.commits, nil
+}
+
+func (p *fakeProvider) FindPullRequest(_ context.Context, _ string, number int) (*goscm.PullRequest, error) {
+	if title, ok := p.pullRequests[number]; ok {
+		return &goscm.PullRequest{Number: number, Title: title}, nil
+	}
+	return nil, fmt.Errorf("pull request %d not found", number)
+}
+
+func TestCollectImages(t *testing.T) {
+	pipelineRuns := []v1alpha3.PipelineRun{{
+		ObjectMeta: metav1.ObjectMeta{Name: "build-1"},
+		Status: v1alpha3.PipelineRunStatus{Results: []v1alpha3.RunResult{
+			{Name: "IMAGE_URL", Value: "registry:5000/org/app:v1.0.0"},
+			{Name: "IMAGE_DIGEST", Value: "sha256:app"},
+			{Name: "WEB_IMAGE_URL", Value: "docker.io/org/web@sha256:web"},
+			{Name: "WEB_IMAGE_DIGEST", Value: "sha256:web"},
+			{Name: "API_IMAGE_DIGEST", Value: "sha256:api"},
+		}},
+	}, {
+		ObjectMeta: metav1.ObjectMeta{Name: "test-1"},
+	}}
+	assert.Equal(t, []v1alpha3.ReleaseImage{
+		{Name: "registry:5000/org/app", Digest: "sha256:app", PipelineRun: "build-1"},
+		{Name: "docker.io/org/web", Digest: "sha256:web", PipelineRun: "build-1"},
+	}, CollectImages(pipelineRuns))
+}
+
+func TestGenerateChangelog(t *testing.T) {
+	provider := &fakeProvider{
+		commits: []*goscm.Commit{
+			{Sha: "sha-3", Message: "Merge pull request #3 from org/feature\n\nAdd the feature", Author: goscm.Signature{Login: "alice"}},
+			{Sha: "sha-2", Message: "Fix the typo (#2)", Author: goscm.Signature{Name: "Bob"}},
+			{Sha: "sha-1", Message: "Update the docs\n\nmore details"},
+		},
+		pullRequests: map[int]string{3: "Add the feature"},
+	}
+	changelog, err := GenerateChangelog(context.TODO(), provider, "org/repo", "v1.1.0", "v1.0.0")
+	assert.Nil(t, err)
+	assert.Equal(t, []v1alpha3.ChangelogEntry{
+		{Sha: "sha-3", Title: "Add the feature", Author: "alice", PullRequest: 3},
+		{Sha: "sha-2", Title: "Fix the typo (#2)", Author: "Bob", PullRequest: 2},
+		{Sha: "sha-1", Title: "Update the docs"},
+	}, changelog)
+}
+
+func TestRenderNotes(t *testing.T) {
+	release := &v1alpha3.Release{
+		Spec: v1alpha3.ReleaseSpec{Tag: "v1.1.0"},
+		Status: v1alpha3.ReleaseStatus{
+			Changelog: []v1alpha3.ChangelogEntry{
+				{Sha: "sha-3", Title: "Add the feature", Author: "alice", PullRequest: 3},
+				{Sha: "sha-2", Title: "Fix the typo (#2)", PullRequest: 2},
+			},
+			Images: []v1alpha3.ReleaseImage{{Name: "docker.io/org/app", Digest: "sha256:app"}},
+		},
+	}
+	assert.Equal(t, "## v1.1.0\n\n### Changes\n\n- Add the feature (#3) by alice\n- Fix the typo (#2)\n\n"+
+		"### Images\n\n- `docker.io/org/app@sha256:app`\n", RenderNotes(release))
+
+	assert.Equal(t, "## v1.1.0\n", RenderNotes(&v1alpha3.Release{Spec: v1alpha3.ReleaseSpec{Tag: "v1.1.0"}}))
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testreport
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+// TrendPoint is the test result of a PipelineRun
+type TrendPoint struct {
+	PipelineRun         string            `json:"pipelineRun"`
+	Phase               v1alpha3.RunPhase `json:"phase,omitempty"`
+	StartTime           *metav1.Time      `json:"startTime,omitempty"`
+	v1alpha3.TestCounts `json:",inline"`
+	// LineRate is the percentage of the covered lines
+	LineRate float64 `json:"lineRate,omitempty"`
+	// BranchRate is the percentage of the covered branches
+	BranchRate float64 `json:"branchRate,omitempty"`
+}
+
+// CaseRecord is the result of a test case in a PipelineRun
+type CaseRecord struct {
+	PipelineRun             string       `json:"pipelineRun"`
+	StartTime               *metav1.Time `json:"startTime,omitempty"`
+	v1alpha3.TestCaseResult `json:",inline"`
+}
+
+// ReportReader reads the raw report by its object key
+type ReportReader func(key string) ([]byte, error)
+
+// GetTrend returns the test results of the PipelineRuns in the same order, the PipelineRuns without
+// test reports are skipped
+func GetTrend(pipelineRuns []v1alpha3.PipelineRun) (points []TrendPoint) {
+	points = []TrendPoint{}
+	for i := range pipelineRuns {
+		pipelineRun := &pipelineRuns[i]
+		tests := pipelineRun.Status.Tests
+		if tests == nil {
+			continue
+		}
+		points = append(points, TrendPoint{
+			PipelineRun: pipelineRun.Name,
+			Phase:       pipelineRun.Status.Phase,
+			StartTime:   pipelineRun.Status.StartTime,
+			TestCounts:  tests.TestCounts,
+			LineRate:    tests.Coverage.GetLineRate(),
+			BranchRate:  tests.Coverage.GetBranchRate(),
+		})
+	}
+	return
+}
+
+// GetCaseHistory returns the results of the test case in the PipelineRuns in the same order. The test case is
+// identified by TestCaseResult.GetID, it's looked up from the raw JUnit reports.
+func GetCaseHistory(pipelineRuns []v1alpha3.PipelineRun, id string, read ReportReader) (records []CaseRecord, err error) {
+	records = []CaseRecord{}
+	for i := range pipelineRuns {
+		pipelineRun := &pipelineRuns[i]
+		if pipelineRun.Status.Tests == nil {
+			continue
+		}
+		for _, report := range pipelineRun.Status.Tests.Reports {
+			if report.Format != v1alpha3.TestReportFormatJUnit || report.Key == "" {
+				continue
+			}
+
+			var data []byte
+			if data, err = read(report.Key); err != nil {
+				return
+			}
+			var cases []v1alpha3.TestCaseResult
+			if cases, err = ParseJUnit(data); err != nil {
+				return
+			}
+			for j := range cases {
+				if cases[j].GetID() == id {
+					records = append(records, CaseRecord{
+						PipelineRun:    pipelineRun.Name,
+						StartTime:      pipelineRun.Status.StartTime,
+						TestCaseResult: cases[j],
+					})
+				}
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package testreport parses the JUnit and coverage reports published by the PipelineRuns,
+// and queries the test results across the PipelineRuns of a Pipeline.
+package testreport
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+// IsSupportedFormat checks if the format of the report could be parsed
+func IsSupportedFormat(format string) bool {
+	switch format {
+	case v1alpha3.TestReportFormatJUnit, v1alpha3.TestReportFormatCobertura, v1alpha3.TestReportFormatJaCoCo:
+		return true
+	}
+	return false
+}
+
+// Parse parses the raw report into a TestReport without the name and the key
+func Parse(format string, data []byte) (report *v1alpha3.TestReport, err error) {
+	report = &v1alpha3.TestReport{Format: format}
+	switch format {
+	case v1alpha3.TestReportFormatJUnit:
+		var cases []v1alpha3.TestCaseResult
+		if cases, err = ParseJUnit(data); err != nil {
+			return
+		}
+		report.Tests = &v1alpha3.TestCounts{}
+		for i := range cases {
+			testCase := cases[i]
+			report.Tests.Total++
+			report.Duration.Duration += testCase.Duration.Duration
+			switch testCase.Status {
+			case v1alpha3.TestCasePassed:
+				report.Tests.Passed++
+			case v1alpha3.TestCaseSkipped:
+				report.Tests.Skipped++
+			default:
+				report.Tests.Failed++
+				if len(report.FailedCases) < v1alpha3.MaxFailedTestCases {
+					report.FailedCases = append(report.FailedCases, testCase)
+				}
+			}
+		}
+	case v1alpha3.TestReportFormatCobertura:
+		report.Coverage, err = ParseCobertura(data)
+	case v1alpha3.TestReportFormatJaCoCo:
+		report.Coverage, err = ParseJaCoCo(data)
+	default:
+		err = fmt.Errorf("unsupported test report format: %s", format)
+	}
+	return
+}
+
+type junitSuites struct {
+	Suites []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name   string          `xml:"name,attr"`
+	Suites []junitSuite    `xml:"testsuite"`
+	Cases  []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure"`
+	Error     *junitFailure `xml:"error"`
+	Skipped   *junitFailure `xml:"skipped"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Content string `xml:",chardata"`
+}
+
+func (f *junitFailure) getMessage() string {
+	if f.Message != "" {
+		return f.Message
+	}
+	// take the first line of the stack trace
+	return strings.TrimSpace(strings.SplitN(strings.TrimSpace(f.Content), "\n", 2)[0])
+}
+
+// ParseJUnit returns all the test cases of a JUnit report, the root element could be testsuites or testsuite
+func ParseJUnit(data []byte) (cases []v1alpha3.TestCaseResult, err error) {
+	var root struct {
+		XMLName xml.Name
+	}
+	if err = xml.Unmarshal(data, &root); err != nil {
+		err = fmt.Errorf("invalid JUnit report: %v", err)
+		return
+	}
+
+	var suites []junitSuite
+	switch root.XMLName.Local {
+	case "testsuites":
+		all := junitSuites{}
+		err = xml.Unmarshal(data, &all)
+		suites = all.Suites
+	case "testsuite":
+		suite := junitSuite{}
+		err = xml.Unmarshal(data, &suite)
+		suites = []junitSuite{suite}
+	default:
+		err = fmt.Errorf("invalid JUnit report: unexpected root element %s", root.XMLName.Local)
+	}
+	if err != nil {
+		return
+	}
+	for i := range suites {
+		cases = appendCases(cases, &suites[i])
+	}
+	return
+}
+
+func appendCases(cases []v1alpha3.TestCaseResult, suite *junitSuite) []v1alpha3.TestCaseResult {
+	for _, item := range suite.Cases {
+		testCase := v1alpha3.TestCaseResult{
+			Suite:     suite.Name,
+			ClassName: item.ClassName,
+			Name:      item.Name,
+			Status:    v1alpha3.TestCasePassed,
+			Duration:  metav1.Duration{Duration: parseSeconds(item.Time)},
+		}
+		switch {
+		case item.Failure != nil:
+			testCase.Status = v1alpha3.TestCaseFailed
+			testCase.Message = item.Failure.getMessage()
+		case item.Error != nil:
+			testCase.Status = v1alpha3.TestCaseFailed
+			testCase.Message = item.Error.getMessage()
+		case item.Skipped != nil:
+			testCase.Status = v1alpha3.TestCaseSkipped
+			testCase.Message = item.Skipped.getMessage()
+		}
+		cases = append(cases, testCase)
+	}
+	for i := range suite.Suites {
+		cases = appendCases(cases, &suite.Suites[i])
+	}
+	return cases
+}
+
+// parseSeconds parses the time attribute, some frameworks put commas as thousands separators
+func parseSeconds(value string) time.Duration {
+	seconds, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", ""), 64)
+	if err != nil || seconds < 0 || math.IsInf(seconds, 0) {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// ParseCobertura returns the coverage of a Cobertura report
+func ParseCobertura(data []byte) (coverage *v1alpha3.CoverageSummary, err error) {
+	report := struct {
+		XMLName         xml.Name `xml:"coverage"`
+		LinesCovered    int64    `xml:"lines-covered,attr"`
+		LinesValid      int64    `xml:"lines-valid,attr"`
+		BranchesCovered int64    `xml:"branches-covered,attr"`
+		BranchesValid   int64    `xml:"branches-valid,attr"`
+	}{}
+	if err = xml.Unmarshal(data, &report); err != nil {
+		err = fmt.Errorf("invalid Cobertura report: %v", err)
+		return
+	}
+	coverage = &v1alpha3.CoverageSummary{
+		LinesCovered:    report.LinesCovered,
+		LinesValid:      report.LinesValid,
+		BranchesCovered: report.BranchesCovered,
+		BranchesValid:   report.BranchesValid,
+	}
+	return
+}
+
+// ParseJaCoCo returns the coverage of a JaCoCo report according to the counters of the whole report
+func ParseJaCoCo(data []byte) (coverage *v1alpha3.CoverageSummary, err error) {
+	report := struct {
+		XMLName  xml.Name `xml:"report"`
+		Counters []struct {
+			Type    string `xml:"type,attr"`
+			Missed  int64  `xml:"missed,attr"`
+			Covered int64  `xml:"covered,attr"`
+		} `xml:"counter"`
+	}{}
+	if err = xml.Unmarshal(data, &report); err != nil {
+		err = fmt.Errorf("invalid JaCoCo report: %v", err)
+		return
+	}
+	coverage = &v1alpha3.CoverageSummary{}
+	for _, counter := range report.Counters {
+		switch counter.Type {
+		case "LINE":
+			coverage.LinesCovered = counter.Covered
+			coverage.LinesValid = counter.Covered + counter.Missed
+		case "BRANCH":
+			coverage.BranchesCovered = counter.Covered
+			coverage.BranchesValid = counter.Covered + counter.Missed
+		}
+	}
+	return
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testreport
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+const junitReport = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="app" tests="4">
+    <testcase classname="com.example.AppTest" name="testAdd" time="0.5"/>
+    <testcase classname="com.example.AppTest" name="testSub" time="1,000.25">
+      <failure message="expected 1 but was 2">stack trace</failure>
+    </testcase>
+    <testcase classname="com.example.AppTest" name="testDiv" time="0.1">
+      <error>java.lang.ArithmeticException: / by zero
+	at com.example.App.div(App.java:12)</error>
+    </testcase>
+    <testsuite name="nested">
+      <testcase name="testSkip"><skipped/></testcase>
+    </testsuite>
+  </testsuite>
+</testsuites>`
+
+func TestParseJUnit(t *testing.T) {
+	cases, err := ParseJUnit([]byte(junitReport))
+	assert.Nil(t, err)
+	assert.Equal(t, []v1alpha3.TestCaseResult{{
+		Suite: "app", ClassName: "com.example.AppTest", Name: "testAdd", Status: v1alpha3.TestCasePassed,
+		Duration: metav1.Duration{Duration: 500 * time.Millisecond},
+	}, {
+		Suite: "app", ClassName: "com.example.AppTest", Name: "testSub", Status: v1alpha3.TestCaseFailed,
+		Duration: metav1.Duration{Duration: 1000250 * time.Millisecond}, Message: "expected 1 but was 2",
+	}, {
+		Suite: "app", ClassName: "com.example.AppTest", Name: "testDiv", Status: v1alpha3.TestCaseFailed,
+		Duration: metav1.Duration{Duration: 100 * time.Millisecond}, Message: "java.lang.ArithmeticException: / by zero",
+	}, {
+		Suite: "nested", Name: "testSkip", Status: v1alpha3.TestCaseSkipped,
+	}}, cases)
+	assert.Equal(t, "com.example.AppTest.testAdd", cases[0].GetID())
+	assert.Equal(t, "nested.testSkip", cases[3].GetID())
+
+	// a single suite
+	cases, err = ParseJUnit([]byte(`<testsuite name="go"><testcase name="TestA" time="x"/></testsuite>`))
+	assert.Nil(t, err)
+	assert.Equal(t, []v1alpha3.TestCaseResult{{Suite: "go", Name: "TestA", Status: v1alpha3.TestCasePassed}}, cases)
+
+	_, err = ParseJUnit([]byte(`<coverage/>`))
+	assert.NotNil(t, err)
+	_, err = ParseJUnit([]byte(`not xml`))
+	assert.NotNil(t, err)
+}
+
+func TestParse(t *testing.T) {
+	report, err := Parse(v1alpha3.TestReportFormatJUnit, []byte(junitReport))
+	assert.Nil(t, err)
+	assert.Equal(t, &v1alpha3.TestCounts{Total: 4, Passed: 1, Failed: 2, Skipped: 1}, report.Tests)
+	assert.Len(t, report.FailedCases, 2)
+	assert.Equal(t, 1000850*time.Millisecond, report.Duration.Duration)
+
+	report, err = Parse(v1alpha3.TestReportFormatCobertura, []byte(`<?xml version="1.0" ?>
+<coverage line-rate="0.8" branch-rate="0.5" lines-covered="80" lines-valid="100" branches-covered="5" branches-valid="10"/>`))
+	assert.Nil(t, err)
+	assert.Equal(t, &v1alpha3.CoverageSummary{LinesCovered: 80, LinesValid: 100, BranchesCovered: 5, BranchesValid: 10}, report.Coverage)
+	assert.Equal(t, float64(80), report.Coverage.GetLineRate())
+	assert.Equal(t, float64(50), report.Coverage.GetBranchRate())
+
+	report, err = Parse(v1alpha3.TestReportFormatJaCoCo, []byte(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<!DOCTYPE report PUBLIC "-//JACOCO//DTD Report 1.1//EN" "report.dtd">
+<report name="app">
+  <package name="com/example"><counter type="LINE" missed="100" covered="100"/></package>
+  <counter type="INSTRUCTION" missed="10" covered="90"/>
+  <counter type="BRANCH" missed="3" covered="1"/>
+  <counter type="LINE" missed="30" covered="70"/>
+</report>`))
+	assert.Nil(t, err)
+	assert.Equal(t, &v1alpha3.CoverageSummary{LinesCovered: 70, LinesValid: 100, BranchesCovered: 1, BranchesValid: 4}, report.Coverage)
+
+	_, err = Parse("html", nil)
+	assert.NotNil(t, err)
+	assert.False(t, IsSupportedFormat("html"))
+	assert.True(t, IsSupportedFormat(v1alpha3.TestReportFormatJaCoCo))
+}
+
+func TestGetTrendAndCaseHistory(t *testing.T) {
+	newPipelineRun := func(name string, tests *v1alpha3.TestSummary) v1alpha3.PipelineRun {
+		return v1alpha3.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     v1alpha3.PipelineRunStatus{Phase: v1alpha3.Succeeded, Tests: tests},
+		}
+	}
+	summary := &v1alpha3.TestSummary{}
+	summary.SetReport(v1alpha3.TestReport{Name: "unit", Format: v1alpha3.TestReportFormatJUnit, Key: "unit.xml",
+		Tests: &v1alpha3.TestCounts{Total: 4, Passed: 1, Failed: 2, Skipped: 1}})
+	summary.SetReport(v1alpha3.TestReport{Name: "coverage", Format: v1alpha3.TestReportFormatCobertura,
+		Coverage: &v1alpha3.CoverageSummary{LinesCovered: 1, LinesValid: 4}})
+	pipelineRuns := []v1alpha3.PipelineRun{newPipelineRun("run-2", summary), newPipelineRun("run-1", nil)}
+
+	assert.Equal(t, []TrendPoint{{
+		PipelineRun: "run-2",
+		Phase:       v1alpha3.Succeeded,
+		TestCounts:  v1alpha3.TestCounts{Total: 4, Passed: 1, Failed: 2, Skipped: 1},
+		LineRate:    25,
+	}}, GetTrend(pipelineRuns))
+
+	records, err := GetCaseHistory(pipelineRuns, "com.example.AppTest.testSub", func(key string) ([]byte, error) {
+		assert.Equal(t, "unit.xml", key)
+		return []byte(junitReport), nil
+	})
+	assert.Nil(t, err)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, "run-2", records[0].PipelineRun)
+		assert.Equal(t, v1alpha3.TestCaseFailed, records[0].Status)
+	}
+
+	_, err = GetCaseHistory(pipelineRuns, "any", func(string) ([]byte, error) {
+		return nil, fmt.Errorf("not found")
+	})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,248 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package usage accounts the compute resources consumed by the PipelineRuns, and sums them up by the Pipelines
+// and the DevOps projects for the chargeback of the CI workloads.
+package usage
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultWindow is the default period of the usage report
+const DefaultWindow = 30 * 24 * time.Hour
+
+// RunURLAnnoKey is the annotation key of the Jenkins agent pods, which is the relative URL of the build, such as
+// job/project/job/pipeline/1/. It's set by the Kubernetes plugin of Jenkins.
+const RunURLAnnoKey = "runUrl"
+
+// PodFinalizerName is the finalizer of the pods which holds the deletion until their usage is accounted
+const PodFinalizerName = "usage.finalizers.kubesphere.io"
+
+const mebibyte = 1024 * 1024
+
+// Usage is the compute resources consumed in a period
+type Usage struct {
+	// CPUMilliCoreSeconds is the CPU requests in millicores multiplied by the lifetime of the pods in seconds
+	CPUMilliCoreSeconds int64 `json:"cpuMilliCoreSeconds"`
+	// MemoryMiBSeconds is the memory requests in MiB multiplied by the lifetime of the pods in seconds
+	MemoryMiBSeconds int64 `json:"memoryMiBSeconds"`
+}
+
+// PipelineUsage is the compute resources consumed by the PipelineRuns of a Pipeline
+type PipelineUsage struct {
+	Pipeline string `json:"pipeline"`
+	// Runs is the number of the PipelineRuns which have been accounted
+	Runs  int `json:"runs"`
+	Usage `json:",inline"`
+}
+
+// Report is the compute resources consumed by the PipelineRuns of a DevOps project in a period
+type Report struct {
+	Project string    `json:"project"`
+	Since   time.Time `json:"since"`
+	Until   time.Time `json:"until"`
+
+	// Total is the sum of all the Pipelines
+	Total Usage `json:"total"`
+	// Pipelines are sorted by the CPU usage in descending order
+	Pipelines []PipelineUsage `json:"pipelines"`
+}
+
+// Collect sums up the usage of the PipelineRuns which were created in the period by their Pipelines
+func Collect(ctx context.Context, c client.Reader, namespace string, since, until time.Time) (report *Report, err error) {
+	pipelineRunList := &v1alpha3.PipelineRunList{}
+	if err = c.List(ctx, pipelineRunList, client.InNamespace(namespace)); err != nil {
+		return
+	}
+
+	report = &Report{Project: namespace, Since: since, Until: until, Pipelines: []PipelineUsage{}}
+	pipelines := map[string]*PipelineUsage{}
+	for i := range pipelineRunList.Items {
+		pipelineRun := &pipelineRunList.Items[i]
+		created := pipelineRun.CreationTimestamp.Time
+		if pipelineRun.Status.Usage == nil || created.Before(since) || !created.Before(until) {
+			continue
+		}
+
+		name := pipelineRun.Labels[v1alpha3.PipelineNameLabelKey]
+		if name == "" && pipelineRun.Spec.PipelineRef != nil {
+			name = pipelineRun.Spec.PipelineRef.Name
+		}
+		pipelineUsage, ok := pipelines[name]
+		if !ok {
+			pipelineUsage = &PipelineUsage{Pipeline: name}
+			pipelines[name] = pipelineUsage
+		}
+		pipelineUsage.Runs++
+		pipelineUsage.add(pipelineRun.Status.Usage.CPUMilliCoreSeconds, pipelineRun.Status.Usage.MemoryMiBSeconds)
+		report.Total.add(pipelineRun.Status.Usage.CPUMilliCoreSeconds, pipelineRun.Status.Usage.MemoryMiBSeconds)
+	}
+
+	for _, pipelineUsage := range pipelines {
+		report.Pipelines = append(report.Pipelines, *pipelineUsage)
+	}
+	sort.Slice(report.Pipelines, func(i, j int) bool {
+		if report.Pipelines[i].CPUMilliCoreSeconds != report.Pipelines[j].CPUMilliCoreSeconds {
+			return report.Pipelines[i].CPUMilliCoreSeconds > report.Pipelines[j].CPUMilliCoreSeconds
+		}
+		return report.Pipelines[i].Pipeline < report.Pipelines[j].Pipeline
+	})
+	return
+}
+
+func (u *Usage) add(cpu, memory int64) {
+	u.CPUMilliCoreSeconds += cpu
+	u.MemoryMiBSeconds += memory
+}
+
+// PodUsage returns the resource requests of the pod multiplied by its lifetime. The lifetime ends when the last
+// container terminated, or when the pod was deleted, otherwise it's still running until now.
+func PodUsage(pod *v1.Pod, now time.Time) (usage Usage) {
+	start := pod.CreationTimestamp.Time
+	if pod.Status.StartTime != nil {
+		start = pod.Status.StartTime.Time
+	}
+	end := now
+	if finished, ok := getFinishedTime(pod); ok {
+		end = finished
+	} else if pod.DeletionTimestamp != nil {
+		end = pod.DeletionTimestamp.Time
+	}
+	seconds := int64(end.Sub(start) / time.Second)
+	if seconds <= 0 {
+		return
+	}
+
+	cpu, memory := getPodRequests(pod)
+	usage.CPUMilliCoreSeconds = cpu.MilliValue() * seconds
+	usage.MemoryMiBSeconds = memory.Value() / mebibyte * seconds
+	return
+}
+
+func getFinishedTime(pod *v1.Pod) (finished time.Time, ok bool) {
+	if pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed {
+		return
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Terminated != nil && status.State.Terminated.FinishedAt.After(finished) {
+			finished = status.State.Terminated.FinishedAt.Time
+			ok = true
+		}
+	}
+	return
+}
+
+// getPodRequests returns the effective requests of the pod, which is the larger one of the sum of the
+// containers and any of the init containers. The limits are taken if the requests are not set.
+func getPodRequests(pod *v1.Pod) (cpu, memory resource.Quantity) {
+	for _, container := range pod.Spec.Containers {
+		containerCPU, containerMemory := getContainerRequests(&container)
+		cpu.Add(containerCPU)
+		memory.Add(containerMemory)
+	}
+	for _, container := range pod.Spec.InitContainers {
+		containerCPU, containerMemory := getContainerRequests(&container)
+		if containerCPU.Cmp(cpu) > 0 {
+			cpu = containerCPU
+		}
+		if containerMemory.Cmp(memory) > 0 {
+			memory = containerMemory
+		}
+	}
+	return
+}
+
+func getContainerRequests(container *v1.Container) (cpu, memory resource.Quantity) {
+	get := func(name v1.ResourceName) resource.Quantity {
+		if quantity, ok := container.Resources.Requests[name]; ok {
+			return quantity
+		}
+		return container.Resources.Limits[name]
+	}
+	return get(v1.ResourceCPU), get(v1.ResourceMemory)
+}
+
+// RunRef is the Jenkins build which a pod belongs to
+type RunRef struct {
+	Project  string
+	Pipeline string
+	// Branch is empty unless it's a multi-branch Pipeline
+	Branch string
+	RunID  string
+}
+
+// ParseRunURL parses the relative URL of a Jenkins build, such as job/project/job/pipeline/job/main/1/
+func ParseRunURL(runURL string) (ref *RunRef, ok bool) {
+	segments := strings.Split(strings.Trim(runURL, "/"), "/")
+	var jobs []string
+	for i := 0; i+1 < len(segments); i += 2 {
+		if segments[i] != "job" {
+			return
+		}
+		jobs = append(jobs, unescape(segments[i+1]))
+	}
+	if len(segments)%2 == 0 || len(jobs) < 2 || len(jobs) > 3 {
+		return
+	}
+
+	ref = &RunRef{Project: jobs[0], Pipeline: jobs[1], RunID: segments[len(segments)-1]}
+	if len(jobs) == 3 {
+		ref.Branch = jobs[2]
+	}
+	ok = ref.RunID != ""
+	return
+}
+
+// unescape decodes the job name, the branch names are encoded by Jenkins then by the URL, such as feat%252Fa
+func unescape(name string) string {
+	for i := 0; i < 2 && strings.Contains(name, "%"); i++ {
+		if unescaped, err := url.PathUnescape(name); err == nil {
+			name = unescaped
+		}
+	}
+	return name
+}
+
+// FindPipelineRun returns the PipelineRun of the Jenkins build, it's nil if not found
+func FindPipelineRun(ctx context.Context, c client.Reader, ref *RunRef) (pipelineRun *v1alpha3.PipelineRun, err error) {
+	pipelineRunList := &v1alpha3.PipelineRunList{}
+	if err = c.List(ctx, pipelineRunList, client.InNamespace(ref.Project),
+		client.MatchingLabels{v1alpha3.PipelineNameLabelKey: ref.Pipeline}); err != nil {
+		return
+	}
+	for i := range pipelineRunList.Items {
+		item := &pipelineRunList.Items[i]
+		if item.Annotations[v1alpha3.JenkinsPipelineRunIDAnnoKey] != ref.RunID {
+			continue
+		}
+		if ref.Branch != "" && (item.Spec.SCM == nil || item.Spec.SCM.RefName != ref.Branch) {
+			continue
+		}
+		pipelineRun = item
+		return
+	}
+	return
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package usage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var baseTime = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func container(cpu, memory string, requests bool) v1.Container {
+	resources := v1.ResourceList{
+		v1.ResourceCPU:    resource.MustParse(cpu),
+		v1.ResourceMemory: resource.MustParse(memory),
+	}
+	if requests {
+		return v1.Container{Resources: v1.ResourceRequirements{Requests: resources}}
+	}
+	return v1.Container{Resources: v1.ResourceRequirements{Limits: resources}}
+}
+
+func TestPodUsage(t *testing.T) {
+	start := metav1.NewTime(baseTime)
+	deleted := metav1.NewTime(baseTime.Add(2 * time.Minute))
+	finished := metav1.NewTime(baseTime.Add(time.Minute))
+	now := baseTime.Add(10 * time.Minute)
+
+	tests := []struct {
+		name   string
+		pod    *v1.Pod
+		expect Usage
+	}{{
+		name: "running pod",
+		pod: &v1.Pod{
+			Spec:   v1.PodSpec{Containers: []v1.Container{container("500m", "512Mi", true), container("1", "1Gi", false)}},
+			Status: v1.PodStatus{StartTime: &start},
+		},
+		expect: Usage{CPUMilliCoreSeconds: 1500 * 600, MemoryMiBSeconds: 1536 * 600},
+	}, {
+		name: "terminated pod",
+		pod: &v1.Pod{
+			Spec: v1.PodSpec{Containers: []v1.Container{container("1", "1Gi", true)}},
+			Status: v1.PodStatus{StartTime: &start, Phase: v1.PodSucceeded, ContainerStatuses: []v1.ContainerStatus{{
+				State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{FinishedAt: finished}},
+			}}},
+		},
+		expect: Usage{CPUMilliCoreSeconds: 1000 * 60, MemoryMiBSeconds: 1024 * 60},
+	}, {
+		name: "deleted pod with a larger init container",
+		pod: &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{CreationTimestamp: start, DeletionTimestamp: &deleted},
+			Spec: v1.PodSpec{
+				InitContainers: []v1.Container{container("2", "256Mi", true)},
+				Containers:     []v1.Container{container("1", "1Gi", true)},
+			},
+		},
+		expect: Usage{CPUMilliCoreSeconds: 2000 * 120, MemoryMiBSeconds: 1024 * 120},
+	}, {
+		name:   "pod without requests",
+		pod:    &v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{{}}}, Status: v1.PodStatus{StartTime: &start}},
+		expect: Usage{},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expect, PodUsage(tt.pod, now))
+		})
+	}
+}
+
+func TestParseRunURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		runURL string
+		expect *RunRef
+	}{{
+		name:   "pipeline",
+		runURL: "job/project/job/pipeline/3/",
+		expect: &RunRef{Project: "project", Pipeline: "pipeline", RunID: "3"},
+	}, {
+		name:   "multi-branch pipeline",
+		runURL: "job/project/job/pipeline/job/feat%252Fa/12/",
+		expect: &RunRef{Project: "project", Pipeline: "pipeline", Branch: "feat/a", RunID: "12"},
+	}, {
+		name:   "without the run ID",
+		runURL: "job/project/job/pipeline/",
+	}, {
+		name:   "not a job",
+		runURL: "view/all/job/pipeline/1/",
+	}, {
+		name:   "too short",
+		runURL: "job/pipeline/1/",
+	}, {
+		name: "empty",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, ok := ParseRunURL(tt.runURL)
+			assert.Equal(t, tt.expect != nil, ok)
+			if ok {
+				assert.Equal(t, tt.expect, ref)
+			}
+		})
+	}
+}
+
+func newPipelineRun(name, pipeline, runID string, created time.Time, usage *v1alpha3.ResourceUsage) *v1alpha3.PipelineRun {
+	return &v1alpha3.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "project",
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(created),
+			Labels:            map[string]string{v1alpha3.PipelineNameLabelKey: pipeline},
+			Annotations:       map[string]string{v1alpha3.JenkinsPipelineRunIDAnnoKey: runID},
+		},
+		Status: v1alpha3.PipelineRunStatus{Usage: usage},
+	}
+}
+
+func TestCollect(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+	c := fake.NewClientBuilder().WithScheme(schema).WithObjects(
+		newPipelineRun("build-1", "build", "1", baseTime.Add(time.Hour),
+			&v1alpha3.ResourceUsage{CPUMilliCoreSeconds: 1000, MemoryMiBSeconds: 10}),
+		newPipelineRun("build-2", "build", "2", baseTime.Add(2*time.Hour),
+			&v1alpha3.ResourceUsage{CPUMilliCoreSeconds: 2000, MemoryMiBSeconds: 20}),
+		newPipelineRun("deploy-1", "deploy", "1", baseTime.Add(time.Hour),
+			&v1alpha3.ResourceUsage{CPUMilliCoreSeconds: 5000, MemoryMiBSeconds: 5}),
+		// not accounted yet
+		newPipelineRun("deploy-2", "deploy", "2", baseTime.Add(time.Hour), nil),
+		// out of the period
+		newPipelineRun("build-0", "build", "0", baseTime.Add(-time.Hour),
+			&v1alpha3.ResourceUsage{CPUMilliCoreSeconds: 1000, MemoryMiBSeconds: 10}),
+	).Build()
+
+	report, err := Collect(context.Background(), c, "project", baseTime, baseTime.Add(24*time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, &Report{
+		Project: "project",
+		Since:   baseTime,
+		Until:   baseTime.Add(24 * time.Hour),
+		Total:   Usage{CPUMilliCoreSeconds: 8000, MemoryMiBSeconds: 35},
+		Pipelines: []PipelineUsage{
+			{Pipeline: "deploy", Runs: 1, Usage: Usage{CPUMilliCoreSeconds: 5000, MemoryMiBSeconds: 5}},
+			{Pipeline: "build", Runs: 2, Usage: Usage{CPUMilliCoreSeconds: 3000, MemoryMiBSeconds: 30}},
+		},
+	}, report)
+
+	report, err = Collect(context.Background(), c, "empty", baseTime, baseTime.Add(24*time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, []PipelineUsage{}, report.Pipelines)
+}
+
+func TestFindPipelineRun(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+	branchRun := newPipelineRun("multi-1", "multi", "1", baseTime, nil)
+	branchRun.Spec.SCM = &v1alpha3.SCM{RefName: "main"}
+	c := fake.NewClientBuilder().WithScheme(schema).WithObjects(
+		newPipelineRun("build-1", "build", "1", baseTime, nil), branchRun).Build()
+
+	pipelineRun, err := FindPipelineRun(context.Background(), c, &RunRef{Project: "project", Pipeline: "build", RunID: "1"})
+	assert.Nil(t, err)
+	assert.Equal(t, "build-1", pipelineRun.Name)
+
+	pipelineRun, err = FindPipelineRun(context.Background(), c,
+		&RunRef{Project: "project", Pipeline: "multi", Branch: "main", RunID: "1"})
+	assert.Nil(t, err)
+	assert.Equal(t, "multi-1", pipelineRun.Name)
+
+	pipelineRun, err = FindPipelineRun(context.Background(), c,
+		&RunRef{Project: "project", Pipeline: "multi", Branch: "dev", RunID: "1"})
+	assert.Nil(t, err)
+	assert.Nil(t, pipelineRun)
+}
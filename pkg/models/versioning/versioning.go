@@ -0,0 +1,150 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package versioning computes the next semantic versions from the git history. The latest version tag is
+// the current version, and the conventional commits after it decide which part of the version is bumped.
+// See also https://www.conventionalcommits.org
+package versioning
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/blang/semver/v4"
+
+	"kubesphere.io/devops/pkg/client/scm"
+)
+
+// Bump is the part of a semantic version which needs to be increased
+type Bump int
+
+// The bumps are ordered, a bigger one takes precedence
+const (
+	BumpNone Bump = iota
+	BumpPatch
+	BumpMinor
+	BumpMajor
+)
+
+// InitialVersion is the version of the repositories which have no version tags
+const InitialVersion = "0.1.0"
+
+// conventionalHeaderPattern matches the header of a conventional commit, such as "feat(api)!: add the field"
+var conventionalHeaderPattern = regexp.MustCompile(`^(\w+)(\([^)]*\))?(!)?: `)
+
+// Version is the next version of a git ref
+type Version struct {
+	// Version is the semantic version without the tag prefix
+	Version string
+	// Tag is the version with the tag prefix
+	Tag string
+	// Sha is the latest commit of the ref, it's empty if the ref has no commits
+	Sha string
+	// Bumped indicates the version is a new one, otherwise it's the version of the latest tag
+	Bumped bool
+}
+
+// ParseBump returns the bump required by a commit message. The breaking changes bump the major version,
+// the features bump the minor version, and the fixes bump the patch version. Other commits, including the
+// ones which do not follow the convention, do not require a bump.
+func ParseBump(message string) Bump {
+	header := strings.SplitN(message, "\n", 2)[0]
+	matches := conventionalHeaderPattern.FindStringSubmatch(header)
+	if matches == nil {
+		return BumpNone
+	}
+	if matches[3] == "!" || strings.Contains(message, "\nBREAKING CHANGE: ") ||
+		strings.Contains(message, "\nBREAKING-CHANGE: ") {
+		return BumpMajor
+	}
+	switch strings.ToLower(matches[1]) {
+	case "feat":
+		return BumpMinor
+	case "fix", "perf":
+		return BumpPatch
+	default:
+		return BumpNone
+	}
+}
+
+// Apply returns the version bumped by the given part
+func (b Bump) Apply(version semver.Version) semver.Version {
+	switch b {
+	case BumpMajor:
+		_ = version.IncrementMajor()
+	case BumpMinor:
+		_ = version.IncrementMinor()
+	case BumpPatch:
+		_ = version.IncrementPatch()
+	}
+	return version
+}
+
+// LatestVersion returns the greatest released version and its tag from the tags, the tags without the
+// prefix and the pre-releases are ignored. The tag is empty if there are no version tags.
+func LatestVersion(tags []string, prefix string) (latest semver.Version, tag string) {
+	for _, name := range tags {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		version, err := semver.Parse(strings.TrimPrefix(name, prefix))
+		if err != nil || len(version.Pre) > 0 {
+			continue
+		}
+		if tag == "" || version.GT(latest) {
+			latest, tag = version, name
+		}
+	}
+	return
+}
+
+// NextVersion computes the next version of the ref. An empty ref means the default branch.
+func NextVersion(ctx context.Context, provider scm.Provider, repo, ref, prefix string) (next *Version, err error) {
+	references, err := provider.ListTags(ctx, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the tags of %s: %v", repo, err)
+	}
+	tags := make([]string, 0, len(references))
+	for _, reference := range references {
+		tags = append(tags, reference.Name)
+	}
+	latest, latestTag := LatestVersion(tags, prefix)
+
+	commits, err := provider.ListCommits(ctx, repo, ref, latestTag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the commits of %s: %v", repo, err)
+	}
+
+	next = &Version{}
+	if len(commits) > 0 {
+		next.Sha = commits[0].Sha
+	}
+	if latestTag == "" {
+		next.Version, next.Bumped = InitialVersion, true
+	} else {
+		bump := BumpNone
+		for _, commit := range commits {
+			if commitBump := ParseBump(commit.Message); commitBump > bump {
+				bump = commitBump
+			}
+		}
+		next.Version, next.Bumped = bump.Apply(latest).String(), bump != BumpNone
+	}
+	next.Tag = prefix + next.Version
+	return
+}
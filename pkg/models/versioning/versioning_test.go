@@ -0,0 +1,142 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package versioning
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blang/semver/v4"
+	goscm "github.com/jenkins-x/go-scm/scm"
+	"github.com/stretchr/testify/assert"
+
+	"kubesphere.io/devops/pkg/client/scm"
+)
+
+func TestParseBump(t *testing.T) {
+	tests := []struct {
+		message string
+		want    Bump
+	}{
+		{message: "feat: add the versioning", want: BumpMinor},
+		{message: "feat(api): add the versioning", want: BumpMinor},
+		{message: "Feat: add the versioning", want: BumpMinor},
+		{message: "fix: the typo", want: BumpPatch},
+		{message: "perf(controller): cache the Pipelines", want: BumpPatch},
+		{message: "feat!: remove the v1alpha2 API", want: BumpMajor},
+		{message: "refactor(api)!: rename the fields", want: BumpMajor},
+		{message: "fix: the parameters\n\nBREAKING CHANGE: the parameters are required", want: BumpMajor},
+		{message: "docs: update the README", want: BumpNone},
+		{message: "chore: release v1.0.0", want: BumpNone},
+		{message: "Merge pull request #12 from org/branch", want: BumpNone},
+		{message: "feat:missing the space", want: BumpNone},
+		{message: "", want: BumpNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseBump(tt.message))
+		})
+	}
+}
+
+func TestBump_Apply(t *testing.T) {
+	version := semver.MustParse("1.2.3")
+	assert.Equal(t, "1.2.3", BumpNone.Apply(version).String())
+	assert.Equal(t, "1.2.4", BumpPatch.Apply(version).String())
+	assert.Equal(t, "1.3.0", BumpMinor.Apply(version).String())
+	assert.Equal(t, "2.0.0", BumpMajor.Apply(version).String())
+	// the origin version is not changed
+	assert.Equal(t, "1.2.3", version.String())
+}
+
+func TestLatestVersion(t *testing.T) {
+	latest, tag := LatestVersion([]string{"v1.0.0", "v1.10.0", "v1.9.0", "v2.0.0-rc.1", "release-3", "1.11.0"}, "v")
+	assert.Equal(t, "v1.10.0", tag)
+	assert.Equal(t, "1.10.0", latest.String())
+
+	latest, tag = LatestVersion([]string{"v1.0.0", "1.11.0"}, "")
+	assert.Equal(t, "1.11.0", tag)
+	assert.Equal(t, "1.11.0", latest.String())
+
+	_, tag = LatestVersion([]string{"release-3"}, "v")
+	assert.Empty(t, tag)
+}
+
+type fakeProvider struct {
+	scm.Provider
+	tags    []string
+	commits []*goscm.Commit
+	since   string
+}
+
+func (p *fakeProvider) ListTags(context.Context, string) (tags []*goscm.Reference, err error) {
+	for _, tag := range p.tags {
+		tags = append(tags, &goscm.Reference{Name: tag})
+	}
+	return
+}
+
+func (p *fakeProvider) ListCommits(_ context.Context, _, _, since string) ([]*goscm.Commit, error) {
+	p.since = since
+	return p.commits, nil
+}
+
+func TestNextVersion(t *testing.T) {
+	tests := []struct {
+		name      string
+		tags      []string
+		commits   []*goscm.Commit
+		want      *Version
+		wantSince string
+	}{{
+		name:    "no version tags",
+		commits: []*goscm.Commit{{Sha: "sha-1", Message: "chore: init"}},
+		want:    &Version{Version: "0.1.0", Tag: "v0.1.0", Sha: "sha-1", Bumped: true},
+	}, {
+		name:      "features",
+		tags:      []string{"v1.0.0", "v1.1.0"},
+		commits:   []*goscm.Commit{{Sha: "sha-3", Message: "fix: the typo"}, {Sha: "sha-2", Message: "feat: add the field"}},
+		want:      &Version{Version: "1.2.0", Tag: "v1.2.0", Sha: "sha-3", Bumped: true},
+		wantSince: "v1.1.0",
+	}, {
+		name:      "breaking changes",
+		tags:      []string{"v1.1.0"},
+		commits:   []*goscm.Commit{{Sha: "sha-2", Message: "feat!: remove the field"}},
+		want:      &Version{Version: "2.0.0", Tag: "v2.0.0", Sha: "sha-2", Bumped: true},
+		wantSince: "v1.1.0",
+	}, {
+		name:      "no releasable commits",
+		tags:      []string{"v1.1.0"},
+		commits:   []*goscm.Commit{{Sha: "sha-2", Message: "docs: update the README"}},
+		want:      &Version{Version: "1.1.0", Tag: "v1.1.0", Sha: "sha-2"},
+		wantSince: "v1.1.0",
+	}, {
+		name:      "no commits after the latest tag",
+		tags:      []string{"v1.1.0"},
+		want:      &Version{Version: "1.1.0", Tag: "v1.1.0"},
+		wantSince: "v1.1.0",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &fakeProvider{tags: tt.tags, commits: tt.commits}
+			next, err := NextVersion(context.TODO(), provider, "org/app", "master", "v")
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, next)
+			assert.Equal(t, tt.wantSince, provider.since)
+		})
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/uuid"
+
+	"kubesphere.io/devops/pkg/client/cache"
+)
+
+// keyPrefix is the prefix of all the webhook deliveries in the cache
+const keyPrefix = "kubesphere:devops:webhook:delivery"
+
+// ErrDeliveryNotFound indicates the delivery does not exist, or it has expired
+var ErrDeliveryNotFound = errors.New("webhook delivery not found")
+
+// Delivery is a webhook request received from an SCM provider, and the outcome of handling it
+type Delivery struct {
+	ID   string    `json:"id"`
+	Time time.Time `json:"time"`
+	// Provider is the SCM provider which sent the webhook, such as github, gitlab or bitbucket
+	Provider   string `json:"provider,omitempty"`
+	Event      string `json:"event,omitempty"`
+	Repository string `json:"repository,omitempty"`
+	Ref        string `json:"ref,omitempty"`
+	// Headers are the HTTP headers of the request, the ones which carry credentials are dropped
+	Headers map[string]string `json:"headers,omitempty"`
+	Payload string            `json:"payload,omitempty"`
+
+	StatusCode int    `json:"statusCode"`
+	Message    string `json:"message,omitempty"`
+	// PipelineRuns are the PipelineRuns which were created by the delivery, in the form of namespace/name
+	PipelineRuns []string `json:"pipelineRuns,omitempty"`
+	// ReplayOf is the ID of the original delivery if this one is a replay
+	ReplayOf string `json:"replayOf,omitempty"`
+}
+
+// Filter is the condition to query the deliveries, the empty fields match everything
+type Filter struct {
+	Repository string
+	Event      string
+}
+
+// Interface is the store of the webhook deliveries
+type Interface interface {
+	// Record saves a delivery, the ID and time are set if they are empty
+	Record(delivery *Delivery) error
+	// List returns the deliveries which match the filter, the latest deliveries come first
+	List(filter Filter) ([]Delivery, error)
+	// Get returns a delivery by its ID, ErrDeliveryNotFound is returned if it does not exist
+	Get(id string) (*Delivery, error)
+}
+
+// NewCacheStore creates a delivery store on top of the cache, the deliveries expire after the retention
+func NewCacheStore(cacheClient cache.Interface, retention time.Duration) Interface {
+	return &cacheStore{cache: cacheClient, retention: retention}
+}
+
+type cacheStore struct {
+	cache     cache.Interface
+	retention time.Duration
+}
+
+func (s *cacheStore) Record(delivery *Delivery) (err error) {
+	if delivery.ID == "" {
+		delivery.ID = string(uuid.NewUUID())
+	}
+	if delivery.Time.IsZero() {
+		delivery.Time = time.Now()
+	}
+	var data []byte
+	if data, err = json.Marshal(delivery); err == nil {
+		err = s.cache.Set(deliveryKey(delivery), string(data), s.retention)
+	}
+	return
+}
+
+func (s *cacheStore) List(filter Filter) (deliveries []Delivery, err error) {
+	var keys []string
+	if keys, err = s.cache.Keys(keyPrefix + ":*"); err != nil {
+		return
+	}
+
+	deliveries = make([]Delivery, 0)
+	for _, key := range keys {
+		var delivery *Delivery
+		if delivery, err = s.load(key); err != nil {
+			return
+		} else if delivery == nil {
+			continue
+		}
+		if (filter.Repository != "" && filter.Repository != delivery.Repository) ||
+			(filter.Event != "" && filter.Event != delivery.Event) {
+			continue
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		return deliveries[i].Time.After(deliveries[j].Time)
+	})
+	return
+}
+
+func (s *cacheStore) Get(id string) (delivery *Delivery, err error) {
+	// the ID is a part of the key pattern, the wildcards must not match other deliveries
+	if id == "" || strings.ContainsAny(id, "*?[]\\") {
+		err = ErrDeliveryNotFound
+		return
+	}
+	var keys []string
+	if keys, err = s.cache.Keys(fmt.Sprintf("%s:*:%s", keyPrefix, id)); err != nil {
+		return
+	}
+	for _, key := range keys {
+		if delivery, err = s.load(key); err != nil || delivery != nil {
+			return
+		}
+	}
+	err = ErrDeliveryNotFound
+	return
+}
+
+// load returns nil if the delivery expired after listing the keys
+func (s *cacheStore) load(key string) (delivery *Delivery, err error) {
+	var data string
+	if data, err = s.cache.Get(key); err != nil {
+		err = nil
+		return
+	}
+	delivery = &Delivery{}
+	err = json.Unmarshal([]byte(data), delivery)
+	return
+}
+
+// deliveryKey returns the cache key of the delivery, it looks like kubesphere:devops:webhook:delivery:{unixNano}:{id}
+func deliveryKey(delivery *Delivery) string {
+	return fmt.Sprintf("%s:%d:%s", keyPrefix, delivery.Time.UnixNano(), delivery.ID)
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"kubesphere.io/devops/pkg/client/cache"
+)
+
+func TestCacheStore(t *testing.T) {
+	store := NewCacheStore(cache.NewSimpleCache(), time.Hour)
+	now := time.Now()
+	deliveries := []*Delivery{{
+		Time: now.Add(-2 * time.Hour), Provider: "github", Event: "push", Repository: "octocat/hello-world",
+	}, {
+		Time: now.Add(-time.Hour), Provider: "gitlab", Event: "Push Hook", Repository: "linuxsuren/test",
+	}, {
+		Provider: "github", Event: "pull_request", Repository: "octocat/hello-world",
+	}}
+	for _, delivery := range deliveries {
+		assert.Nil(t, store.Record(delivery))
+		assert.NotEmpty(t, delivery.ID)
+	}
+	assert.False(t, deliveries[2].Time.IsZero())
+
+	tests := []struct {
+		name   string
+		filter Filter
+		verify func(t *testing.T, deliveries []Delivery)
+	}{{
+		name: "all deliveries",
+		verify: func(t *testing.T, result []Delivery) {
+			if assert.Equal(t, 3, len(result)) {
+				assert.Equal(t, "pull_request", result[0].Event)
+				assert.Equal(t, "push", result[2].Event)
+			}
+		},
+	}, {
+		name:   "filter by repository",
+		filter: Filter{Repository: "octocat/hello-world"},
+		verify: func(t *testing.T, result []Delivery) {
+			assert.Equal(t, 2, len(result))
+		},
+	}, {
+		name:   "filter by event",
+		filter: Filter{Event: "Push Hook"},
+		verify: func(t *testing.T, result []Delivery) {
+			if assert.Equal(t, 1, len(result)) {
+				assert.Equal(t, deliveries[1].ID, result[0].ID)
+			}
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := store.List(tt.filter)
+			assert.Nil(t, err)
+			tt.verify(t, result)
+		})
+	}
+
+	delivery, err := store.Get(deliveries[1].ID)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "linuxsuren/test", delivery.Repository)
+	}
+	_, err = store.Get("fake")
+	assert.Equal(t, ErrDeliveryNotFound, err)
+	_, err = store.Get("*")
+	assert.Equal(t, ErrDeliveryNotFound, err)
+}
@@ -0,0 +1,43 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// Options is the options of the webhook delivery history
+type Options struct {
+	Enabled   bool          `json:"enabled" yaml:"enabled" mapstructure:"enabled"`
+	Retention time.Duration `json:"retention" yaml:"retention" mapstructure:"retention"`
+}
+
+// NewOptions returns the default options, the deliveries are kept for 3 days
+func NewOptions() *Options {
+	return &Options{
+		Enabled:   true,
+		Retention: 3 * 24 * time.Hour,
+	}
+}
+
+// AddFlags adds the flags of the webhook delivery history
+func (o *Options) AddFlags(fs *pflag.FlagSet, s *Options) {
+	fs.BoolVar(&o.Enabled, "webhook-delivery-enabled", s.Enabled, "Record the webhook deliveries from the SCM providers")
+	fs.DurationVar(&o.Retention, "webhook-delivery-retention", s.Retention, "How long the webhook deliveries are kept")
+}
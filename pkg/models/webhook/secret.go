@@ -0,0 +1,144 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// SecretName is the name of the webhook secret in the namespace of a DevOpsProject
+	SecretName = "devops-webhook-secret"
+	// SecretKey is the key of the token in the webhook secret
+	SecretKey = "secret"
+	// SecretLabelKey marks the webhook secrets, the provider webhooks are registered again once they are changed
+	SecretLabelKey = "devops.kubesphere.io/webhook-secret"
+	// SecretRotatedAnnoKey is the annotation key of the last time when the webhook secret was rotated
+	SecretRotatedAnnoKey = "devops.kubesphere.io/webhook-secret-rotated"
+)
+
+// secretLength is the number of random bytes of a webhook secret
+const secretLength = 32
+
+// SecretStatus describes the webhook secret of a DevOpsProject without the token
+type SecretStatus struct {
+	Namespace    string       `json:"namespace"`
+	CreationTime metav1.Time  `json:"creationTime"`
+	RotationTime *metav1.Time `json:"rotationTime,omitempty"`
+}
+
+// SecretToken is the webhook secret along with its token, the token is only returned once it's generated
+type SecretToken struct {
+	SecretStatus `json:",inline"`
+	Token        string `json:"token"`
+}
+
+// GetSecretStatus returns the status of the webhook secret of a DevOpsProject
+func GetSecretStatus(ctx context.Context, c client.Reader, namespace string) (status *SecretStatus, err error) {
+	secret := &v1.Secret{}
+	if err = c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: SecretName}, secret); err == nil {
+		status = getSecretStatus(secret)
+	}
+	return
+}
+
+// GetSecretToken returns the token of the webhook secret of a DevOpsProject, it's empty if there's no webhook secret
+func GetSecretToken(ctx context.Context, c client.Reader, namespace string) (token string, err error) {
+	secret := &v1.Secret{}
+	if err = c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: SecretName}, secret); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	token = string(secret.Data[SecretKey])
+	return
+}
+
+// GenerateSecret creates the webhook secret of a DevOpsProject, an AlreadyExists error is returned if it exists
+func GenerateSecret(ctx context.Context, c client.Client, namespace string) (*SecretToken, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      SecretName,
+			Labels:    map[string]string{SecretLabelKey: "true"},
+		},
+		Type: v1.SecretTypeOpaque,
+		Data: map[string][]byte{SecretKey: []byte(token)},
+	}
+	if err = c.Create(ctx, secret); err != nil {
+		return nil, err
+	}
+	return &SecretToken{SecretStatus: *getSecretStatus(secret), Token: token}, nil
+}
+
+// RotateSecret replaces the token of the webhook secret of a DevOpsProject, a NotFound error is returned if it
+// does not exist
+func RotateSecret(ctx context.Context, c client.Client, namespace string, now time.Time) (*SecretToken, error) {
+	secret := &v1.Secret{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: SecretName}, secret); err != nil {
+		return nil, err
+	}
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[SecretRotatedAnnoKey] = now.UTC().Format(time.RFC3339)
+	secret.Data = map[string][]byte{SecretKey: []byte(token)}
+	if err = c.Update(ctx, secret); err != nil {
+		return nil, err
+	}
+	return &SecretToken{SecretStatus: *getSecretStatus(secret), Token: token}, nil
+}
+
+// RevokeSecret deletes the webhook secret of a DevOpsProject, it's fine if it does not exist
+func RevokeSecret(ctx context.Context, c client.Client, namespace string) error {
+	err := c.Delete(ctx, &v1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: SecretName}})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func getSecretStatus(secret *v1.Secret) *SecretStatus {
+	status := &SecretStatus{Namespace: secret.Namespace, CreationTime: secret.CreationTimestamp}
+	if rotated, err := time.Parse(time.RFC3339, secret.Annotations[SecretRotatedAnnoKey]); err == nil {
+		status.RotationTime = &metav1.Time{Time: rotated}
+	}
+	return status
+}
+
+func newToken() (string, error) {
+	data := make([]byte, secretLength)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(data), nil
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker/decls"
+)
+
+// celEnv declares the variables of the CEL expressions. The attributes of the admission request are put into
+// the variable request instead of the top level, because namespace is a reserved word of CEL.
+var celEnv *cel.Env
+
+func init() {
+	var err error
+	if celEnv, err = cel.NewEnv(cel.Declarations(
+		decls.NewVar("object", decls.NewMapType(decls.String, decls.Dyn)),
+		decls.NewVar("request", decls.NewMapType(decls.String, decls.String)),
+	)); err != nil {
+		panic(err)
+	}
+}
+
+// evaluateCEL indicates if the input passes the expression, the compiled expressions are cached
+func (e *Evaluator) evaluateCEL(expression string, input *Input) (passed bool, err error) {
+	var program cel.Program
+	if cached, ok := e.programs.Load(expression); ok {
+		program = cached.(cel.Program)
+	} else if program, err = compileCEL(expression); err != nil {
+		return
+	} else {
+		e.programs.Store(expression, program)
+	}
+
+	out, _, err := program.Eval(map[string]interface{}{
+		"object": input.Object,
+		"request": map[string]string{
+			"kind":      input.Kind,
+			"namespace": input.Namespace,
+			"operation": input.Operation,
+			"username":  input.Username,
+		},
+	})
+	if err != nil {
+		return
+	}
+	if passed, ok := out.Value().(bool); ok {
+		return passed, nil
+	}
+	err = fmt.Errorf("the expression should be evaluated to a bool, but got %v", out.Type())
+	return
+}
+
+// compileCEL checks and compiles the expression
+func compileCEL(expression string) (cel.Program, error) {
+	ast, issues := celEnv.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	return celEnv.Program(ast)
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package policy evaluates the rules of PipelinePolicies on the admission of the Pipelines and PipelineRuns.
+// The rules are written in CEL which are evaluated in process, or in Rego which are evaluated by an OPA server.
+package policy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+// Input is the object and the attributes of an admission request, which is the input of the Rego rules
+type Input struct {
+	Object    map[string]interface{} `json:"object"`
+	Kind      string                 `json:"kind"`
+	Namespace string                 `json:"namespace"`
+	Operation string                 `json:"operation"`
+	Username  string                 `json:"username"`
+}
+
+// Result is the violations of the PipelinePolicies
+type Result struct {
+	// Denials are the violations of the policies whose action is Deny
+	Denials []string
+	// Warnings are the violations of the policies whose action is Warn
+	Warnings []string
+}
+
+// Evaluator evaluates the rules of all PipelinePolicies which apply to an object
+type Evaluator struct {
+	client.Reader
+	// HTTPClient talks with the OPA servers, http.DefaultClient is used if it's nil
+	HTTPClient *http.Client
+
+	// programs caches the compiled CEL expressions
+	programs sync.Map
+	// modules caches the Rego modules which have been uploaded, the key is the policy ID in the OPA server
+	modules sync.Map
+}
+
+// Evaluate returns the violations of the object in the input
+func (e *Evaluator) Evaluate(ctx context.Context, input *Input) (result *Result, err error) {
+	policies := &v1alpha3.PipelinePolicyList{}
+	if err = e.List(ctx, policies); err != nil {
+		return
+	}
+
+	result = &Result{}
+	var namespaceLabels labels.Set
+	for i := range policies.Items {
+		policy := &policies.Items[i]
+		if !policy.Spec.AppliesToKind(input.Kind) {
+			continue
+		}
+		if selector := policy.Spec.NamespaceSelector; selector != nil {
+			if namespaceLabels == nil {
+				if namespaceLabels, err = e.getNamespaceLabels(ctx, input.Namespace); err != nil {
+					return
+				}
+			}
+			var matched bool
+			if matched, err = matchNamespace(selector, namespaceLabels); err != nil {
+				err = fmt.Errorf("invalid namespace selector of PipelinePolicy %s: %v", policy.Name, err)
+				return
+			} else if !matched {
+				continue
+			}
+		}
+
+		violations := e.evaluatePolicy(ctx, policy, input)
+		if policy.Spec.GetAction() == v1alpha3.PolicyActionWarn {
+			result.Warnings = append(result.Warnings, violations...)
+		} else {
+			result.Denials = append(result.Denials, violations...)
+		}
+	}
+	return
+}
+
+// evaluatePolicy returns the violations of the rules, the messages have the names of the policy and the rule
+func (e *Evaluator) evaluatePolicy(ctx context.Context, policy *v1alpha3.PipelinePolicy, input *Input) (violations []string) {
+	for i := range policy.Spec.Rules {
+		rule := &policy.Spec.Rules[i]
+		messages, err := e.evaluateRule(ctx, policy, rule, input)
+		if err != nil {
+			if policy.Spec.GetFailurePolicy() == v1alpha3.PolicyFailurePolicyIgnore {
+				continue
+			}
+			messages = []string{fmt.Sprintf("failed to evaluate the rule: %v", err)}
+		}
+		for _, message := range messages {
+			violations = append(violations, fmt.Sprintf("[%s/%s] %s", policy.Name, rule.Name, message))
+		}
+	}
+	return
+}
+
+func (e *Evaluator) evaluateRule(ctx context.Context, policy *v1alpha3.PipelinePolicy, rule *v1alpha3.PolicyRule,
+	input *Input) (messages []string, err error) {
+	switch {
+	case rule.CEL != "" && rule.Rego != nil:
+		err = fmt.Errorf("only one of CEL and Rego should be specified")
+	case rule.CEL != "":
+		var passed bool
+		if passed, err = e.evaluateCEL(rule.CEL, input); err == nil && !passed {
+			message := rule.Message
+			if message == "" {
+				message = fmt.Sprintf("the expression %q is violated", rule.CEL)
+			}
+			messages = []string{message}
+		}
+	case rule.Rego != nil:
+		messages, err = e.evaluateRego(ctx, policy.Name+"/"+rule.Name, rule.Rego, input)
+	default:
+		err = fmt.Errorf("neither CEL nor Rego is specified")
+	}
+	return
+}
+
+func (e *Evaluator) getNamespaceLabels(ctx context.Context, namespace string) (labels.Set, error) {
+	ns := &v1.Namespace{}
+	if err := e.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		return nil, err
+	}
+	// not nil even if there're no labels, then the namespace is fetched only once
+	namespaceLabels := labels.Set{}
+	for key, value := range ns.Labels {
+		namespaceLabels[key] = value
+	}
+	return namespaceLabels, nil
+}
+
+func matchNamespace(selector *metav1.LabelSelector, namespaceLabels labels.Set) (bool, error) {
+	labelSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return false, err
+	}
+	return labelSelector.Matches(namespaceLabels), nil
+}
+
+func (e *Evaluator) getHTTPClient() *http.Client {
+	if e.HTTPClient != nil {
+		return e.HTTPClient
+	}
+	return http.DefaultClient
+}
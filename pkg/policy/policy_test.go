@@ -0,0 +1,192 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+func newPolicy(name string, spec v1alpha3.PipelinePolicySpec) *v1alpha3.PipelinePolicy {
+	return &v1alpha3.PipelinePolicy{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: spec}
+}
+
+func newEvaluator(t *testing.T, objects ...client.Object) *Evaluator {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1.AddToScheme(schema))
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+	return &Evaluator{Reader: fake.NewClientBuilder().WithScheme(schema).WithObjects(objects...).Build()}
+}
+
+func pipelineInput(namespace, jenkinsfile string) *Input {
+	return &Input{
+		Kind:      v1alpha3.ResourceKindPipeline,
+		Namespace: namespace,
+		Operation: "CREATE",
+		Username:  "alice",
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "build", "namespace": namespace},
+			"spec": map[string]interface{}{
+				"type":     "pipeline",
+				"pipeline": map[string]interface{}{"jenkinsfile": jenkinsfile},
+			},
+		},
+	}
+}
+
+func TestEvaluator_CEL(t *testing.T) {
+	evaluator := newEvaluator(t,
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "prod", Labels: map[string]string{"env": "prod"}}},
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev"}},
+		newPolicy("no-privileged", v1alpha3.PipelinePolicySpec{
+			Kinds: []string{v1alpha3.ResourceKindPipeline},
+			Rules: []v1alpha3.PolicyRule{{
+				Name:    "privileged",
+				CEL:     `!object.spec.pipeline.jenkinsfile.contains("privileged: true")`,
+				Message: "privileged pod templates are not allowed",
+			}},
+		}),
+		newPolicy("approved-images", v1alpha3.PipelinePolicySpec{
+			Action:            v1alpha3.PolicyActionWarn,
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}},
+			Rules: []v1alpha3.PolicyRule{{
+				Name: "registry",
+				CEL:  `!object.spec.pipeline.jenkinsfile.contains("image:") || object.spec.pipeline.jenkinsfile.contains("image: harbor.example.com/")`,
+			}},
+		}),
+		newPolicy("runs-only", v1alpha3.PipelinePolicySpec{
+			Kinds: []string{v1alpha3.ResourceKindPipelineRun},
+			Rules: []v1alpha3.PolicyRule{{Name: "never", CEL: "false"}},
+		}),
+	)
+
+	tests := []struct {
+		name         string
+		input        *Input
+		wantDenials  []string
+		wantWarnings []string
+	}{{
+		name:  "passed",
+		input: pipelineInput("prod", "image: harbor.example.com/library/maven"),
+	}, {
+		name:        "denied",
+		input:       pipelineInput("dev", "privileged: true"),
+		wantDenials: []string{"[no-privileged/privileged] privileged pod templates are not allowed"},
+	}, {
+		name:         "warned in the selected namespace",
+		input:        pipelineInput("prod", "image: docker.io/library/maven"),
+		wantWarnings: []string{`[approved-images/registry] the expression "!object.spec.pipeline.jenkinsfile.contains(\"image:\") || object.spec.pipeline.jenkinsfile.contains(\"image: harbor.example.com/\")" is violated`},
+	}, {
+		name:  "not selected namespace",
+		input: pipelineInput("dev", "image: docker.io/library/maven"),
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := evaluator.Evaluate(context.Background(), tt.input)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantDenials, result.Denials)
+			assert.Equal(t, tt.wantWarnings, result.Warnings)
+		})
+	}
+}
+
+func TestEvaluator_FailurePolicy(t *testing.T) {
+	rules := []v1alpha3.PolicyRule{{Name: "invalid", CEL: "object.spec +"}, {Name: "not-bool", CEL: "namespace"}}
+	evaluator := newEvaluator(t,
+		newPolicy("fail", v1alpha3.PipelinePolicySpec{Rules: rules}),
+		newPolicy("ignore", v1alpha3.PipelinePolicySpec{Rules: rules, FailurePolicy: v1alpha3.PolicyFailurePolicyIgnore}),
+	)
+	result, err := evaluator.Evaluate(context.Background(), pipelineInput("dev", ""))
+	assert.Nil(t, err)
+	if assert.Len(t, result.Denials, 2) {
+		assert.Contains(t, result.Denials[0], "[fail/invalid] failed to evaluate the rule")
+		assert.Contains(t, result.Denials[1], "[fail/not-bool] failed to evaluate the rule")
+	}
+}
+
+func TestEvaluator_Rego(t *testing.T) {
+	var uploads int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/policies/ks-devops/namespaces/allowed":
+			uploads++
+			module, _ := io.ReadAll(r.Body)
+			assert.Equal(t, "package devops.namespaces", string(module))
+			_, _ = w.Write([]byte(`{}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/data/devops/namespaces/deny":
+			payload := &struct {
+				Input Input `json:"input"`
+			}{}
+			assert.Nil(t, json.NewDecoder(r.Body).Decode(payload))
+			if payload.Input.Namespace == "prod" {
+				_, _ = w.Write([]byte(`{"result": ["namespace prod is reserved", {"msg": "ask the admin"}]}`))
+			} else {
+				_, _ = w.Write([]byte(`{"result": []}`))
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	evaluator := newEvaluator(t, newPolicy("namespaces", v1alpha3.PipelinePolicySpec{
+		Rules: []v1alpha3.PolicyRule{{
+			Name: "allowed",
+			Rego: &v1alpha3.RegoRule{Server: server.URL + "/", Query: "devops/namespaces/deny", Module: "package devops.namespaces"},
+		}},
+	}), &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "prod"}})
+
+	result, err := evaluator.Evaluate(context.Background(), pipelineInput("prod", ""))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"[namespaces/allowed] namespace prod is reserved", "[namespaces/allowed] ask the admin"}, result.Denials)
+
+	result, err = evaluator.Evaluate(context.Background(), pipelineInput("dev", ""))
+	assert.Nil(t, err)
+	assert.Empty(t, result.Denials)
+	// the unchanged module is uploaded only once
+	assert.Equal(t, 1, uploads)
+}
+
+func TestParseViolations(t *testing.T) {
+	messages, err := parseViolations(nil)
+	assert.Nil(t, err)
+	assert.Empty(t, messages)
+
+	messages, err = parseViolations(true)
+	assert.Nil(t, err)
+	assert.Empty(t, messages)
+
+	messages, err = parseViolations(false)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"denied by the Rego policy"}, messages)
+
+	_, err = parseViolations("deny")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+// regoModulePrefix is the prefix of the IDs of the Rego modules which are uploaded to the OPA servers
+const regoModulePrefix = "ks-devops/"
+
+// evaluateRego queries the violations from the OPA server, the module of the rule is uploaded before querying
+func (e *Evaluator) evaluateRego(ctx context.Context, id string, rule *v1alpha3.RegoRule, input *Input) (messages []string, err error) {
+	server := strings.TrimSuffix(rule.Server, "/")
+	if rule.Module != "" {
+		if err = e.uploadModule(ctx, server, regoModulePrefix+id, rule.Module); err != nil {
+			return
+		}
+	}
+
+	var payload []byte
+	if payload, err = json.Marshal(map[string]interface{}{"input": input}); err != nil {
+		return
+	}
+	api := fmt.Sprintf("%s/v1/data/%s", server, strings.Trim(rule.Query, "/"))
+	response := &struct {
+		Result interface{} `json:"result"`
+	}{}
+	if err = e.request(ctx, http.MethodPost, api, "application/json", payload, response); err != nil {
+		return
+	}
+	return parseViolations(response.Result)
+}
+
+// uploadModule creates or updates the Rego module in the OPA server if it has been changed
+func (e *Evaluator) uploadModule(ctx context.Context, server, id, module string) (err error) {
+	key := server + "/" + id
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(module)))
+	if uploaded, ok := e.modules.Load(key); ok && uploaded == hash {
+		return
+	}
+	api := fmt.Sprintf("%s/v1/policies/%s", server, id)
+	if err = e.request(ctx, http.MethodPut, api, "text/plain", []byte(module), nil); err == nil {
+		e.modules.Store(key, hash)
+	}
+	return
+}
+
+func (e *Evaluator) request(ctx context.Context, method, api, contentType string, body []byte, result interface{}) (err error) {
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctx, method, api, bytes.NewReader(body)); err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", contentType)
+	var resp *http.Response
+	if resp, err = e.getHTTPClient().Do(req); err != nil {
+		return
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status code %d from %s, response: %s", resp.StatusCode, api, string(data))
+	}
+	if result != nil {
+		err = json.NewDecoder(resp.Body).Decode(result)
+	}
+	return
+}
+
+// parseViolations returns the messages of the violations. The result could be undefined which means no violations,
+// a bool which is false if the input is not allowed, or a set of messages or objects which have a msg field.
+func parseViolations(result interface{}) (messages []string, err error) {
+	switch value := result.(type) {
+	case nil:
+	case bool:
+		if !value {
+			messages = []string{"denied by the Rego policy"}
+		}
+	case []interface{}:
+		for _, item := range value {
+			switch violation := item.(type) {
+			case string:
+				messages = append(messages, violation)
+			case map[string]interface{}:
+				if msg, ok := violation["msg"].(string); ok {
+					messages = append(messages, msg)
+				} else {
+					messages = append(messages, fmt.Sprint(violation))
+				}
+			default:
+				messages = append(messages, fmt.Sprint(violation))
+			}
+		}
+	default:
+		err = fmt.Errorf("unexpected result of the Rego query: %v", result)
+	}
+	return
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package quota calculates the usage of the DevOpsProject quota, and checks if the new Pipelines
+// or PipelineRuns are within the quota.
+package quota
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/constants"
+)
+
+// GetProjectQuota returns the quota of the DevOpsProject which owns the namespace,
+// or nil if there is no such project or the project has no quota.
+func GetProjectQuota(ctx context.Context, reader client.Reader, namespace string) (*v1alpha3.ProjectQuota, error) {
+	ns := &v1.Namespace{}
+	if err := reader.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	projectName := ns.GetLabels()[constants.DevOpsProjectLabelKey]
+	if projectName == "" {
+		return nil, nil
+	}
+
+	project := &v1alpha3.DevOpsProject{}
+	if err := reader.Get(ctx, types.NamespacedName{Name: projectName}, project); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	return project.Spec.Quota, nil
+}
+
+// GetUsage calculates the quota usage of the Pipelines and PipelineRuns in the namespace.
+// The run minutes are counted from the beginning of the day (UTC) of now.
+func GetUsage(ctx context.Context, reader client.Reader, namespace string, now time.Time) (*v1alpha3.ProjectQuotaUsage, error) {
+	pipelines := &v1alpha3.PipelineList{}
+	if err := reader.List(ctx, pipelines, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	pipelineRuns := &v1alpha3.PipelineRunList{}
+	if err := reader.List(ctx, pipelineRuns, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	now = now.UTC()
+	beginningOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	var running int32
+	var duration time.Duration
+	for i := range pipelineRuns.Items {
+		pr := &pipelineRuns.Items[i]
+		if pr.Status.StartTime == nil {
+			continue
+		}
+		end := now
+		if pr.HasCompleted() {
+			end = pr.Status.CompletionTime.Time
+		} else if pr.HasStarted() {
+			running++
+		}
+		start := pr.Status.StartTime.Time
+		if start.Before(beginningOfDay) {
+			start = beginningOfDay
+		}
+		if end.After(start) {
+			duration += end.Sub(start)
+		}
+	}
+
+	updateTime := metav1.NewTime(now)
+	return &v1alpha3.ProjectQuotaUsage{
+		Pipelines:           int32(len(pipelines.Items)),
+		RunningPipelineRuns: running,
+		RunMinutesToday:     int32(duration / time.Minute),
+		UpdateTime:          &updateTime,
+	}, nil
+}
+
+// CheckPipeline returns an error if one more Pipeline exceeds the quota
+func CheckPipeline(quota *v1alpha3.ProjectQuota, usage *v1alpha3.ProjectQuotaUsage) error {
+	if quota == nil || quota.MaxPipelines == 0 {
+		return nil
+	}
+	if usage.Pipelines >= quota.MaxPipelines {
+		return fmt.Errorf("the project has reached the limit of %d Pipelines", quota.MaxPipelines)
+	}
+	return nil
+}
+
+// CheckPipelineRun returns an error if one more running PipelineRun exceeds the quota.
+// The PipelineRun should be cancelled rather than queued if cancel is true.
+func CheckPipelineRun(quota *v1alpha3.ProjectQuota, usage *v1alpha3.ProjectQuotaUsage) (cancel bool, err error) {
+	if quota == nil {
+		return
+	}
+	if quota.MaxRunMinutesPerDay > 0 && usage.RunMinutesToday >= quota.MaxRunMinutesPerDay {
+		return true, fmt.Errorf("the project has reached the limit of %d run minutes today", quota.MaxRunMinutesPerDay)
+	}
+	if quota.MaxConcurrentRuns > 0 && usage.RunningPipelineRuns >= quota.MaxConcurrentRuns {
+		return false, fmt.Errorf("the project has reached the limit of %d concurrent runs", quota.MaxConcurrentRuns)
+	}
+	return
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quota
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/constants"
+)
+
+func newScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	assert.Nil(t, v1.AddToScheme(scheme))
+	assert.Nil(t, v1alpha3.AddToScheme(scheme))
+	return scheme
+}
+
+func TestGetProjectQuota(t *testing.T) {
+	projectQuota := &v1alpha3.ProjectQuota{MaxPipelines: 1}
+	project := &v1alpha3.DevOpsProject{
+		ObjectMeta: metav1.ObjectMeta{Name: "project"},
+		Spec:       v1alpha3.DevOpsProjectSpec{Quota: projectQuota},
+	}
+	ns := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "ns",
+		Labels: map[string]string{constants.DevOpsProjectLabelKey: "project"},
+	}}
+	plainNs := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "plain"}}
+	reader := fake.NewClientBuilder().WithScheme(newScheme(t)).WithObjects(project, ns, plainNs).Build()
+
+	got, err := GetProjectQuota(context.Background(), reader, "ns")
+	assert.Nil(t, err)
+	assert.Equal(t, projectQuota, got)
+
+	got, err = GetProjectQuota(context.Background(), reader, "plain")
+	assert.Nil(t, err)
+	assert.Nil(t, got)
+
+	got, err = GetProjectQuota(context.Background(), reader, "absent")
+	assert.Nil(t, err)
+	assert.Nil(t, got)
+}
+
+func TestGetUsage(t *testing.T) {
+	now := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	newPipelineRun := func(name string, start time.Time, completion *time.Time) *v1alpha3.PipelineRun {
+		pr := &v1alpha3.PipelineRun{ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "ns",
+			Name:        name,
+			Annotations: map[string]string{v1alpha3.JenkinsPipelineRunIDAnnoKey: "1"},
+		}}
+		pr.Status.StartTime = &metav1.Time{Time: start}
+		if completion != nil {
+			pr.Status.CompletionTime = &metav1.Time{Time: *completion}
+		}
+		return pr
+	}
+	completion := now.Add(-time.Hour)
+	halfPastMidnight := time.Date(2023, 5, 1, 0, 30, 0, 0, time.UTC)
+	yesterday := now.Add(-24 * time.Hour)
+	reader := fake.NewClientBuilder().WithScheme(newScheme(t)).WithObjects(
+		&v1alpha3.Pipeline{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "a"}},
+		&v1alpha3.Pipeline{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "b"}},
+		&v1alpha3.Pipeline{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "c"}},
+		// 10 minutes
+		newPipelineRun("running", now.Add(-10*time.Minute), nil),
+		// 30 minutes
+		newPipelineRun("completed", completion.Add(-30*time.Minute), &completion),
+		// started yesterday, 30 minutes today
+		newPipelineRun("overnight", now.Add(-11*time.Hour), &halfPastMidnight),
+		// completed yesterday
+		newPipelineRun("old", yesterday.Add(-time.Hour), &yesterday),
+		&v1alpha3.PipelineRun{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pending"}},
+	).Build()
+
+	usage, err := GetUsage(context.Background(), reader, "ns", now)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), usage.Pipelines)
+	assert.Equal(t, int32(1), usage.RunningPipelineRuns)
+	assert.Equal(t, int32(70), usage.RunMinutesToday)
+	assert.Equal(t, now, usage.UpdateTime.Time)
+}
+
+func TestCheck(t *testing.T) {
+	usage := &v1alpha3.ProjectQuotaUsage{Pipelines: 2, RunningPipelineRuns: 2, RunMinutesToday: 60}
+
+	assert.Nil(t, CheckPipeline(nil, usage))
+	assert.Nil(t, CheckPipeline(&v1alpha3.ProjectQuota{MaxPipelines: 3}, usage))
+	assert.NotNil(t, CheckPipeline(&v1alpha3.ProjectQuota{MaxPipelines: 2}, usage))
+
+	tests := []struct {
+		name       string
+		quota      *v1alpha3.ProjectQuota
+		wantCancel bool
+		wantErr    bool
+	}{{
+		name: "no quota",
+	}, {
+		name:  "within quota",
+		quota: &v1alpha3.ProjectQuota{MaxConcurrentRuns: 3, MaxRunMinutesPerDay: 120},
+	}, {
+		name:    "concurrent runs exceeded",
+		quota:   &v1alpha3.ProjectQuota{MaxConcurrentRuns: 2},
+		wantErr: true,
+	}, {
+		name:       "run minutes exceeded",
+		quota:      &v1alpha3.ProjectQuota{MaxConcurrentRuns: 2, MaxRunMinutesPerDay: 60},
+		wantCancel: true,
+		wantErr:    true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cancel, err := CheckPipelineRun(tt.quota, usage)
+			assert.Equal(t, tt.wantCancel, cancel)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker/decls"
+	"github.com/google/cel-go/ext"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+// celEnv declares the variable event, the string extensions like replace and split are available as well
+var celEnv *cel.Env
+
+func init() {
+	var err error
+	if celEnv, err = cel.NewEnv(
+		cel.Declarations(decls.NewVar("event", decls.NewMapType(decls.String, decls.Dyn))),
+		ext.Strings(),
+	); err != nil {
+		panic(err)
+	}
+}
+
+// Evaluator evaluates the CEL expressions of the Triggers, the compiled expressions are cached
+type Evaluator struct {
+	programs sync.Map
+}
+
+// Match indicates if the event passes the filter, an empty filter matches everything
+func (e *Evaluator) Match(filter string, event *Event) (matched bool, err error) {
+	if filter == "" {
+		return true, nil
+	}
+	var out interface{}
+	if out, err = e.evaluate(filter, event); err != nil {
+		return
+	}
+	var ok bool
+	if matched, ok = out.(bool); !ok {
+		err = fmt.Errorf("the filter should be evaluated to a bool, but got %T", out)
+	}
+	return
+}
+
+// String evaluates the expression to a string, the numbers and bools are formatted
+func (e *Evaluator) String(expression string, event *Event) (value string, err error) {
+	var out interface{}
+	if out, err = e.evaluate(expression, event); err != nil {
+		return
+	}
+	switch v := out.(type) {
+	case string:
+		value = v
+	case bool, int64, uint64, float64:
+		value = fmt.Sprint(v)
+	default:
+		err = fmt.Errorf("the expression %q should be evaluated to a string, but got %T", expression, out)
+	}
+	return
+}
+
+// Validate checks the syntax of the expression
+func (e *Evaluator) Validate(expression string) (err error) {
+	_, err = e.program(expression)
+	return
+}
+
+func (e *Evaluator) evaluate(expression string, event *Event) (out interface{}, err error) {
+	var program cel.Program
+	if program, err = e.program(expression); err != nil {
+		return
+	}
+	val, _, err := program.Eval(map[string]interface{}{"event": event.variables()})
+	if err != nil {
+		err = fmt.Errorf("failed to evaluate %q: %v", expression, err)
+		return
+	}
+	out = val.Value()
+	return
+}
+
+func (e *Evaluator) program(expression string) (program cel.Program, err error) {
+	if cached, ok := e.programs.Load(expression); ok {
+		return cached.(cel.Program), nil
+	}
+	ast, issues := celEnv.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		err = fmt.Errorf("invalid expression %q: %v", expression, issues.Err())
+		return
+	}
+	if program, err = celEnv.Program(ast); err == nil {
+		e.programs.Store(expression, program)
+	}
+	return
+}
+
+// ValidateSpec checks the schedule and the expressions of a Trigger
+func (e *Evaluator) ValidateSpec(spec *v1alpha3.TriggerSpec) (err error) {
+	if spec.Source.Type == v1alpha3.TriggerSourceCron {
+		if _, err = ParseSchedule(spec.Source.Schedule, spec.Source.TimeZone); err != nil {
+			return
+		}
+	}
+	expressions := []string{spec.Filter, spec.Branch}
+	for _, param := range spec.Parameters {
+		if param.Name == "" || param.Expression == "" {
+			return fmt.Errorf("both the name and the expression of the parameter %q are required", param.Name)
+		}
+		expressions = append(expressions, param.Expression)
+	}
+	for _, expression := range expressions {
+		if expression == "" {
+			continue
+		}
+		if err = e.Validate(expression); err != nil {
+			return
+		}
+	}
+	return
+}
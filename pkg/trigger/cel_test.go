@@ -0,0 +1,103 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+func TestEvaluator(t *testing.T) {
+	event := &Event{
+		Type:     EventPush,
+		Branch:   "main",
+		Revision: "abc",
+		Changes:  []string{"service-a/main.go", "README.md"},
+	}
+	event.SetPayload([]byte(`{"pusher":{"name":"alice"}}`))
+	evaluator := &Evaluator{}
+
+	tests := []struct {
+		name     string
+		filter   string
+		expected bool
+		wantErr  bool
+	}{{
+		name:     "empty filter matches all",
+		expected: true,
+	}, {
+		name:     "fields",
+		filter:   `event.type == "push" && event.branch == "main"`,
+		expected: true,
+	}, {
+		name:     "changes",
+		filter:   `event.changes.exists(f, f.startsWith("service-b/"))`,
+		expected: false,
+	}, {
+		name:     "body",
+		filter:   `event.body.pusher.name == "alice"`,
+		expected: true,
+	}, {
+		name:     "string extension",
+		filter:   `event.branch.upperAscii() == "MAIN"`,
+		expected: true,
+	}, {
+		name:    "not a bool",
+		filter:  `event.branch`,
+		wantErr: true,
+	}, {
+		name:    "invalid syntax",
+		filter:  `event.branch ==`,
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched, err := evaluator.Match(tt.filter, event)
+			assert.Equal(t, tt.wantErr, err != nil, err)
+			assert.Equal(t, tt.expected, matched)
+		})
+	}
+
+	value, err := evaluator.String(`event.branch + "-" + event.revision`, event)
+	assert.Nil(t, err)
+	assert.Equal(t, "main-abc", value)
+	_, err = evaluator.String(`event.changes`, event)
+	assert.NotNil(t, err)
+}
+
+func TestValidateSpec(t *testing.T) {
+	evaluator := &Evaluator{}
+	assert.Nil(t, evaluator.ValidateSpec(&v1alpha3.TriggerSpec{
+		Source:     v1alpha3.TriggerSource{Type: v1alpha3.TriggerSourceCron, Schedule: "@daily"},
+		Filter:     `event.type == "cron"`,
+		Parameters: []v1alpha3.TriggerParameter{{Name: "MODE", Expression: `"nightly"`}},
+	}))
+	assert.NotNil(t, evaluator.ValidateSpec(&v1alpha3.TriggerSpec{
+		Source: v1alpha3.TriggerSource{Type: v1alpha3.TriggerSourceCron, Schedule: "daily"},
+	}))
+	assert.NotNil(t, evaluator.ValidateSpec(&v1alpha3.TriggerSpec{
+		Source: v1alpha3.TriggerSource{Type: v1alpha3.TriggerSourceWebhook},
+		Filter: `event.unknown(`,
+	}))
+	assert.NotNil(t, evaluator.ValidateSpec(&v1alpha3.TriggerSpec{
+		Source:     v1alpha3.TriggerSource{Type: v1alpha3.TriggerSourceWebhook},
+		Parameters: []v1alpha3.TriggerParameter{{Expression: "event.tag"}},
+	}))
+}
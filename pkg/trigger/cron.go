@@ -0,0 +1,186 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression with the fields minute, hour, day of month, month and day of week
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	// domStar and dowStar indicate the day fields are not restricted, a day matches either of the restricted
+	// fields if both of them are restricted, which is the same as the standard cron
+	domStar, dowStar bool
+	location         *time.Location
+}
+
+type cronField struct {
+	min, max uint
+	names    map[string]uint
+}
+
+var (
+	minuteField = cronField{min: 0, max: 59}
+	hourField   = cronField{min: 0, max: 23}
+	domField    = cronField{min: 1, max: 31}
+	monthField  = cronField{min: 1, max: 12, names: map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowField = cronField{min: 0, max: 7, names: map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseSchedule parses a cron expression in the time zone, UTC is used if the time zone is empty
+func ParseSchedule(spec, timeZone string) (schedule *Schedule, err error) {
+	location := time.UTC
+	if timeZone != "" {
+		if location, err = time.LoadLocation(timeZone); err != nil {
+			err = fmt.Errorf("invalid time zone %q: %v", timeZone, err)
+			return
+		}
+	}
+
+	expression := strings.TrimSpace(spec)
+	if descriptor, ok := cronDescriptors[expression]; ok {
+		expression = descriptor
+	}
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		err = fmt.Errorf("invalid schedule %q, it should have 5 fields: minute, hour, day of month, month and day of week", spec)
+		return
+	}
+
+	schedule = &Schedule{location: location, domStar: fields[2] == "*", dowStar: fields[4] == "*"}
+	for i, item := range []struct {
+		bits  *uint64
+		field cronField
+	}{{&schedule.minute, minuteField}, {&schedule.hour, hourField}, {&schedule.dom, domField},
+		{&schedule.month, monthField}, {&schedule.dow, dowField}} {
+		if *item.bits, err = item.field.parse(fields[i]); err != nil {
+			err = fmt.Errorf("invalid schedule %q: %v", spec, err)
+			return nil, err
+		}
+	}
+	// both 0 and 7 are Sunday
+	if schedule.dow&(1<<7) != 0 {
+		schedule.dow |= 1
+	}
+	return
+}
+
+func (f cronField) parse(expression string) (bits uint64, err error) {
+	for _, part := range strings.Split(expression, ",") {
+		rangeExpr, step := part, uint(1)
+		if index := strings.Index(part, "/"); index >= 0 {
+			rangeExpr = part[:index]
+			var value uint64
+			if value, err = strconv.ParseUint(part[index+1:], 10, 8); err != nil || value == 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			step = uint(value)
+		}
+
+		var start, end uint
+		switch {
+		case rangeExpr == "*":
+			start, end = f.min, f.max
+		case strings.Contains(rangeExpr, "-"):
+			bounds := strings.SplitN(rangeExpr, "-", 2)
+			if start, err = f.value(bounds[0]); err == nil {
+				end, err = f.value(bounds[1])
+			}
+			if err == nil && start > end {
+				err = fmt.Errorf("invalid range %q", rangeExpr)
+			}
+		default:
+			if start, err = f.value(rangeExpr); err == nil {
+				end = start
+				if step > 1 {
+					end = f.max
+				}
+			}
+		}
+		if err != nil {
+			return
+		}
+		for value := start; value <= end; value += step {
+			bits |= 1 << value
+		}
+	}
+	return
+}
+
+func (f cronField) value(expression string) (value uint, err error) {
+	if named, ok := f.names[strings.ToLower(expression)]; ok {
+		return named, nil
+	}
+	var parsed uint64
+	if parsed, err = strconv.ParseUint(expression, 10, 8); err != nil {
+		return 0, fmt.Errorf("invalid value %q", expression)
+	}
+	if value = uint(parsed); value < f.min || value > f.max {
+		err = fmt.Errorf("value %d is out of the range [%d, %d]", value, f.min, f.max)
+	}
+	return
+}
+
+// Next returns the first time after the given time which matches the schedule,
+// the zero time is returned if nothing matches in five years, such as Feb 30th
+func (s *Schedule) Next(after time.Time) time.Time {
+	t := after.In(s.location).Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.location)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.location)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, s.location)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatched := s.dom&(1<<uint(t.Day())) != 0
+	dowMatched := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatched && dowMatched
+	}
+	return domMatched || dowMatched
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedule(t *testing.T) {
+	base := time.Date(2023, 3, 1, 10, 30, 0, 0, time.UTC) // Wednesday
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name     string
+		spec     string
+		timeZone string
+		after    time.Time
+		expected time.Time
+		wantErr  bool
+	}{{
+		name:     "every minute",
+		spec:     "* * * * *",
+		after:    base,
+		expected: base.Add(time.Minute),
+	}, {
+		name:     "daily",
+		spec:     "0 2 * * *",
+		after:    base,
+		expected: time.Date(2023, 3, 2, 2, 0, 0, 0, time.UTC),
+	}, {
+		name:     "descriptor",
+		spec:     "@hourly",
+		after:    base,
+		expected: time.Date(2023, 3, 1, 11, 0, 0, 0, time.UTC),
+	}, {
+		name:     "steps",
+		spec:     "*/20 * * * *",
+		after:    base,
+		expected: time.Date(2023, 3, 1, 10, 40, 0, 0, time.UTC),
+	}, {
+		name:     "ranges and names",
+		spec:     "0 9 * * mon-fri",
+		after:    time.Date(2023, 3, 3, 10, 0, 0, 0, time.UTC), // Friday
+		expected: time.Date(2023, 3, 6, 9, 0, 0, 0, time.UTC),
+	}, {
+		name:     "Sunday is 7",
+		spec:     "0 0 * * 7",
+		after:    base,
+		expected: time.Date(2023, 3, 5, 0, 0, 0, 0, time.UTC),
+	}, {
+		name:     "either of the restricted days matches",
+		spec:     "0 0 15 * sat",
+		after:    base,
+		expected: time.Date(2023, 3, 4, 0, 0, 0, 0, time.UTC),
+	}, {
+		name:     "month",
+		spec:     "0 0 1 jan *",
+		after:    base,
+		expected: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}, {
+		name:     "time zone",
+		spec:     "0 2 * * *",
+		timeZone: "Asia/Shanghai",
+		after:    base,
+		expected: time.Date(2023, 3, 2, 2, 0, 0, 0, shanghai),
+	}, {
+		name:     "never matches",
+		spec:     "0 0 31 2 *",
+		after:    base,
+		expected: time.Time{},
+	}, {
+		name:    "invalid number of fields",
+		spec:    "0 2 * *",
+		wantErr: true,
+	}, {
+		name:    "out of range",
+		spec:    "60 * * * *",
+		wantErr: true,
+	}, {
+		name:    "invalid step",
+		spec:    "*/0 * * * *",
+		wantErr: true,
+	}, {
+		name:    "invalid range",
+		spec:    "0 5-2 * * *",
+		wantErr: true,
+	}, {
+		name:     "invalid time zone",
+		spec:     "* * * * *",
+		timeZone: "Mars/Olympus",
+		wantErr:  true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseSchedule(tt.spec, tt.timeZone)
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.True(t, tt.expected.Equal(schedule.Next(tt.after)), "got %v", schedule.Next(tt.after))
+		})
+	}
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/pipelinerun"
+)
+
+// Dispatcher creates the PipelineRuns of the Triggers which match the events
+type Dispatcher struct {
+	client.Client
+	Evaluator *Evaluator
+
+	now func() time.Time
+}
+
+// NewDispatcher creates a Dispatcher
+func NewDispatcher(c client.Client) *Dispatcher {
+	return &Dispatcher{Client: c, Evaluator: &Evaluator{}, now: time.Now}
+}
+
+// Dispatch fires the Triggers of the event source, the created PipelineRuns are returned in the form of namespace/name.
+// The Triggers which failed to fire don't stop the others, their errors are aggregated.
+func (d *Dispatcher) Dispatch(ctx context.Context, event *Event) (pipelineRuns []string, err error) {
+	triggerList := &v1alpha3.TriggerList{}
+	if err = d.List(ctx, triggerList); err != nil {
+		return
+	}
+
+	var errs []error
+	for i := range triggerList.Items {
+		trigger := &triggerList.Items[i]
+		if trigger.Spec.Source.Type != event.SourceType || event.ExcludedNamespaces[trigger.Namespace] {
+			continue
+		}
+		// the unverified events are ignored quietly, the status of the Triggers is not touched by them
+		if !d.admits(ctx, trigger, event) {
+			continue
+		}
+
+		base := trigger.DeepCopy()
+		run, fireErr := d.Fire(ctx, trigger, event)
+		if run == nil && fireErr == nil {
+			continue
+		}
+		if fireErr != nil {
+			errs = append(errs, fmt.Errorf("failed to fire Trigger %s/%s: %v", trigger.Namespace, trigger.Name, fireErr))
+		} else {
+			pipelineRuns = append(pipelineRuns, fmt.Sprintf("%s/%s", run.Namespace, run.Name))
+		}
+		SetStatus(&trigger.Status, run, fireErr, d.now())
+		if patchErr := d.Status().Patch(ctx, trigger, client.MergeFrom(base)); patchErr != nil {
+			errs = append(errs, patchErr)
+		}
+	}
+	err = utilerrors.NewAggregate(errs)
+	return
+}
+
+// admits returns true if the event is allowed to fire the Trigger. A Trigger which has a secret is only fired by the
+// events whose proof is made by it, and the secret is required by all the sources except the SCM webhooks.
+func (d *Dispatcher) admits(ctx context.Context, trigger *v1alpha3.Trigger, event *Event) bool {
+	if ref := trigger.Spec.Source.SecretRef; ref != nil {
+		return event.Proof != nil && d.verify(ctx, trigger.Namespace, ref, event.Proof)
+	}
+	return event.SourceType == v1alpha3.TriggerSourceWebhook
+}
+
+// verify returns true if the proof is made by the secret which the reference points to
+func (d *Dispatcher) verify(ctx context.Context, namespace string, ref *v1.SecretKeySelector, proof *Proof) bool {
+	secret := &v1.Secret{}
+	if err := d.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, secret); err != nil {
+		return false
+	}
+	return proof.Verify(secret.Data[ref.Key])
+}
+
+// Fire creates a PipelineRun if the event passes the filter of the Trigger, nil is returned if it does not pass.
+// The status of the Trigger is not touched, see also SetStatus.
+func (d *Dispatcher) Fire(ctx context.Context, trigger *v1alpha3.Trigger, event *Event) (run *v1alpha3.PipelineRun, err error) {
+	if trigger.Spec.Suspend {
+		return
+	}
+	var matched bool
+	if matched, err = d.Evaluator.Match(trigger.Spec.Filter, event); err != nil || !matched {
+		return
+	}
+
+	pipeline := &v1alpha3.Pipeline{}
+	if err = d.Get(ctx, client.ObjectKey{Namespace: trigger.Namespace, Name: trigger.Spec.Pipeline}, pipeline); err != nil {
+		return
+	}
+
+	parameters := make([]v1alpha3.Parameter, 0, len(trigger.Spec.Parameters))
+	for _, param := range trigger.Spec.Parameters {
+		var value string
+		if value, err = d.Evaluator.String(param.Expression, event); err != nil {
+			return
+		}
+		parameters = append(parameters, v1alpha3.Parameter{Name: param.Name, Value: value})
+	}
+
+	branch := event.Branch
+	if trigger.Spec.Branch != "" {
+		if branch, err = d.Evaluator.String(trigger.Spec.Branch, event); err != nil {
+			return
+		}
+	}
+	var scm *v1alpha3.SCM
+	if scm, err = pipelinerun.CreateScm(&pipeline.Spec, branch); err != nil {
+		return
+	}
+
+	run = pipelinerun.CreateBarePipelineRun(pipeline, parameters, scm)
+	run.Labels[v1alpha3.TriggerLabelKey] = trigger.Name
+	if event.Revision != "" {
+		run.Annotations[v1alpha3.PipelineRunSCMRevisionAnnoKey] = event.Revision
+	}
+	if event.BaseRevision != "" {
+		run.Annotations[v1alpha3.PipelineRunSCMBaseRevisionAnnoKey] = event.BaseRevision
+	}
+	if event.Repository != "" && event.SourceType == v1alpha3.TriggerSourceWebhook {
+		run.Annotations[v1alpha3.PipelineRunSCMRepoAnnoKey] = event.Repository
+	}
+	if err = d.Create(ctx, run); err != nil {
+		run = nil
+	}
+	return
+}
+
+// SetStatus records the outcome of firing a Trigger
+func SetStatus(status *v1alpha3.TriggerStatus, run *v1alpha3.PipelineRun, err error, now time.Time) {
+	if err != nil {
+		status.Message = err.Error()
+		return
+	}
+	if run != nil {
+		status.Message = ""
+		status.LastPipelineRun = run.Name
+		status.LastTriggeredTime = &metav1.Time{Time: now}
+	}
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+func TestDispatch(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	now := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	pipeline := &v1alpha3.Pipeline{
+		TypeMeta:   metav1.TypeMeta{Kind: "Pipeline", APIVersion: v1alpha3.GroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "release"},
+		Spec:       v1alpha3.PipelineSpec{Type: v1alpha3.MultiBranchPipelineType},
+	}
+	newTrigger := func(name, filter string, source v1alpha3.TriggerSourceType) *v1alpha3.Trigger {
+		return &v1alpha3.Trigger{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name},
+			Spec: v1alpha3.TriggerSpec{
+				Pipeline:   pipeline.Name,
+				Source:     v1alpha3.TriggerSource{Type: source},
+				Filter:     filter,
+				Parameters: []v1alpha3.TriggerParameter{{Name: "VERSION", Expression: "event.tag"}},
+			},
+		}
+	}
+	matched := newTrigger("matched", `event.tag.startsWith("v")`, v1alpha3.TriggerSourceWebhook)
+	unmatched := newTrigger("unmatched", `event.tag.startsWith("release-")`, v1alpha3.TriggerSourceWebhook)
+	otherSource := newTrigger("other-source", "", v1alpha3.TriggerSourceCron)
+	suspended := newTrigger("suspended", "", v1alpha3.TriggerSourceWebhook)
+	suspended.Spec.Suspend = true
+	broken := newTrigger("broken", "", v1alpha3.TriggerSourceWebhook)
+	broken.Spec.Pipeline = "missing"
+
+	c := fake.NewClientBuilder().WithScheme(schema).
+		WithObjects(pipeline, matched, unmatched, otherSource, suspended, broken).Build()
+	dispatcher := NewDispatcher(c)
+	dispatcher.now = func() time.Time { return now }
+
+	runs, err := dispatcher.Dispatch(context.Background(), &Event{
+		SourceType:   v1alpha3.TriggerSourceWebhook,
+		Type:         EventTag,
+		Repository:   "https://github.com/alice/app",
+		Branch:       "v1.0.0",
+		Tag:          "v1.0.0",
+		Revision:     "abc",
+		BaseRevision: "def",
+	})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "ns/broken")
+	assert.Len(t, runs, 1)
+
+	runList := &v1alpha3.PipelineRunList{}
+	assert.Nil(t, c.List(context.Background(), runList))
+	assert.Len(t, runList.Items, 1)
+	run := runList.Items[0]
+	assert.Equal(t, "matched", run.Labels[v1alpha3.TriggerLabelKey])
+	assert.Equal(t, v1alpha3.PipelineRunTriggerEvent, run.GetTrigger())
+	assert.Equal(t, "abc", run.Annotations[v1alpha3.PipelineRunSCMRevisionAnnoKey])
+	assert.Equal(t, "def", run.Annotations[v1alpha3.PipelineRunSCMBaseRevisionAnnoKey])
+	assert.Equal(t, "https://github.com/alice/app", run.Annotations[v1alpha3.PipelineRunSCMRepoAnnoKey])
+	assert.Equal(t, []v1alpha3.Parameter{{Name: "VERSION", Value: "v1.0.0"}}, run.Spec.Parameters)
+	assert.Equal(t, "v1.0.0", run.Spec.SCM.RefName)
+
+	trigger := &v1alpha3.Trigger{}
+	assert.Nil(t, c.Get(context.Background(), client.ObjectKeyFromObject(matched), trigger))
+	assert.Equal(t, run.Name, trigger.Status.LastPipelineRun)
+	assert.True(t, now.Equal(trigger.Status.LastTriggeredTime.Time))
+	assert.Nil(t, c.Get(context.Background(), client.ObjectKeyFromObject(broken), trigger))
+	assert.Contains(t, trigger.Status.Message, "not found")
+	assert.Nil(t, c.Get(context.Background(), client.ObjectKeyFromObject(unmatched), trigger))
+	assert.Empty(t, trigger.Status.LastPipelineRun)
+}
+
+func TestDispatchExcludedNamespaces(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	pipeline := &v1alpha3.Pipeline{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "release"}}
+	trigger := &v1alpha3.Trigger{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "push"},
+		Spec: v1alpha3.TriggerSpec{
+			Pipeline: pipeline.Name,
+			Source:   v1alpha3.TriggerSource{Type: v1alpha3.TriggerSourceWebhook},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(schema).WithObjects(pipeline, trigger).Build()
+
+	runs, err := NewDispatcher(c).Dispatch(context.Background(), &Event{
+		SourceType:         v1alpha3.TriggerSourceWebhook,
+		Type:               EventPush,
+		ExcludedNamespaces: map[string]bool{"ns": true},
+	})
+	assert.Nil(t, err)
+	assert.Empty(t, runs)
+}
+
+func TestDispatchWithSecret(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1.AddToScheme(schema))
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	pipeline := &v1alpha3.Pipeline{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "release"}}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "trigger-secret"},
+		Data:       map[string][]byte{"token": []byte("s3cr3t")},
+	}
+	newTrigger := func(name string, source v1alpha3.TriggerSourceType, withSecret bool) *v1alpha3.Trigger {
+		trigger := &v1alpha3.Trigger{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name},
+			Spec: v1alpha3.TriggerSpec{
+				Pipeline: pipeline.Name,
+				Source:   v1alpha3.TriggerSource{Type: source},
+			},
+		}
+		if withSecret {
+			trigger.Spec.Source.SecretRef = &v1.SecretKeySelector{
+				LocalObjectReference: v1.LocalObjectReference{Name: secret.Name}, Key: "token"}
+		}
+		return trigger
+	}
+	verifier := func(secret []byte) bool { return string(secret) == "s3cr3t" }
+
+	tests := []struct {
+		name     string
+		trigger  *v1alpha3.Trigger
+		event    *Event
+		expected int
+	}{{
+		name:     "webhook without a proof",
+		trigger:  newTrigger("push", v1alpha3.TriggerSourceWebhook, true),
+		event:    &Event{SourceType: v1alpha3.TriggerSourceWebhook, Type: EventPush},
+		expected: 0,
+	}, {
+		name:    "webhook with a wrong proof",
+		trigger: newTrigger("push", v1alpha3.TriggerSourceWebhook, true),
+		event: &Event{SourceType: v1alpha3.TriggerSourceWebhook, Type: EventPush,
+			Proof: &Proof{Verifier: func([]byte) bool { return false }}},
+		expected: 0,
+	}, {
+		name:    "webhook with a valid proof",
+		trigger: newTrigger("push", v1alpha3.TriggerSourceWebhook, true),
+		event: &Event{SourceType: v1alpha3.TriggerSourceWebhook, Type: EventPush,
+			Proof: &Proof{Verifier: verifier}},
+		expected: 1,
+	}, {
+		name:    "webhook to the Trigger without a secret",
+		trigger: newTrigger("push", v1alpha3.TriggerSourceWebhook, false),
+		event: &Event{SourceType: v1alpha3.TriggerSourceWebhook, Type: EventPush,
+			Proof: &Proof{Verifier: verifier}},
+		expected: 1,
+	}, {
+		name:     "image push to the Trigger without a secret",
+		trigger:  newTrigger("image", v1alpha3.TriggerSourceImagePush, false),
+		event:    &Event{SourceType: v1alpha3.TriggerSourceImagePush, Type: EventImagePush},
+		expected: 0,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(pipeline, secret, tt.trigger).Build()
+			runs, err := NewDispatcher(c).Dispatch(context.Background(), tt.event)
+			assert.Nil(t, err)
+			assert.Len(t, runs, tt.expected)
+		})
+	}
+}
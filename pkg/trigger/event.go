@@ -0,0 +1,117 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"encoding/json"
+	"time"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+// The types of the events which are not CloudEvents
+const (
+	EventPush        = "push"
+	EventTag         = "tag"
+	EventPullRequest = "pull_request"
+	EventImagePush   = "image-push"
+	EventCron        = "cron"
+)
+
+// Event is the normalized event from a source, it's the variable event of the CEL expressions of the Triggers
+type Event struct {
+	// SourceType decides which Triggers receive the event
+	SourceType v1alpha3.TriggerSourceType
+
+	// Type is the type of the event, such as push, tag, pull_request, image-push, cron, or the type of a CloudEvent
+	Type string
+	// Source is where the event comes from, such as github, the host of the registry, or the source of a CloudEvent
+	Source string
+
+	Repository string
+	URL        string
+	Ref        string
+	Branch     string
+	Tag        string
+	Revision   string
+	// BaseRevision is the commit before the pushed commits, or the commit of the target branch of a pull request
+	BaseRevision string
+	// Changes are the paths of the files which were added, modified or removed by the pushed commits
+	Changes []string
+
+	Image  string
+	Digest string
+
+	Time    time.Time
+	Headers map[string]string
+	// Body is the payload of the event, it's decoded if it's JSON
+	Body interface{}
+
+	// Proof is verified by the secrets of the Triggers before firing them. The Triggers which have a secret are
+	// never fired by the events without a proof or with a wrong one, and the Triggers without a secret are only
+	// fired by the SCM webhooks, which are verified by the webhook secrets of the DevOpsProjects as well.
+	Proof *Proof
+	// ExcludedNamespaces are the namespaces whose Triggers are not fired, such as the DevOpsProjects whose
+	// webhook secret does not match the signature of the SCM webhook
+	ExcludedNamespaces map[string]bool
+}
+
+// SetPayload decodes the payload into the body, the raw payload is kept if it's not JSON
+func (e *Event) SetPayload(payload []byte) {
+	var body interface{}
+	if err := json.Unmarshal(payload, &body); err == nil {
+		e.Body = body
+	} else {
+		e.Body = string(payload)
+	}
+}
+
+func (e *Event) variables() map[string]interface{} {
+	changes := e.Changes
+	if changes == nil {
+		changes = []string{}
+	}
+	headers := e.Headers
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	var body interface{} = map[string]interface{}{}
+	if e.Body != nil {
+		body = e.Body
+	}
+	var eventTime string
+	if !e.Time.IsZero() {
+		eventTime = e.Time.UTC().Format(time.RFC3339)
+	}
+	return map[string]interface{}{
+		"type":         e.Type,
+		"source":       e.Source,
+		"repository":   e.Repository,
+		"url":          e.URL,
+		"ref":          e.Ref,
+		"branch":       e.Branch,
+		"tag":          e.Tag,
+		"revision":     e.Revision,
+		"baseRevision": e.BaseRevision,
+		"changes":      changes,
+		"image":        e.Image,
+		"digest":       e.Digest,
+		"time":         eventTime,
+		"headers":      headers,
+		"body":         body,
+	}
+}
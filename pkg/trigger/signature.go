@@ -0,0 +1,57 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+)
+
+// SignatureHeader carries the HMAC-SHA256 signature of the payload of an event, in the format of sha256=<hex>
+const SignatureHeader = "X-DevOps-Signature-256"
+
+// Proof is what the sender of an event carries to prove that it knows the secret of the Triggers
+type Proof struct {
+	Payload []byte
+	// Signature is the value of SignatureHeader
+	Signature string
+	// Token is the secret itself, it's for the senders which are not able to sign the payloads, such as Docker Hub
+	Token string
+	// Verifier verifies the proof in the way of the sender, such as the signatures of the SCM webhooks.
+	// The Signature and Token are ignored if it's set.
+	Verifier func(secret []byte) bool
+}
+
+// Verify returns true if the signature is made by the secret, or the token equals the secret
+func (p *Proof) Verify(secret []byte) bool {
+	if len(secret) == 0 {
+		return false
+	}
+	if p.Verifier != nil {
+		return p.Verifier(secret)
+	}
+	if p.Signature != "" {
+		mac := hmac.New(sha256.New, secret)
+		_, _ = mac.Write(p.Payload)
+		expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(expected), []byte(strings.ToLower(p.Signature)))
+	}
+	return p.Token != "" && subtle.ConstantTimeCompare([]byte(p.Token), secret) == 1
+}
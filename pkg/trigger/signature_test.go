@@ -0,0 +1,66 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProof_Verify(t *testing.T) {
+	payload := []byte(`{"version":"v1"}`)
+	// generated by: echo -n '{"version":"v1"}' | openssl dgst -sha256 -hmac s3cr3t
+	signature := "sha256=22e848150b27f718649852ce6dabccc95c9b8709f05d4508f37438e76f187371"
+
+	tests := []struct {
+		name   string
+		proof  *Proof
+		secret string
+		expect bool
+	}{{
+		name:   "valid signature",
+		proof:  &Proof{Payload: payload, Signature: signature},
+		secret: "s3cr3t",
+		expect: true,
+	}, {
+		name:   "signed by another secret",
+		proof:  &Proof{Payload: payload, Signature: signature},
+		secret: "another",
+	}, {
+		name:   "the signature takes precedence over the token",
+		proof:  &Proof{Payload: []byte("tampered"), Signature: signature, Token: "s3cr3t"},
+		secret: "s3cr3t",
+	}, {
+		name:   "valid token",
+		proof:  &Proof{Payload: payload, Token: "s3cr3t"},
+		secret: "s3cr3t",
+		expect: true,
+	}, {
+		name:   "invalid token",
+		proof:  &Proof{Payload: payload, Token: "guess"},
+		secret: "s3cr3t",
+	}, {
+		name:  "empty secret",
+		proof: &Proof{Payload: payload},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expect, tt.proof.Verify([]byte(tt.secret)))
+		})
+	}
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+// ErrUnknownPayload indicates the payload is not from a known image registry
+var ErrUnknownPayload = errors.New("unknown payload of the image registry")
+
+// registryPayload has the fields of the notifications of Harbor, Docker Hub and Docker Distribution
+type registryPayload struct {
+	// Harbor
+	Type      string `json:"type"`
+	OccurAt   int64  `json:"occur_at"`
+	EventData *struct {
+		Resources []struct {
+			Digest      string `json:"digest"`
+			Tag         string `json:"tag"`
+			ResourceURL string `json:"resource_url"`
+		} `json:"resources"`
+		Repository struct {
+			RepoFullName string `json:"repo_full_name"`
+		} `json:"repository"`
+	} `json:"event_data"`
+
+	// Docker Hub
+	PushData *struct {
+		Tag      string `json:"tag"`
+		PushedAt int64  `json:"pushed_at"`
+	} `json:"push_data"`
+	Repository *struct {
+		RepoName string `json:"repo_name"`
+		RepoURL  string `json:"repo_url"`
+	} `json:"repository"`
+
+	// Docker Distribution
+	Events []struct {
+		Action    string    `json:"action"`
+		Timestamp time.Time `json:"timestamp"`
+		Target    struct {
+			Digest     string `json:"digest"`
+			Repository string `json:"repository"`
+			Tag        string `json:"tag"`
+		} `json:"target"`
+		Request struct {
+			Host string `json:"host"`
+		} `json:"request"`
+	} `json:"events"`
+}
+
+// ParseImagePushEvents parses the push notifications of Harbor, Docker Hub or Docker Distribution.
+// The notifications of other actions, such as pulling or deleting, are ignored.
+func ParseImagePushEvents(payload []byte) (events []*Event, err error) {
+	data := &registryPayload{}
+	if err = json.Unmarshal(payload, data); err != nil {
+		return
+	}
+
+	newEvent := func(host, repository, tag, digest string, eventTime time.Time) *Event {
+		event := &Event{
+			SourceType: v1alpha3.TriggerSourceImagePush,
+			Type:       EventImagePush,
+			Source:     host,
+			Repository: repository,
+			Image:      host + "/" + repository,
+			Tag:        tag,
+			Digest:     digest,
+			Time:       eventTime,
+		}
+		event.SetPayload(payload)
+		return event
+	}
+
+	switch {
+	case data.EventData != nil:
+		if data.Type != "PUSH_ARTIFACT" && data.Type != "pushImage" {
+			return
+		}
+		repository := data.EventData.Repository.RepoFullName
+		for _, resource := range data.EventData.Resources {
+			host := resource.ResourceURL
+			if index := strings.Index(host, "/"); index > 0 {
+				host = host[:index]
+			}
+			events = append(events, newEvent(host, repository, resource.Tag, resource.Digest, time.Unix(data.OccurAt, 0)))
+		}
+	case data.PushData != nil && data.Repository != nil:
+		events = append(events, newEvent("docker.io", data.Repository.RepoName, data.PushData.Tag, "",
+			time.Unix(data.PushData.PushedAt, 0)))
+	case data.Events != nil:
+		for _, item := range data.Events {
+			// the blobs are pushed before the manifest, only the manifest makes a new image
+			if item.Action != "push" || item.Target.Tag == "" {
+				continue
+			}
+			events = append(events, newEvent(item.Request.Host, item.Target.Repository, item.Target.Tag,
+				item.Target.Digest, item.Timestamp))
+		}
+	default:
+		err = ErrUnknownPayload
+	}
+	return
+}
+
+// cloudEventsContentType is the content type of the CloudEvents in the structured mode
+const cloudEventsContentType = "application/cloudevents+json"
+
+// ParseCloudEvent parses a CloudEvent in either the binary or the structured mode of the HTTP protocol binding.
+// The attributes of the event, except the data, are put into the headers with the prefix ce-.
+func ParseCloudEvent(header http.Header, payload []byte) (event *Event, err error) {
+	event = &Event{
+		SourceType: v1alpha3.TriggerSourceCloudEvent,
+		Headers:    map[string]string{},
+	}
+
+	if strings.HasPrefix(header.Get("Content-Type"), cloudEventsContentType) {
+		attributes := map[string]interface{}{}
+		if err = json.Unmarshal(payload, &attributes); err != nil {
+			err = fmt.Errorf("invalid CloudEvent: %v", err)
+			return
+		}
+		for key, value := range attributes {
+			switch key {
+			case "data", "data_base64":
+				event.Body = value
+			default:
+				event.Headers["ce-"+key] = fmt.Sprint(value)
+			}
+		}
+	} else {
+		for key := range header {
+			if lower := strings.ToLower(key); strings.HasPrefix(lower, "ce-") {
+				event.Headers[lower] = header.Get(key)
+			}
+		}
+		event.SetPayload(payload)
+	}
+
+	event.Type = event.Headers["ce-type"]
+	event.Source = event.Headers["ce-source"]
+	if event.Type == "" || event.Source == "" {
+		err = errors.New("invalid CloudEvent: the attributes type and source are required")
+		return
+	}
+	if eventTime, timeErr := time.Parse(time.RFC3339, event.Headers["ce-time"]); timeErr == nil {
+		event.Time = eventTime
+	}
+	return
+}
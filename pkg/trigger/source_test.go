@@ -0,0 +1,98 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseImagePushEvents(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  string
+		expected []string
+		wantErr  bool
+	}{{
+		name: "Harbor",
+		payload: `{"type":"PUSH_ARTIFACT","occur_at":1672628645,"event_data":{
+"resources":[{"digest":"sha256:abc","tag":"v1","resource_url":"harbor.example.com/library/app:v1"}],
+"repository":{"repo_full_name":"library/app"}}}`,
+		expected: []string{"harbor.example.com/library/app:v1@sha256:abc"},
+	}, {
+		name:    "Harbor pulling is ignored",
+		payload: `{"type":"PULL_ARTIFACT","event_data":{"resources":[{"tag":"v1"}]}}`,
+	}, {
+		name:     "Docker Hub",
+		payload:  `{"push_data":{"tag":"latest","pushed_at":1672628645},"repository":{"repo_name":"alice/app"}}`,
+		expected: []string{"docker.io/alice/app:latest@"},
+	}, {
+		name: "Docker Distribution",
+		payload: `{"events":[
+{"action":"push","target":{"digest":"sha256:layer","repository":"app"},"request":{"host":"registry:5000"}},
+{"action":"push","target":{"digest":"sha256:abc","repository":"app","tag":"v2"},"request":{"host":"registry:5000"}},
+{"action":"pull","target":{"digest":"sha256:abc","repository":"app","tag":"v2"},"request":{"host":"registry:5000"}}]}`,
+		expected: []string{"registry:5000/app:v2@sha256:abc"},
+	}, {
+		name:    "unknown",
+		payload: `{"hello":"world"}`,
+		wantErr: true,
+	}, {
+		name:    "not JSON",
+		payload: `hello`,
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := ParseImagePushEvents([]byte(tt.payload))
+			assert.Equal(t, tt.wantErr, err != nil, err)
+			var images []string
+			for _, event := range events {
+				assert.Equal(t, EventImagePush, event.Type)
+				images = append(images, event.Image+":"+event.Tag+"@"+event.Digest)
+			}
+			assert.Equal(t, tt.expected, images)
+		})
+	}
+}
+
+func TestParseCloudEvent(t *testing.T) {
+	header := http.Header{}
+	header.Set("Content-Type", "application/cloudevents+json")
+	event, err := ParseCloudEvent(header, []byte(`{"specversion":"1.0","id":"1","type":"com.example.release",
+"source":"/release","time":"2023-01-02T03:04:05Z","data":{"version":"v1"}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, "com.example.release", event.Type)
+	assert.Equal(t, "/release", event.Source)
+	assert.Equal(t, "1", event.Headers["ce-id"])
+	assert.Equal(t, map[string]interface{}{"version": "v1"}, event.Body)
+	assert.Equal(t, 2023, event.Time.Year())
+
+	header = http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("Ce-Type", "com.example.release")
+	header.Set("Ce-Source", "/release")
+	event, err = ParseCloudEvent(header, []byte(`{"version":"v1"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, "com.example.release", event.Type)
+	assert.Equal(t, map[string]interface{}{"version": "v1"}, event.Body)
+
+	_, err = ParseCloudEvent(http.Header{}, []byte(`{}`))
+	assert.NotNil(t, err)
+}
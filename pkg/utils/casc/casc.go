@@ -0,0 +1,65 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package casc
+
+import "k8s.io/apimachinery/pkg/api/equality"
+
+// SetGlobalConfigFile replaces the config file of the kind with the ID in the section globalConfigFiles of the
+// Jenkins CasC, or removes it if the config is nil. The kind is one of the config file types of the plugin
+// config-file-provider, such as mavenSettings and custom. It returns false if nothing changed.
+func SetGlobalConfigFile(casc map[string]interface{}, kind, id string, config map[string]interface{}) (changed bool) {
+	unclassified, _ := casc["unclassified"].(map[string]interface{})
+	globalConfigFiles, _ := unclassified["globalConfigFiles"].(map[string]interface{})
+	configs, _ := globalConfigFiles["configs"].([]interface{})
+
+	found := false
+	newConfigs := make([]interface{}, 0, len(configs)+1)
+	for _, item := range configs {
+		if itemMap, ok := item.(map[string]interface{}); ok {
+			if existing, ok := itemMap[kind].(map[string]interface{}); ok && existing["id"] == id {
+				found = true
+				if config == nil {
+					changed = true
+					continue
+				}
+				if !equality.Semantic.DeepEqual(existing, config) {
+					changed = true
+					item = map[string]interface{}{kind: config}
+				}
+			}
+		}
+		newConfigs = append(newConfigs, item)
+	}
+	if !found && config != nil {
+		changed = true
+		newConfigs = append(newConfigs, map[string]interface{}{kind: config})
+	}
+	if !changed {
+		return
+	}
+
+	if unclassified == nil {
+		unclassified = map[string]interface{}{}
+		casc["unclassified"] = unclassified
+	}
+	if globalConfigFiles == nil {
+		globalConfigFiles = map[string]interface{}{}
+		unclassified["globalConfigFiles"] = globalConfigFiles
+	}
+	globalConfigFiles["configs"] = newConfigs
+	return
+}
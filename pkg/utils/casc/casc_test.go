@@ -0,0 +1,51 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package casc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+func TestSetGlobalConfigFile(t *testing.T) {
+	casc := map[string]interface{}{}
+	config := map[string]interface{}{"id": "ns-config", "content": "A=b"}
+
+	// add
+	assert.True(t, SetGlobalConfigFile(casc, "custom", "ns-config", config))
+	assert.False(t, SetGlobalConfigFile(casc, "custom", "ns-config", config))
+	assert.True(t, SetGlobalConfigFile(casc, "mavenSettings", "ns-maven", map[string]interface{}{"id": "ns-maven"}))
+
+	// update
+	assert.True(t, SetGlobalConfigFile(casc, "custom", "ns-config", map[string]interface{}{"id": "ns-config", "content": "A=c"}))
+
+	// remove
+	assert.True(t, SetGlobalConfigFile(casc, "mavenSettings", "ns-maven", nil))
+	assert.False(t, SetGlobalConfigFile(casc, "mavenSettings", "ns-maven", nil))
+
+	data, err := yaml.Marshal(casc)
+	assert.Nil(t, err)
+	assert.Equal(t, `unclassified:
+  globalConfigFiles:
+    configs:
+    - custom:
+        content: A=c
+        id: ns-config
+`, string(data))
+}
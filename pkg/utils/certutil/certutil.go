@@ -0,0 +1,119 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certutil
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	admissionregistrationv1 "k8s.io/client-go/kubernetes/typed/admissionregistration/v1"
+	"k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// CertName is the file name of the serving certificate which the webhook server looks up
+	CertName = "tls.crt"
+	// KeyName is the file name of the serving key which the webhook server looks up
+	KeyName = "tls.key"
+)
+
+// DefaultCertDir returns the directory which the webhook server looks up by default
+func DefaultCertDir() string {
+	return filepath.Join(os.TempDir(), "k8s-webhook-server", "serving-certs")
+}
+
+// EnsureSelfSignedCert generates a self-signed certificate for the host into the directory if there is no
+// certificate yet. The generated certificate is returned as the CA bundle, it will be nil if the certificate
+// was provided by others, e.g. cert-manager.
+func EnsureSelfSignedCert(dir, host string, alternateDNS ...string) (caBundle []byte, err error) {
+	if dir == "" {
+		dir = DefaultCertDir()
+	}
+	certPath := filepath.Join(dir, CertName)
+	keyPath := filepath.Join(dir, KeyName)
+	if _, err = os.Stat(certPath); err == nil {
+		return
+	} else if !os.IsNotExist(err) {
+		return
+	}
+
+	var certData, keyData []byte
+	if certData, keyData, err = cert.GenerateSelfSignedCertKey(host, nil, alternateDNS); err != nil {
+		return
+	}
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+	if err = os.WriteFile(keyPath, keyData, 0600); err != nil {
+		return
+	}
+	if err = os.WriteFile(certPath, certData, 0644); err != nil {
+		return
+	}
+	caBundle = certData
+	return
+}
+
+// InjectCABundle sets the CA bundle to all webhooks of the ValidatingWebhookConfiguration
+func InjectCABundle(ctx context.Context, client admissionregistrationv1.ValidatingWebhookConfigurationsGetter,
+	name string, caBundle []byte) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configuration, err := client.ValidatingWebhookConfigurations().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		changed := false
+		for i := range configuration.Webhooks {
+			if !reflect.DeepEqual(configuration.Webhooks[i].ClientConfig.CABundle, caBundle) {
+				configuration.Webhooks[i].ClientConfig.CABundle = caBundle
+				changed = true
+			}
+		}
+		if !changed {
+			return nil
+		}
+		_, err = client.ValidatingWebhookConfigurations().Update(ctx, configuration, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// InjectMutatingCABundle sets the CA bundle to all webhooks of the MutatingWebhookConfiguration
+func InjectMutatingCABundle(ctx context.Context, client admissionregistrationv1.MutatingWebhookConfigurationsGetter,
+	name string, caBundle []byte) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configuration, err := client.MutatingWebhookConfigurations().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		changed := false
+		for i := range configuration.Webhooks {
+			if !reflect.DeepEqual(configuration.Webhooks[i].ClientConfig.CABundle, caBundle) {
+				configuration.Webhooks[i].ClientConfig.CABundle = caBundle
+				changed = true
+			}
+		}
+		if !changed {
+			return nil
+		}
+		_, err = client.MutatingWebhookConfigurations().Update(ctx, configuration, metav1.UpdateOptions{})
+		return err
+	})
+}
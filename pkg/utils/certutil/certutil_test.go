@@ -0,0 +1,80 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certutil
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestEnsureSelfSignedCert(t *testing.T) {
+	dir := t.TempDir()
+
+	caBundle, err := EnsureSelfSignedCert(dir, "webhook.devops.svc")
+	assert.Nil(t, err)
+	assert.NotEmpty(t, caBundle)
+	certData, err := os.ReadFile(filepath.Join(dir, CertName))
+	assert.Nil(t, err)
+	assert.Equal(t, caBundle, certData)
+	_, err = os.Stat(filepath.Join(dir, KeyName))
+	assert.Nil(t, err)
+
+	// the existing certificate should be kept
+	caBundle, err = EnsureSelfSignedCert(dir, "webhook.devops.svc")
+	assert.Nil(t, err)
+	assert.Nil(t, caBundle)
+}
+
+func TestInjectCABundle(t *testing.T) {
+	client := fake.NewSimpleClientset(&v1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "validating"},
+		Webhooks:   []v1.ValidatingWebhook{{Name: "a"}, {Name: "b"}},
+	})
+
+	err := InjectCABundle(context.TODO(), client.AdmissionregistrationV1(), "validating", []byte("ca"))
+	assert.Nil(t, err)
+	configuration, err := client.AdmissionregistrationV1().ValidatingWebhookConfigurations().
+		Get(context.TODO(), "validating", metav1.GetOptions{})
+	assert.Nil(t, err)
+	for _, webhook := range configuration.Webhooks {
+		assert.Equal(t, []byte("ca"), webhook.ClientConfig.CABundle)
+	}
+
+	err = InjectCABundle(context.TODO(), client.AdmissionregistrationV1(), "not-found", []byte("ca"))
+	assert.NotNil(t, err)
+}
+
+func TestInjectMutatingCABundle(t *testing.T) {
+	client := fake.NewSimpleClientset(&v1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "mutating"},
+		Webhooks:   []v1.MutatingWebhook{{Name: "a"}},
+	})
+
+	err := InjectMutatingCABundle(context.TODO(), client.AdmissionregistrationV1(), "mutating", []byte("ca"))
+	assert.Nil(t, err)
+	configuration, err := client.AdmissionregistrationV1().MutatingWebhookConfigurations().
+		Get(context.TODO(), "mutating", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("ca"), configuration.Webhooks[0].ClientConfig.CABundle)
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+// LogMask replaces the values of the credentials in the logs
+const LogMask = "********"
+
+const (
+	// minLogMaskLength is the minimum length of the values to be masked, the shorter ones would make the logs unreadable
+	minLogMaskLength = 4
+	// maxPendingLogSize is the maximum size of an incomplete line which LogMaskWriter holds
+	maxPendingLogSize = 64 * 1024
+	// pemBoundary is the beginning of the boundaries of the PEM blocks
+	pemBoundary = "-----BEGIN "
+)
+
+// LogMasker masks the values of credentials in the logs of PipelineRuns
+type LogMasker struct {
+	replacer *strings.Replacer
+}
+
+// NewLogMasker creates a LogMasker which masks the sensitive data of the given credentials.
+// The PEM blocks, such as private keys, are masked line by line, and the kubeconfigs are masked by their
+// sensitive fields, such as tokens and client keys.
+func NewLogMasker(secrets ...v1.Secret) *LogMasker {
+	values := map[string]bool{}
+	for i := range secrets {
+		for _, key := range credentialSensitiveKeys[secrets[i].Type] {
+			data := secrets[i].Data[key]
+			if secrets[i].Type == v1alpha3.SecretTypeKubeConfig {
+				addKubeConfigMaskValues(values, data)
+			} else {
+				addMaskValues(values, string(data))
+			}
+		}
+	}
+	if len(values) == 0 {
+		return &LogMasker{}
+	}
+
+	// the longer values come first, then they are not masked partially by their substrings
+	sorted := make([]string, 0, len(values))
+	for value := range values {
+		sorted = append(sorted, value)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i]) == len(sorted[j]) {
+			return sorted[i] < sorted[j]
+		}
+		return len(sorted[i]) > len(sorted[j])
+	})
+	oldNew := make([]string, 0, len(sorted)*2)
+	for _, value := range sorted {
+		oldNew = append(oldNew, value, LogMask)
+	}
+	return &LogMasker{replacer: strings.NewReplacer(oldNew...)}
+}
+
+// addMaskValues adds a value to be masked, the PEM blocks are split into lines, because they are usually
+// printed line by line
+func addMaskValues(values map[string]bool, value string) {
+	lines := []string{value}
+	if strings.Contains(value, pemBoundary) {
+		lines = strings.Split(value, "\n")
+	}
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); len(line) >= minLogMaskLength {
+			values[line] = true
+		}
+	}
+}
+
+// addKubeConfigMaskValues adds the sensitive fields of a kubeconfig to be masked, rather than its lines which
+// are mostly generic, such as "apiVersion: v1". The client keys are masked in both the encoded and the PEM form.
+func addKubeConfigMaskValues(values map[string]bool, data []byte) {
+	config, err := clientcmd.Load(data)
+	if err != nil {
+		addMaskValues(values, string(data))
+		return
+	}
+	for _, authInfo := range config.AuthInfos {
+		addMaskValues(values, authInfo.Token)
+		addMaskValues(values, authInfo.Password)
+		if len(authInfo.ClientKeyData) > 0 {
+			addMaskValues(values, base64.StdEncoding.EncodeToString(authInfo.ClientKeyData))
+			addMaskValues(values, string(authInfo.ClientKeyData))
+		}
+	}
+}
+
+// NewLogMaskerForNamespace creates a LogMasker which masks the credentials in the given namespace, all of them are
+// available to the PipelineRuns in it
+func NewLogMaskerForNamespace(ctx context.Context, c client.Reader, namespace string) (*LogMasker, error) {
+	secrets := &v1.SecretList{}
+	if err := c.List(ctx, secrets, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	return NewLogMasker(secrets.Items...), nil
+}
+
+// Mask returns the logs in which the values of credentials are masked
+func (m *LogMasker) Mask(data []byte) []byte {
+	if m == nil || m.replacer == nil {
+		return data
+	}
+	return []byte(m.replacer.Replace(string(data)))
+}
+
+// NewWriter returns a writer which masks the logs before writing them into the given writer
+func (m *LogMasker) NewWriter(writer io.Writer) *LogMaskWriter {
+	return &LogMaskWriter{masker: m, writer: writer}
+}
+
+// LogMaskWriter masks the logs line by line, then a credential will not be missed even if it's split into
+// different writings. The incomplete line is held until the next newline or Flush.
+type LogMaskWriter struct {
+	masker  *LogMasker
+	writer  io.Writer
+	pending []byte
+}
+
+// Write masks and writes all the complete lines
+func (w *LogMaskWriter) Write(data []byte) (n int, err error) {
+	w.pending = append(w.pending, data...)
+	n = len(data)
+
+	end := bytes.LastIndexByte(w.pending, '\n') + 1
+	if end == 0 {
+		if len(w.pending) < maxPendingLogSize {
+			return
+		}
+		end = len(w.pending)
+	}
+	if _, err = w.writer.Write(w.masker.Mask(w.pending[:end])); err == nil {
+		w.pending = append(w.pending[:0], w.pending[end:]...)
+	}
+	return
+}
+
+// Flush masks and writes the incomplete line
+func (w *LogMaskWriter) Flush() (err error) {
+	if len(w.pending) == 0 {
+		return
+	}
+	if _, err = w.writer.Write(w.masker.Mask(w.pending)); err == nil {
+		w.pending = w.pending[:0]
+	}
+	return
+}
@@ -21,30 +21,15 @@ import (
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
 )
 
-type credentialMask func(*v1.Secret) *v1.Secret
-
 var defaultMasque = []byte("")
-var credentialMaskHolder = map[v1.SecretType]credentialMask{}
 
-func basicAuthCredentialMask(secret *v1.Secret) *v1.Secret {
-	secret.Data[v1alpha3.BasicAuthPasswordKey] = defaultMasque
-	return secret
-}
-
-func sshAuthCredentialMask(secret *v1.Secret) *v1.Secret {
-	secret.Data[v1alpha3.SSHAuthPassphraseKey] = defaultMasque
-	secret.Data[v1alpha3.SSHAuthPrivateKey] = defaultMasque
-	return secret
-}
-
-func secretTextCredentialMask(secret *v1.Secret) *v1.Secret {
-	secret.Data[v1alpha3.SecretTextSecretKey] = defaultMasque
-	return secret
-}
-
-func kubeconfigCredentialMask(secret *v1.Secret) *v1.Secret {
-	secret.Data[v1alpha3.KubeConfigSecretKey] = defaultMasque
-	return secret
+// credentialSensitiveKeys holds the keys of the sensitive data of each credential type
+var credentialSensitiveKeys = map[v1.SecretType][]string{
+	v1alpha3.SecretTypeBasicAuth:  {v1alpha3.BasicAuthPasswordKey},
+	v1alpha3.SecretTypeSSHAuth:    {v1alpha3.SSHAuthPassphraseKey, v1alpha3.SSHAuthPrivateKey},
+	v1alpha3.SecretTypeSecretText: {v1alpha3.SecretTextSecretKey},
+	v1alpha3.SecretTypeKubeConfig: {v1alpha3.KubeConfigSecretKey},
+	v1alpha3.SecretTypeCosignKey:  {v1alpha3.CosignPrivateKey, v1alpha3.CosignPasswordKey},
 }
 
 // MaskCredential masks sensetive data inside credential.
@@ -52,17 +37,8 @@ func MaskCredential(secret *v1.Secret) *v1.Secret {
 	if secret == nil || secret.Data == nil {
 		return secret
 	}
-	credentialMask := credentialMaskHolder[secret.Type]
-	if credentialMask != nil {
-		return credentialMask(secret)
+	for _, key := range credentialSensitiveKeys[secret.Type] {
+		secret.Data[key] = defaultMasque
 	}
 	return secret
 }
-
-func init() {
-	// register credential masks
-	credentialMaskHolder[v1alpha3.SecretTypeBasicAuth] = basicAuthCredentialMask
-	credentialMaskHolder[v1alpha3.SecretTypeSSHAuth] = sshAuthCredentialMask
-	credentialMaskHolder[v1alpha3.SecretTypeSecretText] = secretTextCredentialMask
-	credentialMaskHolder[v1alpha3.SecretTypeKubeConfig] = kubeconfigCredentialMask
-}
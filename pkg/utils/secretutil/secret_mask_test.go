@@ -70,6 +70,26 @@ func Test_maskCredential(t *testing.T) {
 				v1alpha3.SSHAuthUsernameKey:   []byte("fake username"),
 			},
 		},
+	}, {
+		name: "Mask cosign key secret",
+		args: args{
+			secret: &v1.Secret{
+				Type: v1alpha3.SecretTypeCosignKey,
+				Data: map[string][]byte{
+					v1alpha3.CosignPrivateKey:  []byte("fake private key"),
+					v1alpha3.CosignPasswordKey: []byte("fake password"),
+					v1alpha3.CosignPublicKey:   []byte("fake public key"),
+				},
+			},
+		},
+		want: &v1.Secret{
+			Type: v1alpha3.SecretTypeCosignKey,
+			Data: map[string][]byte{
+				v1alpha3.CosignPrivateKey:  []byte(""),
+				v1alpha3.CosignPasswordKey: []byte(""),
+				v1alpha3.CosignPublicKey:   []byte("fake public key"),
+			},
+		},
 	}, {
 		name: "Mask secret text secret",
 		args: args{
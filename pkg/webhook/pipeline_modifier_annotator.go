@@ -0,0 +1,78 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/utils"
+)
+
+//+kubebuilder:webhook:path=/mutate-devops-kubesphere-io-v1alpha3-pipeline,mutating=true,failurePolicy=fail,sideEffects=None,groups=devops.kubesphere.io,resources=pipelines,verbs=create;update,versions=v1alpha3,name=mpipeline.devops.kubesphere.io,admissionReviewVersions=v1
+
+// PipelineModifierAnnotatorPath is the path of the webhook which records the user who changed the Pipeline spec
+const PipelineModifierAnnotatorPath = "/mutate-devops-kubesphere-io-v1alpha3-pipeline"
+
+// serviceAccountPrefix is the prefix of the usernames of the service accounts
+const serviceAccountPrefix = "system:serviceaccount:"
+
+// PipelineModifierAnnotator sets the annotation PipelineModifiedByAnnoKey when the Pipeline spec is changed, the
+// annotation is taken as the author of the PipelineRevision. The service accounts keep the annotation, since they
+// either act on behalf of the users, such as the DevOps apiserver, or derive the change from the last one of the users.
+type PipelineModifierAnnotator struct{}
+
+var _ admission.Handler = &PipelineModifierAnnotator{}
+
+// Handle implements admission.Handler
+func (a *PipelineModifierAnnotator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	pipeline := &v1alpha3.Pipeline{}
+	if err := json.Unmarshal(req.Object.Raw, pipeline); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if req.Operation == admissionv1.Update {
+		oldPipeline := &v1alpha3.Pipeline{}
+		if err := json.Unmarshal(req.OldObject.Raw, oldPipeline); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if utils.ComputeHash(oldPipeline.Spec) == utils.ComputeHash(pipeline.Spec) {
+			return admission.Allowed("")
+		}
+	}
+
+	modifier, ok := pipeline.Annotations[v1alpha3.PipelineModifiedByAnnoKey]
+	if (ok && strings.HasPrefix(req.UserInfo.Username, serviceAccountPrefix)) || modifier == req.UserInfo.Username {
+		return admission.Allowed("")
+	}
+	if pipeline.Annotations == nil {
+		pipeline.Annotations = map[string]string{}
+	}
+	pipeline.Annotations[v1alpha3.PipelineModifiedByAnnoKey] = req.UserInfo.Username
+
+	data, err := json.Marshal(pipeline)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, data)
+}
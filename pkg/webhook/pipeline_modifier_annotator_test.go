@@ -0,0 +1,110 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+func TestPipelineModifierAnnotator_Handle(t *testing.T) {
+	newPipeline := func(jenkinsfile, modifier string) runtime.RawExtension {
+		pipeline := &v1alpha3.Pipeline{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "build"},
+			Spec: v1alpha3.PipelineSpec{
+				Type:     v1alpha3.NoScmPipelineType,
+				Pipeline: &v1alpha3.NoScmPipeline{Name: "build", Jenkinsfile: jenkinsfile},
+			},
+		}
+		if modifier != "" {
+			pipeline.Annotations = map[string]string{v1alpha3.PipelineModifiedByAnnoKey: modifier}
+		}
+		data, _ := json.Marshal(pipeline)
+		return runtime.RawExtension{Raw: data}
+	}
+
+	tests := []struct {
+		name         string
+		operation    admissionv1.Operation
+		username     string
+		object       runtime.RawExtension
+		oldObject    runtime.RawExtension
+		wantModifier string
+	}{{
+		name:         "create a Pipeline",
+		operation:    admissionv1.Create,
+		username:     "alice",
+		object:       newPipeline("a", ""),
+		wantModifier: "alice",
+	}, {
+		name:      "update the annotations only",
+		operation: admissionv1.Update,
+		username:  "bob",
+		object:    newPipeline("a", "alice"),
+		oldObject: newPipeline("a", "alice"),
+	}, {
+		name:         "update the spec",
+		operation:    admissionv1.Update,
+		username:     "bob",
+		object:       newPipeline("b", "alice"),
+		oldObject:    newPipeline("a", "alice"),
+		wantModifier: "bob",
+	}, {
+		name:      "update the spec on behalf of a user",
+		operation: admissionv1.Update,
+		username:  "system:serviceaccount:kubesphere-devops-system:devops-apiserver",
+		object:    newPipeline("b", "bob"),
+		oldObject: newPipeline("a", "alice"),
+	}, {
+		name:         "update the spec by a service account",
+		operation:    admissionv1.Update,
+		username:     "system:serviceaccount:kubesphere-devops-system:devops-controller",
+		object:       newPipeline("b", ""),
+		oldObject:    newPipeline("a", ""),
+		wantModifier: "system:serviceaccount:kubesphere-devops-system:devops-controller",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := (&PipelineModifierAnnotator{}).Handle(context.Background(), admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Operation: tt.operation,
+					UserInfo:  authenticationv1.UserInfo{Username: tt.username},
+					Object:    tt.object,
+					OldObject: tt.oldObject,
+				}})
+			assert.True(t, resp.Allowed, resp.Result)
+			if tt.wantModifier == "" {
+				assert.Empty(t, resp.Patches)
+				return
+			}
+			if assert.Len(t, resp.Patches, 1) {
+				assert.Contains(t, resp.Patches[0].Path, "/metadata/annotations")
+				assert.Contains(t, resp.Patches[0].Json(), tt.wantModifier)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"kubesphere.io/devops/pkg/policy"
+)
+
+//+kubebuilder:webhook:path=/validate-devops-kubesphere-io-v1alpha3-pipeline-policy,mutating=false,failurePolicy=fail,sideEffects=None,groups=devops.kubesphere.io,resources=pipelines;pipelineruns,verbs=create;update,versions=v1alpha3,name=ppipeline.devops.kubesphere.io,admissionReviewVersions=v1
+
+// PipelinePolicyValidatorPath is the path of the webhook which evaluates the PipelinePolicies
+const PipelinePolicyValidatorPath = "/validate-devops-kubesphere-io-v1alpha3-pipeline-policy"
+
+// PipelinePolicyValidator rejects the created or updated Pipelines and PipelineRuns which violate the
+// PipelinePolicies, or warns about the violations according to the actions of the policies. The objects being
+// deleted are always allowed, then their finalizers are able to be removed.
+type PipelinePolicyValidator struct {
+	Evaluator *policy.Evaluator
+}
+
+var _ admission.Handler = &PipelinePolicyValidator{}
+
+// Handle implements admission.Handler
+func (v *PipelinePolicyValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	object := map[string]interface{}{}
+	if err := json.Unmarshal(req.Object.Raw, &object); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if metadata, _ := object["metadata"].(map[string]interface{}); metadata["deletionTimestamp"] != nil {
+		return admission.Allowed("")
+	}
+
+	result, err := v.Evaluator.Evaluate(ctx, &policy.Input{
+		Object:    object,
+		Kind:      req.Kind.Kind,
+		Namespace: req.Namespace,
+		Operation: string(req.Operation),
+		Username:  req.UserInfo.Username,
+	})
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if len(result.Denials) > 0 {
+		return admission.Denied(strings.Join(result.Denials, "; ")).WithWarnings(result.Warnings...)
+	}
+	return admission.Allowed("").WithWarnings(result.Warnings...)
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/policy"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestPipelinePolicyValidator_Handle(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1.AddToScheme(schema))
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	validator := &PipelinePolicyValidator{Evaluator: &policy.Evaluator{
+		Reader: fake.NewClientBuilder().WithScheme(schema).WithObjects(&v1alpha3.PipelinePolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "namespaces"},
+			Spec: v1alpha3.PipelinePolicySpec{
+				Kinds: []string{v1alpha3.ResourceKindPipelineRun},
+				Rules: []v1alpha3.PolicyRule{{
+					Name:    "same-namespace",
+					CEL:     `!has(object.spec.pipelineRef) || object.spec.pipelineRef.namespace == request.namespace`,
+					Message: "the Pipeline should be in the same namespace",
+				}},
+			},
+		}, &v1alpha3.PipelinePolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "owners"},
+			Spec: v1alpha3.PipelinePolicySpec{
+				Action: v1alpha3.PolicyActionWarn,
+				Rules:  []v1alpha3.PolicyRule{{Name: "admin", CEL: `request.username != "admin"`, Message: "avoid the admin"}},
+			},
+		}).Build(),
+	}}
+
+	tests := []struct {
+		name         string
+		object       string
+		operation    admissionv1.Operation
+		username     string
+		wantAllowed  bool
+		wantWarnings []string
+	}{{
+		name:        "allowed",
+		object:      `{"spec": {"pipelineRef": {"namespace": "dev", "name": "build"}}}`,
+		wantAllowed: true,
+	}, {
+		name:   "denied",
+		object: `{"spec": {"pipelineRef": {"namespace": "prod", "name": "build"}}}`,
+	}, {
+		name:         "warned",
+		object:       `{"spec": {}}`,
+		username:     "admin",
+		wantAllowed:  true,
+		wantWarnings: []string{"[owners/admin] avoid the admin"},
+	}, {
+		name:      "denied when updating",
+		object:    `{"spec": {"pipelineRef": {"namespace": "prod", "name": "build"}}}`,
+		operation: admissionv1.Update,
+	}, {
+		name: "allowed when deleting",
+		object: `{"metadata": {"deletionTimestamp": "2023-01-02T03:04:05Z"},
+			"spec": {"pipelineRef": {"namespace": "prod", "name": "build"}}}`,
+		operation:   admissionv1.Update,
+		wantAllowed: true,
+	}, {
+		name:   "invalid object",
+		object: `{`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := admissionv1.AdmissionRequest{
+				Kind:      metav1.GroupVersionKind{Kind: v1alpha3.ResourceKindPipelineRun},
+				Namespace: "dev",
+				Operation: tt.operation,
+			}
+			if request.Operation == "" {
+				request.Operation = admissionv1.Create
+			}
+			request.Object.Raw = []byte(tt.object)
+			request.UserInfo.Username = tt.username
+			resp := validator.Handle(context.Background(), admission.Request{AdmissionRequest: request})
+			assert.Equal(t, tt.wantAllowed, resp.Allowed, resp.Result)
+			assert.Equal(t, tt.wantWarnings, resp.Warnings)
+		})
+	}
+}
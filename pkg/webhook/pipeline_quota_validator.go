@@ -0,0 +1,60 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"kubesphere.io/devops/pkg/quota"
+)
+
+//+kubebuilder:webhook:path=/validate-devops-kubesphere-io-v1alpha3-pipeline-quota,mutating=false,failurePolicy=fail,sideEffects=None,groups=devops.kubesphere.io,resources=pipelines,verbs=create,versions=v1alpha3,name=qpipeline.devops.kubesphere.io,admissionReviewVersions=v1
+
+// PipelineQuotaValidatorPath is the path of the webhook which checks the Pipeline quota of DevOpsProjects
+const PipelineQuotaValidatorPath = "/validate-devops-kubesphere-io-v1alpha3-pipeline-quota"
+
+// PipelineQuotaValidator rejects the new Pipelines which exceed the quota of the DevOpsProject
+type PipelineQuotaValidator struct {
+	client.Reader
+}
+
+var _ admission.Handler = &PipelineQuotaValidator{}
+
+// Handle implements admission.Handler
+func (v *PipelineQuotaValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	projectQuota, err := quota.GetProjectQuota(ctx, v.Reader, req.Namespace)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if projectQuota == nil || projectQuota.MaxPipelines == 0 {
+		return admission.Allowed("")
+	}
+
+	usage, err := quota.GetUsage(ctx, v.Reader, req.Namespace, time.Now())
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if err = quota.CheckPipeline(projectQuota, usage); err != nil {
+		return admission.Denied(err.Error())
+	}
+	return admission.Allowed("")
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/constants"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestPipelineQuotaValidator_Handle(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1.AddToScheme(schema))
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	newNamespace := func(name, project string) *v1.Namespace {
+		ns := &v1.Namespace{}
+		ns.SetName(name)
+		ns.SetLabels(map[string]string{constants.DevOpsProjectLabelKey: project})
+		return ns
+	}
+	limited := &v1alpha3.DevOpsProject{ObjectMeta: metav1.ObjectMeta{Name: "limited"}}
+	limited.Spec.Quota = &v1alpha3.ProjectQuota{MaxPipelines: 1}
+	unlimited := &v1alpha3.DevOpsProject{ObjectMeta: metav1.ObjectMeta{Name: "unlimited"}}
+	validator := &PipelineQuotaValidator{Reader: fake.NewClientBuilder().WithScheme(schema).WithObjects(
+		newNamespace("limited-ns", "limited"), newNamespace("unlimited-ns", "unlimited"), limited, unlimited,
+		&v1alpha3.Pipeline{ObjectMeta: metav1.ObjectMeta{Namespace: "limited-ns", Name: "a"}},
+		&v1alpha3.Pipeline{ObjectMeta: metav1.ObjectMeta{Namespace: "unlimited-ns", Name: "a"}},
+	).Build()}
+
+	tests := []struct {
+		namespace   string
+		wantAllowed bool
+	}{{
+		namespace:   "limited-ns",
+		wantAllowed: false,
+	}, {
+		namespace:   "unlimited-ns",
+		wantAllowed: true,
+	}, {
+		namespace:   "plain-ns",
+		wantAllowed: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.namespace, func(t *testing.T) {
+			resp := validator.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Namespace: tt.namespace,
+				Operation: admissionv1.Create,
+			}})
+			assert.Equal(t, tt.wantAllowed, resp.Allowed, resp.Result)
+		})
+	}
+}
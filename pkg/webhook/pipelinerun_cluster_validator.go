@@ -0,0 +1,74 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/multicluster"
+)
+
+//+kubebuilder:webhook:path=/validate-devops-kubesphere-io-v1alpha3-pipelinerun-cluster,mutating=false,failurePolicy=fail,sideEffects=None,groups=devops.kubesphere.io,resources=pipelineruns,verbs=create;update,versions=v1alpha3,name=cpipelinerun.devops.kubesphere.io,admissionReviewVersions=v1
+
+// PipelineRunClusterValidatorPath is the path of the webhook which checks the ClusterTargets of PipelineRuns
+const PipelineRunClusterValidatorPath = "/validate-devops-kubesphere-io-v1alpha3-pipelinerun-cluster"
+
+// PipelineRunClusterValidator rejects the PipelineRuns which target a member cluster out of the placement of
+// their DevOpsProject
+type PipelineRunClusterValidator struct {
+	client.Reader
+}
+
+var _ admission.Handler = &PipelineRunClusterValidator{}
+
+// Handle implements admission.Handler
+func (v *PipelineRunClusterValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	pipelineRun := &v1alpha3.PipelineRun{}
+	if err := json.Unmarshal(req.Object.Raw, pipelineRun); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if !pipelineRun.Spec.IsRemote() {
+		return admission.Allowed("")
+	}
+	if req.Operation == admissionv1.Update {
+		// the existing PipelineRuns should be able to be updated, such as removing the finalizers
+		old := &v1alpha3.PipelineRun{}
+		if err := json.Unmarshal(req.OldObject.Raw, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if reflect.DeepEqual(old.Spec.ClusterTarget, pipelineRun.Spec.ClusterTarget) {
+			return admission.Allowed("")
+		}
+	}
+
+	if err := multicluster.CheckPlacement(ctx, v.Reader, req.Namespace, pipelineRun.Spec.ClusterTarget.Name); err != nil {
+		if errors.Is(err, multicluster.ErrNotPlaced) {
+			return admission.Denied(err.Error())
+		}
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.Allowed("")
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/constants"
+)
+
+func TestPipelineRunClusterValidator_Handle(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1.AddToScheme(schema))
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	namespace := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "ns",
+		Labels: map[string]string{constants.DevOpsProjectLabelKey: "project"},
+	}}
+	project := &v1alpha3.DevOpsProject{
+		ObjectMeta: metav1.ObjectMeta{Name: "project"},
+		Spec:       v1alpha3.DevOpsProjectSpec{Placement: &v1alpha3.ProjectPlacement{Clusters: []string{"member"}}},
+	}
+	validator := &PipelineRunClusterValidator{
+		Reader: fake.NewClientBuilder().WithScheme(schema).WithObjects(namespace, project).Build(),
+	}
+	newRawPipelineRun := func(cluster string) runtime.RawExtension {
+		pipelineRun := &v1alpha3.PipelineRun{}
+		if cluster != "" {
+			pipelineRun.Spec.ClusterTarget = &v1alpha3.ClusterTarget{Name: cluster}
+		}
+		data, err := json.Marshal(pipelineRun)
+		assert.Nil(t, err)
+		return runtime.RawExtension{Raw: data}
+	}
+
+	tests := []struct {
+		name        string
+		namespace   string
+		operation   admissionv1.Operation
+		cluster     string
+		oldCluster  string
+		wantAllowed bool
+	}{{
+		name:        "run on the current cluster",
+		namespace:   "ns",
+		operation:   admissionv1.Create,
+		wantAllowed: true,
+	}, {
+		name:        "placed cluster",
+		namespace:   "ns",
+		operation:   admissionv1.Create,
+		cluster:     "member",
+		wantAllowed: true,
+	}, {
+		name:      "cluster out of the placement",
+		namespace: "ns",
+		operation: admissionv1.Create,
+		cluster:   "other",
+	}, {
+		name:      "namespace without a DevOpsProject",
+		namespace: "plain-ns",
+		operation: admissionv1.Create,
+		cluster:   "member",
+	}, {
+		name:       "change the cluster to one out of the placement",
+		namespace:  "ns",
+		operation:  admissionv1.Update,
+		cluster:    "other",
+		oldCluster: "member",
+	}, {
+		name:        "update without changing the cluster",
+		namespace:   "ns",
+		operation:   admissionv1.Update,
+		cluster:     "other",
+		oldCluster:  "other",
+		wantAllowed: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := validator.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Namespace: tt.namespace,
+				Operation: tt.operation,
+				Object:    newRawPipelineRun(tt.cluster),
+				OldObject: newRawPipelineRun(tt.oldCluster),
+			}})
+			assert.Equal(t, tt.wantAllowed, resp.Allowed, resp.Result)
+		})
+	}
+}
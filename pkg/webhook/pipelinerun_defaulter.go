@@ -0,0 +1,214 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/backend"
+	"kubesphere.io/devops/pkg/constants"
+)
+
+//+kubebuilder:webhook:path=/mutate-devops-kubesphere-io-v1alpha3-pipelinerun,mutating=true,failurePolicy=fail,sideEffects=None,groups=devops.kubesphere.io,resources=pipelineruns,verbs=create,versions=v1alpha3,name=mpipelinerun.devops.kubesphere.io,admissionReviewVersions=v1
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=devopsprojectconfigs,verbs=get;list;watch
+
+// PipelineRunDefaulter fills the fields of PipelineRun which could be derived from its Pipeline and DevOpsProject,
+// so that users are able to submit a minimal PipelineRun which only has a PipelineRef. It records the identity of
+// the user who created the PipelineRun as well.
+type PipelineRunDefaulter struct {
+	client.Reader
+
+	// Router finds out the backend of the PipelineRuns without a Pipeline backend label
+	Router *backend.Router
+	// APIServerServiceAccount is the username of the DevOps apiserver, such as
+	// system:serviceaccount:kubesphere-devops-system:devops-apiserver. It's the only one which is able to record the
+	// identity of other users, the identity given by anyone else is overwritten.
+	APIServerServiceAccount string
+}
+
+var _ admission.CustomDefaulter = &PipelineRunDefaulter{}
+
+// Default implements admission.CustomDefaulter
+func (d *PipelineRunDefaulter) Default(ctx context.Context, obj runtime.Object) (err error) {
+	pr, ok := obj.(*v1alpha3.PipelineRun)
+	if !ok {
+		return fmt.Errorf("expected a PipelineRun but got a %T", obj)
+	}
+	if pr.Labels == nil {
+		pr.Labels = map[string]string{}
+	}
+	// the namespace of the object could be empty if it's omitted in the manifest
+	namespace := pr.Namespace
+	if req, reqErr := admission.RequestFromContext(ctx); reqErr == nil {
+		if namespace == "" {
+			namespace = req.Namespace
+		}
+		setTriggerIdentity(pr, req.UserInfo, d.APIServerServiceAccount)
+	}
+
+	if ref := pr.Spec.PipelineRef; ref != nil && ref.Name != "" {
+		if ref.Namespace == "" {
+			ref.Namespace = namespace
+		}
+		if ref.Kind == "" {
+			ref.Kind = v1alpha3.ResourceKindPipeline
+		}
+		if pr.Name == "" && pr.GenerateName == "" {
+			// the name should be like "pipeline-xyzmnt", which is the same as the PipelineRuns created by API
+			pr.GenerateName = ref.Name + "-"
+		}
+		pr.Labels[v1alpha3.PipelineNameLabelKey] = ref.Name
+
+		pipeline := &v1alpha3.Pipeline{}
+		if err = d.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, pipeline); err != nil {
+			// the validating webhook or the controller will take care of the missing Pipeline
+			return client.IgnoreNotFound(err)
+		}
+		setPipelineDefaults(pr, pipeline, namespace)
+		if err = d.setArtifactRepositoryParameters(ctx, pr); err != nil {
+			return
+		}
+	}
+	if err = d.setProjectConfigParameters(ctx, pr, namespace); err != nil {
+		return
+	}
+	if err = d.setProjectLabels(ctx, pr, namespace); err != nil {
+		return
+	}
+
+	if _, ok := backend.TypeOf(pr); !ok && d.Router != nil {
+		var backendType backend.Type
+		if backendType, err = d.Router.BackendOf(ctx, namespace); err != nil {
+			return
+		}
+		if backendType != "" {
+			pr.Labels[v1alpha3.PipelineBackendLabelKey] = string(backendType)
+		}
+	}
+	return
+}
+
+// setPipelineDefaults fills the fields of the PipelineRun from its Pipeline
+func setPipelineDefaults(pr *v1alpha3.PipelineRun, pipeline *v1alpha3.Pipeline, namespace string) {
+	if metav1.GetControllerOf(pr) == nil && namespace == pipeline.Namespace {
+		pr.OwnerReferences = append(pr.OwnerReferences,
+			*metav1.NewControllerRef(pipeline, v1alpha3.GroupVersion.WithKind(v1alpha3.ResourceKindPipeline)))
+	}
+	if pr.Spec.PipelineSpec == nil {
+		pr.Spec.PipelineSpec = pipeline.Spec.DeepCopy()
+	}
+	// the PipelineRun should be handled by the same backend as its Pipeline
+	if backendType, ok := pipeline.Labels[v1alpha3.PipelineBackendLabelKey]; ok {
+		if _, exist := pr.Labels[v1alpha3.PipelineBackendLabelKey]; !exist {
+			pr.Labels[v1alpha3.PipelineBackendLabelKey] = backendType
+		}
+	}
+}
+
+// setProjectConfigParameters passes the parameters of the DevOpsProjectConfig to the PipelineRun, the parameters
+// given by users and the ones of the ArtifactRepository take precedence
+func (d *PipelineRunDefaulter) setProjectConfigParameters(ctx context.Context, pr *v1alpha3.PipelineRun, namespace string) (err error) {
+	config := &v1alpha3.DevOpsProjectConfig{}
+	if err = d.Get(ctx, types.NamespacedName{Namespace: namespace, Name: v1alpha3.DevOpsProjectConfigName}, config); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	pr.Spec.Parameters = appendMissingParameters(pr.Spec.Parameters, config.GetParameters())
+	return
+}
+
+// setProjectLabels copies the labels of the DevOpsProject to the PipelineRun, the existing labels take precedence
+func (d *PipelineRunDefaulter) setProjectLabels(ctx context.Context, pr *v1alpha3.PipelineRun, namespace string) (err error) {
+	ns := &v1.Namespace{}
+	if err = d.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	projectName := ns.GetLabels()[constants.DevOpsProjectLabelKey]
+	if projectName == "" {
+		return
+	}
+
+	project := &v1alpha3.DevOpsProject{}
+	if err = d.Get(ctx, types.NamespacedName{Name: projectName}, project); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	for key, value := range project.GetLabels() {
+		if _, exist := pr.Labels[key]; !exist {
+			pr.Labels[key] = value
+		}
+	}
+	return
+}
+
+// appendMissingParameters appends the parameters whose names are not given yet
+func appendMissingParameters(given, parameters []v1alpha3.Parameter) []v1alpha3.Parameter {
+	names := map[string]bool{}
+	for _, parameter := range given {
+		names[parameter.Name] = true
+	}
+	for _, parameter := range parameters {
+		if !names[parameter.Name] {
+			names[parameter.Name] = true
+			given = append(given, parameter)
+		}
+	}
+	return given
+}
+
+// setTriggerIdentity records the user who created the PipelineRun. Only the DevOps apiserver keeps the recorded
+// identity, since it creates the PipelineRuns on behalf of the users whom it has verified.
+func setTriggerIdentity(pr *v1alpha3.PipelineRun, userInfo authenticationv1.UserInfo, apiServerServiceAccount string) {
+	if apiServerServiceAccount != "" && userInfo.Username == apiServerServiceAccount && pr.GetTriggerIdentity() != nil {
+		return
+	}
+	delete(pr.Annotations, v1alpha3.PipelineRunIdentityAnnoKey)
+	if userInfo.Username == "" {
+		return
+	}
+	pr.SetTriggerIdentity(&v1alpha3.TriggerIdentity{
+		Username: userInfo.Username,
+		Groups:   userInfo.Groups,
+		Source:   v1alpha3.TriggerIdentityKubernetes,
+	})
+}
+
+// setArtifactRepositoryParameters passes the parameters of the ArtifactRepository used by the Pipeline to the
+// PipelineRun, the parameters given by users take precedence
+func (d *PipelineRunDefaulter) setArtifactRepositoryParameters(ctx context.Context, pr *v1alpha3.PipelineRun) (err error) {
+	if pr.Spec.PipelineSpec == nil || pr.Spec.PipelineSpec.ArtifactRepository == "" {
+		return
+	}
+	repo := &v1alpha3.ArtifactRepository{}
+	if err = d.Get(ctx, types.NamespacedName{
+		Namespace: pr.Spec.PipelineRef.Namespace,
+		Name:      pr.Spec.PipelineSpec.ArtifactRepository,
+	}, repo); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	pr.Spec.Parameters = appendMissingParameters(pr.Spec.Parameters, repo.GetParameters())
+	return
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/backend"
+	"kubesphere.io/devops/pkg/constants"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestPipelineRunDefaulter_Default(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1.AddToScheme(schema))
+	assert.Nil(t, v1alpha3.AddToScheme(schema))
+
+	ns := &v1.Namespace{}
+	ns.SetName("ns")
+	ns.SetLabels(map[string]string{constants.DevOpsProjectLabelKey: "project"})
+	project := &v1alpha3.DevOpsProject{}
+	project.SetName("project")
+	project.SetLabels(map[string]string{"kubesphere.io/workspace": "ws", "team": "devops"})
+	project.Spec.PipelineBackend = string(backend.Tekton)
+
+	pipeline := &v1alpha3.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pipeline", UID: "uid"},
+		Spec:       v1alpha3.PipelineSpec{Type: v1alpha3.NoScmPipelineType, Pipeline: &v1alpha3.NoScmPipeline{Name: "pipeline"}},
+	}
+	labeledPipeline := pipeline.DeepCopy()
+	labeledPipeline.Name = "labeled"
+	labeledPipeline.Labels = map[string]string{v1alpha3.PipelineBackendLabelKey: string(backend.Jenkins)}
+	artifactPipeline := pipeline.DeepCopy()
+	artifactPipeline.Name = "artifact"
+	artifactPipeline.Spec.ArtifactRepository = "nexus"
+	artifactRepository := &v1alpha3.ArtifactRepository{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "nexus"},
+		Spec: v1alpha3.ArtifactRepositorySpec{
+			Type:       v1alpha3.ArtifactRepositoryNexus,
+			Server:     "https://nexus.example.com",
+			Repository: "maven-public",
+			Format:     v1alpha3.ArtifactFormatMaven,
+			Secret:     "nexus",
+			Proxy:      &v1alpha3.ArtifactRepositoryProxy{URL: "http://proxy.example.com:3128"},
+		},
+	}
+	projectConfig := &v1alpha3.DevOpsProjectConfig{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: v1alpha3.DevOpsProjectConfigName},
+		Spec: v1alpha3.DevOpsProjectConfigSpec{
+			Registry:   "harbor.example.com",
+			Proxy:      &v1alpha3.ArtifactRepositoryProxy{URL: "http://project:3128", NoProxy: []string{"harbor.example.com"}},
+			Parameters: []v1alpha3.Parameter{{Name: "REGION", Value: "east"}},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		pr     *v1alpha3.PipelineRun
+		verify func(t *testing.T, pr *v1alpha3.PipelineRun)
+	}{{
+		name: "minimal PipelineRun",
+		pr: &v1alpha3.PipelineRun{
+			Spec: v1alpha3.PipelineRunSpec{PipelineRef: &v1.ObjectReference{Name: "pipeline"}},
+		},
+		verify: func(t *testing.T, pr *v1alpha3.PipelineRun) {
+			assert.Equal(t, "pipeline-", pr.GenerateName)
+			assert.Equal(t, "ns", pr.Spec.PipelineRef.Namespace)
+			assert.Equal(t, v1alpha3.ResourceKindPipeline, pr.Spec.PipelineRef.Kind)
+			assert.Equal(t, "pipeline", pr.Labels[v1alpha3.PipelineNameLabelKey])
+			assert.Equal(t, string(backend.Tekton), pr.Labels[v1alpha3.PipelineBackendLabelKey])
+			assert.Equal(t, "ws", pr.Labels["kubesphere.io/workspace"])
+			assert.Equal(t, "devops", pr.Labels["team"])
+			assert.Equal(t, &pipeline.Spec, pr.Spec.PipelineSpec)
+			if assert.NotNil(t, metav1.GetControllerOf(pr)) {
+				assert.Equal(t, pipeline.UID, metav1.GetControllerOf(pr).UID)
+			}
+		},
+	}, {
+		name: "backend label from the Pipeline",
+		pr: &v1alpha3.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{Name: "run", Namespace: "ns", Labels: map[string]string{"team": "qa"}},
+			Spec:       v1alpha3.PipelineRunSpec{PipelineRef: &v1.ObjectReference{Name: "labeled"}},
+		},
+		verify: func(t *testing.T, pr *v1alpha3.PipelineRun) {
+			assert.Equal(t, "", pr.GenerateName)
+			assert.Equal(t, string(backend.Jenkins), pr.Labels[v1alpha3.PipelineBackendLabelKey])
+			// the project labels never overwrite the existing ones
+			assert.Equal(t, "qa", pr.Labels["team"])
+			assert.Equal(t, "ws", pr.Labels["kubesphere.io/workspace"])
+		},
+	}, {
+		name: "Pipeline not found",
+		pr: &v1alpha3.PipelineRun{
+			Spec: v1alpha3.PipelineRunSpec{PipelineRef: &v1.ObjectReference{Name: "not-found"}},
+		},
+		verify: func(t *testing.T, pr *v1alpha3.PipelineRun) {
+			assert.Equal(t, "not-found-", pr.GenerateName)
+			assert.Nil(t, pr.Spec.PipelineSpec)
+			assert.Nil(t, metav1.GetControllerOf(pr))
+		},
+	}, {
+		name: "parameters of the ArtifactRepository",
+		pr: &v1alpha3.PipelineRun{
+			Spec: v1alpha3.PipelineRunSpec{
+				PipelineRef: &v1.ObjectReference{Name: "artifact"},
+				Parameters:  []v1alpha3.Parameter{{Name: v1alpha3.HTTPProxyParameter, Value: "http://other:8080"}},
+			},
+		},
+		verify: func(t *testing.T, pr *v1alpha3.PipelineRun) {
+			assert.Equal(t, []v1alpha3.Parameter{
+				{Name: v1alpha3.HTTPProxyParameter, Value: "http://other:8080"},
+				{Name: v1alpha3.ArtifactRepositoryURLParameter, Value: "https://nexus.example.com/repository/maven-public"},
+				{Name: v1alpha3.ArtifactRepositoryFormatParameter, Value: v1alpha3.ArtifactFormatMaven},
+				{Name: v1alpha3.ArtifactRepositoryCredentialParameter, Value: "nexus"},
+				{Name: v1alpha3.ArtifactRepositorySettingsParameter, Value: "ns-nexus"},
+				{Name: v1alpha3.HTTPSProxyParameter, Value: "http://proxy.example.com:3128"},
+				{Name: "REGION", Value: "east"},
+				{Name: v1alpha3.ImageRegistryParameter, Value: "harbor.example.com"},
+				{Name: v1alpha3.NoProxyParameter, Value: "harbor.example.com"},
+			}, pr.Spec.Parameters)
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := fake.NewClientBuilder().WithScheme(schema).
+				WithObjects([]client.Object{ns, project, pipeline, labeledPipeline, artifactPipeline, artifactRepository,
+					projectConfig}...).Build()
+			defaulter := &PipelineRunDefaulter{Reader: reader, Router: backend.NewRouter(reader, backend.Jenkins)}
+			ctx := admission.NewContextWithRequest(context.TODO(), admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{Namespace: "ns"},
+			})
+
+			err := defaulter.Default(ctx, tt.pr)
+			assert.Nil(t, err)
+			tt.verify(t, tt.pr)
+		})
+	}
+
+	err := (&PipelineRunDefaulter{}).Default(context.TODO(), &v1alpha3.Pipeline{})
+	assert.NotNil(t, err)
+}
+
+func TestSetTriggerIdentity(t *testing.T) {
+	const apiServerServiceAccount = "system:serviceaccount:kubesphere-devops-system:devops-apiserver"
+	recorded := &v1alpha3.TriggerIdentity{Username: "alice", Groups: []string{"dev"}, Source: v1alpha3.TriggerIdentityToken}
+	tests := []struct {
+		name     string
+		recorded *v1alpha3.TriggerIdentity
+		userInfo authenticationv1.UserInfo
+		expect   *v1alpha3.TriggerIdentity
+	}{{
+		name:     "created by a user",
+		userInfo: authenticationv1.UserInfo{Username: "bob", Groups: []string{"ops"}},
+		expect:   &v1alpha3.TriggerIdentity{Username: "bob", Groups: []string{"ops"}, Source: v1alpha3.TriggerIdentityKubernetes},
+	}, {
+		name:     "a user cannot forge the identity",
+		recorded: recorded,
+		userInfo: authenticationv1.UserInfo{Username: "bob"},
+		expect:   &v1alpha3.TriggerIdentity{Username: "bob", Source: v1alpha3.TriggerIdentityKubernetes},
+	}, {
+		name:     "created by the apiserver on behalf of a user",
+		recorded: recorded,
+		userInfo: authenticationv1.UserInfo{Username: apiServerServiceAccount},
+		expect:   recorded,
+	}, {
+		name:     "created by the apiserver without an identity",
+		userInfo: authenticationv1.UserInfo{Username: apiServerServiceAccount},
+		expect:   &v1alpha3.TriggerIdentity{Username: apiServerServiceAccount, Source: v1alpha3.TriggerIdentityKubernetes},
+	}, {
+		name:     "other service accounts cannot forge the identity",
+		recorded: recorded,
+		userInfo: authenticationv1.UserInfo{Username: "system:serviceaccount:ns:default"},
+		expect: &v1alpha3.TriggerIdentity{
+			Username: "system:serviceaccount:ns:default",
+			Source:   v1alpha3.TriggerIdentityKubernetes,
+		},
+	}, {
+		name:     "created by a service account",
+		userInfo: authenticationv1.UserInfo{Username: "system:serviceaccount:ns:default"},
+		expect: &v1alpha3.TriggerIdentity{
+			Username: "system:serviceaccount:ns:default",
+			Source:   v1alpha3.TriggerIdentityKubernetes,
+		},
+	}, {
+		name: "no user",
+	}, {
+		name:     "no user with a forged identity",
+		recorded: recorded,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := &v1alpha3.PipelineRun{}
+			pr.SetTriggerIdentity(tt.recorded)
+			setTriggerIdentity(pr, tt.userInfo, apiServerServiceAccount)
+			assert.Equal(t, tt.expect, pr.GetTriggerIdentity())
+		})
+	}
+}